@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// apiBackend carries out commands through a server's /api/v1 endpoints
+type apiBackend struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newAPIBackend(baseURL, token string) *apiBackend {
+	return &apiBackend{
+		baseURL: strings.TrimSuffix(baseURL, "/") + "/api/v1",
+		token:   token,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// apiResponse is the envelope of the API's JSON responses
+type apiResponse struct {
+	Status string          `json:"status"`
+	Data   json.RawMessage `json:"data"`
+	Error  string          `json:"error"`
+}
+
+func (b *apiBackend) categoryID(ctx context.Context, userID, name string) (string, error) {
+	var categories []*domain.Category
+	if err := b.call(ctx, "GET", "/categories?"+url.Values{"user_id": {userID}}.Encode(), nil, &categories); err != nil {
+		return "", err
+	}
+	for _, category := range categories {
+		if strings.EqualFold(category.Name, name) {
+			return category.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no category named %q", name)
+}
+
+func (b *apiBackend) createExpense(ctx context.Context, req *usecase.CreateRequest) (*usecase.CreateResponse, error) {
+	body := map[string]interface{}{
+		"user_id":     req.UserID,
+		"description": req.Description,
+		"amount":      req.Amount,
+		"currency":    req.Currency,
+		"category_id": req.CategoryID,
+		"account":     req.Account,
+		"merchant":    req.Merchant,
+		"tags":        req.Tags,
+		"date":        req.Date,
+	}
+	var resp usecase.CreateResponse
+	if err := b.call(ctx, "POST", "/expenses", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (b *apiBackend) listExpenses(ctx context.Context, req *usecase.GetAllRequest) (*usecase.GetAllResponse, error) {
+	query := url.Values{
+		"user_id":  {req.UserID},
+		"limit":    {strconv.Itoa(req.Limit)},
+		"sort_by":  {req.SortBy},
+		"sort_dir": {req.SortDir},
+	}
+	if req.Cursor != "" {
+		query.Set("cursor", req.Cursor)
+	}
+	var resp usecase.GetAllResponse
+	if err := b.call(ctx, "GET", "/expenses?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (b *apiBackend) generateReport(ctx context.Context, req *usecase.ReportRequest) (*usecase.ExpenseReport, error) {
+	body := map[string]interface{}{
+		"user_id":     req.UserID,
+		"report_type": req.ReportType,
+		"start_date":  req.StartDate,
+		"end_date":    req.EndDate,
+	}
+	var report usecase.ExpenseReport
+	if err := b.call(ctx, "POST", "/reports/generate", body, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (b *apiBackend) exportExpenses(ctx context.Context, req *usecase.ExportRequest) ([]byte, error) {
+	query := url.Values{
+		"user_id":    {req.UserID},
+		"format":     {req.Format},
+		"start_date": {req.StartDate.Format(dateLayout)},
+		"end_date":   {req.EndDate.Format(dateLayout)},
+	}
+	resp, err := b.do(ctx, "GET", "/export/expenses?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, apiError(resp.StatusCode, data)
+	}
+	return data, nil
+}
+
+func (b *apiBackend) Close() error {
+	return nil
+}
+
+// call sends body as JSON and decodes the data of a successful response into out
+func (b *apiBackend) call(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+	resp, err := b.do(ctx, method, path, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return apiError(resp.StatusCode, data)
+	}
+
+	var envelope apiResponse
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("unexpected response from %s: %w", path, err)
+	}
+	return json.Unmarshal(envelope.Data, out)
+}
+
+func (b *apiBackend) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	return b.client.Do(req)
+}
+
+// apiError reports a failed request with the error the API gave, if any
+func apiError(status int, body []byte) error {
+	var envelope apiResponse
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error != "" {
+		return fmt.Errorf("API error (%d): %s", status, envelope.Error)
+	}
+	return fmt.Errorf("API error (%d): %s", status, http.StatusText(status))
+}
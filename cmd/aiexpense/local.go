@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/riverlin/aiexpense/internal/adapter/encryption"
+	"github.com/riverlin/aiexpense/internal/adapter/exchangerate"
+	sqliteRepo "github.com/riverlin/aiexpense/internal/adapter/repository/sqlite"
+	"github.com/riverlin/aiexpense/internal/ai"
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// localBackend carries out commands on a SQLite database with the same use
+// cases the server runs
+type localBackend struct {
+	db               *sql.DB
+	categoryRepo     domain.CategoryRepository
+	createExpenseUC  *usecase.CreateExpenseUseCase
+	getExpensesUC    *usecase.GetExpensesUseCase
+	generateReportUC *usecase.GenerateReportUseCase
+	dataExportUC     *usecase.DataExportUseCase
+}
+
+// openLocalBackend opens the database, bringing its schema up to date, and
+// signs the user up if they are new so they have the default categories.
+// ENCRYPTION_KEYS is needed for a database the server encrypts; AI_PROVIDER
+// and its API key let the AI categorize expenses added without a category.
+func openLocalBackend(ctx context.Context, dbPath, userID string) (*localBackend, error) {
+	db, err := sqliteRepo.OpenDB(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cipher domain.FieldCipher
+	if keys := os.Getenv("ENCRYPTION_KEYS"); keys != "" {
+		keyring, err := encryption.ParseKeys(keys)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to load encryption keys: %w", err)
+		}
+		cipher = keyring
+	}
+
+	userRepo := sqliteRepo.NewUserRepository(db)
+	categoryRepo := sqliteRepo.NewCategoryRepository(db)
+	expenseRepo := sqliteRepo.NewExpenseRepository(db)
+	expenseRepo.SetCipher(cipher)
+	auditRepo := sqliteRepo.NewExpenseAuditRepository(db)
+	auditRepo.SetCipher(cipher)
+	tagRepo := sqliteRepo.NewTagRepository(db)
+	expenseItemRepo := sqliteRepo.NewExpenseItemRepository(db)
+
+	if err := usecase.NewAutoSignupUseCase(userRepo, categoryRepo).Execute(ctx, userID, "terminal"); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	exchangeRateSvc := usecase.NewExchangeRateService(sqliteRepo.NewExchangeRateRepository(db), exchangerate.NewFrankfurterProvider(nil))
+	createExpenseUC := usecase.NewCreateExpenseUseCase(expenseRepo, categoryRepo, userRepo, exchangeRateSvc, nil, nil, localAIService())
+	createExpenseUC.SetAuditRepository(auditRepo)
+	createExpenseUC.SetUnitOfWork(sqliteRepo.NewUnitOfWork(db))
+	createExpenseUC.SetTagRepository(tagRepo)
+	createExpenseUC.SetExpenseItemRepository(expenseItemRepo)
+	createExpenseUC.SetMerchantRepository(sqliteRepo.NewMerchantRepository(db))
+	generateReportUC := usecase.NewGenerateReportUseCase(expenseRepo, categoryRepo, sqliteRepo.NewMetricsRepository(db), sqliteRepo.NewGroupRepository(db))
+	generateReportUC.SetTagRepository(tagRepo)
+	dataExportUC := usecase.NewDataExportUseCase(expenseRepo, categoryRepo)
+	dataExportUC.SetTagRepository(tagRepo)
+	dataExportUC.SetExpenseItemRepository(expenseItemRepo)
+
+	return &localBackend{
+		db:               db,
+		categoryRepo:     categoryRepo,
+		createExpenseUC:  createExpenseUC,
+		getExpensesUC:    usecase.NewGetExpensesUseCase(expenseRepo, categoryRepo),
+		generateReportUC: generateReportUC,
+		dataExportUC:     dataExportUC,
+	}, nil
+}
+
+func (b *localBackend) categoryID(ctx context.Context, userID, name string) (string, error) {
+	category, err := b.categoryRepo.GetByUserIDAndName(ctx, userID, name)
+	if errors.Is(err, domain.ErrNotFound) {
+		return "", fmt.Errorf("no category named %q", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return category.ID, nil
+}
+
+func (b *localBackend) createExpense(ctx context.Context, req *usecase.CreateRequest) (*usecase.CreateResponse, error) {
+	ctx = domain.WithAuditSource(ctx, domain.AuditSource{Actor: req.UserID, Channel: domain.AuditChannelAPI})
+	return b.createExpenseUC.Execute(ctx, req)
+}
+
+func (b *localBackend) listExpenses(ctx context.Context, req *usecase.GetAllRequest) (*usecase.GetAllResponse, error) {
+	return b.getExpensesUC.ExecuteGetAll(ctx, req)
+}
+
+func (b *localBackend) generateReport(ctx context.Context, req *usecase.ReportRequest) (*usecase.ExpenseReport, error) {
+	return b.generateReportUC.Execute(ctx, req)
+}
+
+func (b *localBackend) exportExpenses(ctx context.Context, req *usecase.ExportRequest) ([]byte, error) {
+	switch req.Format {
+	case "json":
+		return b.dataExportUC.ExportAsJSON(ctx, req)
+	case "xlsx":
+		return b.dataExportUC.ExportAsXLSX(ctx, req)
+	case "pdf":
+		return b.dataExportUC.ExportAsPDF(ctx, req)
+	}
+	return b.dataExportUC.ExportAsCSV(ctx, req)
+}
+
+func (b *localBackend) Close() error {
+	return b.db.Close()
+}
+
+// localAIService returns the AI provider configured as for the server, or
+// one that suggests nothing when no API key is set
+func localAIService() ai.Service {
+	provider := getEnv("AI_PROVIDER", "gemini")
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if provider == "claude" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if apiKey == "" {
+		return noAIService{}
+	}
+	service, err := ai.Factory(provider, apiKey, os.Getenv("AI_MODEL"), nil)
+	if err != nil || service == nil {
+		return noAIService{}
+	}
+	return service
+}
+
+// errNoAI is what noAIService answers with
+var errNoAI = errors.New("no AI provider configured")
+
+// noAIService leaves expenses added without a category uncategorized
+type noAIService struct{}
+
+func (noAIService) ParseExpense(ctx context.Context, text string, userID string) (*ai.ParseExpenseResponse, error) {
+	return nil, errNoAI
+}
+
+func (noAIService) SuggestCategory(ctx context.Context, description string, userID string) (*ai.SuggestCategoryResponse, error) {
+	return nil, errNoAI
+}
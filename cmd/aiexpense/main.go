@@ -0,0 +1,351 @@
+// Command aiexpense records and looks up expenses from the terminal, through
+// the HTTP API of a running server or directly in a local SQLite database.
+//
+//	aiexpense [-api URL] [-token TOKEN] [-db PATH] [-user ID] <command> [flags] [args]
+//
+// Commands:
+//
+//	add [-currency C] [-category NAME] [-date YYYY-MM-DD] [-account A] [-merchant M] [-tag T]... <amount> <description>
+//	list [-limit N] [-sort date|amount|created_at] [-asc] [-cursor C] [-json]
+//	report [-type daily|weekly|monthly] [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-json]
+//	export [-format csv|json|xlsx|pdf] [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-o FILE]
+//
+// The global flags default to $AIEXPENSE_API_URL, $AIEXPENSE_TOKEN,
+// $AIEXPENSE_DB and $AIEXPENSE_USER. With -db set, the database is opened
+// directly and no server is needed. It exits with 1 when a command fails and
+// with 2 when it is used wrongly.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// dateLayout is how dates are given on the command line
+const dateLayout = "2006-01-02"
+
+// errUsage is returned after a command has printed how it is used
+var errUsage = errors.New("invalid usage")
+
+// backend carries out commands, against the HTTP API or a local database
+type backend interface {
+	// categoryID returns the ID of the user's category with the given name
+	categoryID(ctx context.Context, userID, name string) (string, error)
+	createExpense(ctx context.Context, req *usecase.CreateRequest) (*usecase.CreateResponse, error)
+	listExpenses(ctx context.Context, req *usecase.GetAllRequest) (*usecase.GetAllResponse, error)
+	generateReport(ctx context.Context, req *usecase.ReportRequest) (*usecase.ExpenseReport, error)
+	exportExpenses(ctx context.Context, req *usecase.ExportRequest) ([]byte, error)
+	Close() error
+}
+
+func main() {
+	// Keep the terminal for output; only problems are logged
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	os.Exit(exitCode(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr), os.Stderr))
+}
+
+// exitCode reports err, unless usage was already printed for it, and returns
+// the status to exit with
+func exitCode(err error, stderr io.Writer) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	}
+	fmt.Fprintln(stderr, "aiexpense:", err)
+	return 1
+}
+
+// run parses the global flags, opens the backend and runs the command
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("aiexpense", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	apiURL := flags.String("api", getEnv("AIEXPENSE_API_URL", "http://localhost:8080"), "URL of the server's HTTP API")
+	token := flags.String("token", os.Getenv("AIEXPENSE_TOKEN"), "access token for the API")
+	dbPath := flags.String("db", os.Getenv("AIEXPENSE_DB"), "SQLite database to use directly instead of the API")
+	userID := flags.String("user", getEnv("AIEXPENSE_USER", "cli"), "user whose expenses to work with")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: aiexpense [flags] add|list|report|export [command flags] [args]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	command, commandArgs := flags.Arg(0), flags.Args()[1:]
+	var runCommand func(context.Context, backend, string, []string, io.Writer, io.Writer) error
+	switch command {
+	case "add":
+		runCommand = runAdd
+	case "list":
+		runCommand = runList
+	case "report":
+		runCommand = runReport
+	case "export":
+		runCommand = runExport
+	default:
+		fmt.Fprintf(stderr, "aiexpense: unknown command %q\n", command)
+		flags.Usage()
+		return errUsage
+	}
+
+	var b backend
+	if *dbPath != "" {
+		local, err := openLocalBackend(ctx, *dbPath, *userID)
+		if err != nil {
+			return err
+		}
+		b = local
+	} else {
+		b = newAPIBackend(*apiURL, *token)
+	}
+	defer b.Close()
+
+	return runCommand(ctx, b, *userID, commandArgs, stdout, stderr)
+}
+
+func runAdd(ctx context.Context, b backend, userID string, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("add", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	currency := flags.String("currency", "", "ISO 4217 currency; the home currency when omitted")
+	category := flags.String("category", "", "category name; chosen by the AI when omitted")
+	date := flags.String("date", "", "date as YYYY-MM-DD; today when omitted")
+	account := flags.String("account", "", "payment method")
+	merchant := flags.String("merchant", "", "store or service paid")
+	var tags stringList
+	flags.Var(&tags, "tag", "tag, repeatable")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: aiexpense add [flags] <amount> <description>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errUsage
+	}
+	amount, err := strconv.ParseFloat(flags.Arg(0), 64)
+	if err != nil || amount <= 0 {
+		return fmt.Errorf("amount must be a positive number, got %q", flags.Arg(0))
+	}
+
+	req := &usecase.CreateRequest{
+		UserID:      userID,
+		Description: strings.Join(flags.Args()[1:], " "),
+		Amount:      amount,
+		Currency:    strings.ToUpper(*currency),
+		Account:     *account,
+		Merchant:    *merchant,
+		Tags:        tags,
+		Date:        time.Now(),
+	}
+	if *date != "" {
+		if req.Date, err = parseDate(*date); err != nil {
+			return err
+		}
+	}
+	if *category != "" {
+		id, err := b.categoryID(ctx, userID, *category)
+		if err != nil {
+			return err
+		}
+		req.CategoryID = &id
+	}
+
+	resp, err := b.createExpense(ctx, req)
+	if err != nil {
+		return err
+	}
+	line := fmt.Sprintf("Added %s %s %s", req.Date.Format(dateLayout), formatAmount(resp.OriginalAmount, resp.Currency), req.Description)
+	if resp.Category != "" {
+		line += " (" + resp.Category + ")"
+	}
+	fmt.Fprintf(stdout, "%s [%s]\n", line, resp.ID)
+	return nil
+}
+
+func runList(ctx context.Context, b backend, userID string, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	limit := flags.Int("limit", 20, "how many expenses to list")
+	sortBy := flags.String("sort", "date", "sort by date, amount or created_at")
+	asc := flags.Bool("asc", false, "oldest or smallest first")
+	cursor := flags.String("cursor", "", "cursor printed by the previous page")
+	asJSON := flags.Bool("json", false, "print JSON")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	req := &usecase.GetAllRequest{UserID: userID, Limit: *limit, Cursor: *cursor, SortBy: *sortBy, SortDir: "desc"}
+	if *asc {
+		req.SortDir = "asc"
+	}
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	resp, err := b.listExpenses(ctx, req)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(stdout, resp)
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tAMOUNT\tCATEGORY\tDESCRIPTION\tID")
+	for _, e := range resp.Expenses {
+		category := ""
+		if e.CategoryName != nil {
+			category = *e.CategoryName
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Date.Format(dateLayout), formatAmount(e.OriginalAmount, e.Currency), category, e.Description, e.ID)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Total %.2f over %d expenses\n", resp.Total, resp.Count)
+	if resp.NextCursor != "" {
+		fmt.Fprintf(stdout, "Next page: -cursor %s\n", resp.NextCursor)
+	}
+	return nil
+}
+
+func runReport(ctx context.Context, b backend, userID string, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	reportType := flags.String("type", "monthly", "daily, weekly or monthly")
+	from := flags.String("from", "", "first day as YYYY-MM-DD; a month ago when omitted")
+	to := flags.String("to", "", "last day as YYYY-MM-DD; today when omitted")
+	asJSON := flags.Bool("json", false, "print JSON")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	start, end, err := parsePeriod(*from, *to, time.Now().AddDate(0, -1, 0))
+	if err != nil {
+		return err
+	}
+	report, err := b.generateReport(ctx, &usecase.ReportRequest{UserID: userID, ReportType: *reportType, StartDate: start, EndDate: end})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(stdout, report)
+	}
+
+	fmt.Fprintf(stdout, "%s report, %s to %s\n", report.ReportType, report.StartDate.Format(dateLayout), report.EndDate.Format(dateLayout))
+	fmt.Fprintf(stdout, "Total %.2f over %d expenses (average %.2f, highest %.2f)\n", report.TotalExpenses, report.TransactionCount, report.AverageExpense, report.HighestExpense)
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CATEGORY\tTOTAL\tCOUNT\tSHARE")
+	for _, c := range report.CategoryBreakdown {
+		fmt.Fprintf(tw, "%s\t%.2f\t%d\t%.1f%%\n", c.Category, c.Total, c.Count, c.Percentage)
+	}
+	return tw.Flush()
+}
+
+func runExport(ctx context.Context, b backend, userID string, args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "csv", "csv, json, xlsx or pdf")
+	from := flags.String("from", "", "first day as YYYY-MM-DD; a year ago when omitted")
+	to := flags.String("to", "", "last day as YYYY-MM-DD; today when omitted")
+	output := flags.String("o", "", "file to write; standard output when omitted")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	switch *format {
+	case "csv", "json", "xlsx", "pdf":
+	default:
+		return fmt.Errorf("unknown export format %q; use csv, json, xlsx or pdf", *format)
+	}
+
+	start, end, err := parsePeriod(*from, *to, time.Now().AddDate(-1, 0, 0))
+	if err != nil {
+		return err
+	}
+	data, err := b.exportExpenses(ctx, &usecase.ExportRequest{UserID: userID, Format: *format, StartDate: start, EndDate: end})
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(stderr, "Exported to %s\n", *output)
+	return nil
+}
+
+// parsePeriod reads a from-to period of whole days, starting at defaultStart
+// and ending now unless given
+func parsePeriod(from, to string, defaultStart time.Time) (start, end time.Time, err error) {
+	start, end = defaultStart, time.Now()
+	if from != "" {
+		if start, err = parseDate(from); err != nil {
+			return start, end, err
+		}
+	}
+	if to != "" {
+		if end, err = parseDate(to); err != nil {
+			return start, end, err
+		}
+		end = end.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return start, end, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	date, err := time.ParseInLocation(dateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("dates are YYYY-MM-DD, got %q", value)
+	}
+	return date, nil
+}
+
+func formatAmount(amount float64, currency string) string {
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", amount, currency))
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func getEnv(key, defaultVal string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultVal
+}
+
+// stringList collects a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// runCLI runs the command line and returns its exit code and output
+func runCLI(t *testing.T, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = exitCode(run(context.Background(), args, &out, &errOut), &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRun_Usage(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code int
+		want string
+	}{
+		{name: "No command", args: nil, code: 2, want: "Usage: aiexpense"},
+		{name: "Unknown command", args: []string{"remove"}, code: 2, want: `unknown command "remove"`},
+		{name: "Unknown global flag", args: []string{"-verbose", "list"}, code: 2, want: "flag provided but not defined: -verbose"},
+		{name: "Add without a description", args: []string{"add", "120"}, code: 2, want: "Usage: aiexpense add"},
+		{name: "Unknown list flag", args: []string{"list", "-page", "2"}, code: 2, want: "flag provided but not defined: -page"},
+		{name: "Amount not a number", args: []string{"add", "lunch", "120"}, code: 1, want: `aiexpense: amount must be a positive number, got "lunch"`},
+		{name: "Negative amount", args: []string{"add", "--", "-5", "refund"}, code: 1, want: "amount must be a positive number"},
+		{name: "Bad date", args: []string{"add", "-date", "16/10/2026", "120", "lunch"}, code: 1, want: `dates are YYYY-MM-DD, got "16/10/2026"`},
+		{name: "Bad sort", args: []string{"list", "-sort", "name"}, code: 1, want: "aiexpense:"},
+		{name: "Bad export format", args: []string{"export", "-format", "xml"}, code: 1, want: `unknown export format "xml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fail fast should a case reach the API
+			code, stdout, stderr := runCLI(t, append([]string{"-api", "http://127.0.0.1:1"}, tt.args...)...)
+			if code != tt.code {
+				t.Errorf("expected exit code %d, got %d (stderr %q)", tt.code, code, stderr)
+			}
+			if !strings.Contains(stderr, tt.want) {
+				t.Errorf("expected %q in stderr, got %q", tt.want, stderr)
+			}
+			if stdout != "" {
+				t.Errorf("expected no output, got %q", stdout)
+			}
+		})
+	}
+
+	if code := exitCode(nil, &bytes.Buffer{}); code != 0 {
+		t.Errorf("expected success to exit with 0, got %d", code)
+	}
+}
+
+// fakeAPI serves the endpoints the CLI calls, recording the requests it gets
+type fakeAPI struct {
+	t        *testing.T
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   []map[string]interface{}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	f.mu.Lock()
+	f.requests = append(f.requests, r)
+	f.bodies = append(f.bodies, body)
+	f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer t0ken" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": "invalid or expired token"})
+		return
+	}
+	food := "Food"
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)
+	var data interface{}
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/v1/categories":
+		data = []*domain.Category{{ID: "cat-transport", Name: "Transport"}, {ID: "cat-food", Name: "Food"}}
+	case "POST /api/v1/expenses":
+		data = &usecase.CreateResponse{ID: "exp-1", Category: "Food", OriginalAmount: 120, Currency: "TWD"}
+	case "GET /api/v1/expenses":
+		data = &usecase.GetAllResponse{
+			Expenses: []*usecase.ExpenseDTO{
+				{ID: "exp-1", Description: "Lunch", OriginalAmount: 120, Currency: "TWD", CategoryName: &food, Date: day},
+				{ID: "exp-2", Description: "Taxi", OriginalAmount: 300.5, Currency: "TWD", Date: day},
+			},
+			Total:      420.5,
+			Count:      2,
+			NextCursor: "c2",
+		}
+	case "POST /api/v1/reports/generate":
+		data = &usecase.ExpenseReport{
+			ReportType:        "monthly",
+			StartDate:         day.AddDate(0, -1, 0),
+			EndDate:           day,
+			TotalExpenses:     420.5,
+			TransactionCount:  2,
+			AverageExpense:    210.25,
+			HighestExpense:    300.5,
+			CategoryBreakdown: []usecase.CategoryBreakdown{{Category: "Food", Total: 120, Count: 1, Percentage: 28.5}},
+		}
+	case "GET /api/v1/export/expenses":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("date,amount,description\n2026-10-16,120,Lunch\n"))
+		return
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "data": data})
+}
+
+// last returns the last request the API got and its JSON body
+func (f *fakeAPI) last() (*http.Request, map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[len(f.requests)-1], f.bodies[len(f.bodies)-1]
+}
+
+func TestRun_API(t *testing.T) {
+	api := &fakeAPI{t: t}
+	server := httptest.NewServer(api)
+	defer server.Close()
+	t.Setenv("AIEXPENSE_API_URL", server.URL+"/")
+	t.Setenv("AIEXPENSE_TOKEN", "t0ken")
+	t.Setenv("AIEXPENSE_USER", "alice")
+
+	t.Run("Add", func(t *testing.T) {
+		code, stdout, stderr := runCLI(t, "add", "-category", "food", "-date", "2026-10-16", "-currency", "twd", "-tag", "work", "-tag", "team", "120", "team", "lunch")
+		if code != 0 {
+			t.Fatalf("expected success, got %d: %s", code, stderr)
+		}
+		if want := "Added 2026-10-16 120.00 TWD team lunch (Food) [exp-1]\n"; stdout != want {
+			t.Errorf("expected %q, got %q", want, stdout)
+		}
+		api.mu.Lock()
+		lookup := api.requests[len(api.requests)-2]
+		api.mu.Unlock()
+		if lookup.URL.Query().Get("user_id") != "alice" {
+			t.Errorf("expected the categories of alice to be looked up, got %s", lookup.URL)
+		}
+		_, body := api.last()
+		if body["category_id"] != "cat-food" || body["currency"] != "TWD" || body["description"] != "team lunch" || body["user_id"] != "alice" {
+			t.Errorf("unexpected expense sent: %v", body)
+		}
+		if tags, _ := body["tags"].([]interface{}); len(tags) != 2 || tags[1] != "team" {
+			t.Errorf("expected both tags, got %v", body["tags"])
+		}
+	})
+
+	t.Run("Add to an unknown category", func(t *testing.T) {
+		code, _, stderr := runCLI(t, "add", "-category", "Rent", "9000", "October rent")
+		if code != 1 || !strings.Contains(stderr, `no category named "Rent"`) {
+			t.Errorf("expected the category to be refused, got %d %q", code, stderr)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "list", "-limit", "2", "-sort", "amount", "-asc", "-cursor", "c1")
+		if code != 0 {
+			t.Fatalf("expected success, got %d", code)
+		}
+		for _, want := range []string{
+			"DATE        AMOUNT      CATEGORY  DESCRIPTION  ID",
+			"2026-10-16  120.00 TWD  Food      Lunch        exp-1",
+			"2026-10-16  300.50 TWD            Taxi         exp-2",
+			"Total 420.50 over 2 expenses",
+			"Next page: -cursor c2",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("expected %q in\n%s", want, stdout)
+			}
+		}
+		req, _ := api.last()
+		if query := req.URL.Query(); query.Get("limit") != "2" || query.Get("sort_by") != "amount" || query.Get("sort_dir") != "asc" || query.Get("cursor") != "c1" {
+			t.Errorf("unexpected query %s", req.URL.RawQuery)
+		}
+	})
+
+	t.Run("List as JSON", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "list", "-json")
+		var resp usecase.GetAllResponse
+		if code != 0 || json.Unmarshal([]byte(stdout), &resp) != nil || resp.Count != 2 {
+			t.Errorf("expected the listing as JSON, got %d %q", code, stdout)
+		}
+	})
+
+	t.Run("Report", func(t *testing.T) {
+		code, stdout, _ := runCLI(t, "report", "-from", "2026-09-16", "-to", "2026-10-16")
+		if code != 0 {
+			t.Fatalf("expected success, got %d", code)
+		}
+		for _, want := range []string{
+			"monthly report, 2026-09-16 to 2026-10-16",
+			"Total 420.50 over 2 expenses (average 210.25, highest 300.50)",
+			"Food      120.00  1      28.5%",
+		} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("expected %q in\n%s", want, stdout)
+			}
+		}
+		_, body := api.last()
+		if body["report_type"] != "monthly" || !strings.HasPrefix(body["start_date"].(string), "2026-09-16") || !strings.HasPrefix(body["end_date"].(string), "2026-10-16T23:59:59") {
+			t.Errorf("unexpected report request: %v", body)
+		}
+	})
+
+	t.Run("Export to a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "expenses.csv")
+		code, stdout, stderr := runCLI(t, "export", "-from", "2026-01-01", "-o", path)
+		if code != 0 || stdout != "" || !strings.Contains(stderr, "Exported to "+path) {
+			t.Fatalf("expected the export to be written, got %d %q %q", code, stdout, stderr)
+		}
+		if data, _ := os.ReadFile(path); !strings.Contains(string(data), "2026-10-16,120,Lunch") {
+			t.Errorf("unexpected export %q", data)
+		}
+		req, _ := api.last()
+		if query := req.URL.Query(); query.Get("format") != "csv" || query.Get("start_date") != "2026-01-01" {
+			t.Errorf("unexpected query %s", req.URL.RawQuery)
+		}
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		code, stdout, stderr := runCLI(t, "-token", "stale", "list")
+		if code != 1 || stdout != "" || stderr != "aiexpense: API error (401): invalid or expired token\n" {
+			t.Errorf("expected the API's error, got %d %q %q", code, stdout, stderr)
+		}
+	})
+
+	t.Run("Server unreachable", func(t *testing.T) {
+		code, _, stderr := runCLI(t, "-api", "http://127.0.0.1:1", "list")
+		if code != 1 || !strings.Contains(stderr, "connection refused") {
+			t.Errorf("expected a connection error, got %d %q", code, stderr)
+		}
+	})
+}
+
+func TestRun_Local(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	t.Setenv("ENCRYPTION_KEYS", "")
+	t.Setenv("AIEXPENSE_DB", filepath.Join(t.TempDir(), "aiexpense.db"))
+	t.Setenv("AIEXPENSE_USER", "bob")
+
+	for _, args := range [][]string{
+		{"add", "-category", "Food", "-date", "2026-10-01", "120", "Lunch"},
+		{"add", "-category", "Transport", "-date", "2026-10-02", "-merchant", "Uber", "300.5", "Taxi", "home"},
+	} {
+		if code, stdout, stderr := runCLI(t, args...); code != 0 || !strings.HasPrefix(stdout, "Added 2026-10-0") {
+			t.Fatalf("%v: expected the expense to be added, got %d %q %q", args, code, stdout, stderr)
+		}
+	}
+
+	code, stdout, stderr := runCLI(t, "add", "-category", "Rent", "9000", "October rent")
+	if code != 1 || !strings.Contains(stderr, `no category named "Rent"`) {
+		t.Errorf("expected the category to be refused, got %d %q", code, stderr)
+	}
+
+	code, stdout, _ = runCLI(t, "list")
+	if code != 0 {
+		t.Fatalf("expected success, got %d", code)
+	}
+	for _, want := range []string{"2026-10-02  300.50", "Transport  Taxi home", "2026-10-01  120.00", "Food       Lunch", "Total 420.50 over 2 expenses"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in\n%s", want, stdout)
+		}
+	}
+	if strings.Index(stdout, "Taxi") > strings.Index(stdout, "Lunch") {
+		t.Errorf("expected the newest expense first in\n%s", stdout)
+	}
+
+	code, stdout, _ = runCLI(t, "report", "-from", "2026-10-01", "-to", "2026-10-31", "-json")
+	var report usecase.ExpenseReport
+	if err := json.Unmarshal([]byte(stdout), &report); code != 0 || err != nil {
+		t.Fatalf("expected the report as JSON, got %d %v", code, err)
+	}
+	if report.TotalExpenses != 420.5 || report.TransactionCount != 2 || len(report.CategoryBreakdown) != 2 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	code, stdout, _ = runCLI(t, "export", "-from", "2026-10-01", "-to", "2026-10-31")
+	if code != 0 || !strings.Contains(stdout, "Lunch") || !strings.Contains(stdout, "Taxi home") {
+		t.Errorf("expected the CSV export on stdout, got %d %q", code, stdout)
+	}
+
+	// Another user's expenses are kept apart
+	code, stdout, _ = runCLI(t, "-user", "carol", "list")
+	if code != 0 || !strings.Contains(stdout, "Total 0.00 over 0 expenses") {
+		t.Errorf("expected no expenses for carol, got %d %q", code, stdout)
+	}
+}
+
+func TestRun_LocalDatabaseError(t *testing.T) {
+	dir := t.TempDir()
+	code, _, stderr := runCLI(t, "-db", filepath.Join(dir, "missing", "aiexpense.db"), "list")
+	if code != 1 || !strings.HasPrefix(stderr, "aiexpense: ") {
+		t.Errorf("expected the database error, got %d %q", code, stderr)
+	}
+	if errors.Is(run(context.Background(), []string{"-db", dir, "list"}, &bytes.Buffer{}, &bytes.Buffer{}), errUsage) {
+		t.Error("expected a database error not to count as a usage error")
+	}
+}
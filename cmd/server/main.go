@@ -1,32 +1,119 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Users' timezones, as the container has no zoneinfo
 
+	"github.com/riverlin/aiexpense/internal/adapter/chart"
+	"github.com/riverlin/aiexpense/internal/adapter/email"
+	"github.com/riverlin/aiexpense/internal/adapter/encryption"
 	"github.com/riverlin/aiexpense/internal/adapter/exchangerate"
+	grpcAdapter "github.com/riverlin/aiexpense/internal/adapter/grpc"
 	httpAdapter "github.com/riverlin/aiexpense/internal/adapter/http"
+	"github.com/riverlin/aiexpense/internal/adapter/kvcache"
+	"github.com/riverlin/aiexpense/internal/adapter/mcp"
 	"github.com/riverlin/aiexpense/internal/adapter/messenger/discord"
 	"github.com/riverlin/aiexpense/internal/adapter/messenger/line"
+	"github.com/riverlin/aiexpense/internal/adapter/messenger/matrix"
 	"github.com/riverlin/aiexpense/internal/adapter/messenger/slack"
 	"github.com/riverlin/aiexpense/internal/adapter/messenger/teams"
 	"github.com/riverlin/aiexpense/internal/adapter/messenger/telegram"
 	"github.com/riverlin/aiexpense/internal/adapter/messenger/terminal"
 	"github.com/riverlin/aiexpense/internal/adapter/messenger/whatsapp"
+	"github.com/riverlin/aiexpense/internal/adapter/repository/migrations"
+	mysqlRepo "github.com/riverlin/aiexpense/internal/adapter/repository/mysql"
 	postgresRepo "github.com/riverlin/aiexpense/internal/adapter/repository/postgresql"
 	sqliteRepo "github.com/riverlin/aiexpense/internal/adapter/repository/sqlite"
+	"github.com/riverlin/aiexpense/internal/adapter/storage"
 	"github.com/riverlin/aiexpense/internal/ai"
+	"github.com/riverlin/aiexpense/internal/async"
 	"github.com/riverlin/aiexpense/internal/config"
 	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/logging"
 	"github.com/riverlin/aiexpense/internal/usecase"
 )
 
 func main() {
+	// Without a command, or with "serve", the server starts; -repl has it chat
+	// on the terminal instead of serving HTTP
+	serve, serving, err := parseServeOptions(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "server:", err)
+		os.Exit(2)
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("Failed to load configuration", err)
+	}
+
+	// Log structured lines from here on, including those of the standard log
+	// package, at a level configuration reloads can change
+	logLevel := new(slog.LevelVar)
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		fatal("Failed to configure logging", err)
+	}
+	logLevel.Set(level)
+	logger, err := logging.NewWithLevel(os.Stderr, cfg.LogFormat, logLevel)
+	if err != nil {
+		fatal("Failed to configure logging", err)
+	}
+	slog.SetDefault(logger)
+	for _, warning := range cfg.Warnings() {
+		slog.Warn(warning)
+	}
+
+	// A server about to listen checks its ports before the slow startup and
+	// shows what the configuration turns on
+	if serving && !serve.repl {
+		if err := cfg.CheckPorts(); err != nil {
+			fatal("Cannot start server", err)
+		}
+		cfg.WriteCapabilities(os.Stderr)
+	}
+
+	// Encrypt sensitive columns at rest once keys are configured
+	var cipher domain.FieldCipher
+	if cfg.EncryptionKeys != "" {
+		keyring, err := encryption.ParseKeys(cfg.EncryptionKeys)
+		if err != nil {
+			fatal("Failed to load encryption keys", err)
+		}
+		cipher = keyring
+	}
+
+	// "server reencrypt" migrates existing rows to the primary key and exits
+	if len(os.Args) > 1 && os.Args[1] == "reencrypt" {
+		if err := runReencrypt(context.Background(), cfg, cipher); err != nil {
+			fatal("Failed to re-encrypt sensitive columns", err)
+		}
+		return
+	}
+
+	// "server migrate ..." moves the schema between versions and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			fatal("Migration failed", err)
+		}
+		return
 	}
 
 	// Open database based on configuration
@@ -34,55 +121,230 @@ func main() {
 	var categoryRepo domain.CategoryRepository
 	var expenseRepo domain.ExpenseRepository
 	var metricsRepo domain.MetricsRepository
+	var metricsRollupRepo domain.MetricsRollupRepository
 	var aiCostRepo domain.AICostRepository
 	var policyRepo domain.PolicyRepository
 	var interactionLogRepo domain.InteractionLogRepository
 	var dbCloser interface{ Close() error }
+	var dbStats func() sql.DBStats
+	var dbPing func(ctx context.Context) error
 
 	var pricingRepo domain.PricingRepository
 	var shortLinkRepo domain.ShortLinkRepository
 	var exchangeRateRepo domain.ExchangeRateRepository
+	var budgetRepo domain.BudgetRepository
+	var groupRepo domain.GroupRepository
+	var expenseSplitRepo domain.ExpenseSplitRepository
+	var attachmentRepo domain.AttachmentRepository
+	var processedEventRepo domain.ProcessedEventRepository
+	var aiCostCapRepo domain.AICostCapRepository
+	var aiCostAnomalyRepo domain.AICostAnomalyRepository
+	var replyOutboxRepo domain.ReplyOutboxRepository
+	var promptRepo domain.PromptRepository
+	var categoryCorrectionRepo domain.CategoryCorrectionRepository
+	var expenseAuditRepo domain.ExpenseAuditRepository
+	var reportScheduleRepo domain.ReportScheduleRepository
+	var webhookRepo domain.WebhookRepository
+	var apiKeyRepo domain.APIKeyRepository
+	var emailAddressRepo domain.EmailAddressRepository
+	var conversationStateRepo domain.ConversationStateRepository
+	var notificationPreferencesRepo domain.NotificationPreferencesRepository
+	var userDeletionRepo domain.UserDeletionRepository
+	var archiveRepo domain.ArchiveRepository
+	var archivePolicyRepo domain.ArchivePolicyRepository
+	var tagRepo domain.TagRepository
+	var expenseItemRepo domain.ExpenseItemRepository
+	var merchantRepo domain.MerchantRepository
+	var workspaceRepo domain.WorkspaceRepository
+	// expenseSearchRepo stays nil for MySQL, which searches in memory
+	var expenseSearchRepo domain.ExpenseSearchRepository
+	var unitOfWork domain.UnitOfWork
+
+	switch cfg.DatabaseDriver() {
+	case "mysql":
+		// Use MySQL or MariaDB
+		slog.Info("Connecting to MySQL")
+		db, err := mysqlRepo.Connect(cfg.DatabaseURL)
+		if err != nil {
+			fatal("Failed to open MySQL database", err)
+		}
+		dbCloser = db
+		dbStats = db.Stats
+		dbPing = db.PingContext
+		if err := prepareSchema(db, "mysql", cfg.AutoMigrate); err != nil {
+			fatal("Failed to prepare MySQL schema", err)
+		}
 
-	if cfg.DatabaseURL != "" {
+		userRepo = mysqlRepo.NewUserRepository(db)
+		categoryRepo = mysqlRepo.NewCategoryRepository(db)
+		mysqlExpenseRepo := mysqlRepo.NewExpenseRepository(db)
+		mysqlExpenseRepo.SetCipher(cipher)
+		expenseRepo = mysqlExpenseRepo
+		metricsRepo = mysqlRepo.NewMetricsRepository(db)
+		metricsRollupRepo = mysqlRepo.NewMetricsRollupRepository(db)
+		aiCostRepo = mysqlRepo.NewAICostRepository(db)
+		policyRepo = mysqlRepo.NewPolicyRepository(db)
+		pricingRepo = mysqlRepo.NewPricingRepository(db)
+		mysqlInteractionLogRepo := mysqlRepo.NewInteractionLogRepository(db)
+		mysqlInteractionLogRepo.SetCipher(cipher)
+		interactionLogRepo = mysqlInteractionLogRepo
+		shortLinkRepo = mysqlRepo.NewShortLinkRepository(db)
+		exchangeRateRepo = mysqlRepo.NewExchangeRateRepository(db)
+		budgetRepo = mysqlRepo.NewBudgetRepository(db)
+		groupRepo = mysqlRepo.NewGroupRepository(db)
+		expenseSplitRepo = mysqlRepo.NewExpenseSplitRepository(db)
+		attachmentRepo = mysqlRepo.NewAttachmentRepository(db)
+		processedEventRepo = mysqlRepo.NewProcessedEventRepository(db)
+		aiCostCapRepo = mysqlRepo.NewAICostCapRepository(db)
+		aiCostAnomalyRepo = mysqlRepo.NewAICostAnomalyRepository(db)
+		replyOutboxRepo = mysqlRepo.NewReplyOutboxRepository(db)
+		promptRepo = mysqlRepo.NewPromptRepository(db)
+		mysqlCategoryCorrectionRepo := mysqlRepo.NewCategoryCorrectionRepository(db)
+		mysqlCategoryCorrectionRepo.SetCipher(cipher)
+		categoryCorrectionRepo = mysqlCategoryCorrectionRepo
+		mysqlExpenseAuditRepo := mysqlRepo.NewExpenseAuditRepository(db)
+		mysqlExpenseAuditRepo.SetCipher(cipher)
+		expenseAuditRepo = mysqlExpenseAuditRepo
+		reportScheduleRepo = mysqlRepo.NewReportScheduleRepository(db)
+		webhookRepo = mysqlRepo.NewWebhookRepository(db)
+		apiKeyRepo = mysqlRepo.NewAPIKeyRepository(db)
+		emailAddressRepo = mysqlRepo.NewEmailAddressRepository(db)
+		conversationStateRepo = mysqlRepo.NewConversationStateRepository(db)
+		notificationPreferencesRepo = mysqlRepo.NewNotificationPreferencesRepository(db)
+		userDeletionRepo = mysqlRepo.NewUserDeletionRepository(db)
+		archiveRepo = mysqlRepo.NewArchiveRepository(db)
+		archivePolicyRepo = mysqlRepo.NewArchivePolicyRepository(db)
+		tagRepo = mysqlRepo.NewTagRepository(db)
+		expenseItemRepo = mysqlRepo.NewExpenseItemRepository(db)
+		merchantRepo = mysqlRepo.NewMerchantRepository(db)
+		workspaceRepo = mysqlRepo.NewWorkspaceRepository(db)
+		unitOfWork = mysqlRepo.NewUnitOfWork(db)
+		slog.Info("Connected to MySQL database")
+	case "postgres":
 		// Use PostgreSQL
-		log.Printf("Connecting to PostgreSQL: %s", cfg.DatabaseURL)
-		db, err := postgresRepo.OpenDB(cfg.DatabaseURL)
+		slog.Info("Connecting to PostgreSQL")
+		db, err := postgresRepo.ConnectWithPool(cfg.DatabaseURL, postgresRepo.PoolConfig{
+			MaxOpenConns:    cfg.DBMaxOpenConns,
+			MaxIdleConns:    cfg.DBMaxIdleConns,
+			ConnMaxLifetime: cfg.DBConnMaxLifetime,
+			ConnMaxIdleTime: cfg.DBConnMaxIdleTime,
+		})
 		if err != nil {
-			log.Fatalf("Failed to open PostgreSQL database: %v", err)
+			fatal("Failed to open PostgreSQL database", err)
 		}
 		dbCloser = db
+		dbStats = db.Stats
+		dbPing = db.PingContext
+		if err := prepareSchema(db, "postgres", cfg.AutoMigrate); err != nil {
+			fatal("Failed to prepare PostgreSQL schema", err)
+		}
 
 		userRepo = postgresRepo.NewUserRepository(db)
 		categoryRepo = postgresRepo.NewCategoryRepository(db)
-		expenseRepo = postgresRepo.NewExpenseRepository(db)
+		pgExpenseRepo := postgresRepo.NewExpenseRepository(db)
+		pgExpenseRepo.SetCipher(cipher)
+		expenseRepo = pgExpenseRepo
+		expenseSearchRepo = pgExpenseRepo
 		metricsRepo = postgresRepo.NewMetricsRepository(db)
+		metricsRollupRepo = postgresRepo.NewMetricsRollupRepository(db)
 		aiCostRepo = postgresRepo.NewAICostRepository(db)
 		policyRepo = postgresRepo.NewPolicyRepository(db)
 		pricingRepo = postgresRepo.NewPricingRepository(db)
-		interactionLogRepo = postgresRepo.NewInteractionLogRepository(db)
+		pgInteractionLogRepo := postgresRepo.NewInteractionLogRepository(db)
+		pgInteractionLogRepo.SetCipher(cipher)
+		interactionLogRepo = pgInteractionLogRepo
 		shortLinkRepo = postgresRepo.NewShortLinkRepository(db)
 		exchangeRateRepo = postgresRepo.NewExchangeRateRepository(db)
-		log.Printf("Connected to PostgreSQL database")
-	} else {
+		budgetRepo = postgresRepo.NewBudgetRepository(db)
+		groupRepo = postgresRepo.NewGroupRepository(db)
+		expenseSplitRepo = postgresRepo.NewExpenseSplitRepository(db)
+		attachmentRepo = postgresRepo.NewAttachmentRepository(db)
+		processedEventRepo = postgresRepo.NewProcessedEventRepository(db)
+		aiCostCapRepo = postgresRepo.NewAICostCapRepository(db)
+		aiCostAnomalyRepo = postgresRepo.NewAICostAnomalyRepository(db)
+		replyOutboxRepo = postgresRepo.NewReplyOutboxRepository(db)
+		promptRepo = postgresRepo.NewPromptRepository(db)
+		pgCategoryCorrectionRepo := postgresRepo.NewCategoryCorrectionRepository(db)
+		pgCategoryCorrectionRepo.SetCipher(cipher)
+		categoryCorrectionRepo = pgCategoryCorrectionRepo
+		pgExpenseAuditRepo := postgresRepo.NewExpenseAuditRepository(db)
+		pgExpenseAuditRepo.SetCipher(cipher)
+		expenseAuditRepo = pgExpenseAuditRepo
+		reportScheduleRepo = postgresRepo.NewReportScheduleRepository(db)
+		webhookRepo = postgresRepo.NewWebhookRepository(db)
+		apiKeyRepo = postgresRepo.NewAPIKeyRepository(db)
+		emailAddressRepo = postgresRepo.NewEmailAddressRepository(db)
+		conversationStateRepo = postgresRepo.NewConversationStateRepository(db)
+		notificationPreferencesRepo = postgresRepo.NewNotificationPreferencesRepository(db)
+		userDeletionRepo = postgresRepo.NewUserDeletionRepository(db)
+		archiveRepo = postgresRepo.NewArchiveRepository(db)
+		archivePolicyRepo = postgresRepo.NewArchivePolicyRepository(db)
+		tagRepo = postgresRepo.NewTagRepository(db)
+		expenseItemRepo = postgresRepo.NewExpenseItemRepository(db)
+		merchantRepo = postgresRepo.NewMerchantRepository(db)
+		workspaceRepo = postgresRepo.NewWorkspaceRepository(db)
+		unitOfWork = postgresRepo.NewUnitOfWork(db)
+		slog.Info("Connected to PostgreSQL database")
+	default:
 		// Use SQLite
-		log.Printf("Opening SQLite database: %s", cfg.DatabasePath)
-		db, err := sqliteRepo.OpenDB(cfg.DatabasePath)
+		slog.Info("Opening SQLite database", "path", cfg.DatabasePath)
+		db, err := sqliteRepo.Connect(cfg.DatabasePath)
 		if err != nil {
-			log.Fatalf("Failed to open SQLite database: %v", err)
+			fatal("Failed to open SQLite database", err)
 		}
 		dbCloser = db
+		dbStats = db.Stats
+		dbPing = db.PingContext
+		if err := prepareSchema(db, "sqlite3", cfg.AutoMigrate); err != nil {
+			fatal("Failed to prepare SQLite schema", err)
+		}
 
 		userRepo = sqliteRepo.NewUserRepository(db)
 		categoryRepo = sqliteRepo.NewCategoryRepository(db)
-		expenseRepo = sqliteRepo.NewExpenseRepository(db)
+		sqliteExpenseRepo := sqliteRepo.NewExpenseRepository(db)
+		sqliteExpenseRepo.SetCipher(cipher)
+		expenseRepo = sqliteExpenseRepo
+		expenseSearchRepo = sqliteExpenseRepo
 		metricsRepo = sqliteRepo.NewMetricsRepository(db)
+		metricsRollupRepo = sqliteRepo.NewMetricsRollupRepository(db)
 		aiCostRepo = sqliteRepo.NewAICostRepository(db)
 		policyRepo = sqliteRepo.NewPolicyRepository(db)
 		pricingRepo = sqliteRepo.NewPricingRepository(db)
-		interactionLogRepo = sqliteRepo.NewInteractionLogRepository(db)
+		sqliteInteractionLogRepo := sqliteRepo.NewInteractionLogRepository(db)
+		sqliteInteractionLogRepo.SetCipher(cipher)
+		interactionLogRepo = sqliteInteractionLogRepo
 		shortLinkRepo = sqliteRepo.NewShortLinkRepository(db)
 		exchangeRateRepo = sqliteRepo.NewExchangeRateRepository(db)
-		log.Printf("Connected to SQLite database")
+		budgetRepo = sqliteRepo.NewBudgetRepository(db)
+		groupRepo = sqliteRepo.NewGroupRepository(db)
+		expenseSplitRepo = sqliteRepo.NewExpenseSplitRepository(db)
+		attachmentRepo = sqliteRepo.NewAttachmentRepository(db)
+		processedEventRepo = sqliteRepo.NewProcessedEventRepository(db)
+		aiCostCapRepo = sqliteRepo.NewAICostCapRepository(db)
+		aiCostAnomalyRepo = sqliteRepo.NewAICostAnomalyRepository(db)
+		replyOutboxRepo = sqliteRepo.NewReplyOutboxRepository(db)
+		promptRepo = sqliteRepo.NewPromptRepository(db)
+		sqliteCategoryCorrectionRepo := sqliteRepo.NewCategoryCorrectionRepository(db)
+		sqliteCategoryCorrectionRepo.SetCipher(cipher)
+		categoryCorrectionRepo = sqliteCategoryCorrectionRepo
+		sqliteExpenseAuditRepo := sqliteRepo.NewExpenseAuditRepository(db)
+		sqliteExpenseAuditRepo.SetCipher(cipher)
+		expenseAuditRepo = sqliteExpenseAuditRepo
+		reportScheduleRepo = sqliteRepo.NewReportScheduleRepository(db)
+		webhookRepo = sqliteRepo.NewWebhookRepository(db)
+		apiKeyRepo = sqliteRepo.NewAPIKeyRepository(db)
+		emailAddressRepo = sqliteRepo.NewEmailAddressRepository(db)
+		conversationStateRepo = sqliteRepo.NewConversationStateRepository(db)
+		notificationPreferencesRepo = sqliteRepo.NewNotificationPreferencesRepository(db)
+		userDeletionRepo = sqliteRepo.NewUserDeletionRepository(db)
+		archiveRepo = sqliteRepo.NewArchiveRepository(db)
+		archivePolicyRepo = sqliteRepo.NewArchivePolicyRepository(db)
+		tagRepo = sqliteRepo.NewTagRepository(db)
+		expenseItemRepo = sqliteRepo.NewExpenseItemRepository(db)
+		merchantRepo = sqliteRepo.NewMerchantRepository(db)
+		workspaceRepo = sqliteRepo.NewWorkspaceRepository(db)
+		unitOfWork = sqliteRepo.NewUnitOfWork(db)
+		slog.Info("Connected to SQLite database")
 	}
 
 	// Ensure database is closed on exit
@@ -92,18 +354,52 @@ func main() {
 		}
 	}()
 
-	// Initialize AI service
-	aiService, err := ai.Factory(cfg.AIProvider, cfg.GeminiAPIKey, cfg.AIModel, aiCostRepo)
+	// Initialize AI service, failing over through AI_PROVIDERS when set
+	var aiService ai.Service
+	if len(cfg.AIProviders) > 0 {
+		specs := make([]ai.ProviderSpec, 0, len(cfg.AIProviders))
+		for _, entry := range cfg.AIProviders {
+			provider, model, _ := strings.Cut(entry, ":")
+			if model == "" && provider == cfg.AIProvider {
+				model = cfg.AIModel
+			}
+			specs = append(specs, ai.ProviderSpec{Provider: provider, APIKey: cfg.AIProviderAPIKey(provider), Model: model})
+		}
+		aiService, err = ai.NewFailoverService(specs, cfg.AIFailoverThreshold, cfg.AIFailoverCooldown)
+	} else {
+		aiService, err = ai.Factory(cfg.AIProvider, cfg.AIAPIKey(), cfg.AIModel, aiCostRepo)
+	}
 	if err != nil {
-		log.Fatalf("Failed to initialize AI service: %v", err)
+		fatal("Failed to initialize AI service", err)
+	}
+	if configurable, ok := aiService.(ai.PromptConfigurable); ok {
+		configurable.SetPromptSource(promptRepo)
+	}
+	if configurable, ok := aiService.(ai.ClientPolicyConfigurable); ok {
+		configurable.SetClientPolicy(ai.ClientPolicy{
+			Timeout:          cfg.GeminiTimeout,
+			MaxRetries:       cfg.GeminiMaxRetries,
+			RetryBaseDelay:   cfg.GeminiRetryBaseDelay,
+			RetryMaxDelay:    ai.DefaultClientPolicy.RetryMaxDelay,
+			BreakerThreshold: cfg.GeminiBreakerThreshold,
+			BreakerCooldown:  cfg.GeminiBreakerCooldown,
+		})
+	}
+	categoryLearningUseCase := usecase.NewCategoryLearningUseCase(categoryRepo, categoryCorrectionRepo)
+	if configurable, ok := aiService.(ai.CategoryHintConfigurable); ok {
+		configurable.SetCategoryHintSource(categoryLearningUseCase)
 	}
 
 	// Initialize use cases
 	autoSignupUseCase := usecase.NewAutoSignupUseCase(userRepo, categoryRepo)
 
+	// Background schedulers are started through the monitor, which readiness checks
+	schedulers := usecase.NewSchedulerMonitor()
+
 	// Initialize exchange rate service
 	exchangeRateProvider := exchangerate.NewFrankfurterProvider(nil)
 	exchangeRateSvc := usecase.NewExchangeRateService(exchangeRateRepo, exchangeRateProvider)
+	schedulers.Go(context.Background(), "exchange_rates", func(ctx context.Context) { exchangeRateSvc.RunDailyRefresh(ctx, 24*time.Hour) })
 
 	parseConversationUseCase := usecase.NewParseConversationUseCase(
 		aiService,
@@ -112,6 +408,13 @@ func main() {
 		cfg.AIProvider,
 		cfg.AIModel,
 	)
+	costGuardUseCase := usecase.NewCostGuardUseCase(
+		aiCostRepo,
+		aiCostCapRepo,
+		domain.AICostCap{DailyUSD: cfg.AIDailyCapUSD, MonthlyUSD: cfg.AIMonthlyCapUSD},
+		domain.AICostCap{DailyUSD: cfg.AIUserDailyCapUSD, MonthlyUSD: cfg.AIUserMonthlyCapUSD},
+	)
+	parseConversationUseCase.SetCostGuard(costGuardUseCase)
 	createExpenseUseCase := usecase.NewCreateExpenseUseCaseWithAIConfig(
 		expenseRepo,
 		categoryRepo,
@@ -125,19 +428,95 @@ func main() {
 	)
 	getExpensesUseCase := usecase.NewGetExpensesUseCase(expenseRepo, categoryRepo)
 	updateExpenseUseCase := usecase.NewUpdateExpenseUseCase(expenseRepo, categoryRepo)
+	updateExpenseUseCase.SetCategoryLearner(categoryLearningUseCase)
+	updateExpenseUseCase.SetAuditRepository(expenseAuditRepo)
+	updateExpenseUseCase.SetUnitOfWork(unitOfWork)
 	deleteExpenseUseCase := usecase.NewDeleteExpenseUseCase(expenseRepo)
+	deleteExpenseUseCase.SetAuditRepository(expenseAuditRepo)
+	deleteExpenseUseCase.SetUnitOfWork(unitOfWork)
 	manageCategoryUseCase := usecase.NewManageCategoryUseCase(categoryRepo)
-	generateReportUseCase := usecase.NewGenerateReportUseCase(expenseRepo, categoryRepo, metricsRepo)
-	budgetManagementUseCase := usecase.NewBudgetManagementUseCase(categoryRepo, expenseRepo)
+	generateReportUseCase := usecase.NewGenerateReportUseCase(expenseRepo, categoryRepo, metricsRepo, groupRepo)
+	generateReportUseCase.SetTagRepository(tagRepo)
+	budgetManagementUseCase := usecase.NewBudgetManagementUseCase(budgetRepo, categoryRepo, expenseRepo, groupRepo)
+	budgetManagementUseCase.SetUserRepository(userRepo)
+	budgetAlertUseCase := usecase.NewBudgetAlertUseCase(budgetManagementUseCase, userRepo)
+	authUseCase := usecase.NewAuthUseCase(userRepo, cfg.JWTSecret)
+	createExpenseUseCase.SetCategoryConfirmThreshold(cfg.CategoryConfirmThreshold)
+	createExpenseUseCase.SetAuditRepository(expenseAuditRepo)
+	createExpenseUseCase.SetUnitOfWork(unitOfWork)
+	createExpenseUseCase.SetTagRepository(tagRepo)
+	createExpenseUseCase.SetExpenseItemRepository(expenseItemRepo)
+	createExpenseUseCase.SetMerchantRepository(merchantRepo)
+	webhookUseCase := usecase.NewWebhookUseCase(webhookRepo)
 	dataExportUseCase := usecase.NewDataExportUseCase(expenseRepo, categoryRepo)
+	dataExportUseCase.SetTagRepository(tagRepo)
+	dataExportUseCase.SetExpenseItemRepository(expenseItemRepo)
 	metricsUseCase := usecase.NewMetricsUseCase(metricsRepo)
+	metricsUseCase.SetDBStats(cfg.DatabaseDriver(), dbStats)
+	if reporter, ok := aiService.(ai.HealthReporter); ok {
+		metricsUseCase.SetAIProviderHealth(reporter.Health)
+	}
 	aiCostUseCase := usecase.NewAICostUseCase(aiCostRepo)
+	metricsAggregator := usecase.NewMetricsAggregator(metricsRollupRepo)
+	metricsUseCase.SetRollups(metricsRollupRepo)
+	aiCostUseCase.SetRollups(metricsRollupRepo)
 	recurringExpenseUseCase := usecase.NewRecurringExpenseUseCase(expenseRepo, categoryRepo)
+	recurringExpenseUseCase.SetMerchantRepository(merchantRepo)
 	notificationUseCase := usecase.NewNotificationUseCase()
+	notificationUseCase.SetPreferencesRepository(notificationPreferencesRepo)
 	searchExpenseUseCase := usecase.NewSearchExpenseUseCase(expenseRepo, categoryRepo)
-	archiveUseCase := usecase.NewArchiveUseCase(expenseRepo)
+	searchExpenseUseCase.SetTagRepository(tagRepo)
+	// The full-text index only holds ciphertext once descriptions are encrypted
+	if expenseSearchRepo != nil && cipher == nil {
+		searchExpenseUseCase.SetSearchRepository(expenseSearchRepo)
+	}
+	archiveUseCase := usecase.NewArchiveUseCase(expenseRepo, categoryRepo)
+	archiveUseCase.SetUnitOfWork(unitOfWork)
 	getPolicyUseCase := usecase.NewGetPolicyUseCase(policyRepo)
+	tagUseCase := usecase.NewTagUseCase(tagRepo, expenseRepo)
+	merchantUseCase := usecase.NewMerchantUseCase(merchantRepo, expenseRepo)
+	reimbursementUseCase := usecase.NewReimbursementUseCase(expenseRepo, categoryRepo)
+	reimbursementUseCase.SetMerchantRepository(merchantRepo)
+	workspaceUseCase := usecase.NewWorkspaceUseCase(workspaceRepo, userRepo, categoryRepo, expenseRepo, budgetRepo)
 	generateReportLinkUseCase := usecase.NewGenerateReportLinkUseCase(cfg.APIPublicURL, shortLinkRepo)
+	groupLedgerUseCase := usecase.NewGroupLedgerUseCase(groupRepo)
+	splitExpenseUseCase := usecase.NewSplitExpenseUseCase(expenseRepo, expenseSplitRepo, groupRepo)
+	settlementUseCase := usecase.NewSettlementUseCase(groupRepo, expenseSplitRepo, expenseRepo)
+
+	// Use cases publish their changes in-process; budget alerts, webhooks,
+	// metrics and the live update stream react to them
+	eventBus := usecase.NewEventBus()
+	autoSignupUseCase.SetEventPublisher(eventBus)
+	createExpenseUseCase.SetEventPublisher(eventBus)
+	updateExpenseUseCase.SetEventPublisher(eventBus)
+	deleteExpenseUseCase.SetEventPublisher(eventBus)
+	budgetAlertUseCase.SetEventPublisher(eventBus)
+	notificationUseCase.SetEventPublisher(eventBus)
+	eventBus.Handle(domain.EventExpenseCreated, budgetAlertUseCase.HandleExpenseCreated)
+	for _, event := range domain.WebhookEvents {
+		eventBus.Handle(event, webhookUseCase.HandleEvent)
+	}
+	for _, event := range []string{domain.EventUserSignedUp, domain.EventExpenseCreated, domain.EventBudgetExceeded} {
+		eventBus.Handle(event, metricsUseCase.RecordEvent)
+	}
+	for _, event := range []string{domain.EventExpenseCreated, domain.EventExpenseUpdated, domain.EventExpenseDeleted, domain.EventExpenseRestored} {
+		eventBus.Handle(event, metricsAggregator.HandleExpenseEvent)
+	}
+
+	// Expense tools for desktop agents, over stdio below or POST /mcp
+	mcpServer := mcp.NewServer(createExpenseUseCase, getExpensesUseCase, generateReportUseCase, categoryRepo)
+
+	// "server mcp <user_id>" serves the tools on stdin/stdout for an agent that
+	// launches it, acting for that user, and exits when the agent closes stdin
+	if len(os.Args) > 1 && os.Args[1] == "mcp" {
+		if len(os.Args) < 3 {
+			fatal("MCP server failed", fmt.Errorf("usage: server mcp <user_id>"))
+		}
+		if err := mcpServer.ServeStdio(context.Background(), os.Args[2], os.Stdin, os.Stdout); err != nil {
+			fatal("MCP server failed", err)
+		}
+		return
+	}
 
 	// Initialize Unified Message Processor
 	processMessageUseCase := usecase.NewProcessMessageUseCase(
@@ -148,6 +527,183 @@ func main() {
 		generateReportLinkUseCase,
 		interactionLogRepo,
 	)
+	processMessageUseCase.SetReceiptParser(parseConversationUseCase)
+	processMessageUseCase.SetGroupResolver(groupLedgerUseCase)
+	processMessageUseCase.SetBillSplitter(splitExpenseUseCase)
+	processMessageUseCase.SetSettlementReporter(settlementUseCase)
+	processMessageUseCase.SetExpenseUndoer(deleteExpenseUseCase)
+	processMessageUseCase.SetExpenseDeleter(deleteExpenseUseCase)
+	processMessageUseCase.SetExpenseUpdater(updateExpenseUseCase)
+	processMessageUseCase.SetExpenseEditor(usecase.NewExpenseEditUseCase(expenseRepo, categoryRepo, updateExpenseUseCase))
+	processMessageUseCase.SetExpenseQuerier(usecase.NewExpenseQueryUseCase(generateReportUseCase, categoryRepo))
+	processMessageUseCase.SetCategoryManager(manageCategoryUseCase)
+	processMessageUseCase.SetBudgetStatusReporter(budgetManagementUseCase)
+	processMessageUseCase.SetBudgetSelector(budgetManagementUseCase)
+	processMessageUseCase.SetReportComparer(generateReportUseCase)
+	processMessageUseCase.SetReportCharts(usecase.NewReportChartUseCase(generateReportUseCase, cfg.APIPublicURL))
+	processMessageUseCase.SetSubscriptionDetector(recurringExpenseUseCase)
+	processMessageUseCase.SetClaimTracker(reimbursementUseCase)
+	processMessageUseCase.SetWorkspaceSwitcher(workspaceUseCase)
+	processMessageUseCase.SetCategoryConfirmer(usecase.NewCategoryConfirmationUseCase(categoryRepo, updateExpenseUseCase, usecase.DefaultCategoryConfirmationTTL))
+	conversationStateUseCase := usecase.NewConversationStateUseCase(conversationStateRepo, usecase.DefaultConversationStateTTL)
+	schedulers.Go(context.Background(), "conversation_cleanup", func(ctx context.Context) { conversationStateUseCase.RunCleanup(ctx, time.Hour) })
+	processMessageUseCase.SetConversationStore(conversationStateUseCase)
+	onboardingUseCase := usecase.NewOnboardingUseCase(userRepo, conversationStateUseCase)
+	onboardingUseCase.SetBudgetCreator(budgetManagementUseCase)
+	processMessageUseCase.SetOnboarder(onboardingUseCase)
+	timezoneUseCase := usecase.NewTimezoneUseCase(userRepo)
+	processMessageUseCase.SetTimezoneManager(timezoneUseCase)
+	processMessageUseCase.SetLocaleManager(usecase.NewLocaleUseCase(userRepo))
+	// The limiter is set even while disabled, so a configuration reload can enable it
+	rateLimitUseCase := usecase.NewRateLimitUseCase(userRepo, cfg.RateLimitPerMinute, cfg.RateLimitBurst)
+	processMessageUseCase.SetRateLimiter(rateLimitUseCase)
+	if cfg.RateLimitPerMinute > 0 {
+		slog.Info("Message rate limit enabled", "per_minute", cfg.RateLimitPerMinute, "burst", cfg.RateLimitBurst)
+	}
+
+	// Initialize speech-to-text for voice messages (optional)
+	if cfg.SpeechProvider != "" && cfg.SpeechAPIKey() != "" {
+		transcriber, err := ai.NewTranscriber(cfg.SpeechProvider, cfg.SpeechAPIKey(), cfg.SpeechModel)
+		if err != nil {
+			fatal("Failed to initialize speech provider", err)
+		}
+		if configurable, ok := transcriber.(ai.PromptConfigurable); ok {
+			configurable.SetPromptSource(promptRepo)
+		}
+		processMessageUseCase.SetTranscriber(usecase.NewTranscribeAudioUseCase(
+			transcriber,
+			pricingRepo,
+			aiCostRepo,
+			cfg.SpeechProvider,
+			cfg.SpeechModel,
+		))
+		slog.Info("Voice messages enabled", "provider", cfg.SpeechProvider)
+	}
+
+	// Users can download a zip of their data through a link sent to their messenger
+	userExportUseCase := usecase.NewUserExportUseCase(userRepo, expenseRepo, categoryRepo, budgetRepo, recurringExpenseUseCase, notificationUseCase, cfg.APIPublicURL)
+	userExportUseCase.SetTagRepository(tagRepo)
+	userExportUseCase.SetExpenseItemRepository(expenseItemRepo)
+	processMessageUseCase.SetDataExporter(userExportUseCase)
+
+	// Users can erase their data, which is purged after a grace period
+	userDeletionUseCase := usecase.NewUserDeletionUseCase(userDeletionRepo, userRepo, time.Duration(cfg.UserDeletionGraceDays)*24*time.Hour)
+
+	// Initialize receipt attachment storage (optional)
+	var attachmentUseCase *usecase.AttachmentUseCase
+	if cfg.AttachmentStorage != "" {
+		blobStorage, err := storage.New(storage.Config{
+			Provider:          cfg.AttachmentStorage,
+			Dir:               cfg.AttachmentDir,
+			S3Endpoint:        cfg.S3Endpoint,
+			S3Region:          cfg.S3Region,
+			S3Bucket:          cfg.S3Bucket,
+			S3AccessKeyID:     cfg.S3AccessKeyID,
+			S3SecretAccessKey: cfg.S3SecretAccessKey,
+		})
+		if err != nil {
+			slog.Warn("Attachment storage disabled", "error", err)
+		} else {
+			attachmentUseCase = usecase.NewAttachmentUseCase(attachmentRepo, blobStorage, expenseRepo, groupRepo)
+			processMessageUseCase.SetAttachmentSaver(attachmentUseCase)
+			userDeletionUseCase.SetBlobStorage(blobStorage)
+			slog.Info("Receipt attachments enabled", "storage", cfg.AttachmentStorage)
+		}
+	}
+
+	// "server serve -repl" chats with the message processor on the terminal
+	// and exits on /quit
+	if serve.repl {
+		if err := runREPL(context.Background(), processMessageUseCase, serve.user); err != nil {
+			fatal("REPL failed", err)
+		}
+		return
+	}
+
+	// Initialize expense archive storage (optional); archive policies are
+	// applied daily once there is somewhere to put the archives
+	archivePolicyUseCase := usecase.NewArchivePolicyUseCase(archivePolicyRepo, archiveUseCase, domain.ArchivePolicy{
+		ArchiveAfterMonths: cfg.ArchiveAfterMonths,
+		PurgeAfterDays:     cfg.ArchivePurgeAfterDays,
+	})
+	archivePolicyUseCase.SetDryRun(cfg.ArchivePolicyDryRun)
+	archivePolicyUseCase.SetTimezoneLocator(timezoneUseCase)
+	if cfg.ArchiveStorage != "" {
+		archiveStorage, err := storage.New(storage.Config{
+			Provider:          cfg.ArchiveStorage,
+			Dir:               cfg.ArchiveDir,
+			S3Endpoint:        cfg.S3Endpoint,
+			S3Region:          cfg.S3Region,
+			S3Bucket:          cfg.ArchiveS3Bucket,
+			S3AccessKeyID:     cfg.S3AccessKeyID,
+			S3SecretAccessKey: cfg.S3SecretAccessKey,
+		})
+		if err != nil {
+			slog.Warn("Archive storage disabled", "error", err)
+		} else {
+			archiveUseCase.SetStorage(archiveRepo, archiveStorage)
+			userDeletionUseCase.SetArchiveStorage(archiveStorage)
+			schedulers.Go(context.Background(), "archive_policies", func(ctx context.Context) { archivePolicyUseCase.RunScheduler(ctx, 24*time.Hour) })
+			slog.Info("Expense archives enabled", "storage", cfg.ArchiveStorage, "policy_dry_run", cfg.ArchivePolicyDryRun)
+		}
+	}
+
+	// Initialize the report and budget status cache (optional)
+	if cfg.SummaryCache != "" {
+		summaryStore, err := kvcache.New(kvcache.Config{Provider: cfg.SummaryCache, RedisURL: cfg.RedisURL})
+		if err != nil {
+			slog.Warn("Summary cache disabled", "error", err)
+		} else {
+			summaryCache := usecase.NewSummaryCache(summaryStore, cfg.SummaryCacheTTL)
+			generateReportUseCase.SetSummaryCache(summaryCache)
+			budgetManagementUseCase.SetSummaryCache(summaryCache)
+			tagUseCase.SetSummaryCache(summaryCache)
+			for _, event := range []string{domain.EventExpenseCreated, domain.EventExpenseUpdated, domain.EventExpenseDeleted, domain.EventExpenseRestored} {
+				eventBus.Handle(event, summaryCache.HandleExpenseEvent)
+			}
+			slog.Info("Summary cache enabled", "cache", cfg.SummaryCache, "ttl", cfg.SummaryCacheTTL)
+		}
+	}
+
+	// Initialize scheduled email reports and forwarded receipts (optional)
+	var emailSender domain.EmailSender
+	var emailAddressUseCase *usecase.EmailAddressUseCase
+	if cfg.SMTPHost != "" {
+		sender, err := email.NewSMTPSender(email.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		})
+		if err != nil {
+			slog.Warn("Email reports disabled", "error", err)
+		} else {
+			reportScheduleUseCase := usecase.NewReportScheduleUseCase(reportScheduleRepo, generateReportUseCase, dataExportUseCase, sender)
+			reportScheduleUseCase.SetTimezoneLocator(timezoneUseCase)
+			notificationUseCase.SetReportScheduler(reportScheduleUseCase)
+			schedulers.Go(context.Background(), "report_schedules", func(ctx context.Context) { reportScheduleUseCase.RunScheduler(ctx, time.Hour) })
+			slog.Info("Email reports enabled", "smtp_host", cfg.SMTPHost)
+			emailSender = sender
+			emailAddressUseCase = usecase.NewEmailAddressUseCase(emailAddressRepo, sender, cfg.InboundEmailAddress)
+		}
+	}
+
+	// Initialize bank statement import, with optional column mapping profiles
+	importUseCase := usecase.NewImportUseCase(expenseRepo, createExpenseUseCase)
+	if cfg.ImportProfilesPath != "" {
+		data, err := os.ReadFile(cfg.ImportProfilesPath)
+		if err == nil {
+			var profiles []usecase.ImportProfile
+			if profiles, err = usecase.ParseImportProfiles(data); err == nil {
+				importUseCase.SetProfiles(profiles)
+				slog.Info("Loaded import profiles", "count", len(profiles), "path", cfg.ImportProfilesPath)
+			}
+		}
+		if err != nil {
+			slog.Warn("Import profiles not loaded", "error", err)
+		}
+	}
 
 	// Initialize HTTP handler
 	handler := httpAdapter.NewHandler(
@@ -172,15 +728,31 @@ func main() {
 		categoryRepo,
 		expenseRepo,
 		metricsRepo,
-		cfg.AdminAPIKey,
 	)
 
 	// Initialize AI Cost handler
-	aiCostHandler := httpAdapter.NewAICostHandler(aiCostUseCase, cfg.AdminAPIKey)
+	aiCostHandler := httpAdapter.NewAICostHandler(aiCostUseCase)
+	aiCostHandler.SetCostGuard(costGuardUseCase)
+
+	// Flag days of runaway AI spend for the admins (optional)
+	if cfg.AICostAnomalyFactor > 0 {
+		anomalyDetector := usecase.NewAICostAnomalyDetector(aiCostRepo, aiCostAnomalyRepo, cfg.AICostAnomalyFactor, cfg.AICostAnomalyMinUSD)
+		anomalyDetector.SetEventPublisher(eventBus)
+		eventBus.Handle(domain.EventAICostAnomalyDetected, metricsUseCase.RecordEvent)
+		aiCostHandler.SetAnomalyDetector(anomalyDetector)
+		schedulers.Go(context.Background(), "ai_cost_anomalies", func(ctx context.Context) { anomalyDetector.Run(ctx, time.Hour) })
+	}
 
 	// Initialize Report handler (Secure Link)
 	reportHandler := httpAdapter.NewReportHandler(generateReportUseCase)
 	shortLinkHandler := httpAdapter.NewShortLinkHandler(shortLinkRepo, cfg.DashboardURL)
+	groupHandler := httpAdapter.NewGroupHandler(groupLedgerUseCase, settlementUseCase)
+	splitHandler := httpAdapter.NewSplitHandler(splitExpenseUseCase)
+	var attachmentHandler *httpAdapter.AttachmentHandler
+	if attachmentUseCase != nil {
+		attachmentHandler = httpAdapter.NewAttachmentHandler(attachmentUseCase)
+	}
+	historyHandler := httpAdapter.NewExpenseHistoryHandler(usecase.NewExpenseAuditUseCase(expenseAuditRepo, expenseRepo))
 
 	// Providers
 	geminiProvider := ai.NewGeminiPricingProvider(nil)
@@ -191,137 +763,444 @@ func main() {
 	// Initialize Pricing handler
 	pricingHandler := httpAdapter.NewPricingHandler(
 		pricingRepo,
-		cfg.AdminAPIKey,
 		pricingProviders,
 	)
 
+	// Without pricing for the configured model, its AI calls are logged at zero cost
+	if _, err := usecase.NewPricingManagementUseCase(pricingRepo).ValidateModel(context.Background(), cfg.AIProvider, cfg.AIModel); err != nil {
+		slog.Warn("AI model pricing check failed; add it with POST /api/admin/pricing", "provider", cfg.AIProvider, "model", cfg.AIModel, "error", err)
+	}
+
+	promptHandler := httpAdapter.NewPromptHandler(usecase.NewPromptManagementUseCase(promptRepo))
+	interactionHandler := httpAdapter.NewInteractionHandler(usecase.NewInteractionLogUseCase(interactionLogRepo))
+	importHandler := httpAdapter.NewImportHandler(importUseCase)
+	webhookHandler := httpAdapter.NewWebhookHandler(webhookUseCase)
+	streamHandler := httpAdapter.NewStreamHandler(eventBus)
+	authHandler := httpAdapter.NewAuthHandler(authUseCase)
+	userDeletionHandler := httpAdapter.NewUserDeletionHandler(userDeletionUseCase)
+	userExportHandler := httpAdapter.NewUserExportHandler(userExportUseCase)
+	archivePolicyHandler := httpAdapter.NewArchivePolicyHandler(archivePolicyUseCase)
+	tagHandler := httpAdapter.NewTagHandler(tagUseCase)
+	merchantHandler := httpAdapter.NewMerchantHandler(merchantUseCase)
+	chartHandler := httpAdapter.NewChartHandler(chart.NewRenderer())
+	claimHandler := httpAdapter.NewClaimHandler(reimbursementUseCase)
+	workspaceHandler := httpAdapter.NewWorkspaceHandler(workspaceUseCase)
+	var emailAddressHandler *httpAdapter.EmailAddressHandler
+	if emailAddressUseCase != nil {
+		emailAddressHandler = httpAdapter.NewEmailAddressHandler(emailAddressUseCase)
+	}
+
+	// Admin endpoints need an API key with the right scope; ADMIN_API_KEY holds every scope
+	apiKeyUseCase := usecase.NewAPIKeyUseCase(apiKeyRepo, cfg.AdminAPIKey)
+	apiKeyHandler := httpAdapter.NewAPIKeyHandler(apiKeyUseCase)
+
 	// Initialize HTTP server
 	mux := http.NewServeMux()
-	httpAdapter.RegisterRoutes(mux, handler, aiCostHandler, pricingHandler, reportHandler, shortLinkHandler)
+	httpAdapter.RegisterRoutes(mux, httpAdapter.Handlers{
+		API:           handler,
+		AICost:        aiCostHandler,
+		Pricing:       pricingHandler,
+		Report:        reportHandler,
+		ShortLink:     shortLinkHandler,
+		Group:         groupHandler,
+		Split:         splitHandler,
+		Attachment:    attachmentHandler,
+		Prompt:        promptHandler,
+		History:       historyHandler,
+		Interaction:   interactionHandler,
+		Import:        importHandler,
+		Webhook:       webhookHandler,
+		Stream:        streamHandler,
+		Auth:          authHandler,
+		APIKey:        apiKeyHandler,
+		UserDeletion:  userDeletionHandler,
+		UserExport:    userExportHandler,
+		EmailAddress:  emailAddressHandler,
+		ArchivePolicy: archivePolicyHandler,
+		Tag:           tagHandler,
+		Merchant:      merchantHandler,
+		Chart:         chartHandler,
+		Claim:         claimHandler,
+		Workspace:     workspaceHandler,
+	})
+
+	// Retried webhook deliveries are recognised by their event ID
+	eventDedupUseCase := usecase.NewEventDedupUseCase(processedEventRepo, usecase.DefaultProcessedEventTTL)
+	schedulers.Go(context.Background(), "event_dedup_cleanup", func(ctx context.Context) { eventDedupUseCase.RunCleanup(ctx, time.Hour) })
+
+	// Retry failed outbound webhook deliveries with backoff
+	schedulers.Go(context.Background(), "webhook_retries", func(ctx context.Context) { webhookUseCase.RunRetries(ctx, time.Minute) })
+
+	// Purge the data of users whose deletion grace period has ended
+	schedulers.Go(context.Background(), "user_deletion_purges", func(ctx context.Context) { userDeletionUseCase.RunPurges(ctx, time.Hour) })
+
+	// Roll up daily active users, expense totals and AI cost for the metrics endpoints
+	schedulers.Go(context.Background(), "metrics_rollups", func(ctx context.Context) { metricsAggregator.Run(ctx, 15*time.Minute) })
+
+	// Record messenger replies before sending them and retry the undelivered ones
+	replyOutboxUseCase := usecase.NewReplyOutboxUseCase(replyOutboxRepo)
+	schedulers.Go(context.Background(), "reply_outbox_retries", func(ctx context.Context) { replyOutboxUseCase.RunRetries(ctx, 15*time.Second) })
+
+	// Deliver notifications through the channel each user picked for them,
+	// holding pushes in the outbox until the user's quiet hours are over
+	notificationDispatcher := usecase.NewNotificationDispatcher(notificationPreferencesRepo, replyOutboxUseCase)
+	notificationDispatcher.SetEventPublisher(eventBus)
+	notificationDispatcher.SetTimezoneLocator(timezoneUseCase)
+	if emailSender != nil {
+		notificationDispatcher.SetEmail(emailAddressRepo, emailSender)
+	}
+	budgetAlertUseCase.SetDispatcher(notificationDispatcher)
+
+	// Let messenger webhooks answer before their messages are parsed (optional)
+	var messageQueue *async.MessageQueue
+	switch cfg.WebhookQueue {
+	case "memory":
+		messageQueue = async.NewMessageQueue(processMessageUseCase, cfg.WebhookWorkers, cfg.WebhookQueueSize)
+	case "redis":
+		redisStore, err := kvcache.NewRedisCache(cfg.RedisURL)
+		if err != nil {
+			fatal("Failed to initialize webhook queue", err)
+		}
+		messageQueue = async.NewStoreMessageQueue(processMessageUseCase, cfg.WebhookWorkers, redisStore, async.DefaultMessageQueueKey)
+	}
+	if messageQueue != nil {
+		go messageQueue.Run(context.Background())
+		slog.Info("Webhook queue enabled", "queue", cfg.WebhookQueue, "workers", cfg.WebhookWorkers)
+	}
 
 	// Initialize LINE client (if enabled)
 	var lineHandler *line.Handler
 	if cfg.IsMessengerEnabled("line") {
 		lineClient, err := line.NewClient(cfg.LineChannelToken)
 		if err != nil {
-			log.Fatalf("Failed to initialize LINE client: %v", err)
+			fatal("Failed to initialize LINE client", err)
 		}
 
 		// Initialize LINE webhook handler with Unified Message Processor
 		lineHandler = line.NewHandler(cfg.LineChannelSecret, processMessageUseCase, lineClient)
+		lineHandler.SetDeduplicator(eventDedupUseCase)
+		lineHandler.SetOutbox(replyOutboxUseCase)
+		replyOutboxUseCase.RegisterReplier("line", lineHandler)
+		if messageQueue != nil {
+			lineHandler.SetQueue(messageQueue)
+			messageQueue.RegisterReplier("line", replyOutboxUseCase)
+		}
+		budgetAlertUseCase.RegisterNotifier("line", lineClient)
+		authUseCase.RegisterNotifier("line", lineClient)
+		userExportUseCase.RegisterNotifier("line", lineClient)
+		replyOutboxUseCase.RegisterNotifier("line", lineClient)
+
+		// Quick action rich menu (optional); a failure leaves typed messages working
+		if cfg.LineRichMenuImage != "" {
+			image, err := os.ReadFile(cfg.LineRichMenuImage)
+			if err != nil {
+				slog.Warn("Failed to read LINE rich menu image", "path", cfg.LineRichMenuImage, "error", err)
+			} else if menuID, err := lineClient.EnsureRichMenu(context.Background(), line.DefaultRichMenu(), image, http.DetectContentType(image)); err != nil {
+				slog.Warn("Failed to provision LINE rich menu", "error", err)
+			} else {
+				slog.Info("LINE rich menu linked", "rich_menu_id", menuID)
+			}
+		}
+
+		// Dashboard sign-in through LINE Login (optional)
+		if cfg.LineLoginChannelID != "" {
+			lineLogin, err := line.NewLoginVerifier(cfg.LineLoginChannelID, cfg.LineLoginChannelSecret)
+			if err != nil {
+				fatal("Failed to initialize LINE Login", err)
+			}
+			authUseCase.RegisterLoginVerifier("line", lineLogin)
+		}
 	}
 
 	// Initialize Terminal messenger (if enabled)
 	var terminalHandler *terminal.Handler
 	if cfg.IsMessengerEnabled("terminal") {
 		terminalHandler = terminal.NewHandler(processMessageUseCase)
-		log.Printf("Terminal messenger initialized")
+		slog.Info("Terminal messenger initialized")
 	}
 
-	// Initialize Telegram client (optional)
+	// Initialize Telegram client (if enabled)
 	var telegramHandler *telegram.Handler
-	if cfg.IsMessengerEnabled("telegram") && cfg.TelegramBotToken != "" {
+	if cfg.IsMessengerEnabled("telegram") {
 		telegramClient, err := telegram.NewClient(cfg.TelegramBotToken)
 		if err != nil {
-			log.Fatalf("Failed to initialize Telegram client: %v", err)
+			fatal("Failed to initialize Telegram client", err)
 		}
 
 		// Initialize Telegram webhook handler
 		telegramHandler = telegram.NewHandler(cfg.TelegramBotToken, processMessageUseCase, telegramClient)
+		telegramHandler.SetDeduplicator(eventDedupUseCase)
+		telegramHandler.SetOutbox(replyOutboxUseCase)
+		replyOutboxUseCase.RegisterReplier("telegram", telegramHandler)
+		if messageQueue != nil {
+			telegramHandler.SetQueue(messageQueue)
+			messageQueue.RegisterReplier("telegram", replyOutboxUseCase)
+		}
+		budgetAlertUseCase.RegisterNotifier("telegram", telegramClient)
+		authUseCase.RegisterNotifier("telegram", telegramClient)
+		userExportUseCase.RegisterNotifier("telegram", telegramClient)
+		replyOutboxUseCase.RegisterNotifier("telegram", telegramClient)
+		authUseCase.RegisterLoginVerifier("telegram", telegram.NewLoginVerifier(cfg.TelegramBotToken))
+
+		// Command menu shown in the Telegram app; the commands work without it
+		if err := telegramClient.RegisterCommands(context.Background()); err != nil {
+			slog.Warn("Failed to register Telegram commands", "error", err)
+		}
 	}
 
-	// Initialize Discord client (optional)
+	// Initialize Discord client (if enabled)
 	var discordHandler *discord.Handler
-	if cfg.IsMessengerEnabled("discord") && cfg.DiscordBotToken != "" {
+	if cfg.IsMessengerEnabled("discord") {
 		discordClient, err := discord.NewClient(cfg.DiscordBotToken)
 		if err != nil {
-			log.Fatalf("Failed to initialize Discord client: %v", err)
+			fatal("Failed to initialize Discord client", err)
 		}
 
 		// Initialize Discord webhook handler
 		discordHandler = discord.NewHandler(cfg.DiscordBotToken, processMessageUseCase, discordClient)
+		discordHandler.SetDeduplicator(eventDedupUseCase)
 	}
 
-	// Initialize WhatsApp client (optional)
+	// Initialize WhatsApp client (if enabled)
 	var whatsappHandler *whatsapp.Handler
-	if cfg.IsMessengerEnabled("whatsapp") && cfg.WhatsAppPhoneNumberID != "" && cfg.WhatsAppAccessToken != "" {
-		// Client initialization logic removed as it's not used by handler yet
-		// To re-enable client usage, update whatsapp.NewHandler to accept *Client
+	if cfg.IsMessengerEnabled("whatsapp") {
+		whatsappClient, err := whatsapp.NewClient(cfg.WhatsAppPhoneNumberID, cfg.WhatsAppAccessToken)
+		if err != nil {
+			fatal("Failed to initialize WhatsApp client", err)
+		}
 
-		// Initialize WhatsApp webhook handler with app secret
-		appSecret := "" // In production, this would be the app secret from Meta
-		// TODO: Get AppSecret from config
-		whatsappHandler = whatsapp.NewHandler(appSecret, cfg.WhatsAppPhoneNumberID, processMessageUseCase)
+		// Initialize WhatsApp webhook handler with the app secret that signs its
+		// webhooks and the token of the subscription handshake
+		whatsappHandler = whatsapp.NewHandler(cfg.WhatsAppAppSecret, cfg.WhatsAppVerifyToken, cfg.WhatsAppPhoneNumberID, processMessageUseCase, whatsappClient)
+		whatsappHandler.SetDeduplicator(eventDedupUseCase)
+		whatsappHandler.SetOutbox(replyOutboxUseCase)
+		replyOutboxUseCase.RegisterReplier("whatsapp", whatsappHandler)
+		if messageQueue != nil {
+			whatsappHandler.SetQueue(messageQueue)
+			messageQueue.RegisterReplier("whatsapp", replyOutboxUseCase)
+		}
+		var whatsappNotifier domain.PushNotifier = whatsappClient
+		if cfg.WhatsAppAlertTemplate != "" {
+			whatsappNotifier = whatsapp.NewTemplateNotifier(whatsappClient, cfg.WhatsAppAlertTemplate, cfg.WhatsAppAlertLanguage)
+		}
+		budgetAlertUseCase.RegisterNotifier("whatsapp", whatsappNotifier)
+		authUseCase.RegisterNotifier("whatsapp", whatsappClient)
+		userExportUseCase.RegisterNotifier("whatsapp", whatsappClient)
+		replyOutboxUseCase.RegisterNotifier("whatsapp", whatsappNotifier)
 	}
 
-	// Initialize Slack client (optional)
+	// Initialize Slack client (if enabled)
 	var slackHandler *slack.Handler
-	if cfg.IsMessengerEnabled("slack") && cfg.SlackBotToken != "" {
+	if cfg.IsMessengerEnabled("slack") {
 		slackClient, err := slack.NewClient(cfg.SlackBotToken)
 		if err != nil {
-			log.Fatalf("Failed to initialize Slack client: %v", err)
+			fatal("Failed to initialize Slack client", err)
 		}
 
 		// Initialize Slack webhook handler
 		slackHandler = slack.NewHandler(cfg.SlackSigningSecret, processMessageUseCase, slackClient)
+		slackHandler.SetDeduplicator(eventDedupUseCase)
+		slackHandler.SetOutbox(replyOutboxUseCase)
+		replyOutboxUseCase.RegisterReplier("slack", slackHandler)
+		if messageQueue != nil {
+			slackHandler.SetQueue(messageQueue)
+			messageQueue.RegisterReplier("slack", replyOutboxUseCase)
+		}
+		budgetAlertUseCase.RegisterNotifier("slack", slackClient)
+		authUseCase.RegisterNotifier("slack", slackClient)
+		userExportUseCase.RegisterNotifier("slack", slackClient)
+		replyOutboxUseCase.RegisterNotifier("slack", slackClient)
+
+		// App Home tab with the month's spending, republished when expenses change
+		slackHandler.SetHomeSummarizer(usecase.NewSpendingSummaryUseCase(generateReportUseCase, budgetManagementUseCase))
+		for _, event := range []string{domain.EventExpenseCreated, domain.EventExpenseUpdated, domain.EventExpenseDeleted, domain.EventExpenseRestored} {
+			eventBus.Handle(event, func(ctx context.Context, userID string, _ usecase.UserEvent) {
+				if err := slackHandler.RefreshHome(ctx, userID); err != nil {
+					slog.WarnContext(ctx, "Failed to refresh Slack App Home", "error", err)
+				}
+			})
+		}
 	}
 
-	// Initialize Microsoft Teams client (optional)
+	// Initialize Microsoft Teams client (if enabled)
 	var teamsHandler *teams.Handler
-	if cfg.IsMessengerEnabled("teams") && cfg.TeamsAppID != "" && cfg.TeamsAppPassword != "" {
+	if cfg.IsMessengerEnabled("teams") {
 		teamsClient, err := teams.NewClient(cfg.TeamsAppID, cfg.TeamsAppPassword)
 		if err != nil {
-			log.Fatalf("Failed to initialize Teams client: %v", err)
+			fatal("Failed to initialize Teams client", err)
 		}
 
 		// Initialize Teams webhook handler
 		teamsHandler = teams.NewHandler(cfg.TeamsAppID, cfg.TeamsAppPassword, processMessageUseCase, teamsClient)
+		teamsHandler.SetDeduplicator(eventDedupUseCase)
+		teamsHandler.SetOutbox(replyOutboxUseCase)
+		replyOutboxUseCase.RegisterReplier("teams", teamsHandler)
+		if messageQueue != nil {
+			teamsHandler.SetQueue(messageQueue)
+			messageQueue.RegisterReplier("teams", replyOutboxUseCase)
+		}
+	}
+
+	// Initialize Matrix application service (if enabled)
+	var matrixHandler *matrix.Handler
+	if cfg.IsMessengerEnabled("matrix") {
+		matrixClient, err := matrix.NewClient(cfg.MatrixHomeserverURL, cfg.MatrixASToken, cfg.MatrixBotUserID)
+		if err != nil {
+			fatal("Failed to initialize Matrix client", err)
+		}
+
+		matrixHandler = matrix.NewHandler(cfg.MatrixHSToken, cfg.MatrixBotUserID, processMessageUseCase, matrixClient)
+		matrixHandler.SetDeduplicator(eventDedupUseCase)
+		matrixHandler.SetOutbox(replyOutboxUseCase)
+		replyOutboxUseCase.RegisterReplier("matrix", matrixHandler)
+		if messageQueue != nil {
+			matrixHandler.SetQueue(messageQueue)
+			messageQueue.RegisterReplier("matrix", replyOutboxUseCase)
+		}
+		budgetAlertUseCase.RegisterNotifier("matrix", matrixClient)
+		authUseCase.RegisterNotifier("matrix", matrixClient)
+		userExportUseCase.RegisterNotifier("matrix", matrixClient)
+		replyOutboxUseCase.RegisterNotifier("matrix", matrixClient)
+	}
+
+	// Send daily digests once every messenger's notifier is registered; digests
+	// are due on the hour, so checking every few minutes sends them on time
+	dailyDigestUseCase := usecase.NewDailyDigestUseCase(notificationPreferencesRepo, userRepo, generateReportUseCase, budgetManagementUseCase,
+		notificationDispatcher.For(domain.NotificationDailyDigest))
+	dailyDigestUseCase.SetTimezoneLocator(timezoneUseCase)
+	schedulers.Go(context.Background(), "daily_digests", func(ctx context.Context) { dailyDigestUseCase.RunScheduler(ctx, 5*time.Minute) })
+
+	// Send weekly reports on Monday mornings; pushes go through the outbox, which retries failed ones
+	weeklyReportUseCase := usecase.NewWeeklyReportUseCase(notificationPreferencesRepo, userRepo, generateReportUseCase,
+		notificationDispatcher.For(domain.NotificationWeeklyReport))
+	weeklyReportUseCase.SetReportLinker(generateReportLinkUseCase)
+	weeklyReportUseCase.SetTimezoneLocator(timezoneUseCase)
+	schedulers.Go(context.Background(), "weekly_reports", func(ctx context.Context) { weeklyReportUseCase.RunScheduler(ctx, 5*time.Minute) })
+
+	// Remind users who opted in after days without an expense, outside their quiet hours
+	expenseReminderUseCase := usecase.NewExpenseReminderUseCase(notificationPreferencesRepo, userRepo, expenseRepo,
+		notificationDispatcher.For(domain.NotificationExpenseReminders))
+	expenseReminderUseCase.SetTimezoneLocator(timezoneUseCase)
+	schedulers.Go(context.Background(), "expense_reminders", func(ctx context.Context) { expenseReminderUseCase.RunScheduler(ctx, 15*time.Minute) })
+
+	// Alert users to unusual spending shortly after it is recorded; the dispatcher
+	// holds alerts due during quiet hours
+	spendingAnomalyUseCase := usecase.NewSpendingAnomalyUseCase(notificationPreferencesRepo, userRepo, expenseRepo, categoryRepo,
+		notificationDispatcher.For(domain.NotificationSpendingAnomalies))
+	schedulers.Go(context.Background(), "spending_anomalies", func(ctx context.Context) { spendingAnomalyUseCase.RunScheduler(ctx, 15*time.Minute) })
+
+	// Initialize inbound email for forwarded receipts (optional); replies go
+	// out over SMTP, so it needs email configured too
+	if cfg.MailgunSigningKey != "" {
+		if emailAddressUseCase == nil {
+			slog.Warn("MAILGUN_SIGNING_KEY is set but SMTP is not configured; inbound email disabled")
+		} else {
+			inboundHandler := email.NewInboundHandler(cfg.MailgunSigningKey, emailAddressUseCase, processMessageUseCase, emailSender)
+			inboundHandler.SetDeduplicator(eventDedupUseCase)
+			inboundHandler.SetOutbox(replyOutboxUseCase)
+			replyOutboxUseCase.RegisterReplier("email", inboundHandler)
+			if messageQueue != nil {
+				inboundHandler.SetQueue(messageQueue)
+				messageQueue.RegisterReplier("email", replyOutboxUseCase)
+			}
+			mux.HandleFunc("POST /webhook/email/mailgun", inboundHandler.HandleMailgun)
+			slog.Info("Inbound email enabled", "path", "/webhook/email/mailgun", "address", cfg.InboundEmailAddress)
+		}
 	}
 
 	// Add LINE webhook endpoint
 	if lineHandler != nil {
 		mux.HandleFunc("/webhook/line", lineHandler.HandleWebhook)
-		log.Printf("LINE webhook enabled at /webhook/line")
+		slog.Info("LINE webhook enabled", "path", "/webhook/line")
 	}
 
 	// Add Terminal messenger endpoints
 	if terminalHandler != nil {
-		mux.HandleFunc("/api/chat/terminal", terminalHandler.HandleMessage)
-		mux.HandleFunc("/api/chat/terminal/user", terminalHandler.GetUserInfo)
-		log.Printf("Terminal messenger enabled at /api/chat/terminal")
+		httpAdapter.HandleAPI(mux, "/chat/terminal", terminalHandler.HandleMessage)
+		httpAdapter.HandleAPI(mux, "/chat/terminal/user", terminalHandler.GetUserInfo)
+		slog.Info("Terminal messenger enabled", "path", "/api/v1/chat/terminal")
 	}
 
 	// Add Telegram webhook endpoint (if configured)
 	if telegramHandler != nil {
 		mux.HandleFunc("/webhook/telegram", telegramHandler.HandleWebhook)
-		log.Printf("Telegram webhook enabled at /webhook/telegram")
+		slog.Info("Telegram webhook enabled", "path", "/webhook/telegram")
 	}
 
 	// Add Discord webhook endpoint (if configured)
 	if discordHandler != nil {
 		mux.HandleFunc("/webhook/discord", discordHandler.HandleWebhook)
-		log.Printf("Discord webhook enabled at /webhook/discord")
+		slog.Info("Discord webhook enabled", "path", "/webhook/discord")
 	}
 
 	// Add WhatsApp webhook endpoint (if configured)
 	if whatsappHandler != nil {
 		// WhatsApp uses GET for verification and POST for events
 		mux.HandleFunc("/webhook/whatsapp", whatsappHandler.HandleWebhook)
-		log.Printf("WhatsApp webhook enabled at /webhook/whatsapp")
+		slog.Info("WhatsApp webhook enabled", "path", "/webhook/whatsapp")
 	}
 
 	// Add Slack webhook endpoint (if configured)
 	if slackHandler != nil {
 		mux.HandleFunc("/webhook/slack", slackHandler.HandleWebhook)
-		log.Printf("Slack webhook enabled at /webhook/slack")
+		mux.HandleFunc("/webhook/slack/commands", slackHandler.HandleSlashCommand)
+		mux.HandleFunc("/webhook/slack/interactivity", slackHandler.HandleInteractivity)
+		slog.Info("Slack webhook enabled", "path", "/webhook/slack", "commands", "/webhook/slack/commands", "interactivity", "/webhook/slack/interactivity")
 	}
 
 	// Add Microsoft Teams webhook endpoint (if configured)
 	if teamsHandler != nil {
 		mux.HandleFunc("/webhook/teams", teamsHandler.HandleWebhook)
-		log.Printf("Microsoft Teams webhook enabled at /webhook/teams")
+		slog.Info("Microsoft Teams webhook enabled", "path", "/webhook/teams")
 	}
 
+	// Add Matrix application service endpoint (if configured); the registration's
+	// url is the server's /webhook/matrix, under which homeservers push transactions
+	if matrixHandler != nil {
+		mux.HandleFunc("PUT /webhook/matrix/_matrix/app/v1/transactions/{txnId}", matrixHandler.HandleTransaction)
+		slog.Info("Matrix application service enabled", "path", "/webhook/matrix")
+	}
+
+	// Remote agents reach the expense tools with the user's bearer token
+	mux.HandleFunc("POST /mcp", httpAdapter.NewMCPHandler(mcpServer).Serve)
+
+	// Kubernetes probes: /livez while the process serves, /readyz while the
+	// database and schedulers do too; an unreachable AI provider only degrades
+	// readiness, as expenses can still be parsed without it
+	healthUseCase := usecase.NewHealthUseCase()
+	healthUseCase.AddCheck(usecase.HealthCheck{Name: "database", Check: dbPing})
+	if pinger, ok := aiService.(ai.Pinger); ok {
+		healthUseCase.AddCheck(usecase.HealthCheck{Name: "ai_provider", Check: pinger.Ping, Optional: true, CacheFor: usecase.DefaultAIReachabilityTTL})
+	}
+	healthUseCase.AddCheck(usecase.HealthCheck{Name: "schedulers", Check: schedulers.Check})
+	healthHandler := httpAdapter.NewHealthHandler(healthUseCase)
+	mux.HandleFunc("GET /livez", healthHandler.Livez)
+	mux.HandleFunc("GET /readyz", healthHandler.Readyz)
+
+	// Reload the log level, rate limits, AI model and cost caps on SIGHUP and
+	// while polling CONFIG_RELOAD_FILE, without a restart
+	reloader := config.NewReloader(cfg)
+	reloader.OnReload(func(next *config.Config) {
+		if level, err := logging.ParseLevel(next.LogLevel); err == nil {
+			logLevel.Set(level)
+		}
+		rateLimitUseCase.SetLimits(next.RateLimitPerMinute, next.RateLimitBurst)
+		costGuardUseCase.SetDefaultCaps(
+			domain.AICostCap{DailyUSD: next.AIDailyCapUSD, MonthlyUSD: next.AIMonthlyCapUSD},
+			domain.AICostCap{DailyUSD: next.AIUserDailyCapUSD, MonthlyUSD: next.AIUserMonthlyCapUSD},
+		)
+		if configurable, ok := aiService.(ai.ModelConfigurable); ok {
+			configurable.SetModel(next.AIModel)
+		} else if next.AIModel != cfg.AIModel {
+			slog.Warn("AI_MODEL needs a restart to apply with AI_PROVIDERS", "model", next.AIModel)
+		}
+	})
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	go reloader.Run(context.Background(), reloadSignals)
+	configHandler := httpAdapter.NewConfigHandler(reloader)
+	httpAdapter.HandleAPI(mux, "GET /admin/config", apiKeyHandler.RequireScope(domain.APIKeyScopeAdmin, configHandler.GetConfig))
+
 	// TODO: Add more use cases and handlers:
 	// - UpdateExpenseUseCase
 	// - DeleteExpenseUseCase
@@ -329,35 +1208,205 @@ func main() {
 	// - GenerateReportUseCase
 	// - MetricsAggregatorUseCase
 
-	// Wrap mux with CORS middleware for dashboard
-	corsHandler := withCORS(mux)
+	// Answer API callers in the language they ask for, about the workspace they name
+	localizedHandler := httpAdapter.LocaleMiddleware(httpAdapter.WorkspaceMiddleware(mux))
+
+	// Identify API users from their access tokens
+	authenticatedHandler := httpAdapter.AuthMiddleware(authUseCase, authConfig(cfg.AuthRequired), localizedHandler)
+
+	// Wrap with CORS middleware for dashboard
+	corsHandler := httpAdapter.CORSMiddleware(httpAdapter.CORSConfig{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowCredentials: cfg.CORSAllowCredentials,
+		PublicPaths:      cfg.CORSPublicPaths,
+	}, authenticatedHandler)
 
 	// Wrap with logging middleware
 	loggingHandler := httpAdapter.LoggingMiddleware(corsHandler)
 
+	// Serve the core expense operations over gRPC to internal services on their own port
+	if cfg.GRPCPort != "" {
+		listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			fatal("Failed to listen for gRPC", err)
+		}
+		grpcServer := grpcAdapter.NewServer(grpcAdapter.NewExpenseService(createExpenseUseCase, getExpensesUseCase, generateReportUseCase, parseConversationUseCase), apiKeyUseCase)
+		slog.Info("gRPC expense service enabled", "addr", listener.Addr().String())
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				fatal("gRPC server failed", err)
+			}
+		}()
+	}
+
 	// Start server
 	addr := ":" + cfg.ServerPort
-	log.Printf("Starting server on %s", addr)
-	fmt.Printf("SERVER STARTED ON %s\n", addr)
+	slog.Info("Starting server", "addr", addr)
 	if err := http.ListenAndServe(addr, loggingHandler); err != nil {
-		log.Fatalf("Server failed: %v", err)
+		fatal("Server failed", err)
+	}
+}
+
+// prepareSchema applies pending migrations, or with autoMigrate off checks
+// that "server migrate up" has already brought the schema up to date
+func prepareSchema(db *sql.DB, dbType string, autoMigrate bool) error {
+	if autoMigrate {
+		return migrations.RunMigrations(db, dbType)
 	}
+
+	m, err := migrations.New(db, dbType)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	version, dirty, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+	latest, err := migrations.Latest()
+	if err != nil {
+		return err
+	}
+	if dirty || version != latest {
+		return fmt.Errorf("schema is at version %d (dirty=%v) but this build expects %d: run \"server migrate up\"", version, dirty, latest)
+	}
+	return nil
 }
 
-// withCORS wraps HTTP handler with CORS headers
-func withCORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, Authorization")
-		w.Header().Set("Access-Control-Max-Age", "3600")
+// runMigrate implements "server migrate [up | down [n] | goto <version> |
+// force <version> | version]", defaulting to up
+func runMigrate(cfg *config.Config, args []string) error {
+	var db *sql.DB
+	var err error
+	dbType := cfg.DatabaseDriver()
+	switch dbType {
+	case "mysql":
+		db, err = mysqlRepo.Connect(cfg.DatabaseURL)
+	case "postgres":
+		db, err = postgresRepo.Connect(cfg.DatabaseURL)
+	default:
+		db, err = sqliteRepo.Connect(cfg.DatabasePath)
+	}
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := migrations.New(db, dbType)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
 
-		// Handle OPTIONS requests
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+	number := func(defaultVal int) (int, error) {
+		if len(args) < 2 {
+			if defaultVal < 0 {
+				return 0, fmt.Errorf("migrate %s needs a version", command)
+			}
+			return defaultVal, nil
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number %q", args[1])
 		}
+		return n, nil
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	switch command {
+	case "up":
+		err = m.Up()
+	case "down":
+		var steps int
+		if steps, err = number(1); err == nil {
+			err = m.Down(steps)
+		}
+	case "goto":
+		var version int
+		if version, err = number(-1); err == nil {
+			err = m.Goto(uint(version))
+		}
+	case "force":
+		var version int
+		if version, err = number(-1); err == nil {
+			err = m.Force(version)
+		}
+	case "version":
+	default:
+		return fmt.Errorf("unknown migrate command %q (want up, down, goto, force or version)", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, dirty, ok, err := m.Version()
+	if err != nil {
+		return err
+	}
+	latest, err := migrations.Latest()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		slog.Info("No migrations applied", "latest", latest)
+		return nil
+	}
+	slog.Info("Schema version", "version", version, "dirty", dirty, "latest", latest)
+	return nil
+}
+
+// runReencrypt implements "server reencrypt": it encrypts sensitive columns
+// written before encryption was enabled and moves rows sealed with a retired
+// key onto the primary one, so the old key can then be dropped
+func runReencrypt(ctx context.Context, cfg *config.Config, cipher domain.FieldCipher) error {
+	if cipher == nil {
+		return fmt.Errorf("ENCRYPTION_KEYS must be set to re-encrypt")
+	}
+
+	var rewritten map[string]int
+	switch cfg.DatabaseDriver() {
+	case "mysql":
+		db, err := mysqlRepo.OpenDB(cfg.DatabaseURL)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if rewritten, err = mysqlRepo.ReencryptSensitiveColumns(ctx, db, cipher); err != nil {
+			return err
+		}
+	case "postgres":
+		db, err := postgresRepo.OpenDB(cfg.DatabaseURL)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if rewritten, err = postgresRepo.ReencryptSensitiveColumns(ctx, db, cipher); err != nil {
+			return err
+		}
+	default:
+		db, err := sqliteRepo.OpenDB(cfg.DatabasePath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if rewritten, err = sqliteRepo.ReencryptSensitiveColumns(ctx, db, cipher); err != nil {
+			return err
+		}
+	}
+
+	for table, count := range rewritten {
+		slog.InfoContext(ctx, "Re-encrypted rows", "table", table, "rows", count)
+	}
+	slog.InfoContext(ctx, "Re-encryption complete")
+	return nil
+}
+
+// fatal logs a startup failure and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
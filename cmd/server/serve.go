@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	httpAdapter "github.com/riverlin/aiexpense/internal/adapter/http"
+	"github.com/riverlin/aiexpense/internal/adapter/messenger/terminal"
+)
+
+// commands are what the server can be asked to do besides serving
+var commands = []string{"migrate", "reencrypt", "mcp"}
+
+// serveOptions are the flags of "server serve", the command run when no
+// other is named
+type serveOptions struct {
+	// repl chats with the message processor on the terminal instead of
+	// listening for HTTP requests
+	repl bool
+	// user is who the REPL chats as; a new user for the session when empty
+	user string
+}
+
+// parseServeOptions parses the arguments after the program name. serving is
+// false when they name another command, such as migrate.
+func parseServeOptions(args []string, stderr io.Writer) (opts serveOptions, serving bool, err error) {
+	if len(args) > 0 && args[0] == "serve" {
+		args = args[1:]
+	} else if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if !slices.Contains(commands, args[0]) {
+			return opts, false, fmt.Errorf("unknown command %q; use serve, %s", args[0], strings.Join(commands, ", "))
+		}
+		return opts, false, nil
+	}
+
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.BoolVar(&opts.repl, "repl", false, "chat with the bot on the terminal instead of serving HTTP")
+	flags.StringVar(&opts.user, "user", "", "user the REPL chats as; a new one for the session when empty")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: server [serve] [-repl [-user ID]]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return opts, true, err
+	}
+	if flags.NArg() > 0 {
+		return opts, true, fmt.Errorf("unexpected arguments %q", flags.Args())
+	}
+	if opts.user != "" && !opts.repl {
+		return opts, true, fmt.Errorf("-user only applies with -repl")
+	}
+	return opts, true, nil
+}
+
+// runREPL chats with the message processor on the terminal, as user or a new
+// user for the session, until /quit or the end of input
+func runREPL(ctx context.Context, processor terminal.MessageProcessor, user string) error {
+	repl := terminal.NewREPL(processor, user)
+	repl.SetColor(terminal.ColorEnabled(os.Stdout))
+	return repl.Run(ctx, os.Stdin, os.Stdout)
+}
+
+// authConfig is how the API identifies its callers. Only the shared report
+// summary, which checks its link token itself, is public among the report
+// routes; the others act for the signed-in user.
+func authConfig(authRequired bool) httpAdapter.AuthConfig {
+	return httpAdapter.AuthConfig{
+		AllowAnonymous: !authRequired,
+		PublicPaths: []string{
+			"/health", "/livez", "/readyz", "/api/auth/", "/api/users/auto-signup", "/api/chat/terminal",
+			"/api/reports/summary", "/api/policies/", "/api/currencies/", "/api/exports/", "/webhook/", "/r/", "/charts/",
+		},
+		ReportPaths: []string{"/api/reports/"},
+		AdminPaths: []string{
+			"/api/admin/", "/api/metrics/", "/api/pricing", "/api/prompts", "/api/ai-costs/", "/api/exchange-rates/refresh",
+		},
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	httpAdapter "github.com/riverlin/aiexpense/internal/adapter/http"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+func TestParseServeOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    serveOptions
+		serving bool
+		wantErr string
+	}{
+		{name: "No command", args: nil, serving: true},
+		{name: "Serve", args: []string{"serve"}, serving: true},
+		{name: "REPL", args: []string{"serve", "-repl"}, want: serveOptions{repl: true}, serving: true},
+		{name: "REPL as a user", args: []string{"serve", "--repl", "--user", "line_u123"}, want: serveOptions{repl: true, user: "line_u123"}, serving: true},
+		{name: "Flags without serve", args: []string{"-repl"}, want: serveOptions{repl: true}, serving: true},
+		{name: "Other command", args: []string{"migrate", "up"}, serving: false},
+		{name: "Unknown command", args: []string{"repl"}, wantErr: `unknown command "repl"`},
+		{name: "User without REPL", args: []string{"serve", "-user", "u1"}, serving: true, wantErr: "-user only applies with -repl"},
+		{name: "Extra arguments", args: []string{"serve", "-repl", "u1"}, serving: true, wantErr: "unexpected arguments"},
+		{name: "Unknown flag", args: []string{"serve", "-port", "80"}, serving: true, wantErr: "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, serving, err := parseServeOptions(tt.args, &bytes.Buffer{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error with %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want || serving != tt.serving {
+				t.Errorf("expected %+v serving=%v, got %+v serving=%v, %v", tt.want, tt.serving, got, serving, err)
+			}
+		})
+	}
+
+	var usage bytes.Buffer
+	if _, _, err := parseServeOptions([]string{"serve", "-h"}, &usage); !errors.Is(err, flag.ErrHelp) || !strings.Contains(usage.String(), "Usage: server [serve] [-repl [-user ID]]") {
+		t.Errorf("expected help, got %v %q", err, usage.String())
+	}
+}
+
+func TestAuthConfig(t *testing.T) {
+	authUC := usecase.NewAuthUseCase(usecase.NewMockUserRepository(), "test-secret")
+
+	tests := []struct {
+		name         string
+		authRequired bool
+		method, path string
+		apiKey       string
+		want         int
+	}{
+		{name: "Report generation", authRequired: true, method: "POST", path: "/api/reports/generate?user_id=victim", want: http.StatusUnauthorized},
+		{name: "Report comparison", authRequired: true, method: "GET", path: "/api/reports/compare?user_id=victim", want: http.StatusUnauthorized},
+		{name: "Category trends", authRequired: true, method: "GET", path: "/api/v1/reports/category-trends?user_id=victim", want: http.StatusUnauthorized},
+		{name: "Shared report summary", authRequired: true, method: "GET", path: "/api/reports/summary?token=link", want: http.StatusNoContent},
+		{name: "API key on a user route", authRequired: true, method: "GET", path: "/api/expenses?user_id=victim", apiKey: "anything", want: http.StatusUnauthorized},
+		{name: "API key on an admin route", authRequired: true, method: "GET", path: "/api/admin/interactions", apiKey: "anything", want: http.StatusNoContent},
+		{name: "Anonymous development mode", authRequired: false, method: "POST", path: "/api/reports/generate?user_id=dev", want: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) })
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+			httpAdapter.AuthMiddleware(authUC, authConfig(tt.authRequired), next).ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
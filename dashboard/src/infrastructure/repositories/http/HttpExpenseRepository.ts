@@ -196,8 +196,7 @@ export class HttpExpenseRepository implements ExpenseRepository {
 
   async updateExpense(token: string, expense: Expense): Promise<void> {
     try {
-      // Backend expects ID in the body for PUT /api/expenses, not as a path parameter
-      const url = `${this.baseURL}/api/expenses?token=${token}`;
+      const url = `${this.baseURL}/api/expenses/${encodeURIComponent(expense.id)}?token=${token}`;
       
       const payload: any = {
         id: expense.id,
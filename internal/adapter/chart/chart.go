@@ -0,0 +1,180 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ChartRenderer = (*Renderer)(nil)
+
+// Chart image layout, 4:3 as messengers show it
+const (
+	width     = 800
+	height    = 600
+	margin    = 40
+	pieRadius = 260
+	samples   = 3 // Samples per pixel along each axis, smoothing edges
+)
+
+// palette colors the slices and bars in order, matching domain.ChartLegendMarks
+var palette = [domain.MaxChartValues]color.RGBA{
+	{0xE5, 0x39, 0x35, 0xFF}, // 🟥
+	{0xFB, 0x8C, 0x00, 0xFF}, // 🟧
+	{0xFD, 0xD8, 0x35, 0xFF}, // 🟨
+	{0x43, 0xA0, 0x47, 0xFF}, // 🟩
+	{0x1E, 0x88, 0xE5, 0xFF}, // 🟦
+	{0x8E, 0x24, 0xAA, 0xFF}, // 🟪
+	{0x6D, 0x4C, 0x41, 0xFF}, // 🟫
+	{0x42, 0x42, 0x42, 0xFF}, // ⬛
+}
+
+var (
+	background = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	gridLine   = color.RGBA{0xE0, 0xE0, 0xE0, 0xFF}
+	axisLine   = color.RGBA{0x9E, 0x9E, 0x9E, 0xFF}
+)
+
+// Renderer draws pie and bar charts as PNG images, without labels: the text
+// beside a chart is its legend
+type Renderer struct{}
+
+// NewRenderer creates a new chart renderer
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+// Render draws the chart as a PNG image
+func (r *Renderer) Render(chart *domain.Chart) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), background)
+
+	switch chart.Type {
+	case domain.ChartPie:
+		drawPie(img, chart.Values)
+	case domain.ChartBar:
+		drawBars(img, chart.Values, chart.Previous)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", domain.ErrInvalidChart, chart.Type)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawPie draws each value as a slice, clockwise from twelve o'clock. Pixels
+// on an edge are blended from samples within them.
+func drawPie(img *image.RGBA, values []float64) {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	if total == 0 {
+		return
+	}
+	// Where each slice ends, as a fraction of the circle
+	ends := make([]float64, len(values))
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		ends[i] = sum / total
+	}
+
+	cx, cy := float64(width)/2, float64(height)/2
+	sliceAt := func(x, y float64) int {
+		dx, dy := x-cx, y-cy
+		if dx*dx+dy*dy > pieRadius*pieRadius {
+			return -1
+		}
+		// Clockwise from twelve o'clock, with y growing downwards
+		turn := math.Atan2(dx, -dy) / (2 * math.Pi)
+		if turn < 0 {
+			turn++
+		}
+		for i, end := range ends {
+			if turn < end {
+				return i
+			}
+		}
+		return len(ends) - 1
+	}
+
+	for py := int(cy) - pieRadius - 1; py <= int(cy)+pieRadius+1; py++ {
+		for px := int(cx) - pieRadius - 1; px <= int(cx)+pieRadius+1; px++ {
+			var r, g, b float64
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					c := background
+					if i := sliceAt(float64(px)+(float64(sx)+0.5)/samples, float64(py)+(float64(sy)+0.5)/samples); i >= 0 {
+						c = palette[i%len(palette)]
+					}
+					r, g, b = r+float64(c.R), g+float64(c.G), b+float64(c.B)
+				}
+			}
+			n := float64(samples * samples)
+			img.SetRGBA(px, py, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), 0xFF})
+		}
+	}
+}
+
+// drawBars draws a bar per value, scaled to the largest, over quarter grid
+// lines. With previous amounts, each bar has the earlier one in a lighter
+// shade on its left.
+func drawBars(img *image.RGBA, values, previous []float64) {
+	top, bottom := margin, height-margin
+	left, right := margin, width-margin
+	for i := 1; i <= 4; i++ {
+		y := bottom - (bottom-top)*i/4
+		fill(img, image.Rect(left, y, right, y+1), gridLine)
+	}
+
+	highest := 0.0
+	for _, v := range append(append([]float64(nil), values...), previous...) {
+		highest = math.Max(highest, v)
+	}
+	if len(values) > 0 && highest > 0 {
+		group := (right - left) / len(values)
+		bars := 1
+		if len(previous) > 0 {
+			bars = 2
+		}
+		// A third of each group is the gap between groups
+		barWidth := group * 2 / 3 / bars
+		barHeight := func(v float64) int {
+			return int(math.Round(v / highest * float64(bottom-top)))
+		}
+		for i, v := range values {
+			x := left + group*i + group/6
+			c := palette[i%len(palette)]
+			if len(previous) > 0 {
+				fill(img, image.Rect(x, bottom-barHeight(previous[i]), x+barWidth, bottom), lighten(c))
+				x += barWidth
+			}
+			fill(img, image.Rect(x, bottom-barHeight(v), x+barWidth, bottom), c)
+		}
+	}
+	fill(img, image.Rect(left, bottom, right, bottom+2), axisLine)
+}
+
+// lighten blends c halfway to white
+func lighten(c color.RGBA) color.RGBA {
+	return color.RGBA{c.R/2 + 0x80, c.G/2 + 0x80, c.B/2 + 0x80, 0xFF}
+}
+
+// fill paints the rectangle r
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
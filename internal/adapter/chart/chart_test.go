@@ -0,0 +1,70 @@
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func render(t *testing.T, chart *domain.Chart) image.Image {
+	t.Helper()
+	data, err := NewRenderer().Render(chart)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("rendered chart is not a PNG: %v", err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(width, height) {
+		t.Fatalf("chart size = %v, want %dx%d", got, width, height)
+	}
+	return img
+}
+
+func assertColor(t *testing.T, img image.Image, x, y int, want color.RGBA) {
+	t.Helper()
+	if got := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA); got != want {
+		t.Errorf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+	}
+}
+
+func TestRenderPie(t *testing.T) {
+	// Three quarters, then a quarter, clockwise from twelve o'clock
+	img := render(t, &domain.Chart{Type: domain.ChartPie, Values: []float64{300, 100}})
+	cx, cy := width/2, height/2
+	assertColor(t, img, cx+100, cy+10, palette[0])
+	assertColor(t, img, cx-10, cy+100, palette[0])
+	assertColor(t, img, cx-100, cy-10, palette[1])
+	assertColor(t, img, cx+pieRadius+10, cy, background)
+	assertColor(t, img, 5, 5, background)
+}
+
+func TestRenderBars(t *testing.T) {
+	img := render(t, &domain.Chart{Type: domain.ChartBar, Values: []float64{200, 50}, Previous: []float64{100, 0}})
+	group := (width - 2*margin) / 2
+	barWidth := group * 2 / 3 / 2
+	bottom := height - margin
+	x := margin + group/6
+
+	// The first category's earlier amount, half as high as its current one
+	assertColor(t, img, x+barWidth/2, bottom-10, lighten(palette[0]))
+	assertColor(t, img, x+barWidth/2, margin+(bottom-margin)/4+5, background)
+	assertColor(t, img, x+barWidth*3/2, margin+5, palette[0])
+	// The second category's quarter-high bar, with nothing spent before
+	x += group
+	assertColor(t, img, x+barWidth*3/2, bottom-10, palette[1])
+	assertColor(t, img, x+barWidth*3/2, bottom-(bottom-margin)/2+5, background)
+	assertColor(t, img, x+barWidth/2, bottom-10, background)
+}
+
+func TestRenderUnknownType(t *testing.T) {
+	if _, err := NewRenderer().Render(&domain.Chart{Type: "line", Values: []float64{1}}); !errors.Is(err, domain.ErrInvalidChart) {
+		t.Errorf("Render() error = %v, want ErrInvalidChart", err)
+	}
+}
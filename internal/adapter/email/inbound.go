@@ -0,0 +1,352 @@
+package email
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/logging"
+)
+
+const (
+	// maxInboundSize caps the inbound webhook body, attachments included
+	maxInboundSize = 25 << 20
+	// maxReceiptImageSize caps the receipt photo read from an attachment
+	maxReceiptImageSize = 10 << 20
+	// maxInboundText caps the receipt text handed to the parser
+	maxInboundText = 4000
+	// maxSignatureAge is how old a signed webhook may be, against replays
+	maxSignatureAge = 15 * time.Minute
+)
+
+// MessageProcessor defines the interface for processing messages
+type MessageProcessor interface {
+	Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error)
+}
+
+// SenderResolver returns the user an email address is bound to, or
+// domain.ErrNotFound for addresses nobody has verified
+type SenderResolver interface {
+	Resolve(ctx context.Context, address string) (string, error)
+}
+
+// InboundHandler records the receipts users forward to the inbound address.
+// Mailgun receives the email and posts it here (a route with a forward()
+// action); the sender must have bound their address beforehand. The receipt
+// text, or a photo of it attached, is parsed like a chat message and the
+// result emailed back.
+type InboundHandler struct {
+	signingKey string
+	resolver   SenderResolver
+	useCase    MessageProcessor
+	sender     domain.EmailSender
+	dedup      domain.EventDeduplicator
+	queue      domain.MessageQueue
+	outbox     domain.MessageReplier
+	now        func() time.Time
+}
+
+// NewInboundHandler creates a new inbound email handler; signingKey is the
+// Mailgun webhook signing key
+func NewInboundHandler(signingKey string, resolver SenderResolver, useCase MessageProcessor, sender domain.EmailSender) *InboundHandler {
+	return &InboundHandler{
+		signingKey: signingKey,
+		resolver:   resolver,
+		useCase:    useCase,
+		sender:     sender,
+		now:        time.Now,
+	}
+}
+
+// SetDeduplicator skips emails that were already handled, e.g. retried deliveries
+func (h *InboundHandler) SetDeduplicator(dedup domain.EventDeduplicator) {
+	h.dedup = dedup
+}
+
+// SetQueue leaves messages to the queue's workers, which answer through Reply
+func (h *InboundHandler) SetQueue(queue domain.MessageQueue) {
+	h.queue = queue
+}
+
+// SetOutbox sends replies through an outbox that records them and retries
+// the ones that couldn't be delivered
+func (h *InboundHandler) SetOutbox(outbox domain.MessageReplier) {
+	h.outbox = outbox
+}
+
+// enqueue hands the message to the queue, reporting false when it has to be
+// processed here
+func (h *InboundHandler) enqueue(ctx context.Context, msg *domain.UserMessage) bool {
+	if h.queue == nil {
+		return false
+	}
+	if err := h.queue.Enqueue(ctx, msg); err != nil {
+		slog.WarnContext(ctx, "Failed to queue inbound email, processing it inline", "error", err)
+		return false
+	}
+	return true
+}
+
+// Reply answers a forwarded receipt by email, to the address it came from
+func (h *InboundHandler) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	to, _ := msg.Metadata["from"].(string)
+	if to == "" || h.sender == nil {
+		return nil
+	}
+	subject, _ := msg.Metadata["subject"].(string)
+	if subject == "" {
+		subject = "Your forwarded receipt"
+	}
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+	return h.sender.Send(ctx, &domain.EmailMessage{
+		To:       to,
+		Subject:  subject,
+		TextBody: resp.Text,
+		HTMLBody: "<p>" + strings.ReplaceAll(html.EscapeString(resp.Text), "\n", "<br>") + "</p>",
+	})
+}
+
+// HandleMailgun handles an email Mailgun forwards to /webhook/email/mailgun.
+// Emails from addresses nobody has bound are dropped with a 200, so Mailgun
+// doesn't retry them and strangers get no answer.
+func (h *InboundHandler) HandleMailgun(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxInboundSize)
+	if err := r.ParseMultipartForm(maxReceiptImageSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		http.Error(w, "failed to parse email", http.StatusBadRequest)
+		return
+	}
+	if r.MultipartForm != nil {
+		defer r.MultipartForm.RemoveAll()
+	}
+	ctx := r.Context()
+
+	if !h.verifySignature(r.FormValue("timestamp"), r.FormValue("token"), r.FormValue("signature")) {
+		slog.WarnContext(ctx, "Mailgun signature verification failed")
+		http.Error(w, "signature verification failed", http.StatusUnauthorized)
+		return
+	}
+
+	from, err := mail.ParseAddress(r.FormValue("from"))
+	if err != nil {
+		from, err = mail.ParseAddress(r.FormValue("sender"))
+	}
+	if err != nil {
+		slog.InfoContext(ctx, "Inbound email without a sender address")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	address := strings.ToLower(from.Address)
+
+	headers := parseMessageHeaders(r.FormValue("message-headers"))
+	if !authenticated(r.Form, headers) {
+		slog.WarnContext(ctx, "Inbound email failed SPF and DKIM, dropped")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	// Out-of-office and other automatic replies would answer ours forever
+	if auto := headers["auto-submitted"]; auto != "" && !strings.EqualFold(auto, "no") {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	userID, err := h.resolver.Resolve(ctx, address)
+	if errors.Is(err, domain.ErrNotFound) {
+		slog.InfoContext(ctx, "Inbound email from an unbound address, dropped")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to resolve inbound email sender", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	ctx = logging.WithUser(ctx, userID, "email")
+
+	messageID := r.FormValue("Message-Id")
+	if messageID == "" {
+		messageID = headers["message-id"]
+	}
+	if messageID != "" && h.dedup != nil && h.dedup.Seen(ctx, "email", messageID) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	subject := r.FormValue("subject")
+	body := r.FormValue("body-plain")
+	if strings.TrimSpace(body) == "" {
+		body = htmlToText(r.FormValue("body-html"))
+	}
+	content := strings.TrimSpace(subject + "\n\n" + strings.TrimSpace(body))
+	if runes := []rune(content); len(runes) > maxInboundText {
+		content = string(runes[:maxInboundText])
+	}
+
+	var attachments []*domain.Attachment
+	if image := receiptImage(ctx, r.MultipartForm); image != nil {
+		attachments = append(attachments, image)
+	}
+
+	userMsg := &domain.UserMessage{
+		UserID:      userID,
+		Content:     content,
+		Source:      "email",
+		Attachments: attachments,
+		Timestamp:   time.Now(),
+		Metadata: map[string]interface{}{
+			"from":       address,
+			"subject":    subject,
+			"message_id": messageID,
+		},
+	}
+
+	// Mailgun waits for the answer, so the receipt is parsed afterwards
+	go func(ctx context.Context) {
+		if h.enqueue(ctx, userMsg) {
+			return
+		}
+		resp, err := h.useCase.Execute(ctx, userMsg)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to handle inbound email", "error", err)
+			return
+		}
+		if resp.Text == "" {
+			return
+		}
+		if h.outbox != nil {
+			err = h.outbox.Reply(ctx, userMsg, resp)
+		} else {
+			err = h.Reply(ctx, userMsg, resp)
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to send inbound email reply", "error", err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// verifySignature checks Mailgun's signature, an HMAC-SHA256 of the timestamp
+// and token keyed with the webhook signing key, and that it is recent
+func (h *InboundHandler) verifySignature(timestamp, token, signature string) bool {
+	if h.signingKey == "" || timestamp == "" || token == "" || signature == "" {
+		return false
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := h.now().Sub(time.Unix(seconds, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.signingKey))
+	mac.Write([]byte(timestamp + token))
+	return hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+// authenticated reports whether the sender's domain vouched for the email
+// through SPF or DKIM, as checked by Mailgun and posted both as fields and
+// headers; without the checks' results the email is taken as is
+func authenticated(form map[string][]string, headers map[string]string) bool {
+	spf, dkim := first(form["X-Mailgun-Spf"]), first(form["X-Mailgun-Dkim-Check-Result"])
+	if spf == "" {
+		spf = headers["x-mailgun-spf"]
+	}
+	if dkim == "" {
+		dkim = headers["x-mailgun-dkim-check-result"]
+	}
+	if spf == "" && dkim == "" {
+		return true
+	}
+	return strings.EqualFold(spf, "Pass") || strings.EqualFold(dkim, "Pass")
+}
+
+// parseMessageHeaders reads Mailgun's message-headers field, a JSON list of
+// [name, value] pairs, into a map keyed by lowercased name
+func parseMessageHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	var pairs [][]string
+	if err := json.Unmarshal([]byte(raw), &pairs); err != nil {
+		return headers
+	}
+	for _, pair := range pairs {
+		if len(pair) == 2 {
+			headers[strings.ToLower(pair[0])] = pair[1]
+		}
+	}
+	return headers
+}
+
+// receiptImage returns the first image attached to the email, if any
+func receiptImage(ctx context.Context, form *multipart.Form) *domain.Attachment {
+	if form == nil {
+		return nil
+	}
+	for i := 1; ; i++ {
+		files := form.File["attachment-"+strconv.Itoa(i)]
+		if len(files) == 0 {
+			return nil
+		}
+		file := files[0]
+		contentType := file.Header.Get("Content-Type")
+		if !strings.HasPrefix(contentType, "image/") || file.Size > maxReceiptImageSize {
+			continue
+		}
+		f, err := file.Open()
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to open email attachment", "error", err)
+			continue
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to read email attachment", "error", err)
+			continue
+		}
+		return &domain.Attachment{Type: domain.AttachmentTypeImage, MimeType: contentType, Data: data}
+	}
+}
+
+var (
+	htmlHiddenPattern = regexp.MustCompile(`(?is)<(style|script|head)\b.*?</(style|script|head)>`)
+	htmlBreakPattern  = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|tr|li|h[1-6]|table)>`)
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	blankLinesPattern = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// htmlToText reduces an HTML-only e-receipt to its text, a line per row or paragraph
+func htmlToText(body string) string {
+	text := htmlHiddenPattern.ReplaceAllString(body, "")
+	text = htmlBreakPattern.ReplaceAllString(text, "\n")
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n"))
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,197 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+const testSigningKey = "key-test"
+
+type fakeResolver map[string]string
+
+func (f fakeResolver) Resolve(ctx context.Context, address string) (string, error) {
+	if userID, ok := f[address]; ok {
+		return userID, nil
+	}
+	return "", domain.ErrNotFound
+}
+
+type fakeProcessor struct {
+	messages chan *domain.UserMessage
+}
+
+func (f *fakeProcessor) Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error) {
+	f.messages <- msg
+	return &domain.MessageResponse{Text: "✓ Uber 250"}, nil
+}
+
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []*domain.EmailMessage
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg *domain.EmailMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// mailgunRequest builds a signed Mailgun forward of an email with the given fields
+func mailgunRequest(t *testing.T, fields map[string]string, image []byte) *http.Request {
+	t.Helper()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	token := "f0a1b2c3d4"
+	mac := hmac.New(sha256.New, []byte(testSigningKey))
+	mac.Write([]byte(timestamp + token))
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("timestamp", timestamp)
+	form.WriteField("token", token)
+	form.WriteField("signature", hex.EncodeToString(mac.Sum(nil)))
+	for name, value := range fields {
+		form.WriteField(name, value)
+	}
+	if image != nil {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="attachment-1"; filename="receipt.jpg"`)
+		header.Set("Content-Type", "image/jpeg")
+		part, _ := form.CreatePart(header)
+		part.Write(image)
+	}
+	form.Close()
+
+	req := httptest.NewRequest("POST", "/webhook/email/mailgun", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestInboundHandler_HandleMailgun(t *testing.T) {
+	processor := &fakeProcessor{messages: make(chan *domain.UserMessage, 1)}
+	sender := &recordingSender{}
+	handler := NewInboundHandler(testSigningKey, fakeResolver{"river@example.com": "line_u1"}, processor, sender)
+
+	req := mailgunRequest(t, map[string]string{
+		"from":                        "River <River@Example.com>",
+		"subject":                     "Fwd: Your Uber receipt",
+		"body-plain":                  "Total NT$250\nThanks for riding",
+		"Message-Id":                  "<abc@mail.example.com>",
+		"X-Mailgun-Spf":               "Pass",
+		"X-Mailgun-Dkim-Check-Result": "Fail",
+	}, []byte("jpeg bytes"))
+	w := httptest.NewRecorder()
+	handler.HandleMailgun(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var msg *domain.UserMessage
+	select {
+	case msg = <-processor.messages:
+	case <-time.After(time.Second):
+		t.Fatal("expected the email to be processed")
+	}
+	if msg.UserID != "line_u1" || msg.Source != "email" {
+		t.Errorf("expected a message from line_u1 via email, got %+v", msg)
+	}
+	if msg.Content != "Fwd: Your Uber receipt\n\nTotal NT$250\nThanks for riding" {
+		t.Errorf("unexpected content %q", msg.Content)
+	}
+	if image := msg.FirstAttachment(domain.AttachmentTypeImage); image == nil || string(image.Data) != "jpeg bytes" {
+		t.Errorf("expected the attached receipt photo, got %+v", msg.Attachments)
+	}
+
+	// The reply goes out once Execute returns
+	deadline := time.Now().Add(time.Second)
+	for {
+		sender.mu.Lock()
+		n := len(sender.sent)
+		sender.mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].To != "river@example.com" || sender.sent[0].Subject != "Re: Fwd: Your Uber receipt" || sender.sent[0].TextBody != "✓ Uber 250" {
+		t.Errorf("unexpected reply %+v", sender.sent)
+	}
+}
+
+func TestInboundHandler_HandleMailgun_Dropped(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]string
+	}{
+		{"unbound address", map[string]string{"from": "stranger@example.com", "body-plain": "Total 100"}},
+		{"spoofed sender", map[string]string{"from": "river@example.com", "body-plain": "Total 100", "X-Mailgun-Spf": "Fail", "X-Mailgun-Dkim-Check-Result": "Fail"}},
+		{"automatic reply", map[string]string{"from": "river@example.com", "body-plain": "I'm away", "message-headers": `[["Auto-Submitted", "auto-replied"]]`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := &fakeProcessor{messages: make(chan *domain.UserMessage, 1)}
+			handler := NewInboundHandler(testSigningKey, fakeResolver{"river@example.com": "line_u1"}, processor, &recordingSender{})
+
+			w := httptest.NewRecorder()
+			handler.HandleMailgun(w, mailgunRequest(t, tt.fields, nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("expected status 200 so Mailgun doesn't retry, got %d", w.Code)
+			}
+			select {
+			case msg := <-processor.messages:
+				t.Errorf("expected the email dropped, got %+v", msg)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestInboundHandler_VerifySignature(t *testing.T) {
+	handler := NewInboundHandler(testSigningKey, fakeResolver{}, &fakeProcessor{}, nil)
+	now := time.Unix(1700000000, 0)
+	handler.now = func() time.Time { return now }
+
+	mac := hmac.New(sha256.New, []byte(testSigningKey))
+	mac.Write([]byte("1700000000" + "token"))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	if !handler.verifySignature("1700000000", "token", signature) {
+		t.Error("expected a valid signature accepted")
+	}
+	if handler.verifySignature("1700000000", "token", strings.Repeat("0", len(signature))) {
+		t.Error("expected a wrong signature rejected")
+	}
+	handler.now = func() time.Time { return now.Add(time.Hour) }
+	if handler.verifySignature("1700000000", "token", signature) {
+		t.Error("expected a stale signature rejected")
+	}
+	handler.signingKey = ""
+	if handler.verifySignature("1700000000", "token", signature) {
+		t.Error("expected every signature rejected without a signing key")
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	body := `<html><head><style>td { color: red; }</style></head><body>
+		<table><tr><td>Latte</td><td>NT$&nbsp;120</td></tr><tr><td>Total</td><td>NT$ 120</td></tr></table>
+		<p>Thanks &amp; see you</p></body></html>`
+	want := "Latte NT$ 120\nTotal NT$ 120\nThanks & see you"
+	if got := htmlToText(body); got != want {
+		t.Errorf("htmlToText() = %q, want %q", got, want)
+	}
+}
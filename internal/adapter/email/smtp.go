@@ -0,0 +1,187 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.EmailSender = (*SMTPSender)(nil)
+
+// SMTPConfig configures the SMTP server email is sent through. The connection is
+// upgraded with STARTTLS when the server supports it, e.g. on port 587.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // Optional; enables PLAIN authentication
+	Password string
+	From     string // Sender address, e.g. "AI Expense <reports@example.com>"
+}
+
+// SMTPSender sends email through an SMTP server
+type SMTPSender struct {
+	cfg      SMTPConfig
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPSender creates an SMTP email sender
+func NewSMTPSender(cfg SMTPConfig) (*SMTPSender, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if cfg.From == "" {
+		return nil, fmt.Errorf("SMTP sender address is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &SMTPSender{cfg: cfg, sendMail: smtp.SendMail}, nil
+}
+
+// Send delivers the message to its recipient
+func (s *SMTPSender) Send(ctx context.Context, msg *domain.EmailMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	from, err := mailAddress(s.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to, err := mailAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	data, err := buildMessage(s.cfg.From, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	if err := s.sendMail(addr, auth, from, []string{to}, data); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// buildMessage renders msg as a MIME message: the text and HTML bodies as
+// alternatives, followed by any attachments
+func buildMessage(from string, msg *domain.EmailMessage, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	headers := []struct{ key, value string }{
+		{"From", from},
+		{"To", msg.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/mixed; boundary=" + mixed.Boundary()},
+	}
+	var head bytes.Buffer
+	for _, h := range headers {
+		fmt.Fprintf(&head, "%s: %s\r\n", h.key, h.value)
+	}
+	head.WriteString("\r\n")
+
+	// Bodies
+	var bodies bytes.Buffer
+	alternative := multipart.NewWriter(&bodies)
+	for _, body := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.TextBody},
+		{"text/html; charset=utf-8", msg.HTMLBody},
+	} {
+		if body.content == "" {
+			continue
+		}
+		part, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(body.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(bodies.Bytes()); err != nil {
+		return nil, err
+	}
+
+	// Attachments
+	for _, attachment := range msg.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64Lines(part, attachment.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+// writeBase64Lines writes data base64-encoded in lines of 76 characters, as MIME requires
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(len(encoded), 76)
+		if _, err := w.Write([]byte(encoded[:n] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
+
+// mailAddress returns the bare address of "Name <user@example.com>" or "user@example.com"
+func mailAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return parsed.Address, nil
+}
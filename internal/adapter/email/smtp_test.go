@@ -0,0 +1,125 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestSMTPSender_Send(t *testing.T) {
+	sender, err := NewSMTPSender(SMTPConfig{
+		Host:     "smtp.example.com",
+		Username: "reports",
+		Password: "secret",
+		From:     "AI Expense <reports@example.com>",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	sender.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+		if auth == nil {
+			t.Error("expected authentication when a username is set")
+		}
+		return nil
+	}
+
+	pdf := bytes.Repeat([]byte("%PDF-1.4 statement "), 20)
+	err = sender.Send(context.Background(), &domain.EmailMessage{
+		To:       "user@example.com",
+		Subject:  "本月支出摘要",
+		TextBody: "Total: 500",
+		HTMLBody: "<p>Total: <b>500</b></p>",
+		Attachments: []domain.EmailAttachment{
+			{Filename: "statement-2026-03.pdf", ContentType: "application/pdf", Data: pdf},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotAddr != "smtp.example.com:587" || gotFrom != "reports@example.com" || len(gotTo) != 1 || gotTo[0] != "user@example.com" {
+		t.Errorf("unexpected envelope: addr=%s from=%s to=%v", gotAddr, gotFrom, gotTo)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(gotMsg))
+	if err != nil {
+		t.Fatalf("expected a valid message: %v", err)
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "本月支出摘要" {
+		t.Errorf("expected the subject to round-trip, got %q", subject)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("invalid content type: %v", err)
+	}
+	mixed := multipart.NewReader(msg.Body, params["boundary"])
+
+	bodies, err := mixed.NextPart()
+	if err != nil {
+		t.Fatalf("expected a body part: %v", err)
+	}
+	_, params, _ = mime.ParseMediaType(bodies.Header.Get("Content-Type"))
+	alternative := multipart.NewReader(bodies, params["boundary"])
+	for _, want := range []string{"Total: 500", "<p>Total: <b>500</b></p>"} {
+		part, err := alternative.NextPart()
+		if err != nil {
+			t.Fatalf("expected body %q: %v", want, err)
+		}
+		content, _ := io.ReadAll(part) // quoted-printable is decoded by the reader
+		if string(content) != want {
+			t.Errorf("expected body %q, got %q", want, content)
+		}
+	}
+
+	attachment, err := mixed.NextPart()
+	if err != nil {
+		t.Fatalf("expected an attachment: %v", err)
+	}
+	if attachment.FileName() != "statement-2026-03.pdf" {
+		t.Errorf("unexpected attachment name %q", attachment.FileName())
+	}
+	encoded, _ := io.ReadAll(attachment)
+	for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 line longer than 76 characters: %d", len(line))
+		}
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if !bytes.Equal(decoded, pdf) {
+		t.Error("expected the attachment to round-trip")
+	}
+}
+
+func TestSMTPSender_InvalidConfig(t *testing.T) {
+	if _, err := NewSMTPSender(SMTPConfig{From: "reports@example.com"}); err == nil {
+		t.Error("expected a missing host to be rejected")
+	}
+	if _, err := NewSMTPSender(SMTPConfig{Host: "smtp.example.com"}); err == nil {
+		t.Error("expected a missing sender to be rejected")
+	}
+
+	sender, _ := NewSMTPSender(SMTPConfig{Host: "smtp.example.com", From: "reports@example.com"})
+	sender.sendMail = func(string, smtp.Auth, string, []string, []byte) error {
+		t.Error("expected nothing to be sent")
+		return nil
+	}
+	if err := sender.Send(context.Background(), &domain.EmailMessage{To: "not an address\r\nBcc: x@example.com"}); err == nil {
+		t.Error("expected an invalid recipient to be rejected")
+	}
+}
@@ -0,0 +1,115 @@
+// Package encryption encrypts sensitive database columns with AES-256-GCM
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.FieldCipher = (*Keyring)(nil)
+
+// prefix marks an encrypted value; the key ID and ciphertext follow it
+const prefix = "enc:v1:"
+
+// Keyring encrypts with its primary key and decrypts with any key it holds, so
+// keys can be rotated by adding a new primary and re-encrypting old rows.
+// Stored values look like "enc:v1:<key id>:<base64 nonce and ciphertext>".
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// ParseKeys builds a keyring from a comma-separated list of "id:key" pairs,
+// where each key is 32 bytes, base64 encoded. The first key is the primary.
+func ParseKeys(spec string) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[string]cipher.AEAD)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("encryption key must be in id:base64 form")
+		}
+		if _, exists := keyring.keys[id]; exists {
+			return nil, fmt.Errorf("duplicate encryption key id %q", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q is not valid base64: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("encryption key %q must be 32 bytes, got %d", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		keyring.keys[id] = aead
+		if keyring.primary == "" {
+			keyring.primary = id
+		}
+	}
+	if keyring.primary == "" {
+		return nil, fmt.Errorf("no encryption keys given")
+	}
+	return keyring, nil
+}
+
+// Encrypt seals plaintext with the primary key. Empty values stay empty.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead := k.keys[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + k.primary + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed with any key in the keyring and returns
+// values without the encryption prefix unchanged
+func (k *Keyring) Decrypt(stored string) (string, error) {
+	if !strings.HasPrefix(stored, prefix) {
+		return stored, nil
+	}
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(stored, prefix), ":")
+	if !ok {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	aead := k.keys[id]
+	if aead == nil {
+		return "", fmt.Errorf("unknown encryption key %q", id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt with key %q: %w", id, err)
+	}
+	return string(plaintext), nil
+}
+
+// NeedsReencrypt reports whether stored is plaintext or sealed with an old key
+func (k *Keyring) NeedsReencrypt(stored string) bool {
+	if stored == "" {
+		return false
+	}
+	return !strings.HasPrefix(stored, prefix+k.primary+":")
+}
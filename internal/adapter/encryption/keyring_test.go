@@ -0,0 +1,76 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestKeyring(t *testing.T) {
+	old, err := ParseKeys("k1:" + testKey('a'))
+	if err != nil {
+		t.Fatalf("ParseKeys failed: %v", err)
+	}
+
+	sealed, err := old.Encrypt("Lunch at Ichiran")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if !strings.HasPrefix(sealed, "enc:v1:k1:") || strings.Contains(sealed, "Ichiran") {
+		t.Errorf("expected an opaque value tagged with the key, got %q", sealed)
+	}
+	if again, _ := old.Encrypt("Lunch at Ichiran"); again == sealed {
+		t.Error("expected a fresh nonce for each encryption")
+	}
+	if plain, err := old.Decrypt(sealed); err != nil || plain != "Lunch at Ichiran" {
+		t.Errorf("expected the plaintext back, got %q, %v", plain, err)
+	}
+
+	t.Run("Plaintext passes through", func(t *testing.T) {
+		if plain, err := old.Decrypt("Coffee"); err != nil || plain != "Coffee" {
+			t.Errorf("expected unencrypted values unchanged, got %q, %v", plain, err)
+		}
+		if !old.NeedsReencrypt("Coffee") || old.NeedsReencrypt("") || old.NeedsReencrypt(sealed) {
+			t.Error("expected only the plaintext to need encrypting")
+		}
+	})
+
+	t.Run("Rotation", func(t *testing.T) {
+		rotated, err := ParseKeys("k2:" + testKey('b') + ", k1:" + testKey('a'))
+		if err != nil {
+			t.Fatalf("ParseKeys failed: %v", err)
+		}
+		if plain, err := rotated.Decrypt(sealed); err != nil || plain != "Lunch at Ichiran" {
+			t.Errorf("expected old values to stay readable, got %q, %v", plain, err)
+		}
+		if !rotated.NeedsReencrypt(sealed) {
+			t.Error("expected values under the old key to need re-encrypting")
+		}
+		resealed, _ := rotated.Encrypt("Lunch at Ichiran")
+		if !strings.HasPrefix(resealed, "enc:v1:k2:") {
+			t.Errorf("expected the new primary key to be used, got %q", resealed)
+		}
+		if _, err := old.Decrypt(resealed); err == nil {
+			t.Error("expected an error for a key the keyring doesn't hold")
+		}
+	})
+
+	t.Run("Tampering detected", func(t *testing.T) {
+		tampered := sealed[:len(sealed)-4] + "AAA="
+		if _, err := old.Decrypt(tampered); err == nil {
+			t.Error("expected tampered ciphertext to fail")
+		}
+	})
+
+	t.Run("Invalid keys", func(t *testing.T) {
+		for _, spec := range []string{"", "nokey", "k1:not-base64!", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")), "k1:" + testKey('a') + ",k1:" + testKey('b')} {
+			if _, err := ParseKeys(spec); err == nil {
+				t.Errorf("expected an error for %q", spec)
+			}
+		}
+	})
+}
@@ -0,0 +1,1619 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        v5.29.3
+// source: aiexpense/v1/expense.proto
+
+package aiexpensev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateExpenseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Amount        float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency      string                 `protobuf:"bytes,4,opt,name=currency,proto3" json:"currency,omitempty"`                             // ISO 4217; the user's home currency when empty
+	CategoryId    *string                `protobuf:"bytes,5,opt,name=category_id,json=categoryId,proto3,oneof" json:"category_id,omitempty"` // Chosen by the AI when unset
+	Account       string                 `protobuf:"bytes,6,opt,name=account,proto3" json:"account,omitempty"`
+	Merchant      string                 `protobuf:"bytes,7,opt,name=merchant,proto3" json:"merchant,omitempty"`
+	Tags          []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"` // With or without the #; #報帳 also marks it reimbursable
+	Reimbursable  bool                   `protobuf:"varint,9,opt,name=reimbursable,proto3" json:"reimbursable,omitempty"`
+	Date          *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=date,proto3" json:"date,omitempty"` // Now when unset
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateExpenseRequest) Reset() {
+	*x = CreateExpenseRequest{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateExpenseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateExpenseRequest) ProtoMessage() {}
+
+func (x *CreateExpenseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateExpenseRequest.ProtoReflect.Descriptor instead.
+func (*CreateExpenseRequest) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateExpenseRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *CreateExpenseRequest) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetCategoryId() string {
+	if x != nil && x.CategoryId != nil {
+		return *x.CategoryId
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetMerchant() string {
+	if x != nil {
+		return x.Merchant
+	}
+	return ""
+}
+
+func (x *CreateExpenseRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateExpenseRequest) GetReimbursable() bool {
+	if x != nil {
+		return x.Reimbursable
+	}
+	return false
+}
+
+func (x *CreateExpenseRequest) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+type CreateExpenseResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Message        string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Category       string                 `protobuf:"bytes,3,opt,name=category,proto3" json:"category,omitempty"`
+	OriginalAmount float64                `protobuf:"fixed64,4,opt,name=original_amount,json=originalAmount,proto3" json:"original_amount,omitempty"`
+	Currency       string                 `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	HomeAmount     float64                `protobuf:"fixed64,6,opt,name=home_amount,json=homeAmount,proto3" json:"home_amount,omitempty"`
+	HomeCurrency   string                 `protobuf:"bytes,7,opt,name=home_currency,json=homeCurrency,proto3" json:"home_currency,omitempty"`
+	ExchangeRate   float64                `protobuf:"fixed64,8,opt,name=exchange_rate,json=exchangeRate,proto3" json:"exchange_rate,omitempty"`
+	Account        string                 `protobuf:"bytes,9,opt,name=account,proto3" json:"account,omitempty"`
+	Merchant       string                 `protobuf:"bytes,10,opt,name=merchant,proto3" json:"merchant,omitempty"`
+	Tags           []string               `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	Reimbursable   bool                   `protobuf:"varint,12,opt,name=reimbursable,proto3" json:"reimbursable,omitempty"`
+	// Set when the AI was unsure of the category; alternatives are the user's
+	// categories it considered next, most likely first
+	NeedsCategoryConfirmation bool     `protobuf:"varint,13,opt,name=needs_category_confirmation,json=needsCategoryConfirmation,proto3" json:"needs_category_confirmation,omitempty"`
+	CategoryConfidence        float64  `protobuf:"fixed64,14,opt,name=category_confidence,json=categoryConfidence,proto3" json:"category_confidence,omitempty"`
+	CategoryAlternatives      []string `protobuf:"bytes,15,rep,name=category_alternatives,json=categoryAlternatives,proto3" json:"category_alternatives,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *CreateExpenseResponse) Reset() {
+	*x = CreateExpenseResponse{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateExpenseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateExpenseResponse) ProtoMessage() {}
+
+func (x *CreateExpenseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateExpenseResponse.ProtoReflect.Descriptor instead.
+func (*CreateExpenseResponse) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateExpenseResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CreateExpenseResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CreateExpenseResponse) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CreateExpenseResponse) GetOriginalAmount() float64 {
+	if x != nil {
+		return x.OriginalAmount
+	}
+	return 0
+}
+
+func (x *CreateExpenseResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *CreateExpenseResponse) GetHomeAmount() float64 {
+	if x != nil {
+		return x.HomeAmount
+	}
+	return 0
+}
+
+func (x *CreateExpenseResponse) GetHomeCurrency() string {
+	if x != nil {
+		return x.HomeCurrency
+	}
+	return ""
+}
+
+func (x *CreateExpenseResponse) GetExchangeRate() float64 {
+	if x != nil {
+		return x.ExchangeRate
+	}
+	return 0
+}
+
+func (x *CreateExpenseResponse) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *CreateExpenseResponse) GetMerchant() string {
+	if x != nil {
+		return x.Merchant
+	}
+	return ""
+}
+
+func (x *CreateExpenseResponse) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateExpenseResponse) GetReimbursable() bool {
+	if x != nil {
+		return x.Reimbursable
+	}
+	return false
+}
+
+func (x *CreateExpenseResponse) GetNeedsCategoryConfirmation() bool {
+	if x != nil {
+		return x.NeedsCategoryConfirmation
+	}
+	return false
+}
+
+func (x *CreateExpenseResponse) GetCategoryConfidence() float64 {
+	if x != nil {
+		return x.CategoryConfidence
+	}
+	return 0
+}
+
+func (x *CreateExpenseResponse) GetCategoryAlternatives() []string {
+	if x != nil {
+		return x.CategoryAlternatives
+	}
+	return nil
+}
+
+// GetExpensesRequest returns every expense unless a paging or sorting field is set
+type GetExpensesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"` // 50 by default when paging, at most 500
+	Offset        int32                  `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Cursor        string                 `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`                  // next_cursor of the previous page; cannot be combined with offset
+	SortBy        string                 `protobuf:"bytes,5,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`    // "date" (default), "amount" or "created_at"
+	SortDir       string                 `protobuf:"bytes,6,opt,name=sort_dir,json=sortDir,proto3" json:"sort_dir,omitempty"` // "desc" (default) or "asc"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetExpensesRequest) Reset() {
+	*x = GetExpensesRequest{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetExpensesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetExpensesRequest) ProtoMessage() {}
+
+func (x *GetExpensesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetExpensesRequest.ProtoReflect.Descriptor instead.
+func (*GetExpensesRequest) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetExpensesRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GetExpensesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetExpensesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *GetExpensesRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *GetExpensesRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *GetExpensesRequest) GetSortDir() string {
+	if x != nil {
+		return x.SortDir
+	}
+	return ""
+}
+
+type Expense struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Description    string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Amount         float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"` // In the home currency
+	OriginalAmount float64                `protobuf:"fixed64,4,opt,name=original_amount,json=originalAmount,proto3" json:"original_amount,omitempty"`
+	Currency       string                 `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	HomeCurrency   string                 `protobuf:"bytes,6,opt,name=home_currency,json=homeCurrency,proto3" json:"home_currency,omitempty"`
+	ExchangeRate   float64                `protobuf:"fixed64,7,opt,name=exchange_rate,json=exchangeRate,proto3" json:"exchange_rate,omitempty"`
+	CategoryId     *string                `protobuf:"bytes,8,opt,name=category_id,json=categoryId,proto3,oneof" json:"category_id,omitempty"`
+	CategoryName   *string                `protobuf:"bytes,9,opt,name=category_name,json=categoryName,proto3,oneof" json:"category_name,omitempty"`
+	Date           *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=date,proto3" json:"date,omitempty"`
+	Account        string                 `protobuf:"bytes,11,opt,name=account,proto3" json:"account,omitempty"`
+	Reimbursable   bool                   `protobuf:"varint,12,opt,name=reimbursable,proto3" json:"reimbursable,omitempty"`
+	ClaimStatus    string                 `protobuf:"bytes,13,opt,name=claim_status,json=claimStatus,proto3" json:"claim_status,omitempty"` // Set for a reimbursable expense
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Expense) Reset() {
+	*x = Expense{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Expense) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Expense) ProtoMessage() {}
+
+func (x *Expense) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Expense.ProtoReflect.Descriptor instead.
+func (*Expense) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Expense) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Expense) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Expense) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *Expense) GetOriginalAmount() float64 {
+	if x != nil {
+		return x.OriginalAmount
+	}
+	return 0
+}
+
+func (x *Expense) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *Expense) GetHomeCurrency() string {
+	if x != nil {
+		return x.HomeCurrency
+	}
+	return ""
+}
+
+func (x *Expense) GetExchangeRate() float64 {
+	if x != nil {
+		return x.ExchangeRate
+	}
+	return 0
+}
+
+func (x *Expense) GetCategoryId() string {
+	if x != nil && x.CategoryId != nil {
+		return *x.CategoryId
+	}
+	return ""
+}
+
+func (x *Expense) GetCategoryName() string {
+	if x != nil && x.CategoryName != nil {
+		return *x.CategoryName
+	}
+	return ""
+}
+
+func (x *Expense) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *Expense) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *Expense) GetReimbursable() bool {
+	if x != nil {
+		return x.Reimbursable
+	}
+	return false
+}
+
+func (x *Expense) GetClaimStatus() string {
+	if x != nil {
+		return x.ClaimStatus
+	}
+	return ""
+}
+
+type GetExpensesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Expenses      []*Expense             `protobuf:"bytes,1,rep,name=expenses,proto3" json:"expenses,omitempty"`
+	Total         float64                `protobuf:"fixed64,2,opt,name=total,proto3" json:"total,omitempty"`
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,4,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"` // Set when a paged listing has more expenses
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetExpensesResponse) Reset() {
+	*x = GetExpensesResponse{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetExpensesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetExpensesResponse) ProtoMessage() {}
+
+func (x *GetExpensesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetExpensesResponse.ProtoReflect.Descriptor instead.
+func (*GetExpensesResponse) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetExpensesResponse) GetExpenses() []*Expense {
+	if x != nil {
+		return x.Expenses
+	}
+	return nil
+}
+
+func (x *GetExpensesResponse) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *GetExpensesResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GetExpensesResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type GenerateReportRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	GroupId       string                 `protobuf:"bytes,2,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`          // Report on a shared group ledger the user belongs to
+	ReportType    string                 `protobuf:"bytes,3,opt,name=report_type,json=reportType,proto3" json:"report_type,omitempty"` // "daily", "weekly" or "monthly" (default)
+	StartDate     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`    // A month ago when unset
+	EndDate       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`          // Now when unset
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GenerateReportRequest) Reset() {
+	*x = GenerateReportRequest{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateReportRequest) ProtoMessage() {}
+
+func (x *GenerateReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateReportRequest.ProtoReflect.Descriptor instead.
+func (*GenerateReportRequest) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GenerateReportRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GenerateReportRequest) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *GenerateReportRequest) GetReportType() string {
+	if x != nil {
+		return x.ReportType
+	}
+	return ""
+}
+
+func (x *GenerateReportRequest) GetStartDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *GenerateReportRequest) GetEndDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+type CategoryBreakdown struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Category      string                 `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	Total         float64                `protobuf:"fixed64,2,opt,name=total,proto3" json:"total,omitempty"`
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	Percentage    float64                `protobuf:"fixed64,4,opt,name=percentage,proto3" json:"percentage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategoryBreakdown) Reset() {
+	*x = CategoryBreakdown{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategoryBreakdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategoryBreakdown) ProtoMessage() {}
+
+func (x *CategoryBreakdown) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategoryBreakdown.ProtoReflect.Descriptor instead.
+func (*CategoryBreakdown) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CategoryBreakdown) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CategoryBreakdown) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *CategoryBreakdown) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *CategoryBreakdown) GetPercentage() float64 {
+	if x != nil {
+		return x.Percentage
+	}
+	return 0
+}
+
+type TagBreakdown struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tag           string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Total         float64                `protobuf:"fixed64,2,opt,name=total,proto3" json:"total,omitempty"`
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	Percentage    float64                `protobuf:"fixed64,4,opt,name=percentage,proto3" json:"percentage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TagBreakdown) Reset() {
+	*x = TagBreakdown{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TagBreakdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagBreakdown) ProtoMessage() {}
+
+func (x *TagBreakdown) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagBreakdown.ProtoReflect.Descriptor instead.
+func (*TagBreakdown) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TagBreakdown) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *TagBreakdown) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *TagBreakdown) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *TagBreakdown) GetPercentage() float64 {
+	if x != nil {
+		return x.Percentage
+	}
+	return 0
+}
+
+type DailyBreakdown struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Date          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
+	Total         float64                `protobuf:"fixed64,2,opt,name=total,proto3" json:"total,omitempty"`
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DailyBreakdown) Reset() {
+	*x = DailyBreakdown{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DailyBreakdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DailyBreakdown) ProtoMessage() {}
+
+func (x *DailyBreakdown) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DailyBreakdown.ProtoReflect.Descriptor instead.
+func (*DailyBreakdown) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DailyBreakdown) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *DailyBreakdown) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *DailyBreakdown) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type MonthlyBreakdown struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Month         string                 `protobuf:"bytes,1,opt,name=month,proto3" json:"month,omitempty"` // YYYY-MM
+	Total         float64                `protobuf:"fixed64,2,opt,name=total,proto3" json:"total,omitempty"`
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MonthlyBreakdown) Reset() {
+	*x = MonthlyBreakdown{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MonthlyBreakdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MonthlyBreakdown) ProtoMessage() {}
+
+func (x *MonthlyBreakdown) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MonthlyBreakdown.ProtoReflect.Descriptor instead.
+func (*MonthlyBreakdown) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MonthlyBreakdown) GetMonth() string {
+	if x != nil {
+		return x.Month
+	}
+	return ""
+}
+
+func (x *MonthlyBreakdown) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *MonthlyBreakdown) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type ReportExpense struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Amount        float64                `protobuf:"fixed64,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Category      string                 `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	Tags          []string               `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	Date          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=date,proto3" json:"date,omitempty"`
+	Account       string                 `protobuf:"bytes,7,opt,name=account,proto3" json:"account,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportExpense) Reset() {
+	*x = ReportExpense{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportExpense) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportExpense) ProtoMessage() {}
+
+func (x *ReportExpense) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportExpense.ProtoReflect.Descriptor instead.
+func (*ReportExpense) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReportExpense) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ReportExpense) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ReportExpense) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ReportExpense) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *ReportExpense) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ReportExpense) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+func (x *ReportExpense) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+type GenerateReportResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	UserId            string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	GroupId           string                 `protobuf:"bytes,2,opt,name=group_id,json=groupId,proto3" json:"group_id,omitempty"`
+	ReportType        string                 `protobuf:"bytes,3,opt,name=report_type,json=reportType,proto3" json:"report_type,omitempty"`
+	Period            string                 `protobuf:"bytes,4,opt,name=period,proto3" json:"period,omitempty"`
+	StartDate         *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate           *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	TotalExpenses     float64                `protobuf:"fixed64,7,opt,name=total_expenses,json=totalExpenses,proto3" json:"total_expenses,omitempty"`
+	TransactionCount  int32                  `protobuf:"varint,8,opt,name=transaction_count,json=transactionCount,proto3" json:"transaction_count,omitempty"`
+	AverageExpense    float64                `protobuf:"fixed64,9,opt,name=average_expense,json=averageExpense,proto3" json:"average_expense,omitempty"`
+	HighestExpense    float64                `protobuf:"fixed64,10,opt,name=highest_expense,json=highestExpense,proto3" json:"highest_expense,omitempty"`
+	LowestExpense     float64                `protobuf:"fixed64,11,opt,name=lowest_expense,json=lowestExpense,proto3" json:"lowest_expense,omitempty"`
+	CategoryBreakdown []*CategoryBreakdown   `protobuf:"bytes,12,rep,name=category_breakdown,json=categoryBreakdown,proto3" json:"category_breakdown,omitempty"`
+	TagBreakdown      []*TagBreakdown        `protobuf:"bytes,13,rep,name=tag_breakdown,json=tagBreakdown,proto3" json:"tag_breakdown,omitempty"`
+	DailyBreakdown    []*DailyBreakdown      `protobuf:"bytes,14,rep,name=daily_breakdown,json=dailyBreakdown,proto3" json:"daily_breakdown,omitempty"`
+	MonthlyBreakdown  []*MonthlyBreakdown    `protobuf:"bytes,15,rep,name=monthly_breakdown,json=monthlyBreakdown,proto3" json:"monthly_breakdown,omitempty"`
+	TopExpenses       []*ReportExpense       `protobuf:"bytes,16,rep,name=top_expenses,json=topExpenses,proto3" json:"top_expenses,omitempty"`
+	GeneratedAt       *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *GenerateReportResponse) Reset() {
+	*x = GenerateReportResponse{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenerateReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenerateReportResponse) ProtoMessage() {}
+
+func (x *GenerateReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenerateReportResponse.ProtoReflect.Descriptor instead.
+func (*GenerateReportResponse) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GenerateReportResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *GenerateReportResponse) GetGroupId() string {
+	if x != nil {
+		return x.GroupId
+	}
+	return ""
+}
+
+func (x *GenerateReportResponse) GetReportType() string {
+	if x != nil {
+		return x.ReportType
+	}
+	return ""
+}
+
+func (x *GenerateReportResponse) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *GenerateReportResponse) GetStartDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *GenerateReportResponse) GetEndDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *GenerateReportResponse) GetTotalExpenses() float64 {
+	if x != nil {
+		return x.TotalExpenses
+	}
+	return 0
+}
+
+func (x *GenerateReportResponse) GetTransactionCount() int32 {
+	if x != nil {
+		return x.TransactionCount
+	}
+	return 0
+}
+
+func (x *GenerateReportResponse) GetAverageExpense() float64 {
+	if x != nil {
+		return x.AverageExpense
+	}
+	return 0
+}
+
+func (x *GenerateReportResponse) GetHighestExpense() float64 {
+	if x != nil {
+		return x.HighestExpense
+	}
+	return 0
+}
+
+func (x *GenerateReportResponse) GetLowestExpense() float64 {
+	if x != nil {
+		return x.LowestExpense
+	}
+	return 0
+}
+
+func (x *GenerateReportResponse) GetCategoryBreakdown() []*CategoryBreakdown {
+	if x != nil {
+		return x.CategoryBreakdown
+	}
+	return nil
+}
+
+func (x *GenerateReportResponse) GetTagBreakdown() []*TagBreakdown {
+	if x != nil {
+		return x.TagBreakdown
+	}
+	return nil
+}
+
+func (x *GenerateReportResponse) GetDailyBreakdown() []*DailyBreakdown {
+	if x != nil {
+		return x.DailyBreakdown
+	}
+	return nil
+}
+
+func (x *GenerateReportResponse) GetMonthlyBreakdown() []*MonthlyBreakdown {
+	if x != nil {
+		return x.MonthlyBreakdown
+	}
+	return nil
+}
+
+func (x *GenerateReportResponse) GetTopExpenses() []*ReportExpense {
+	if x != nil {
+		return x.TopExpenses
+	}
+	return nil
+}
+
+func (x *GenerateReportResponse) GetGeneratedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return nil
+}
+
+type ParseTextRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseTextRequest) Reset() {
+	*x = ParseTextRequest{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseTextRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseTextRequest) ProtoMessage() {}
+
+func (x *ParseTextRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseTextRequest.ProtoReflect.Descriptor instead.
+func (*ParseTextRequest) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ParseTextRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ParseTextRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type ParsedExpense struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Description       string                 `protobuf:"bytes,1,opt,name=description,proto3" json:"description,omitempty"`
+	Amount            float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Currency          string                 `protobuf:"bytes,3,opt,name=currency,proto3" json:"currency,omitempty"`
+	SuggestedCategory string                 `protobuf:"bytes,4,opt,name=suggested_category,json=suggestedCategory,proto3" json:"suggested_category,omitempty"`
+	Account           string                 `protobuf:"bytes,5,opt,name=account,proto3" json:"account,omitempty"`
+	Merchant          string                 `protobuf:"bytes,6,opt,name=merchant,proto3" json:"merchant,omitempty"`
+	Tags              []string               `protobuf:"bytes,7,rep,name=tags,proto3" json:"tags,omitempty"`
+	Date              *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=date,proto3" json:"date,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ParsedExpense) Reset() {
+	*x = ParsedExpense{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParsedExpense) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParsedExpense) ProtoMessage() {}
+
+func (x *ParsedExpense) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParsedExpense.ProtoReflect.Descriptor instead.
+func (*ParsedExpense) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ParsedExpense) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ParsedExpense) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *ParsedExpense) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+func (x *ParsedExpense) GetSuggestedCategory() string {
+	if x != nil {
+		return x.SuggestedCategory
+	}
+	return ""
+}
+
+func (x *ParsedExpense) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *ParsedExpense) GetMerchant() string {
+	if x != nil {
+		return x.Merchant
+	}
+	return ""
+}
+
+func (x *ParsedExpense) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ParsedExpense) GetDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Date
+	}
+	return nil
+}
+
+type ParseTextResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Expenses      []*ParsedExpense       `protobuf:"bytes,1,rep,name=expenses,proto3" json:"expenses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseTextResponse) Reset() {
+	*x = ParseTextResponse{}
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseTextResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseTextResponse) ProtoMessage() {}
+
+func (x *ParseTextResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_aiexpense_v1_expense_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseTextResponse.ProtoReflect.Descriptor instead.
+func (*ParseTextResponse) Descriptor() ([]byte, []int) {
+	return file_aiexpense_v1_expense_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ParseTextResponse) GetExpenses() []*ParsedExpense {
+	if x != nil {
+		return x.Expenses
+	}
+	return nil
+}
+
+var File_aiexpense_v1_expense_proto protoreflect.FileDescriptor
+
+const file_aiexpense_v1_expense_proto_rawDesc = "" +
+	"\n" +
+	"\x1aaiexpense/v1/expense.proto\x12\faiexpense.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd9\x02\n" +
+	"\x14CreateExpenseRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x04 \x01(\tR\bcurrency\x12$\n" +
+	"\vcategory_id\x18\x05 \x01(\tH\x00R\n" +
+	"categoryId\x88\x01\x01\x12\x18\n" +
+	"\aaccount\x18\x06 \x01(\tR\aaccount\x12\x1a\n" +
+	"\bmerchant\x18\a \x01(\tR\bmerchant\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\x12\"\n" +
+	"\freimbursable\x18\t \x01(\bR\freimbursable\x12.\n" +
+	"\x04date\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x04dateB\x0e\n" +
+	"\f_category_id\"\xa1\x04\n" +
+	"\x15CreateExpenseResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1a\n" +
+	"\bcategory\x18\x03 \x01(\tR\bcategory\x12'\n" +
+	"\x0foriginal_amount\x18\x04 \x01(\x01R\x0eoriginalAmount\x12\x1a\n" +
+	"\bcurrency\x18\x05 \x01(\tR\bcurrency\x12\x1f\n" +
+	"\vhome_amount\x18\x06 \x01(\x01R\n" +
+	"homeAmount\x12#\n" +
+	"\rhome_currency\x18\a \x01(\tR\fhomeCurrency\x12#\n" +
+	"\rexchange_rate\x18\b \x01(\x01R\fexchangeRate\x12\x18\n" +
+	"\aaccount\x18\t \x01(\tR\aaccount\x12\x1a\n" +
+	"\bmerchant\x18\n" +
+	" \x01(\tR\bmerchant\x12\x12\n" +
+	"\x04tags\x18\v \x03(\tR\x04tags\x12\"\n" +
+	"\freimbursable\x18\f \x01(\bR\freimbursable\x12>\n" +
+	"\x1bneeds_category_confirmation\x18\r \x01(\bR\x19needsCategoryConfirmation\x12/\n" +
+	"\x13category_confidence\x18\x0e \x01(\x01R\x12categoryConfidence\x123\n" +
+	"\x15category_alternatives\x18\x0f \x03(\tR\x14categoryAlternatives\"\xa7\x01\n" +
+	"\x12GetExpensesRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\x12\x17\n" +
+	"\asort_by\x18\x05 \x01(\tR\x06sortBy\x12\x19\n" +
+	"\bsort_dir\x18\x06 \x01(\tR\asortDir\"\xe5\x03\n" +
+	"\aExpense\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12'\n" +
+	"\x0foriginal_amount\x18\x04 \x01(\x01R\x0eoriginalAmount\x12\x1a\n" +
+	"\bcurrency\x18\x05 \x01(\tR\bcurrency\x12#\n" +
+	"\rhome_currency\x18\x06 \x01(\tR\fhomeCurrency\x12#\n" +
+	"\rexchange_rate\x18\a \x01(\x01R\fexchangeRate\x12$\n" +
+	"\vcategory_id\x18\b \x01(\tH\x00R\n" +
+	"categoryId\x88\x01\x01\x12(\n" +
+	"\rcategory_name\x18\t \x01(\tH\x01R\fcategoryName\x88\x01\x01\x12.\n" +
+	"\x04date\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x18\n" +
+	"\aaccount\x18\v \x01(\tR\aaccount\x12\"\n" +
+	"\freimbursable\x18\f \x01(\bR\freimbursable\x12!\n" +
+	"\fclaim_status\x18\r \x01(\tR\vclaimStatusB\x0e\n" +
+	"\f_category_idB\x10\n" +
+	"\x0e_category_name\"\x95\x01\n" +
+	"\x13GetExpensesResponse\x121\n" +
+	"\bexpenses\x18\x01 \x03(\v2\x15.aiexpense.v1.ExpenseR\bexpenses\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x01R\x05total\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x1f\n" +
+	"\vnext_cursor\x18\x04 \x01(\tR\n" +
+	"nextCursor\"\xde\x01\n" +
+	"\x15GenerateReportRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bgroup_id\x18\x02 \x01(\tR\agroupId\x12\x1f\n" +
+	"\vreport_type\x18\x03 \x01(\tR\n" +
+	"reportType\x129\n" +
+	"\n" +
+	"start_date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tstartDate\x125\n" +
+	"\bend_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\aendDate\"{\n" +
+	"\x11CategoryBreakdown\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x01R\x05total\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x1e\n" +
+	"\n" +
+	"percentage\x18\x04 \x01(\x01R\n" +
+	"percentage\"l\n" +
+	"\fTagBreakdown\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x01R\x05total\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x1e\n" +
+	"\n" +
+	"percentage\x18\x04 \x01(\x01R\n" +
+	"percentage\"l\n" +
+	"\x0eDailyBreakdown\x12.\n" +
+	"\x04date\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x01R\x05total\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\"T\n" +
+	"\x10MonthlyBreakdown\x12\x14\n" +
+	"\x05month\x18\x01 \x01(\tR\x05month\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x01R\x05total\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\"\xd3\x01\n" +
+	"\rReportExpense\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x12.\n" +
+	"\x04date\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x04date\x12\x18\n" +
+	"\aaccount\x18\a \x01(\tR\aaccount\"\xe8\x06\n" +
+	"\x16GenerateReportResponse\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bgroup_id\x18\x02 \x01(\tR\agroupId\x12\x1f\n" +
+	"\vreport_type\x18\x03 \x01(\tR\n" +
+	"reportType\x12\x16\n" +
+	"\x06period\x18\x04 \x01(\tR\x06period\x129\n" +
+	"\n" +
+	"start_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tstartDate\x125\n" +
+	"\bend_date\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\aendDate\x12%\n" +
+	"\x0etotal_expenses\x18\a \x01(\x01R\rtotalExpenses\x12+\n" +
+	"\x11transaction_count\x18\b \x01(\x05R\x10transactionCount\x12'\n" +
+	"\x0faverage_expense\x18\t \x01(\x01R\x0eaverageExpense\x12'\n" +
+	"\x0fhighest_expense\x18\n" +
+	" \x01(\x01R\x0ehighestExpense\x12%\n" +
+	"\x0elowest_expense\x18\v \x01(\x01R\rlowestExpense\x12N\n" +
+	"\x12category_breakdown\x18\f \x03(\v2\x1f.aiexpense.v1.CategoryBreakdownR\x11categoryBreakdown\x12?\n" +
+	"\rtag_breakdown\x18\r \x03(\v2\x1a.aiexpense.v1.TagBreakdownR\ftagBreakdown\x12E\n" +
+	"\x0fdaily_breakdown\x18\x0e \x03(\v2\x1c.aiexpense.v1.DailyBreakdownR\x0edailyBreakdown\x12K\n" +
+	"\x11monthly_breakdown\x18\x0f \x03(\v2\x1e.aiexpense.v1.MonthlyBreakdownR\x10monthlyBreakdown\x12>\n" +
+	"\ftop_expenses\x18\x10 \x03(\v2\x1b.aiexpense.v1.ReportExpenseR\vtopExpenses\x12=\n" +
+	"\fgenerated_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\vgeneratedAt\"?\n" +
+	"\x10ParseTextRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\"\x8e\x02\n" +
+	"\rParsedExpense\x12 \n" +
+	"\vdescription\x18\x01 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12\x1a\n" +
+	"\bcurrency\x18\x03 \x01(\tR\bcurrency\x12-\n" +
+	"\x12suggested_category\x18\x04 \x01(\tR\x11suggestedCategory\x12\x18\n" +
+	"\aaccount\x18\x05 \x01(\tR\aaccount\x12\x1a\n" +
+	"\bmerchant\x18\x06 \x01(\tR\bmerchant\x12\x12\n" +
+	"\x04tags\x18\a \x03(\tR\x04tags\x12.\n" +
+	"\x04date\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x04date\"L\n" +
+	"\x11ParseTextResponse\x127\n" +
+	"\bexpenses\x18\x01 \x03(\v2\x1b.aiexpense.v1.ParsedExpenseR\bexpenses2\xe9\x02\n" +
+	"\x0eExpenseService\x12X\n" +
+	"\rCreateExpense\x12\".aiexpense.v1.CreateExpenseRequest\x1a#.aiexpense.v1.CreateExpenseResponse\x12R\n" +
+	"\vGetExpenses\x12 .aiexpense.v1.GetExpensesRequest\x1a!.aiexpense.v1.GetExpensesResponse\x12[\n" +
+	"\x0eGenerateReport\x12#.aiexpense.v1.GenerateReportRequest\x1a$.aiexpense.v1.GenerateReportResponse\x12L\n" +
+	"\tParseText\x12\x1e.aiexpense.v1.ParseTextRequest\x1a\x1f.aiexpense.v1.ParseTextResponseBMZKgithub.com/riverlin/aiexpense/internal/adapter/grpc/aiexpensev1;aiexpensev1b\x06proto3"
+
+var (
+	file_aiexpense_v1_expense_proto_rawDescOnce sync.Once
+	file_aiexpense_v1_expense_proto_rawDescData []byte
+)
+
+func file_aiexpense_v1_expense_proto_rawDescGZIP() []byte {
+	file_aiexpense_v1_expense_proto_rawDescOnce.Do(func() {
+		file_aiexpense_v1_expense_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_aiexpense_v1_expense_proto_rawDesc), len(file_aiexpense_v1_expense_proto_rawDesc)))
+	})
+	return file_aiexpense_v1_expense_proto_rawDescData
+}
+
+var file_aiexpense_v1_expense_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_aiexpense_v1_expense_proto_goTypes = []any{
+	(*CreateExpenseRequest)(nil),   // 0: aiexpense.v1.CreateExpenseRequest
+	(*CreateExpenseResponse)(nil),  // 1: aiexpense.v1.CreateExpenseResponse
+	(*GetExpensesRequest)(nil),     // 2: aiexpense.v1.GetExpensesRequest
+	(*Expense)(nil),                // 3: aiexpense.v1.Expense
+	(*GetExpensesResponse)(nil),    // 4: aiexpense.v1.GetExpensesResponse
+	(*GenerateReportRequest)(nil),  // 5: aiexpense.v1.GenerateReportRequest
+	(*CategoryBreakdown)(nil),      // 6: aiexpense.v1.CategoryBreakdown
+	(*TagBreakdown)(nil),           // 7: aiexpense.v1.TagBreakdown
+	(*DailyBreakdown)(nil),         // 8: aiexpense.v1.DailyBreakdown
+	(*MonthlyBreakdown)(nil),       // 9: aiexpense.v1.MonthlyBreakdown
+	(*ReportExpense)(nil),          // 10: aiexpense.v1.ReportExpense
+	(*GenerateReportResponse)(nil), // 11: aiexpense.v1.GenerateReportResponse
+	(*ParseTextRequest)(nil),       // 12: aiexpense.v1.ParseTextRequest
+	(*ParsedExpense)(nil),          // 13: aiexpense.v1.ParsedExpense
+	(*ParseTextResponse)(nil),      // 14: aiexpense.v1.ParseTextResponse
+	(*timestamppb.Timestamp)(nil),  // 15: google.protobuf.Timestamp
+}
+var file_aiexpense_v1_expense_proto_depIdxs = []int32{
+	15, // 0: aiexpense.v1.CreateExpenseRequest.date:type_name -> google.protobuf.Timestamp
+	15, // 1: aiexpense.v1.Expense.date:type_name -> google.protobuf.Timestamp
+	3,  // 2: aiexpense.v1.GetExpensesResponse.expenses:type_name -> aiexpense.v1.Expense
+	15, // 3: aiexpense.v1.GenerateReportRequest.start_date:type_name -> google.protobuf.Timestamp
+	15, // 4: aiexpense.v1.GenerateReportRequest.end_date:type_name -> google.protobuf.Timestamp
+	15, // 5: aiexpense.v1.DailyBreakdown.date:type_name -> google.protobuf.Timestamp
+	15, // 6: aiexpense.v1.ReportExpense.date:type_name -> google.protobuf.Timestamp
+	15, // 7: aiexpense.v1.GenerateReportResponse.start_date:type_name -> google.protobuf.Timestamp
+	15, // 8: aiexpense.v1.GenerateReportResponse.end_date:type_name -> google.protobuf.Timestamp
+	6,  // 9: aiexpense.v1.GenerateReportResponse.category_breakdown:type_name -> aiexpense.v1.CategoryBreakdown
+	7,  // 10: aiexpense.v1.GenerateReportResponse.tag_breakdown:type_name -> aiexpense.v1.TagBreakdown
+	8,  // 11: aiexpense.v1.GenerateReportResponse.daily_breakdown:type_name -> aiexpense.v1.DailyBreakdown
+	9,  // 12: aiexpense.v1.GenerateReportResponse.monthly_breakdown:type_name -> aiexpense.v1.MonthlyBreakdown
+	10, // 13: aiexpense.v1.GenerateReportResponse.top_expenses:type_name -> aiexpense.v1.ReportExpense
+	15, // 14: aiexpense.v1.GenerateReportResponse.generated_at:type_name -> google.protobuf.Timestamp
+	15, // 15: aiexpense.v1.ParsedExpense.date:type_name -> google.protobuf.Timestamp
+	13, // 16: aiexpense.v1.ParseTextResponse.expenses:type_name -> aiexpense.v1.ParsedExpense
+	0,  // 17: aiexpense.v1.ExpenseService.CreateExpense:input_type -> aiexpense.v1.CreateExpenseRequest
+	2,  // 18: aiexpense.v1.ExpenseService.GetExpenses:input_type -> aiexpense.v1.GetExpensesRequest
+	5,  // 19: aiexpense.v1.ExpenseService.GenerateReport:input_type -> aiexpense.v1.GenerateReportRequest
+	12, // 20: aiexpense.v1.ExpenseService.ParseText:input_type -> aiexpense.v1.ParseTextRequest
+	1,  // 21: aiexpense.v1.ExpenseService.CreateExpense:output_type -> aiexpense.v1.CreateExpenseResponse
+	4,  // 22: aiexpense.v1.ExpenseService.GetExpenses:output_type -> aiexpense.v1.GetExpensesResponse
+	11, // 23: aiexpense.v1.ExpenseService.GenerateReport:output_type -> aiexpense.v1.GenerateReportResponse
+	14, // 24: aiexpense.v1.ExpenseService.ParseText:output_type -> aiexpense.v1.ParseTextResponse
+	21, // [21:25] is the sub-list for method output_type
+	17, // [17:21] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_aiexpense_v1_expense_proto_init() }
+func file_aiexpense_v1_expense_proto_init() {
+	if File_aiexpense_v1_expense_proto != nil {
+		return
+	}
+	file_aiexpense_v1_expense_proto_msgTypes[0].OneofWrappers = []any{}
+	file_aiexpense_v1_expense_proto_msgTypes[3].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_aiexpense_v1_expense_proto_rawDesc), len(file_aiexpense_v1_expense_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_aiexpense_v1_expense_proto_goTypes,
+		DependencyIndexes: file_aiexpense_v1_expense_proto_depIdxs,
+		MessageInfos:      file_aiexpense_v1_expense_proto_msgTypes,
+	}.Build()
+	File_aiexpense_v1_expense_proto = out.File
+	file_aiexpense_v1_expense_proto_goTypes = nil
+	file_aiexpense_v1_expense_proto_depIdxs = nil
+}
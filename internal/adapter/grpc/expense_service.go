@@ -0,0 +1,256 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/riverlin/aiexpense/internal/adapter/grpc/aiexpensev1"
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// ExpenseService implements aiexpensev1.ExpenseServiceServer on the same use
+// cases as the REST API
+type ExpenseService struct {
+	aiexpensev1.UnimplementedExpenseServiceServer
+	createExpenseUC     *usecase.CreateExpenseUseCase
+	getExpensesUC       *usecase.GetExpensesUseCase
+	generateReportUC    *usecase.GenerateReportUseCase
+	parseConversationUC *usecase.ParseConversationUseCase
+}
+
+// NewExpenseService creates a new gRPC expense service
+func NewExpenseService(
+	createExpenseUC *usecase.CreateExpenseUseCase,
+	getExpensesUC *usecase.GetExpensesUseCase,
+	generateReportUC *usecase.GenerateReportUseCase,
+	parseConversationUC *usecase.ParseConversationUseCase,
+) *ExpenseService {
+	return &ExpenseService{
+		createExpenseUC:     createExpenseUC,
+		getExpensesUC:       getExpensesUC,
+		generateReportUC:    generateReportUC,
+		parseConversationUC: parseConversationUC,
+	}
+}
+
+// CreateExpense records an expense, attributed to the API in its audit trail
+func (s *ExpenseService) CreateExpense(ctx context.Context, req *aiexpensev1.CreateExpenseRequest) (*aiexpensev1.CreateExpenseResponse, error) {
+	switch {
+	case req.GetUserId() == "":
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	case strings.TrimSpace(req.GetDescription()) == "":
+		return nil, status.Error(codes.InvalidArgument, "description is required")
+	case req.GetAmount() <= 0:
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+
+	date := time.Now()
+	if req.GetDate() != nil {
+		date = req.GetDate().AsTime()
+	}
+	resp, err := s.createExpenseUC.Execute(apiAuditContext(ctx, req.GetUserId()), &usecase.CreateRequest{
+		UserID:       req.GetUserId(),
+		Description:  req.GetDescription(),
+		Amount:       req.GetAmount(),
+		Currency:     req.GetCurrency(),
+		CategoryID:   req.CategoryId,
+		Account:      req.GetAccount(),
+		Merchant:     req.GetMerchant(),
+		Tags:         req.GetTags(),
+		Reimbursable: req.GetReimbursable(),
+		Date:         date,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &aiexpensev1.CreateExpenseResponse{
+		Id:                        resp.ID,
+		Message:                   resp.Message,
+		Category:                  resp.Category,
+		OriginalAmount:            resp.OriginalAmount,
+		Currency:                  resp.Currency,
+		HomeAmount:                resp.HomeAmount,
+		HomeCurrency:              resp.HomeCurrency,
+		ExchangeRate:              resp.ExchangeRate,
+		Account:                   resp.Account,
+		Merchant:                  resp.Merchant,
+		Tags:                      resp.Tags,
+		Reimbursable:              resp.Reimbursable,
+		NeedsCategoryConfirmation: resp.NeedsCategoryConfirmation,
+		CategoryConfidence:        resp.CategoryConfidence,
+		CategoryAlternatives:      resp.CategoryAlternatives,
+	}, nil
+}
+
+// GetExpenses lists a user's expenses, all of them or one page
+func (s *ExpenseService) GetExpenses(ctx context.Context, req *aiexpensev1.GetExpensesRequest) (*aiexpensev1.GetExpensesResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	listReq := &usecase.GetAllRequest{
+		UserID:  req.GetUserId(),
+		Limit:   int(req.GetLimit()),
+		Offset:  int(req.GetOffset()),
+		Cursor:  req.GetCursor(),
+		SortBy:  req.GetSortBy(),
+		SortDir: strings.ToLower(req.GetSortDir()),
+	}
+	if err := listReq.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	resp, err := s.getExpensesUC.ExecuteGetAll(ctx, listReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	expenses := make([]*aiexpensev1.Expense, len(resp.Expenses))
+	for i, e := range resp.Expenses {
+		expenses[i] = &aiexpensev1.Expense{
+			Id:             e.ID,
+			Description:    e.Description,
+			Amount:         e.Amount,
+			OriginalAmount: e.OriginalAmount,
+			Currency:       e.Currency,
+			HomeCurrency:   e.HomeCurrency,
+			ExchangeRate:   e.ExchangeRate,
+			CategoryId:     e.CategoryID,
+			CategoryName:   e.CategoryName,
+			Date:           timestamppb.New(e.Date),
+			Account:        e.Account,
+			Reimbursable:   e.Reimbursable,
+			ClaimStatus:    e.ClaimStatus,
+		}
+	}
+	return &aiexpensev1.GetExpensesResponse{
+		Expenses:   expenses,
+		Total:      resp.Total,
+		Count:      int32(resp.Count),
+		NextCursor: resp.NextCursor,
+	}, nil
+}
+
+// GenerateReport reports on a user's or group ledger's spending, over the
+// last month unless the request sets a period
+func (s *ExpenseService) GenerateReport(ctx context.Context, req *aiexpensev1.GenerateReportRequest) (*aiexpensev1.GenerateReportResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	reportReq := &usecase.ReportRequest{
+		UserID:     req.GetUserId(),
+		GroupID:    req.GetGroupId(),
+		ReportType: req.GetReportType(),
+		StartDate:  time.Now().AddDate(0, -1, 0),
+		EndDate:    time.Now(),
+	}
+	if reportReq.ReportType == "" {
+		reportReq.ReportType = "monthly"
+	}
+	if req.GetStartDate() != nil {
+		reportReq.StartDate = req.GetStartDate().AsTime()
+	}
+	if req.GetEndDate() != nil {
+		reportReq.EndDate = req.GetEndDate().AsTime()
+	}
+
+	report, err := s.generateReportUC.Execute(ctx, reportReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &aiexpensev1.GenerateReportResponse{
+		UserId:           report.UserID,
+		GroupId:          report.GroupID,
+		ReportType:       report.ReportType,
+		Period:           report.Period,
+		StartDate:        timestamppb.New(report.StartDate),
+		EndDate:          timestamppb.New(report.EndDate),
+		TotalExpenses:    report.TotalExpenses,
+		TransactionCount: int32(report.TransactionCount),
+		AverageExpense:   report.AverageExpense,
+		HighestExpense:   report.HighestExpense,
+		LowestExpense:    report.LowestExpense,
+		GeneratedAt:      timestamppb.New(report.GeneratedAt),
+	}
+	for _, b := range report.CategoryBreakdown {
+		resp.CategoryBreakdown = append(resp.CategoryBreakdown, &aiexpensev1.CategoryBreakdown{
+			Category: b.Category, Total: b.Total, Count: int32(b.Count), Percentage: b.Percentage,
+		})
+	}
+	for _, b := range report.TagBreakdown {
+		resp.TagBreakdown = append(resp.TagBreakdown, &aiexpensev1.TagBreakdown{
+			Tag: b.Tag, Total: b.Total, Count: int32(b.Count), Percentage: b.Percentage,
+		})
+	}
+	for _, b := range report.DailyBreakdown {
+		resp.DailyBreakdown = append(resp.DailyBreakdown, &aiexpensev1.DailyBreakdown{
+			Date: timestamppb.New(b.Date), Total: b.Total, Count: int32(b.Count),
+		})
+	}
+	for _, b := range report.MonthlyBreakdown {
+		resp.MonthlyBreakdown = append(resp.MonthlyBreakdown, &aiexpensev1.MonthlyBreakdown{
+			Month: b.Month, Total: b.Total, Count: int32(b.Count),
+		})
+	}
+	for _, e := range report.TopExpenses {
+		resp.TopExpenses = append(resp.TopExpenses, &aiexpensev1.ReportExpense{
+			Id:          e.ID,
+			Description: e.Description,
+			Amount:      e.Amount,
+			Category:    e.Category,
+			Tags:        e.Tags,
+			Date:        timestamppb.New(e.Date),
+			Account:     e.Account,
+		})
+	}
+	return resp, nil
+}
+
+// ParseText reads the expenses in a message without recording them. Like a
+// chat message, it falls back to the regex parser once AI spending caps are hit.
+func (s *ExpenseService) ParseText(ctx context.Context, req *aiexpensev1.ParseTextRequest) (*aiexpensev1.ParseTextResponse, error) {
+	switch {
+	case req.GetUserId() == "":
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	case strings.TrimSpace(req.GetText()) == "":
+		return nil, status.Error(codes.InvalidArgument, "text is required")
+	}
+
+	result, err := s.parseConversationUC.Execute(ctx, req.GetText(), req.GetUserId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &aiexpensev1.ParseTextResponse{}
+	for _, e := range result.Expenses {
+		parsed := &aiexpensev1.ParsedExpense{
+			Description:       e.Description,
+			Amount:            e.Amount,
+			Currency:          e.Currency,
+			SuggestedCategory: e.SuggestedCategory,
+			Account:           e.Account,
+			Merchant:          e.Merchant,
+			Tags:              e.Tags,
+		}
+		if !e.Date.IsZero() {
+			parsed.Date = timestamppb.New(e.Date)
+		}
+		resp.Expenses = append(resp.Expenses, parsed)
+	}
+	return resp, nil
+}
+
+// apiAuditContext attributes expense changes made over gRPC to the user they
+// are made for, as changes made through the REST API are
+func apiAuditContext(ctx context.Context, userID string) context.Context {
+	return domain.WithAuditSource(ctx, domain.AuditSource{Actor: userID, Channel: domain.AuditChannelAPI})
+}
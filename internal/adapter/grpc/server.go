@@ -0,0 +1,103 @@
+// Package grpc serves the core expense operations over gRPC, for internal
+// services such as the dashboard BFF that would rather not go through JSON.
+// The service is defined in proto/aiexpense/v1/expense.proto; regenerate
+// aiexpensev1 with protoc-gen-go and protoc-gen-go-grpc after changing it.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/riverlin/aiexpense/internal/adapter/grpc/aiexpensev1"
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// Metadata callers send with each call
+const (
+	apiKeyMetadata    = "x-api-key"      // API key holding the expenses:rpc scope
+	workspaceMetadata = "x-workspace-id" // Workspace the call is about; "personal" for the personal one
+)
+
+// personalWorkspace names the personal workspace in metadata, as it has no ID
+const personalWorkspace = "personal"
+
+// NewServer returns a gRPC server for the expense service. Calls need an API
+// key with the expenses:rpc scope, checked as the admin REST endpoints check
+// theirs.
+func NewServer(service *ExpenseService, apiKeyUC *usecase.APIKeyUseCase) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		logCalls,
+		authorize(apiKeyUC),
+		withWorkspace,
+	))
+	aiexpensev1.RegisterExpenseServiceServer(server, service)
+	return server
+}
+
+// authorize rejects calls without an API key granting the expenses:rpc scope
+func authorize(apiKeyUC *usecase.APIKeyUseCase) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		err := apiKeyUC.Authorize(ctx, firstMetadata(ctx, apiKeyMetadata), domain.APIKeyScopeExpensesRPC)
+		switch {
+		case errors.Is(err, usecase.ErrAPIKeyInvalid):
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		case errors.Is(err, usecase.ErrAPIKeyScope):
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case err != nil:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return handler(ctx, req)
+	}
+}
+
+// withWorkspace scopes a call to the workspace in its x-workspace-id metadata,
+// as the X-Workspace-ID header does for the REST API. Calls naming none span
+// every workspace and record into the personal one.
+func withWorkspace(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if workspaceID := firstMetadata(ctx, workspaceMetadata); workspaceID != "" {
+		if workspaceID == personalWorkspace {
+			workspaceID = domain.PersonalWorkspaceID
+		}
+		ctx = domain.WithWorkspace(ctx, workspaceID)
+	}
+	return handler(ctx, req)
+}
+
+// logCalls logs calls that fail with a server error
+func logCalls(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if code := status.Code(err); code == codes.Internal || code == codes.Unknown {
+		slog.ErrorContext(ctx, "gRPC call failed", "method", info.FullMethod, "code", code.String(), "error", err)
+	}
+	return resp, err
+}
+
+// firstMetadata returns the first value of an incoming metadata key, "" if unset
+func firstMetadata(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(values[0])
+}
+
+// toStatus maps a use case error to the gRPC status the caller gets
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, usecase.ErrAICostCapReached):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,116 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/riverlin/aiexpense/internal/adapter/grpc/aiexpensev1"
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+func TestExpenseService(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := usecase.NewMockExpenseRepository()
+	categoryRepo := usecase.NewMockCategoryRepository()
+	aiService := usecase.NewMockAIService()
+	service := NewExpenseService(
+		usecase.NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, aiService),
+		usecase.NewGetExpensesUseCase(expenseRepo, categoryRepo),
+		usecase.NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, usecase.NewMockGroupRepository()),
+		usecase.NewParseConversationUseCase(aiService, nil, nil, "test", "test-model"),
+	)
+	apiKeyUC := usecase.NewAPIKeyUseCase(usecase.NewMockAPIKeyRepository(), "")
+	rpcKey, err := apiKeyUC.Create(ctx, &usecase.CreateAPIKeyRequest{Name: "bff", Scopes: []string{domain.APIKeyScopeExpensesRPC}})
+	require.NoError(t, err)
+	metricsKey, err := apiKeyUC.Create(ctx, &usecase.CreateAPIKeyRequest{Name: "grafana", Scopes: []string{domain.APIKeyScopeMetricsRead}})
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(service, apiKeyUC)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := aiexpensev1.NewExpenseServiceClient(conn)
+
+	withKey := func(key string) context.Context {
+		return metadata.AppendToOutgoingContext(ctx, "x-api-key", key)
+	}
+
+	// Calls need a key with the expenses:rpc scope
+	_, err = client.GetExpenses(ctx, &aiexpensev1.GetExpensesRequest{UserId: "user1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetExpenses(withKey(metricsKey.Key), &aiexpensev1.GetExpensesRequest{UserId: "user1"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	authed := withKey(rpcKey.Key)
+	date := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	created, err := client.CreateExpense(authed, &aiexpensev1.CreateExpenseRequest{
+		UserId:      "user1",
+		Description: "Lunch",
+		Amount:      250,
+		Date:        timestamppb.New(date),
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.GetId())
+	assert.Equal(t, 250.0, created.GetHomeAmount())
+
+	recorded, err := expenseRepo.GetByID(ctx, created.GetId())
+	require.NoError(t, err)
+	assert.True(t, recorded.ExpenseDate.Equal(date))
+
+	_, err = client.CreateExpense(authed, &aiexpensev1.CreateExpenseRequest{UserId: "user1", Description: "Lunch"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	listed, err := client.GetExpenses(authed, &aiexpensev1.GetExpensesRequest{UserId: "user1"})
+	require.NoError(t, err)
+	require.Len(t, listed.GetExpenses(), 1)
+	assert.Equal(t, "Lunch", listed.GetExpenses()[0].GetDescription())
+	assert.Equal(t, 250.0, listed.GetTotal())
+	assert.True(t, listed.GetExpenses()[0].GetDate().AsTime().Equal(date))
+
+	_, err = client.GetExpenses(authed, &aiexpensev1.GetExpensesRequest{UserId: "user1", SortBy: "name"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Workspace metadata scopes the call like the X-Workspace-ID header
+	inWorkspace := metadata.AppendToOutgoingContext(authed, "x-workspace-id", "ws_business")
+	listed, err = client.GetExpenses(inWorkspace, &aiexpensev1.GetExpensesRequest{UserId: "user1"})
+	require.NoError(t, err)
+	assert.Empty(t, listed.GetExpenses())
+
+	report, err := client.GenerateReport(authed, &aiexpensev1.GenerateReportRequest{
+		UserId:    "user1",
+		StartDate: timestamppb.New(date.AddDate(0, 0, -1)),
+		EndDate:   timestamppb.New(date.AddDate(0, 0, 1)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "monthly", report.GetReportType())
+	assert.Equal(t, 250.0, report.GetTotalExpenses())
+	assert.Equal(t, int32(1), report.GetTransactionCount())
+
+	parsed, err := client.ParseText(authed, &aiexpensev1.ParseTextRequest{UserId: "user1", Text: "breakfast $20"})
+	require.NoError(t, err)
+	require.Len(t, parsed.GetExpenses(), 1)
+	assert.Equal(t, 20.0, parsed.GetExpenses()[0].GetAmount())
+
+	_, err = client.ParseText(authed, &aiexpensev1.ParseTextRequest{UserId: "user1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -5,27 +5,30 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/riverlin/aiexpense/internal/domain"
 	"github.com/riverlin/aiexpense/internal/usecase"
 )
 
 type AICostHandler struct {
-	aiCostUC    *usecase.AICostUseCase
-	adminAPIKey string
+	aiCostUC  *usecase.AICostUseCase
+	costGuard *usecase.CostGuardUseCase
+	anomalies *usecase.AICostAnomalyDetector
 }
 
-func NewAICostHandler(aiCostUC *usecase.AICostUseCase, adminAPIKey string) *AICostHandler {
+func NewAICostHandler(aiCostUC *usecase.AICostUseCase) *AICostHandler {
 	return &AICostHandler{
-		aiCostUC:    aiCostUC,
-		adminAPIKey: adminAPIKey,
+		aiCostUC: aiCostUC,
 	}
 }
 
-func (h *AICostHandler) authenticateAdmin(r *http.Request) bool {
-	if h.adminAPIKey == "" {
-		return true
-	}
-	key := r.Header.Get("X-API-Key")
-	return key == h.adminAPIKey
+// SetCostGuard enables the spending cap endpoints
+func (h *AICostHandler) SetCostGuard(costGuard *usecase.CostGuardUseCase) {
+	h.costGuard = costGuard
+}
+
+// SetAnomalyDetector enables the AI cost anomaly alerts endpoint
+func (h *AICostHandler) SetAnomalyDetector(anomalies *usecase.AICostAnomalyDetector) {
+	h.anomalies = anomalies
 }
 
 func (h *AICostHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -35,11 +38,6 @@ func (h *AICostHandler) writeJSON(w http.ResponseWriter, status int, data interf
 }
 
 func (h *AICostHandler) GetAICostMetrics(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"status": "error", "error": "Unauthorized"})
-		return
-	}
-
 	ctx := r.Context()
 
 	daysStr := r.URL.Query().Get("days")
@@ -60,11 +58,6 @@ func (h *AICostHandler) GetAICostMetrics(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *AICostHandler) GetAICostSummary(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"status": "error", "error": "Unauthorized"})
-		return
-	}
-
 	ctx := r.Context()
 
 	daysStr := r.URL.Query().Get("days")
@@ -85,11 +78,6 @@ func (h *AICostHandler) GetAICostSummary(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *AICostHandler) GetAICostDaily(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"status": "error", "error": "Unauthorized"})
-		return
-	}
-
 	ctx := r.Context()
 
 	daysStr := r.URL.Query().Get("days")
@@ -110,11 +98,6 @@ func (h *AICostHandler) GetAICostDaily(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AICostHandler) GetAICostByOperation(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"status": "error", "error": "Unauthorized"})
-		return
-	}
-
 	ctx := r.Context()
 
 	daysStr := r.URL.Query().Get("days")
@@ -135,11 +118,6 @@ func (h *AICostHandler) GetAICostByOperation(w http.ResponseWriter, r *http.Requ
 }
 
 func (h *AICostHandler) GetAICostTopUsers(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"status": "error", "error": "Unauthorized"})
-		return
-	}
-
 	ctx := r.Context()
 
 	daysStr := r.URL.Query().Get("days")
@@ -167,10 +145,90 @@ func (h *AICostHandler) GetAICostTopUsers(w http.ResponseWriter, r *http.Request
 	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": resp})
 }
 
+// GetAICostCaps shows the global spending cap, optionally a user's cap, and all overrides
+func (h *AICostHandler) GetAICostCaps(w http.ResponseWriter, r *http.Request) {
+	if h.costGuard == nil {
+		h.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "error", "error": "AI spending caps are not configured"})
+		return
+	}
+
+	resp, err := h.costGuard.GetStatus(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": resp})
+}
+
+// UpdateAICostCap overrides the spending cap for "global" or a user ID
+func (h *AICostHandler) UpdateAICostCap(w http.ResponseWriter, r *http.Request) {
+	if h.costGuard == nil {
+		h.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "error", "error": "AI spending caps are not configured"})
+		return
+	}
+
+	var req struct {
+		DailyUSD   float64 `json:"daily_usd"`
+		MonthlyUSD float64 `json:"monthly_usd"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": "Invalid request body"})
+		return
+	}
+
+	costCap, err := h.costGuard.SetCap(r.Context(), &domain.AICostCap{
+		Scope:      r.PathValue("scope"),
+		DailyUSD:   req.DailyUSD,
+		MonthlyUSD: req.MonthlyUSD,
+	})
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": costCap})
+}
+
+// DeleteAICostCap removes an override so the configured cap applies again
+func (h *AICostHandler) DeleteAICostCap(w http.ResponseWriter, r *http.Request) {
+	if h.costGuard == nil {
+		h.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "error", "error": "AI spending caps are not configured"})
+		return
+	}
+
+	if err := h.costGuard.ResetCap(r.Context(), r.PathValue("scope")); err != nil {
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "message": "AI cost costCap reset"})
+}
+
+// GetAICostAnomalies lists the days whose AI spend was flagged as anomalous
+func (h *AICostHandler) GetAICostAnomalies(w http.ResponseWriter, r *http.Request) {
+	if h.anomalies == nil {
+		h.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "error", "error": "AI cost anomaly detection is not configured"})
+		return
+	}
+
+	anomalies, err := h.anomalies.ListAnomalies(r.Context())
+	if err != nil {
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": anomalies})
+}
+
 func RegisterAICostRoutes(mux *http.ServeMux, handler *AICostHandler) {
 	mux.HandleFunc("GET /api/metrics/ai-costs", handler.GetAICostMetrics)
 	mux.HandleFunc("GET /api/metrics/ai-costs/summary", handler.GetAICostSummary)
 	mux.HandleFunc("GET /api/metrics/ai-costs/daily", handler.GetAICostDaily)
 	mux.HandleFunc("GET /api/metrics/ai-costs/by-operation", handler.GetAICostByOperation)
 	mux.HandleFunc("GET /api/metrics/ai-costs/top-users", handler.GetAICostTopUsers)
+	mux.HandleFunc("GET /api/metrics/ai-costs/caps", handler.GetAICostCaps)
+	mux.HandleFunc("PUT /api/metrics/ai-costs/caps/{scope}", handler.UpdateAICostCap)
+	mux.HandleFunc("DELETE /api/metrics/ai-costs/caps/{scope}", handler.DeleteAICostCap)
+	mux.HandleFunc("GET /api/ai-costs/anomalies", handler.GetAICostAnomalies)
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -15,11 +16,10 @@ import (
 	"github.com/riverlin/aiexpense/internal/usecase"
 )
 
-var errTestNotFound = errors.New("not found")
-
 // Test repositories for API integration tests
 type TestExpenseRepository struct {
 	expenses map[string]*domain.Expense
+	deleted  map[string]*domain.Expense
 }
 
 func (r *TestExpenseRepository) Create(ctx context.Context, expense *domain.Expense) error {
@@ -27,11 +27,18 @@ func (r *TestExpenseRepository) Create(ctx context.Context, expense *domain.Expe
 	return nil
 }
 
+func (r *TestExpenseRepository) CreateBatch(ctx context.Context, expenses []*domain.Expense) error {
+	for _, expense := range expenses {
+		r.expenses[expense.ID] = expense
+	}
+	return nil
+}
+
 func (r *TestExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Expense, error) {
 	if exp, ok := r.expenses[id]; ok {
 		return exp, nil
 	}
-	return nil, errTestNotFound
+	return nil, domain.ErrNotFound
 }
 
 func (r *TestExpenseRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Expense, error) {
@@ -44,6 +51,10 @@ func (r *TestExpenseRepository) GetByUserID(ctx context.Context, userID string)
 	return result, nil
 }
 
+func (r *TestExpenseRepository) ListByUserID(ctx context.Context, userID string, opts domain.ExpenseListOptions) ([]*domain.Expense, error) {
+	return r.GetByUserID(ctx, userID)
+}
+
 func (r *TestExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, from, to time.Time) ([]*domain.Expense, error) {
 	var result []*domain.Expense
 	for _, exp := range r.expenses {
@@ -54,6 +65,34 @@ func (r *TestExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, use
 	return result, nil
 }
 
+func (r *TestExpenseRepository) GetByGroupIDAndDateRange(ctx context.Context, groupID string, from, to time.Time) ([]*domain.Expense, error) {
+	var result []*domain.Expense
+	for _, exp := range r.expenses {
+		if exp.GroupID != nil && *exp.GroupID == groupID && !exp.ExpenseDate.Before(from) && !exp.ExpenseDate.After(to) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
+func (r *TestExpenseRepository) selectTotals(query domain.ExpenseTotalsQuery) []*domain.Expense {
+	var selected []*domain.Expense
+	for _, exp := range r.expenses {
+		if query.Matches(exp) {
+			selected = append(selected, exp)
+		}
+	}
+	return selected
+}
+
+func (r *TestExpenseRepository) SumByCategoryAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.CategoryTotal, error) {
+	return domain.SumExpensesByCategory(r.selectTotals(query)), nil
+}
+
+func (r *TestExpenseRepository) SumByPeriodAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery, period string) ([]*domain.PeriodTotal, error) {
+	return domain.SumExpensesByPeriod(r.selectTotals(query), period), nil
+}
+
 func (r *TestExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID, categoryID string) ([]*domain.Expense, error) {
 	var result []*domain.Expense
 	for _, exp := range r.expenses {
@@ -64,16 +103,50 @@ func (r *TestExpenseRepository) GetByUserIDAndCategory(ctx context.Context, user
 	return result, nil
 }
 
+func (r *TestExpenseRepository) Filter(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, error) {
+	var result []*domain.Expense
+	for _, exp := range r.expenses {
+		if filter.Matches(exp) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
 func (r *TestExpenseRepository) Update(ctx context.Context, expense *domain.Expense) error {
 	r.expenses[expense.ID] = expense
 	return nil
 }
 
 func (r *TestExpenseRepository) Delete(ctx context.Context, id string) error {
+	if exp, ok := r.expenses[id]; ok {
+		if r.deleted == nil {
+			r.deleted = make(map[string]*domain.Expense)
+		}
+		now := time.Now()
+		exp.DeletedAt = &now
+		r.deleted[id] = exp
+	}
 	delete(r.expenses, id)
 	return nil
 }
 
+func (r *TestExpenseRepository) GetDeletedByID(ctx context.Context, id string) (*domain.Expense, error) {
+	if exp, ok := r.deleted[id]; ok {
+		return exp, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *TestExpenseRepository) Restore(ctx context.Context, id string) error {
+	if exp, ok := r.deleted[id]; ok {
+		exp.DeletedAt = nil
+		r.expenses[id] = exp
+		delete(r.deleted, id)
+	}
+	return nil
+}
+
 type TestUserRepository struct {
 	users map[string]*domain.User
 }
@@ -87,7 +160,12 @@ func (r *TestUserRepository) GetByID(ctx context.Context, userID string) (*domai
 	if user, ok := r.users[userID]; ok {
 		return user, nil
 	}
-	return nil, errTestNotFound
+	return nil, domain.ErrNotFound
+}
+
+func (r *TestUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.users[user.UserID] = user
+	return nil
 }
 
 func (r *TestUserRepository) Exists(ctx context.Context, userID string) (bool, error) {
@@ -108,7 +186,7 @@ func (r *TestCategoryRepository) GetByID(ctx context.Context, id string) (*domai
 	if cat, ok := r.categories[id]; ok {
 		return cat, nil
 	}
-	return nil, errTestNotFound
+	return nil, domain.ErrNotFound
 }
 
 func (r *TestCategoryRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Category, error) {
@@ -127,7 +205,7 @@ func (r *TestCategoryRepository) GetByUserIDAndName(ctx context.Context, userID,
 			return cat, nil
 		}
 	}
-	return nil, errTestNotFound
+	return nil, domain.ErrNotFound
 }
 
 func (r *TestCategoryRepository) Update(ctx context.Context, category *domain.Category) error {
@@ -148,6 +226,14 @@ func (r *TestCategoryRepository) GetKeywordsByCategory(ctx context.Context, cate
 	return []*domain.CategoryKeyword{}, nil
 }
 
+func (r *TestCategoryRepository) GetKeywordsByUserID(ctx context.Context, userID string) ([]*domain.CategoryKeyword, error) {
+	return []*domain.CategoryKeyword{}, nil
+}
+
+func (r *TestCategoryRepository) UpdateKeyword(ctx context.Context, keyword *domain.CategoryKeyword) error {
+	return nil
+}
+
 func (r *TestCategoryRepository) DeleteKeyword(ctx context.Context, id string) error {
 	return nil
 }
@@ -177,6 +263,8 @@ func (s *TestAIService) ParseExpense(ctx context.Context, text string, userID st
 type TestExchangeRateService struct {
 	refreshCalled bool
 	refreshErr    error
+	rates         []*domain.ExchangeRate
+	ratesBase     string
 }
 
 var _ domain.ExchangeRateService = (*TestExchangeRateService)(nil)
@@ -194,6 +282,11 @@ func (s *TestExchangeRateService) GetRate(ctx context.Context, fromCurrency, toC
 	return nil, nil
 }
 
+func (s *TestExchangeRateService) GetRates(ctx context.Context, baseCurrency string, date time.Time) ([]*domain.ExchangeRate, error) {
+	s.ratesBase = baseCurrency
+	return s.rates, nil
+}
+
 func (s *TestAIService) SuggestCategory(ctx context.Context, description string, userID string) (*ai.SuggestCategoryResponse, error) {
 	return &ai.SuggestCategoryResponse{
 		Category: "food",
@@ -228,6 +321,14 @@ func (r *TestMetricsRepository) GetNewUsersPerDay(ctx context.Context, from, to
 	return []*domain.DailyMetrics{}, nil
 }
 
+func (r *TestMetricsRepository) GetUserActivity(ctx context.Context, since time.Time) ([]*domain.UserActivity, error) {
+	return []*domain.UserActivity{}, nil
+}
+
+func (r *TestMetricsRepository) GetPlatformMetrics(ctx context.Context, from, to time.Time) ([]*domain.PlatformMetrics, error) {
+	return []*domain.PlatformMetrics{}, nil
+}
+
 // TestPolicyRepository for API integration tests
 type TestPolicyRepository struct {
 	policies map[string]*domain.Policy
@@ -237,7 +338,27 @@ func (r *TestPolicyRepository) GetByKey(ctx context.Context, key string) (*domai
 	if p, ok := r.policies[key]; ok {
 		return p, nil
 	}
-	return nil, nil // Return nil if not found (matching sqlite behavior)
+	return nil, domain.ErrNotFound
+}
+
+// TestExpenseAuditRepository for API integration tests
+type TestExpenseAuditRepository struct {
+	entries []*domain.ExpenseAuditEntry
+}
+
+func (r *TestExpenseAuditRepository) Create(ctx context.Context, entry *domain.ExpenseAuditEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *TestExpenseAuditRepository) GetByExpenseID(ctx context.Context, expenseID string) ([]*domain.ExpenseAuditEntry, error) {
+	var result []*domain.ExpenseAuditEntry
+	for _, entry := range r.entries {
+		if entry.ExpenseID == expenseID {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
 }
 
 // TestPricingRepository for API integration tests
@@ -250,7 +371,16 @@ func (r *TestPricingRepository) GetByProviderAndModel(ctx context.Context, provi
 	if p, ok := r.pricing[key]; ok {
 		return p, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
+}
+
+func (r *TestPricingRepository) GetByID(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	for _, p := range r.pricing {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrNotFound
 }
 
 func (r *TestPricingRepository) GetAll(ctx context.Context) ([]*domain.PricingConfig, error) {
@@ -320,6 +450,10 @@ func (r *TestAICostRepository) GetByUserSummary(ctx context.Context, from, to ti
 	return []*domain.AICostByUser{}, nil
 }
 
+func (r *TestAICostRepository) GetUserSummary(ctx context.Context, userID string, from, to time.Time) (*domain.AICostSummary, error) {
+	return &domain.AICostSummary{}, nil
+}
+
 // TestAPIAutoSignupFlow tests complete auto-signup flow
 func TestAPIAutoSignupFlow(t *testing.T) {
 	userRepo := &TestUserRepository{users: make(map[string]*domain.User)}
@@ -331,7 +465,7 @@ func TestAPIAutoSignupFlow(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		userRepo, categoryRepo, nil, nil, "",
+		userRepo, categoryRepo, nil, nil,
 	)
 
 	// Create request body
@@ -369,7 +503,7 @@ func TestAPIAutoSignup(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		userRepo, categoryRepo, nil, nil, "",
+		userRepo, categoryRepo, nil, nil,
 	)
 
 	bodyMap := map[string]string{
@@ -416,7 +550,7 @@ func TestAPIParseExpenses(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		userRepo, categoryRepo, nil, nil, "",
+		userRepo, categoryRepo, nil, nil,
 	)
 
 	bodyMap := map[string]string{
@@ -437,6 +571,12 @@ func TestAPIParseExpenses(t *testing.T) {
 }
 
 // TestAPICreateExpense tests expense creation
+// anonymous marks req as served without a token under AuthConfig.AllowAnonymous,
+// so handlers act for the user_id it names
+func anonymous(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), anonymousContextKey{}, true))
+}
+
 func TestAPICreateExpense(t *testing.T) {
 	userRepo := &TestUserRepository{users: make(map[string]*domain.User)}
 	categoryRepo := &TestCategoryRepository{categories: make(map[string]*domain.Category)}
@@ -461,7 +601,7 @@ func TestAPICreateExpense(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		userRepo, categoryRepo, expenseRepo, nil, "",
+		userRepo, categoryRepo, expenseRepo, nil,
 	)
 
 	bodyMap := map[string]interface{}{
@@ -475,7 +615,7 @@ func TestAPICreateExpense(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
-	handler.CreateExpense(w, req)
+	handler.CreateExpense(w, anonymous(req))
 
 	if w.Code != http.StatusCreated {
 		t.Errorf("Expected %d, got %d", http.StatusCreated, w.Code)
@@ -488,6 +628,70 @@ func TestAPICreateExpense(t *testing.T) {
 	}
 }
 
+// TestAPICreateExpenseBatch tests bulk expense creation with a failing entry
+func TestAPICreateExpenseBatch(t *testing.T) {
+	userRepo := &TestUserRepository{users: make(map[string]*domain.User)}
+	categoryRepo := &TestCategoryRepository{categories: make(map[string]*domain.Category)}
+	expenseRepo := &TestExpenseRepository{expenses: make(map[string]*domain.Expense)}
+	aiService := &TestAIService{}
+
+	handler := NewHandler(
+		usecase.NewAutoSignupUseCase(userRepo, categoryRepo),
+		nil,
+		usecase.NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, aiService),
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil,
+		nil,
+		userRepo, categoryRepo, expenseRepo, nil,
+	)
+
+	post := func(body map[string]interface{}) *httptest.ResponseRecorder {
+		bodyBytes, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/api/expenses/batch", bytes.NewReader(bodyBytes))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.CreateExpenseBatch(w, anonymous(req))
+		return w
+	}
+
+	w := post(map[string]interface{}{
+		"user_id": "test_user_1",
+		"expenses": []map[string]interface{}{
+			{"description": "早餐", "amount": 50},
+			{"description": "午餐", "amount": -1},
+			{"description": "晚餐", "amount": 200},
+		},
+	})
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusMultiStatus, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data BatchExpenseResponse `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Data.Created != 2 || resp.Data.Failed != 1 {
+		t.Errorf("Expected 2 created and 1 failed, got %+v", resp.Data)
+	}
+	if len(resp.Data.Results) != 3 || resp.Data.Results[1].Status != "failed" || resp.Data.Results[1].Error == "" {
+		t.Errorf("Expected the second entry reported as failed, got %+v", resp.Data.Results)
+	}
+	if expenses, _ := expenseRepo.GetByUserID(context.Background(), "test_user_1"); len(expenses) != 2 {
+		t.Errorf("Expected 2 expenses created, got %d", len(expenses))
+	}
+
+	w = post(map[string]interface{}{
+		"user_id":  "test_user_1",
+		"expenses": []map[string]interface{}{{"description": "咖啡", "amount": 80}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected %d when every entry is created, got %d", http.StatusCreated, w.Code)
+	}
+
+	if w := post(map[string]interface{}{"user_id": "test_user_1"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected %d for an empty batch, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 // TestAPIGetExpenses tests expense retrieval
 func TestAPIGetExpenses(t *testing.T) {
 	userRepo := &TestUserRepository{users: make(map[string]*domain.User)}
@@ -519,7 +723,7 @@ func TestAPIGetExpenses(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		userRepo, categoryRepo, expenseRepo, nil, "",
+		userRepo, categoryRepo, expenseRepo, nil,
 	)
 
 	req := httptest.NewRequest("GET", "/api/expenses?user_id=test_user_1", nil)
@@ -539,6 +743,191 @@ func TestAPIGetExpenses(t *testing.T) {
 	}
 }
 
+// TestAPIExpenseResourceRoutes tests the /api/expenses/{id} routes and the deprecated body-ID aliases
+func TestAPIExpenseResourceRoutes(t *testing.T) {
+	userRepo := &TestUserRepository{users: make(map[string]*domain.User)}
+	categoryRepo := &TestCategoryRepository{categories: make(map[string]*domain.Category)}
+	expenseRepo := &TestExpenseRepository{expenses: make(map[string]*domain.Expense)}
+
+	for _, id := range []string{"exp_001", "exp_002"} {
+		expenseRepo.Create(context.Background(), &domain.Expense{
+			ID:          id,
+			UserID:      "test_user_1",
+			Description: "Test expense",
+			Amount:      20.0,
+			ExpenseDate: time.Now(),
+			CreatedAt:   time.Now(),
+		})
+	}
+
+	auditRepo := &TestExpenseAuditRepository{}
+	deleteUC := usecase.NewDeleteExpenseUseCase(expenseRepo)
+	deleteUC.SetAuditRepository(auditRepo)
+
+	handler := NewHandler(
+		nil, nil, nil,
+		usecase.NewGetExpensesUseCase(expenseRepo, categoryRepo),
+		nil,
+		deleteUC,
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		userRepo, categoryRepo, expenseRepo, nil,
+	)
+	historyHandler := NewExpenseHistoryHandler(usecase.NewExpenseAuditUseCase(auditRepo, expenseRepo))
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, Handlers{API: handler, History: historyHandler})
+
+	serve := func(method, target string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, anonymous(req))
+		return w
+	}
+
+	if w := serve("GET", "/api/expenses/exp_001?user_id=test_user_1", nil); w.Code != http.StatusOK {
+		t.Errorf("GET by id: expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := serve("GET", "/api/expenses/exp_001?user_id=someone_else", nil); w.Code != http.StatusNotFound {
+		t.Errorf("GET another user's expense: expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w := serve("DELETE", "/api/v1/expenses/exp_001?user_id=test_user_1", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("DELETE by id: expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Deprecation") != "" {
+		t.Error("DELETE by id should not be marked deprecated")
+	}
+	if _, ok := expenseRepo.expenses["exp_001"]; ok {
+		t.Error("Expected exp_001 to be deleted")
+	}
+
+	if w := serve("POST", "/api/expenses/exp_001/restore?user_id=someone_else", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Restore another user's expense: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := serve("POST", "/api/expenses/exp_001/restore?user_id=test_user_1", nil); w.Code != http.StatusOK {
+		t.Errorf("Restore: expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if _, ok := expenseRepo.expenses["exp_001"]; !ok {
+		t.Error("Expected exp_001 to be restored")
+	}
+	if w := serve("POST", "/api/expenses/exp_001/restore?user_id=test_user_1", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Restore an expense that is not deleted: expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = serve("GET", "/api/expenses/exp_001/history?user_id=test_user_1", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("History: expected %d, got %d", http.StatusOK, w.Code)
+	}
+	var history struct {
+		Data []*domain.ExpenseAuditEntry `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&history)
+	if len(history.Data) != 2 || history.Data[0].Action != domain.ExpenseAuditDelete || history.Data[1].Action != domain.ExpenseAuditRestore {
+		t.Errorf("History: expected delete and restore entries, got %+v", history.Data)
+	}
+	for _, entry := range history.Data {
+		if entry.Actor != "test_user_1" || entry.Channel != domain.AuditChannelAPI {
+			t.Errorf("History: expected changes by test_user_1 via api, got %s via %s", entry.Actor, entry.Channel)
+		}
+	}
+	if w := serve("GET", "/api/expenses/exp_001/history?user_id=someone_else", nil); w.Code != http.StatusNotFound {
+		t.Errorf("History of another user's expense: expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	body, _ := json.Marshal(map[string]string{"id": "exp_002", "user_id": "test_user_1"})
+	w = serve("DELETE", "/api/expenses", body)
+	if w.Code != http.StatusOK {
+		t.Errorf("Legacy DELETE: expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Deprecation") != "true" {
+		t.Error("Legacy DELETE should be marked deprecated")
+	}
+	if _, ok := expenseRepo.expenses["exp_002"]; ok {
+		t.Error("Expected exp_002 to be deleted")
+	}
+}
+
+// TestAPIImportExpenses tests importing a CSV bank statement through POST /api/import
+func TestAPIImportExpenses(t *testing.T) {
+	categoryRepo := &TestCategoryRepository{categories: make(map[string]*domain.Category)}
+	expenseRepo := &TestExpenseRepository{expenses: make(map[string]*domain.Expense)}
+	createUC := usecase.NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, &TestAIService{})
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, Handlers{API: &Handler{}, Import: NewImportHandler(usecase.NewImportUseCase(expenseRepo, createUC))})
+
+	upload := func(fields map[string]string, filename, content string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		for key, value := range fields {
+			form.WriteField(key, value)
+		}
+		if filename != "" {
+			part, _ := form.CreateFormFile("file", filename)
+			part.Write([]byte(content))
+		}
+		form.Close()
+
+		req := httptest.NewRequest("POST", "/api/import", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, anonymous(req))
+		return w
+	}
+
+	statement := "Posted,Payee,Out\n2026-03-02,Coffee,3.50\n2026-03-03,Book,12\n"
+	w := upload(map[string]string{
+		"user_id": "test_user_1",
+		"account": "Visa",
+		"mapping": `{"date_column": "Posted", "description_column": "Payee", "debit_column": "Out"}`,
+	}, "statement.csv", statement)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data usecase.ImportResult `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Data.Imported) != 2 {
+		t.Fatalf("expected 2 imported expenses, got %+v", resp.Data)
+	}
+	expenses, _ := expenseRepo.GetByUserID(context.Background(), "test_user_1")
+	if len(expenses) != 2 || expenses[0].Account != "Visa" {
+		t.Errorf("expected 2 expenses on the Visa account, got %d", len(expenses))
+	}
+
+	if w := upload(map[string]string{"user_id": "test_user_1"}, "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("missing file: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := upload(map[string]string{"user_id": "test_user_1", "mapping": "{"}, "statement.csv", statement); w.Code != http.StatusBadRequest {
+		t.Errorf("bad mapping: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	// A signed-in user imports into their own ledger whatever user_id the form names
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("user_id", "victim")
+	form.WriteField("mapping", `{"date_column": "Posted", "description_column": "Payee", "debit_column": "Out"}`)
+	part, _ := form.CreateFormFile("file", "statement.csv")
+	part.Write([]byte("Posted,Payee,Out\n2026-03-04,Lunch,8\n"))
+	form.Close()
+	req := httptest.NewRequest("POST", "/api/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req = req.WithContext(context.WithValue(req.Context(), authContextKey{}, "test_user_1"))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("signed-in import: expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if victims, _ := expenseRepo.GetByUserID(context.Background(), "victim"); len(victims) != 0 {
+		t.Errorf("expected nothing imported for the named user, got %d expenses", len(victims))
+	}
+	if expenses, _ := expenseRepo.GetByUserID(context.Background(), "test_user_1"); len(expenses) != 3 {
+		t.Errorf("expected the statement imported for the signed-in user, got %d expenses", len(expenses))
+	}
+}
+
 // TestAPIMissingRequired tests error handling for missing required fields
 func TestAPIMissingRequired(t *testing.T) {
 	policyRepo := &TestPolicyRepository{policies: make(map[string]*domain.Policy)}
@@ -551,7 +940,7 @@ func TestAPIMissingRequired(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		nil, nil, nil, nil, "",
+		nil, nil, nil, nil,
 	)
 
 	// Missing user_id
@@ -586,7 +975,7 @@ func TestAPINotFound(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		userRepo, categoryRepo, expenseRepo, nil, "",
+		userRepo, categoryRepo, expenseRepo, nil,
 	)
 
 	// Try to get expenses for non-existent user
@@ -602,6 +991,34 @@ func TestAPINotFound(t *testing.T) {
 	}
 }
 
+// TestAPIReportsRequireSignIn tests that per-user reports never act for a
+// user_id named in the query, even when anonymous requests are allowed
+func TestAPIReportsRequireSignIn(t *testing.T) {
+	handler := NewHandler(
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, nil, nil,
+	)
+
+	tests := []struct {
+		name   string
+		path   string
+		handle http.HandlerFunc
+	}{
+		{name: "Category trends", path: "/api/reports/category-trends?user_id=victim", handle: handler.GetCategoryTrends},
+		{name: "Report comparison", path: "/api/reports/compare?user_id=victim", handle: handler.CompareReports},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handle(w, anonymous(httptest.NewRequest("GET", tt.path, nil)))
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("Expected %d, got %d: %s", http.StatusUnauthorized, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
 // TestAPICategoryManagement tests category operations
 func TestAPICategoryManagement(t *testing.T) {
 	userRepo := &TestUserRepository{users: make(map[string]*domain.User)}
@@ -623,7 +1040,7 @@ func TestAPICategoryManagement(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		userRepo, categoryRepo, nil, nil, "",
+		userRepo, categoryRepo, nil, nil,
 	)
 
 	// Create category
@@ -637,7 +1054,7 @@ func TestAPICategoryManagement(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
-	handler.CreateCategory(w, req)
+	handler.CreateCategory(w, anonymous(req))
 
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected %d, got %d", http.StatusOK, w.Code)
@@ -672,7 +1089,7 @@ func TestAPIMultipleExpenses(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		userRepo, categoryRepo, expenseRepo, nil, "",
+		userRepo, categoryRepo, expenseRepo, nil,
 	)
 
 	// Create first expense
@@ -685,7 +1102,7 @@ func TestAPIMultipleExpenses(t *testing.T) {
 	req1 := httptest.NewRequest("POST", "/api/expenses", bytes.NewReader(bodyBytes1))
 	req1.Header.Set("Content-Type", "application/json")
 	w1 := httptest.NewRecorder()
-	handler.CreateExpense(w1, req1)
+	handler.CreateExpense(w1, anonymous(req1))
 	if w1.Code != http.StatusCreated {
 		t.Errorf("First expense: expected %d, got %d", http.StatusCreated, w1.Code)
 	}
@@ -700,7 +1117,7 @@ func TestAPIMultipleExpenses(t *testing.T) {
 	req2 := httptest.NewRequest("POST", "/api/expenses", bytes.NewReader(bodyBytes2))
 	req2.Header.Set("Content-Type", "application/json")
 	w2 := httptest.NewRecorder()
-	handler.CreateExpense(w2, req2)
+	handler.CreateExpense(w2, anonymous(req2))
 	if w2.Code != http.StatusCreated {
 		t.Errorf("Second expense: expected %d, got %d", http.StatusCreated, w2.Code)
 	}
@@ -723,7 +1140,7 @@ func TestAPIConcurrentRequests(t *testing.T) {
 		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 		usecase.NewGetPolicyUseCase(policyRepo),
 		nil,
-		userRepo, categoryRepo, nil, nil, "",
+		userRepo, categoryRepo, nil, nil,
 	)
 
 	// Simulate concurrent signup requests
@@ -759,20 +1176,19 @@ func TestAPIConcurrentRequests(t *testing.T) {
 }
 
 func TestRefreshExchangeRates(t *testing.T) {
-	newHandler := func(svc domain.ExchangeRateService, adminKey string) *Handler {
+	newHandler := func(svc domain.ExchangeRateService) *Handler {
 		policyRepo := &TestPolicyRepository{policies: make(map[string]*domain.Policy)}
 		return NewHandler(
 			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
 			usecase.NewGetPolicyUseCase(policyRepo),
 			svc,
 			nil, nil, nil, nil,
-			adminKey,
 		)
 	}
 
 	t.Run("Success", func(t *testing.T) {
 		svc := &TestExchangeRateService{}
-		handler := newHandler(svc, "secret")
+		handler := newHandler(svc)
 		req := httptest.NewRequest("POST", "/api/exchange-rates/refresh", nil)
 		req.Header.Set("X-API-Key", "secret")
 		w := httptest.NewRecorder()
@@ -788,10 +1204,12 @@ func TestRefreshExchangeRates(t *testing.T) {
 
 	t.Run("Unauthorized", func(t *testing.T) {
 		svc := &TestExchangeRateService{}
-		handler := newHandler(svc, "secret")
+		mux := http.NewServeMux()
+		apiKeyHandler := NewAPIKeyHandler(usecase.NewAPIKeyUseCase(&TestAPIKeyRepository{}, "secret"))
+		RegisterRoutes(mux, Handlers{API: newHandler(svc), APIKey: apiKeyHandler})
 		req := httptest.NewRequest("POST", "/api/exchange-rates/refresh", nil)
 		w := httptest.NewRecorder()
-		handler.RefreshExchangeRates(w, req)
+		mux.ServeHTTP(w, req)
 
 		if w.Code != http.StatusUnauthorized {
 			t.Fatalf("expected %d, got %d", http.StatusUnauthorized, w.Code)
@@ -803,7 +1221,7 @@ func TestRefreshExchangeRates(t *testing.T) {
 
 	t.Run("RefreshError", func(t *testing.T) {
 		svc := &TestExchangeRateService{refreshErr: errors.New("boom")}
-		handler := newHandler(svc, "secret")
+		handler := newHandler(svc)
 		req := httptest.NewRequest("POST", "/api/exchange-rates/refresh", nil)
 		req.Header.Set("X-API-Key", "secret")
 		w := httptest.NewRecorder()
@@ -815,7 +1233,7 @@ func TestRefreshExchangeRates(t *testing.T) {
 	})
 
 	t.Run("ServiceUnavailable", func(t *testing.T) {
-		handler := newHandler(nil, "secret")
+		handler := newHandler(nil)
 		req := httptest.NewRequest("POST", "/api/exchange-rates/refresh", nil)
 		req.Header.Set("X-API-Key", "secret")
 		w := httptest.NewRecorder()
@@ -826,3 +1244,68 @@ func TestRefreshExchangeRates(t *testing.T) {
 		}
 	})
 }
+
+func TestGetCurrencyRates(t *testing.T) {
+	policyRepo := &TestPolicyRepository{policies: make(map[string]*domain.Policy)}
+	newHandler := func(svc domain.ExchangeRateService) *Handler {
+		return NewHandler(
+			nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+			usecase.NewGetPolicyUseCase(policyRepo),
+			svc,
+			nil, nil, nil, nil,
+		)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		svc := &TestExchangeRateService{rates: []*domain.ExchangeRate{
+			{BaseCurrency: "USD", TargetCurrency: "JPY", Rate: 150.5},
+			{BaseCurrency: "USD", TargetCurrency: "TWD", Rate: 32.1},
+		}}
+		handler := newHandler(svc)
+		req := httptest.NewRequest("GET", "/api/currencies/rates?base=usd&date=2025-01-15&symbols=twd", nil)
+		w := httptest.NewRecorder()
+		handler.GetCurrencyRates(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d", http.StatusOK, w.Code)
+		}
+		if svc.ratesBase != "USD" {
+			t.Errorf("expected base USD, got %q", svc.ratesBase)
+		}
+
+		var resp struct {
+			Data CurrencyRatesResponse `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Data.Date != "2025-01-15" {
+			t.Errorf("expected date 2025-01-15, got %q", resp.Data.Date)
+		}
+		if len(resp.Data.Rates) != 1 || resp.Data.Rates["TWD"] != 32.1 {
+			t.Errorf("expected only TWD rate, got %v", resp.Data.Rates)
+		}
+	})
+
+	t.Run("InvalidDate", func(t *testing.T) {
+		handler := newHandler(&TestExchangeRateService{})
+		req := httptest.NewRequest("GET", "/api/currencies/rates?date=15-01-2025", nil)
+		w := httptest.NewRecorder()
+		handler.GetCurrencyRates(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("ServiceUnavailable", func(t *testing.T) {
+		handler := newHandler(nil)
+		req := httptest.NewRequest("GET", "/api/currencies/rates", nil)
+		w := httptest.NewRecorder()
+		handler.GetCurrencyRates(w, req)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// APIKeyHandler manages admin API keys and checks them on admin endpoints
+type APIKeyHandler struct {
+	apiKeyUC *usecase.APIKeyUseCase
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeyUC *usecase.APIKeyUseCase) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUC: apiKeyUC,
+	}
+}
+
+func (h *APIKeyHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// RequireScope serves next only for requests whose X-API-Key grants scope.
+// Requests without a valid key get 401; keys lacking the scope get 403.
+func (h *APIKeyHandler) RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := h.apiKeyUC.Authorize(r.Context(), r.Header.Get("X-API-Key"), scope)
+		switch {
+		case err == nil:
+			next(w, r)
+		case errors.Is(err, usecase.ErrAPIKeyInvalid):
+			h.writeJSON(w, http.StatusUnauthorized, &Response{Status: "error", Error: "Unauthorized"})
+		case errors.Is(err, usecase.ErrAPIKeyScope):
+			h.writeJSON(w, http.StatusForbidden, &Response{Status: "error", Error: "API key lacks the " + scope + " scope"})
+		default:
+			h.writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		}
+	}
+}
+
+// requireScope guards an admin endpoint with apiKeyHandler, if there is one
+func requireScope(apiKeyHandler *APIKeyHandler, scope string, next http.HandlerFunc) http.HandlerFunc {
+	if apiKeyHandler == nil {
+		return next
+	}
+	return apiKeyHandler.RequireScope(scope, next)
+}
+
+// CreateAPIKey handles POST /api/admin/api-keys. The response includes the
+// key, which is not returned again.
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req usecase.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+
+	key, err := h.apiKeyUC.Create(r.Context(), &req)
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, &Response{Status: "success", Data: key})
+}
+
+// ListAPIKeys handles GET /api/admin/api-keys
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeyUC.List(r.Context())
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: map[string]interface{}{
+		"keys":   keys,
+		"scopes": domain.APIKeyScopes,
+	}})
+}
+
+// DeleteAPIKey handles DELETE /api/admin/api-keys/{id} by revoking the key
+func (h *APIKeyHandler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := h.apiKeyUC.Revoke(r.Context(), r.PathValue("id")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrAPIKeyNotFound) {
+			status = http.StatusNotFound
+		}
+		h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Message: localize(r, "api.api_key_revoked")})
+}
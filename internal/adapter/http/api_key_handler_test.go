@@ -0,0 +1,110 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// TestAPIKeyRepository is an in-memory API key repository for handler tests
+type TestAPIKeyRepository struct {
+	keys []*domain.APIKey
+}
+
+func (r *TestAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *TestAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			return key, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *TestAPIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	return r.keys, nil
+}
+
+func (r *TestAPIKeyRepository) Delete(ctx context.Context, id string) error {
+	for i, key := range r.keys {
+		if key.ID == id {
+			r.keys = append(r.keys[:i], r.keys[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *TestAPIKeyRepository) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	return nil
+}
+
+func TestAPIKeyHandler(t *testing.T) {
+	policyRepo := &TestPolicyRepository{policies: make(map[string]*domain.Policy)}
+	handler := NewHandler(
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		usecase.NewMetricsUseCase(nil),
+		usecase.NewGetPolicyUseCase(policyRepo),
+		nil, nil, nil, nil, nil,
+	)
+	mux := http.NewServeMux()
+	apiKeyHandler := NewAPIKeyHandler(usecase.NewAPIKeyUseCase(&TestAPIKeyRepository{}, "bootstrap"))
+	RegisterRoutes(mux, Handlers{API: handler, APIKey: apiKeyHandler})
+
+	serve := func(method, path, key string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &buf)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("POST", "/api/admin/api-keys", "bootstrap", map[string]interface{}{
+		"name":   "grafana",
+		"scopes": []string{domain.APIKeyScopeMetricsRead},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected the bootstrap key to create a key, got %d %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID  string `json:"id"`
+			Key string `json:"key"`
+		} `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&created)
+
+	if w := serve("GET", "/api/metrics/events", created.Data.Key, nil); w.Code != http.StatusOK {
+		t.Errorf("expected the key to read metrics, got %d %s", w.Code, w.Body.String())
+	}
+	if w := serve("GET", "/api/admin/api-keys", created.Data.Key, nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 without the admin scope, got %d", w.Code)
+	}
+	if w := serve("GET", "/api/metrics/events", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a key, got %d", w.Code)
+	}
+
+	if w := serve("DELETE", "/api/admin/api-keys/"+created.Data.ID, "bootstrap", nil); w.Code != http.StatusOK {
+		t.Fatalf("expected the key to be revoked, got %d %s", w.Code, w.Body.String())
+	}
+	if w := serve("GET", "/api/metrics/events", created.Data.Key, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a revoked key, got %d", w.Code)
+	}
+}
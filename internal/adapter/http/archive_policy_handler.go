@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// ArchivePolicyHandler lets users choose when their expenses are archived and
+// admins preview what the archive policies would do
+type ArchivePolicyHandler struct {
+	policyUC *usecase.ArchivePolicyUseCase
+}
+
+// NewArchivePolicyHandler creates a new archive policy handler
+func NewArchivePolicyHandler(policyUC *usecase.ArchivePolicyUseCase) *ArchivePolicyHandler {
+	return &ArchivePolicyHandler{
+		policyUC: policyUC,
+	}
+}
+
+func (h *ArchivePolicyHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *ArchivePolicyHandler) writeError(w http.ResponseWriter, err error) {
+	status := errorStatus(err, http.StatusInternalServerError)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidArchivePolicy):
+		status = http.StatusBadRequest
+	case errors.Is(err, usecase.ErrArchivesDisabled):
+		status = http.StatusServiceUnavailable
+	}
+	h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+}
+
+// archivePolicyRequest is the body of PUT /api/users/me/archive-policy
+type archivePolicyRequest struct {
+	ArchiveAfterMonths int `json:"archive_after_months"`
+	PurgeAfterDays     int `json:"purge_after_days"`
+}
+
+// GetMyPolicy handles GET /api/users/me/archive-policy, returning the policy
+// that applies to the signed-in user
+func (h *ArchivePolicyHandler) GetMyPolicy(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	policy, err := h.policyUC.GetPolicy(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: policy})
+}
+
+// UpdateMyPolicy handles PUT /api/users/me/archive-policy, giving the
+// signed-in user a policy of their own
+func (h *ArchivePolicyHandler) UpdateMyPolicy(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	var req archivePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+
+	policy, err := h.policyUC.SavePolicy(r.Context(), &domain.ArchivePolicy{
+		UserID:             userID,
+		ArchiveAfterMonths: req.ArchiveAfterMonths,
+		PurgeAfterDays:     req.PurgeAfterDays,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: policy})
+}
+
+// ResetMyPolicy handles DELETE /api/users/me/archive-policy, putting the
+// signed-in user back on the global policy
+func (h *ArchivePolicyHandler) ResetMyPolicy(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	policy, err := h.policyUC.ResetPolicy(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: policy})
+}
+
+// GetReport handles GET /api/admin/archive-policy/report, a dry run listing
+// what applying the archive policies now would archive and purge
+func (h *ArchivePolicyHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.policyUC.Preview(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: report})
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// AttachmentHandler serves files attached to expenses, e.g. receipt photos
+type AttachmentHandler struct {
+	attachmentUC *usecase.AttachmentUseCase
+}
+
+func NewAttachmentHandler(attachmentUC *usecase.AttachmentUseCase) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentUC: attachmentUC,
+	}
+}
+
+func (h *AttachmentHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// ListAttachments returns the attachments linked to an expense
+func (h *AttachmentHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	expenseID := r.PathValue("id")
+	userID := r.URL.Query().Get("user_id")
+	if expenseID == "" || userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "expense id and user_id are required"})
+		return
+	}
+
+	resp, err := h.attachmentUC.ListForExpense(r.Context(), expenseID, userID)
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// GetAttachment streams the contents of an attachment
+func (h *AttachmentHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	userID := r.URL.Query().Get("user_id")
+	if id == "" || userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "attachment id and user_id are required"})
+		return
+	}
+
+	attachment, data, err := h.attachmentUC.Open(r.Context(), id, userID)
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	contentType := attachment.MimeType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,262 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/logging"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+type authContextKey struct{}
+
+// anonymousContextKey marks requests served without a token under
+// AuthConfig.AllowAnonymous, the only ones that may act for the user_id they name
+type anonymousContextKey struct{}
+
+// sessionCookie holds the dashboard's access token after a messenger login
+const sessionCookie = "aiexpense_session"
+
+// AuthHandler signs users in to the API with codes sent to their messenger
+type AuthHandler struct {
+	authUC *usecase.AuthUseCase
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(authUC *usecase.AuthUseCase) *AuthHandler {
+	return &AuthHandler{
+		authUC: authUC,
+	}
+}
+
+func (h *AuthHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// RequestCode handles POST /api/auth/code by sending a sign-in code to the
+// user's messenger
+func (h *AuthHandler) RequestCode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	if err := h.authUC.RequestCode(r.Context(), req.UserID); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, usecase.ErrNoMessenger):
+			status = http.StatusBadRequest
+		case errors.Is(err, usecase.ErrTooManyCodeRequests):
+			status = http.StatusTooManyRequests
+		}
+		h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusAccepted, &Response{Status: "success", Message: localize(r, "api.code_sent")})
+}
+
+// IssueToken handles POST /api/auth/token by exchanging a sign-in code for an
+// access token
+func (h *AuthHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+		Code   string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" || req.Code == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id and code are required"})
+		return
+	}
+
+	token, err := h.authUC.VerifyCode(r.Context(), req.UserID, req.Code)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrInvalidLoginCode) {
+			status = http.StatusUnauthorized
+		}
+		h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: token})
+}
+
+// Login handles POST /api/auth/login/{messenger}, signing a dashboard user in
+// with their messenger's web login: the LINE Login callback's code and
+// redirect_uri, or the fields the Telegram Login widget returns. The session
+// token is set as an HttpOnly cookie and also returned.
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+	payload := make(map[string]string, len(fields))
+	for key, value := range fields {
+		if value != nil {
+			payload[key] = fmt.Sprint(value)
+		}
+	}
+
+	token, err := h.authUC.Login(r.Context(), r.PathValue("messenger"), payload)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, usecase.ErrLoginNotSupported):
+			status = http.StatusNotFound
+		case errors.Is(err, usecase.ErrLoginFailed):
+			status = http.StatusUnauthorized
+		case errors.Is(err, usecase.ErrUnknownUser):
+			status = http.StatusForbidden
+		}
+		h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token.Token,
+		Path:     "/",
+		Expires:  token.ExpiresAt,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: token})
+}
+
+// Logout handles POST /api/auth/logout by clearing the session cookie
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Message: localize(r, "api.logged_out")})
+}
+
+// AuthConfig controls how API callers are identified
+type AuthConfig struct {
+	// AllowAnonymous serves user requests without a token, acting for the
+	// user_id they name, as before tokens existed. Only for development: anyone
+	// can act as any user.
+	AllowAnonymous bool
+
+	// PublicPaths are path prefixes served without a token, such as sign-in,
+	// messenger webhooks and shared report links. An /api prefix covers the
+	// /api/v1 path too.
+	PublicPaths []string
+
+	// ReportPaths are path prefixes of the report read routes, where GET
+	// requests may also carry the token behind a shared report link
+	ReportPaths []string
+
+	// AdminPaths are path prefixes of the admin routes, which check the scope
+	// of the request's X-API-Key themselves. Requests carrying an X-API-Key
+	// are served there without a token; elsewhere the header is ignored.
+	AdminPaths []string
+}
+
+// AuthMiddleware identifies the caller from an "Authorization: Bearer" token,
+// or else the dashboard's session cookie. Handlers act for the token's user in
+// place of any user_id in the query string or request body. Requests with an
+// invalid bearer token are rejected, and so are requests without a token
+// unless cfg.AllowAnonymous is set, except on public paths and for requests
+// to admin paths carrying an X-API-Key. An expired session cookie counts as no
+// token. Report link tokens are only accepted on cfg.ReportPaths.
+func AuthMiddleware(authUC *usecase.AuthUseCase, cfg AuthConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticate := authUC.Authenticate
+		if r.Method == http.MethodGet && isPublicPath(r.URL.Path, cfg.ReportPaths) {
+			authenticate = authUC.AuthenticateReport
+		}
+
+		token, hasToken := bearerToken(r)
+		if !hasToken {
+			if cookie, err := r.Cookie(sessionCookie); err == nil {
+				if _, err := authUC.Authenticate(cookie.Value); err == nil {
+					token, hasToken = cookie.Value, true
+				}
+			}
+		}
+		if !hasToken {
+			adminRequest := r.Header.Get("X-API-Key") != "" && isPublicPath(r.URL.Path, cfg.AdminPaths)
+			if !cfg.AllowAnonymous && r.Method != http.MethodOptions && !adminRequest && !isPublicPath(r.URL.Path, cfg.PublicPaths) {
+				writeUnauthorized(w, "Authentication required")
+				return
+			}
+			if cfg.AllowAnonymous {
+				r = r.WithContext(context.WithValue(r.Context(), anonymousContextKey{}, true))
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, err := authenticate(token)
+		if err != nil {
+			writeUnauthorized(w, err.Error())
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), authContextKey{}, userID)
+		ctx = logging.WithUser(ctx, userID, "")
+		r = r.WithContext(ctx)
+		if query := r.URL.Query(); query.Has("user_id") {
+			query.Set("user_id", userID)
+			r.URL.RawQuery = query.Encode()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AuthenticatedUser returns the user the request's token was issued to, or ""
+func AuthenticatedUser(ctx context.Context) string {
+	userID, _ := ctx.Value(authContextKey{}).(string)
+	return userID
+}
+
+// requestUserID returns the authenticated user. Only requests served without
+// a token under AuthConfig.AllowAnonymous fall back to the user_id they name;
+// any other request without a token gets "".
+func requestUserID(r *http.Request, claimedID string) string {
+	if userID := AuthenticatedUser(r.Context()); userID != "" {
+		return userID
+	}
+	if anonymous, _ := r.Context().Value(anonymousContextKey{}).(bool); anonymous {
+		return claimedID
+	}
+	return ""
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(header[7:]), true
+}
+
+// isHTTPS reports whether the client reached us over HTTPS, directly or through a proxy
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(&Response{Status: "error", Error: message})
+}
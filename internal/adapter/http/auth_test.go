@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	authUC := usecase.NewAuthUseCase(&TestUserRepository{}, "test-secret")
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  "user1",
+		"exp":  time.Now().Add(time.Hour).Unix(),
+		"type": "access",
+	}).SignedString([]byte("test-secret"))
+
+	var seenQuery, seenBody string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenQuery = r.URL.Query().Get("user_id")
+		seenBody = requestUserID(r, "claimed")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	serve := func(cfg AuthConfig, path, authorization string, header ...string) *httptest.ResponseRecorder {
+		seenQuery, seenBody = "", ""
+		req := httptest.NewRequest("GET", path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		AuthMiddleware(authUC, cfg, next).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Token replaces the claimed user", func(t *testing.T) {
+		w := serve(AuthConfig{}, "/api/expenses?user_id=user2&limit=5", "Bearer "+token)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("expected the request to be served, got %d", w.Code)
+		}
+		if seenQuery != "user1" || seenBody != "user1" {
+			t.Errorf("expected handlers to act for user1, got query %q and body %q", seenQuery, seenBody)
+		}
+	})
+
+	t.Run("Session cookie", func(t *testing.T) {
+		seenQuery = ""
+		req := httptest.NewRequest("GET", "/api/expenses?user_id=user2", nil)
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: token})
+		w := httptest.NewRecorder()
+		AuthMiddleware(authUC, AuthConfig{}, next).ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent || seenQuery != "user1" {
+			t.Errorf("expected the session's user, got %d %q", w.Code, seenQuery)
+		}
+
+		req = httptest.NewRequest("GET", "/api/expenses?user_id=user2", nil)
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: "expired"})
+		w = httptest.NewRecorder()
+		AuthMiddleware(authUC, AuthConfig{}, next).ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected a stale session to count as signed out, got %d", w.Code)
+		}
+	})
+
+	t.Run("Invalid token rejected", func(t *testing.T) {
+		w := serve(AuthConfig{}, "/api/expenses?user_id=user1", "Bearer not-a-token")
+		if w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), "invalid") {
+			t.Errorf("expected 401, got %d %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("No token while anonymous allowed", func(t *testing.T) {
+		w := serve(AuthConfig{AllowAnonymous: true}, "/api/expenses?user_id=user2", "")
+		if w.Code != http.StatusNoContent || seenQuery != "user2" || seenBody != "claimed" {
+			t.Errorf("expected the claimed user to be kept, got %d %q %q", w.Code, seenQuery, seenBody)
+		}
+	})
+
+	t.Run("Report link token only on report paths", func(t *testing.T) {
+		reportToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":  "user1",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+			"type": "report_access",
+		}).SignedString([]byte("test-secret"))
+		cfg := AuthConfig{PublicPaths: []string{"/api/reports/"}, ReportPaths: []string{"/api/reports/"}}
+
+		if w := serve(cfg, "/api/v1/reports/summary?user_id=user2", "Bearer "+reportToken); w.Code != http.StatusNoContent || seenQuery != "user1" {
+			t.Errorf("expected the report to be read for user1, got %d %q", w.Code, seenQuery)
+		}
+		if w := serve(cfg, "/api/expenses?user_id=user1", "Bearer "+reportToken); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 outside the report paths, got %d", w.Code)
+		}
+	})
+
+	t.Run("No token rejected by default", func(t *testing.T) {
+		cfg := AuthConfig{PublicPaths: []string{"/api/auth/"}, AdminPaths: []string{"/api/metrics/"}}
+		if w := serve(cfg, "/api/expenses?user_id=user2", ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", w.Code)
+		}
+		if w := serve(cfg, "/api/auth/code", ""); w.Code != http.StatusNoContent {
+			t.Errorf("expected a public path to be served, got %d", w.Code)
+		}
+		if w := serve(cfg, "/api/metrics/dau", "", "X-API-Key", "admin"); w.Code != http.StatusNoContent {
+			t.Errorf("expected an admin request to reach its handler, got %d", w.Code)
+		}
+		if w := serve(AuthConfig{}, "/api/expenses?user_id=user2", ""); w.Code != http.StatusUnauthorized || seenBody != "" {
+			t.Errorf("expected 401 without acting for the claimed user, got %d %q", w.Code, seenBody)
+		}
+	})
+
+	t.Run("API key only exempts admin paths", func(t *testing.T) {
+		cfg := AuthConfig{AdminPaths: []string{"/api/metrics/"}}
+		if w := serve(cfg, "/api/expenses?user_id=victim", "", "X-API-Key", "anything"); w.Code != http.StatusUnauthorized || seenQuery != "" {
+			t.Errorf("expected 401 for a user route, got %d acting for %q", w.Code, seenQuery)
+		}
+		if w := serve(cfg, "/api/metrics/dau?user_id=victim", "", "X-API-Key", "anything"); w.Code != http.StatusNoContent || seenBody != "" {
+			t.Errorf("expected the admin route without a user, got %d acting for %q", w.Code, seenBody)
+		}
+
+		// The admin route itself rejects a key that doesn't exist
+		apiKeys := NewAPIKeyHandler(usecase.NewAPIKeyUseCase(usecase.NewMockAPIKeyRepository(), ""))
+		req := httptest.NewRequest("GET", "/api/metrics/dau", nil)
+		req.Header.Set("X-API-Key", "anything")
+		w := httptest.NewRecorder()
+		AuthMiddleware(authUC, cfg, apiKeys.RequireScope(domain.APIKeyScopeMetricsRead, next)).ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected an unknown key to be rejected, got %d", w.Code)
+		}
+	})
+}
+
+// stubLoginVerifier signs in the user named by the payload's "user" field
+type stubLoginVerifier struct{}
+
+func (stubLoginVerifier) VerifyLogin(ctx context.Context, payload map[string]string) (string, error) {
+	if payload["user"] == "" {
+		return "", errors.New("bad payload")
+	}
+	return payload["user"], nil
+}
+
+func TestAuthHandler_Login(t *testing.T) {
+	userRepo := &TestUserRepository{users: map[string]*domain.User{
+		"telegram_42": {UserID: "telegram_42", MessengerType: "telegram"},
+		"U1234":       {UserID: "U1234", MessengerType: "line"},
+	}}
+	authUC := usecase.NewAuthUseCase(userRepo, "test-secret")
+	authUC.RegisterLoginVerifier("telegram", stubLoginVerifier{})
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, Handlers{API: &Handler{}, Auth: NewAuthHandler(authUC)})
+
+	login := func(messenger, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/auth/login/"+messenger, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := login("telegram", `{"user": "telegram_42", "auth_date": 1700000000}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected login to succeed, got %d %s", w.Code, w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != sessionCookie || !cookies[0].HttpOnly {
+		t.Fatalf("expected an HttpOnly session cookie, got %+v", cookies)
+	}
+	if userID, err := authUC.Authenticate(cookies[0].Value); err != nil || userID != "telegram_42" {
+		t.Errorf("expected the session to belong to telegram_42, got %q, %v", userID, err)
+	}
+
+	for name, tc := range map[string]struct {
+		messenger, body string
+		status          int
+	}{
+		"bad payload":     {"telegram", `{}`, http.StatusUnauthorized},
+		"other messenger": {"telegram", `{"user": "U1234"}`, http.StatusForbidden},
+		"no web login":    {"discord", `{"user": "telegram_42"}`, http.StatusNotFound},
+	} {
+		if w := login(tc.messenger, tc.body); w.Code != tc.status {
+			t.Errorf("%s: expected %d, got %d", name, tc.status, w.Code)
+		}
+	}
+}
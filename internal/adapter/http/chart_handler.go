@@ -0,0 +1,43 @@
+package http
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ChartHandler serves the chart images report replies link to. Messengers
+// fetch them without credentials, and a chart link only carries amounts.
+type ChartHandler struct {
+	renderer domain.ChartRenderer
+}
+
+// NewChartHandler creates a new chart handler
+func NewChartHandler(renderer domain.ChartRenderer) *ChartHandler {
+	return &ChartHandler{
+		renderer: renderer,
+	}
+}
+
+// GetChart handles GET /charts/{name}, e.g. /charts/pie.png?v=1230,450,300,
+// drawing the chart the link describes
+func (h *ChartHandler) GetChart(w http.ResponseWriter, r *http.Request) {
+	chart, err := domain.ParseChart(r.PathValue("name"), r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	image, err := h.renderer.Render(chart)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to render chart", "error", err)
+		http.Error(w, "failed to render chart", http.StatusInternalServerError)
+		return
+	}
+
+	// The link describes the whole image, so it never changes
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Write(image)
+}
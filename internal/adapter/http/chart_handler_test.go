@@ -0,0 +1,36 @@
+package http
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/adapter/chart"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChartHandler_GetChart(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /charts/{name}", NewChartHandler(chart.NewRenderer()).GetChart)
+
+	t.Run("Draws the chart in the link", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/charts/bar.png?v=1230,450&p=1000,500", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Cache-Control"), "immutable")
+		_, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid chart", func(t *testing.T) {
+		for _, path := range []string{"/charts/pie.png", "/charts/line.png?v=1", "/charts/pie.png?v=-5"} {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			assert.Equal(t, http.StatusBadRequest, w.Code, path)
+		}
+	})
+}
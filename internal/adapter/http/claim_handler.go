@@ -0,0 +1,151 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// ClaimHandler tracks reimbursable expenses and the claims made for them
+type ClaimHandler struct {
+	reimbursementUC *usecase.ReimbursementUseCase
+}
+
+// NewClaimHandler creates a new claim handler
+func NewClaimHandler(reimbursementUC *usecase.ReimbursementUseCase) *ClaimHandler {
+	return &ClaimHandler{
+		reimbursementUC: reimbursementUC,
+	}
+}
+
+func (h *ClaimHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *ClaimHandler) writeError(w http.ResponseWriter, err error) {
+	status := errorStatus(err, http.StatusInternalServerError)
+	if errors.Is(err, usecase.ErrInvalidClaim) {
+		status = http.StatusBadRequest
+	}
+	h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+}
+
+// claimRequest is the body of the claim endpoints
+type claimRequest struct {
+	UserID       string   `json:"user_id"`
+	Reimbursable bool     `json:"reimbursable"`
+	ExpenseIDs   []string `json:"expense_ids,omitempty"`
+	Status       string   `json:"status,omitempty"`
+}
+
+// readClaimRequest decodes a claim request body, taking the user from the
+// credentials when there are any
+func (h *ClaimHandler) readClaimRequest(w http.ResponseWriter, r *http.Request) (*claimRequest, bool) {
+	var req claimRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return nil, false
+	}
+	req.UserID = requestUserID(r, req.UserID)
+	if req.UserID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return nil, false
+	}
+	return &req, true
+}
+
+// listClaimsRequest reads the claims selected by query parameters
+func listClaimsRequest(r *http.Request, query url.Values) (*usecase.ListClaimsRequest, error) {
+	req := &usecase.ListClaimsRequest{
+		UserID:   requestUserID(r, query.Get("user_id")),
+		Statuses: listParam(query["status"]),
+	}
+	if req.UserID == "" {
+		return nil, errors.New("user_id is required")
+	}
+	var err error
+	req.From, req.To, err = filterDates(query.Get("start_date"), query.Get("end_date"))
+	return req, err
+}
+
+// ListClaims handles GET /api/claims
+func (h *ClaimHandler) ListClaims(w http.ResponseWriter, r *http.Request) {
+	req, err := listClaimsRequest(r, r.URL.Query())
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	claims, err := h.reimbursementUC.ListClaims(r.Context(), req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: claims})
+}
+
+// UpdateClaimStatus handles PUT /api/claims/status, moving claims through
+// pending, submitted and paid
+func (h *ClaimHandler) UpdateClaimStatus(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.readClaimRequest(w, r)
+	if !ok {
+		return
+	}
+
+	claims, err := h.reimbursementUC.UpdateClaimStatus(r.Context(), req.UserID, req.ExpenseIDs, req.Status)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: map[string]interface{}{"claims": claims}})
+}
+
+// ExportClaims handles GET /api/claims/export, the outstanding claims as CSV
+// to submit to an employer
+func (h *ClaimHandler) ExportClaims(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	list, err := listClaimsRequest(r, query)
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	markSubmitted, _ := strconv.ParseBool(query.Get("mark_submitted"))
+
+	data, err := h.reimbursementUC.ExportClaims(r.Context(), &usecase.ExportClaimsRequest{
+		UserID:        list.UserID,
+		Statuses:      list.Statuses,
+		From:          list.From,
+		To:            list.To,
+		MarkSubmitted: markSubmitted,
+	})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=claims.csv")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// SetReimbursable handles PUT /api/expenses/{id}/reimbursable, marking the
+// expense as paid for an employer or no longer so
+func (h *ClaimHandler) SetReimbursable(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.readClaimRequest(w, r)
+	if !ok {
+		return
+	}
+
+	claim, err := h.reimbursementUC.MarkReimbursable(r.Context(), req.UserID, r.PathValue("id"), req.Reimbursable)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: claim})
+}
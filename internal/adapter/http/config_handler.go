@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/config"
+)
+
+// ConfigHandler shows admins the configuration the server runs with
+type ConfigHandler struct {
+	reloader *config.Reloader
+}
+
+// NewConfigHandler creates a new config handler
+func NewConfigHandler(reloader *config.Reloader) *ConfigHandler {
+	return &ConfigHandler{reloader: reloader}
+}
+
+// GetConfig handles GET /api/admin/config with the settings in effect,
+// including those changed by reloads since startup, and the last reload.
+// Secrets are redacted.
+func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &Response{Status: "success", Data: h.reloader.Effective()})
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/config"
+)
+
+func TestConfigHandler_GetConfig(t *testing.T) {
+	handler := NewConfigHandler(config.NewReloader(&config.Config{
+		AIProvider:   "gemini",
+		AIModel:      "gemini-2.5-flash",
+		GeminiAPIKey: "AIzaSecret",
+		DatabaseURL:  "postgres://app:hunter2@db/aiexpense",
+		LogLevel:     "debug",
+	}))
+
+	w := httptest.NewRecorder()
+	handler.GetConfig(w, httptest.NewRequest("GET", "/api/admin/config", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	for _, want := range []string{`"AIModel":"gemini-2.5-flash"`, `"LogLevel":"debug"`, `"GeminiAPIKey":"[redacted]"`, `"reloadable":["LOG_LEVEL"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %s", want, body)
+		}
+	}
+	if strings.Contains(body, "AIzaSecret") || strings.Contains(body, "hunter2") {
+		t.Errorf("expected no secrets in %s", body)
+	}
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CORSConfig controls which browser origins may call the API
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to call the API, such as
+	// "https://dashboard.example.com"; "*" allows any origin
+	AllowedOrigins []string
+
+	// AllowCredentials lets allowed origins send cookies and Authorization headers
+	AllowCredentials bool
+
+	// PublicPaths are path prefixes any origin may call without credentials,
+	// e.g. shared report links embedded elsewhere. An /api prefix covers the
+	// /api/v1 path too.
+	PublicPaths []string
+}
+
+// CORSMiddleware adds CORS headers for allowed origins and answers preflight
+// requests. Requests from other origins are served without CORS headers, so
+// browsers keep their pages from reading the response.
+func CORSMiddleware(cfg CORSConfig, next http.Handler) http.Handler {
+	anyOrigin := false
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			anyOrigin = true
+		} else if origin != "" {
+			allowed[origin] = true
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		origin := r.Header.Get("Origin")
+		h.Add("Vary", "Origin")
+
+		switch {
+		case origin == "":
+			// Not a cross-origin browser request
+		case isPublicPath(r.URL.Path, cfg.PublicPaths):
+			h.Set("Access-Control-Allow-Origin", "*")
+		case allowed[origin] || anyOrigin:
+			// Credentials can't be combined with a wildcard, so name the origin
+			if anyOrigin && !cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if h.Get("Access-Control-Allow-Origin") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, Authorization, X-Workspace-ID, API-Version")
+			h.Set("Access-Control-Max-Age", "3600")
+		}
+
+		// Handle OPTIONS requests
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isPublicPath(path string, prefixes []string) bool {
+	path = unversionedPath(path)
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
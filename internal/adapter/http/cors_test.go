@@ -0,0 +1,86 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	serve := func(cfg CORSConfig, method, path, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		CORSMiddleware(cfg, next).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Wildcard", func(t *testing.T) {
+		w := serve(CORSConfig{AllowedOrigins: []string{"*"}}, "GET", "/api/expenses", "https://anywhere.example")
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("expected any origin to be allowed, got %q", got)
+		}
+		if w.Code != http.StatusTeapot {
+			t.Errorf("expected the request to reach the handler, got %d", w.Code)
+		}
+	})
+
+	cfg := CORSConfig{
+		AllowedOrigins:   []string{"https://dashboard.example", "http://localhost:3000/"},
+		AllowCredentials: true,
+		PublicPaths:      []string{"/r/"},
+	}
+
+	t.Run("Allowed origin", func(t *testing.T) {
+		for _, origin := range []string{"https://dashboard.example", "http://localhost:3000"} {
+			w := serve(cfg, "GET", "/api/metrics/dau", origin)
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != origin {
+				t.Errorf("expected %s to be allowed, got %q", origin, got)
+			}
+			if w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Errorf("expected credentials to be allowed for %s", origin)
+			}
+		}
+	})
+
+	t.Run("Unknown origin", func(t *testing.T) {
+		w := serve(cfg, "GET", "/api/metrics/dau", "https://evil.example")
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("expected no CORS headers, got %q", got)
+		}
+		if w.Header().Get("Vary") != "Origin" {
+			t.Errorf("expected responses to vary by origin")
+		}
+
+		w = serve(cfg, "OPTIONS", "/api/metrics/dau", "https://evil.example")
+		if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Methods") != "" {
+			t.Errorf("expected the preflight to be answered without allowing it, got %d %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("Public path", func(t *testing.T) {
+		w := serve(cfg, "GET", "/r/abc123", "https://evil.example")
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("expected any origin on a public path, got %q", got)
+		}
+		if w.Header().Get("Access-Control-Allow-Credentials") != "" {
+			t.Errorf("expected no credentials on a public path")
+		}
+	})
+
+	t.Run("Wildcard with credentials names the origin", func(t *testing.T) {
+		w := serve(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, "OPTIONS", "/api/expenses", "https://anywhere.example")
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://anywhere.example" {
+			t.Errorf("expected the request origin, got %q", got)
+		}
+		if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Methods") == "" {
+			t.Errorf("expected an allowed preflight, got %d %v", w.Code, w.Header())
+		}
+	})
+}
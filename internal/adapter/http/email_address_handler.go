@@ -0,0 +1,127 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// EmailAddressHandler lets users bind the email addresses they forward
+// receipts from
+type EmailAddressHandler struct {
+	emailUC *usecase.EmailAddressUseCase
+}
+
+// NewEmailAddressHandler creates a new email address handler
+func NewEmailAddressHandler(emailUC *usecase.EmailAddressUseCase) *EmailAddressHandler {
+	return &EmailAddressHandler{
+		emailUC: emailUC,
+	}
+}
+
+func (h *EmailAddressHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *EmailAddressHandler) writeError(w http.ResponseWriter, err error) {
+	status := errorStatus(err, http.StatusInternalServerError)
+	if errors.Is(err, usecase.ErrInvalidEmailAddress) || errors.Is(err, usecase.ErrInvalidVerificationCode) {
+		status = http.StatusBadRequest
+	}
+	h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+}
+
+// emailAddressRequest is the body of the email address endpoints
+type emailAddressRequest struct {
+	Email string `json:"email"`
+	Code  string `json:"code,omitempty"`
+}
+
+// AddEmailAddress handles POST /api/users/me/email-addresses by emailing a
+// verification code to the address
+func (h *EmailAddressHandler) AddEmailAddress(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	var req emailAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+
+	address, err := h.emailUC.RequestVerification(r.Context(), userID, req.Email)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if address.VerifiedAt != nil {
+		h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: address, Message: localize(r, "api.email_already_verified")})
+		return
+	}
+	h.writeJSON(w, http.StatusAccepted, &Response{
+		Status:  "success",
+		Data:    address,
+		Message: localize(r, "api.email_code_sent"),
+	})
+}
+
+// VerifyEmailAddress handles POST /api/users/me/email-addresses/verify,
+// binding the address once the code emailed to it is entered
+func (h *EmailAddressHandler) VerifyEmailAddress(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	var req emailAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+
+	address, err := h.emailUC.Verify(r.Context(), userID, req.Email, req.Code)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: address, Message: localize(r, "api.email_verified")})
+}
+
+// ListEmailAddresses handles GET /api/users/me/email-addresses
+func (h *EmailAddressHandler) ListEmailAddresses(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	addresses, err := h.emailUC.List(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: addresses})
+}
+
+// DeleteEmailAddress handles DELETE /api/users/me/email-addresses/{address}
+func (h *EmailAddressHandler) DeleteEmailAddress(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	if err := h.emailUC.Remove(r.Context(), userID, r.PathValue("address")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Message: localize(r, "api.email_removed")})
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// expenseFilterBody is the JSON form of an expense filter
+type expenseFilterBody struct {
+	UserID     string   `json:"user_id"`
+	Categories []string `json:"categories"`
+	Currencies []string `json:"currencies"`
+	Amount     struct {
+		Min *float64 `json:"min"`
+		Max *float64 `json:"max"`
+	} `json:"amount"`
+	HasAttachment *bool    `json:"has_attachment"`
+	Text          string   `json:"text"`
+	Tags          []string `json:"tags"`
+	Reimbursable  *bool    `json:"reimbursable"`
+	ClaimStatuses []string `json:"claim_statuses"`
+	Period        string   `json:"period"`
+	StartDate     string   `json:"start_date"`
+	EndDate       string   `json:"end_date"`
+}
+
+// filterRequestFromQuery reads an expense filter from query parameters. Lists
+// may be given by repeating the parameter or as comma-separated values.
+func filterRequestFromQuery(query url.Values) (*usecase.FilterRequest, error) {
+	req := &usecase.FilterRequest{
+		UserID:        query.Get("user_id"),
+		CategoryIDs:   listParam(query["category_id"]),
+		Currencies:    listParam(query["currency"]),
+		Tags:          listParam(query["tag"]),
+		ClaimStatuses: listParam(query["claim_status"]),
+		Text:          query.Get("q"),
+		Period:        query.Get("period"),
+	}
+
+	var err error
+	if req.MinAmount, err = floatParam(query, "min_amount"); err != nil {
+		return nil, err
+	}
+	if req.MaxAmount, err = floatParam(query, "max_amount"); err != nil {
+		return nil, err
+	}
+	if value := query.Get("has_attachment"); value != "" {
+		hasAttachment, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid has_attachment %q", value)
+		}
+		req.HasAttachment = &hasAttachment
+	}
+	if value := query.Get("reimbursable"); value != "" {
+		reimbursable, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reimbursable %q", value)
+		}
+		req.Reimbursable = &reimbursable
+	}
+	if req.StartDate, req.EndDate, err = filterDates(query.Get("start_date"), query.Get("end_date")); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// filterRequest converts the JSON form of an expense filter
+func (b *expenseFilterBody) filterRequest() (*usecase.FilterRequest, error) {
+	startDate, endDate, err := filterDates(b.StartDate, b.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	return &usecase.FilterRequest{
+		UserID:        b.UserID,
+		CategoryIDs:   listParam(b.Categories),
+		Currencies:    listParam(b.Currencies),
+		MinAmount:     b.Amount.Min,
+		MaxAmount:     b.Amount.Max,
+		HasAttachment: b.HasAttachment,
+		Text:          b.Text,
+		Tags:          listParam(b.Tags),
+		Reimbursable:  b.Reimbursable,
+		ClaimStatuses: listParam(b.ClaimStatuses),
+		Period:        b.Period,
+		StartDate:     startDate,
+		EndDate:       endDate,
+	}, nil
+}
+
+// listParam splits comma-separated values and drops empty ones
+func listParam(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func floatParam(query url.Values, name string) (*float64, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return &f, nil
+}
+
+// filterDates parses YYYY-MM-DD bounds; the end date includes its whole day
+func filterDates(start, end string) (*time.Time, *time.Time, error) {
+	var startDate, endDate *time.Time
+	if start != "" {
+		t, err := time.Parse("2006-01-02", start)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid start_date %q", start)
+		}
+		startDate = &t
+	}
+	if end != "" {
+		t, err := time.Parse("2006-01-02", end)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid end_date %q", end)
+		}
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		endDate = &t
+	}
+	return startDate, endDate, nil
+}
+
+// FilterExpenses godoc
+func (h *Handler) FilterExpenses(w http.ResponseWriter, r *http.Request) {
+	var req *usecase.FilterRequest
+	var err error
+	if r.Method == http.MethodPost {
+		var body expenseFilterBody
+		if err := h.ReadJSON(r, &body); err != nil {
+			h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+			return
+		}
+		req, err = body.filterRequest()
+	} else {
+		req, err = filterRequestFromQuery(r.URL.Query())
+	}
+	if err != nil {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	req.UserID = requestUserID(r, req.UserID)
+
+	if req.UserID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	resp, err := h.searchExpenseUC.Filter(r.Context(), req)
+	if err != nil {
+		status := errorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, usecase.ErrInvalidFilter) {
+			status = http.StatusBadRequest
+		}
+		h.WriteJSON(w, status, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
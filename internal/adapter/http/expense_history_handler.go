@@ -0,0 +1,43 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// ExpenseHistoryHandler serves the audit trail of expenses
+type ExpenseHistoryHandler struct {
+	expenseAuditUC *usecase.ExpenseAuditUseCase
+}
+
+func NewExpenseHistoryHandler(expenseAuditUC *usecase.ExpenseAuditUseCase) *ExpenseHistoryHandler {
+	return &ExpenseHistoryHandler{
+		expenseAuditUC: expenseAuditUC,
+	}
+}
+
+func (h *ExpenseHistoryHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// GetHistory returns every change made to an expense, oldest first
+func (h *ExpenseHistoryHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	expenseID := r.PathValue("id")
+	userID := r.URL.Query().Get("user_id")
+	if expenseID == "" || userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "expense id and user_id are required"})
+		return
+	}
+
+	entries, err := h.expenseAuditUC.GetHistory(r.Context(), expenseID, userID)
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: entries})
+}
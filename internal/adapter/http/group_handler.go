@@ -0,0 +1,80 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// GroupHandler serves shared group ledgers
+type GroupHandler struct {
+	groupLedgerUC *usecase.GroupLedgerUseCase
+	settlementUC  *usecase.SettlementUseCase
+}
+
+func NewGroupHandler(groupLedgerUC *usecase.GroupLedgerUseCase, settlementUC *usecase.SettlementUseCase) *GroupHandler {
+	return &GroupHandler{
+		groupLedgerUC: groupLedgerUC,
+		settlementUC:  settlementUC,
+	}
+}
+
+func (h *GroupHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// ListGroups returns the groups the user belongs to
+func (h *GroupHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	groups, err := h.groupLedgerUC.ListGroups(r.Context(), userID)
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: groups})
+}
+
+// ListMembers returns the members of a group the user belongs to
+func (h *GroupHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	groupID := r.PathValue("id")
+	userID := r.URL.Query().Get("user_id")
+	if groupID == "" || userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "group id and user_id are required"})
+		return
+	}
+
+	members, err := h.groupLedgerUC.ListMembers(r.Context(), groupID, userID)
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: members})
+}
+
+// GetSettlement returns the transfers that settle up a group the user belongs to
+func (h *GroupHandler) GetSettlement(w http.ResponseWriter, r *http.Request) {
+	groupID := r.PathValue("id")
+	userID := r.URL.Query().Get("user_id")
+	if groupID == "" || userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "group id and user_id are required"})
+		return
+	}
+
+	settlement, err := h.settlementUC.Execute(r.Context(), groupID, userID)
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: settlement, Message: settlement.Text})
+}
@@ -1,8 +1,12 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
@@ -32,7 +36,6 @@ type Handler struct {
 	categoryRepo        domain.CategoryRepository
 	expenseRepo         domain.ExpenseRepository
 	metricsRepo         domain.MetricsRepository
-	adminAPIKey         string
 }
 
 // NewHandler creates a new HTTP handler
@@ -58,7 +61,6 @@ func NewHandler(
 	categoryRepo domain.CategoryRepository,
 	expenseRepo domain.ExpenseRepository,
 	metricsRepo domain.MetricsRepository,
-	adminAPIKey string,
 ) *Handler {
 	return &Handler{
 		autoSignupUC:        autoSignupUC,
@@ -82,7 +84,6 @@ func NewHandler(
 		categoryRepo:        categoryRepo,
 		expenseRepo:         expenseRepo,
 		metricsRepo:         metricsRepo,
-		adminAPIKey:         adminAPIKey,
 	}
 }
 
@@ -101,12 +102,50 @@ func (h *Handler) WriteJSON(w http.ResponseWriter, status int, resp *Response) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// errorStatus maps an error to its HTTP status: 404 for a missing resource,
+// 409 for a write that conflicts with an existing one and fallback otherwise
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	}
+	return fallback
+}
+
 // ReadJSON reads a JSON request body
 func (h *Handler) ReadJSON(r *http.Request, v interface{}) error {
 	defer r.Body.Close()
 	return json.NewDecoder(r.Body).Decode(v)
 }
 
+// resourceID returns the {id} path parameter, falling back to the ID the
+// deprecated routes take in the request body or query string
+func resourceID(r *http.Request, legacyID string) string {
+	if id := r.PathValue("id"); id != "" {
+		return id
+	}
+	return legacyID
+}
+
+// apiAuditContext attributes expense changes made through the API to the requesting user
+func apiAuditContext(ctx context.Context, userID string) context.Context {
+	return domain.WithAuditSource(ctx, domain.AuditSource{Actor: userID, Channel: domain.AuditChannelAPI})
+}
+
+// deprecatedRoute marks a response from a route kept for backward compatibility,
+// pointing clients at its replacement. These aliases will be removed next release.
+func deprecatedRoute(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !negotiateAPIVersion(w, r) {
+			return
+		}
+		markDeprecated(w, successor)
+		next(w, r)
+	}
+}
+
 // AutoSignup godoc
 // @Summary Auto-signup a user
 // @Description Create a new user if not exists
@@ -137,11 +176,11 @@ func (h *Handler) AutoSignup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.autoSignupUC.Execute(ctx, req.UserID, req.MessengerType); err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
-	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Message: "User signed up successfully"})
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Message: localize(r, "api.signed_up")})
 }
 
 // ParseExpenses godoc
@@ -158,10 +197,11 @@ func (h *Handler) ParseExpenses(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	expenses, err := h.parseConversationUC.Execute(ctx, req.Text, req.UserID)
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -172,34 +212,49 @@ func (h *Handler) ParseExpenses(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) CreateExpense(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	type CreateRequest struct {
-		UserID           string     `json:"user_id"`
-		Description      string     `json:"description"`
-		Amount           float64    `json:"amount"`
-		Currency         string     `json:"currency,omitempty"`
-		CurrencyOriginal string     `json:"currency_original,omitempty"`
-		ConvertedAmount  float64    `json:"converted_amount,omitempty"`
-		HomeCurrency     string     `json:"home_currency,omitempty"`
-		ExchangeRate     float64    `json:"exchange_rate,omitempty"`
-		CategoryID       *string    `json:"category_id,omitempty"`
-		Account          string     `json:"account,omitempty"`
-		Date             *time.Time `json:"date,omitempty"`
-	}
-
-	var req CreateRequest
+	var req createExpenseRequest
 	if err := h.ReadJSON(r, &req); err != nil {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
+	resp, err := h.createExpenseUC.Execute(apiAuditContext(ctx, req.UserID), req.toUseCase(req.UserID))
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusCreated, &Response{Status: "success", Data: resp})
+}
+
+// createExpenseRequest is the body of POST /api/expenses and one entry of a batch
+type createExpenseRequest struct {
+	UserID           string     `json:"user_id"`
+	Description      string     `json:"description"`
+	Amount           float64    `json:"amount"`
+	Currency         string     `json:"currency,omitempty"`
+	CurrencyOriginal string     `json:"currency_original,omitempty"`
+	ConvertedAmount  float64    `json:"converted_amount,omitempty"`
+	HomeCurrency     string     `json:"home_currency,omitempty"`
+	ExchangeRate     float64    `json:"exchange_rate,omitempty"`
+	CategoryID       *string    `json:"category_id,omitempty"`
+	Account          string     `json:"account,omitempty"`
+	Merchant         string     `json:"merchant,omitempty"`
+	Tags             []string   `json:"tags,omitempty"`
+	Reimbursable     bool       `json:"reimbursable,omitempty"`
+	Date             *time.Time `json:"date,omitempty"`
+}
+
+func (req *createExpenseRequest) toUseCase(userID string) *usecase.CreateRequest {
 	// Set default date to now
 	date := time.Now()
 	if req.Date != nil {
 		date = *req.Date
 	}
 
-	ucReq := &usecase.CreateRequest{
-		UserID:           req.UserID,
+	return &usecase.CreateRequest{
+		UserID:           userID,
 		Description:      req.Description,
 		Amount:           req.Amount,
 		Currency:         req.Currency,
@@ -209,16 +264,78 @@ func (h *Handler) CreateExpense(w http.ResponseWriter, r *http.Request) {
 		ExchangeRate:     req.ExchangeRate,
 		CategoryID:       req.CategoryID,
 		Account:          req.Account,
+		Merchant:         req.Merchant,
+		Tags:             req.Tags,
+		Reimbursable:     req.Reimbursable,
 		Date:             date,
 	}
+}
+
+// BatchExpenseResult reports one entry of a batch create, by its position in the request
+type BatchExpenseResult struct {
+	Index   int                     `json:"index"`
+	Status  string                  `json:"status"` // "created" or "failed"
+	Expense *usecase.CreateResponse `json:"expense,omitempty"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+// BatchExpenseResponse is the data of POST /api/expenses/batch
+type BatchExpenseResponse struct {
+	Created int                  `json:"created"`
+	Failed  int                  `json:"failed"`
+	Results []BatchExpenseResult `json:"results"`
+}
+
+// CreateExpenseBatch handles POST /api/expenses/batch, creating up to
+// usecase.MaxBatchExpenses expenses for one user with a single database write.
+// It answers 201 when every expense was created and 207 when some failed.
+func (h *Handler) CreateExpenseBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-	resp, err := h.createExpenseUC.Execute(ctx, ucReq)
+	var req struct {
+		UserID   string                 `json:"user_id"`
+		Expenses []createExpenseRequest `json:"expenses"`
+	}
+	if err := h.ReadJSON(r, &req); err != nil {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+	req.UserID = requestUserID(r, req.UserID)
+	if req.UserID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+	if len(req.Expenses) == 0 {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "expenses is required"})
+		return
+	}
+
+	ucReqs := make([]*usecase.CreateRequest, len(req.Expenses))
+	for i := range req.Expenses {
+		ucReqs[i] = req.Expenses[i].toUseCase(req.UserID)
+	}
+	results, err := h.createExpenseUC.ExecuteBatch(apiAuditContext(ctx, req.UserID), ucReqs)
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
-	h.WriteJSON(w, http.StatusCreated, &Response{Status: "success", Data: resp})
+	resp := &BatchExpenseResponse{Results: make([]BatchExpenseResult, len(results))}
+	for i, result := range results {
+		if result.Error != nil {
+			resp.Failed++
+			resp.Results[i] = BatchExpenseResult{Index: i, Status: "failed", Error: result.Error.Error()}
+			continue
+		}
+		resp.Created++
+		resp.Results[i] = BatchExpenseResult{Index: i, Status: "created", Expense: result.Response}
+	}
+
+	status := http.StatusCreated
+	if resp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	h.WriteJSON(w, status, &Response{Status: "success", Data: resp})
 }
 
 // GetExpenses godoc
@@ -231,10 +348,55 @@ func (h *Handler) GetExpenses(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	req := &usecase.GetAllRequest{UserID: userID}
+	query := r.URL.Query()
+	req := &usecase.GetAllRequest{
+		UserID:  userID,
+		Cursor:  query.Get("cursor"),
+		SortBy:  query.Get("sort_by"),
+		SortDir: strings.ToLower(query.Get("sort_dir")),
+	}
+	var err error
+	if raw := query.Get("limit"); raw != "" {
+		if req.Limit, err = strconv.Atoi(raw); err != nil {
+			h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "limit must be an integer"})
+			return
+		}
+	}
+	if raw := query.Get("offset"); raw != "" {
+		if req.Offset, err = strconv.Atoi(raw); err != nil {
+			h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "offset must be an integer"})
+			return
+		}
+	}
+
+	if err := req.Validate(); err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
 	resp, err := h.getExpensesUC.ExecuteGetAll(ctx, req)
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// GetExpense retrieves a single expense
+func (h *Handler) GetExpense(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+	userID := r.URL.Query().Get("user_id")
+
+	if id == "" || userID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "id and user_id are required"})
+		return
+	}
+
+	resp, err := h.getExpensesUC.ExecuteGetByID(ctx, &usecase.GetByIDRequest{ID: id, UserID: userID})
+	if err != nil {
+		h.WriteJSON(w, http.StatusNotFound, &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -253,7 +415,7 @@ func (h *Handler) GetCategories(w http.ResponseWriter, r *http.Request) {
 
 	categories, err := h.categoryRepo.GetByUserID(ctx, userID)
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -262,17 +424,11 @@ func (h *Handler) GetCategories(w http.ResponseWriter, r *http.Request) {
 
 // GetMetricsDAU retrieves daily active users
 func (h *Handler) GetMetricsDAU(w http.ResponseWriter, r *http.Request) {
-	// Check authentication
-	if !h.authenticateAdmin(r) {
-		h.WriteJSON(w, http.StatusUnauthorized, &Response{Status: "error", Error: "Unauthorized"})
-		return
-	}
-
 	ctx := r.Context()
 
 	resp, err := h.metricsUC.GetDailyActiveUsers(ctx, &usecase.DailyActiveUsersRequest{Days: 30})
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -281,16 +437,11 @@ func (h *Handler) GetMetricsDAU(w http.ResponseWriter, r *http.Request) {
 
 // GetMetricsExpenses retrieves expense summary
 func (h *Handler) GetMetricsExpenses(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.WriteJSON(w, http.StatusUnauthorized, &Response{Status: "error", Error: "Unauthorized"})
-		return
-	}
-
 	ctx := r.Context()
 
 	resp, err := h.metricsUC.GetExpensesSummary(ctx, &usecase.ExpensesSummaryRequest{Days: 30})
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -299,29 +450,86 @@ func (h *Handler) GetMetricsExpenses(w http.ResponseWriter, r *http.Request) {
 
 // GetMetricsGrowth retrieves growth metrics
 func (h *Handler) GetMetricsGrowth(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.WriteJSON(w, http.StatusUnauthorized, &Response{Status: "error", Error: "Unauthorized"})
+	ctx := r.Context()
+
+	resp, err := h.metricsUC.GetGrowthMetrics(ctx, &usecase.GrowthMetricsRequest{Days: 30})
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// GetMetricsRetention retrieves weekly cohort retention and churn
+func (h *Handler) GetMetricsRetention(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	weeks := usecase.DefaultRetentionWeeks
+	if weeksStr := r.URL.Query().Get("weeks"); weeksStr != "" {
+		if n, err := strconv.Atoi(weeksStr); err == nil && n > 0 {
+			weeks = n
+		}
+	}
+
+	resp, err := h.metricsUC.GetRetention(ctx, &usecase.RetentionRequest{Weeks: weeks})
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// GetMetricsPlatforms retrieves metrics broken down by messenger platform
+func (h *Handler) GetMetricsPlatforms(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	resp, err := h.metricsUC.GetGrowthMetrics(ctx, &usecase.GrowthMetricsRequest{Days: 30})
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		if n, err := strconv.Atoi(daysStr); err == nil && n > 0 {
+			days = n
+		}
+	}
+
+	resp, err := h.metricsUC.GetPlatformMetrics(ctx, &usecase.PlatformMetricsRequest{Days: days})
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
 	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
 }
 
-// RefreshExchangeRates triggers a manual exchange rate refresh
-func (h *Handler) RefreshExchangeRates(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.WriteJSON(w, http.StatusUnauthorized, &Response{Status: "error", Error: "Unauthorized"})
+// GetMetricsEvents retrieves how many of each domain event were published
+func (h *Handler) GetMetricsEvents(w http.ResponseWriter, r *http.Request) {
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: h.metricsUC.GetEventCounts()})
+}
+
+// GetMetricsDBPool retrieves the database connection pool statistics
+func (h *Handler) GetMetricsDBPool(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.metricsUC.GetDBPoolStats()
+	if err != nil {
+		h.WriteJSON(w, http.StatusServiceUnavailable, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// GetMetricsAIProviders retrieves the circuit breaker state of each AI provider
+func (h *Handler) GetMetricsAIProviders(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.metricsUC.GetAIProviderHealth()
+	if err != nil {
+		h.WriteJSON(w, http.StatusServiceUnavailable, &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// RefreshExchangeRates triggers a manual exchange rate refresh
+func (h *Handler) RefreshExchangeRates(w http.ResponseWriter, r *http.Request) {
 	if h.exchangeRateSvc == nil {
 		h.WriteJSON(w, http.StatusServiceUnavailable, &Response{Status: "error", Error: "Exchange rate service not configured"})
 		return
@@ -329,21 +537,74 @@ func (h *Handler) RefreshExchangeRates(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	if err := h.exchangeRateSvc.RefreshRates(ctx); err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
-	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Message: "Exchange rates refreshed"})
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Message: localize(r, "api.rates_refreshed")})
+}
+
+// CurrencyRatesResponse lists exchange rates from one base currency
+type CurrencyRatesResponse struct {
+	Base  string             `json:"base"`
+	Date  string             `json:"date"`
+	Rates map[string]float64 `json:"rates"`
 }
 
-// authenticateAdmin checks if request has valid admin API key
-func (h *Handler) authenticateAdmin(r *http.Request) bool {
-	if h.adminAPIKey == "" {
-		return true // No auth required if key not set
+// GetCurrencyRates godoc
+// @Summary Get exchange rates
+// @Description List the latest daily exchange rates for a base currency
+// @Tags currencies
+// @Produce json
+// @Param base query string false "Base currency (default TWD)"
+// @Param date query string false "Rate date YYYY-MM-DD (default today)"
+// @Param symbols query string false "Comma-separated target currencies"
+// @Success 200 {object} Response
+// @Router /api/currencies/rates [get]
+func (h *Handler) GetCurrencyRates(w http.ResponseWriter, r *http.Request) {
+	if h.exchangeRateSvc == nil {
+		h.WriteJSON(w, http.StatusServiceUnavailable, &Response{Status: "error", Error: "Exchange rate service not configured"})
+		return
+	}
+
+	ctx := r.Context()
+	base := strings.ToUpper(r.URL.Query().Get("base"))
+	if base == "" {
+		base = "TWD"
 	}
 
-	key := r.Header.Get("X-API-Key")
-	return key == h.adminAPIKey
+	date := time.Now()
+	if dateStr := r.URL.Query().Get("date"); dateStr != "" {
+		parsed, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "date must be in YYYY-MM-DD format"})
+			return
+		}
+		date = parsed
+	}
+
+	symbols := make(map[string]bool)
+	for _, symbol := range strings.Split(r.URL.Query().Get("symbols"), ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			symbols[symbol] = true
+		}
+	}
+
+	rates, err := h.exchangeRateSvc.GetRates(ctx, base, date)
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	resp := &CurrencyRatesResponse{Base: base, Date: date.Format("2006-01-02"), Rates: make(map[string]float64)}
+	for _, rate := range rates {
+		if len(symbols) > 0 && !symbols[rate.TargetCurrency] {
+			continue
+		}
+		resp.Rates[rate.TargetCurrency] = rate.Rate
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
 }
 
 // UpdateExpense godoc
@@ -365,13 +626,15 @@ func (h *Handler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
+	req.ID = resourceID(r, req.ID)
 
 	if req.ID == "" || req.UserID == "" {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "id and user_id are required"})
 		return
 	}
 
-	resp, err := h.updateExpenseUC.Execute(ctx, &usecase.UpdateRequest{
+	resp, err := h.updateExpenseUC.Execute(apiAuditContext(ctx, req.UserID), &usecase.UpdateRequest{
 		ID:          req.ID,
 		UserID:      req.UserID,
 		Description: req.Description,
@@ -382,7 +645,7 @@ func (h *Handler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -399,23 +662,63 @@ func (h *Handler) DeleteExpense(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req DeleteExpenseRequest
-	if err := h.ReadJSON(r, &req); err != nil {
+	if id := r.PathValue("id"); id != "" {
+		req.ID = id
+		req.UserID = r.URL.Query().Get("user_id")
+	} else if err := h.ReadJSON(r, &req); err != nil {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	if req.ID == "" || req.UserID == "" {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "id and user_id are required"})
 		return
 	}
 
-	resp, err := h.deleteExpenseUC.Execute(ctx, &usecase.DeleteRequest{
+	resp, err := h.deleteExpenseUC.Execute(apiAuditContext(ctx, req.UserID), &usecase.DeleteRequest{
 		ID:     req.ID,
 		UserID: req.UserID,
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// RestoreExpense handles POST /api/expenses/{id}/restore, undoing a delete within the grace window
+func (h *Handler) RestoreExpense(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	type RestoreExpenseRequest struct {
+		UserID string `json:"user_id"`
+	}
+
+	var req RestoreExpenseRequest
+	if req.UserID = r.URL.Query().Get("user_id"); req.UserID == "" && r.ContentLength != 0 {
+		if err := h.ReadJSON(r, &req); err != nil {
+			h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+			return
+		}
+	}
+	req.UserID = requestUserID(r, req.UserID)
+
+	id := r.PathValue("id")
+	if id == "" || req.UserID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "id and user_id are required"})
+		return
+	}
+
+	resp, err := h.deleteExpenseUC.Restore(apiAuditContext(ctx, req.UserID), &usecase.RestoreRequest{
+		ID:     id,
+		UserID: req.UserID,
+	})
+
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -437,6 +740,7 @@ func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	if req.UserID == "" || req.Name == "" {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id and name are required"})
@@ -450,7 +754,7 @@ func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -473,6 +777,8 @@ func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
+	req.ID = resourceID(r, req.ID)
 
 	if req.ID == "" || req.UserID == "" {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "id and user_id are required"})
@@ -487,7 +793,7 @@ func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -504,10 +810,14 @@ func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req DeleteCategoryRequest
-	if err := h.ReadJSON(r, &req); err != nil {
+	if id := r.PathValue("id"); id != "" {
+		req.ID = id
+		req.UserID = r.URL.Query().Get("user_id")
+	} else if err := h.ReadJSON(r, &req); err != nil {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	if req.ID == "" || req.UserID == "" {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "id and user_id are required"})
@@ -520,7 +830,7 @@ func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -542,7 +852,7 @@ func (h *Handler) ListCategories(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -555,6 +865,7 @@ func (h *Handler) GenerateReport(w http.ResponseWriter, r *http.Request) {
 
 	type GenerateReportRequest struct {
 		UserID     string    `json:"user_id"`
+		GroupID    string    `json:"group_id,omitempty"`
 		ReportType string    `json:"report_type"`
 		StartDate  time.Time `json:"start_date"`
 		EndDate    time.Time `json:"end_date"`
@@ -565,6 +876,7 @@ func (h *Handler) GenerateReport(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	if req.UserID == "" {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
@@ -585,19 +897,270 @@ func (h *Handler) GenerateReport(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.generateReportUC.Execute(ctx, &usecase.ReportRequest{
 		UserID:     req.UserID,
+		GroupID:    req.GroupID,
 		ReportType: req.ReportType,
 		StartDate:  req.StartDate,
 		EndDate:    req.EndDate,
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// CompareReports handles GET /api/reports/compare, a month's spending per
+// category against last month's or the same month last year's
+func (h *Handler) CompareReports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := &usecase.ComparisonRequest{
+		UserID:  AuthenticatedUser(r.Context()),
+		GroupID: query.Get("group_id"),
+		Mode:    query.Get("mode"),
+	}
+	if req.UserID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+	if month := query.Get("month"); month != "" {
+		t, err := time.ParseInLocation("2006-01", month, domain.LocationFromContext(r.Context()))
+		if err != nil {
+			h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid month. Use YYYY-MM"})
+			return
+		}
+		req.Month = t
+	}
+
+	resp, err := h.generateReportUC.Compare(r.Context(), req)
+	if err != nil {
+		status := errorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, usecase.ErrInvalidComparison) {
+			status = http.StatusBadRequest
+		}
+		h.WriteJSON(w, status, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// GetCategoryTrends handles GET /api/reports/category-trends, the user's
+// spending per category in each week or month, for charts
+func (h *Handler) GetCategoryTrends(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := &usecase.CategoryTrendSeriesRequest{
+		UserID: AuthenticatedUser(r.Context()),
+		Bucket: query.Get("bucket"),
+	}
+	if req.UserID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+	if periods := query.Get("periods"); periods != "" {
+		n, err := strconv.Atoi(periods)
+		if err != nil || n < 1 {
+			h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "periods must be a positive number"})
+			return
+		}
+		req.Periods = n
+	}
+
+	resp, err := h.metricsUC.GetCategoryTrendSeries(r.Context(), req)
+	if err != nil {
+		status := errorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, usecase.ErrInvalidTrendQuery) {
+			status = http.StatusBadRequest
+		}
+		h.WriteJSON(w, status, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// CreateBudget godoc
+func (h *Handler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	type CreateBudgetRequest struct {
+		UserID     string  `json:"user_id"`
+		CategoryID *string `json:"category_id,omitempty"`
+		GroupID    *string `json:"group_id,omitempty"`
+		Limit      float64 `json:"limit"`
+		Period     string  `json:"period,omitempty"`
+		Threshold  float64 `json:"threshold,omitempty"`
+		Name       string  `json:"name,omitempty"`
+		StartDate  string  `json:"start_date,omitempty"` // YYYY-MM-DD, for a custom period
+		EndDate    string  `json:"end_date,omitempty"`
+	}
+
+	var req CreateBudgetRequest
+	if err := h.ReadJSON(r, &req); err != nil {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+	req.UserID = requestUserID(r, req.UserID)
+
+	if req.UserID == "" || req.Limit == 0 {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id and limit are required"})
+		return
+	}
+	startDate, endDate, ok := budgetDates(req.StartDate, req.EndDate)
+	if !ok {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "start_date and end_date must look like 2026-01-31"})
+		return
+	}
+
+	resp, err := h.budgetManagementUC.CreateBudget(ctx, &usecase.CreateBudgetRequest{
+		UserID:     req.UserID,
+		CategoryID: req.CategoryID,
+		GroupID:    req.GroupID,
+		Limit:      req.Limit,
+		Period:     req.Period,
+		Threshold:  req.Threshold,
+		Name:       req.Name,
+		StartDate:  startDate,
+		EndDate:    endDate,
+	})
+
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
 	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
 }
 
+// UpdateBudget godoc
+func (h *Handler) UpdateBudget(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	type UpdateBudgetRequest struct {
+		ID         string   `json:"id"`
+		UserID     string   `json:"user_id"`
+		CategoryID *string  `json:"category_id,omitempty"`
+		Limit      *float64 `json:"limit,omitempty"`
+		Period     *string  `json:"period,omitempty"`
+		Threshold  *float64 `json:"threshold,omitempty"`
+		Name       *string  `json:"name,omitempty"`
+		StartDate  string   `json:"start_date,omitempty"`
+		EndDate    string   `json:"end_date,omitempty"`
+	}
+
+	var req UpdateBudgetRequest
+	if err := h.ReadJSON(r, &req); err != nil {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+	req.UserID = requestUserID(r, req.UserID)
+
+	if req.ID == "" || req.UserID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "id and user_id are required"})
+		return
+	}
+	startDate, endDate, ok := budgetDates(req.StartDate, req.EndDate)
+	if !ok {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "start_date and end_date must look like 2026-01-31"})
+		return
+	}
+
+	resp, err := h.budgetManagementUC.UpdateBudget(ctx, &usecase.UpdateBudgetRequest{
+		ID:         req.ID,
+		UserID:     req.UserID,
+		CategoryID: req.CategoryID,
+		Limit:      req.Limit,
+		Period:     req.Period,
+		Threshold:  req.Threshold,
+		Name:       req.Name,
+		StartDate:  startDate,
+		EndDate:    endDate,
+	})
+
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// budgetDates parses the optional dates of a custom budget period
+func budgetDates(start, end string) (*time.Time, *time.Time, bool) {
+	var dates [2]*time.Time
+	for i, s := range []string{start, end} {
+		if s == "" {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return nil, nil, false
+		}
+		dates[i] = &date
+	}
+	return dates[0], dates[1], true
+}
+
+// DeleteBudget godoc
+func (h *Handler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	type DeleteBudgetRequest struct {
+		ID     string `json:"id"`
+		UserID string `json:"user_id"`
+	}
+
+	var req DeleteBudgetRequest
+	if err := h.ReadJSON(r, &req); err != nil {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+	req.UserID = requestUserID(r, req.UserID)
+
+	if req.ID == "" || req.UserID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "id and user_id are required"})
+		return
+	}
+
+	resp, err := h.budgetManagementUC.DeleteBudget(ctx, &usecase.DeleteBudgetRequest{
+		ID:     req.ID,
+		UserID: req.UserID,
+	})
+
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// ListBudgets godoc
+func (h *Handler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := r.URL.Query().Get("user_id")
+
+	if userID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	var budgets []*domain.Budget
+	var err error
+	if groupID := r.URL.Query().Get("group_id"); groupID != "" {
+		budgets, err = h.budgetManagementUC.ListGroupBudgets(ctx, groupID, userID)
+	} else {
+		budgets, err = h.budgetManagementUC.ListBudgets(ctx, userID)
+	}
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: budgets})
+}
+
 // GetBudgetStatus godoc
 func (h *Handler) GetBudgetStatus(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -608,18 +1171,69 @@ func (h *Handler) GetBudgetStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.budgetManagementUC.GetBudgetStatus(ctx, &usecase.GetBudgetStatusRequest{
-		UserID: userID,
-	})
+	req := &usecase.GetBudgetStatusRequest{
+		UserID:  userID,
+		GroupID: r.URL.Query().Get("group_id"),
+		Active:  r.URL.Query().Get("active") == "true",
+	}
+	if r.URL.Query().Has("name") {
+		name := r.URL.Query().Get("name")
+		req.Name = &name
+	}
 
+	resp, err := h.budgetManagementUC.GetBudgetStatus(ctx, req)
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
 	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
 }
 
+// ListNamedBudgets handles GET /api/budgets/named, the user's named budgets
+// and which one chat replies report on
+func (h *Handler) ListNamedBudgets(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r, r.URL.Query().Get("user_id"))
+	if userID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	named, err := h.budgetManagementUC.ListNamedBudgets(r.Context(), userID)
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: named})
+}
+
+// SetActiveBudget handles PUT /api/budgets/active, switching the named budget
+// chat replies report on; an empty name is the everyday budget
+func (h *Handler) SetActiveBudget(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+		Name   string `json:"name"`
+	}
+	if err := h.ReadJSON(r, &req); err != nil {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+	req.UserID = requestUserID(r, req.UserID)
+	if req.UserID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	active, err := h.budgetManagementUC.SetActiveBudget(r.Context(), req.UserID, req.Name)
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: active})
+}
+
 // CompareToBudget godoc
 func (h *Handler) CompareToBudget(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -644,7 +1258,7 @@ func (h *Handler) CompareToBudget(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -668,18 +1282,24 @@ func (h *Handler) ExportExpenses(w http.ResponseWriter, r *http.Request) {
 		format = "json"
 	}
 
-	// Parse dates
+	// Parse dates. A PDF statement covers the current month by default.
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 	var start, end time.Time
 	if startDate != "" {
 		start, _ = time.Parse("2006-01-02", startDate)
+	} else if format == "pdf" {
+		start = monthStart
 	} else {
-		start = time.Now().AddDate(-1, 0, 0)
+		start = now.AddDate(-1, 0, 0)
 	}
 
 	if endDate != "" {
 		end, _ = time.Parse("2006-01-02", endDate)
+	} else if format == "pdf" && startDate == "" {
+		end = monthStart.AddDate(0, 1, -1)
 	} else {
-		end = time.Now()
+		end = now
 	}
 
 	req := &usecase.ExportRequest{
@@ -692,19 +1312,32 @@ func (h *Handler) ExportExpenses(w http.ResponseWriter, r *http.Request) {
 	if format == "csv" {
 		data, err := h.dataExportUC.ExportAsCSV(ctx, req)
 		if err != nil {
-			h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+			h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 			return
 		}
 
 		w.Header().Set("Content-Type", "text/csv")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-API-Key, Authorization, X-Workspace-ID, API-Version")
 		w.Header().Set("Content-Disposition", "attachment; filename=expenses.csv")
 		w.WriteHeader(http.StatusOK)
 		w.Write(data)
+	} else if format == "pdf" {
+		data, err := h.dataExportUC.ExportAsPDF(ctx, req)
+		if err != nil {
+			h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", "attachment; filename=statement-"+start.Format("2006-01")+".pdf")
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	} else if format == "xlsx" {
+		h.writeXLSXExport(w, r, req)
 	} else {
 		data, err := h.dataExportUC.ExportAsJSON(ctx, req)
 		if err != nil {
-			h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+			h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 			return
 		}
 
@@ -714,6 +1347,20 @@ func (h *Handler) ExportExpenses(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeXLSXExport sends the expenses as a workbook with a summary sheet and a sheet per category
+func (h *Handler) writeXLSXExport(w http.ResponseWriter, r *http.Request, req *usecase.ExportRequest) {
+	data, err := h.dataExportUC.ExportAsXLSX(r.Context(), req)
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", "attachment; filename=expenses.xlsx")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
 // ExportSummary godoc
 func (h *Handler) ExportSummary(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -740,6 +1387,11 @@ func (h *Handler) ExportSummary(w http.ResponseWriter, r *http.Request) {
 		end = time.Now()
 	}
 
+	if r.URL.Query().Get("format") == "xlsx" {
+		h.writeXLSXExport(w, r, &usecase.ExportRequest{UserID: userID, StartDate: start, EndDate: end})
+		return
+	}
+
 	resp, err := h.dataExportUC.ExportSummary(ctx, &usecase.SummaryExportRequest{
 		UserID:    userID,
 		StartDate: start,
@@ -747,7 +1399,7 @@ func (h *Handler) ExportSummary(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -761,7 +1413,8 @@ func (h *Handler) SearchExpenses(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	categoryID := r.URL.Query().Get("category_id")
 	sortBy := r.URL.Query().Get("sort_by")
-	limit := 20
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
 
 	if userID == "" {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
@@ -777,39 +1430,14 @@ func (h *Handler) SearchExpenses(w http.ResponseWriter, r *http.Request) {
 		UserID:     userID,
 		Query:      query,
 		CategoryID: category,
+		Tags:       listParam(r.URL.Query()["tag"]),
 		SortBy:     sortBy,
 		Limit:      limit,
-		Offset:     0,
+		Offset:     offset,
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
-		return
-	}
-
-	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
-}
-
-// FilterExpenses godoc
-func (h *Handler) FilterExpenses(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-	userID := r.URL.Query().Get("user_id")
-	period := r.URL.Query().Get("period")
-	categoryID := r.URL.Query().Get("category_id")
-
-	if userID == "" {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
-		return
-	}
-
-	resp, err := h.searchExpenseUC.Filter(ctx, &usecase.FilterRequest{
-		UserID:     userID,
-		CategoryID: categoryID,
-		Period:     period,
-	})
-
-	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -834,6 +1462,7 @@ func (h *Handler) CreateRecurring(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.recurringExpenseUC.CreateRecurring(ctx, &usecase.CreateRecurringRequest{
 		UserID:      req.UserID,
@@ -845,7 +1474,7 @@ func (h *Handler) CreateRecurring(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -867,7 +1496,7 @@ func (h *Handler) ListRecurring(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -891,6 +1520,8 @@ func (h *Handler) UpdateRecurring(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
+	req.ID = resourceID(r, req.ID)
 
 	resp, err := h.recurringExpenseUC.UpdateRecurring(ctx, &usecase.UpdateRecurringRequest{
 		UserID:      req.UserID,
@@ -901,7 +1532,7 @@ func (h *Handler) UpdateRecurring(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -912,7 +1543,7 @@ func (h *Handler) UpdateRecurring(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) DeleteRecurring(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID := r.URL.Query().Get("user_id")
-	id := r.URL.Query().Get("id")
+	id := resourceID(r, r.URL.Query().Get("id"))
 
 	if userID == "" || id == "" {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id and id are required"})
@@ -925,7 +1556,7 @@ func (h *Handler) DeleteRecurring(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -942,13 +1573,80 @@ func (h *Handler) GetUpcomingRecurring(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	days := 30
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		if n, err := strconv.Atoi(daysStr); err == nil && n > 0 {
+			days = n
+		}
+	}
+
 	resp, err := h.recurringExpenseUC.GetUpcoming(ctx, &usecase.GetUpcomingRequest{
 		UserID: userID,
-		Days:   30,
+		Days:   days,
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// PreviewRecurrence handles GET /api/recurring/preview, e.g.
+// ?frequency=FREQ=MONTHLY;BYDAY=2FR&start_date=2026-01-01&count=5, validating
+// a frequency and listing when it falls due
+func (h *Handler) PreviewRecurrence(w http.ResponseWriter, r *http.Request) {
+	req := &usecase.PreviewRecurrenceRequest{Frequency: r.URL.Query().Get("frequency")}
+	if startDate := r.URL.Query().Get("start_date"); startDate != "" {
+		start, err := time.ParseInLocation("2006-01-02", startDate, domain.LocationFromContext(r.Context()))
+		if err != nil {
+			h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "start_date must look like 2026-01-31"})
+			return
+		}
+		req.StartDate = start
+	}
+	req.Count, _ = strconv.Atoi(r.URL.Query().Get("count"))
+
+	resp, err := h.recurringExpenseUC.PreviewRecurrence(r.Context(), req)
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// GetDetectedSubscriptions handles GET /api/recurring/detected, the charges
+// the user pays every month that aren't tracked as recurring expenses yet
+func (h *Handler) GetDetectedSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r, r.URL.Query().Get("user_id"))
+	if userID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	resp, err := h.recurringExpenseUC.DetectSubscriptions(r.Context(), userID)
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.WriteJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// ConfirmDetectedSubscription handles POST /api/recurring/detected/{id}/confirm,
+// tracking a detected subscription as a monthly recurring expense
+func (h *Handler) ConfirmDetectedSubscription(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r, r.URL.Query().Get("user_id"))
+	if userID == "" {
+		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	resp, err := h.recurringExpenseUC.ConfirmSubscription(r.Context(), userID, r.PathValue("id"))
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -969,6 +1667,7 @@ func (h *Handler) ProcessRecurring(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.recurringExpenseUC.ProcessRecurring(ctx, &usecase.ProcessRecurringRequest{
 		UserID: req.UserID,
@@ -976,7 +1675,7 @@ func (h *Handler) ProcessRecurring(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1000,6 +1699,7 @@ func (h *Handler) CreateNotification(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.notificationUC.CreateNotification(ctx, &usecase.CreateNotificationRequest{
 		UserID:  req.UserID,
@@ -1010,7 +1710,7 @@ func (h *Handler) CreateNotification(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1033,7 +1733,7 @@ func (h *Handler) ListNotifications(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1050,10 +1750,14 @@ func (h *Handler) MarkNotificationAsRead(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req MarkAsReadRequest
-	if err := h.ReadJSON(r, &req); err != nil {
+	if id := r.PathValue("id"); id != "" {
+		req.NotificationID = id
+		req.UserID = r.URL.Query().Get("user_id")
+	} else if err := h.ReadJSON(r, &req); err != nil {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.notificationUC.MarkAsRead(ctx, &usecase.MarkAsReadRequest{
 		UserID:         req.UserID,
@@ -1061,7 +1765,7 @@ func (h *Handler) MarkNotificationAsRead(w http.ResponseWriter, r *http.Request)
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1081,13 +1785,14 @@ func (h *Handler) MarkAllNotificationsAsRead(w http.ResponseWriter, r *http.Requ
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.notificationUC.MarkAllAsRead(ctx, &usecase.MarkAllAsReadRequest{
 		UserID: req.UserID,
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1098,7 +1803,7 @@ func (h *Handler) MarkAllNotificationsAsRead(w http.ResponseWriter, r *http.Requ
 func (h *Handler) DeleteNotification(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID := r.URL.Query().Get("user_id")
-	notificationID := r.URL.Query().Get("id")
+	notificationID := resourceID(r, r.URL.Query().Get("id"))
 
 	if userID == "" || notificationID == "" {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id and id are required"})
@@ -1111,7 +1816,7 @@ func (h *Handler) DeleteNotification(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1133,7 +1838,7 @@ func (h *Handler) GetNotificationPreferences(w http.ResponseWriter, r *http.Requ
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1145,13 +1850,22 @@ func (h *Handler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.R
 	ctx := r.Context()
 
 	type UpdatePreferencesRequest struct {
-		UserID              string `json:"user_id"`
-		BudgetAlerts        *bool  `json:"budget_alerts,omitempty"`
-		RecurringReminders  *bool  `json:"recurring_reminders,omitempty"`
-		ReportNotifications *bool  `json:"report_notifications,omitempty"`
-		ExpenseReminders    *bool  `json:"expense_reminders,omitempty"`
-		DailyDigest         *bool  `json:"daily_digest,omitempty"`
-		WeeklyReport        *bool  `json:"weekly_report,omitempty"`
+		UserID              string  `json:"user_id"`
+		BudgetAlerts        *bool   `json:"budget_alerts,omitempty"`
+		RecurringReminders  *bool   `json:"recurring_reminders,omitempty"`
+		ReportNotifications *bool   `json:"report_notifications,omitempty"`
+		ExpenseReminders    *bool   `json:"expense_reminders,omitempty"`
+		DailyDigest         *bool   `json:"daily_digest,omitempty"`
+		DailyDigestHour     *int    `json:"daily_digest_hour,omitempty"`
+		WeeklyReport        *bool   `json:"weekly_report,omitempty"`
+		ExpenseReminderDays *int    `json:"expense_reminder_days,omitempty"`
+		QuietHoursStart     *int    `json:"quiet_hours_start,omitempty"`
+		QuietHoursEnd       *int    `json:"quiet_hours_end,omitempty"`
+		SpendingAnomalies   *bool   `json:"spending_anomalies,omitempty"`
+		AnomalySensitivity  *string `json:"anomaly_sensitivity,omitempty"` // low, medium or high
+
+		Channels    map[string]string             `json:"channels,omitempty"` // e.g. {"weekly_report": "email"}
+		EmailReport *usecase.ReportScheduleUpdate `json:"email_report,omitempty"`
 	}
 
 	var req UpdatePreferencesRequest
@@ -1159,6 +1873,7 @@ func (h *Handler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.R
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.notificationUC.UpdatePreferences(ctx, &usecase.UpdatePreferencesRequest{
 		UserID:              req.UserID,
@@ -1167,11 +1882,19 @@ func (h *Handler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.R
 		ReportNotifications: req.ReportNotifications,
 		ExpenseReminders:    req.ExpenseReminders,
 		DailyDigest:         req.DailyDigest,
+		DailyDigestHour:     req.DailyDigestHour,
 		WeeklyReport:        req.WeeklyReport,
+		ExpenseReminderDays: req.ExpenseReminderDays,
+		QuietHoursStart:     req.QuietHoursStart,
+		QuietHoursEnd:       req.QuietHoursEnd,
+		SpendingAnomalies:   req.SpendingAnomalies,
+		AnomalySensitivity:  req.AnomalySensitivity,
+		Channels:            req.Channels,
+		EmailReport:         req.EmailReport,
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1195,6 +1918,7 @@ func (h *Handler) CreateArchive(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.archiveUC.CreateArchive(ctx, &usecase.CreateArchiveRequest{
 		UserID:        req.UserID,
@@ -1205,7 +1929,7 @@ func (h *Handler) CreateArchive(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1222,12 +1946,17 @@ func (h *Handler) ListArchives(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
 	resp, err := h.archiveUC.ListArchives(ctx, &usecase.ListArchivesRequest{
 		UserID: userID,
+		Limit:  limit,
+		Offset: offset,
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1249,7 +1978,7 @@ func (h *Handler) GetArchiveStats(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1273,7 +2002,7 @@ func (h *Handler) GetArchiveDetails(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1295,6 +2024,7 @@ func (h *Handler) RestoreArchive(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.archiveUC.RestoreArchive(ctx, &usecase.RestoreArchiveRequest{
 		UserID:    req.UserID,
@@ -1303,7 +2033,7 @@ func (h *Handler) RestoreArchive(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1325,6 +2055,7 @@ func (h *Handler) PurgeArchive(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.archiveUC.PurgeArchive(ctx, &usecase.PurgeArchiveRequest{
 		UserID:  req.UserID,
@@ -1333,7 +2064,7 @@ func (h *Handler) PurgeArchive(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1355,6 +2086,7 @@ func (h *Handler) ExportArchive(w http.ResponseWriter, r *http.Request) {
 		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
 		return
 	}
+	req.UserID = requestUserID(r, req.UserID)
 
 	resp, err := h.archiveUC.ExportArchive(ctx, &usecase.ExportArchiveRequest{
 		UserID:    req.UserID,
@@ -1363,7 +2095,7 @@ func (h *Handler) ExportArchive(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.WriteJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		h.WriteJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -1375,106 +2107,297 @@ func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	h.WriteJSON(w, http.StatusOK, &Response{Status: "ok"})
 }
 
-// RegisterRoutes registers all HTTP routes
-func RegisterRoutes(
-	mux *http.ServeMux,
-	handler *Handler,
-	aiCostHandler *AICostHandler,
-	pricingHandler *PricingHandler,
-	reportHandler *ReportHandler,
-	shortLinkHandler *ShortLinkHandler,
-) {
+// Handlers are the HTTP handlers RegisterRoutes serves. API is required; the
+// routes of any other handler left nil are not registered, and without APIKey
+// the admin routes don't check the caller's scope.
+type Handlers struct {
+	API           *Handler
+	AICost        *AICostHandler
+	Pricing       *PricingHandler
+	Report        *ReportHandler
+	ShortLink     *ShortLinkHandler
+	Group         *GroupHandler
+	Split         *SplitHandler
+	Attachment    *AttachmentHandler
+	Prompt        *PromptHandler
+	History       *ExpenseHistoryHandler
+	Interaction   *InteractionHandler
+	Import        *ImportHandler
+	Webhook       *WebhookHandler
+	Stream        *StreamHandler
+	Auth          *AuthHandler
+	APIKey        *APIKeyHandler
+	UserDeletion  *UserDeletionHandler
+	UserExport    *UserExportHandler
+	EmailAddress  *EmailAddressHandler
+	ArchivePolicy *ArchivePolicyHandler
+	Tag           *TagHandler
+	Merchant      *MerchantHandler
+	Chart         *ChartHandler
+	Claim         *ClaimHandler
+	Workspace     *WorkspaceHandler
+}
+
+// RegisterRoutes registers all HTTP routes. The API endpoints are served from
+// a route table under /api/v1 and at their deprecated unversioned /api paths.
+func RegisterRoutes(mux *http.ServeMux, h Handlers) {
+	var routes apiRoutes
+
 	// User endpoints
-	mux.HandleFunc("POST /api/users/auto-signup", handler.AutoSignup)
+	routes.handle("POST /users/auto-signup", h.API.AutoSignup)
+	if h.UserDeletion != nil {
+		routes.handle("DELETE /users/me", h.UserDeletion.DeleteMe)
+		routes.handle("GET /users/me/deletion", h.UserDeletion.GetMyDeletion)
+		routes.handle("POST /users/me/restore", h.UserDeletion.RestoreMe)
+	}
+	if h.UserExport != nil {
+		routes.handle("GET /users/me/export", h.UserExport.ExportMe)
+		routes.handle("GET /exports/{token}", h.UserExport.Download)
+	}
+	if h.EmailAddress != nil {
+		routes.handle("POST /users/me/email-addresses", h.EmailAddress.AddEmailAddress)
+		routes.handle("POST /users/me/email-addresses/verify", h.EmailAddress.VerifyEmailAddress)
+		routes.handle("GET /users/me/email-addresses", h.EmailAddress.ListEmailAddresses)
+		routes.handle("DELETE /users/me/email-addresses/{address}", h.EmailAddress.DeleteEmailAddress)
+	}
+	if h.ArchivePolicy != nil {
+		routes.handle("GET /users/me/archive-policy", h.ArchivePolicy.GetMyPolicy)
+		routes.handle("PUT /users/me/archive-policy", h.ArchivePolicy.UpdateMyPolicy)
+		routes.handle("DELETE /users/me/archive-policy", h.ArchivePolicy.ResetMyPolicy)
+	}
+
+	// Sign-in endpoints
+	if h.Auth != nil {
+		routes.handle("POST /auth/code", h.Auth.RequestCode)
+		routes.handle("POST /auth/token", h.Auth.IssueToken)
+		routes.handle("POST /auth/login/{messenger}", h.Auth.Login)
+		routes.handle("POST /auth/logout", h.Auth.Logout)
+	}
 
 	// Expense endpoints
-	mux.HandleFunc("POST /api/expenses/parse", handler.ParseExpenses)
-	mux.HandleFunc("POST /api/expenses", handler.CreateExpense)
-	mux.HandleFunc("PUT /api/expenses", handler.UpdateExpense)
-	mux.HandleFunc("DELETE /api/expenses", handler.DeleteExpense)
-	mux.HandleFunc("GET /api/expenses", handler.GetExpenses)
-	mux.HandleFunc("GET /api/expenses/search", handler.SearchExpenses)
-	mux.HandleFunc("GET /api/expenses/filter", handler.FilterExpenses)
+	routes.handle("POST /expenses/parse", h.API.ParseExpenses)
+	routes.handle("POST /expenses", h.API.CreateExpense)
+	routes.handle("POST /expenses/batch", h.API.CreateExpenseBatch)
+	routes.handle("GET /expenses", h.API.GetExpenses)
+	routes.handle("GET /expenses/{id}", h.API.GetExpense)
+	routes.handle("PUT /expenses/{id}", h.API.UpdateExpense)
+	routes.handle("DELETE /expenses/{id}", h.API.DeleteExpense)
+	routes.handle("POST /expenses/{id}/restore", h.API.RestoreExpense)
+	routes.handle("GET /expenses/search", h.API.SearchExpenses)
+	routes.handle("GET /expenses/filter", h.API.FilterExpenses)
+	routes.handle("POST /expenses/filter", h.API.FilterExpenses)
+	if h.Split != nil {
+		routes.handle("POST /expenses/split", h.Split.SplitExpense)
+		routes.handle("GET /expenses/split", h.Split.GetSplit)
+		routes.handle("GET /splits/summary", h.Split.GetSummary)
+	}
+	if h.Attachment != nil {
+		routes.handle("GET /expenses/{id}/attachments", h.Attachment.ListAttachments)
+		routes.handle("GET /attachments/{id}", h.Attachment.GetAttachment)
+	}
+	if h.History != nil {
+		routes.handle("GET /expenses/{id}/history", h.History.GetHistory)
+	}
+	if h.Tag != nil {
+		routes.handle("PUT /expenses/{id}/tags", h.Tag.SetExpenseTags)
+	}
+	if h.Claim != nil {
+		routes.handle("PUT /expenses/{id}/reimbursable", h.Claim.SetReimbursable)
+	}
 
 	// Category endpoints
-	mux.HandleFunc("POST /api/categories", handler.CreateCategory)
-	mux.HandleFunc("PUT /api/categories", handler.UpdateCategory)
-	mux.HandleFunc("DELETE /api/categories", handler.DeleteCategory)
-	mux.HandleFunc("GET /api/categories", handler.GetCategories)
-	mux.HandleFunc("GET /api/categories/list", handler.ListCategories)
+	routes.handle("POST /categories", h.API.CreateCategory)
+	routes.handle("PUT /categories/{id}", h.API.UpdateCategory)
+	routes.handle("DELETE /categories/{id}", h.API.DeleteCategory)
+	routes.handle("GET /categories", h.API.GetCategories)
+	routes.handle("GET /categories/list", h.API.ListCategories)
+
+	// Tag endpoints
+	if h.Tag != nil {
+		routes.handle("GET /tags", h.Tag.ListTags)
+		routes.handle("POST /tags", h.Tag.CreateTag)
+		routes.handle("PUT /tags/{id}", h.Tag.RenameTag)
+		routes.handle("DELETE /tags/{id}", h.Tag.DeleteTag)
+	}
+
+	// Merchant endpoints
+	if h.Merchant != nil {
+		routes.handle("GET /merchants/top", h.Merchant.TopMerchants)
+		routes.handle("GET /merchants/{id}/trend", h.Merchant.MerchantTrend)
+	}
+
+	// Reimbursement claim endpoints
+	if h.Claim != nil {
+		routes.handle("GET /claims", h.Claim.ListClaims)
+		routes.handle("PUT /claims/status", h.Claim.UpdateClaimStatus)
+		routes.handle("GET /claims/export", h.Claim.ExportClaims)
+	}
+
+	// Workspace endpoints
+	if h.Workspace != nil {
+		routes.handle("GET /workspaces", h.Workspace.ListWorkspaces)
+		routes.handle("POST /workspaces", h.Workspace.CreateWorkspace)
+		routes.handle("PUT /workspaces/active", h.Workspace.SwitchWorkspace)
+		routes.handle("PUT /workspaces/{id}", h.Workspace.RenameWorkspace)
+		routes.handle("DELETE /workspaces/{id}", h.Workspace.DeleteWorkspace)
+	}
 
 	// Recurring expense endpoints
-	mux.HandleFunc("POST /api/recurring", handler.CreateRecurring)
-	mux.HandleFunc("GET /api/recurring", handler.ListRecurring)
-	mux.HandleFunc("PUT /api/recurring", handler.UpdateRecurring)
-	mux.HandleFunc("DELETE /api/recurring", handler.DeleteRecurring)
-	mux.HandleFunc("GET /api/recurring/upcoming", handler.GetUpcomingRecurring)
-	mux.HandleFunc("POST /api/recurring/process", handler.ProcessRecurring)
+	routes.handle("POST /recurring", h.API.CreateRecurring)
+	routes.handle("GET /recurring", h.API.ListRecurring)
+	routes.handle("PUT /recurring/{id}", h.API.UpdateRecurring)
+	routes.handle("DELETE /recurring/{id}", h.API.DeleteRecurring)
+	routes.handle("GET /recurring/upcoming", h.API.GetUpcomingRecurring)
+	routes.handle("GET /recurring/preview", h.API.PreviewRecurrence)
+	routes.handle("GET /recurring/detected", h.API.GetDetectedSubscriptions)
+	routes.handle("POST /recurring/detected/{id}/confirm", h.API.ConfirmDetectedSubscription)
+	routes.handle("POST /recurring/process", h.API.ProcessRecurring)
 
 	// Notification endpoints
-	mux.HandleFunc("POST /api/notifications", handler.CreateNotification)
-	mux.HandleFunc("GET /api/notifications", handler.ListNotifications)
-	mux.HandleFunc("PUT /api/notifications", handler.MarkNotificationAsRead)
-	mux.HandleFunc("PUT /api/notifications/mark-all", handler.MarkAllNotificationsAsRead)
-	mux.HandleFunc("DELETE /api/notifications", handler.DeleteNotification)
-	mux.HandleFunc("GET /api/notifications/preferences", handler.GetNotificationPreferences)
-	mux.HandleFunc("PUT /api/notifications/preferences", handler.UpdateNotificationPreferences)
+	routes.handle("POST /notifications", h.API.CreateNotification)
+	routes.handle("GET /notifications", h.API.ListNotifications)
+	routes.handle("PUT /notifications/{id}/read", h.API.MarkNotificationAsRead)
+	routes.handle("PUT /notifications/mark-all", h.API.MarkAllNotificationsAsRead)
+	routes.handle("DELETE /notifications/{id}", h.API.DeleteNotification)
+	routes.handle("GET /notifications/preferences", h.API.GetNotificationPreferences)
+	routes.handle("PUT /notifications/preferences", h.API.UpdateNotificationPreferences)
 
 	// Archive endpoints
-	mux.HandleFunc("POST /api/archives", handler.CreateArchive)
-	mux.HandleFunc("GET /api/archives", handler.ListArchives)
-	mux.HandleFunc("GET /api/archives/stats", handler.GetArchiveStats)
-	mux.HandleFunc("GET /api/archives/details", handler.GetArchiveDetails)
-	mux.HandleFunc("POST /api/archives/restore", handler.RestoreArchive)
-	mux.HandleFunc("POST /api/archives/purge", handler.PurgeArchive)
-	mux.HandleFunc("POST /api/archives/export", handler.ExportArchive)
+	routes.handle("POST /archives", h.API.CreateArchive)
+	routes.handle("GET /archives", h.API.ListArchives)
+	routes.handle("GET /archives/stats", h.API.GetArchiveStats)
+	routes.handle("GET /archives/details", h.API.GetArchiveDetails)
+	routes.handle("POST /archives/restore", h.API.RestoreArchive)
+	routes.handle("POST /archives/purge", h.API.PurgeArchive)
+	routes.handle("POST /archives/export", h.API.ExportArchive)
 
 	// Report endpoints
-	mux.HandleFunc("POST /api/reports/generate", handler.GenerateReport)
-	if reportHandler != nil {
-		mux.HandleFunc("GET /api/reports/summary", reportHandler.GetReportSummary)
+	routes.handle("POST /reports/generate", h.API.GenerateReport)
+	routes.handle("GET /reports/category-trends", h.API.GetCategoryTrends)
+	routes.handle("GET /reports/compare", h.API.CompareReports)
+	if h.Report != nil {
+		routes.handle("GET /reports/summary", h.Report.GetReportSummary)
 	}
 
 	// Short link endpoint
-	if shortLinkHandler != nil {
-		mux.HandleFunc("GET /r/{id}", shortLinkHandler.HandleRedirect)
+	if h.ShortLink != nil {
+		mux.HandleFunc("GET /r/{id}", h.ShortLink.HandleRedirect)
+	}
+
+	// Chart images linked from report replies
+	if h.Chart != nil {
+		mux.HandleFunc("GET /charts/{name}", h.Chart.GetChart)
+	}
+
+	// Group ledger endpoints
+	if h.Group != nil {
+		routes.handle("GET /groups", h.Group.ListGroups)
+		routes.handle("GET /groups/{id}/members", h.Group.ListMembers)
+		routes.handle("GET /groups/{id}/settlement", h.Group.GetSettlement)
 	}
 
 	// Budget endpoints
-	mux.HandleFunc("GET /api/budgets/status", handler.GetBudgetStatus)
-	mux.HandleFunc("GET /api/budgets/compare", handler.CompareToBudget)
+	routes.handle("POST /budgets", h.API.CreateBudget)
+	routes.handle("GET /budgets", h.API.ListBudgets)
+	routes.handle("PUT /budgets", h.API.UpdateBudget)
+	routes.handle("DELETE /budgets", h.API.DeleteBudget)
+	routes.handle("GET /budgets/status", h.API.GetBudgetStatus)
+	routes.handle("GET /budgets/named", h.API.ListNamedBudgets)
+	routes.handle("PUT /budgets/active", h.API.SetActiveBudget)
+	routes.handle("GET /budgets/compare", h.API.CompareToBudget)
 
 	// Export endpoints
-	mux.HandleFunc("GET /api/export/expenses", handler.ExportExpenses)
-	mux.HandleFunc("GET /api/export/summary", handler.ExportSummary)
+	routes.handle("GET /export/expenses", h.API.ExportExpenses)
+	routes.handle("GET /export/summary", h.API.ExportSummary)
+
+	// Import endpoints
+	if h.Import != nil {
+		routes.handle("POST /import", h.Import.ImportExpenses)
+	}
+
+	// Webhook endpoints
+	if h.Webhook != nil {
+		routes.handle("POST /webhooks", h.Webhook.CreateWebhook)
+		routes.handle("GET /webhooks", h.Webhook.ListWebhooks)
+		routes.handle("PUT /webhooks/{id}", h.Webhook.UpdateWebhook)
+		routes.handle("DELETE /webhooks/{id}", h.Webhook.DeleteWebhook)
+		routes.handle("GET /webhooks/{id}/deliveries", h.Webhook.ListDeliveries)
+	}
+
+	// Live update stream
+	if h.Stream != nil {
+		routes.handle("GET /stream", h.Stream.Stream)
+	}
 
 	// Metrics endpoints
-	mux.HandleFunc("GET /api/metrics/dau", handler.GetMetricsDAU)
-	mux.HandleFunc("GET /api/metrics/expenses-summary", handler.GetMetricsExpenses)
-	mux.HandleFunc("GET /api/metrics/growth", handler.GetMetricsGrowth)
-	mux.HandleFunc("POST /api/exchange-rates/refresh", handler.RefreshExchangeRates)
+	routes.handle("GET /metrics/dau", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.API.GetMetricsDAU))
+	routes.handle("GET /metrics/expenses-summary", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.API.GetMetricsExpenses))
+	routes.handle("GET /metrics/growth", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.API.GetMetricsGrowth))
+	routes.handle("GET /metrics/retention", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.API.GetMetricsRetention))
+	routes.handle("GET /metrics/platforms", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.API.GetMetricsPlatforms))
+	routes.handle("GET /metrics/events", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.API.GetMetricsEvents))
+	routes.handle("GET /metrics/db-pool", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.API.GetMetricsDBPool))
+	routes.handle("GET /metrics/ai-providers", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.API.GetMetricsAIProviders))
+	routes.handle("POST /exchange-rates/refresh", requireScope(h.APIKey, domain.APIKeyScopeAdmin, h.API.RefreshExchangeRates))
+
+	// Admin endpoints
+	if h.Interaction != nil {
+		routes.handle("GET /admin/interactions", requireScope(h.APIKey, domain.APIKeyScopeInteractionsRead, h.Interaction.ListInteractions))
+	}
+	if h.APIKey != nil {
+		routes.handle("POST /admin/api-keys", h.APIKey.RequireScope(domain.APIKeyScopeAdmin, h.APIKey.CreateAPIKey))
+		routes.handle("GET /admin/api-keys", h.APIKey.RequireScope(domain.APIKeyScopeAdmin, h.APIKey.ListAPIKeys))
+		routes.handle("DELETE /admin/api-keys/{id}", h.APIKey.RequireScope(domain.APIKeyScopeAdmin, h.APIKey.DeleteAPIKey))
+	}
+	if h.UserDeletion != nil {
+		routes.handle("GET /admin/user-deletions", requireScope(h.APIKey, domain.APIKeyScopeAdmin, h.UserDeletion.ListDeletions))
+	}
+	if h.ArchivePolicy != nil {
+		routes.handle("GET /admin/archive-policy/report", requireScope(h.APIKey, domain.APIKeyScopeAdmin, h.ArchivePolicy.GetReport))
+	}
+
+	// Currency endpoints
+	routes.handle("GET /currencies/rates", h.API.GetCurrencyRates)
 
 	// AI Cost endpoints
-	if aiCostHandler != nil {
-		mux.HandleFunc("GET /api/metrics/ai-costs", aiCostHandler.GetAICostMetrics)
-		mux.HandleFunc("GET /api/metrics/ai-costs/summary", aiCostHandler.GetAICostSummary)
-		mux.HandleFunc("GET /api/metrics/ai-costs/daily", aiCostHandler.GetAICostDaily)
-		mux.HandleFunc("GET /api/metrics/ai-costs/by-operation", aiCostHandler.GetAICostByOperation)
-		mux.HandleFunc("GET /api/metrics/ai-costs/top-users", aiCostHandler.GetAICostTopUsers)
+	if h.AICost != nil {
+		routes.handle("GET /metrics/ai-costs", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.AICost.GetAICostMetrics))
+		routes.handle("GET /metrics/ai-costs/summary", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.AICost.GetAICostSummary))
+		routes.handle("GET /metrics/ai-costs/daily", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.AICost.GetAICostDaily))
+		routes.handle("GET /metrics/ai-costs/by-operation", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.AICost.GetAICostByOperation))
+		routes.handle("GET /metrics/ai-costs/top-users", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.AICost.GetAICostTopUsers))
+		routes.handle("GET /metrics/ai-costs/caps", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.AICost.GetAICostCaps))
+		routes.handle("PUT /metrics/ai-costs/caps/{scope}", requireScope(h.APIKey, domain.APIKeyScopePricingWrite, h.AICost.UpdateAICostCap))
+		routes.handle("DELETE /metrics/ai-costs/caps/{scope}", requireScope(h.APIKey, domain.APIKeyScopePricingWrite, h.AICost.DeleteAICostCap))
+		routes.handle("GET /ai-costs/anomalies", requireScope(h.APIKey, domain.APIKeyScopeMetricsRead, h.AICost.GetAICostAnomalies))
 	}
 
 	// Pricing endpoints
-	if pricingHandler != nil {
-		mux.HandleFunc("POST /api/pricing/sync", pricingHandler.SyncPricing)
-		mux.HandleFunc("GET /api/pricing", pricingHandler.ListPricing)
-		mux.HandleFunc("POST /api/pricing", pricingHandler.CreatePricing)
-		mux.HandleFunc("PUT /api/pricing/{id}", pricingHandler.UpdatePricing)
-		mux.HandleFunc("DELETE /api/pricing/{id}", pricingHandler.DeletePricing)
+	if h.Prompt != nil {
+		routes.handle("GET /prompts", requireScope(h.APIKey, domain.APIKeyScopePromptsWrite, h.Prompt.ListPrompts))
+		routes.handle("GET /prompts/{name}", requireScope(h.APIKey, domain.APIKeyScopePromptsWrite, h.Prompt.GetPrompt))
+		routes.handle("POST /prompts/{name}/versions", requireScope(h.APIKey, domain.APIKeyScopePromptsWrite, h.Prompt.CreatePromptVersion))
+		routes.handle("PUT /prompts/{name}/active", requireScope(h.APIKey, domain.APIKeyScopePromptsWrite, h.Prompt.ActivatePromptVersion))
 	}
 
+	routes.register(mux)
+	if h.Pricing != nil {
+		RegisterPricingRoutes(mux, h.Pricing, h.APIKey)
+	}
+
+	// Deprecated ID-in-body/query aliases, kept for one release
+	mux.HandleFunc("PUT /api/expenses", deprecatedRoute("/api/v1/expenses/{id}", h.API.UpdateExpense))
+	mux.HandleFunc("DELETE /api/expenses", deprecatedRoute("/api/v1/expenses/{id}", h.API.DeleteExpense))
+	mux.HandleFunc("PUT /api/categories", deprecatedRoute("/api/v1/categories/{id}", h.API.UpdateCategory))
+	mux.HandleFunc("DELETE /api/categories", deprecatedRoute("/api/v1/categories/{id}", h.API.DeleteCategory))
+	mux.HandleFunc("PUT /api/recurring", deprecatedRoute("/api/v1/recurring/{id}", h.API.UpdateRecurring))
+	mux.HandleFunc("DELETE /api/recurring", deprecatedRoute("/api/v1/recurring/{id}", h.API.DeleteRecurring))
+	mux.HandleFunc("PUT /api/notifications", deprecatedRoute("/api/v1/notifications/{id}/read", h.API.MarkNotificationAsRead))
+	mux.HandleFunc("DELETE /api/notifications", deprecatedRoute("/api/v1/notifications/{id}", h.API.DeleteNotification))
+
 	// Legal endpoints
-	mux.HandleFunc("GET /api/policies/{key}", handler.GetPolicy)
+	routes.handle("GET /policies/{key}", h.API.GetPolicy)
 
 	// Health endpoint
-	mux.HandleFunc("/health", handler.Health)
+	mux.HandleFunc("/health", h.API.Health)
 }
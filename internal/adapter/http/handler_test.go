@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -32,6 +33,13 @@ func (m *MockExpenseRepository) Create(ctx context.Context, expense *domain.Expe
 	return nil
 }
 
+func (m *MockExpenseRepository) CreateBatch(ctx context.Context, expenses []*domain.Expense) error {
+	for _, expense := range expenses {
+		m.expenses[expense.ID] = expense
+	}
+	return nil
+}
+
 func (m *MockExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Expense, error) {
 	if exp, ok := m.expenses[id]; ok {
 		return exp, nil
@@ -49,6 +57,10 @@ func (m *MockExpenseRepository) GetByUserID(ctx context.Context, userID string)
 	return result, nil
 }
 
+func (m *MockExpenseRepository) ListByUserID(ctx context.Context, userID string, opts domain.ExpenseListOptions) ([]*domain.Expense, error) {
+	return m.GetByUserID(ctx, userID)
+}
+
 func (m *MockExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, from, to time.Time) ([]*domain.Expense, error) {
 	var result []*domain.Expense
 	for _, exp := range m.expenses {
@@ -59,6 +71,34 @@ func (m *MockExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, use
 	return result, nil
 }
 
+func (m *MockExpenseRepository) GetByGroupIDAndDateRange(ctx context.Context, groupID string, from, to time.Time) ([]*domain.Expense, error) {
+	var result []*domain.Expense
+	for _, exp := range m.expenses {
+		if exp.GroupID != nil && *exp.GroupID == groupID && !exp.ExpenseDate.Before(from) && !exp.ExpenseDate.After(to) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockExpenseRepository) selectTotals(query domain.ExpenseTotalsQuery) []*domain.Expense {
+	var selected []*domain.Expense
+	for _, exp := range m.expenses {
+		if query.Matches(exp) {
+			selected = append(selected, exp)
+		}
+	}
+	return selected
+}
+
+func (m *MockExpenseRepository) SumByCategoryAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.CategoryTotal, error) {
+	return domain.SumExpensesByCategory(m.selectTotals(query)), nil
+}
+
+func (m *MockExpenseRepository) SumByPeriodAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery, period string) ([]*domain.PeriodTotal, error) {
+	return domain.SumExpensesByPeriod(m.selectTotals(query), period), nil
+}
+
 func (m *MockExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID, categoryID string) ([]*domain.Expense, error) {
 	var result []*domain.Expense
 	for _, exp := range m.expenses {
@@ -69,6 +109,16 @@ func (m *MockExpenseRepository) GetByUserIDAndCategory(ctx context.Context, user
 	return result, nil
 }
 
+func (m *MockExpenseRepository) Filter(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, error) {
+	var result []*domain.Expense
+	for _, exp := range m.expenses {
+		if filter.Matches(exp) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
 func (m *MockExpenseRepository) Update(ctx context.Context, expense *domain.Expense) error {
 	m.expenses[expense.ID] = expense
 	return nil
@@ -79,6 +129,14 @@ func (m *MockExpenseRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (m *MockExpenseRepository) GetDeletedByID(ctx context.Context, id string) (*domain.Expense, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockExpenseRepository) Restore(ctx context.Context, id string) error {
+	return nil
+}
+
 // MockUserRepository for HTTP handler tests
 type MockUserRepository struct {
 	users map[string]*domain.User
@@ -102,6 +160,11 @@ func (m *MockUserRepository) GetByID(ctx context.Context, userID string) (*domai
 	return nil, ErrNotFound
 }
 
+func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
+	m.users[user.UserID] = user
+	return nil
+}
+
 func (m *MockUserRepository) Exists(ctx context.Context, userID string) (bool, error) {
 	_, ok := m.users[userID]
 	return ok, nil
@@ -167,6 +230,14 @@ func (m *MockCategoryRepository) GetKeywordsByCategory(ctx context.Context, cate
 	return []*domain.CategoryKeyword{}, nil
 }
 
+func (m *MockCategoryRepository) GetKeywordsByUserID(ctx context.Context, userID string) ([]*domain.CategoryKeyword, error) {
+	return []*domain.CategoryKeyword{}, nil
+}
+
+func (m *MockCategoryRepository) UpdateKeyword(ctx context.Context, keyword *domain.CategoryKeyword) error {
+	return nil
+}
+
 func (m *MockCategoryRepository) DeleteKeyword(ctx context.Context, id string) error {
 	return nil
 }
@@ -249,6 +320,14 @@ func (m *MockMetricsRepository) GetNewUsersPerDay(ctx context.Context, from, to
 	return []*domain.DailyMetrics{}, nil
 }
 
+func (m *MockMetricsRepository) GetUserActivity(ctx context.Context, since time.Time) ([]*domain.UserActivity, error) {
+	return []*domain.UserActivity{}, nil
+}
+
+func (m *MockMetricsRepository) GetPlatformMetrics(ctx context.Context, from, to time.Time) ([]*domain.PlatformMetrics, error) {
+	return []*domain.PlatformMetrics{}, nil
+}
+
 // TestCreateExpenseRequest tests expense creation request parsing
 func TestCreateExpenseRequest(t *testing.T) {
 	requestBody := map[string]interface{}{
@@ -371,3 +450,19 @@ func TestHTTPStatusCodes(t *testing.T) {
 		t.Errorf("Expected status 500, got %d", w.Code)
 	}
 }
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("expense %w", domain.ErrNotFound), http.StatusNotFound},
+		{fmt.Errorf("category 'Food' %w", domain.ErrConflict), http.StatusConflict},
+		{errors.New("invalid amount"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if got := errorStatus(tt.err, http.StatusBadRequest); got != tt.want {
+			t.Errorf("errorStatus(%q) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	healthUseCase *usecase.HealthUseCase
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(healthUseCase *usecase.HealthUseCase) *HealthHandler {
+	return &HealthHandler{healthUseCase: healthUseCase}
+}
+
+// Livez handles GET /livez. It checks no dependencies, so an outage of one
+// takes pods out of rotation through /readyz instead of restarting them all.
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &Response{Status: "ok", Data: h.healthUseCase.Liveness()})
+}
+
+// Readyz handles GET /readyz with the status of each component. It answers
+// 503 when a required component fails, and 200 while the server is ready or
+// only degraded.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	readiness := h.healthUseCase.Readiness(r.Context())
+	if readiness.Status == usecase.ReadinessNotReady {
+		writeJSON(w, http.StatusServiceUnavailable, &Response{Status: "error", Error: "Not ready", Data: readiness})
+		return
+	}
+	writeJSON(w, http.StatusOK, &Response{Status: "ok", Data: readiness})
+}
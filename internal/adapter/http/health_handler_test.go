@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+func TestHealthHandler(t *testing.T) {
+	var dbErr, aiErr error
+	healthUseCase := usecase.NewHealthUseCase()
+	healthUseCase.AddCheck(usecase.HealthCheck{Name: "database", Check: func(ctx context.Context) error { return dbErr }})
+	healthUseCase.AddCheck(usecase.HealthCheck{Name: "ai_provider", Optional: true, Check: func(ctx context.Context) error { return aiErr }})
+	handler := NewHealthHandler(healthUseCase)
+
+	readyz := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.Readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+		return w
+	}
+
+	if w := readyz(); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"ready"`) {
+		t.Errorf("expected ready, got %d %s", w.Code, w.Body.String())
+	}
+
+	aiErr = errors.New("API error 503")
+	if w := readyz(); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"degraded"`) {
+		t.Errorf("expected degraded but serving, got %d %s", w.Code, w.Body.String())
+	}
+
+	dbErr = errors.New("connection refused")
+	w := readyz()
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"error":"connection refused"`) {
+		t.Errorf("expected 503 naming the database error, got %d %s", w.Code, w.Body.String())
+	}
+
+	// Liveness doesn't depend on the database
+	w = httptest.NewRecorder()
+	handler.Livez(w, httptest.NewRequest("GET", "/livez", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"uptime"`) {
+		t.Errorf("expected live, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// maxImportFileSize is the largest statement file accepted for import
+const maxImportFileSize = 5 << 20
+
+// ImportHandler serves bank statement imports
+type ImportHandler struct {
+	importUC *usecase.ImportUseCase
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(importUC *usecase.ImportUseCase) *ImportHandler {
+	return &ImportHandler{
+		importUC: importUC,
+	}
+}
+
+func (h *ImportHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// ImportExpenses handles POST /api/import, a multipart form with the statement
+// in "file" and the fields user_id, format (csv or ofx, detected when omitted),
+// profile or mapping (a JSON column mapping) and account
+func (h *ImportHandler) ImportExpenses(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize+1<<20)
+	if err := r.ParseMultipartForm(maxImportFileSize); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "invalid upload: " + err.Error()})
+		return
+	}
+
+	userID := requestUserID(r, r.FormValue("user_id"))
+	if userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "file is required"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileSize+1))
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "failed to read file"})
+		return
+	}
+	if len(data) > maxImportFileSize {
+		h.writeJSON(w, http.StatusRequestEntityTooLarge, &Response{Status: "error", Error: "file is too large"})
+		return
+	}
+
+	req := &usecase.ImportRequest{
+		UserID:  userID,
+		Data:    data,
+		Format:  r.FormValue("format"),
+		Profile: r.FormValue("profile"),
+		Account: r.FormValue("account"),
+	}
+	if req.Format == "" {
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".ofx", ".qfx":
+			req.Format = usecase.ImportFormatOFX
+		case ".csv":
+			req.Format = usecase.ImportFormatCSV
+		}
+	}
+	if mapping := r.FormValue("mapping"); mapping != "" {
+		req.Mapping = &usecase.ImportProfile{}
+		if err := json.Unmarshal([]byte(mapping), req.Mapping); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "mapping must be a JSON object"})
+			return
+		}
+	}
+
+	result, err := h.importUC.Execute(r.Context(), req)
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: result})
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// InteractionHandler serves the admin API for browsing logged conversations
+type InteractionHandler struct {
+	interactionUC *usecase.InteractionLogUseCase
+}
+
+// NewInteractionHandler creates a new interaction handler
+func NewInteractionHandler(interactionUC *usecase.InteractionLogUseCase) *InteractionHandler {
+	return &InteractionHandler{
+		interactionUC: interactionUC,
+	}
+}
+
+func (h *InteractionHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// ListInteractions handles GET /api/admin/interactions. Results can be filtered by
+// user_id, source, intent and a from/to date (YYYY-MM-DD, to inclusive) and paged
+// with limit and offset.
+func (h *InteractionHandler) ListInteractions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.InteractionLogFilter{
+		UserID: query.Get("user_id"),
+		Source: query.Get("source"),
+		Intent: query.Get("intent"),
+	}
+
+	var err error
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": "limit must be a number"})
+			return
+		}
+	}
+	if v := query.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": "offset must be a number"})
+			return
+		}
+	}
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse("2006-01-02", v); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": "from must be a date (YYYY-MM-DD)"})
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		to, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": "to must be a date (YYYY-MM-DD)"})
+			return
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	page, err := h.interactionUC.List(r.Context(), filter)
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": page})
+}
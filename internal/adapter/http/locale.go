@@ -0,0 +1,25 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// LocaleMiddleware adds the locale of the caller's Accept-Language header to
+// the request context, so messages in responses are in their language.
+// Callers asking for no language we have get the default locale.
+func LocaleMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		if locale := i18n.MatchAcceptLanguage(r.Header.Get("Accept-Language")); locale != "" {
+			r = r.WithContext(i18n.WithLocale(r.Context(), locale))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// localize returns the message in the request's locale
+func localize(r *http.Request, key string) string {
+	return i18n.T(i18n.FromContext(r.Context()), key)
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+func TestLocaleMiddleware(t *testing.T) {
+	var got string
+	handler := LocaleMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = i18n.FromContext(r.Context())
+	}))
+
+	for header, want := range map[string]string{
+		"ja-JP,ja;q=0.9,en;q=0.8": "ja",
+		"fr-FR, en;q=0.5":         "en",
+		"zh-CN":                   "zh-CN",
+		"fr":                      i18n.DefaultLocale,
+		"":                        i18n.DefaultLocale,
+	} {
+		req := httptest.NewRequest("GET", "/api/users/me", nil)
+		if header != "" {
+			req.Header.Set("Accept-Language", header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if got != want {
+			t.Errorf("Accept-Language %q: got locale %q, want %q", header, got, want)
+		}
+		if w.Header().Get("Vary") != "Accept-Language" {
+			t.Errorf("expected responses to vary by Accept-Language")
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package http
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/riverlin/aiexpense/internal/logging"
 )
 
+// maxRequestIDLength bounds request IDs accepted from the X-Request-ID header
+const maxRequestIDLength = 128
+
 // responseWriter is a wrapper around http.ResponseWriter to capture status code and size
 type responseWriter struct {
 	http.ResponseWriter
@@ -24,28 +29,42 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return size, err
 }
 
-// LoggingMiddleware logs HTTP request details
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush event streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// LoggingMiddleware gives each request an ID, kept from the X-Request-ID header
+// if the caller sent one, and logs the request once it is served. The ID and the
+// user_id query parameter are added to the request context, so lines logged
+// while handling it carry them too.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = logging.NewRequestID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
+		ctx := logging.WithRequestID(r.Context(), requestID)
+		if userID := r.URL.Query().Get("user_id"); userID != "" {
+			ctx = logging.WithUser(ctx, userID, "")
+		}
+
 		rw := &responseWriter{
 			ResponseWriter: w,
 			status:         http.StatusOK, // Default to 200 OK
 		}
 
-		next.ServeHTTP(rw, r)
-
-		duration := time.Since(start)
+		next.ServeHTTP(rw, r.WithContext(ctx))
 
-		log.Printf(
-			"[API] %s | %3d | %13v | %-7s %s | %s",
-			start.Format("2006/01/02 15:04:05"),
-			rw.status,
-			duration,
-			r.Method,
-			r.URL.Path,
-			r.UserAgent(),
+		slog.InfoContext(ctx, "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rw.status,
+			"duration", time.Since(start),
+			"user_agent", r.UserAgent(),
 		)
 	})
 }
@@ -2,27 +2,35 @@ package http
 
 import (
 	"bytes"
-	"log"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/riverlin/aiexpense/internal/logging"
 )
 
 func TestLoggingMiddleware(t *testing.T) {
 	// Capture log output
 	var buf bytes.Buffer
-	log.SetOutput(&buf)
-	defer log.SetOutput(nil) // Restore logger
+	logger, err := logging.New(&buf, "json", "info")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer slog.SetDefault(slog.Default()) // Restore logger
+	slog.SetDefault(logger)
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slog.InfoContext(r.Context(), "handling")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
 
 	loggingHandler := LoggingMiddleware(handler)
 
-	req := httptest.NewRequest("GET", "/test-path", nil)
+	req := httptest.NewRequest("GET", "/test-path?user_id=user1", nil)
 	w := httptest.NewRecorder()
 
 	loggingHandler.ServeHTTP(w, req)
@@ -31,19 +39,44 @@ func TestLoggingMiddleware(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status 200, got %d", w.Code)
 	}
+	requestID := w.Header().Get("X-Request-ID")
+	if requestID == "" {
+		t.Fatalf("Response should carry the request ID")
+	}
 
-	// Check log output
-	logOutput := buf.String()
-	if !strings.Contains(logOutput, "[API]") {
-		t.Errorf("Log output should contain [API] prefix")
+	// Check log output: the handler's line and the request line
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %q", buf.String())
+	}
+	for _, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Log line should be JSON: %v", err)
+		}
+		if entry["request_id"] != requestID || entry["user_id"] != "user1" {
+			t.Errorf("Log line should carry the request ID and user, got %s", line)
+		}
 	}
-	if !strings.Contains(logOutput, "GET") {
+
+	var entry map[string]interface{}
+	json.Unmarshal([]byte(lines[1]), &entry)
+	if entry["method"] != "GET" {
 		t.Errorf("Log output should contain HTTP method")
 	}
-	if !strings.Contains(logOutput, "/test-path") {
+	if entry["path"] != "/test-path" {
 		t.Errorf("Log output should contain URL path")
 	}
-	if !strings.Contains(logOutput, "200") {
+	if entry["status"] != float64(200) {
 		t.Errorf("Log output should contain status code")
 	}
+
+	// A request ID sent by the caller is kept
+	req = httptest.NewRequest("GET", "/test-path", nil)
+	req.Header.Set("X-Request-ID", "upstream-123")
+	w = httptest.NewRecorder()
+	loggingHandler.ServeHTTP(w, req)
+	if w.Header().Get("X-Request-ID") != "upstream-123" {
+		t.Errorf("Expected the caller's request ID, got %q", w.Header().Get("X-Request-ID"))
+	}
 }
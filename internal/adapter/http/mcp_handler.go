@@ -0,0 +1,47 @@
+package http
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/adapter/mcp"
+)
+
+// maxMCPMessageSize bounds the body of a POST /mcp request
+const maxMCPMessageSize = 1 << 20
+
+// MCPHandler serves the MCP expense tools to remote agents over HTTP
+type MCPHandler struct {
+	server *mcp.Server
+}
+
+// NewMCPHandler creates a new MCP handler
+func NewMCPHandler(server *mcp.Server) *MCPHandler {
+	return &MCPHandler{server: server}
+}
+
+// Serve handles POST /mcp, one JSON-RPC message per request, acting for the
+// user of the bearer token. Responses are plain JSON rather than an event
+// stream, as the tools answer at once; notifications get 202.
+func (h *MCPHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Sign in to use the expense tools")
+		return
+	}
+
+	message, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMCPMessageSize))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid message: " + err.Error()})
+		return
+	}
+
+	reply := h.server.Handle(r.Context(), userID, message)
+	if reply == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(reply)
+}
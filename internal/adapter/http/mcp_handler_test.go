@@ -0,0 +1,49 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/adapter/mcp"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+func TestMCPHandler(t *testing.T) {
+	expenseRepo := usecase.NewMockExpenseRepository()
+	categoryRepo := usecase.NewMockCategoryRepository()
+	handler := NewMCPHandler(mcp.NewServer(
+		usecase.NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, usecase.NewMockAIService()),
+		usecase.NewGetExpensesUseCase(expenseRepo, categoryRepo),
+		usecase.NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil),
+		categoryRepo,
+	))
+
+	serve := func(userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/mcp", strings.NewReader(body))
+		if userID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), authContextKey{}, userID))
+		}
+		w := httptest.NewRecorder()
+		handler.Serve(w, req)
+		return w
+	}
+
+	if w := serve("", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a signed-in user, got %d", w.Code)
+	}
+
+	w := serve("user1", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a JSON answer, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `"add_expense"`) {
+		t.Errorf("expected the tools to be listed, got %s", w.Body.String())
+	}
+
+	if w := serve("user1", `{"jsonrpc":"2.0","method":"notifications/initialized"}`); w.Code != http.StatusAccepted || w.Body.Len() != 0 {
+		t.Errorf("expected 202 for a notification, got %d %q", w.Code, w.Body.String())
+	}
+}
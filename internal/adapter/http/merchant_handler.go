@@ -0,0 +1,85 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// MerchantHandler serves merchant rankings and trends
+type MerchantHandler struct {
+	merchantUC *usecase.MerchantUseCase
+}
+
+// NewMerchantHandler creates a new merchant handler
+func NewMerchantHandler(merchantUC *usecase.MerchantUseCase) *MerchantHandler {
+	return &MerchantHandler{
+		merchantUC: merchantUC,
+	}
+}
+
+func (h *MerchantHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *MerchantHandler) writeError(w http.ResponseWriter, err error) {
+	status := errorStatus(err, http.StatusInternalServerError)
+	if errors.Is(err, usecase.ErrInvalidMerchantQuery) {
+		status = http.StatusBadRequest
+	}
+	h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+}
+
+// TopMerchants handles GET /api/merchants/top
+func (h *MerchantHandler) TopMerchants(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := &usecase.TopMerchantsRequest{
+		UserID: requestUserID(r, query.Get("user_id")),
+		SortBy: query.Get("sort_by"),
+	}
+	if req.UserID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+	start, end, err := filterDates(query.Get("start_date"), query.Get("end_date"))
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	if start != nil {
+		req.From = *start
+	}
+	if end != nil {
+		req.To = *end
+	}
+	req.Limit, _ = strconv.Atoi(query.Get("limit"))
+
+	resp, err := h.merchantUC.Top(r.Context(), req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// MerchantTrend handles GET /api/merchants/{id}/trend
+func (h *MerchantHandler) MerchantTrend(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r, r.URL.Query().Get("user_id"))
+	if userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+	months, _ := strconv.Atoi(r.URL.Query().Get("months"))
+
+	trend, err := h.merchantUC.Trend(r.Context(), userID, r.PathValue("id"), months)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: trend})
+}
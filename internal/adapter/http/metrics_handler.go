@@ -37,7 +37,7 @@ func (h *MetricsHandler) GetDAU(w http.ResponseWriter, r *http.Request) {
 		Days: days,
 	})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -61,7 +61,7 @@ func (h *MetricsHandler) GetExpensesSummary(w http.ResponseWriter, r *http.Reque
 		Days: days,
 	})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -92,7 +92,7 @@ func (h *MetricsHandler) GetCategoryTrends(w http.ResponseWriter, r *http.Reques
 		Days:   days,
 	})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -116,7 +116,7 @@ func (h *MetricsHandler) GetGrowth(w http.ResponseWriter, r *http.Request) {
 		Days: days,
 	})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+		writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
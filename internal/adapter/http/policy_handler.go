@@ -1,6 +1,8 @@
 package http
 
 import (
+	"errors"
+	"github.com/riverlin/aiexpense/internal/domain"
 	"net/http"
 )
 
@@ -24,13 +26,12 @@ func (h *Handler) GetPolicy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	policy, err := h.getPolicyUC.Execute(ctx, key)
-	if err != nil {
-		h.WriteJSON(w, http.StatusInternalServerError, &Response{Status: "error", Error: err.Error()})
+	if errors.Is(err, domain.ErrNotFound) {
+		h.WriteJSON(w, http.StatusNotFound, &Response{Status: "error", Error: "Policy not found"})
 		return
 	}
-
-	if policy == nil {
-		h.WriteJSON(w, http.StatusNotFound, &Response{Status: "error", Error: "Policy not found"})
+	if err != nil {
+		h.WriteJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
 		return
 	}
 
@@ -2,40 +2,32 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
 	"github.com/riverlin/aiexpense/internal/usecase"
 )
 
+// PricingHandler serves the admin API for AI model pricing
 type PricingHandler struct {
-	syncUC      *usecase.PricingSyncUseCase
+	pricingUC   *usecase.PricingManagementUseCase
 	pricingRepo domain.PricingRepository
-	adminAPIKey string
 	providers   map[string]domain.PricingProvider
 }
 
+// NewPricingHandler creates a new pricing handler
 func NewPricingHandler(
 	pricingRepo domain.PricingRepository,
-	adminAPIKey string,
 	providers map[string]domain.PricingProvider,
 ) *PricingHandler {
 	return &PricingHandler{
+		pricingUC:   usecase.NewPricingManagementUseCase(pricingRepo),
 		pricingRepo: pricingRepo,
-		adminAPIKey: adminAPIKey,
 		providers:   providers,
 	}
 }
 
-func (h *PricingHandler) authenticateAdmin(r *http.Request) bool {
-	if h.adminAPIKey == "" {
-		return true
-	}
-	key := r.Header.Get("X-API-Key")
-	return key == h.adminAPIKey
-}
-
 func (h *PricingHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -44,11 +36,6 @@ func (h *PricingHandler) writeJSON(w http.ResponseWriter, status int, data inter
 
 // SyncPricing handles POST /api/pricing/sync?provider=gemini
 func (h *PricingHandler) SyncPricing(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
-		return
-	}
-
 	ctx := r.Context()
 	provider := r.URL.Query().Get("provider")
 
@@ -74,127 +61,104 @@ func (h *PricingHandler) SyncPricing(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, result)
 }
 
-// ListPricing handles GET /api/pricing
+// ListPricing handles GET /api/admin/pricing, listing the active pricing rows
 func (h *PricingHandler) ListPricing(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
-		return
-	}
-
-	ctx := r.Context()
-	configs, err := h.pricingRepo.GetAll(ctx)
-
+	configs, err := h.pricingUC.List(r.Context())
 	if err != nil {
 		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
 
-	activeOnly := r.URL.Query().Get("active") == "true"
-	if activeOnly {
-		active := []*domain.PricingConfig{}
-		for _, c := range configs {
-			if c.IsActive {
-				active = append(active, c)
-			}
-		}
-		configs = active
-	}
-
 	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": configs})
 }
 
-// CreatePricing handles POST /api/pricing
+// CreatePricing handles POST /api/admin/pricing, replacing the model's active pricing
 func (h *PricingHandler) CreatePricing(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
-		return
-	}
-
-	ctx := r.Context()
-	var req struct {
-		Provider         string  `json:"provider"`
-		Model            string  `json:"model"`
-		InputTokenPrice  float64 `json:"input_token_price"`
-		OutputTokenPrice float64 `json:"output_token_price"`
-	}
-
+	var req usecase.PricingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
 		return
 	}
 
-	now := time.Now()
-	config := &domain.PricingConfig{
-		ID:               req.Provider + "_" + req.Model + "_" + now.Format("20060102150405"),
-		Provider:         req.Provider,
-		Model:            req.Model,
-		InputTokenPrice:  req.InputTokenPrice,
-		OutputTokenPrice: req.OutputTokenPrice,
-		Currency:         "USD",
-		EffectiveDate:    now,
-		IsActive:         true,
-		CreatedAt:        now,
-		UpdatedAt:        now,
-	}
-
-	if err := h.pricingRepo.Create(ctx, config); err != nil {
-		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	config, err := h.pricingUC.Create(r.Context(), &req)
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
 
 	h.writeJSON(w, http.StatusCreated, map[string]interface{}{"status": "success", "data": config})
 }
 
-// UpdatePricing handles PUT /api/pricing/{id}
+// UpdatePricing handles PUT /api/admin/pricing/{id}
 func (h *PricingHandler) UpdatePricing(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
-		return
-	}
-
-	ctx := r.Context()
-	id := r.PathValue("id")
-
 	var req struct {
 		InputTokenPrice  float64 `json:"input_token_price"`
 		OutputTokenPrice float64 `json:"output_token_price"`
 	}
-
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid request"})
 		return
 	}
 
-	config := &domain.PricingConfig{
-		ID:               id,
-		InputTokenPrice:  req.InputTokenPrice,
-		OutputTokenPrice: req.OutputTokenPrice,
-		UpdatedAt:        time.Now(),
+	config, err := h.pricingUC.Update(r.Context(), r.PathValue("id"), req.InputTokenPrice, req.OutputTokenPrice)
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusBadRequest), map[string]string{"error": err.Error()})
+		return
 	}
 
-	if err := h.pricingRepo.Update(ctx, config); err != nil {
-		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": config})
+}
+
+// DeletePricing handles DELETE /api/admin/pricing/{id} by deactivating the pricing
+func (h *PricingHandler) DeletePricing(w http.ResponseWriter, r *http.Request) {
+	config, err := h.pricingUC.Deactivate(r.Context(), r.PathValue("id"))
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusInternalServerError), map[string]string{"error": err.Error()})
 		return
 	}
 
 	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": config})
 }
 
-// DeletePricing handles DELETE /api/pricing/{id}
-func (h *PricingHandler) DeletePricing(w http.ResponseWriter, r *http.Request) {
-	if !h.authenticateAdmin(r) {
-		h.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+// ValidatePricing handles GET /api/admin/pricing/validate?provider=gemini&model=...,
+// reporting whether the model's AI calls are priced or would be logged at zero cost
+func (h *PricingHandler) ValidatePricing(w http.ResponseWriter, r *http.Request) {
+	provider := r.URL.Query().Get("provider")
+	model := r.URL.Query().Get("model")
+	if provider == "" || model == "" {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "provider and model parameters required"})
+		return
+	}
+
+	config, err := h.pricingUC.ValidateModel(r.Context(), provider, model)
+	if err != nil && !errors.Is(err, usecase.ErrModelNotPriced) {
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
 
-	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "message": "pricing deactivated (simulated)"})
+	result := map[string]interface{}{"provider": provider, "model": model, "priced": config != nil, "pricing": config}
+	if err != nil {
+		result["error"] = err.Error()
+	}
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": result})
 }
 
-// RegisterPricingRoutes registers all pricing routes
-func RegisterPricingRoutes(mux *http.ServeMux, handler *PricingHandler) {
-	mux.HandleFunc("POST /api/pricing/sync", handler.SyncPricing)
-	mux.HandleFunc("GET /api/pricing", handler.ListPricing)
-	mux.HandleFunc("POST /api/pricing", handler.CreatePricing)
-	mux.HandleFunc("PUT /api/pricing/{id}", handler.UpdatePricing)
-	mux.HandleFunc("DELETE /api/pricing/{id}", handler.DeletePricing)
+// RegisterPricingRoutes registers all pricing routes, each needing a key with the pricing:write scope.
+// The /api/pricing CRUD routes are deprecated aliases of /api/admin/pricing.
+func RegisterPricingRoutes(mux *http.ServeMux, handler *PricingHandler, apiKeyHandler *APIKeyHandler) {
+	var routes apiRoutes
+	routes.handle("POST /pricing/sync", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.SyncPricing))
+
+	routes.handle("GET /admin/pricing", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.ListPricing))
+	routes.handle("POST /admin/pricing", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.CreatePricing))
+	routes.handle("GET /admin/pricing/validate", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.ValidatePricing))
+	routes.handle("PUT /admin/pricing/{id}", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.UpdatePricing))
+	routes.handle("DELETE /admin/pricing/{id}", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.DeletePricing))
+
+	routes.handle("GET /pricing", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.ListPricing))
+	routes.handle("POST /pricing", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.CreatePricing))
+	routes.handle("PUT /pricing/{id}", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.UpdatePricing))
+	routes.handle("DELETE /pricing/{id}", requireScope(apiKeyHandler, domain.APIKeyScopePricingWrite, handler.DeletePricing))
+
+	routes.register(mux)
 }
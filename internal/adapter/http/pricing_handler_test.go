@@ -37,7 +37,16 @@ func (tr *TestPricingRepositoryHandler) GetByProviderAndModel(ctx context.Contex
 			return c, nil
 		}
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
+}
+
+func (tr *TestPricingRepositoryHandler) GetByID(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	for _, c := range tr.data {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, domain.ErrNotFound
 }
 
 func (tr *TestPricingRepositoryHandler) GetAll(ctx context.Context) ([]*domain.PricingConfig, error) {
@@ -88,10 +97,10 @@ func TestSyncEndpoint_Success(t *testing.T) {
 
 	repo := &TestPricingRepositoryHandler{data: []*domain.PricingConfig{}}
 	provider := &TestPricingProvider{configs: fetched}
-	handler := NewPricingHandler(repo, "test-key", map[string]domain.PricingProvider{"gemini": provider})
+	handler := NewPricingHandler(repo, map[string]domain.PricingProvider{"gemini": provider})
 
 	mux := http.NewServeMux()
-	RegisterPricingRoutes(mux, handler)
+	RegisterPricingRoutes(mux, handler, NewAPIKeyHandler(usecase.NewAPIKeyUseCase(&TestAPIKeyRepository{}, "test-key")))
 
 	req := httptest.NewRequest("POST", "/api/pricing/sync?provider=gemini", nil)
 	req.Header.Set("X-API-Key", "test-key")
@@ -118,10 +127,10 @@ func TestSyncEndpoint_Success(t *testing.T) {
 // TestSyncEndpoint_Unauthorized tests missing API key
 func TestSyncEndpoint_Unauthorized(t *testing.T) {
 	repo := &TestPricingRepositoryHandler{data: []*domain.PricingConfig{}}
-	handler := NewPricingHandler(repo, "test-key", map[string]domain.PricingProvider{})
+	handler := NewPricingHandler(repo, map[string]domain.PricingProvider{})
 
 	mux := http.NewServeMux()
-	RegisterPricingRoutes(mux, handler)
+	RegisterPricingRoutes(mux, handler, NewAPIKeyHandler(usecase.NewAPIKeyUseCase(&TestAPIKeyRepository{}, "test-key")))
 
 	req := httptest.NewRequest("POST", "/api/pricing/sync?provider=gemini", nil)
 	// No X-API-Key header
@@ -137,10 +146,10 @@ func TestSyncEndpoint_Unauthorized(t *testing.T) {
 // TestSyncEndpoint_InvalidProvider tests invalid provider
 func TestSyncEndpoint_InvalidProvider(t *testing.T) {
 	repo := &TestPricingRepositoryHandler{data: []*domain.PricingConfig{}}
-	handler := NewPricingHandler(repo, "test-key", map[string]domain.PricingProvider{})
+	handler := NewPricingHandler(repo, map[string]domain.PricingProvider{})
 
 	mux := http.NewServeMux()
-	RegisterPricingRoutes(mux, handler)
+	RegisterPricingRoutes(mux, handler, NewAPIKeyHandler(usecase.NewAPIKeyUseCase(&TestAPIKeyRepository{}, "test-key")))
 
 	req := httptest.NewRequest("POST", "/api/pricing/sync?provider=unknown", nil)
 	req.Header.Set("X-API-Key", "test-key")
@@ -170,10 +179,10 @@ func TestListEndpoint(t *testing.T) {
 			},
 		},
 	}
-	handler := NewPricingHandler(repo, "test-key", map[string]domain.PricingProvider{})
+	handler := NewPricingHandler(repo, map[string]domain.PricingProvider{})
 
 	mux := http.NewServeMux()
-	RegisterPricingRoutes(mux, handler)
+	RegisterPricingRoutes(mux, handler, NewAPIKeyHandler(usecase.NewAPIKeyUseCase(&TestAPIKeyRepository{}, "test-key")))
 
 	req := httptest.NewRequest("GET", "/api/pricing", nil)
 	req.Header.Set("X-API-Key", "test-key")
@@ -196,10 +205,10 @@ func TestListEndpoint(t *testing.T) {
 // TestCreateEndpoint tests POST /api/pricing
 func TestCreateEndpoint(t *testing.T) {
 	repo := &TestPricingRepositoryHandler{data: []*domain.PricingConfig{}}
-	handler := NewPricingHandler(repo, "test-key", map[string]domain.PricingProvider{})
+	handler := NewPricingHandler(repo, map[string]domain.PricingProvider{})
 
 	mux := http.NewServeMux()
-	RegisterPricingRoutes(mux, handler)
+	RegisterPricingRoutes(mux, handler, NewAPIKeyHandler(usecase.NewAPIKeyUseCase(&TestAPIKeyRepository{}, "test-key")))
 
 	body := []byte(`{
 		"provider": "gemini",
@@ -223,3 +232,69 @@ func TestCreateEndpoint(t *testing.T) {
 		t.Errorf("Expected 1 config created, got %d", len(repo.data))
 	}
 }
+
+// TestAdminPricingEndpoints tests updating, deactivating and validating pricing under /api/admin/pricing
+func TestAdminPricingEndpoints(t *testing.T) {
+	repo := &TestPricingRepositoryHandler{data: []*domain.PricingConfig{}}
+	handler := NewPricingHandler(repo, map[string]domain.PricingProvider{})
+
+	mux := http.NewServeMux()
+	RegisterPricingRoutes(mux, handler, NewAPIKeyHandler(usecase.NewAPIKeyUseCase(&TestAPIKeyRepository{}, "test-key")))
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("X-API-Key", "test-key")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	validate := func() bool {
+		var result struct {
+			Data struct {
+				Priced bool `json:"priced"`
+			} `json:"data"`
+		}
+		w := serve("GET", "/api/admin/pricing/validate?provider=gemini&model=gemini-2.5-lite", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		json.NewDecoder(w.Body).Decode(&result)
+		return result.Data.Priced
+	}
+
+	if validate() {
+		t.Error("Expected the model unpriced before any pricing is created")
+	}
+	if w := serve("POST", "/api/admin/pricing", `{"provider": "gemini", "model": "gemini-2.5-lite"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected zero prices to be rejected with 400, got %d", w.Code)
+	}
+
+	w := serve("POST", "/api/admin/pricing", `{"provider": "gemini", "model": "gemini-2.5-lite", "input_token_price": 0.1, "output_token_price": 0.4}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", w.Code)
+	}
+	var created struct {
+		Data domain.PricingConfig `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&created)
+	if !validate() {
+		t.Error("Expected the model priced")
+	}
+
+	if w := serve("PUT", "/api/admin/pricing/"+created.Data.ID, `{"input_token_price": 0.2, "output_token_price": 0.8}`); w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+	if repo.data[0].OutputTokenPrice != 0.8 || !repo.data[0].IsActive {
+		t.Errorf("Expected the prices updated on an active row, got %+v", repo.data[0])
+	}
+	if w := serve("PUT", "/api/admin/pricing/missing", `{"input_token_price": 0.2, "output_token_price": 0.8}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", w.Code)
+	}
+
+	if w := serve("DELETE", "/api/admin/pricing/"+created.Data.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", w.Code)
+	}
+	if validate() {
+		t.Error("Expected the model unpriced after deactivation")
+	}
+}
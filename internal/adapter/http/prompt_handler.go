@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// PromptHandler serves the admin API for versioned AI prompts
+type PromptHandler struct {
+	promptUC *usecase.PromptManagementUseCase
+}
+
+// NewPromptHandler creates a new prompt handler
+func NewPromptHandler(promptUC *usecase.PromptManagementUseCase) *PromptHandler {
+	return &PromptHandler{
+		promptUC: promptUC,
+	}
+}
+
+func (h *PromptHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// ListPrompts handles GET /api/prompts
+func (h *PromptHandler) ListPrompts(w http.ResponseWriter, r *http.Request) {
+	prompts, err := h.promptUC.List(r.Context())
+	if err != nil {
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": prompts})
+}
+
+// GetPrompt handles GET /api/prompts/{name}
+func (h *PromptHandler) GetPrompt(w http.ResponseWriter, r *http.Request) {
+	prompt, err := h.promptUC.Get(r.Context(), r.PathValue("name"))
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": prompt})
+}
+
+// CreatePromptVersion handles POST /api/prompts/{name}/versions
+func (h *PromptHandler) CreatePromptVersion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Template string `json:"template"`
+		Activate bool   `json:"activate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": "Invalid request body"})
+		return
+	}
+
+	prompt, err := h.promptUC.CreateVersion(r.Context(), r.PathValue("name"), req.Template, req.Activate)
+	if err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, map[string]interface{}{"status": "success", "data": prompt})
+}
+
+// ActivatePromptVersion handles PUT /api/prompts/{name}/active
+func (h *PromptHandler) ActivatePromptVersion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Version int `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": "Invalid request body"})
+		return
+	}
+
+	name := r.PathValue("name")
+	if err := h.promptUC.Activate(r.Context(), name, req.Version); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success", "data": map[string]interface{}{"name": name, "active_version": req.Version}})
+}
@@ -72,6 +72,10 @@ func (h *ReportHandler) GetReportSummary(w http.ResponseWriter, r *http.Request)
 		h.writeResponse(w, http.StatusUnauthorized, &Response{Status: "error", Error: "Invalid token claims"})
 		return
 	}
+	if tokenType, _ := claims["type"].(string); tokenType != "access" && tokenType != "report_access" {
+		h.writeResponse(w, http.StatusUnauthorized, &Response{Status: "error", Error: "Invalid token claims"})
+		return
+	}
 
 	userID, ok := claims["sub"].(string)
 	if !ok || userID == "" {
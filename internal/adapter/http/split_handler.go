@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// SplitHandler serves split-bill shares
+type SplitHandler struct {
+	splitExpenseUC *usecase.SplitExpenseUseCase
+}
+
+func NewSplitHandler(splitExpenseUC *usecase.SplitExpenseUseCase) *SplitHandler {
+	return &SplitHandler{
+		splitExpenseUC: splitExpenseUC,
+	}
+}
+
+func (h *SplitHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// SplitExpense splits an expense equally (count) or by explicit participant shares
+func (h *SplitHandler) SplitExpense(w http.ResponseWriter, r *http.Request) {
+	type SplitExpenseRequest struct {
+		ExpenseID    string                     `json:"expense_id"`
+		UserID       string                     `json:"user_id"`
+		Count        int                        `json:"count,omitempty"`
+		Participants []usecase.SplitParticipant `json:"participants,omitempty"`
+	}
+
+	var req SplitExpenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+	req.UserID = requestUserID(r, req.UserID)
+
+	if req.ExpenseID == "" || req.UserID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "expense_id and user_id are required"})
+		return
+	}
+
+	resp, err := h.splitExpenseUC.Execute(r.Context(), &usecase.SplitExpenseRequest{
+		ExpenseID:    req.ExpenseID,
+		UserID:       req.UserID,
+		Participants: req.Participants,
+		Count:        req.Count,
+	})
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: resp, Message: resp.Message})
+}
+
+// GetSplit returns an expense's shares
+func (h *SplitHandler) GetSplit(w http.ResponseWriter, r *http.Request) {
+	expenseID := r.URL.Query().Get("expense_id")
+	userID := r.URL.Query().Get("user_id")
+	if expenseID == "" || userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "expense_id and user_id are required"})
+		return
+	}
+
+	resp, err := h.splitExpenseUC.GetSplits(r.Context(), expenseID, userID)
+	if err != nil {
+		h.writeJSON(w, http.StatusNotFound, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: resp})
+}
+
+// GetSummary returns what everyone owes the user across split expenses
+func (h *SplitHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	summary, err := h.splitExpenseUC.GetSummary(r.Context(), userID)
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: summary})
+}
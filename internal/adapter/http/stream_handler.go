@@ -0,0 +1,82 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// streamHeartbeat is how often an idle stream sends a comment, so proxies keep it open
+const streamHeartbeat = 30 * time.Second
+
+// StreamHandler streams a user's events to the dashboard as Server-Sent Events
+type StreamHandler struct {
+	bus       *usecase.EventBus
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new stream handler
+func NewStreamHandler(bus *usecase.EventBus) *StreamHandler {
+	return &StreamHandler{
+		bus:       bus,
+		heartbeat: streamHeartbeat,
+	}
+}
+
+// Stream handles GET /api/stream?user_id=, sending each of the user's events
+// as it happens until the client disconnects. Every message has the event type
+// as its "event" field and a JSON "data" field.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	events, unsubscribe := h.bus.Subscribe(userID)
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	// Ask browsers to wait a few seconds before reconnecting
+	fmt.Fprint(w, "retry: 3000\n: connected\n\n")
+	if err := rc.Flush(); err != nil {
+		slog.WarnContext(r.Context(), "Event stream not supported", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.WarnContext(r.Context(), "Failed to encode stream event", "event", event.Type, "error", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
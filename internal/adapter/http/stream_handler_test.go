@@ -0,0 +1,74 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+func TestStreamHandler(t *testing.T) {
+	bus := usecase.NewEventBus()
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, Handlers{API: &Handler{}, Stream: NewStreamHandler(bus)})
+	server := httptest.NewServer(LoggingMiddleware(mux))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/stream")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 without user_id, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/stream?user_id=user1", nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", resp.Header.Get("Content-Type"))
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readMessage := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("stream ended: %v", err)
+			}
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	// The connection message is flushed right away, so the subscription is open once it arrives
+	if msg := readMessage(); !strings.Contains(msg, ": connected") {
+		t.Fatalf("expected the connection message, got %q", msg)
+	}
+
+	bus.Publish(context.Background(), "user2", domain.EventExpenseCreated, &usecase.ExpenseEvent{ExpenseID: "other"})
+	bus.Publish(context.Background(), "user1", domain.EventExpenseCreated, &usecase.ExpenseEvent{ExpenseID: "e1", Description: "午餐"})
+
+	msg := readMessage()
+	if !strings.Contains(msg, "event: expense.created\n") || !strings.Contains(msg, `"expense_id":"e1"`) || !strings.Contains(msg, `"description":"午餐"`) {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if !strings.HasPrefix(msg, "id: ") {
+		t.Errorf("expected the message to have an id, got %q", msg)
+	}
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// TagHandler manages tags and the expenses they label
+type TagHandler struct {
+	tagUC *usecase.TagUseCase
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(tagUC *usecase.TagUseCase) *TagHandler {
+	return &TagHandler{
+		tagUC: tagUC,
+	}
+}
+
+func (h *TagHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *TagHandler) writeError(w http.ResponseWriter, err error) {
+	status := errorStatus(err, http.StatusInternalServerError)
+	if errors.Is(err, usecase.ErrInvalidTagName) {
+		status = http.StatusBadRequest
+	}
+	h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+}
+
+// tagRequest is the body of the tag endpoints
+type tagRequest struct {
+	UserID string   `json:"user_id"`
+	Name   string   `json:"name,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// readTagRequest decodes a tag request body, taking the user from the
+// credentials when there are any
+func (h *TagHandler) readTagRequest(w http.ResponseWriter, r *http.Request) (*tagRequest, bool) {
+	var req tagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return nil, false
+	}
+	req.UserID = requestUserID(r, req.UserID)
+	if req.UserID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return nil, false
+	}
+	return &req, true
+}
+
+// ListTags handles GET /api/tags
+func (h *TagHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r, r.URL.Query().Get("user_id"))
+	if userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	tags, err := h.tagUC.List(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: tags})
+}
+
+// CreateTag handles POST /api/tags
+func (h *TagHandler) CreateTag(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.readTagRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.tagUC.Create(r.Context(), req.UserID, req.Name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, &Response{Status: "success", Data: tag})
+}
+
+// RenameTag handles PUT /api/tags/{id}
+func (h *TagHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.readTagRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tag, err := h.tagUC.Rename(r.Context(), req.UserID, r.PathValue("id"), req.Name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: tag})
+}
+
+// DeleteTag handles DELETE /api/tags/{id}
+func (h *TagHandler) DeleteTag(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r, r.URL.Query().Get("user_id"))
+	if userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	if err := h.tagUC.Delete(r.Context(), userID, r.PathValue("id")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Message: "Tag deleted"})
+}
+
+// SetExpenseTags handles PUT /api/expenses/{id}/tags, replacing the tags of
+// the expense; tags the user doesn't have yet are created
+func (h *TagHandler) SetExpenseTags(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.readTagRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := h.tagUC.SetExpenseTags(r.Context(), req.UserID, r.PathValue("id"), req.Tags)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: map[string]interface{}{"tags": tags}})
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// UserDeletionHandler lets users erase their data and admins audit the purges
+type UserDeletionHandler struct {
+	deletionUC *usecase.UserDeletionUseCase
+}
+
+// NewUserDeletionHandler creates a new user deletion handler
+func NewUserDeletionHandler(deletionUC *usecase.UserDeletionUseCase) *UserDeletionHandler {
+	return &UserDeletionHandler{
+		deletionUC: deletionUC,
+	}
+}
+
+func (h *UserDeletionHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// DeleteMe handles DELETE /api/users/me, scheduling the signed-in user's data
+// to be purged once the grace period ends. Only a token can ask for this; a
+// claimed user_id is not enough.
+func (h *UserDeletionHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	deletion, err := h.deletionUC.RequestDeletion(r.Context(), userID)
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusAccepted, &Response{
+		Status:  "success",
+		Data:    deletion,
+		Message: localize(r, "api.deletion_scheduled"),
+	})
+}
+
+// GetMyDeletion handles GET /api/users/me/deletion, returning the signed-in
+// user's pending deletion
+func (h *UserDeletionHandler) GetMyDeletion(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	deletion, err := h.deletionUC.GetPending(r.Context(), userID)
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	if deletion == nil {
+		h.writeJSON(w, http.StatusNotFound, &Response{Status: "error", Error: usecase.ErrNoPendingDeletion.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: deletion})
+}
+
+// RestoreMe handles POST /api/users/me/restore, cancelling the signed-in
+// user's pending deletion
+func (h *UserDeletionHandler) RestoreMe(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	if err := h.deletionUC.Cancel(r.Context(), userID); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrNoPendingDeletion) {
+			status = http.StatusNotFound
+		}
+		h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Message: localize(r, "api.deletion_cancelled")})
+}
+
+// ListDeletions handles GET /api/admin/user-deletions, the audit trail of
+// deletion requests and how much each purge removed
+func (h *UserDeletionHandler) ListDeletions(w http.ResponseWriter, r *http.Request) {
+	deletions, err := h.deletionUC.List(r.Context())
+	if err != nil {
+		h.writeJSON(w, errorStatus(err, http.StatusInternalServerError), &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: deletions})
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// TestUserDeletionRepository is an in-memory user deletion repository for handler tests
+type TestUserDeletionRepository struct {
+	deletions []*domain.UserDeletion
+}
+
+func (r *TestUserDeletionRepository) Create(ctx context.Context, deletion *domain.UserDeletion) error {
+	r.deletions = append(r.deletions, deletion)
+	return nil
+}
+
+func (r *TestUserDeletionRepository) Update(ctx context.Context, deletion *domain.UserDeletion) error {
+	return nil
+}
+
+func (r *TestUserDeletionRepository) GetPending(ctx context.Context, userID string) (*domain.UserDeletion, error) {
+	for _, deletion := range r.deletions {
+		if deletion.UserID == userID && deletion.Status == domain.UserDeletionPending {
+			return deletion, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *TestUserDeletionRepository) GetDue(ctx context.Context, now time.Time) ([]*domain.UserDeletion, error) {
+	return nil, nil
+}
+
+func (r *TestUserDeletionRepository) List(ctx context.Context, limit int) ([]*domain.UserDeletion, error) {
+	return r.deletions, nil
+}
+
+func (r *TestUserDeletionRepository) PurgeUserData(ctx context.Context, userID string) (*domain.UserPurge, error) {
+	return &domain.UserPurge{}, nil
+}
+
+func TestUserDeletionHandler(t *testing.T) {
+	userRepo := &TestUserRepository{users: map[string]*domain.User{
+		"telegram_42": {UserID: "telegram_42", MessengerType: "telegram"},
+	}}
+	authUC := usecase.NewAuthUseCase(userRepo, "test-secret")
+	authUC.RegisterLoginVerifier("telegram", stubLoginVerifier{})
+	token, err := authUC.Login(context.Background(), "telegram", map[string]string{"user": "telegram_42"})
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	deletionUC := usecase.NewUserDeletionUseCase(&TestUserDeletionRepository{}, userRepo, 30*24*time.Hour)
+	mux := http.NewServeMux()
+	apiKeyHandler := NewAPIKeyHandler(usecase.NewAPIKeyUseCase(&TestAPIKeyRepository{}, "bootstrap"))
+	RegisterRoutes(mux, Handlers{API: &Handler{}, APIKey: apiKeyHandler, UserDeletion: NewUserDeletionHandler(deletionUC)})
+	server := AuthMiddleware(authUC, AuthConfig{AdminPaths: []string{"/api/admin/"}}, mux)
+
+	serve := func(method, path, bearer, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(""))
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("DELETE", "/api/users/me?user_id=telegram_42", "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", w.Code)
+	}
+
+	w := serve("DELETE", "/api/users/me", token.Token, "")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected the deletion to be scheduled, got %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data domain.UserDeletion `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Data.Status != domain.UserDeletionPending || resp.Data.PurgeAfter.IsZero() {
+		t.Errorf("expected a pending deletion with a purge date, got %+v", resp.Data)
+	}
+
+	if w := serve("GET", "/api/users/me/deletion", token.Token, ""); w.Code != http.StatusOK {
+		t.Errorf("expected the pending deletion, got %d", w.Code)
+	}
+	if w := serve("GET", "/api/admin/user-deletions", token.Token, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the audit list to need an admin key, got %d", w.Code)
+	}
+	if w := serve("GET", "/api/admin/user-deletions", "", "bootstrap"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "telegram_42") {
+		t.Errorf("expected the audit list to show the deletion, got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := serve("POST", "/api/users/me/restore", token.Token, ""); w.Code != http.StatusOK {
+		t.Fatalf("expected the deletion to be cancelled, got %d %s", w.Code, w.Body.String())
+	}
+	if w := serve("POST", "/api/users/me/restore", token.Token, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 with nothing to restore, got %d", w.Code)
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// UserExportHandler lets users download everything they have stored
+type UserExportHandler struct {
+	exportUC *usecase.UserExportUseCase
+}
+
+// NewUserExportHandler creates a new user export handler
+func NewUserExportHandler(exportUC *usecase.UserExportUseCase) *UserExportHandler {
+	return &UserExportHandler{
+		exportUC: exportUC,
+	}
+}
+
+func (h *UserExportHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// ExportMe handles GET /api/users/me/export by starting a zip export of the
+// signed-in user's data. The download link is sent to their messenger.
+func (h *UserExportHandler) ExportMe(w http.ResponseWriter, r *http.Request) {
+	userID := AuthenticatedUser(r.Context())
+	if userID == "" {
+		writeUnauthorized(w, "Authentication required")
+		return
+	}
+
+	export, err := h.exportUC.RequestExport(r.Context(), userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrNoMessenger) {
+			status = http.StatusBadRequest
+		}
+		h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+		return
+	}
+	h.writeJSON(w, http.StatusAccepted, &Response{
+		Status:  "success",
+		Data:    export,
+		Message: localize(r, "api.export_preparing"),
+	})
+}
+
+// Download handles GET /api/exports/{token}, the link sent to the user's
+// messenger. The token is the only credential, so it is served without sign-in.
+func (h *UserExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	data, export := h.exportUC.Download(r.PathValue("token"))
+	if data == nil {
+		h.writeJSON(w, http.StatusNotFound, &Response{Status: "error", Error: "Export not found or expired"})
+		return
+	}
+
+	filename := fmt.Sprintf("aiexpense-export-%s.zip", export.RequestedAt.Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// TestBudgetRepository is a budget repository without budgets for handler tests
+type TestBudgetRepository struct{}
+
+func (r *TestBudgetRepository) Create(ctx context.Context, budget *domain.Budget) error { return nil }
+func (r *TestBudgetRepository) GetByID(ctx context.Context, id string) (*domain.Budget, error) {
+	return nil, domain.ErrNotFound
+}
+func (r *TestBudgetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Budget, error) {
+	return nil, nil
+}
+func (r *TestBudgetRepository) GetByGroupID(ctx context.Context, groupID string) ([]*domain.Budget, error) {
+	return nil, nil
+}
+func (r *TestBudgetRepository) Update(ctx context.Context, budget *domain.Budget) error { return nil }
+func (r *TestBudgetRepository) Delete(ctx context.Context, id string) error             { return nil }
+
+// recordingNotifier keeps the messages pushed to users
+type recordingNotifier struct {
+	messages []string
+}
+
+func (n *recordingNotifier) PushMessage(ctx context.Context, userID, text string) error {
+	n.messages = append(n.messages, text)
+	return nil
+}
+
+func TestUserExportHandler(t *testing.T) {
+	userRepo := &TestUserRepository{users: map[string]*domain.User{
+		"telegram_42": {UserID: "telegram_42", MessengerType: "telegram"},
+	}}
+	expenseRepo := &TestExpenseRepository{expenses: make(map[string]*domain.Expense)}
+	categoryRepo := &TestCategoryRepository{categories: make(map[string]*domain.Category)}
+	authUC := usecase.NewAuthUseCase(userRepo, "test-secret")
+	authUC.RegisterLoginVerifier("telegram", stubLoginVerifier{})
+	token, err := authUC.Login(context.Background(), "telegram", map[string]string{"user": "telegram_42"})
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	notifier := &recordingNotifier{}
+	exportUC := usecase.NewUserExportUseCase(userRepo, expenseRepo, categoryRepo, &TestBudgetRepository{},
+		usecase.NewRecurringExpenseUseCase(expenseRepo, categoryRepo), usecase.NewNotificationUseCase(), "http://api.test")
+	exportUC.RegisterNotifier("telegram", notifier)
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, Handlers{API: &Handler{}, UserExport: NewUserExportHandler(exportUC)})
+	server := AuthMiddleware(authUC, AuthConfig{PublicPaths: []string{"/api/exports/"}}, mux)
+
+	serve := func(path, bearer string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("/api/users/me/export", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", w.Code)
+	}
+	if w := serve("/api/users/me/export", token.Token); w.Code != http.StatusAccepted {
+		t.Fatalf("expected the export to start, got %d %s", w.Code, w.Body.String())
+	}
+	exportUC.Wait()
+
+	if len(notifier.messages) != 1 {
+		t.Fatalf("expected the download link to be sent, got %v", notifier.messages)
+	}
+	link := regexp.MustCompile(`http://api\.test(/api/v1/exports/\w+)`).FindStringSubmatch(notifier.messages[0])
+	if link == nil {
+		t.Fatalf("expected a download link in %q", notifier.messages[0])
+	}
+
+	w := serve(link[1], "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("expected the zip to download without sign-in, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if _, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len())); err != nil {
+		t.Errorf("expected a valid zip: %v", err)
+	}
+	if w := serve("/api/exports/unknown", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown export, got %d", w.Code)
+	}
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIVersion is the current version of the REST API, served under /api/v1
+const APIVersion = "1"
+
+// apiV1Prefix is where version 1 of the API is served
+const apiV1Prefix = "/api/v1"
+
+// supportedAPIVersions are the versions clients can ask for. A breaking change
+// adds the next version with its own route table; the versions before it keep
+// being served until their sunset.
+var supportedAPIVersions = []string{APIVersion}
+
+// LegacyAPISunset is when the unversioned /api paths, aliases of /api/v1, stop
+// being served
+var LegacyAPISunset = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+
+// apiRoute is one API endpoint, its pattern's path relative to the version
+// prefix, e.g. "GET /expenses/{id}"
+type apiRoute struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+// apiRoutes is a table of API endpoints, each served under /api/v1 and at its
+// deprecated unversioned /api path
+type apiRoutes []apiRoute
+
+// handle adds an endpoint to the table
+func (t *apiRoutes) handle(pattern string, handler http.HandlerFunc) {
+	*t = append(*t, apiRoute{pattern: pattern, handler: handler})
+}
+
+// register serves the table's endpoints on mux
+func (t apiRoutes) register(mux *http.ServeMux) {
+	for _, route := range t {
+		HandleAPI(mux, route.pattern, route.handler)
+	}
+}
+
+// HandleAPI serves an API endpoint, its pattern's path relative to the version
+// prefix, under /api/v1 and, marked deprecated, at the unversioned /api path
+func HandleAPI(mux *http.ServeMux, pattern string, handler http.HandlerFunc) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+	mux.HandleFunc(strings.TrimSpace(method+" "+apiV1Prefix+path), versionedRoute(handler))
+	mux.HandleFunc(strings.TrimSpace(method+" /api"+path), legacyRoute(handler))
+}
+
+// versionedRoute serves a version 1 endpoint, refusing callers that ask for
+// another version
+func versionedRoute(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !negotiateAPIVersion(w, r) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// legacyRoute serves an unversioned /api alias of a version 1 endpoint,
+// pointing clients at the /api/v1 path that replaces it
+func legacyRoute(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !negotiateAPIVersion(w, r) {
+			return
+		}
+		markDeprecated(w, versionedPath(r.URL.Path))
+		next(w, r)
+	}
+}
+
+// markDeprecated tells clients a route is going away at LegacyAPISunset and
+// what replaces it
+func markDeprecated(w http.ResponseWriter, successor string) {
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Sunset", LegacyAPISunset.UTC().Format(http.TimeFormat))
+	w.Header().Set("Link", "<"+successor+">; rel=\"successor-version\"")
+}
+
+// negotiateAPIVersion answers with the API version served, or with 406 when
+// the caller asks for a version that isn't supported. Callers ask with the
+// API-Version header or an Accept of application/vnd.aiexpense.v1+json;
+// asking for none gets the current version.
+func negotiateAPIVersion(w http.ResponseWriter, r *http.Request) bool {
+	version := requestedAPIVersion(r)
+	if version != "" && !slices.Contains(supportedAPIVersions, version) {
+		writeJSON(w, http.StatusNotAcceptable, &Response{
+			Status: "error",
+			Error:  fmt.Sprintf("API version %s is not supported; supported versions: %s", version, strings.Join(supportedAPIVersions, ", ")),
+		})
+		return false
+	}
+	w.Header().Set("API-Version", APIVersion)
+	return true
+}
+
+// requestedAPIVersion returns the version the caller asks for, "" for none
+func requestedAPIVersion(r *http.Request) string {
+	if version := strings.TrimSpace(r.Header.Get("API-Version")); version != "" {
+		return strings.TrimPrefix(strings.ToLower(version), "v")
+	}
+	for _, mediaType := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ = strings.Cut(mediaType, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if version, ok := strings.CutPrefix(mediaType, "application/vnd.aiexpense.v"); ok {
+			return strings.TrimSuffix(version, "+json")
+		}
+	}
+	return ""
+}
+
+// versionedPath returns the /api/v1 path of an unversioned /api path
+func versionedPath(path string) string {
+	return apiV1Prefix + strings.TrimPrefix(path, "/api")
+}
+
+// unversionedPath returns the /api path of an /api/v1 path, so checks on
+// paths apply to both
+func unversionedPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, apiV1Prefix); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+		return "/api" + rest
+	}
+	return path
+}
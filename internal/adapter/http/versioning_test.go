@@ -0,0 +1,84 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIVersioning(t *testing.T) {
+	var routes apiRoutes
+	routes.handle("GET /expenses/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("id")))
+	})
+	mux := http.NewServeMux()
+	routes.register(mux)
+
+	serve := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/api/v1/expenses/exp_1", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "exp_1" {
+		t.Fatalf("v1: expected exp_1, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("API-Version") != "1" || w.Header().Get("Deprecation") != "" {
+		t.Errorf("v1: unexpected headers %v", w.Header())
+	}
+
+	// The unversioned path is a deprecated alias pointing at its /api/v1 path
+	w = serve("/api/expenses/exp_1", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "exp_1" {
+		t.Fatalf("legacy: expected exp_1, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Deprecation") != "true" {
+		t.Error("legacy: expected the alias to be marked deprecated")
+	}
+	if got, want := w.Header().Get("Sunset"), LegacyAPISunset.Format(http.TimeFormat); got != want {
+		t.Errorf("legacy: expected Sunset %q, got %q", want, got)
+	}
+	if got := w.Header().Get("Link"); got != `</api/v1/expenses/exp_1>; rel="successor-version"` {
+		t.Errorf("legacy: unexpected Link %q", got)
+	}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"API-Version header", http.Header{"Api-Version": {"1"}}, http.StatusOK},
+		{"prefixed API-Version header", http.Header{"Api-Version": {"v1"}}, http.StatusOK},
+		{"vendor media type", http.Header{"Accept": {"application/json, application/vnd.aiexpense.v1+json"}}, http.StatusOK},
+		{"plain Accept", http.Header{"Accept": {"application/json"}}, http.StatusOK},
+		{"unsupported API-Version header", http.Header{"Api-Version": {"2"}}, http.StatusNotAcceptable},
+		{"unsupported vendor media type", http.Header{"Accept": {"application/vnd.aiexpense.v2+json"}}, http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		for _, target := range []string{"/api/v1/expenses/exp_1", "/api/expenses/exp_1"} {
+			if w := serve(target, tt.header); w.Code != tt.want {
+				t.Errorf("%s at %s: expected %d, got %d", tt.name, target, tt.want, w.Code)
+			}
+		}
+	}
+}
+
+func TestPublicPathCoversVersionedPath(t *testing.T) {
+	prefixes := []string{"/api/reports/", "/health"}
+	for path, want := range map[string]bool{
+		"/api/reports/summary":    true,
+		"/api/v1/reports/summary": true,
+		"/api/v1/expenses":        false,
+		"/api/v10/reports/":       false,
+		"/health":                 true,
+	} {
+		if got := isPublicPath(path, prefixes); got != want {
+			t.Errorf("isPublicPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
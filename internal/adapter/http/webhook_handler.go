@@ -0,0 +1,108 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// WebhookHandler serves users' outbound webhook subscriptions and their delivery logs
+type WebhookHandler struct {
+	webhookUC *usecase.WebhookUseCase
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookUC *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{
+		webhookUC: webhookUC,
+	}
+}
+
+func (h *WebhookHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *WebhookHandler) writeError(w http.ResponseWriter, err error) {
+	h.writeJSON(w, errorStatus(err, http.StatusBadRequest), &Response{Status: "error", Error: err.Error()})
+}
+
+// CreateWebhook handles POST /api/webhooks. The response includes the signing
+// secret, which is not returned again.
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req usecase.CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+	req.UserID = requestUserID(r, req.UserID)
+
+	subscription, err := h.webhookUC.CreateSubscription(r.Context(), &req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, &Response{Status: "success", Data: subscription})
+}
+
+// ListWebhooks handles GET /api/webhooks?user_id=
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.webhookUC.ListSubscriptions(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: subscriptions})
+}
+
+// UpdateWebhook handles PUT /api/webhooks/{id} with user_id and the fields to change
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+		usecase.UpdateWebhookRequest
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return
+	}
+	req.UserID = requestUserID(r, req.UserID)
+
+	subscription, err := h.webhookUC.UpdateSubscription(r.Context(), r.PathValue("id"), req.UserID, &req.UpdateWebhookRequest)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: subscription})
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/{id}?user_id=
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.webhookUC.DeleteSubscription(r.Context(), r.PathValue("id"), r.URL.Query().Get("user_id")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: map[string]string{"message": "Webhook deleted"}})
+}
+
+// ListDeliveries handles GET /api/webhooks/{id}/deliveries?user_id=&limit=, newest first
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil {
+			h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "limit must be a number"})
+			return
+		}
+	}
+
+	deliveries, err := h.webhookUC.ListDeliveries(r.Context(), r.PathValue("id"), query.Get("user_id"), limit)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: deliveries})
+}
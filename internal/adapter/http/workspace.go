@@ -0,0 +1,32 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// personalWorkspace names the personal workspace in requests, as it has no ID
+const personalWorkspace = "personal"
+
+// WorkspaceMiddleware adds the workspace of the caller's X-Workspace-ID
+// header, or workspace_id query parameter, to the request context, so
+// listings and reports are that workspace's and what is recorded goes into
+// it. "personal" selects the personal workspace. Requests naming none span
+// every workspace and record into the personal one.
+func WorkspaceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "X-Workspace-ID")
+		workspaceID := r.Header.Get("X-Workspace-ID")
+		if workspaceID == "" {
+			workspaceID = r.URL.Query().Get("workspace_id")
+		}
+		if workspaceID != "" {
+			if workspaceID == personalWorkspace {
+				workspaceID = domain.PersonalWorkspaceID
+			}
+			r = r.WithContext(domain.WithWorkspace(r.Context(), workspaceID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// WorkspaceHandler manages a user's workspaces and the active one
+type WorkspaceHandler struct {
+	workspaceUC *usecase.WorkspaceUseCase
+}
+
+// NewWorkspaceHandler creates a new workspace handler
+func NewWorkspaceHandler(workspaceUC *usecase.WorkspaceUseCase) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaceUC: workspaceUC,
+	}
+}
+
+func (h *WorkspaceHandler) writeJSON(w http.ResponseWriter, status int, data *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func (h *WorkspaceHandler) writeError(w http.ResponseWriter, err error) {
+	status := errorStatus(err, http.StatusInternalServerError)
+	if errors.Is(err, usecase.ErrInvalidWorkspace) {
+		status = http.StatusBadRequest
+	}
+	h.writeJSON(w, status, &Response{Status: "error", Error: err.Error()})
+}
+
+// workspaceRequest is the body of the workspace endpoints
+type workspaceRequest struct {
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	Workspace string `json:"workspace"` // The name or ID of the workspace to switch to, empty for the personal one
+}
+
+// readWorkspaceRequest decodes a workspace request body, taking the user from
+// the credentials when there are any
+func (h *WorkspaceHandler) readWorkspaceRequest(w http.ResponseWriter, r *http.Request) (*workspaceRequest, bool) {
+	var req workspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "Invalid request"})
+		return nil, false
+	}
+	req.UserID = requestUserID(r, req.UserID)
+	if req.UserID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return nil, false
+	}
+	return &req, true
+}
+
+// ListWorkspaces handles GET /api/workspaces, the personal workspace first
+func (h *WorkspaceHandler) ListWorkspaces(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r, r.URL.Query().Get("user_id"))
+	if userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	workspaces, err := h.workspaceUC.ListWorkspaces(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: workspaces})
+}
+
+// CreateWorkspace handles POST /api/workspaces
+func (h *WorkspaceHandler) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.readWorkspaceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	workspace, err := h.workspaceUC.CreateWorkspace(r.Context(), req.UserID, req.Name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, &Response{Status: "success", Data: workspace})
+}
+
+// RenameWorkspace handles PUT /api/workspaces/{id}
+func (h *WorkspaceHandler) RenameWorkspace(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.readWorkspaceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	workspace, err := h.workspaceUC.RenameWorkspace(r.Context(), req.UserID, r.PathValue("id"), req.Name)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: workspace})
+}
+
+// DeleteWorkspace handles DELETE /api/workspaces/{id}, refused while the
+// workspace has expenses
+func (h *WorkspaceHandler) DeleteWorkspace(w http.ResponseWriter, r *http.Request) {
+	userID := requestUserID(r, r.URL.Query().Get("user_id"))
+	if userID == "" {
+		h.writeJSON(w, http.StatusBadRequest, &Response{Status: "error", Error: "user_id is required"})
+		return
+	}
+
+	if err := h.workspaceUC.DeleteWorkspace(r.Context(), userID, r.PathValue("id")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Message: "Workspace deleted"})
+}
+
+// SwitchWorkspace handles PUT /api/workspaces/active, choosing the workspace
+// the user's chat messages go into
+func (h *WorkspaceHandler) SwitchWorkspace(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.readWorkspaceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	workspace, err := h.workspaceUC.SwitchWorkspace(r.Context(), req.UserID, req.Workspace)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, &Response{Status: "success", Data: workspace})
+}
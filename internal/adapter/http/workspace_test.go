@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestWorkspaceMiddleware(t *testing.T) {
+	var got string
+	var scoped bool
+	handler := WorkspaceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, scoped = domain.WorkspaceFromContext(r.Context())
+	}))
+
+	tests := []struct {
+		header, query string
+		want          string
+		scoped        bool
+	}{
+		{header: "ws-1", want: "ws-1", scoped: true},
+		{query: "ws-2", want: "ws-2", scoped: true},
+		{header: "ws-1", query: "ws-2", want: "ws-1", scoped: true},
+		{header: "personal", want: domain.PersonalWorkspaceID, scoped: true},
+		{want: "", scoped: false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/expenses?workspace_id="+tt.query, nil)
+		if tt.header != "" {
+			req.Header.Set("X-Workspace-ID", tt.header)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if got != tt.want || scoped != tt.scoped {
+			t.Errorf("header %q, query %q: got workspace %q (scoped %v), want %q (scoped %v)", tt.header, tt.query, got, scoped, tt.want, tt.scoped)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package kvcache
+
+import (
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Config selects and configures the cache backend
+type Config struct {
+	Provider string // "memory" or "redis"
+
+	// In-process LRU cache; MaxEntries <= 0 uses DefaultMaxEntries
+	MaxEntries int
+
+	// Redis server, e.g. redis://:password@localhost:6379/0
+	RedisURL string
+}
+
+// New creates the cache for the configured provider
+func New(cfg Config) (domain.Cache, error) {
+	switch cfg.Provider {
+	case "memory":
+		return NewMemoryCache(cfg.MaxEntries), nil
+	case "redis":
+		return NewRedisCache(cfg.RedisURL)
+	default:
+		return nil, fmt.Errorf("unsupported cache: %s", cfg.Provider)
+	}
+}
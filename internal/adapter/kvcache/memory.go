@@ -0,0 +1,47 @@
+package kvcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/cache"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.Cache = (*MemoryCache)(nil)
+
+// DefaultMaxEntries is how many values a memory cache holds before it evicts
+// the least recently used one
+const DefaultMaxEntries = 10000
+
+// MemoryCache keeps values in an in-process LRU cache. Each server instance
+// has its own, so use Redis when several instances serve the same users.
+type MemoryCache struct {
+	lru *cache.LRUCache[string, []byte]
+}
+
+// NewMemoryCache creates a memory cache holding up to maxEntries values
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &MemoryCache{lru: cache.NewLRUCache[string, []byte](maxEntries)}
+}
+
+// Get retrieves the value stored under key
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok := c.lru.Get(key)
+	return value, ok, nil
+}
+
+// Set stores value under key until ttl has passed
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.lru.SetWithTTL(key, value, ttl)
+	return nil
+}
+
+// Delete removes the value stored under key
+func (c *MemoryCache) Delete(ctx context.Context, key string) error {
+	c.lru.Delete(key)
+	return nil
+}
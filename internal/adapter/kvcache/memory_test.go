@@ -0,0 +1,30 @@
+package kvcache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(2)
+
+	if err := c.Set(ctx, "a", []byte("1"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if value, ok, _ := c.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Errorf("Get(a) = %q, %v; want \"1\", true", value, ok)
+	}
+
+	c.Delete(ctx, "a")
+	if _, ok, _ := c.Get(ctx, "a"); ok {
+		t.Error("expected a to be deleted")
+	}
+
+	c.Set(ctx, "expired", []byte("x"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok, _ := c.Get(ctx, "expired"); ok {
+		t.Error("expected an expired value to be missing")
+	}
+}
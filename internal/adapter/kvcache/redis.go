@@ -0,0 +1,238 @@
+package kvcache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.Cache = (*RedisCache)(nil)
+
+const (
+	redisTimeout   = 2 * time.Second
+	redisIdleConns = 8
+)
+
+// RedisCache stores values in Redis, speaking its RESP protocol directly so
+// the cache needs no client library
+type RedisCache struct {
+	addr     string
+	username string
+	password string
+	db       int
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// redisError is an error reply from the server, after which the connection
+// is still usable
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// NewRedisCache creates a cache for a redis://[user:password@]host[:port][/db] URL
+func NewRedisCache(redisURL string) (*RedisCache, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil || u.Scheme != "redis" || u.Hostname() == "" {
+		return nil, fmt.Errorf("REDIS_URL must be a redis://host:port URL")
+	}
+
+	c := &RedisCache{
+		addr: u.Host,
+		idle: make(chan *redisConn, redisIdleConns),
+	}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis database number: %s", db)
+		}
+	}
+	return c, nil
+}
+
+// Get retrieves the value stored under key
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, value != nil, nil
+}
+
+// Set stores value under key until ttl has passed
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// Delete removes the value stored under key
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, "DEL", key)
+	return err
+}
+
+// Push appends value to the list stored under key
+func (c *RedisCache) Push(ctx context.Context, key string, value []byte) error {
+	_, err := c.do(ctx, "RPUSH", key, string(value))
+	return err
+}
+
+// Pop removes and returns the first value of the list stored under key,
+// reporting false when the list is empty
+func (c *RedisCache) Pop(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.do(ctx, "LPOP", key)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, value != nil, nil
+}
+
+// Close closes the idle connections
+func (c *RedisCache) Close() error {
+	for {
+		select {
+		case rc := <-c.idle:
+			rc.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command and returns its reply, nil for a missing value
+func (c *RedisCache) do(ctx context.Context, args ...string) ([]byte, error) {
+	rc, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := rc.do(ctx, args...)
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		rc.conn.Close()
+		return nil, err
+	}
+
+	select {
+	case c.idle <- rc:
+	default:
+		rc.conn.Close()
+	}
+	return reply, err
+}
+
+// conn returns an idle connection or dials, authenticates and selects the
+// database on a new one
+func (c *RedisCache) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case rc := <-c.idle:
+		return rc, nil
+	default:
+	}
+
+	dialer := net.Dialer{Timeout: redisTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	rc := &redisConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if c.password != "" {
+		auth := []string{"AUTH", c.password}
+		if c.username != "" {
+			auth = []string{"AUTH", c.username, c.password}
+		}
+		if _, err := rc.do(ctx, auth...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to authenticate to Redis: %w", err)
+		}
+	}
+	if c.db != 0 {
+		if _, err := rc.do(ctx, "SELECT", strconv.Itoa(c.db)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to select Redis database: %w", err)
+		}
+	}
+	return rc, nil
+}
+
+// do writes a command as an array of bulk strings and reads one reply
+func (rc *redisConn) do(ctx context.Context, args ...string) ([]byte, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisTimeout)
+	}
+	if err := rc.conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var cmd bytes.Buffer
+	fmt.Fprintf(&cmd, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&cmd, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := rc.conn.Write(cmd.Bytes()); err != nil {
+		return nil, err
+	}
+	return rc.readReply()
+}
+
+// readReply reads a simple string, error, integer or bulk string reply
+func (rc *redisConn) readReply() ([]byte, error) {
+	line, err := rc.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: invalid bulk length %q", line[1:])
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(rc.reader, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
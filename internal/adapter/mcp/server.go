@@ -0,0 +1,187 @@
+// Package mcp serves expense operations as Model Context Protocol tools, so
+// desktop agents such as Claude or ChatGPT can add and look up a user's
+// expenses. Messages are JSON-RPC 2.0, over stdio (ServeStdio) or HTTP.
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// ProtocolVersion is the newest MCP revision served
+const ProtocolVersion = "2025-06-18"
+
+// supportedProtocolVersions are the revisions a client can negotiate, newest first
+var supportedProtocolVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// serverName identifies the server to clients during initialization
+const serverName = "aiexpense"
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// maxMessageSize bounds a message read from stdio
+const maxMessageSize = 1 << 20
+
+// Server answers MCP requests with the expense tools, acting for one user per call
+type Server struct {
+	createExpenseUC  *usecase.CreateExpenseUseCase
+	getExpensesUC    *usecase.GetExpensesUseCase
+	generateReportUC *usecase.GenerateReportUseCase
+	categoryRepo     domain.CategoryRepository
+}
+
+// NewServer creates a new MCP server
+func NewServer(
+	createExpenseUC *usecase.CreateExpenseUseCase,
+	getExpensesUC *usecase.GetExpensesUseCase,
+	generateReportUC *usecase.GenerateReportUseCase,
+	categoryRepo domain.CategoryRepository,
+) *Server {
+	return &Server{
+		createExpenseUC:  createExpenseUC,
+		getExpensesUC:    getExpensesUC,
+		generateReportUC: generateReportUC,
+		categoryRepo:     categoryRepo,
+	}
+}
+
+// request is a JSON-RPC request, or a notification when it has no ID
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// response is a JSON-RPC response, holding either a result or an error
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handle answers one JSON-RPC message for userID. It returns nil for
+// notifications, which get no answer.
+func (s *Server) Handle(ctx context.Context, userID string, message []byte) []byte {
+	var req request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return encode(&response{ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "invalid JSON"}})
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return encode(&response{ID: idOrNull(req.ID), Error: &rpcError{Code: codeInvalidRequest, Message: "not a JSON-RPC 2.0 request"}})
+	}
+	if len(req.ID) == 0 {
+		// notifications/initialized and notifications/cancelled need no action
+		return nil
+	}
+
+	result, rpcErr := s.dispatch(ctx, userID, &req)
+	return encode(&response{ID: req.ID, Result: result, Error: rpcErr})
+}
+
+func (s *Server) dispatch(ctx context.Context, userID string, req *request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		// Agree to the client's revision when it is one we serve, else offer our newest
+		version := ProtocolVersion
+		if slices.Contains(supportedProtocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+			"serverInfo":      map[string]string{"name": serverName, "version": "1.0.0"},
+			"instructions":    "Tools for the user's expense ledger: add expenses, list them and report on spending.",
+		}, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.callTool(ctx, userID, params.Name, params.Arguments)
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+}
+
+// ServeStdio answers the messages a client writes to in, one JSON object per
+// line, on out until in is closed. Logs go to stderr, so out carries only
+// protocol messages.
+func (s *Server) ServeStdio(ctx context.Context, userID string, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		reply := s.Handle(ctx, userID, line)
+		if reply == nil {
+			continue
+		}
+		if _, err := out.Write(append(reply, '\n')); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func unmarshalParams(raw json.RawMessage, v interface{}) *rpcError {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+func encode(resp *response) []byte {
+	resp.JSONRPC = "2.0"
+	data, err := json.Marshal(resp)
+	if err != nil {
+		slog.Error("Failed to encode MCP response", "error", err)
+		data, _ = json.Marshal(&response{JSONRPC: "2.0", ID: resp.ID, Error: &rpcError{Code: codeInternalError, Message: "failed to encode response"}})
+	}
+	return data
+}
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	ctx := context.Background()
+	userRepo := usecase.NewMockUserRepository()
+	categoryRepo := usecase.NewMockCategoryRepository()
+	expenseRepo := usecase.NewMockExpenseRepository()
+	require.NoError(t, usecase.NewAutoSignupUseCase(userRepo, categoryRepo).Execute(ctx, "user1", "line"))
+	return NewServer(
+		usecase.NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, usecase.NewMockAIService()),
+		usecase.NewGetExpensesUseCase(expenseRepo, categoryRepo),
+		usecase.NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, usecase.NewMockGroupRepository()),
+		categoryRepo,
+	)
+}
+
+// call sends a request and decodes its response
+func call(t *testing.T, s *Server, id int, method string, params interface{}) map[string]interface{} {
+	t.Helper()
+	message, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	require.NoError(t, err)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(s.Handle(context.Background(), "user1", message), &resp))
+	assert.Equal(t, float64(id), resp["id"])
+	return resp
+}
+
+// callTool calls a tool and returns its result
+func callTool(t *testing.T, s *Server, name string, arguments interface{}) map[string]interface{} {
+	t.Helper()
+	resp := call(t, s, 1, "tools/call", map[string]interface{}{"name": name, "arguments": arguments})
+	require.Nil(t, resp["error"])
+	return resp["result"].(map[string]interface{})
+}
+
+func TestServer(t *testing.T) {
+	s := newTestServer(t)
+
+	resp := call(t, s, 1, "initialize", map[string]interface{}{"protocolVersion": "2025-03-26", "capabilities": map[string]interface{}{}})
+	result := resp["result"].(map[string]interface{})
+	assert.Equal(t, "2025-03-26", result["protocolVersion"])
+	assert.Contains(t, result["capabilities"], "tools")
+	resp = call(t, s, 2, "initialize", map[string]interface{}{"protocolVersion": "2099-01-01"})
+	assert.Equal(t, ProtocolVersion, resp["result"].(map[string]interface{})["protocolVersion"])
+
+	// Notifications get no answer
+	assert.Nil(t, s.Handle(context.Background(), "user1", []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)))
+
+	resp = call(t, s, 3, "tools/list", nil)
+	var names []string
+	for _, tool := range resp["result"].(map[string]interface{})["tools"].([]interface{}) {
+		names = append(names, tool.(map[string]interface{})["name"].(string))
+	}
+	assert.Equal(t, []string{"add_expense", "query_expenses", "get_report"}, names)
+
+	resp = call(t, s, 4, "resources/list", nil)
+	assert.Equal(t, float64(codeMethodNotFound), resp["error"].(map[string]interface{})["code"])
+	resp = call(t, s, 5, "tools/call", map[string]interface{}{"name": "delete_everything"})
+	assert.Equal(t, float64(codeInvalidParams), resp["error"].(map[string]interface{})["code"])
+
+	var parseErr map[string]interface{}
+	require.NoError(t, json.Unmarshal(s.Handle(context.Background(), "user1", []byte("{")), &parseErr))
+	assert.Equal(t, float64(codeParseError), parseErr["error"].(map[string]interface{})["code"])
+}
+
+func TestTools(t *testing.T) {
+	s := newTestServer(t)
+
+	result := callTool(t, s, "add_expense", map[string]interface{}{"description": "Lunch", "amount": 180, "category": "Food", "date": "2026-03-14"})
+	assert.Nil(t, result["isError"])
+	created := result["structuredContent"].(map[string]interface{})
+	assert.Equal(t, "Food", created["Category"])
+	callTool(t, s, "add_expense", map[string]interface{}{"description": "Taxi", "amount": 320, "date": "2026-03-15"})
+
+	// Mistakes come back as tool errors the agent can read and correct
+	for _, arguments := range []map[string]interface{}{
+		{"description": "Lunch"},
+		{"description": "Lunch", "amount": 100, "category": "Yachts"},
+		{"description": "Lunch", "amount": 100, "date": "14/03/2026"},
+	} {
+		result := callTool(t, s, "add_expense", arguments)
+		assert.Equal(t, true, result["isError"], "arguments %v", arguments)
+	}
+
+	result = callTool(t, s, "query_expenses", map[string]interface{}{"limit": 1, "sort_by": "amount"})
+	listed := result["structuredContent"].(map[string]interface{})
+	expenses := listed["Expenses"].([]interface{})
+	require.Len(t, expenses, 1)
+	assert.Equal(t, "Taxi", expenses[0].(map[string]interface{})["Description"])
+	assert.NotEmpty(t, listed["NextCursor"])
+	assert.Equal(t, true, callTool(t, s, "query_expenses", map[string]interface{}{"sort_by": "mood"})["isError"])
+
+	// The report includes expenses on its end date
+	result = callTool(t, s, "get_report", map[string]interface{}{"start_date": "2026-03-01", "end_date": "2026-03-15"})
+	report := result["structuredContent"].(map[string]interface{})
+	assert.Equal(t, "monthly", report["report_type"])
+	assert.Equal(t, 500.0, report["total_expenses"])
+	assert.Contains(t, result["content"].([]interface{})[0].(map[string]interface{})["text"], `"total_expenses":500`)
+}
+
+func TestServeStdio(t *testing.T) {
+	s := newTestServer(t)
+	in := strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}`,
+	}, "\n"))
+	var out bytes.Buffer
+	require.NoError(t, s.ServeStdio(context.Background(), "user1", in, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"protocolVersion":"2025-06-18"`)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":2,"result":{}}`, lines[1])
+}
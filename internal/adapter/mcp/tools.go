@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// defaultQueryLimit is how many expenses query_expenses lists when not asked
+const defaultQueryLimit = 20
+
+// dateLayout is how tools take dates, in the user's timezone
+const dateLayout = "2006-01-02"
+
+// tool describes a tool to clients, its input as a JSON Schema
+type tool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+	Annotations toolAnnotations `json:"annotations"`
+}
+
+// toolAnnotations hint to clients whether a tool changes anything
+type toolAnnotations struct {
+	ReadOnlyHint    bool `json:"readOnlyHint"`
+	DestructiveHint bool `json:"destructiveHint"`
+	IdempotentHint  bool `json:"idempotentHint"`
+}
+
+// tools are the tools served, in the order clients list them
+var tools = []tool{
+	{
+		Name:        "add_expense",
+		Title:       "Add expense",
+		Description: "Record an expense. The category is chosen automatically unless one of the user's category names is given.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"description": {"type": "string", "description": "What was bought, e.g. \"Lunch\""},
+				"amount": {"type": "number", "exclusiveMinimum": 0},
+				"currency": {"type": "string", "description": "ISO 4217 code; the user's home currency when omitted"},
+				"category": {"type": "string", "description": "Name of one of the user's categories"},
+				"date": {"type": "string", "format": "date", "description": "YYYY-MM-DD; today when omitted"},
+				"account": {"type": "string", "description": "Payment method, e.g. \"Credit card\""},
+				"merchant": {"type": "string"},
+				"tags": {"type": "array", "items": {"type": "string"}}
+			},
+			"required": ["description", "amount"]
+		}`),
+	},
+	{
+		Name:        "query_expenses",
+		Title:       "Query expenses",
+		Description: "List the user's expenses, newest first unless sorted otherwise. Pass the NextCursor of a result as cursor for the next page.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"limit": {"type": "integer", "minimum": 1, "maximum": 500, "default": 20},
+				"cursor": {"type": "string"},
+				"sort_by": {"type": "string", "enum": ["date", "amount", "created_at"]},
+				"sort_dir": {"type": "string", "enum": ["desc", "asc"]}
+			}
+		}`),
+		Annotations: toolAnnotations{ReadOnlyHint: true, IdempotentHint: true},
+	},
+	{
+		Name:        "get_report",
+		Title:       "Get spending report",
+		Description: "Summarize the user's spending over a period: the total, a breakdown by category, tag and day, and the top expenses.",
+		InputSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"report_type": {"type": "string", "enum": ["daily", "weekly", "monthly"], "default": "monthly"},
+				"start_date": {"type": "string", "format": "date", "description": "YYYY-MM-DD; a month ago when omitted"},
+				"end_date": {"type": "string", "format": "date", "description": "YYYY-MM-DD, inclusive; today when omitted"}
+			}
+		}`),
+		Annotations: toolAnnotations{ReadOnlyHint: true, IdempotentHint: true},
+	},
+}
+
+// toolResult is the result of tools/call. A tool that fails answers with
+// IsError set and the reason as text, so the agent can correct itself.
+type toolResult struct {
+	Content           []toolContent `json:"content"`
+	StructuredContent interface{}   `json:"structuredContent,omitempty"`
+	IsError           bool          `json:"isError,omitempty"`
+}
+
+type toolContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (s *Server) callTool(ctx context.Context, userID, name string, arguments json.RawMessage) (interface{}, *rpcError) {
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+
+	var result interface{}
+	var err error
+	switch name {
+	case "add_expense":
+		result, err = s.addExpense(ctx, userID, arguments)
+	case "query_expenses":
+		result, err = s.queryExpenses(ctx, userID, arguments)
+	case "get_report":
+		result, err = s.getReport(ctx, userID, arguments)
+	default:
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", name)}
+	}
+	if err != nil {
+		return &toolResult{Content: []toolContent{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+
+	text, err := json.Marshal(result)
+	if err != nil {
+		return nil, &rpcError{Code: codeInternalError, Message: err.Error()}
+	}
+	return &toolResult{Content: []toolContent{{Type: "text", Text: string(text)}}, StructuredContent: result}, nil
+}
+
+func (s *Server) addExpense(ctx context.Context, userID string, arguments json.RawMessage) (*usecase.CreateResponse, error) {
+	var args struct {
+		Description string   `json:"description"`
+		Amount      float64  `json:"amount"`
+		Currency    string   `json:"currency"`
+		Category    string   `json:"category"`
+		Date        string   `json:"date"`
+		Account     string   `json:"account"`
+		Merchant    string   `json:"merchant"`
+		Tags        []string `json:"tags"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(args.Description) == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+	if args.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	date := time.Now()
+	if args.Date != "" {
+		var err error
+		if date, err = parseDate(ctx, args.Date); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &usecase.CreateRequest{
+		UserID:      userID,
+		Description: args.Description,
+		Amount:      args.Amount,
+		Currency:    strings.ToUpper(args.Currency),
+		Account:     args.Account,
+		Merchant:    args.Merchant,
+		Tags:        args.Tags,
+		Date:        date,
+	}
+	if args.Category != "" {
+		category, err := s.categoryRepo.GetByUserIDAndName(ctx, userID, args.Category)
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("the user has no category %q", args.Category)
+		}
+		if err != nil {
+			return nil, err
+		}
+		req.CategoryID = &category.ID
+	}
+
+	ctx = domain.WithAuditSource(ctx, domain.AuditSource{Actor: userID, Channel: domain.AuditChannelAPI})
+	return s.createExpenseUC.Execute(ctx, req)
+}
+
+func (s *Server) queryExpenses(ctx context.Context, userID string, arguments json.RawMessage) (*usecase.GetAllResponse, error) {
+	var args struct {
+		Limit   int    `json:"limit"`
+		Cursor  string `json:"cursor"`
+		SortBy  string `json:"sort_by"`
+		SortDir string `json:"sort_dir"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Limit == 0 {
+		args.Limit = defaultQueryLimit
+	}
+
+	req := &usecase.GetAllRequest{
+		UserID:  userID,
+		Limit:   args.Limit,
+		Cursor:  args.Cursor,
+		SortBy:  args.SortBy,
+		SortDir: strings.ToLower(args.SortDir),
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	return s.getExpensesUC.ExecuteGetAll(ctx, req)
+}
+
+func (s *Server) getReport(ctx context.Context, userID string, arguments json.RawMessage) (*usecase.ExpenseReport, error) {
+	var args struct {
+		ReportType string `json:"report_type"`
+		StartDate  string `json:"start_date"`
+		EndDate    string `json:"end_date"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	req := &usecase.ReportRequest{
+		UserID:     userID,
+		ReportType: args.ReportType,
+		StartDate:  time.Now().AddDate(0, -1, 0),
+		EndDate:    time.Now(),
+	}
+	if req.ReportType == "" {
+		req.ReportType = "monthly"
+	}
+	var err error
+	if args.StartDate != "" {
+		if req.StartDate, err = parseDate(ctx, args.StartDate); err != nil {
+			return nil, err
+		}
+	}
+	if args.EndDate != "" {
+		if req.EndDate, err = parseDate(ctx, args.EndDate); err != nil {
+			return nil, err
+		}
+		// The end date is inclusive, so the report runs to the end of that day
+		req.EndDate = req.EndDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return s.generateReportUC.Execute(ctx, req)
+}
+
+// parseDate reads a YYYY-MM-DD date in the user's timezone
+func parseDate(ctx context.Context, value string) (time.Time, error) {
+	date, err := time.ParseInLocation(dateLayout, value, domain.LocationFromContext(ctx))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("dates are YYYY-MM-DD, got %q", value)
+	}
+	return date, nil
+}
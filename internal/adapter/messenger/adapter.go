@@ -0,0 +1,66 @@
+// Package messenger holds what the chat platform integrations share: the
+// Adapter interface a platform implements and the Webhook pipeline that
+// verifies, deduplicates, queues, processes and answers its messages.
+package messenger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ErrInvalidSignature is returned by VerifyRequest for requests whose
+// signature or token doesn't match
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// MessageProcessor defines the interface for processing messages
+type MessageProcessor interface {
+	Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error)
+}
+
+// Adapter is the transport of a chat platform: how its webhook requests are
+// verified and read, and how messages are sent back to it
+type Adapter interface {
+	// VerifyRequest checks the request comes from the platform, usually by
+	// its signature over the body
+	VerifyRequest(r *http.Request, body []byte) error
+
+	// ExtractMessages reads the user messages of a verified request. Other
+	// events are handled or ignored here; an error rejects the request.
+	ExtractMessages(ctx context.Context, body []byte) ([]*Inbound, error)
+
+	// SendReply answers a message where it was sent
+	SendReply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error
+
+	// SendPush sends a message to a user, identified by their app user ID,
+	// who didn't just write to the bot
+	SendPush(ctx context.Context, userID, text string) error
+}
+
+// Inbound is a user message read from a webhook request
+type Inbound struct {
+	// EventID identifies the event to skip redeliveries, which keep it;
+	// messages without one are never skipped
+	EventID string
+
+	Message *domain.UserMessage
+
+	// Download fetches the message's photo or voice note, if any, before it
+	// is processed; an error drops the message
+	Download func(ctx context.Context) ([]*domain.Attachment, error)
+}
+
+// Acknowledger is implemented by adapters whose platform expects a particular
+// answer to its webhook requests; the others get an empty 200
+type Acknowledger interface {
+	Acknowledge(w http.ResponseWriter, body []byte)
+}
+
+// ProgressIndicator is implemented by adapters that show the user their
+// message is being worked on, e.g. a "typing" indicator. It returns the
+// context the message is processed with.
+type ProgressIndicator interface {
+	IndicateProgress(ctx context.Context, msg *domain.UserMessage) context.Context
+}
@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
@@ -65,7 +65,7 @@ func (c *Client) SendMessage(ctx context.Context, token, interactionID, text str
 
 	payload, err := json.Marshal(ackReq)
 	if err != nil {
-		log.Printf("Error marshaling ack request: %v", err)
+		slog.ErrorContext(ctx, "Failed to encode Discord ack", "error", err)
 		return fmt.Errorf("failed to marshal ack request: %w", err)
 	}
 
@@ -79,14 +79,14 @@ func (c *Client) SendMessage(ctx context.Context, token, interactionID, text str
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		log.Printf("Error sending ack to Discord: %v", err)
+		slog.ErrorContext(ctx, "Failed to send ack to Discord", "error", err)
 		return fmt.Errorf("failed to send ack: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("Ack error response: %s", string(body))
+		slog.ErrorContext(ctx, "Discord ack returned an error", "response", string(body))
 		return fmt.Errorf("discord api error on ack: status %d", resp.StatusCode)
 	}
 
@@ -98,7 +98,7 @@ func (c *Client) SendMessage(ctx context.Context, token, interactionID, text str
 
 	payload, err = json.Marshal(followupMsg)
 	if err != nil {
-		log.Printf("Error marshaling followup request: %v", err)
+		slog.ErrorContext(ctx, "Failed to encode Discord followup", "error", err)
 		return fmt.Errorf("failed to marshal followup request: %w", err)
 	}
 
@@ -112,7 +112,7 @@ func (c *Client) SendMessage(ctx context.Context, token, interactionID, text str
 
 	resp, err = c.httpClient.Do(httpReq)
 	if err != nil {
-		log.Printf("Error sending followup to Discord: %v", err)
+		slog.ErrorContext(ctx, "Failed to send followup to Discord", "error", err)
 		return fmt.Errorf("failed to send followup: %w", err)
 	}
 	defer resp.Body.Close()
@@ -130,7 +130,7 @@ func (c *Client) SendMessage(ctx context.Context, token, interactionID, text str
 		return fmt.Errorf("discord api error: status %d", resp.StatusCode)
 	}
 
-	log.Printf("[Discord] Message sent for interaction %s", interactionID)
+	slog.DebugContext(ctx, "Discord message sent", "interaction_id", interactionID)
 	return nil
 }
 
@@ -156,6 +156,6 @@ func (c *Client) GetBotInfo(ctx context.Context) error {
 		return fmt.Errorf("discord api error: status %d - %s", resp.StatusCode, string(body))
 	}
 
-	log.Printf("[Discord] Bot connected and ready")
+	slog.InfoContext(ctx, "Discord bot connected")
 	return nil
 }
@@ -4,11 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/logging"
 )
 
 // MessageProcessor defines the interface for processing messages
@@ -21,6 +22,7 @@ type Handler struct {
 	botToken string
 	useCase  MessageProcessor
 	client   *Client
+	dedup    domain.EventDeduplicator
 }
 
 // NewHandler creates a new Discord webhook handler
@@ -32,6 +34,16 @@ func NewHandler(botToken string, useCase MessageProcessor, client *Client) *Hand
 	}
 }
 
+// SetDeduplicator skips webhook events that were already handled, e.g. retried deliveries
+func (h *Handler) SetDeduplicator(dedup domain.EventDeduplicator) {
+	h.dedup = dedup
+}
+
+// isDuplicate reports whether the event was already handled
+func (h *Handler) isDuplicate(ctx context.Context, eventID string) bool {
+	return h.dedup != nil && h.dedup.Seen(ctx, "discord", eventID)
+}
+
 // DiscordInteraction represents an interaction from Discord
 type DiscordInteraction struct {
 	Type      int             `json:"type"`
@@ -92,7 +104,7 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf("Error reading request body: %v", err)
+		slog.WarnContext(r.Context(), "Failed to read Discord request body", "error", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -100,7 +112,7 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 
 	var interaction DiscordInteraction
 	if err := json.Unmarshal(body, &interaction); err != nil {
-		log.Printf("Error unmarshaling interaction: %v", err)
+		slog.WarnContext(r.Context(), "Failed to parse Discord interaction", "error", err)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -112,6 +124,12 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The original delivery already answered this interaction
+	if h.isDuplicate(r.Context(), interaction.ID) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	// Extract user info
 	userID := interaction.User.ID
 	if userID == "" && interaction.Member.User.ID != "" {
@@ -148,9 +166,10 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Process message
-	resp, err := h.useCase.Execute(r.Context(), userMsg)
+	ctx := logging.WithUser(r.Context(), userID, "discord")
+	resp, err := h.useCase.Execute(ctx, userMsg)
 	if err != nil {
-		log.Printf("Error processing message: %v", err)
+		slog.ErrorContext(ctx, "Failed to handle Discord message", "error", err)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"type": 4,
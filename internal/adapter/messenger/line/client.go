@@ -6,14 +6,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/domain"
 )
 
+var _ domain.PushNotifier = (*Client)(nil)
+
 // Client represents the LINE Messaging API client
 type Client struct {
 	channelToken string
 	apiURL       string
+	dataAPIURL   string
+	botAPIURL    string // rich menu endpoints
+	dataBotURL   string // rich menu images
 	httpClient   *http.Client
 }
 
@@ -26,6 +33,9 @@ func NewClient(channelToken string) (*Client, error) {
 	return &Client{
 		channelToken: channelToken,
 		apiURL:       "https://api.line.me/v2/bot/message",
+		dataAPIURL:   "https://api-data.line.me/v2/bot/message",
+		botAPIURL:    "https://api.line.me/v2/bot",
+		dataBotURL:   "https://api-data.line.me/v2/bot",
 		httpClient:   &http.Client{},
 	}, nil
 }
@@ -36,6 +46,12 @@ type ReplyMessageRequest struct {
 	Messages   []TextMessage `json:"messages"`
 }
 
+// PushMessageRequest represents the request to push a message to a user
+type PushMessageRequest struct {
+	To       string        `json:"to"`
+	Messages []TextMessage `json:"messages"`
+}
+
 // TextMessage represents a text message
 type TextMessage struct {
 	Type string `json:"type"`
@@ -61,13 +77,44 @@ func (c *Client) SendMessage(ctx context.Context, replyToken, text string) error
 		},
 	}
 
+	if err := c.postMessage(ctx, "reply", req); err != nil {
+		return err
+	}
+
+	slog.DebugContext(ctx, "LINE reply sent")
+	return nil
+}
+
+// PushMessage sends a message to a user without a reply token, e.g. for proactive alerts.
+// Push messages count against the channel's monthly message quota.
+func (c *Client) PushMessage(ctx context.Context, userID, text string) error {
+	req := PushMessageRequest{
+		To: userID,
+		Messages: []TextMessage{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}
+
+	if err := c.postMessage(ctx, "push", req); err != nil {
+		return err
+	}
+
+	slog.DebugContext(ctx, "LINE push message sent", "to", userID)
+	return nil
+}
+
+// postMessage posts a message request to the given Messaging API endpoint
+func (c *Client) postMessage(ctx context.Context, endpoint string, req interface{}) error {
 	payload, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error marshaling request: %v", err)
+		slog.ErrorContext(ctx, "Failed to encode LINE request", "error", err)
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/reply", c.apiURL), bytes.NewReader(payload))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/%s", c.apiURL, endpoint), bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -77,7 +124,7 @@ func (c *Client) SendMessage(ctx context.Context, replyToken, text string) error
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		log.Printf("Error sending message to LINE: %v", err)
+		slog.ErrorContext(ctx, "Failed to send message to LINE", "error", err)
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 	defer resp.Body.Close()
@@ -89,7 +136,7 @@ func (c *Client) SendMessage(ctx context.Context, replyToken, text string) error
 
 	// Check HTTP status code
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		log.Printf("[LINE API Error] Status: %d, Body: %s", resp.StatusCode, string(body))
+		slog.ErrorContext(ctx, "LINE API returned an error", "status", resp.StatusCode, "response", string(body))
 		var apiResp LineAPIResponse
 		if err := json.Unmarshal(body, &apiResp); err == nil && apiResp.Message != "" {
 			return fmt.Errorf("line api error: %s (status: %d)", apiResp.Message, resp.StatusCode)
@@ -97,7 +144,6 @@ func (c *Client) SendMessage(ctx context.Context, replyToken, text string) error
 		return fmt.Errorf("line api error: status %d, body: %s", resp.StatusCode, string(body))
 	}
 
-	log.Printf("[LINE] Message sent to reply token %s", replyToken)
 	return nil
 }
 
@@ -105,3 +151,50 @@ func (c *Client) SendMessage(ctx context.Context, replyToken, text string) error
 func (c *Client) SendReply(ctx context.Context, replyToken, text string) error {
 	return c.SendMessage(ctx, replyToken, text)
 }
+
+// SendFlex replies with a Flex message
+func (c *Client) SendFlex(ctx context.Context, replyToken string, message *FlexMessage) error {
+	req := FlexReplyRequest{ReplyToken: replyToken, Messages: []*FlexMessage{message}}
+	if err := c.postMessage(ctx, "reply", req); err != nil {
+		return err
+	}
+	slog.DebugContext(ctx, "LINE flex reply sent")
+	return nil
+}
+
+// PushFlex sends a Flex message to a user, group or room without a reply token
+func (c *Client) PushFlex(ctx context.Context, to string, message *FlexMessage) error {
+	req := FlexPushRequest{To: to, Messages: []*FlexMessage{message}}
+	if err := c.postMessage(ctx, "push", req); err != nil {
+		return err
+	}
+	slog.DebugContext(ctx, "LINE flex push message sent", "to", to)
+	return nil
+}
+
+// GetMessageContent downloads the binary content (image, audio, etc.) of a user message
+func (c *Client) GetMessageContent(ctx context.Context, messageID string) ([]byte, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%s/content", c.dataAPIURL, messageID), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.channelToken))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get message content: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("line api error: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	return body, resp.Header.Get("Content-Type"), nil
+}
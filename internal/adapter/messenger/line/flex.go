@@ -0,0 +1,209 @@
+package line
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Flex Message limits: https://developers.line.biz/en/reference/messaging-api/#flex-message
+const (
+	maxAltText     = 400
+	maxActionLabel = 40
+	maxPostback    = 300
+	maxFlexRows    = 30
+	maxFlexButtons = 10
+)
+
+// FlexReplyRequest represents the request to reply with Flex messages
+type FlexReplyRequest struct {
+	ReplyToken string         `json:"replyToken"`
+	Messages   []*FlexMessage `json:"messages"`
+}
+
+// FlexPushRequest represents the request to push Flex messages
+type FlexPushRequest struct {
+	To       string         `json:"to"`
+	Messages []*FlexMessage `json:"messages"`
+}
+
+// FlexMessage is a message laid out with Flex components; AltText is shown
+// in notifications and chat lists
+type FlexMessage struct {
+	Type     string      `json:"type"` // "flex"
+	AltText  string      `json:"altText"`
+	Contents *FlexBubble `json:"contents"`
+}
+
+// FlexBubble is a single message bubble
+type FlexBubble struct {
+	Type   string         `json:"type"` // "bubble"
+	Body   *FlexComponent `json:"body,omitempty"`
+	Footer *FlexComponent `json:"footer,omitempty"`
+}
+
+// FlexComponent is a box, text, image, separator or button
+type FlexComponent struct {
+	Type        string           `json:"type"`
+	Layout      string           `json:"layout,omitempty"` // Boxes: "vertical" or "horizontal"
+	Contents    []*FlexComponent `json:"contents,omitempty"`
+	Spacing     string           `json:"spacing,omitempty"`
+	Margin      string           `json:"margin,omitempty"`
+	Text        string           `json:"text,omitempty"`
+	Size        string           `json:"size,omitempty"`
+	Weight      string           `json:"weight,omitempty"`
+	Color       string           `json:"color,omitempty"`
+	Align       string           `json:"align,omitempty"`
+	Wrap        bool             `json:"wrap,omitempty"`
+	URL         string           `json:"url,omitempty"` // Images
+	AspectMode  string           `json:"aspectMode,omitempty"`
+	AspectRatio string           `json:"aspectRatio,omitempty"`
+	Style       string           `json:"style,omitempty"` // Buttons: "primary", "secondary" or "link"
+	Action      *FlexAction      `json:"action,omitempty"`
+}
+
+// FlexAction is what a button does: open a URI or send postback data, which
+// arrives as a postback event like the rich menu's
+type FlexAction struct {
+	Type        string `json:"type"` // "uri" or "postback"
+	Label       string `json:"label"`
+	URI         string `json:"uri,omitempty"`
+	Data        string `json:"data,omitempty"`
+	DisplayText string `json:"displayText,omitempty"`
+}
+
+// buildFlex lays the response's content blocks out as a Flex bubble, with
+// its buttons in the footer. It returns nil without blocks, when plain text
+// says it all.
+func buildFlex(resp *domain.MessageResponse) *FlexMessage {
+	var body []*FlexComponent
+	for _, c := range resp.Blocks {
+		components := flexComponents(c)
+		if len(components) == 0 {
+			continue
+		}
+		if len(body) > 0 {
+			body = append(body, &FlexComponent{Type: "separator", Margin: "lg"})
+		}
+		body = append(body, components...)
+	}
+	if len(body) == 0 {
+		return nil
+	}
+
+	bubble := &FlexBubble{
+		Type: "bubble",
+		Body: &FlexComponent{Type: "box", Layout: "vertical", Spacing: "sm", Contents: body},
+	}
+	var buttons []*FlexComponent
+	for _, b := range resp.Buttons {
+		if action := flexAction(b); action != nil && len(buttons) < maxFlexButtons {
+			buttons = append(buttons, &FlexComponent{Type: "button", Style: "link", Action: action})
+		}
+	}
+	if len(buttons) > 0 {
+		bubble.Footer = &FlexComponent{Type: "box", Layout: "vertical", Contents: buttons}
+	}
+
+	altText := resp.Text
+	if altText == "" {
+		altText = resp.Blocks[0].Title
+	}
+	return &FlexMessage{Type: "flex", AltText: cut(altText, maxAltText), Contents: bubble}
+}
+
+// flexComponents renders a content block: a card's title, text and fields,
+// a table's header and rows, or a chart image and its legend
+func flexComponents(c *domain.ContentBlock) []*FlexComponent {
+	var components []*FlexComponent
+	if c.Title != "" {
+		components = append(components, &FlexComponent{Type: "text", Text: c.Title, Weight: "bold", Size: "md", Wrap: true})
+	}
+
+	switch c.Type {
+	case domain.ContentBlockCard:
+		if c.Text != "" {
+			components = append(components, &FlexComponent{Type: "text", Text: c.Text, Size: "sm", Wrap: true})
+		}
+		for _, f := range c.Fields {
+			components = append(components, &FlexComponent{
+				Type:   "box",
+				Layout: "horizontal",
+				Contents: []*FlexComponent{
+					{Type: "text", Text: cellText(f.Label), Size: "sm", Color: "#888888"},
+					{Type: "text", Text: cellText(f.Value), Size: "sm", Align: "end", Wrap: true},
+				},
+			})
+		}
+
+	case domain.ContentBlockTable:
+		components = append(components, flexRow(c.Columns, "#888888"))
+		for i, row := range c.Rows {
+			if i == maxFlexRows {
+				break
+			}
+			components = append(components, flexRow(row, ""))
+		}
+
+	case domain.ContentBlockChart:
+		if c.ImageURL == "" {
+			return nil
+		}
+		components = append(components, &FlexComponent{Type: "image", URL: c.ImageURL, Size: "full", AspectMode: "fit", AspectRatio: "4:3"})
+		if c.Text != "" {
+			components = append(components, &FlexComponent{Type: "text", Text: c.Text, Size: "sm", Wrap: true})
+		}
+	}
+	return components
+}
+
+// flexRow is a table row; the first column is left aligned and the others,
+// usually amounts, right aligned
+func flexRow(cells []string, color string) *FlexComponent {
+	row := &FlexComponent{Type: "box", Layout: "horizontal", Spacing: "sm"}
+	for i, cell := range cells {
+		text := &FlexComponent{Type: "text", Text: cellText(cell), Size: "xs", Color: color, Wrap: true}
+		if i > 0 {
+			text.Align = "end"
+		}
+		row.Contents = append(row.Contents, text)
+	}
+	return row
+}
+
+// flexAction turns a button into a URI or postback action, or nil when it
+// doesn't fit in one
+func flexAction(b *domain.MessageButton) *FlexAction {
+	label := cut(b.Label, maxActionLabel)
+	if b.URL != "" {
+		return &FlexAction{Type: "uri", Label: label, URI: b.URL}
+	}
+	if b.Action == "" {
+		return nil
+	}
+	data := url.Values{"action": {b.Action}}
+	if b.Value != "" {
+		data.Set("name", b.Value)
+	}
+	if len(data.Encode()) > maxPostback {
+		return nil
+	}
+	return &FlexAction{Type: "postback", Label: label, Data: data.Encode(), DisplayText: b.Label}
+}
+
+// cellText keeps empty cells, which Flex text can't be, visible as a dash
+func cellText(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return "-"
+	}
+	return text
+}
+
+// cut shortens text to n characters
+func cut(text string, n int) string {
+	if runes := []rune(text); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,74 @@
+package line
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestBuildFlex(t *testing.T) {
+	if flex := buildFlex(&domain.MessageResponse{Text: "Hi", Buttons: []*domain.MessageButton{{Label: "Open report", URL: "https://example.com/r"}}}); flex != nil {
+		t.Errorf("expected plain text without blocks, got %+v", flex)
+	}
+
+	flex := buildFlex(&domain.MessageResponse{
+		Text: "💰 Budgets",
+		Blocks: []*domain.ContentBlock{
+			{Type: domain.ContentBlockTable, Title: "💰 Budgets", Columns: []string{"Category", "Spent"}, Rows: [][]string{{"Food", "1200"}, {"", "30"}}},
+			{Type: domain.ContentBlockChart, Text: "🟥 Food", ImageURL: "https://example.com/chart.png"},
+		},
+		Buttons: []*domain.MessageButton{
+			{Label: "Open report", URL: "https://example.com/r"},
+			{Label: "寵物", Action: domain.MessageActionChangeCategory, Value: "exp1 cat1"},
+		},
+	})
+	if flex == nil || flex.AltText != "💰 Budgets" {
+		t.Fatalf("unexpected flex message: %+v", flex)
+	}
+
+	body := flex.Contents.Body.Contents
+	// Title, header, two rows, separator, the chart and its legend
+	if len(body) != 7 || body[1].Contents[0].Text != "Category" || body[3].Contents[0].Text != "-" || body[3].Contents[1].Align != "end" {
+		t.Fatalf("unexpected table: %+v", body)
+	}
+	if body[4].Type != "separator" || body[5].Type != "image" || body[5].URL != "https://example.com/chart.png" || body[6].Text != "🟥 Food" {
+		t.Errorf("unexpected chart: %+v", body[4:])
+	}
+
+	buttons := flex.Contents.Footer.Contents
+	if len(buttons) != 2 || buttons[0].Action.URI != "https://example.com/r" {
+		t.Fatalf("unexpected buttons: %+v", buttons)
+	}
+	if action, name := parsePostback(buttons[1].Action.Data); action != domain.MessageActionChangeCategory || name != "exp1 cat1" {
+		t.Errorf("unexpected postback: %q %q", action, name)
+	}
+}
+
+func TestHandler_SendReply_Flex(t *testing.T) {
+	var paths []string
+	var sent FlexReplyRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &sent)
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("token")
+	client.apiURL = server.URL
+	handler := NewHandler("secret", nil, client)
+
+	msg := &domain.UserMessage{UserID: "U1", Metadata: map[string]interface{}{"reply_token": "token1"}}
+	resp := &domain.MessageResponse{Text: "This month", Blocks: []*domain.ContentBlock{{Type: domain.ContentBlockCard, Title: "This month", Text: "120 across 1 expense"}}}
+	if err := handler.SendReply(t.Context(), msg, resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != "/reply" || sent.ReplyToken != "token1" || len(sent.Messages) != 1 || sent.Messages[0].Type != "flex" {
+		t.Errorf("expected a flex reply, got %v %+v", paths, sent)
+	}
+}
@@ -6,34 +6,83 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
-	"io"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/riverlin/aiexpense/internal/adapter/messenger"
 	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/logging"
 )
 
 // MessageProcessor defines the interface for processing messages
-type MessageProcessor interface {
-	Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error)
-}
+type MessageProcessor = messenger.MessageProcessor
 
 // Handler handles LINE bot webhook events
 type Handler struct {
+	*messenger.Webhook
 	channelSecret string
-	useCase       MessageProcessor
 	client        *Client
 }
 
-// NewHandler creates a new LINE webhook handler
+// NewHandler creates a new LINE webhook handler. Messages are processed
+// before the webhook is acknowledged, while their reply tokens are valid,
+// unless a queue is set.
 func NewHandler(channelSecret string, useCase MessageProcessor, client *Client) *Handler {
-	return &Handler{
+	h := &Handler{
 		channelSecret: channelSecret,
-		useCase:       useCase,
 		client:        client,
 	}
+	h.Webhook = messenger.NewWebhook("line", h, useCase)
+	h.SetInline(true)
+	return h
+}
+
+// Reply answers a queued message with its reply token, or pushes the answer
+// once the token has expired
+func (h *Handler) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	return h.SendReply(ctx, msg, resp)
+}
+
+// SendReply answers a message with its reply token, or pushes the answer
+// once the token has expired. Content blocks are sent as a Flex message.
+func (h *Handler) SendReply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	if h.client == nil {
+		return nil
+	}
+	flex := buildFlex(resp)
+	if replyToken, _ := msg.Metadata["reply_token"].(string); replyToken != "" {
+		var err error
+		if flex != nil {
+			err = h.client.SendFlex(ctx, replyToken, flex)
+		} else {
+			err = h.client.SendReply(ctx, replyToken, resp.Text)
+		}
+		if err == nil {
+			return nil
+		}
+		slog.DebugContext(ctx, "LINE reply token rejected, pushing the reply", "error", err)
+	}
+
+	to := msg.UserID
+	if msg.GroupChatID != "" {
+		to = msg.GroupChatID
+	}
+	if flex != nil {
+		return h.client.PushFlex(ctx, to, flex)
+	}
+	return h.client.PushMessage(ctx, to, resp.Text)
+}
+
+// SendPush sends a message to a LINE user
+func (h *Handler) SendPush(ctx context.Context, userID, text string) error {
+	if h.client == nil {
+		return fmt.Errorf("line client not configured")
+	}
+	return h.client.PushMessage(ctx, userID, text)
 }
 
 // LineEvent represents a LINE messaging event
@@ -41,54 +90,58 @@ type LineEvent struct {
 	Events []struct {
 		Type    string `json:"type"`
 		Message struct {
+			ID   string `json:"id"`
 			Type string `json:"type"`
 			Text string `json:"text"`
 		} `json:"message"`
+		Postback struct {
+			Data string `json:"data"` // e.g. "action=today_spending", see RichMenuAction
+		} `json:"postback"`
 		Source struct {
-			Type   string `json:"type"`
-			UserID string `json:"userId"`
+			Type    string `json:"type"` // "user", "group" or "room"
+			UserID  string `json:"userId"`
+			GroupID string `json:"groupId,omitempty"`
+			RoomID  string `json:"roomId,omitempty"`
 		} `json:"source"`
-		ReplyToken string `json:"replyToken"`
-		Timestamp  int64  `json:"timestamp"`
+		ReplyToken     string `json:"replyToken"`
+		Timestamp      int64  `json:"timestamp"`
+		WebhookEventID string `json:"webhookEventId"`
 	} `json:"events"`
 }
 
-// HandleWebhook processes incoming LINE webhook events
-func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	// Verify signature
-	signature := r.Header.Get("X-Line-Signature")
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Failed to read body", http.StatusBadRequest)
-		return
+// VerifyRequest checks the request's X-Line-Signature header
+func (h *Handler) VerifyRequest(r *http.Request, body []byte) error {
+	if !h.verifySignature(r.Header.Get("X-Line-Signature"), body) {
+		return messenger.ErrInvalidSignature
 	}
+	return nil
+}
 
-	// Debug log: Print webhook details
-	log.Printf("[LINE Webhook] Signature: %s", signature)
-	log.Printf("[LINE Webhook] Body: %s", string(body))
-
-	if !h.verifySignature(signature, body) {
-		log.Printf("[LINE Webhook] Invalid signature")
-		http.Error(w, "Invalid signature", http.StatusUnauthorized)
-		return
-	}
+// ExtractMessages reads the text, image and postback events of a webhook
+func (h *Handler) ExtractMessages(ctx context.Context, body []byte) ([]*messenger.Inbound, error) {
+	slog.DebugContext(ctx, "LINE webhook received", "body", string(body))
 
-	// Parse events
 	var event LineEvent
 	if err := json.Unmarshal(body, &event); err != nil {
-		http.Error(w, "Failed to parse event", http.StatusBadRequest)
-		return
+		return nil, fmt.Errorf("failed to parse event: %w", err)
 	}
 
-	ctx := context.Background()
-
-	// Process each event
+	var messages []*messenger.Inbound
 	for _, e := range event.Events {
-		if e.Type != "message" || e.Message.Type != "text" {
+		isPostback := e.Type == "postback"
+		if !isPostback && (e.Type != "message" || (e.Message.Type != "text" && e.Message.Type != "image")) {
+			continue
+		}
+		if e.Message.Type == "image" && h.client == nil {
 			continue
 		}
 
-		log.Printf("[LINE Webhook] Processing message event from user %s: %s", e.Source.UserID, e.Message.Text)
+		ctx := logging.WithUser(ctx, e.Source.UserID, "line")
+		if isPostback {
+			slog.InfoContext(ctx, "Received LINE postback event", "data", e.Postback.Data)
+		} else {
+			slog.InfoContext(ctx, "Received LINE message event", "type", e.Message.Type)
+		}
 
 		// Map to UserMessage
 		userMsg := &domain.UserMessage{
@@ -101,26 +154,44 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 				"reply_token": e.ReplyToken,
 			},
 		}
-
-		// Execute logic
-		resp, err := h.useCase.Execute(ctx, userMsg)
-		if err != nil {
-			log.Printf("[LINE Webhook] Error handling message: %v", err)
-			// Optionally send error message to user if appropriate
-			continue
+		if isPostback {
+			userMsg.Action, userMsg.Content = parsePostback(e.Postback.Data)
+		}
+		switch e.Source.Type {
+		case "group":
+			userMsg.GroupChatID = e.Source.GroupID
+		case "room":
+			userMsg.GroupChatID = e.Source.RoomID
 		}
 
-		// Send reply
-		if resp.Text != "" && h.client != nil {
-			if err := h.client.SendReply(ctx, e.ReplyToken, resp.Text); err != nil {
-				log.Printf("[LINE Webhook] Failed to send reply: %v", err)
-			} else {
-				log.Printf("[LINE Webhook] Reply sent successfully")
+		// Redelivered events keep their webhookEventId
+		in := &messenger.Inbound{EventID: e.WebhookEventID, Message: userMsg}
+		if in.EventID == "" {
+			in.EventID = e.Message.ID
+		}
+		if e.Message.Type == "image" {
+			messageID := e.Message.ID
+			in.Download = func(ctx context.Context) ([]*domain.Attachment, error) {
+				data, mimeType, err := h.client.GetMessageContent(ctx, messageID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to download image %s: %w", messageID, err)
+				}
+				return []*domain.Attachment{{Type: domain.AttachmentTypeImage, MimeType: mimeType, Data: data}}, nil
 			}
 		}
+		messages = append(messages, in)
 	}
+	return messages, nil
+}
 
-	w.WriteHeader(http.StatusOK)
+// parsePostback returns the quick action and its argument carried by postback
+// data such as "action=add_category&name=寵物"
+func parsePostback(data string) (string, string) {
+	values, err := url.ParseQuery(data)
+	if err != nil {
+		return "", ""
+	}
+	return values.Get("action"), values.Get("name")
 }
 
 // verifySignature verifies the LINE webhook signature
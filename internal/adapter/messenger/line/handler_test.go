@@ -143,3 +143,80 @@ func TestLineHandler_HandleWebhook_ExecuteError(t *testing.T) {
 
 	mockUC.AssertExpectations(t)
 }
+
+// recordingQueue keeps the messages handed to it, failing while err is set
+type recordingQueue struct {
+	messages []*domain.UserMessage
+	err      error
+}
+
+func (q *recordingQueue) Enqueue(ctx context.Context, msg *domain.UserMessage) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.messages = append(q.messages, msg)
+	return nil
+}
+
+func TestLineHandler_HandleWebhook_Queued(t *testing.T) {
+	mockUC := new(MockMessageProcessor)
+	handler := NewHandler("test_channel_secret", mockUC, nil)
+	queue := &recordingQueue{}
+	handler.SetQueue(queue)
+
+	payload, signature := createLineWebhookPayload("line_test_user", "breakfast $20")
+	req := httptest.NewRequest("POST", "/webhook/line", bytes.NewReader(payload))
+	req.Header.Set("X-Line-Signature", signature)
+	w := httptest.NewRecorder()
+	handler.HandleWebhook(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if len(queue.messages) != 1 || queue.messages[0].Content != "breakfast $20" || queue.messages[0].Metadata["reply_token"] != "test_reply_token_123" {
+		t.Fatalf("expected the message queued with its reply token, got %+v", queue.messages)
+	}
+	mockUC.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+
+	// A full queue falls back to processing the message inline
+	queue.err = fmt.Errorf("message queue is full")
+	mockUC.On("Execute", mock.Anything, mock.Anything).Return(&domain.MessageResponse{Text: "Saved"}, nil)
+	req = httptest.NewRequest("POST", "/webhook/line", bytes.NewReader(payload))
+	req.Header.Set("X-Line-Signature", signature)
+	handler.HandleWebhook(httptest.NewRecorder(), req)
+	mockUC.AssertNumberOfCalls(t, "Execute", 1)
+}
+
+func TestLineHandler_HandleWebhook_Postback(t *testing.T) {
+	mockUC := new(MockMessageProcessor)
+	handler := NewHandler("test_channel_secret", mockUC, nil)
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"events": []map[string]interface{}{
+			{
+				"type":           "postback",
+				"source":         map[string]string{"type": "user", "userId": "line_test_user"},
+				"postback":       map[string]string{"data": "action=add_category&name=寵物"},
+				"replyToken":     "test_reply_token_123",
+				"webhookEventId": "evt_postback",
+			},
+		},
+	})
+	hash := hmac.New(sha256.New, []byte("test_channel_secret"))
+	hash.Write(payload)
+	signature := base64.StdEncoding.EncodeToString(hash.Sum(nil))
+
+	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
+		return msg.Action == domain.MessageActionAddCategory && msg.Content == "寵物" && msg.UserID == "line_test_user"
+	})).Return(&domain.MessageResponse{Text: "✓ Added category '寵物'"}, nil)
+
+	req := httptest.NewRequest("POST", "/webhook/line", bytes.NewReader(payload))
+	req.Header.Set("X-Line-Signature", signature)
+	w := httptest.NewRecorder()
+	handler.HandleWebhook(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	mockUC.AssertNumberOfCalls(t, "Execute", 1)
+}
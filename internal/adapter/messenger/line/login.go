@@ -0,0 +1,104 @@
+package line
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.LoginVerifier = (*LoginVerifier)(nil)
+
+// LoginVerifier completes LINE Login for the dashboard: it exchanges the
+// authorization code for an ID token and has LINE verify the token. The login
+// channel must belong to the same provider as the Messaging API channel so the
+// user ID matches the one the bot sees.
+// See https://developers.line.biz/en/docs/line-login/integrate-line-login/
+type LoginVerifier struct {
+	channelID     string
+	channelSecret string
+	tokenURL      string
+	verifyURL     string
+	httpClient    *http.Client
+}
+
+// NewLoginVerifier creates a verifier for a LINE Login channel
+func NewLoginVerifier(channelID, channelSecret string) (*LoginVerifier, error) {
+	if channelID == "" || channelSecret == "" {
+		return nil, fmt.Errorf("LINE Login channel ID and secret are required")
+	}
+
+	return &LoginVerifier{
+		channelID:     channelID,
+		channelSecret: channelSecret,
+		tokenURL:      "https://api.line.me/oauth2/v2.1/token",
+		verifyURL:     "https://api.line.me/oauth2/v2.1/verify",
+		httpClient:    &http.Client{},
+	}, nil
+}
+
+// VerifyLogin takes the code and redirect_uri from the LINE Login callback and
+// returns the LINE user ID
+func (v *LoginVerifier) VerifyLogin(ctx context.Context, payload map[string]string) (string, error) {
+	if payload["code"] == "" || payload["redirect_uri"] == "" {
+		return "", fmt.Errorf("code and redirect_uri are required")
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := v.postForm(ctx, v.tokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {payload["code"]},
+		"redirect_uri":  {payload["redirect_uri"]},
+		"client_id":     {v.channelID},
+		"client_secret": {v.channelSecret},
+	}, &token); err != nil {
+		return "", fmt.Errorf("failed to exchange login code: %w", err)
+	}
+	if token.IDToken == "" {
+		return "", fmt.Errorf("LINE returned no ID token")
+	}
+
+	var claims struct {
+		Sub string `json:"sub"`
+	}
+	if err := v.postForm(ctx, v.verifyURL, url.Values{
+		"id_token":  {token.IDToken},
+		"client_id": {v.channelID},
+	}, &claims); err != nil {
+		return "", fmt.Errorf("failed to verify ID token: %w", err)
+	}
+	if claims.Sub == "" {
+		return "", fmt.Errorf("ID token has no user")
+	}
+	return claims.Sub, nil
+}
+
+func (v *LoginVerifier) postForm(ctx context.Context, endpoint string, form url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("LINE API error (status %d): %s %s", resp.StatusCode, apiErr.Error, apiErr.ErrorDescription)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
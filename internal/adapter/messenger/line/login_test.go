@@ -0,0 +1,47 @@
+package line
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoginVerifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/token":
+			if r.Form.Get("code") != "good-code" || r.Form.Get("client_secret") != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"id_token": "id-token"})
+		case "/verify":
+			if r.Form.Get("id_token") != "id-token" || r.Form.Get("client_id") != "1234" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"sub": "U1234567890"})
+		}
+	}))
+	defer server.Close()
+
+	verifier, err := NewLoginVerifier("1234", "secret")
+	if err != nil {
+		t.Fatalf("NewLoginVerifier failed: %v", err)
+	}
+	verifier.tokenURL = server.URL + "/token"
+	verifier.verifyURL = server.URL + "/verify"
+
+	userID, err := verifier.VerifyLogin(context.Background(), map[string]string{"code": "good-code", "redirect_uri": "https://dashboard.example/callback"})
+	if err != nil || userID != "U1234567890" {
+		t.Fatalf("expected U1234567890, got %q, %v", userID, err)
+	}
+
+	if _, err := verifier.VerifyLogin(context.Background(), map[string]string{"code": "bad-code", "redirect_uri": "https://dashboard.example/callback"}); err == nil {
+		t.Error("expected a rejected code to fail")
+	}
+}
@@ -0,0 +1,183 @@
+package line
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// DefaultRichMenuName names the quick action menu, so a restart finds the
+// menu it created earlier instead of creating another
+const DefaultRichMenuName = "aiexpense-quick-actions"
+
+// RichMenu is a menu shown below the LINE chat, whose areas act like buttons
+type RichMenu struct {
+	Size        RichMenuSize   `json:"size"`
+	Selected    bool           `json:"selected"` // Shown open by default
+	Name        string         `json:"name"`
+	ChatBarText string         `json:"chatBarText"`
+	Areas       []RichMenuArea `json:"areas"`
+}
+
+// RichMenuSize is the size of the menu image in pixels
+type RichMenuSize struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// RichMenuArea is a tappable region of the menu image
+type RichMenuArea struct {
+	Bounds RichMenuBounds `json:"bounds"`
+	Action RichMenuAction `json:"action"`
+}
+
+// RichMenuBounds locates an area on the menu image
+type RichMenuBounds struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// RichMenuAction is a postback action. Data is parsed by the webhook into the
+// message's quick action, e.g. "action=today_spending".
+type RichMenuAction struct {
+	Type        string `json:"type"`
+	Label       string `json:"label,omitempty"`
+	Data        string `json:"data"`
+	DisplayText string `json:"displayText,omitempty"`
+	InputOption string `json:"inputOption,omitempty"` // e.g. "openKeyboard"
+	FillInText  string `json:"fillInText,omitempty"`  // Prefilled keyboard text with openKeyboard
+}
+
+// DefaultRichMenu returns the quick action menu: 今日支出, 本月報表 and 新增分類
+// side by side on a 2500x843 image
+func DefaultRichMenu() *RichMenu {
+	const width, height = 2500, 843
+	actions := []RichMenuAction{
+		{Type: "postback", Label: "今日支出", Data: postbackData(domain.MessageActionTodaySpending), DisplayText: "今日支出"},
+		{Type: "postback", Label: "本月報表", Data: postbackData(domain.MessageActionMonthlyReport), DisplayText: "本月報表"},
+		// Opens the keyboard so the category name can be typed after the prefix
+		{Type: "postback", Label: "新增分類", Data: postbackData(domain.MessageActionAddCategory), InputOption: "openKeyboard", FillInText: "新增分類 "},
+	}
+
+	menu := &RichMenu{
+		Size:        RichMenuSize{Width: width, Height: height},
+		Selected:    true,
+		Name:        DefaultRichMenuName,
+		ChatBarText: "快速功能",
+	}
+	areaWidth := width / len(actions)
+	for i, action := range actions {
+		bounds := RichMenuBounds{X: i * areaWidth, Width: areaWidth, Height: height}
+		if i == len(actions)-1 {
+			bounds.Width = width - bounds.X
+		}
+		menu.Areas = append(menu.Areas, RichMenuArea{Bounds: bounds, Action: action})
+	}
+	return menu
+}
+
+// postbackData encodes a quick action as postback data, see parsePostback
+func postbackData(action string) string {
+	return url.Values{"action": {action}}.Encode()
+}
+
+// EnsureRichMenu makes the menu the default one for every user, creating it
+// and uploading its image unless a menu with the same name already exists.
+// It returns the rich menu ID.
+func (c *Client) EnsureRichMenu(ctx context.Context, menu *RichMenu, image []byte, contentType string) (string, error) {
+	menuID, err := c.findRichMenu(ctx, menu.Name)
+	if err != nil {
+		return "", err
+	}
+
+	if menuID == "" {
+		payload, err := json.Marshal(menu)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal rich menu: %w", err)
+		}
+		body, err := c.doBotRequest(ctx, http.MethodPost, c.botAPIURL+"/richmenu", "application/json", payload)
+		if err != nil {
+			return "", fmt.Errorf("failed to create rich menu: %w", err)
+		}
+		var created struct {
+			RichMenuID string `json:"richMenuId"`
+		}
+		if err := json.Unmarshal(body, &created); err != nil || created.RichMenuID == "" {
+			return "", fmt.Errorf("unexpected create rich menu response: %s", string(body))
+		}
+		menuID = created.RichMenuID
+
+		if _, err := c.doBotRequest(ctx, http.MethodPost, fmt.Sprintf("%s/richmenu/%s/content", c.dataBotURL, menuID), contentType, image); err != nil {
+			return "", fmt.Errorf("failed to upload rich menu image: %w", err)
+		}
+		slog.InfoContext(ctx, "LINE rich menu created", "rich_menu_id", menuID, "name", menu.Name)
+	}
+
+	if _, err := c.doBotRequest(ctx, http.MethodPost, fmt.Sprintf("%s/user/all/richmenu/%s", c.botAPIURL, menuID), "", nil); err != nil {
+		return "", fmt.Errorf("failed to set default rich menu: %w", err)
+	}
+	return menuID, nil
+}
+
+// findRichMenu returns the ID of the channel's rich menu with the given name, or ""
+func (c *Client) findRichMenu(ctx context.Context, name string) (string, error) {
+	body, err := c.doBotRequest(ctx, http.MethodGet, c.botAPIURL+"/richmenu/list", "", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to list rich menus: %w", err)
+	}
+	var list struct {
+		RichMenus []struct {
+			RichMenuID string `json:"richMenuId"`
+			Name       string `json:"name"`
+		} `json:"richmenus"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return "", fmt.Errorf("failed to decode rich menu list: %w", err)
+	}
+	for _, m := range list.RichMenus {
+		if m.Name == name {
+			return m.RichMenuID, nil
+		}
+	}
+	return "", nil
+}
+
+// doBotRequest sends a Messaging API request and returns the response body
+func (c *Client) doBotRequest(ctx context.Context, method, endpoint, contentType string, payload []byte) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.channelToken))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("line api error: status %d, body: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,76 @@
+package line
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_EnsureRichMenu(t *testing.T) {
+	var existing []map[string]string
+	var calls []string
+	var uploaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/bot/richmenu/list":
+			json.NewEncoder(w).Encode(map[string]interface{}{"richmenus": existing})
+		case "/bot/richmenu":
+			var menu RichMenu
+			json.NewDecoder(r.Body).Decode(&menu)
+			existing = append(existing, map[string]string{"richMenuId": "richmenu-1", "name": menu.Name})
+			json.NewEncoder(w).Encode(map[string]string{"richMenuId": "richmenu-1"})
+		case "/data/richmenu/richmenu-1/content":
+			body, _ := io.ReadAll(r.Body)
+			uploaded = r.Header.Get("Content-Type") + ":" + string(body)
+			w.Write([]byte("{}"))
+		case "/bot/user/all/richmenu/richmenu-1":
+			w.Write([]byte("{}"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("token")
+	client.botAPIURL = server.URL + "/bot"
+	client.dataBotURL = server.URL + "/data"
+
+	menuID, err := client.EnsureRichMenu(t.Context(), DefaultRichMenu(), []byte("png"), "image/png")
+	if err != nil || menuID != "richmenu-1" {
+		t.Fatalf("EnsureRichMenu = %q, %v", menuID, err)
+	}
+	if uploaded != "image/png:png" {
+		t.Errorf("expected the image uploaded, got %q", uploaded)
+	}
+
+	// A restart links the existing menu again without creating another
+	calls = nil
+	if menuID, err := client.EnsureRichMenu(t.Context(), DefaultRichMenu(), []byte("png"), "image/png"); err != nil || menuID != "richmenu-1" {
+		t.Fatalf("EnsureRichMenu = %q, %v", menuID, err)
+	}
+	if got := strings.Join(calls, ", "); got != "GET /bot/richmenu/list, POST /bot/user/all/richmenu/richmenu-1" {
+		t.Errorf("unexpected calls for an existing menu: %s", got)
+	}
+}
+
+func TestDefaultRichMenu(t *testing.T) {
+	menu := DefaultRichMenu()
+	if len(menu.Areas) != 3 {
+		t.Fatalf("expected three quick actions, got %d", len(menu.Areas))
+	}
+	last := menu.Areas[2].Bounds
+	if last.X+last.Width != menu.Size.Width {
+		t.Errorf("expected the areas to cover the menu width, last area ends at %d", last.X+last.Width)
+	}
+	if action, name := parsePostback(menu.Areas[0].Action.Data); action != "today_spending" || name != "" {
+		t.Errorf("unexpected postback for the first area: %q %q", action, name)
+	}
+}
@@ -0,0 +1,247 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.PushNotifier = (*Client)(nil)
+
+// maxMediaSize caps the photos and voice messages downloaded from the homeserver
+const maxMediaSize = 20 << 20
+
+// Client calls the homeserver's client-server API as the application
+// service's bot user
+type Client struct {
+	homeserverURL string
+	asToken       string
+	botUserID     string
+	httpClient    *http.Client
+}
+
+// NewClient creates a new Matrix client; asToken is the application service
+// token from its registration file and botUserID the bot's Matrix ID, e.g.
+// "@expense:example.org"
+func NewClient(homeserverURL, asToken, botUserID string) (*Client, error) {
+	if homeserverURL == "" || asToken == "" || botUserID == "" {
+		return nil, fmt.Errorf("matrix homeserver URL, as_token and bot user ID are required")
+	}
+
+	return &Client{
+		homeserverURL: strings.TrimSuffix(homeserverURL, "/"),
+		asToken:       asToken,
+		botUserID:     botUserID,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// APIError is an error returned by the homeserver
+type APIError struct {
+	Status  int    `json:"-"`
+	ErrCode string `json:"errcode"`
+	Message string `json:"error"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("matrix api error: %s %s (status %d)", e.ErrCode, e.Message, e.Status)
+}
+
+// MessageContent is the content of an m.room.message event
+type MessageContent struct {
+	MsgType string `json:"msgtype"`
+	Body    string `json:"body"`
+}
+
+// SendMessage sends the text to a room as a notice, the message type bots
+// use so other bots don't answer them
+func (c *Client) SendMessage(ctx context.Context, roomID, text string) error {
+	if roomID == "" || text == "" {
+		return fmt.Errorf("room_id and text are required")
+	}
+	path := fmt.Sprintf("/_matrix/client/v3/rooms/%s/send/m.room.message/%s", url.PathEscape(roomID), uuid.New().String())
+	if err := c.do(ctx, "PUT", path, &MessageContent{MsgType: "m.notice", Body: text}, nil); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	slog.DebugContext(ctx, "Matrix message sent", "room_id", roomID)
+	return nil
+}
+
+// PushMessage sends a message to a user identified by their app user ID
+// ("matrix_<mxid>"), in their direct chat with the bot, which is created if
+// they have none
+func (c *Client) PushMessage(ctx context.Context, userID, text string) error {
+	mxid := strings.TrimPrefix(userID, "matrix_")
+	if !strings.HasPrefix(mxid, "@") {
+		return fmt.Errorf("invalid matrix user id %q", userID)
+	}
+	roomID, err := c.directRoom(ctx, mxid)
+	if err != nil {
+		return err
+	}
+	return c.SendMessage(ctx, roomID, text)
+}
+
+// JoinRoom accepts an invite to a room
+func (c *Client) JoinRoom(ctx context.Context, roomID string) error {
+	if err := c.do(ctx, "POST", "/_matrix/client/v3/join/"+url.PathEscape(roomID), struct{}{}, nil); err != nil {
+		return fmt.Errorf("failed to join room: %w", err)
+	}
+	return nil
+}
+
+// DownloadMedia fetches an mxc:// content URI from the homeserver's media repository
+func (c *Client) DownloadMedia(ctx context.Context, mxcURI string) ([]byte, string, error) {
+	serverName, mediaID, ok := strings.Cut(strings.TrimPrefix(mxcURI, "mxc://"), "/")
+	if !strings.HasPrefix(mxcURI, "mxc://") || !ok || serverName == "" || mediaID == "" {
+		return nil, "", fmt.Errorf("invalid content URI %q", mxcURI)
+	}
+
+	path := fmt.Sprintf("/_matrix/client/v1/media/download/%s/%s", url.PathEscape(serverName), url.PathEscape(mediaID))
+	req, err := http.NewRequestWithContext(ctx, "GET", c.homeserverURL+path, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.asToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download media: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", apiError(resp)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxMediaSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read media: %w", err)
+	}
+	if len(data) > maxMediaSize {
+		return nil, "", fmt.Errorf("media larger than %d bytes", maxMediaSize)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
+
+// AddDirectRoom records the room as the bot's direct chat with the user, in
+// the bot's m.direct account data, where PushMessage finds it
+func (c *Client) AddDirectRoom(ctx context.Context, mxid, roomID string) error {
+	direct, err := c.directRooms(ctx)
+	if err != nil {
+		return err
+	}
+	for _, id := range direct[mxid] {
+		if id == roomID {
+			return nil
+		}
+	}
+	direct[mxid] = append(direct[mxid], roomID)
+	if err := c.do(ctx, "PUT", c.directPath(), direct, nil); err != nil {
+		return fmt.Errorf("failed to save direct rooms: %w", err)
+	}
+	return nil
+}
+
+// directRoom returns the latest direct chat with the user, creating one and
+// inviting them if there is none
+func (c *Client) directRoom(ctx context.Context, mxid string) (string, error) {
+	direct, err := c.directRooms(ctx)
+	if err != nil {
+		return "", err
+	}
+	if rooms := direct[mxid]; len(rooms) > 0 {
+		return rooms[len(rooms)-1], nil
+	}
+
+	var created struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := c.do(ctx, "POST", "/_matrix/client/v3/createRoom", map[string]interface{}{
+		"preset":    "trusted_private_chat",
+		"is_direct": true,
+		"invite":    []string{mxid},
+	}, &created); err != nil {
+		return "", fmt.Errorf("failed to create direct room: %w", err)
+	}
+	if err := c.AddDirectRoom(ctx, mxid, created.RoomID); err != nil {
+		slog.WarnContext(ctx, "Failed to record Matrix direct room", "room_id", created.RoomID, "error", err)
+	}
+	return created.RoomID, nil
+}
+
+// directRooms reads the bot's m.direct account data, a map of user IDs to
+// their direct rooms
+func (c *Client) directRooms(ctx context.Context) (map[string][]string, error) {
+	direct := make(map[string][]string)
+	err := c.do(ctx, "GET", c.directPath(), nil, &direct)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.ErrCode == "M_NOT_FOUND" {
+		// The bot has no direct rooms yet
+		return make(map[string][]string), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get direct rooms: %w", err)
+	}
+	return direct, nil
+}
+
+func (c *Client) directPath() string {
+	return fmt.Sprintf("/_matrix/client/v3/user/%s/account_data/m.direct", url.PathEscape(c.botUserID))
+}
+
+// do sends a JSON request to the client-server API, decoding the response into out
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.homeserverURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.asToken)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return apiError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// apiError reads the homeserver's error from a failed response
+func apiError(resp *http.Response) error {
+	apiErr := &APIError{Status: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	json.Unmarshal(body, apiErr)
+	return apiErr
+}
@@ -0,0 +1,253 @@
+package matrix
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/adapter/messenger"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// MessageProcessor defines the interface for processing messages
+type MessageProcessor = messenger.MessageProcessor
+
+// Handler handles the transactions a Matrix homeserver pushes to the bot's
+// application service
+type Handler struct {
+	*messenger.Webhook
+	hsToken   string
+	botUserID string
+	client    *Client
+}
+
+// NewHandler creates a new Matrix application service handler; hsToken is
+// the token the homeserver authenticates with, from the registration file
+func NewHandler(hsToken, botUserID string, useCase MessageProcessor, client *Client) *Handler {
+	h := &Handler{
+		hsToken:   hsToken,
+		botUserID: botUserID,
+		client:    client,
+	}
+	h.Webhook = messenger.NewWebhook("matrix", h, useCase)
+	return h
+}
+
+// Reply answers a queued message in the room it was sent in
+func (h *Handler) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	return h.SendReply(ctx, msg, resp)
+}
+
+// SendReply answers a message in the room it was sent in
+func (h *Handler) SendReply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	if h.client == nil {
+		return nil
+	}
+	roomID, _ := msg.Metadata["room_id"].(string)
+	return h.client.SendMessage(ctx, roomID, resp.Text)
+}
+
+// SendPush sends a message in the user's direct chat with the bot
+func (h *Handler) SendPush(ctx context.Context, userID, text string) error {
+	if h.client == nil {
+		return fmt.Errorf("matrix client not configured")
+	}
+	return h.client.PushMessage(ctx, userID, text)
+}
+
+// Transaction is a batch of events pushed by the homeserver
+type Transaction struct {
+	Events []Event `json:"events"`
+}
+
+// Event is a room event
+type Event struct {
+	Type           string       `json:"type"`
+	EventID        string       `json:"event_id"`
+	RoomID         string       `json:"room_id"`
+	Sender         string       `json:"sender"`
+	StateKey       *string      `json:"state_key,omitempty"`
+	OriginServerTS int64        `json:"origin_server_ts"`
+	Content        EventContent `json:"content"`
+}
+
+// EventContent holds the fields of m.room.message and m.room.member contents
+// the bot reads
+type EventContent struct {
+	MsgType    string     `json:"msgtype"`
+	Body       string     `json:"body"`
+	URL        string     `json:"url"`
+	Info       *MediaInfo `json:"info,omitempty"`
+	RelatesTo  *RelatesTo `json:"m.relates_to,omitempty"`
+	Membership string     `json:"membership"`
+	IsDirect   bool       `json:"is_direct"`
+}
+
+// MediaInfo describes an image or audio file
+type MediaInfo struct {
+	MimeType string `json:"mimetype"`
+}
+
+// RelatesTo relates an event to another, e.g. an edit ("m.replace")
+type RelatesTo struct {
+	RelType string `json:"rel_type"`
+	EventID string `json:"event_id"`
+}
+
+// HandleTransaction handles PUT /_matrix/app/v1/transactions/{txnId} under
+// the URL registered for the application service. Homeservers resend a
+// transaction with the same ID until it is acknowledged, so retries are
+// skipped as a whole.
+func (h *Handler) HandleTransaction(w http.ResponseWriter, r *http.Request) {
+	if err := h.VerifyRequest(r, nil); err != nil {
+		slog.WarnContext(r.Context(), "Matrix transaction with a wrong hs_token")
+		writeError(w, http.StatusForbidden, "M_FORBIDDEN", "Bad hs_token")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "M_NOT_JSON", "Failed to read transaction")
+		return
+	}
+	ctx := context.WithoutCancel(r.Context())
+	messages, err := h.ExtractMessages(ctx, body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "M_NOT_JSON", "Failed to parse transaction")
+		return
+	}
+
+	if !h.IsDuplicate(ctx, r.PathValue("txnId")) {
+		for _, in := range messages {
+			h.Dispatch(ctx, in)
+		}
+	}
+	h.Acknowledge(w, body)
+}
+
+// VerifyRequest checks the hs_token, sent as a bearer token or, by
+// homeservers predating Matrix 1.4, as the access_token parameter
+func (h *Handler) VerifyRequest(r *http.Request, body []byte) error {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("access_token")
+	}
+	if h.hsToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.hsToken)) != 1 {
+		return messenger.ErrInvalidSignature
+	}
+	return nil
+}
+
+// ExtractMessages reads the messages of a transaction, and joins the rooms
+// the bot is invited to
+func (h *Handler) ExtractMessages(ctx context.Context, body []byte) ([]*messenger.Inbound, error) {
+	var txn Transaction
+	if err := json.Unmarshal(body, &txn); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction: %w", err)
+	}
+
+	var messages []*messenger.Inbound
+	for i := range txn.Events {
+		if in := h.handleEvent(ctx, &txn.Events[i]); in != nil {
+			messages = append(messages, in)
+		}
+	}
+	return messages, nil
+}
+
+// Acknowledge answers the transaction with an empty object
+func (h *Handler) Acknowledge(w http.ResponseWriter, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("{}"))
+}
+
+// handleEvent joins the room the bot is invited to, or maps a message sent in
+// one; the homeserver waits for the transaction's answer, so both happen
+// afterwards
+func (h *Handler) handleEvent(ctx context.Context, event *Event) *messenger.Inbound {
+	if event.Sender == h.botUserID {
+		return nil
+	}
+
+	switch event.Type {
+	case "m.room.member":
+		if event.StateKey == nil || *event.StateKey != h.botUserID || event.Content.Membership != "invite" || h.client == nil {
+			return nil
+		}
+		go func() {
+			if err := h.client.JoinRoom(ctx, event.RoomID); err != nil {
+				slog.WarnContext(ctx, "Failed to join Matrix room", "room_id", event.RoomID, "error", err)
+				return
+			}
+			slog.InfoContext(ctx, "Joined Matrix room", "room_id", event.RoomID, "inviter", event.Sender)
+			if event.Content.IsDirect {
+				if err := h.client.AddDirectRoom(ctx, event.Sender, event.RoomID); err != nil {
+					slog.WarnContext(ctx, "Failed to record Matrix direct room", "room_id", event.RoomID, "error", err)
+				}
+			}
+		}()
+
+	case "m.room.message":
+		// Edits repeat the message they replace, and notices come from other bots
+		if event.Content.RelatesTo != nil && event.Content.RelatesTo.RelType == "m.replace" {
+			return nil
+		}
+		content := event.Content
+		if content.MsgType != "m.text" && content.MsgType != "m.image" && content.MsgType != "m.audio" {
+			return nil
+		}
+
+		in := &messenger.Inbound{Message: &domain.UserMessage{
+			UserID:    "matrix_" + event.Sender,
+			Content:   content.Body,
+			Source:    "matrix",
+			Timestamp: time.UnixMilli(event.OriginServerTS),
+			Metadata: map[string]interface{}{
+				"room_id":  event.RoomID,
+				"event_id": event.EventID,
+			},
+		}}
+		if content.MsgType == "m.image" || content.MsgType == "m.audio" {
+			if h.client == nil {
+				return nil
+			}
+			// The body of a media message is its file name
+			in.Message.Content = ""
+			in.Download = func(ctx context.Context) ([]*domain.Attachment, error) {
+				return h.downloadMedia(ctx, &content)
+			}
+		}
+		return in
+	}
+	return nil
+}
+
+// downloadMedia fetches the photo or voice note of a message
+func (h *Handler) downloadMedia(ctx context.Context, content *EventContent) ([]*domain.Attachment, error) {
+	data, mimeType, err := h.client.DownloadMedia(ctx, content.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", content.URL, err)
+	}
+	if content.Info != nil && content.Info.MimeType != "" {
+		mimeType = content.Info.MimeType
+	}
+	attachmentType := domain.AttachmentTypeImage
+	if content.MsgType == "m.audio" {
+		attachmentType = domain.AttachmentTypeAudio
+	}
+	return []*domain.Attachment{{Type: attachmentType, MimeType: mimeType, Data: data}}, nil
+}
+
+// writeError answers with a Matrix error
+func writeError(w http.ResponseWriter, status int, errCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"errcode": errCode, "error": message})
+}
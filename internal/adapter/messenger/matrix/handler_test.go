@@ -0,0 +1,253 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMessageProcessor for testing
+type MockMessageProcessor struct {
+	mock.Mock
+}
+
+func (m *MockMessageProcessor) Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error) {
+	args := m.Called(ctx, msg)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.MessageResponse), args.Error(1)
+}
+
+// homeserver is a fake homeserver recording the client-server API calls
+type homeserver struct {
+	mu     sync.Mutex
+	calls  []string
+	bodies map[string]string
+	direct string
+	server *httptest.Server
+}
+
+func newHomeserver(t *testing.T) *homeserver {
+	hs := &homeserver{bodies: make(map[string]string)}
+	hs.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer as_token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body bytes.Buffer
+		body.ReadFrom(r.Body)
+		call := r.Method + " " + r.URL.Path
+
+		hs.mu.Lock()
+		defer hs.mu.Unlock()
+		hs.calls = append(hs.calls, call)
+		hs.bodies[call] = body.String()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/account_data/m.direct") && r.Method == "GET":
+			if hs.direct == "" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errcode":"M_NOT_FOUND","error":"Account data not found"}`))
+				return
+			}
+			w.Write([]byte(hs.direct))
+		case strings.HasSuffix(r.URL.Path, "/account_data/m.direct"):
+			hs.direct = body.String()
+			w.Write([]byte(`{}`))
+		case r.URL.Path == "/_matrix/client/v3/createRoom":
+			w.Write([]byte(`{"room_id":"!dm:example.org"}`))
+		case strings.HasPrefix(r.URL.Path, "/_matrix/client/v1/media/download/"):
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("jpeg bytes"))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(hs.server.Close)
+	return hs
+}
+
+// waitForCall waits for the handler's goroutines to make a call starting with prefix
+func (hs *homeserver) waitForCall(t *testing.T, prefix string) string {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		hs.mu.Lock()
+		for _, call := range hs.calls {
+			if strings.HasPrefix(call, prefix) {
+				hs.mu.Unlock()
+				return call
+			}
+		}
+		hs.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected a call to %s, got %v", prefix, hs.calls)
+	return ""
+}
+
+func transaction(t *testing.T, handler *Handler, txnID, token string, events ...Event) *httptest.ResponseRecorder {
+	t.Helper()
+	body, _ := json.Marshal(Transaction{Events: events})
+	req := httptest.NewRequest("PUT", "/webhook/matrix/_matrix/app/v1/transactions/"+txnID, bytes.NewReader(body))
+	req.SetPathValue("txnId", txnID)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	handler.HandleTransaction(w, req)
+	return w
+}
+
+func TestMatrixHandler_HandleTransaction(t *testing.T) {
+	hs := newHomeserver(t)
+	client, err := NewClient(hs.server.URL, "as_token", "@expense:example.org")
+	require.NoError(t, err)
+
+	mockUC := new(MockMessageProcessor)
+	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
+		return msg.UserID == "matrix_@alice:example.org" && msg.Content == "breakfast $20" && msg.Source == "matrix"
+	})).Return(&domain.MessageResponse{Text: "Saved"}, nil).Once()
+	handler := NewHandler("hs_token", "@expense:example.org", mockUC, client)
+
+	message := Event{
+		Type:           "m.room.message",
+		EventID:        "$event1",
+		RoomID:         "!room:example.org",
+		Sender:         "@alice:example.org",
+		OriginServerTS: 1700000000000,
+		Content:        EventContent{MsgType: "m.text", Body: "breakfast $20"},
+	}
+	ownNotice := Event{Type: "m.room.message", RoomID: "!room:example.org", Sender: "@expense:example.org", Content: EventContent{MsgType: "m.notice", Body: "Saved"}}
+
+	w := transaction(t, handler, "txn1", "hs_token", message, ownNotice)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{}`, w.Body.String())
+
+	call := hs.waitForCall(t, "PUT /_matrix/client/v3/rooms/!room:example.org/send/m.room.message/")
+	hs.mu.Lock()
+	assert.JSONEq(t, `{"msgtype":"m.notice","body":"Saved"}`, hs.bodies[call])
+	hs.mu.Unlock()
+	mockUC.AssertExpectations(t)
+}
+
+func TestMatrixHandler_HandleTransaction_Rejected(t *testing.T) {
+	handler := NewHandler("hs_token", "@expense:example.org", new(MockMessageProcessor), nil)
+
+	w := transaction(t, handler, "txn1", "wrong_token")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "M_FORBIDDEN")
+
+	// Homeservers before Matrix 1.4 send the token as a parameter
+	req := httptest.NewRequest("PUT", "/webhook/matrix/_matrix/app/v1/transactions/txn2?access_token=hs_token", strings.NewReader(`{"events":[]}`))
+	w = httptest.NewRecorder()
+	handler.HandleTransaction(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMatrixHandler_HandleTransaction_Invite(t *testing.T) {
+	hs := newHomeserver(t)
+	client, _ := NewClient(hs.server.URL, "as_token", "@expense:example.org")
+	handler := NewHandler("hs_token", "@expense:example.org", new(MockMessageProcessor), client)
+
+	botUserID := "@expense:example.org"
+	w := transaction(t, handler, "txn1", "hs_token", Event{
+		Type:     "m.room.member",
+		RoomID:   "!dm:example.org",
+		Sender:   "@alice:example.org",
+		StateKey: &botUserID,
+		Content:  EventContent{Membership: "invite", IsDirect: true},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	hs.waitForCall(t, "POST /_matrix/client/v3/join/!dm:example.org")
+	hs.waitForCall(t, "PUT /_matrix/client/v3/user/@expense:example.org/account_data/m.direct")
+	hs.mu.Lock()
+	assert.JSONEq(t, `{"@alice:example.org":["!dm:example.org"]}`, hs.direct)
+	hs.mu.Unlock()
+}
+
+func TestMatrixHandler_HandleTransaction_Image(t *testing.T) {
+	hs := newHomeserver(t)
+	client, _ := NewClient(hs.server.URL, "as_token", "@expense:example.org")
+
+	mockUC := new(MockMessageProcessor)
+	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
+		image := msg.FirstAttachment(domain.AttachmentTypeImage)
+		return msg.Content == "" && image != nil && string(image.Data) == "jpeg bytes" && image.MimeType == "image/jpeg"
+	})).Return(&domain.MessageResponse{Text: "Saved"}, nil).Once()
+	handler := NewHandler("hs_token", "@expense:example.org", mockUC, client)
+
+	transaction(t, handler, "txn1", "hs_token", Event{
+		Type:    "m.room.message",
+		RoomID:  "!room:example.org",
+		Sender:  "@alice:example.org",
+		Content: EventContent{MsgType: "m.image", Body: "receipt.jpg", URL: "mxc://example.org/abc123"},
+	})
+
+	hs.waitForCall(t, "GET /_matrix/client/v1/media/download/example.org/abc123")
+	hs.waitForCall(t, "PUT /_matrix/client/v3/rooms/!room:example.org/send/")
+	mockUC.AssertExpectations(t)
+}
+
+func TestMatrixHandler_HandleTransaction_Duplicate(t *testing.T) {
+	mockUC := new(MockMessageProcessor)
+	mockUC.On("Execute", mock.Anything, mock.Anything).Return(&domain.MessageResponse{}, nil).Once()
+	handler := NewHandler("hs_token", "@expense:example.org", mockUC, nil)
+	handler.SetDeduplicator(&seenDeduplicator{seen: make(map[string]bool)})
+
+	message := Event{Type: "m.room.message", RoomID: "!room:example.org", Sender: "@alice:example.org", Content: EventContent{MsgType: "m.text", Body: "lunch 120"}}
+	edit := message
+	edit.Content.RelatesTo = &RelatesTo{RelType: "m.replace", EventID: "$event1"}
+	transaction(t, handler, "txn1", "hs_token", message, edit)
+	transaction(t, handler, "txn1", "hs_token", message)
+
+	time.Sleep(100 * time.Millisecond)
+	mockUC.AssertExpectations(t)
+}
+
+// seenDeduplicator remembers the IDs it has seen
+type seenDeduplicator struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (d *seenDeduplicator) Seen(ctx context.Context, source, eventID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := source + ":" + eventID
+	if d.seen[key] {
+		return true
+	}
+	d.seen[key] = true
+	return false
+}
+
+func TestClient_PushMessage(t *testing.T) {
+	hs := newHomeserver(t)
+	client, _ := NewClient(hs.server.URL, "as_token", "@expense:example.org")
+
+	// Without a direct room, one is created and remembered
+	require.NoError(t, client.PushMessage(context.Background(), "matrix_@alice:example.org", "🚨 Food budget exceeded"))
+	hs.waitForCall(t, "POST /_matrix/client/v3/createRoom")
+	hs.waitForCall(t, "PUT /_matrix/client/v3/rooms/!dm:example.org/send/m.room.message/")
+	hs.mu.Lock()
+	assert.JSONEq(t, `{"preset":"trusted_private_chat","is_direct":true,"invite":["@alice:example.org"]}`, hs.bodies["POST /_matrix/client/v3/createRoom"])
+	hs.direct = `{"@alice:example.org":["!old:example.org","!latest:example.org"]}`
+	hs.mu.Unlock()
+
+	require.NoError(t, client.PushMessage(context.Background(), "matrix_@alice:example.org", "🚨 Food budget exceeded"))
+	hs.waitForCall(t, "PUT /_matrix/client/v3/rooms/!latest:example.org/send/m.room.message/")
+
+	assert.Error(t, client.PushMessage(context.Background(), "telegram_42", "hi"))
+}
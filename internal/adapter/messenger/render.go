@@ -0,0 +1,70 @@
+package messenger
+
+import "strings"
+
+// TextTable lays a table block out as monospaced text, for messengers that
+// show tables in a code block. Columns are padded to the widest cell, with
+// CJK characters and emoji counted as two columns wide.
+func TextTable(columns []string, rows [][]string) string {
+	widths := make([]int, len(columns))
+	for _, row := range append([][]string{columns}, rows...) {
+		for i, cell := range row {
+			if i < len(widths) {
+				widths[i] = max(widths[i], displayWidth(cell))
+			}
+		}
+	}
+
+	var sb strings.Builder
+	writeRow := func(row []string) {
+		var line strings.Builder
+		for i, width := range widths {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
+			}
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(cell)
+			line.WriteString(strings.Repeat(" ", width-displayWidth(cell)))
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " "))
+		sb.WriteString("\n")
+	}
+	writeRow(columns)
+	for _, row := range rows {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// displayWidth is the number of columns text takes up in a monospaced font
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		switch {
+		case r == 0xFE0F || r == 0x200D:
+			// Variation selectors and joiners are part of the previous character
+		case isWide(r):
+			width += 2
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// isWide reports whether the character is double width: CJK characters,
+// full-width forms and emoji
+func isWide(r rune) bool {
+	return r >= 0x1100 && (r <= 0x115F ||
+		(r >= 0x2E80 && r <= 0xA4CF) ||
+		(r >= 0xAC00 && r <= 0xD7A3) ||
+		(r >= 0xF900 && r <= 0xFAFF) ||
+		(r >= 0xFE30 && r <= 0xFE4F) ||
+		(r >= 0xFF00 && r <= 0xFF60) ||
+		(r >= 0xFFE0 && r <= 0xFFE6) ||
+		(r >= 0x1F300 && r <= 0x1FAFF) ||
+		(r >= 0x2600 && r <= 0x27BF))
+}
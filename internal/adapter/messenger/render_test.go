@@ -0,0 +1,17 @@
+package messenger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextTable(t *testing.T) {
+	table := TextTable([]string{"Category", "Spent", "Used"}, [][]string{
+		{"Food", "1200", "25%"},
+		{"交通", "620", "124% ⚠️"},
+	})
+	assert.Equal(t, "Category  Spent  Used\n"+
+		"Food      1200   25%\n"+
+		"交通      620    124% ⚠️", table)
+}
@@ -0,0 +1,195 @@
+package slack
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/adapter/messenger"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Block Kit limits: https://api.slack.com/reference/block-kit/blocks
+const (
+	maxSectionText    = 3000
+	maxButtonText     = 75
+	maxActionElements = 25
+	maxCardExpenses   = 20 // Two blocks each, under the 50 blocks a message can have
+	maxSectionFields  = 10
+)
+
+// openLinkActionID marks URL buttons; Slack reports their clicks, which need no answer
+const openLinkActionID = "open_link"
+
+// Block is a Block Kit layout block
+type Block struct {
+	Type     string        `json:"type"`
+	Text     *TextObject   `json:"text,omitempty"`
+	Fields   []*TextObject `json:"fields,omitempty"`
+	Elements []interface{} `json:"elements,omitempty"`
+	Title    *TextObject   `json:"title,omitempty"`     // Image blocks
+	ImageURL string        `json:"image_url,omitempty"` // Image blocks
+	AltText  string        `json:"alt_text,omitempty"`  // Image blocks
+}
+
+// TextObject is Block Kit text, "mrkdwn" or "plain_text"
+type TextObject struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// ButtonElement is a Block Kit button. Clicks are sent to the interactivity
+// endpoint with ActionID and Value, except for URL buttons.
+type ButtonElement struct {
+	Type     string      `json:"type"`
+	Text     *TextObject `json:"text"`
+	ActionID string      `json:"action_id"`
+	Value    string      `json:"value,omitempty"`
+	URL      string      `json:"url,omitempty"`
+	Style    string      `json:"style,omitempty"` // "primary" or "danger"
+}
+
+// buildBlocks lays the response out as a Block Kit card: the text, or the
+// response's content blocks in its place, a row of change category and delete
+// buttons for each recorded expense, and the response's own buttons. It
+// returns nil when plain text says it all.
+func buildBlocks(resp *domain.MessageResponse) []Block {
+	expenses, _ := resp.Data.([]map[string]interface{})
+	if len(expenses) == 0 && len(resp.Buttons) == 0 && len(resp.Blocks) == 0 {
+		return nil
+	}
+
+	blocks := contentBlocks(resp.Blocks)
+	if len(blocks) == 0 {
+		blocks = []Block{sectionBlock(resp.Text)}
+	}
+
+	if len(expenses) > 0 {
+		blocks = append(blocks, Block{Type: "divider"})
+	}
+	for i, expense := range expenses {
+		if i == maxCardExpenses {
+			break
+		}
+		id, _ := expense["id"].(string)
+		if id == "" {
+			continue
+		}
+		blocks = append(blocks, sectionBlock(expenseLine(expense)), Block{
+			Type: "actions",
+			Elements: []interface{}{
+				button("Change category", domain.MessageActionChangeCategory, id, ""),
+				button("Delete", domain.MessageActionDeleteExpense, id, "danger"),
+			},
+		})
+	}
+
+	var elements []interface{}
+	for _, b := range resp.Buttons {
+		if b.URL != "" {
+			element := button(b.Label, openLinkActionID, "", "primary")
+			element.URL = b.URL
+			elements = append(elements, element)
+		} else {
+			elements = append(elements, button(b.Label, b.Action, b.Value, ""))
+		}
+	}
+	for len(elements) > 0 {
+		n := min(len(elements), maxActionElements)
+		blocks = append(blocks, Block{Type: "actions", Elements: elements[:n]})
+		elements = elements[n:]
+	}
+
+	return blocks
+}
+
+// contentBlocks renders the response's content blocks: cards as sections
+// with their fields side by side, tables in a code block and charts as images
+func contentBlocks(content []*domain.ContentBlock) []Block {
+	var blocks []Block
+	for _, c := range content {
+		switch c.Type {
+		case domain.ContentBlockCard:
+			text := c.Text
+			if c.Title != "" {
+				text = strings.TrimSuffix("*"+c.Title+"*\n"+text, "\n")
+			}
+			if text != "" {
+				blocks = append(blocks, sectionBlock(text))
+			}
+			for fields := c.Fields; len(fields) > 0; {
+				n := min(len(fields), maxSectionFields)
+				section := Block{Type: "section"}
+				for _, f := range fields[:n] {
+					section.Fields = append(section.Fields, &TextObject{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", f.Label, f.Value)})
+				}
+				blocks = append(blocks, section)
+				fields = fields[n:]
+			}
+
+		case domain.ContentBlockTable:
+			text := "```" + messenger.TextTable(c.Columns, c.Rows) + "```"
+			if c.Title != "" {
+				text = "*" + c.Title + "*\n" + text
+			}
+			blocks = append(blocks, sectionBlock(text))
+
+		case domain.ContentBlockChart:
+			if c.ImageURL == "" {
+				continue
+			}
+			image := Block{Type: "image", ImageURL: c.ImageURL, AltText: "Chart"}
+			if c.Title != "" {
+				image.Title = &TextObject{Type: "plain_text", Text: c.Title, Emoji: true}
+				image.AltText = c.Title
+			}
+			blocks = append(blocks, image)
+			if c.Text != "" {
+				blocks = append(blocks, sectionBlock(c.Text))
+			}
+		}
+	}
+	return blocks
+}
+
+// sectionBlock is a section of mrkdwn text, cut to the section limit
+func sectionBlock(text string) Block {
+	if runes := []rune(text); len(runes) > maxSectionText {
+		text = string(runes[:maxSectionText-1]) + "…"
+	}
+	return Block{Type: "section", Text: &TextObject{Type: "mrkdwn", Text: text}}
+}
+
+// button builds a button, cutting its label to the button limit
+func button(label, actionID, value, style string) *ButtonElement {
+	if runes := []rune(label); len(runes) > maxButtonText {
+		label = string(runes[:maxButtonText-1]) + "…"
+	}
+	return &ButtonElement{
+		Type:     "button",
+		Text:     &TextObject{Type: "plain_text", Text: label, Emoji: true},
+		ActionID: actionID,
+		Value:    value,
+		Style:    style,
+	}
+}
+
+// expenseLine describes a recorded expense, e.g. "*Lunch* 120 TWD · Food"
+func expenseLine(expense map[string]interface{}) string {
+	description, _ := expense["description"].(string)
+	amount, _ := expense["home_amount"].(float64)
+	currency, _ := expense["home_currency"].(string)
+	category, _ := expense["category"].(string)
+
+	line := fmt.Sprintf("*%s* %s %s", description, formatAmount(amount), currency)
+	if category != "" {
+		line += " · " + category
+	}
+	return line
+}
+
+// formatAmount formats an amount without trailing zeros, e.g. "120.5"
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
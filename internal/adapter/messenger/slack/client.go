@@ -6,11 +6,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/domain"
 )
 
+var _ domain.PushNotifier = (*Client)(nil)
+
 // Client handles Slack API communication
 type Client struct {
 	botToken   string
+	apiURL     string // Web API for Block Kit messages and views
 	httpClient *http.Client
 }
 
@@ -22,6 +27,7 @@ func NewClient(botToken string) (*Client, error) {
 
 	return &Client{
 		botToken:   botToken,
+		apiURL:     "https://slack.com/api",
 		httpClient: &http.Client{},
 	}, nil
 }
@@ -76,6 +82,11 @@ func (c *Client) SendMessage(userID, text string) error {
 	return nil
 }
 
+// PushMessage sends a direct message to a Slack user
+func (c *Client) PushMessage(ctx context.Context, userID, text string) error {
+	return c.SendMessage(userID, text)
+}
+
 // PostMessage sends a message to a Slack channel (alias for SendMessage)
 func (c *Client) PostMessage(ctx context.Context, channelID, text string) error {
 	// For now ignoring context as SendMessage doesn't use it, but keeping signature correct for future
@@ -163,3 +174,76 @@ func (c *Client) OpenConversation(userID string) (string, error) {
 
 	return "", fmt.Errorf("failed to extract channel ID from response")
 }
+
+// PostBlocks sends a Block Kit message to a Slack channel; text is shown in
+// notifications and by clients that can't render the blocks
+func (c *Client) PostBlocks(ctx context.Context, channelID, text string, blocks []Block) error {
+	if len(blocks) == 0 {
+		return c.PostMessage(ctx, channelID, text)
+	}
+	return c.postJSON(ctx, c.apiURL+"/chat.postMessage", map[string]interface{}{
+		"channel": channelID,
+		"text":    text,
+		"blocks":  blocks,
+	})
+}
+
+// Respond answers a slash command or a button click through its response_url,
+// visible only to the user who sent it
+func (c *Client) Respond(ctx context.Context, responseURL, text string, blocks []Block) error {
+	payload := map[string]interface{}{
+		"response_type": "ephemeral",
+		"text":          text,
+	}
+	if len(blocks) > 0 {
+		payload["blocks"] = blocks
+	}
+	return c.postJSON(ctx, responseURL, payload)
+}
+
+// postJSON posts a JSON payload to a Slack endpoint. Web API methods answer
+// with {"ok": false} on failure, response URLs with a non-200 status.
+func (c *Client) postJSON(ctx context.Context, endpoint string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.botToken))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack API returned status %d", resp.StatusCode)
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		// Response URLs may answer with plain "ok"
+		return nil
+	}
+	if ok, exists := result["ok"].(bool); exists && !ok {
+		if errMsg, hasErr := result["error"].(string); hasErr {
+			return fmt.Errorf("slack API error: %s", errMsg)
+		}
+	}
+	return nil
+}
+
+// PublishView publishes a view, such as the App Home tab, for a user
+func (c *Client) PublishView(ctx context.Context, userID string, view interface{}) error {
+	return c.postJSON(ctx, c.apiURL+"/views.publish", map[string]interface{}{
+		"user_id": userID,
+		"view":    view,
+	})
+}
@@ -7,33 +7,68 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/riverlin/aiexpense/internal/adapter/messenger"
 	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/logging"
 )
 
 // MessageProcessor defines the interface for processing messages
-type MessageProcessor interface {
-	Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error)
-}
+type MessageProcessor = messenger.MessageProcessor
 
 // Handler handles Slack webhook events
 type Handler struct {
+	*messenger.Webhook
 	signingSecret string
-	useCase       MessageProcessor
 	client        *Client
+	home          HomeSummarizer
+
+	homeMu    sync.Mutex
+	homeUsers map[string]bool // Users who opened the App Home, whose view is kept current
 }
 
 // NewHandler creates a new Slack webhook handler
 func NewHandler(signingSecret string, useCase MessageProcessor, client *Client) *Handler {
-	return &Handler{
+	h := &Handler{
 		signingSecret: signingSecret,
-		useCase:       useCase,
 		client:        client,
 	}
+	h.Webhook = messenger.NewWebhook("slack", h, useCase)
+	return h
+}
+
+// Reply answers a queued message with a Block Kit card
+func (h *Handler) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	return h.SendReply(ctx, msg, resp)
+}
+
+// SendReply answers a message with a Block Kit card, through the response URL
+// of a slash command or button click, or else in the channel it was sent in
+func (h *Handler) SendReply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	if h.client == nil {
+		return nil
+	}
+	blocks := buildBlocks(resp)
+	if responseURL, _ := msg.Metadata["response_url"].(string); responseURL != "" {
+		return h.client.Respond(ctx, responseURL, resp.Text, blocks)
+	}
+	channelID, _ := msg.Metadata["channel"].(string)
+	if channelID == "" {
+		return fmt.Errorf("slack message has no channel")
+	}
+	return h.client.PostBlocks(ctx, channelID, resp.Text, blocks)
+}
+
+// SendPush sends a direct message to a Slack user
+func (h *Handler) SendPush(ctx context.Context, userID, text string) error {
+	if h.client == nil {
+		return fmt.Errorf("slack client not configured")
+	}
+	return h.client.PushMessage(ctx, userID, text)
 }
 
 // SlackEvent represents a Slack event
@@ -51,6 +86,7 @@ type SlackEvent struct {
 // Event represents the event payload
 type Event struct {
 	Type            string `json:"type"`
+	Tab             string `json:"tab"` // app_home_opened: "home" or "messages"
 	User            string `json:"user"`
 	Text            string `json:"text"`
 	Channel         string `json:"channel"`
@@ -59,90 +95,78 @@ type Event struct {
 	ThreadTimestamp string `json:"thread_ts"`
 }
 
-// HandleWebhook handles incoming Slack webhook requests
-func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	// Read the request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Slack: failed to read request body: %v", err)
-		http.Error(w, "failed to read request body", http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
-
-	// Verify request signature
-	if h.signingSecret != "" {
-		if !h.verifySignature(r, body) {
-			log.Printf("Slack: signature verification failed")
-			http.Error(w, "signature verification failed", http.StatusUnauthorized)
-			return
-		}
+// VerifyRequest checks the request's signature when a signing secret is set
+func (h *Handler) VerifyRequest(r *http.Request, body []byte) error {
+	if h.signingSecret != "" && !h.verifySignature(r, body) {
+		return messenger.ErrInvalidSignature
 	}
+	return nil
+}
 
-	// Parse the event
+// ExtractMessages reads the user message of an Events API callback, and
+// publishes the App Home view when the user opens its Home tab
+func (h *Handler) ExtractMessages(ctx context.Context, body []byte) ([]*messenger.Inbound, error) {
 	var slackEvent SlackEvent
 	if err := json.Unmarshal(body, &slackEvent); err != nil {
-		log.Printf("Slack: failed to parse event: %v", err)
-		http.Error(w, "failed to parse event", http.StatusBadRequest)
-		return
+		return nil, fmt.Errorf("failed to parse event: %w", err)
 	}
 
-	// Handle URL verification challenge
-	if slackEvent.Type == "url_verification" {
-		w.Header().Set("Content-Type", "text/plain")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(slackEvent.Challenge))
-		return
+	// Ignore the URL verification challenge, bot messages and other non-user messages
+	event := slackEvent.Event
+	if slackEvent.Type == "url_verification" || event == nil || event.BotID != "" {
+		return nil, nil
 	}
 
-	// Ignore bot messages and other non-user messages
-	if slackEvent.Event == nil || slackEvent.Event.BotID != "" {
-		w.WriteHeader(http.StatusOK)
-		return
+	if event.Type == "app_home_opened" && event.Tab == "home" && event.User != "" {
+		ctx := logging.WithUser(ctx, event.User, "slack")
+		go func() {
+			if err := h.PublishHome(ctx, event.User); err != nil {
+				slog.WarnContext(ctx, "Failed to publish Slack App Home", "error", err)
+			}
+		}()
 	}
 
-	// Handle different event types
-	if (slackEvent.Event.Type == "message" || slackEvent.Event.Type == "app_mention") && slackEvent.Event.Text != "" && slackEvent.Event.User != "" {
-		// Map to UserMessage
-		userMsg := &domain.UserMessage{
-			UserID:    slackEvent.Event.User,
-			Content:   slackEvent.Event.Text,
+	if (event.Type != "message" && event.Type != "app_mention") || event.Text == "" || event.User == "" {
+		return nil, nil
+	}
+	// Slack retries events it considers unacknowledged with the same event_id
+	return []*messenger.Inbound{{
+		EventID: slackEvent.EventID,
+		Message: &domain.UserMessage{
+			UserID:    event.User,
+			Content:   event.Text,
 			Source:    "slack",
 			Timestamp: time.Now(), // Slack timestamp is a string, using Now for simplicity or parse if needed
 			Metadata: map[string]interface{}{
-				"channel":   slackEvent.Event.Channel,
-				"thread_ts": slackEvent.Event.ThreadTimestamp,
+				"channel":   event.Channel,
+				"thread_ts": event.ThreadTimestamp,
 			},
-		}
-
-		// Handle asynchronously as Slack requires quick response
-		go func(msg *domain.UserMessage, channelID string) {
-			ctx := context.Background() // Create new context for async
-			resp, err := h.useCase.Execute(ctx, msg)
-			if err != nil {
-				log.Printf("Slack: message processing failed: %v", err)
-				// Optionally send error message
-			} else {
-				// Send reply
-				if resp.Text != "" && h.client != nil {
-					if err := h.client.PostMessage(ctx, channelID, resp.Text); err != nil {
-						log.Printf("Slack: failed to send reply: %v", err)
-					}
-				}
-			}
-		}(userMsg, slackEvent.Event.Channel)
-	}
+		},
+	}}, nil
+}
 
-	// Always respond with 200 OK to acknowledge receipt
-	w.WriteHeader(http.StatusOK)
+// Acknowledge answers the URL verification challenge with its value, and
+// other events with 200 OK
+func (h *Handler) Acknowledge(w http.ResponseWriter, body []byte) {
+	var slackEvent SlackEvent
+	if json.Unmarshal(body, &slackEvent) == nil && slackEvent.Type == "url_verification" {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(slackEvent.Challenge))
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"ok": "true"})
 }
 
 // verifySignature verifies the Slack request signature
 func (h *Handler) verifySignature(r *http.Request, body []byte) bool {
 	// Get signature from headers
-	signature := r.Header.Get("X-Slack-Request-Signature")
+	signature := r.Header.Get("X-Slack-Signature")
+	if signature == "" {
+		signature = r.Header.Get("X-Slack-Request-Signature")
+	}
 	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
 
 	if signature == "" || timestamp == "" {
@@ -0,0 +1,108 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// HomeSummarizer provides the spending summary shown on the App Home tab
+type HomeSummarizer interface {
+	Summarize(ctx context.Context, userID string) (*domain.SpendingSummary, error)
+}
+
+// SetHomeSummarizer publishes a spending summary to the App Home tab of users
+// who open it; without one the tab is left as configured in the Slack app
+func (h *Handler) SetHomeSummarizer(home HomeSummarizer) {
+	h.home = home
+}
+
+// PublishHome publishes the user's spending summary to their App Home tab
+// and keeps it current from then on, see RefreshHome
+func (h *Handler) PublishHome(ctx context.Context, userID string) error {
+	if h.home == nil || h.client == nil {
+		return nil
+	}
+
+	h.homeMu.Lock()
+	if h.homeUsers == nil {
+		h.homeUsers = make(map[string]bool)
+	}
+	h.homeUsers[userID] = true
+	h.homeMu.Unlock()
+
+	summary, err := h.home.Summarize(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to summarize spending: %w", err)
+	}
+	return h.client.PublishView(ctx, userID, map[string]interface{}{
+		"type":   "home",
+		"blocks": homeBlocks(summary, time.Now()),
+	})
+}
+
+// RefreshHome republishes the App Home of a user whose expenses changed, if
+// they have opened it since the server started; other users' events, such as
+// those of LINE users, are ignored
+func (h *Handler) RefreshHome(ctx context.Context, userID string) error {
+	h.homeMu.Lock()
+	opened := h.homeUsers[userID]
+	h.homeMu.Unlock()
+	if !opened {
+		return nil
+	}
+	return h.PublishHome(ctx, userID)
+}
+
+// homeBlocks lays out the App Home: the month's total, the largest
+// categories and how each budget stands
+func homeBlocks(summary *domain.SpendingSummary, updatedAt time.Time) []Block {
+	title := summary.Period
+	if month, err := time.Parse("2006-01", summary.Period); err == nil {
+		title = month.Format("January 2006")
+	}
+
+	blocks := []Block{
+		{Type: "header", Text: &TextObject{Type: "plain_text", Text: fmt.Sprintf("📊 %s so far", title), Emoji: true}},
+		{Type: "section", Fields: []*TextObject{
+			{Type: "mrkdwn", Text: "*Spent*\n" + formatAmount(summary.Total)},
+			{Type: "mrkdwn", Text: fmt.Sprintf("*Expenses*\n%d", summary.ExpenseCount)},
+		}},
+		{Type: "divider"},
+	}
+
+	var sb strings.Builder
+	sb.WriteString("*Top categories*")
+	if len(summary.TopCategories) == 0 {
+		sb.WriteString("\nNo expenses yet this month. Send me one like `lunch 120`.")
+	}
+	for _, c := range summary.TopCategories {
+		sb.WriteString(fmt.Sprintf("\n• %s %s (%.0f%%)", c.Category, formatAmount(c.Total), c.Percentage))
+	}
+	blocks = append(blocks, sectionBlock(sb.String()), Block{Type: "divider"})
+
+	sb.Reset()
+	sb.WriteString("*Budgets*")
+	if len(summary.Budgets) == 0 {
+		sb.WriteString("\nNo budgets set.")
+	}
+	for _, b := range summary.Budgets {
+		status := "✅"
+		if b.Exceeded {
+			status = "🚨"
+		} else if b.Alert {
+			status = "⚠️"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %s (%s): %s / %s", status, b.Category, b.Period, formatAmount(b.Spent), formatAmount(b.Limit)))
+	}
+	blocks = append(blocks, sectionBlock(sb.String()))
+
+	// Slack shows the time in each viewer's own time zone
+	blocks = append(blocks, Block{Type: "context", Elements: []interface{}{
+		&TextObject{Type: "mrkdwn", Text: fmt.Sprintf("Updated <!date^%d^{date_short_pretty} at {time}|%s>", updatedAt.Unix(), updatedAt.UTC().Format("2006-01-02 15:04 UTC"))},
+	}})
+	return blocks
+}
@@ -0,0 +1,83 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// countingSummarizer returns a fixed summary and counts the users it summarized
+type countingSummarizer struct {
+	calls map[string]int
+}
+
+func (s *countingSummarizer) Summarize(ctx context.Context, userID string) (*domain.SpendingSummary, error) {
+	s.calls[userID]++
+	return &domain.SpendingSummary{Period: "2026-03", Total: 550, ExpenseCount: 4}, nil
+}
+
+func TestHandler_PublishHome(t *testing.T) {
+	var published []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			UserID string `json:"user_id"`
+			View   struct {
+				Type string `json:"type"`
+			} `json:"view"`
+		}
+		if r.URL.Path != "/views.publish" || json.NewDecoder(r.Body).Decode(&req) != nil || req.View.Type != "home" {
+			w.Write([]byte(`{"ok":false,"error":"invalid_arguments"}`))
+			return
+		}
+		published = append(published, req.UserID)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("xoxb-test")
+	client.apiURL = server.URL
+	handler := NewHandler("", new(MockMessageProcessor), client)
+	summarizer := &countingSummarizer{calls: map[string]int{}}
+	handler.SetHomeSummarizer(summarizer)
+
+	ctx := context.Background()
+	// Expense events of users who never opened the App Home don't publish it
+	if err := handler.RefreshHome(ctx, "U1"); err != nil || len(published) != 0 {
+		t.Fatalf("expected no view for an unopened home, got %v, %v", published, err)
+	}
+	if err := handler.PublishHome(ctx, "U1"); err != nil {
+		t.Fatalf("PublishHome failed: %v", err)
+	}
+	if err := handler.RefreshHome(ctx, "U1"); err != nil {
+		t.Fatalf("RefreshHome failed: %v", err)
+	}
+	if strings.Join(published, ",") != "U1,U1" || summarizer.calls["U1"] != 2 {
+		t.Errorf("expected the home published and refreshed, got %v", published)
+	}
+}
+
+func TestHomeBlocks(t *testing.T) {
+	blocks := homeBlocks(&domain.SpendingSummary{
+		Period:        "2026-03",
+		Total:         550,
+		ExpenseCount:  4,
+		TopCategories: []domain.CategorySpending{{Category: "交通", Total: 300, Percentage: 54.6}},
+		Budgets:       []domain.BudgetSpending{{Category: "Food", Period: "monthly", Limit: 100, Spent: 120, Exceeded: true}},
+	}, time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC))
+
+	if blocks[0].Text.Text != "📊 March 2026 so far" || blocks[1].Fields[0].Text != "*Spent*\n550" {
+		t.Errorf("unexpected totals: %+v %+v", blocks[0].Text, blocks[1].Fields)
+	}
+	if text := blocks[3].Text.Text; text != "*Top categories*\n• 交通 300 (55%)" {
+		t.Errorf("unexpected categories: %q", text)
+	}
+	if text := blocks[5].Text.Text; text != "*Budgets*\n🚨 Food (monthly): 120 / 100" {
+		t.Errorf("unexpected budgets: %q", text)
+	}
+}
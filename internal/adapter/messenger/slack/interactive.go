@@ -0,0 +1,145 @@
+package slack
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/adapter/messenger"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// slashCommandActions maps the first word after /expense to the quick action it runs
+var slashCommandActions = map[string]string{
+	"today":      domain.MessageActionTodaySpending,
+	"report":     domain.MessageActionMonthlyReport,
+	"budget":     domain.MessageActionBudgetStatus,
+	"categories": domain.MessageActionListCategories,
+	"export":     domain.MessageActionExport,
+}
+
+// slashCommandHelp answers /expense without text or with "help"
+const slashCommandHelp = "Record an expense with `/expense lunch 120`, or ask `/expense 這個月花多少`.\n" +
+	"`/expense today` - today's spending\n" +
+	"`/expense report` - this month's spending and report link\n" +
+	"`/expense budget` - how your budgets stand\n" +
+	"`/expense categories` - your expense categories\n" +
+	"`/expense export` - export all your data"
+
+// interactionPayload is the part of a block_actions payload the buttons need
+type interactionPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// readForm reads and verifies a signed form-encoded Slack request
+func (h *Handler) readForm(w http.ResponseWriter, r *http.Request) (url.Values, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return nil, false
+	}
+	defer r.Body.Close()
+
+	if h.signingSecret != "" && !h.verifySignature(r, body) {
+		slog.WarnContext(r.Context(), "Slack signature verification failed")
+		http.Error(w, "signature verification failed", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "failed to parse request", http.StatusBadRequest)
+		return nil, false
+	}
+	return form, true
+}
+
+// HandleSlashCommand handles the /expense slash command. Its text is recorded
+// like a message, or runs a quick action such as "/expense report"; the answer
+// is sent through the command's response URL.
+func (h *Handler) HandleSlashCommand(w http.ResponseWriter, r *http.Request) {
+	form, ok := h.readForm(w, r)
+	if !ok {
+		return
+	}
+
+	text := strings.TrimSpace(form.Get("text"))
+	userID := form.Get("user_id")
+	if userID == "" || text == "" || strings.EqualFold(text, "help") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"response_type": "ephemeral", "text": slashCommandHelp})
+		return
+	}
+
+	userMsg := &domain.UserMessage{
+		UserID:    userID,
+		Content:   text,
+		Source:    "slack",
+		Timestamp: time.Now(),
+		Metadata: map[string]interface{}{
+			"channel":      form.Get("channel_id"),
+			"response_url": form.Get("response_url"),
+		},
+	}
+	word, rest, _ := strings.Cut(text, " ")
+	if action, ok := slashCommandActions[strings.ToLower(word)]; ok {
+		userMsg.Action = action
+		userMsg.Content = strings.TrimSpace(rest)
+	}
+
+	h.Dispatch(r.Context(), &messenger.Inbound{Message: userMsg})
+
+	// An empty acknowledgement keeps the command out of the channel
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleInteractivity handles clicks on the buttons of Block Kit cards, which
+// carry the quick action to run and its argument
+func (h *Handler) HandleInteractivity(w http.ResponseWriter, r *http.Request) {
+	form, ok := h.readForm(w, r)
+	if !ok {
+		return
+	}
+
+	var payload interactionPayload
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		http.Error(w, "failed to parse payload", http.StatusBadRequest)
+		return
+	}
+
+	if payload.Type == "block_actions" && payload.User.ID != "" {
+		for _, action := range payload.Actions {
+			if action.ActionID == "" || action.ActionID == openLinkActionID {
+				continue
+			}
+			h.Dispatch(r.Context(), &messenger.Inbound{Message: &domain.UserMessage{
+				UserID:    payload.User.ID,
+				Content:   action.Value,
+				Action:    action.ActionID,
+				Source:    "slack",
+				Timestamp: time.Now(),
+				Metadata: map[string]interface{}{
+					"channel":      payload.Channel.ID,
+					"response_url": payload.ResponseURL,
+				},
+			}})
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,132 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// recordingQueue records the messages handed to it
+type recordingQueue struct {
+	messages []*domain.UserMessage
+}
+
+func (q *recordingQueue) Enqueue(ctx context.Context, msg *domain.UserMessage) error {
+	q.messages = append(q.messages, msg)
+	return nil
+}
+
+func postForm(handle http.HandlerFunc, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/webhook/slack", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handle(w, req)
+	return w
+}
+
+func TestSlackHandler_HandleSlashCommand(t *testing.T) {
+	handler := NewHandler("", new(MockMessageProcessor), nil)
+	queue := &recordingQueue{}
+	handler.SetQueue(queue)
+
+	w := postForm(handler.HandleSlashCommand, url.Values{"command": {"/expense"}, "user_id": {"U1"}, "text": {""}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/expense report") {
+		t.Fatalf("expected the help text, got %d %s", w.Code, w.Body.String())
+	}
+
+	postForm(handler.HandleSlashCommand, url.Values{"user_id": {"U1"}, "channel_id": {"C1"}, "response_url": {"https://hooks.slack.com/1"}, "text": {"lunch 120"}})
+	postForm(handler.HandleSlashCommand, url.Values{"user_id": {"U1"}, "text": {"Report"}})
+	if len(queue.messages) != 2 {
+		t.Fatalf("expected two messages queued, got %d", len(queue.messages))
+	}
+	if msg := queue.messages[0]; msg.Content != "lunch 120" || msg.Action != "" || msg.Metadata["response_url"] != "https://hooks.slack.com/1" {
+		t.Errorf("unexpected expense message: %+v", msg)
+	}
+	if msg := queue.messages[1]; msg.Action != domain.MessageActionMonthlyReport || msg.Content != "" {
+		t.Errorf("unexpected report message: %+v", msg)
+	}
+}
+
+func TestSlackHandler_HandleInteractivity(t *testing.T) {
+	handler := NewHandler("", new(MockMessageProcessor), nil)
+	queue := &recordingQueue{}
+	handler.SetQueue(queue)
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":         "block_actions",
+		"user":         map[string]string{"id": "U1"},
+		"channel":      map[string]string{"id": "C1"},
+		"response_url": "https://hooks.slack.com/2",
+		"actions": []map[string]string{
+			{"action_id": domain.MessageActionDeleteExpense, "value": "exp1"},
+			{"action_id": openLinkActionID},
+		},
+	})
+	if w := postForm(handler.HandleInteractivity, url.Values{"payload": {string(payload)}}); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if len(queue.messages) != 1 {
+		t.Fatalf("expected only the delete click queued, got %d", len(queue.messages))
+	}
+	if msg := queue.messages[0]; msg.Action != domain.MessageActionDeleteExpense || msg.Content != "exp1" || msg.UserID != "U1" || msg.Metadata["response_url"] != "https://hooks.slack.com/2" {
+		t.Errorf("unexpected click message: %+v", msg)
+	}
+}
+
+func TestBuildBlocks(t *testing.T) {
+	if blocks := buildBlocks(&domain.MessageResponse{Text: "Hi"}); blocks != nil {
+		t.Errorf("expected plain text without blocks, got %+v", blocks)
+	}
+
+	blocks := buildBlocks(&domain.MessageResponse{
+		Text: "Saved 1 expense",
+		Data: []map[string]interface{}{
+			{"id": "exp1", "description": "Lunch", "home_amount": 120.5, "home_currency": "TWD", "category": "Food"},
+		},
+	})
+	if len(blocks) != 4 || blocks[2].Text.Text != "*Lunch* 120.5 TWD · Food" {
+		t.Fatalf("unexpected expense card: %+v", blocks)
+	}
+	if buttons := blocks[3].Elements; len(buttons) != 2 || buttons[1].(*ButtonElement).ActionID != domain.MessageActionDeleteExpense || buttons[1].(*ButtonElement).Value != "exp1" {
+		t.Errorf("unexpected expense buttons: %+v", buttons)
+	}
+
+	blocks = buildBlocks(&domain.MessageResponse{
+		Text:    "Here is your expense report",
+		Buttons: []*domain.MessageButton{{Label: "Open report", URL: "https://example.com/r"}},
+	})
+	if len(blocks) != 2 || blocks[1].Elements[0].(*ButtonElement).URL != "https://example.com/r" {
+		t.Errorf("unexpected report card: %+v", blocks)
+	}
+
+	// Content blocks are shown in place of the text
+	blocks = buildBlocks(&domain.MessageResponse{
+		Text: "💰 Budgets\n• Food (monthly): 1200 / 5000",
+		Blocks: []*domain.ContentBlock{
+			{Type: domain.ContentBlockCard, Title: "This month", Fields: []*domain.ContentField{{Label: "Spent", Value: "1200"}, {Label: "Budget", Value: "5000"}}},
+			{Type: domain.ContentBlockTable, Title: "💰 Budgets", Columns: []string{"Category", "Spent"}, Rows: [][]string{{"Food", "1200"}}},
+			{Type: domain.ContentBlockChart, Title: "By category", Text: "🟥 Food", ImageURL: "https://example.com/chart.png"},
+		},
+	})
+	if len(blocks) != 5 {
+		t.Fatalf("unexpected content blocks: %+v", blocks)
+	}
+	if blocks[0].Text.Text != "*This month*" || len(blocks[1].Fields) != 2 || blocks[1].Fields[0].Text != "*Spent*\n1200" {
+		t.Errorf("unexpected card: %+v %+v", blocks[0], blocks[1])
+	}
+	if blocks[2].Text.Text != "*💰 Budgets*\n```Category  Spent\nFood      1200```" {
+		t.Errorf("unexpected table: %q", blocks[2].Text.Text)
+	}
+	if blocks[3].Type != "image" || blocks[3].ImageURL != "https://example.com/chart.png" || blocks[3].AltText != "By category" {
+		t.Errorf("unexpected chart: %+v", blocks[3])
+	}
+	if blocks[4].Text.Text != "🟥 Food" {
+		t.Errorf("unexpected chart legend: %+v", blocks[4])
+	}
+}
@@ -6,36 +6,59 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
-	"io"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/riverlin/aiexpense/internal/adapter/messenger"
 	"github.com/riverlin/aiexpense/internal/domain"
 )
 
 // MessageProcessor defines the interface for processing messages
-type MessageProcessor interface {
-	Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error)
-}
+type MessageProcessor = messenger.MessageProcessor
 
 // Handler handles Microsoft Teams webhook events
 type Handler struct {
+	*messenger.Webhook
 	appID       string
 	appPassword string
-	useCase     MessageProcessor
 	client      *Client
 }
 
 // NewHandler creates a new Teams webhook handler
 func NewHandler(appID, appPassword string, useCase MessageProcessor, client *Client) *Handler {
-	return &Handler{
+	h := &Handler{
 		appID:       appID,
 		appPassword: appPassword,
-		useCase:     useCase,
 		client:      client,
 	}
+	h.Webhook = messenger.NewWebhook("teams", h, useCase)
+	return h
+}
+
+// Reply answers a queued message in the conversation it was sent in
+func (h *Handler) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	return h.SendReply(ctx, msg, resp)
+}
+
+// SendReply answers a message in the conversation it was sent in
+func (h *Handler) SendReply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	if h.client == nil {
+		return nil
+	}
+	if serviceURL, _ := msg.Metadata["service_url"].(string); serviceURL != "" {
+		h.client.SetServiceURL(serviceURL)
+	}
+	conversationID, _ := msg.Metadata["conversation_id"].(string)
+	return h.client.SendMessage(conversationID, resp.Text)
+}
+
+// SendPush is not supported: the bot can only answer in conversations it
+// has seen, which are addressed by their ID and service URL
+func (h *Handler) SendPush(ctx context.Context, userID, text string) error {
+	return fmt.Errorf("teams push messages are not supported")
 }
 
 // Activity represents a Teams activity/event
@@ -44,6 +67,7 @@ type Activity struct {
 	ID             string       `json:"id"`
 	Timestamp      string       `json:"timestamp"`
 	LocalTimestamp string       `json:"localTimestamp"`
+	LocalTimezone  string       `json:"localTimezone"` // The sender's IANA timezone, e.g. "Asia/Taipei"
 	ServiceURL     string       `json:"serviceUrl"`
 	ChannelID      string       `json:"channelId"`
 	ChannelData    ChannelData  `json:"channelData"`
@@ -83,82 +107,57 @@ type Mention struct {
 	Text      string `json:"text"`
 }
 
-// HandleWebhook handles incoming Teams webhook requests
-func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	// Read the request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Teams: failed to read request body: %v", err)
-		http.Error(w, "failed to read request body", http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
-
-	// Verify request signature
+// VerifyRequest checks the request's signature
+func (h *Handler) VerifyRequest(r *http.Request, body []byte) error {
 	if !h.verifySignature(r, body) {
-		log.Printf("Teams: signature verification failed")
-		http.Error(w, "signature verification failed", http.StatusUnauthorized)
-		return
+		return messenger.ErrInvalidSignature
 	}
+	return nil
+}
 
-	// Parse the activity
+// ExtractMessages reads the text message of an activity
+func (h *Handler) ExtractMessages(ctx context.Context, body []byte) ([]*messenger.Inbound, error) {
 	var activity Activity
 	if err := json.Unmarshal(body, &activity); err != nil {
-		log.Printf("Teams: failed to parse activity: %v", err)
-		http.Error(w, "failed to parse activity", http.StatusBadRequest)
-		return
+		return nil, fmt.Errorf("failed to parse activity: %w", err)
 	}
 
-	// Set service URL for reply
-	if h.client != nil {
-		h.client.SetServiceURL(activity.ServiceURL)
-	}
-
-	// Handle different activity types
 	switch activity.Type {
 	case "message":
-		// Process text messages
-		if activity.Text != "" && activity.From.ID != "" {
-			// Map to UserMessage
-			userMsg := &domain.UserMessage{
+		if activity.Text == "" || activity.From.ID == "" {
+			return nil, nil
+		}
+		// Bot Framework redeliveries keep the activity ID
+		return []*messenger.Inbound{{
+			EventID: activity.ID,
+			Message: &domain.UserMessage{
 				UserID:    activity.From.ID,
 				Content:   activity.Text,
 				Source:    "teams",
+				Timezone:  activity.LocalTimezone,
 				Timestamp: time.Now(), // Should parse activity.Timestamp if precise time needed
 				Metadata: map[string]interface{}{
 					"conversation_id": activity.Conversation.ID,
 					"service_url":     activity.ServiceURL,
 				},
-			}
-
-			go func() {
-				ctx := context.Background()
-				resp, err := h.useCase.Execute(ctx, userMsg)
-				if err != nil {
-					log.Printf("Teams: processing failed: %v", err)
-				} else {
-					// Send reply
-					if resp.Text != "" && h.client != nil {
-						if err := h.client.SendMessage(activity.Conversation.ID, resp.Text); err != nil {
-							log.Printf("Teams: failed to send reply: %v", err)
-						}
-					}
-				}
-			}()
-		}
+			},
+		}}, nil
 
 	case "conversationUpdate":
 		// Handle bot added to conversation
-		log.Printf("Teams: bot added to conversation: %s", activity.Conversation.ID)
+		slog.InfoContext(ctx, "Teams bot added to conversation", "conversation_id", activity.Conversation.ID)
 
 	case "event":
 		// Handle other events
-		log.Printf("Teams: event received: %v", activity)
+		slog.InfoContext(ctx, "Teams event received", "activity", activity)
 	}
+	return nil, nil
+}
 
-	// Always respond with 200 OK
-	w.WriteHeader(http.StatusOK)
+// Acknowledge answers every accepted activity with 200 OK
+func (h *Handler) Acknowledge(w http.ResponseWriter, body []byte) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"ok": "true"})
 }
 
@@ -6,14 +6,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
 )
 
+var _ domain.PushNotifier = (*Client)(nil)
+
 // Client represents the Telegram Bot API client
 type Client struct {
 	botToken   string
 	apiURL     string
+	fileURL    string
 	httpClient *http.Client
 }
 
@@ -26,6 +34,7 @@ func NewClient(botToken string) (*Client, error) {
 	return &Client{
 		botToken:   botToken,
 		apiURL:     fmt.Sprintf("https://api.telegram.org/bot%s", botToken),
+		fileURL:    fmt.Sprintf("https://api.telegram.org/file/bot%s", botToken),
 		httpClient: &http.Client{},
 	}, nil
 }
@@ -56,7 +65,7 @@ func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) err
 
 	payload, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error marshaling request: %v", err)
+		slog.ErrorContext(ctx, "Failed to encode Telegram request", "error", err)
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
@@ -69,7 +78,7 @@ func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) err
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		log.Printf("Error sending message to Telegram: %v", err)
+		slog.ErrorContext(ctx, "Failed to send message to Telegram", "error", err)
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 	defer resp.Body.Close()
@@ -88,7 +97,56 @@ func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) err
 		return fmt.Errorf("telegram api error: %s (code: %d)", apiResp.Error, apiResp.ErrorCode)
 	}
 
-	log.Printf("[Telegram] Message sent to chat %d", chatID)
+	slog.DebugContext(ctx, "Telegram message sent", "chat_id", chatID)
+	return nil
+}
+
+// PushMessage sends a message to a user identified by their app user ID ("telegram_<id>").
+// For private chats the Telegram chat ID equals the user ID.
+func (c *Client) PushMessage(ctx context.Context, userID, text string) error {
+	chatID, err := strconv.ParseInt(strings.TrimPrefix(userID, "telegram_"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram user id %q: %w", userID, err)
+	}
+	return c.SendMessage(ctx, chatID, text)
+}
+
+// SendChatAction broadcasts a chat action such as "typing"; Telegram clears it after about 5 seconds
+func (c *Client) SendChatAction(ctx context.Context, chatID int64, action string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"chat_id": chatID,
+		"action":  action,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/sendChatAction", c.apiURL), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send chat action: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var apiResp TelegramAPIResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !apiResp.OK {
+		return fmt.Errorf("telegram api error: %s (code: %d)", apiResp.Error, apiResp.ErrorCode)
+	}
 	return nil
 }
 
@@ -124,6 +182,57 @@ func (c *Client) GetMe(ctx context.Context) error {
 		return fmt.Errorf("telegram api error: %s", apiResp.Error)
 	}
 
-	log.Printf("[Telegram] Bot connected and ready")
+	slog.InfoContext(ctx, "Telegram bot connected")
 	return nil
 }
+
+// DownloadFile resolves a file_id via getFile and downloads its content
+func (c *Client) DownloadFile(ctx context.Context, fileID string) ([]byte, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/getFile?file_id=%s", c.apiURL, url.QueryEscape(fileID)), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get file info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var fileResp struct {
+		OK     bool `json:"ok"`
+		Result struct {
+			FilePath string `json:"file_path"`
+		} `json:"result"`
+		Error string `json:"description,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&fileResp); err != nil {
+		return nil, "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !fileResp.OK || fileResp.Result.FilePath == "" {
+		return nil, "", fmt.Errorf("telegram api error: %s", fileResp.Error)
+	}
+
+	fileReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%s", c.fileURL, fileResp.Result.FilePath), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	fileHTTPResp, err := c.httpClient.Do(fileReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download file: %w", err)
+	}
+	defer fileHTTPResp.Body.Close()
+
+	if fileHTTPResp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("telegram file download failed: status %d", fileHTTPResp.StatusCode)
+	}
+
+	data, err := io.ReadAll(fileHTTPResp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Telegram serves files as application/octet-stream, so sniff the real type
+	return data, http.DetectContentType(data), nil
+}
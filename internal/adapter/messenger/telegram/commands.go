@@ -0,0 +1,130 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// commandActions maps the slash commands to the quick actions they run
+var commandActions = map[string]string{
+	"report":     domain.MessageActionMonthlyReport,
+	"budget":     domain.MessageActionBudgetStatus,
+	"categories": domain.MessageActionListCategories,
+	"export":     domain.MessageActionExport,
+}
+
+// BotCommand is a command listed in the Telegram app's command menu
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+// menuCommands are the commands listed in the command menu, in order
+var menuCommands = []string{"start", "report", "budget", "categories", "export"}
+
+// commandMenuLanguages are the Telegram language codes each command menu is
+// registered for; "" is the fallback for every other language. Telegram only
+// takes two-letter codes, so Chinese speakers get the Traditional menu.
+var commandMenuLanguages = map[string]string{
+	"":   "en",
+	"zh": "zh-TW",
+	"ja": "ja",
+}
+
+// parseCommand splits a slash command such as "/report@aiexpense_bot 2025-03"
+// into its lowercased name and arguments
+func parseCommand(text string) (string, string, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return "", "", false
+	}
+	name, args, _ := strings.Cut(text[1:], " ")
+	// Commands sent in group chats are addressed to the bot
+	name, _, _ = strings.Cut(name, "@")
+	if name == "" {
+		return "", "", false
+	}
+	return strings.ToLower(name), strings.TrimSpace(args), true
+}
+
+// userLocale maps a Telegram language code (IETF, e.g. "zh-hant") to one of
+// the app's locales, defaulting to English
+func userLocale(languageCode string) string {
+	if locale := i18n.Match(languageCode); locale != "" {
+		return locale
+	}
+	return "en"
+}
+
+// helpText answers /start, /help and unknown commands in the user's Telegram language
+func helpText(languageCode string) string {
+	return i18n.T(userLocale(languageCode), "telegram.help")
+}
+
+// commandList returns the command menu in the locale
+func commandList(locale string) []BotCommand {
+	commands := make([]BotCommand, 0, len(menuCommands))
+	for _, command := range menuCommands {
+		commands = append(commands, BotCommand{Command: command, Description: i18n.T(locale, "telegram.command."+command)})
+	}
+	return commands
+}
+
+// RegisterCommands sets the command menu shown in the Telegram app, in each
+// language that has a translation, as BotFather's /setcommands would
+func (c *Client) RegisterCommands(ctx context.Context) error {
+	for languageCode, locale := range commandMenuLanguages {
+		if err := c.SetMyCommands(ctx, commandList(locale), languageCode); err != nil {
+			return fmt.Errorf("failed to register %s commands: %w", locale, err)
+		}
+	}
+	return nil
+}
+
+// SetMyCommands replaces the bot's command list for users with the given
+// language code, or for everyone else when it is empty
+func (c *Client) SetMyCommands(ctx context.Context, commands []BotCommand, languageCode string) error {
+	req := map[string]interface{}{"commands": commands}
+	if languageCode != "" {
+		req["language_code"] = languageCode
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/setMyCommands", c.apiURL), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to set commands: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var apiResp TelegramAPIResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if !apiResp.OK {
+		return fmt.Errorf("telegram api error: %s (code: %d)", apiResp.Error, apiResp.ErrorCode)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text, name, args string
+		ok               bool
+	}{
+		{"/report", "report", "", true},
+		{"/Budget@aiexpense_bot", "budget", "", true},
+		{"/start  hello ", "start", "hello", true},
+		{"lunch 120", "", "", false},
+		{"/", "", "", false},
+	}
+	for _, tt := range tests {
+		name, args, ok := parseCommand(tt.text)
+		if name != tt.name || args != tt.args || ok != tt.ok {
+			t.Errorf("parseCommand(%q) = %q, %q, %v", tt.text, name, args, ok)
+		}
+	}
+}
+
+func TestUserLocale(t *testing.T) {
+	for code, want := range map[string]string{"zh-hant": "zh-TW", "zh-hans": "zh-CN", "zh": "zh-TW", "ja": "ja", "en": "en", "": "en", "de": "en"} {
+		if got := userLocale(code); got != want {
+			t.Errorf("userLocale(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+// recordingReplier records the replies it is asked to send
+type recordingReplier struct {
+	replies []string
+}
+
+func (r *recordingReplier) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	r.replies = append(r.replies, resp.Text)
+	return nil
+}
+
+func TestTelegramHandler_HandleWebhook_Commands(t *testing.T) {
+	mockUC := new(MockMessageProcessor)
+	handler := NewHandler("test_bot_token", mockUC, nil)
+	outbox := &recordingReplier{}
+	handler.SetOutbox(outbox)
+
+	send := func(text string) {
+		body, _ := json.Marshal(TelegramUpdate{
+			UpdateID: 1,
+			Message: &TelegramMessage{
+				From: &TelegramUser{ID: 12345, LanguageCode: "zh-hant"},
+				Chat: &TelegramChat{ID: 12345, Type: "private"},
+				Text: text,
+			},
+		})
+		w := httptest.NewRecorder()
+		handler.HandleWebhook(w, httptest.NewRequest("POST", "/webhook/telegram", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	}
+
+	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
+		return msg.Action == domain.MessageActionBudgetStatus && msg.Content == ""
+	})).Return(&domain.MessageResponse{Text: "💰 Budgets"}, nil)
+
+	send("/budget@aiexpense_bot")
+	send("/start")
+	mockUC.AssertNumberOfCalls(t, "Execute", 1)
+	if len(outbox.replies) != 2 || outbox.replies[0] != "💰 Budgets" || !strings.Contains(outbox.replies[1], "/report - 本月支出與報表連結") {
+		t.Errorf("unexpected replies: %q", outbox.replies)
+	}
+}
+
+func TestClient_RegisterCommands(t *testing.T) {
+	registered := map[string][]BotCommand{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Commands     []BotCommand `json:"commands"`
+			LanguageCode string       `json:"language_code"`
+		}
+		if r.URL.Path != "/setMyCommands" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			w.Write([]byte(`{"ok":false,"description":"bad request","error_code":400}`))
+			return
+		}
+		registered[req.LanguageCode] = req.Commands
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("test_bot_token")
+	client.apiURL = server.URL
+	if err := client.RegisterCommands(context.Background()); err != nil {
+		t.Fatalf("RegisterCommands failed: %v", err)
+	}
+	if len(registered) != len(commandMenuLanguages) || registered["ja"][0].Command != "start" || registered[""][1].Description != "This month's spending and report link" {
+		t.Errorf("unexpected command menus: %+v", registered)
+	}
+}
@@ -4,53 +4,128 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/riverlin/aiexpense/internal/adapter/messenger"
 	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/logging"
 )
 
+// typingRefreshInterval is how often the "typing" chat action is re-sent during long parses
+const typingRefreshInterval = 4 * time.Second
+
 // MessageProcessor defines the interface for processing messages
-type MessageProcessor interface {
-	Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error)
-}
+type MessageProcessor = messenger.MessageProcessor
 
 // Handler handles Telegram bot webhook events
 type Handler struct {
+	*messenger.Webhook
 	botToken string
-	useCase  MessageProcessor
 	client   *Client
 }
 
-// NewHandler creates a new Telegram webhook handler
+// NewHandler creates a new Telegram webhook handler. Messages are processed
+// before the webhook is acknowledged unless a queue is set.
 func NewHandler(botToken string, useCase MessageProcessor, client *Client) *Handler {
-	return &Handler{
+	h := &Handler{
 		botToken: botToken,
-		useCase:  useCase,
 		client:   client,
 	}
+	h.Webhook = messenger.NewWebhook("telegram", h, useCase)
+	h.SetInline(true)
+	return h
+}
+
+// Reply answers a queued message in the chat it was sent in
+func (h *Handler) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	return h.SendReply(ctx, msg, resp)
+}
+
+// SendReply answers a message in the chat it was sent in, with its content
+// blocks laid out in HTML
+func (h *Handler) SendReply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	if h.client == nil {
+		return nil
+	}
+	chatID, ok := chatIDOf(msg)
+	if !ok {
+		return fmt.Errorf("telegram message has no chat_id")
+	}
+	return h.client.SendMessage(ctx, chatID, renderHTML(resp))
+}
+
+// SendPush sends a message to a Telegram user
+func (h *Handler) SendPush(ctx context.Context, userID, text string) error {
+	if h.client == nil {
+		return fmt.Errorf("telegram client not configured")
+	}
+	return h.client.PushMessage(ctx, userID, text)
+}
+
+// chatIDOf returns the chat a message was sent in; the chat ID is a float64
+// once a message has been through a shared queue
+func chatIDOf(msg *domain.UserMessage) (int64, bool) {
+	switch chatID := msg.Metadata["chat_id"].(type) {
+	case int64:
+		return chatID, true
+	case float64:
+		return int64(chatID), true
+	default:
+		return 0, false
+	}
 }
 
 // TelegramUpdate represents a Telegram incoming update (webhook event)
 type TelegramUpdate struct {
-	UpdateID int64 `json:"update_id"`
-	Message  *struct {
-		MessageID int64 `json:"message_id"`
-		From      *struct {
-			ID        int64  `json:"id"`
-			IsBot     bool   `json:"is_bot"`
-			FirstName string `json:"first_name"`
-			Username  string `json:"username"`
-		} `json:"from"`
-		Chat *struct {
-			ID   int64  `json:"id"`
-			Type string `json:"type"`
-		} `json:"chat"`
-		Date int64  `json:"date"`
-		Text string `json:"text"`
-	} `json:"message"`
+	UpdateID int64            `json:"update_id"`
+	Message  *TelegramMessage `json:"message"`
+}
+
+// TelegramMessage represents an incoming Telegram message
+type TelegramMessage struct {
+	MessageID int64               `json:"message_id"`
+	From      *TelegramUser       `json:"from"`
+	Chat      *TelegramChat       `json:"chat"`
+	Date      int64               `json:"date"`
+	Text      string              `json:"text"`
+	Caption   string              `json:"caption,omitempty"`
+	Photo     []TelegramPhotoSize `json:"photo,omitempty"`
+	Voice     *TelegramVoice      `json:"voice,omitempty"`
+}
+
+// TelegramUser represents the sender of a Telegram message
+type TelegramUser struct {
+	ID           int64  `json:"id"`
+	IsBot        bool   `json:"is_bot"`
+	FirstName    string `json:"first_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code,omitempty"` // IETF tag of the app's language, e.g. "zh-hant"
+}
+
+// TelegramChat represents the chat a Telegram message was sent in
+type TelegramChat struct {
+	ID    int64  `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title,omitempty"` // Group and supergroup chats only
+}
+
+// TelegramVoice represents a voice note recorded in the Telegram app
+type TelegramVoice struct {
+	FileID   string `json:"file_id"`
+	Duration int    `json:"duration"`
+	MimeType string `json:"mime_type,omitempty"`
+	FileSize int    `json:"file_size,omitempty"`
+}
+
+// TelegramPhotoSize represents one resolution of a photo; Telegram sends several per photo
+type TelegramPhotoSize struct {
+	FileID   string `json:"file_id"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+	FileSize int    `json:"file_size,omitempty"`
 }
 
 // TelegramResponse represents a Telegram API response
@@ -60,59 +135,152 @@ type TelegramResponse struct {
 	Error  string      `json:"description,omitempty"`
 }
 
-// HandleWebhook processes incoming Telegram webhook events
-func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Failed to read body", http.StatusBadRequest)
-		return
-	}
+// VerifyRequest accepts every update: Telegram doesn't sign them
+func (h *Handler) VerifyRequest(r *http.Request, body []byte) error {
+	return nil
+}
 
+// ExtractMessages reads the text, photo or voice message of an update.
+// Commands without a quick action are answered here with the help text.
+func (h *Handler) ExtractMessages(ctx context.Context, body []byte) ([]*messenger.Inbound, error) {
 	var update TelegramUpdate
 	if err := json.Unmarshal(body, &update); err != nil {
-		http.Error(w, "Failed to parse update", http.StatusBadRequest)
-		return
-	}
-
-	// Process message if present
-	if update.Message != nil && update.Message.Text != "" {
-		if update.Message.From != nil && update.Message.Chat != nil {
-			userID := fmt.Sprintf("telegram_%d", update.Message.From.ID)
-			chatID := update.Message.Chat.ID
-
-			// Map to UserMessage
-			userMsg := &domain.UserMessage{
-				UserID:    userID,
-				Content:   update.Message.Text,
-				Source:    "telegram",
-				Timestamp: time.Unix(update.Message.Date, 0),
-				Metadata: map[string]interface{}{
-					"chat_id": chatID,
-				},
-			}
+		return nil, fmt.Errorf("failed to parse update: %w", err)
+	}
 
-			// Execute logic
-			resp, err := h.useCase.Execute(r.Context(), userMsg)
-			if err != nil {
-				log.Printf("Error handling message: %v", err)
-				// Optionally send error to user
-			} else {
-				// Send reply
-				if resp.Text != "" && h.client != nil {
-					if err := h.client.SendMessage(r.Context(), chatID, resp.Text); err != nil {
-						log.Printf("Error sending reply: %v", err)
-					}
-				}
+	message := update.Message
+	if message == nil || message.From == nil || message.Chat == nil {
+		return nil, nil
+	}
+	if message.Text == "" && len(message.Photo) == 0 && message.Voice == nil {
+		return nil, nil
+	}
+
+	// Map to UserMessage
+	userMsg := &domain.UserMessage{
+		UserID:    fmt.Sprintf("telegram_%d", message.From.ID),
+		Content:   message.Text,
+		Source:    "telegram",
+		Timestamp: time.Unix(message.Date, 0),
+		Metadata: map[string]interface{}{
+			"chat_id": message.Chat.ID,
+		},
+	}
+	if chat := message.Chat; chat.Type == "group" || chat.Type == "supergroup" {
+		userMsg.GroupChatID = fmt.Sprintf("%d", chat.ID)
+		userMsg.GroupName = chat.Title
+	}
+	if len(message.Photo) > 0 {
+		userMsg.Content = message.Caption
+	}
+
+	// Telegram retries undelivered updates with the same update_id
+	in := &messenger.Inbound{EventID: fmt.Sprintf("%d", update.UpdateID), Message: userMsg}
+	if h.client != nil && (len(message.Photo) > 0 || message.Voice != nil) {
+		in.Download = func(ctx context.Context) ([]*domain.Attachment, error) {
+			return h.downloadMedia(ctx, message), nil
+		}
+	}
+
+	// Slash commands run quick actions; /start, /help and unknown ones get the help text
+	command, args, isCommand := parseCommand(message.Text)
+	if action, ok := commandActions[command]; isCommand && ok {
+		userMsg.Action = action
+		userMsg.Content = args
+	}
+	if isCommand && userMsg.Action == "" {
+		ctx := logging.WithUser(ctx, userMsg.UserID, "telegram")
+		if !h.IsDuplicate(ctx, in.EventID) {
+			h.Respond(ctx, userMsg, &domain.MessageResponse{Text: helpText(message.From.LanguageCode)})
+		}
+		return nil, nil
+	}
+	return []*messenger.Inbound{in}, nil
+}
+
+// downloadMedia fetches the message's photo and voice note; one that fails to
+// download is left out, so the caption is still recorded
+func (h *Handler) downloadMedia(ctx context.Context, message *TelegramMessage) []*domain.Attachment {
+	var attachments []*domain.Attachment
+	if len(message.Photo) > 0 {
+		// The last size is the largest resolution, which gives OCR the best chance
+		photo := message.Photo[len(message.Photo)-1]
+		data, mimeType, err := h.client.DownloadFile(ctx, photo.FileID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to download Telegram photo", "file_id", photo.FileID, "error", err)
+		} else {
+			attachments = append(attachments, &domain.Attachment{
+				Type:     domain.AttachmentTypeImage,
+				MimeType: mimeType,
+				Data:     data,
+			})
+		}
+	}
+	if voice := message.Voice; voice != nil {
+		data, mimeType, err := h.client.DownloadFile(ctx, voice.FileID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to download Telegram voice message", "file_id", voice.FileID, "error", err)
+		} else {
+			if voice.MimeType != "" {
+				mimeType = voice.MimeType
 			}
+			attachments = append(attachments, &domain.Attachment{
+				Type:     domain.AttachmentTypeAudio,
+				MimeType: mimeType,
+				Data:     data,
+			})
 		}
 	}
+	return attachments
+}
 
-	// Always respond 200 OK to Telegram
+// Acknowledge answers every accepted update with 200 OK
+func (h *Handler) Acknowledge(w http.ResponseWriter, body []byte) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true})
 }
 
+// IndicateProgress keeps the "typing" indicator alive while the message is parsed
+func (h *Handler) IndicateProgress(ctx context.Context, msg *domain.UserMessage) context.Context {
+	chatID, ok := chatIDOf(msg)
+	if !ok {
+		return ctx
+	}
+	return h.withTypingIndicator(ctx, chatID)
+}
+
+// withTypingIndicator sends an initial "typing" action and refreshes it on parse progress,
+// throttled to Telegram's ~5 second display window
+func (h *Handler) withTypingIndicator(ctx context.Context, chatID int64) context.Context {
+	if h.client == nil {
+		return ctx
+	}
+
+	var mu sync.Mutex
+	var lastSent time.Time
+	sendTyping := func() {
+		mu.Lock()
+		if time.Since(lastSent) < typingRefreshInterval {
+			mu.Unlock()
+			return
+		}
+		lastSent = time.Now()
+		mu.Unlock()
+
+		if err := h.client.SendChatAction(ctx, chatID, "typing"); err != nil {
+			slog.WarnContext(ctx, "Failed to send Telegram typing indicator", "error", err)
+		}
+	}
+
+	sendTyping()
+	return domain.WithProgress(ctx, func(progress *domain.ParseProgress) {
+		if !progress.Done {
+			sendTyping()
+		}
+	})
+}
+
 // VerifySecret verifies the Telegram webhook secret (optional)
 // Telegram doesn't require signature verification like LINE does,
 // but you can implement custom secret verification if needed
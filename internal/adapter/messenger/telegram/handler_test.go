@@ -38,33 +38,11 @@ func TestTelegramHandler_HandleWebhook_Success(t *testing.T) {
 	// Create valid webhook payload (simplified)
 	update := TelegramUpdate{
 		UpdateID: 123,
-		Message: &struct {
-			MessageID int64 `json:"message_id"`
-			From      *struct {
-				ID        int64  `json:"id"`
-				IsBot     bool   `json:"is_bot"`
-				FirstName string `json:"first_name"`
-				Username  string `json:"username"`
-			} `json:"from"`
-			Chat *struct {
-				ID   int64  `json:"id"`
-				Type string `json:"type"`
-			} `json:"chat"`
-			Date int64  `json:"date"`
-			Text string `json:"text"`
-		}{
+		Message: &TelegramMessage{
 			MessageID: 1,
-			From: &struct {
-				ID        int64  `json:"id"`
-				IsBot     bool   `json:"is_bot"`
-				FirstName string `json:"first_name"`
-				Username  string `json:"username"`
-			}{ID: 12345, FirstName: "Test"},
-			Chat: &struct {
-				ID   int64  `json:"id"`
-				Type string `json:"type"`
-			}{ID: 67890},
-			Text: "breakfast $20",
+			From:      &TelegramUser{ID: 12345, FirstName: "Test"},
+			Chat:      &TelegramChat{ID: 67890},
+			Text:      "breakfast $20",
 		},
 	}
 	body, _ := json.Marshal(update)
@@ -80,3 +58,44 @@ func TestTelegramHandler_HandleWebhook_Success(t *testing.T) {
 
 	mockUC.AssertExpectations(t)
 }
+
+// memoryDedup remembers event IDs in memory
+type memoryDedup map[string]bool
+
+func (d memoryDedup) Seen(ctx context.Context, messenger, eventID string) bool {
+	key := messenger + "/" + eventID
+	seen := d[key]
+	d[key] = true
+	return seen
+}
+
+func TestTelegramHandler_HandleWebhook_DuplicateUpdate(t *testing.T) {
+	mockUC := new(MockMessageProcessor)
+	handler := NewHandler("test_bot_token", mockUC, nil)
+	handler.SetDeduplicator(memoryDedup{})
+
+	mockUC.On("Execute", mock.Anything, mock.Anything).Return(&domain.MessageResponse{Text: "Saved"}, nil).Once()
+
+	update := TelegramUpdate{
+		UpdateID: 124,
+		Message: &TelegramMessage{
+			MessageID: 2,
+			From:      &TelegramUser{ID: 12345, FirstName: "Test"},
+			Chat:      &TelegramChat{ID: 67890},
+			Text:      "lunch $15",
+		},
+	}
+	body, _ := json.Marshal(update)
+
+	// Telegram redelivers the same update when it misses the acknowledgement
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/webhook/telegram", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.HandleWebhook(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	}
+
+	mockUC.AssertNumberOfCalls(t, "Execute", 1)
+}
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.LoginVerifier = (*LoginVerifier)(nil)
+
+// loginMaxAge is how old a Telegram Login widget payload may be
+const loginMaxAge = 24 * time.Hour
+
+// LoginVerifier checks payloads from the Telegram Login widget, which Telegram
+// signs with a key derived from the bot token.
+// See https://core.telegram.org/widgets/login#checking-authorization
+type LoginVerifier struct {
+	botToken string
+	now      func() time.Time
+}
+
+// NewLoginVerifier creates a verifier for the bot's login widget
+func NewLoginVerifier(botToken string) *LoginVerifier {
+	return &LoginVerifier{
+		botToken: botToken,
+		now:      time.Now,
+	}
+}
+
+// VerifyLogin checks the widget's hash and auth_date and returns the user's ID
+// as used by the Telegram messenger
+func (v *LoginVerifier) VerifyLogin(ctx context.Context, payload map[string]string) (string, error) {
+	hash := payload["hash"]
+	if hash == "" || payload["id"] == "" {
+		return "", fmt.Errorf("id and hash are required")
+	}
+
+	// The data-check-string is every other field as key=value, sorted by key
+	var fields []string
+	for key, value := range payload {
+		if key != "hash" {
+			fields = append(fields, key+"="+value)
+		}
+	}
+	sort.Strings(fields)
+
+	secret := sha256.Sum256([]byte(v.botToken))
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(hash))) {
+		return "", fmt.Errorf("invalid login hash")
+	}
+
+	authDate, err := strconv.ParseInt(payload["auth_date"], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid auth_date")
+	}
+	if v.now().Sub(time.Unix(authDate, 0)) > loginMaxAge {
+		return "", fmt.Errorf("login has expired")
+	}
+
+	id, err := strconv.ParseInt(payload["id"], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid id")
+	}
+	return fmt.Sprintf("telegram_%d", id), nil
+}
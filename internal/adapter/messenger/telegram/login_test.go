@@ -0,0 +1,47 @@
+package telegram
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLoginVerifier(t *testing.T) {
+	now := time.Now()
+	verifier := NewLoginVerifier("test_bot_token")
+	verifier.now = func() time.Time { return now }
+
+	// sign builds a payload the way the Telegram Login widget does
+	sign := func(authDate time.Time) map[string]string {
+		payload := map[string]string{
+			"id":         "12345",
+			"first_name": "Test",
+			"username":   "tester",
+			"auth_date":  strconv.FormatInt(authDate.Unix(), 10),
+		}
+		secret := sha256.Sum256([]byte("test_bot_token"))
+		mac := hmac.New(sha256.New, secret[:])
+		mac.Write([]byte("auth_date=" + payload["auth_date"] + "\nfirst_name=Test\nid=12345\nusername=tester"))
+		payload["hash"] = hex.EncodeToString(mac.Sum(nil))
+		return payload
+	}
+
+	userID, err := verifier.VerifyLogin(context.Background(), sign(now.Add(-time.Minute)))
+	if err != nil || userID != "telegram_12345" {
+		t.Fatalf("expected telegram_12345, got %q, %v", userID, err)
+	}
+
+	tampered := sign(now)
+	tampered["id"] = "99999"
+	if _, err := verifier.VerifyLogin(context.Background(), tampered); err == nil {
+		t.Error("expected a tampered payload to be rejected")
+	}
+
+	if _, err := verifier.VerifyLogin(context.Background(), sign(now.Add(-loginMaxAge-time.Minute))); err == nil {
+		t.Error("expected an old payload to be rejected")
+	}
+}
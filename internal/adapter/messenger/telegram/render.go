@@ -0,0 +1,58 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/adapter/messenger"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// renderHTML lays the response's content blocks out in Telegram's HTML: cards
+// with a bold title and their fields, tables as preformatted text and charts
+// as a link whose preview shows the image, above their legend. Without blocks
+// it is the text.
+func renderHTML(resp *domain.MessageResponse) string {
+	if len(resp.Blocks) == 0 {
+		return resp.Text
+	}
+
+	var parts []string
+	for _, c := range resp.Blocks {
+		var sb strings.Builder
+		if c.Title != "" && c.Type != domain.ContentBlockChart {
+			sb.WriteString("<b>" + html.EscapeString(c.Title) + "</b>\n")
+		}
+		switch c.Type {
+		case domain.ContentBlockCard:
+			if c.Text != "" {
+				sb.WriteString(html.EscapeString(c.Text) + "\n")
+			}
+			for _, f := range c.Fields {
+				sb.WriteString(fmt.Sprintf("%s: <b>%s</b>\n", html.EscapeString(f.Label), html.EscapeString(f.Value)))
+			}
+		case domain.ContentBlockTable:
+			sb.WriteString("<pre>" + html.EscapeString(messenger.TextTable(c.Columns, c.Rows)) + "</pre>")
+		case domain.ContentBlockChart:
+			if c.ImageURL == "" {
+				continue
+			}
+			title := c.Title
+			if title == "" {
+				title = "Chart"
+			}
+			sb.WriteString(fmt.Sprintf("📊 <a href=\"%s\">%s</a>", html.EscapeString(c.ImageURL), html.EscapeString(title)))
+			if c.Text != "" {
+				sb.WriteString("\n" + html.EscapeString(c.Text))
+			}
+		}
+		if part := strings.TrimSpace(sb.String()); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return resp.Text
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,24 @@
+package telegram
+
+import (
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderHTML(t *testing.T) {
+	assert.Equal(t, "Saved <b>1</b> expense", renderHTML(&domain.MessageResponse{Text: "Saved <b>1</b> expense"}))
+
+	text := renderHTML(&domain.MessageResponse{
+		Text: "fallback",
+		Blocks: []*domain.ContentBlock{
+			{Type: domain.ContentBlockCard, Title: "This month", Text: "Food & drinks", Fields: []*domain.ContentField{{Label: "Spent", Value: "1200"}}},
+			{Type: domain.ContentBlockTable, Title: "💰 Budgets", Columns: []string{"Category", "Spent"}, Rows: [][]string{{"<Food>", "1200"}}},
+			{Type: domain.ContentBlockChart, Text: "🟥 Food & drinks", ImageURL: "https://example.com/chart.png?a=1&b=2"},
+		},
+	})
+	assert.Equal(t, "<b>This month</b>\nFood &amp; drinks\nSpent: <b>1200</b>\n\n"+
+		"<b>💰 Budgets</b>\n<pre>Category  Spent\n&lt;Food&gt;    1200</pre>\n\n"+
+		"📊 <a href=\"https://example.com/chart.png?a=1&amp;b=2\">Chart</a>\n🟥 Food &amp; drinks", text)
+}
@@ -0,0 +1,182 @@
+package terminal
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ANSI escapes for the REPL's colored output
+const (
+	ansiReset = "\033[0m"
+	ansiBold  = "\033[1m"
+	ansiDim   = "\033[2m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiCyan  = "\033[36m"
+)
+
+const replHelp = `Type a message as you would send it in a chat, e.g. "lunch 120".
+  /1, /2, ...   choose a quick reply from the last answer
+  /user [id]    show the session user, or switch to another
+  /help         show this help
+  /quit         leave (or Ctrl-D)`
+
+// REPL chats with the message processor on a terminal, a line per message,
+// so developers can try the bot without a messenger or HTTP calls. Its user
+// lasts for the session, and is a fresh one unless given.
+type REPL struct {
+	useCase MessageProcessor
+	userID  string
+	color   bool
+	buttons []*domain.MessageButton // The last answer's quick replies
+}
+
+// NewREPL creates a REPL for userID, or for a new session user if it is empty
+func NewREPL(useCase MessageProcessor, userID string) *REPL {
+	if userID == "" {
+		userID = SessionUserID()
+	}
+	return &REPL{useCase: useCase, userID: userID}
+}
+
+// SetColor turns ANSI colors on or off; they are off by default
+func (r *REPL) SetColor(enabled bool) {
+	r.color = enabled
+}
+
+// UserID returns the user the REPL sends messages as
+func (r *REPL) UserID() string {
+	return r.userID
+}
+
+// SessionUserID returns a user ID for a new REPL session
+func SessionUserID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return "repl_" + hex.EncodeToString(b)
+}
+
+// ColorEnabled reports whether f is a terminal that should get colors, which
+// the NO_COLOR convention turns off
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Run reads messages from in and writes the answers to out until in ends,
+// /quit is entered or ctx is done. A failed message is reported and the
+// session goes on.
+func (r *REPL) Run(ctx context.Context, in io.Reader, out io.Writer) error {
+	fmt.Fprintf(out, "%s as %s. Type /help for commands.\n", r.paint(ansiBold, "AI Expense REPL"), r.paint(ansiCyan, r.userID))
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, r.paint(ansiGreen, "> "))
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		msg := &domain.UserMessage{UserID: r.userID, Content: line, Source: "terminal", Timestamp: time.Now()}
+		if strings.HasPrefix(line, "/") {
+			command, arg, _ := strings.Cut(line[1:], " ")
+			switch command {
+			case "quit", "exit":
+				return nil
+			case "help":
+				fmt.Fprintln(out, replHelp)
+				continue
+			case "user":
+				if arg = strings.TrimSpace(arg); arg != "" {
+					r.userID = arg
+					r.buttons = nil
+				}
+				fmt.Fprintf(out, "Sending as %s\n", r.paint(ansiCyan, r.userID))
+				continue
+			}
+			if n, err := strconv.Atoi(command); err == nil {
+				if n < 1 || n > len(r.buttons) {
+					fmt.Fprintln(out, r.paint(ansiRed, fmt.Sprintf("No quick reply %d", n)))
+					continue
+				}
+				button := r.buttons[n-1]
+				if button.URL != "" {
+					fmt.Fprintln(out, button.URL)
+					continue
+				}
+				msg.Action, msg.Content = button.Action, button.Value
+			}
+		}
+
+		resp, err := r.useCase.Execute(ctx, msg)
+		if err != nil {
+			fmt.Fprintln(out, r.paint(ansiRed, "Error: "+err.Error()))
+			continue
+		}
+		r.render(out, resp)
+	}
+}
+
+// render writes the answer's blocks, or its text when it has none, followed
+// by its quick replies numbered for /1, /2, ...
+func (r *REPL) render(out io.Writer, resp *domain.MessageResponse) {
+	if len(resp.Blocks) == 0 {
+		fmt.Fprintln(out, resp.Text)
+	}
+	for _, block := range resp.Blocks {
+		if block.Title != "" {
+			fmt.Fprintln(out, r.paint(ansiBold, block.Title))
+		}
+		if block.Text != "" {
+			fmt.Fprintln(out, block.Text)
+		}
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		for _, field := range block.Fields {
+			fmt.Fprintf(tw, "  %s\t%s\n", field.Label, field.Value)
+		}
+		if len(block.Columns) > 0 {
+			fmt.Fprintf(tw, "  %s\n", strings.Join(block.Columns, "\t"))
+		}
+		for _, row := range block.Rows {
+			fmt.Fprintf(tw, "  %s\n", strings.Join(row, "\t"))
+		}
+		tw.Flush()
+		if block.ImageURL != "" {
+			fmt.Fprintln(out, r.paint(ansiDim, block.ImageURL))
+		}
+	}
+
+	r.buttons = resp.Buttons
+	for i, button := range resp.Buttons {
+		fmt.Fprintf(out, "%s %s\n", r.paint(ansiCyan, fmt.Sprintf("/%d", i+1)), button.Label)
+	}
+}
+
+// paint wraps s in an ANSI style when colors are on
+func (r *REPL) paint(style, s string) string {
+	if !r.color {
+		return s
+	}
+	return style + s + ansiReset
+}
@@ -0,0 +1,68 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestREPL_Run(t *testing.T) {
+	mockUC := new(mockProcessMessageUseCase)
+	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
+		return msg.UserID == "dev" && msg.Content == "lunch 120" && msg.Source == "terminal"
+	})).Return(&domain.MessageResponse{
+		Text:    "Recorded lunch 120",
+		Buttons: []*domain.MessageButton{{Label: "Undo", Action: "undo", Value: "exp1"}},
+	}, nil).Once()
+	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
+		return msg.UserID == "dev" && msg.Action == "undo" && msg.Content == "exp1"
+	})).Return(&domain.MessageResponse{Text: "Removed lunch"}, nil).Once()
+	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
+		return msg.UserID == "other" && msg.Content == "report"
+	})).Return(&domain.MessageResponse{
+		Text: "March: 500",
+		Blocks: []*domain.ContentBlock{{
+			Type:   domain.ContentBlockCard,
+			Title:  "March",
+			Fields: []*domain.ContentField{{Label: "Spent", Value: "500"}},
+		}},
+	}, nil).Once()
+	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
+		return msg.Content == "boom"
+	})).Return(nil, errors.New("database is locked")).Once()
+
+	in := strings.NewReader(strings.Join([]string{"lunch 120", "/1", "/1", "", "/user other", "report", "boom", "/quit", "never sent"}, "\n"))
+	var out bytes.Buffer
+	repl := NewREPL(mockUC, "dev")
+	require.NoError(t, repl.Run(context.Background(), in, &out))
+
+	mockUC.AssertExpectations(t)
+	assert.Equal(t, "other", repl.UserID())
+	output := out.String()
+	assert.Contains(t, output, "Recorded lunch 120\n/1 Undo\n")
+	assert.Contains(t, output, "Removed lunch")
+	assert.Contains(t, output, "No quick reply 1")
+	assert.Contains(t, output, "Sending as other")
+	assert.Contains(t, output, "March\n  Spent  500\n")
+	assert.NotContains(t, output, "March: 500", "blocks replace the text")
+	assert.Contains(t, output, "Error: database is locked")
+	assert.NotContains(t, output, "\033[", "colors are off by default")
+}
+
+func TestREPL_SessionUser(t *testing.T) {
+	repl := NewREPL(new(mockProcessMessageUseCase), "")
+	assert.True(t, strings.HasPrefix(repl.UserID(), "repl_"))
+	assert.NotEqual(t, repl.UserID(), NewREPL(new(mockProcessMessageUseCase), "").UserID())
+
+	repl.SetColor(true)
+	var out bytes.Buffer
+	require.NoError(t, repl.Run(context.Background(), strings.NewReader(""), &out))
+	assert.Contains(t, out.String(), ansiCyan+repl.UserID()+ansiReset)
+}
@@ -0,0 +1,186 @@
+package messenger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/logging"
+)
+
+var _ domain.PushNotifier = (*Webhook)(nil)
+
+// maxBodySize caps webhook requests; platforms send a few events at a time
+const maxBodySize = 1 << 20
+
+// Webhook handles a platform's webhook requests through its Adapter: messages
+// are deduplicated, handed to the queue or processed, and answered through
+// the outbox. Platform handlers embed it.
+type Webhook struct {
+	name      string
+	adapter   Adapter
+	processor MessageProcessor
+	inline    bool
+	dedup     domain.EventDeduplicator
+	queue     domain.MessageQueue
+	outbox    domain.MessageReplier
+}
+
+// NewWebhook creates the webhook pipeline of a platform; name is the
+// messenger's source name, e.g. "line"
+func NewWebhook(name string, adapter Adapter, processor MessageProcessor) *Webhook {
+	return &Webhook{
+		name:      name,
+		adapter:   adapter,
+		processor: processor,
+	}
+}
+
+// SetDeduplicator skips webhook events that were already handled, e.g. retried deliveries
+func (wh *Webhook) SetDeduplicator(dedup domain.EventDeduplicator) {
+	wh.dedup = dedup
+}
+
+// SetQueue leaves messages to the queue's workers, which answer through the
+// handler's Reply
+func (wh *Webhook) SetQueue(queue domain.MessageQueue) {
+	wh.queue = queue
+}
+
+// SetOutbox sends replies through an outbox that records them and retries
+// the ones that couldn't be delivered
+func (wh *Webhook) SetOutbox(outbox domain.MessageReplier) {
+	wh.outbox = outbox
+}
+
+// SetInline processes messages before the webhook request is answered, for
+// platforms whose reply tokens expire soon after; by default they are
+// processed in the background so the platform isn't kept waiting
+func (wh *Webhook) SetInline(inline bool) {
+	wh.inline = inline
+}
+
+// IsDuplicate reports whether the event was already handled
+func (wh *Webhook) IsDuplicate(ctx context.Context, eventID string) bool {
+	return eventID != "" && wh.dedup != nil && wh.dedup.Seen(ctx, wh.name, eventID)
+}
+
+// HandleWebhook verifies the request, dispatches its new messages and
+// acknowledges it
+func (wh *Webhook) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to read webhook request body", "messenger", wh.name, "error", err)
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := wh.adapter.VerifyRequest(r, body); err != nil {
+		slog.WarnContext(r.Context(), "Webhook verification failed", "messenger", wh.name, "error", err)
+		http.Error(w, "signature verification failed", http.StatusUnauthorized)
+		return
+	}
+
+	// Messages are answered after the request may have timed out, so only keep its log fields
+	ctx := context.WithoutCancel(r.Context())
+	messages, err := wh.adapter.ExtractMessages(ctx, body)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to parse webhook request", "messenger", wh.name, "error", err)
+		http.Error(w, "failed to parse request", http.StatusBadRequest)
+		return
+	}
+
+	for _, in := range messages {
+		if wh.IsDuplicate(ctx, in.EventID) {
+			continue
+		}
+		wh.Dispatch(ctx, in)
+	}
+
+	if ack, ok := wh.adapter.(Acknowledger); ok {
+		ack.Acknowledge(w, body)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Dispatch hands the message to the queue or processes it, in the background
+// unless the webhook is inline, and replies once it is done
+func (wh *Webhook) Dispatch(ctx context.Context, in *Inbound) {
+	ctx = logging.WithUser(context.WithoutCancel(ctx), in.Message.UserID, wh.name)
+	// Messages without attachments are queued before the request is acknowledged
+	if in.Download == nil && wh.enqueue(ctx, in.Message) {
+		return
+	}
+	if wh.inline {
+		wh.process(ctx, in)
+		return
+	}
+	go wh.process(ctx, in)
+}
+
+// process downloads the message's attachments, then queues or executes it
+func (wh *Webhook) process(ctx context.Context, in *Inbound) {
+	msg := in.Message
+	if in.Download != nil {
+		attachments, err := in.Download(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to download message attachment", "messenger", wh.name, "error", err)
+			return
+		}
+		msg.Attachments = append(msg.Attachments, attachments...)
+		if wh.enqueue(ctx, msg) {
+			return
+		}
+	}
+
+	execCtx := ctx
+	if indicator, ok := wh.adapter.(ProgressIndicator); ok {
+		execCtx = indicator.IndicateProgress(ctx, msg)
+	}
+	resp, err := wh.processor.Execute(execCtx, msg)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to handle message", "messenger", wh.name, "error", err)
+		return
+	}
+	wh.Respond(ctx, msg, resp)
+}
+
+// enqueue hands the message to the queue, reporting false when it has to be
+// processed here
+func (wh *Webhook) enqueue(ctx context.Context, msg *domain.UserMessage) bool {
+	if wh.queue == nil {
+		return false
+	}
+	if err := wh.queue.Enqueue(ctx, msg); err != nil {
+		slog.WarnContext(ctx, "Failed to queue message, processing it inline", "messenger", wh.name, "error", err)
+		return false
+	}
+	return true
+}
+
+// Respond answers the message through the outbox, or directly without one;
+// empty responses are not sent
+func (wh *Webhook) Respond(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) {
+	if resp == nil || resp.Text == "" {
+		return
+	}
+	var err error
+	if wh.outbox != nil {
+		err = wh.outbox.Reply(ctx, msg, resp)
+	} else {
+		err = wh.adapter.SendReply(ctx, msg, resp)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to send reply", "messenger", wh.name, "error", err)
+	}
+}
+
+// PushMessage sends a message to a user through the adapter, so a handler
+// can be registered as a notifier
+func (wh *Webhook) PushMessage(ctx context.Context, userID, text string) error {
+	return wh.adapter.SendPush(ctx, userID, text)
+}
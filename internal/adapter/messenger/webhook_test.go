@@ -0,0 +1,154 @@
+package messenger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAdapter reads messages from a JSON list of {"id", "user", "text"} and
+// records its replies
+type fakeAdapter struct {
+	mu      sync.Mutex
+	replies []string
+}
+
+func (a *fakeAdapter) VerifyRequest(r *http.Request, body []byte) error {
+	if r.Header.Get("X-Signature") != "valid" {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (a *fakeAdapter) ExtractMessages(ctx context.Context, body []byte) ([]*Inbound, error) {
+	var events []struct{ ID, User, Text, Image string }
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, err
+	}
+	var messages []*Inbound
+	for _, e := range events {
+		in := &Inbound{EventID: e.ID, Message: &domain.UserMessage{UserID: e.User, Content: e.Text, Source: "fake"}}
+		if image := e.Image; image != "" {
+			in.Download = func(ctx context.Context) ([]*domain.Attachment, error) {
+				if image == "missing" {
+					return nil, errors.New("not found")
+				}
+				return []*domain.Attachment{{Type: domain.AttachmentTypeImage, Data: []byte(image)}}, nil
+			}
+		}
+		messages = append(messages, in)
+	}
+	return messages, nil
+}
+
+func (a *fakeAdapter) SendReply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.replies = append(a.replies, msg.UserID+": "+resp.Text)
+	return nil
+}
+
+func (a *fakeAdapter) SendPush(ctx context.Context, userID, text string) error {
+	return a.SendReply(ctx, &domain.UserMessage{UserID: userID}, &domain.MessageResponse{Text: text})
+}
+
+// echoProcessor answers each message with its content and attachment
+type echoProcessor struct{}
+
+func (echoProcessor) Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error) {
+	if image := msg.FirstAttachment(domain.AttachmentTypeImage); image != nil {
+		return &domain.MessageResponse{Text: "receipt " + string(image.Data)}, nil
+	}
+	return &domain.MessageResponse{Text: "saved " + msg.Content}, nil
+}
+
+// seenDeduplicator remembers the IDs it has seen
+type seenDeduplicator struct {
+	seen map[string]bool
+}
+
+func (d *seenDeduplicator) Seen(ctx context.Context, source, eventID string) bool {
+	key := source + ":" + eventID
+	if d.seen[key] {
+		return true
+	}
+	d.seen[key] = true
+	return false
+}
+
+// recordingQueue records the messages enqueued
+type recordingQueue struct {
+	messages []*domain.UserMessage
+}
+
+func (q *recordingQueue) Enqueue(ctx context.Context, msg *domain.UserMessage) error {
+	q.messages = append(q.messages, msg)
+	return nil
+}
+
+func post(wh *Webhook, signature, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/webhook/fake", strings.NewReader(body))
+	req.Header.Set("X-Signature", signature)
+	w := httptest.NewRecorder()
+	wh.HandleWebhook(w, req)
+	return w
+}
+
+func TestWebhook_HandleWebhook(t *testing.T) {
+	adapter := &fakeAdapter{}
+	wh := NewWebhook("fake", adapter, echoProcessor{})
+	wh.SetInline(true)
+	wh.SetDeduplicator(&seenDeduplicator{seen: make(map[string]bool)})
+
+	body := `[{"id":"1","user":"alice","text":"lunch 120"},{"id":"2","user":"bob","image":"jpeg"},{"id":"3","user":"carol","image":"missing"}]`
+	w := post(wh, "valid", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"alice: saved lunch 120", "bob: receipt jpeg"}, adapter.replies)
+
+	// A redelivery is skipped, messages without an ID never are
+	post(wh, "valid", `[{"id":"1","user":"alice","text":"lunch 120"},{"user":"dave","text":"taxi 250"}]`)
+	assert.Equal(t, []string{"alice: saved lunch 120", "bob: receipt jpeg", "dave: saved taxi 250"}, adapter.replies)
+
+	assert.Equal(t, http.StatusUnauthorized, post(wh, "forged", body).Code)
+	assert.Equal(t, http.StatusBadRequest, post(wh, "valid", "not json").Code)
+	assert.Len(t, adapter.replies, 3)
+}
+
+func TestWebhook_Queue(t *testing.T) {
+	adapter := &fakeAdapter{}
+	queue := &recordingQueue{}
+	wh := NewWebhook("fake", adapter, echoProcessor{})
+	wh.SetQueue(queue)
+
+	// Queued messages are answered by the queue's workers; photos are
+	// downloaded before they are queued
+	post(wh, "valid", `[{"id":"1","user":"alice","text":"lunch 120"}]`)
+	require.Len(t, queue.messages, 1)
+	assert.Equal(t, "lunch 120", queue.messages[0].Content)
+
+	wh.SetInline(true)
+	post(wh, "valid", `[{"id":"2","user":"bob","image":"jpeg"}]`)
+	require.Len(t, queue.messages, 2)
+	assert.NotNil(t, queue.messages[1].FirstAttachment(domain.AttachmentTypeImage))
+	assert.Empty(t, adapter.replies)
+}
+
+func TestWebhook_Respond(t *testing.T) {
+	adapter := &fakeAdapter{}
+	wh := NewWebhook("fake", adapter, echoProcessor{})
+	msg := &domain.UserMessage{UserID: "alice"}
+
+	wh.Respond(context.Background(), msg, &domain.MessageResponse{})
+	wh.Respond(context.Background(), msg, &domain.MessageResponse{Text: "Saved"})
+	require.NoError(t, wh.PushMessage(context.Background(), "bob", "Budget exceeded"))
+	assert.Equal(t, []string{"alice: Saved", "bob: Budget exceeded"}, adapter.replies)
+}
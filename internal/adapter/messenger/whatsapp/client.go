@@ -6,10 +6,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
 )
 
+var _ domain.PushNotifier = (*Client)(nil)
+
 // Client represents the WhatsApp Business API client
 type Client struct {
 	phoneNumberID string
@@ -27,17 +32,20 @@ func NewClient(phoneNumberID, accessToken string) (*Client, error) {
 	return &Client{
 		phoneNumberID: phoneNumberID,
 		accessToken:   accessToken,
-		apiURL:        "https://graph.instagram.com/v18.0",
+		apiURL:        "https://graph.facebook.com/v18.0",
 		httpClient:    &http.Client{},
 	}, nil
 }
 
 // SendMessageRequest represents a request to send a message
 type SendMessageRequest struct {
-	MessagingProduct string      `json:"messaging_product"`
-	To               string      `json:"to"`
-	Type             string      `json:"type"`
-	Text             TextMessage `json:"text,omitempty"`
+	MessagingProduct string       `json:"messaging_product"`
+	To               string       `json:"to"`
+	Type             string       `json:"type"`
+	Text             *TextMessage `json:"text,omitempty"`
+	Interactive      *Interactive `json:"interactive,omitempty"`
+	Template         *Template    `json:"template,omitempty"`
+	Image            *ImageLink   `json:"image,omitempty"`
 }
 
 // TextMessage represents a text message
@@ -46,6 +54,12 @@ type TextMessage struct {
 	Body       string `json:"body"`
 }
 
+// ImageLink is an image WhatsApp fetches from a public URL
+type ImageLink struct {
+	Link    string `json:"link"`
+	Caption string `json:"caption,omitempty"`
+}
+
 // WhatsAppAPIResponse represents a response from WhatsApp API
 type WhatsAppAPIResponse struct {
 	Messages []struct {
@@ -58,26 +72,78 @@ type WhatsAppAPIResponse struct {
 	} `json:"error,omitempty"`
 }
 
+// APIError is an error returned by the WhatsApp Cloud API
+type APIError struct {
+	Message string
+	Code    int
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("whatsapp api error: %s (code: %d)", e.Message, e.Code)
+}
+
+// errCodeReengagement is returned for free-form messages to users who haven't
+// written in the last 24 hours; only template messages reach them
+const errCodeReengagement = 131047
+
 // SendMessage sends a message via WhatsApp Business API
 func (c *Client) SendMessage(ctx context.Context, phoneNumber, text string) error {
-	// Ensure phone number format (without +)
-	if len(phoneNumber) > 0 && phoneNumber[0] == '+' {
-		phoneNumber = phoneNumber[1:]
-	}
-
-	req := SendMessageRequest{
-		MessagingProduct: "whatsapp",
-		To:               phoneNumber,
-		Type:             "text",
-		Text: TextMessage{
+	return c.send(ctx, &SendMessageRequest{
+		To:   phoneNumber,
+		Type: "text",
+		Text: &TextMessage{
 			PreviewURL: false,
 			Body:       text,
 		},
+	})
+}
+
+// SendInteractive sends a message with reply buttons or a list
+func (c *Client) SendInteractive(ctx context.Context, phoneNumber string, interactive *Interactive) error {
+	return c.send(ctx, &SendMessageRequest{
+		To:          phoneNumber,
+		Type:        "interactive",
+		Interactive: interactive,
+	})
+}
+
+// SendImage sends the image at a public URL with a caption
+func (c *Client) SendImage(ctx context.Context, phoneNumber, link, caption string) error {
+	return c.send(ctx, &SendMessageRequest{
+		To:    phoneNumber,
+		Type:  "image",
+		Image: &ImageLink{Link: link, Caption: caption},
+	})
+}
+
+// SendTemplate sends an approved template message, which WhatsApp delivers
+// even outside the 24 hours after the user's last message. params fill the
+// template body's {{1}}, {{2}}, ... placeholders.
+func (c *Client) SendTemplate(ctx context.Context, phoneNumber, name, language string, params ...string) error {
+	template := &Template{Name: name, Language: TemplateLanguage{Code: language}}
+	if len(params) > 0 {
+		body := TemplateComponent{Type: "body"}
+		for _, p := range params {
+			body.Parameters = append(body.Parameters, TemplateParameter{Type: "text", Text: p})
+		}
+		template.Components = []TemplateComponent{body}
 	}
+	return c.send(ctx, &SendMessageRequest{
+		To:       phoneNumber,
+		Type:     "template",
+		Template: template,
+	})
+}
+
+// send posts a message to the Cloud API
+func (c *Client) send(ctx context.Context, req *SendMessageRequest) error {
+	// Ensure phone number format (without +)
+	req.To = strings.TrimPrefix(req.To, "+")
+	req.MessagingProduct = "whatsapp"
 
 	payload, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error marshaling request: %v", err)
+		slog.ErrorContext(ctx, "Failed to encode WhatsApp request", "error", err)
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
@@ -92,7 +158,7 @@ func (c *Client) SendMessage(ctx context.Context, phoneNumber, text string) erro
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		log.Printf("Error sending message to WhatsApp: %v", err)
+		slog.ErrorContext(ctx, "Failed to send message to WhatsApp", "error", err)
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 	defer resp.Body.Close()
@@ -108,16 +174,73 @@ func (c *Client) SendMessage(ctx context.Context, phoneNumber, text string) erro
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("whatsapp api error: %s (code: %d)", apiResp.Error.Message, apiResp.Error.Code)
+		return &APIError{Message: apiResp.Error.Message, Code: apiResp.Error.Code}
 	}
 
 	if len(apiResp.Messages) > 0 {
-		log.Printf("[WhatsApp] Message sent to %s (ID: %s)", phoneNumber, apiResp.Messages[0].ID)
+		slog.DebugContext(ctx, "WhatsApp message sent", "to", req.To, "type", req.Type, "message_id", apiResp.Messages[0].ID)
 	}
 
 	return nil
 }
 
+// PushMessage sends a free-form message to a user identified by phone number.
+// WhatsApp only delivers these within 24 hours of the user's last message.
+func (c *Client) PushMessage(ctx context.Context, userID, text string) error {
+	return c.SendMessage(ctx, userID, text)
+}
+
+// DownloadMedia resolves a media ID to its temporary URL and downloads the content
+func (c *Client) DownloadMedia(ctx context.Context, mediaID string) ([]byte, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%s", c.apiURL, mediaID), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.accessToken))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get media info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var media struct {
+		URL      string `json:"url"`
+		MimeType string `json:"mime_type"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, "", fmt.Errorf("whatsapp api error: status %d - %s", resp.StatusCode, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&media); err != nil {
+		return nil, "", fmt.Errorf("failed to parse media info: %w", err)
+	}
+
+	// The media URL also requires the access token
+	mediaReq, err := http.NewRequestWithContext(ctx, "GET", media.URL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	mediaReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.accessToken))
+
+	mediaResp, err := c.httpClient.Do(mediaReq)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download media: %w", err)
+	}
+	defer mediaResp.Body.Close()
+
+	if mediaResp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("whatsapp media download failed: status %d", mediaResp.StatusCode)
+	}
+
+	data, err := io.ReadAll(mediaResp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read media: %w", err)
+	}
+
+	return data, media.MimeType, nil
+}
+
 // UploadMedia uploads media to WhatsApp
 func (c *Client) UploadMedia(ctx context.Context, mediaURL, mediaType string) (string, error) {
 	// This is a placeholder for media upload functionality
@@ -147,6 +270,6 @@ func (c *Client) GetPhoneInfo(ctx context.Context) error {
 		return fmt.Errorf("whatsapp api error: status %d - %s", resp.StatusCode, string(body))
 	}
 
-	log.Printf("[WhatsApp] Phone number verified and connected")
+	slog.InfoContext(ctx, "WhatsApp phone number verified")
 	return nil
 }
@@ -6,34 +6,76 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"io"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/riverlin/aiexpense/internal/adapter/messenger"
 	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/logging"
 )
 
 // MessageProcessor defines the interface for processing messages
-type MessageProcessor interface {
-	Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error)
-}
+type MessageProcessor = messenger.MessageProcessor
 
 // Handler handles WhatsApp webhook events
 type Handler struct {
-	appSecret string
-	phone     string
-	useCase   MessageProcessor
+	*messenger.Webhook
+	appSecret   string
+	verifyToken string
+	phone       string
+	client      *Client
+}
+
+// NewHandler creates a new WhatsApp webhook handler. appSecret verifies the
+// signature of webhook events and verifyToken the subscription handshake, as
+// set up in the Meta app dashboard.
+func NewHandler(appSecret, verifyToken, phoneNumber string, useCase MessageProcessor, client *Client) *Handler {
+	h := &Handler{
+		appSecret:   appSecret,
+		verifyToken: verifyToken,
+		phone:       phoneNumber,
+		client:      client,
+	}
+	h.Webhook = messenger.NewWebhook("whatsapp", h, useCase)
+	return h
+}
+
+// Reply answers a queued message to the number it came from
+func (h *Handler) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	return h.SendReply(ctx, msg, resp)
+}
+
+// SendReply answers a message to the number it came from, with reply buttons
+// when the response has any. Its charts are sent first, as images captioned
+// with their legend; the text stands alone if they can't be sent.
+func (h *Handler) SendReply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	if h.client == nil {
+		return nil
+	}
+	for _, c := range resp.Blocks {
+		if c.Type != domain.ContentBlockChart || c.ImageURL == "" {
+			continue
+		}
+		caption := strings.TrimSpace(c.Title + "\n" + c.Text)
+		if err := h.client.SendImage(ctx, msg.UserID, c.ImageURL, cut(caption, maxBodyText)); err != nil {
+			slog.WarnContext(ctx, "Failed to send WhatsApp chart", "error", err)
+		}
+	}
+	if interactive := buildInteractive(resp); interactive != nil {
+		return h.client.SendInteractive(ctx, msg.UserID, interactive)
+	}
+	return h.client.SendMessage(ctx, msg.UserID, resp.Text)
 }
 
-// NewHandler creates a new WhatsApp webhook handler
-func NewHandler(appSecret, phoneNumber string, useCase MessageProcessor) *Handler {
-	return &Handler{
-		appSecret: appSecret,
-		phone:     phoneNumber,
-		useCase:   useCase,
+// SendPush sends a message to a WhatsApp number
+func (h *Handler) SendPush(ctx context.Context, userID, text string) error {
+	if h.client == nil {
+		return fmt.Errorf("whatsapp client not configured")
 	}
+	return h.client.PushMessage(ctx, userID, text)
 }
 
 // WebhookPayload represents the webhook payload from WhatsApp
@@ -78,6 +120,16 @@ type IncomingMessage struct {
 	Text        TextContent        `json:"text,omitempty"`
 	Button      ButtonContent      `json:"button,omitempty"`
 	Interactive InteractiveContent `json:"interactive,omitempty"`
+	Image       *MediaContent      `json:"image,omitempty"`
+	Audio       *MediaContent      `json:"audio,omitempty"`
+}
+
+// MediaContent represents an image, audio or document attachment
+type MediaContent struct {
+	ID       string `json:"id"`
+	MimeType string `json:"mime_type"`
+	Caption  string `json:"caption,omitempty"`
+	Voice    bool   `json:"voice,omitempty"` // true for voice notes recorded in the app
 }
 
 // TextContent represents text message content
@@ -93,11 +145,12 @@ type ButtonContent struct {
 
 // InteractiveContent represents interactive message content
 type InteractiveContent struct {
-	Type        string      `json:"type"`
+	Type        string      `json:"type"` // "button_reply" or "list_reply"
 	ButtonReply ButtonReply `json:"button_reply,omitempty"`
+	ListReply   ListRow     `json:"list_reply,omitempty"`
 }
 
-// ButtonReply represents a button reply
+// ButtonReply represents a reply button, and the button tapped in a reply
 type ButtonReply struct {
 	ID    string `json:"id"`
 	Title string `json:"title"`
@@ -111,67 +164,55 @@ type MessageStatus struct {
 	Recipient string `json:"recipient_id,omitempty"`
 }
 
-// HandleWebhook handles incoming WhatsApp webhooks
+// HandleWebhook handles incoming WhatsApp webhooks: the subscription
+// handshake and signed events
 func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodGet {
+	switch r.Method {
+	case http.MethodGet:
 		h.handleVerification(w, r)
-		return
-	}
-
-	if r.Method != http.MethodPost {
+	case http.MethodPost:
+		h.Webhook.HandleWebhook(w, r)
+	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
-
-	// Verify webhook signature
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		log.Printf("Error reading request body: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
-	defer r.Body.Close()
-
-	if !h.verifySignature(r.Header.Get("X-Hub-Signature-256"), string(body)) {
-		log.Printf("Webhook signature verification failed")
-		w.WriteHeader(http.StatusUnauthorized)
-		return
-	}
-
-	var payload WebhookPayload
-	if err := json.Unmarshal(body, &payload); err != nil {
-		log.Printf("Error unmarshaling payload: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		return
 	}
-
-	// Process the payload
-	h.processPayload(r, &payload)
-
-	// Always return 200 to acknowledge receipt
-	w.WriteHeader(http.StatusOK)
 }
 
-// handleVerification handles webhook verification from WhatsApp
+// handleVerification answers the handshake Meta performs when the webhook's
+// callback URL is saved: the challenge is echoed back if the verify token matches
 func (h *Handler) handleVerification(w http.ResponseWriter, r *http.Request) {
 	params := r.URL.Query()
 	challenge := params.Get("hub.challenge")
 	token := params.Get("hub.verify_token")
 	mode := params.Get("hub.mode")
 
-	if mode != "subscribe" || token != "verify_token" {
-		w.WriteHeader(http.StatusUnauthorized)
+	if mode != "subscribe" || challenge == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if h.verifyToken == "" || !hmac.Equal([]byte(token), []byte(h.verifyToken)) {
+		slog.WarnContext(r.Context(), "WhatsApp webhook verification failed: verify token mismatch")
+		w.WriteHeader(http.StatusForbidden)
 		return
 	}
 
+	slog.InfoContext(r.Context(), "WhatsApp webhook verified")
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(challenge))
 }
 
-// verifySignature verifies the webhook signature
+// VerifyRequest checks the event's X-Hub-Signature-256 header
+func (h *Handler) VerifyRequest(r *http.Request, body []byte) error {
+	if !h.verifySignature(r.Header.Get("X-Hub-Signature-256"), string(body)) {
+		return messenger.ErrInvalidSignature
+	}
+	return nil
+}
+
+// verifySignature verifies the webhook signature. Without an app secret no
+// event can be trusted, so all are rejected.
 func (h *Handler) verifySignature(signature, payload string) bool {
-	if signature == "" {
+	if signature == "" || h.appSecret == "" {
 		return false
 	}
 
@@ -191,73 +232,93 @@ func (h *Handler) verifySignature(signature, payload string) bool {
 	return hmac.Equal([]byte(expectedHash), []byte(calculatedHash))
 }
 
-// processPayload processes the webhook payload
-func (h *Handler) processPayload(r *http.Request, payload *WebhookPayload) {
+// ExtractMessages reads the messages of the payload's changes
+func (h *Handler) ExtractMessages(ctx context.Context, body []byte) ([]*messenger.Inbound, error) {
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse payload: %w", err)
+	}
+
+	var messages []*messenger.Inbound
 	for _, entry := range payload.Entry {
 		for _, change := range entry.Changes {
-			if change.Field == "messages" {
-				h.processMessages(r, &change.Value)
+			if change.Field != "messages" {
+				continue
+			}
+			for _, msg := range change.Value.Messages {
+				if in := h.inbound(logging.WithUser(ctx, msg.From, "whatsapp"), msg); in != nil {
+					messages = append(messages, in)
+				}
 			}
 		}
 	}
+	return messages, nil
 }
 
-// processMessages processes incoming messages
-func (h *Handler) processMessages(r *http.Request, value *WebhookChangeValue) {
-	for _, msg := range value.Messages {
-		userID := msg.From
-		var messageText string
-
-		switch msg.Type {
-		case "text":
-			messageText = msg.Text.Body
-		case "button":
-			messageText = msg.Button.Payload
-		case "interactive":
-			if msg.Interactive.ButtonReply.Title != "" {
-				messageText = msg.Interactive.ButtonReply.Title
-			}
-		default:
-			log.Printf("Unsupported message type: %s", msg.Type)
-			continue
+// inbound maps an incoming message, returning nil for the ones that can't be
+// answered
+func (h *Handler) inbound(ctx context.Context, msg IncomingMessage) *messenger.Inbound {
+	var messageText, action string
+	var media *MediaContent
+	var mediaType string
+
+	switch msg.Type {
+	case "text":
+		messageText = msg.Text.Body
+	case "button":
+		messageText = msg.Button.Payload
+	case "interactive":
+		reply := msg.Interactive.ButtonReply
+		if msg.Interactive.Type == "list_reply" {
+			reply = ButtonReply{ID: msg.Interactive.ListReply.ID, Title: msg.Interactive.ListReply.Title}
 		}
-
-		if messageText == "" {
-			log.Printf("Empty message from %s", userID)
-			continue
+		// Buttons sent by Reply carry a quick action; others are answered like their title
+		var ok bool
+		if action, messageText, ok = parseReplyID(reply.ID); !ok {
+			messageText = reply.Title
+		}
+	case "image":
+		if msg.Image == nil || h.client == nil {
+			slog.WarnContext(ctx, "Cannot download WhatsApp image")
+			return nil
 		}
+		media, mediaType = msg.Image, domain.AttachmentTypeImage
+		messageText = msg.Image.Caption
+	case "audio":
+		if msg.Audio == nil || h.client == nil {
+			slog.WarnContext(ctx, "Cannot download WhatsApp audio")
+			return nil
+		}
+		media, mediaType = msg.Audio, domain.AttachmentTypeAudio
+	default:
+		slog.InfoContext(ctx, "Unsupported WhatsApp message type", "type", msg.Type)
+		return nil
+	}
 
-		// Handle the message asynchronously
-		go func(uid, text string) {
-			// Map to UserMessage
-			userMsg := &domain.UserMessage{
-				UserID:    uid,
-				Content:   text,
-				Source:    "whatsapp",
-				Timestamp: time.Now(),
-			}
+	if messageText == "" && action == "" && media == nil {
+		slog.InfoContext(ctx, "Empty WhatsApp message")
+		return nil
+	}
 
-			// Execute logic
-			ctx := context.Background()
-			resp, err := h.useCase.Execute(ctx, userMsg)
+	// WhatsApp redelivers unacknowledged messages with the same message ID
+	in := &messenger.Inbound{
+		EventID: msg.ID,
+		Message: &domain.UserMessage{
+			UserID:    msg.From,
+			Content:   messageText,
+			Action:    action,
+			Source:    "whatsapp",
+			Timestamp: time.Now(),
+		},
+	}
+	if media != nil {
+		in.Download = func(ctx context.Context) ([]*domain.Attachment, error) {
+			data, mimeType, err := h.client.DownloadMedia(ctx, media.ID)
 			if err != nil {
-				log.Printf("Error handling message from %s: %v", uid, err)
-			} else {
-				// Send Reply (requires WhatsApp Client which is not implemented in Handler struct here yet, similar to other adapters)
-				// Assuming Client is not part of this refactor scope OR it was missing from original code.
-				// Based on original code reading, WhatsApp handler struct didn't have Client field?
-				// Checking original code...
-				// Original Handler struct: {appSecret, phone, useCase *WhatsAppUseCase}
-				// Original UseCase likely had client?
-				// Since we deleted UseCase, we lost where client was.
-				// However, `Handler` struct in original code `internal/adapter/messenger/whatsapp/handler.go` line 18: `useCase *WhatsAppUseCase`.
-				// UseCase likely had the logic to send message.
-				// For this refactor, we focus on input processing. Reply sending implementation would need a Client injected into Handler.
-				// We'll log the reply for now.
-				if resp.Text != "" {
-					log.Printf("[WhatsApp] Should reply to %s: %s", uid, resp.Text)
-				}
+				return nil, fmt.Errorf("failed to download %s %s: %w", mediaType, media.ID, err)
 			}
-		}(userID, messageText)
+			return []*domain.Attachment{{Type: mediaType, MimeType: mimeType, Data: data}}, nil
+		}
 	}
+	return in
 }
@@ -32,7 +32,7 @@ func (m *MockMessageProcessor) Execute(ctx context.Context, msg *domain.UserMess
 func TestWhatsAppHandler_HandleWebhook_Success(t *testing.T) {
 	// Setup
 	mockUC := new(MockMessageProcessor)
-	handler := NewHandler("test_app_secret", "1234567890", mockUC)
+	handler := NewHandler("test_app_secret", "test_verify_token", "1234567890", mockUC, nil)
 
 	// Expectations
 	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
@@ -104,3 +104,69 @@ func createWhatsAppWebhookPayload(from, text string) ([]byte, string) {
 
 	return body, signature
 }
+
+func TestWhatsAppHandler_Verification(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		verify     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"matching token", "hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=test_verify_token", "test_verify_token", http.StatusOK, "1158201444"},
+		{"wrong token", "hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=guess", "test_verify_token", http.StatusForbidden, ""},
+		{"token not configured", "hub.mode=subscribe&hub.challenge=1158201444&hub.verify_token=", "", http.StatusForbidden, ""},
+		{"not a subscription", "hub.mode=unsubscribe&hub.challenge=1158201444&hub.verify_token=test_verify_token", "test_verify_token", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler("test_app_secret", tt.verify, "1234567890", new(MockMessageProcessor), nil)
+
+			req := httptest.NewRequest("GET", "/webhook/whatsapp?"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.HandleWebhook(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWhatsAppHandler_HandleWebhook_RejectsUnverified(t *testing.T) {
+	mockUC := new(MockMessageProcessor)
+	payload, signature := createWhatsAppWebhookPayload("1234567890", "breakfast $20")
+
+	tests := []struct {
+		name      string
+		appSecret string
+		signature string
+	}{
+		{"missing signature", "test_app_secret", ""},
+		{"wrong signature", "test_app_secret", "sha256=00"},
+		{"app secret not configured", "", signature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(tt.appSecret, "test_verify_token", "1234567890", mockUC, nil)
+
+			req := httptest.NewRequest("POST", "/webhook/whatsapp", bytes.NewReader(payload))
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			w := httptest.NewRecorder()
+			handler.HandleWebhook(w, req)
+
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+			}
+		})
+	}
+
+	mockUC.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+}
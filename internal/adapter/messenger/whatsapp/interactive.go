@@ -0,0 +1,160 @@
+package whatsapp
+
+import (
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Interactive message limits: https://developers.facebook.com/docs/whatsapp/cloud-api/messages/interactive-reply-buttons-messages
+const (
+	maxBodyText      = 1024
+	maxReplyButtons  = 3
+	maxButtonTitle   = 20
+	maxButtonID      = 256
+	maxListRows      = 10
+	maxRowTitle      = 24
+	maxRowDesc       = 72
+	maxRowID         = 200
+	listMenuLabel    = "Choose"
+	replyIDSeparator = "|"
+)
+
+// Interactive is the content of an interactive message: up to three reply
+// buttons, or a list whose rows open from a menu button
+type Interactive struct {
+	Type   string            `json:"type"` // "button" or "list"
+	Body   InteractiveBody   `json:"body"`
+	Action InteractiveAction `json:"action"`
+}
+
+// InteractiveBody is the text of an interactive message
+type InteractiveBody struct {
+	Text string `json:"text"`
+}
+
+// InteractiveAction holds the reply buttons, or the list menu label and rows
+type InteractiveAction struct {
+	Buttons  []InteractiveButton `json:"buttons,omitempty"`
+	Button   string              `json:"button,omitempty"`
+	Sections []ListSection       `json:"sections,omitempty"`
+}
+
+// InteractiveButton is a reply button
+type InteractiveButton struct {
+	Type  string      `json:"type"` // "reply"
+	Reply ButtonReply `json:"reply"`
+}
+
+// ListSection is a group of list rows
+type ListSection struct {
+	Title string    `json:"title,omitempty"`
+	Rows  []ListRow `json:"rows"`
+}
+
+// ListRow is a row of a list message, also sent back when the user picks it
+type ListRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// choice is an option offered as a reply button or a list row
+type choice struct {
+	title       string
+	description string
+	id          string
+}
+
+// buildInteractive offers the response's action buttons, or buttons to change
+// or delete the expenses it recorded, as reply buttons or as a list when there
+// are more than three. URL buttons are left out: the link is in the text. It
+// returns nil when plain text says it all.
+func buildInteractive(resp *domain.MessageResponse) *Interactive {
+	var choices []choice
+	for _, b := range resp.Buttons {
+		if b.Action != "" {
+			choices = append(choices, choice{title: b.Label, id: replyID(b.Action, b.Value)})
+		}
+	}
+	if len(choices) == 0 {
+		choices = expenseChoices(resp.Data)
+	}
+	if len(choices) == 0 || resp.Text == "" || len([]rune(resp.Text)) > maxBodyText {
+		return nil
+	}
+
+	interactive := &Interactive{Body: InteractiveBody{Text: resp.Text}}
+	if len(choices) <= maxReplyButtons {
+		interactive.Type = "button"
+		for _, c := range choices {
+			if len(c.id) > maxButtonID {
+				return nil
+			}
+			interactive.Action.Buttons = append(interactive.Action.Buttons, InteractiveButton{
+				Type:  "reply",
+				Reply: ButtonReply{ID: c.id, Title: cut(c.title, maxButtonTitle)},
+			})
+		}
+		return interactive
+	}
+
+	// A list holds ten rows; further choices, such as a long tail of
+	// categories, are left out
+	interactive.Type = "list"
+	interactive.Action.Button = listMenuLabel
+	var rows []ListRow
+	for _, c := range choices[:min(len(choices), maxListRows)] {
+		if len(c.id) > maxRowID {
+			return nil
+		}
+		rows = append(rows, ListRow{ID: c.id, Title: cut(c.title, maxRowTitle), Description: cut(c.description, maxRowDesc)})
+	}
+	interactive.Action.Sections = []ListSection{{Rows: rows}}
+	return interactive
+}
+
+// expenseChoices offers to change the category of or delete each expense in
+// the response's data: two buttons for a single expense, list rows for more
+func expenseChoices(data interface{}) []choice {
+	expenses, _ := data.([]map[string]interface{})
+	var choices []choice
+	for _, expense := range expenses {
+		id, _ := expense["id"].(string)
+		if id == "" {
+			continue
+		}
+		description, _ := expense["description"].(string)
+		choices = append(choices,
+			choice{title: description, description: "Change category", id: replyID(domain.MessageActionChangeCategory, id)},
+			choice{title: description, description: "Delete", id: replyID(domain.MessageActionDeleteExpense, id)},
+		)
+	}
+	if len(choices) == 2 {
+		choices[0].title, choices[1].title = "Change category", "Delete"
+	}
+	return choices
+}
+
+// replyID encodes a quick action and its argument as a button or row ID
+func replyID(action, value string) string {
+	return action + replyIDSeparator + value
+}
+
+// parseReplyID decodes the quick action and argument of a tapped button or
+// picked row; IDs of buttons sent elsewhere, such as in templates, don't parse
+func parseReplyID(id string) (string, string, bool) {
+	action, value, ok := strings.Cut(id, replyIDSeparator)
+	if !ok || action == "" {
+		return "", "", false
+	}
+	return action, value, true
+}
+
+// cut shortens text to n characters
+func cut(text string, n int) string {
+	if runes := []rune(text); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return text
+}
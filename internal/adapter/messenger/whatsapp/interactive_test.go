@@ -0,0 +1,244 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildInteractive(t *testing.T) {
+	t.Run("plain text", func(t *testing.T) {
+		assert.Nil(t, buildInteractive(&domain.MessageResponse{Text: "Saved"}))
+	})
+
+	t.Run("URL buttons stay in the text", func(t *testing.T) {
+		resp := &domain.MessageResponse{Text: "Full report", Buttons: []*domain.MessageButton{{Label: "Open report", URL: "https://example.com/r"}}}
+		assert.Nil(t, buildInteractive(resp))
+	})
+
+	t.Run("single expense", func(t *testing.T) {
+		resp := &domain.MessageResponse{
+			Text: "✓ Lunch 120",
+			Data: []map[string]interface{}{{"id": "exp-1", "description": "Lunch"}},
+		}
+		interactive := buildInteractive(resp)
+		require.NotNil(t, interactive)
+		assert.Equal(t, "button", interactive.Type)
+		assert.Equal(t, "✓ Lunch 120", interactive.Body.Text)
+		require.Len(t, interactive.Action.Buttons, 2)
+		assert.Equal(t, ButtonReply{ID: "change_category|exp-1", Title: "Change category"}, interactive.Action.Buttons[0].Reply)
+		assert.Equal(t, ButtonReply{ID: "delete_expense|exp-1", Title: "Delete"}, interactive.Action.Buttons[1].Reply)
+	})
+
+	t.Run("several expenses", func(t *testing.T) {
+		resp := &domain.MessageResponse{
+			Text: "✓ 2 expenses",
+			Data: []map[string]interface{}{
+				{"id": "exp-1", "description": "Breakfast"},
+				{"id": "exp-2", "description": "Lunch"},
+			},
+		}
+		interactive := buildInteractive(resp)
+		require.NotNil(t, interactive)
+		assert.Equal(t, "list", interactive.Type)
+		require.Len(t, interactive.Action.Sections, 1)
+		rows := interactive.Action.Sections[0].Rows
+		require.Len(t, rows, 4)
+		assert.Equal(t, ListRow{ID: "change_category|exp-1", Title: "Breakfast", Description: "Change category"}, rows[0])
+		assert.Equal(t, ListRow{ID: "delete_expense|exp-2", Title: "Lunch", Description: "Delete"}, rows[3])
+	})
+
+	t.Run("category list", func(t *testing.T) {
+		resp := &domain.MessageResponse{Text: "Pick the new category:"}
+		for i := 0; i < 12; i++ {
+			resp.Buttons = append(resp.Buttons, &domain.MessageButton{
+				Label:  fmt.Sprintf("A very long category name %d", i),
+				Action: domain.MessageActionChangeCategory,
+				Value:  fmt.Sprintf("exp-1 cat-%d", i),
+			})
+		}
+		interactive := buildInteractive(resp)
+		require.NotNil(t, interactive)
+		assert.Equal(t, "list", interactive.Type)
+		assert.Equal(t, "Choose", interactive.Action.Button)
+		rows := interactive.Action.Sections[0].Rows
+		require.Len(t, rows, maxListRows)
+		assert.Equal(t, "change_category|exp-1 cat-0", rows[0].ID)
+		assert.Len(t, []rune(rows[0].Title), maxRowTitle)
+	})
+}
+
+func TestParseReplyID(t *testing.T) {
+	action, value, ok := parseReplyID("change_category|exp-1 cat-2")
+	assert.True(t, ok)
+	assert.Equal(t, domain.MessageActionChangeCategory, action)
+	assert.Equal(t, "exp-1 cat-2", value)
+
+	_, _, ok = parseReplyID("yes")
+	assert.False(t, ok)
+}
+
+func TestWhatsAppHandler_InteractiveReply(t *testing.T) {
+	sent := make(chan SendMessageRequest, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SendMessageRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent <- req
+		w.Write([]byte(`{"messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer api.Close()
+	client, _ := NewClient("phone_id_123", "token")
+	client.apiURL = api.URL
+
+	mockUC := new(MockMessageProcessor)
+	mockUC.On("Execute", mock.Anything, mock.MatchedBy(func(msg *domain.UserMessage) bool {
+		return msg.Action == domain.MessageActionChangeCategory && msg.Content == "exp-1"
+	})).Return(&domain.MessageResponse{
+		Text: "Pick the new category:",
+		Buttons: []*domain.MessageButton{
+			{Label: "Food", Action: domain.MessageActionChangeCategory, Value: "exp-1 cat-1"},
+			{Label: "Transport", Action: domain.MessageActionChangeCategory, Value: "exp-1 cat-2"},
+		},
+	}, nil)
+	handler := NewHandler("test_app_secret", "test_verify_token", "1234567890", mockUC, client)
+
+	payload := WebhookPayload{
+		Object: "whatsapp_business_account",
+		Entry: []WebhookEntry{{Changes: []WebhookChange{{
+			Field: "messages",
+			Value: WebhookChangeValue{Messages: []IncomingMessage{{
+				From: "886912345678",
+				ID:   "msg_456",
+				Type: "interactive",
+				Interactive: InteractiveContent{
+					Type:        "button_reply",
+					ButtonReply: ButtonReply{ID: "change_category|exp-1", Title: "Change category"},
+				},
+			}}},
+		}}}},
+	}
+	body, _ := json.Marshal(payload)
+	hash := hmac.New(sha256.New, []byte("test_app_secret"))
+	hash.Write(body)
+
+	req := httptest.NewRequest("POST", "/webhook/whatsapp", bytes.NewReader(body))
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(hash.Sum(nil)))
+	w := httptest.NewRecorder()
+	handler.HandleWebhook(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The reply is sent from a goroutine
+	var reply SendMessageRequest
+	select {
+	case reply = <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the reply")
+	}
+
+	mockUC.AssertExpectations(t)
+	assert.Equal(t, "interactive", reply.Type)
+	assert.Equal(t, "886912345678", reply.To)
+	require.NotNil(t, reply.Interactive)
+	assert.Equal(t, "button", reply.Interactive.Type)
+	assert.Equal(t, "change_category|exp-1 cat-2", reply.Interactive.Action.Buttons[1].Reply.ID)
+}
+
+func TestWhatsAppHandler_SendReply_Charts(t *testing.T) {
+	sent := make(chan SendMessageRequest, 3)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SendMessageRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent <- req
+		w.Write([]byte(`{"messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer api.Close()
+	client, _ := NewClient("phone_id_123", "token")
+	client.apiURL = api.URL
+	handler := NewHandler("test_app_secret", "test_verify_token", "1234567890", new(MockMessageProcessor), client)
+
+	err := handler.SendReply(context.Background(), &domain.UserMessage{UserID: "886912345678"}, &domain.MessageResponse{
+		Text: "📊 This month: 1,980 across 3 expenses",
+		Blocks: []*domain.ContentBlock{
+			{Type: domain.ContentBlockCard, Title: "This month", Text: "1,980"},
+			{Type: domain.ContentBlockChart, Title: "Spending by category", Text: "🟥 Food 1,230 (62%)", ImageURL: "https://example.com/charts/pie.png?v=1230,750"},
+		},
+	})
+	require.NoError(t, err)
+
+	// The chart, then the text
+	close(sent)
+	var messages []SendMessageRequest
+	for req := range sent {
+		messages = append(messages, req)
+	}
+	require.Len(t, messages, 2)
+	assert.Equal(t, "image", messages[0].Type)
+	assert.Equal(t, &ImageLink{Link: "https://example.com/charts/pie.png?v=1230,750", Caption: "Spending by category\n🟥 Food 1,230 (62%)"}, messages[0].Image)
+	assert.Equal(t, "text", messages[1].Type)
+	assert.Equal(t, "📊 This month: 1,980 across 3 expenses", messages[1].Text.Body)
+}
+
+func TestTemplateNotifier_PushMessage(t *testing.T) {
+	var mu sync.Mutex
+	var sent []SendMessageRequest
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req SendMessageRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		sent = append(sent, req)
+		mu.Unlock()
+		if req.Type == "text" && req.To == "886900000000" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"Re-engagement message","code":131047}}`))
+			return
+		}
+		w.Write([]byte(`{"messages":[{"id":"wamid.1"}]}`))
+	}))
+	defer api.Close()
+	client, _ := NewClient("phone_id_123", "token")
+	client.apiURL = api.URL
+	notifier := NewTemplateNotifier(client, "budget_alert", "en")
+
+	// takeSent returns the messages sent so far and forgets them
+	takeSent := func() []SendMessageRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		taken := sent
+		sent = nil
+		return taken
+	}
+
+	t.Run("inside the 24-hour window", func(t *testing.T) {
+		takeSent()
+		require.NoError(t, notifier.PushMessage(context.Background(), "886912345678", "🚨 Food monthly budget exceeded"))
+		sent := takeSent()
+		require.Len(t, sent, 1)
+		assert.Equal(t, "text", sent[0].Type)
+	})
+
+	t.Run("outside the 24-hour window", func(t *testing.T) {
+		takeSent()
+		require.NoError(t, notifier.PushMessage(context.Background(), "+886900000000", "🚨 Food monthly\nbudget exceeded"))
+		sent := takeSent()
+		require.Len(t, sent, 2)
+		assert.Equal(t, "template", sent[1].Type)
+		require.NotNil(t, sent[1].Template)
+		assert.Equal(t, "budget_alert", sent[1].Template.Name)
+		assert.Equal(t, "en", sent[1].Template.Language.Code)
+		assert.Equal(t, []TemplateComponent{{Type: "body", Parameters: []TemplateParameter{{Type: "text", Text: "🚨 Food monthly budget exceeded"}}}}, sent[1].Template.Components)
+	})
+}
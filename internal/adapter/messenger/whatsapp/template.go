@@ -0,0 +1,75 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.PushNotifier = (*TemplateNotifier)(nil)
+
+// Template is a template message, approved in WhatsApp Manager beforehand
+type Template struct {
+	Name       string              `json:"name"`
+	Language   TemplateLanguage    `json:"language"`
+	Components []TemplateComponent `json:"components,omitempty"`
+}
+
+// TemplateLanguage is the language of the template translation to send
+type TemplateLanguage struct {
+	Code string `json:"code"`
+}
+
+// TemplateComponent fills a part of the template, such as its body
+type TemplateComponent struct {
+	Type       string              `json:"type"`
+	Parameters []TemplateParameter `json:"parameters"`
+}
+
+// TemplateParameter is the value of a template placeholder
+type TemplateParameter struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// TemplateNotifier pushes notifications as free-form messages, falling back
+// to a template message for users who haven't written in the last 24 hours,
+// whom WhatsApp only lets businesses reach with templates. The template's
+// body must have a single {{1}} placeholder, which gets the notification text.
+type TemplateNotifier struct {
+	client   *Client
+	name     string
+	language string
+}
+
+// NewTemplateNotifier creates a notifier that falls back to the named template
+// in the given language, e.g. "en_US"
+func NewTemplateNotifier(client *Client, name, language string) *TemplateNotifier {
+	return &TemplateNotifier{
+		client:   client,
+		name:     name,
+		language: language,
+	}
+}
+
+// PushMessage sends the text, as a template message if the user is outside
+// the 24-hour window
+func (n *TemplateNotifier) PushMessage(ctx context.Context, userID, text string) error {
+	err := n.client.PushMessage(ctx, userID, text)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != errCodeReengagement {
+		return err
+	}
+
+	slog.InfoContext(ctx, "WhatsApp user outside the 24-hour window, sending template", "template", n.name)
+	return n.client.SendTemplate(ctx, userID, n.name, n.language, templateParam(text))
+}
+
+// templateParam makes text fit a template parameter, which can't contain
+// new lines, tabs or more than four spaces in a row
+func templateParam(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS budgets (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  category_id TEXT,
+  limit_amount DECIMAL NOT NULL,
+  period TEXT NOT NULL DEFAULT 'monthly',
+  threshold DECIMAL NOT NULL DEFAULT 80,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_budgets_user ON budgets(user_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_budgets_group;
+DROP INDEX IF EXISTS idx_expenses_group_date;
+
+ALTER TABLE budgets DROP COLUMN group_id;
+
+ALTER TABLE expenses DROP COLUMN group_id;
+
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS expense_groups;
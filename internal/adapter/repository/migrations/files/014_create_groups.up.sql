@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS expense_groups (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL DEFAULT '',
+  messenger_type TEXT NOT NULL,
+  external_id TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE(messenger_type, external_id)
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+  group_id TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  role TEXT NOT NULL DEFAULT 'member',
+  joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (group_id, user_id),
+  FOREIGN KEY (group_id) REFERENCES expense_groups(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+
+ALTER TABLE expenses ADD COLUMN group_id TEXT;
+
+ALTER TABLE budgets ADD COLUMN group_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_group_members_user ON group_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_expenses_group_date ON expenses(group_id, expense_date);
+CREATE INDEX IF NOT EXISTS idx_budgets_group ON budgets(group_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_expense_splits_payer;
+DROP INDEX IF EXISTS idx_expense_splits_expense;
+
+DROP TABLE IF EXISTS expense_splits;
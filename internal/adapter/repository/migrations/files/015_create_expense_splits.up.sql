@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS expense_splits (
+  id TEXT PRIMARY KEY,
+  expense_id TEXT NOT NULL,
+  payer_id TEXT NOT NULL,
+  participant TEXT NOT NULL,
+  share_amount DECIMAL NOT NULL,
+  percentage DECIMAL NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE,
+  FOREIGN KEY (payer_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_splits_expense ON expense_splits(expense_id);
+CREATE INDEX IF NOT EXISTS idx_expense_splits_payer ON expense_splits(payer_id);
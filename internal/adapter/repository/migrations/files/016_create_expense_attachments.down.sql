@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_expense_attachments_expense;
+
+DROP TABLE IF EXISTS expense_attachments;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS expense_attachments (
+  id TEXT PRIMARY KEY,
+  expense_id TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  mime_type TEXT NOT NULL DEFAULT '',
+  size_bytes BIGINT NOT NULL DEFAULT 0,
+  storage_key TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_attachments_expense ON expense_attachments(expense_id);
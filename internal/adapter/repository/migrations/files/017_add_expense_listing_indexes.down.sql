@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_expenses_user_created_id;
+DROP INDEX IF EXISTS idx_expenses_user_amount_id;
+DROP INDEX IF EXISTS idx_expenses_user_date_id;
@@ -0,0 +1,4 @@
+-- Keyset pagination for expense listings: each sort key is paired with id as a tie-breaker
+CREATE INDEX IF NOT EXISTS idx_expenses_user_date_id ON expenses(user_id, expense_date, id);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_amount_id ON expenses(user_id, home_amount, id);
+CREATE INDEX IF NOT EXISTS idx_expenses_user_created_id ON expenses(user_id, created_at, id);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS processed_events (
+  messenger TEXT NOT NULL,
+  event_id TEXT NOT NULL,
+  processed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (messenger, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_events_processed_at ON processed_events(processed_at);
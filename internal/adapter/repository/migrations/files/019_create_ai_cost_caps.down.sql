@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_ai_cost_logs_user_created;
+DROP TABLE IF EXISTS ai_cost_caps;
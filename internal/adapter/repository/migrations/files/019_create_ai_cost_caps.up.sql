@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS ai_cost_caps (
+  scope TEXT PRIMARY KEY,
+  daily_usd DECIMAL(10, 4) NOT NULL DEFAULT 0,
+  monthly_usd DECIMAL(10, 4) NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ai_cost_logs_user_created ON ai_cost_logs(user_id, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE ai_cost_logs DROP COLUMN prompt_version;
+DROP TABLE IF EXISTS prompt_templates;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS prompt_templates (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  version INTEGER NOT NULL,
+  template TEXT NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (name, version)
+);
+
+ALTER TABLE ai_cost_logs ADD COLUMN prompt_version INTEGER NOT NULL DEFAULT 0;
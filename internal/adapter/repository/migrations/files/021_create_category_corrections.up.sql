@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS category_corrections (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  expense_id TEXT NOT NULL,
+  description TEXT NOT NULL,
+  from_category_id TEXT,
+  to_category_id TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_category_corrections_user_created ON category_corrections(user_id, created_at);
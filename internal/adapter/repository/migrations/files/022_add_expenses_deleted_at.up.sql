@@ -0,0 +1,3 @@
+ALTER TABLE expenses ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_expenses_deleted_at ON expenses(deleted_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS expense_audit_log (
+  id TEXT PRIMARY KEY,
+  expense_id TEXT NOT NULL,
+  user_id TEXT NOT NULL,
+  action TEXT NOT NULL,
+  actor TEXT NOT NULL,
+  channel TEXT NOT NULL,
+  before_snapshot TEXT,
+  after_snapshot TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_audit_log_expense_created ON expense_audit_log(expense_id, created_at);
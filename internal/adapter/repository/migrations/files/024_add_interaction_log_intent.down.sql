@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_interaction_logs_intent_timestamp;
+
+ALTER TABLE interaction_logs DROP COLUMN intent;
+ALTER TABLE interaction_logs DROP COLUMN source;
@@ -0,0 +1,4 @@
+ALTER TABLE interaction_logs ADD COLUMN source TEXT NOT NULL DEFAULT '';
+ALTER TABLE interaction_logs ADD COLUMN intent TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_interaction_logs_intent_timestamp ON interaction_logs(intent, timestamp);
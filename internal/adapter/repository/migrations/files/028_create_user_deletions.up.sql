@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS user_deletions (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  status TEXT NOT NULL,
+  requested_at TIMESTAMP NOT NULL,
+  purge_after TIMESTAMP NOT NULL,
+  purged_at TIMESTAMP,
+  deleted_counts TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_deletions_user ON user_deletions(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_user_deletions_due ON user_deletions(status, purge_after);
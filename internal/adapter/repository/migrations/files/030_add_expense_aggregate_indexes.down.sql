@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_expenses_group_date_totals;
+DROP INDEX IF EXISTS idx_expenses_user_date_totals;
@@ -0,0 +1,4 @@
+-- Covering indexes for report and budget aggregates, so sums by category and
+-- day over a date range are answered from the index alone
+CREATE INDEX IF NOT EXISTS idx_expenses_user_date_totals ON expenses(user_id, expense_date) INCLUDE (category_id, home_amount) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_group_date_totals ON expenses(group_id, expense_date) INCLUDE (category_id, home_amount) WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_interaction_logs_source_timestamp;
+DROP INDEX IF EXISTS idx_ai_cost_logs_source_created;
+
+ALTER TABLE ai_cost_logs DROP COLUMN source;
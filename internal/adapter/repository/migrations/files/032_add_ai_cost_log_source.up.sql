@@ -0,0 +1,5 @@
+-- Messenger type the AI call was made for, so cost can be broken down by platform
+ALTER TABLE ai_cost_logs ADD COLUMN source TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_ai_cost_logs_source_created ON ai_cost_logs(source, created_at);
+CREATE INDEX IF NOT EXISTS idx_interaction_logs_source_timestamp ON interaction_logs(source, timestamp);
@@ -0,0 +1,12 @@
+-- Days whose AI spend far exceeded the rolling average of the days before;
+-- the admin dashboard lists them as alerts
+CREATE TABLE IF NOT EXISTS ai_cost_anomalies (
+  id TEXT PRIMARY KEY,
+  anomaly_date DATE NOT NULL UNIQUE,
+  cost DECIMAL(18, 10) NOT NULL,
+  calls INT NOT NULL DEFAULT 0,
+  baseline_cost DECIMAL(18, 10) NOT NULL,
+  ratio DECIMAL(12, 4) NOT NULL,
+  detected_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,19 @@
+-- Messenger replies, persisted before they are sent and retried until the
+-- messenger accepts them
+CREATE TABLE IF NOT EXISTS reply_outbox (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  messenger TEXT NOT NULL,
+  group_chat_id TEXT NOT NULL DEFAULT '',
+  metadata TEXT NOT NULL,
+  text TEXT NOT NULL,
+  status TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  delivered_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reply_outbox_due ON reply_outbox(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_reply_outbox_created ON reply_outbox(created_at);
@@ -0,0 +1,13 @@
+-- Email addresses bound to users, whose forwarded receipts are recorded as
+-- the user's expenses; unverified addresses hold the hash of the code sent
+CREATE TABLE IF NOT EXISTS email_addresses (
+  address TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  code_hash TEXT NOT NULL DEFAULT '',
+  code_expires_at TIMESTAMP,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  verified_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_addresses_user ON email_addresses(user_id);
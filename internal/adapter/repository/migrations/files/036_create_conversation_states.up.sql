@@ -0,0 +1,12 @@
+-- The clarification question each user was last asked, answered by their
+-- next message until it expires; slots is a JSON object
+CREATE TABLE IF NOT EXISTS conversation_states (
+  user_id TEXT PRIMARY KEY,
+  intent TEXT NOT NULL,
+  awaiting TEXT NOT NULL DEFAULT '',
+  slots TEXT NOT NULL DEFAULT '{}',
+  expires_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_states_expires ON conversation_states(expires_at);
@@ -0,0 +1,5 @@
+-- Set once a user finishes or skips the onboarding wizard; existing users
+-- were onboarded before there was one
+ALTER TABLE users ADD COLUMN onboarded_at TIMESTAMP;
+
+UPDATE users SET onboarded_at = created_at;
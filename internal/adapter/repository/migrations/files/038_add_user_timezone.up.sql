@@ -0,0 +1,3 @@
+-- The user's IANA timezone, which relative dates and report periods are
+-- resolved in; empty for the server's
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
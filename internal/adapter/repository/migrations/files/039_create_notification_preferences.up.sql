@@ -0,0 +1,13 @@
+-- The notification preferences of users who changed the defaults
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  user_id TEXT PRIMARY KEY,
+  budget_alerts BOOLEAN NOT NULL DEFAULT TRUE,
+  recurring_reminders BOOLEAN NOT NULL DEFAULT TRUE,
+  report_notifications BOOLEAN NOT NULL DEFAULT TRUE,
+  expense_reminders BOOLEAN NOT NULL DEFAULT FALSE,
+  daily_digest BOOLEAN NOT NULL DEFAULT FALSE,
+  daily_digest_hour INTEGER NOT NULL DEFAULT 8,
+  weekly_report BOOLEAN NOT NULL DEFAULT TRUE,
+  last_daily_digest_at TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+ALTER TABLE reply_outbox DROP COLUMN push;
+ALTER TABLE notification_preferences DROP COLUMN last_weekly_report_at;
@@ -0,0 +1,6 @@
+-- When each user was last pushed the weekly report
+ALTER TABLE notification_preferences ADD COLUMN last_weekly_report_at TIMESTAMP;
+
+-- Pushed messages, such as the weekly report, are sent with the messenger's
+-- notifier instead of replying to a message
+ALTER TABLE reply_outbox ADD COLUMN push BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+ALTER TABLE notification_preferences DROP COLUMN last_expense_reminder_at;
+ALTER TABLE notification_preferences DROP COLUMN quiet_hours_end;
+ALTER TABLE notification_preferences DROP COLUMN quiet_hours_start;
+ALTER TABLE notification_preferences DROP COLUMN expense_reminder_days;
@@ -0,0 +1,6 @@
+-- Inactivity reminders: how many days without an expense before one is sent,
+-- the hours of the day they are held back, and when the last one went out
+ALTER TABLE notification_preferences ADD COLUMN expense_reminder_days INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE notification_preferences ADD COLUMN quiet_hours_start INTEGER NOT NULL DEFAULT 22;
+ALTER TABLE notification_preferences ADD COLUMN quiet_hours_end INTEGER NOT NULL DEFAULT 8;
+ALTER TABLE notification_preferences ADD COLUMN last_expense_reminder_at TIMESTAMP;
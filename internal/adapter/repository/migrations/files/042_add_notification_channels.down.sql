@@ -0,0 +1 @@
+ALTER TABLE notification_preferences DROP COLUMN channels;
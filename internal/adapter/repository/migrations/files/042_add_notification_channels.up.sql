@@ -0,0 +1,3 @@
+-- The channel each notification goes through, as a JSON object keyed by
+-- notification, e.g. {"weekly_report": "email"}; missing ones are pushed
+ALTER TABLE notification_preferences ADD COLUMN channels TEXT NOT NULL DEFAULT '{}';
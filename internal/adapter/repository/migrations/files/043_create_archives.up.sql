@@ -0,0 +1,21 @@
+-- Expense archives: the expenses themselves are a compressed bundle in object
+-- storage under object_key, so only the metadata is kept here
+CREATE TABLE IF NOT EXISTS archives (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  period TEXT NOT NULL,
+  start_date TIMESTAMP NOT NULL,
+  end_date TIMESTAMP NOT NULL,
+  expense_count INTEGER NOT NULL DEFAULT 0,
+  total_amount DECIMAL NOT NULL DEFAULT 0,
+  format TEXT NOT NULL,
+  object_key TEXT NOT NULL,
+  checksum TEXT NOT NULL,
+  size_bytes BIGINT NOT NULL DEFAULT 0,
+  compressed_size_bytes BIGINT NOT NULL DEFAULT 0,
+  retention_days INTEGER NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archives_user ON archives(user_id, created_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_archives_created;
+DROP TABLE IF EXISTS archive_policies;
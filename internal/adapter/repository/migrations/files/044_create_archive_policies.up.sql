@@ -0,0 +1,9 @@
+-- Per-user archive policies; users without one follow the global policy
+CREATE TABLE IF NOT EXISTS archive_policies (
+  user_id TEXT PRIMARY KEY,
+  archive_after_months INTEGER NOT NULL DEFAULT 0,
+  purge_after_days INTEGER NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_archives_created ON archives(created_at);
@@ -0,0 +1,3 @@
+-- pg_trgm is left installed, other databases on the server may use it
+DROP INDEX IF EXISTS idx_expenses_description_trgm;
+DROP INDEX IF EXISTS idx_expenses_description_fts;
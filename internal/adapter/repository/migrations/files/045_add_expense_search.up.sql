@@ -0,0 +1,5 @@
+-- Full-text search on expense descriptions, plus trigram matching for
+-- substrings (CJK descriptions are one token) and misspelled words
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_expenses_description_fts ON expenses USING GIN (to_tsvector('simple', description));
+CREATE INDEX IF NOT EXISTS idx_expenses_description_trgm ON expenses USING GIN (description gin_trgm_ops);
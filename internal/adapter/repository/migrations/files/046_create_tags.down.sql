@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_expense_tags_tag;
+DROP TABLE IF EXISTS expense_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,19 @@
+-- Tags label expenses across categories, e.g. #出差
+CREATE TABLE IF NOT EXISTS tags (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS expense_tags (
+  expense_id TEXT NOT NULL,
+  tag_id TEXT NOT NULL,
+  PRIMARY KEY (expense_id, tag_id),
+  FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE,
+  FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_tags_tag ON expense_tags(tag_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_expenses_merchant_date;
+
+ALTER TABLE expenses DROP COLUMN merchant_id;
+
+DROP TABLE IF EXISTS merchants;
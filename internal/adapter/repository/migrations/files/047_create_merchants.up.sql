@@ -0,0 +1,15 @@
+-- Merchants are the stores expenses were paid at, one per normalized name, so
+-- "7-11" and "7-Eleven 信義店" count as the same store
+CREATE TABLE IF NOT EXISTS merchants (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  normalized_name TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE (user_id, normalized_name)
+);
+
+ALTER TABLE expenses ADD COLUMN merchant_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_expenses_merchant_date ON expenses(merchant_id, expense_date);
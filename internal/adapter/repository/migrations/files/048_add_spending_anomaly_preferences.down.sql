@@ -0,0 +1,3 @@
+ALTER TABLE notification_preferences DROP COLUMN last_anomaly_check_at;
+ALTER TABLE notification_preferences DROP COLUMN anomaly_sensitivity;
+ALTER TABLE notification_preferences DROP COLUMN spending_anomalies;
@@ -0,0 +1,5 @@
+-- Spending anomaly alerts: whether the user gets them, how unusual spending
+-- must be before one is sent, and up to when their expenses were checked
+ALTER TABLE notification_preferences ADD COLUMN spending_anomalies BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE notification_preferences ADD COLUMN anomaly_sensitivity VARCHAR(10) NOT NULL DEFAULT 'medium';
+ALTER TABLE notification_preferences ADD COLUMN last_anomaly_check_at TIMESTAMP;
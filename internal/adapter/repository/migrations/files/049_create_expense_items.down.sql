@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_expense_items_expense;
+DROP TABLE IF EXISTS expense_items;
@@ -0,0 +1,13 @@
+-- Expense items are the lines of the receipt an expense was read from
+CREATE TABLE IF NOT EXISTS expense_items (
+  id TEXT PRIMARY KEY,
+  expense_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  quantity DECIMAL NOT NULL DEFAULT 1,
+  unit_price DECIMAL NOT NULL,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_expense_items_expense ON expense_items(expense_id, position);
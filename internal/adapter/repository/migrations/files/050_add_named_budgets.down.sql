@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN active_budget;
+ALTER TABLE budgets DROP COLUMN end_date;
+ALTER TABLE budgets DROP COLUMN start_date;
+ALTER TABLE budgets DROP COLUMN name;
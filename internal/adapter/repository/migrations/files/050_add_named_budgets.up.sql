@@ -0,0 +1,7 @@
+-- Named budgets: a budget belongs to the named budget it is part of ('' for
+-- the everyday one) and may cover a date range, like a trip, instead of
+-- recurring monthly or weekly. The user's chat replies follow their active one.
+ALTER TABLE budgets ADD COLUMN name TEXT NOT NULL DEFAULT '';
+ALTER TABLE budgets ADD COLUMN start_date TIMESTAMP;
+ALTER TABLE budgets ADD COLUMN end_date TIMESTAMP;
+ALTER TABLE users ADD COLUMN active_budget TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_expenses_user_claim_status;
+
+ALTER TABLE expenses DROP COLUMN claim_status;
+ALTER TABLE expenses DROP COLUMN is_reimbursable;
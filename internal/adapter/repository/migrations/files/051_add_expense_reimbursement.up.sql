@@ -0,0 +1,6 @@
+-- Reimbursable expenses were paid for an employer and are claimed back; the
+-- claim moves from pending to submitted to paid
+ALTER TABLE expenses ADD COLUMN is_reimbursable BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE expenses ADD COLUMN claim_status TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_expenses_user_claim_status ON expenses(user_id, claim_status);
@@ -0,0 +1,17 @@
+-- The workspaces' expenses and budgets fall back to the personal workspace;
+-- their categories, which may share names with the personal ones, go
+UPDATE expenses SET category_id = NULL WHERE category_id IN (SELECT id FROM categories WHERE workspace_id <> '');
+DELETE FROM category_keywords WHERE category_id IN (SELECT id FROM categories WHERE workspace_id <> '');
+DELETE FROM categories WHERE workspace_id <> '';
+
+ALTER TABLE categories DROP CONSTRAINT categories_user_id_workspace_id_name_key;
+ALTER TABLE categories ADD CONSTRAINT categories_user_id_name_key UNIQUE (user_id, name);
+
+DROP INDEX IF EXISTS idx_expenses_user_workspace_date;
+
+ALTER TABLE users DROP COLUMN active_workspace;
+ALTER TABLE categories DROP COLUMN workspace_id;
+ALTER TABLE budgets DROP COLUMN workspace_id;
+ALTER TABLE expenses DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspaces;
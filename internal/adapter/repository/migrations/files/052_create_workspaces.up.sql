@@ -0,0 +1,21 @@
+-- Workspaces keep a user's business apart from their personal expenses.
+-- Expenses, categories and budgets belong to one ('' for the personal one),
+-- each with its own categories, and chat messages go into the active one.
+CREATE TABLE IF NOT EXISTS workspaces (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE (user_id, name)
+);
+
+ALTER TABLE expenses ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE budgets ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN active_workspace TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE categories DROP CONSTRAINT categories_user_id_name_key;
+ALTER TABLE categories ADD CONSTRAINT categories_user_id_workspace_id_name_key UNIQUE (user_id, workspace_id, name);
+
+CREATE INDEX IF NOT EXISTS idx_expenses_user_workspace_date ON expenses(user_id, workspace_id, expense_date);
@@ -0,0 +1,5 @@
+-- Indexes are dropped with their tables
+DROP TABLE IF EXISTS category_keywords;
+DROP TABLE IF EXISTS expenses;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS users;
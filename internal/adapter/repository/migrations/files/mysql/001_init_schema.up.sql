@@ -0,0 +1,50 @@
+-- User profiles (created first - no FK dependencies)
+CREATE TABLE IF NOT EXISTS users (
+  user_id VARCHAR(191) PRIMARY KEY,
+  messenger_type VARCHAR(32) NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+-- Categories (created before expenses - expenses references categories)
+CREATE TABLE IF NOT EXISTS categories (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  name VARCHAR(191) NOT NULL,
+  is_default BOOLEAN DEFAULT FALSE,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE(user_id, name)
+);
+
+-- Category suggestion keywords
+CREATE TABLE IF NOT EXISTS category_keywords (
+  id VARCHAR(191) PRIMARY KEY,
+  category_id VARCHAR(191) NOT NULL,
+  keyword VARCHAR(191) NOT NULL,
+  priority INT DEFAULT 0,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (category_id) REFERENCES categories(id),
+  UNIQUE(category_id, keyword)
+);
+
+-- Expense records (created after categories - has FK to categories).
+-- The category FK gets its own index so later migrations can drop theirs.
+CREATE TABLE IF NOT EXISTS expenses (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  description TEXT NOT NULL,
+  amount DECIMAL(18, 4) NOT NULL,
+  category_id VARCHAR(191),
+  expense_date DATE NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  INDEX idx_expenses_category_fk (category_id),
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  FOREIGN KEY (category_id) REFERENCES categories(id)
+);
+
+-- Indexes for performance
+CREATE INDEX idx_expenses_user_date ON expenses(user_id, expense_date);
+CREATE INDEX idx_expenses_user_category ON expenses(user_id, category_id);
+CREATE INDEX idx_categories_user ON categories(user_id);
+CREATE INDEX idx_keywords_category ON category_keywords(category_id);
@@ -0,0 +1,9 @@
+DROP INDEX idx_users_created ON users;
+DROP INDEX idx_categories_created ON categories;
+DROP INDEX idx_keywords_priority ON category_keywords;
+DROP INDEX idx_expenses_user_period ON expenses;
+DROP INDEX idx_expenses_date ON expenses;
+DROP INDEX idx_expenses_category ON expenses;
+DROP INDEX idx_expenses_user_created ON expenses;
+DROP INDEX idx_expenses_user ON expenses;
+DROP INDEX idx_expenses_created_at ON expenses;
@@ -0,0 +1,9 @@
+CREATE INDEX idx_expenses_created_at ON expenses(created_at DESC);
+CREATE INDEX idx_expenses_user ON expenses(user_id);
+CREATE INDEX idx_expenses_user_created ON expenses(user_id, created_at DESC);
+CREATE INDEX idx_expenses_category ON expenses(category_id);
+CREATE INDEX idx_expenses_date ON expenses(expense_date DESC);
+CREATE INDEX idx_expenses_user_period ON expenses(user_id, expense_date);
+CREATE INDEX idx_keywords_priority ON category_keywords(priority DESC);
+CREATE INDEX idx_categories_created ON categories(created_at DESC);
+CREATE INDEX idx_users_created ON users(created_at DESC);
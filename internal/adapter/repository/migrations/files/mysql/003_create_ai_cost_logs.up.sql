@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS ai_cost_logs (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  operation VARCHAR(64) NOT NULL,
+  provider VARCHAR(64) NOT NULL,
+  model VARCHAR(191) NOT NULL,
+  input_tokens INT DEFAULT 0,
+  output_tokens INT DEFAULT 0,
+  total_tokens INT DEFAULT 0,
+  cost DECIMAL(18, 10) NOT NULL,
+  currency VARCHAR(16) NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX idx_ai_cost_logs_user ON ai_cost_logs(user_id);
+CREATE INDEX idx_ai_cost_logs_created_at ON ai_cost_logs(created_at);
@@ -1,16 +1,16 @@
 -- Create policies table
 CREATE TABLE IF NOT EXISTS policies (
-  id TEXT PRIMARY KEY,
-  key TEXT NOT NULL UNIQUE,
+  id VARCHAR(191) PRIMARY KEY,
+  `key` VARCHAR(191) NOT NULL UNIQUE,
   title TEXT NOT NULL,
   content TEXT NOT NULL,
-  version TEXT NOT NULL,
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+  version VARCHAR(32) NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
 );
 
--- Seed initial Privacy Policy (using ON CONFLICT for PostgreSQL/SQLite compatibility)
-INSERT INTO policies (id, key, title, content, version, created_at, updated_at)
+-- Seed initial Privacy Policy
+INSERT IGNORE INTO policies (id, `key`, title, content, version, created_at, updated_at)
 VALUES (
   'policy_privacy',
   'privacy_policy',
@@ -19,11 +19,10 @@ VALUES (
   '1.0',
   CURRENT_TIMESTAMP,
   CURRENT_TIMESTAMP
-)
-ON CONFLICT (key) DO NOTHING;
+);
 
 -- Seed initial Terms of Use
-INSERT INTO policies (id, key, title, content, version, created_at, updated_at)
+INSERT IGNORE INTO policies (id, `key`, title, content, version, created_at, updated_at)
 VALUES (
   'policy_terms',
   'terms_of_use',
@@ -32,5 +31,4 @@ VALUES (
   '1.0',
   CURRENT_TIMESTAMP,
   CURRENT_TIMESTAMP
-)
-ON CONFLICT (key) DO NOTHING;
+);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS interaction_logs (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  user_input MEDIUMTEXT NOT NULL,
+  system_prompt MEDIUMTEXT NOT NULL,
+  ai_raw_response MEDIUMTEXT NOT NULL,
+  bot_final_reply MEDIUMTEXT NOT NULL,
+  duration_ms BIGINT NOT NULL,
+  error TEXT,
+  timestamp DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX idx_interaction_logs_user ON interaction_logs(user_id);
+CREATE INDEX idx_interaction_logs_timestamp ON interaction_logs(timestamp);
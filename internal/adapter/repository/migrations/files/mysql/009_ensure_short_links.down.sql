@@ -0,0 +1,2 @@
+-- 007 owns short_links; MySQL rejects a migration made only of comments
+DO 0;
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS short_links (
+    id VARCHAR(191) PRIMARY KEY,
+    target_token TEXT NOT NULL,
+    expires_at DATETIME(6) NOT NULL,
+    created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6)
+);
@@ -0,0 +1,60 @@
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(16) PRIMARY KEY,
+    symbol VARCHAR(16) NOT NULL,
+    aliases JSON NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS currency_translations (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    currency_code VARCHAR(16) NOT NULL,
+    locale VARCHAR(16) NOT NULL,
+    name VARCHAR(191) NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(currency_code, locale),
+    FOREIGN KEY (currency_code) REFERENCES currencies(code) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    provider VARCHAR(64) NOT NULL,
+    base_currency VARCHAR(16) NOT NULL,
+    target_currency VARCHAR(16) NOT NULL,
+    rate DECIMAL(18, 8) NOT NULL,
+    rate_date DATE NOT NULL,
+    fetched_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE(provider, base_currency, target_currency, rate_date)
+);
+
+INSERT INTO currencies (code, symbol, aliases)
+VALUES
+    ('TWD', 'NT$', '["台幣", "元", "新台幣", "NT$", "TWD"]'),
+    ('JPY', '¥', '["日幣", "日元", "円", "JPY", "yen"]'),
+    ('USD', '$', '["美元", "美金", "USD", "dollar", "$"]'),
+    ('EUR', '€', '["歐元", "EUR", "euro", "€"]'),
+    ('CNY', '¥', '["人民幣", "人民币", "RMB", "CNY", "¥"]')
+ON DUPLICATE KEY UPDATE symbol = VALUES(symbol),
+                        aliases = VALUES(aliases),
+                        updated_at = CURRENT_TIMESTAMP(6);
+
+INSERT INTO currency_translations (currency_code, locale, name)
+VALUES
+    ('TWD', 'en', 'New Taiwan Dollar'),
+    ('TWD', 'zh-TW', '台幣'),
+    ('TWD', 'zh-CN', '新台币'),
+    ('JPY', 'en', 'Japanese Yen'),
+    ('JPY', 'zh-TW', '日幣'),
+    ('JPY', 'zh-CN', '日元'),
+    ('JPY', 'ja', '円'),
+    ('USD', 'en', 'US Dollar'),
+    ('USD', 'zh-TW', '美金'),
+    ('USD', 'zh-CN', '美元'),
+    ('EUR', 'en', 'Euro'),
+    ('EUR', 'zh-TW', '歐元'),
+    ('EUR', 'zh-CN', '欧元'),
+    ('CNY', 'en', 'Chinese Yuan'),
+    ('CNY', 'zh-TW', '人民幣'),
+    ('CNY', 'zh-CN', '人民币')
+ON DUPLICATE KEY UPDATE name = VALUES(name);
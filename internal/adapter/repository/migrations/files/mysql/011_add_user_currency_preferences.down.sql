@@ -0,0 +1,5 @@
+ALTER TABLE users
+    DROP COLUMN home_currency;
+
+ALTER TABLE users
+    DROP COLUMN locale;
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN home_currency VARCHAR(16) NOT NULL DEFAULT 'TWD';
+
+ALTER TABLE users
+    ADD COLUMN locale VARCHAR(16) NOT NULL DEFAULT 'zh-TW';
@@ -0,0 +1,8 @@
+ALTER TABLE expenses
+    DROP COLUMN currency,
+    DROP COLUMN home_amount,
+    DROP COLUMN home_currency,
+    DROP COLUMN exchange_rate;
+
+ALTER TABLE expenses
+    RENAME COLUMN original_amount TO amount;
@@ -0,0 +1,11 @@
+ALTER TABLE expenses
+    RENAME COLUMN amount TO original_amount;
+
+ALTER TABLE expenses
+    ADD COLUMN currency VARCHAR(16) NOT NULL DEFAULT 'TWD',
+    ADD COLUMN home_amount DECIMAL(18, 4) NOT NULL DEFAULT 0,
+    ADD COLUMN home_currency VARCHAR(16) NOT NULL DEFAULT 'TWD',
+    ADD COLUMN exchange_rate DECIMAL(18, 8) NOT NULL DEFAULT 1.0;
+
+UPDATE expenses
+SET home_amount = original_amount;
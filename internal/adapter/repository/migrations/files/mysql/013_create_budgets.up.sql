@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS budgets (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  category_id VARCHAR(191),
+  limit_amount DECIMAL(18, 4) NOT NULL,
+  period VARCHAR(32) NOT NULL DEFAULT 'monthly',
+  threshold DECIMAL(9, 4) NOT NULL DEFAULT 80,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_budgets_user ON budgets(user_id);
@@ -0,0 +1,8 @@
+DROP INDEX idx_budgets_group ON budgets;
+DROP INDEX idx_expenses_group_date ON expenses;
+
+ALTER TABLE budgets DROP COLUMN group_id;
+ALTER TABLE expenses DROP COLUMN group_id;
+
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS expense_groups;
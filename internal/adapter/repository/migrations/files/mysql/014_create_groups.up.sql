@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS expense_groups (
+  id VARCHAR(191) PRIMARY KEY,
+  name VARCHAR(191) NOT NULL DEFAULT '',
+  messenger_type VARCHAR(32) NOT NULL,
+  external_id VARCHAR(191) NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  UNIQUE(messenger_type, external_id)
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+  group_id VARCHAR(191) NOT NULL,
+  user_id VARCHAR(191) NOT NULL,
+  role VARCHAR(32) NOT NULL DEFAULT 'member',
+  joined_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  PRIMARY KEY (group_id, user_id),
+  FOREIGN KEY (group_id) REFERENCES expense_groups(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+
+ALTER TABLE expenses ADD COLUMN group_id VARCHAR(191);
+
+ALTER TABLE budgets ADD COLUMN group_id VARCHAR(191);
+
+CREATE INDEX idx_group_members_user ON group_members(user_id);
+CREATE INDEX idx_expenses_group_date ON expenses(group_id, expense_date);
+CREATE INDEX idx_budgets_group ON budgets(group_id);
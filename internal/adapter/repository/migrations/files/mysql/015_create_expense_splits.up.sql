@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS expense_splits (
+  id VARCHAR(191) PRIMARY KEY,
+  expense_id VARCHAR(191) NOT NULL,
+  payer_id VARCHAR(191) NOT NULL,
+  participant VARCHAR(191) NOT NULL,
+  share_amount DECIMAL(18, 4) NOT NULL,
+  percentage DECIMAL(9, 4) NOT NULL DEFAULT 0,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE,
+  FOREIGN KEY (payer_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX idx_expense_splits_expense ON expense_splits(expense_id);
+CREATE INDEX idx_expense_splits_payer ON expense_splits(payer_id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS expense_attachments (
+  id VARCHAR(191) PRIMARY KEY,
+  expense_id VARCHAR(191) NOT NULL,
+  user_id VARCHAR(191) NOT NULL,
+  mime_type VARCHAR(191) NOT NULL DEFAULT '',
+  size_bytes BIGINT NOT NULL DEFAULT 0,
+  storage_key TEXT NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX idx_expense_attachments_expense ON expense_attachments(expense_id);
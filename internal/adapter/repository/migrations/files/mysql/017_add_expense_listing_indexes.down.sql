@@ -0,0 +1,3 @@
+DROP INDEX idx_expenses_user_created_id ON expenses;
+DROP INDEX idx_expenses_user_amount_id ON expenses;
+DROP INDEX idx_expenses_user_date_id ON expenses;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS processed_events (
+  messenger VARCHAR(32) NOT NULL,
+  event_id VARCHAR(191) NOT NULL,
+  processed_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  PRIMARY KEY (messenger, event_id)
+);
+
+CREATE INDEX idx_processed_events_processed_at ON processed_events(processed_at);
@@ -0,0 +1,2 @@
+DROP INDEX idx_ai_cost_logs_user_created ON ai_cost_logs;
+DROP TABLE IF EXISTS ai_cost_caps;
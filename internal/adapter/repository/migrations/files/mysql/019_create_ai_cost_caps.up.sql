@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS ai_cost_caps (
+  scope VARCHAR(191) PRIMARY KEY,
+  daily_usd DECIMAL(10, 4) NOT NULL DEFAULT 0,
+  monthly_usd DECIMAL(10, 4) NOT NULL DEFAULT 0,
+  updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_ai_cost_logs_user_created ON ai_cost_logs(user_id, created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS prompt_templates (
+  id VARCHAR(191) PRIMARY KEY,
+  name VARCHAR(191) NOT NULL,
+  version INTEGER NOT NULL,
+  template MEDIUMTEXT NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  UNIQUE (name, version)
+);
+
+ALTER TABLE ai_cost_logs ADD COLUMN prompt_version INTEGER NOT NULL DEFAULT 0;
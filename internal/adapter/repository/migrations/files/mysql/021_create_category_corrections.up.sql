@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS category_corrections (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  expense_id VARCHAR(191) NOT NULL,
+  description TEXT NOT NULL,
+  from_category_id VARCHAR(191),
+  to_category_id VARCHAR(191) NOT NULL,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_category_corrections_user_created ON category_corrections(user_id, created_at);
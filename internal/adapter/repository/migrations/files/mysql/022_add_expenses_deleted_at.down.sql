@@ -0,0 +1,2 @@
+DROP INDEX idx_expenses_deleted_at ON expenses;
+ALTER TABLE expenses DROP COLUMN deleted_at;
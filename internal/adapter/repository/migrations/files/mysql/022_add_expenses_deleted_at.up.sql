@@ -0,0 +1,3 @@
+ALTER TABLE expenses ADD COLUMN deleted_at DATETIME(6);
+
+CREATE INDEX idx_expenses_deleted_at ON expenses(deleted_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS expense_audit_log (
+  id VARCHAR(191) PRIMARY KEY,
+  expense_id VARCHAR(191) NOT NULL,
+  user_id VARCHAR(191) NOT NULL,
+  action VARCHAR(32) NOT NULL,
+  actor VARCHAR(191) NOT NULL,
+  channel VARCHAR(32) NOT NULL,
+  before_snapshot TEXT,
+  after_snapshot TEXT,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_expense_audit_log_expense_created ON expense_audit_log(expense_id, created_at);
@@ -0,0 +1,3 @@
+DROP INDEX idx_interaction_logs_intent_timestamp ON interaction_logs;
+ALTER TABLE interaction_logs DROP COLUMN intent;
+ALTER TABLE interaction_logs DROP COLUMN source;
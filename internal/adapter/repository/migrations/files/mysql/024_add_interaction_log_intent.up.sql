@@ -0,0 +1,4 @@
+ALTER TABLE interaction_logs ADD COLUMN source VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE interaction_logs ADD COLUMN intent VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_interaction_logs_intent_timestamp ON interaction_logs(intent, timestamp);
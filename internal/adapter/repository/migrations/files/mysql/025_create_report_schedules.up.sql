@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS report_schedules (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL UNIQUE,
+  email VARCHAR(320) NOT NULL,
+  frequency VARCHAR(32) NOT NULL,
+  format VARCHAR(32) NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  next_run_at DATETIME(6) NOT NULL,
+  last_sent_at DATETIME(6),
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_report_schedules_due ON report_schedules(enabled, next_run_at);
@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  url TEXT NOT NULL,
+  secret VARCHAR(191) NOT NULL,
+  events TEXT NOT NULL,
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (user_id) REFERENCES users(user_id)
+);
+
+CREATE INDEX idx_webhook_subscriptions_user ON webhook_subscriptions(user_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id VARCHAR(191) PRIMARY KEY,
+  subscription_id VARCHAR(191) NOT NULL,
+  event VARCHAR(64) NOT NULL,
+  payload MEDIUMTEXT NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  response_status INTEGER NOT NULL DEFAULT 0,
+  last_error VARCHAR(1024) NOT NULL DEFAULT '',
+  next_attempt_at DATETIME(6),
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  delivered_at DATETIME(6),
+  FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at);
+CREATE INDEX idx_webhook_deliveries_retry ON webhook_deliveries(status, next_attempt_at);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+  id VARCHAR(191) PRIMARY KEY,
+  name VARCHAR(191) NOT NULL,
+  prefix VARCHAR(32) NOT NULL,
+  key_hash VARCHAR(191) NOT NULL UNIQUE,
+  scopes TEXT NOT NULL,
+  expires_at DATETIME(6),
+  last_used_at DATETIME(6),
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
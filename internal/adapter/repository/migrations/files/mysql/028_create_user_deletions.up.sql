@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS user_deletions (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  requested_at DATETIME(6) NOT NULL,
+  purge_after DATETIME(6) NOT NULL,
+  purged_at DATETIME(6),
+  deleted_counts TEXT NOT NULL
+);
+
+CREATE INDEX idx_user_deletions_user ON user_deletions(user_id, status);
+CREATE INDEX idx_user_deletions_due ON user_deletions(status, purge_after);
@@ -0,0 +1 @@
+ALTER TABLE expenses ADD COLUMN account VARCHAR(191) DEFAULT 'Cash';
@@ -0,0 +1,2 @@
+DROP INDEX idx_expenses_group_date_totals ON expenses;
+DROP INDEX idx_expenses_user_date_totals ON expenses;
@@ -0,0 +1,4 @@
+-- MySQL has neither INCLUDE nor partial indexes, so every column the
+-- aggregates read is a key column
+CREATE INDEX idx_expenses_user_date_totals ON expenses(user_id, expense_date, category_id, home_amount, deleted_at);
+CREATE INDEX idx_expenses_group_date_totals ON expenses(group_id, expense_date, category_id, home_amount, deleted_at);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS daily_metrics (
+  metric_date DATE PRIMARY KEY,
+  active_users INT NOT NULL DEFAULT 0,
+  new_users INT NOT NULL DEFAULT 0,
+  total_expense DECIMAL(18, 4) NOT NULL DEFAULT 0,
+  expense_count INT NOT NULL DEFAULT 0,
+  ai_calls INT NOT NULL DEFAULT 0,
+  ai_input_tokens BIGINT NOT NULL DEFAULT 0,
+  ai_output_tokens BIGINT NOT NULL DEFAULT 0,
+  ai_total_tokens BIGINT NOT NULL DEFAULT 0,
+  ai_cost DECIMAL(18, 10) NOT NULL DEFAULT 0,
+  updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
@@ -0,0 +1,4 @@
+DROP INDEX idx_interaction_logs_source_timestamp ON interaction_logs;
+DROP INDEX idx_ai_cost_logs_source_created ON ai_cost_logs;
+
+ALTER TABLE ai_cost_logs DROP COLUMN source;
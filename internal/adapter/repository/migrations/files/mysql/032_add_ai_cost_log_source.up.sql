@@ -0,0 +1,4 @@
+ALTER TABLE ai_cost_logs ADD COLUMN source VARCHAR(32) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_ai_cost_logs_source_created ON ai_cost_logs(source, created_at);
+CREATE INDEX idx_interaction_logs_source_timestamp ON interaction_logs(source, timestamp);
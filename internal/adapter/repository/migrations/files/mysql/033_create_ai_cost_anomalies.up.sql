@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS ai_cost_anomalies (
+  id VARCHAR(191) PRIMARY KEY,
+  anomaly_date DATE NOT NULL UNIQUE,
+  cost DECIMAL(18, 10) NOT NULL,
+  calls INT NOT NULL DEFAULT 0,
+  baseline_cost DECIMAL(18, 10) NOT NULL,
+  ratio DECIMAL(12, 4) NOT NULL,
+  detected_at DATETIME(6) NOT NULL,
+  updated_at DATETIME(6) NOT NULL
+);
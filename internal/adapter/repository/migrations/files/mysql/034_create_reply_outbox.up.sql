@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS reply_outbox (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  messenger VARCHAR(32) NOT NULL,
+  group_chat_id VARCHAR(191) NOT NULL DEFAULT '',
+  metadata TEXT NOT NULL,
+  text MEDIUMTEXT NOT NULL,
+  status VARCHAR(32) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error VARCHAR(1024) NOT NULL DEFAULT '',
+  next_attempt_at DATETIME(6),
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  delivered_at DATETIME(6)
+);
+
+CREATE INDEX idx_reply_outbox_due ON reply_outbox(status, next_attempt_at);
+CREATE INDEX idx_reply_outbox_created ON reply_outbox(created_at);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS email_addresses (
+  address VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  code_hash VARCHAR(191) NOT NULL DEFAULT '',
+  code_expires_at DATETIME(6),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  verified_at DATETIME(6),
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_email_addresses_user ON email_addresses(user_id);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS conversation_states (
+  user_id VARCHAR(191) PRIMARY KEY,
+  intent VARCHAR(64) NOT NULL,
+  awaiting VARCHAR(64) NOT NULL DEFAULT '',
+  slots TEXT NOT NULL,
+  expires_at DATETIME(6) NOT NULL,
+  updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_conversation_states_expires ON conversation_states(expires_at);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN onboarded_at DATETIME(6);
+
+UPDATE users SET onboarded_at = created_at;
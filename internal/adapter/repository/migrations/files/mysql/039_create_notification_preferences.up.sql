@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  user_id VARCHAR(191) PRIMARY KEY,
+  budget_alerts BOOLEAN NOT NULL DEFAULT TRUE,
+  recurring_reminders BOOLEAN NOT NULL DEFAULT TRUE,
+  report_notifications BOOLEAN NOT NULL DEFAULT TRUE,
+  expense_reminders BOOLEAN NOT NULL DEFAULT FALSE,
+  daily_digest BOOLEAN NOT NULL DEFAULT FALSE,
+  daily_digest_hour INT NOT NULL DEFAULT 8,
+  weekly_report BOOLEAN NOT NULL DEFAULT TRUE,
+  last_daily_digest_at DATETIME(6),
+  updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
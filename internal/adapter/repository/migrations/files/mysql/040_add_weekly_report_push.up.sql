@@ -0,0 +1,2 @@
+ALTER TABLE notification_preferences ADD COLUMN last_weekly_report_at DATETIME(6);
+ALTER TABLE reply_outbox ADD COLUMN push BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+ALTER TABLE notification_preferences ADD COLUMN expense_reminder_days INT NOT NULL DEFAULT 3;
+ALTER TABLE notification_preferences ADD COLUMN quiet_hours_start INT NOT NULL DEFAULT 22;
+ALTER TABLE notification_preferences ADD COLUMN quiet_hours_end INT NOT NULL DEFAULT 8;
+ALTER TABLE notification_preferences ADD COLUMN last_expense_reminder_at DATETIME(6);
@@ -0,0 +1 @@
+ALTER TABLE notification_preferences ADD COLUMN channels VARCHAR(1024) NOT NULL DEFAULT '{}';
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS archives (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  period VARCHAR(32) NOT NULL,
+  start_date DATETIME(6) NOT NULL,
+  end_date DATETIME(6) NOT NULL,
+  expense_count INT NOT NULL DEFAULT 0,
+  total_amount DECIMAL(18, 4) NOT NULL DEFAULT 0,
+  format VARCHAR(32) NOT NULL,
+  object_key VARCHAR(512) NOT NULL,
+  checksum VARCHAR(64) NOT NULL,
+  size_bytes BIGINT NOT NULL DEFAULT 0,
+  compressed_size_bytes BIGINT NOT NULL DEFAULT 0,
+  retention_days INT NOT NULL DEFAULT 0,
+  expires_at DATETIME(6),
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_archives_user ON archives(user_id, created_at);
@@ -0,0 +1,2 @@
+DROP INDEX idx_archives_created ON archives;
+DROP TABLE IF EXISTS archive_policies;
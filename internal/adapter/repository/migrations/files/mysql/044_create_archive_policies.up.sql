@@ -0,0 +1,9 @@
+-- Per-user archive policies; users without one follow the global policy
+CREATE TABLE IF NOT EXISTS archive_policies (
+  user_id VARCHAR(191) PRIMARY KEY,
+  archive_after_months INT NOT NULL DEFAULT 0,
+  purge_after_days INT NOT NULL DEFAULT 0,
+  updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX idx_archives_created ON archives(created_at);
@@ -0,0 +1,2 @@
+-- Nothing to undo; MySQL rejects a migration made only of comments
+DO 0;
@@ -0,0 +1,3 @@
+-- MySQL searches expenses without a full-text index; MySQL rejects a
+-- migration made only of comments
+DO 0;
@@ -0,0 +1,19 @@
+-- Tags label expenses across categories, e.g. #出差
+CREATE TABLE IF NOT EXISTS tags (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  name VARCHAR(191) NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS expense_tags (
+  expense_id VARCHAR(191) NOT NULL,
+  tag_id VARCHAR(191) NOT NULL,
+  PRIMARY KEY (expense_id, tag_id),
+  FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE,
+  FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_expense_tags_tag ON expense_tags(tag_id);
@@ -0,0 +1,5 @@
+DROP INDEX idx_expenses_merchant_date ON expenses;
+
+ALTER TABLE expenses DROP COLUMN merchant_id;
+
+DROP TABLE IF EXISTS merchants;
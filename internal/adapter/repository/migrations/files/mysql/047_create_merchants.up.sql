@@ -0,0 +1,15 @@
+-- Merchants are the stores expenses were paid at, one per normalized name, so
+-- "7-11" and "7-Eleven 信義店" count as the same store
+CREATE TABLE IF NOT EXISTS merchants (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  name VARCHAR(191) NOT NULL,
+  normalized_name VARCHAR(191) NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE (user_id, normalized_name)
+);
+
+ALTER TABLE expenses ADD COLUMN merchant_id VARCHAR(191);
+
+CREATE INDEX idx_expenses_merchant_date ON expenses(merchant_id, expense_date);
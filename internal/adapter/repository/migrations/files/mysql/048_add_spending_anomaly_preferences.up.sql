@@ -0,0 +1,3 @@
+ALTER TABLE notification_preferences ADD COLUMN spending_anomalies BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE notification_preferences ADD COLUMN anomaly_sensitivity VARCHAR(10) NOT NULL DEFAULT 'medium';
+ALTER TABLE notification_preferences ADD COLUMN last_anomaly_check_at DATETIME(6);
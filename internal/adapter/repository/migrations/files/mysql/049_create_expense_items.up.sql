@@ -0,0 +1,13 @@
+-- Expense items are the lines of the receipt an expense was read from
+CREATE TABLE IF NOT EXISTS expense_items (
+  id VARCHAR(191) PRIMARY KEY,
+  expense_id VARCHAR(191) NOT NULL,
+  name TEXT NOT NULL,
+  quantity DECIMAL(18, 4) NOT NULL DEFAULT 1,
+  unit_price DECIMAL(18, 4) NOT NULL,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (expense_id) REFERENCES expenses(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_expense_items_expense ON expense_items(expense_id, position);
@@ -0,0 +1,4 @@
+ALTER TABLE budgets ADD COLUMN name VARCHAR(191) NOT NULL DEFAULT '';
+ALTER TABLE budgets ADD COLUMN start_date DATETIME(6);
+ALTER TABLE budgets ADD COLUMN end_date DATETIME(6);
+ALTER TABLE users ADD COLUMN active_budget VARCHAR(191) NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+-- The workspaces' expenses and budgets fall back to the personal workspace;
+-- their categories, which may share names with the personal ones, go
+UPDATE expenses SET category_id = NULL WHERE category_id IN (SELECT id FROM categories WHERE workspace_id <> '');
+DELETE FROM category_keywords WHERE category_id IN (SELECT id FROM categories WHERE workspace_id <> '');
+DELETE FROM categories WHERE workspace_id <> '';
+
+ALTER TABLE categories DROP INDEX uq_categories_user_workspace_name;
+ALTER TABLE categories ADD CONSTRAINT user_id UNIQUE (user_id, name);
+
+DROP INDEX idx_expenses_user_workspace_date ON expenses;
+
+ALTER TABLE users DROP COLUMN active_workspace;
+ALTER TABLE categories DROP COLUMN workspace_id;
+ALTER TABLE budgets DROP COLUMN workspace_id;
+ALTER TABLE expenses DROP COLUMN workspace_id;
+
+DROP TABLE IF EXISTS workspaces;
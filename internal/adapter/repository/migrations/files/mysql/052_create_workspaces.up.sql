@@ -0,0 +1,23 @@
+-- Workspaces keep a user's business apart from their personal expenses.
+-- Expenses, categories and budgets belong to one ('' for the personal one),
+-- each with its own categories, and chat messages go into the active one.
+CREATE TABLE IF NOT EXISTS workspaces (
+  id VARCHAR(191) PRIMARY KEY,
+  user_id VARCHAR(191) NOT NULL,
+  name VARCHAR(191) NOT NULL,
+  created_at DATETIME(6) DEFAULT CURRENT_TIMESTAMP(6),
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE (user_id, name)
+);
+
+ALTER TABLE expenses ADD COLUMN workspace_id VARCHAR(191) NOT NULL DEFAULT '';
+ALTER TABLE budgets ADD COLUMN workspace_id VARCHAR(191) NOT NULL DEFAULT '';
+ALTER TABLE categories ADD COLUMN workspace_id VARCHAR(191) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN active_workspace VARCHAR(191) NOT NULL DEFAULT '';
+
+-- UNIQUE(user_id, name) was named after its first column; idx_categories_user
+-- keeps the user_id foreign key indexed without it
+ALTER TABLE categories DROP INDEX user_id;
+ALTER TABLE categories ADD CONSTRAINT uq_categories_user_workspace_name UNIQUE (user_id, workspace_id, name);
+
+CREATE INDEX idx_expenses_user_workspace_date ON expenses(user_id, workspace_id, expense_date);
@@ -1,14 +1,14 @@
 CREATE TABLE IF NOT EXISTS currencies (
     code TEXT PRIMARY KEY,
     symbol TEXT NOT NULL,
-    aliases JSONB NOT NULL DEFAULT '[]'::jsonb,
+    aliases JSON NOT NULL DEFAULT '[]',
     is_active BOOLEAN NOT NULL DEFAULT TRUE,
     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
 CREATE TABLE IF NOT EXISTS currency_translations (
-    id SERIAL PRIMARY KEY,
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
     currency_code TEXT NOT NULL REFERENCES currencies(code) ON DELETE CASCADE,
     locale TEXT NOT NULL,
     name TEXT NOT NULL,
@@ -17,11 +17,11 @@ CREATE TABLE IF NOT EXISTS currency_translations (
 );
 
 CREATE TABLE IF NOT EXISTS exchange_rates (
-    id BIGSERIAL PRIMARY KEY,
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
     provider TEXT NOT NULL,
     base_currency TEXT NOT NULL,
     target_currency TEXT NOT NULL,
-    rate NUMERIC(18,8) NOT NULL,
+    rate NUMERIC NOT NULL,
     rate_date DATE NOT NULL,
     fetched_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(provider, base_currency, target_currency, rate_date)
@@ -29,14 +29,14 @@ CREATE TABLE IF NOT EXISTS exchange_rates (
 
 INSERT INTO currencies (code, symbol, aliases)
 VALUES
-    ('TWD', 'NT$', '["台幣", "元", "新台幣", "NT$", "TWD"]'::jsonb),
-    ('JPY', '¥', '["日幣", "日元", "円", "JPY", "yen"]'::jsonb),
-    ('USD', '$', '["美元", "美金", "USD", "dollar", "$"]'::jsonb),
-    ('EUR', '€', '["歐元", "EUR", "euro", "€"]'::jsonb),
-    ('CNY', '¥', '["人民幣", "人民币", "RMB", "CNY", "¥"]'::jsonb)
+    ('TWD', 'NT$', json('["台幣", "元", "新台幣", "NT$", "TWD"]')),
+    ('JPY', '¥', json('["日幣", "日元", "円", "JPY", "yen"]')),
+    ('USD', '$', json('["美元", "美金", "USD", "dollar", "$"]')),
+    ('EUR', '€', json('["歐元", "EUR", "euro", "€"]')),
+    ('CNY', '¥', json('["人民幣", "人民币", "RMB", "CNY", "¥"]'))
 ON CONFLICT (code)
-DO UPDATE SET symbol = EXCLUDED.symbol,
-              aliases = EXCLUDED.aliases,
+DO UPDATE SET symbol = excluded.symbol,
+              aliases = excluded.aliases,
               updated_at = CURRENT_TIMESTAMP;
 
 INSERT INTO currency_translations (currency_code, locale, name)
@@ -58,4 +58,4 @@ VALUES
     ('CNY', 'zh-TW', '人民幣'),
     ('CNY', 'zh-CN', '人民币')
 ON CONFLICT (currency_code, locale)
-DO UPDATE SET name = EXCLUDED.name;
+DO UPDATE SET name = excluded.name;
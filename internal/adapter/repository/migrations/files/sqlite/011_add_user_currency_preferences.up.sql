@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN home_currency TEXT NOT NULL DEFAULT 'TWD';
+
+ALTER TABLE users
+    ADD COLUMN locale TEXT NOT NULL DEFAULT 'zh-TW';
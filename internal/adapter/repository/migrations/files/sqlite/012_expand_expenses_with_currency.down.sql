@@ -0,0 +1,14 @@
+ALTER TABLE expenses
+    DROP COLUMN currency;
+
+ALTER TABLE expenses
+    DROP COLUMN home_amount;
+
+ALTER TABLE expenses
+    DROP COLUMN home_currency;
+
+ALTER TABLE expenses
+    DROP COLUMN exchange_rate;
+
+ALTER TABLE expenses
+    RENAME COLUMN original_amount TO amount;
@@ -0,0 +1,17 @@
+ALTER TABLE expenses
+    RENAME COLUMN amount TO original_amount;
+
+ALTER TABLE expenses
+    ADD COLUMN currency TEXT NOT NULL DEFAULT 'TWD';
+
+ALTER TABLE expenses
+    ADD COLUMN home_amount DECIMAL NOT NULL DEFAULT 0;
+
+ALTER TABLE expenses
+    ADD COLUMN home_currency TEXT NOT NULL DEFAULT 'TWD';
+
+ALTER TABLE expenses
+    ADD COLUMN exchange_rate NUMERIC NOT NULL DEFAULT 1.0;
+
+UPDATE expenses
+SET home_amount = original_amount;
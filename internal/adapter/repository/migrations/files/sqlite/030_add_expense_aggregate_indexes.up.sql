@@ -0,0 +1,3 @@
+-- SQLite has no INCLUDE, so the summed columns are trailing key columns
+CREATE INDEX IF NOT EXISTS idx_expenses_user_date_totals ON expenses(user_id, expense_date, category_id, home_amount) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_expenses_group_date_totals ON expenses(group_id, expense_date, category_id, home_amount) WHERE deleted_at IS NULL;
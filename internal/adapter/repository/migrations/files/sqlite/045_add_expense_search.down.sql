@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS expenses_search_delete;
+DROP TRIGGER IF EXISTS expenses_search_update;
+DROP TRIGGER IF EXISTS expenses_search_insert;
+DROP TABLE IF EXISTS expenses_fts_terms;
+DROP TABLE IF EXISTS expenses_fts;
+DROP TABLE IF EXISTS expense_search_docs;
@@ -0,0 +1,30 @@
+-- Full-text index of expense descriptions. The SQLite driver is built without
+-- FTS5, so this is FTS4. Expenses have no stable integer rowid, so
+-- expense_search_docs assigns each one the docid it is indexed under.
+CREATE TABLE IF NOT EXISTS expense_search_docs (
+  docid INTEGER PRIMARY KEY,
+  expense_id TEXT NOT NULL UNIQUE
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS expenses_fts USING fts4(description, tokenize=unicode61 "remove_diacritics=2");
+-- The indexed words, for finding the ones close to a misspelled search term
+CREATE VIRTUAL TABLE IF NOT EXISTS expenses_fts_terms USING fts4aux(expenses_fts);
+
+INSERT INTO expense_search_docs (expense_id) SELECT id FROM expenses;
+INSERT INTO expenses_fts (docid, description)
+SELECT d.docid, e.description FROM expense_search_docs d JOIN expenses e ON e.id = d.expense_id;
+
+CREATE TRIGGER IF NOT EXISTS expenses_search_insert AFTER INSERT ON expenses BEGIN
+  INSERT INTO expense_search_docs (expense_id) VALUES (new.id);
+  INSERT INTO expenses_fts (docid, description)
+  VALUES ((SELECT docid FROM expense_search_docs WHERE expense_id = new.id), new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS expenses_search_update AFTER UPDATE OF description ON expenses BEGIN
+  UPDATE expenses_fts SET description = new.description
+  WHERE docid = (SELECT docid FROM expense_search_docs WHERE expense_id = new.id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS expenses_search_delete AFTER DELETE ON expenses BEGIN
+  DELETE FROM expenses_fts WHERE docid = (SELECT docid FROM expense_search_docs WHERE expense_id = old.id);
+  DELETE FROM expense_search_docs WHERE expense_id = old.id;
+END;
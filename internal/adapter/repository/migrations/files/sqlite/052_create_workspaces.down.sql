@@ -0,0 +1,37 @@
+-- The workspaces' expenses and budgets fall back to the personal workspace;
+-- their categories, which may share names with the personal ones, go
+UPDATE expenses SET category_id = NULL WHERE category_id IN (SELECT id FROM categories WHERE workspace_id <> '');
+DELETE FROM category_keywords WHERE category_id IN (SELECT id FROM categories WHERE workspace_id <> '');
+DELETE FROM categories WHERE workspace_id <> '';
+
+DROP INDEX IF EXISTS idx_expenses_user_workspace_date;
+
+ALTER TABLE users DROP COLUMN active_workspace;
+ALTER TABLE budgets DROP COLUMN workspace_id;
+ALTER TABLE expenses DROP COLUMN workspace_id;
+
+-- Rebuilt without workspace_id, as in 052's up migration
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TABLE categories_backup AS SELECT id, user_id, name, is_default, created_at FROM categories;
+CREATE TABLE budgets_backup AS SELECT * FROM budgets WHERE category_id IS NOT NULL;
+DROP TABLE categories;
+
+CREATE TABLE categories (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  is_default BOOLEAN DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE(user_id, name)
+);
+INSERT INTO categories SELECT * FROM categories_backup;
+INSERT OR IGNORE INTO budgets SELECT * FROM budgets_backup;
+DROP TABLE categories_backup;
+DROP TABLE budgets_backup;
+
+CREATE INDEX IF NOT EXISTS idx_categories_user ON categories(user_id);
+CREATE INDEX IF NOT EXISTS idx_categories_created ON categories(created_at DESC);
+
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,44 @@
+-- Workspaces keep a user's business apart from their personal expenses.
+-- Expenses, categories and budgets belong to one ('' for the personal one),
+-- each with its own categories, and chat messages go into the active one.
+CREATE TABLE IF NOT EXISTS workspaces (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE (user_id, name)
+);
+
+ALTER TABLE expenses ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE budgets ADD COLUMN workspace_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN active_workspace TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_expenses_user_workspace_date ON expenses(user_id, workspace_id, expense_date);
+
+-- SQLite can't drop UNIQUE(user_id, name), so categories are rebuilt. Their
+-- references are checked at commit, once the rows are back, and the budgets
+-- dropping the table cascades to are put back too.
+PRAGMA defer_foreign_keys = ON;
+
+CREATE TABLE categories_backup AS SELECT id, user_id, name, is_default, created_at FROM categories;
+CREATE TABLE budgets_backup AS SELECT * FROM budgets WHERE category_id IS NOT NULL;
+DROP TABLE categories;
+
+CREATE TABLE categories (
+  id TEXT PRIMARY KEY,
+  user_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  is_default BOOLEAN DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  workspace_id TEXT NOT NULL DEFAULT '',
+  FOREIGN KEY (user_id) REFERENCES users(user_id),
+  UNIQUE(user_id, workspace_id, name)
+);
+INSERT INTO categories (id, user_id, name, is_default, created_at) SELECT id, user_id, name, is_default, created_at FROM categories_backup;
+INSERT OR IGNORE INTO budgets SELECT * FROM budgets_backup;
+DROP TABLE categories_backup;
+DROP TABLE budgets_backup;
+
+CREATE INDEX IF NOT EXISTS idx_categories_user ON categories(user_id);
+CREATE INDEX IF NOT EXISTS idx_categories_created ON categories(created_at DESC);
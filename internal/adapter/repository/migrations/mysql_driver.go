@@ -0,0 +1,17 @@
+//go:build mysql
+
+package migrations
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	migratemysql "github.com/golang-migrate/migrate/v4/database/mysql"
+)
+
+func init() {
+	mysqlDriver = func(ctx context.Context, conn *sql.Conn) (database.Driver, error) {
+		return migratemysql.WithConnection(ctx, conn, &migratemysql.Config{})
+	}
+}
@@ -1,75 +1,235 @@
+// Package migrations applies the versioned SQL schema shared by the SQLite,
+// PostgreSQL and MySQL repositories. The SQL files are embedded in the binary.
 package migrations
 
 import (
+	"context"
 	"database/sql"
+	"embed"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
+	"log/slog"
 	"os"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
-// RunMigrations runs pending migrations for the given database
-// dbType should be "sqlite3" or "postgres"
-func RunMigrations(db *sql.DB, dbType string) error {
-	var m *migrate.Migrate
-	var err error
+// files holds the shared migrations. A file of the same name under a
+// dialect directory (files/sqlite, files/mysql) replaces the shared one for
+// that dialect, for statements the dialect cannot run as written.
+//
+//go:embed files
+var files embed.FS
+
+// dialectDirs maps database types to their override directory
+var dialectDirs = map[string]string{
+	"sqlite3": "sqlite",
+	"mysql":   "mysql",
+}
+
+// mysqlDriver creates the MySQL migrate driver. It is only set in binaries
+// built with the mysql tag, which links the MySQL client library.
+var mysqlDriver func(ctx context.Context, conn *sql.Conn) (database.Driver, error)
 
-	path := "file://internal/adapter/repository/migrations/files"
-	if _, err := os.Stat("/app/migrations"); err == nil {
-		path = "file:///app/migrations"
+// Migrator moves a database between schema versions
+type Migrator struct {
+	m    *migrate.Migrate
+	conn *sql.Conn
+}
+
+// New creates a migrator for db. dbType should be "sqlite3", "postgres" or
+// "mysql". Close releases it without closing db.
+func New(db *sql.DB, dbType string) (*Migrator, error) {
+	src, err := iofs.New(dialectFS(dbType), ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
 	}
 
-	// Initialize migrator with appropriate driver based on database type
+	var driver database.Driver
+	var conn *sql.Conn
 	switch dbType {
 	case "sqlite3":
-		driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
-		if err != nil {
-			return fmt.Errorf("failed to create SQLite migrate driver: %w", err)
-		}
-		m, err = migrate.NewWithDatabaseInstance(
-			path,
-			"sqlite3",
-			driver,
-		)
-		if err != nil {
-			return fmt.Errorf("failed to initialize migrator for SQLite: %w", err)
+		if driver, err = sqlite3.WithInstance(db, &sqlite3.Config{}); err != nil {
+			return nil, fmt.Errorf("failed to create SQLite migrate driver: %w", err)
 		}
 
-	case "postgres":
-		driver, err := postgres.WithInstance(db, &postgres.Config{})
-		if err != nil {
-			return fmt.Errorf("failed to create PostgreSQL migrate driver: %w", err)
+	case "postgres", "mysql":
+		// Migrate over a single connection so closing the migrator hands it
+		// back to the pool instead of closing db
+		if dbType == "mysql" && mysqlDriver == nil {
+			return nil, fmt.Errorf("MySQL support is not compiled in: build with -tags mysql")
+		}
+		ctx := context.Background()
+		if conn, err = db.Conn(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get %s connection: %w", dbType, err)
+		}
+		if dbType == "mysql" {
+			driver, err = mysqlDriver(ctx, conn)
+		} else {
+			driver, err = postgres.WithConnection(ctx, conn, &postgres.Config{})
 		}
-		m, err = migrate.NewWithDatabaseInstance(
-			path,
-			"postgres",
-			driver,
-		)
 		if err != nil {
-			return fmt.Errorf("failed to initialize migrator for PostgreSQL: %w", err)
+			conn.Close()
+			return nil, fmt.Errorf("failed to create %s migrate driver: %w", dbType, err)
 		}
 
 	default:
-		return fmt.Errorf("unsupported database type: %s", dbType)
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, dbType, driver)
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, fmt.Errorf("failed to initialize migrator: %w", err)
+	}
+	return &Migrator{m: m, conn: conn}, nil
+}
+
+// Up applies all pending migrations
+func (g *Migrator) Up() error {
+	return g.run(g.m.Up())
+}
+
+// Down reverts the given number of applied migrations
+func (g *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive")
+	}
+	return g.run(g.m.Steps(-steps))
+}
+
+// Goto migrates up or down to the given version
+func (g *Migrator) Goto(version uint) error {
+	return g.run(g.m.Migrate(version))
+}
+
+// Force records version as applied and clears the dirty flag without running
+// any SQL, once the schema has been repaired by hand after a failed migration
+func (g *Migrator) Force(version int) error {
+	return g.m.Force(version)
+}
+
+// Version returns the applied schema version. ok is false on a database no
+// migration has run on yet.
+func (g *Migrator) Version() (version uint, dirty bool, ok bool, err error) {
+	version, dirty, err = g.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, false, nil
+	}
+	if err != nil {
+		return 0, false, false, err
+	}
+	return version, dirty, true, nil
+}
+
+// Latest returns the newest version among the embedded migrations
+func Latest() (uint, error) {
+	src, err := iofs.New(dialectFS(""), ".")
+	if err != nil {
+		return 0, fmt.Errorf("failed to load migrations: %w", err)
 	}
+	defer src.Close()
 
-	// Run migrations (idempotent - only applies new versions)
-	err = m.Up()
+	version, err := src.First()
 	if err != nil {
-		if err.Error() == "no change" {
-			log.Println("No new migrations to apply")
-			return nil
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
 		}
-		if err.Error() == "dirty" {
-			return fmt.Errorf("database is in dirty state (interrupted migration). Manual intervention required: check schema_migrations table and rollback if needed")
+		if err != nil {
+			return 0, err
 		}
+		version = next
+	}
+}
+
+// Close releases the migrator's connection
+func (g *Migrator) Close() error {
+	if g.conn != nil {
+		return g.conn.Close()
+	}
+	return nil
+}
+
+// run treats "already at that version" as success and explains a dirty database
+func (g *Migrator) run(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	var dirty migrate.ErrDirty
+	if errors.As(err, &dirty) {
+		return fmt.Errorf("database is in dirty state at version %d (interrupted migration): repair the schema, then run \"server migrate force %d\"", dirty.Version, dirty.Version-1)
+	}
+	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
+	return nil
+}
+
+// RunMigrations runs pending migrations for the given database
+// dbType should be "sqlite3", "postgres" or "mysql"
+func RunMigrations(db *sql.DB, dbType string) error {
+	m, err := New(db, dbType)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
 
-	log.Println("Migrations applied successfully")
+	before, _, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if err := m.Up(); err != nil {
+		return err
+	}
+	after, _, _, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	if after == before {
+		slog.Info("No new migrations to apply", "version", after)
+	} else {
+		slog.Info("Migrations applied successfully", "from", before, "to", after)
+	}
 	return nil
 }
+
+// dialectFS returns the migrations for dbType, with its overrides in place of
+// the shared files
+func dialectFS(dbType string) fs.FS {
+	shared, _ := fs.Sub(files, "files")
+	dir, ok := dialectDirs[dbType]
+	if !ok {
+		return shared
+	}
+	overrides, _ := fs.Sub(shared, dir)
+	return overlayFS{shared: shared, overrides: overrides}
+}
+
+// overlayFS lists the shared files and opens a dialect override when one exists
+type overlayFS struct {
+	shared    fs.FS
+	overrides fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if f, err := o.overrides.Open(name); err == nil {
+		return f, nil
+	}
+	return o.shared.Open(name)
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(o.shared, name)
+}
@@ -0,0 +1,95 @@
+package migrations
+
+import (
+	"database/sql"
+	"io/fs"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigratorSQLiteUpDown(t *testing.T) {
+	db := openSQLite(t)
+	latest, err := Latest()
+	if err != nil {
+		t.Fatalf("failed to read latest version: %v", err)
+	}
+
+	m, err := New(db, "sqlite3")
+	if err != nil {
+		t.Fatalf("failed to create migrator: %v", err)
+	}
+	defer m.Close()
+
+	if _, _, ok, err := m.Version(); err != nil || ok {
+		t.Fatalf("expected an unversioned database, got ok=%v err=%v", ok, err)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("up failed: %v", err)
+	}
+	if version, dirty, ok, _ := m.Version(); !ok || dirty || version != latest {
+		t.Fatalf("expected clean version %d, got %d (dirty=%v)", latest, version, dirty)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("expected a second up to be a no-op, got %v", err)
+	}
+
+	// Every down migration must undo its up migration on SQLite too
+	if err := m.Goto(1); err != nil {
+		t.Fatalf("down to version 1 failed: %v", err)
+	}
+	if err := m.Down(1); err != nil {
+		t.Fatalf("down failed: %v", err)
+	}
+	if _, _, ok, _ := m.Version(); ok {
+		t.Fatal("expected every migration reverted")
+	}
+	var tables int
+	db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'expenses'`).Scan(&tables)
+	if tables != 0 {
+		t.Error("expected the expenses table dropped")
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("re-applying migrations failed: %v", err)
+	}
+}
+
+func TestRunMigrationsKeepsDBOpen(t *testing.T) {
+	db := openSQLite(t)
+	if err := RunMigrations(db, "sqlite3"); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+	if err := db.Ping(); err != nil {
+		t.Fatalf("expected the database to stay open: %v", err)
+	}
+	if err := RunMigrations(db, "oracle"); err == nil {
+		t.Error("expected an unsupported database type to fail")
+	}
+}
+
+func TestDialectOverridesShadowSharedFiles(t *testing.T) {
+	for _, dir := range dialectDirs {
+		entries, err := fs.ReadDir(files, "files/"+dir)
+		if err != nil {
+			t.Fatalf("failed to list %s overrides: %v", dir, err)
+		}
+		for _, entry := range entries {
+			if _, err := fs.Stat(files, "files/"+entry.Name()); err != nil {
+				t.Errorf("%s/%s does not replace a shared migration", dir, entry.Name())
+			}
+		}
+	}
+}
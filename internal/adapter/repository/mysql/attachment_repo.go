@@ -0,0 +1,99 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.AttachmentRepository = (*AttachmentRepository)(nil)
+
+// AttachmentRepository stores expense attachment metadata in MySQL
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// Create creates a new attachment record
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *domain.ExpenseAttachment) error {
+	const query = `
+		INSERT INTO expense_attachments (id, expense_id, user_id, mime_type, size_bytes, storage_key, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		attachment.ID,
+		attachment.ExpenseID,
+		attachment.UserID,
+		attachment.MimeType,
+		attachment.Size,
+		attachment.StorageKey,
+		attachment.CreatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves an attachment by ID
+func (r *AttachmentRepository) GetByID(ctx context.Context, id string) (*domain.ExpenseAttachment, error) {
+	const query = `
+		SELECT id, expense_id, user_id, mime_type, size_bytes, storage_key, created_at
+		FROM expense_attachments
+		WHERE id = ?
+	`
+	attachment := &domain.ExpenseAttachment{}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&attachment.ID,
+		&attachment.ExpenseID,
+		&attachment.UserID,
+		&attachment.MimeType,
+		&attachment.Size,
+		&attachment.StorageKey,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// GetByExpenseID retrieves all attachments of an expense
+func (r *AttachmentRepository) GetByExpenseID(ctx context.Context, expenseID string) ([]*domain.ExpenseAttachment, error) {
+	const query = `
+		SELECT id, expense_id, user_id, mime_type, size_bytes, storage_key, created_at
+		FROM expense_attachments
+		WHERE expense_id = ?
+		ORDER BY created_at ASC
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []*domain.ExpenseAttachment
+	for rows.Next() {
+		attachment := &domain.ExpenseAttachment{}
+		err := rows.Scan(
+			&attachment.ID,
+			&attachment.ExpenseID,
+			&attachment.UserID,
+			&attachment.MimeType,
+			&attachment.Size,
+			&attachment.StorageKey,
+			&attachment.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, rows.Err()
+}
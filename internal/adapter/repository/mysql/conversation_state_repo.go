@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ConversationStateRepository = (*ConversationStateRepository)(nil)
+
+// ConversationStateRepository stores the users' pending clarification questions in MySQL
+type ConversationStateRepository struct {
+	db *sql.DB
+}
+
+// NewConversationStateRepository creates a new conversation state repository
+func NewConversationStateRepository(db *sql.DB) *ConversationStateRepository {
+	return &ConversationStateRepository{db: db}
+}
+
+const conversationStateColumns = `user_id, intent, awaiting, slots, expires_at, updated_at`
+
+// Save creates the user's state or replaces it
+func (r *ConversationStateRepository) Save(ctx context.Context, state *domain.ConversationState) error {
+	slots, err := json.Marshal(state.Slots)
+	if err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO conversation_states (` + conversationStateColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			intent = VALUES(intent),
+			awaiting = VALUES(awaiting),
+			slots = VALUES(slots),
+			expires_at = VALUES(expires_at),
+			updated_at = VALUES(updated_at)
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		state.UserID,
+		state.Intent,
+		state.Awaiting,
+		string(slots),
+		state.ExpiresAt,
+		state.UpdatedAt,
+	)
+	return err
+}
+
+// GetByUserID retrieves the user's state, or ErrNotFound if there is none
+func (r *ConversationStateRepository) GetByUserID(ctx context.Context, userID string) (*domain.ConversationState, error) {
+	const query = `SELECT ` + conversationStateColumns + ` FROM conversation_states WHERE user_id = ?`
+	state := &domain.ConversationState{}
+	var slots string
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(
+		&state.UserID,
+		&state.Intent,
+		&state.Awaiting,
+		&slots,
+		&state.ExpiresAt,
+		&state.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(slots), &state.Slots); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Delete removes the user's state
+func (r *ConversationStateRepository) Delete(ctx context.Context, userID string) error {
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, `DELETE FROM conversation_states WHERE user_id = ?`, userID)
+	return err
+}
+
+// DeleteExpiredBefore removes states that expired before the given time
+func (r *ConversationStateRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := txOrDB(ctx, r.db).ExecContext(ctx, `DELETE FROM conversation_states WHERE expires_at < ?`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,18 @@
+//go:build mysql
+
+package mysql
+
+import (
+	"errors"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+// erDupEntry is the server error number of a duplicate key
+const erDupEntry = 1062
+
+// isDuplicateEntry reports whether err is a unique or primary key violation
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erDupEntry
+}
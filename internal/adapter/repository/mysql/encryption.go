@@ -0,0 +1,150 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// fieldCipher encrypts sensitive columns when a cipher is set and leaves them
+// as plaintext otherwise
+type fieldCipher struct {
+	cipher domain.FieldCipher
+}
+
+func (c fieldCipher) encrypt(value string) (string, error) {
+	if c.cipher == nil {
+		return value, nil
+	}
+	encrypted, err := c.cipher.Encrypt(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt: %w", err)
+	}
+	return encrypted, nil
+}
+
+func (c fieldCipher) decrypt(value string) (string, error) {
+	if c.cipher == nil {
+		return value, nil
+	}
+	decrypted, err := c.cipher.Decrypt(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return decrypted, nil
+}
+
+// encryptAll encrypts each value, keeping their order
+func (c fieldCipher) encryptAll(values ...string) ([]string, error) {
+	encrypted := make([]string, len(values))
+	for i, value := range values {
+		var err error
+		if encrypted[i], err = c.encrypt(value); err != nil {
+			return nil, err
+		}
+	}
+	return encrypted, nil
+}
+
+// decryptAll decrypts each value in place
+func (c fieldCipher) decryptAll(values ...*string) error {
+	for _, value := range values {
+		decrypted, err := c.decrypt(*value)
+		if err != nil {
+			return err
+		}
+		*value = decrypted
+	}
+	return nil
+}
+
+// sensitiveColumns are the columns stored encrypted once a cipher is set
+var sensitiveColumns = []struct {
+	table   string
+	columns []string
+}{
+	{"expenses", []string{"description"}},
+	{"category_corrections", []string{"description"}},
+	{"expense_audit_log", []string{"before_snapshot", "after_snapshot"}},
+	{"interaction_logs", []string{"user_input", "system_prompt", "ai_raw_response", "bot_final_reply"}},
+}
+
+// reencryptBatch is how many rows ReencryptSensitiveColumns reads at a time
+const reencryptBatch = 500
+
+// ReencryptSensitiveColumns encrypts plaintext left from before encryption was
+// enabled and re-encrypts values sealed with a retired key, returning how many
+// rows were rewritten per table. It can be stopped and run again safely.
+func ReencryptSensitiveColumns(ctx context.Context, db *sql.DB, cipher domain.FieldCipher) (map[string]int, error) {
+	rewritten := make(map[string]int)
+	for _, spec := range sensitiveColumns {
+		selectQuery := fmt.Sprintf(`SELECT id, %s FROM %s WHERE id > ? ORDER BY id LIMIT ?`, strings.Join(spec.columns, ", "), spec.table)
+		assignments := make([]string, len(spec.columns))
+		for i, column := range spec.columns {
+			assignments[i] = column + " = ?"
+		}
+		updateQuery := fmt.Sprintf(`UPDATE %s SET %s WHERE id = ?`, spec.table, strings.Join(assignments, ", "))
+
+		lastID := ""
+		for {
+			rows, err := db.QueryContext(ctx, selectQuery, lastID, reencryptBatch)
+			if err != nil {
+				return rewritten, fmt.Errorf("failed to read %s: %w", spec.table, err)
+			}
+			type row struct {
+				id     string
+				values []sql.NullString
+			}
+			var batch []row
+			for rows.Next() {
+				r := row{values: make([]sql.NullString, len(spec.columns))}
+				dest := []interface{}{&r.id}
+				for i := range r.values {
+					dest = append(dest, &r.values[i])
+				}
+				if err := rows.Scan(dest...); err != nil {
+					rows.Close()
+					return rewritten, fmt.Errorf("failed to read %s: %w", spec.table, err)
+				}
+				batch = append(batch, r)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return rewritten, fmt.Errorf("failed to read %s: %w", spec.table, err)
+			}
+			if len(batch) == 0 {
+				break
+			}
+
+			for _, r := range batch {
+				lastID = r.id
+				changed := false
+				args := make([]interface{}, 0, len(r.values)+1)
+				for _, value := range r.values {
+					if value.Valid && cipher.NeedsReencrypt(value.String) {
+						plaintext, err := cipher.Decrypt(value.String)
+						if err != nil {
+							return rewritten, fmt.Errorf("failed to decrypt %s %s: %w", spec.table, r.id, err)
+						}
+						if value.String, err = cipher.Encrypt(plaintext); err != nil {
+							return rewritten, fmt.Errorf("failed to encrypt %s %s: %w", spec.table, r.id, err)
+						}
+						changed = true
+					}
+					args = append(args, value)
+				}
+				if !changed {
+					continue
+				}
+				if _, err := db.ExecContext(ctx, updateQuery, append(args, r.id)...); err != nil {
+					return rewritten, fmt.Errorf("failed to update %s %s: %w", spec.table, r.id, err)
+				}
+				rewritten[spec.table]++
+			}
+		}
+	}
+	return rewritten, nil
+}
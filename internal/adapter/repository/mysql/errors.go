@@ -0,0 +1,15 @@
+package mysql
+
+import (
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// conflictErr maps a unique or primary key violation to domain.ErrConflict
+func conflictErr(err error) error {
+	if isDuplicateEntry(err) {
+		return fmt.Errorf("%w: %v", domain.ErrConflict, err)
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ExchangeRateRepository = (*ExchangeRateRepository)(nil)
+
+const defaultRateProvider = "frankfurter"
+
+// ExchangeRateRepository stores exchange rates in MySQL
+type ExchangeRateRepository struct {
+	db *sql.DB
+}
+
+// NewExchangeRateRepository creates a new ExchangeRateRepository
+func NewExchangeRateRepository(db *sql.DB) *ExchangeRateRepository {
+	return &ExchangeRateRepository{db: db}
+}
+
+// SaveRate upserts a rate for a given provider/base/target/day
+func (r *ExchangeRateRepository) SaveRate(ctx context.Context, rate *domain.ExchangeRate) error {
+	provider := rate.Provider
+	if provider == "" {
+		provider = defaultRateProvider
+	}
+	const query = `INSERT INTO exchange_rates (provider, base_currency, target_currency, rate, rate_date, fetched_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE rate = VALUES(rate), fetched_at = VALUES(fetched_at)`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		provider,
+		rate.BaseCurrency,
+		rate.TargetCurrency,
+		rate.Rate,
+		rate.RateDate.Format("2006-01-02"),
+		rate.FetchedAt,
+	)
+	return err
+}
+
+// GetRate gets an exact rate for provider/base/target/date
+func (r *ExchangeRateRepository) GetRate(ctx context.Context, baseCurrency, targetCurrency string, rateDate time.Time) (*domain.ExchangeRate, error) {
+	return r.getRateInternal(ctx, baseCurrency, targetCurrency, rateDate, false)
+}
+
+// GetMostRecentRate returns the latest rate before or on given date
+func (r *ExchangeRateRepository) GetMostRecentRate(ctx context.Context, baseCurrency, targetCurrency string, before time.Time) (*domain.ExchangeRate, error) {
+	return r.getRateInternal(ctx, baseCurrency, targetCurrency, before, true)
+}
+
+// GetLatestRates returns the most recent rate on or before date for every target of a base currency
+func (r *ExchangeRateRepository) GetLatestRates(ctx context.Context, baseCurrency string, before time.Time) ([]*domain.ExchangeRate, error) {
+	query := `SELECT e.id, e.provider, e.base_currency, e.target_currency, e.rate, e.rate_date, e.fetched_at
+		FROM exchange_rates e
+		WHERE e.provider = ? AND e.base_currency = ? AND e.rate_date = (
+			SELECT MAX(x.rate_date) FROM exchange_rates x
+			WHERE x.provider = e.provider AND x.base_currency = e.base_currency
+				AND x.target_currency = e.target_currency AND x.rate_date <= ?
+		)
+		ORDER BY e.target_currency`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, defaultRateProvider, baseCurrency, before.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []*domain.ExchangeRate
+	for rows.Next() {
+		var rate domain.ExchangeRate
+		if err := rows.Scan(&rate.ID, &rate.Provider, &rate.BaseCurrency, &rate.TargetCurrency, &rate.Rate, &rate.RateDate, &rate.FetchedAt); err != nil {
+			return nil, err
+		}
+		rates = append(rates, &rate)
+	}
+	return rates, rows.Err()
+}
+
+func (r *ExchangeRateRepository) getRateInternal(ctx context.Context, baseCurrency, targetCurrency string, date time.Time, allowBefore bool) (*domain.ExchangeRate, error) {
+	provider := defaultRateProvider
+	var query string
+	var args []interface{}
+	if allowBefore {
+		query = `SELECT id, provider, base_currency, target_currency, rate, rate_date, fetched_at
+			FROM exchange_rates
+			WHERE provider = ? AND base_currency = ? AND target_currency = ? AND rate_date <= ?
+			ORDER BY rate_date DESC LIMIT 1`
+		args = []interface{}{provider, baseCurrency, targetCurrency, date.Format("2006-01-02")}
+	} else {
+		query = `SELECT id, provider, base_currency, target_currency, rate, rate_date, fetched_at
+			FROM exchange_rates
+			WHERE provider = ? AND base_currency = ? AND target_currency = ? AND rate_date = ?`
+		args = []interface{}{provider, baseCurrency, targetCurrency, date.Format("2006-01-02")}
+	}
+	row := txOrDB(ctx, r.db).QueryRowContext(ctx, query, args...)
+	var rate domain.ExchangeRate
+	if err := row.Scan(&rate.ID, &rate.Provider, &rate.BaseCurrency, &rate.TargetCurrency, &rate.Rate, &rate.RateDate, &rate.FetchedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	rate.Provider = provider
+	return &rate, nil
+}
@@ -0,0 +1,130 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ExpenseAuditRepository = (*ExpenseAuditRepository)(nil)
+
+// ExpenseAuditRepository stores the change history of expenses in MySQL.
+// Snapshots are stored as JSON.
+type ExpenseAuditRepository struct {
+	db     *sql.DB
+	cipher fieldCipher
+}
+
+// NewExpenseAuditRepository creates a new expense audit repository
+func NewExpenseAuditRepository(db *sql.DB) *ExpenseAuditRepository {
+	return &ExpenseAuditRepository{db: db}
+}
+
+// SetCipher encrypts snapshots at rest
+func (r *ExpenseAuditRepository) SetCipher(cipher domain.FieldCipher) {
+	r.cipher = fieldCipher{cipher: cipher}
+}
+
+// Create records an audit entry
+func (r *ExpenseAuditRepository) Create(ctx context.Context, entry *domain.ExpenseAuditEntry) error {
+	before, err := encodeExpenseSnapshot(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := encodeExpenseSnapshot(entry.After)
+	if err != nil {
+		return err
+	}
+	if before.String, err = r.cipher.encrypt(before.String); err != nil {
+		return err
+	}
+	if after.String, err = r.cipher.encrypt(after.String); err != nil {
+		return err
+	}
+
+	const query = `
+		INSERT INTO expense_audit_log (id, expense_id, user_id, action, actor, channel, before_snapshot, after_snapshot, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		entry.ID,
+		entry.ExpenseID,
+		entry.UserID,
+		entry.Action,
+		entry.Actor,
+		entry.Channel,
+		before,
+		after,
+		entry.CreatedAt,
+	)
+	return err
+}
+
+// GetByExpenseID retrieves an expense's audit entries, oldest first
+func (r *ExpenseAuditRepository) GetByExpenseID(ctx context.Context, expenseID string) ([]*domain.ExpenseAuditEntry, error) {
+	const query = `
+		SELECT id, expense_id, user_id, action, actor, channel, before_snapshot, after_snapshot, created_at
+		FROM expense_audit_log
+		WHERE expense_id = ?
+		ORDER BY created_at ASC
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.ExpenseAuditEntry
+	for rows.Next() {
+		entry := &domain.ExpenseAuditEntry{}
+		var before, after sql.NullString
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.ExpenseID,
+			&entry.UserID,
+			&entry.Action,
+			&entry.Actor,
+			&entry.Channel,
+			&before,
+			&after,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := r.cipher.decryptAll(&before.String, &after.String); err != nil {
+			return nil, err
+		}
+		if entry.Before, err = decodeExpenseSnapshot(before); err != nil {
+			return nil, err
+		}
+		if entry.After, err = decodeExpenseSnapshot(after); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func encodeExpenseSnapshot(expense *domain.Expense) (sql.NullString, error) {
+	if expense == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(expense)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func decodeExpenseSnapshot(raw sql.NullString) (*domain.Expense, error) {
+	if !raw.Valid || raw.String == "" {
+		return nil, nil
+	}
+	expense := &domain.Expense{}
+	if err := json.Unmarshal([]byte(raw.String), expense); err != nil {
+		return nil, err
+	}
+	return expense, nil
+}
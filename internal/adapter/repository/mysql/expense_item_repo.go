@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ExpenseItemRepository = (*ExpenseItemRepository)(nil)
+
+// ExpenseItemRepository stores receipt line items in MySQL
+type ExpenseItemRepository struct {
+	db *sql.DB
+}
+
+// NewExpenseItemRepository creates a new expense item repository
+func NewExpenseItemRepository(db *sql.DB) *ExpenseItemRepository {
+	return &ExpenseItemRepository{db: db}
+}
+
+// ReplaceForExpense replaces all line items of an expense in one transaction
+func (r *ExpenseItemRepository) ReplaceForExpense(ctx context.Context, expenseID string, items []*domain.ExpenseItem) error {
+	const deleteQuery = `DELETE FROM expense_items WHERE expense_id = ?`
+	const insertQuery = `
+		INSERT INTO expense_items (id, expense_id, name, quantity, unit_price, position, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, deleteQuery, expenseID); err != nil {
+		return err
+	}
+	for _, item := range items {
+		_, err := tx.ExecContext(ctx, insertQuery,
+			item.ID,
+			expenseID,
+			item.Name,
+			item.Quantity,
+			item.UnitPrice,
+			item.Position,
+			item.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetByExpenseIDs retrieves the line items of each expense, in receipt order
+func (r *ExpenseItemRepository) GetByExpenseIDs(ctx context.Context, expenseIDs []string) (map[string][]*domain.ExpenseItem, error) {
+	items := make(map[string][]*domain.ExpenseItem)
+	for start := 0; start < len(expenseIDs); start += maxTagLookupIDs {
+		ids := expenseIDs[start:min(start+maxTagLookupIDs, len(expenseIDs))]
+		args := make([]any, len(ids))
+		for i, id := range ids {
+			args[i] = id
+		}
+		rows, err := txOrDB(ctx, r.db).QueryContext(ctx, `
+			SELECT id, expense_id, name, quantity, unit_price, position, created_at
+			FROM expense_items
+			WHERE expense_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+			ORDER BY expense_id, position
+		`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			item := &domain.ExpenseItem{}
+			if err := rows.Scan(&item.ID, &item.ExpenseID, &item.Name, &item.Quantity, &item.UnitPrice, &item.Position, &item.CreatedAt); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			items[item.ExpenseID] = append(items[item.ExpenseID], item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
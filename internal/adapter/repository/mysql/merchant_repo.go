@@ -0,0 +1,77 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.MerchantRepository = (*MerchantRepository)(nil)
+
+// MerchantRepository stores the merchants expenses are paid at in MySQL
+type MerchantRepository struct {
+	db *sql.DB
+}
+
+// NewMerchantRepository creates a new merchant repository
+func NewMerchantRepository(db *sql.DB) *MerchantRepository {
+	return &MerchantRepository{db: db}
+}
+
+// Create creates a new merchant
+func (r *MerchantRepository) Create(ctx context.Context, merchant *domain.Merchant) error {
+	const query = `INSERT INTO merchants (id, user_id, name, normalized_name, created_at) VALUES (?, ?, ?, ?, ?)`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, merchant.ID, merchant.UserID, merchant.Name, merchant.NormalizedName, merchant.CreatedAt)
+	return conflictErr(err)
+}
+
+// GetByID retrieves a merchant by ID
+func (r *MerchantRepository) GetByID(ctx context.Context, id string) (*domain.Merchant, error) {
+	const query = `SELECT id, user_id, name, normalized_name, created_at FROM merchants WHERE id = ?`
+	return scanMerchant(txOrDB(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+// GetByUserIDAndNormalizedName retrieves a merchant by user and normalized name
+func (r *MerchantRepository) GetByUserIDAndNormalizedName(ctx context.Context, userID, normalizedName string) (*domain.Merchant, error) {
+	const query = `SELECT id, user_id, name, normalized_name, created_at FROM merchants WHERE user_id = ? AND normalized_name = ?`
+	return scanMerchant(txOrDB(ctx, r.db).QueryRowContext(ctx, query, userID, normalizedName))
+}
+
+func scanMerchant(row *sql.Row) (*domain.Merchant, error) {
+	merchant := &domain.Merchant{}
+	if err := row.Scan(&merchant.ID, &merchant.UserID, &merchant.Name, &merchant.NormalizedName, &merchant.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return merchant, nil
+}
+
+// SumByMerchantAndDateRange totals the selected expenses per merchant, largest total first
+func (r *MerchantRepository) SumByMerchantAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.MerchantTotal, error) {
+	where, args := expenseTotalsScope(ctx, query)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, `
+		SELECT m.id, m.name, SUM(e.home_amount) AS total, COUNT(*)
+		FROM (SELECT merchant_id, home_amount FROM expenses WHERE `+where+`) e
+		JOIN merchants m ON m.id = e.merchant_id
+		GROUP BY m.id, m.name
+		ORDER BY total DESC, m.name
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*domain.MerchantTotal
+	for rows.Next() {
+		total := &domain.MerchantTotal{}
+		if err := rows.Scan(&total.MerchantID, &total.Name, &total.Total, &total.Count); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
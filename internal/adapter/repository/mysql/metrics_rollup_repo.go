@@ -0,0 +1,142 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.MetricsRollupRepository = (*MetricsRollupRepository)(nil)
+
+// MetricsRollupRepository computes and stores the per-day metrics rollup in MySQL
+type MetricsRollupRepository struct {
+	db *sql.DB
+}
+
+// NewMetricsRollupRepository creates a new metrics rollup repository
+func NewMetricsRollupRepository(db *sql.DB) *MetricsRollupRepository {
+	return &MetricsRollupRepository{db: db}
+}
+
+const metricsRollupColumns = `metric_date, active_users, new_users, total_expense, expense_count, ai_calls, ai_input_tokens, ai_output_tokens, ai_total_tokens, ai_cost, updated_at`
+
+// ComputeDay aggregates the raw tables for the UTC day starting at day.
+// A user is active on a day they recorded an expense or made an AI call.
+func (r *MetricsRollupRepository) ComputeDay(ctx context.Context, day time.Time) (*domain.MetricsRollup, error) {
+	const query = `
+		SELECT a.active_users, u.new_users, e.total_expense, e.expense_count,
+			c.calls, c.input_tokens, c.output_tokens, c.total_tokens, c.cost
+		FROM
+			(SELECT COUNT(*) AS active_users FROM (
+				SELECT user_id FROM expenses WHERE created_at >= ? AND created_at < ?
+				UNION
+				SELECT user_id FROM ai_cost_logs WHERE created_at >= ? AND created_at < ?
+			) active) a,
+			(SELECT COUNT(*) AS new_users FROM users WHERE created_at >= ? AND created_at < ?) u,
+			(SELECT COALESCE(SUM(home_amount), 0) AS total_expense, COUNT(*) AS expense_count
+				FROM expenses WHERE expense_date >= ? AND expense_date < ? AND deleted_at IS NULL) e,
+			(SELECT COUNT(*) AS calls,
+				COALESCE(SUM(input_tokens), 0) AS input_tokens,
+				COALESCE(SUM(output_tokens), 0) AS output_tokens,
+				COALESCE(SUM(total_tokens), 0) AS total_tokens,
+				COALESCE(SUM(cost), 0) AS cost
+				FROM ai_cost_logs WHERE created_at >= ? AND created_at < ?) c
+	`
+	day = domain.MetricsDay(day)
+	rollup := &domain.MetricsRollup{Date: day}
+	// MySQL has no numbered placeholders, so each of the five scans takes the day's bounds
+	var args []any
+	for range 5 {
+		args = append(args, day, day.AddDate(0, 0, 1))
+	}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(
+		&rollup.ActiveUsers,
+		&rollup.NewUsers,
+		&rollup.TotalExpense,
+		&rollup.ExpenseCount,
+		&rollup.AICalls,
+		&rollup.AIInputTokens,
+		&rollup.AIOutputTokens,
+		&rollup.AITotalTokens,
+		&rollup.AICost,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rollup, nil
+}
+
+// Upsert stores a day's rollup, replacing any earlier one
+func (r *MetricsRollupRepository) Upsert(ctx context.Context, rollup *domain.MetricsRollup) error {
+	const query = `
+		INSERT INTO daily_metrics (` + metricsRollupColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			active_users = VALUES(active_users),
+			new_users = VALUES(new_users),
+			total_expense = VALUES(total_expense),
+			expense_count = VALUES(expense_count),
+			ai_calls = VALUES(ai_calls),
+			ai_input_tokens = VALUES(ai_input_tokens),
+			ai_output_tokens = VALUES(ai_output_tokens),
+			ai_total_tokens = VALUES(ai_total_tokens),
+			ai_cost = VALUES(ai_cost),
+			updated_at = VALUES(updated_at)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		rollup.Date.Format("2006-01-02"),
+		rollup.ActiveUsers,
+		rollup.NewUsers,
+		rollup.TotalExpense,
+		rollup.ExpenseCount,
+		rollup.AICalls,
+		rollup.AIInputTokens,
+		rollup.AIOutputTokens,
+		rollup.AITotalTokens,
+		rollup.AICost,
+		rollup.UpdatedAt,
+	)
+	return err
+}
+
+// GetRange retrieves the rollups of the days from through to, oldest first
+func (r *MetricsRollupRepository) GetRange(ctx context.Context, from, to time.Time) ([]*domain.MetricsRollup, error) {
+	const query = `
+		SELECT ` + metricsRollupColumns + `
+		FROM daily_metrics
+		WHERE metric_date >= ? AND metric_date <= ?
+		ORDER BY metric_date ASC
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query,
+		domain.MetricsDay(from).Format("2006-01-02"),
+		domain.MetricsDay(to).Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollups []*domain.MetricsRollup
+	for rows.Next() {
+		rollup := &domain.MetricsRollup{}
+		if err := rows.Scan(
+			&rollup.Date,
+			&rollup.ActiveUsers,
+			&rollup.NewUsers,
+			&rollup.TotalExpense,
+			&rollup.ExpenseCount,
+			&rollup.AICalls,
+			&rollup.AIInputTokens,
+			&rollup.AIOutputTokens,
+			&rollup.AITotalTokens,
+			&rollup.AICost,
+			&rollup.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, rollup)
+	}
+	return rollups, rows.Err()
+}
@@ -0,0 +1,8 @@
+//go:build !mysql
+
+package mysql
+
+// isDuplicateEntry is never true without the driver, as no query can run
+func isDuplicateEntry(err error) bool {
+	return false
+}
@@ -0,0 +1,44 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.PolicyRepository = (*PolicyRepository)(nil)
+
+// PolicyRepository implements domain.PolicyRepository using MySQL
+type PolicyRepository struct {
+	db *sql.DB
+}
+
+// NewPolicyRepository creates a new policy repository
+func NewPolicyRepository(db *sql.DB) *PolicyRepository {
+	return &PolicyRepository{db: db}
+}
+
+// GetByKey retrieves a policy by its unique key
+func (r *PolicyRepository) GetByKey(ctx context.Context, key string) (*domain.Policy, error) {
+	// key is a reserved word in MySQL
+	const query = "SELECT id, `key`, title, content, version, created_at, updated_at FROM policies WHERE `key` = ?"
+	policy := &domain.Policy{}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, key).Scan(
+		&policy.ID,
+		&policy.Key,
+		&policy.Title,
+		&policy.Content,
+		&policy.Version,
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return policy, nil
+}
@@ -0,0 +1,48 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ProcessedEventRepository = (*ProcessedEventRepository)(nil)
+
+// ProcessedEventRepository tracks handled webhook events in MySQL
+type ProcessedEventRepository struct {
+	db *sql.DB
+}
+
+// NewProcessedEventRepository creates a new processed event repository
+func NewProcessedEventRepository(db *sql.DB) *ProcessedEventRepository {
+	return &ProcessedEventRepository{db: db}
+}
+
+// MarkProcessed records the event, returning false if it was already recorded
+func (r *ProcessedEventRepository) MarkProcessed(ctx context.Context, event *domain.ProcessedEvent) (bool, error) {
+	const query = `
+		INSERT IGNORE INTO processed_events (messenger, event_id, processed_at)
+		VALUES (?, ?, ?)
+	`
+	result, err := txOrDB(ctx, r.db).ExecContext(ctx, query, event.Messenger, event.EventID, event.ProcessedAt)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// DeleteBefore removes events processed before the given time
+func (r *ProcessedEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	const query = `DELETE FROM processed_events WHERE processed_at < ?`
+	result, err := txOrDB(ctx, r.db).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
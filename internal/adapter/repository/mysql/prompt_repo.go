@@ -0,0 +1,113 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.PromptRepository = (*PromptRepository)(nil)
+
+// PromptRepository stores versioned AI prompt templates in MySQL
+type PromptRepository struct {
+	db *sql.DB
+}
+
+// NewPromptRepository creates a new prompt repository
+func NewPromptRepository(db *sql.DB) *PromptRepository {
+	return &PromptRepository{db: db}
+}
+
+// Create stores a new prompt version
+func (r *PromptRepository) Create(ctx context.Context, prompt *domain.PromptTemplate) error {
+	const query = `
+		INSERT INTO prompt_templates (id, name, version, template, active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		prompt.ID,
+		prompt.Name,
+		prompt.Version,
+		prompt.Template,
+		prompt.Active,
+		prompt.CreatedAt,
+	)
+	return err
+}
+
+// GetActive retrieves the active version of a prompt, or nil if none is active
+func (r *PromptRepository) GetActive(ctx context.Context, name string) (*domain.PromptTemplate, error) {
+	const query = `
+		SELECT id, name, version, template, active, created_at
+		FROM prompt_templates
+		WHERE name = ? AND active = TRUE
+		ORDER BY version DESC
+		LIMIT 1
+	`
+	prompts, err := r.queryPrompts(ctx, query, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(prompts) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return prompts[0], nil
+}
+
+// GetByName retrieves all versions of a prompt, newest first
+func (r *PromptRepository) GetByName(ctx context.Context, name string) ([]*domain.PromptTemplate, error) {
+	const query = `
+		SELECT id, name, version, template, active, created_at
+		FROM prompt_templates
+		WHERE name = ?
+		ORDER BY version DESC
+	`
+	return r.queryPrompts(ctx, query, name)
+}
+
+// Activate makes a version the only active one for its prompt; version 0
+// deactivates all versions so the built-in prompt is used
+func (r *PromptRepository) Activate(ctx context.Context, name string, version int) error {
+	const existsQuery = `SELECT COUNT(*) FROM prompt_templates WHERE name = ? AND version = ?`
+	const updateQuery = `UPDATE prompt_templates SET active = (version = ?) WHERE name = ?`
+
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if version != 0 {
+		var count int
+		if err := tx.QueryRowContext(ctx, existsQuery, name, version).Scan(&count); err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("prompt %s version %d not found", name, version)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, updateQuery, version, name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PromptRepository) queryPrompts(ctx context.Context, query string, args ...interface{}) ([]*domain.PromptTemplate, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prompts []*domain.PromptTemplate
+	for rows.Next() {
+		prompt := &domain.PromptTemplate{}
+		if err := rows.Scan(&prompt.ID, &prompt.Name, &prompt.Version, &prompt.Template, &prompt.Active, &prompt.CreatedAt); err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, prompt)
+	}
+	return prompts, rows.Err()
+}
@@ -0,0 +1,58 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+type ShortLinkRepository struct {
+	db *sql.DB
+}
+
+func NewShortLinkRepository(db *sql.DB) *ShortLinkRepository {
+	return &ShortLinkRepository{db: db}
+}
+
+func (r *ShortLinkRepository) Create(ctx context.Context, link *domain.ShortLink) error {
+	query := `
+		INSERT INTO short_links (id, target_token, expires_at, created_at)
+		VALUES (?, ?, ?, ?)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, link.ID, link.TargetToken, link.ExpiresAt, link.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create short link: %w", err)
+	}
+	return nil
+}
+
+func (r *ShortLinkRepository) Get(ctx context.Context, id string) (*domain.ShortLink, error) {
+	query := `
+		SELECT id, target_token, expires_at, created_at
+		FROM short_links
+		WHERE id = ? AND expires_at > ?
+	`
+	row := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id, time.Now())
+
+	var link domain.ShortLink
+	err := row.Scan(&link.ID, &link.TargetToken, &link.ExpiresAt, &link.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("short link %w or expired", domain.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get short link: %w", err)
+	}
+	return &link, nil
+}
+
+func (r *ShortLinkRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM short_links WHERE expires_at <= ?`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired short links: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.UnitOfWork = (*UnitOfWork)(nil)
+
+// UnitOfWork runs repository calls on the same database in one transaction
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork creates a unit of work for the repositories built on db
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// activeTx is the transaction a unit of work carries through the context.
+// It records its database so repositories on another one ignore it.
+type activeTx struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+type activeTxKey struct{}
+
+// Do runs fn in a transaction, or in the caller's when ctx already has one
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if current, ok := ctx.Value(activeTxKey{}).(activeTx); ok && current.db == u.db {
+		return fn(ctx)
+	}
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txCtx := domain.WithinUnitOfWork(context.WithValue(ctx, activeTxKey{}, activeTx{db: u.db, tx: tx}))
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// dbtx is what repositories query through: a *sql.DB or a *sql.Tx
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txOrDB returns the unit of work's transaction on db when ctx carries one,
+// and db itself otherwise
+func txOrDB(ctx context.Context, db *sql.DB) dbtx {
+	if current, ok := ctx.Value(activeTxKey{}).(activeTx); ok && current.db == db {
+		return current.tx
+	}
+	return db
+}
+
+// repoTx is a transaction a repository method opened for its own statements.
+// Inside a unit of work it is the unit's transaction, which the unit commits
+// or rolls back, so Commit and Rollback do nothing.
+type repoTx struct {
+	*sql.Tx
+	joined bool
+}
+
+// beginTx starts a transaction for a repository method, joining the unit of
+// work in ctx if there is one
+func beginTx(ctx context.Context, db *sql.DB) (*repoTx, error) {
+	if current, ok := ctx.Value(activeTxKey{}).(activeTx); ok && current.db == db {
+		return &repoTx{Tx: current.tx, joined: true}, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &repoTx{Tx: tx}, nil
+}
+
+// Commit commits a transaction the repository method opened itself
+func (t *repoTx) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+// Rollback rolls back a transaction the repository method opened itself
+func (t *repoTx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
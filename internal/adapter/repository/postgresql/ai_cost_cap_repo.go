@@ -0,0 +1,75 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.AICostCapRepository = (*AICostCapRepository)(nil)
+
+// AICostCapRepository stores AI spending cap overrides in PostgreSQL
+type AICostCapRepository struct {
+	db *sql.DB
+}
+
+// NewAICostCapRepository creates a new AI cost cap repository
+func NewAICostCapRepository(db *sql.DB) *AICostCapRepository {
+	return &AICostCapRepository{db: db}
+}
+
+// Get retrieves the cap for a scope, or nil if it is not overridden
+func (r *AICostCapRepository) Get(ctx context.Context, scope string) (*domain.AICostCap, error) {
+	const query = `SELECT scope, daily_usd, monthly_usd, updated_at FROM ai_cost_caps WHERE scope = $1`
+	costCap := &domain.AICostCap{}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, scope).Scan(&costCap.Scope, &costCap.DailyUSD, &costCap.MonthlyUSD, &costCap.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return costCap, nil
+}
+
+// GetAll retrieves all cap overrides
+func (r *AICostCapRepository) GetAll(ctx context.Context) ([]*domain.AICostCap, error) {
+	const query = `SELECT scope, daily_usd, monthly_usd, updated_at FROM ai_cost_caps ORDER BY scope`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var caps []*domain.AICostCap
+	for rows.Next() {
+		costCap := &domain.AICostCap{}
+		if err := rows.Scan(&costCap.Scope, &costCap.DailyUSD, &costCap.MonthlyUSD, &costCap.UpdatedAt); err != nil {
+			return nil, err
+		}
+		caps = append(caps, costCap)
+	}
+	return caps, rows.Err()
+}
+
+// Upsert creates or replaces the cap for its scope
+func (r *AICostCapRepository) Upsert(ctx context.Context, costCap *domain.AICostCap) error {
+	const query = `
+		INSERT INTO ai_cost_caps (scope, daily_usd, monthly_usd, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope) DO UPDATE SET
+			daily_usd = excluded.daily_usd,
+			monthly_usd = excluded.monthly_usd,
+			updated_at = excluded.updated_at
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, costCap.Scope, costCap.DailyUSD, costCap.MonthlyUSD, costCap.UpdatedAt)
+	return err
+}
+
+// Delete removes the cap override for a scope
+func (r *AICostCapRepository) Delete(ctx context.Context, scope string) error {
+	const query = `DELETE FROM ai_cost_caps WHERE scope = $1`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, scope)
+	return err
+}
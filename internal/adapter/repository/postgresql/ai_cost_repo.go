@@ -23,14 +23,14 @@ func (r *AICostRepository) Create(ctx context.Context, log *domain.AICostLog) er
 		INSERT INTO ai_cost_logs (
 			id, user_id, operation, provider, model,
 			input_tokens, output_tokens, total_tokens,
-			cost, currency, cost_note, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			cost, currency, cost_note, prompt_version, source, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		log.ID, log.UserID, log.Operation, log.Provider, log.Model,
 		log.InputTokens, log.OutputTokens, log.TotalTokens,
-		log.Cost, log.Currency, log.CostNote, log.CreatedAt,
+		log.Cost, log.Currency, log.CostNote, log.PromptVersion, log.Source, log.CreatedAt,
 	)
 	return err
 }
@@ -40,14 +40,14 @@ func (r *AICostRepository) GetByUserID(ctx context.Context, userID string, limit
 		SELECT
 			id, user_id, operation, provider, model,
 			input_tokens, output_tokens, total_tokens,
-			cost, currency, cost_note, created_at
+			cost, currency, cost_note, prompt_version, source, created_at
 		FROM ai_cost_logs
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 		LIMIT $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, userID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -59,7 +59,7 @@ func (r *AICostRepository) GetByUserID(ctx context.Context, userID string, limit
 		if err := rows.Scan(
 			&log.ID, &log.UserID, &log.Operation, &log.Provider, &log.Model,
 			&log.InputTokens, &log.OutputTokens, &log.TotalTokens,
-			&log.Cost, &log.Currency, &log.CostNote, &log.CreatedAt,
+			&log.Cost, &log.Currency, &log.CostNote, &log.PromptVersion, &log.Source, &log.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -80,7 +80,7 @@ func (r *AICostRepository) GetSummary(ctx context.Context, from, to time.Time) (
 	`
 
 	summary := &domain.AICostSummary{}
-	err := r.db.QueryRowContext(ctx, query, from, to).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, from, to).Scan(
 		&summary.TotalCalls,
 		&summary.TotalInputTokens,
 		&summary.TotalOutputTokens,
@@ -106,7 +106,7 @@ func (r *AICostRepository) GetDailyStats(ctx context.Context, from, to time.Time
 		ORDER BY date DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -143,7 +143,7 @@ func (r *AICostRepository) GetByOperation(ctx context.Context, from, to time.Tim
 		ORDER BY cost DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -181,7 +181,7 @@ func (r *AICostRepository) GetByUserSummary(ctx context.Context, from, to time.T
 		LIMIT $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, from, to, limit)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -203,3 +203,29 @@ func (r *AICostRepository) GetByUserSummary(ctx context.Context, from, to time.T
 	}
 	return results, rows.Err()
 }
+
+// GetUserSummary retrieves aggregated AI cost metrics for one user in a date range
+func (r *AICostRepository) GetUserSummary(ctx context.Context, userID string, from, to time.Time) (*domain.AICostSummary, error) {
+	const query = `
+		SELECT
+			COUNT(*) as total_calls,
+			COALESCE(SUM(input_tokens), 0) as total_input_tokens,
+			COALESCE(SUM(output_tokens), 0) as total_output_tokens,
+			COALESCE(SUM(total_tokens), 0) as total_tokens,
+			COALESCE(SUM(cost), 0) as total_cost
+		FROM ai_cost_logs
+		WHERE user_id = $1 AND created_at >= $2 AND created_at <= $3
+	`
+	summary := &domain.AICostSummary{Currency: "USD"}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, userID, from, to).Scan(
+		&summary.TotalCalls,
+		&summary.TotalInputTokens,
+		&summary.TotalOutputTokens,
+		&summary.TotalTokens,
+		&summary.TotalCost,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ArchiveRepository = (*ArchiveRepository)(nil)
+
+// ArchiveRepository stores expense archive metadata in PostgreSQL
+type ArchiveRepository struct {
+	db *sql.DB
+}
+
+// NewArchiveRepository creates a new archive repository
+func NewArchiveRepository(db *sql.DB) *ArchiveRepository {
+	return &ArchiveRepository{db: db}
+}
+
+const archiveColumns = `id, user_id, period, start_date, end_date, expense_count, total_amount, format,
+	object_key, checksum, size_bytes, compressed_size_bytes, retention_days, expires_at, created_at`
+
+// Create creates a new archive record
+func (r *ArchiveRepository) Create(ctx context.Context, archive *domain.Archive) error {
+	const query = `
+		INSERT INTO archives (` + archiveColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		archive.ID,
+		archive.UserID,
+		archive.Period,
+		archive.StartDate,
+		archive.EndDate,
+		archive.ExpenseCount,
+		archive.TotalAmount,
+		archive.Format,
+		archive.ObjectKey,
+		archive.Checksum,
+		archive.Size,
+		archive.CompressedSize,
+		archive.RetentionDays,
+		archive.ExpiresAt,
+		archive.CreatedAt,
+	)
+	return err
+}
+
+// GetByID retrieves an archive by ID
+func (r *ArchiveRepository) GetByID(ctx context.Context, id string) (*domain.Archive, error) {
+	const query = `SELECT ` + archiveColumns + ` FROM archives WHERE id = $1`
+	archives, err := r.query(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return archives[0], nil
+}
+
+// GetByUserID retrieves the user's archives, newest first
+func (r *ArchiveRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Archive, error) {
+	const query = `SELECT ` + archiveColumns + ` FROM archives WHERE user_id = $1 ORDER BY created_at DESC, id`
+	return r.query(ctx, query, userID)
+}
+
+// Delete removes an archive record
+func (r *ArchiveRepository) Delete(ctx context.Context, id string) error {
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, `DELETE FROM archives WHERE id = $1`, id)
+	return err
+}
+
+// GetCreatedBefore retrieves every user's archives created before the given time, oldest first
+func (r *ArchiveRepository) GetCreatedBefore(ctx context.Context, before time.Time) ([]*domain.Archive, error) {
+	const query = `SELECT ` + archiveColumns + ` FROM archives WHERE created_at < $1 ORDER BY created_at, id`
+	return r.query(ctx, query, before)
+}
+
+func (r *ArchiveRepository) query(ctx context.Context, query string, args ...interface{}) ([]*domain.Archive, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var archives []*domain.Archive
+	for rows.Next() {
+		archive := &domain.Archive{}
+		if err := rows.Scan(
+			&archive.ID,
+			&archive.UserID,
+			&archive.Period,
+			&archive.StartDate,
+			&archive.EndDate,
+			&archive.ExpenseCount,
+			&archive.TotalAmount,
+			&archive.Format,
+			&archive.ObjectKey,
+			&archive.Checksum,
+			&archive.Size,
+			&archive.CompressedSize,
+			&archive.RetentionDays,
+			&archive.ExpiresAt,
+			&archive.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		archives = append(archives, archive)
+	}
+	return archives, rows.Err()
+}
@@ -0,0 +1,160 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.BudgetRepository = (*BudgetRepository)(nil)
+
+// BudgetRepository stores budgets in PostgreSQL
+type BudgetRepository struct {
+	db *sql.DB
+}
+
+// NewBudgetRepository creates a new budget repository
+func NewBudgetRepository(db *sql.DB) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+// Create creates a new budget
+func (r *BudgetRepository) Create(ctx context.Context, budget *domain.Budget) error {
+	const query = `
+		INSERT INTO budgets (id, user_id, category_id, group_id, limit_amount, period, threshold, name, start_date, end_date, workspace_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		budget.ID,
+		budget.UserID,
+		budget.CategoryID,
+		budget.GroupID,
+		budget.Limit,
+		budget.Period,
+		budget.Threshold,
+		budget.Name,
+		budget.StartDate,
+		budget.EndDate,
+		budget.WorkspaceID,
+		budget.CreatedAt,
+		budget.UpdatedAt,
+	)
+	return conflictErr(err)
+}
+
+// GetByID retrieves a budget by ID
+func (r *BudgetRepository) GetByID(ctx context.Context, id string) (*domain.Budget, error) {
+	const query = `
+		SELECT id, user_id, category_id, group_id, limit_amount, period, threshold, name, start_date, end_date, workspace_id, created_at, updated_at
+		FROM budgets
+		WHERE id = $1
+	`
+	budget := &domain.Budget{}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&budget.ID,
+		&budget.UserID,
+		&budget.CategoryID,
+		&budget.GroupID,
+		&budget.Limit,
+		&budget.Period,
+		&budget.Threshold,
+		&budget.Name,
+		&budget.StartDate,
+		&budget.EndDate,
+		&budget.WorkspaceID,
+		&budget.CreatedAt,
+		&budget.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return budget, nil
+}
+
+// GetByUserID retrieves all personal budgets for a user, overall budgets first
+func (r *BudgetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Budget, error) {
+	scope, args := workspaceScope(ctx, "workspace_id", []any{userID})
+	query := `
+		SELECT id, user_id, category_id, group_id, limit_amount, period, threshold, name, start_date, end_date, workspace_id, created_at, updated_at
+		FROM budgets
+		WHERE user_id = $1 AND group_id IS NULL` + scope + `
+		ORDER BY category_id IS NOT NULL, created_at ASC
+	`
+	return r.queryBudgets(ctx, query, args...)
+}
+
+// GetByGroupID retrieves all budgets for a group ledger, overall budgets first
+func (r *BudgetRepository) GetByGroupID(ctx context.Context, groupID string) ([]*domain.Budget, error) {
+	const query = `
+		SELECT id, user_id, category_id, group_id, limit_amount, period, threshold, name, start_date, end_date, workspace_id, created_at, updated_at
+		FROM budgets
+		WHERE group_id = $1
+		ORDER BY category_id IS NOT NULL, created_at ASC
+	`
+	return r.queryBudgets(ctx, query, groupID)
+}
+
+func (r *BudgetRepository) queryBudgets(ctx context.Context, query string, args ...interface{}) ([]*domain.Budget, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []*domain.Budget
+	for rows.Next() {
+		budget := &domain.Budget{}
+		if err := rows.Scan(
+			&budget.ID,
+			&budget.UserID,
+			&budget.CategoryID,
+			&budget.GroupID,
+			&budget.Limit,
+			&budget.Period,
+			&budget.Threshold,
+			&budget.Name,
+			&budget.StartDate,
+			&budget.EndDate,
+			&budget.WorkspaceID,
+			&budget.CreatedAt,
+			&budget.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+	return budgets, rows.Err()
+}
+
+// Update updates a budget
+func (r *BudgetRepository) Update(ctx context.Context, budget *domain.Budget) error {
+	const query = `
+		UPDATE budgets
+		SET category_id = $1, limit_amount = $2, period = $3, threshold = $4, name = $5, start_date = $6, end_date = $7, updated_at = $8
+		WHERE id = $9
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		budget.CategoryID,
+		budget.Limit,
+		budget.Period,
+		budget.Threshold,
+		budget.Name,
+		budget.StartDate,
+		budget.EndDate,
+		budget.UpdatedAt,
+		budget.ID,
+	)
+	return err
+}
+
+// Delete deletes a budget
+func (r *BudgetRepository) Delete(ctx context.Context, id string) error {
+	const query = `DELETE FROM budgets WHERE id = $1`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, id)
+	return err
+}
@@ -20,32 +20,32 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 
 func (r *CategoryRepository) Create(ctx context.Context, category *domain.Category) error {
 	const query = `
-		INSERT INTO categories (id, user_id, name, is_default, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO categories (id, user_id, name, is_default, workspace_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		category.ID, category.UserID, category.Name,
-		category.IsDefault, category.CreatedAt,
+		category.IsDefault, category.WorkspaceID, category.CreatedAt,
 	)
-	return err
+	return conflictErr(err)
 }
 
 func (r *CategoryRepository) GetByID(ctx context.Context, id string) (*domain.Category, error) {
 	const query = `
-		SELECT id, user_id, name, is_default, created_at
+		SELECT id, user_id, name, is_default, workspace_id, created_at
 		FROM categories
 		WHERE id = $1
 	`
 
 	category := &domain.Category{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&category.ID, &category.UserID, &category.Name,
-		&category.IsDefault, &category.CreatedAt,
+		&category.IsDefault, &category.WorkspaceID, &category.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
@@ -53,14 +53,15 @@ func (r *CategoryRepository) GetByID(ctx context.Context, id string) (*domain.Ca
 }
 
 func (r *CategoryRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Category, error) {
-	const query = `
-		SELECT id, user_id, name, is_default, created_at
+	scope, args := workspaceScope(ctx, "workspace_id", []any{userID})
+	query := `
+		SELECT id, user_id, name, is_default, workspace_id, created_at
 		FROM categories
-		WHERE user_id = $1
+		WHERE user_id = $1` + scope + `
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +72,7 @@ func (r *CategoryRepository) GetByUserID(ctx context.Context, userID string) ([]
 		category := &domain.Category{}
 		if err := rows.Scan(
 			&category.ID, &category.UserID, &category.Name,
-			&category.IsDefault, &category.CreatedAt,
+			&category.IsDefault, &category.WorkspaceID, &category.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -81,20 +82,23 @@ func (r *CategoryRepository) GetByUserID(ctx context.Context, userID string) ([]
 }
 
 func (r *CategoryRepository) GetByUserIDAndName(ctx context.Context, userID, name string) (*domain.Category, error) {
-	const query = `
-		SELECT id, user_id, name, is_default, created_at
+	scope, args := workspaceScope(ctx, "workspace_id", []any{userID, name})
+	query := `
+		SELECT id, user_id, name, is_default, workspace_id, created_at
 		FROM categories
-		WHERE user_id = $1 AND name = $2
+		WHERE user_id = $1 AND name = $2` + scope + `
+		ORDER BY workspace_id
+		LIMIT 1
 	`
 
 	category := &domain.Category{}
-	err := r.db.QueryRowContext(ctx, query, userID, name).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(
 		&category.ID, &category.UserID, &category.Name,
-		&category.IsDefault, &category.CreatedAt,
+		&category.IsDefault, &category.WorkspaceID, &category.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
@@ -108,7 +112,7 @@ func (r *CategoryRepository) Update(ctx context.Context, category *domain.Catego
 		WHERE id = $1
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		category.ID, category.Name, category.IsDefault,
 	)
 	return err
@@ -116,7 +120,7 @@ func (r *CategoryRepository) Update(ctx context.Context, category *domain.Catego
 
 func (r *CategoryRepository) Delete(ctx context.Context, id string) error {
 	const query = `DELETE FROM categories WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, id)
 	return err
 }
 
@@ -126,11 +130,11 @@ func (r *CategoryRepository) CreateKeyword(ctx context.Context, keyword *domain.
 		VALUES ($1, $2, $3, $4, $5)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		keyword.ID, keyword.CategoryID, keyword.Keyword,
 		keyword.Priority, keyword.CreatedAt,
 	)
-	return err
+	return conflictErr(err)
 }
 
 func (r *CategoryRepository) GetKeywordsByCategory(ctx context.Context, categoryID string) ([]*domain.CategoryKeyword, error) {
@@ -141,7 +145,7 @@ func (r *CategoryRepository) GetKeywordsByCategory(ctx context.Context, category
 		ORDER BY priority DESC, created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, categoryID)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, categoryID)
 	if err != nil {
 		return nil, err
 	}
@@ -161,8 +165,44 @@ func (r *CategoryRepository) GetKeywordsByCategory(ctx context.Context, category
 	return keywords, rows.Err()
 }
 
+func (r *CategoryRepository) GetKeywordsByUserID(ctx context.Context, userID string) ([]*domain.CategoryKeyword, error) {
+	scope, args := workspaceScope(ctx, "c.workspace_id", []any{userID})
+	query := `
+		SELECT k.id, k.category_id, k.keyword, k.priority, k.created_at
+		FROM category_keywords k
+		JOIN categories c ON c.id = k.category_id
+		WHERE c.user_id = $1` + scope + `
+		ORDER BY k.priority DESC, k.created_at DESC
+	`
+
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keywords []*domain.CategoryKeyword
+	for rows.Next() {
+		keyword := &domain.CategoryKeyword{}
+		if err := rows.Scan(
+			&keyword.ID, &keyword.CategoryID, &keyword.Keyword,
+			&keyword.Priority, &keyword.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, keyword)
+	}
+	return keywords, rows.Err()
+}
+
+func (r *CategoryRepository) UpdateKeyword(ctx context.Context, keyword *domain.CategoryKeyword) error {
+	const query = `UPDATE category_keywords SET priority = $1 WHERE id = $2`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, keyword.Priority, keyword.ID)
+	return err
+}
+
 func (r *CategoryRepository) DeleteKeyword(ctx context.Context, id string) error {
 	const query = `DELETE FROM category_keywords WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, id)
 	return err
 }
@@ -24,7 +24,7 @@ func NewCurrencyRepository(db *sql.DB) *CurrencyRepository {
 // GetAll returns all currencies ordered by code
 func (r *CurrencyRepository) GetAll(ctx context.Context) ([]*domain.Currency, error) {
 	const query = `SELECT code, symbol, aliases, is_active, created_at, updated_at FROM currencies ORDER BY code`
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -43,11 +43,11 @@ func (r *CurrencyRepository) GetAll(ctx context.Context) ([]*domain.Currency, er
 // GetByCode fetches a currency by code
 func (r *CurrencyRepository) GetByCode(ctx context.Context, code string) (*domain.Currency, error) {
 	const query = `SELECT code, symbol, aliases, is_active, created_at, updated_at FROM currencies WHERE code = $1`
-	row := r.db.QueryRowContext(ctx, query, code)
+	row := txOrDB(ctx, r.db).QueryRowContext(ctx, query, code)
 	currency, err := scanCurrency(row)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
@@ -58,7 +58,7 @@ func (r *CurrencyRepository) GetByCode(ctx context.Context, code string) (*domai
 func (r *CurrencyRepository) GetName(ctx context.Context, code, locale string) (string, error) {
 	const query = `SELECT name FROM currency_translations WHERE currency_code = $1 AND locale = $2`
 	var name string
-	err := r.db.QueryRowContext(ctx, query, code, locale).Scan(&name)
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, code, locale).Scan(&name)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", nil
@@ -81,7 +81,7 @@ func (r *CurrencyRepository) Upsert(ctx context.Context, currency *domain.Curren
 			aliases = EXCLUDED.aliases,
 			is_active = EXCLUDED.is_active,
 			updated_at = CURRENT_TIMESTAMP`
-	_, err = r.db.ExecContext(ctx, query, currency.Code, currency.Symbol, string(aliasesJSON), currency.IsActive)
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query, currency.Code, currency.Symbol, string(aliasesJSON), currency.IsActive)
 	return err
 }
 
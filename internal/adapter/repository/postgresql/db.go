@@ -12,10 +12,58 @@ import (
 
 // OpenDB opens a PostgreSQL database connection and runs migrations
 func OpenDB(databaseURL string) (*sql.DB, error) {
+	db, err := Connect(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run migrations using golang-migrate
+	if err := migrations.RunMigrations(db, "postgres"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return db, nil
+}
+
+// PoolConfig sizes the connection pool; see the sql.DB setters for what zero
+// and negative values mean
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// DefaultPoolConfig is the pool Connect uses
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    25,
+		MaxIdleConns:    5,
+		ConnMaxLifetime: 5 * time.Minute,
+	}
+}
+
+// Apply sets the pool limits on db
+func (p PoolConfig) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(p.MaxOpenConns)
+	db.SetMaxIdleConns(p.MaxIdleConns)
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+}
+
+// Connect opens a PostgreSQL database connection without touching the schema
+func Connect(databaseURL string) (*sql.DB, error) {
+	return ConnectWithPool(databaseURL, DefaultPoolConfig())
+}
+
+// ConnectWithPool is Connect with the connection pool sized by pool
+func ConnectWithPool(databaseURL string, pool PoolConfig) (*sql.DB, error) {
 	db, err := sql.Open("postgres", databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open PostgreSQL database: %w", err)
 	}
+	pool.Apply(db)
 
 	// Test the connection with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -26,11 +74,5 @@ func OpenDB(databaseURL string) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to ping PostgreSQL database: %w", err)
 	}
 
-	// Run migrations using golang-migrate
-	if err := migrations.RunMigrations(db, "postgres"); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
-	}
-
 	return db, nil
 }
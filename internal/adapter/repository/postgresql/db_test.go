@@ -0,0 +1,22 @@
+package postgresql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestPoolConfigApply(t *testing.T) {
+	// sql.Open does not connect, so no server is needed to inspect the pool
+	db, err := sql.Open("postgres", "postgres://app@localhost/aiexpense?sslmode=disable")
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	defer db.Close()
+
+	PoolConfig{MaxOpenConns: 40, MaxIdleConns: 8, ConnMaxLifetime: time.Minute}.Apply(db)
+
+	if got := db.Stats().MaxOpenConnections; got != 40 {
+		t.Errorf("MaxOpenConnections = %d, want 40", got)
+	}
+}
@@ -0,0 +1,21 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// uniqueViolation is the SQLSTATE of a duplicate key
+const uniqueViolation = "23505"
+
+// conflictErr maps a unique or primary key violation to domain.ErrConflict
+func conflictErr(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: %v", domain.ErrConflict, err)
+	}
+	return err
+}
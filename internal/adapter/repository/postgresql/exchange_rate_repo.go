@@ -33,7 +33,7 @@ func (r *ExchangeRateRepository) SaveRate(ctx context.Context, rate *domain.Exch
 		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (provider, base_currency, target_currency, rate_date)
 		DO UPDATE SET rate = EXCLUDED.rate, fetched_at = EXCLUDED.fetched_at`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		provider,
 		rate.BaseCurrency,
 		rate.TargetCurrency,
@@ -54,6 +54,35 @@ func (r *ExchangeRateRepository) GetMostRecentRate(ctx context.Context, baseCurr
 	return r.getRateInternal(ctx, baseCurrency, targetCurrency, before, true)
 }
 
+// GetLatestRates returns the most recent rate on or before date for every target of a base currency
+func (r *ExchangeRateRepository) GetLatestRates(ctx context.Context, baseCurrency string, before time.Time) ([]*domain.ExchangeRate, error) {
+	query := `SELECT e.id, e.provider, e.base_currency, e.target_currency, e.rate, e.rate_date, e.fetched_at
+		FROM exchange_rates e
+		WHERE e.provider = $1 AND e.base_currency = $2 AND e.rate_date = (
+			SELECT MAX(x.rate_date) FROM exchange_rates x
+			WHERE x.provider = e.provider AND x.base_currency = e.base_currency
+				AND x.target_currency = e.target_currency AND x.rate_date <= $3
+		)
+		ORDER BY e.target_currency`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, defaultRateProvider, baseCurrency, before.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []*domain.ExchangeRate
+	for rows.Next() {
+		var rate domain.ExchangeRate
+		var rateDateStr string
+		if err := rows.Scan(&rate.ID, &rate.Provider, &rate.BaseCurrency, &rate.TargetCurrency, &rate.Rate, &rateDateStr, &rate.FetchedAt); err != nil {
+			return nil, err
+		}
+		rate.RateDate, _ = time.Parse("2006-01-02", rateDateStr)
+		rates = append(rates, &rate)
+	}
+	return rates, rows.Err()
+}
+
 func (r *ExchangeRateRepository) getRateInternal(ctx context.Context, baseCurrency, targetCurrency string, date time.Time, allowBefore bool) (*domain.ExchangeRate, error) {
 	provider := defaultRateProvider
 	var query string
@@ -70,12 +99,12 @@ func (r *ExchangeRateRepository) getRateInternal(ctx context.Context, baseCurren
 			WHERE provider = $1 AND base_currency = $2 AND target_currency = $3 AND rate_date = $4`
 		args = []interface{}{provider, baseCurrency, targetCurrency, date.Format("2006-01-02")}
 	}
-	row := r.db.QueryRowContext(ctx, query, args...)
+	row := txOrDB(ctx, r.db).QueryRowContext(ctx, query, args...)
 	var rate domain.ExchangeRate
 	var rateDateStr string
 	if err := row.Scan(&rate.ID, &rate.Provider, &rate.BaseCurrency, &rate.TargetCurrency, &rate.Rate, &rateDateStr, &rate.FetchedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
@@ -0,0 +1,83 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ExpenseItemRepository = (*ExpenseItemRepository)(nil)
+
+// ExpenseItemRepository stores receipt line items in PostgreSQL
+type ExpenseItemRepository struct {
+	db *sql.DB
+}
+
+// NewExpenseItemRepository creates a new expense item repository
+func NewExpenseItemRepository(db *sql.DB) *ExpenseItemRepository {
+	return &ExpenseItemRepository{db: db}
+}
+
+// ReplaceForExpense replaces all line items of an expense in one transaction
+func (r *ExpenseItemRepository) ReplaceForExpense(ctx context.Context, expenseID string, items []*domain.ExpenseItem) error {
+	const deleteQuery = `DELETE FROM expense_items WHERE expense_id = $1`
+	const insertQuery = `
+		INSERT INTO expense_items (id, expense_id, name, quantity, unit_price, position, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, deleteQuery, expenseID); err != nil {
+		return err
+	}
+	for _, item := range items {
+		_, err := tx.ExecContext(ctx, insertQuery,
+			item.ID,
+			expenseID,
+			item.Name,
+			item.Quantity,
+			item.UnitPrice,
+			item.Position,
+			item.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetByExpenseIDs retrieves the line items of each expense, in receipt order
+func (r *ExpenseItemRepository) GetByExpenseIDs(ctx context.Context, expenseIDs []string) (map[string][]*domain.ExpenseItem, error) {
+	items := make(map[string][]*domain.ExpenseItem)
+	if len(expenseIDs) == 0 {
+		return items, nil
+	}
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, `
+		SELECT id, expense_id, name, quantity, unit_price, position, created_at
+		FROM expense_items
+		WHERE expense_id = ANY($1)
+		ORDER BY expense_id, position
+	`, pq.Array(expenseIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := &domain.ExpenseItem{}
+		if err := rows.Scan(&item.ID, &item.ExpenseID, &item.Name, &item.Quantity, &item.UnitPrice, &item.Position, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		items[item.ExpenseID] = append(items[item.ExpenseID], item)
+	}
+	return items, rows.Err()
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/riverlin/aiexpense/internal/domain"
 )
 
 var _ domain.ExpenseRepository = (*ExpenseRepository)(nil)
 
 type ExpenseRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	cipher fieldCipher
+	stmts  stmtCache
 }
 
 func hydrateExpenseAmounts(expense *domain.Expense) {
@@ -66,56 +71,97 @@ func NewExpenseRepository(db *sql.DB) *ExpenseRepository {
 	return &ExpenseRepository{db: db}
 }
 
+// SetCipher encrypts descriptions at rest; without it they are stored as plaintext
+func (r *ExpenseRepository) SetCipher(cipher domain.FieldCipher) {
+	r.cipher = fieldCipher{cipher: cipher}
+}
+
+// expenseInsertColumns are the columns Create and CreateBatch write
+var expenseInsertColumns = []string{
+	"id", "user_id", "description", "original_amount", "currency", "home_amount", "home_currency",
+	"exchange_rate", "category_id", "group_id", "account", "merchant_id", "is_reimbursable", "claim_status", "workspace_id", "expense_date", "created_at", "updated_at",
+}
+
+// expenseBatchSize is how many rows one multi-row insert writes
+const expenseBatchSize = 50
+
+// Create creates a new expense
 func (r *ExpenseRepository) Create(ctx context.Context, expense *domain.Expense) error {
-	const query = `
-		INSERT INTO expenses (
-			id,
-			user_id,
+	return conflictErr(r.insert(ctx, txOrDB(ctx, r.db), []*domain.Expense{expense}))
+}
+
+// CreateBatch creates expenses with multi-row inserts in one transaction
+func (r *ExpenseRepository) CreateBatch(ctx context.Context, expenses []*domain.Expense) error {
+	if len(expenses) == 0 {
+		return nil
+	}
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(expenses); start += expenseBatchSize {
+		end := min(start+expenseBatchSize, len(expenses))
+		if err := r.insert(ctx, tx, expenses[start:end]); err != nil {
+			return conflictErr(err)
+		}
+	}
+	return tx.Commit()
+}
+
+// insert writes expenses with a single INSERT statement
+func (r *ExpenseRepository) insert(ctx context.Context, q dbtx, expenses []*domain.Expense) error {
+	rows := make([]string, len(expenses))
+	args := make([]any, 0, len(expenses)*len(expenseInsertColumns))
+	for i, expense := range expenses {
+		normalizeExpenseForWrite(expense)
+		description, err := r.cipher.encrypt(expense.Description)
+		if err != nil {
+			return err
+		}
+		n := i * len(expenseInsertColumns)
+		placeholders := make([]string, len(expenseInsertColumns))
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", n+j+1)
+		}
+		rows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+		args = append(args,
+			expense.ID,
+			expense.UserID,
 			description,
-			original_amount,
-			currency,
-			home_amount,
-			home_currency,
-			exchange_rate,
-			category_id,
-			account,
-			expense_date,
-			created_at,
-			updated_at
+			expense.OriginalAmount,
+			expense.Currency,
+			expense.HomeAmount,
+			expense.HomeCurrency,
+			expense.ExchangeRate,
+			expense.CategoryID,
+			expense.GroupID,
+			expense.Account,
+			expense.MerchantID,
+			expense.Reimbursable,
+			expense.ClaimStatus,
+			expense.WorkspaceID,
+			expense.ExpenseDate,
+			expense.CreatedAt,
+			expense.UpdatedAt,
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-	`
+	}
 
-	normalizeExpenseForWrite(expense)
-	_, err := r.db.ExecContext(
-		ctx,
-		query,
-		expense.ID,
-		expense.UserID,
-		expense.Description,
-		expense.OriginalAmount,
-		expense.Currency,
-		expense.HomeAmount,
-		expense.HomeCurrency,
-		expense.ExchangeRate,
-		expense.CategoryID,
-		expense.Account,
-		expense.ExpenseDate,
-		expense.CreatedAt,
-		expense.UpdatedAt,
-	)
+	query := "INSERT INTO expenses (" + strings.Join(expenseInsertColumns, ", ") + ") VALUES " + strings.Join(rows, ", ")
+	_, err := q.ExecContext(ctx, query, args...)
 	return err
 }
 
 func (r *ExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Expense, error) {
 	const query = `
-		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, account, expense_date, created_at, updated_at
+		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, group_id, account, merchant_id, is_reimbursable, claim_status, workspace_id, expense_date, created_at, updated_at
 		FROM expenses
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
 	expense := &domain.Expense{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&expense.ID,
 		&expense.UserID,
 		&expense.Description,
@@ -125,30 +171,118 @@ func (r *ExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Exp
 		&expense.HomeCurrency,
 		&expense.ExchangeRate,
 		&expense.CategoryID,
+		&expense.GroupID,
 		&expense.Account,
+		&expense.MerchantID,
+		&expense.Reimbursable,
+		&expense.ClaimStatus,
+		&expense.WorkspaceID,
 		&expense.ExpenseDate,
 		&expense.CreatedAt,
 		&expense.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
 	hydrateExpenseAmounts(expense)
+	if expense.Description, err = r.cipher.decrypt(expense.Description); err != nil {
+		return nil, err
+	}
 	return expense, nil
 }
 
 func (r *ExpenseRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Expense, error) {
-	const query = `
-		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, account, expense_date, created_at, updated_at
+	scope, args := workspaceScope(ctx, "workspace_id", []any{userID})
+	query := `
+		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, group_id, account, merchant_id, is_reimbursable, claim_status, workspace_id, expense_date, created_at, updated_at
 		FROM expenses
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL` + scope + `
 		ORDER BY expense_date DESC, created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []*domain.Expense
+	for rows.Next() {
+		expense := &domain.Expense{}
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.UserID,
+			&expense.Description,
+			&expense.OriginalAmount,
+			&expense.Currency,
+			&expense.HomeAmount,
+			&expense.HomeCurrency,
+			&expense.ExchangeRate,
+			&expense.CategoryID,
+			&expense.GroupID,
+			&expense.Account,
+			&expense.MerchantID,
+			&expense.Reimbursable,
+			&expense.ClaimStatus,
+			&expense.WorkspaceID,
+			&expense.ExpenseDate,
+			&expense.CreatedAt,
+			&expense.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		hydrateExpenseAmounts(expense)
+		if expense.Description, err = r.cipher.decrypt(expense.Description); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, expense)
+	}
+	return expenses, rows.Err()
+}
+
+// expenseSortColumns maps listing sort keys to columns; anything else sorts by date
+var expenseSortColumns = map[string]string{
+	domain.ExpenseSortDate:      "expense_date",
+	domain.ExpenseSortAmount:    "home_amount",
+	domain.ExpenseSortCreatedAt: "created_at",
+}
+
+// expenseListOrder returns the sort column, direction and the keyset comparison
+// operator that selects rows after the cursor in that direction
+func expenseListOrder(opts domain.ExpenseListOptions) (column, dir, cmp string) {
+	column, ok := expenseSortColumns[opts.SortBy]
+	if !ok {
+		column = "expense_date"
+	}
+	if opts.SortDir == domain.SortAsc {
+		return column, "ASC", ">"
+	}
+	return column, "DESC", "<"
+}
+
+func (r *ExpenseRepository) ListByUserID(ctx context.Context, userID string, opts domain.ExpenseListOptions) ([]*domain.Expense, error) {
+	column, dir, cmp := expenseListOrder(opts)
+
+	query := `
+		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, group_id, account, merchant_id, is_reimbursable, claim_status, workspace_id, expense_date, created_at, updated_at
+		FROM expenses
+		WHERE user_id = $1 AND deleted_at IS NULL`
+	scope, args := workspaceScope(ctx, "workspace_id", []any{userID})
+	query += scope
+	if opts.AfterID != "" {
+		args = append(args, opts.AfterID)
+		query += fmt.Sprintf(` AND (%s, id) %s (SELECT %s, id FROM expenses WHERE id = $%d)`, column, cmp, column, len(args))
+	}
+	query += fmt.Sprintf(` ORDER BY %s %s, id %s`, column, dir, dir)
+	if opts.Limit > 0 {
+		args = append(args, opts.Limit, opts.Offset)
+		query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	}
+
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +301,12 @@ func (r *ExpenseRepository) GetByUserID(ctx context.Context, userID string) ([]*
 			&expense.HomeCurrency,
 			&expense.ExchangeRate,
 			&expense.CategoryID,
+			&expense.GroupID,
 			&expense.Account,
+			&expense.MerchantID,
+			&expense.Reimbursable,
+			&expense.ClaimStatus,
+			&expense.WorkspaceID,
 			&expense.ExpenseDate,
 			&expense.CreatedAt,
 			&expense.UpdatedAt,
@@ -175,6 +314,123 @@ func (r *ExpenseRepository) GetByUserID(ctx context.Context, userID string) ([]*
 			return nil, err
 		}
 		hydrateExpenseAmounts(expense)
+		if expense.Description, err = r.cipher.decrypt(expense.Description); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, expense)
+	}
+	return expenses, rows.Err()
+}
+
+// expenseFilterClause compiles a filter into a WHERE clause on expenses in the
+// workspace ctx carries.
+// Encrypted descriptions can't be matched in SQL, so with matchText false the
+// text is left for the caller to check.
+func expenseFilterClause(ctx context.Context, filter domain.ExpenseFilter, matchText bool) (string, []any) {
+	scope, args := workspaceScope(ctx, "workspace_id", []any{filter.UserID})
+	where := []string{"user_id = $1" + scope, "deleted_at IS NULL"}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		where = append(where, fmt.Sprintf("expense_date >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		where = append(where, fmt.Sprintf("expense_date <= $%d", len(args)))
+	}
+	if filter.MinAmount != nil {
+		args = append(args, *filter.MinAmount)
+		where = append(where, fmt.Sprintf("home_amount >= $%d", len(args)))
+	}
+	if filter.MaxAmount != nil {
+		args = append(args, *filter.MaxAmount)
+		where = append(where, fmt.Sprintf("home_amount <= $%d", len(args)))
+	}
+	if len(filter.CategoryIDs) > 0 {
+		args = append(args, pq.Array(filter.CategoryIDs))
+		where = append(where, fmt.Sprintf("category_id = ANY($%d)", len(args)))
+	}
+	if len(filter.Currencies) > 0 {
+		args = append(args, pq.Array(filter.Currencies))
+		where = append(where, fmt.Sprintf("currency = ANY($%d)", len(args)))
+	}
+	if filter.HasAttachment != nil {
+		exists := "EXISTS (SELECT 1 FROM expense_attachments a WHERE a.expense_id = expenses.id)"
+		if !*filter.HasAttachment {
+			exists = "NOT " + exists
+		}
+		where = append(where, exists)
+	}
+	for _, tag := range filter.Tags {
+		args = append(args, tag)
+		where = append(where, fmt.Sprintf("EXISTS (SELECT 1 FROM expense_tags et JOIN tags t ON t.id = et.tag_id WHERE et.expense_id = expenses.id AND t.name = $%d)", len(args)))
+	}
+	if filter.Reimbursable != nil {
+		args = append(args, *filter.Reimbursable)
+		where = append(where, fmt.Sprintf("is_reimbursable = $%d", len(args)))
+	}
+	if len(filter.ClaimStatuses) > 0 {
+		args = append(args, pq.Array(filter.ClaimStatuses))
+		where = append(where, fmt.Sprintf("claim_status = ANY($%d)", len(args)))
+	}
+	if filter.Text != "" && matchText {
+		args = append(args, "%"+escapeLike(filter.Text)+"%")
+		where = append(where, fmt.Sprintf("description ILIKE $%d ESCAPE '!'", len(args)))
+	}
+	return strings.Join(where, " AND "), args
+}
+
+// escapeLike makes text match literally in a LIKE pattern with ESCAPE '!'
+func escapeLike(text string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(text)
+}
+
+// Filter retrieves the expenses a filter selects, newest first
+func (r *ExpenseRepository) Filter(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, error) {
+	encrypted := r.cipher.cipher != nil
+	where, args := expenseFilterClause(ctx, filter, !encrypted)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, `
+		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, group_id, account, merchant_id, is_reimbursable, claim_status, workspace_id, expense_date, created_at, updated_at
+		FROM expenses
+		WHERE `+where+`
+		ORDER BY expense_date DESC, created_at DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []*domain.Expense
+	for rows.Next() {
+		expense := &domain.Expense{}
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.UserID,
+			&expense.Description,
+			&expense.OriginalAmount,
+			&expense.Currency,
+			&expense.HomeAmount,
+			&expense.HomeCurrency,
+			&expense.ExchangeRate,
+			&expense.CategoryID,
+			&expense.GroupID,
+			&expense.Account,
+			&expense.MerchantID,
+			&expense.Reimbursable,
+			&expense.ClaimStatus,
+			&expense.WorkspaceID,
+			&expense.ExpenseDate,
+			&expense.CreatedAt,
+			&expense.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		hydrateExpenseAmounts(expense)
+		if expense.Description, err = r.cipher.decrypt(expense.Description); err != nil {
+			return nil, err
+		}
+		if encrypted && !filter.MatchesText(expense.Description) {
+			continue
+		}
 		expenses = append(expenses, expense)
 	}
 	return expenses, rows.Err()
@@ -183,14 +439,18 @@ func (r *ExpenseRepository) GetByUserID(ctx context.Context, userID string) ([]*
 func (r *ExpenseRepository) Update(ctx context.Context, expense *domain.Expense) error {
 	const query = `
 		UPDATE expenses
-		SET description = $2, original_amount = $3, currency = $4, home_amount = $5, home_currency = $6, exchange_rate = $7, category_id = $8, account = $9, expense_date = $10, updated_at = $11
+		SET description = $2, original_amount = $3, currency = $4, home_amount = $5, home_currency = $6, exchange_rate = $7, category_id = $8, account = $9, merchant_id = $10, is_reimbursable = $11, claim_status = $12, workspace_id = $13, expense_date = $14, updated_at = $15
 		WHERE id = $1
 	`
 
 	normalizeExpenseForWrite(expense)
-	_, err := r.db.ExecContext(ctx, query,
+	description, err := r.cipher.encrypt(expense.Description)
+	if err != nil {
+		return err
+	}
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
 		expense.ID,
-		expense.Description,
+		description,
 		expense.OriginalAmount,
 		expense.Currency,
 		expense.HomeAmount,
@@ -198,6 +458,10 @@ func (r *ExpenseRepository) Update(ctx context.Context, expense *domain.Expense)
 		expense.ExchangeRate,
 		expense.CategoryID,
 		expense.Account,
+		expense.MerchantID,
+		expense.Reimbursable,
+		expense.ClaimStatus,
+		expense.WorkspaceID,
 		expense.ExpenseDate,
 		time.Now(),
 	)
@@ -205,14 +469,16 @@ func (r *ExpenseRepository) Update(ctx context.Context, expense *domain.Expense)
 }
 
 func (r *ExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, from, to time.Time) ([]*domain.Expense, error) {
-	const query = `
-		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, account, expense_date, created_at, updated_at
+	scope, args := workspaceScope(ctx, "workspace_id", []any{userID, from, to})
+	query := `
+		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, group_id, account, merchant_id, is_reimbursable, claim_status, workspace_id, expense_date, created_at, updated_at
 		FROM expenses
-		WHERE user_id = $1 AND expense_date BETWEEN $2 AND $3
+		WHERE user_id = $1 AND expense_date BETWEEN $2 AND $3 AND deleted_at IS NULL` + scope + `
 		ORDER BY expense_date DESC, created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, from, to)
+	// Reports and budgets run this on every request, so it is prepared once
+	rows, err := r.stmts.queryContext(ctx, r.db, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -231,7 +497,12 @@ func (r *ExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID
 			&expense.HomeCurrency,
 			&expense.ExchangeRate,
 			&expense.CategoryID,
+			&expense.GroupID,
 			&expense.Account,
+			&expense.MerchantID,
+			&expense.Reimbursable,
+			&expense.ClaimStatus,
+			&expense.WorkspaceID,
 			&expense.ExpenseDate,
 			&expense.CreatedAt,
 			&expense.UpdatedAt,
@@ -239,20 +510,24 @@ func (r *ExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID
 			return nil, err
 		}
 		hydrateExpenseAmounts(expense)
+		if expense.Description, err = r.cipher.decrypt(expense.Description); err != nil {
+			return nil, err
+		}
 		expenses = append(expenses, expense)
 	}
 	return expenses, rows.Err()
 }
 
 func (r *ExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID, categoryID string) ([]*domain.Expense, error) {
-	const query = `
-		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, account, expense_date, created_at, updated_at
+	scope, args := workspaceScope(ctx, "workspace_id", []any{userID, categoryID})
+	query := `
+		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, group_id, account, merchant_id, is_reimbursable, claim_status, workspace_id, expense_date, created_at, updated_at
 		FROM expenses
-		WHERE user_id = $1 AND category_id = $2
+		WHERE user_id = $1 AND category_id = $2 AND deleted_at IS NULL` + scope + `
 		ORDER BY expense_date DESC, created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, categoryID)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -271,7 +546,12 @@ func (r *ExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID,
 			&expense.HomeCurrency,
 			&expense.ExchangeRate,
 			&expense.CategoryID,
+			&expense.GroupID,
 			&expense.Account,
+			&expense.MerchantID,
+			&expense.Reimbursable,
+			&expense.ClaimStatus,
+			&expense.WorkspaceID,
 			&expense.ExpenseDate,
 			&expense.CreatedAt,
 			&expense.UpdatedAt,
@@ -279,13 +559,196 @@ func (r *ExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID,
 			return nil, err
 		}
 		hydrateExpenseAmounts(expense)
+		if expense.Description, err = r.cipher.decrypt(expense.Description); err != nil {
+			return nil, err
+		}
 		expenses = append(expenses, expense)
 	}
 	return expenses, rows.Err()
 }
 
 func (r *ExpenseRepository) Delete(ctx context.Context, id string) error {
-	const query = `DELETE FROM expenses WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	const query = `UPDATE expenses SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, time.Now(), id)
 	return err
 }
+
+func (r *ExpenseRepository) GetDeletedByID(ctx context.Context, id string) (*domain.Expense, error) {
+	const query = `
+		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, group_id, account, merchant_id, is_reimbursable, claim_status, workspace_id, expense_date, created_at, updated_at, deleted_at
+		FROM expenses
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`
+
+	expense := &domain.Expense{}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&expense.ID,
+		&expense.UserID,
+		&expense.Description,
+		&expense.OriginalAmount,
+		&expense.Currency,
+		&expense.HomeAmount,
+		&expense.HomeCurrency,
+		&expense.ExchangeRate,
+		&expense.CategoryID,
+		&expense.GroupID,
+		&expense.Account,
+		&expense.MerchantID,
+		&expense.Reimbursable,
+		&expense.ClaimStatus,
+		&expense.WorkspaceID,
+		&expense.ExpenseDate,
+		&expense.CreatedAt,
+		&expense.UpdatedAt,
+		&expense.DeletedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	hydrateExpenseAmounts(expense)
+	if expense.Description, err = r.cipher.decrypt(expense.Description); err != nil {
+		return nil, err
+	}
+	return expense, nil
+}
+
+func (r *ExpenseRepository) Restore(ctx context.Context, id string) error {
+	const query = `UPDATE expenses SET deleted_at = NULL, updated_at = $1 WHERE id = $2`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, time.Now(), id)
+	return err
+}
+
+// GetByGroupIDAndDateRange retrieves a group ledger's expenses within a date range
+func (r *ExpenseRepository) GetByGroupIDAndDateRange(ctx context.Context, groupID string, from, to time.Time) ([]*domain.Expense, error) {
+	const query = `
+		SELECT id, user_id, description, original_amount, currency, home_amount, home_currency, exchange_rate, category_id, group_id, account, merchant_id, is_reimbursable, claim_status, workspace_id, expense_date, created_at, updated_at
+		FROM expenses
+		WHERE group_id = $1 AND expense_date >= $2 AND expense_date <= $3 AND deleted_at IS NULL
+		ORDER BY expense_date DESC, created_at DESC
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, groupID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []*domain.Expense
+	for rows.Next() {
+		expense := &domain.Expense{}
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.UserID,
+			&expense.Description,
+			&expense.OriginalAmount,
+			&expense.Currency,
+			&expense.HomeAmount,
+			&expense.HomeCurrency,
+			&expense.ExchangeRate,
+			&expense.CategoryID,
+			&expense.GroupID,
+			&expense.Account,
+			&expense.MerchantID,
+			&expense.Reimbursable,
+			&expense.ClaimStatus,
+			&expense.WorkspaceID,
+			&expense.ExpenseDate,
+			&expense.CreatedAt,
+			&expense.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		hydrateExpenseAmounts(expense)
+		if expense.Description, err = r.cipher.decrypt(expense.Description); err != nil {
+			return nil, err
+		}
+		expenses = append(expenses, expense)
+	}
+	return expenses, rows.Err()
+}
+
+// expenseTotalsScope returns the WHERE clause selecting the query's ledger,
+// date range and merchant, and its arguments. A user's own ledger is narrowed
+// to the workspace ctx carries; a group's spans its members' workspaces.
+func expenseTotalsScope(ctx context.Context, query domain.ExpenseTotalsQuery) (string, []any) {
+	where, args := "user_id = $1 AND expense_date >= $2 AND expense_date <= $3 AND deleted_at IS NULL", []any{query.UserID, query.From, query.To}
+	if query.GroupID != "" {
+		where, args = "group_id = $1 AND expense_date >= $2 AND expense_date <= $3 AND deleted_at IS NULL", []any{query.GroupID, query.From, query.To}
+	} else {
+		var scope string
+		scope, args = workspaceScope(ctx, "workspace_id", args)
+		where += scope
+	}
+	if query.MerchantID != "" {
+		where += fmt.Sprintf(" AND merchant_id = $%d", len(args)+1)
+		args = append(args, query.MerchantID)
+	}
+	return where, args
+}
+
+// SumByCategoryAndDateRange totals the selected expenses per category
+func (r *ExpenseRepository) SumByCategoryAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.CategoryTotal, error) {
+	where, args := expenseTotalsScope(ctx, query)
+	rows, err := r.stmts.queryContext(ctx, r.db, `
+		SELECT category_id, SUM(home_amount), COUNT(*), MAX(home_amount), MIN(home_amount)
+		FROM expenses
+		WHERE `+where+`
+		GROUP BY category_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*domain.CategoryTotal
+	for rows.Next() {
+		total := &domain.CategoryTotal{}
+		if err := rows.Scan(&total.CategoryID, &total.Total, &total.Count, &total.Highest, &total.Lowest); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
+
+// expensePeriodStart formats the first day of an expense's day or month as YYYY-MM-DD
+var expensePeriodStart = map[string]string{
+	domain.TotalsPeriodDay:   "to_char(expense_date, 'YYYY-MM-DD')",
+	domain.TotalsPeriodMonth: "to_char(date_trunc('month', expense_date), 'YYYY-MM-DD')",
+}
+
+// SumByPeriodAndDateRange totals the selected expenses per day or month, oldest first
+func (r *ExpenseRepository) SumByPeriodAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery, period string) ([]*domain.PeriodTotal, error) {
+	start, ok := expensePeriodStart[period]
+	if !ok {
+		return nil, fmt.Errorf("unsupported totals period: %s", period)
+	}
+	where, args := expenseTotalsScope(ctx, query)
+	rows, err := r.stmts.queryContext(ctx, r.db, `
+		SELECT `+start+` AS period_start, SUM(home_amount), COUNT(*)
+		FROM expenses
+		WHERE `+where+`
+		GROUP BY period_start
+		ORDER BY period_start
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*domain.PeriodTotal
+	for rows.Next() {
+		total := &domain.PeriodTotal{}
+		var day string
+		if err := rows.Scan(&day, &total.Total, &total.Count); err != nil {
+			return nil, err
+		}
+		if total.Start, err = time.Parse("2006-01-02", day); err != nil {
+			return nil, fmt.Errorf("invalid period start %q: %w", day, err)
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
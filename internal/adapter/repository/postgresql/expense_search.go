@@ -0,0 +1,140 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ExpenseSearchRepository = (*ExpenseRepository)(nil)
+
+// searchSimilarity is the pg_trgm word similarity from which a word counts as
+// a misspelling of a term; pg_trgm's own default of 0.6 misses most one-letter
+// typos in short words
+const searchSimilarity = 0.5
+
+// searchOrders maps search sort keys to ORDER BY clauses; ties go newest first
+var searchOrders = map[string]string{
+	domain.SearchSortRelevance:  "score DESC, e.expense_date DESC, e.id",
+	domain.SearchSortDateDesc:   "e.expense_date DESC, e.id",
+	domain.SearchSortDateAsc:    "e.expense_date ASC, e.id",
+	domain.SearchSortAmountDesc: "e.home_amount DESC, e.expense_date DESC, e.id",
+	domain.SearchSortAmountAsc:  "e.home_amount ASC, e.expense_date DESC, e.id",
+}
+
+// Search matches descriptions with the tsvector and trigram indexes: each term
+// as a word prefix, as a substring, which finds words inside CJK runs the
+// parser keeps whole, or, for terms domain.SearchTermMaxEdits allows typos in,
+// as a similar word. Matches are ranked by ts_rank plus word similarity.
+// Terms are only letters and digits, so they need no quoting in tsqueries or
+// LIKE patterns. Encrypted descriptions can't be searched.
+func (r *ExpenseRepository) Search(ctx context.Context, query *domain.ExpenseSearchQuery) ([]*domain.ExpenseSearchHit, int, error) {
+	if r.cipher.cipher != nil {
+		return nil, 0, errors.New("full-text search is unavailable while descriptions are encrypted")
+	}
+
+	scope, args := workspaceScope(ctx, "e.workspace_id", []any{query.UserID, query.StartDate, query.EndDate})
+	where := []string{"e.user_id = $1", "e.deleted_at IS NULL", "e.expense_date >= $2", "e.expense_date <= $3" + scope}
+	prefixes := make([]string, len(query.Terms))
+	for i, term := range query.Terms {
+		prefixes[i] = term + ":*"
+		args = append(args, prefixes[i], "%"+term+"%")
+		condition := fmt.Sprintf("to_tsvector('simple', e.description) @@ to_tsquery('simple', $%d) OR e.description ILIKE $%d", len(args)-1, len(args))
+		if domain.SearchTermMaxEdits(term) > 0 {
+			args = append(args, term)
+			condition += fmt.Sprintf(" OR word_similarity($%d, e.description) >= %v", len(args), searchSimilarity)
+		}
+		where = append(where, "("+condition+")")
+	}
+	if query.CategoryID != nil {
+		args = append(args, *query.CategoryID)
+		where = append(where, fmt.Sprintf("e.category_id = $%d", len(args)))
+	}
+	if query.MinAmount != nil {
+		args = append(args, *query.MinAmount)
+		where = append(where, fmt.Sprintf("e.home_amount >= $%d", len(args)))
+	}
+	if query.MaxAmount != nil {
+		args = append(args, *query.MaxAmount)
+		where = append(where, fmt.Sprintf("e.home_amount <= $%d", len(args)))
+	}
+	for _, tag := range query.Tags {
+		args = append(args, tag)
+		where = append(where, fmt.Sprintf("EXISTS (SELECT 1 FROM expense_tags et JOIN tags t ON t.id = et.tag_id WHERE et.expense_id = e.id AND t.name = $%d)", len(args)))
+	}
+
+	filter := strings.Join(where, " AND ")
+	filterArgs := len(args)
+
+	score := "0"
+	if len(query.Terms) > 0 {
+		args = append(args, strings.Join(prefixes, " | "), strings.Join(query.Terms, " "))
+		score = fmt.Sprintf("ts_rank(to_tsvector('simple', e.description), to_tsquery('simple', $%d)) + word_similarity($%d, e.description)", len(args)-1, len(args))
+	}
+	order, ok := searchOrders[query.SortBy]
+	if !ok {
+		order = searchOrders[domain.SearchSortDateDesc]
+	}
+	selectQuery := `
+		SELECT e.id, e.user_id, e.description, e.original_amount, e.currency, e.home_amount, e.home_currency, e.exchange_rate, e.category_id, e.group_id, e.account, e.merchant_id, e.is_reimbursable, e.claim_status, e.workspace_id, e.expense_date, e.created_at, e.updated_at,
+			` + score + ` AS score, COUNT(*) OVER () AS total
+		FROM expenses e
+		WHERE ` + filter + `
+		ORDER BY ` + order
+	if query.Limit > 0 {
+		args = append(args, query.Limit, query.Offset)
+		selectQuery += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	}
+
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var hits []*domain.ExpenseSearchHit
+	total := 0
+	for rows.Next() {
+		hit := &domain.ExpenseSearchHit{Expense: &domain.Expense{}}
+		expense := hit.Expense
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.UserID,
+			&expense.Description,
+			&expense.OriginalAmount,
+			&expense.Currency,
+			&expense.HomeAmount,
+			&expense.HomeCurrency,
+			&expense.ExchangeRate,
+			&expense.CategoryID,
+			&expense.GroupID,
+			&expense.Account,
+			&expense.MerchantID,
+			&expense.Reimbursable,
+			&expense.ClaimStatus,
+			&expense.WorkspaceID,
+			&expense.ExpenseDate,
+			&expense.CreatedAt,
+			&expense.UpdatedAt,
+			&hit.Score,
+			&total,
+		); err != nil {
+			return nil, 0, err
+		}
+		hydrateExpenseAmounts(expense)
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(hits) == 0 && query.Offset > 0 {
+		// Past the last page there are no rows to read the total from
+		if err := txOrDB(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM expenses e WHERE `+filter, args[:filterArgs]...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+	return hits, total, nil
+}
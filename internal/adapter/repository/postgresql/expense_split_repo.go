@@ -0,0 +1,116 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ExpenseSplitRepository = (*ExpenseSplitRepository)(nil)
+
+// ExpenseSplitRepository stores split-bill shares in PostgreSQL
+type ExpenseSplitRepository struct {
+	db *sql.DB
+}
+
+// NewExpenseSplitRepository creates a new expense split repository
+func NewExpenseSplitRepository(db *sql.DB) *ExpenseSplitRepository {
+	return &ExpenseSplitRepository{db: db}
+}
+
+// ReplaceForExpense replaces all shares of an expense in one transaction
+func (r *ExpenseSplitRepository) ReplaceForExpense(ctx context.Context, expenseID string, splits []*domain.ExpenseSplit) error {
+	const deleteQuery = `DELETE FROM expense_splits WHERE expense_id = $1`
+	const insertQuery = `
+		INSERT INTO expense_splits (id, expense_id, payer_id, participant, share_amount, percentage, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, deleteQuery, expenseID); err != nil {
+		return err
+	}
+	for _, split := range splits {
+		_, err := tx.ExecContext(ctx, insertQuery,
+			split.ID,
+			expenseID,
+			split.PayerID,
+			split.Participant,
+			split.Amount,
+			split.Percentage,
+			split.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetByExpenseID retrieves the shares of an expense
+func (r *ExpenseSplitRepository) GetByExpenseID(ctx context.Context, expenseID string) ([]*domain.ExpenseSplit, error) {
+	const query = `
+		SELECT id, expense_id, payer_id, participant, share_amount, percentage, created_at
+		FROM expense_splits
+		WHERE expense_id = $1
+		ORDER BY created_at ASC, participant ASC
+	`
+	return r.querySplits(ctx, query, expenseID)
+}
+
+// GetByPayerID retrieves the shares of all expenses a user paid for
+func (r *ExpenseSplitRepository) GetByPayerID(ctx context.Context, payerID string) ([]*domain.ExpenseSplit, error) {
+	const query = `
+		SELECT id, expense_id, payer_id, participant, share_amount, percentage, created_at
+		FROM expense_splits
+		WHERE payer_id = $1
+		ORDER BY created_at ASC, participant ASC
+	`
+	return r.querySplits(ctx, query, payerID)
+}
+
+// GetByGroupID retrieves the shares of all expenses in a group ledger
+func (r *ExpenseSplitRepository) GetByGroupID(ctx context.Context, groupID string) ([]*domain.ExpenseSplit, error) {
+	const query = `
+		SELECT s.id, s.expense_id, s.payer_id, s.participant, s.share_amount, s.percentage, s.created_at
+		FROM expense_splits s
+		JOIN expenses e ON e.id = s.expense_id
+		WHERE e.deleted_at IS NULL AND e.group_id = $1
+		ORDER BY s.created_at ASC, s.participant ASC
+	`
+	return r.querySplits(ctx, query, groupID)
+}
+
+func (r *ExpenseSplitRepository) querySplits(ctx context.Context, query string, args ...interface{}) ([]*domain.ExpenseSplit, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var splits []*domain.ExpenseSplit
+	for rows.Next() {
+		split := &domain.ExpenseSplit{}
+		err := rows.Scan(
+			&split.ID,
+			&split.ExpenseID,
+			&split.PayerID,
+			&split.Participant,
+			&split.Amount,
+			&split.Percentage,
+			&split.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		splits = append(splits, split)
+	}
+	return splits, rows.Err()
+}
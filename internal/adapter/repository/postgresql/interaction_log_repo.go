@@ -3,13 +3,16 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
 	"github.com/riverlin/aiexpense/internal/domain"
 )
 
 // InteractionLogRepository implements domain.InteractionLogRepository for PostgreSQL
 type InteractionLogRepository struct {
-	db *sql.DB
+	db     *sql.DB
+	cipher fieldCipher
 }
 
 // NewInteractionLogRepository creates a new PostgreSQL interaction log repository
@@ -17,26 +20,112 @@ func NewInteractionLogRepository(db *sql.DB) *InteractionLogRepository {
 	return &InteractionLogRepository{db: db}
 }
 
+// SetCipher encrypts the user input, prompt and AI payloads at rest
+func (r *InteractionLogRepository) SetCipher(cipher domain.FieldCipher) {
+	r.cipher = fieldCipher{cipher: cipher}
+}
+
 // Create creates a new interaction log entry
 func (r *InteractionLogRepository) Create(ctx context.Context, log *domain.InteractionLog) error {
 	query := `
 		INSERT INTO interaction_logs (
-			id, user_id, user_input, system_prompt, 
+			id, user_id, source, intent, user_input, system_prompt, 
 			ai_raw_response, bot_final_reply, duration_ms, 
 			error, timestamp
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	payloads, err := r.cipher.encryptAll(log.UserInput, log.SystemPrompt, log.AIRawResponse, log.BotFinalReply)
+	if err != nil {
+		return err
+	}
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
 		log.ID,
 		log.UserID,
-		log.UserInput,
-		log.SystemPrompt,
-		log.AIRawResponse,
-		log.BotFinalReply,
+		log.Source,
+		log.Intent,
+		payloads[0],
+		payloads[1],
+		payloads[2],
+		payloads[3],
 		log.DurationMs,
 		log.Error,
 		log.Timestamp,
 	)
 	return err
 }
+
+// List retrieves a page of entries matching the filter, newest first, and the total number of matches
+func (r *InteractionLogRepository) List(ctx context.Context, filter domain.InteractionLogFilter) ([]*domain.InteractionLog, int, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.UserID != "" {
+		args = append(args, filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Source != "" {
+		args = append(args, filter.Source)
+		conditions = append(conditions, fmt.Sprintf("source = $%d", len(args)))
+	}
+	if filter.Intent != "" {
+		args = append(args, filter.Intent)
+		conditions = append(conditions, fmt.Sprintf("intent = $%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("timestamp >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		conditions = append(conditions, fmt.Sprintf("timestamp < $%d", len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := txOrDB(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM interaction_logs`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT id, user_id, source, intent, user_input, system_prompt, ai_raw_response, bot_final_reply, duration_ms, COALESCE(error, ''), timestamp
+		FROM interaction_logs` + where + `
+		ORDER BY timestamp DESC, id DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit, filter.Offset)
+		query += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+	}
+
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var logs []*domain.InteractionLog
+	for rows.Next() {
+		log := &domain.InteractionLog{}
+		if err := rows.Scan(
+			&log.ID,
+			&log.UserID,
+			&log.Source,
+			&log.Intent,
+			&log.UserInput,
+			&log.SystemPrompt,
+			&log.AIRawResponse,
+			&log.BotFinalReply,
+			&log.DurationMs,
+			&log.Error,
+			&log.Timestamp,
+		); err != nil {
+			return nil, 0, err
+		}
+		if err := r.cipher.decryptAll(&log.UserInput, &log.SystemPrompt, &log.AIRawResponse, &log.BotFinalReply); err != nil {
+			return nil, 0, err
+		}
+		logs = append(logs, log)
+	}
+	return logs, total, rows.Err()
+}
@@ -27,7 +27,7 @@ func (r *MetricsRepository) GetDailyActiveUsers(ctx context.Context, from, to ti
 		ORDER BY date DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -48,12 +48,12 @@ func (r *MetricsRepository) GetExpensesSummary(ctx context.Context, from, to tim
 	const query = `
 		SELECT DATE(expense_date) as date, COUNT(*) as count
 		FROM expenses
-		WHERE expense_date >= $1 AND expense_date <= $2
+		WHERE expense_date >= $1 AND expense_date <= $2 AND deleted_at IS NULL
 		GROUP BY DATE(expense_date)
 		ORDER BY date DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -71,19 +71,20 @@ func (r *MetricsRepository) GetExpensesSummary(ctx context.Context, from, to tim
 }
 
 func (r *MetricsRepository) GetCategoryTrends(ctx context.Context, userID string, from, to time.Time) ([]*domain.CategoryMetrics, error) {
-	const query = `
+	scope, args := workspaceScope(ctx, "e.workspace_id", []any{userID, from, to})
+	query := `
 		SELECT
 			COALESCE(c.name, 'Uncategorized') as category_name,
 			COUNT(e.id) as expense_count,
 			COALESCE(SUM(e.home_amount), 0) as total_amount
 		FROM expenses e
 		LEFT JOIN categories c ON e.category_id = c.id
-		WHERE e.user_id = $1 AND e.expense_date >= $2 AND e.expense_date <= $3
+		WHERE e.user_id = $1 AND e.expense_date >= $2 AND e.expense_date <= $3 AND e.deleted_at IS NULL` + scope + `
 		GROUP BY c.name
 		ORDER BY total_amount DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +106,7 @@ func (r *MetricsRepository) GetGrowthMetrics(ctx context.Context, days int) (map
 
 	// Total users
 	var totalUsers int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&totalUsers)
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&totalUsers)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -113,7 +114,7 @@ func (r *MetricsRepository) GetGrowthMetrics(ctx context.Context, days int) (map
 
 	// Total expenses
 	var totalExpenses int
-	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM expenses").Scan(&totalExpenses)
+	err = txOrDB(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM expenses WHERE deleted_at IS NULL").Scan(&totalExpenses)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -122,7 +123,7 @@ func (r *MetricsRepository) GetGrowthMetrics(ctx context.Context, days int) (map
 	// New users in period
 	var newUsers int
 	fromDate := time.Now().AddDate(0, 0, -days)
-	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE created_at >= $1", fromDate).Scan(&newUsers)
+	err = txOrDB(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE created_at >= $1", fromDate).Scan(&newUsers)
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
@@ -140,7 +141,7 @@ func (r *MetricsRepository) GetNewUsersPerDay(ctx context.Context, from, to time
 		ORDER BY date DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -156,3 +157,87 @@ func (r *MetricsRepository) GetNewUsersPerDay(ctx context.Context, from, to time
 	}
 	return metrics, rows.Err()
 }
+
+// GetUserActivity retrieves the users who signed up or were active since the
+// given time, with the days they were active on since then
+func (r *MetricsRepository) GetUserActivity(ctx context.Context, since time.Time) ([]*domain.UserActivity, error) {
+	const query = `
+		SELECT u.user_id, u.created_at, a.active_date
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, DATE(created_at) AS active_date FROM expenses WHERE created_at >= $1
+			UNION
+			SELECT user_id, DATE(created_at) FROM ai_cost_logs WHERE created_at >= $1
+		) a ON a.user_id = u.user_id
+		WHERE u.created_at >= $1 OR a.user_id IS NOT NULL
+		ORDER BY u.user_id, a.active_date
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []*domain.UserActivity
+	var current *domain.UserActivity
+	for rows.Next() {
+		var userID string
+		var signedUpAt time.Time
+		var activeDate sql.NullTime
+		if err := rows.Scan(&userID, &signedUpAt, &activeDate); err != nil {
+			return nil, err
+		}
+		if current == nil || current.UserID != userID {
+			current = &domain.UserActivity{UserID: userID, SignedUpAt: signedUpAt}
+			activity = append(activity, current)
+		}
+		if activeDate.Valid {
+			current.ActiveDays = append(current.ActiveDays, activeDate.Time)
+		}
+	}
+	return activity, rows.Err()
+}
+
+// GetPlatformMetrics retrieves signups, messages, active users and AI cost
+// per messenger platform for a date range. Logs written before their source
+// was recorded are attributed to the user's messenger type.
+func (r *MetricsRepository) GetPlatformMetrics(ctx context.Context, from, to time.Time) ([]*domain.PlatformMetrics, error) {
+	const query = `
+		SELECT platform, SUM(signups), SUM(active_users), SUM(messages), SUM(ai_calls), SUM(ai_cost)
+		FROM (
+			SELECT messenger_type AS platform, COUNT(*) AS signups, 0 AS active_users, 0 AS messages, 0 AS ai_calls, 0 AS ai_cost
+			FROM users
+			WHERE created_at >= $1 AND created_at <= $2
+			GROUP BY messenger_type
+			UNION ALL
+			SELECT COALESCE(NULLIF(l.source, ''), u.messenger_type, 'unknown'), 0, COUNT(DISTINCT l.user_id), COUNT(*), 0, 0
+			FROM interaction_logs l
+			LEFT JOIN users u ON u.user_id = l.user_id
+			WHERE l.timestamp >= $1 AND l.timestamp <= $2
+			GROUP BY COALESCE(NULLIF(l.source, ''), u.messenger_type, 'unknown')
+			UNION ALL
+			SELECT COALESCE(NULLIF(c.source, ''), u.messenger_type, 'unknown'), 0, 0, 0, COUNT(*), COALESCE(SUM(c.cost), 0)
+			FROM ai_cost_logs c
+			LEFT JOIN users u ON u.user_id = c.user_id
+			WHERE c.created_at >= $1 AND c.created_at <= $2
+			GROUP BY COALESCE(NULLIF(c.source, ''), u.messenger_type, 'unknown')
+		) platforms
+		GROUP BY platform
+		ORDER BY platform
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []*domain.PlatformMetrics
+	for rows.Next() {
+		m := &domain.PlatformMetrics{}
+		if err := rows.Scan(&m.Platform, &m.Signups, &m.ActiveUsers, &m.Messages, &m.AICalls, &m.AICost); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
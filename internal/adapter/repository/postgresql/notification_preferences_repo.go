@@ -0,0 +1,182 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.NotificationPreferencesRepository = (*NotificationPreferencesRepository)(nil)
+
+// NotificationPreferencesRepository stores users' notification preferences in PostgreSQL
+type NotificationPreferencesRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationPreferencesRepository creates a new notification preferences repository
+func NewNotificationPreferencesRepository(db *sql.DB) *NotificationPreferencesRepository {
+	return &NotificationPreferencesRepository{db: db}
+}
+
+const notificationPreferencesColumns = `user_id, budget_alerts, recurring_reminders, report_notifications, expense_reminders,
+	daily_digest, daily_digest_hour, weekly_report, expense_reminder_days, quiet_hours_start, quiet_hours_end,
+	spending_anomalies, anomaly_sensitivity, channels,
+	last_daily_digest_at, last_weekly_report_at, last_expense_reminder_at, last_anomaly_check_at, updated_at`
+
+// subscribedColumns are the columns opting into each notification, keeping
+// ListSubscribed's query to known columns
+var subscribedColumns = map[string]string{
+	domain.NotificationDailyDigest:       "daily_digest",
+	domain.NotificationWeeklyReport:      "weekly_report",
+	domain.NotificationExpenseReminders:  "expense_reminders",
+	domain.NotificationSpendingAnomalies: "spending_anomalies",
+}
+
+// subscribedByDefault are the notifications users without stored preferences get
+var subscribedByDefault = map[string]bool{
+	domain.NotificationWeeklyReport:      true,
+	domain.NotificationSpendingAnomalies: true,
+}
+
+// Save creates the user's preferences or replaces them
+func (r *NotificationPreferencesRepository) Save(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	channels, err := json.Marshal(prefs.Channels)
+	if err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO notification_preferences (` + notificationPreferencesColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+		ON CONFLICT (user_id) DO UPDATE SET
+			budget_alerts = excluded.budget_alerts,
+			recurring_reminders = excluded.recurring_reminders,
+			report_notifications = excluded.report_notifications,
+			expense_reminders = excluded.expense_reminders,
+			daily_digest = excluded.daily_digest,
+			daily_digest_hour = excluded.daily_digest_hour,
+			weekly_report = excluded.weekly_report,
+			expense_reminder_days = excluded.expense_reminder_days,
+			quiet_hours_start = excluded.quiet_hours_start,
+			quiet_hours_end = excluded.quiet_hours_end,
+			spending_anomalies = excluded.spending_anomalies,
+			anomaly_sensitivity = excluded.anomaly_sensitivity,
+			channels = excluded.channels,
+			last_daily_digest_at = excluded.last_daily_digest_at,
+			last_weekly_report_at = excluded.last_weekly_report_at,
+			last_expense_reminder_at = excluded.last_expense_reminder_at,
+			last_anomaly_check_at = excluded.last_anomaly_check_at,
+			updated_at = excluded.updated_at
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		prefs.UserID,
+		prefs.BudgetAlerts,
+		prefs.RecurringReminders,
+		prefs.ReportNotifications,
+		prefs.ExpenseReminders,
+		prefs.DailyDigest,
+		prefs.DailyDigestHour,
+		prefs.WeeklyReport,
+		prefs.ExpenseReminderDays,
+		prefs.QuietHoursStart,
+		prefs.QuietHoursEnd,
+		prefs.SpendingAnomalies,
+		prefs.AnomalySensitivity,
+		string(channels),
+		prefs.LastDailyDigestAt,
+		prefs.LastWeeklyReportAt,
+		prefs.LastExpenseReminderAt,
+		prefs.LastAnomalyCheckAt,
+		prefs.UpdatedAt,
+	)
+	return err
+}
+
+// GetByUserID retrieves the user's preferences, or ErrNotFound if they have the defaults
+func (r *NotificationPreferencesRepository) GetByUserID(ctx context.Context, userID string) (*domain.NotificationPreferences, error) {
+	const query = `SELECT ` + notificationPreferencesColumns + ` FROM notification_preferences WHERE user_id = $1`
+	prefs, err := r.queryPreferences(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(prefs) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return prefs[0], nil
+}
+
+// ListSubscribed retrieves the preferences of users who opted into the notification
+func (r *NotificationPreferencesRepository) ListSubscribed(ctx context.Context, notification string) ([]*domain.NotificationPreferences, error) {
+	column, ok := subscribedColumns[notification]
+	if !ok {
+		return nil, fmt.Errorf("unknown notification %q", notification)
+	}
+	query := `SELECT ` + notificationPreferencesColumns + ` FROM notification_preferences WHERE ` + column + ` = TRUE ORDER BY user_id`
+	list, err := r.queryPreferences(ctx, query)
+	if err != nil || !subscribedByDefault[notification] {
+		return list, err
+	}
+
+	const defaultsQuery = `
+		SELECT user_id FROM users
+		WHERE user_id NOT IN (SELECT user_id FROM notification_preferences)
+		ORDER BY user_id
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, defaultsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		list = append(list, domain.DefaultNotificationPreferences(userID))
+	}
+	return list, rows.Err()
+}
+
+func (r *NotificationPreferencesRepository) queryPreferences(ctx context.Context, query string, args ...interface{}) ([]*domain.NotificationPreferences, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*domain.NotificationPreferences
+	for rows.Next() {
+		prefs := &domain.NotificationPreferences{}
+		var channels string
+		if err := rows.Scan(
+			&prefs.UserID,
+			&prefs.BudgetAlerts,
+			&prefs.RecurringReminders,
+			&prefs.ReportNotifications,
+			&prefs.ExpenseReminders,
+			&prefs.DailyDigest,
+			&prefs.DailyDigestHour,
+			&prefs.WeeklyReport,
+			&prefs.ExpenseReminderDays,
+			&prefs.QuietHoursStart,
+			&prefs.QuietHoursEnd,
+			&prefs.SpendingAnomalies,
+			&prefs.AnomalySensitivity,
+			&channels,
+			&prefs.LastDailyDigestAt,
+			&prefs.LastWeeklyReportAt,
+			&prefs.LastExpenseReminderAt,
+			&prefs.LastAnomalyCheckAt,
+			&prefs.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(channels), &prefs.Channels); err != nil {
+			return nil, err
+		}
+		list = append(list, prefs)
+	}
+	return list, rows.Err()
+}
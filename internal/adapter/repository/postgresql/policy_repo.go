@@ -26,14 +26,14 @@ func (r *PolicyRepository) GetByKey(ctx context.Context, key string) (*domain.Po
 	`
 
 	policy := &domain.Policy{}
-	err := r.db.QueryRowContext(ctx, query, key).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, key).Scan(
 		&policy.ID, &policy.Key, &policy.Title,
 		&policy.Content, &policy.Version, &policy.CreatedAt,
 		&policy.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
@@ -28,7 +28,7 @@ func (r *PricingRepository) GetByProviderAndModel(ctx context.Context, provider,
 		ORDER BY effective_date DESC
 		LIMIT 1
 	`
-	row := r.db.QueryRowContext(ctx, query, provider, model)
+	row := txOrDB(ctx, r.db).QueryRowContext(ctx, query, provider, model)
 
 	config := &domain.PricingConfig{}
 	err := row.Scan(
@@ -37,7 +37,32 @@ func (r *PricingRepository) GetByProviderAndModel(ctx context.Context, provider,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil // No pricing found, not an error
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// GetByID retrieves a pricing configuration by ID, active or not
+func (r *PricingRepository) GetByID(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	const query = `
+		SELECT id, provider, model, input_token_price, output_token_price,
+		       currency, effective_date, is_active, created_at, updated_at
+		FROM ai_pricing_config
+		WHERE id = $1
+	`
+	row := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id)
+
+	config := &domain.PricingConfig{}
+	err := row.Scan(
+		&config.ID, &config.Provider, &config.Model, &config.InputTokenPrice, &config.OutputTokenPrice,
+		&config.Currency, &config.EffectiveDate, &config.IsActive, &config.CreatedAt, &config.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
@@ -54,7 +79,7 @@ func (r *PricingRepository) GetAll(ctx context.Context) ([]*domain.PricingConfig
 		WHERE is_active = true
 		ORDER BY provider, model, effective_date DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +109,7 @@ func (r *PricingRepository) Create(ctx context.Context, config *domain.PricingCo
 			currency, effective_date, is_active, created_at, updated_at
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		config.ID, config.Provider, config.Model, config.InputTokenPrice, config.OutputTokenPrice,
 		config.Currency, config.EffectiveDate, config.IsActive, config.CreatedAt, config.UpdatedAt,
 	)
@@ -98,7 +123,7 @@ func (r *PricingRepository) Update(ctx context.Context, config *domain.PricingCo
 		SET input_token_price = $1, output_token_price = $2, is_active = $3, updated_at = $4
 		WHERE id = $5
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		config.InputTokenPrice, config.OutputTokenPrice, config.IsActive, time.Now().UTC(), config.ID,
 	)
 	return err
@@ -111,6 +136,6 @@ func (r *PricingRepository) Deactivate(ctx context.Context, provider, model stri
 		SET is_active = false, updated_at = NOW()
 		WHERE provider = $1 AND model = $2
 	`
-	_, err := r.db.ExecContext(ctx, query, provider, model)
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, provider, model)
 	return err
 }
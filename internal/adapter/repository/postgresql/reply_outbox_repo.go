@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ReplyOutboxRepository = (*ReplyOutboxRepository)(nil)
+
+// ReplyOutboxRepository stores the outbox of messenger replies in PostgreSQL
+type ReplyOutboxRepository struct {
+	db *sql.DB
+}
+
+// NewReplyOutboxRepository creates a new reply outbox repository
+func NewReplyOutboxRepository(db *sql.DB) *ReplyOutboxRepository {
+	return &ReplyOutboxRepository{db: db}
+}
+
+const replyOutboxColumns = `id, user_id, messenger, group_chat_id, metadata, push, text, status, attempts, last_error, next_attempt_at, created_at, delivered_at`
+
+// Create records a new reply
+func (r *ReplyOutboxRepository) Create(ctx context.Context, reply *domain.OutboxReply) error {
+	const query = `
+		INSERT INTO reply_outbox (` + replyOutboxColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		reply.ID,
+		reply.UserID,
+		reply.Messenger,
+		reply.GroupChatID,
+		reply.Metadata,
+		reply.Push,
+		reply.Text,
+		reply.Status,
+		reply.Attempts,
+		reply.LastError,
+		reply.NextAttemptAt,
+		reply.CreatedAt,
+		reply.DeliveredAt,
+	)
+	return err
+}
+
+// Update records the outcome of a delivery attempt
+func (r *ReplyOutboxRepository) Update(ctx context.Context, reply *domain.OutboxReply) error {
+	const query = `
+		UPDATE reply_outbox
+		SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, delivered_at = $5
+		WHERE id = $6
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		reply.Status,
+		reply.Attempts,
+		reply.LastError,
+		reply.NextAttemptAt,
+		reply.DeliveredAt,
+		reply.ID,
+	)
+	return err
+}
+
+// GetDue retrieves pending replies whose next attempt is at or before now, oldest first
+func (r *ReplyOutboxRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*domain.OutboxReply, error) {
+	const query = `
+		SELECT ` + replyOutboxColumns + `
+		FROM reply_outbox
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at ASC
+		LIMIT $3
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, domain.OutboxReplyPending, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var replies []*domain.OutboxReply
+	for rows.Next() {
+		reply := &domain.OutboxReply{}
+		if err := rows.Scan(
+			&reply.ID,
+			&reply.UserID,
+			&reply.Messenger,
+			&reply.GroupChatID,
+			&reply.Metadata,
+			&reply.Push,
+			&reply.Text,
+			&reply.Status,
+			&reply.Attempts,
+			&reply.LastError,
+			&reply.NextAttemptAt,
+			&reply.CreatedAt,
+			&reply.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		replies = append(replies, reply)
+	}
+	return replies, rows.Err()
+}
+
+// DeleteFinishedBefore removes delivered and failed replies created before the given time
+func (r *ReplyOutboxRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	const query = `DELETE FROM reply_outbox WHERE status <> $1 AND created_at < $2`
+	result, err := txOrDB(ctx, r.db).ExecContext(ctx, query, domain.OutboxReplyPending, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
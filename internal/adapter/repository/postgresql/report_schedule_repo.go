@@ -0,0 +1,107 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ReportScheduleRepository = (*ReportScheduleRepository)(nil)
+
+// ReportScheduleRepository stores emailed report schedules in PostgreSQL
+type ReportScheduleRepository struct {
+	db *sql.DB
+}
+
+// NewReportScheduleRepository creates a new report schedule repository
+func NewReportScheduleRepository(db *sql.DB) *ReportScheduleRepository {
+	return &ReportScheduleRepository{db: db}
+}
+
+// Save creates the user's schedule or replaces the existing one
+func (r *ReportScheduleRepository) Save(ctx context.Context, schedule *domain.ReportSchedule) error {
+	const query = `
+		INSERT INTO report_schedules (id, user_id, email, frequency, format, enabled, next_run_at, last_sent_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email = excluded.email,
+			frequency = excluded.frequency,
+			format = excluded.format,
+			enabled = excluded.enabled,
+			next_run_at = excluded.next_run_at,
+			last_sent_at = excluded.last_sent_at,
+			updated_at = excluded.updated_at
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		schedule.ID,
+		schedule.UserID,
+		schedule.Email,
+		schedule.Frequency,
+		schedule.Format,
+		schedule.Enabled,
+		schedule.NextRunAt,
+		schedule.LastSentAt,
+		schedule.CreatedAt,
+		schedule.UpdatedAt,
+	)
+	return err
+}
+
+// GetByUserID retrieves the user's schedule, or nil if there is none
+func (r *ReportScheduleRepository) GetByUserID(ctx context.Context, userID string) (*domain.ReportSchedule, error) {
+	const query = `
+		SELECT id, user_id, email, frequency, format, enabled, next_run_at, last_sent_at, created_at, updated_at
+		FROM report_schedules
+		WHERE user_id = $1
+	`
+	schedules, err := r.querySchedules(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return schedules[0], nil
+}
+
+// GetDue retrieves enabled schedules whose next run is at or before now
+func (r *ReportScheduleRepository) GetDue(ctx context.Context, now time.Time) ([]*domain.ReportSchedule, error) {
+	const query = `
+		SELECT id, user_id, email, frequency, format, enabled, next_run_at, last_sent_at, created_at, updated_at
+		FROM report_schedules
+		WHERE enabled = TRUE AND next_run_at <= $1
+		ORDER BY next_run_at ASC
+	`
+	return r.querySchedules(ctx, query, now)
+}
+
+func (r *ReportScheduleRepository) querySchedules(ctx context.Context, query string, args ...interface{}) ([]*domain.ReportSchedule, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*domain.ReportSchedule
+	for rows.Next() {
+		schedule := &domain.ReportSchedule{}
+		if err := rows.Scan(
+			&schedule.ID,
+			&schedule.UserID,
+			&schedule.Email,
+			&schedule.Frequency,
+			&schedule.Format,
+			&schedule.Enabled,
+			&schedule.NextRunAt,
+			&schedule.LastSentAt,
+			&schedule.CreatedAt,
+			&schedule.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, rows.Err()
+}
@@ -22,7 +22,7 @@ func (r *ShortLinkRepository) Create(ctx context.Context, link *domain.ShortLink
 		INSERT INTO short_links (id, target_token, expires_at, created_at)
 		VALUES ($1, $2, $3, $4)
 	`
-	_, err := r.db.ExecContext(ctx, query, link.ID, link.TargetToken, link.ExpiresAt, link.CreatedAt)
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, link.ID, link.TargetToken, link.ExpiresAt, link.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create short link: %w", err)
 	}
@@ -35,13 +35,13 @@ func (r *ShortLinkRepository) Get(ctx context.Context, id string) (*domain.Short
 		FROM short_links
 		WHERE id = $1 AND expires_at > $2
 	`
-	row := r.db.QueryRowContext(ctx, query, id, time.Now())
+	row := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id, time.Now())
 
 	var link domain.ShortLink
 	err := row.Scan(&link.ID, &link.TargetToken, &link.ExpiresAt, &link.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("short link not found or expired")
+			return nil, fmt.Errorf("short link %w or expired", domain.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get short link: %w", err)
 	}
@@ -50,7 +50,7 @@ func (r *ShortLinkRepository) Get(ctx context.Context, id string) (*domain.Short
 
 func (r *ShortLinkRepository) DeleteExpired(ctx context.Context) error {
 	query := `DELETE FROM short_links WHERE expires_at <= $1`
-	_, err := r.db.ExecContext(ctx, query, time.Now())
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to delete expired short links: %w", err)
 	}
@@ -0,0 +1,48 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// stmtCache keeps prepared statements for a repository's hot queries so the
+// server plans them once per connection instead of on every call. The zero
+// value is ready to use; the statements live as long as the database.
+type stmtCache struct {
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// prepare returns the statement for query on db, preparing it on first use
+func (c *stmtCache) prepare(ctx context.Context, db *sql.DB, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	if c.stmts == nil {
+		c.stmts = make(map[string]*sql.Stmt)
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// queryContext runs the prepared query, on the unit of work's transaction
+// when ctx carries one for db
+func (c *stmtCache) queryContext(ctx context.Context, db *sql.DB, query string, args ...any) (*sql.Rows, error) {
+	stmt, err := c.prepare(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	if current, ok := ctx.Value(activeTxKey{}).(activeTx); ok && current.db == db {
+		stmt = current.tx.StmtContext(ctx, stmt)
+	}
+	return stmt.QueryContext(ctx, args...)
+}
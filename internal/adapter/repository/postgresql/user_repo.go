@@ -20,8 +20,8 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	const query = `
-		INSERT INTO users (user_id, messenger_type, created_at, home_currency, locale)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO users (user_id, messenger_type, created_at, home_currency, locale, onboarded_at, timezone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
 
 	homeCurrency := user.HomeCurrency
@@ -32,44 +32,61 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	if locale == "" {
 		locale = "zh-TW"
 	}
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		user.UserID,
 		user.MessengerType,
 		user.CreatedAt,
 		homeCurrency,
 		locale,
+		user.OnboardedAt,
+		user.Timezone,
 	)
-	return err
+	return conflictErr(err)
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, userID string) (*domain.User, error) {
 	const query = `
-		SELECT user_id, messenger_type, created_at, home_currency, locale
+		SELECT user_id, messenger_type, created_at, home_currency, locale, onboarded_at, timezone, active_budget, active_workspace
 		FROM users
 		WHERE user_id = $1
 	`
 
 	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(
 		&user.UserID,
 		&user.MessengerType,
 		&user.CreatedAt,
 		&user.HomeCurrency,
 		&user.Locale,
+		&user.OnboardedAt,
+		&user.Timezone,
+		&user.ActiveBudget,
+		&user.ActiveWorkspace,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
 	return user, nil
 }
 
+// Update saves the user's preferences, onboarding, timezone, active budget and
+// active workspace
+func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
+	const query = `
+		UPDATE users SET home_currency = $1, locale = $2, onboarded_at = $3, timezone = $4, active_budget = $5, active_workspace = $6
+		WHERE user_id = $7
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, user.HomeCurrency, user.Locale, user.OnboardedAt, user.Timezone, user.ActiveBudget, user.ActiveWorkspace, user.UserID)
+	return err
+}
+
 func (r *UserRepository) Exists(ctx context.Context, userID string) (bool, error) {
 	const query = `SELECT 1 FROM users WHERE user_id = $1`
 	var exists int
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&exists)
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&exists)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return false, nil
@@ -0,0 +1,232 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.WebhookRepository = (*WebhookRepository)(nil)
+
+// WebhookRepository stores webhook subscriptions and deliveries in PostgreSQL
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookSubscriptionColumns = `id, user_id, url, secret, events, enabled, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, subscription_id, event, payload, status, attempts, response_status, last_error, next_attempt_at, created_at, delivered_at`
+
+// CreateSubscription creates a new webhook subscription
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	events, err := json.Marshal(subscription.Events)
+	if err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO webhook_subscriptions (` + webhookSubscriptionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		subscription.ID,
+		subscription.UserID,
+		subscription.URL,
+		subscription.Secret,
+		string(events),
+		subscription.Enabled,
+		subscription.CreatedAt,
+		subscription.UpdatedAt,
+	)
+	return err
+}
+
+// GetSubscription retrieves a subscription by ID, or nil if it does not exist
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	const query = `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+	subscriptions, err := r.querySubscriptions(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return subscriptions[0], nil
+}
+
+// ListSubscriptions retrieves the user's subscriptions, oldest first
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context, userID string) ([]*domain.WebhookSubscription, error) {
+	const query = `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE user_id = $1 ORDER BY created_at ASC`
+	return r.querySubscriptions(ctx, query, userID)
+}
+
+// UpdateSubscription updates a subscription's URL, events and enabled flag
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	events, err := json.Marshal(subscription.Events)
+	if err != nil {
+		return err
+	}
+	const query = `
+		UPDATE webhook_subscriptions
+		SET url = $1, events = $2, enabled = $3, updated_at = $4
+		WHERE id = $5
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		subscription.URL,
+		string(events),
+		subscription.Enabled,
+		subscription.UpdatedAt,
+		subscription.ID,
+	)
+	return err
+}
+
+// DeleteSubscription deletes a subscription along with its deliveries
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE subscription_id = $1`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateDelivery records a new delivery
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	const query = `
+		INSERT INTO webhook_deliveries (` + webhookDeliveryColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		delivery.ID,
+		delivery.SubscriptionID,
+		delivery.Event,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+		delivery.DeliveredAt,
+	)
+	return err
+}
+
+// UpdateDelivery records the outcome of a delivery attempt
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	const query = `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, response_status = $3, last_error = $4, next_attempt_at = $5, delivered_at = $6
+		WHERE id = $7
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.DeliveredAt,
+		delivery.ID,
+	)
+	return err
+}
+
+// ListDeliveries retrieves a subscription's most recent deliveries, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*domain.WebhookDelivery, error) {
+	const query = `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE subscription_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+	return r.queryDeliveries(ctx, query, subscriptionID, limit)
+}
+
+// GetRetryableDeliveries retrieves pending deliveries whose next attempt is at or before now
+func (r *WebhookRepository) GetRetryableDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	const query = `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at ASC
+		LIMIT $3
+	`
+	return r.queryDeliveries(ctx, query, domain.WebhookDeliveryPending, now, limit)
+}
+
+func (r *WebhookRepository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]*domain.WebhookSubscription, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*domain.WebhookSubscription
+	for rows.Next() {
+		subscription := &domain.WebhookSubscription{}
+		var events string
+		if err := rows.Scan(
+			&subscription.ID,
+			&subscription.UserID,
+			&subscription.URL,
+			&subscription.Secret,
+			&events,
+			&subscription.Enabled,
+			&subscription.CreatedAt,
+			&subscription.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(events), &subscription.Events); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *WebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*domain.WebhookDelivery, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery := &domain.WebhookDelivery{}
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.ResponseStatus,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,92 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.WorkspaceRepository = (*WorkspaceRepository)(nil)
+
+// WorkspaceRepository stores the workspaces users keep apart from their personal one in PostgreSQL
+type WorkspaceRepository struct {
+	db *sql.DB
+}
+
+// NewWorkspaceRepository creates a new workspace repository
+func NewWorkspaceRepository(db *sql.DB) *WorkspaceRepository {
+	return &WorkspaceRepository{db: db}
+}
+
+// workspaceScope narrows a query on a user's rows to the workspace ctx
+// carries, if any, returning the condition to append and its arguments
+func workspaceScope(ctx context.Context, column string, args []any) (string, []any) {
+	workspaceID, ok := domain.WorkspaceFromContext(ctx)
+	if !ok {
+		return "", args
+	}
+	return fmt.Sprintf(" AND %s = $%d", column, len(args)+1), append(args, workspaceID)
+}
+
+// Create creates a new workspace
+func (r *WorkspaceRepository) Create(ctx context.Context, workspace *domain.Workspace) error {
+	const query = `INSERT INTO workspaces (id, user_id, name, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, workspace.ID, workspace.UserID, workspace.Name, workspace.CreatedAt)
+	return conflictErr(err)
+}
+
+// GetByID retrieves a workspace by ID
+func (r *WorkspaceRepository) GetByID(ctx context.Context, id string) (*domain.Workspace, error) {
+	const query = `SELECT id, user_id, name, created_at FROM workspaces WHERE id = $1`
+	workspace := &domain.Workspace{}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&workspace.ID, &workspace.UserID, &workspace.Name, &workspace.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// GetByUserID retrieves a user's workspaces by name
+func (r *WorkspaceRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Workspace, error) {
+	const query = `SELECT id, user_id, name, created_at FROM workspaces WHERE user_id = $1 ORDER BY name`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var workspaces []*domain.Workspace
+	for rows.Next() {
+		workspace := &domain.Workspace{}
+		if err := rows.Scan(&workspace.ID, &workspace.UserID, &workspace.Name, &workspace.CreatedAt); err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, workspace)
+	}
+	return workspaces, rows.Err()
+}
+
+// Update renames a workspace
+func (r *WorkspaceRepository) Update(ctx context.Context, workspace *domain.Workspace) error {
+	const query = `UPDATE workspaces SET name = $1 WHERE id = $2`
+	result, err := txOrDB(ctx, r.db).ExecContext(ctx, query, workspace.Name, workspace.ID)
+	if err != nil {
+		return conflictErr(err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Delete deletes a workspace
+func (r *WorkspaceRepository) Delete(ctx context.Context, id string) error {
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, `DELETE FROM workspaces WHERE id = $1`, id)
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/adapter/repository/repotest"
 	"github.com/riverlin/aiexpense/internal/domain"
 	"github.com/riverlin/aiexpense/internal/usecase"
 )
@@ -465,172 +466,8 @@ func TestCategoryRepositoryMock(t *testing.T) {
 
 // === Repository Contract Tests ===
 
-// RepositoryContractTest runs common tests for any repository implementation
-type RepositoryContractTest struct {
-	UserRepo       domain.UserRepository
-	ExpenseRepo    domain.ExpenseRepository
-	CategoryRepo   domain.CategoryRepository
-	MetricsRepo    domain.MetricsRepository
-}
-
-// TestRepositoryContract tests standard repository operations
+// TestRepositoryContract runs the shared contract against the mocks, SQLite and,
+// when their test database URLs are set, PostgreSQL and MySQL
 func TestRepositoryContract(t *testing.T) {
-	t.Run("UserRepositoryContract", func(t *testing.T) {
-		repo := usecase.NewMockUserRepository()
-		ctx := context.Background()
-
-		// Test Create
-		user := &domain.User{UserID: "test_user", MessengerType: "line", CreatedAt: time.Now()}
-		if err := repo.Create(ctx, user); err != nil {
-			t.Fatalf("Create failed: %v", err)
-		}
-
-		// Test GetByID
-		retrieved, err := repo.GetByID(ctx, user.UserID)
-		if err != nil {
-			t.Fatalf("GetByID failed: %v", err)
-		}
-		if retrieved == nil {
-			t.Fatal("GetByID returned nil")
-		}
-
-		// Test Exists
-		exists, err := repo.Exists(ctx, user.UserID)
-		if err != nil {
-			t.Fatalf("Exists failed: %v", err)
-		}
-		if !exists {
-			t.Error("Exists returned false for existing user")
-		}
-	})
-
-	t.Run("ExpenseRepositoryContract", func(t *testing.T) {
-		repo := usecase.NewMockExpenseRepository()
-		ctx := context.Background()
-
-		now := time.Now()
-		expense := &domain.Expense{
-			ID:          uuid.New().String(),
-			UserID:      "test_user",
-			Description: "test",
-			Amount:      20.0,
-			ExpenseDate: now,
-			CreatedAt:   now,
-			UpdatedAt:   now,
-		}
-
-		// Test Create
-		if err := repo.Create(ctx, expense); err != nil {
-			t.Fatalf("Create failed: %v", err)
-		}
-
-		// Test GetByID
-		retrieved, err := repo.GetByID(ctx, expense.ID)
-		if err != nil {
-			t.Fatalf("GetByID failed: %v", err)
-		}
-		if retrieved == nil {
-			t.Fatal("GetByID returned nil")
-		}
-
-		// Test GetByUserID
-		expenses, err := repo.GetByUserID(ctx, "test_user")
-		if err != nil {
-			t.Fatalf("GetByUserID failed: %v", err)
-		}
-		if len(expenses) == 0 {
-			t.Error("GetByUserID returned no expenses")
-		}
-
-		// Test Update
-		expense.Description = "updated"
-		if err := repo.Update(ctx, expense); err != nil {
-			t.Fatalf("Update failed: %v", err)
-		}
-
-		// Test Delete
-		if err := repo.Delete(ctx, expense.ID); err != nil {
-			t.Fatalf("Delete failed: %v", err)
-		}
-
-		// Verify deletion
-		deleted, _ := repo.GetByID(ctx, expense.ID)
-		if deleted != nil {
-			t.Error("Delete did not remove expense")
-		}
-	})
-
-	t.Run("CategoryRepositoryContract", func(t *testing.T) {
-		repo := usecase.NewMockCategoryRepository()
-		ctx := context.Background()
-
-		category := &domain.Category{
-			ID:        "cat_test",
-			UserID:    "test_user",
-			Name:      "Test",
-			IsDefault: false,
-			CreatedAt: time.Now(),
-		}
-
-		// Test Create
-		if err := repo.Create(ctx, category); err != nil {
-			t.Fatalf("Create failed: %v", err)
-		}
-
-		// Test GetByID
-		retrieved, err := repo.GetByID(ctx, category.ID)
-		if err != nil {
-			t.Fatalf("GetByID failed: %v", err)
-		}
-		if retrieved == nil {
-			t.Fatal("GetByID returned nil")
-		}
-
-		// Test GetByUserID
-		categories, err := repo.GetByUserID(ctx, "test_user")
-		if err != nil {
-			t.Fatalf("GetByUserID failed: %v", err)
-		}
-		if len(categories) == 0 {
-			t.Error("GetByUserID returned no categories")
-		}
-
-		// Test CreateKeyword
-		keyword := &domain.CategoryKeyword{
-			ID:         "kw_test",
-			CategoryID: category.ID,
-			Keyword:    "test",
-			Priority:   1,
-			CreatedAt:  time.Now(),
-		}
-		if err := repo.CreateKeyword(ctx, keyword); err != nil {
-			t.Fatalf("CreateKeyword failed: %v", err)
-		}
-
-		// Test GetKeywordsByCategory
-		keywords, err := repo.GetKeywordsByCategory(ctx, category.ID)
-		if err != nil {
-			t.Fatalf("GetKeywordsByCategory failed: %v", err)
-		}
-		if len(keywords) == 0 {
-			t.Error("GetKeywordsByCategory returned no keywords")
-		}
-
-		// Test Update
-		category.Name = "Updated"
-		if err := repo.Update(ctx, category); err != nil {
-			t.Fatalf("Update failed: %v", err)
-		}
-
-		// Test Delete
-		if err := repo.Delete(ctx, category.ID); err != nil {
-			t.Fatalf("Delete failed: %v", err)
-		}
-
-		// Verify deletion
-		deleted, _ := repo.GetByID(ctx, category.ID)
-		if deleted != nil {
-			t.Error("Delete did not remove category")
-		}
-	})
+	repotest.RunAll(t)
 }
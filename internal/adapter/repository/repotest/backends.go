@@ -0,0 +1,125 @@
+package repotest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/adapter/repository/postgresql"
+	"github.com/riverlin/aiexpense/internal/adapter/repository/sqlite"
+	"github.com/riverlin/aiexpense/internal/usecase"
+)
+
+// Backend opens the repositories of one storage implementation. Open skips
+// the test when the backend is not available, e.g. no database URL is set.
+type Backend struct {
+	Name string
+	Open func(t *testing.T) Repositories
+}
+
+// backends lists every backend; builds with the mysql tag add MySQL
+var backends = []Backend{
+	{Name: "Mock", Open: Mock},
+	{Name: "SQLite", Open: SQLite},
+	{Name: "PostgreSQL", Open: Postgres},
+}
+
+// Backends returns every backend the contract can run against
+func Backends() []Backend {
+	return backends
+}
+
+// RunAll runs the contract against each backend as a subtest
+func RunAll(t *testing.T) {
+	for _, backend := range Backends() {
+		t.Run(backend.Name, func(t *testing.T) {
+			Run(t, backend.Open(t))
+		})
+	}
+}
+
+// Mock returns the in-memory repositories used by the use case tests. The use
+// case tests have no short link or pricing mocks to share.
+func Mock(t *testing.T) Repositories {
+	return Repositories{
+		UserRepo:     usecase.NewMockUserRepository(),
+		CategoryRepo: usecase.NewMockCategoryRepository(),
+		ExpenseRepo:  usecase.NewMockExpenseRepository(),
+
+		ExpenseAuditRepo:            usecase.NewMockExpenseAuditRepository(),
+		InteractionLogRepo:          usecase.NewMockInteractionLogRepository(),
+		ProcessedEventRepo:          usecase.NewMockProcessedEventRepository(),
+		WebhookRepo:                 usecase.NewMockWebhookRepository(),
+		ReplyOutboxRepo:             usecase.NewMockReplyOutboxRepository(),
+		NotificationPreferencesRepo: usecase.NewMockNotificationPreferencesRepository(),
+		ConversationStateRepo:       usecase.NewMockConversationStateRepository(),
+		ReportScheduleRepo:          usecase.NewMockReportScheduleRepository(),
+		EmailAddressRepo:            usecase.NewMockEmailAddressRepository(),
+		APIKeyRepo:                  usecase.NewMockAPIKeyRepository(),
+	}
+}
+
+// SQLite returns repositories on a fresh, migrated database file
+func SQLite(t *testing.T) Repositories {
+	t.Helper()
+	db, err := sqlite.OpenDB(filepath.Join(t.TempDir(), "contract.db"))
+	if err != nil {
+		t.Fatalf("failed to open SQLite database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return Repositories{
+		UserRepo:     sqlite.NewUserRepository(db),
+		CategoryRepo: sqlite.NewCategoryRepository(db),
+		ExpenseRepo:  sqlite.NewExpenseRepository(db),
+		MetricsRepo:  sqlite.NewMetricsRepository(db),
+
+		ExpenseAuditRepo:            sqlite.NewExpenseAuditRepository(db),
+		InteractionLogRepo:          sqlite.NewInteractionLogRepository(db),
+		ProcessedEventRepo:          sqlite.NewProcessedEventRepository(db),
+		WebhookRepo:                 sqlite.NewWebhookRepository(db),
+		ReplyOutboxRepo:             sqlite.NewReplyOutboxRepository(db),
+		NotificationPreferencesRepo: sqlite.NewNotificationPreferencesRepository(db),
+		ConversationStateRepo:       sqlite.NewConversationStateRepository(db),
+		ReportScheduleRepo:          sqlite.NewReportScheduleRepository(db),
+		EmailAddressRepo:            sqlite.NewEmailAddressRepository(db),
+		ShortLinkRepo:               sqlite.NewShortLinkRepository(db),
+		PricingRepo:                 sqlite.NewPricingRepository(db),
+		APIKeyRepo:                  sqlite.NewAPIKeyRepository(db),
+	}
+}
+
+// Postgres returns repositories on the database at POSTGRES_TEST_DATABASE_URL,
+// migrated to the latest version
+func Postgres(t *testing.T) Repositories {
+	t.Helper()
+	databaseURL := os.Getenv("POSTGRES_TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("POSTGRES_TEST_DATABASE_URL is not set")
+	}
+	db, err := postgresql.OpenDB(databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open PostgreSQL database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return Repositories{
+		UserRepo:     postgresql.NewUserRepository(db),
+		CategoryRepo: postgresql.NewCategoryRepository(db),
+		ExpenseRepo:  postgresql.NewExpenseRepository(db),
+		MetricsRepo:  postgresql.NewMetricsRepository(db),
+
+		ExpenseAuditRepo:            postgresql.NewExpenseAuditRepository(db),
+		InteractionLogRepo:          postgresql.NewInteractionLogRepository(db),
+		ProcessedEventRepo:          postgresql.NewProcessedEventRepository(db),
+		WebhookRepo:                 postgresql.NewWebhookRepository(db),
+		ReplyOutboxRepo:             postgresql.NewReplyOutboxRepository(db),
+		NotificationPreferencesRepo: postgresql.NewNotificationPreferencesRepository(db),
+		ConversationStateRepo:       postgresql.NewConversationStateRepository(db),
+		ReportScheduleRepo:          postgresql.NewReportScheduleRepository(db),
+		EmailAddressRepo:            postgresql.NewEmailAddressRepository(db),
+		ShortLinkRepo:               postgresql.NewShortLinkRepository(db),
+		PricingRepo:                 postgresql.NewPricingRepository(db),
+		APIKeyRepo:                  postgresql.NewAPIKeyRepository(db),
+	}
+}
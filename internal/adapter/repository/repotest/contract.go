@@ -0,0 +1,460 @@
+// Package repotest runs one behavioral contract against every repository
+// backend, so the in-memory mocks, SQLite, PostgreSQL and MySQL cannot drift
+// apart on semantics such as what a lookup of a missing row returns.
+//
+// The contract covers the repositories listed in Repositories. The others,
+// such as budgets, groups and workspaces, are covered by each backend's own
+// tests.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Repositories are the implementations of one backend under test. Only
+// UserRepo, CategoryRepo and ExpenseRepo are required; the contract of any
+// other repository is skipped for backends without one.
+type Repositories struct {
+	UserRepo     domain.UserRepository
+	CategoryRepo domain.CategoryRepository
+	ExpenseRepo  domain.ExpenseRepository
+	MetricsRepo  domain.MetricsRepository
+
+	ExpenseAuditRepo            domain.ExpenseAuditRepository
+	InteractionLogRepo          domain.InteractionLogRepository
+	ProcessedEventRepo          domain.ProcessedEventRepository
+	WebhookRepo                 domain.WebhookRepository
+	ReplyOutboxRepo             domain.ReplyOutboxRepository
+	NotificationPreferencesRepo domain.NotificationPreferencesRepository
+	ConversationStateRepo       domain.ConversationStateRepository
+	ReportScheduleRepo          domain.ReportScheduleRepository
+	EmailAddressRepo            domain.EmailAddressRepository
+	ShortLinkRepo               domain.ShortLinkRepository
+	PricingRepo                 domain.PricingRepository
+	APIKeyRepo                  domain.APIKeyRepository
+}
+
+// Run checks repos against the repository contract. IDs are unique per call,
+// so it can run against a database shared with other tests or earlier runs.
+func Run(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	suffix := uuid.New().String()[:8]
+	userID := "contract_user_" + suffix
+
+	t.Run("UserRepositoryContract", func(t *testing.T) {
+		repo := repos.UserRepo
+		user := &domain.User{UserID: userID, MessengerType: "line", CreatedAt: time.Now()}
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		retrieved, err := repo.GetByID(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if retrieved == nil {
+			t.Fatal("GetByID returned nil")
+		}
+		if retrieved.MessengerType != "line" {
+			t.Errorf("messenger type mismatch: expected line, got %s", retrieved.MessengerType)
+		}
+		if retrieved.OnboardedAt != nil {
+			t.Errorf("expected a new user not onboarded, got %v", retrieved.OnboardedAt)
+		}
+
+		onboardedAt := time.Now().UTC().Truncate(time.Second)
+		if err := repo.Update(ctx, &domain.User{UserID: userID, HomeCurrency: "JPY", Locale: "ja", OnboardedAt: &onboardedAt, Timezone: "Asia/Tokyo"}); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		updated, err := repo.GetByID(ctx, userID)
+		if err != nil || updated.HomeCurrency != "JPY" || updated.Locale != "ja" || updated.OnboardedAt == nil || !updated.OnboardedAt.Equal(onboardedAt) || updated.Timezone != "Asia/Tokyo" {
+			t.Errorf("Update: expected JPY, ja, the onboarding time and Asia/Tokyo, got %+v, %v", updated, err)
+		}
+
+		exists, err := repo.Exists(ctx, userID)
+		if err != nil {
+			t.Fatalf("Exists failed: %v", err)
+		}
+		if !exists {
+			t.Error("Exists returned false for existing user")
+		}
+
+		// A missing row is domain.ErrNotFound
+		if _, err := repo.GetByID(ctx, "missing_"+suffix); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("GetByID of a missing user: expected ErrNotFound, got %v", err)
+		}
+		if err := repo.Create(ctx, user); !errors.Is(err, domain.ErrConflict) {
+			t.Errorf("Create of a duplicate user: expected ErrConflict, got %v", err)
+		}
+		exists, err = repo.Exists(ctx, "missing_"+suffix)
+		if err != nil || exists {
+			t.Errorf("Exists of a missing user: expected false, nil, got %v, %v", exists, err)
+		}
+	})
+
+	categoryID := "cat_" + suffix
+	t.Run("CategoryRepositoryContract", func(t *testing.T) {
+		repo := repos.CategoryRepo
+		category := &domain.Category{
+			ID:        categoryID,
+			UserID:    userID,
+			Name:      "Food",
+			CreatedAt: time.Now(),
+		}
+		if err := repo.Create(ctx, category); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+
+		retrieved, err := repo.GetByID(ctx, categoryID)
+		if err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if retrieved == nil || retrieved.Name != "Food" {
+			t.Fatalf("GetByID returned %+v", retrieved)
+		}
+
+		categories, err := repo.GetByUserID(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetByUserID failed: %v", err)
+		}
+		if len(categories) != 1 {
+			t.Errorf("expected 1 category, got %d", len(categories))
+		}
+
+		byName, err := repo.GetByUserIDAndName(ctx, userID, "Food")
+		if err != nil || byName == nil || byName.ID != categoryID {
+			t.Errorf("GetByUserIDAndName: expected %s, got %v, %v", categoryID, byName, err)
+		}
+		if _, err := repo.GetByUserIDAndName(ctx, userID, "Nonexistent"); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("GetByUserIDAndName of a missing name: expected ErrNotFound, got %v", err)
+		}
+		if _, err := repo.GetByID(ctx, "missing_"+suffix); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("GetByID of a missing category: expected ErrNotFound, got %v", err)
+		}
+		if err := repo.Create(ctx, category); !errors.Is(err, domain.ErrConflict) {
+			t.Errorf("Create of a duplicate category: expected ErrConflict, got %v", err)
+		}
+
+		keyword := &domain.CategoryKeyword{
+			ID:         "kw_" + suffix,
+			CategoryID: categoryID,
+			Keyword:    "lunch",
+			Priority:   1,
+			CreatedAt:  time.Now(),
+		}
+		if err := repo.CreateKeyword(ctx, keyword); err != nil {
+			t.Fatalf("CreateKeyword failed: %v", err)
+		}
+		keywords, err := repo.GetKeywordsByCategory(ctx, categoryID)
+		if err != nil {
+			t.Fatalf("GetKeywordsByCategory failed: %v", err)
+		}
+		if len(keywords) != 1 || keywords[0].Keyword != "lunch" {
+			t.Errorf("expected the lunch keyword, got %v", keywords)
+		}
+		userKeywords, err := repo.GetKeywordsByUserID(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetKeywordsByUserID failed: %v", err)
+		}
+		if len(userKeywords) != 1 {
+			t.Errorf("expected 1 keyword for the user, got %d", len(userKeywords))
+		}
+		if err := repo.DeleteKeyword(ctx, keyword.ID); err != nil {
+			t.Fatalf("DeleteKeyword failed: %v", err)
+		}
+		if keywords, _ := repo.GetKeywordsByCategory(ctx, categoryID); len(keywords) != 0 {
+			t.Errorf("DeleteKeyword left %d keywords", len(keywords))
+		}
+
+		category.Name = "Groceries"
+		if err := repo.Update(ctx, category); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if updated, _ := repo.GetByID(ctx, categoryID); updated == nil || updated.Name != "Groceries" {
+			t.Errorf("Update was not persisted: %+v", updated)
+		}
+	})
+
+	t.Run("ExpenseRepositoryContract", func(t *testing.T) {
+		repo := repos.ExpenseRepo
+		day := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+		newExpense := func(id, description string, amount float64, date time.Time) *domain.Expense {
+			return &domain.Expense{
+				ID:             id,
+				UserID:         userID,
+				Description:    description,
+				OriginalAmount: amount,
+				Currency:       "TWD",
+				HomeAmount:     amount,
+				HomeCurrency:   "TWD",
+				ExchangeRate:   1,
+				Amount:         amount,
+				CategoryID:     &categoryID,
+				Account:        "Cash",
+				ExpenseDate:    date,
+				CreatedAt:      date,
+				UpdatedAt:      date,
+			}
+		}
+		lunch := newExpense("exp_lunch_"+suffix, "Lunch", 120, day)
+		dinner := newExpense("exp_dinner_"+suffix, "Dinner", 300, day.AddDate(0, 0, 1))
+		for _, expense := range []*domain.Expense{lunch, dinner} {
+			if err := repo.Create(ctx, expense); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+		}
+
+		retrieved, err := repo.GetByID(ctx, lunch.ID)
+		if err != nil {
+			t.Fatalf("GetByID failed: %v", err)
+		}
+		if retrieved == nil {
+			t.Fatal("GetByID returned nil")
+		}
+		if retrieved.HomeAmount != 120 || retrieved.Amount != 120 || retrieved.Description != "Lunch" {
+			t.Errorf("round trip changed the expense: %+v", retrieved)
+		}
+		if retrieved.CategoryID == nil || *retrieved.CategoryID != categoryID {
+			t.Errorf("expected category %s, got %v", categoryID, retrieved.CategoryID)
+		}
+		if _, err := repo.GetByID(ctx, "missing_"+suffix); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("GetByID of a missing expense: expected ErrNotFound, got %v", err)
+		}
+
+		expenses, err := repo.GetByUserID(ctx, userID)
+		if err != nil {
+			t.Fatalf("GetByUserID failed: %v", err)
+		}
+		if len(expenses) != 2 {
+			t.Errorf("expected 2 expenses, got %d", len(expenses))
+		}
+
+		inRange, err := repo.GetByUserIDAndDateRange(ctx, userID, day.Add(-time.Hour), day.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("GetByUserIDAndDateRange failed: %v", err)
+		}
+		if len(inRange) != 1 || inRange[0].ID != lunch.ID {
+			t.Errorf("expected only the lunch in range, got %d expenses", len(inRange))
+		}
+
+		byCategory, err := repo.GetByUserIDAndCategory(ctx, userID, categoryID)
+		if err != nil {
+			t.Fatalf("GetByUserIDAndCategory failed: %v", err)
+		}
+		if len(byCategory) != 2 {
+			t.Errorf("expected 2 expenses in the category, got %d", len(byCategory))
+		}
+
+		listed, err := repo.ListByUserID(ctx, userID, domain.ExpenseListOptions{
+			SortBy:  domain.ExpenseSortAmount,
+			SortDir: domain.SortDesc,
+			Limit:   1,
+		})
+		if err != nil {
+			t.Fatalf("ListByUserID failed: %v", err)
+		}
+		if len(listed) != 1 || listed[0].ID != dinner.ID {
+			t.Errorf("expected the dinner first by amount, got %d expenses", len(listed))
+		}
+
+		lunch.Description = "Brunch"
+		if err := repo.Update(ctx, lunch); err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		if updated, _ := repo.GetByID(ctx, lunch.ID); updated == nil || updated.Description != "Brunch" {
+			t.Errorf("Update was not persisted: %+v", updated)
+		}
+
+		// Delete is a soft delete that Restore undoes
+		if err := repo.Delete(ctx, lunch.ID); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if _, err := repo.GetByID(ctx, lunch.ID); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("GetByID of a deleted expense: expected ErrNotFound, got %v", err)
+		}
+		if remaining, _ := repo.GetByUserID(ctx, userID); len(remaining) != 1 {
+			t.Errorf("expected 1 expense after delete, got %d", len(remaining))
+		}
+		deleted, err := repo.GetDeletedByID(ctx, lunch.ID)
+		if err != nil {
+			t.Fatalf("GetDeletedByID failed: %v", err)
+		}
+		if deleted == nil || deleted.DeletedAt == nil {
+			t.Fatalf("expected the deleted expense with DeletedAt set, got %+v", deleted)
+		}
+		if _, err := repo.GetDeletedByID(ctx, dinner.ID); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("GetDeletedByID of a live expense: expected ErrNotFound, got %v", err)
+		}
+		if err := repo.Restore(ctx, lunch.ID); err != nil {
+			t.Fatalf("Restore failed: %v", err)
+		}
+		if restored, _ := repo.GetByID(ctx, lunch.ID); restored == nil || restored.Description != "Brunch" {
+			t.Errorf("Restore did not bring back the expense: %+v", restored)
+		}
+
+		// Deleting a missing expense is not an error
+		if err := repo.Delete(ctx, "missing_"+suffix); err != nil {
+			t.Errorf("Delete of a missing expense: expected nil, got %v", err)
+		}
+
+		// CreateBatch writes every expense or none of them
+		may := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+		breakfast := newExpense("exp_breakfast_"+suffix, "Breakfast", 50, may)
+		supper := newExpense("exp_supper_"+suffix, "Supper", 200, may)
+		if err := repo.CreateBatch(ctx, []*domain.Expense{breakfast, supper}); err != nil {
+			t.Fatalf("CreateBatch failed: %v", err)
+		}
+		for _, expense := range []*domain.Expense{breakfast, supper} {
+			if created, err := repo.GetByID(ctx, expense.ID); err != nil || created.HomeAmount != expense.HomeAmount {
+				t.Errorf("CreateBatch did not persist %s: %+v, %v", expense.ID, created, err)
+			}
+		}
+		snack := newExpense("exp_snack_"+suffix, "Snack", 30, may)
+		if err := repo.CreateBatch(ctx, []*domain.Expense{snack, breakfast}); !errors.Is(err, domain.ErrConflict) {
+			t.Errorf("CreateBatch with a duplicate: expected ErrConflict, got %v", err)
+		}
+		if _, err := repo.GetByID(ctx, snack.ID); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("CreateBatch with a duplicate kept part of the batch: %v", err)
+		}
+
+		// Aggregates total the live expenses of the ledger in the date range
+		repo.Delete(ctx, supper.ID)
+		totalsQuery := domain.ExpenseTotalsQuery{UserID: userID, From: day.AddDate(0, 0, -1), To: may.AddDate(0, 0, 1)}
+		categoryTotals, err := repo.SumByCategoryAndDateRange(ctx, totalsQuery)
+		if err != nil {
+			t.Fatalf("SumByCategoryAndDateRange failed: %v", err)
+		}
+		if len(categoryTotals) != 1 || categoryTotals[0].CategoryID == nil || *categoryTotals[0].CategoryID != categoryID {
+			t.Fatalf("expected one total for %s, got %d", categoryID, len(categoryTotals))
+		}
+		if got := categoryTotals[0]; got.Total != 470 || got.Count != 3 || got.Highest != 300 || got.Lowest != 50 {
+			t.Errorf("unexpected category total: %+v", got)
+		}
+
+		dailyTotals, err := repo.SumByPeriodAndDateRange(ctx, totalsQuery, domain.TotalsPeriodDay)
+		if err != nil {
+			t.Fatalf("SumByPeriodAndDateRange by day failed: %v", err)
+		}
+		wantDaily := []domain.PeriodTotal{
+			{Start: time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC), Total: 120, Count: 1},
+			{Start: time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC), Total: 300, Count: 1},
+			{Start: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), Total: 50, Count: 1},
+		}
+		if len(dailyTotals) != len(wantDaily) {
+			t.Fatalf("expected %d daily totals, got %d", len(wantDaily), len(dailyTotals))
+		}
+		for i, want := range wantDaily {
+			if got := dailyTotals[i]; !got.Start.Equal(want.Start) || got.Total != want.Total || got.Count != want.Count {
+				t.Errorf("daily total %d = %+v, want %+v", i, got, want)
+			}
+		}
+
+		monthlyTotals, err := repo.SumByPeriodAndDateRange(ctx, totalsQuery, domain.TotalsPeriodMonth)
+		if err != nil {
+			t.Fatalf("SumByPeriodAndDateRange by month failed: %v", err)
+		}
+		if len(monthlyTotals) != 2 || !monthlyTotals[0].Start.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || monthlyTotals[0].Total != 420 || monthlyTotals[1].Count != 1 {
+			t.Errorf("unexpected monthly totals: %d entries", len(monthlyTotals))
+		}
+
+		// Filter combines every criterion it sets, newest first
+		minAmount, maxAmount, noAttachment, hasAttachment := 100.0, 400.0, false, true
+		filtered, err := repo.Filter(ctx, domain.ExpenseFilter{UserID: userID})
+		if err != nil {
+			t.Fatalf("Filter failed: %v", err)
+		}
+		if len(filtered) != 3 || filtered[0].ID != breakfast.ID || filtered[2].ID != lunch.ID {
+			t.Errorf("expected the 3 live expenses newest first, got %d", len(filtered))
+		}
+		filtered, err = repo.Filter(ctx, domain.ExpenseFilter{
+			UserID:        userID,
+			MinAmount:     &minAmount,
+			MaxAmount:     &maxAmount,
+			Currencies:    []string{"USD", "TWD"},
+			HasAttachment: &noAttachment,
+			Text:          "RUNCH",
+		})
+		if err != nil {
+			t.Fatalf("Filter failed: %v", err)
+		}
+		if len(filtered) != 1 || filtered[0].ID != lunch.ID {
+			t.Errorf("expected only the brunch, got %d expenses", len(filtered))
+		}
+		from := may.AddDate(0, 0, -1)
+		filtered, _ = repo.Filter(ctx, domain.ExpenseFilter{UserID: userID, From: &from, CategoryIDs: []string{"missing_" + suffix, categoryID}})
+		if len(filtered) != 1 || filtered[0].ID != breakfast.ID {
+			t.Errorf("expected only the breakfast from May, got %d expenses", len(filtered))
+		}
+		for _, filter := range []domain.ExpenseFilter{
+			{UserID: userID, HasAttachment: &hasAttachment},
+			{UserID: userID, Currencies: []string{"USD"}},
+			{UserID: userID, Text: "100%"},
+		} {
+			if filtered, _ := repo.Filter(ctx, filter); len(filtered) != 0 {
+				t.Errorf("expected no expenses for %+v, got %d", filter, len(filtered))
+			}
+		}
+
+		for _, expense := range []*domain.Expense{breakfast, supper} {
+			repo.Delete(ctx, expense.ID)
+		}
+	})
+
+	t.Run("MetricsRepositoryContract", func(t *testing.T) {
+		if repos.MetricsRepo == nil {
+			t.Skip("backend has no metrics repository")
+		}
+		from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)
+
+		trends, err := repos.MetricsRepo.GetCategoryTrends(ctx, userID, from, to)
+		if err != nil {
+			t.Fatalf("GetCategoryTrends failed: %v", err)
+		}
+		var total float64
+		for _, trend := range trends {
+			total += trend.Total
+		}
+		if total != 420 {
+			t.Errorf("expected category totals of 420, got %v", total)
+		}
+
+		if _, err := repos.MetricsRepo.GetExpensesSummary(ctx, from, to); err != nil {
+			t.Errorf("GetExpensesSummary failed: %v", err)
+		}
+		if _, err := repos.MetricsRepo.GetDailyActiveUsers(ctx, from, to); err != nil {
+			t.Errorf("GetDailyActiveUsers failed: %v", err)
+		}
+		if _, err := repos.MetricsRepo.GetGrowthMetrics(ctx, 7); err != nil {
+			t.Errorf("GetGrowthMetrics failed: %v", err)
+		}
+	})
+
+	f := fixture{ctx: ctx, userID: userID, suffix: suffix}
+	optional := func(name string, missing bool, contract func(t *testing.T)) {
+		t.Run(name, func(t *testing.T) {
+			if missing {
+				t.Skip("backend has no repository for this contract")
+			}
+			contract(t)
+		})
+	}
+	optional("ExpenseAuditRepositoryContract", repos.ExpenseAuditRepo == nil, func(t *testing.T) { f.expenseAudit(t, repos.ExpenseAuditRepo) })
+	optional("InteractionLogRepositoryContract", repos.InteractionLogRepo == nil, func(t *testing.T) { f.interactionLog(t, repos.InteractionLogRepo) })
+	optional("ProcessedEventRepositoryContract", repos.ProcessedEventRepo == nil, func(t *testing.T) { f.processedEvent(t, repos.ProcessedEventRepo) })
+	optional("WebhookRepositoryContract", repos.WebhookRepo == nil, func(t *testing.T) { f.webhook(t, repos.WebhookRepo) })
+	optional("ReplyOutboxRepositoryContract", repos.ReplyOutboxRepo == nil, func(t *testing.T) { f.replyOutbox(t, repos.ReplyOutboxRepo) })
+	optional("NotificationPreferencesRepositoryContract", repos.NotificationPreferencesRepo == nil, func(t *testing.T) { f.notificationPreferences(t, repos.NotificationPreferencesRepo) })
+	optional("ConversationStateRepositoryContract", repos.ConversationStateRepo == nil, func(t *testing.T) { f.conversationState(t, repos.ConversationStateRepo) })
+	optional("ReportScheduleRepositoryContract", repos.ReportScheduleRepo == nil, func(t *testing.T) { f.reportSchedule(t, repos.ReportScheduleRepo) })
+	optional("EmailAddressRepositoryContract", repos.EmailAddressRepo == nil, func(t *testing.T) { f.emailAddress(t, repos.EmailAddressRepo) })
+	optional("ShortLinkRepositoryContract", repos.ShortLinkRepo == nil, func(t *testing.T) { f.shortLink(t, repos.ShortLinkRepo) })
+	optional("PricingRepositoryContract", repos.PricingRepo == nil, func(t *testing.T) { f.pricing(t, repos.PricingRepo) })
+	optional("APIKeyRepositoryContract", repos.APIKeyRepo == nil, func(t *testing.T) { f.apiKey(t, repos.APIKeyRepo) })
+}
@@ -0,0 +1,684 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// fixture holds what the contracts of the optional repositories share: the
+// contract user and the suffix that keeps their IDs unique
+type fixture struct {
+	ctx    context.Context
+	userID string
+	suffix string
+}
+
+// longAgo dates the rows of the contracts whose queries span every user, such
+// as due outbox replies, so they are the only rows in a shared database that
+// the contract's cutoffs reach
+var longAgo = time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (f fixture) expenseAudit(t *testing.T, repo domain.ExpenseAuditRepository) {
+	expenseID := "exp_audited_" + f.suffix
+	created := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	entries := []*domain.ExpenseAuditEntry{
+		{ID: "audit_create_" + f.suffix, ExpenseID: expenseID, UserID: f.userID, Action: domain.ExpenseAuditCreate, Actor: f.userID, Channel: "line",
+			After: &domain.Expense{ID: expenseID, Description: "Lunch", HomeAmount: 120}, CreatedAt: created},
+		{ID: "audit_update_" + f.suffix, ExpenseID: expenseID, UserID: f.userID, Action: domain.ExpenseAuditUpdate, Actor: f.userID, Channel: "api",
+			Before: &domain.Expense{ID: expenseID, Description: "Lunch", HomeAmount: 120}, After: &domain.Expense{ID: expenseID, Description: "Brunch", HomeAmount: 150}, CreatedAt: created.Add(time.Minute)},
+	}
+	for _, entry := range entries {
+		if err := repo.Create(f.ctx, entry); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	// Later changes to the expense don't reach the stored snapshot
+	entries[1].After.Description = "Dinner"
+
+	history, err := repo.GetByExpenseID(f.ctx, expenseID)
+	if err != nil {
+		t.Fatalf("GetByExpenseID failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Action != domain.ExpenseAuditCreate || history[1].Action != domain.ExpenseAuditUpdate {
+		t.Fatalf("expected the create then the update, got %d entries", len(history))
+	}
+	if history[0].Before != nil || history[0].After == nil || history[0].After.Description != "Lunch" {
+		t.Errorf("unexpected snapshots of the create: %+v, %+v", history[0].Before, history[0].After)
+	}
+	if update := history[1]; update.Before == nil || update.Before.HomeAmount != 120 || update.After == nil || update.After.Description != "Brunch" || update.Channel != "api" {
+		t.Errorf("unexpected update entry: %+v", update)
+	}
+	if history, err := repo.GetByExpenseID(f.ctx, "missing_"+f.suffix); err != nil || len(history) != 0 {
+		t.Errorf("GetByExpenseID of an expense without history: expected no entries, got %d, %v", len(history), err)
+	}
+}
+
+func (f fixture) interactionLog(t *testing.T, repo domain.InteractionLogRepository) {
+	start := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	for i, source := range []string{"telegram", "line", "telegram"} {
+		log := &domain.InteractionLog{
+			ID:            "log_" + string(rune('a'+i)) + "_" + f.suffix,
+			UserID:        f.userID,
+			Source:        source,
+			Intent:        domain.InteractionIntentExpense,
+			UserInput:     "lunch 120",
+			AIRawResponse: `{"expenses": []}`,
+			BotFinalReply: "Recorded",
+			DurationMs:    int64(100 * (i + 1)),
+			Timestamp:     start.Add(time.Duration(i) * time.Minute),
+		}
+		if err := repo.Create(f.ctx, log); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	logs, total, err := repo.List(f.ctx, domain.InteractionLogFilter{UserID: f.userID})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if total != 3 || len(logs) != 3 || logs[0].ID != "log_c_"+f.suffix || logs[2].ID != "log_a_"+f.suffix {
+		t.Fatalf("expected the 3 entries newest first, got %d of %d", len(logs), total)
+	}
+	if logs[0].UserInput != "lunch 120" || logs[0].DurationMs != 300 || !logs[0].Timestamp.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("round trip changed the entry: %+v", logs[0])
+	}
+
+	// Limit and Offset page through the matches; the total counts all of them
+	logs, total, err = repo.List(f.ctx, domain.InteractionLogFilter{UserID: f.userID, Limit: 1, Offset: 1})
+	if err != nil || total != 3 || len(logs) != 1 || logs[0].ID != "log_b_"+f.suffix {
+		t.Errorf("expected the second entry of 3, got %d of %d, %v", len(logs), total, err)
+	}
+	logs, total, _ = repo.List(f.ctx, domain.InteractionLogFilter{UserID: f.userID, Source: "telegram"})
+	if total != 2 || len(logs) != 2 {
+		t.Errorf("expected 2 telegram entries, got %d of %d", len(logs), total)
+	}
+
+	// From is inclusive and To exclusive
+	logs, total, _ = repo.List(f.ctx, domain.InteractionLogFilter{UserID: f.userID, From: start, To: start.Add(2 * time.Minute)})
+	if total != 2 || len(logs) != 2 || logs[0].ID != "log_b_"+f.suffix {
+		t.Errorf("expected the first 2 entries in range, got %d of %d", len(logs), total)
+	}
+	if logs, total, _ := repo.List(f.ctx, domain.InteractionLogFilter{UserID: f.userID, Intent: domain.InteractionIntentReport}); total != 0 || len(logs) != 0 {
+		t.Errorf("expected no report entries, got %d of %d", len(logs), total)
+	}
+}
+
+func (f fixture) processedEvent(t *testing.T, repo domain.ProcessedEventRepository) {
+	event := &domain.ProcessedEvent{Messenger: "telegram", EventID: "update_" + f.suffix, ProcessedAt: longAgo}
+	if first, err := repo.MarkProcessed(f.ctx, event); err != nil || !first {
+		t.Fatalf("MarkProcessed of a new event: expected true, got %v, %v", first, err)
+	}
+	if first, err := repo.MarkProcessed(f.ctx, event); err != nil || first {
+		t.Errorf("MarkProcessed of a recorded event: expected false, got %v, %v", first, err)
+	}
+	// The event ID is unique per messenger
+	if first, err := repo.MarkProcessed(f.ctx, &domain.ProcessedEvent{Messenger: "line", EventID: event.EventID, ProcessedAt: longAgo}); err != nil || !first {
+		t.Errorf("MarkProcessed of the ID on another messenger: expected true, got %v, %v", first, err)
+	}
+
+	deleted, err := repo.DeleteBefore(f.ctx, longAgo.Add(time.Second))
+	if err != nil {
+		t.Fatalf("DeleteBefore failed: %v", err)
+	}
+	if deleted < 2 {
+		t.Errorf("expected DeleteBefore to remove both events, got %d", deleted)
+	}
+	if first, err := repo.MarkProcessed(f.ctx, event); err != nil || !first {
+		t.Errorf("MarkProcessed of a deleted event: expected true, got %v, %v", first, err)
+	}
+	repo.DeleteBefore(f.ctx, longAgo.Add(time.Second))
+}
+
+func (f fixture) webhook(t *testing.T, repo domain.WebhookRepository) {
+	created := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	subscription := &domain.WebhookSubscription{
+		ID:        "hook_" + f.suffix,
+		UserID:    f.userID,
+		URL:       "https://example.com/hooks",
+		Secret:    "whsec_" + f.suffix,
+		Events:    []string{"expense.created", "expense.deleted"},
+		Enabled:   true,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	if err := repo.CreateSubscription(f.ctx, subscription); err != nil {
+		t.Fatalf("CreateSubscription failed: %v", err)
+	}
+	retrieved, err := repo.GetSubscription(f.ctx, subscription.ID)
+	if err != nil {
+		t.Fatalf("GetSubscription failed: %v", err)
+	}
+	if retrieved.URL != subscription.URL || len(retrieved.Events) != 2 || !retrieved.Subscribes("expense.deleted") || !retrieved.Enabled {
+		t.Errorf("round trip changed the subscription: %+v", retrieved)
+	}
+	if _, err := repo.GetSubscription(f.ctx, "missing_"+f.suffix); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetSubscription of a missing subscription: expected ErrNotFound, got %v", err)
+	}
+	if subscriptions, err := repo.ListSubscriptions(f.ctx, f.userID); err != nil || len(subscriptions) != 1 {
+		t.Errorf("expected 1 subscription for the user, got %d, %v", len(subscriptions), err)
+	}
+
+	subscription.URL = "https://example.com/v2/hooks"
+	subscription.Events = []string{"expense.created"}
+	subscription.Enabled = false
+	if err := repo.UpdateSubscription(f.ctx, subscription); err != nil {
+		t.Fatalf("UpdateSubscription failed: %v", err)
+	}
+	if updated, _ := repo.GetSubscription(f.ctx, subscription.ID); updated == nil || updated.URL != subscription.URL || updated.Subscribes("expense.deleted") || updated.Enabled {
+		t.Errorf("UpdateSubscription was not persisted: %+v", updated)
+	}
+
+	retryAt := longAgo
+	delivered := created.Add(time.Second)
+	pending := &domain.WebhookDelivery{ID: "delivery_pending_" + f.suffix, SubscriptionID: subscription.ID, Event: "expense.created", Payload: `{"id": 1}`,
+		Status: domain.WebhookDeliveryPending, Attempts: 1, ResponseStatus: 500, LastError: "server error", NextAttemptAt: &retryAt, CreatedAt: created}
+	succeeded := &domain.WebhookDelivery{ID: "delivery_succeeded_" + f.suffix, SubscriptionID: subscription.ID, Event: "expense.created", Payload: `{"id": 2}`,
+		Status: domain.WebhookDeliverySucceeded, Attempts: 1, ResponseStatus: 200, CreatedAt: created.Add(time.Minute), DeliveredAt: &delivered}
+	for _, delivery := range []*domain.WebhookDelivery{pending, succeeded} {
+		if err := repo.CreateDelivery(f.ctx, delivery); err != nil {
+			t.Fatalf("CreateDelivery failed: %v", err)
+		}
+	}
+
+	deliveries, err := repo.ListDeliveries(f.ctx, subscription.ID, 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries failed: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].ID != succeeded.ID || deliveries[1].ResponseStatus != 500 || deliveries[1].LastError != "server error" {
+		t.Errorf("expected both deliveries newest first, got %d", len(deliveries))
+	}
+	if deliveries, _ := repo.ListDeliveries(f.ctx, subscription.ID, 1); len(deliveries) != 1 || deliveries[0].ID != succeeded.ID {
+		t.Errorf("expected only the latest delivery, got %d", len(deliveries))
+	}
+
+	retryable := func() []string {
+		deliveries, err := repo.GetRetryableDeliveries(f.ctx, longAgo.Add(time.Hour), 100)
+		if err != nil {
+			t.Fatalf("GetRetryableDeliveries failed: %v", err)
+		}
+		var ids []string
+		for _, delivery := range deliveries {
+			if delivery.SubscriptionID == subscription.ID {
+				ids = append(ids, delivery.ID)
+			}
+		}
+		return ids
+	}
+	if ids := retryable(); len(ids) != 1 || ids[0] != pending.ID {
+		t.Errorf("expected only the pending delivery to be retryable, got %v", ids)
+	}
+	pending.Status, pending.Attempts, pending.ResponseStatus, pending.LastError = domain.WebhookDeliverySucceeded, 2, 204, ""
+	pending.NextAttemptAt, pending.DeliveredAt = nil, &delivered
+	if err := repo.UpdateDelivery(f.ctx, pending); err != nil {
+		t.Fatalf("UpdateDelivery failed: %v", err)
+	}
+	if ids := retryable(); len(ids) != 0 {
+		t.Errorf("expected no retryable deliveries after success, got %v", ids)
+	}
+
+	// Deleting the subscription deletes its deliveries
+	if err := repo.DeleteSubscription(f.ctx, subscription.ID); err != nil {
+		t.Fatalf("DeleteSubscription failed: %v", err)
+	}
+	if _, err := repo.GetSubscription(f.ctx, subscription.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetSubscription of a deleted subscription: expected ErrNotFound, got %v", err)
+	}
+	if deliveries, _ := repo.ListDeliveries(f.ctx, subscription.ID, 10); len(deliveries) != 0 {
+		t.Errorf("DeleteSubscription left %d deliveries", len(deliveries))
+	}
+}
+
+func (f fixture) replyOutbox(t *testing.T, repo domain.ReplyOutboxRepository) {
+	first, second := longAgo.Add(time.Minute), longAgo.Add(2*time.Minute)
+	newReply := func(id, status string, nextAttemptAt *time.Time) *domain.OutboxReply {
+		return &domain.OutboxReply{
+			ID:            id + "_" + f.suffix,
+			UserID:        f.userID,
+			Messenger:     "telegram",
+			Metadata:      `{"chat_id": "42"}`,
+			Text:          "Recorded lunch",
+			Status:        status,
+			NextAttemptAt: nextAttemptAt,
+			CreatedAt:     longAgo,
+		}
+	}
+	later := newReply("reply_later", domain.OutboxReplyPending, &second)
+	sooner := newReply("reply_sooner", domain.OutboxReplyPending, &first)
+	delivered := newReply("reply_delivered", domain.OutboxReplyDelivered, nil)
+	delivered.Push, delivered.DeliveredAt = true, &first
+	for _, reply := range []*domain.OutboxReply{later, sooner, delivered} {
+		if err := repo.Create(f.ctx, reply); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	due := func(now time.Time) []*domain.OutboxReply {
+		replies, err := repo.GetDue(f.ctx, now, 100)
+		if err != nil {
+			t.Fatalf("GetDue failed: %v", err)
+		}
+		var ours []*domain.OutboxReply
+		for _, reply := range replies {
+			if reply.UserID == f.userID {
+				ours = append(ours, reply)
+			}
+		}
+		return ours
+	}
+	replies := due(second)
+	if len(replies) != 2 || replies[0].ID != sooner.ID || replies[1].ID != later.ID {
+		t.Fatalf("expected both pending replies, oldest attempt first, got %d", len(replies))
+	}
+	if replies[0].Metadata != sooner.Metadata || replies[0].Text != sooner.Text || replies[0].Push {
+		t.Errorf("round trip changed the reply: %+v", replies[0])
+	}
+	if replies := due(first); len(replies) != 1 || replies[0].ID != sooner.ID {
+		t.Errorf("expected only the reply due at the first attempt, got %d", len(replies))
+	}
+
+	sooner.Status, sooner.Attempts, sooner.LastError, sooner.NextAttemptAt = domain.OutboxReplyFailed, 5, "blocked by user", nil
+	if err := repo.Update(f.ctx, sooner); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if replies := due(second); len(replies) != 1 || replies[0].ID != later.ID {
+		t.Errorf("expected only the pending reply after the failure, got %d", len(replies))
+	}
+
+	// Only finished replies are deleted
+	deleted, err := repo.DeleteFinishedBefore(f.ctx, longAgo.Add(time.Second))
+	if err != nil {
+		t.Fatalf("DeleteFinishedBefore failed: %v", err)
+	}
+	if deleted < 2 {
+		t.Errorf("expected DeleteFinishedBefore to remove the delivered and failed replies, got %d", deleted)
+	}
+	if replies := due(second); len(replies) != 1 || replies[0].ID != later.ID {
+		t.Errorf("DeleteFinishedBefore removed the pending reply")
+	}
+
+	later.Status, later.NextAttemptAt = domain.OutboxReplyDelivered, nil
+	repo.Update(f.ctx, later)
+	repo.DeleteFinishedBefore(f.ctx, longAgo.Add(time.Second))
+}
+
+func (f fixture) notificationPreferences(t *testing.T, repo domain.NotificationPreferencesRepository) {
+	userID := "prefs_" + f.suffix
+	if _, err := repo.GetByUserID(f.ctx, userID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByUserID of a user with the defaults: expected ErrNotFound, got %v", err)
+	}
+
+	digestAt := time.Date(2024, 3, 15, 8, 0, 0, 0, time.UTC)
+	prefs := &domain.NotificationPreferences{
+		UserID:              userID,
+		BudgetAlerts:        true,
+		ReportNotifications: true,
+		DailyDigest:         true,
+		DailyDigestHour:     7,
+		WeeklyReport:        true,
+		ExpenseReminderDays: 3,
+		QuietHoursStart:     22,
+		QuietHoursEnd:       7,
+		AnomalySensitivity:  domain.AnomalySensitivityHigh,
+		Channels:            map[string]string{domain.NotificationBudgetAlerts: domain.NotificationChannelInApp},
+		LastDailyDigestAt:   &digestAt,
+		UpdatedAt:           digestAt,
+	}
+	if err := repo.Save(f.ctx, prefs); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, err := repo.GetByUserID(f.ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByUserID failed: %v", err)
+	}
+	if !saved.DailyDigest || saved.DailyDigestHour != 7 || saved.QuietHoursStart != 22 || saved.QuietHoursEnd != 7 || saved.ExpenseReminders || saved.AnomalySensitivity != domain.AnomalySensitivityHigh {
+		t.Errorf("round trip changed the preferences: %+v", saved)
+	}
+	if saved.Channels[domain.NotificationBudgetAlerts] != domain.NotificationChannelInApp || saved.LastDailyDigestAt == nil || !saved.LastDailyDigestAt.Equal(digestAt) {
+		t.Errorf("round trip changed the channels or digest time: %v, %v", saved.Channels, saved.LastDailyDigestAt)
+	}
+
+	subscribed := func(notification string) bool {
+		list, err := repo.ListSubscribed(f.ctx, notification)
+		if err != nil {
+			t.Fatalf("ListSubscribed failed: %v", err)
+		}
+		for _, prefs := range list {
+			if prefs.UserID == userID {
+				return true
+			}
+		}
+		return false
+	}
+	if !subscribed(domain.NotificationDailyDigest) || subscribed(domain.NotificationExpenseReminders) {
+		t.Error("expected the user subscribed to the daily digest only")
+	}
+
+	// Save replaces the stored preferences
+	prefs.DailyDigest, prefs.ExpenseReminders = false, true
+	if err := repo.Save(f.ctx, prefs); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if subscribed(domain.NotificationDailyDigest) || !subscribed(domain.NotificationExpenseReminders) {
+		t.Error("expected the user subscribed to expense reminders only after the update")
+	}
+}
+
+func (f fixture) conversationState(t *testing.T, repo domain.ConversationStateRepository) {
+	userID := "conversation_" + f.suffix
+	if _, err := repo.GetByUserID(f.ctx, userID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByUserID of a user without a state: expected ErrNotFound, got %v", err)
+	}
+
+	expires := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	state := &domain.ConversationState{
+		UserID:    userID,
+		Intent:    domain.MessageActionAddCategory,
+		Awaiting:  "name",
+		Slots:     map[string]string{"expense_id": "exp_1"},
+		ExpiresAt: expires,
+		UpdatedAt: expires.Add(-time.Hour),
+	}
+	if err := repo.Save(f.ctx, state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, err := repo.GetByUserID(f.ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByUserID failed: %v", err)
+	}
+	if saved.Awaiting != "name" || saved.Slots["expense_id"] != "exp_1" || !saved.ExpiresAt.Equal(expires) {
+		t.Errorf("round trip changed the state: %+v", saved)
+	}
+
+	// Save replaces the state, and an expired state is returned until deleted
+	state.Awaiting, state.ExpiresAt = "amount", longAgo
+	if err := repo.Save(f.ctx, state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if saved, err := repo.GetByUserID(f.ctx, userID); err != nil || saved.Awaiting != "amount" {
+		t.Errorf("expected the replaced, expired state, got %+v, %v", saved, err)
+	}
+	deleted, err := repo.DeleteExpiredBefore(f.ctx, longAgo.Add(time.Second))
+	if err != nil || deleted < 1 {
+		t.Errorf("expected DeleteExpiredBefore to remove the state, got %d, %v", deleted, err)
+	}
+	if _, err := repo.GetByUserID(f.ctx, userID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByUserID after DeleteExpiredBefore: expected ErrNotFound, got %v", err)
+	}
+
+	repo.Save(f.ctx, state)
+	if err := repo.Delete(f.ctx, userID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByUserID(f.ctx, userID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByUserID after Delete: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.Delete(f.ctx, userID); err != nil {
+		t.Errorf("Delete of a missing state: expected nil, got %v", err)
+	}
+}
+
+func (f fixture) reportSchedule(t *testing.T, repo domain.ReportScheduleRepository) {
+	userID := "schedule_" + f.suffix
+	if _, err := repo.GetByUserID(f.ctx, userID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByUserID of a user without a schedule: expected ErrNotFound, got %v", err)
+	}
+
+	schedule := &domain.ReportSchedule{
+		ID:        "schedule_" + f.suffix,
+		UserID:    userID,
+		Email:     "reports@example.com",
+		Frequency: domain.ReportFrequencyWeekly,
+		Format:    "csv",
+		Enabled:   true,
+		NextRunAt: longAgo,
+		CreatedAt: longAgo,
+		UpdatedAt: longAgo,
+	}
+	if err := repo.Save(f.ctx, schedule); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, err := repo.GetByUserID(f.ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByUserID failed: %v", err)
+	}
+	if saved.ID != schedule.ID || saved.Email != schedule.Email || saved.Frequency != domain.ReportFrequencyWeekly || !saved.NextRunAt.Equal(longAgo) || saved.LastSentAt != nil {
+		t.Errorf("round trip changed the schedule: %+v", saved)
+	}
+
+	isDue := func() bool {
+		due, err := repo.GetDue(f.ctx, longAgo.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("GetDue failed: %v", err)
+		}
+		for _, schedule := range due {
+			if schedule.UserID == userID {
+				return true
+			}
+		}
+		return false
+	}
+	if !isDue() {
+		t.Error("expected the schedule to be due")
+	}
+
+	// Save replaces the user's schedule, and disabled schedules are never due
+	sent := longAgo.Add(time.Minute)
+	schedule.Frequency, schedule.Enabled, schedule.LastSentAt = domain.ReportFrequencyMonthly, false, &sent
+	if err := repo.Save(f.ctx, schedule); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if saved, _ := repo.GetByUserID(f.ctx, userID); saved == nil || saved.Frequency != domain.ReportFrequencyMonthly || saved.Enabled || saved.LastSentAt == nil {
+		t.Errorf("Save did not replace the schedule: %+v", saved)
+	}
+	if isDue() {
+		t.Error("expected a disabled schedule not to be due")
+	}
+}
+
+func (f fixture) emailAddress(t *testing.T, repo domain.EmailAddressRepository) {
+	userID := "email_" + f.suffix
+	codeExpires := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	address := &domain.EmailAddress{
+		Address:       "contract-" + f.suffix + "@example.com",
+		UserID:        userID,
+		CodeHash:      "code_hash",
+		CodeExpiresAt: &codeExpires,
+		CreatedAt:     codeExpires.Add(-time.Hour),
+	}
+	if err := repo.Save(f.ctx, address); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved, err := repo.GetByAddress(f.ctx, address.Address)
+	if err != nil {
+		t.Fatalf("GetByAddress failed: %v", err)
+	}
+	if saved.UserID != userID || saved.CodeHash != "code_hash" || saved.CodeExpiresAt == nil || !saved.CodeExpiresAt.Equal(codeExpires) || saved.VerifiedAt != nil {
+		t.Errorf("round trip changed the address: %+v", saved)
+	}
+	if _, err := repo.GetByAddress(f.ctx, "missing-"+f.suffix+"@example.com"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByAddress of a missing address: expected ErrNotFound, got %v", err)
+	}
+
+	// Save replaces the address, e.g. once it is verified
+	verified := codeExpires.Add(-30 * time.Minute)
+	address.CodeHash, address.CodeExpiresAt, address.Attempts, address.VerifiedAt = "", nil, 1, &verified
+	if err := repo.Save(f.ctx, address); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	addresses, err := repo.GetByUserID(f.ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByUserID failed: %v", err)
+	}
+	if len(addresses) != 1 || addresses[0].VerifiedAt == nil || addresses[0].CodeExpiresAt != nil || addresses[0].Attempts != 1 {
+		t.Errorf("expected the one verified address, got %d", len(addresses))
+	}
+
+	if err := repo.Delete(f.ctx, address.Address); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByAddress(f.ctx, address.Address); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByAddress of a deleted address: expected ErrNotFound, got %v", err)
+	}
+}
+
+func (f fixture) shortLink(t *testing.T, repo domain.ShortLinkRepository) {
+	now := time.Now().UTC().Truncate(time.Second)
+	link := &domain.ShortLink{ID: "s" + f.suffix, TargetToken: "report-token", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+	expired := &domain.ShortLink{ID: "x" + f.suffix, TargetToken: "old-token", ExpiresAt: now.Add(-time.Hour), CreatedAt: now.Add(-2 * time.Hour)}
+	for _, l := range []*domain.ShortLink{link, expired} {
+		if err := repo.Create(f.ctx, l); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	retrieved, err := repo.Get(f.ctx, link.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if retrieved.TargetToken != "report-token" || !retrieved.ExpiresAt.Equal(link.ExpiresAt) {
+		t.Errorf("round trip changed the link: %+v", retrieved)
+	}
+
+	// An expired link is as good as a missing one
+	if _, err := repo.Get(f.ctx, expired.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Get of an expired link: expected ErrNotFound, got %v", err)
+	}
+	if _, err := repo.Get(f.ctx, "m"+f.suffix); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Get of a missing link: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.DeleteExpired(f.ctx); err != nil {
+		t.Fatalf("DeleteExpired failed: %v", err)
+	}
+	if _, err := repo.Get(f.ctx, link.ID); err != nil {
+		t.Errorf("DeleteExpired removed a live link: %v", err)
+	}
+}
+
+func (f fixture) pricing(t *testing.T, repo domain.PricingRepository) {
+	provider := "contract_" + f.suffix
+	effective := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	config := &domain.PricingConfig{
+		ID:               "pricing_" + f.suffix,
+		Provider:         provider,
+		Model:            "model-small",
+		InputTokenPrice:  0.0001,
+		OutputTokenPrice: 0.0004,
+		Currency:         "USD",
+		EffectiveDate:    effective,
+		IsActive:         true,
+		CreatedAt:        effective,
+		UpdatedAt:        effective,
+	}
+	if err := repo.Create(f.ctx, config); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	retrieved, err := repo.GetByProviderAndModel(f.ctx, provider, "model-small")
+	if err != nil {
+		t.Fatalf("GetByProviderAndModel failed: %v", err)
+	}
+	if retrieved.ID != config.ID || retrieved.InputTokenPrice != 0.0001 || retrieved.Currency != "USD" || !retrieved.IsActive {
+		t.Errorf("round trip changed the pricing: %+v", retrieved)
+	}
+	if _, err := repo.GetByProviderAndModel(f.ctx, provider, "model-missing"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByProviderAndModel of a missing model: expected ErrNotFound, got %v", err)
+	}
+	if _, err := repo.GetByID(f.ctx, "missing_"+f.suffix); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByID of a missing pricing: expected ErrNotFound, got %v", err)
+	}
+
+	config.OutputTokenPrice = 0.0005
+	if err := repo.Update(f.ctx, config); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if updated, _ := repo.GetByID(f.ctx, config.ID); updated == nil || updated.OutputTokenPrice != 0.0005 {
+		t.Errorf("Update was not persisted: %+v", updated)
+	}
+
+	listed := func() bool {
+		all, err := repo.GetAll(f.ctx)
+		if err != nil {
+			t.Fatalf("GetAll failed: %v", err)
+		}
+		for _, c := range all {
+			if c.ID == config.ID {
+				return true
+			}
+		}
+		return false
+	}
+	if !listed() {
+		t.Error("expected GetAll to include the active pricing")
+	}
+
+	// A deactivated pricing is only found by ID
+	if err := repo.Deactivate(f.ctx, provider, "model-small"); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+	if _, err := repo.GetByProviderAndModel(f.ctx, provider, "model-small"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByProviderAndModel of a deactivated pricing: expected ErrNotFound, got %v", err)
+	}
+	if deactivated, err := repo.GetByID(f.ctx, config.ID); err != nil || deactivated.IsActive {
+		t.Errorf("expected GetByID to return the inactive pricing, got %+v, %v", deactivated, err)
+	}
+	if listed() {
+		t.Error("expected GetAll to leave out the deactivated pricing")
+	}
+}
+
+func (f fixture) apiKey(t *testing.T, repo domain.APIKeyRepository) {
+	created := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	expires := created.AddDate(1, 0, 0)
+	key := &domain.APIKey{
+		ID:        "key_" + f.suffix,
+		Name:      "Dashboard",
+		Prefix:    "aiex_" + f.suffix[:4],
+		KeyHash:   "hash_" + f.suffix,
+		Scopes:    []string{domain.APIKeyScopeMetricsRead, domain.APIKeyScopeInteractionsRead},
+		ExpiresAt: &expires,
+		CreatedAt: created,
+	}
+	if err := repo.Create(f.ctx, key); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	retrieved, err := repo.GetByHash(f.ctx, key.KeyHash)
+	if err != nil {
+		t.Fatalf("GetByHash failed: %v", err)
+	}
+	if retrieved.ID != key.ID || len(retrieved.Scopes) != 2 || retrieved.Scopes[1] != domain.APIKeyScopeInteractionsRead || retrieved.ExpiresAt == nil || !retrieved.ExpiresAt.Equal(expires) || retrieved.LastUsedAt != nil {
+		t.Errorf("round trip changed the key: %+v", retrieved)
+	}
+	if _, err := repo.GetByHash(f.ctx, "missing_"+f.suffix); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByHash of a missing key: expected ErrNotFound, got %v", err)
+	}
+
+	usedAt := created.Add(time.Hour)
+	if err := repo.UpdateLastUsed(f.ctx, key.ID, usedAt); err != nil {
+		t.Fatalf("UpdateLastUsed failed: %v", err)
+	}
+	keys, err := repo.List(f.ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var listed *domain.APIKey
+	for _, k := range keys {
+		if k.ID == key.ID {
+			listed = k
+		}
+	}
+	if listed == nil || listed.LastUsedAt == nil || !listed.LastUsedAt.Equal(usedAt) {
+		t.Errorf("expected List to include the key with its last use, got %+v", listed)
+	}
+
+	if err := repo.Delete(f.ctx, key.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByHash(f.ctx, key.KeyHash); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("GetByHash of a revoked key: expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.AICostAnomalyRepository = (*AICostAnomalyRepository)(nil)
+
+// AICostAnomalyRepository stores detected AI cost anomalies in SQLite
+type AICostAnomalyRepository struct {
+	db *sql.DB
+}
+
+// NewAICostAnomalyRepository creates a new AI cost anomaly repository
+func NewAICostAnomalyRepository(db *sql.DB) *AICostAnomalyRepository {
+	return &AICostAnomalyRepository{db: db}
+}
+
+const aiCostAnomalyColumns = `id, anomaly_date, cost, calls, baseline_cost, ratio, detected_at, updated_at`
+
+func scanAICostAnomaly(scanner interface {
+	Scan(dest ...interface{}) error
+}) (*domain.AICostAnomaly, error) {
+	anomaly := &domain.AICostAnomaly{}
+	err := scanner.Scan(
+		&anomaly.ID,
+		&anomaly.Date,
+		&anomaly.Cost,
+		&anomaly.Calls,
+		&anomaly.BaselineCost,
+		&anomaly.Ratio,
+		&anomaly.DetectedAt,
+		&anomaly.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return anomaly, nil
+}
+
+// GetByDate retrieves the anomaly of a UTC day, or ErrNotFound
+func (r *AICostAnomalyRepository) GetByDate(ctx context.Context, date time.Time) (*domain.AICostAnomaly, error) {
+	const query = `SELECT ` + aiCostAnomalyColumns + ` FROM ai_cost_anomalies WHERE anomaly_date = ?`
+	anomaly, err := scanAICostAnomaly(txOrDB(ctx, r.db).QueryRowContext(ctx, query, domain.MetricsDay(date).Format("2006-01-02")))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return anomaly, nil
+}
+
+// Create stores a new anomaly
+func (r *AICostAnomalyRepository) Create(ctx context.Context, anomaly *domain.AICostAnomaly) error {
+	const query = `
+		INSERT INTO ai_cost_anomalies (` + aiCostAnomalyColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		anomaly.ID,
+		domain.MetricsDay(anomaly.Date).Format("2006-01-02"),
+		anomaly.Cost,
+		anomaly.Calls,
+		anomaly.BaselineCost,
+		anomaly.Ratio,
+		anomaly.DetectedAt,
+		anomaly.UpdatedAt,
+	)
+	return err
+}
+
+// Update saves an anomaly's latest cost and ratio
+func (r *AICostAnomalyRepository) Update(ctx context.Context, anomaly *domain.AICostAnomaly) error {
+	const query = `
+		UPDATE ai_cost_anomalies
+		SET cost = ?, calls = ?, baseline_cost = ?, ratio = ?, updated_at = ?
+		WHERE id = ?
+	`
+	result, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		anomaly.Cost,
+		anomaly.Calls,
+		anomaly.BaselineCost,
+		anomaly.Ratio,
+		anomaly.UpdatedAt,
+		anomaly.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// List retrieves the latest anomalies, newest day first
+func (r *AICostAnomalyRepository) List(ctx context.Context, limit int) ([]*domain.AICostAnomaly, error) {
+	const query = `SELECT ` + aiCostAnomalyColumns + ` FROM ai_cost_anomalies ORDER BY anomaly_date DESC LIMIT ?`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var anomalies []*domain.AICostAnomaly
+	for rows.Next() {
+		anomaly, err := scanAICostAnomaly(rows)
+		if err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+	return anomalies, rows.Err()
+}
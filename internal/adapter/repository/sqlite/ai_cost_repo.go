@@ -23,13 +23,13 @@ func (r *AICostRepository) Create(ctx context.Context, log *domain.AICostLog) er
 		INSERT INTO ai_cost_logs (
 			id, user_id, operation, provider, model,
 			input_tokens, output_tokens, total_tokens,
-			cost, currency, cost_note, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			cost, currency, cost_note, prompt_version, source, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		log.ID, log.UserID, log.Operation, log.Provider, log.Model,
 		log.InputTokens, log.OutputTokens, log.TotalTokens,
-		log.Cost, log.Currency, log.CostNote, log.CreatedAt,
+		log.Cost, log.Currency, log.CostNote, log.PromptVersion, log.Source, log.CreatedAt,
 	)
 	return err
 }
@@ -39,13 +39,13 @@ func (r *AICostRepository) GetByUserID(ctx context.Context, userID string, limit
 		SELECT
 			id, user_id, operation, provider, model,
 			input_tokens, output_tokens, total_tokens,
-			cost, currency, cost_note, created_at
+			cost, currency, cost_note, prompt_version, source, created_at
 		FROM ai_cost_logs
 		WHERE user_id = ?
 		ORDER BY created_at DESC
 		LIMIT ?
 	`
-	rows, err := r.db.QueryContext(ctx, query, userID, limit)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, userID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +57,7 @@ func (r *AICostRepository) GetByUserID(ctx context.Context, userID string, limit
 		err := rows.Scan(
 			&log.ID, &log.UserID, &log.Operation, &log.Provider, &log.Model,
 			&log.InputTokens, &log.OutputTokens, &log.TotalTokens,
-			&log.Cost, &log.Currency, &log.CostNote, &log.CreatedAt,
+			&log.Cost, &log.Currency, &log.CostNote, &log.PromptVersion, &log.Source, &log.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -79,7 +79,7 @@ func (r *AICostRepository) GetSummary(ctx context.Context, from, to time.Time) (
 		WHERE created_at >= ? AND created_at <= ?
 	`
 	summary := &domain.AICostSummary{Currency: "USD"}
-	err := r.db.QueryRowContext(ctx, query, from, to).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, from, to).Scan(
 		&summary.TotalCalls,
 		&summary.TotalInputTokens,
 		&summary.TotalOutputTokens,
@@ -106,7 +106,7 @@ func (r *AICostRepository) GetDailyStats(ctx context.Context, from, to time.Time
 		GROUP BY DATE(created_at)
 		ORDER BY date ASC
 	`
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +140,7 @@ func (r *AICostRepository) GetByOperation(ctx context.Context, from, to time.Tim
 		GROUP BY operation
 		ORDER BY total_tokens DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -184,7 +184,7 @@ func (r *AICostRepository) GetByUserSummary(ctx context.Context, from, to time.T
 		ORDER BY total_tokens DESC
 		LIMIT ?
 	`
-	rows, err := r.db.QueryContext(ctx, query, from, to, limit)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -201,3 +201,29 @@ func (r *AICostRepository) GetByUserSummary(ctx context.Context, from, to time.T
 	}
 	return results, rows.Err()
 }
+
+// GetUserSummary retrieves aggregated AI cost metrics for one user in a date range
+func (r *AICostRepository) GetUserSummary(ctx context.Context, userID string, from, to time.Time) (*domain.AICostSummary, error) {
+	const query = `
+		SELECT
+			COUNT(*) as total_calls,
+			COALESCE(SUM(input_tokens), 0) as total_input_tokens,
+			COALESCE(SUM(output_tokens), 0) as total_output_tokens,
+			COALESCE(SUM(total_tokens), 0) as total_tokens,
+			COALESCE(SUM(cost), 0) as total_cost
+		FROM ai_cost_logs
+		WHERE user_id = ? AND created_at >= ? AND created_at <= ?
+	`
+	summary := &domain.AICostSummary{Currency: "USD"}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, userID, from, to).Scan(
+		&summary.TotalCalls,
+		&summary.TotalInputTokens,
+		&summary.TotalOutputTokens,
+		&summary.TotalTokens,
+		&summary.TotalCost,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -57,6 +57,7 @@ func TestSQLiteAICostRepository(t *testing.T) {
 			TotalTokens:  150,
 			Cost:         0.00015,
 			Currency:     "USD",
+			Source:       "line",
 			CreatedAt:    time.Now(),
 		}
 
@@ -78,8 +79,75 @@ func TestSQLiteAICostRepository(t *testing.T) {
 		if retrieved.ID != "log_001" {
 			t.Errorf("Expected log ID 'log_001', got '%s'", retrieved.ID)
 		}
+		if retrieved.Source != "line" {
+			t.Errorf("Expected source 'line', got '%s'", retrieved.Source)
+		}
 		if retrieved.Cost != 0.00015 {
 			t.Errorf("Expected cost 0.00015, got %f", retrieved.Cost)
 		}
 	})
 }
+
+// TestSQLiteAICostAnomalyRepository integration tests
+func TestSQLiteAICostAnomalyRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	repo := NewAICostAnomalyRepository(db)
+	ctx := context.Background()
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	if _, err := repo.GetByDate(ctx, day); err != domain.ErrNotFound {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	now := time.Now()
+	for i, date := range []time.Time{day.AddDate(0, 0, -1), day} {
+		anomaly := &domain.AICostAnomaly{
+			ID:           "anomaly_" + date.Format("0102"),
+			Date:         date,
+			Cost:         3.5,
+			Calls:        40 + i,
+			BaselineCost: 1.1,
+			Ratio:        3.18,
+			DetectedAt:   now,
+			UpdatedAt:    now,
+		}
+		if err := repo.Create(ctx, anomaly); err != nil {
+			t.Fatalf("Failed to create anomaly: %v", err)
+		}
+	}
+
+	anomaly, err := repo.GetByDate(ctx, day.Add(15*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to get anomaly: %v", err)
+	}
+	if anomaly.Calls != 41 || !anomaly.Date.Equal(day) {
+		t.Errorf("Unexpected anomaly: %+v", anomaly)
+	}
+
+	anomaly.Cost = 5
+	anomaly.Ratio = 4.55
+	if err := repo.Update(ctx, anomaly); err != nil {
+		t.Fatalf("Failed to update anomaly: %v", err)
+	}
+
+	anomalies, err := repo.List(ctx, 10)
+	if err != nil {
+		t.Fatalf("Failed to list anomalies: %v", err)
+	}
+	if len(anomalies) != 2 || anomalies[0].ID != anomaly.ID || anomalies[0].Cost != 5 {
+		t.Errorf("Expected the updated anomaly first, got %+v", anomalies)
+	}
+}
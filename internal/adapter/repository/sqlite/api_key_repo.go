@@ -0,0 +1,109 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.APIKeyRepository = (*APIKeyRepository)(nil)
+
+// APIKeyRepository stores admin API keys in SQLite
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, expires_at, last_used_at, created_at`
+
+// Create stores a new key
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO api_keys (` + apiKeyColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		string(scopes),
+		key.ExpiresAt,
+		key.LastUsedAt,
+		key.CreatedAt,
+	)
+	return err
+}
+
+// GetByHash retrieves the key with the given hash, or nil if there is none
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	const query = `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+	keys, err := r.query(ctx, query, keyHash)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return keys[0], nil
+}
+
+// List retrieves all keys, oldest first
+func (r *APIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	const query = `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at ASC`
+	return r.query(ctx, query)
+}
+
+// Delete revokes a key
+func (r *APIKeyRepository) Delete(ctx context.Context, id string) error {
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, `DELETE FROM api_keys WHERE id = ?`, id)
+	return err
+}
+
+// UpdateLastUsed records when a key was last used
+func (r *APIKeyRepository) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, usedAt, id)
+	return err
+}
+
+func (r *APIKeyRepository) query(ctx context.Context, query string, args ...interface{}) ([]*domain.APIKey, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		key := &domain.APIKey{}
+		var scopes string
+		if err := rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			&scopes,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+			&key.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ArchivePolicyRepository = (*ArchivePolicyRepository)(nil)
+
+// ArchivePolicyRepository stores per-user archive policies in SQLite
+type ArchivePolicyRepository struct {
+	db *sql.DB
+}
+
+// NewArchivePolicyRepository creates a new archive policy repository
+func NewArchivePolicyRepository(db *sql.DB) *ArchivePolicyRepository {
+	return &ArchivePolicyRepository{db: db}
+}
+
+const archivePolicyColumns = `user_id, archive_after_months, purge_after_days, updated_at`
+
+// GetByUserID retrieves the user's policy
+func (r *ArchivePolicyRepository) GetByUserID(ctx context.Context, userID string) (*domain.ArchivePolicy, error) {
+	const query = `SELECT ` + archivePolicyColumns + ` FROM archive_policies WHERE user_id = ?`
+	policies, err := r.query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return policies[0], nil
+}
+
+// Save creates the user's policy or replaces it
+func (r *ArchivePolicyRepository) Save(ctx context.Context, policy *domain.ArchivePolicy) error {
+	const query = `
+		INSERT INTO archive_policies (` + archivePolicyColumns + `)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			archive_after_months = excluded.archive_after_months,
+			purge_after_days = excluded.purge_after_days,
+			updated_at = excluded.updated_at
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		policy.UserID,
+		policy.ArchiveAfterMonths,
+		policy.PurgeAfterDays,
+		policy.UpdatedAt,
+	)
+	return err
+}
+
+// Delete removes the user's policy
+func (r *ArchivePolicyRepository) Delete(ctx context.Context, userID string) error {
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, `DELETE FROM archive_policies WHERE user_id = ?`, userID)
+	return err
+}
+
+// List retrieves every per-user policy
+func (r *ArchivePolicyRepository) List(ctx context.Context) ([]*domain.ArchivePolicy, error) {
+	const query = `SELECT ` + archivePolicyColumns + ` FROM archive_policies ORDER BY user_id`
+	return r.query(ctx, query)
+}
+
+// ListUsersWithExpensesBefore retrieves the IDs of users with live expenses dated before the given time
+func (r *ArchivePolicyRepository) ListUsersWithExpensesBefore(ctx context.Context, before time.Time) ([]string, error) {
+	const query = `
+		SELECT DISTINCT user_id FROM expenses
+		WHERE expense_date < ? AND deleted_at IS NULL
+		ORDER BY user_id
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func (r *ArchivePolicyRepository) query(ctx context.Context, query string, args ...interface{}) ([]*domain.ArchivePolicy, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []*domain.ArchivePolicy
+	for rows.Next() {
+		policy := &domain.ArchivePolicy{}
+		if err := rows.Scan(
+			&policy.UserID,
+			&policy.ArchiveAfterMonths,
+			&policy.PurgeAfterDays,
+			&policy.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
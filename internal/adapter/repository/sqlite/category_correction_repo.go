@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.CategoryCorrectionRepository = (*CategoryCorrectionRepository)(nil)
+
+// CategoryCorrectionRepository stores users' category corrections in SQLite
+type CategoryCorrectionRepository struct {
+	db     *sql.DB
+	cipher fieldCipher
+}
+
+// NewCategoryCorrectionRepository creates a new category correction repository
+func NewCategoryCorrectionRepository(db *sql.DB) *CategoryCorrectionRepository {
+	return &CategoryCorrectionRepository{db: db}
+}
+
+// SetCipher encrypts correction descriptions at rest
+func (r *CategoryCorrectionRepository) SetCipher(cipher domain.FieldCipher) {
+	r.cipher = fieldCipher{cipher: cipher}
+}
+
+// Create records a category correction
+func (r *CategoryCorrectionRepository) Create(ctx context.Context, correction *domain.CategoryCorrection) error {
+	const query = `
+		INSERT INTO category_corrections (id, user_id, expense_id, description, from_category_id, to_category_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	description, err := r.cipher.encrypt(correction.Description)
+	if err != nil {
+		return err
+	}
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		correction.ID,
+		correction.UserID,
+		correction.ExpenseID,
+		description,
+		correction.FromCategoryID,
+		correction.ToCategoryID,
+		correction.CreatedAt,
+	)
+	return err
+}
+
+// GetRecentByUserID retrieves a user's latest corrections, newest first
+func (r *CategoryCorrectionRepository) GetRecentByUserID(ctx context.Context, userID string, limit int) ([]*domain.CategoryCorrection, error) {
+	const query = `
+		SELECT id, user_id, expense_id, description, from_category_id, to_category_id, created_at
+		FROM category_corrections
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var corrections []*domain.CategoryCorrection
+	for rows.Next() {
+		correction := &domain.CategoryCorrection{}
+		if err := rows.Scan(
+			&correction.ID,
+			&correction.UserID,
+			&correction.ExpenseID,
+			&correction.Description,
+			&correction.FromCategoryID,
+			&correction.ToCategoryID,
+			&correction.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if correction.Description, err = r.cipher.decrypt(correction.Description); err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, correction)
+	}
+	return corrections, rows.Err()
+}
@@ -22,31 +22,32 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 // Create creates a new category
 func (r *CategoryRepository) Create(ctx context.Context, category *domain.Category) error {
 	const query = `
-		INSERT INTO categories (id, user_id, name, is_default, created_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO categories (id, user_id, name, is_default, workspace_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.db.ExecContext(ctx, query, category.ID, category.UserID, category.Name, category.IsDefault, category.CreatedAt)
-	return err
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, category.ID, category.UserID, category.Name, category.IsDefault, category.WorkspaceID, category.CreatedAt)
+	return conflictErr(err)
 }
 
 // GetByID retrieves a category by ID
 func (r *CategoryRepository) GetByID(ctx context.Context, id string) (*domain.Category, error) {
 	const query = `
-		SELECT id, user_id, name, is_default, created_at
+		SELECT id, user_id, name, is_default, workspace_id, created_at
 		FROM categories
 		WHERE id = ?
 	`
 	category := &domain.Category{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&category.ID,
 		&category.UserID,
 		&category.Name,
 		&category.IsDefault,
+		&category.WorkspaceID,
 		&category.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
@@ -55,13 +56,14 @@ func (r *CategoryRepository) GetByID(ctx context.Context, id string) (*domain.Ca
 
 // GetByUserID retrieves all categories for a user
 func (r *CategoryRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Category, error) {
-	const query = `
-		SELECT id, user_id, name, is_default, created_at
+	scope, args := workspaceScope(ctx, "workspace_id", []any{userID})
+	query := `
+		SELECT id, user_id, name, is_default, workspace_id, created_at
 		FROM categories
-		WHERE user_id = ?
+		WHERE user_id = ?` + scope + `
 		ORDER BY is_default DESC, name ASC
 	`
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +72,7 @@ func (r *CategoryRepository) GetByUserID(ctx context.Context, userID string) ([]
 	var categories []*domain.Category
 	for rows.Next() {
 		category := &domain.Category{}
-		if err := rows.Scan(&category.ID, &category.UserID, &category.Name, &category.IsDefault, &category.CreatedAt); err != nil {
+		if err := rows.Scan(&category.ID, &category.UserID, &category.Name, &category.IsDefault, &category.WorkspaceID, &category.CreatedAt); err != nil {
 			return nil, err
 		}
 		categories = append(categories, category)
@@ -78,24 +80,29 @@ func (r *CategoryRepository) GetByUserID(ctx context.Context, userID string) ([]
 	return categories, rows.Err()
 }
 
-// GetByUserIDAndName retrieves a category by user and name
+// GetByUserIDAndName retrieves a category by user and name, in the workspace
+// ctx carries or, with none, preferring the personal workspace's
 func (r *CategoryRepository) GetByUserIDAndName(ctx context.Context, userID, name string) (*domain.Category, error) {
-	const query = `
-		SELECT id, user_id, name, is_default, created_at
+	scope, args := workspaceScope(ctx, "workspace_id", []any{userID, name})
+	query := `
+		SELECT id, user_id, name, is_default, workspace_id, created_at
 		FROM categories
-		WHERE user_id = ? AND name = ?
+		WHERE user_id = ? AND name = ?` + scope + `
+		ORDER BY workspace_id
+		LIMIT 1
 	`
 	category := &domain.Category{}
-	err := r.db.QueryRowContext(ctx, query, userID, name).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(
 		&category.ID,
 		&category.UserID,
 		&category.Name,
 		&category.IsDefault,
+		&category.WorkspaceID,
 		&category.CreatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
@@ -109,14 +116,14 @@ func (r *CategoryRepository) Update(ctx context.Context, category *domain.Catego
 		SET name = ?, is_default = ?
 		WHERE id = ?
 	`
-	_, err := r.db.ExecContext(ctx, query, category.Name, category.IsDefault, category.ID)
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, category.Name, category.IsDefault, category.ID)
 	return err
 }
 
 // Delete deletes a category
 func (r *CategoryRepository) Delete(ctx context.Context, id string) error {
 	const query = `DELETE FROM categories WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, id)
 	return err
 }
 
@@ -126,8 +133,8 @@ func (r *CategoryRepository) CreateKeyword(ctx context.Context, keyword *domain.
 		INSERT INTO category_keywords (id, category_id, keyword, priority, created_at)
 		VALUES (?, ?, ?, ?, ?)
 	`
-	_, err := r.db.ExecContext(ctx, query, keyword.ID, keyword.CategoryID, keyword.Keyword, keyword.Priority, keyword.CreatedAt)
-	return err
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, keyword.ID, keyword.CategoryID, keyword.Keyword, keyword.Priority, keyword.CreatedAt)
+	return conflictErr(err)
 }
 
 // GetKeywordsByCategory retrieves keywords for a category
@@ -138,7 +145,7 @@ func (r *CategoryRepository) GetKeywordsByCategory(ctx context.Context, category
 		WHERE category_id = ?
 		ORDER BY priority DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query, categoryID)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, categoryID)
 	if err != nil {
 		return nil, err
 	}
@@ -155,9 +162,43 @@ func (r *CategoryRepository) GetKeywordsByCategory(ctx context.Context, category
 	return keywords, rows.Err()
 }
 
+// GetKeywordsByUserID retrieves keywords across all of a user's categories
+func (r *CategoryRepository) GetKeywordsByUserID(ctx context.Context, userID string) ([]*domain.CategoryKeyword, error) {
+	scope, args := workspaceScope(ctx, "c.workspace_id", []any{userID})
+	query := `
+		SELECT k.id, k.category_id, k.keyword, k.priority, k.created_at
+		FROM category_keywords k
+		JOIN categories c ON c.id = k.category_id
+		WHERE c.user_id = ?` + scope + `
+		ORDER BY k.priority DESC
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keywords []*domain.CategoryKeyword
+	for rows.Next() {
+		kw := &domain.CategoryKeyword{}
+		if err := rows.Scan(&kw.ID, &kw.CategoryID, &kw.Keyword, &kw.Priority, &kw.CreatedAt); err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, kw)
+	}
+	return keywords, rows.Err()
+}
+
+// UpdateKeyword updates a keyword mapping's priority
+func (r *CategoryRepository) UpdateKeyword(ctx context.Context, keyword *domain.CategoryKeyword) error {
+	const query = `UPDATE category_keywords SET priority = ? WHERE id = ?`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, keyword.Priority, keyword.ID)
+	return err
+}
+
 // DeleteKeyword deletes a keyword mapping
 func (r *CategoryRepository) DeleteKeyword(ctx context.Context, id string) error {
 	const query = `DELETE FROM category_keywords WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, id)
 	return err
 }
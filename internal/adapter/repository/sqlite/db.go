@@ -11,6 +11,22 @@ import (
 
 // OpenDB opens a SQLite database connection and runs migrations
 func OpenDB(dbPath string) (*sql.DB, error) {
+	db, err := Connect(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Run migrations using golang-migrate
+	if err := migrations.RunMigrations(db, "sqlite3"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return db, nil
+}
+
+// Connect opens a SQLite database connection without touching the schema
+func Connect(dbPath string) (*sql.DB, error) {
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -18,6 +34,7 @@ func OpenDB(dbPath string) (*sql.DB, error) {
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
@@ -31,14 +48,10 @@ func OpenDB(dbPath string) (*sql.DB, error) {
 
 	// Apply SQLite optimizations via pragmas
 	if err := optimizeSQLite(db); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to optimize SQLite: %w", err)
 	}
 
-	// Run migrations using golang-migrate
-	if err := migrations.RunMigrations(db, "sqlite3"); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
-	}
-
 	return db, nil
 }
 
@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.EmailAddressRepository = (*EmailAddressRepository)(nil)
+
+// EmailAddressRepository stores the email addresses bound to users in SQLite
+type EmailAddressRepository struct {
+	db *sql.DB
+}
+
+// NewEmailAddressRepository creates a new email address repository
+func NewEmailAddressRepository(db *sql.DB) *EmailAddressRepository {
+	return &EmailAddressRepository{db: db}
+}
+
+const emailAddressColumns = `address, user_id, code_hash, code_expires_at, attempts, verified_at, created_at`
+
+// Save creates the address or replaces the existing one
+func (r *EmailAddressRepository) Save(ctx context.Context, address *domain.EmailAddress) error {
+	const query = `
+		INSERT INTO email_addresses (` + emailAddressColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (address) DO UPDATE SET
+			user_id = excluded.user_id,
+			code_hash = excluded.code_hash,
+			code_expires_at = excluded.code_expires_at,
+			attempts = excluded.attempts,
+			verified_at = excluded.verified_at,
+			created_at = excluded.created_at
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		address.Address,
+		address.UserID,
+		address.CodeHash,
+		address.CodeExpiresAt,
+		address.Attempts,
+		address.VerifiedAt,
+		address.CreatedAt,
+	)
+	return err
+}
+
+// GetByAddress retrieves the address, or ErrNotFound if it isn't bound or being verified
+func (r *EmailAddressRepository) GetByAddress(ctx context.Context, address string) (*domain.EmailAddress, error) {
+	const query = `SELECT ` + emailAddressColumns + ` FROM email_addresses WHERE address = ?`
+	addresses, err := r.query(ctx, query, address)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return addresses[0], nil
+}
+
+// GetByUserID retrieves the user's addresses, oldest first
+func (r *EmailAddressRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.EmailAddress, error) {
+	const query = `SELECT ` + emailAddressColumns + ` FROM email_addresses WHERE user_id = ? ORDER BY created_at ASC`
+	return r.query(ctx, query, userID)
+}
+
+// Delete removes the address
+func (r *EmailAddressRepository) Delete(ctx context.Context, address string) error {
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, `DELETE FROM email_addresses WHERE address = ?`, address)
+	return err
+}
+
+func (r *EmailAddressRepository) query(ctx context.Context, query string, args ...interface{}) ([]*domain.EmailAddress, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []*domain.EmailAddress
+	for rows.Next() {
+		address := &domain.EmailAddress{}
+		if err := rows.Scan(
+			&address.Address,
+			&address.UserID,
+			&address.CodeHash,
+			&address.CodeExpiresAt,
+			&address.Attempts,
+			&address.VerifiedAt,
+			&address.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, rows.Err()
+}
@@ -0,0 +1,19 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// conflictErr maps a unique or primary key violation to domain.ErrConflict
+func conflictErr(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) &&
+		(sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey) {
+		return fmt.Errorf("%w: %v", domain.ErrConflict, err)
+	}
+	return err
+}
@@ -0,0 +1,225 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.ExpenseSearchRepository = (*ExpenseRepository)(nil)
+
+// maxSearchAlternatives caps how many indexed words stand in for a misspelled term
+const maxSearchAlternatives = 10
+
+// searchPhrase is one phrase of an FTS query and how much a match on it counts
+type searchPhrase struct {
+	text   string
+	weight float64
+}
+
+// Search matches descriptions against the expenses_fts index: each term as a
+// word prefix, as an indexed word within domain.SearchTermMaxEdits of it, or
+// as a substring, which finds words inside CJK runs the tokenizer keeps whole.
+// Matches are ranked by BM25 over the index, substring-only matches last.
+// Terms are only letters and digits, so they need no quoting in FTS queries or
+// LIKE patterns. Encrypted descriptions can't be searched.
+func (r *ExpenseRepository) Search(ctx context.Context, query *domain.ExpenseSearchQuery) ([]*domain.ExpenseSearchHit, int, error) {
+	if r.cipher.cipher != nil {
+		return nil, 0, errors.New("full-text search is unavailable while descriptions are encrypted")
+	}
+
+	// Each term's phrases, and all of them for ranking
+	var ranked []searchPhrase
+	scope, args := workspaceScope(ctx, "e.workspace_id", []any{query.UserID, query.StartDate, query.EndDate})
+	where := []string{"e.user_id = ?", "e.deleted_at IS NULL", "e.expense_date >= ?", "e.expense_date <= ?" + scope}
+	for _, term := range query.Terms {
+		phrases, err := r.searchPhrases(ctx, term)
+		if err != nil {
+			return nil, 0, err
+		}
+		ranked = append(ranked, phrases...)
+		where = append(where, `(e.id IN (
+			SELECT d.expense_id FROM expenses_fts JOIN expense_search_docs d ON d.docid = expenses_fts.docid
+			WHERE expenses_fts MATCH ?) OR e.description LIKE ?)`)
+		args = append(args, matchExpression(phrases), "%"+term+"%")
+	}
+	if query.CategoryID != nil {
+		where = append(where, "e.category_id = ?")
+		args = append(args, *query.CategoryID)
+	}
+	if query.MinAmount != nil {
+		where = append(where, "e.home_amount >= ?")
+		args = append(args, *query.MinAmount)
+	}
+	if query.MaxAmount != nil {
+		where = append(where, "e.home_amount <= ?")
+		args = append(args, *query.MaxAmount)
+	}
+	for _, tag := range query.Tags {
+		where = append(where, "EXISTS (SELECT 1 FROM expense_tags et JOIN tags t ON t.id = et.tag_id WHERE et.expense_id = e.id AND t.name = ?)")
+		args = append(args, tag)
+	}
+
+	rank := "NULL"
+	join := ""
+	if len(ranked) > 0 {
+		rank = "m.info"
+		join = `LEFT JOIN (
+			SELECT d.expense_id, matchinfo(expenses_fts, 'pcnx') AS info
+			FROM expenses_fts JOIN expense_search_docs d ON d.docid = expenses_fts.docid
+			WHERE expenses_fts MATCH ?
+		) m ON m.expense_id = e.id`
+		args = append([]any{matchExpression(ranked)}, args...)
+	}
+
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, `
+		SELECT e.id, e.user_id, e.description, e.original_amount, e.currency, e.home_amount, e.home_currency, e.exchange_rate, e.category_id, e.group_id, e.account, e.merchant_id, e.is_reimbursable, e.claim_status, e.workspace_id, e.expense_date, e.created_at, e.updated_at, `+rank+`
+		FROM expenses e `+join+`
+		WHERE `+strings.Join(where, " AND "), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var hits []*domain.ExpenseSearchHit
+	for rows.Next() {
+		expense := &domain.Expense{}
+		var info []byte
+		if err := rows.Scan(
+			&expense.ID,
+			&expense.UserID,
+			&expense.Description,
+			&expense.OriginalAmount,
+			&expense.Currency,
+			&expense.HomeAmount,
+			&expense.HomeCurrency,
+			&expense.ExchangeRate,
+			&expense.CategoryID,
+			&expense.GroupID,
+			&expense.Account,
+			&expense.MerchantID,
+			&expense.Reimbursable,
+			&expense.ClaimStatus,
+			&expense.WorkspaceID,
+			&expense.ExpenseDate,
+			&expense.CreatedAt,
+			&expense.UpdatedAt,
+			&info,
+		); err != nil {
+			return nil, 0, err
+		}
+		hydrateExpenseAmounts(expense)
+		hits = append(hits, &domain.ExpenseSearchHit{Expense: expense, Score: bm25(info, ranked)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// matchinfo can only be read row by row, so hits are ranked and paged here
+	domain.SortSearchHits(hits, query.SortBy)
+	total := len(hits)
+	start := min(query.Offset, total)
+	end := total
+	if query.Limit > 0 {
+		end = min(start+query.Limit, total)
+	}
+	return hits[start:end], total, nil
+}
+
+// searchPhrases returns the FTS phrases a term matches: the term as a word
+// prefix, then the closest indexed words it could be a misspelling of
+func (r *ExpenseRepository) searchPhrases(ctx context.Context, term string) ([]searchPhrase, error) {
+	phrases := []searchPhrase{{text: `"` + term + `*"`, weight: 1}}
+	maxEdits := domain.SearchTermMaxEdits(term)
+	if maxEdits == 0 {
+		return phrases, nil
+	}
+
+	// Only words sharing the first letter are considered, which keeps the
+	// scan of the vocabulary short
+	first := []rune(term)[0]
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, `
+		SELECT term FROM expenses_fts_terms
+		WHERE col = '*' AND term >= ? AND term < ?
+	`, string(first), string(first+1))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type alternative struct {
+		word  string
+		edits int
+	}
+	var alternatives []alternative
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(word, term) {
+			continue // Already matched by the prefix
+		}
+		if edits := domain.EditDistance(word, term); edits <= maxEdits {
+			alternatives = append(alternatives, alternative{word, edits})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(alternatives, func(i, j int) bool { return alternatives[i].edits < alternatives[j].edits })
+	for i, alt := range alternatives {
+		if i == maxSearchAlternatives {
+			break
+		}
+		phrases = append(phrases, searchPhrase{text: `"` + alt.word + `"`, weight: 0.5 / float64(alt.edits)})
+	}
+	return phrases, nil
+}
+
+// matchExpression joins phrases into an FTS query matching any of them
+func matchExpression(phrases []searchPhrase) string {
+	texts := make([]string, len(phrases))
+	for i, phrase := range phrases {
+		texts[i] = phrase.text
+	}
+	return strings.Join(texts, " OR ")
+}
+
+// bm25 scores a row from its matchinfo 'pcnx' blob: the phrase and column
+// counts, the number of indexed rows, then for each phrase and column the
+// hits in this row, the hits in all rows and the rows with a hit. Descriptions
+// are short, so unlike full BM25 their length isn't taken into account.
+func bm25(info []byte, phrases []searchPhrase) float64 {
+	if len(info) < 12 {
+		return 0
+	}
+	value := func(i int) float64 {
+		return float64(binary.NativeEndian.Uint32(info[4*i:]))
+	}
+	phraseCount, columnCount, rowCount := int(value(0)), int(value(1)), value(2)
+	if phraseCount != len(phrases) || len(info) < 4*(3+3*phraseCount*columnCount) {
+		return 0
+	}
+
+	const k1 = 1.2
+	score := 0.0
+	for p, phrase := range phrases {
+		for c := 0; c < columnCount; c++ {
+			x := 3 + 3*(p*columnCount+c)
+			hits, rowsWithHits := value(x), value(x+2)
+			if hits == 0 {
+				continue
+			}
+			idf := math.Log(1 + (rowCount-rowsWithHits+0.5)/(rowsWithHits+0.5))
+			score += phrase.weight * idf * hits * (k1 + 1) / (hits + k1)
+		}
+	}
+	return score
+}
@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.GroupRepository = (*GroupRepository)(nil)
+
+// GroupRepository stores shared group ledgers in SQLite
+type GroupRepository struct {
+	db *sql.DB
+}
+
+// NewGroupRepository creates a new group repository
+func NewGroupRepository(db *sql.DB) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+// Create creates a new group
+func (r *GroupRepository) Create(ctx context.Context, group *domain.Group) error {
+	const query = `
+		INSERT INTO expense_groups (id, name, messenger_type, external_id, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, group.ID, group.Name, group.MessengerType, group.ExternalID, group.CreatedAt)
+	return conflictErr(err)
+}
+
+// GetByID retrieves a group by ID
+func (r *GroupRepository) GetByID(ctx context.Context, id string) (*domain.Group, error) {
+	const query = `
+		SELECT id, name, messenger_type, external_id, created_at
+		FROM expense_groups
+		WHERE id = ?
+	`
+	return r.scanGroup(txOrDB(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+// GetByExternalID retrieves the group bound to a messenger chat
+func (r *GroupRepository) GetByExternalID(ctx context.Context, messengerType, externalID string) (*domain.Group, error) {
+	const query = `
+		SELECT id, name, messenger_type, external_id, created_at
+		FROM expense_groups
+		WHERE messenger_type = ? AND external_id = ?
+	`
+	return r.scanGroup(txOrDB(ctx, r.db).QueryRowContext(ctx, query, messengerType, externalID))
+}
+
+// GetByUserID retrieves all groups a user belongs to
+func (r *GroupRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Group, error) {
+	const query = `
+		SELECT g.id, g.name, g.messenger_type, g.external_id, g.created_at
+		FROM expense_groups g
+		JOIN group_members m ON m.group_id = g.id
+		WHERE m.user_id = ?
+		ORDER BY g.created_at ASC
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*domain.Group
+	for rows.Next() {
+		group := &domain.Group{}
+		if err := rows.Scan(&group.ID, &group.Name, &group.MessengerType, &group.ExternalID, &group.CreatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+// AddMember adds a user to a group; adding an existing member is a no-op
+func (r *GroupRepository) AddMember(ctx context.Context, member *domain.GroupMember) error {
+	const query = `
+		INSERT INTO group_members (group_id, user_id, role, joined_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(group_id, user_id) DO NOTHING
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, member.GroupID, member.UserID, member.Role, member.JoinedAt)
+	return err
+}
+
+// GetMembers retrieves all members of a group
+func (r *GroupRepository) GetMembers(ctx context.Context, groupID string) ([]*domain.GroupMember, error) {
+	const query = `
+		SELECT group_id, user_id, role, joined_at
+		FROM group_members
+		WHERE group_id = ?
+		ORDER BY joined_at ASC
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*domain.GroupMember
+	for rows.Next() {
+		member := &domain.GroupMember{}
+		if err := rows.Scan(&member.GroupID, &member.UserID, &member.Role, &member.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// IsMember checks if a user belongs to a group
+func (r *GroupRepository) IsMember(ctx context.Context, groupID, userID string) (bool, error) {
+	const query = `SELECT COUNT(*) FROM group_members WHERE group_id = ? AND user_id = ?`
+	var count int
+	if err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, groupID, userID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *GroupRepository) scanGroup(row *sql.Row) (*domain.Group, error) {
+	group := &domain.Group{}
+	err := row.Scan(&group.ID, &group.Name, &group.MessengerType, &group.ExternalID, &group.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return group, nil
+}
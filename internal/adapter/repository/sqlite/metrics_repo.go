@@ -33,7 +33,7 @@ func (r *MetricsRepository) GetDailyActiveUsers(ctx context.Context, from, to ti
 		GROUP BY DATE(created_at)
 		ORDER BY date DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -62,11 +62,11 @@ func (r *MetricsRepository) GetExpensesSummary(ctx context.Context, from, to tim
 			COUNT(*) as expense_count,
 			AVG(home_amount) as average_expense
 		FROM expenses
-		WHERE expense_date >= ? AND expense_date <= ?
+		WHERE expense_date >= ? AND expense_date <= ? AND deleted_at IS NULL
 		GROUP BY expense_date
 		ORDER BY expense_date DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -85,19 +85,20 @@ func (r *MetricsRepository) GetExpensesSummary(ctx context.Context, from, to tim
 
 // GetCategoryTrends retrieves expense breakdown by category
 func (r *MetricsRepository) GetCategoryTrends(ctx context.Context, userID string, from, to time.Time) ([]*domain.CategoryMetrics, error) {
-	const query = `
+	scope, args := workspaceScope(ctx, "c.workspace_id", []any{userID, from, to, userID})
+	query := `
 		SELECT
 			c.id,
 			c.name,
 			COALESCE(SUM(e.home_amount), 0) as total,
 			COUNT(e.id) as count
 		FROM categories c
-		LEFT JOIN expenses e ON c.id = e.category_id AND e.user_id = ? AND e.expense_date >= ? AND e.expense_date <= ?
-		WHERE c.user_id = ?
+		LEFT JOIN expenses e ON c.id = e.category_id AND e.user_id = ? AND e.expense_date >= ? AND e.expense_date <= ? AND e.deleted_at IS NULL
+		WHERE c.user_id = ?` + scope + `
 		GROUP BY c.id, c.name
 		ORDER BY total DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query, userID, from, to, userID)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -132,7 +133,7 @@ func (r *MetricsRepository) GetCategoryTrends(ctx context.Context, userID string
 func (r *MetricsRepository) GetGrowthMetrics(ctx context.Context, days int) (map[string]interface{}, error) {
 	// Get total users
 	var totalUsers int
-	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&totalUsers)
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&totalUsers)
 	if err != nil {
 		return nil, err
 	}
@@ -140,7 +141,7 @@ func (r *MetricsRepository) GetGrowthMetrics(ctx context.Context, days int) (map
 	// Get new users today
 	var newUsersToday int
 	today := time.Now().Format("2006-01-02")
-	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE DATE(created_at) = ?", today).Scan(&newUsersToday)
+	err = txOrDB(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE DATE(created_at) = ?", today).Scan(&newUsersToday)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +149,7 @@ func (r *MetricsRepository) GetGrowthMetrics(ctx context.Context, days int) (map
 	// Get new users this week
 	var newUsersWeek int
 	weekAgo := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
-	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE created_at >= ?", weekAgo).Scan(&newUsersWeek)
+	err = txOrDB(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE created_at >= ?", weekAgo).Scan(&newUsersWeek)
 	if err != nil {
 		return nil, err
 	}
@@ -156,14 +157,14 @@ func (r *MetricsRepository) GetGrowthMetrics(ctx context.Context, days int) (map
 	// Get new users this month
 	var newUsersMonth int
 	monthAgo := time.Now().AddDate(0, -1, 0).Format("2006-01-02")
-	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE created_at >= ?", monthAgo).Scan(&newUsersMonth)
+	err = txOrDB(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE created_at >= ?", monthAgo).Scan(&newUsersMonth)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get total expenses
 	var totalExpenses float64
-	err = r.db.QueryRowContext(ctx, "SELECT COALESCE(SUM(home_amount), 0) FROM expenses").Scan(&totalExpenses)
+	err = txOrDB(ctx, r.db).QueryRowContext(ctx, "SELECT COALESCE(SUM(home_amount), 0) FROM expenses WHERE deleted_at IS NULL").Scan(&totalExpenses)
 	if err != nil {
 		return nil, err
 	}
@@ -191,7 +192,7 @@ func (r *MetricsRepository) GetNewUsersPerDay(ctx context.Context, from, to time
 		GROUP BY DATE(created_at)
 		ORDER BY date DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query, from, to)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -209,3 +210,88 @@ func (r *MetricsRepository) GetNewUsersPerDay(ctx context.Context, from, to time
 	}
 	return metrics, rows.Err()
 }
+
+// GetUserActivity retrieves the users who signed up or were active since the
+// given time, with the days they were active on since then
+func (r *MetricsRepository) GetUserActivity(ctx context.Context, since time.Time) ([]*domain.UserActivity, error) {
+	const query = `
+		SELECT u.user_id, u.created_at, a.active_date
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, DATE(created_at) AS active_date FROM expenses WHERE created_at >= ?1
+			UNION
+			SELECT user_id, DATE(created_at) FROM ai_cost_logs WHERE created_at >= ?1
+		) a ON a.user_id = u.user_id
+		WHERE u.created_at >= ?1 OR a.user_id IS NOT NULL
+		ORDER BY u.user_id, a.active_date
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var activity []*domain.UserActivity
+	var current *domain.UserActivity
+	for rows.Next() {
+		var userID string
+		var signedUpAt time.Time
+		var activeDate sql.NullString
+		if err := rows.Scan(&userID, &signedUpAt, &activeDate); err != nil {
+			return nil, err
+		}
+		if current == nil || current.UserID != userID {
+			current = &domain.UserActivity{UserID: userID, SignedUpAt: signedUpAt}
+			activity = append(activity, current)
+		}
+		if activeDate.Valid {
+			day, _ := time.Parse("2006-01-02", activeDate.String)
+			current.ActiveDays = append(current.ActiveDays, day)
+		}
+	}
+	return activity, rows.Err()
+}
+
+// GetPlatformMetrics retrieves signups, messages, active users and AI cost
+// per messenger platform for a date range. Logs written before their source
+// was recorded are attributed to the user's messenger type.
+func (r *MetricsRepository) GetPlatformMetrics(ctx context.Context, from, to time.Time) ([]*domain.PlatformMetrics, error) {
+	const query = `
+		SELECT platform, SUM(signups), SUM(active_users), SUM(messages), SUM(ai_calls), SUM(ai_cost)
+		FROM (
+			SELECT messenger_type AS platform, COUNT(*) AS signups, 0 AS active_users, 0 AS messages, 0 AS ai_calls, 0 AS ai_cost
+			FROM users
+			WHERE created_at >= ?1 AND created_at <= ?2
+			GROUP BY messenger_type
+			UNION ALL
+			SELECT COALESCE(NULLIF(l.source, ''), u.messenger_type, 'unknown'), 0, COUNT(DISTINCT l.user_id), COUNT(*), 0, 0
+			FROM interaction_logs l
+			LEFT JOIN users u ON u.user_id = l.user_id
+			WHERE l.timestamp >= ?1 AND l.timestamp <= ?2
+			GROUP BY COALESCE(NULLIF(l.source, ''), u.messenger_type, 'unknown')
+			UNION ALL
+			SELECT COALESCE(NULLIF(c.source, ''), u.messenger_type, 'unknown'), 0, 0, 0, COUNT(*), COALESCE(SUM(c.cost), 0)
+			FROM ai_cost_logs c
+			LEFT JOIN users u ON u.user_id = c.user_id
+			WHERE c.created_at >= ?1 AND c.created_at <= ?2
+			GROUP BY COALESCE(NULLIF(c.source, ''), u.messenger_type, 'unknown')
+		) platforms
+		GROUP BY platform
+		ORDER BY platform
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []*domain.PlatformMetrics
+	for rows.Next() {
+		m := &domain.PlatformMetrics{}
+		if err := rows.Scan(&m.Platform, &m.Signups, &m.ActiveUsers, &m.Messages, &m.AICalls, &m.AICost); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
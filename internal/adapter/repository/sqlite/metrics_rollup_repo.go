@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.MetricsRollupRepository = (*MetricsRollupRepository)(nil)
+
+// MetricsRollupRepository computes and stores the per-day metrics rollup in SQLite
+type MetricsRollupRepository struct {
+	db *sql.DB
+}
+
+// NewMetricsRollupRepository creates a new metrics rollup repository
+func NewMetricsRollupRepository(db *sql.DB) *MetricsRollupRepository {
+	return &MetricsRollupRepository{db: db}
+}
+
+const metricsRollupColumns = `metric_date, active_users, new_users, total_expense, expense_count, ai_calls, ai_input_tokens, ai_output_tokens, ai_total_tokens, ai_cost, updated_at`
+
+// ComputeDay aggregates the raw tables for the UTC day starting at day.
+// A user is active on a day they recorded an expense or made an AI call.
+func (r *MetricsRollupRepository) ComputeDay(ctx context.Context, day time.Time) (*domain.MetricsRollup, error) {
+	const query = `
+		SELECT a.active_users, u.new_users, e.total_expense, e.expense_count,
+			c.calls, c.input_tokens, c.output_tokens, c.total_tokens, c.cost
+		FROM
+			(SELECT COUNT(*) AS active_users FROM (
+				SELECT user_id FROM expenses WHERE created_at >= ?1 AND created_at < ?2
+				UNION
+				SELECT user_id FROM ai_cost_logs WHERE created_at >= ?1 AND created_at < ?2
+			) active) a,
+			(SELECT COUNT(*) AS new_users FROM users WHERE created_at >= ?1 AND created_at < ?2) u,
+			(SELECT COALESCE(SUM(home_amount), 0) AS total_expense, COUNT(*) AS expense_count
+				FROM expenses WHERE expense_date >= ?1 AND expense_date < ?2 AND deleted_at IS NULL) e,
+			(SELECT COUNT(*) AS calls,
+				COALESCE(SUM(input_tokens), 0) AS input_tokens,
+				COALESCE(SUM(output_tokens), 0) AS output_tokens,
+				COALESCE(SUM(total_tokens), 0) AS total_tokens,
+				COALESCE(SUM(cost), 0) AS cost
+				FROM ai_cost_logs WHERE created_at >= ?1 AND created_at < ?2) c
+	`
+	day = domain.MetricsDay(day)
+	rollup := &domain.MetricsRollup{Date: day}
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, day, day.AddDate(0, 0, 1)).Scan(
+		&rollup.ActiveUsers,
+		&rollup.NewUsers,
+		&rollup.TotalExpense,
+		&rollup.ExpenseCount,
+		&rollup.AICalls,
+		&rollup.AIInputTokens,
+		&rollup.AIOutputTokens,
+		&rollup.AITotalTokens,
+		&rollup.AICost,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rollup, nil
+}
+
+// Upsert stores a day's rollup, replacing any earlier one
+func (r *MetricsRollupRepository) Upsert(ctx context.Context, rollup *domain.MetricsRollup) error {
+	const query = `
+		INSERT INTO daily_metrics (` + metricsRollupColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (metric_date) DO UPDATE SET
+			active_users = excluded.active_users,
+			new_users = excluded.new_users,
+			total_expense = excluded.total_expense,
+			expense_count = excluded.expense_count,
+			ai_calls = excluded.ai_calls,
+			ai_input_tokens = excluded.ai_input_tokens,
+			ai_output_tokens = excluded.ai_output_tokens,
+			ai_total_tokens = excluded.ai_total_tokens,
+			ai_cost = excluded.ai_cost,
+			updated_at = excluded.updated_at
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		rollup.Date.Format("2006-01-02"),
+		rollup.ActiveUsers,
+		rollup.NewUsers,
+		rollup.TotalExpense,
+		rollup.ExpenseCount,
+		rollup.AICalls,
+		rollup.AIInputTokens,
+		rollup.AIOutputTokens,
+		rollup.AITotalTokens,
+		rollup.AICost,
+		rollup.UpdatedAt,
+	)
+	return err
+}
+
+// GetRange retrieves the rollups of the days from through to, oldest first
+func (r *MetricsRollupRepository) GetRange(ctx context.Context, from, to time.Time) ([]*domain.MetricsRollup, error) {
+	const query = `
+		SELECT ` + metricsRollupColumns + `
+		FROM daily_metrics
+		WHERE metric_date >= ? AND metric_date <= ?
+		ORDER BY metric_date ASC
+	`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query,
+		domain.MetricsDay(from).Format("2006-01-02"),
+		domain.MetricsDay(to).Format("2006-01-02"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rollups []*domain.MetricsRollup
+	for rows.Next() {
+		rollup := &domain.MetricsRollup{}
+		if err := rows.Scan(
+			&rollup.Date,
+			&rollup.ActiveUsers,
+			&rollup.NewUsers,
+			&rollup.TotalExpense,
+			&rollup.ExpenseCount,
+			&rollup.AICalls,
+			&rollup.AIInputTokens,
+			&rollup.AIOutputTokens,
+			&rollup.AITotalTokens,
+			&rollup.AICost,
+			&rollup.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, rollup)
+	}
+	return rollups, rows.Err()
+}
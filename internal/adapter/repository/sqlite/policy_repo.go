@@ -28,7 +28,7 @@ func (r *PolicyRepository) GetByKey(ctx context.Context, key string) (*domain.Po
 		WHERE key = ?
 	`
 	policy := &domain.Policy{}
-	err := r.db.QueryRowContext(ctx, query, key).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, key).Scan(
 		&policy.ID,
 		&policy.Key,
 		&policy.Title,
@@ -39,7 +39,7 @@ func (r *PolicyRepository) GetByKey(ctx context.Context, key string) (*domain.Po
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil // Policy not found
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
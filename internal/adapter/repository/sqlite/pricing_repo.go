@@ -28,7 +28,7 @@ func (r *PricingRepository) GetByProviderAndModel(ctx context.Context, provider,
 		ORDER BY effective_date DESC
 		LIMIT 1
 	`
-	row := r.db.QueryRowContext(ctx, query, provider, model, time.Now().UTC())
+	row := txOrDB(ctx, r.db).QueryRowContext(ctx, query, provider, model, time.Now().UTC())
 
 	config := &domain.PricingConfig{}
 	err := row.Scan(
@@ -37,7 +37,32 @@ func (r *PricingRepository) GetByProviderAndModel(ctx context.Context, provider,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil // No pricing found, not an error
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// GetByID retrieves a pricing configuration by ID, active or not
+func (r *PricingRepository) GetByID(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	const query = `
+		SELECT id, provider, model, input_token_price, output_token_price,
+		       currency, effective_date, is_active, created_at, updated_at
+		FROM ai_pricing_config
+		WHERE id = ?
+	`
+	row := txOrDB(ctx, r.db).QueryRowContext(ctx, query, id)
+
+	config := &domain.PricingConfig{}
+	err := row.Scan(
+		&config.ID, &config.Provider, &config.Model, &config.InputTokenPrice, &config.OutputTokenPrice,
+		&config.Currency, &config.EffectiveDate, &config.IsActive, &config.CreatedAt, &config.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
@@ -54,7 +79,7 @@ func (r *PricingRepository) GetAll(ctx context.Context) ([]*domain.PricingConfig
 		WHERE is_active = 1
 		ORDER BY provider, model, effective_date DESC
 	`
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +109,7 @@ func (r *PricingRepository) Create(ctx context.Context, config *domain.PricingCo
 			currency, effective_date, is_active, created_at, updated_at
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		config.ID, config.Provider, config.Model, config.InputTokenPrice, config.OutputTokenPrice,
 		config.Currency, config.EffectiveDate, config.IsActive, config.CreatedAt, config.UpdatedAt,
 	)
@@ -98,7 +123,7 @@ func (r *PricingRepository) Update(ctx context.Context, config *domain.PricingCo
 		SET input_token_price = ?, output_token_price = ?, is_active = ?, updated_at = ?
 		WHERE id = ?
 	`
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
 		config.InputTokenPrice, config.OutputTokenPrice, config.IsActive, time.Now().UTC(), config.ID,
 	)
 	return err
@@ -111,6 +136,6 @@ func (r *PricingRepository) Deactivate(ctx context.Context, provider, model stri
 		SET is_active = 0, updated_at = ?
 		WHERE provider = ? AND model = ?
 	`
-	_, err := r.db.ExecContext(ctx, query, time.Now().UTC(), provider, model)
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, time.Now().UTC(), provider, model)
 	return err
 }
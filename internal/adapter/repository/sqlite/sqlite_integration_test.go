@@ -2,11 +2,14 @@ package sqlite
 
 import (
 	"context"
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/riverlin/aiexpense/internal/adapter/encryption"
 	"github.com/riverlin/aiexpense/internal/domain"
 )
 
@@ -378,12 +381,8 @@ func TestSQLiteExpenseRepository(t *testing.T) {
 		}
 
 		// Verify deletion
-		exp, err := expenseRepo.GetByID(ctx, "exp_001")
-		if err != nil {
-			t.Fatalf("Failed to check deletion: %v", err)
-		}
-		if exp != nil {
-			t.Error("Expected expense to be deleted")
+		if _, err := expenseRepo.GetByID(ctx, "exp_001"); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Expected expense to be deleted, got %v", err)
 		}
 	})
 
@@ -412,6 +411,50 @@ func TestSQLiteExpenseRepository(t *testing.T) {
 			t.Error("Expected to retrieve expenses in category")
 		}
 	})
+
+	t.Run("ListByUserIDPaged", func(t *testing.T) {
+		userRepo.Create(ctx, &domain.User{UserID: "page_test_user", MessengerType: "line", CreatedAt: time.Now()})
+
+		base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		for i := 1; i <= 4; i++ {
+			if err := expenseRepo.Create(ctx, &domain.Expense{
+				ID:          "page_" + strconv.Itoa(i),
+				UserID:      "page_test_user",
+				Description: "Paged expense",
+				Amount:      float64(i * 10),
+				ExpenseDate: base.AddDate(0, 0, i),
+				CreatedAt:   base,
+			}); err != nil {
+				t.Fatalf("Failed to create expense: %v", err)
+			}
+		}
+
+		first, err := expenseRepo.ListByUserID(ctx, "page_test_user", domain.ExpenseListOptions{Limit: 2})
+		if err != nil {
+			t.Fatalf("Failed to list expenses: %v", err)
+		}
+		if len(first) != 2 || first[0].ID != "page_4" || first[1].ID != "page_3" {
+			t.Fatalf("Unexpected first page: %v", first)
+		}
+
+		next, err := expenseRepo.ListByUserID(ctx, "page_test_user", domain.ExpenseListOptions{Limit: 2, AfterID: first[1].ID})
+		if err != nil {
+			t.Fatalf("Failed to list next page: %v", err)
+		}
+		if len(next) != 2 || next[0].ID != "page_2" || next[1].ID != "page_1" {
+			t.Errorf("Unexpected next page: %v", next)
+		}
+
+		byAmount, err := expenseRepo.ListByUserID(ctx, "page_test_user", domain.ExpenseListOptions{
+			Limit: 1, Offset: 1, SortBy: domain.ExpenseSortAmount, SortDir: domain.SortAsc,
+		})
+		if err != nil {
+			t.Fatalf("Failed to list by amount: %v", err)
+		}
+		if len(byAmount) != 1 || byAmount[0].ID != "page_2" {
+			t.Errorf("Unexpected amount-sorted page: %v", byAmount)
+		}
+	})
 }
 
 // TestSQLiteMetricsRepository integration tests
@@ -505,4 +548,1296 @@ func TestSQLiteMetricsRepository(t *testing.T) {
 			t.Error("Expected to retrieve growth metrics")
 		}
 	})
+
+	t.Run("GetUserActivity", func(t *testing.T) {
+		activity, err := metricsRepo.GetUserActivity(ctx, time.Now().AddDate(0, 0, -30))
+		if err != nil {
+			t.Fatalf("Failed to get user activity: %v", err)
+		}
+
+		if len(activity) != 3 {
+			t.Fatalf("Expected 3 users, got %d", len(activity))
+		}
+		today := domain.MetricsDay(time.Now())
+		for _, user := range activity {
+			if len(user.ActiveDays) != 1 || !user.ActiveDays[0].Equal(today) {
+				t.Errorf("Expected %s active only today, got %v", user.UserID, user.ActiveDays)
+			}
+		}
+	})
+
+	t.Run("GetPlatformMetrics", func(t *testing.T) {
+		userID := "metrics_user_" + string(rune(1))
+		aiCostRepo := NewAICostRepository(db)
+		interactionRepo := NewInteractionLogRepository(db)
+		now := time.Now()
+		aiCostRepo.Create(ctx, &domain.AICostLog{ID: "platform_cost_1", UserID: userID, Operation: "parse_expense", Cost: 0.5, Currency: "USD", Source: "slack", CreatedAt: now})
+		// Logged before the source was recorded, so attributed to the user's messenger
+		aiCostRepo.Create(ctx, &domain.AICostLog{ID: "platform_cost_2", UserID: userID, Operation: "parse_expense", Cost: 0.25, Currency: "USD", CreatedAt: now})
+		interactionRepo.Create(ctx, &domain.InteractionLog{ID: "platform_int_1", UserID: userID, Source: "telegram", UserInput: "lunch 10", Timestamp: now})
+
+		platforms, err := metricsRepo.GetPlatformMetrics(ctx, now.AddDate(0, 0, -30), now.Add(time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to get platform metrics: %v", err)
+		}
+
+		byPlatform := make(map[string]*domain.PlatformMetrics)
+		for _, p := range platforms {
+			byPlatform[p.Platform] = p
+		}
+		telegram, slack := byPlatform["telegram"], byPlatform["slack"]
+		if telegram == nil || telegram.Signups != 3 || telegram.Messages != 1 || telegram.ActiveUsers != 1 || telegram.AICalls != 1 || telegram.AICost != 0.25 {
+			t.Errorf("Unexpected telegram metrics: %+v", telegram)
+		}
+		if slack == nil || slack.Signups != 0 || slack.AICalls != 1 || slack.AICost != 0.5 {
+			t.Errorf("Unexpected slack metrics: %+v", slack)
+		}
+	})
+
+	t.Run("MetricsRollup", func(t *testing.T) {
+		rollupRepo := NewMetricsRollupRepository(db)
+		today := domain.MetricsDay(time.Now())
+		yesterday := today.AddDate(0, 0, -1)
+
+		active, err := rollupRepo.ComputeDay(ctx, today)
+		if err != nil {
+			t.Fatalf("Failed to compute today: %v", err)
+		}
+		if active.ActiveUsers != 3 {
+			t.Errorf("Expected 3 active users today, got %d", active.ActiveUsers)
+		}
+
+		spent, err := rollupRepo.ComputeDay(ctx, yesterday)
+		if err != nil {
+			t.Fatalf("Failed to compute yesterday: %v", err)
+		}
+		if spent.ExpenseCount != 3 || spent.TotalExpense != 30 || spent.NewUsers != 1 {
+			t.Errorf("Unexpected rollup for yesterday: %+v", spent)
+		}
+
+		for _, rollup := range []*domain.MetricsRollup{active, spent, spent} {
+			rollup.UpdatedAt = time.Now()
+			if err := rollupRepo.Upsert(ctx, rollup); err != nil {
+				t.Fatalf("Failed to upsert rollup: %v", err)
+			}
+		}
+		rollups, err := rollupRepo.GetRange(ctx, yesterday, today)
+		if err != nil {
+			t.Fatalf("Failed to get rollups: %v", err)
+		}
+		if len(rollups) != 2 || !rollups[0].Date.Equal(yesterday) || rollups[0].ExpenseCount != 3 || rollups[1].ActiveUsers != 3 {
+			t.Errorf("Expected yesterday then today, got %+v", rollups)
+		}
+	})
+}
+
+func TestSQLiteUserDeletionRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	categoryRepo := NewCategoryRepository(db)
+	expenseRepo := NewExpenseRepository(db)
+	repo := NewUserDeletionRepository(db)
+	ctx := context.Background()
+
+	for _, userID := range []string{"leaving_user", "staying_user"} {
+		userRepo.Create(ctx, &domain.User{UserID: userID, MessengerType: "line", CreatedAt: time.Now()})
+		catID := "cat_" + userID
+		categoryRepo.Create(ctx, &domain.Category{ID: catID, UserID: userID, Name: "Food", CreatedAt: time.Now()})
+		if err := expenseRepo.Create(ctx, &domain.Expense{
+			ID:          "exp_" + userID,
+			UserID:      userID,
+			Description: "Lunch",
+			Amount:      12,
+			CategoryID:  &catID,
+			ExpenseDate: time.Now(),
+			CreatedAt:   time.Now(),
+		}); err != nil {
+			t.Fatalf("Failed to create expense: %v", err)
+		}
+	}
+
+	t.Run("PendingAndDue", func(t *testing.T) {
+		now := time.Now()
+		deletion := &domain.UserDeletion{
+			ID:          "del_1",
+			UserID:      "leaving_user",
+			Status:      domain.UserDeletionPending,
+			RequestedAt: now.Add(-time.Hour),
+			PurgeAfter:  now.Add(-time.Minute),
+		}
+		if err := repo.Create(ctx, deletion); err != nil {
+			t.Fatalf("Failed to create deletion: %v", err)
+		}
+
+		pending, err := repo.GetPending(ctx, "leaving_user")
+		if err != nil || pending == nil || pending.ID != "del_1" {
+			t.Fatalf("Expected the pending deletion, got %v, %v", pending, err)
+		}
+		if none, _ := repo.GetPending(ctx, "staying_user"); none != nil {
+			t.Errorf("Expected no pending deletion for staying_user, got %v", none)
+		}
+
+		due, err := repo.GetDue(ctx, now)
+		if err != nil || len(due) != 1 {
+			t.Fatalf("Expected 1 due deletion, got %d, %v", len(due), err)
+		}
+	})
+
+	t.Run("PurgeUserData", func(t *testing.T) {
+		archive := &domain.Archive{ID: "arc_1", UserID: "leaving_user", Period: "monthly", Format: domain.ArchiveFormatJSONLinesGzip, ObjectKey: "archives/leaving_user/arc_1.jsonl.gz", CreatedAt: time.Now()}
+		if err := NewArchiveRepository(db).Create(ctx, archive); err != nil {
+			t.Fatalf("Failed to create archive: %v", err)
+		}
+
+		purge, err := repo.PurgeUserData(ctx, "leaving_user")
+		if err != nil {
+			t.Fatalf("Failed to purge: %v", err)
+		}
+		if len(purge.ArchiveKeys) != 1 || purge.ArchiveKeys[0] != archive.ObjectKey || purge.DeletedCounts["archives"] != 1 {
+			t.Errorf("Expected the archive to be purged, got keys %v and counts %v", purge.ArchiveKeys, purge.DeletedCounts)
+		}
+		if purge.DeletedCounts["expenses"] != 1 || purge.DeletedCounts["categories"] != 1 || purge.DeletedCounts["users"] != 1 {
+			t.Errorf("Unexpected deleted counts: %v", purge.DeletedCounts)
+		}
+
+		if user, _ := userRepo.GetByID(ctx, "leaving_user"); user != nil {
+			t.Error("Expected the user to be deleted")
+		}
+		if user, _ := userRepo.GetByID(ctx, "staying_user"); user == nil {
+			t.Error("Expected other users to be kept")
+		}
+		if expense, _ := expenseRepo.GetByID(ctx, "exp_staying_user"); expense == nil {
+			t.Error("Expected other users' expenses to be kept")
+		}
+	})
+
+	t.Run("UpdateAndList", func(t *testing.T) {
+		deletion, _ := repo.GetPending(ctx, "leaving_user")
+		purgedAt := time.Now()
+		deletion.Status = domain.UserDeletionPurged
+		deletion.PurgedAt = &purgedAt
+		deletion.DeletedCounts = map[string]int64{"expenses": 1}
+		if err := repo.Update(ctx, deletion); err != nil {
+			t.Fatalf("Failed to update deletion: %v", err)
+		}
+
+		deletions, err := repo.List(ctx, 10)
+		if err != nil || len(deletions) != 1 {
+			t.Fatalf("Expected 1 deletion, got %d, %v", len(deletions), err)
+		}
+		if deletions[0].Status != domain.UserDeletionPurged || deletions[0].DeletedCounts["expenses"] != 1 || deletions[0].PurgedAt == nil {
+			t.Errorf("Unexpected deletion after update: %+v", deletions[0])
+		}
+	})
+}
+
+// TestSQLiteEncryptedColumns integration tests
+func TestSQLiteReplyOutboxRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	repo := NewReplyOutboxRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	for i, next := range []time.Time{now.Add(-time.Minute), now.Add(time.Minute)} {
+		if err := repo.Create(ctx, &domain.OutboxReply{
+			ID:            "reply" + strconv.Itoa(i),
+			UserID:        "telegram_42",
+			Messenger:     "telegram",
+			Metadata:      `{"chat_id":42}`,
+			Push:          i == 0,
+			Text:          "Saved lunch $100",
+			Status:        domain.OutboxReplyPending,
+			Attempts:      1,
+			NextAttemptAt: &next,
+			CreatedAt:     now.Add(-time.Hour),
+		}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	due, err := repo.GetDue(ctx, now, 10)
+	if err != nil || len(due) != 1 || due[0].ID != "reply0" || due[0].Metadata != `{"chat_id":42}` || !due[0].Push {
+		t.Fatalf("expected only reply0 due, got %+v, %v", due, err)
+	}
+
+	due[0].Status = domain.OutboxReplyDelivered
+	due[0].Attempts = 2
+	due[0].NextAttemptAt = nil
+	due[0].DeliveredAt = &now
+	if err := repo.Update(ctx, due[0]); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if due, _ := repo.GetDue(ctx, now.Add(time.Hour), 10); len(due) != 1 || due[0].ID != "reply1" {
+		t.Errorf("expected the delivered reply no longer due, got %+v", due)
+	}
+
+	deleted, err := repo.DeleteFinishedBefore(ctx, now)
+	if err != nil || deleted != 1 {
+		t.Errorf("expected the delivered reply deleted and the pending one kept, got %d, %v", deleted, err)
+	}
+}
+
+func TestSQLiteEmailAddressRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	repo := NewEmailAddressRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(15 * time.Minute)
+
+	pending := &domain.EmailAddress{
+		Address:       "river@example.com",
+		UserID:        "line_u1",
+		CodeHash:      "hash",
+		CodeExpiresAt: &expiresAt,
+		CreatedAt:     now,
+	}
+	if err := repo.Save(ctx, pending); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if _, err := repo.GetByAddress(ctx, "other@example.com"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown address, got %v", err)
+	}
+
+	pending.VerifiedAt = &now
+	pending.CodeHash = ""
+	pending.CodeExpiresAt = nil
+	if err := repo.Save(ctx, pending); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, err := repo.GetByAddress(ctx, "river@example.com")
+	if err != nil || got.UserID != "line_u1" || got.VerifiedAt == nil || got.CodeExpiresAt != nil || got.CodeHash != "" {
+		t.Fatalf("expected the verified address, got %+v, %v", got, err)
+	}
+
+	if addresses, err := repo.GetByUserID(ctx, "line_u1"); err != nil || len(addresses) != 1 {
+		t.Errorf("expected one address for line_u1, got %+v, %v", addresses, err)
+	}
+	if err := repo.Delete(ctx, "river@example.com"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if addresses, _ := repo.GetByUserID(ctx, "line_u1"); len(addresses) != 0 {
+		t.Errorf("expected the address deleted, got %+v", addresses)
+	}
+}
+
+func TestSQLiteConversationStateRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	repo := NewConversationStateRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	if _, err := repo.GetByUserID(ctx, "line_u1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound without a state, got %v", err)
+	}
+
+	state := &domain.ConversationState{
+		UserID:    "line_u1",
+		Intent:    domain.MessageActionAddCategory,
+		Awaiting:  "name",
+		ExpiresAt: now.Add(10 * time.Minute),
+		UpdatedAt: now,
+	}
+	if err := repo.Save(ctx, state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A new question replaces the previous one
+	state.Intent = domain.MessageActionChangeCategory
+	state.Awaiting = "category"
+	state.Slots = map[string]string{"expense_id": "exp1"}
+	if err := repo.Save(ctx, state); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, err := repo.GetByUserID(ctx, "line_u1")
+	if err != nil || got.Intent != domain.MessageActionChangeCategory || got.Awaiting != "category" || got.Slots["expense_id"] != "exp1" || !got.ExpiresAt.Equal(state.ExpiresAt) {
+		t.Fatalf("expected the replaced state, got %+v, %v", got, err)
+	}
+
+	repo.Save(ctx, &domain.ConversationState{UserID: "line_u2", Intent: domain.MessageActionAddCategory, ExpiresAt: now.Add(-time.Minute), UpdatedAt: now})
+	deleted, err := repo.DeleteExpiredBefore(ctx, now)
+	if err != nil || deleted != 1 {
+		t.Errorf("expected the expired state deleted, got %d, %v", deleted, err)
+	}
+
+	if err := repo.Delete(ctx, "line_u1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByUserID(ctx, "line_u1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected the state deleted, got %v", err)
+	}
+}
+
+func TestSQLiteEncryptedColumns(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	NewUserRepository(db).Create(ctx, &domain.User{UserID: "enc_user", MessengerType: "line", CreatedAt: time.Now()})
+
+	// Written before encryption was enabled
+	plainRepo := NewExpenseRepository(db)
+	if err := plainRepo.Create(ctx, &domain.Expense{
+		ID:          "exp_enc",
+		UserID:      "enc_user",
+		Description: "Pharmacy",
+		Amount:      30,
+		ExpenseDate: time.Now(),
+		CreatedAt:   time.Now(),
+	}); err != nil {
+		t.Fatalf("Failed to create expense: %v", err)
+	}
+
+	rawDescription := func() string {
+		var description string
+		if err := db.QueryRowContext(ctx, `SELECT description FROM expenses WHERE id = ?`, "exp_enc").Scan(&description); err != nil {
+			t.Fatalf("Failed to read raw description: %v", err)
+		}
+		return description
+	}
+
+	oldKeys, _ := encryption.ParseKeys("k1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+	rotatedKeys, _ := encryption.ParseKeys("k2:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=,k1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+
+	t.Run("ReencryptPlaintext", func(t *testing.T) {
+		rewritten, err := ReencryptSensitiveColumns(ctx, db, oldKeys)
+		if err != nil {
+			t.Fatalf("Failed to re-encrypt: %v", err)
+		}
+		if rewritten["expenses"] != 1 {
+			t.Errorf("Expected 1 expense rewritten, got %v", rewritten)
+		}
+		if raw := rawDescription(); !strings.HasPrefix(raw, "enc:v1:k1:") {
+			t.Errorf("Expected description encrypted with k1, got %q", raw)
+		}
+
+		repo := NewExpenseRepository(db)
+		repo.SetCipher(oldKeys)
+		expense, err := repo.GetByID(ctx, "exp_enc")
+		if err != nil || expense == nil || expense.Description != "Pharmacy" {
+			t.Fatalf("Expected decrypted description, got %+v (%v)", expense, err)
+		}
+	})
+
+	t.Run("RotateKey", func(t *testing.T) {
+		rewritten, err := ReencryptSensitiveColumns(ctx, db, rotatedKeys)
+		if err != nil {
+			t.Fatalf("Failed to re-encrypt: %v", err)
+		}
+		if rewritten["expenses"] != 1 {
+			t.Errorf("Expected 1 expense rewritten, got %v", rewritten)
+		}
+		if raw := rawDescription(); !strings.HasPrefix(raw, "enc:v1:k2:") {
+			t.Errorf("Expected description encrypted with k2, got %q", raw)
+		}
+		if rewritten, _ := ReencryptSensitiveColumns(ctx, db, rotatedKeys); rewritten["expenses"] != 0 {
+			t.Errorf("Expected nothing left to rewrite, got %v", rewritten)
+		}
+	})
+}
+
+func TestSQLiteUnitOfWork(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	userRepo := NewUserRepository(db)
+	expenseRepo := NewExpenseRepository(db)
+	splitRepo := NewExpenseSplitRepository(db)
+	uow := NewUnitOfWork(db)
+	ctx := context.Background()
+
+	if err := userRepo.Create(ctx, &domain.User{UserID: "uow_user", MessengerType: "line", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	newExpense := func(id string) *domain.Expense {
+		return &domain.Expense{ID: id, UserID: "uow_user", Description: "Dinner", Amount: 300, ExpenseDate: time.Now(), CreatedAt: time.Now()}
+	}
+	split := func(expenseID string) []*domain.ExpenseSplit {
+		return []*domain.ExpenseSplit{{ID: "split_" + expenseID, ExpenseID: expenseID, PayerID: "uow_user", Participant: "bob", Amount: 150, CreatedAt: time.Now()}}
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			if !domain.InUnitOfWork(ctx) {
+				t.Error("Expected the context to be marked as inside a unit of work")
+			}
+			if err := expenseRepo.Create(ctx, newExpense("exp_committed")); err != nil {
+				return err
+			}
+			// Reads inside the unit see its uncommitted writes
+			if _, err := expenseRepo.GetByID(ctx, "exp_committed"); err != nil {
+				return err
+			}
+			return splitRepo.ReplaceForExpense(ctx, "exp_committed", split("exp_committed"))
+		})
+		if err != nil {
+			t.Fatalf("Unit of work failed: %v", err)
+		}
+		if _, err := expenseRepo.GetByID(ctx, "exp_committed"); err != nil {
+			t.Errorf("Expected the expense committed, got %v", err)
+		}
+		if splits, _ := splitRepo.GetByExpenseID(ctx, "exp_committed"); len(splits) != 1 {
+			t.Errorf("Expected 1 split committed, got %d", len(splits))
+		}
+	})
+
+	t.Run("RollbackOnError", func(t *testing.T) {
+		failure := errors.New("notification failed")
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			if err := expenseRepo.Create(ctx, newExpense("exp_rolled_back")); err != nil {
+				return err
+			}
+			// The split repository's own transaction joins the unit of work
+			if err := splitRepo.ReplaceForExpense(ctx, "exp_rolled_back", split("exp_rolled_back")); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("Expected the unit of work to return fn's error, got %v", err)
+		}
+		if _, err := expenseRepo.GetByID(ctx, "exp_rolled_back"); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Expected the expense rolled back, got %v", err)
+		}
+		if splits, _ := splitRepo.GetByExpenseID(ctx, "exp_rolled_back"); len(splits) != 0 {
+			t.Errorf("Expected the split rolled back, got %d", len(splits))
+		}
+	})
+
+	t.Run("NestedJoinsOuter", func(t *testing.T) {
+		err := uow.Do(ctx, func(ctx context.Context) error {
+			if err := uow.Do(ctx, func(ctx context.Context) error {
+				return expenseRepo.Create(ctx, newExpense("exp_nested"))
+			}); err != nil {
+				return err
+			}
+			return errors.New("outer step failed")
+		})
+		if err == nil {
+			t.Fatal("Expected the outer error")
+		}
+		if _, err := expenseRepo.GetByID(ctx, "exp_nested"); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Expected the inner write rolled back with the outer unit, got %v", err)
+		}
+	})
+}
+
+func TestSQLiteNotificationPreferencesRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	repo := NewNotificationPreferencesRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	if _, err := repo.GetByUserID(ctx, "line_u1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound without preferences, got %v", err)
+	}
+
+	prefs := domain.DefaultNotificationPreferences("line_u1")
+	prefs.DailyDigest = true
+	prefs.DailyDigestHour = 7
+	prefs.ExpenseReminders = true
+	prefs.QuietHoursStart = 23
+	prefs.Channels = map[string]string{domain.NotificationWeeklyReport: domain.NotificationChannelEmail}
+	prefs.UpdatedAt = now
+	if err := repo.Save(ctx, prefs); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	other := domain.DefaultNotificationPreferences("line_u2")
+	other.UpdatedAt = now
+	if err := repo.Save(ctx, other); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Saving again replaces the preferences
+	prefs.LastDailyDigestAt = &now
+	if err := repo.Save(ctx, prefs); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, err := repo.GetByUserID(ctx, "line_u1")
+	if err != nil || !got.DailyDigest || got.DailyDigestHour != 7 || !got.WeeklyReport || got.LastDailyDigestAt == nil || !got.LastDailyDigestAt.Equal(now) ||
+		got.ExpenseReminderDays != domain.DefaultExpenseReminderDays || got.QuietHoursStart != 23 || got.QuietHoursEnd != domain.DefaultQuietHoursEnd ||
+		got.Channel(domain.NotificationWeeklyReport) != domain.NotificationChannelEmail || got.Channel(domain.NotificationBudgetAlerts) != domain.NotificationChannelPush {
+		t.Fatalf("expected the saved preferences, got %+v, %v", got, err)
+	}
+	if got, err := repo.GetByUserID(ctx, "line_u2"); err != nil || got.Channels != nil {
+		t.Errorf("expected no channels picked, got %+v, %v", got, err)
+	}
+
+	subscribed, err := repo.ListSubscribed(ctx, domain.NotificationDailyDigest)
+	if err != nil || len(subscribed) != 1 || subscribed[0].UserID != "line_u1" {
+		t.Errorf("expected only line_u1 subscribed to the daily digest, got %v, %v", subscribed, err)
+	}
+	subscribed, err = repo.ListSubscribed(ctx, domain.NotificationExpenseReminders)
+	if err != nil || len(subscribed) != 1 || subscribed[0].UserID != "line_u1" {
+		t.Errorf("expected only line_u1 subscribed to expense reminders, got %v, %v", subscribed, err)
+	}
+
+	// The weekly report is on by default, so users without preferences get it
+	// unless they turned it off
+	users := NewUserRepository(db)
+	for _, userID := range []string{"line_u1", "line_u2", "line_u3"} {
+		if err := users.Create(ctx, &domain.User{UserID: userID, MessengerType: "line", CreatedAt: now}); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+	other.WeeklyReport = false
+	if err := repo.Save(ctx, other); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	subscribed, err = repo.ListSubscribed(ctx, domain.NotificationWeeklyReport)
+	if err != nil || len(subscribed) != 2 || subscribed[0].UserID != "line_u1" || subscribed[0].DailyDigestHour != 7 ||
+		subscribed[1].UserID != "line_u3" || !subscribed[1].WeeklyReport {
+		t.Errorf("expected line_u1 and line_u3 subscribed to the weekly report, got %v, %v", subscribed, err)
+	}
+
+	// So are spending anomaly alerts, with the sensitivity each user picked
+	other.SpendingAnomalies = false
+	prefs.AnomalySensitivity = domain.AnomalySensitivityHigh
+	prefs.LastAnomalyCheckAt = &now
+	for _, p := range []*domain.NotificationPreferences{prefs, other} {
+		if err := repo.Save(ctx, p); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	subscribed, err = repo.ListSubscribed(ctx, domain.NotificationSpendingAnomalies)
+	if err != nil || len(subscribed) != 2 || subscribed[0].AnomalySensitivity != domain.AnomalySensitivityHigh ||
+		subscribed[0].LastAnomalyCheckAt == nil || !subscribed[0].LastAnomalyCheckAt.Equal(now) ||
+		subscribed[1].UserID != "line_u3" || subscribed[1].AnomalySensitivity != domain.DefaultAnomalySensitivity {
+		t.Errorf("expected line_u1 and line_u3 subscribed to spending anomalies, got %v, %v", subscribed, err)
+	}
+
+	if _, err := repo.ListSubscribed(ctx, "carrier_pigeon"); err == nil {
+		t.Error("expected an error for an unknown notification")
+	}
+}
+
+func TestSQLiteArchiveRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	repo := NewArchiveRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.AddDate(7, 0, 0)
+
+	for i, archive := range []*domain.Archive{
+		{ID: "arc_old", UserID: "line_u1", CreatedAt: now.AddDate(0, -1, 0)},
+		{ID: "arc_new", UserID: "line_u1", CreatedAt: now, ExpenseCount: 12, TotalAmount: 3450.5, Size: 4096, CompressedSize: 812, RetentionDays: 2555, ExpiresAt: &expiresAt},
+		{ID: "arc_other", UserID: "line_u2", CreatedAt: now},
+	} {
+		archive.Period = "monthly"
+		archive.StartDate = time.Date(2025, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC)
+		archive.EndDate = archive.StartDate.AddDate(0, 1, 0)
+		archive.Format = domain.ArchiveFormatJSONLinesGzip
+		archive.ObjectKey = "archives/" + archive.UserID + "/" + archive.ID + ".jsonl.gz"
+		archive.Checksum = "abc123"
+		if err := repo.Create(ctx, archive); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	got, err := repo.GetByID(ctx, "arc_new")
+	if err != nil || got.UserID != "line_u1" || got.ExpenseCount != 12 || got.TotalAmount != 3450.5 || got.Size != 4096 || got.CompressedSize != 812 ||
+		got.ObjectKey != "archives/line_u1/arc_new.jsonl.gz" || got.ExpiresAt == nil || !got.ExpiresAt.Equal(expiresAt) || !got.StartDate.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the saved archive, got %+v, %v", got, err)
+	}
+
+	archives, err := repo.GetByUserID(ctx, "line_u1")
+	if err != nil || len(archives) != 2 || archives[0].ID != "arc_new" || archives[1].ID != "arc_old" || archives[1].ExpiresAt != nil {
+		t.Fatalf("expected line_u1's archives newest first, got %v, %v", archives, err)
+	}
+	archives, err = repo.GetCreatedBefore(ctx, now)
+	if err != nil || len(archives) != 1 || archives[0].ID != "arc_old" {
+		t.Errorf("expected only arc_old created before now, got %v, %v", archives, err)
+	}
+
+	if err := repo.Delete(ctx, "arc_old"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "arc_old"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestSQLiteArchivePolicyRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	repo := NewArchivePolicyRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	if _, err := repo.GetByUserID(ctx, "line_u1"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound without a policy, got %v", err)
+	}
+
+	policy := &domain.ArchivePolicy{UserID: "line_u1", ArchiveAfterMonths: 12, PurgeAfterDays: 365, UpdatedAt: now}
+	if err := repo.Save(ctx, policy); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	policy.ArchiveAfterMonths = 36
+	if err := repo.Save(ctx, policy); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := repo.Save(ctx, &domain.ArchivePolicy{UserID: "line_u2", UpdatedAt: now}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	got, err := repo.GetByUserID(ctx, "line_u1")
+	if err != nil || got.ArchiveAfterMonths != 36 || got.PurgeAfterDays != 365 || !got.UpdatedAt.Equal(now) {
+		t.Fatalf("expected the saved policy, got %+v, %v", got, err)
+	}
+	policies, err := repo.List(ctx)
+	if err != nil || len(policies) != 2 || policies[0].UserID != "line_u1" || policies[1].UserID != "line_u2" {
+		t.Errorf("expected both policies, got %v, %v", policies, err)
+	}
+	if err := repo.Delete(ctx, "line_u2"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.GetByUserID(ctx, "line_u2"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+
+	users := NewUserRepository(db)
+	expenses := NewExpenseRepository(db)
+	for i, e := range []struct {
+		userID string
+		date   time.Time
+	}{
+		{"line_u1", now.AddDate(-3, 0, 0)},
+		{"line_u2", now.AddDate(-3, 0, 0)},
+		{"line_u3", now},
+	} {
+		if err := users.Create(ctx, &domain.User{UserID: e.userID, MessengerType: "line", CreatedAt: now}); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		if err := expenses.Create(ctx, &domain.Expense{ID: "exp_" + strconv.Itoa(i), UserID: e.userID, Description: "Lunch", ExpenseDate: e.date, CreatedAt: e.date}); err != nil {
+			t.Fatalf("failed to create expense: %v", err)
+		}
+	}
+	// Deleted expenses aren't archived
+	if err := expenses.Delete(ctx, "exp_1"); err != nil {
+		t.Fatalf("failed to delete expense: %v", err)
+	}
+	userIDs, err := repo.ListUsersWithExpensesBefore(ctx, now.AddDate(-2, 0, 0))
+	if err != nil || len(userIDs) != 1 || userIDs[0] != "line_u1" {
+		t.Errorf("expected only line_u1 with old expenses, got %v, %v", userIDs, err)
+	}
+}
+
+func TestSQLiteExpenseSearch(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	users := NewUserRepository(db)
+	repo := NewExpenseRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, userID := range []string{"line_u1", "line_u2"} {
+		if err := users.Create(ctx, &domain.User{UserID: userID, MessengerType: "line", CreatedAt: now}); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+	}
+	for i, e := range []struct {
+		id, userID, description string
+		amount                  float64
+	}{
+		{"exp_cafe", "line_u1", "Lunch at Café Lunch", 180},
+		{"exp_box", "line_u1", "lunch box", 90},
+		{"exp_bento", "line_u1", "午餐便當", 100},
+		{"exp_taxi", "line_u1", "Taxi home", 250},
+		{"exp_deleted", "line_u1", "Lunch with team", 600},
+		{"exp_other", "line_u2", "Lunch", 120},
+	} {
+		date := now.AddDate(0, 0, -i)
+		if err := repo.Create(ctx, &domain.Expense{ID: e.id, UserID: e.userID, Description: e.description, Amount: e.amount, ExpenseDate: date, CreatedAt: date}); err != nil {
+			t.Fatalf("failed to create expense: %v", err)
+		}
+	}
+	if err := repo.Delete(ctx, "exp_deleted"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	search := func(terms []string, mutate ...func(*domain.ExpenseSearchQuery)) ([]string, int) {
+		t.Helper()
+		query := &domain.ExpenseSearchQuery{
+			UserID:    "line_u1",
+			Terms:     terms,
+			StartDate: now.AddDate(-1, 0, 0),
+			EndDate:   now,
+			SortBy:    domain.SearchSortRelevance,
+		}
+		for _, m := range mutate {
+			m(query)
+		}
+		hits, total, err := repo.Search(ctx, query)
+		if err != nil {
+			t.Fatalf("Search %v failed: %v", terms, err)
+		}
+		var ids []string
+		for _, hit := range hits {
+			ids = append(ids, hit.Expense.ID)
+		}
+		return ids, total
+	}
+
+	// The description mentioning lunch twice ranks first
+	if ids, total := search([]string{"lunch"}); total != 2 || strings.Join(ids, ",") != "exp_cafe,exp_box" {
+		t.Errorf("expected both of line_u1's lunches, best first, got %v (%d)", ids, total)
+	}
+	if ids, _ := search([]string{"lun"}); len(ids) != 2 {
+		t.Errorf("expected a prefix to match, got %v", ids)
+	}
+	if ids, _ := search([]string{"lunhc"}); len(ids) != 2 {
+		t.Errorf("expected a misspelling to match, got %v", ids)
+	}
+	if ids, _ := search([]string{"cafe", "lunch"}); strings.Join(ids, ",") != "exp_cafe" {
+		t.Errorf("expected every term to match, ignoring accents, got %v", ids)
+	}
+	if ids, _ := search([]string{"便當"}); strings.Join(ids, ",") != "exp_bento" {
+		t.Errorf("expected a substring of a CJK description to match, got %v", ids)
+	}
+	if ids, _ := search([]string{"tax"}); strings.Join(ids, ",") != "exp_taxi" {
+		t.Errorf("expected tax to match taxi, got %v", ids)
+	}
+	if ids, _ := search([]string{"lnc"}); ids != nil {
+		t.Errorf("expected no typos in short terms, got %v", ids)
+	}
+
+	// Filters, sorting and paging
+	minAmount := 100.0
+	if ids, _ := search([]string{"lunch"}, func(q *domain.ExpenseSearchQuery) { q.MinAmount = &minAmount }); strings.Join(ids, ",") != "exp_cafe" {
+		t.Errorf("expected the amount filter to apply, got %v", ids)
+	}
+	ids, total := search(nil, func(q *domain.ExpenseSearchQuery) {
+		q.SortBy = domain.SearchSortAmountDesc
+		q.Limit = 2
+		q.Offset = 1
+	})
+	if total != 4 || strings.Join(ids, ",") != "exp_cafe,exp_bento" {
+		t.Errorf("expected the second page by amount, got %v (%d)", ids, total)
+	}
+
+	// Edits and deletes keep the index current
+	expense, err := repo.GetByID(ctx, "exp_taxi")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	expense.Description = "Lunch delivery"
+	if err := repo.Update(ctx, expense); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if ids, _ := search([]string{"taxi"}); ids != nil {
+		t.Errorf("expected the old description to be gone, got %v", ids)
+	}
+	if _, err := db.Exec(`DELETE FROM expenses WHERE id = 'exp_box'`); err != nil {
+		t.Fatalf("failed to delete expense: %v", err)
+	}
+	if ids, total := search([]string{"lunch"}); total != 2 || strings.Join(ids, ",") != "exp_cafe,exp_taxi" {
+		t.Errorf("expected the edited expense to match, got %v (%d)", ids, total)
+	}
+}
+
+func TestSQLiteTagRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	users := NewUserRepository(db)
+	expenses := NewExpenseRepository(db)
+	repo := NewTagRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	if err := users.Create(ctx, &domain.User{UserID: "line_u1", MessengerType: "line", CreatedAt: now}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	for _, e := range []struct {
+		id     string
+		amount float64
+	}{{"exp_taxi", 300}, {"exp_hotel", 2000}, {"exp_lunch", 120}} {
+		if err := expenses.Create(ctx, &domain.Expense{ID: e.id, UserID: "line_u1", Description: e.id, Amount: e.amount, ExpenseDate: now, CreatedAt: now}); err != nil {
+			t.Fatalf("failed to create expense: %v", err)
+		}
+	}
+
+	trip := &domain.Tag{ID: "tag_trip", UserID: "line_u1", Name: "出差", CreatedAt: now}
+	claim := &domain.Tag{ID: "tag_claim", UserID: "line_u1", Name: "報帳", CreatedAt: now}
+	for _, tag := range []*domain.Tag{trip, claim} {
+		if err := repo.Create(ctx, tag); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	if err := repo.Create(ctx, &domain.Tag{ID: "tag_dup", UserID: "line_u1", Name: "出差", CreatedAt: now}); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("expected a duplicate name to conflict, got %v", err)
+	}
+	if tag, err := repo.GetByUserIDAndName(ctx, "line_u1", "報帳"); err != nil || tag.ID != "tag_claim" {
+		t.Errorf("expected to find the tag by name, got %v, %v", tag, err)
+	}
+
+	if err := repo.SetExpenseTags(ctx, "exp_taxi", []string{"tag_trip", "tag_claim"}); err != nil {
+		t.Fatalf("SetExpenseTags failed: %v", err)
+	}
+	if err := repo.SetExpenseTags(ctx, "exp_hotel", []string{"tag_trip"}); err != nil {
+		t.Fatalf("SetExpenseTags failed: %v", err)
+	}
+	names, err := repo.GetNamesByExpenseIDs(ctx, []string{"exp_taxi", "exp_hotel", "exp_lunch"})
+	if err != nil {
+		t.Fatalf("GetNamesByExpenseIDs failed: %v", err)
+	}
+	if strings.Join(names["exp_taxi"], ",") != "出差,報帳" || strings.Join(names["exp_hotel"], ",") != "出差" || names["exp_lunch"] != nil {
+		t.Errorf("unexpected tag names: %v", names)
+	}
+
+	totals, err := repo.SumByTagAndDateRange(ctx, domain.ExpenseTotalsQuery{UserID: "line_u1", From: now.AddDate(0, 0, -1), To: now.AddDate(0, 0, 1)})
+	if err != nil {
+		t.Fatalf("SumByTagAndDateRange failed: %v", err)
+	}
+	if len(totals) != 2 || totals[0].Name != "出差" || totals[0].Total != 2300 || totals[0].Count != 2 || totals[1].Total != 300 {
+		t.Errorf("unexpected tag totals: %+v", totals)
+	}
+
+	filtered, err := expenses.Filter(ctx, domain.ExpenseFilter{UserID: "line_u1", Tags: []string{"出差", "報帳"}})
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != "exp_taxi" {
+		t.Errorf("expected every tag to have to match, got %v", filtered)
+	}
+
+	// Replacing the tags drops the old links; deleting a tag drops its links
+	if err := repo.SetExpenseTags(ctx, "exp_taxi", []string{"tag_claim"}); err != nil {
+		t.Fatalf("SetExpenseTags failed: %v", err)
+	}
+	if err := repo.Delete(ctx, "tag_trip"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	names, err = repo.GetNamesByExpenseIDs(ctx, []string{"exp_taxi", "exp_hotel"})
+	if err != nil {
+		t.Fatalf("GetNamesByExpenseIDs failed: %v", err)
+	}
+	if strings.Join(names["exp_taxi"], ",") != "報帳" || names["exp_hotel"] != nil {
+		t.Errorf("unexpected tag names after the changes: %v", names)
+	}
+	claim.Name = "claim"
+	if err := repo.Update(ctx, claim); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.Update(ctx, trip); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected updating a deleted tag to be not found, got %v", err)
+	}
+}
+
+func TestSQLiteExpenseClaims(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	users := NewUserRepository(db)
+	expenses := NewExpenseRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	if err := users.Create(ctx, &domain.User{UserID: "line_u1", MessengerType: "line", CreatedAt: now}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	for _, id := range []string{"exp_taxi", "exp_hotel", "exp_lunch"} {
+		expense := &domain.Expense{ID: id, UserID: "line_u1", Description: id, Amount: 100, ExpenseDate: now, CreatedAt: now}
+		expense.SetReimbursable(id != "exp_lunch")
+		if err := expenses.Create(ctx, expense); err != nil {
+			t.Fatalf("failed to create expense: %v", err)
+		}
+	}
+
+	hotel, err := expenses.GetByID(ctx, "exp_hotel")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if !hotel.Reimbursable || hotel.ClaimStatus != domain.ClaimStatusPending {
+		t.Fatalf("expected a pending claim, got %v %q", hotel.Reimbursable, hotel.ClaimStatus)
+	}
+	hotel.ClaimStatus = domain.ClaimStatusSubmitted
+	if err := expenses.Update(ctx, hotel); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	reimbursable := true
+	filtered, err := expenses.Filter(ctx, domain.ExpenseFilter{UserID: "line_u1", Reimbursable: &reimbursable})
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	if len(filtered) != 2 {
+		t.Errorf("expected the 2 reimbursable expenses, got %d", len(filtered))
+	}
+	filtered, err = expenses.Filter(ctx, domain.ExpenseFilter{UserID: "line_u1", ClaimStatuses: []string{domain.ClaimStatusSubmitted, domain.ClaimStatusPaid}})
+	if err != nil {
+		t.Fatalf("Filter failed: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != "exp_hotel" || filtered[0].ClaimStatus != domain.ClaimStatusSubmitted {
+		t.Errorf("expected the submitted claim, got %v", filtered)
+	}
+}
+
+func TestSQLiteExpenseItemRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	users := NewUserRepository(db)
+	expenses := NewExpenseRepository(db)
+	repo := NewExpenseItemRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	if err := users.Create(ctx, &domain.User{UserID: "line_u1", MessengerType: "line", CreatedAt: now}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	for _, id := range []string{"exp_food", "exp_taxi"} {
+		if err := expenses.Create(ctx, &domain.Expense{ID: id, UserID: "line_u1", Description: id, Amount: 175, ExpenseDate: now, CreatedAt: now}); err != nil {
+			t.Fatalf("failed to create expense: %v", err)
+		}
+	}
+
+	items := []*domain.ExpenseItem{
+		{ID: "item_sandwich", Name: "Sandwich", Quantity: 1, UnitPrice: 45, Position: 1, CreatedAt: now},
+		{ID: "item_latte", Name: "Latte", Quantity: 2, UnitPrice: 65, Position: 0, CreatedAt: now},
+	}
+	if err := repo.ReplaceForExpense(ctx, "exp_food", items); err != nil {
+		t.Fatalf("ReplaceForExpense failed: %v", err)
+	}
+	byExpense, err := repo.GetByExpenseIDs(ctx, []string{"exp_food", "exp_taxi"})
+	if err != nil {
+		t.Fatalf("GetByExpenseIDs failed: %v", err)
+	}
+	food := byExpense["exp_food"]
+	if len(food) != 2 || food[0].Name != "Latte" || food[0].Quantity != 2 || food[0].UnitPrice != 65 || food[0].ExpenseID != "exp_food" || food[1].Name != "Sandwich" {
+		t.Errorf("expected the items in receipt order, got %+v", food)
+	}
+	if byExpense["exp_taxi"] != nil {
+		t.Errorf("expected no items for an expense typed in, got %+v", byExpense["exp_taxi"])
+	}
+
+	// Replacing drops the old items; deleting the expense drops its items
+	if err := repo.ReplaceForExpense(ctx, "exp_food", items[:1]); err != nil {
+		t.Fatalf("ReplaceForExpense failed: %v", err)
+	}
+	if byExpense, err = repo.GetByExpenseIDs(ctx, []string{"exp_food"}); err != nil || len(byExpense["exp_food"]) != 1 {
+		t.Errorf("expected 1 item after replacing, got %v, %v", byExpense, err)
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM expenses WHERE id = ?`, "exp_food"); err != nil {
+		t.Fatalf("failed to delete expense: %v", err)
+	}
+	if byExpense, err = repo.GetByExpenseIDs(ctx, []string{"exp_food"}); err != nil || len(byExpense) != 0 {
+		t.Errorf("expected the items to cascade, got %v, %v", byExpense, err)
+	}
+}
+
+func TestSQLiteMerchantRepository(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	users := NewUserRepository(db)
+	expenses := NewExpenseRepository(db)
+	repo := NewMerchantRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	if err := users.Create(ctx, &domain.User{UserID: "line_u1", MessengerType: "line", CreatedAt: now}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	seven := &domain.Merchant{ID: "m_seven", UserID: "line_u1", Name: "7-ELEVEN", NormalizedName: "7eleven", CreatedAt: now}
+	cafe := &domain.Merchant{ID: "m_cafe", UserID: "line_u1", Name: "Starbucks", NormalizedName: "starbucks", CreatedAt: now}
+	for _, merchant := range []*domain.Merchant{seven, cafe} {
+		if err := repo.Create(ctx, merchant); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+	if err := repo.Create(ctx, &domain.Merchant{ID: "m_dup", UserID: "line_u1", Name: "7-11", NormalizedName: "7eleven", CreatedAt: now}); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("expected a duplicate normalized name to conflict, got %v", err)
+	}
+	if merchant, err := repo.GetByUserIDAndNormalizedName(ctx, "line_u1", "starbucks"); err != nil || merchant.ID != "m_cafe" {
+		t.Errorf("expected to find the merchant by normalized name, got %v, %v", merchant, err)
+	}
+	if _, err := repo.GetByID(ctx, "m_missing"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected a missing merchant to be not found, got %v", err)
+	}
+
+	for _, e := range []struct {
+		id       string
+		amount   float64
+		merchant *string
+		date     time.Time
+	}{
+		{"exp_coffee", 65, &seven.ID, now},
+		{"exp_snacks", 40, &seven.ID, now.AddDate(0, -1, 0)},
+		{"exp_latte", 150, &cafe.ID, now},
+		{"exp_dinner", 900, nil, now},
+	} {
+		if err := expenses.Create(ctx, &domain.Expense{ID: e.id, UserID: "line_u1", Description: e.id, Amount: e.amount, MerchantID: e.merchant, ExpenseDate: e.date, CreatedAt: e.date}); err != nil {
+			t.Fatalf("failed to create expense: %v", err)
+		}
+	}
+	if expense, err := expenses.GetByID(ctx, "exp_coffee"); err != nil || expense.MerchantID == nil || *expense.MerchantID != "m_seven" {
+		t.Errorf("expected the expense to keep its merchant, got %v, %v", expense, err)
+	}
+
+	query := domain.ExpenseTotalsQuery{UserID: "line_u1", From: now.AddDate(0, -2, 0), To: now.AddDate(0, 0, 1)}
+	totals, err := repo.SumByMerchantAndDateRange(ctx, query)
+	if err != nil {
+		t.Fatalf("SumByMerchantAndDateRange failed: %v", err)
+	}
+	if len(totals) != 2 || totals[0].Name != "Starbucks" || totals[0].Total != 150 || totals[1].Total != 105 || totals[1].Count != 2 {
+		t.Errorf("unexpected merchant totals: %+v", totals)
+	}
+
+	query.MerchantID = "m_seven"
+	months, err := expenses.SumByPeriodAndDateRange(ctx, query, domain.TotalsPeriodMonth)
+	if err != nil {
+		t.Fatalf("SumByPeriodAndDateRange failed: %v", err)
+	}
+	if len(months) != 2 || months[0].Total != 40 || months[1].Total != 65 {
+		t.Errorf("unexpected monthly totals at the merchant: %+v", months)
+	}
+}
+
+func TestSQLiteWorkspaces(t *testing.T) {
+	tmpfile, err := os.CreateTemp("", "test_*.db")
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(tmpfile.Name())
+	tmpfile.Close()
+
+	db, err := OpenDB(tmpfile.Name())
+	if err != nil {
+		t.Skipf("Skipping integration test: could not open database: %v (run from project root)", err)
+		return
+	}
+	defer db.Close()
+
+	users := NewUserRepository(db)
+	workspaces := NewWorkspaceRepository(db)
+	categories := NewCategoryRepository(db)
+	expenses := NewExpenseRepository(db)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	if err := users.Create(ctx, &domain.User{UserID: "line_u1", MessengerType: "line", CreatedAt: now}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	business := &domain.Workspace{ID: "ws_business", UserID: "line_u1", Name: "公司", CreatedAt: now}
+	if err := workspaces.Create(ctx, business); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := workspaces.Create(ctx, &domain.Workspace{ID: "ws_dup", UserID: "line_u1", Name: "公司", CreatedAt: now}); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("expected a duplicate workspace name to conflict, got %v", err)
+	}
+
+	user, err := users.GetByID(ctx, "line_u1")
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	user.ActiveWorkspace = business.ID
+	if err := users.Update(ctx, user); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if user, err := users.GetByID(ctx, "line_u1"); err != nil || user.ActiveWorkspace != business.ID {
+		t.Errorf("expected the active workspace to be kept, got %v, %v", user, err)
+	}
+
+	// Each workspace has its own Food category
+	personalCtx := domain.WithWorkspace(ctx, domain.PersonalWorkspaceID)
+	businessCtx := domain.WithWorkspace(ctx, business.ID)
+	for _, category := range []*domain.Category{
+		{ID: "cat_food", UserID: "line_u1", Name: "Food", CreatedAt: now},
+		{ID: "cat_business_food", UserID: "line_u1", Name: "Food", WorkspaceID: business.ID, CreatedAt: now},
+	} {
+		if err := categories.Create(ctx, category); err != nil {
+			t.Fatalf("failed to create category: %v", err)
+		}
+	}
+	if category, err := categories.GetByUserIDAndName(businessCtx, "line_u1", "Food"); err != nil || category.ID != "cat_business_food" {
+		t.Errorf("expected the business workspace's category, got %v, %v", category, err)
+	}
+	if category, err := categories.GetByUserIDAndName(personalCtx, "line_u1", "Food"); err != nil || category.ID != "cat_food" {
+		t.Errorf("expected the personal workspace's category, got %v, %v", category, err)
+	}
+	if all, err := categories.GetByUserID(ctx, "line_u1"); err != nil || len(all) != 2 {
+		t.Errorf("expected both categories without a workspace, got %d, %v", len(all), err)
+	}
+
+	for _, e := range []*domain.Expense{
+		{ID: "exp_dinner", UserID: "line_u1", Description: "Dinner", Amount: 500, ExpenseDate: now, CreatedAt: now},
+		{ID: "exp_lunch", UserID: "line_u1", Description: "Client lunch", Amount: 1200, WorkspaceID: business.ID, ExpenseDate: now, CreatedAt: now},
+	} {
+		if err := expenses.Create(ctx, e); err != nil {
+			t.Fatalf("failed to create expense: %v", err)
+		}
+	}
+	if listed, err := expenses.GetByUserID(businessCtx, "line_u1"); err != nil || len(listed) != 1 || listed[0].ID != "exp_lunch" || listed[0].WorkspaceID != business.ID {
+		t.Errorf("expected only the business expense, got %v, %v", listed, err)
+	}
+	if listed, err := expenses.GetByUserID(ctx, "line_u1"); err != nil || len(listed) != 2 {
+		t.Errorf("expected both expenses without a workspace, got %d, %v", len(listed), err)
+	}
+	query := domain.ExpenseTotalsQuery{UserID: "line_u1", From: now.AddDate(0, 0, -1), To: now.AddDate(0, 0, 1)}
+	if totals, err := expenses.SumByPeriodAndDateRange(personalCtx, query, domain.TotalsPeriodMonth); err != nil || len(totals) != 1 || totals[0].Total != 500 {
+		t.Errorf("expected the personal workspace's total, got %+v, %v", totals, err)
+	}
 }
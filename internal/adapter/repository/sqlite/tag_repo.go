@@ -0,0 +1,176 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.TagRepository = (*TagRepository)(nil)
+
+// maxTagLookupIDs is how many expense IDs one tag lookup binds, well below
+// SQLite's limit on query parameters
+const maxTagLookupIDs = 500
+
+// TagRepository stores tags and the expenses they label in SQLite
+type TagRepository struct {
+	db *sql.DB
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// Create creates a new tag
+func (r *TagRepository) Create(ctx context.Context, tag *domain.Tag) error {
+	const query = `INSERT INTO tags (id, user_id, name, created_at) VALUES (?, ?, ?, ?)`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, tag.ID, tag.UserID, tag.Name, tag.CreatedAt)
+	return conflictErr(err)
+}
+
+// GetByID retrieves a tag by ID
+func (r *TagRepository) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	const query = `SELECT id, user_id, name, created_at FROM tags WHERE id = ?`
+	return scanTag(txOrDB(ctx, r.db).QueryRowContext(ctx, query, id))
+}
+
+// GetByUserIDAndName retrieves a tag by user and name
+func (r *TagRepository) GetByUserIDAndName(ctx context.Context, userID, name string) (*domain.Tag, error) {
+	const query = `SELECT id, user_id, name, created_at FROM tags WHERE user_id = ? AND name = ?`
+	return scanTag(txOrDB(ctx, r.db).QueryRowContext(ctx, query, userID, name))
+}
+
+func scanTag(row *sql.Row) (*domain.Tag, error) {
+	tag := &domain.Tag{}
+	if err := row.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, err
+	}
+	return tag, nil
+}
+
+// GetByUserID retrieves all tags for a user, by name
+func (r *TagRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Tag, error) {
+	const query = `SELECT id, user_id, name, created_at FROM tags WHERE user_id = ? ORDER BY name`
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*domain.Tag
+	for rows.Next() {
+		tag := &domain.Tag{}
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// Update renames a tag
+func (r *TagRepository) Update(ctx context.Context, tag *domain.Tag) error {
+	const query = `UPDATE tags SET name = ? WHERE id = ?`
+	result, err := txOrDB(ctx, r.db).ExecContext(ctx, query, tag.Name, tag.ID)
+	if err != nil {
+		return conflictErr(err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+// Delete deletes a tag; its expense links cascade
+func (r *TagRepository) Delete(ctx context.Context, id string) error {
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, `DELETE FROM tags WHERE id = ?`, id)
+	return err
+}
+
+// SetExpenseTags replaces the tags of an expense in one transaction
+func (r *TagRepository) SetExpenseTags(ctx context.Context, expenseID string, tagIDs []string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM expense_tags WHERE expense_id = ?`, expenseID); err != nil {
+		return err
+	}
+	for _, tagID := range tagIDs {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO expense_tags (expense_id, tag_id) VALUES (?, ?)`, expenseID, tagID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetNamesByExpenseIDs returns the tag names of each expense, by name
+func (r *TagRepository) GetNamesByExpenseIDs(ctx context.Context, expenseIDs []string) (map[string][]string, error) {
+	names := make(map[string][]string)
+	for start := 0; start < len(expenseIDs); start += maxTagLookupIDs {
+		ids := expenseIDs[start:min(start+maxTagLookupIDs, len(expenseIDs))]
+		args := make([]any, len(ids))
+		for i, id := range ids {
+			args[i] = id
+		}
+		rows, err := txOrDB(ctx, r.db).QueryContext(ctx, `
+			SELECT et.expense_id, t.name
+			FROM expense_tags et JOIN tags t ON t.id = et.tag_id
+			WHERE et.expense_id IN (?`+strings.Repeat(", ?", len(ids)-1)+`)
+			ORDER BY t.name
+		`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var expenseID, name string
+			if err := rows.Scan(&expenseID, &name); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			names[expenseID] = append(names[expenseID], name)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// SumByTagAndDateRange totals the selected expenses per tag, largest total first
+func (r *TagRepository) SumByTagAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.TagTotal, error) {
+	where, args := expenseTotalsScope(ctx, query)
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, `
+		SELECT t.id, t.name, SUM(e.home_amount) AS total, COUNT(*)
+		FROM expense_tags et
+		JOIN tags t ON t.id = et.tag_id
+		JOIN (SELECT id, home_amount FROM expenses WHERE `+where+`) e ON e.id = et.expense_id
+		GROUP BY t.id, t.name
+		ORDER BY total DESC, t.name
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*domain.TagTotal
+	for rows.Next() {
+		total := &domain.TagTotal{}
+		if err := rows.Scan(&total.TagID, &total.Name, &total.Total, &total.Count); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	return totals, rows.Err()
+}
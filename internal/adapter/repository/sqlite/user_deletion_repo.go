@@ -0,0 +1,222 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.UserDeletionRepository = (*UserDeletionRepository)(nil)
+
+// UserDeletionRepository stores data deletion requests and purges users' data in SQLite
+type UserDeletionRepository struct {
+	db *sql.DB
+}
+
+// NewUserDeletionRepository creates a new user deletion repository
+func NewUserDeletionRepository(db *sql.DB) *UserDeletionRepository {
+	return &UserDeletionRepository{db: db}
+}
+
+const userDeletionColumns = `id, user_id, status, requested_at, purge_after, purged_at, deleted_counts`
+
+// userPurgeSteps delete a user's rows, children before the rows they reference.
+// Each statement takes the user ID as ?1.
+var userPurgeSteps = []struct {
+	table string
+	query string
+}{
+	{"webhook_deliveries", `DELETE FROM webhook_deliveries WHERE subscription_id IN (SELECT id FROM webhook_subscriptions WHERE user_id = ?1)`},
+	{"webhook_subscriptions", `DELETE FROM webhook_subscriptions WHERE user_id = ?1`},
+	{"report_schedules", `DELETE FROM report_schedules WHERE user_id = ?1`},
+	{"email_addresses", `DELETE FROM email_addresses WHERE user_id = ?1`},
+	{"conversation_states", `DELETE FROM conversation_states WHERE user_id = ?1`},
+	{"archive_policies", `DELETE FROM archive_policies WHERE user_id = ?1`},
+	{"archives", `DELETE FROM archives WHERE user_id = ?1`},
+	{"notification_preferences", `DELETE FROM notification_preferences WHERE user_id = ?1`},
+	{"expense_audit_log", `DELETE FROM expense_audit_log WHERE user_id = ?1`},
+	{"category_corrections", `DELETE FROM category_corrections WHERE user_id = ?1`},
+	{"expense_attachments", `DELETE FROM expense_attachments WHERE user_id = ?1 OR expense_id IN (SELECT id FROM expenses WHERE user_id = ?1)`},
+	{"expense_splits", `DELETE FROM expense_splits WHERE payer_id = ?1 OR expense_id IN (SELECT id FROM expenses WHERE user_id = ?1)`},
+	{"expense_tags", `DELETE FROM expense_tags WHERE tag_id IN (SELECT id FROM tags WHERE user_id = ?1) OR expense_id IN (SELECT id FROM expenses WHERE user_id = ?1)`},
+	{"expense_items", `DELETE FROM expense_items WHERE expense_id IN (SELECT id FROM expenses WHERE user_id = ?1)`},
+	{"budgets", `DELETE FROM budgets WHERE user_id = ?1 OR category_id IN (SELECT id FROM categories WHERE user_id = ?1)`},
+	{"group_members", `DELETE FROM group_members WHERE user_id = ?1`},
+	{"expenses", `DELETE FROM expenses WHERE user_id = ?1`},
+	{"category_keywords", `DELETE FROM category_keywords WHERE category_id IN (SELECT id FROM categories WHERE user_id = ?1)`},
+	{"categories", `DELETE FROM categories WHERE user_id = ?1`},
+	{"tags", `DELETE FROM tags WHERE user_id = ?1`},
+	{"merchants", `DELETE FROM merchants WHERE user_id = ?1`},
+	{"workspaces", `DELETE FROM workspaces WHERE user_id = ?1`},
+	{"interaction_logs", `DELETE FROM interaction_logs WHERE user_id = ?1`},
+	{"ai_cost_logs", `DELETE FROM ai_cost_logs WHERE user_id = ?1`},
+	{"ai_cost_caps", `DELETE FROM ai_cost_caps WHERE scope = ?1`},
+	{"users", `DELETE FROM users WHERE user_id = ?1`},
+}
+
+// Create stores a new deletion request
+func (r *UserDeletionRepository) Create(ctx context.Context, deletion *domain.UserDeletion) error {
+	counts, err := json.Marshal(deletion.DeletedCounts)
+	if err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO user_deletions (` + userDeletionColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		deletion.ID,
+		deletion.UserID,
+		deletion.Status,
+		deletion.RequestedAt,
+		deletion.PurgeAfter,
+		deletion.PurgedAt,
+		string(counts),
+	)
+	return err
+}
+
+// Update saves a deletion's status and purge results
+func (r *UserDeletionRepository) Update(ctx context.Context, deletion *domain.UserDeletion) error {
+	counts, err := json.Marshal(deletion.DeletedCounts)
+	if err != nil {
+		return err
+	}
+	const query = `
+		UPDATE user_deletions
+		SET status = ?, purge_after = ?, purged_at = ?, deleted_counts = ?
+		WHERE id = ?
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		deletion.Status,
+		deletion.PurgeAfter,
+		deletion.PurgedAt,
+		string(counts),
+		deletion.ID,
+	)
+	return err
+}
+
+// GetPending retrieves the user's pending deletion, or nil if there is none
+func (r *UserDeletionRepository) GetPending(ctx context.Context, userID string) (*domain.UserDeletion, error) {
+	const query = `SELECT ` + userDeletionColumns + ` FROM user_deletions WHERE user_id = ? AND status = ? LIMIT 1`
+	deletions, err := r.query(ctx, query, userID, domain.UserDeletionPending)
+	if err != nil {
+		return nil, err
+	}
+	if len(deletions) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return deletions[0], nil
+}
+
+// GetDue retrieves pending deletions whose grace period ended at or before now
+func (r *UserDeletionRepository) GetDue(ctx context.Context, now time.Time) ([]*domain.UserDeletion, error) {
+	const query = `SELECT ` + userDeletionColumns + ` FROM user_deletions WHERE status = ? AND purge_after <= ? ORDER BY purge_after ASC`
+	return r.query(ctx, query, domain.UserDeletionPending, now)
+}
+
+// List retrieves the most recent deletions, newest first
+func (r *UserDeletionRepository) List(ctx context.Context, limit int) ([]*domain.UserDeletion, error) {
+	const query = `SELECT ` + userDeletionColumns + ` FROM user_deletions ORDER BY requested_at DESC LIMIT ?`
+	return r.query(ctx, query, limit)
+}
+
+// PurgeUserData deletes the user and every row that belongs to them in one
+// transaction. Audit entries the user wrote on other users' expenses are kept
+// with the actor anonymized.
+func (r *UserDeletionRepository) PurgeUserData(ctx context.Context, userID string) (*domain.UserPurge, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	purge := &domain.UserPurge{DeletedCounts: make(map[string]int64)}
+
+	purge.StorageKeys, err = queryKeys(ctx, tx, `
+		SELECT storage_key FROM expense_attachments
+		WHERE user_id = ?1 OR expense_id IN (SELECT id FROM expenses WHERE user_id = ?1)
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	purge.ArchiveKeys, err = queryKeys(ctx, tx, `SELECT object_key FROM archives WHERE user_id = ?1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+
+	for _, step := range userPurgeSteps {
+		result, err := tx.ExecContext(ctx, step.query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete from %s: %w", step.table, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to count rows deleted from %s: %w", step.table, err)
+		}
+		purge.DeletedCounts[step.table] = n
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE expense_audit_log SET actor = ? WHERE actor = ?`, domain.DeletedUserActor, userID); err != nil {
+		return nil, fmt.Errorf("failed to anonymize audit log: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit purge: %w", err)
+	}
+	return purge, nil
+}
+
+// queryKeys returns the blob storage keys a purge is about to orphan
+func queryKeys(ctx context.Context, tx dbtx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *UserDeletionRepository) query(ctx context.Context, query string, args ...interface{}) ([]*domain.UserDeletion, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deletions []*domain.UserDeletion
+	for rows.Next() {
+		deletion := &domain.UserDeletion{}
+		var counts string
+		if err := rows.Scan(
+			&deletion.ID,
+			&deletion.UserID,
+			&deletion.Status,
+			&deletion.RequestedAt,
+			&deletion.PurgeAfter,
+			&deletion.PurgedAt,
+			&counts,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(counts), &deletion.DeletedCounts); err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions, rows.Err()
+}
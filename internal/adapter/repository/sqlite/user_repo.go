@@ -22,8 +22,8 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 // Create creates a new user
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	const query = `
-		INSERT INTO users (user_id, messenger_type, created_at, home_currency, locale)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO users (user_id, messenger_type, created_at, home_currency, locale, onboarded_at, timezone)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 	homeCurrency := user.HomeCurrency
 	if homeCurrency == "" {
@@ -33,44 +33,56 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	if locale == "" {
 		locale = "zh-TW"
 	}
-	_, err := r.db.ExecContext(ctx, query, user.UserID, user.MessengerType, user.CreatedAt, homeCurrency, locale)
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, user.UserID, user.MessengerType, user.CreatedAt, homeCurrency, locale, user.OnboardedAt, user.Timezone)
+	return conflictErr(err)
 }
 
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(ctx context.Context, userID string) (*domain.User, error) {
 	const query = `
-		SELECT user_id, messenger_type, created_at, home_currency, locale
+		SELECT user_id, messenger_type, created_at, home_currency, locale, onboarded_at, timezone, active_budget, active_workspace
 		FROM users
 		WHERE user_id = ?
 	`
 	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(
 		&user.UserID,
 		&user.MessengerType,
 		&user.CreatedAt,
 		&user.HomeCurrency,
 		&user.Locale,
+		&user.OnboardedAt,
+		&user.Timezone,
+		&user.ActiveBudget,
+		&user.ActiveWorkspace,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil
+			return nil, domain.ErrNotFound
 		}
 		return nil, err
 	}
 	return user, nil
 }
 
+// Update saves the user's preferences, onboarding, timezone, active budget and
+// active workspace
+func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
+	const query = `
+		UPDATE users SET home_currency = ?, locale = ?, onboarded_at = ?, timezone = ?, active_budget = ?, active_workspace = ?
+		WHERE user_id = ?
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query, user.HomeCurrency, user.Locale, user.OnboardedAt, user.Timezone, user.ActiveBudget, user.ActiveWorkspace, user.UserID)
+	return err
+}
+
 // Exists checks if a user exists
 func (r *UserRepository) Exists(ctx context.Context, userID string) (bool, error) {
 	const query = `
 		SELECT 1 FROM users WHERE user_id = ?
 	`
 	var exists int
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(&exists)
+	err := txOrDB(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(&exists)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return false, nil
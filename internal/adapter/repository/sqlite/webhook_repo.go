@@ -0,0 +1,232 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.WebhookRepository = (*WebhookRepository)(nil)
+
+// WebhookRepository stores webhook subscriptions and deliveries in SQLite
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookSubscriptionColumns = `id, user_id, url, secret, events, enabled, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, subscription_id, event, payload, status, attempts, response_status, last_error, next_attempt_at, created_at, delivered_at`
+
+// CreateSubscription creates a new webhook subscription
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	events, err := json.Marshal(subscription.Events)
+	if err != nil {
+		return err
+	}
+	const query = `
+		INSERT INTO webhook_subscriptions (` + webhookSubscriptionColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		subscription.ID,
+		subscription.UserID,
+		subscription.URL,
+		subscription.Secret,
+		string(events),
+		subscription.Enabled,
+		subscription.CreatedAt,
+		subscription.UpdatedAt,
+	)
+	return err
+}
+
+// GetSubscription retrieves a subscription by ID, or nil if it does not exist
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	const query = `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = ?`
+	subscriptions, err := r.querySubscriptions(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, domain.ErrNotFound
+	}
+	return subscriptions[0], nil
+}
+
+// ListSubscriptions retrieves the user's subscriptions, oldest first
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context, userID string) ([]*domain.WebhookSubscription, error) {
+	const query = `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE user_id = ? ORDER BY created_at ASC`
+	return r.querySubscriptions(ctx, query, userID)
+}
+
+// UpdateSubscription updates a subscription's URL, events and enabled flag
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	events, err := json.Marshal(subscription.Events)
+	if err != nil {
+		return err
+	}
+	const query = `
+		UPDATE webhook_subscriptions
+		SET url = ?, events = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`
+	_, err = txOrDB(ctx, r.db).ExecContext(ctx, query,
+		subscription.URL,
+		string(events),
+		subscription.Enabled,
+		subscription.UpdatedAt,
+		subscription.ID,
+	)
+	return err
+}
+
+// DeleteSubscription deletes a subscription along with its deliveries
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE subscription_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateDelivery records a new delivery
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	const query = `
+		INSERT INTO webhook_deliveries (` + webhookDeliveryColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		delivery.ID,
+		delivery.SubscriptionID,
+		delivery.Event,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+		delivery.DeliveredAt,
+	)
+	return err
+}
+
+// UpdateDelivery records the outcome of a delivery attempt
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	const query = `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, response_status = ?, last_error = ?, next_attempt_at = ?, delivered_at = ?
+		WHERE id = ?
+	`
+	_, err := txOrDB(ctx, r.db).ExecContext(ctx, query,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.ResponseStatus,
+		delivery.LastError,
+		delivery.NextAttemptAt,
+		delivery.DeliveredAt,
+		delivery.ID,
+	)
+	return err
+}
+
+// ListDeliveries retrieves a subscription's most recent deliveries, newest first
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*domain.WebhookDelivery, error) {
+	const query = `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE subscription_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+	return r.queryDeliveries(ctx, query, subscriptionID, limit)
+}
+
+// GetRetryableDeliveries retrieves pending deliveries whose next attempt is at or before now
+func (r *WebhookRepository) GetRetryableDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	const query = `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC
+		LIMIT ?
+	`
+	return r.queryDeliveries(ctx, query, domain.WebhookDeliveryPending, now, limit)
+}
+
+func (r *WebhookRepository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]*domain.WebhookSubscription, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*domain.WebhookSubscription
+	for rows.Next() {
+		subscription := &domain.WebhookSubscription{}
+		var events string
+		if err := rows.Scan(
+			&subscription.ID,
+			&subscription.UserID,
+			&subscription.URL,
+			&subscription.Secret,
+			&events,
+			&subscription.Enabled,
+			&subscription.CreatedAt,
+			&subscription.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(events), &subscription.Events); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (r *WebhookRepository) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]*domain.WebhookDelivery, error) {
+	rows, err := txOrDB(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery := &domain.WebhookDelivery{}
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.ResponseStatus,
+			&delivery.LastError,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
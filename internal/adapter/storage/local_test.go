@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := s.Put(ctx, "user1/receipt.jpg", []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := s.Get(ctx, "user1/receipt.jpg")
+	if err != nil || string(data) != "jpeg" {
+		t.Fatalf("expected stored data, got %q (%v)", data, err)
+	}
+	f, err := s.Open(ctx, "user1/receipt.jpg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = io.ReadAll(f)
+	f.Close()
+	if string(data) != "jpeg" {
+		t.Fatalf("expected streamed data, got %q", data)
+	}
+
+	if err := s.Delete(ctx, "user1/receipt.jpg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Get(ctx, "user1/receipt.jpg"); err == nil {
+		t.Error("expected deleted attachment to be gone")
+	}
+	if err := s.Delete(ctx, "user1/receipt.jpg"); err != nil {
+		t.Errorf("expected deleting a missing attachment to succeed, got %v", err)
+	}
+
+	for _, key := range []string{"", "../secret", "/etc/passwd", "a/../../b"} {
+		if err := s.Put(ctx, key, []byte("x"), ""); err == nil {
+			t.Errorf("expected key %q to be rejected", key)
+		}
+	}
+}
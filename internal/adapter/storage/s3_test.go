@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	objects := make(map[string][]byte)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20260102/auto/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+			t.Errorf("unexpected Authorization header: %q", auth)
+		}
+		if r.Header.Get("X-Amz-Date") != "20260102T030405Z" {
+			t.Errorf("unexpected X-Amz-Date: %q", r.Header.Get("X-Amz-Date"))
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get("X-Amz-Content-Sha256") != sha256Hex(body) {
+				t.Error("payload hash does not match body")
+			}
+			objects[r.URL.Path] = body
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+				return
+			}
+			w.Write(data)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	s, err := NewS3Storage(server.URL, "auto", "receipts", "AKID", "SECRET", server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	if err := s.Put(ctx, "user1/receipt.jpg", []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := objects["/receipts/user1/receipt.jpg"]; !ok {
+		t.Fatalf("expected path-style object key, got %v", objects)
+	}
+
+	data, err := s.Get(ctx, "user1/receipt.jpg")
+	if err != nil || string(data) != "jpeg" {
+		t.Fatalf("expected stored data, got %q (%v)", data, err)
+	}
+	body, err := s.Open(ctx, "user1/receipt.jpg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, _ = io.ReadAll(body)
+	body.Close()
+	if string(data) != "jpeg" {
+		t.Fatalf("expected streamed data, got %q", data)
+	}
+
+	if err := s.Delete(ctx, "user1/receipt.jpg"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Get(ctx, "user1/receipt.jpg"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected 404 error, got %v", err)
+	}
+	if _, err := s.Open(ctx, "user1/receipt.jpg"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected 404 error, got %v", err)
+	}
+}
+
+func TestS3StorageSignatureIsDeterministic(t *testing.T) {
+	s, _ := NewS3Storage("https://minio.local", "us-east-1", "bucket", "AKID", "SECRET", nil)
+	s.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	sign := func(key string) string {
+		req, _ := http.NewRequest(http.MethodGet, "https://minio.local/bucket/"+key, nil)
+		s.sign(req, nil)
+		return req.Header.Get("Authorization")
+	}
+
+	if sign("a.jpg") != sign("a.jpg") {
+		t.Error("expected the same request to get the same signature")
+	}
+	if sign("a.jpg") == sign("b.jpg") {
+		t.Error("expected different objects to get different signatures")
+	}
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Config selects and configures the attachment blob storage backend
+type Config struct {
+	Provider string // "local" or "s3"
+
+	// Local disk
+	Dir string
+
+	// S3-compatible object storage (AWS S3, MinIO, Cloudflare R2, ...)
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+}
+
+// New creates the blob storage for the configured provider
+func New(cfg Config) (domain.BlobStorage, error) {
+	switch cfg.Provider {
+	case "local":
+		return NewLocalStorage(cfg.Dir)
+	case "s3":
+		return NewS3Storage(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKeyID, cfg.S3SecretAccessKey, nil)
+	default:
+		return nil, fmt.Errorf("unsupported attachment storage: %s", cfg.Provider)
+	}
+}
@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// maxCategoryExamples bounds the few-shot examples added to the category prompt
+const maxCategoryExamples = 5
+
+// CategoryExample is an expense the user filed under a category
+type CategoryExample struct {
+	Description string
+	Category    string
+}
+
+// LearnedKeyword is a user keyword for a category; higher priorities win
+type LearnedKeyword struct {
+	Keyword  string
+	Category string
+	Priority int
+}
+
+// CategoryHints is what a user has taught the categorizer through their corrections
+type CategoryHints struct {
+	Examples []CategoryExample // newest first
+	Keywords []LearnedKeyword  // highest priority first
+}
+
+// CategoryHintSource supplies a user's category hints
+type CategoryHintSource interface {
+	GetCategoryHints(ctx context.Context, userID string) (*CategoryHints, error)
+}
+
+// CategoryHintConfigurable is implemented by providers that can use per-user category hints
+type CategoryHintConfigurable interface {
+	SetCategoryHintSource(source CategoryHintSource)
+}
+
+// loadCategoryHints returns the user's hints, or nil when there is no source or it fails
+func loadCategoryHints(ctx context.Context, source CategoryHintSource, userID string) *CategoryHints {
+	if source == nil || userID == "" {
+		return nil
+	}
+	hints, err := source.GetCategoryHints(ctx, userID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load category hints", "user_id", userID, "error", err)
+		return nil
+	}
+	return hints
+}
+
+// categoryExamples returns the hints' few-shot examples for the prompt
+func categoryExamples(hints *CategoryHints) []CategoryExample {
+	if hints == nil {
+		return nil
+	}
+	if len(hints.Examples) > maxCategoryExamples {
+		return hints.Examples[:maxCategoryExamples]
+	}
+	return hints.Examples
+}
+
+// fallbackSuggestCategory matches the user's learned keywords before the built-in ones
+func fallbackSuggestCategory(hints *CategoryHints, description string) string {
+	if hints != nil {
+		lower := strings.ToLower(description)
+		for _, kw := range hints.Keywords {
+			if kw.Priority > 0 && kw.Keyword != "" && strings.Contains(lower, strings.ToLower(kw.Keyword)) {
+				return kw.Category
+			}
+		}
+	}
+	return keywordSuggestCategory(description)
+}
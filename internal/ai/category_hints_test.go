@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestFallbackSuggestCategory(t *testing.T) {
+	hints := &CategoryHints{
+		Keywords: []LearnedKeyword{
+			{Keyword: "全聯", Category: "Groceries", Priority: 3},
+			{Keyword: "午餐", Category: "Work Meals", Priority: 0},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		hints            *CategoryHints
+		description      string
+		expectedCategory string
+	}{
+		{name: "learned keyword", hints: hints, description: "全聯 牛奶", expectedCategory: "Groceries"},
+		{name: "zero priority is ignored", hints: hints, description: "午餐", expectedCategory: "Food"},
+		{name: "no hints", hints: nil, description: "全聯 牛奶", expectedCategory: "Other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if category := fallbackSuggestCategory(tt.hints, tt.description); category != tt.expectedCategory {
+				t.Errorf("expected %q, got %q", tt.expectedCategory, category)
+			}
+		})
+	}
+}
+
+func TestRenderPrompt_CategoryExamples(t *testing.T) {
+	hints := &CategoryHints{Examples: []CategoryExample{{Description: "全聯", Category: "Groceries"}}}
+
+	prompt, _ := renderPrompt(context.Background(), nil, domain.PromptSuggestCategory, PromptData{Description: "全聯 牛奶", Examples: categoryExamples(hints)})
+	if !strings.Contains(prompt, `"全聯" -> Groceries`) {
+		t.Errorf("expected few-shot example in prompt, got %q", prompt)
+	}
+
+	prompt, _ = renderPrompt(context.Background(), nil, domain.PromptSuggestCategory, PromptData{Description: "全聯 牛奶"})
+	if strings.Contains(prompt, "follow their habits") {
+		t.Errorf("expected no examples section without examples, got %q", prompt)
+	}
+}
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling an API whose circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Circuit breaker states reported by CircuitHealth
+const (
+	CircuitClosed   = "closed"    // calls go through
+	CircuitOpen     = "open"      // calls are skipped until open_until
+	CircuitHalfOpen = "half_open" // the next call is a trial
+)
+
+// CircuitHealth is the state of a circuit breaker
+type CircuitHealth struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Calls               int64      `json:"calls"`
+	Failures            int64      `json:"failures"`
+	Retries             int64      `json:"retries,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// circuitBreaker opens after threshold consecutive failures, skipping calls
+// for the cooldown; then one trial call is let through, which closes it again
+// on success
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	openUntil           time.Time
+	calls               int64
+	failures            int64
+	retries             int64
+	lastError           string
+	lastFailureAt       time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may be made. Once an open circuit's cooldown
+// has passed, it lets one trial call through and keeps skipping other calls
+// until that trial is recorded.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.consecutiveFailures < b.threshold {
+		return true
+	}
+	if now.Before(b.openUntil) {
+		return false
+	}
+	b.openUntil = now.Add(b.cooldown)
+	return true
+}
+
+// release undoes allow for a call that wasn't made or whose outcome says
+// nothing about the API
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.consecutiveFailures > 0 {
+		b.openUntil = time.Time{}
+	}
+}
+
+// record tracks a call's outcome and reports whether it opened the circuit
+func (b *circuitBreaker) record(err error, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls++
+	if err == nil {
+		b.consecutiveFailures = 0
+		b.openUntil = time.Time{}
+		return false
+	}
+
+	b.failures++
+	b.consecutiveFailures++
+	b.lastError = err.Error()
+	b.lastFailureAt = now
+	if b.consecutiveFailures < b.threshold {
+		return false
+	}
+	b.openUntil = now.Add(b.cooldown)
+	return b.consecutiveFailures == b.threshold
+}
+
+// countRetry tracks a call that is made again after a failure
+func (b *circuitBreaker) countRetry() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.retries++
+}
+
+func (b *circuitBreaker) health(now time.Time) CircuitHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	health := CircuitHealth{
+		State:               CircuitClosed,
+		ConsecutiveFailures: b.consecutiveFailures,
+		Calls:               b.calls,
+		Failures:            b.failures,
+		Retries:             b.retries,
+		LastError:           b.lastError,
+	}
+	if !b.lastFailureAt.IsZero() {
+		lastFailureAt := b.lastFailureAt
+		health.LastFailureAt = &lastFailureAt
+	}
+	if b.consecutiveFailures >= b.threshold {
+		health.State = CircuitHalfOpen
+		if now.Before(b.openUntil) {
+			openUntil := b.openUntil
+			health.State = CircuitOpen
+			health.OpenUntil = &openUntil
+		}
+	}
+	return health
+}
@@ -0,0 +1,463 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ StreamingService = (*ClaudeAI)(nil)
+var _ ReceiptService = (*ClaudeAI)(nil)
+
+const (
+	defaultClaudeModel      = "claude-3-5-haiku-latest"
+	defaultClaudeBaseURL    = "https://api.anthropic.com/v1"
+	claudeAPIVersion        = "2023-06-01"
+	claudeMaxTokens         = 1024
+	claudeRequestTimeout    = 60 * time.Second
+	claudeCategoryMaxTokens = 32
+)
+
+// ClaudeAI implements the AI Service using the Anthropic Messages API
+type ClaudeAI struct {
+	apiKey        string
+	model         modelSetting
+	baseURL       string
+	httpClient    *http.Client
+	prompts       PromptSource
+	categoryHints CategoryHintSource
+}
+
+// NewClaudeAI creates a new Claude AI service
+func NewClaudeAI(apiKey string, model string) (*ClaudeAI, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("Anthropic API key is required")
+	}
+
+	c := &ClaudeAI{
+		apiKey:     apiKey,
+		baseURL:    defaultClaudeBaseURL,
+		httpClient: &http.Client{Timeout: claudeRequestTimeout},
+	}
+	c.SetModel(model)
+	return c, nil
+}
+
+// SetPromptSource uses managed prompt versions instead of the built-in prompts
+func (c *ClaudeAI) SetPromptSource(source PromptSource) {
+	c.prompts = source
+}
+
+// SetCategoryHintSource personalizes category suggestions with what each user has taught
+func (c *ClaudeAI) SetCategoryHintSource(source CategoryHintSource) {
+	c.categoryHints = source
+}
+
+type claudeMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"` // plain string or []claudeContentBlock
+}
+
+type claudeContentBlock struct {
+	Type   string             `json:"type"`
+	Text   string             `json:"text,omitempty"`
+	Source *claudeImageSource `json:"source,omitempty"`
+}
+
+type claudeImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"` // base64 encoded
+}
+
+type claudeRequest struct {
+	Model     string          `json:"model"`
+	MaxTokens int             `json:"max_tokens"`
+	Messages  []claudeMessage `json:"messages"`
+	Stream    bool            `json:"stream,omitempty"`
+}
+
+type claudeUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type claudeResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage claudeUsage `json:"usage"`
+}
+
+// claudeStreamEvent covers the subset of server-sent event payloads we consume
+type claudeStreamEvent struct {
+	Type    string `json:"type"`
+	Message *struct {
+		Usage claudeUsage `json:"usage"`
+	} `json:"message,omitempty"`
+	Delta *struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta,omitempty"`
+	Usage *claudeUsage `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// ParseExpense extracts expenses from natural language text
+func (c *ClaudeAI) ParseExpense(ctx context.Context, text string, userID string) (*ParseExpenseResponse, error) {
+	return c.ParseExpenseStream(ctx, text, userID, nil)
+}
+
+// ParseExpenseStream extracts expenses while streaming the model output, reporting
+// each fully parsed expense through onProgress as soon as it is available
+func (c *ClaudeAI) ParseExpenseStream(ctx context.Context, text string, userID string, onProgress domain.ProgressFunc) (*ParseExpenseResponse, error) {
+	resp, err := c.callClaudeStream(ctx, text, onProgress)
+	if err == nil {
+		if onProgress != nil {
+			onProgress(&domain.ParseProgress{Expenses: resp.Expenses, Done: true})
+		}
+		return resp, nil
+	}
+
+	slog.WarnContext(ctx, "Claude API failed, using regex fallback", "error", err)
+
+	// Fallback to regex - return zero token metadata since no API call succeeded
+	expenses, err := regexParseExpenses(text)
+	if err != nil {
+		return nil, err
+	}
+	if onProgress != nil {
+		onProgress(&domain.ParseProgress{Expenses: expenses, Done: true})
+	}
+
+	return &ParseExpenseResponse{
+		Expenses: expenses,
+		Tokens: &TokenMetadata{
+			InputTokens:  0,
+			OutputTokens: 0,
+			TotalTokens:  0,
+		},
+	}, nil
+}
+
+// SuggestCategory suggests a category based on description
+func (c *ClaudeAI) SuggestCategory(ctx context.Context, description string, userID string) (*SuggestCategoryResponse, error) {
+	hints := loadCategoryHints(ctx, c.categoryHints, userID)
+
+	resp, err := c.callClaudeCategory(ctx, description, hints)
+	if err == nil {
+		return resp, nil
+	}
+
+	slog.WarnContext(ctx, "Claude API failed for category suggestion, using fallback", "error", err)
+
+	return &SuggestCategoryResponse{
+		Category:   fallbackSuggestCategory(hints, description),
+		Confidence: 1,
+		Tokens: &TokenMetadata{
+			InputTokens:  0,
+			OutputTokens: 0,
+			TotalTokens:  0,
+		},
+	}, nil
+}
+
+// parseExpenseAPI parses with the Claude API only, returning its error instead of falling back
+func (c *ClaudeAI) parseExpenseAPI(ctx context.Context, text string, onProgress domain.ProgressFunc) (*ParseExpenseResponse, error) {
+	return c.callClaudeStream(ctx, text, onProgress)
+}
+
+// suggestCategoryAPI suggests with the Claude API only, returning its error instead of falling back
+func (c *ClaudeAI) suggestCategoryAPI(ctx context.Context, description string, userID string) (*SuggestCategoryResponse, error) {
+	return c.callClaudeCategory(ctx, description, loadCategoryHints(ctx, c.categoryHints, userID))
+}
+
+func (c *ClaudeAI) modelName() string {
+	return c.model.get()
+}
+
+// ParseReceipt extracts line items from a receipt photo using Claude's vision input
+func (c *ClaudeAI) ParseReceipt(ctx context.Context, image []byte, mimeType string, userID string) (*ParseReceiptResponse, error) {
+	prompt, promptVersion := renderPrompt(ctx, c.prompts, domain.PromptParseReceipt, PromptData{})
+	slog.DebugContext(ctx, "Claude receipt prompt", "prompt", prompt)
+
+	claudeResp, rawResponse, err := c.sendMessage(ctx, claudeRequest{
+		Model:     c.model.get(),
+		MaxTokens: claudeMaxTokens,
+		Messages: []claudeMessage{{
+			Role: "user",
+			Content: []claudeContentBlock{
+				{Type: "image", Source: &claudeImageSource{Type: "base64", MediaType: mimeType, Data: base64.StdEncoding.EncodeToString(image)}},
+				{Type: "text", Text: prompt},
+			},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := parseReceiptResponseText(claudeResp.Content[0].Text, domain.LocationFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Claude receipt response: %w", err)
+	}
+
+	receipt.Tokens = &TokenMetadata{
+		InputTokens:  claudeResp.Usage.InputTokens,
+		OutputTokens: claudeResp.Usage.OutputTokens,
+		TotalTokens:  claudeResp.Usage.InputTokens + claudeResp.Usage.OutputTokens,
+	}
+	receipt.SystemPrompt = prompt
+	receipt.PromptVersion = promptVersion
+	receipt.RawResponse = rawResponse
+	return receipt, nil
+}
+
+func (c *ClaudeAI) newRequest(ctx context.Context, body claudeRequest) (*http.Request, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/messages", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", claudeAPIVersion)
+	if body.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	return req, nil
+}
+
+func (c *ClaudeAI) callClaudeStream(ctx context.Context, text string, onProgress domain.ProgressFunc) (*ParseExpenseResponse, error) {
+	prompt, promptVersion := renderPrompt(ctx, c.prompts, domain.PromptParseExpense, PromptData{Text: text})
+	slog.DebugContext(ctx, "Claude parse prompt", "prompt", prompt)
+
+	req, err := c.newRequest(ctx, claudeRequest{
+		Model:     c.model.get(),
+		MaxTokens: claudeMaxTokens,
+		Messages:  []claudeMessage{{Role: "user", Content: prompt}},
+		Stream:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		slog.ErrorContext(ctx, "Claude API returned an error", "status", resp.StatusCode, "response", string(bodyBytes))
+		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var (
+		output strings.Builder
+		raw    strings.Builder
+		usage  claudeUsage
+		parsed []*domain.ParsedExpense
+	)
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		raw.WriteString(line)
+		raw.WriteString("\n")
+
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+
+		var event claudeStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				usage.InputTokens = event.Message.Usage.InputTokens
+			}
+		case "content_block_delta":
+			if event.Delta == nil || event.Delta.Type != "text_delta" {
+				continue
+			}
+			output.WriteString(event.Delta.Text)
+			if onProgress == nil {
+				continue
+			}
+			if objects := completedJSONObjects(output.String()); len(objects) > len(parsed) {
+				if expenses, err := parseGeminiResponseText("["+strings.Join(objects, ",")+"]", domain.LocationFromContext(ctx)); err == nil {
+					parsed = expenses
+				}
+			}
+			onProgress(&domain.ParseProgress{Expenses: parsed})
+		case "message_delta":
+			if event.Usage != nil {
+				usage.OutputTokens = event.Usage.OutputTokens
+			}
+		case "error":
+			if event.Error != nil {
+				return nil, fmt.Errorf("stream error %s: %s", event.Error.Type, event.Error.Message)
+			}
+			return nil, fmt.Errorf("stream error")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	slog.DebugContext(ctx, "Claude API streamed response", "response", output.String())
+
+	expenses, err := parseGeminiResponseText(output.String(), domain.LocationFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Claude response: %w", err)
+	}
+
+	return &ParseExpenseResponse{
+		Expenses: expenses,
+		Tokens: &TokenMetadata{
+			InputTokens:  usage.InputTokens,
+			OutputTokens: usage.OutputTokens,
+			TotalTokens:  usage.InputTokens + usage.OutputTokens,
+		},
+		SystemPrompt:  prompt,
+		PromptVersion: promptVersion,
+		RawResponse:   raw.String(),
+	}, nil
+}
+
+func (c *ClaudeAI) callClaudeCategory(ctx context.Context, description string, hints *CategoryHints) (*SuggestCategoryResponse, error) {
+	prompt, promptVersion := renderPrompt(ctx, c.prompts, domain.PromptSuggestCategory, PromptData{Description: description, Examples: categoryExamples(hints)})
+	slog.DebugContext(ctx, "Claude category prompt", "prompt", prompt)
+
+	claudeResp, rawResponse, err := c.sendMessage(ctx, claudeRequest{
+		Model:     c.model.get(),
+		MaxTokens: claudeCategoryMaxTokens,
+		Messages:  []claudeMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	category, confidence, alternatives := parseCategorySuggestion(claudeResp.Content[0].Text)
+
+	return &SuggestCategoryResponse{
+		Category:     category,
+		Confidence:   confidence,
+		Alternatives: alternatives,
+		Tokens: &TokenMetadata{
+			InputTokens:  claudeResp.Usage.InputTokens,
+			OutputTokens: claudeResp.Usage.OutputTokens,
+			TotalTokens:  claudeResp.Usage.InputTokens + claudeResp.Usage.OutputTokens,
+		},
+		SystemPrompt:  prompt,
+		PromptVersion: promptVersion,
+		RawResponse:   rawResponse,
+	}, nil
+}
+
+// sendMessage performs a non-streaming Messages API call
+func (c *ClaudeAI) sendMessage(ctx context.Context, body claudeRequest) (*claudeResponse, string, error) {
+	req, err := c.newRequest(ctx, body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to call API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response body: %w", err)
+	}
+	rawResponse := string(bodyBytes)
+
+	if resp.StatusCode != http.StatusOK {
+		slog.ErrorContext(ctx, "Claude API returned an error", "status", resp.StatusCode, "response", rawResponse)
+		return nil, rawResponse, fmt.Errorf("API error %d: %s", resp.StatusCode, rawResponse)
+	}
+
+	var claudeResp claudeResponse
+	if err := json.Unmarshal(bodyBytes, &claudeResp); err != nil {
+		return nil, rawResponse, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(claudeResp.Content) == 0 {
+		return nil, rawResponse, fmt.Errorf("no content in response")
+	}
+
+	return &claudeResp, rawResponse, nil
+}
+
+// completedJSONObjects returns the top-level objects of a (possibly truncated) JSON array
+// that have been fully received so far
+func completedJSONObjects(s string) []string {
+	var objects []string
+	depth := 0
+	start := -1
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch ch {
+		case '"':
+			inString = true
+		case '{':
+			if depth == 0 {
+				start = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth == 0 && start >= 0 {
+				objects = append(objects, s[start:i+1])
+				start = -1
+			}
+		}
+	}
+	return objects
+}
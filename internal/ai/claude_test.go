@@ -0,0 +1,199 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func newTestClaudeAI(t *testing.T, handler http.HandlerFunc) *ClaudeAI {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewClaudeAI("test_key", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.baseURL = server.URL
+	c.httpClient = server.Client()
+	return c
+}
+
+func writeClaudeStream(w http.ResponseWriter, chunks []string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":42,\"output_tokens\":1}}}\n\n")
+	for _, chunk := range chunks {
+		fmt.Fprintf(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":%q}}\n\n", chunk)
+	}
+	fmt.Fprint(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":17}}\n\n")
+	fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+}
+
+func TestNewClaudeAI(t *testing.T) {
+	if _, err := NewClaudeAI("", ""); err == nil {
+		t.Error("expected error for empty api key")
+	}
+
+	c, err := NewClaudeAI("test_key", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Model() != defaultClaudeModel {
+		t.Errorf("expected default model %q, got %q", defaultClaudeModel, c.Model())
+	}
+}
+
+func TestClaudeParseExpenseStream(t *testing.T) {
+	c := newTestClaudeAI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "test_key" {
+			t.Errorf("expected api key header")
+		}
+		if r.Header.Get("anthropic-version") == "" {
+			t.Errorf("expected anthropic-version header")
+		}
+		writeClaudeStream(w, []string{
+			`[{"description":"早餐","amount":80,"curr`,
+			`ency":"TWD","suggested_category":"Food"},`,
+			`{"description":"咖啡 {大杯}","amount":120,"currency":"TWD"}]`,
+		})
+	})
+
+	var updates []*domain.ParseProgress
+	resp, err := c.ParseExpenseStream(context.Background(), "早餐80 咖啡120", "user1", func(p *domain.ParseProgress) {
+		updates = append(updates, p)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.Expenses) != 2 {
+		t.Fatalf("expected 2 expenses, got %d", len(resp.Expenses))
+	}
+	if resp.Expenses[1].Description != "咖啡 {大杯}" {
+		t.Errorf("expected second description to keep braces, got %q", resp.Expenses[1].Description)
+	}
+	if resp.Tokens.InputTokens != 42 || resp.Tokens.OutputTokens != 17 || resp.Tokens.TotalTokens != 59 {
+		t.Errorf("unexpected token usage: %+v", resp.Tokens)
+	}
+
+	if len(updates) != 4 {
+		t.Fatalf("expected 4 progress updates, got %d", len(updates))
+	}
+	wantCounts := []int{0, 1, 2, 2}
+	for i, want := range wantCounts {
+		if got := len(updates[i].Expenses); got != want {
+			t.Errorf("update %d: expected %d expenses, got %d", i, want, got)
+		}
+	}
+	if !updates[3].Done {
+		t.Error("expected final update to be marked done")
+	}
+}
+
+func TestClaudeParseExpense_FallbackOnAPIError(t *testing.T) {
+	c := newTestClaudeAI(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, 529)
+	})
+
+	resp, err := c.ParseExpense(context.Background(), "早餐$20午餐$30", "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Expenses) != 2 {
+		t.Errorf("expected 2 regex expenses, got %d", len(resp.Expenses))
+	}
+	if resp.Tokens.TotalTokens != 0 {
+		t.Errorf("expected 0 tokens for fallback, got %d", resp.Tokens.TotalTokens)
+	}
+}
+
+func TestClaudeSuggestCategory(t *testing.T) {
+	c := newTestClaudeAI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"Transport."}],"usage":{"input_tokens":30,"output_tokens":2}}`)
+	})
+
+	resp, err := c.SuggestCategory(context.Background(), "高鐵", "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Category != "Transport" {
+		t.Errorf("expected Transport, got %q", resp.Category)
+	}
+	if resp.Tokens.TotalTokens != 32 {
+		t.Errorf("expected 32 tokens, got %d", resp.Tokens.TotalTokens)
+	}
+}
+
+func TestClaudeSuggestCategory_ConfidenceAndAlternatives(t *testing.T) {
+	c := newTestClaudeAI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"{\"category\":\"Shopping\",\"confidence\":0.4,\"alternatives\":[\"Food\",\"Other\"]}"}],"usage":{"input_tokens":30,"output_tokens":20}}`)
+	})
+
+	resp, err := c.SuggestCategory(context.Background(), "全聯", "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Category != "Shopping" {
+		t.Errorf("expected Shopping, got %q", resp.Category)
+	}
+	if resp.Confidence != 0.4 {
+		t.Errorf("expected confidence 0.4, got %v", resp.Confidence)
+	}
+	if len(resp.Alternatives) != 2 || resp.Alternatives[0] != "Food" {
+		t.Errorf("expected alternatives [Food Other], got %v", resp.Alternatives)
+	}
+}
+
+func TestCompletedJSONObjects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  int
+	}{
+		{name: "empty", input: "", want: 0},
+		{name: "open array", input: "[", want: 0},
+		{name: "partial object", input: `[{"a":1`, want: 0},
+		{name: "one complete", input: `[{"a":1},{"b"`, want: 1},
+		{name: "nested and quoted braces", input: "```json\n" + `[{"a":{"b":"}"}},{"c":"\"{"}]`, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := completedJSONObjects(tt.input)
+			if len(got) != tt.want {
+				t.Errorf("expected %d objects, got %d (%s)", tt.want, len(got), strings.Join(got, " | "))
+			}
+		})
+	}
+}
+
+func TestClaudeParseReceipt(t *testing.T) {
+	c := newTestClaudeAI(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"media_type":"image/png"`) {
+			t.Errorf("expected image block in request, got %s", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"{\"merchant\":\"全家\",\"currency\":\"TWD\",\"total\":80,\"items\":[{\"description\":\"飯糰\",\"amount\":35},{\"description\":\"豆漿\",\"amount\":45}]}"}],"usage":{"input_tokens":900,"output_tokens":60}}`)
+	})
+
+	resp, err := c.ParseReceipt(context.Background(), []byte("png"), "image/png", "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Merchant != "全家" || len(resp.Expenses) != 1 || len(resp.Expenses[0].Items) != 2 {
+		t.Errorf("unexpected receipt: merchant=%q expenses=%d", resp.Merchant, len(resp.Expenses))
+	}
+	if resp.Tokens.TotalTokens != 960 {
+		t.Errorf("expected 960 tokens, got %d", resp.Tokens.TotalTokens)
+	}
+}
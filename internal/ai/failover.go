@@ -0,0 +1,315 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ StreamingService = (*FailoverService)(nil)
+var _ ReceiptService = (*FailoverService)(nil)
+var _ PromptConfigurable = (*FailoverService)(nil)
+var _ CategoryHintConfigurable = (*FailoverService)(nil)
+var _ ClientPolicyConfigurable = (*FailoverService)(nil)
+var _ HealthReporter = (*FailoverService)(nil)
+
+// Defaults of the failover circuit breakers
+const (
+	DefaultFailoverThreshold = 3
+	DefaultFailoverCooldown  = time.Minute
+)
+
+// RegexProvider ends a failover chain with the free regex and keyword parsers
+const RegexProvider = "regex"
+
+// ErrNoAIProvider is returned when every provider of a failover chain failed
+// or is skipped by its circuit breaker, and the chain doesn't end with regex
+var ErrNoAIProvider = errors.New("no AI provider available")
+
+// chainProvider is implemented by providers a failover chain can try. Unlike
+// the Service methods, these return the API error instead of falling back to
+// the regex parser, so the chain can move on to the next provider.
+type chainProvider interface {
+	parseExpenseAPI(ctx context.Context, text string, onProgress domain.ProgressFunc) (*ParseExpenseResponse, error)
+	suggestCategoryAPI(ctx context.Context, description string, userID string) (*SuggestCategoryResponse, error)
+	modelName() string
+}
+
+// ProviderSpec configures one provider of a failover chain. Model may be
+// empty for the provider's default model.
+type ProviderSpec struct {
+	Provider string
+	APIKey   string
+	Model    string
+}
+
+// ProviderHealth is the health of one AI provider. Within a failover chain
+// the circuit fields are the chain's breaker of the provider and HTTPClient
+// the provider's own HTTP client breaker, when it has one.
+type ProviderHealth struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+	CircuitHealth
+	HTTPClient *CircuitHealth `json:"http_client,omitempty"`
+}
+
+// HealthReporter is implemented by AI services that track the health of the providers they call
+type HealthReporter interface {
+	Health() []ProviderHealth
+}
+
+// providerCircuit is one provider of a failover chain and its circuit breaker
+type providerCircuit struct {
+	name     string
+	provider chainProvider
+	breaker  *circuitBreaker
+}
+
+// FailoverService tries an ordered list of AI providers, so an outage of the
+// first one fails over to the next instead of degrading to the regex parser
+// for everyone. A provider that keeps failing is skipped by its circuit
+// breaker until a cooldown has passed.
+type FailoverService struct {
+	circuits []*providerCircuit
+	regex    bool
+	now      func() time.Time
+
+	regexMu    sync.Mutex
+	regexCalls int64
+
+	categoryHints CategoryHintSource
+}
+
+// NewFailoverService creates a failover chain of the providers in order.
+// "regex" may only come last; without it the chain returns ErrNoAIProvider
+// once every provider failed.
+func NewFailoverService(specs []ProviderSpec, threshold int, cooldown time.Duration) (*FailoverService, error) {
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("failover chain needs at least one provider")
+	}
+
+	var circuits []*providerCircuit
+	regex := false
+	for i, spec := range specs {
+		var provider chainProvider
+		var err error
+		switch spec.Provider {
+		case "gemini":
+			provider, err = NewGeminiAI(spec.APIKey, spec.Model, nil)
+		case "claude":
+			provider, err = NewClaudeAI(spec.APIKey, spec.Model)
+		case RegexProvider:
+			if i != len(specs)-1 {
+				return nil, fmt.Errorf("%s must be the last provider of the failover chain", RegexProvider)
+			}
+			regex = true
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported AI provider in failover chain: %s", spec.Provider)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s provider: %w", spec.Provider, err)
+		}
+		circuits = append(circuits, &providerCircuit{name: spec.Provider, provider: provider})
+	}
+	return newFailoverService(circuits, regex, threshold, cooldown), nil
+}
+
+func newFailoverService(circuits []*providerCircuit, regex bool, threshold int, cooldown time.Duration) *FailoverService {
+	if threshold <= 0 {
+		threshold = DefaultFailoverThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultFailoverCooldown
+	}
+	for _, circuit := range circuits {
+		circuit.breaker = newCircuitBreaker(threshold, cooldown)
+	}
+	return &FailoverService{
+		circuits: circuits,
+		regex:    regex,
+		now:      time.Now,
+	}
+}
+
+// SetPromptSource uses managed prompt versions in every provider of the chain
+func (f *FailoverService) SetPromptSource(source PromptSource) {
+	for _, circuit := range f.circuits {
+		if configurable, ok := circuit.provider.(PromptConfigurable); ok {
+			configurable.SetPromptSource(source)
+		}
+	}
+}
+
+// SetCategoryHintSource personalizes category suggestions in every provider of
+// the chain and in the keyword fallback
+func (f *FailoverService) SetCategoryHintSource(source CategoryHintSource) {
+	f.categoryHints = source
+	for _, circuit := range f.circuits {
+		if configurable, ok := circuit.provider.(CategoryHintConfigurable); ok {
+			configurable.SetCategoryHintSource(source)
+		}
+	}
+}
+
+// SetClientPolicy sets the HTTP client policy of every provider of the chain that has one
+func (f *FailoverService) SetClientPolicy(policy ClientPolicy) {
+	for _, circuit := range f.circuits {
+		if configurable, ok := circuit.provider.(ClientPolicyConfigurable); ok {
+			configurable.SetClientPolicy(policy)
+		}
+	}
+}
+
+// ParseExpense extracts expenses with the first provider that succeeds
+func (f *FailoverService) ParseExpense(ctx context.Context, text string, userID string) (*ParseExpenseResponse, error) {
+	return f.ParseExpenseStream(ctx, text, userID, nil)
+}
+
+// ParseExpenseStream extracts expenses with the first provider that succeeds,
+// reporting partial results when that provider streams
+func (f *FailoverService) ParseExpenseStream(ctx context.Context, text string, userID string, onProgress domain.ProgressFunc) (*ParseExpenseResponse, error) {
+	var resp *ParseExpenseResponse
+	circuit, err := f.try(ctx, "parse_expense", func(c *providerCircuit) error {
+		var err error
+		resp, err = c.provider.parseExpenseAPI(ctx, text, onProgress)
+		return err
+	})
+	if err == nil {
+		resp.Provider, resp.Model = circuit.name, circuit.provider.modelName()
+	} else if f.regex && ctx.Err() == nil {
+		f.countRegex()
+		expenses, regexErr := regexParseExpenses(text)
+		if regexErr != nil {
+			return nil, regexErr
+		}
+		resp, err = &ParseExpenseResponse{Expenses: expenses, Tokens: &TokenMetadata{}, Provider: RegexProvider}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if onProgress != nil {
+		onProgress(&domain.ParseProgress{Expenses: resp.Expenses, Done: true})
+	}
+	return resp, nil
+}
+
+// SuggestCategory suggests a category with the first provider that succeeds
+func (f *FailoverService) SuggestCategory(ctx context.Context, description string, userID string) (*SuggestCategoryResponse, error) {
+	var resp *SuggestCategoryResponse
+	circuit, err := f.try(ctx, "suggest_category", func(c *providerCircuit) error {
+		var err error
+		resp, err = c.provider.suggestCategoryAPI(ctx, description, userID)
+		return err
+	})
+	if err == nil {
+		resp.Provider, resp.Model = circuit.name, circuit.provider.modelName()
+		return resp, nil
+	}
+	if !f.regex || ctx.Err() != nil {
+		return nil, err
+	}
+
+	f.countRegex()
+	return &SuggestCategoryResponse{
+		Category:   fallbackSuggestCategory(loadCategoryHints(ctx, f.categoryHints, userID), description),
+		Confidence: 1,
+		Tokens:     &TokenMetadata{},
+		Provider:   RegexProvider,
+	}, nil
+}
+
+// ParseReceipt reads a receipt photo with the first multimodal provider that succeeds.
+// There is no regex fallback for images.
+func (f *FailoverService) ParseReceipt(ctx context.Context, image []byte, mimeType string, userID string) (*ParseReceiptResponse, error) {
+	var resp *ParseReceiptResponse
+	circuit, err := f.try(ctx, "parse_receipt", func(c *providerCircuit) error {
+		receiptService, ok := c.provider.(ReceiptService)
+		if !ok {
+			return errSkipProvider
+		}
+		var err error
+		resp, err = receiptService.ParseReceipt(ctx, image, mimeType, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.Provider, resp.Model = circuit.name, circuit.provider.modelName()
+	return resp, nil
+}
+
+// errSkipProvider is returned by a call that the provider doesn't support; it
+// moves on to the next provider without counting as a failure
+var errSkipProvider = errors.New("operation not supported by provider")
+
+// try calls each provider whose circuit allows it until one succeeds, and
+// returns the provider that did
+func (f *FailoverService) try(ctx context.Context, operation string, call func(*providerCircuit) error) (*providerCircuit, error) {
+	var lastErr error
+	for _, circuit := range f.circuits {
+		if !circuit.breaker.allow(f.now()) {
+			continue
+		}
+		err := call(circuit)
+		if errors.Is(err, errSkipProvider) {
+			circuit.breaker.release()
+			continue
+		}
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up; that says nothing about the provider
+			circuit.breaker.release()
+			return nil, err
+		}
+		if circuit.breaker.record(err, f.now()) {
+			slog.WarnContext(ctx, "AI provider circuit opened", "provider", circuit.name, "cooldown", circuit.breaker.cooldown, "error", err)
+		}
+		if err == nil {
+			return circuit, nil
+		}
+		slog.WarnContext(ctx, "AI provider failed, failing over", "provider", circuit.name, "operation", operation, "error", err)
+		lastErr = err
+	}
+	if lastErr == nil {
+		return nil, ErrNoAIProvider
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNoAIProvider, lastErr)
+}
+
+func (f *FailoverService) countRegex() {
+	f.regexMu.Lock()
+	defer f.regexMu.Unlock()
+	f.regexCalls++
+}
+
+// Health reports each provider's circuit breaker, followed by how often the
+// chain fell back to regex when it ends with it
+func (f *FailoverService) Health() []ProviderHealth {
+	now := f.now()
+	health := make([]ProviderHealth, 0, len(f.circuits)+1)
+	for _, circuit := range f.circuits {
+		provider := ProviderHealth{
+			Provider:      circuit.name,
+			Model:         circuit.provider.modelName(),
+			CircuitHealth: circuit.breaker.health(now),
+		}
+		if reporter, ok := circuit.provider.(HealthReporter); ok {
+			if reported := reporter.Health(); len(reported) > 0 {
+				provider.HTTPClient = &reported[0].CircuitHealth
+			}
+		}
+		health = append(health, provider)
+	}
+	if f.regex {
+		f.regexMu.Lock()
+		health = append(health, ProviderHealth{Provider: RegexProvider, CircuitHealth: CircuitHealth{State: CircuitClosed, Calls: f.regexCalls}})
+		f.regexMu.Unlock()
+	}
+	return health
+}
@@ -0,0 +1,139 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// fakeChainProvider answers with fixed expenses, or fails while err is set
+type fakeChainProvider struct {
+	model string
+	err   error
+	calls int
+}
+
+func (p *fakeChainProvider) parseExpenseAPI(ctx context.Context, text string, onProgress domain.ProgressFunc) (*ParseExpenseResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &ParseExpenseResponse{
+		Expenses: []*domain.ParsedExpense{{Description: p.model, Amount: 1}},
+		Tokens:   &TokenMetadata{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (p *fakeChainProvider) suggestCategoryAPI(ctx context.Context, description string, userID string) (*SuggestCategoryResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &SuggestCategoryResponse{Category: "Food", Confidence: 1, Tokens: &TokenMetadata{TotalTokens: 3}}, nil
+}
+
+func (p *fakeChainProvider) modelName() string {
+	return p.model
+}
+
+func TestFailoverService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	setup := func(regex bool) (*FailoverService, *fakeChainProvider, *fakeChainProvider) {
+		primary := &fakeChainProvider{model: "gemini-2.5-flash-lite"}
+		secondary := &fakeChainProvider{model: "claude-3-5-haiku-latest"}
+		f := newFailoverService([]*providerCircuit{
+			{name: "gemini", provider: primary},
+			{name: "claude", provider: secondary},
+		}, regex, 2, time.Minute)
+		f.now = func() time.Time { return now }
+		return f, primary, secondary
+	}
+
+	t.Run("Fails over and attributes the call to the provider that served it", func(t *testing.T) {
+		f, primary, _ := setup(false)
+		primary.err = errors.New("503 unavailable")
+
+		resp, err := f.ParseExpense(ctx, "lunch 100", "U1")
+		if err != nil {
+			t.Fatalf("ParseExpense failed: %v", err)
+		}
+		if resp.Provider != "claude" || resp.Model != "claude-3-5-haiku-latest" || resp.Tokens.TotalTokens != 15 {
+			t.Errorf("expected the claude response, got %+v", resp)
+		}
+
+		category, err := f.SuggestCategory(ctx, "lunch", "U1")
+		if err != nil || category.Provider != "claude" {
+			t.Errorf("expected the claude suggestion, got %+v, %v", category, err)
+		}
+	})
+
+	t.Run("Opens the circuit and closes it after a successful trial", func(t *testing.T) {
+		f, primary, _ := setup(false)
+		primary.err = errors.New("503 unavailable")
+		f.ParseExpense(ctx, "lunch 100", "U1")
+		f.ParseExpense(ctx, "lunch 100", "U1")
+		if health := f.Health()[0]; health.State != CircuitOpen || health.ConsecutiveFailures != 2 || health.OpenUntil == nil {
+			t.Fatalf("expected the gemini circuit open, got %+v", health)
+		}
+
+		f.ParseExpense(ctx, "lunch 100", "U1")
+		if primary.calls != 2 {
+			t.Errorf("expected the open circuit to skip gemini, got %d calls", primary.calls)
+		}
+
+		now = now.Add(2 * time.Minute)
+		if health := f.Health()[0]; health.State != CircuitHalfOpen {
+			t.Errorf("expected the gemini circuit half open after the cooldown, got %+v", health)
+		}
+		primary.err = nil
+		resp, err := f.ParseExpense(ctx, "lunch 100", "U1")
+		if err != nil || resp.Provider != "gemini" {
+			t.Fatalf("expected the trial call served by gemini, got %+v, %v", resp, err)
+		}
+		if health := f.Health()[0]; health.State != CircuitClosed || health.ConsecutiveFailures != 0 || health.Failures != 2 {
+			t.Errorf("expected the gemini circuit closed, got %+v", health)
+		}
+	})
+
+	t.Run("Ends with regex or an error when every provider fails", func(t *testing.T) {
+		f, primary, secondary := setup(false)
+		primary.err = errors.New("503 unavailable")
+		secondary.err = errors.New("529 overloaded")
+		if _, err := f.ParseExpense(ctx, "lunch 100", "U1"); !errors.Is(err, ErrNoAIProvider) {
+			t.Errorf("expected ErrNoAIProvider, got %v", err)
+		}
+
+		f, primary, secondary = setup(true)
+		primary.err = errors.New("503 unavailable")
+		secondary.err = errors.New("529 overloaded")
+		resp, err := f.ParseExpense(ctx, "lunch $100", "U1")
+		if err != nil || resp.Provider != RegexProvider || len(resp.Expenses) != 1 || resp.Tokens.TotalTokens != 0 {
+			t.Fatalf("expected the regex result, got %+v, %v", resp, err)
+		}
+		health := f.Health()
+		if len(health) != 3 || health[2].Provider != RegexProvider || health[2].Calls != 1 {
+			t.Errorf("expected the regex fallback counted, got %+v", health)
+		}
+	})
+
+	t.Run("Rejects a misconfigured chain", func(t *testing.T) {
+		for _, specs := range [][]ProviderSpec{
+			nil,
+			{{Provider: "openai", APIKey: "key"}},
+			{{Provider: RegexProvider}, {Provider: "gemini", APIKey: "key"}},
+			{{Provider: "claude"}},
+		} {
+			if _, err := NewFailoverService(specs, 0, 0); err == nil {
+				t.Errorf("expected %+v to be rejected", specs)
+			}
+		}
+		if _, err := NewFailoverService([]ProviderSpec{{Provider: "gemini", APIKey: "key"}, {Provider: "claude", APIKey: "key"}, {Provider: RegexProvider}}, 0, 0); err != nil {
+			t.Errorf("expected a valid chain, got %v", err)
+		}
+	})
+}
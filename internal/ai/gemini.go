@@ -3,10 +3,13 @@ package ai
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -17,14 +20,31 @@ import (
 )
 
 var _ Service = (*GeminiAI)(nil)
-
-const defaultGeminiModel = "gemini-2.5-flash-lite"
+var _ ReceiptService = (*GeminiAI)(nil)
+var _ Transcriber = (*GeminiAI)(nil)
+var _ ClientPolicyConfigurable = (*GeminiAI)(nil)
+var _ HealthReporter = (*GeminiAI)(nil)
+
+const (
+	defaultGeminiModel   = "gemini-2.5-flash-lite"
+	defaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+)
 
 // GeminiAI implements the AI Service using Google Gemini API
 type GeminiAI struct {
-	apiKey string
-	model  string
+	apiKey        string
+	model         modelSetting
+	baseURL       string
+	prompts       PromptSource
+	categoryHints CategoryHintSource
 	// client *genai.Client // TODO: Initialize when Gemini SDK is available
+
+	// generateContent calls are retried and guarded by a circuit breaker, so
+	// an unhealthy API fails fast instead of every request waiting for a timeout
+	policy     ClientPolicy
+	httpClient *http.Client
+	breaker    *circuitBreaker
+	now        func() time.Time
 }
 
 // NewGeminiAI creates a new Gemini AI service
@@ -32,9 +52,6 @@ func NewGeminiAI(apiKey string, model string, costRepo domain.AICostRepository)
 	if apiKey == "" {
 		return nil, fmt.Errorf("Gemini API key is required")
 	}
-	if model == "" {
-		model = defaultGeminiModel
-	}
 
 	// TODO: Initialize Gemini client
 	// client, err := genai.NewClient(context.Background(), option.WithAPIKey(apiKey))
@@ -42,16 +59,43 @@ func NewGeminiAI(apiKey string, model string, costRepo domain.AICostRepository)
 	//     return nil, fmt.Errorf("failed to create Gemini client: %w", err)
 	// }
 
-	return &GeminiAI{
-		apiKey: apiKey,
-		model:  model,
+	g := &GeminiAI{
+		apiKey:  apiKey,
+		baseURL: defaultGeminiBaseURL,
+		now:     time.Now,
 		// client: client,
-	}, nil
+	}
+	g.SetModel(model)
+	g.SetClientPolicy(DefaultClientPolicy)
+	return g, nil
+}
+
+// SetClientPolicy sets the timeout, retries and circuit breaker of API calls,
+// resetting the breaker
+func (g *GeminiAI) SetClientPolicy(policy ClientPolicy) {
+	g.policy = policy.withDefaults()
+	g.httpClient = &http.Client{Timeout: g.policy.Timeout}
+	g.breaker = newCircuitBreaker(g.policy.BreakerThreshold, g.policy.BreakerCooldown)
+}
+
+// Health reports the circuit breaker of the Gemini API client
+func (g *GeminiAI) Health() []ProviderHealth {
+	return []ProviderHealth{{Provider: "gemini", Model: g.model.get(), CircuitHealth: g.breaker.health(g.now())}}
+}
+
+// SetPromptSource uses managed prompt versions instead of the built-in prompts
+func (g *GeminiAI) SetPromptSource(source PromptSource) {
+	g.prompts = source
+}
+
+// SetCategoryHintSource personalizes category suggestions with what each user has taught
+func (g *GeminiAI) SetCategoryHintSource(source CategoryHintSource) {
+	g.categoryHints = source
 }
 
 // ParseExpense extracts expenses from natural language text
 func (g *GeminiAI) ParseExpense(ctx context.Context, text string, userID string) (*ParseExpenseResponse, error) {
-	log.Printf("DEBUG: GeminiAI.ParseExpense called with: %s", text)
+	slog.DebugContext(ctx, "GeminiAI.ParseExpense called", "text", text)
 
 	// Try Gemini API first
 	resp, err := g.callGeminiAPI(ctx, text)
@@ -60,7 +104,7 @@ func (g *GeminiAI) ParseExpense(ctx context.Context, text string, userID string)
 		return resp, nil
 	}
 
-	log.Printf("WARN: Gemini API failed (using regex fallback): %v", err)
+	slog.WarnContext(ctx, "Gemini API failed, using regex fallback", "error", err)
 
 	// Fallback to regex - return zero token metadata since no API call was made
 	expenses, err := g.parseExpenseRegex(text)
@@ -78,6 +122,20 @@ func (g *GeminiAI) ParseExpense(ctx context.Context, text string, userID string)
 	}, nil
 }
 
+// parseExpenseAPI parses with the Gemini API only, returning its error instead of falling back
+func (g *GeminiAI) parseExpenseAPI(ctx context.Context, text string, onProgress domain.ProgressFunc) (*ParseExpenseResponse, error) {
+	return g.callGeminiAPI(ctx, text)
+}
+
+// suggestCategoryAPI suggests with the Gemini API only, returning its error instead of falling back
+func (g *GeminiAI) suggestCategoryAPI(ctx context.Context, description string, userID string) (*SuggestCategoryResponse, error) {
+	return g.callGeminiCategoryAPI(ctx, description, loadCategoryHints(ctx, g.categoryHints, userID))
+}
+
+func (g *GeminiAI) modelName() string {
+	return g.model.get()
+}
+
 type geminiRequest struct {
 	Contents         []geminiContent         `json:"contents"`
 	GenerationConfig *geminiGenerationConfig `json:"generationConfig,omitempty"`
@@ -88,7 +146,13 @@ type geminiContent struct {
 }
 
 type geminiPart struct {
-	Text string `json:"text"`
+	Text       string            `json:"text,omitempty"`
+	InlineData *geminiInlineData `json:"inline_data,omitempty"`
+}
+
+type geminiInlineData struct {
+	MimeType string `json:"mime_type"`
+	Data     string `json:"data"` // base64 encoded
 }
 
 type geminiGenerationConfig struct {
@@ -124,17 +188,24 @@ func cleanJSON(s string) string {
 }
 
 func (g *GeminiAI) sendGeminiRequest(ctx context.Context, prompt string) (*geminiResponse, string, error) {
-	model := g.model
+	return g.sendGeminiParts(ctx, []geminiPart{{Text: prompt}})
+}
+
+// sendGeminiParts calls generateContent, retrying 5xx responses, rate limiting
+// and timeouts with jittered backoff. generateContent has no side effects, so
+// a retried call can't be applied twice.
+func (g *GeminiAI) sendGeminiParts(ctx context.Context, parts []geminiPart) (*geminiResponse, string, error) {
+	model := g.model.get()
 	if model == "" {
 		model = defaultGeminiModel
 	}
-	url := "https://generativelanguage.googleapis.com/v1beta/models/" + model + ":generateContent?key=" + g.apiKey
+	url := g.baseURL + "/models/" + model + ":generateContent?key=" + g.apiKey
 
 	maskedKey := g.apiKey
 	if len(maskedKey) > 8 {
 		maskedKey = maskedKey[:4] + "..." + maskedKey[len(maskedKey)-4:]
 	}
-	log.Printf("DEBUG: Sending request to Gemini API. Model: %s, URL: %s", model, "https://generativelanguage.googleapis.com/v1beta/models/"+model+":generateContent?key="+maskedKey)
+	slog.DebugContext(ctx, "Sending request to Gemini API", "model", model, "url", g.baseURL+"/models/"+model+":generateContent?key="+maskedKey)
 
 	// Gemma 3 models do not support "response_mime_type": "application/json"
 	useJSONMode := !strings.Contains(strings.ToLower(model), "gemma-3")
@@ -149,9 +220,7 @@ func (g *GeminiAI) sendGeminiRequest(ctx context.Context, prompt string) (*gemin
 	reqBody := geminiRequest{
 		Contents: []geminiContent{
 			{
-				Parts: []geminiPart{
-					{Text: prompt},
-				},
+				Parts: parts,
 			},
 		},
 		GenerationConfig: generationConfig,
@@ -162,61 +231,85 @@ func (g *GeminiAI) sendGeminiRequest(ctx context.Context, prompt string) (*gemin
 		return nil, "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	for retry := 0; ; retry++ {
+		if !g.breaker.allow(g.now()) {
+			return nil, "", fmt.Errorf("Gemini API: %w", ErrCircuitOpen)
+		}
+
+		bodyBytes, err := g.postGemini(ctx, url, jsonBody)
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up; that says nothing about the API
+			g.breaker.release()
+			return nil, "", fmt.Errorf("failed to call API: %w", err)
+		}
+		if isServerFailure(err) {
+			if g.breaker.record(err, g.now()) {
+				slog.WarnContext(ctx, "Gemini API circuit breaker opened", "cooldown", g.policy.BreakerCooldown, "error", err)
+			}
+		} else {
+			g.breaker.record(nil, g.now())
+		}
+
+		if err != nil {
+			if !isRetryable(err) || retry >= g.policy.MaxRetries {
+				var apiErr *apiError
+				if errors.As(err, &apiErr) {
+					return nil, apiErr.Body, err
+				}
+				return nil, "", fmt.Errorf("failed to call API: %w", err)
+			}
+			delay := g.policy.backoff(retry)
+			slog.WarnContext(ctx, "Gemini API call failed, retrying", "retry", retry+1, "delay", delay, "error", err)
+			g.breaker.countRetry()
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, "", fmt.Errorf("failed to call API: %w", err)
+			}
+			continue
+		}
+
+		rawResponse := string(bodyBytes)
+		slog.DebugContext(ctx, "Gemini API raw response", "response", rawResponse)
+
+		var geminiResp geminiResponse
+		if err := json.Unmarshal(bodyBytes, &geminiResp); err != nil {
+			return nil, rawResponse, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		return &geminiResp, rawResponse, nil
+	}
+}
+
+// postGemini sends one generateContent request and returns the response body,
+// or an *apiError for a non-200 response
+func (g *GeminiAI) postGemini(ctx context.Context, url string, jsonBody []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := g.httpClient.Do(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to call API: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
-	rawResponse := string(bodyBytes)
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("ERROR: Gemini API returned status %d. Response: %s", resp.StatusCode, rawResponse)
-		return nil, rawResponse, fmt.Errorf("API error %d: %s", resp.StatusCode, rawResponse)
-	}
-
-	log.Printf("DEBUG: Gemini API raw response: %s", rawResponse)
-
-	var geminiResp geminiResponse
-	if err := json.Unmarshal(bodyBytes, &geminiResp); err != nil {
-		return nil, rawResponse, fmt.Errorf("failed to decode response: %w", err)
+		slog.ErrorContext(ctx, "Gemini API returned an error", "status", resp.StatusCode, "response", string(bodyBytes))
+		return nil, &apiError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
-
-	return &geminiResp, rawResponse, nil
+	return bodyBytes, nil
 }
 
 func (g *GeminiAI) callGeminiAPI(ctx context.Context, text string) (*ParseExpenseResponse, error) {
-	prompt := fmt.Sprintf(`
-You are an expense tracking assistant. Extract expenses from the following text.
-Today is %s.
-
-Return a JSON array of objects with these fields:
-- description: string (what was bought)
-- amount: number (price)
-- currency: string (ISO 4217 code like TWD, JPY, USD; use uppercase; leave empty if ambiguous)
-- currency_original: string (exact word or symbol the user typed for currency, e.g., "$", "日幣")
-- suggested_category: string (Food, Transport, Shopping, Entertainment, Other)
-- date: string (ISO 8601 format YYYY-MM-DD, resolve relative dates like "yesterday" based on today's date)
-- account: string (optional, the specific account/card used, e.g. "台新信用卡", "西瓜卡", "中信銀行", or null if not specified)
-
-If the currency is not specified, assume TWD for calculations but still set currency to "TWD" and currency_original to the best hint (or "" if none).
-If no expenses are found, return an empty array [].
-
-Text: %s
-`, time.Now().Format("2006-01-02"), text)
-
-	log.Printf("DEBUG: Gemini AI Parse Prompt: %s", prompt)
+	prompt, promptVersion := renderPrompt(ctx, g.prompts, domain.PromptParseExpense, PromptData{Text: text})
+
+	slog.DebugContext(ctx, "Gemini parse prompt", "prompt", prompt)
 	geminiResp, rawResp, err := g.sendGeminiRequest(ctx, prompt)
 	if err != nil {
 		return nil, err
@@ -229,7 +322,7 @@ Text: %s
 	responseText := geminiResp.Candidates[0].Content.Parts[0].Text
 
 	// Parse the JSON array from the response text
-	expenses, err := parseGeminiResponseText(responseText)
+	expenses, err := parseGeminiResponseText(responseText, domain.LocationFromContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse Gemini response: %w", err)
 	}
@@ -242,14 +335,17 @@ Text: %s
 	}
 
 	return &ParseExpenseResponse{
-		Expenses:     expenses,
-		Tokens:       tokens,
-		SystemPrompt: prompt,
-		RawResponse:  rawResp,
+		Expenses:      expenses,
+		Tokens:        tokens,
+		SystemPrompt:  prompt,
+		PromptVersion: promptVersion,
+		RawResponse:   rawResp,
 	}, nil
 }
 
-func parseGeminiResponseText(responseText string) ([]*domain.ParsedExpense, error) {
+// parseGeminiResponseText converts the model's expense JSON into parsed
+// expenses, with their dates in loc, the user's timezone
+func parseGeminiResponseText(responseText string, loc *time.Location) ([]*domain.ParsedExpense, error) {
 	responseText = cleanJSON(responseText)
 
 	var parsedItems []struct {
@@ -260,6 +356,7 @@ func parseGeminiResponseText(responseText string) ([]*domain.ParsedExpense, erro
 		SuggestedCategory string  `json:"suggested_category"`
 		Date              string  `json:"date"`
 		Account           string  `json:"account"` // Renamed from payment_method
+		Merchant          string  `json:"merchant"`
 	}
 
 	if err := json.Unmarshal([]byte(responseText), &parsedItems); err != nil {
@@ -270,13 +367,13 @@ func parseGeminiResponseText(responseText string) ([]*domain.ParsedExpense, erro
 	for _, item := range parsedItems {
 		var expenseDate time.Time
 		if item.Date != "" {
-			if parsedDate, err := time.Parse("2006-01-02", item.Date); err == nil {
+			if parsedDate, err := time.ParseInLocation("2006-01-02", item.Date, loc); err == nil {
 				expenseDate = parsedDate
 			} else {
-				expenseDate = time.Now()
+				expenseDate = time.Now().In(loc)
 			}
 		} else {
-			expenseDate = time.Now()
+			expenseDate = time.Now().In(loc)
 		}
 
 		currencyCode := strings.ToUpper(strings.TrimSpace(item.Currency))
@@ -288,30 +385,17 @@ func parseGeminiResponseText(responseText string) ([]*domain.ParsedExpense, erro
 			CurrencyOriginal:  currencyOriginal,
 			SuggestedCategory: item.SuggestedCategory,
 			Account:           item.Account,
+			Merchant:          strings.TrimSpace(item.Merchant),
 			Date:              expenseDate,
 		})
 	}
 	return expenses, nil
 }
 
-func (g *GeminiAI) callGeminiCategoryAPI(ctx context.Context, description string) (*SuggestCategoryResponse, error) {
-	prompt := fmt.Sprintf(`
-You are an expense tracking assistant. Categorize the following expense description into one of these categories:
-- Food
-- Transport
-- Shopping
-- Entertainment
-- Other
-- Health
-- Education
-- Bills
-
-Description: %s
+func (g *GeminiAI) callGeminiCategoryAPI(ctx context.Context, description string, hints *CategoryHints) (*SuggestCategoryResponse, error) {
+	prompt, promptVersion := renderPrompt(ctx, g.prompts, domain.PromptSuggestCategory, PromptData{Description: description, Examples: categoryExamples(hints)})
 
-Return JUST the category name. Do not add any punctuation or explanation.
-`, description)
-
-	log.Printf("DEBUG: Gemini AI Category Prompt: %s", prompt)
+	slog.DebugContext(ctx, "Gemini category prompt", "prompt", prompt)
 	geminiResp, rawResp, err := g.sendGeminiRequest(ctx, prompt)
 	if err != nil {
 		return nil, err
@@ -321,11 +405,7 @@ Return JUST the category name. Do not add any punctuation or explanation.
 		return nil, fmt.Errorf("no content in response")
 	}
 
-	category := strings.TrimSpace(geminiResp.Candidates[0].Content.Parts[0].Text)
-	category = cleanJSON(category)
-
-	// Clean up category string just in case
-	category = strings.Trim(category, ".\"")
+	category, confidence, alternatives := parseCategorySuggestion(geminiResp.Candidates[0].Content.Parts[0].Text)
 
 	tokens := &TokenMetadata{
 		InputTokens:  geminiResp.UsageMetadata.PromptTokenCount,
@@ -334,16 +414,91 @@ Return JUST the category name. Do not add any punctuation or explanation.
 	}
 
 	return &SuggestCategoryResponse{
-		Category:     category,
-		Tokens:       tokens,
-		SystemPrompt: prompt,
-		RawResponse:  rawResp,
+		Category:      category,
+		Confidence:    confidence,
+		Alternatives:  alternatives,
+		Tokens:        tokens,
+		SystemPrompt:  prompt,
+		PromptVersion: promptVersion,
+		RawResponse:   rawResp,
 	}, nil
 }
 
-// parseExpenseRegex uses regex to extract expenses (fallback when AI unavailable)
+// ParseReceipt extracts line items from a receipt photo using Gemini's multimodal input
+func (g *GeminiAI) ParseReceipt(ctx context.Context, image []byte, mimeType string, userID string) (*ParseReceiptResponse, error) {
+	prompt, promptVersion := renderPrompt(ctx, g.prompts, domain.PromptParseReceipt, PromptData{})
+	slog.DebugContext(ctx, "Gemini receipt prompt", "prompt", prompt)
 
+	geminiResp, rawResp, err := g.sendGeminiParts(ctx, []geminiPart{
+		{Text: prompt},
+		{InlineData: &geminiInlineData{MimeType: mimeType, Data: base64.StdEncoding.EncodeToString(image)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no content in response")
+	}
+
+	receipt, err := parseReceiptResponseText(geminiResp.Candidates[0].Content.Parts[0].Text, domain.LocationFromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Gemini receipt response: %w", err)
+	}
+
+	receipt.Tokens = &TokenMetadata{
+		InputTokens:  geminiResp.UsageMetadata.PromptTokenCount,
+		OutputTokens: geminiResp.UsageMetadata.CandidatesTokenCount,
+		TotalTokens:  geminiResp.UsageMetadata.PromptTokenCount + geminiResp.UsageMetadata.CandidatesTokenCount,
+	}
+	receipt.SystemPrompt = prompt
+	receipt.PromptVersion = promptVersion
+	receipt.RawResponse = rawResp
+	return receipt, nil
+}
+
+// Transcribe converts a voice message to text using Gemini's audio input
+func (g *GeminiAI) Transcribe(ctx context.Context, audio []byte, mimeType string) (*TranscriptionResponse, error) {
+	prompt, promptVersion := renderPrompt(ctx, g.prompts, domain.PromptTranscribe, PromptData{})
+
+	geminiResp, rawResp, err := g.sendGeminiParts(ctx, []geminiPart{
+		{Text: prompt},
+		{InlineData: &geminiInlineData{MimeType: mimeType, Data: base64.StdEncoding.EncodeToString(audio)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
+		return nil, fmt.Errorf("no content in response")
+	}
+
+	// JSON mode may wrap the transcript in a JSON string literal
+	transcript := cleanJSON(geminiResp.Candidates[0].Content.Parts[0].Text)
+	var unquoted string
+	if err := json.Unmarshal([]byte(transcript), &unquoted); err == nil {
+		transcript = unquoted
+	}
+
+	return &TranscriptionResponse{
+		Text: strings.TrimSpace(transcript),
+		Tokens: &TokenMetadata{
+			InputTokens:  geminiResp.UsageMetadata.PromptTokenCount,
+			OutputTokens: geminiResp.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:  geminiResp.UsageMetadata.PromptTokenCount + geminiResp.UsageMetadata.CandidatesTokenCount,
+		},
+		PromptVersion: promptVersion,
+		RawResponse:   rawResp,
+	}, nil
+}
+
+// parseExpenseRegex uses regex to extract expenses (fallback when AI unavailable)
 func (g *GeminiAI) parseExpenseRegex(text string) ([]*domain.ParsedExpense, error) {
+	return regexParseExpenses(text)
+}
+
+// regexParseExpenses extracts expenses with regex patterns, shared by all providers as a fallback
+func regexParseExpenses(text string) ([]*domain.ParsedExpense, error) {
 	var expenses []*domain.ParsedExpense
 
 	// Helper to add expense
@@ -436,19 +591,22 @@ func detectCurrencyFromContext(text string) (string, string) {
 
 // SuggestCategory suggests a category based on description
 func (g *GeminiAI) SuggestCategory(ctx context.Context, description string, userID string) (*SuggestCategoryResponse, error) {
+	hints := loadCategoryHints(ctx, g.categoryHints, userID)
+
 	// Try Gemini API first
-	resp, err := g.callGeminiCategoryAPI(ctx, description)
+	resp, err := g.callGeminiCategoryAPI(ctx, description, hints)
 	if err == nil {
 		return resp, nil
 	}
 
-	log.Printf("WARN: Gemini API failed for category suggestion (using fallback): %v", err)
+	slog.WarnContext(ctx, "Gemini API failed for category suggestion, using fallback", "error", err)
 
-	// Fallback to keyword matching (free, no API call)
-	category := g.suggestCategoryKeywords(description)
+	// Fallback to keyword matching (free, no API call), preferring the user's learned keywords
+	category := fallbackSuggestCategory(hints, description)
 
 	return &SuggestCategoryResponse{
-		Category: category,
+		Category:   category,
+		Confidence: 1,
 		Tokens: &TokenMetadata{
 			InputTokens:  0,
 			OutputTokens: 0,
@@ -459,6 +617,55 @@ func (g *GeminiAI) SuggestCategory(ctx context.Context, description string, user
 
 // suggestCategoryKeywords uses keyword matching for category suggestion (fallback)
 func (g *GeminiAI) suggestCategoryKeywords(description string) string {
+	return keywordSuggestCategory(description)
+}
+
+// parseCategorySuggestion reads a category answer, either the JSON object asked for by the
+// built-in prompt or just a category name from older prompt versions
+func parseCategorySuggestion(text string) (string, float64, []string) {
+	text = cleanJSON(text)
+
+	var suggestion struct {
+		Category     string   `json:"category"`
+		Confidence   *float64 `json:"confidence"`
+		Alternatives []string `json:"alternatives"`
+	}
+	if err := json.Unmarshal([]byte(text), &suggestion); err != nil || suggestion.Category == "" {
+		return strings.Trim(text, ".\""), 1, nil
+	}
+
+	category := strings.Trim(strings.TrimSpace(suggestion.Category), ".\"")
+	confidence := 1.0
+	if suggestion.Confidence != nil {
+		confidence = math.Max(0, math.Min(1, *suggestion.Confidence))
+	}
+
+	var alternatives []string
+	for _, alt := range suggestion.Alternatives {
+		alt = strings.Trim(strings.TrimSpace(alt), ".\"")
+		if alt == "" || strings.EqualFold(alt, category) || containsFold(alternatives, alt) {
+			continue
+		}
+		alternatives = append(alternatives, alt)
+		if len(alternatives) == 3 {
+			break
+		}
+	}
+	return category, confidence, alternatives
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// keywordSuggestCategory matches built-in keywords to a category, shared by all providers as a fallback
+func keywordSuggestCategory(description string) string {
 	description = strings.ToLower(description)
 
 	foodKeywords := []string{"早餐", "午餐", "晚餐", "咖啡", "吃", "食物", "餐", "飯", "菜", "麵"}
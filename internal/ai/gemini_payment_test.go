@@ -2,6 +2,7 @@ package ai
 
 import (
 	"testing"
+	"time"
 )
 
 func TestParseGeminiResponseText_Account(t *testing.T) {
@@ -21,7 +22,7 @@ func TestParseGeminiResponseText_Account(t *testing.T) {
 		}
 	]`
 
-	expenses, err := parseGeminiResponseText(jsonText)
+	expenses, err := parseGeminiResponseText(jsonText, time.UTC)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -40,3 +41,19 @@ func TestParseGeminiResponseText_Account(t *testing.T) {
 		t.Errorf("Expense 2: expected Account 'Credit Card', got '%s'", expenses[1].Account)
 	}
 }
+
+func TestParseGeminiResponseText_Merchant(t *testing.T) {
+	expenses, err := parseGeminiResponseText(`[{"description": "小籠包", "amount": 250, "merchant": " 鼎泰豐 "}, {"description": "Gas", "amount": 1500}]`, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(expenses) != 2 {
+		t.Fatalf("expected 2 expenses, got %d", len(expenses))
+	}
+	if expenses[0].Merchant != "鼎泰豐" {
+		t.Errorf("expected merchant '鼎泰豐', got '%s'", expenses[0].Merchant)
+	}
+	if expenses[1].Merchant != "" {
+		t.Errorf("expected no merchant, got '%s'", expenses[1].Merchant)
+	}
+}
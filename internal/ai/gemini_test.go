@@ -2,7 +2,13 @@ package ai
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
 )
@@ -144,6 +150,71 @@ func TestSuggestCategoryKeywords(t *testing.T) {
 	}
 }
 
+func TestParseCategorySuggestion(t *testing.T) {
+	tests := []struct {
+		name                 string
+		text                 string
+		expectedCategory     string
+		expectedConfidence   float64
+		expectedAlternatives []string
+	}{
+		{
+			name:                 "json answer",
+			text:                 `{"category": "Food", "confidence": 0.42, "alternatives": ["Entertainment", "Shopping"]}`,
+			expectedCategory:     "Food",
+			expectedConfidence:   0.42,
+			expectedAlternatives: []string{"Entertainment", "Shopping"},
+		},
+		{
+			name:                 "json in code block with duplicate alternatives",
+			text:                 "```json\n{\"category\": \"Transport\", \"confidence\": 0.3, \"alternatives\": [\"transport\", \"Bills\", \"Bills\", \"Other\", \"Shopping\", \"Food\"]}\n```",
+			expectedCategory:     "Transport",
+			expectedConfidence:   0.3,
+			expectedAlternatives: []string{"Bills", "Other", "Shopping"},
+		},
+		{
+			name:               "json without confidence",
+			text:               `{"category": "Bills"}`,
+			expectedCategory:   "Bills",
+			expectedConfidence: 1,
+		},
+		{
+			name:               "confidence out of range",
+			text:               `{"category": "Bills", "confidence": 85}`,
+			expectedCategory:   "Bills",
+			expectedConfidence: 1,
+		},
+		{
+			name:               "plain category name",
+			text:               "Shopping.",
+			expectedCategory:   "Shopping",
+			expectedConfidence: 1,
+		},
+		{
+			name:               "quoted category name",
+			text:               `"Health"`,
+			expectedCategory:   "Health",
+			expectedConfidence: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, confidence, alternatives := parseCategorySuggestion(tt.text)
+
+			if category != tt.expectedCategory {
+				t.Errorf("expected category %q, got %q", tt.expectedCategory, category)
+			}
+			if confidence != tt.expectedConfidence {
+				t.Errorf("expected confidence %v, got %v", tt.expectedConfidence, confidence)
+			}
+			if strings.Join(alternatives, ",") != strings.Join(tt.expectedAlternatives, ",") {
+				t.Errorf("expected alternatives %v, got %v", tt.expectedAlternatives, alternatives)
+			}
+		})
+	}
+}
+
 func TestNewGeminiAI(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -174,7 +245,7 @@ func TestNewGeminiAI(t *testing.T) {
 }
 
 func TestParseExpense(t *testing.T) {
-	ai := &GeminiAI{apiKey: "test"}
+	ai, _ := NewGeminiAI("test", "", nil)
 	ctx := context.Background()
 
 	text := "早餐$20午餐$30"
@@ -207,7 +278,7 @@ func TestParseExpense(t *testing.T) {
 }
 
 func TestSuggestCategory(t *testing.T) {
-	ai := &GeminiAI{apiKey: "test"}
+	ai, _ := NewGeminiAI("test", "", nil)
 	ctx := context.Background()
 
 	resp, err := ai.SuggestCategory(ctx, "早餐咖啡", "test_user")
@@ -225,3 +296,81 @@ func TestSuggestCategory(t *testing.T) {
 		t.Errorf("expected 0 tokens for keyword match, got %d", resp.Tokens.TotalTokens)
 	}
 }
+
+func TestGeminiClient_RetriesAndCircuitBreaker(t *testing.T) {
+	var calls int32
+	var status int32 = http.StatusServiceUnavailable
+	failures := int32(2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if n <= atomic.LoadInt32(&failures) {
+			w.WriteHeader(int(atomic.LoadInt32(&status)))
+			w.Write([]byte(`{"error": "unavailable"}`))
+			return
+		}
+		w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "[{\"description\": \"lunch\", \"amount\": 100}]"}]}}], "usageMetadata": {"promptTokenCount": 10, "candidatesTokenCount": 5}}`))
+	}))
+	defer server.Close()
+
+	newClient := func(maxRetries, threshold int) *GeminiAI {
+		g, _ := NewGeminiAI("test", "", nil)
+		g.baseURL = server.URL
+		g.SetClientPolicy(ClientPolicy{MaxRetries: maxRetries, RetryBaseDelay: time.Millisecond, BreakerThreshold: threshold, BreakerCooldown: time.Minute})
+		return g
+	}
+	ctx := context.Background()
+
+	g := newClient(2, 3)
+	resp, err := g.callGeminiAPI(ctx, "lunch 100")
+	if err != nil || len(resp.Expenses) != 1 || resp.Tokens.TotalTokens != 15 {
+		t.Fatalf("expected the call to succeed on the third attempt, got %+v, %v", resp, err)
+	}
+	if health := g.Health()[0]; health.State != CircuitClosed || health.Retries != 2 || health.Calls != 3 || health.Failures != 2 {
+		t.Errorf("expected 2 retries and a closed breaker, got %+v", health)
+	}
+
+	// A client error is not retried and doesn't count toward the breaker
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&status, http.StatusBadRequest)
+	g = newClient(2, 3)
+	if _, err := g.callGeminiAPI(ctx, "lunch 100"); err == nil || atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("expected one failed attempt, got %d: %v", calls, err)
+	}
+
+	// Consecutive 5xx responses open the breaker, which then fails fast
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&status, http.StatusInternalServerError)
+	atomic.StoreInt32(&failures, 100)
+	g = newClient(0, 2)
+	g.callGeminiAPI(ctx, "lunch 100")
+	g.callGeminiAPI(ctx, "lunch 100")
+	if _, err := g.callGeminiAPI(ctx, "lunch 100"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("expected the open breaker to skip the API, got %d calls", n)
+	}
+	if health := g.Health()[0]; health.State != CircuitOpen || health.OpenUntil == nil {
+		t.Errorf("expected an open breaker, got %+v", health)
+	}
+}
+
+func TestGeminiAI_SetModel(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`{"candidates": [{"content": {"parts": [{"text": "[]"}]}}]}`))
+	}))
+	defer server.Close()
+
+	g, _ := NewGeminiAI("test", "", nil)
+	g.baseURL = server.URL
+	if g.Model() != defaultGeminiModel {
+		t.Errorf("expected the default model, got %q", g.Model())
+	}
+	g.SetModel("gemini-2.5-pro")
+	g.callGeminiAPI(context.Background(), "lunch 100")
+	if path != "/models/gemini-2.5-pro:generateContent" {
+		t.Errorf("expected the request to use the new model, got %s", path)
+	}
+}
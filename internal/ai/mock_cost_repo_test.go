@@ -35,3 +35,7 @@ func (m *MockAICostRepository) GetByOperation(ctx context.Context, from, to time
 func (m *MockAICostRepository) GetByUserSummary(ctx context.Context, from, to time.Time, limit int) ([]*domain.AICostByUser, error) {
 	return nil, nil
 }
+
+func (m *MockAICostRepository) GetUserSummary(ctx context.Context, userID string, from, to time.Time) (*domain.AICostSummary, error) {
+	return nil, nil
+}
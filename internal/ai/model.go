@@ -0,0 +1,56 @@
+package ai
+
+import "sync/atomic"
+
+var _ ModelConfigurable = (*GeminiAI)(nil)
+var _ ModelConfigurable = (*ClaudeAI)(nil)
+
+// ModelConfigurable is implemented by providers whose model can be switched
+// while they serve requests, as a configuration reload does
+type ModelConfigurable interface {
+	SetModel(model string)
+	Model() string
+}
+
+// modelSetting holds a provider's model, read by every request and written
+// by reloads
+type modelSetting struct {
+	name atomic.Value
+}
+
+func (m *modelSetting) get() string {
+	name, _ := m.name.Load().(string)
+	return name
+}
+
+func (m *modelSetting) set(name string) {
+	m.name.Store(name)
+}
+
+// SetModel switches the Gemini model of the following requests; empty
+// switches back to the default model
+func (g *GeminiAI) SetModel(model string) {
+	if model == "" {
+		model = defaultGeminiModel
+	}
+	g.model.set(model)
+}
+
+// Model returns the Gemini model requests are sent to
+func (g *GeminiAI) Model() string {
+	return g.model.get()
+}
+
+// SetModel switches the Claude model of the following requests; empty
+// switches back to the default model
+func (c *ClaudeAI) SetModel(model string) {
+	if model == "" {
+		model = defaultClaudeModel
+	}
+	c.model.set(model)
+}
+
+// Model returns the Claude model requests are sent to
+func (c *ClaudeAI) Model() string {
+	return c.model.get()
+}
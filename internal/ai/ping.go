@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var _ Pinger = (*GeminiAI)(nil)
+var _ Pinger = (*ClaudeAI)(nil)
+var _ Pinger = (*FailoverService)(nil)
+
+// Pinger is implemented by AI services that can check their provider is
+// reachable and accepts the API key, without spending tokens
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping lists a single model of the Gemini API
+func (g *GeminiAI) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", g.baseURL+"/models?pageSize=1", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-goog-api-key", g.apiKey)
+	return ping(g.httpClient, req)
+}
+
+// Ping lists a single model of the Anthropic API
+func (c *ClaudeAI) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models?limit=1", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", claudeAPIVersion)
+	return ping(c.httpClient, req)
+}
+
+// Ping succeeds when any provider of the chain is reachable, as the chain
+// still answers then. A chain of only regex has nothing to reach.
+func (f *FailoverService) Ping(ctx context.Context) error {
+	var errs []error
+	for _, circuit := range f.circuits {
+		pinger, ok := circuit.provider.(Pinger)
+		if !ok {
+			continue
+		}
+		err := pinger.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", circuit.name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// ping sends req and expects a 200. Errors leave out the URL and the body of
+// the response, as health checks are shown to anyone who asks.
+func ping(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to call API: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API error %d", resp.StatusCode)
+	}
+	return nil
+}
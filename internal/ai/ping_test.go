@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGeminiAI_Ping(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || r.Header.Get("x-goog-api-key") != "test" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	g, _ := NewGeminiAI("test", "", nil)
+	g.baseURL = server.URL
+	if err := g.Ping(context.Background()); err != nil {
+		t.Errorf("expected the ping to succeed, got %v", err)
+	}
+	status = http.StatusForbidden
+	if err := g.Ping(context.Background()); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected a 403 error, got %v", err)
+	}
+
+	// The key stays out of errors, which readiness shows
+	server.Close()
+	if err := g.Ping(context.Background()); err == nil || strings.Contains(err.Error(), server.URL) {
+		t.Errorf("expected a connection error without the URL, got %v", err)
+	}
+}
+
+func TestClaudeAI_Ping(t *testing.T) {
+	c := newTestClaudeAI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/models" || r.Header.Get("x-api-key") != "test_key" || r.Header.Get("anthropic-version") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": []}`))
+	})
+	if err := c.Ping(context.Background()); err != nil {
+		t.Errorf("expected the ping to succeed, got %v", err)
+	}
+}
+
+// fakePingProvider is a chain provider whose ping fails while pingErr is set
+type fakePingProvider struct {
+	fakeChainProvider
+	pingErr error
+}
+
+func (p *fakePingProvider) Ping(ctx context.Context) error {
+	return p.pingErr
+}
+
+func TestFailoverService_Ping(t *testing.T) {
+	primary := &fakePingProvider{pingErr: errors.New("API error 503")}
+	secondary := &fakePingProvider{}
+	f := newFailoverService([]*providerCircuit{
+		{name: "gemini", provider: primary},
+		{name: "claude", provider: secondary},
+	}, true, 2, time.Minute)
+
+	if err := f.Ping(context.Background()); err != nil {
+		t.Errorf("expected the chain to be reachable through claude, got %v", err)
+	}
+	secondary.pingErr = errors.New("API error 401")
+	err := f.Ping(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "gemini: API error 503") || !strings.Contains(err.Error(), "claude: API error 401") {
+		t.Errorf("expected both providers' errors, got %v", err)
+	}
+
+	if err := newFailoverService(nil, true, 2, time.Minute).Ping(context.Background()); err != nil {
+		t.Errorf("expected a regex-only chain to have nothing to reach, got %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -41,7 +42,7 @@ func (g *GeminiPricingProvider) Fetch(ctx context.Context) ([]*domain.PricingCon
 
 		lastErr = err
 		backoff := time.Duration((1 << uint(attempt-1))) * time.Second
-		fmt.Printf("[WARN] pricing_fetch attempt %d/3 failed: %v, retrying in %vs\n", attempt, err, backoff.Seconds())
+		slog.WarnContext(ctx, "Pricing fetch failed", "provider", "gemini", "attempt", attempt, "max_attempts", 3, "retry_in", backoff, "error", err)
 
 		if attempt < 3 {
 			select {
@@ -52,7 +53,7 @@ func (g *GeminiPricingProvider) Fetch(ctx context.Context) ([]*domain.PricingCon
 		}
 	}
 
-	fmt.Printf("[ERROR] pricing_fetch failed after 3 attempts for provider=gemini: %v\n", lastErr)
+	slog.ErrorContext(ctx, "Pricing fetch failed after all attempts", "provider", "gemini", "attempts", 3, "error", lastErr)
 	return nil, fmt.Errorf("failed to fetch gemini pricing after 3 attempts: %w", lastErr)
 }
 
@@ -0,0 +1,164 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// PromptSource supplies the active version of a managed prompt template
+type PromptSource interface {
+	GetActive(ctx context.Context, name string) (*domain.PromptTemplate, error)
+}
+
+// PromptConfigurable is implemented by providers whose prompts can be edited at runtime
+type PromptConfigurable interface {
+	SetPromptSource(source PromptSource)
+}
+
+// PromptData is the data available to prompt templates
+type PromptData struct {
+	Today       string            // YYYY-MM-DD
+	Text        string            // message text, for parse_expense
+	Description string            // expense description, for suggest_category
+	Examples    []CategoryExample // the user's own past categorizations, for suggest_category
+}
+
+// defaultPrompts are the built-in templates (version 0) shared by all providers
+var defaultPrompts = map[string]string{
+	domain.PromptParseExpense: `
+You are an expense tracking assistant. Extract expenses from the following text.
+Today is {{.Today}}.
+
+Return a JSON array of objects with these fields:
+- description: string (what was bought)
+- amount: number (price)
+- currency: string (ISO 4217 code like TWD, JPY, USD; use uppercase; leave empty if ambiguous)
+- currency_original: string (exact word or symbol the user typed for currency, e.g., "$", "日幣")
+- suggested_category: string (Food, Transport, Shopping, Entertainment, Other)
+- date: string (ISO 8601 format YYYY-MM-DD, resolve relative dates like "yesterday" based on today's date)
+- account: string (optional, the specific account/card used, e.g. "台新信用卡", "西瓜卡", "中信銀行", or null if not specified)
+- merchant: string (optional, the store, restaurant or service paid, e.g. "星巴克", "7-11", "Uber", or "" if not mentioned)
+
+If the currency is not specified, assume TWD for calculations but still set currency to "TWD" and currency_original to the best hint (or "" if none).
+Split-bill phrases like "三人平分" or "split 3 ways" are not expenses; record the full bill amount once.
+If no expenses are found, return an empty array [].
+
+Text: {{.Text}}
+`,
+
+	domain.PromptSuggestCategory: `
+You are an expense tracking assistant. Categorize the following expense description into one of these categories:
+- Food
+- Transport
+- Shopping
+- Entertainment
+- Other
+- Health
+- Education
+- Bills
+
+{{if .Examples}}This user has filed these expenses as follows; follow their habits:
+{{range .Examples}}- "{{.Description}}" -> {{.Category}}
+{{end}}
+{{end}}Description: {{.Description}}
+
+Return a JSON object with these fields:
+- category: string (the best matching category name)
+- confidence: number (0 to 1, how sure you are about category)
+- alternatives: array of up to 3 other likely category names, most likely first
+
+Do not add any explanation.
+`,
+
+	domain.PromptParseReceipt: `
+You are an expense tracking assistant. Read the attached receipt photo and extract its line items.
+Today is {{.Today}}.
+
+Return a JSON object with these fields:
+- merchant: string (store or restaurant name, "" if unreadable)
+- date: string (ISO 8601 format YYYY-MM-DD as printed on the receipt, "" if missing)
+- currency: string (ISO 4217 code like TWD, JPY, USD; use uppercase; "" if ambiguous)
+- total: number (grand total printed on the receipt, 0 if missing)
+- items: array of objects with these fields:
+  - description: string (item name as printed)
+  - quantity: number (units bought, 1 if not printed)
+  - amount: number (line total including quantity)
+  - suggested_category: string (Food, Transport, Shopping, Entertainment, Other)
+
+Ignore subtotal, tax, change and payment lines when listing items.
+If the image is not a receipt, return {"items": []}.
+`,
+
+	domain.PromptTranscribe: `
+Transcribe the attached voice message verbatim in the language it was spoken.
+Keep numbers, amounts and currency words exactly as said (e.g. "兩百塊", "$20").
+Return only the transcript text with no explanation. If there is no speech, return an empty string.
+`,
+}
+
+// DefaultPrompt returns the built-in template for a prompt
+func DefaultPrompt(name string) (string, bool) {
+	tmpl, ok := defaultPrompts[name]
+	return tmpl, ok
+}
+
+// ValidatePrompt checks that a template parses and renders with sample data
+func ValidatePrompt(tmpl string) error {
+	_, err := executePrompt(tmpl, PromptData{
+		Today:       "2006-01-02",
+		Text:        "lunch 120",
+		Description: "lunch",
+		Examples:    []CategoryExample{{Description: "coffee", Category: "Food"}},
+	})
+	return err
+}
+
+// renderPrompt renders the active version of a prompt and returns it with its
+// version. The built-in prompt (version 0) is used when no version is active
+// or the active one cannot be loaded or rendered.
+func renderPrompt(ctx context.Context, source PromptSource, name string, data PromptData) (string, int) {
+	if data.Today == "" {
+		data.Today = domain.LocalNow(ctx).Format("2006-01-02")
+	}
+
+	if source != nil {
+		active, err := source.GetActive(ctx, name)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+		case err != nil:
+			slog.WarnContext(ctx, "Failed to load prompt, using built-in", "prompt", name, "error", err)
+		default:
+			prompt, err := executePrompt(active.Template, data)
+			if err == nil {
+				return prompt, active.Version
+			}
+			slog.WarnContext(ctx, "Failed to render prompt, using built-in", "prompt", name, "version", active.Version, "error", err)
+		}
+	}
+
+	prompt, err := executePrompt(defaultPrompts[name], data)
+	if err != nil {
+		// Built-in templates are covered by tests; this only guards against typos
+		slog.ErrorContext(ctx, "Failed to render built-in prompt", "prompt", name, "error", err)
+	}
+	return prompt, 0
+}
+
+// executePrompt renders a prompt template; unknown fields are errors so typos are caught on save
+func executePrompt(tmpl string, data PromptData) (string, error) {
+	t, err := template.New("prompt").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template: %w", err)
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("invalid prompt template: %w", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,71 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// fakePromptSource serves fixed active prompts by name
+type fakePromptSource map[string]*domain.PromptTemplate
+
+func (f fakePromptSource) GetActive(ctx context.Context, name string) (*domain.PromptTemplate, error) {
+	if prompt, ok := f[name]; ok {
+		return prompt, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func TestRenderPrompt(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Built-in prompts render", func(t *testing.T) {
+		for _, name := range domain.PromptNames {
+			prompt, version := renderPrompt(ctx, nil, name, PromptData{Today: "2026-03-01", Text: "lunch 120", Description: "lunch"})
+			if prompt == "" || version != 0 {
+				t.Errorf("%s: expected built-in prompt, got version %d", name, version)
+			}
+			if strings.Contains(prompt, "{{") {
+				t.Errorf("%s: template action left in prompt", name)
+			}
+		}
+
+		prompt, _ := renderPrompt(ctx, nil, domain.PromptParseExpense, PromptData{Today: "2026-03-01", Text: "lunch 120"})
+		if !strings.Contains(prompt, "Today is 2026-03-01.") || !strings.Contains(prompt, "Text: lunch 120") {
+			t.Errorf("unexpected parse prompt: %s", prompt)
+		}
+	})
+
+	t.Run("Active version replaces built-in", func(t *testing.T) {
+		source := fakePromptSource{
+			domain.PromptSuggestCategory: {Name: domain.PromptSuggestCategory, Version: 3, Template: "Category for {{.Description}}?", Active: true},
+		}
+		prompt, version := renderPrompt(ctx, source, domain.PromptSuggestCategory, PromptData{Description: "taxi"})
+		if prompt != "Category for taxi?" || version != 3 {
+			t.Errorf("expected v3 prompt, got %q (v%d)", prompt, version)
+		}
+	})
+
+	t.Run("Broken active version falls back to built-in", func(t *testing.T) {
+		source := fakePromptSource{
+			domain.PromptParseExpense: {Name: domain.PromptParseExpense, Version: 2, Template: "{{.Unknown}}", Active: true},
+		}
+		prompt, version := renderPrompt(ctx, source, domain.PromptParseExpense, PromptData{Text: "lunch 120"})
+		if version != 0 || !strings.Contains(prompt, "Text: lunch 120") {
+			t.Errorf("expected built-in prompt, got %q (v%d)", prompt, version)
+		}
+	})
+}
+
+func TestValidatePrompt(t *testing.T) {
+	if err := ValidatePrompt("Parse {{.Text}} as of {{.Today}}"); err != nil {
+		t.Errorf("expected valid template, got %v", err)
+	}
+	for _, tmpl := range []string{"{{.Text", "{{.Amount}}"} {
+		if err := ValidatePrompt(tmpl); err == nil {
+			t.Errorf("expected error for %q", tmpl)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// maxReceiptDescriptionItems is how many item names describe an expense
+// grouping several lines of a receipt
+const maxReceiptDescriptionItems = 3
+
+// parseReceiptResponseText converts the model's receipt JSON into parsed expenses,
+// one per suggested category, each carrying the line items it adds up and sharing
+// the receipt's merchant, date and currency. The date is read in loc, the user's
+// timezone.
+func parseReceiptResponseText(responseText string, loc *time.Location) (*ParseReceiptResponse, error) {
+	responseText = cleanJSON(responseText)
+
+	var receipt struct {
+		Merchant string  `json:"merchant"`
+		Date     string  `json:"date"`
+		Currency string  `json:"currency"`
+		Total    float64 `json:"total"`
+		Items    []struct {
+			Description       string  `json:"description"`
+			Quantity          float64 `json:"quantity"`
+			Amount            float64 `json:"amount"`
+			SuggestedCategory string  `json:"suggested_category"`
+		} `json:"items"`
+	}
+
+	if err := json.Unmarshal([]byte(responseText), &receipt); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal receipt JSON: %w", err)
+	}
+
+	receiptDate := time.Now().In(loc)
+	if receipt.Date != "" {
+		if parsedDate, err := time.ParseInLocation("2006-01-02", receipt.Date, loc); err == nil {
+			receiptDate = parsedDate
+		}
+	}
+
+	currencyCode := strings.ToUpper(strings.TrimSpace(receipt.Currency))
+	merchant := strings.TrimSpace(receipt.Merchant)
+
+	var expenses []*domain.ParsedExpense
+	byCategory := make(map[string]*domain.ParsedExpense)
+	for _, item := range receipt.Items {
+		name := strings.TrimSpace(item.Description)
+		if name == "" || item.Amount <= 0 {
+			continue
+		}
+		quantity := item.Quantity
+		if quantity <= 0 {
+			quantity = 1
+		}
+		// The line total is what was paid, so the unit price follows from it
+		// and the items always add up to the expense
+		unitPrice := item.Amount / quantity
+
+		category := strings.TrimSpace(item.SuggestedCategory)
+		expense, ok := byCategory[category]
+		if !ok {
+			expense = &domain.ParsedExpense{
+				Currency:          currencyCode,
+				CurrencyOriginal:  currencyCode,
+				SuggestedCategory: category,
+				Merchant:          merchant,
+				Date:              receiptDate,
+			}
+			byCategory[category] = expense
+			expenses = append(expenses, expense)
+		}
+		expense.Amount += item.Amount
+		expense.Items = append(expense.Items, &domain.ExpenseItem{
+			Name:      name,
+			Quantity:  quantity,
+			UnitPrice: unitPrice,
+		})
+	}
+	for _, expense := range expenses {
+		expense.Description = describeReceiptItems(expense.Items)
+	}
+
+	return &ParseReceiptResponse{
+		Merchant: merchant,
+		Expenses: expenses,
+		Total:    receipt.Total,
+	}, nil
+}
+
+// describeReceiptItems names the first few line items of an expense, e.g.
+// "Latte, Sandwich, Cookie…"
+func describeReceiptItems(items []*domain.ExpenseItem) string {
+	names := make([]string, 0, maxReceiptDescriptionItems)
+	for _, item := range items[:min(len(items), maxReceiptDescriptionItems)] {
+		names = append(names, item.Name)
+	}
+	description := strings.Join(names, ", ")
+	if len(items) > maxReceiptDescriptionItems {
+		description += "…"
+	}
+	return description
+}
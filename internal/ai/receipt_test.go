@@ -0,0 +1,85 @@
+package ai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestParseReceiptResponseText(t *testing.T) {
+	jsonText := "```json\n" + `{
+		"merchant": " 7-ELEVEN ",
+		"date": "2026-03-02",
+		"currency": "twd",
+		"total": 160,
+		"items": [
+			{"description": "Latte", "quantity": 2, "amount": 130, "suggested_category": "Food"},
+			{"description": "Umbrella", "amount": 20, "suggested_category": "Shopping"},
+			{"description": "Sandwich", "amount": 10, "suggested_category": "Food"},
+			{"description": "", "amount": 10},
+			{"description": "Discount", "amount": -5}
+		]
+	}` + "\n```"
+
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	receipt, err := parseReceiptResponseText(jsonText, loc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if receipt.Merchant != "7-ELEVEN" {
+		t.Errorf("expected merchant 7-ELEVEN, got %q", receipt.Merchant)
+	}
+	if receipt.Total != 160 {
+		t.Errorf("expected total 160, got %f", receipt.Total)
+	}
+	if len(receipt.Expenses) != 2 {
+		t.Fatalf("expected one expense per category, got %d", len(receipt.Expenses))
+	}
+
+	food := receipt.Expenses[0]
+	if food.Description != "Latte, Sandwich" || food.Amount != 140 || food.SuggestedCategory != "Food" {
+		t.Errorf("expected the Food lines grouped, got %q %f %q", food.Description, food.Amount, food.SuggestedCategory)
+	}
+	if len(food.Items) != 2 {
+		t.Fatalf("expected 2 Food items, got %d", len(food.Items))
+	}
+	if latte := food.Items[0]; latte.Name != "Latte" || latte.Quantity != 2 || latte.UnitPrice != 65 {
+		t.Errorf("expected 2 Latte at 65, got %+v", latte)
+	}
+	if sandwich := food.Items[1]; sandwich.Quantity != 1 || sandwich.UnitPrice != 10 {
+		t.Errorf("expected the quantity to default to 1, got %+v", sandwich)
+	}
+	if shopping := receipt.Expenses[1]; shopping.Description != "Umbrella" || shopping.Amount != 20 || len(shopping.Items) != 1 {
+		t.Errorf("expected the Umbrella on its own, got %+v", shopping)
+	}
+
+	for _, expense := range receipt.Expenses {
+		if expense.Currency != "TWD" {
+			t.Errorf("expected currency TWD, got %q", expense.Currency)
+		}
+		if !expense.Date.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, loc)) {
+			t.Errorf("expected the receipt date in the user's timezone on every item, got %v", expense.Date)
+		}
+	}
+}
+
+func TestDescribeReceiptItems(t *testing.T) {
+	var items []*domain.ExpenseItem
+	for _, name := range []string{"Latte", "Sandwich", "Cookie", "Water"} {
+		items = append(items, &domain.ExpenseItem{Name: name})
+	}
+	if got := describeReceiptItems(items[:3]); got != "Latte, Sandwich, Cookie" {
+		t.Errorf("expected every name, got %q", got)
+	}
+	if got := describeReceiptItems(items); got != "Latte, Sandwich, Cookie…" {
+		t.Errorf("expected the first names, got %q", got)
+	}
+}
+
+func TestParseReceiptResponseText_InvalidJSON(t *testing.T) {
+	if _, err := parseReceiptResponseText("not a receipt", time.UTC); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
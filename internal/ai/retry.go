@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ClientPolicy controls the timeouts, retries and circuit breaker of a provider's HTTP client
+type ClientPolicy struct {
+	Timeout          time.Duration // per attempt
+	MaxRetries       int           // retries after the first attempt
+	RetryBaseDelay   time.Duration // backoff before the first retry, doubling for each one
+	RetryMaxDelay    time.Duration
+	BreakerThreshold int           // consecutive 5xx responses or timeouts that open the breaker
+	BreakerCooldown  time.Duration // how long an open breaker fails calls without sending them
+}
+
+// DefaultClientPolicy is used by providers until SetClientPolicy is called
+var DefaultClientPolicy = ClientPolicy{
+	Timeout:          10 * time.Second,
+	MaxRetries:       2,
+	RetryBaseDelay:   250 * time.Millisecond,
+	RetryMaxDelay:    2 * time.Second,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+// ClientPolicyConfigurable is implemented by providers whose HTTP client policy can be configured
+type ClientPolicyConfigurable interface {
+	SetClientPolicy(policy ClientPolicy)
+}
+
+// withDefaults fills in the unset fields from DefaultClientPolicy; a negative
+// MaxRetries disables retries
+func (p ClientPolicy) withDefaults() ClientPolicy {
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultClientPolicy.Timeout
+	}
+	if p.MaxRetries < 0 {
+		p.MaxRetries = 0
+	}
+	if p.RetryBaseDelay <= 0 {
+		p.RetryBaseDelay = DefaultClientPolicy.RetryBaseDelay
+	}
+	if p.RetryMaxDelay < p.RetryBaseDelay {
+		p.RetryMaxDelay = p.RetryBaseDelay
+	}
+	if p.BreakerThreshold <= 0 {
+		p.BreakerThreshold = DefaultClientPolicy.BreakerThreshold
+	}
+	if p.BreakerCooldown <= 0 {
+		p.BreakerCooldown = DefaultClientPolicy.BreakerCooldown
+	}
+	return p
+}
+
+// backoff returns the wait before retry number retry (from 0): the doubled
+// base delay capped at the max, of which a random half is jitter so clients
+// failing together don't retry together
+func (p ClientPolicy) backoff(retry int) time.Duration {
+	delay := p.RetryBaseDelay << uint(retry)
+	if delay <= 0 || delay > p.RetryMaxDelay {
+		delay = p.RetryMaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// apiError is a non-2xx response from a provider API
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
+
+// isServerFailure reports whether err is a 5xx response, a timeout or a
+// network error, which says the API is unhealthy and counts toward its breaker
+func isServerFailure(err error) bool {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isRetryable reports whether a call that failed with err may succeed when
+// sent again: server failures and rate limiting
+func isRetryable(err error) bool {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return isServerFailure(err)
+}
+
+// sleepContext waits for d, or returns the context's error if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
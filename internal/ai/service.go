@@ -1,6 +1,11 @@
 package ai
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
 
 // Service defines the AI service interface for expense parsing and categorization
 type Service interface {
@@ -13,6 +18,39 @@ type Service interface {
 	SuggestCategory(ctx context.Context, description string, userID string) (*SuggestCategoryResponse, error)
 }
 
+// StreamingService is implemented by providers that can report partial parse results
+// while the model is still generating, so callers can keep users informed on long messages
+type StreamingService interface {
+	Service
+
+	// ParseExpenseStream behaves like ParseExpense but invokes onProgress as expenses are parsed
+	ParseExpenseStream(ctx context.Context, text string, userID string, onProgress domain.ProgressFunc) (*ParseExpenseResponse, error)
+}
+
+// ReceiptService is implemented by multimodal providers that can read receipt photos
+type ReceiptService interface {
+	// ParseReceipt runs OCR and line-item extraction on a receipt image
+	ParseReceipt(ctx context.Context, image []byte, mimeType string, userID string) (*ParseReceiptResponse, error)
+}
+
+// Transcriber converts voice messages to text so they can be parsed like typed messages
+type Transcriber interface {
+	// Transcribe returns the spoken text of an audio clip
+	Transcribe(ctx context.Context, audio []byte, mimeType string) (*TranscriptionResponse, error)
+}
+
+// NewTranscriber creates a speech-to-text service based on the provider type
+func NewTranscriber(provider string, apiKey string, model string) (Transcriber, error) {
+	switch provider {
+	case "gemini":
+		return NewGeminiAI(apiKey, model, nil)
+	case "openai", "whisper":
+		return NewWhisperTranscriber(apiKey, model)
+	default:
+		return nil, fmt.Errorf("unsupported speech provider: %s", provider)
+	}
+}
+
 // Factory creates an AI service based on the provider type
 // Note: costRepo parameter is deprecated and kept only for backward compatibility during migration
 func Factory(provider string, apiKey string, model string, costRepo interface{}) (Service, error) {
@@ -20,8 +58,7 @@ func Factory(provider string, apiKey string, model string, costRepo interface{})
 	case "gemini":
 		return NewGeminiAI(apiKey, model, nil)
 	case "claude":
-		// TODO: Implement Claude AI
-		return nil, nil
+		return NewClaudeAI(apiKey, model)
 	case "openai":
 		// TODO: Implement OpenAI
 		return nil, nil
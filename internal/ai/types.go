@@ -9,18 +9,50 @@ type TokenMetadata struct {
 	TotalTokens  int
 }
 
-// ParseExpenseResponse wraps parsed expenses with token metadata
+// ParseExpenseResponse wraps parsed expenses with token metadata.
+// Provider and Model name what served the call when it differs from the
+// configured provider, e.g. after a failover; they are empty otherwise.
 type ParseExpenseResponse struct {
-	Expenses     []*domain.ParsedExpense
-	Tokens       *TokenMetadata
-	SystemPrompt string
-	RawResponse  string
+	Expenses      []*domain.ParsedExpense
+	Tokens        *TokenMetadata
+	SystemPrompt  string
+	PromptVersion int
+	RawResponse   string
+	Provider      string
+	Model         string
 }
 
-// SuggestCategoryResponse wraps suggested category with token metadata
+// SuggestCategoryResponse wraps suggested category with token metadata.
+// Confidence is 0-1; answers without a score (e.g. plain-text prompts) count as 1.
 type SuggestCategoryResponse struct {
-	Category     string
-	Tokens       *TokenMetadata
-	SystemPrompt string
-	RawResponse  string
+	Category      string
+	Confidence    float64
+	Alternatives  []string // up to 3 other likely categories, most likely first
+	Tokens        *TokenMetadata
+	SystemPrompt  string
+	PromptVersion int
+	RawResponse   string
+	Provider      string // set like ParseExpenseResponse.Provider
+	Model         string
+}
+
+// ParseReceiptResponse wraps the line items read from a receipt photo with token metadata
+type ParseReceiptResponse struct {
+	Merchant      string
+	Expenses      []*domain.ParsedExpense
+	Total         float64
+	Tokens        *TokenMetadata
+	SystemPrompt  string
+	PromptVersion int
+	RawResponse   string
+	Provider      string // set like ParseExpenseResponse.Provider
+	Model         string
+}
+
+// TranscriptionResponse wraps the text transcribed from an audio clip with token metadata
+type TranscriptionResponse struct {
+	Text          string
+	Tokens        *TokenMetadata
+	PromptVersion int
+	RawResponse   string
 }
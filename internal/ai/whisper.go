@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var _ Transcriber = (*WhisperTranscriber)(nil)
+
+const (
+	defaultWhisperModel   = "whisper-1"
+	defaultOpenAIBaseURL  = "https://api.openai.com/v1"
+	whisperRequestTimeout = 60 * time.Second
+)
+
+// WhisperTranscriber implements speech-to-text using the OpenAI audio transcription API
+type WhisperTranscriber struct {
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewWhisperTranscriber creates a new OpenAI Whisper transcriber
+func NewWhisperTranscriber(apiKey string, model string) (*WhisperTranscriber, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("OpenAI API key is required")
+	}
+	if model == "" {
+		model = defaultWhisperModel
+	}
+
+	return &WhisperTranscriber{
+		apiKey:     apiKey,
+		model:      model,
+		baseURL:    defaultOpenAIBaseURL,
+		httpClient: &http.Client{Timeout: whisperRequestTimeout},
+	}, nil
+}
+
+// Transcribe converts a voice message to text
+func (w *WhisperTranscriber) Transcribe(ctx context.Context, audio []byte, mimeType string) (*TranscriptionResponse, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	if err := writer.WriteField("model", w.model); err != nil {
+		return nil, fmt.Errorf("failed to write model field: %w", err)
+	}
+	part, err := writer.CreateFormFile("file", "voice"+audioFileExtension(mimeType))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file field: %w", err)
+	}
+	if _, err := part.Write(audio); err != nil {
+		return nil, fmt.Errorf("failed to write audio: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", w.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+w.apiKey)
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	rawResponse := string(bodyBytes)
+
+	if resp.StatusCode != http.StatusOK {
+		slog.ErrorContext(ctx, "Whisper API returned an error", "status", resp.StatusCode, "response", rawResponse)
+		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, rawResponse)
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	// Whisper bills by audio duration rather than tokens
+	return &TranscriptionResponse{
+		Text:        strings.TrimSpace(result.Text),
+		Tokens:      &TokenMetadata{},
+		RawResponse: rawResponse,
+	}, nil
+}
+
+// audioFileExtension maps an audio MIME type to the file extension Whisper uses to detect the format
+func audioFileExtension(mimeType string) string {
+	switch strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0])) {
+	case "audio/mpeg", "audio/mp3":
+		return ".mp3"
+	case "audio/mp4", "audio/m4a", "audio/x-m4a", "audio/aac":
+		return ".m4a"
+	case "audio/wav", "audio/x-wav":
+		return ".wav"
+	case "audio/webm":
+		return ".webm"
+	default:
+		// Telegram and WhatsApp voice notes are OGG/Opus
+		return ".ogg"
+	}
+}
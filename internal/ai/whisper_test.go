@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWhisperTranscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio/transcriptions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test_key" {
+			t.Errorf("expected bearer token")
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("expected multipart form: %v", err)
+		}
+		if r.FormValue("model") != "whisper-1" {
+			t.Errorf("expected default model, got %q", r.FormValue("model"))
+		}
+		_, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("expected file field: %v", err)
+		}
+		if header.Filename != "voice.ogg" {
+			t.Errorf("expected voice.ogg, got %q", header.Filename)
+		}
+		fmt.Fprint(w, `{"text":" 我剛花了兩百塊買咖啡 "}`)
+	}))
+	defer server.Close()
+
+	w, err := NewWhisperTranscriber("test_key", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.baseURL = server.URL
+
+	resp, err := w.Transcribe(context.Background(), []byte("OggS"), "audio/ogg")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Text != "我剛花了兩百塊買咖啡" {
+		t.Errorf("unexpected transcript %q", resp.Text)
+	}
+}
+
+func TestNewTranscriber(t *testing.T) {
+	if _, err := NewTranscriber("openai", "", ""); err == nil {
+		t.Error("expected error for missing OpenAI key")
+	}
+	if _, err := NewTranscriber("unknown", "key", ""); err == nil {
+		t.Error("expected error for unsupported provider")
+	}
+	if _, err := NewTranscriber("gemini", "key", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestAudioFileExtension(t *testing.T) {
+	tests := map[string]string{
+		"audio/ogg; codecs=opus": ".ogg",
+		"audio/mpeg":             ".mp3",
+		"audio/mp4":              ".m4a",
+		"":                       ".ogg",
+	}
+	for mimeType, want := range tests {
+		if got := audioFileExtension(mimeType); got != want {
+			t.Errorf("audioFileExtension(%q) = %q, want %q", mimeType, got, want)
+		}
+	}
+}
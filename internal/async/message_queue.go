@@ -0,0 +1,221 @@
+package async
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/logging"
+)
+
+var _ domain.MessageQueue = (*MessageQueue)(nil)
+
+// Defaults of the webhook message queue
+const (
+	DefaultMessageWorkers   = 4
+	DefaultMessageQueueSize = 1000
+	DefaultMessageQueueKey  = "aiexpense:message_queue"
+)
+
+// ErrQueueFull is returned by Enqueue when the queue has no room for another message
+var ErrQueueFull = errors.New("message queue is full")
+
+// MessageProcessor processes a message, as the webhook handlers would inline
+type MessageProcessor interface {
+	Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error)
+}
+
+// ListStore is a list shared by every server instance, such as kvcache.RedisCache
+type ListStore interface {
+	Push(ctx context.Context, key string, value []byte) error
+	Pop(ctx context.Context, key string) ([]byte, bool, error)
+}
+
+// queuedMessage is a message waiting for a worker. The attachments travel
+// next to the message because UserMessage doesn't serialize them.
+type queuedMessage struct {
+	Message     *domain.UserMessage  `json:"message"`
+	Attachments []*domain.Attachment `json:"attachments,omitempty"`
+	RequestID   string               `json:"request_id,omitempty"`
+	EnqueuedAt  time.Time            `json:"enqueued_at"`
+}
+
+// MessageQueue processes webhook messages on a pool of workers, so the
+// webhook can be acknowledged before the AI has parsed the message. The reply
+// is delivered afterwards by the replier registered for the message's source.
+//
+// Messages wait in memory, or in a shared Redis list so that any server
+// instance may process them and they survive a restart.
+type MessageQueue struct {
+	processor MessageProcessor
+	workers   int
+
+	pending chan *queuedMessage // in-process queue, nil when store is set
+
+	store        ListStore
+	key          string
+	pollInterval time.Duration
+
+	mu       sync.RWMutex
+	repliers map[string]domain.MessageReplier
+}
+
+// NewMessageQueue creates a queue that keeps up to size messages in memory.
+// workers and size <= 0 use the defaults.
+func NewMessageQueue(processor MessageProcessor, workers, size int) *MessageQueue {
+	if size <= 0 {
+		size = DefaultMessageQueueSize
+	}
+	q := newMessageQueue(processor, workers)
+	q.pending = make(chan *queuedMessage, size)
+	return q
+}
+
+// NewStoreMessageQueue creates a queue that keeps messages in the list stored
+// under key; workers poll it when it is empty
+func NewStoreMessageQueue(processor MessageProcessor, workers int, store ListStore, key string) *MessageQueue {
+	if key == "" {
+		key = DefaultMessageQueueKey
+	}
+	q := newMessageQueue(processor, workers)
+	q.store = store
+	q.key = key
+	q.pollInterval = 500 * time.Millisecond
+	return q
+}
+
+func newMessageQueue(processor MessageProcessor, workers int) *MessageQueue {
+	if workers <= 0 {
+		workers = DefaultMessageWorkers
+	}
+	return &MessageQueue{
+		processor: processor,
+		workers:   workers,
+		repliers:  make(map[string]domain.MessageReplier),
+	}
+}
+
+// RegisterReplier delivers the responses to messages from the given source
+func (q *MessageQueue) RegisterReplier(source string, replier domain.MessageReplier) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.repliers[source] = replier
+}
+
+// Enqueue hands the message to a worker without waiting for it to be processed
+func (q *MessageQueue) Enqueue(ctx context.Context, msg *domain.UserMessage) error {
+	queued := &queuedMessage{
+		Message:     msg,
+		Attachments: msg.Attachments,
+		RequestID:   logging.RequestID(ctx),
+		EnqueuedAt:  time.Now(),
+	}
+
+	if q.store == nil {
+		select {
+		case q.pending <- queued:
+			return nil
+		default:
+			return ErrQueueFull
+		}
+	}
+
+	data, err := json.Marshal(queued)
+	if err != nil {
+		return fmt.Errorf("failed to encode queued message: %w", err)
+	}
+	if err := q.store.Push(ctx, q.key, data); err != nil {
+		return fmt.Errorf("failed to enqueue message: %w", err)
+	}
+	return nil
+}
+
+// Run processes queued messages on the workers until ctx is done
+func (q *MessageQueue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				queued, err := q.next(ctx)
+				if err != nil {
+					return
+				}
+				q.process(ctx, queued)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// next waits for the next queued message, returning an error once ctx is done
+func (q *MessageQueue) next(ctx context.Context) (*queuedMessage, error) {
+	if q.store == nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case queued := <-q.pending:
+			return queued, nil
+		}
+	}
+
+	for {
+		data, ok, err := q.store.Pop(ctx, q.key)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to read message queue", "error", err)
+		}
+		if ok {
+			var queued queuedMessage
+			if err := json.Unmarshal(data, &queued); err != nil || queued.Message == nil {
+				slog.ErrorContext(ctx, "Dropping malformed queued message", "error", err)
+				continue
+			}
+			return &queued, nil
+		}
+
+		timer := time.NewTimer(q.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// process runs the message through the processor and delivers the reply
+func (q *MessageQueue) process(ctx context.Context, queued *queuedMessage) {
+	msg := queued.Message
+	msg.Attachments = queued.Attachments
+	if queued.RequestID != "" {
+		ctx = logging.WithRequestID(ctx, queued.RequestID)
+	}
+	ctx = logging.WithUser(ctx, msg.UserID, msg.Source)
+
+	slog.DebugContext(ctx, "Processing queued message", "waited", time.Since(queued.EnqueuedAt))
+	resp, err := q.processor.Execute(ctx, msg)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to handle queued message", "error", err)
+		return
+	}
+	if resp == nil || resp.Text == "" {
+		return
+	}
+
+	q.mu.RLock()
+	replier := q.repliers[msg.Source]
+	q.mu.RUnlock()
+	if replier == nil {
+		slog.WarnContext(ctx, "No replier registered for queued message")
+		return
+	}
+	if err := replier.Reply(ctx, msg, resp); err != nil {
+		slog.WarnContext(ctx, "Failed to deliver reply to queued message", "error", err)
+	}
+}
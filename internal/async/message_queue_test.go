@@ -0,0 +1,141 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// echoProcessor answers every message with its content
+type echoProcessor struct{}
+
+func (echoProcessor) Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error) {
+	if msg.Content == "fail" {
+		return nil, errors.New("parse failed")
+	}
+	return &domain.MessageResponse{Text: "saved " + msg.Content}, nil
+}
+
+// replyRecorder delivers replies to a channel
+type replyRecorder struct {
+	replies chan string
+	msgs    chan *domain.UserMessage
+}
+
+func newReplyRecorder() *replyRecorder {
+	return &replyRecorder{replies: make(chan string, 10), msgs: make(chan *domain.UserMessage, 10)}
+}
+
+func (r *replyRecorder) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	r.msgs <- msg
+	r.replies <- resp.Text
+	return nil
+}
+
+func (r *replyRecorder) next(t *testing.T) (*domain.UserMessage, string) {
+	t.Helper()
+	select {
+	case msg := <-r.msgs:
+		return msg, <-r.replies
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a reply")
+		return nil, ""
+	}
+}
+
+// memoryList is an in-process ListStore
+type memoryList struct {
+	mu     sync.Mutex
+	values map[string][][]byte
+}
+
+func (l *memoryList) Push(ctx context.Context, key string, value []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.values[key] = append(l.values[key], value)
+	return nil
+}
+
+func (l *memoryList) Pop(ctx context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.values[key]) == 0 {
+		return nil, false, nil
+	}
+	value := l.values[key][0]
+	l.values[key] = l.values[key][1:]
+	return value, true, nil
+}
+
+func TestMessageQueue(t *testing.T) {
+	t.Run("Memory queue replies through the source's replier and rejects messages when full", func(t *testing.T) {
+		q := NewMessageQueue(echoProcessor{}, 2, 1)
+		replier := newReplyRecorder()
+		q.RegisterReplier("line", replier)
+
+		ctx := context.Background()
+		if err := q.Enqueue(ctx, &domain.UserMessage{UserID: "U1", Content: "lunch 100", Source: "line"}); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+		if err := q.Enqueue(ctx, &domain.UserMessage{UserID: "U1", Content: "coffee 50", Source: "line"}); !errors.Is(err, ErrQueueFull) {
+			t.Errorf("expected ErrQueueFull, got %v", err)
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			q.Run(runCtx)
+			close(done)
+		}()
+		if msg, reply := replier.next(t); msg.UserID != "U1" || reply != "saved lunch 100" {
+			t.Errorf("unexpected reply %q to %+v", reply, msg)
+		}
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Run did not return after its context was cancelled")
+		}
+	})
+
+	t.Run("Store queue carries attachments and metadata through the list", func(t *testing.T) {
+		store := &memoryList{values: make(map[string][][]byte)}
+		q := NewStoreMessageQueue(echoProcessor{}, 1, store, "")
+		q.pollInterval = 10 * time.Millisecond
+		replier := newReplyRecorder()
+		q.RegisterReplier("telegram", replier)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		for _, content := range []string{"fail", "taxi 300"} {
+			err := q.Enqueue(ctx, &domain.UserMessage{
+				UserID:      "telegram_42",
+				Content:     content,
+				Source:      "telegram",
+				Attachments: []*domain.Attachment{{Type: domain.AttachmentTypeImage, MimeType: "image/jpeg", Data: []byte{0xff, 0xd8}}},
+				Metadata:    map[string]interface{}{"chat_id": int64(42)},
+			})
+			if err != nil {
+				t.Fatalf("Enqueue failed: %v", err)
+			}
+		}
+		if len(store.values[DefaultMessageQueueKey]) != 2 {
+			t.Fatalf("expected the messages in the %s list, got %v", DefaultMessageQueueKey, store.values)
+		}
+		go q.Run(ctx)
+
+		// The failed message gets no reply
+		msg, reply := replier.next(t)
+		if reply != "saved taxi 300" || msg.Metadata["chat_id"] != float64(42) {
+			t.Errorf("unexpected reply %q to %+v", reply, msg)
+		}
+		if image := msg.FirstAttachment(domain.AttachmentTypeImage); image == nil || image.MimeType != "image/jpeg" || len(image.Data) != 2 {
+			t.Errorf("expected the image attachment restored, got %+v", msg.Attachments)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// awsSecretsStore reads a secret from AWS Secrets Manager, whose SecretString
+// is a JSON object of settings. Requests are signed with AWS Signature
+// Version 4 using static credentials.
+type awsSecretsStore struct {
+	endpoint        string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	secretID        string
+	httpClient      *http.Client
+	now             func() time.Time
+}
+
+// newAWSSecretsStore creates a store for secretID, a name or ARN. An empty
+// endpoint uses AWS Secrets Manager in region.
+func newAWSSecretsStore(endpoint, region, accessKeyID, secretAccessKey, sessionToken, secretID string) (*awsSecretsStore, error) {
+	if region == "" {
+		return nil, fmt.Errorf("AWS_REGION is required when using aws secrets backend")
+	}
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required when using aws secrets backend")
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+	return &awsSecretsStore{
+		endpoint:        strings.TrimSuffix(endpoint, "/"),
+		region:          region,
+		accessKeyID:     accessKeyID,
+		secretAccessKey: secretAccessKey,
+		sessionToken:    sessionToken,
+		secretID:        secretID,
+		httpClient:      &http.Client{Timeout: secretsTimeout},
+		now:             time.Now,
+	}, nil
+}
+
+func (a *awsSecretsStore) fetch(ctx context.Context) (map[string]string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": a.secretID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	a.sign(req, body)
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Secrets Manager: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		SecretString string `json:"SecretString"`
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to decode Secrets Manager response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		errorType := result.Type[strings.LastIndex(result.Type, "#")+1:]
+		return nil, fmt.Errorf("Secrets Manager returned status %d: %s %s", resp.StatusCode, errorType, result.Message)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(result.SecretString))
+	decoder.UseNumber()
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("the secret must be a JSON object of settings, such as {\"GEMINI_API_KEY\": \"...\"}")
+	}
+	return secretStrings(values)
+}
+
+// sign adds AWS Signature Version 4 headers to req
+func (a *awsSecretsStore) sign(req *http.Request, body []byte) {
+	now := a.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	headers := []string{
+		"content-type:" + req.Header.Get("Content-Type"),
+		"host:" + req.URL.Host,
+		"x-amz-date:" + amzDate,
+	}
+	signedHeaders := "content-type;host;x-amz-date"
+	if a.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.sessionToken)
+		headers = append(headers, "x-amz-security-token:"+a.sessionToken)
+		signedHeaders += ";x-amz-security-token"
+	}
+	headers = append(headers, "x-amz-target:"+req.Header.Get("X-Amz-Target"))
+	signedHeaders += ";x-amz-target"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		strings.Join(headers, "\n"),
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/secretsmanager/aws4_request", date, a.region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+a.secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, a.region)
+	signingKey = hmacSHA256(signingKey, "secretsmanager")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.accessKeyID, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+)
+
+// Capability is a feature of the server and how the configuration sets it up
+type Capability struct {
+	Name    string
+	Enabled bool
+	Detail  string // What it uses when enabled, or the setting that enables it
+}
+
+// Capabilities lists what the configuration turns on and off. Details never
+// include secrets.
+func (c *Config) Capabilities() []Capability {
+	caps := []Capability{{Name: "database", Enabled: true, Detail: c.databaseDetail()}}
+
+	for _, name := range Messengers {
+		capability := Capability{Name: "messenger " + name, Enabled: c.IsMessengerEnabled(name)}
+		if !capability.Enabled {
+			capability.Detail = "add to ENABLED_MESSENGERS"
+		}
+		caps = append(caps, capability)
+	}
+
+	ai := Capability{Name: "AI parsing", Enabled: true, Detail: c.AIProvider + " " + c.AIModel}
+	if len(c.AIProviders) > 0 {
+		ai.Detail = "failover " + strings.Join(c.AIProviders, " > ")
+	}
+	caps = append(caps, ai)
+	caps = append(caps, optional("voice messages", c.SpeechProvider != "" && c.SpeechAPIKey() != "", c.SpeechProvider+" "+c.SpeechModel, "set SPEECH_PROVIDER and its API key"))
+	caps = append(caps, optional("receipt attachments", c.AttachmentStorage != "", storageDetail(c.AttachmentStorage, c.AttachmentDir, c.S3Bucket), "set ATTACHMENT_STORAGE"))
+	caps = append(caps, optional("expense archives", c.ArchiveStorage != "", storageDetail(c.ArchiveStorage, c.ArchiveDir, c.ArchiveS3Bucket), "set ARCHIVE_STORAGE"))
+	caps = append(caps, optional("summary cache", c.SummaryCache != "", fmt.Sprintf("%s, %s TTL", c.SummaryCache, c.SummaryCacheTTL), "set SUMMARY_CACHE"))
+	caps = append(caps, optional("webhook queue", c.WebhookQueue != "", fmt.Sprintf("%s, %d workers", c.WebhookQueue, c.WebhookWorkers), "set WEBHOOK_QUEUE"))
+	caps = append(caps, optional("rate limit", c.RateLimitPerMinute > 0, fmt.Sprintf("%d/min, burst %d", c.RateLimitPerMinute, c.RateLimitBurst), "set RATE_LIMIT_PER_MINUTE"))
+	caps = append(caps, optional("email reports", c.SMTPHost != "", fmt.Sprintf("%s:%d", c.SMTPHost, c.SMTPPort), "set SMTP_HOST"))
+	caps = append(caps, optional("inbound email", c.MailgunSigningKey != "" && c.SMTPHost != "", c.InboundEmailAddress, "set MAILGUN_SIGNING_KEY and SMTP_HOST"))
+	caps = append(caps, optional("gRPC", c.GRPCPort != "", "port "+c.GRPCPort, "set GRPC_PORT"))
+	caps = append(caps, optional("required sign-in", c.AuthRequired, "API requests need a token", "requests act for the user_id they name (development only)"))
+	caps = append(caps, optional("secrets manager", c.SecretsBackend != "", c.SecretsBackend+" "+c.SecretsPath, "set SECRETS_BACKEND"))
+	caps = append(caps, optional("encryption at rest", c.EncryptionKeys != "", fmt.Sprintf("%d keys", len(strings.Split(c.EncryptionKeys, ","))), "set ENCRYPTION_KEYS"))
+	return caps
+}
+
+// WriteCapabilities prints the capabilities as a table
+func (c *Config) WriteCapabilities(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CAPABILITY\tSTATUS\tDETAIL")
+	for _, capability := range c.Capabilities() {
+		status := "off"
+		if capability.Enabled {
+			status = "on"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", capability.Name, status, capability.Detail)
+	}
+	tw.Flush()
+}
+
+// optional is a capability with a detail for each of its states
+func optional(name string, enabled bool, detail, enableWith string) Capability {
+	if !enabled {
+		detail = enableWith
+	}
+	return Capability{Name: name, Enabled: enabled, Detail: detail}
+}
+
+// databaseDetail names the database, without the password of its URL
+func (c *Config) databaseDetail() string {
+	if c.DatabaseURL == "" {
+		return "sqlite3 " + c.DatabasePath
+	}
+	u, err := url.Parse(c.DatabaseURL)
+	if err != nil || u.Host == "" {
+		return c.DatabaseDriver()
+	}
+	return c.DatabaseDriver() + " " + u.Host + u.Path
+}
+
+// storageDetail names where a storage provider keeps its files
+func storageDetail(provider, dir, bucket string) string {
+	if provider == "s3" {
+		return "s3 bucket " + bucket
+	}
+	return provider + " " + dir
+}
@@ -3,19 +3,38 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
 	// Database
 	DatabasePath string
 	DatabaseURL  string
+	// AutoMigrate applies pending migrations on startup; when off, startup
+	// fails until "server migrate up" has brought the schema up to date
+	AutoMigrate bool
+	// PostgreSQL connection pool; DBMaxOpenConns <= 0 means unlimited and a
+	// zero lifetime or idle time keeps connections open indefinitely
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
 
 	// LINE Bot
 	LineChannelToken  string
 	LineChannelID     string
 	LineChannelSecret string
 
+	// LineRichMenuImage is the image of the quick action rich menu (2500x843
+	// PNG or JPEG); the menu is provisioned at startup only when it is set
+	LineRichMenuImage string
+
+	// LINE Login channel for dashboard sign-in, under the same provider as the bot
+	LineLoginChannelID     string
+	LineLoginChannelSecret string
+
 	// Telegram Bot
 	TelegramBotToken string
 
@@ -25,6 +44,15 @@ type Config struct {
 	// WhatsApp Business API
 	WhatsAppPhoneNumberID string
 	WhatsAppAccessToken   string
+	WhatsAppAppSecret     string // verifies webhook signatures
+	WhatsAppVerifyToken   string // answers the webhook subscription handshake
+
+	// WhatsAppAlertTemplate is the approved template pushed notifications, such
+	// as budget alerts, fall back to for users who haven't written in 24 hours,
+	// in WhatsAppAlertLanguage; its body is a single {{1}}. Without one, such
+	// notifications are not delivered.
+	WhatsAppAlertTemplate string
+	WhatsAppAlertLanguage string
 
 	// Slack Bot
 	SlackBotToken      string
@@ -34,56 +62,357 @@ type Config struct {
 	TeamsAppID       string
 	TeamsAppPassword string
 
+	// Matrix bot, an application service registered with the homeserver;
+	// the tokens are the as_token and hs_token of its registration file
+	MatrixHomeserverURL string
+	MatrixASToken       string
+	MatrixHSToken       string
+	MatrixBotUserID     string // e.g. "@expense:example.org"
+
 	// AI Service
-	GeminiAPIKey string
-	AIProvider   string // "gemini", "claude", "openai"
-	AIModel      string // e.g., "gemini-2.5-flash-lite"
+	GeminiAPIKey    string
+	AnthropicAPIKey string
+	AIProvider      string // "gemini", "claude", "openai"
+	AIModel         string // e.g., "gemini-2.5-flash-lite"
+
+	// AI provider failover chain, e.g. "gemini", "claude:claude-3-5-haiku-latest", "regex";
+	// empty uses AIProvider alone
+	AIProviders         []string
+	AIFailoverThreshold int           // consecutive failures that open a provider's circuit
+	AIFailoverCooldown  time.Duration // how long an open circuit skips the provider
+
+	// Gemini HTTP client: per-attempt timeout, retries with jittered backoff and
+	// a circuit breaker on consecutive 5xx responses or timeouts
+	GeminiTimeout          time.Duration
+	GeminiMaxRetries       int
+	GeminiRetryBaseDelay   time.Duration
+	GeminiBreakerThreshold int
+	GeminiBreakerCooldown  time.Duration
+
+	// Speech-to-text for voice messages
+	OpenAIAPIKey   string
+	SpeechProvider string // "gemini", "openai"; empty disables voice messages
+	SpeechModel    string // e.g., "whisper-1"
+
+	// Attachment storage for receipt photos
+	AttachmentStorage string // "local", "s3"; empty disables attachments
+	AttachmentDir     string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+
+	// Storage for expense archive bundles, sharing the S3 endpoint and
+	// credentials above; ArchiveS3Bucket defaults to S3Bucket
+	ArchiveStorage  string // "local", "s3"; empty disables archives
+	ArchiveDir      string
+	ArchiveS3Bucket string
+
+	// Global archive policy for users without their own: expenses older than
+	// ArchiveAfterMonths are archived and archives older than
+	// ArchivePurgeAfterDays purged (0 turns either off). ArchivePolicyDryRun
+	// only logs what the daily run would do.
+	ArchiveAfterMonths    int
+	ArchivePurgeAfterDays int
+	ArchivePolicyDryRun   bool
+
+	// Cache for report and budget status summaries: "memory" or "redis";
+	// empty disables it. SummaryCacheTTL bounds how long a summary is kept.
+	SummaryCache    string
+	SummaryCacheTTL time.Duration
+	RedisURL        string
+
+	// Queue that lets messenger webhooks answer before their messages are
+	// parsed: "memory" or "redis"; empty processes messages inline
+	WebhookQueue     string
+	WebhookWorkers   int
+	WebhookQueueSize int // messages the memory queue holds before webhooks fall back to inline processing
+
+	// Per-user message rate limit; RateLimitPerMinute <= 0 disables it
+	RateLimitPerMinute int
+	RateLimitBurst     int
+
+	// AI spending caps in USD; 0 means unlimited. Admins can override them at runtime.
+	AIDailyCapUSD       float64
+	AIMonthlyCapUSD     float64
+	AIUserDailyCapUSD   float64
+	AIUserMonthlyCapUSD float64
+
+	// Days whose AI spend reaches AICostAnomalyFactor times the previous
+	// week's daily average, and at least AICostAnomalyMinUSD, are flagged for
+	// the admins; a factor of 0 disables detection
+	AICostAnomalyFactor float64
+	AICostAnomalyMinUSD float64
+
+	// AI category confidence (0-1) below which the user is asked to confirm; 0 never asks
+	CategoryConfirmThreshold float64
+
+	// JSON file of column mapping profiles for bank statement imports; optional
+	ImportProfilesPath string
+
+	// SMTP server for scheduled email reports; email is disabled when SMTPHost is empty
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+
+	// Inbound email: receipts forwarded to InboundEmailAddress are posted by a
+	// Mailgun route; the webhook is disabled when MailgunSigningKey is empty
+	MailgunSigningKey   string
+	InboundEmailAddress string
 
 	// Server
 	ServerPort string
 
+	// Environment is SERVER_ENV: "production", or "development" to allow the
+	// insecure conveniences meant for local runs, such as AUTH_REQUIRED=false
+	Environment string
+
+	// GRPCPort serves the gRPC expense service for internal services; empty disables it
+	GRPCPort string
+
+	// Log output: LogFormat is "text" or "json"; LogLevel is debug, info, warn or error
+	LogFormat string
+	LogLevel  string
+
 	// Dashboard URL for report links
 	DashboardURL string
 
 	// API Public URL for short links
 	APIPublicURL string
 
-	// Admin API Key for metrics
+	// Bootstrap admin API key, holding every scope; further keys are managed
+	// through /api/admin/api-keys
 	AdminAPIKey string
 
+	// Browser origins allowed to call the API ("*" for any), whether they may
+	// send credentials, and path prefixes open to any origin regardless
+	CORSAllowedOrigins   []string
+	CORSAllowCredentials bool
+	CORSPublicPaths      []string
+
+	// JWTSecret signs API access tokens and report links; AuthRequired rejects
+	// user requests made without a token. Turning it off, so requests act for
+	// the user_id they claim, is only allowed in development.
+	JWTSecret    string
+	AuthRequired bool
+
+	// Days a user can still cancel DELETE /api/users/me before their data is purged
+	UserDeletionGraceDays int
+
+	// EncryptionKeys is a comma-separated "id:base64 key" list for encrypting
+	// sensitive columns at rest; the first key encrypts, the rest only decrypt
+	EncryptionKeys string
+
 	// Enabled Messengers
 	EnabledMessengers []string
+
+	// ReloadFile holds KEY=VALUE lines of reloadable settings, read over the
+	// environment on SIGHUP and every ReloadInterval (0 reads only on SIGHUP)
+	ReloadFile     string
+	ReloadInterval time.Duration
+
+	// SecretsBackend is the secret manager ("vault" or "aws") whose secret at
+	// SecretsPath fills in the secrets the environment leaves unset
+	SecretsBackend string
+	SecretsPath    string
 }
 
 func Load() (*Config, error) {
+	// Secrets can come from files and a secret manager instead of the environment
+	secrets, err := loadSecretSource()
+	if err != nil {
+		return nil, err
+	}
+
 	// Get database configuration (prefer DATABASE_URL if set)
-	databaseURL := getEnv("DATABASE_URL", "")
+	databaseURL, err := secrets.get("DATABASE_URL", "")
+	if err != nil {
+		return nil, err
+	}
 	databasePath := ""
 	if databaseURL == "" {
 		databasePath = getEnv("DATABASE_PATH", "./aiexpense.db")
 	}
 
+	aiProvider := getEnv("AI_PROVIDER", "gemini")
+	speechProvider := getEnv("SPEECH_PROVIDER", "gemini")
+
 	cfg := &Config{
 		DatabasePath:          databasePath,
 		DatabaseURL:           databaseURL,
-		LineChannelToken:      getEnv("LINE_CHANNEL_TOKEN", ""),
 		LineChannelID:         getEnv("LINE_CHANNEL_ID", ""),
-		LineChannelSecret:     getEnv("LINE_CHANNEL_SECRET", ""),
-		TelegramBotToken:      getEnv("TELEGRAM_BOT_TOKEN", ""),
-		DiscordBotToken:       getEnv("DISCORD_BOT_TOKEN", ""),
+		LineLoginChannelID:    getEnv("LINE_LOGIN_CHANNEL_ID", ""),
+		LineRichMenuImage:     getEnv("LINE_RICH_MENU_IMAGE", ""),
 		WhatsAppPhoneNumberID: getEnv("WHATSAPP_PHONE_NUMBER_ID", ""),
-		WhatsAppAccessToken:   getEnv("WHATSAPP_ACCESS_TOKEN", ""),
-		SlackBotToken:         getEnv("SLACK_BOT_TOKEN", ""),
-		SlackSigningSecret:    getEnv("SLACK_SIGNING_SECRET", ""),
+		WhatsAppAlertTemplate: getEnv("WHATSAPP_ALERT_TEMPLATE", ""),
+		WhatsAppAlertLanguage: getEnv("WHATSAPP_ALERT_LANGUAGE", "en"),
 		TeamsAppID:            getEnv("TEAMS_APP_ID", ""),
-		TeamsAppPassword:      getEnv("TEAMS_APP_PASSWORD", ""),
-		GeminiAPIKey:          getEnv("GEMINI_API_KEY", ""),
-		AIProvider:            getEnv("AI_PROVIDER", "gemini"),
-		AIModel:               getEnv("AI_MODEL", "gemini-2.5-flash-lite"),
+		MatrixHomeserverURL:   getEnv("MATRIX_HOMESERVER_URL", ""),
+		MatrixBotUserID:       getEnv("MATRIX_BOT_USER_ID", ""),
+		AIProvider:            aiProvider,
+		SpeechProvider:        speechProvider,
+		SpeechModel:           getEnv("SPEECH_MODEL", defaultSpeechModel(speechProvider)),
+		AttachmentStorage:     getEnv("ATTACHMENT_STORAGE", "local"),
+		AttachmentDir:         getEnv("ATTACHMENT_DIR", "./attachments"),
+		S3Endpoint:            getEnv("S3_ENDPOINT", ""),
+		S3Region:              getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:              getEnv("S3_BUCKET", ""),
+		ArchiveStorage:        getEnv("ARCHIVE_STORAGE", "local"),
+		ArchiveDir:            getEnv("ARCHIVE_DIR", "./archives"),
+		ArchiveS3Bucket:       getEnv("ARCHIVE_S3_BUCKET", getEnv("S3_BUCKET", "")),
+		SummaryCache:          getEnv("SUMMARY_CACHE", "memory"),
+		WebhookQueue:          getEnv("WEBHOOK_QUEUE", ""),
 		ServerPort:            getEnv("SERVER_PORT", "8080"),
+		Environment:           getEnv("SERVER_ENV", "production"),
+		GRPCPort:              getEnv("GRPC_PORT", ""),
+		LogFormat:             getEnv("LOG_FORMAT", "text"),
 		DashboardURL:          getEnv("DASHBOARD_URL", "http://localhost:3000"),
 		APIPublicURL:          getEnv("API_PUBLIC_URL", "http://localhost:8080"),
-		AdminAPIKey:           getEnv("ADMIN_API_KEY", ""),
+		ImportProfilesPath:    getEnv("IMPORT_PROFILES_PATH", ""),
+		SMTPHost:              getEnv("SMTP_HOST", ""),
+		SMTPUsername:          getEnv("SMTP_USERNAME", ""),
+		SMTPFrom:              getEnv("SMTP_FROM", ""),
+		InboundEmailAddress:   getEnv("INBOUND_EMAIL_ADDRESS", ""),
+		ReloadFile:            getEnv("CONFIG_RELOAD_FILE", ""),
+		SecretsBackend:        getEnv("SECRETS_BACKEND", ""),
+		SecretsPath:           getEnv("SECRETS_PATH", ""),
+	}
+
+	// Parse secrets
+	for _, secret := range []struct {
+		key string
+		dst *string
+	}{
+		{"LINE_CHANNEL_TOKEN", &cfg.LineChannelToken},
+		{"LINE_CHANNEL_SECRET", &cfg.LineChannelSecret},
+		{"LINE_LOGIN_CHANNEL_SECRET", &cfg.LineLoginChannelSecret},
+		{"TELEGRAM_BOT_TOKEN", &cfg.TelegramBotToken},
+		{"DISCORD_BOT_TOKEN", &cfg.DiscordBotToken},
+		{"WHATSAPP_ACCESS_TOKEN", &cfg.WhatsAppAccessToken},
+		{"WHATSAPP_APP_SECRET", &cfg.WhatsAppAppSecret},
+		{"WHATSAPP_VERIFY_TOKEN", &cfg.WhatsAppVerifyToken},
+		{"SLACK_BOT_TOKEN", &cfg.SlackBotToken},
+		{"SLACK_SIGNING_SECRET", &cfg.SlackSigningSecret},
+		{"TEAMS_APP_PASSWORD", &cfg.TeamsAppPassword},
+		{"MATRIX_AS_TOKEN", &cfg.MatrixASToken},
+		{"MATRIX_HS_TOKEN", &cfg.MatrixHSToken},
+		{"GEMINI_API_KEY", &cfg.GeminiAPIKey},
+		{"ANTHROPIC_API_KEY", &cfg.AnthropicAPIKey},
+		{"OPENAI_API_KEY", &cfg.OpenAIAPIKey},
+		{"S3_ACCESS_KEY_ID", &cfg.S3AccessKeyID},
+		{"S3_SECRET_ACCESS_KEY", &cfg.S3SecretAccessKey},
+		{"REDIS_URL", &cfg.RedisURL},
+		{"SMTP_PASSWORD", &cfg.SMTPPassword},
+		{"MAILGUN_SIGNING_KEY", &cfg.MailgunSigningKey},
+		{"ADMIN_API_KEY", &cfg.AdminAPIKey},
+		{"ENCRYPTION_KEYS", &cfg.EncryptionKeys},
+	} {
+		if *secret.dst, err = secrets.get(secret.key, ""); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.JWTSecret, err = secrets.get("JWT_SECRET", defaultJWTSecret); err != nil {
+		return nil, err
+	}
+
+	// Parse the settings a reload can change
+	if err := cfg.loadReloadable(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if cfg.SMTPPort, err = getEnvInt("SMTP_PORT", 587); err != nil {
+		return nil, err
+	}
+	if cfg.SummaryCacheTTL, err = getEnvDuration("SUMMARY_CACHE_TTL", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookWorkers, err = getEnvInt("WEBHOOK_WORKERS", 4); err != nil {
+		return nil, err
+	}
+	if cfg.WebhookQueueSize, err = getEnvInt("WEBHOOK_QUEUE_SIZE", 1000); err != nil {
+		return nil, err
+	}
+
+	if cfg.AICostAnomalyFactor, err = getEnvFloat("AI_COST_ANOMALY_FACTOR", 3); err != nil {
+		return nil, err
+	}
+	if cfg.AICostAnomalyMinUSD, err = getEnvFloat("AI_COST_ANOMALY_MIN_USD", 1); err != nil {
+		return nil, err
+	}
+
+	if cfg.CategoryConfirmThreshold, err = getEnvFloat("CATEGORY_CONFIRM_THRESHOLD", 0.6); err != nil {
+		return nil, err
+	}
+
+	// Parse the AI provider failover chain
+	cfg.AIProviders = getEnvList("AI_PROVIDERS", nil)
+	if cfg.AIFailoverThreshold, err = getEnvInt("AI_FAILOVER_THRESHOLD", 3); err != nil {
+		return nil, err
+	}
+	if cfg.AIFailoverCooldown, err = getEnvDuration("AI_FAILOVER_COOLDOWN", time.Minute); err != nil {
+		return nil, err
+	}
+
+	// Parse the Gemini HTTP client policy
+	if cfg.GeminiTimeout, err = getEnvDuration("GEMINI_TIMEOUT", 10*time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.GeminiMaxRetries, err = getEnvInt("GEMINI_MAX_RETRIES", 2); err != nil {
+		return nil, err
+	}
+	if cfg.GeminiRetryBaseDelay, err = getEnvDuration("GEMINI_RETRY_BASE_DELAY", 250*time.Millisecond); err != nil {
+		return nil, err
+	}
+	if cfg.GeminiBreakerThreshold, err = getEnvInt("GEMINI_BREAKER_THRESHOLD", 5); err != nil {
+		return nil, err
+	}
+	if cfg.GeminiBreakerCooldown, err = getEnvDuration("GEMINI_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return nil, err
+	}
+
+	// Parse CORS settings
+	cfg.CORSAllowedOrigins = getEnvList("CORS_ALLOWED_ORIGINS", []string{"*"})
+	cfg.CORSPublicPaths = getEnvList("CORS_PUBLIC_PATHS", nil)
+	if cfg.CORSAllowCredentials, err = getEnvBool("CORS_ALLOW_CREDENTIALS", false); err != nil {
+		return nil, err
+	}
+	if cfg.AuthRequired, err = getEnvBool("AUTH_REQUIRED", true); err != nil {
+		return nil, err
+	}
+	if cfg.UserDeletionGraceDays, err = getEnvInt("USER_DELETION_GRACE_DAYS", 30); err != nil {
+		return nil, err
+	}
+	if cfg.AutoMigrate, err = getEnvBool("AUTO_MIGRATE", true); err != nil {
+		return nil, err
+	}
+	if cfg.ArchiveAfterMonths, err = getEnvInt("ARCHIVE_AFTER_MONTHS", 24); err != nil {
+		return nil, err
+	}
+	if cfg.ArchivePurgeAfterDays, err = getEnvInt("ARCHIVE_PURGE_AFTER_DAYS", 365*7); err != nil {
+		return nil, err
+	}
+	if cfg.ArchivePolicyDryRun, err = getEnvBool("ARCHIVE_POLICY_DRY_RUN", true); err != nil {
+		return nil, err
+	}
+
+	// Parse database pool settings
+	if cfg.DBMaxOpenConns, err = getEnvInt("DB_MAX_OPEN_CONNS", 25); err != nil {
+		return nil, err
+	}
+	if cfg.DBMaxIdleConns, err = getEnvInt("DB_MAX_IDLE_CONNS", 5); err != nil {
+		return nil, err
+	}
+	if cfg.DBConnMaxLifetime, err = getEnvDuration("DB_CONN_MAX_LIFETIME", 5*time.Minute); err != nil {
+		return nil, err
+	}
+	if cfg.DBConnMaxIdleTime, err = getEnvDuration("DB_CONN_MAX_IDLE_TIME", 0); err != nil {
+		return nil, err
+	}
+	if cfg.ReloadInterval, err = getEnvDuration("CONFIG_RELOAD_INTERVAL", 30*time.Second); err != nil {
+		return nil, err
 	}
 
 	// Parse enabled messengers
@@ -97,22 +426,8 @@ func Load() (*Config, error) {
 		}
 	}
 
-	// Validate required fields
-	if cfg.IsMessengerEnabled("line") && cfg.LineChannelToken == "" {
-		return nil, fmt.Errorf("LINE_CHANNEL_TOKEN is required when line messenger is enabled")
-	}
-
-	if cfg.GeminiAPIKey == "" && cfg.AIProvider == "gemini" {
-		return nil, fmt.Errorf("GEMINI_API_KEY is required when using gemini AI provider")
-	}
-
-	// Validate database configuration - mutually exclusive for SQLite and PostgreSQL
-	if cfg.DatabasePath == "" && cfg.DatabaseURL == "" {
-		return nil, fmt.Errorf("Either DATABASE_PATH or DATABASE_URL must be set")
-	}
-
-	if cfg.DatabasePath != "" && cfg.DatabaseURL != "" {
-		return nil, fmt.Errorf("Only one of DATABASE_PATH or DATABASE_URL can be set, not both")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
@@ -128,9 +443,137 @@ func (c *Config) IsMessengerEnabled(name string) bool {
 	return false
 }
 
+// DatabaseDriver names the database to use: "sqlite3" without DATABASE_URL,
+// "mysql" for a mysql:// URL and "postgres" for any other URL
+func (c *Config) DatabaseDriver() string {
+	switch {
+	case c.DatabaseURL == "":
+		return "sqlite3"
+	case strings.HasPrefix(c.DatabaseURL, "mysql://"):
+		return "mysql"
+	default:
+		return "postgres"
+	}
+}
+
+// AIAPIKey returns the API key for the configured AI provider
+func (c *Config) AIAPIKey() string {
+	return c.AIProviderAPIKey(c.AIProvider)
+}
+
+// AIProviderAPIKey returns the API key for an AI provider
+func (c *Config) AIProviderAPIKey(provider string) string {
+	if provider == "claude" {
+		return c.AnthropicAPIKey
+	}
+	return c.GeminiAPIKey
+}
+
+// SpeechAPIKey returns the API key for the configured speech-to-text provider
+func (c *Config) SpeechAPIKey() string {
+	if c.SpeechProvider == "openai" {
+		return c.OpenAIAPIKey
+	}
+	return c.GeminiAPIKey
+}
+
+// defaultSpeechModel returns the default transcription model for a speech provider
+func defaultSpeechModel(provider string) string {
+	if provider == "openai" {
+		return "whisper-1"
+	}
+	return "gemini-2.5-flash-lite"
+}
+
+// defaultAIModel returns the default model for an AI provider
+func defaultAIModel(provider string) string {
+	if provider == "claude" {
+		return "claude-3-5-haiku-latest"
+	}
+	return "gemini-2.5-flash-lite"
+}
+
+// lookupFunc finds a setting, in the environment or in a reload file over it
+type lookupFunc func(key string) (string, bool)
+
 func getEnv(key, defaultVal string) string {
-	if value, exists := os.LookupEnv(key); exists {
+	return lookupString(os.LookupEnv, key, defaultVal)
+}
+
+func getEnvInt(key string, defaultVal int) (int, error) {
+	return lookupInt(os.LookupEnv, key, defaultVal)
+}
+
+func getEnvFloat(key string, defaultVal float64) (float64, error) {
+	return lookupFloat(os.LookupEnv, key, defaultVal)
+}
+
+func lookupString(lookup lookupFunc, key, defaultVal string) string {
+	if value, exists := lookup(key); exists {
 		return value
 	}
 	return defaultVal
 }
+
+func lookupInt(lookup lookupFunc, key string, defaultVal int) (int, error) {
+	value, exists := lookup(key)
+	if !exists || value == "" {
+		return defaultVal, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+func lookupFloat(lookup lookupFunc, key string, defaultVal float64) (float64, error) {
+	value, exists := lookup(key)
+	if !exists || value == "" {
+		return defaultVal, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number", key)
+	}
+	return f, nil
+}
+
+func getEnvBool(key string, defaultVal bool) (bool, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return defaultVal, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+	return b, nil
+}
+
+func getEnvDuration(key string, defaultVal time.Duration) (time.Duration, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return defaultVal, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration such as 5m", key)
+	}
+	return d, nil
+}
+
+// getEnvList parses a comma-separated list, skipping empty entries
+func getEnvList(key string, defaultVal []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return defaultVal
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
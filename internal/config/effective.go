@@ -0,0 +1,70 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// redacted replaces the value of a secret that is set
+const redacted = "[redacted]"
+
+// secretFieldSuffixes name the Config fields holding secrets
+var secretFieldSuffixes = []string{"Token", "Secret", "Password", "APIKey", "SigningKey", "AccessKeyID", "SecretAccessKey", "EncryptionKeys"}
+
+// EffectiveConfig is the configuration a running server uses, without secrets
+type EffectiveConfig struct {
+	Settings   map[string]interface{} `json:"settings"`
+	Reloadable []string               `json:"reloadable"`
+	Reload     ReloadStatus           `json:"reload"`
+}
+
+// Effective returns the configuration in effect, its secrets redacted
+func (r *Reloader) Effective() *EffectiveConfig {
+	return &EffectiveConfig{
+		Settings:   r.Current().Redacted(),
+		Reloadable: ReloadableSettings,
+		Reload:     r.Status(),
+	}
+}
+
+// Redacted returns the settings by field name. Secrets that are set read
+// [redacted], and URLs lose their passwords.
+func (c *Config) Redacted() map[string]interface{} {
+	settings := map[string]interface{}{}
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		switch value := v.Field(i).Interface().(type) {
+		case string:
+			settings[name] = redactString(name, value)
+		case time.Duration:
+			settings[name] = value.String()
+		default:
+			settings[name] = value
+		}
+	}
+	return settings
+}
+
+// redactString hides the value of the named field if it is a secret
+func redactString(name, value string) string {
+	if value == "" {
+		return ""
+	}
+	for _, suffix := range secretFieldSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return redacted
+		}
+	}
+	if strings.HasSuffix(name, "URL") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+		if !strings.Contains(value, "://") && strings.Contains(value, "password=") {
+			return redacted
+		}
+	}
+	return value
+}
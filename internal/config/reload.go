@@ -0,0 +1,262 @@
+package config
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReloadableSettings are the settings a running server applies again when
+// its configuration is reloaded; every other setting needs a restart
+var ReloadableSettings = []string{
+	"LOG_LEVEL",
+	"RATE_LIMIT_PER_MINUTE", "RATE_LIMIT_BURST",
+	"AI_MODEL",
+	"AI_DAILY_CAP_USD", "AI_MONTHLY_CAP_USD", "AI_USER_DAILY_CAP_USD", "AI_USER_MONTHLY_CAP_USD",
+}
+
+// loadReloadable parses the reloadable settings
+func (c *Config) loadReloadable(lookup lookupFunc) error {
+	c.LogLevel = lookupString(lookup, "LOG_LEVEL", "info")
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	c.AIModel = lookupString(lookup, "AI_MODEL", defaultAIModel(c.AIProvider))
+
+	var err error
+	if c.RateLimitPerMinute, err = lookupInt(lookup, "RATE_LIMIT_PER_MINUTE", 20); err != nil {
+		return err
+	}
+	if c.RateLimitBurst, err = lookupInt(lookup, "RATE_LIMIT_BURST", 5); err != nil {
+		return err
+	}
+	for _, limit := range []struct {
+		key string
+		dst *float64
+	}{
+		{"AI_DAILY_CAP_USD", &c.AIDailyCapUSD},
+		{"AI_MONTHLY_CAP_USD", &c.AIMonthlyCapUSD},
+		{"AI_USER_DAILY_CAP_USD", &c.AIUserDailyCapUSD},
+		{"AI_USER_MONTHLY_CAP_USD", &c.AIUserMonthlyCapUSD},
+	} {
+		if *limit.dst, err = lookupFloat(lookup, limit.key, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reloadableValues returns the reloadable settings by key, to tell which ones
+// a reload changed
+func (c *Config) reloadableValues() map[string]string {
+	return map[string]string{
+		"LOG_LEVEL":               c.LogLevel,
+		"RATE_LIMIT_PER_MINUTE":   strconv.Itoa(c.RateLimitPerMinute),
+		"RATE_LIMIT_BURST":        strconv.Itoa(c.RateLimitBurst),
+		"AI_MODEL":                c.AIModel,
+		"AI_DAILY_CAP_USD":        strconv.FormatFloat(c.AIDailyCapUSD, 'f', -1, 64),
+		"AI_MONTHLY_CAP_USD":      strconv.FormatFloat(c.AIMonthlyCapUSD, 'f', -1, 64),
+		"AI_USER_DAILY_CAP_USD":   strconv.FormatFloat(c.AIUserDailyCapUSD, 'f', -1, 64),
+		"AI_USER_MONTHLY_CAP_USD": strconv.FormatFloat(c.AIUserMonthlyCapUSD, 'f', -1, 64),
+	}
+}
+
+// Reload returns a copy of the configuration whose reloadable settings are
+// read again, from ReloadFile over the environment. Other settings found in
+// the file are returned in needRestart; they are not applied.
+func (c *Config) Reload() (next *Config, needRestart []string, err error) {
+	overrides := map[string]string{}
+	if c.ReloadFile != "" {
+		if overrides, err = readReloadFile(c.ReloadFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	lookup := func(key string) (string, bool) {
+		if value, ok := overrides[key]; ok {
+			return value, true
+		}
+		return os.LookupEnv(key)
+	}
+
+	reloaded := *c
+	if err := reloaded.loadReloadable(lookup); err != nil {
+		return nil, nil, err
+	}
+	for key := range overrides {
+		if !slices.Contains(ReloadableSettings, key) {
+			needRestart = append(needRestart, key)
+		}
+	}
+	slices.Sort(needRestart)
+	return &reloaded, needRestart, nil
+}
+
+// readReloadFile parses KEY=VALUE lines, skipping blank lines and # comments.
+// Values may be quoted.
+func readReloadFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CONFIG_RELOAD_FILE: %w", err)
+	}
+	defer f.Close()
+
+	settings := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		settings[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CONFIG_RELOAD_FILE: %w", err)
+	}
+	return settings, nil
+}
+
+// ReloadStatus tells where reloads come from, when the last one succeeded
+// and why the last attempt failed, if it did
+type ReloadStatus struct {
+	File       string     `json:"file,omitempty"`
+	Interval   string     `json:"interval,omitempty"`
+	ReloadedAt *time.Time `json:"reloaded_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Reloader holds the configuration in effect and reloads it on request, such
+// as on SIGHUP, and every ReloadInterval while a ReloadFile is set. Each
+// reload that changes a setting is handed to the OnReload functions.
+type Reloader struct {
+	mu         sync.Mutex
+	current    *Config
+	appliers   []func(cfg *Config)
+	reloadedAt time.Time
+	lastErr    error
+	warned     map[string]bool // settings already reported as needing a restart
+	now        func() time.Time
+}
+
+// NewReloader creates a reloader starting from cfg
+func NewReloader(cfg *Config) *Reloader {
+	return &Reloader{current: cfg, warned: map[string]bool{}, now: time.Now}
+}
+
+// OnReload adds apply to the functions a changed configuration is handed to
+func (r *Reloader) OnReload(apply func(cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.appliers = append(r.appliers, apply)
+}
+
+// Current returns the configuration in effect
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Status returns the outcome of the reloads so far
+func (r *Reloader) Status() ReloadStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := ReloadStatus{File: r.current.ReloadFile}
+	if status.File != "" {
+		status.Interval = r.current.ReloadInterval.String()
+	}
+	if !r.reloadedAt.IsZero() {
+		reloadedAt := r.reloadedAt
+		status.ReloadedAt = &reloadedAt
+	}
+	if r.lastErr != nil {
+		status.Error = r.lastErr.Error()
+	}
+	return status
+}
+
+// Reload reloads the configuration and applies the settings that changed.
+// When the reload fails, the configuration in effect is kept.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, needRestart, err := r.current.Reload()
+	if err != nil {
+		r.lastErr = err
+		return err
+	}
+	r.lastErr = nil
+	r.reloadedAt = r.now()
+	for _, key := range needRestart {
+		if !r.warned[key] {
+			r.warned[key] = true
+			slog.Warn("Setting in the reload file needs a restart to apply", "key", key)
+		}
+	}
+
+	before, after := r.current.reloadableValues(), next.reloadableValues()
+	var changed []string
+	for _, key := range ReloadableSettings {
+		if before[key] != after[key] {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	r.current = next
+	for _, apply := range r.appliers {
+		apply(next)
+	}
+	slog.Info("Configuration reloaded", "changed", changed)
+	return nil
+}
+
+// Run reloads on each signal from reload, and every ReloadInterval while a
+// ReloadFile is set, until ctx is done. Failed reloads are logged, once for
+// as long as polling keeps failing the same way.
+func (r *Reloader) Run(ctx context.Context, reload <-chan os.Signal) {
+	var lastErr string
+	var tick <-chan time.Time
+	if cfg := r.Current(); cfg.ReloadFile != "" && cfg.ReloadInterval > 0 {
+		ticker := time.NewTicker(cfg.ReloadInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reload:
+			lastErr = ""
+		case <-tick:
+		}
+		err := r.Reload()
+		if err != nil && err.Error() != lastErr {
+			slog.Error("Failed to reload configuration; keeping the current one", "error", err)
+		}
+		lastErr = ""
+		if err != nil {
+			lastErr = err.Error()
+		}
+	}
+}
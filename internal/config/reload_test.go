@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfig_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reload.env")
+	t.Setenv("LOG_LEVEL", "info")
+	t.Setenv("RATE_LIMIT_PER_MINUTE", "20")
+
+	cfg := validConfig()
+	cfg.ReloadFile = path
+	cfg.LogLevel, cfg.RateLimitPerMinute, cfg.RateLimitBurst = "info", 20, 5
+
+	os.WriteFile(path, []byte("# tuned at runtime\nLOG_LEVEL=debug\nAI_MODEL=\"gemini-2.5-pro\"\nAI_USER_DAILY_CAP_USD=0.5\nSERVER_PORT=9090\n"), 0o600)
+	next, needRestart, err := cfg.Reload()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next.LogLevel != "debug" || next.AIModel != "gemini-2.5-pro" || next.AIUserDailyCapUSD != 0.5 {
+		t.Errorf("expected the file's settings, got %s %s %v", next.LogLevel, next.AIModel, next.AIUserDailyCapUSD)
+	}
+	if next.RateLimitPerMinute != 20 || next.ServerPort != "8080" {
+		t.Errorf("expected the environment and the startup values elsewhere, got %d %s", next.RateLimitPerMinute, next.ServerPort)
+	}
+	if len(needRestart) != 1 || needRestart[0] != "SERVER_PORT" {
+		t.Errorf("expected SERVER_PORT to need a restart, got %q", needRestart)
+	}
+	if cfg.LogLevel != "info" {
+		t.Error("expected the original configuration to be left alone")
+	}
+
+	os.WriteFile(path, []byte("LOG_LEVEL=loud\n"), 0o600)
+	if _, _, err := cfg.Reload(); err == nil || !strings.Contains(err.Error(), "LOG_LEVEL must be") {
+		t.Errorf("expected an invalid level to be rejected, got %v", err)
+	}
+	os.WriteFile(path, []byte("LOG_LEVEL\n"), 0o600)
+	if _, _, err := cfg.Reload(); err == nil || !strings.Contains(err.Error(), ":1: expected KEY=VALUE") {
+		t.Errorf("expected the malformed line to be reported, got %v", err)
+	}
+}
+
+func TestReloader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reload.env")
+	cfg := validConfig()
+	cfg.ReloadFile = path
+	cfg.LogLevel, cfg.RateLimitPerMinute, cfg.RateLimitBurst = "info", 20, 5
+
+	reloader := NewReloader(cfg)
+	var applied []*Config
+	reloader.OnReload(func(cfg *Config) { applied = append(applied, cfg) })
+
+	os.WriteFile(path, []byte("RATE_LIMIT_PER_MINUTE=20\n"), 0o600)
+	if err := reloader.Reload(); err != nil || len(applied) != 0 {
+		t.Errorf("expected an unchanged configuration not to be applied, got %d, %v", len(applied), err)
+	}
+
+	os.WriteFile(path, []byte("RATE_LIMIT_PER_MINUTE=60\n"), 0o600)
+	if err := reloader.Reload(); err != nil || len(applied) != 1 || applied[0].RateLimitPerMinute != 60 {
+		t.Fatalf("expected the new rate limit to be applied, got %d, %v", len(applied), err)
+	}
+
+	os.WriteFile(path, []byte("RATE_LIMIT_PER_MINUTE=lots\n"), 0o600)
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("expected an invalid rate limit to fail the reload")
+	}
+	if reloader.Current().RateLimitPerMinute != 60 || len(applied) != 1 {
+		t.Error("expected a failed reload to keep the configuration in effect")
+	}
+	status := reloader.Status()
+	if status.ReloadedAt == nil || !strings.Contains(status.Error, "RATE_LIMIT_PER_MINUTE must be an integer") {
+		t.Errorf("expected the last success and the failure, got %+v", status)
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := validConfig()
+	cfg.DatabaseURL = "postgres://app:hunter2@db/aiexpense"
+	cfg.RedisURL = "redis://:hunter2@cache:6379/0"
+	cfg.SlackSigningSecret = "hunter2"
+	cfg.S3SecretAccessKey = "hunter2"
+
+	settings := cfg.Redacted()
+	for key, value := range settings {
+		if s, ok := value.(string); ok && strings.Contains(s, "hunter2") {
+			t.Errorf("expected %s to be redacted, got %q", key, s)
+		}
+	}
+	if settings["SlackSigningSecret"] != redacted || settings["SlackBotToken"] != "" {
+		t.Errorf("expected set secrets redacted and unset ones empty, got %q %q", settings["SlackSigningSecret"], settings["SlackBotToken"])
+	}
+	if settings["DatabaseURL"] != "postgres://app:xxxxx@db/aiexpense" || settings["AIModel"] != "gemini-2.5-flash-lite" {
+		t.Errorf("expected the URL without its password and plain settings as they are, got %q %q", settings["DatabaseURL"], settings["AIModel"])
+	}
+}
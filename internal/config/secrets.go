@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// secretsTimeout bounds how long startup waits for the secret manager
+const secretsTimeout = 10 * time.Second
+
+// SecretsBackends are the secret managers SECRETS_BACKEND accepts
+var SecretsBackends = []string{"vault", "aws"}
+
+// secretStore fetches the secret SECRETS_PATH names from a secret manager. It
+// holds settings by environment variable name, e.g. {"GEMINI_API_KEY": "..."}.
+type secretStore interface {
+	fetch(ctx context.Context) (map[string]string, error)
+}
+
+// secretSource looks up secret settings in KEY_FILE, then in the environment,
+// then in the secret manager
+type secretSource struct {
+	managed map[string]string
+}
+
+// get returns the secret setting key, or defaultVal when it is set nowhere
+func (s *secretSource) get(key, defaultVal string) (string, error) {
+	if path := getEnv(key+"_FILE", ""); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s_FILE: %w", key, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if value, exists := os.LookupEnv(key); exists {
+		return value, nil
+	}
+	if value, exists := s.managed[key]; exists {
+		return value, nil
+	}
+	return defaultVal, nil
+}
+
+// loadSecretSource fetches the secrets of the secret manager SECRETS_BACKEND
+// names, if any, so they can fill in the secret settings the environment
+// leaves unset
+func loadSecretSource() (*secretSource, error) {
+	backend := getEnv("SECRETS_BACKEND", "")
+	if backend == "" {
+		return &secretSource{}, nil
+	}
+	path := getEnv("SECRETS_PATH", "")
+	if path == "" {
+		return nil, fmt.Errorf("SECRETS_PATH is required when using %s secrets backend", backend)
+	}
+
+	// The backends' own credentials can only come from the environment or files
+	bootstrap := &secretSource{}
+	var store secretStore
+	switch backend {
+	case "vault":
+		token, err := bootstrap.get("VAULT_TOKEN", "")
+		if err != nil {
+			return nil, err
+		}
+		if store, err = newVaultStore(getEnv("VAULT_ADDR", ""), token, getEnv("VAULT_NAMESPACE", ""), path); err != nil {
+			return nil, err
+		}
+	case "aws":
+		accessKeyID, err := bootstrap.get("AWS_ACCESS_KEY_ID", "")
+		if err != nil {
+			return nil, err
+		}
+		secretAccessKey, err := bootstrap.get("AWS_SECRET_ACCESS_KEY", "")
+		if err != nil {
+			return nil, err
+		}
+		region := getEnv("AWS_REGION", getEnv("AWS_DEFAULT_REGION", ""))
+		if store, err = newAWSSecretsStore(getEnv("SECRETS_ENDPOINT", ""), region, accessKeyID, secretAccessKey, getEnv("AWS_SESSION_TOKEN", ""), path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SECRETS_BACKEND %q; use %s, or leave it empty to read secrets from the environment", backend, strings.Join(SecretsBackends, " or "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+	managed, err := store.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secrets from %s at %s: %w", backend, path, err)
+	}
+	return &secretSource{managed: managed}, nil
+}
+
+// secretStrings converts the values of a secret to strings; numbers and
+// booleans keep their JSON spelling
+func secretStrings(values map[string]interface{}) (map[string]string, error) {
+	secrets := make(map[string]string, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			secrets[key] = v
+		case fmt.Stringer, bool:
+			secrets[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("%s must be a string", key)
+		}
+	}
+	return secrets, nil
+}
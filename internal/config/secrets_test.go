@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecretSource_Get(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(path, []byte("from-file\n"), 0o600)
+	secrets := &secretSource{managed: map[string]string{"A_TOKEN": "from-manager", "B_TOKEN": "from-manager", "C_TOKEN": "from-manager"}}
+
+	t.Setenv("A_TOKEN", "from-env")
+	t.Setenv("A_TOKEN_FILE", path)
+	t.Setenv("B_TOKEN", "from-env")
+	for key, want := range map[string]string{"A_TOKEN": "from-file", "B_TOKEN": "from-env", "C_TOKEN": "from-manager", "D_TOKEN": "default"} {
+		if got, err := secrets.get(key, "default"); err != nil || got != want {
+			t.Errorf("expected %s to be %q, got %q, %v", key, want, got, err)
+		}
+	}
+
+	t.Setenv("A_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := secrets.get("A_TOKEN", ""); err == nil || !strings.Contains(err.Error(), "failed to read A_TOKEN_FILE") {
+		t.Errorf("expected a missing file to fail, got %v", err)
+	}
+}
+
+func TestVaultStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors": ["permission denied"]}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/aiexpense":
+			w.Write([]byte(`{"data": {"data": {"GEMINI_API_KEY": "AIzaVault", "SMTP_PORT": 587}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/aiexpense":
+			w.Write([]byte(`{"data": {"GEMINI_API_KEY": "AIzaV1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer server.Close()
+
+	fetch := func(token, path string) (map[string]string, error) {
+		store, err := newVaultStore(server.URL, token, "", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store.fetch(context.Background())
+	}
+
+	secrets, err := fetch("s.token", "/secret/data/aiexpense")
+	if err != nil || secrets["GEMINI_API_KEY"] != "AIzaVault" || secrets["SMTP_PORT"] != "587" {
+		t.Errorf("expected the KV version 2 secret, got %v, %v", secrets, err)
+	}
+	if secrets, err := fetch("s.token", "kv/aiexpense"); err != nil || secrets["GEMINI_API_KEY"] != "AIzaV1" {
+		t.Errorf("expected the KV version 1 secret, got %v, %v", secrets, err)
+	}
+	if _, err := fetch("s.wrong", "kv/aiexpense"); err == nil || !strings.Contains(err.Error(), "status 403: permission denied") {
+		t.Errorf("expected Vault's error, got %v", err)
+	}
+	if _, err := newVaultStore(server.URL, "", "", "kv/aiexpense"); err == nil {
+		t.Error("expected a missing token to be refused")
+	}
+}
+
+func TestAWSSecretsStore(t *testing.T) {
+	secretString := `{"TELEGRAM_BOT_TOKEN": "123:abc"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20260102/eu-west-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature=") {
+			t.Errorf("unexpected Authorization header: %q", auth)
+		}
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" || r.Header.Get("X-Amz-Security-Token") != "session" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		var req struct{ SecretId string }
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &req)
+		if req.SecretId != "prod/aiexpense" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type": "com.amazonaws.secretsmanager#ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"SecretString": secretString})
+	}))
+	defer server.Close()
+
+	fetch := func(secretID string) (map[string]string, error) {
+		store, err := newAWSSecretsStore(server.URL, "eu-west-1", "AKID", "secret", "session", secretID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+		return store.fetch(context.Background())
+	}
+
+	if secrets, err := fetch("prod/aiexpense"); err != nil || secrets["TELEGRAM_BOT_TOKEN"] != "123:abc" {
+		t.Errorf("expected the secret's settings, got %v, %v", secrets, err)
+	}
+	if _, err := fetch("prod/missing"); err == nil || !strings.Contains(err.Error(), "status 400: ResourceNotFoundException") {
+		t.Errorf("expected the error type, got %v", err)
+	}
+	secretString = "plain-token"
+	if _, err := fetch("prod/aiexpense"); err == nil || !strings.Contains(err.Error(), "JSON object") {
+		t.Errorf("expected a plain secret to be refused, got %v", err)
+	}
+}
+
+func TestLoad_SecretsBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"data": {"GEMINI_API_KEY": "AIzaVault", "JWT_SECRET": "vault-jwt"}, "metadata": {}}}`))
+	}))
+	defer server.Close()
+
+	if key, ok := os.LookupEnv("GEMINI_API_KEY"); ok {
+		os.Unsetenv("GEMINI_API_KEY")
+		defer os.Setenv("GEMINI_API_KEY", key)
+	}
+	t.Setenv("SECRETS_BACKEND", "vault")
+	t.Setenv("SECRETS_PATH", "secret/data/aiexpense")
+	t.Setenv("VAULT_ADDR", server.URL)
+	t.Setenv("VAULT_TOKEN", "s.token")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if cfg.GeminiAPIKey != "AIzaVault" || cfg.JWTSecret != "vault-jwt" {
+		t.Errorf("expected secrets from Vault, got %q %q", cfg.GeminiAPIKey, cfg.JWTSecret)
+	}
+
+	t.Setenv("SECRETS_BACKEND", "keychain")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), `unsupported SECRETS_BACKEND "keychain"`) {
+		t.Errorf("expected an unknown backend to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultJWTSecret is what JWT_SECRET falls back to, fit only for development
+const defaultJWTSecret = "default-secret-do-not-use-in-prod"
+
+// Messengers are the names ENABLED_MESSENGERS accepts
+var Messengers = []string{"terminal", "line", "telegram", "discord", "whatsapp", "slack", "teams", "matrix"}
+
+// setting is an environment variable and the value it was loaded into
+type setting struct {
+	key   string
+	value string
+}
+
+// ValidationError lists every problem found in the configuration, so they
+// can all be fixed before the next start
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return e.Problems[0]
+	}
+	return fmt.Sprintf("%d configuration problems: %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// messengerSecrets returns the settings a messenger needs to run
+func (c *Config) messengerSecrets(name string) []setting {
+	switch name {
+	case "line":
+		return []setting{{"LINE_CHANNEL_TOKEN", c.LineChannelToken}, {"LINE_CHANNEL_SECRET", c.LineChannelSecret}}
+	case "telegram":
+		return []setting{{"TELEGRAM_BOT_TOKEN", c.TelegramBotToken}}
+	case "discord":
+		return []setting{{"DISCORD_BOT_TOKEN", c.DiscordBotToken}}
+	case "whatsapp":
+		return []setting{
+			{"WHATSAPP_PHONE_NUMBER_ID", c.WhatsAppPhoneNumberID}, {"WHATSAPP_ACCESS_TOKEN", c.WhatsAppAccessToken},
+			{"WHATSAPP_APP_SECRET", c.WhatsAppAppSecret}, {"WHATSAPP_VERIFY_TOKEN", c.WhatsAppVerifyToken},
+		}
+	case "slack":
+		return []setting{{"SLACK_BOT_TOKEN", c.SlackBotToken}, {"SLACK_SIGNING_SECRET", c.SlackSigningSecret}}
+	case "teams":
+		return []setting{{"TEAMS_APP_ID", c.TeamsAppID}, {"TEAMS_APP_PASSWORD", c.TeamsAppPassword}}
+	case "matrix":
+		return []setting{
+			{"MATRIX_HOMESERVER_URL", c.MatrixHomeserverURL}, {"MATRIX_AS_TOKEN", c.MatrixASToken},
+			{"MATRIX_HS_TOKEN", c.MatrixHSToken}, {"MATRIX_BOT_USER_ID", c.MatrixBotUserID},
+		}
+	}
+	return nil
+}
+
+// Validate checks that the configuration is complete and consistent. It
+// reports every problem at once, each naming the setting that fixes it.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// Messengers and their secrets
+	for _, name := range c.EnabledMessengers {
+		if !slices.Contains(Messengers, name) {
+			add("unknown messenger %q in ENABLED_MESSENGERS; choose from %s", name, strings.Join(Messengers, ", "))
+			continue
+		}
+		for _, secret := range c.messengerSecrets(name) {
+			if secret.value == "" {
+				add("%s is required when the %s messenger is enabled", secret.key, name)
+			}
+		}
+	}
+
+	// Database: DATABASE_PATH for SQLite and DATABASE_URL for PostgreSQL or
+	// MySQL are mutually exclusive
+	switch {
+	case c.DatabasePath == "" && c.DatabaseURL == "":
+		add("Either DATABASE_PATH or DATABASE_URL must be set")
+	case c.DatabasePath != "" && c.DatabaseURL != "":
+		add("Only one of DATABASE_PATH or DATABASE_URL can be set, not both")
+	case c.DatabaseURL != "":
+		if err := validateDatabaseURL(c.DatabaseURL); err != nil {
+			add("DATABASE_URL %v", err)
+		}
+	}
+
+	// Ports
+	if !validPort(c.ServerPort) {
+		add("SERVER_PORT must be a port number between 1 and 65535, got %q", c.ServerPort)
+	}
+	if c.GRPCPort != "" && !validPort(c.GRPCPort) {
+		add("GRPC_PORT must be a port number between 1 and 65535, got %q", c.GRPCPort)
+	}
+	if c.GRPCPort != "" && c.GRPCPort == c.ServerPort {
+		add("GRPC_PORT must differ from SERVER_PORT (%s)", c.ServerPort)
+	}
+
+	// Environment and sign-in
+	switch c.Environment {
+	case "production", "development":
+	default:
+		add("SERVER_ENV must be production or development, got %q", c.Environment)
+	}
+	if !c.AuthRequired && c.Environment != "development" {
+		add("AUTH_REQUIRED=false lets anyone act as any user; it is only allowed with SERVER_ENV=development")
+	}
+	if c.JWTSecret == defaultJWTSecret && (c.AuthRequired || c.Environment != "development") {
+		add("JWT_SECRET must be set to a long random value so access tokens and report links can't be forged; the built-in one is only allowed with SERVER_ENV=development and AUTH_REQUIRED=false")
+	}
+
+	// AI providers and their keys
+	switch c.AIProvider {
+	case "gemini", "claude":
+		if c.AIAPIKey() == "" {
+			add("%s is required when using %s AI provider", aiKeyName(c.AIProvider), c.AIProvider)
+		}
+	default:
+		add("unsupported AI_PROVIDER %q; use gemini or claude", c.AIProvider)
+	}
+	for i, entry := range c.AIProviders {
+		provider, _, _ := strings.Cut(entry, ":")
+		switch provider {
+		case "gemini", "claude":
+			if c.AIProviderAPIKey(provider) == "" {
+				add("an API key is required for %s in AI_PROVIDERS; set %s", provider, aiKeyName(provider))
+			}
+		case "regex":
+			if i != len(c.AIProviders)-1 {
+				add("regex must be the last provider of AI_PROVIDERS")
+			}
+		default:
+			add("unsupported provider %q in AI_PROVIDERS; use gemini, claude or regex", provider)
+		}
+	}
+	switch c.SpeechProvider {
+	case "", "gemini":
+	case "openai", "whisper":
+		if c.OpenAIAPIKey == "" {
+			add("OPENAI_API_KEY is required when using %s speech provider", c.SpeechProvider)
+		}
+	default:
+		add("unsupported SPEECH_PROVIDER %q; use gemini, openai, or leave it empty to turn voice messages off", c.SpeechProvider)
+	}
+
+	// Storage
+	for _, storage := range []setting{{"ATTACHMENT_STORAGE", c.AttachmentStorage}, {"ARCHIVE_STORAGE", c.ArchiveStorage}} {
+		if !slices.Contains([]string{"", "local", "s3"}, storage.value) {
+			add("unsupported %s %q; use local, s3, or leave it empty to turn it off", storage.key, storage.value)
+		}
+	}
+	if c.AttachmentStorage == "s3" && c.S3Bucket == "" {
+		add("S3_BUCKET is required when using s3 attachment storage")
+	}
+	if c.ArchiveStorage == "s3" && c.ArchiveS3Bucket == "" {
+		add("ARCHIVE_S3_BUCKET or S3_BUCKET is required when using s3 archive storage")
+	}
+	if c.ArchiveAfterMonths < 0 || c.ArchivePurgeAfterDays < 0 {
+		add("ARCHIVE_AFTER_MONTHS and ARCHIVE_PURGE_AFTER_DAYS must not be negative")
+	}
+
+	// Redis-backed cache and queue
+	switch c.SummaryCache {
+	case "", "memory":
+	case "redis":
+		if c.RedisURL == "" {
+			add("REDIS_URL is required when using redis summary cache")
+		}
+	default:
+		add("unsupported SUMMARY_CACHE %q; use memory, redis, or leave it empty to turn it off", c.SummaryCache)
+	}
+	switch c.WebhookQueue {
+	case "", "memory":
+	case "redis":
+		if c.RedisURL == "" {
+			add("REDIS_URL is required when using redis webhook queue")
+		}
+	default:
+		add("unsupported WEBHOOK_QUEUE %q; use memory, redis, or leave it empty to process messages inline", c.WebhookQueue)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Warnings lists settings that work but are risky outside development
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.JWTSecret == defaultJWTSecret {
+		warnings = append(warnings, "JWT_SECRET is the built-in development secret; set a random one before exposing the server")
+	}
+	if c.GeminiAPIKey != "" && !strings.HasPrefix(c.GeminiAPIKey, "AIza") {
+		warnings = append(warnings, "GEMINI_API_KEY doesn't look like a Google API key (AIza...)")
+	}
+	if c.AnthropicAPIKey != "" && !strings.HasPrefix(c.AnthropicAPIKey, "sk-ant-") {
+		warnings = append(warnings, "ANTHROPIC_API_KEY doesn't look like an Anthropic API key (sk-ant-...)")
+	}
+	return warnings
+}
+
+// CheckPorts fails when a port the server listens on is already taken, so
+// the server stops before its slow startup rather than after it
+func (c *Config) CheckPorts() error {
+	for _, port := range []setting{{"SERVER_PORT", c.ServerPort}, {"GRPC_PORT", c.GRPCPort}} {
+		if port.value == "" {
+			continue
+		}
+		listener, err := net.Listen("tcp", ":"+port.value)
+		if err != nil {
+			return fmt.Errorf("%s %s is not available; stop what is listening on it or choose another port: %w", port.key, port.value, err)
+		}
+		listener.Close()
+	}
+	return nil
+}
+
+// validateDatabaseURL checks a PostgreSQL or MySQL URL, or a PostgreSQL
+// key=value connection string
+func validateDatabaseURL(databaseURL string) error {
+	if !strings.Contains(databaseURL, "://") {
+		if strings.Contains(databaseURL, "=") {
+			return nil
+		}
+		return fmt.Errorf("must be a postgres://, postgresql:// or mysql:// URL; use DATABASE_PATH for a SQLite file")
+	}
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL: %v", withoutURL(err))
+	}
+	switch u.Scheme {
+	case "postgres", "postgresql", "mysql":
+	default:
+		return fmt.Errorf("has unsupported scheme %q; use postgres, postgresql or mysql", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("needs a host, e.g. %s://user:password@localhost/aiexpense", u.Scheme)
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		return fmt.Errorf("needs a database name, e.g. %s://user:password@%s/aiexpense", u.Scheme, u.Host)
+	}
+	return nil
+}
+
+// withoutURL strips the URL from a url.Error, as it may hold the password
+func withoutURL(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
+
+// validPort reports whether port is a TCP port number
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// aiKeyName returns the environment variable of an AI provider's API key
+func aiKeyName(provider string) string {
+	if provider == "claude" {
+		return "ANTHROPIC_API_KEY"
+	}
+	return "GEMINI_API_KEY"
+}
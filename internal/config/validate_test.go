@@ -0,0 +1,193 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// validConfig returns a configuration that passes validation
+func validConfig() *Config {
+	return &Config{
+		DatabasePath:      "./aiexpense.db",
+		ServerPort:        "8080",
+		AIProvider:        "gemini",
+		AIModel:           "gemini-2.5-flash-lite",
+		GeminiAPIKey:      "AIzaTest",
+		SpeechProvider:    "gemini",
+		AttachmentStorage: "local",
+		ArchiveStorage:    "local",
+		SummaryCache:      "memory",
+		Environment:       "production",
+		JWTSecret:         "s3cret",
+		AuthRequired:      true,
+		EnabledMessengers: []string{"terminal"},
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := validConfig().Validate(); err != nil {
+		t.Fatalf("expected a valid configuration, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   []string
+	}{
+		{
+			name:   "Every missing messenger secret",
+			modify: func(c *Config) { c.EnabledMessengers = []string{"slack", "teams"}; c.SlackBotToken = "xoxb" },
+			want:   []string{"SLACK_SIGNING_SECRET is required when the slack messenger is enabled", "TEAMS_APP_ID", "TEAMS_APP_PASSWORD"},
+		},
+		{
+			name:   "Unknown messenger",
+			modify: func(c *Config) { c.EnabledMessengers = []string{"icq"} },
+			want:   []string{`unknown messenger "icq" in ENABLED_MESSENGERS`},
+		},
+		{
+			name:   "SQLite path as a database URL",
+			modify: func(c *Config) { c.DatabasePath = ""; c.DatabaseURL = "/var/lib/aiexpense.db" },
+			want:   []string{"use DATABASE_PATH for a SQLite file"},
+		},
+		{
+			name:   "Database URL without a database name",
+			modify: func(c *Config) { c.DatabasePath = ""; c.DatabaseURL = "postgres://app:pw@db:5432" },
+			want:   []string{"DATABASE_URL needs a database name"},
+		},
+		{
+			name:   "Database URL with an unsupported scheme",
+			modify: func(c *Config) { c.DatabasePath = ""; c.DatabaseURL = "mongodb://db/aiexpense" },
+			want:   []string{`unsupported scheme "mongodb"`},
+		},
+		{
+			name:   "Ports",
+			modify: func(c *Config) { c.ServerPort = "http"; c.GRPCPort = "70000" },
+			want:   []string{`SERVER_PORT must be a port number between 1 and 65535, got "http"`, "GRPC_PORT must be a port number"},
+		},
+		{
+			name:   "Anonymous requests outside development",
+			modify: func(c *Config) { c.AuthRequired = false },
+			want:   []string{"AUTH_REQUIRED=false lets anyone act as any user; it is only allowed with SERVER_ENV=development"},
+		},
+		{
+			name:   "Built-in JWT secret",
+			modify: func(c *Config) { c.JWTSecret = defaultJWTSecret },
+			want:   []string{"JWT_SECRET must be set to a long random value"},
+		},
+		{
+			name:   "Built-in JWT secret in development with sign-in",
+			modify: func(c *Config) { c.Environment, c.JWTSecret = "development", defaultJWTSecret },
+			want:   []string{"JWT_SECRET must be set to a long random value"},
+		},
+		{
+			name:   "Unknown environment",
+			modify: func(c *Config) { c.Environment = "staging" },
+			want:   []string{`SERVER_ENV must be production or development, got "staging"`},
+		},
+		{
+			name:   "AI keys",
+			modify: func(c *Config) { c.GeminiAPIKey = ""; c.AIProviders = []string{"regex", "claude"} },
+			want:   []string{"GEMINI_API_KEY is required", "regex must be the last provider", "set ANTHROPIC_API_KEY"},
+		},
+		{
+			name:   "Unsupported providers",
+			modify: func(c *Config) { c.AIProvider = "openai"; c.SpeechProvider = "siri"; c.WebhookQueue = "kafka" },
+			want:   []string{`unsupported AI_PROVIDER "openai"`, `unsupported SPEECH_PROVIDER "siri"`, `unsupported WEBHOOK_QUEUE "kafka"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("expected a ValidationError, got %v", err)
+			}
+			if len(invalid.Problems) != len(tt.want) {
+				t.Errorf("expected %d problems, got %q", len(tt.want), invalid.Problems)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in %q", want, err.Error())
+				}
+			}
+		})
+	}
+
+	cfg := validConfig()
+	cfg.Environment, cfg.AuthRequired, cfg.JWTSecret = "development", false, defaultJWTSecret
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected anonymous requests and the built-in secret to be allowed in development, got %v", err)
+	}
+
+	cfg = validConfig()
+	cfg.DatabasePath = ""
+	for _, databaseURL := range []string{"postgres://app:pw@db:5432/aiexpense?sslmode=disable", "mysql://app:pw@db/aiexpense", "host=db dbname=aiexpense"} {
+		cfg.DatabaseURL = databaseURL
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("expected %q to be valid, got %v", databaseURL, err)
+		}
+	}
+}
+
+func TestConfig_CheckPorts(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, taken, _ := net.SplitHostPort(listener.Addr().String())
+
+	cfg := validConfig()
+	cfg.ServerPort = taken
+	if err := cfg.CheckPorts(); err == nil || !strings.Contains(err.Error(), "SERVER_PORT "+taken+" is not available") {
+		t.Errorf("expected the taken port to be reported, got %v", err)
+	}
+	listener.Close()
+	if err := cfg.CheckPorts(); err != nil {
+		t.Errorf("expected the freed port to be available, got %v", err)
+	}
+}
+
+func TestConfig_WriteCapabilities(t *testing.T) {
+	cfg := validConfig()
+	cfg.DatabasePath = ""
+	cfg.DatabaseURL = "postgres://app:hunter2@db:5432/aiexpense"
+	cfg.EnabledMessengers = []string{"terminal", "telegram"}
+	cfg.TelegramBotToken = "123:abc"
+	cfg.RateLimitPerMinute, cfg.RateLimitBurst = 20, 5
+
+	var out bytes.Buffer
+	cfg.WriteCapabilities(&out)
+	table := out.String()
+	for _, want := range []string{
+		"database             on      postgres db:5432/aiexpense",
+		"messenger telegram   on",
+		"messenger line       off     add to ENABLED_MESSENGERS",
+		"rate limit           on      20/min, burst 5",
+		"gRPC                 off     set GRPC_PORT",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("expected %q in\n%s", want, table)
+		}
+	}
+	if strings.Contains(table, "hunter2") || strings.Contains(table, "123:abc") {
+		t.Errorf("expected no secrets in\n%s", table)
+	}
+}
+
+func TestConfig_Warnings(t *testing.T) {
+	cfg := validConfig()
+	if warnings := cfg.Warnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %q", warnings)
+	}
+	cfg.Environment, cfg.AuthRequired, cfg.JWTSecret = "development", false, defaultJWTSecret
+	cfg.AnthropicAPIKey = "dummy"
+	if warnings := cfg.Warnings(); len(warnings) != 2 {
+		t.Errorf("expected warnings for the JWT secret and the Anthropic key, got %q", warnings)
+	}
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// vaultStore reads a secret from HashiCorp Vault's KV secrets engine, version
+// 1 or 2. For version 2 the path includes data/, e.g. secret/data/aiexpense.
+type vaultStore struct {
+	addr       string
+	token      string
+	namespace  string
+	path       string
+	httpClient *http.Client
+}
+
+func newVaultStore(addr, token, namespace, path string) (*vaultStore, error) {
+	if addr == "" || token == "" {
+		return nil, fmt.Errorf("VAULT_ADDR and VAULT_TOKEN are required when using vault secrets backend")
+	}
+	return &vaultStore{
+		addr:       strings.TrimSuffix(addr, "/"),
+		token:      token,
+		namespace:  namespace,
+		path:       strings.Trim(path, "/"),
+		httpClient: &http.Client{Timeout: secretsTimeout},
+	}, nil
+}
+
+func (v *vaultStore) fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", v.addr+"/v1/"+v.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(io.LimitReader(resp.Body, 1<<20))
+	decoder.UseNumber()
+	var body struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []string               `json:"errors"`
+	}
+	if err := decoder.Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("failed to decode Vault response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Vault returned status %d: %s", resp.StatusCode, strings.Join(body.Errors, "; "))
+	}
+
+	// KV version 2 nests the secret under data, next to its metadata
+	data := body.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+	return secretStrings(data)
+}
@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Chart types
+const (
+	ChartPie = "pie" // Shares of a total, e.g. spending per category
+	ChartBar = "bar" // Amounts side by side, each optionally beside an earlier one
+)
+
+// MaxChartValues is the most slices or bars a chart has
+const MaxChartValues = 8
+
+// ChartLegendMarks stand for a chart's slices or bars, in order, in text
+// legends; each is the color the slice or bar is drawn in
+var ChartLegendMarks = [MaxChartValues]string{"🟥", "🟧", "🟨", "🟩", "🟦", "🟪", "🟫", "⬛"}
+
+// ErrInvalidChart is returned for a chart link that doesn't describe a chart
+var ErrInvalidChart = errors.New("invalid chart")
+
+// Chart is what a chart image shows. It carries no labels, so a chart link
+// gives nothing away but amounts; the legend goes in the text beside it.
+type Chart struct {
+	Type     string
+	Values   []float64
+	Previous []float64 // Bar charts: the earlier amounts drawn beside Values
+}
+
+// Path is the chart's path on the chart endpoint, e.g.
+// "/charts/pie.png?v=1230,450,300". The image is drawn from the path alone,
+// so it needs no storage and looks the same whenever it is fetched.
+func (c *Chart) Path() string {
+	query := url.Values{"v": {formatChartValues(c.Values)}}
+	if len(c.Previous) > 0 {
+		query.Set("p", formatChartValues(c.Previous))
+	}
+	// Commas are fine in a query, and the short form keeps links readable
+	return "/charts/" + c.Type + ".png?" + strings.ReplaceAll(query.Encode(), "%2C", ",")
+}
+
+// ParseChart reads a chart back from the file name and query of its Path
+func ParseChart(name string, query url.Values) (*Chart, error) {
+	chart := &Chart{Type: strings.TrimSuffix(name, ".png")}
+	if chart.Type != ChartPie && chart.Type != ChartBar {
+		return nil, ErrInvalidChart
+	}
+	var err error
+	if chart.Values, err = parseChartValues(query.Get("v")); err != nil {
+		return nil, err
+	}
+	if p := query.Get("p"); p != "" {
+		if chart.Type != ChartBar {
+			return nil, ErrInvalidChart
+		}
+		if chart.Previous, err = parseChartValues(p); err != nil {
+			return nil, err
+		}
+		if len(chart.Previous) != len(chart.Values) {
+			return nil, ErrInvalidChart
+		}
+	}
+	return chart, nil
+}
+
+// formatChartValues joins values rounded to cents
+func formatChartValues(values []float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseChartValues reads between one and MaxChartValues non-negative amounts
+func parseChartValues(s string) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if s == "" || len(parts) > MaxChartValues {
+		return nil, ErrInvalidChart
+	}
+	values := make([]float64, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, ErrInvalidChart
+		}
+		values[i] = v
+	}
+	return values, nil
+}
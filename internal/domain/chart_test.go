@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChartPath(t *testing.T) {
+	chart := &Chart{Type: ChartBar, Values: []float64{1230.456, 450, 0}, Previous: []float64{1000, 500, 20}}
+	path := chart.Path()
+	if want := "/charts/bar.png?p=1000,500,20&v=1230.46,450,0"; path != want {
+		t.Fatalf("expected %q, got %q", want, path)
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseChart(strings.TrimPrefix(u.Path, "/charts/"), u.Query())
+	if err != nil {
+		t.Fatalf("ParseChart() error = %v", err)
+	}
+	want := &Chart{Type: ChartBar, Values: []float64{1230.46, 450, 0}, Previous: []float64{1000, 500, 20}}
+	if !reflect.DeepEqual(parsed, want) {
+		t.Errorf("expected %+v, got %+v", want, parsed)
+	}
+}
+
+func TestParseChartInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"line.png", "v=1,2"},
+		{"pie.png", ""},
+		{"pie.png", "v=1,-2"},
+		{"pie.png", "v=1,x"},
+		{"pie.png", "v=1,NaN"},
+		{"pie.png", "v=1,2,3,4,5,6,7,8,9"},
+		{"pie.png", "v=1,2&p=1,2"},
+		{"bar.png", "v=1,2&p=1"},
+	}
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		if _, err := ParseChart(tt.name, query); err != ErrInvalidChart {
+			t.Errorf("ParseChart(%q, %q) error = %v, want ErrInvalidChart", tt.name, tt.query, err)
+		}
+	}
+}
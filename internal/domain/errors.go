@@ -0,0 +1,14 @@
+package domain
+
+import "errors"
+
+// Repositories return these sentinels, possibly wrapped, so callers can tell
+// outcomes apart with errors.Is whatever the storage backend
+var (
+	// ErrNotFound means no row matched a single-row lookup. Its message reads
+	// naturally after a noun: fmt.Errorf("expense %w", ErrNotFound).
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict means a write clashed with a unique key, such as a duplicate ID or name
+	ErrConflict = errors.New("already exists")
+)
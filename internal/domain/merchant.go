@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Merchant is a store, restaurant or service a user pays, shared by every
+// spelling of its name that normalizes the same, e.g. "7-11" and "7-Eleven 信義店"
+type Merchant struct {
+	ID             string    `json:"id" db:"id"`
+	UserID         string    `json:"user_id" db:"user_id"`
+	Name           string    `json:"name" db:"name"`         // As first written, or the chain's usual name
+	NormalizedName string    `json:"-" db:"normalized_name"` // From NormalizeMerchantName
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// MerchantTotal sums the selected expenses paid at one merchant in home currency
+type MerchantTotal struct {
+	MerchantID string
+	Name       string
+	Total      float64
+	Count      int
+}
+
+// merchantBranch matches a branch written after the name, e.g. "信義店",
+// "南京門市" or "(台北車站店)"
+var merchantBranch = regexp.MustCompile(`(\s+\S*(店|門市)|\s*[(（][^)）]*[)）])$`)
+
+// merchantChains are the usual names of chains written several ways, by
+// normalized spelling
+var merchantChains = map[string]string{
+	"711":         "7-ELEVEN",
+	"7eleven":     "7-ELEVEN",
+	"seveneleven": "7-ELEVEN",
+	"統一超商":        "7-ELEVEN",
+	"小七":          "7-ELEVEN",
+	"全家":          "全家便利商店",
+	"familymart":  "全家便利商店",
+	"全聯":          "全聯福利中心",
+	"pxmart":      "全聯福利中心",
+	"星巴克":         "Starbucks",
+	"starbucks":   "Starbucks",
+	"麥當勞":         "麥當勞",
+	"mcdonalds":   "麥當勞",
+	"好市多":         "Costco",
+	"costco":      "Costco",
+}
+
+// NormalizeMerchantName returns the name a merchant is shown under and the
+// key it is stored under: full-width letters made half-width, a trailing
+// branch dropped and known chains given their usual name. The key is the
+// name lowercased with only its letters and digits. Both are "" when the
+// name has no letters or digits.
+func NormalizeMerchantName(name string) (display, key string) {
+	display = strings.Map(func(r rune) rune {
+		if r >= '！' && r <= '～' {
+			return r - '！' + '!'
+		}
+		return r
+	}, name)
+	display = strings.Join(strings.Fields(display), " ")
+	if trimmed := merchantBranch.ReplaceAllString(display, ""); trimmed != "" {
+		display = trimmed
+	}
+
+	key = merchantKey(display)
+	if chain, ok := merchantChains[key]; ok {
+		return chain, merchantKey(chain)
+	}
+	if key == "" {
+		return "", ""
+	}
+	return display, key
+}
+
+// merchantKey lowercases a name and keeps only its letters and digits
+func merchantKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
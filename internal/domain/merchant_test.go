@@ -0,0 +1,24 @@
+package domain
+
+import "testing"
+
+func TestNormalizeMerchantName(t *testing.T) {
+	tests := []struct {
+		name, display, key string
+	}{
+		{"7-11", "7-ELEVEN", "7eleven"},
+		{"7-Eleven 信義店", "7-ELEVEN", "7eleven"},
+		{"統一超商", "7-ELEVEN", "7eleven"},
+		{"ＳＴＡＲＢＵＣＫＳ", "Starbucks", "starbucks"},
+		{"  Din  Tai Fung (台北101店)", "Din Tai Fung", "dintaifung"},
+		{"鼎泰豐 南京門市", "鼎泰豐", "鼎泰豐"},
+		{"Uber", "Uber", "uber"},
+		{"---", "", ""},
+	}
+	for _, tt := range tests {
+		display, key := NormalizeMerchantName(tt.name)
+		if display != tt.display || key != tt.key {
+			t.Errorf("NormalizeMerchantName(%q) = %q, %q, want %q, %q", tt.name, display, key, tt.display, tt.key)
+		}
+	}
+}
@@ -1,18 +1,189 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
+
+// Attachment types supported on incoming messages
+const (
+	AttachmentTypeImage = "image"
+	AttachmentTypeAudio = "audio"
+)
 
 // UserMessage represents a normalized message from any messenger source
 type UserMessage struct {
-	UserID    string                 `json:"user_id"`
-	Content   string                 `json:"content"`
-	Source    string                 `json:"source"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
-	Timestamp time.Time              `json:"timestamp"`
+	UserID      string                 `json:"user_id"`
+	Content     string                 `json:"content"`
+	Source      string                 `json:"source"`
+	GroupChatID string                 `json:"group_chat_id,omitempty"` // Set when sent in a group chat
+	GroupName   string                 `json:"group_name,omitempty"`
+	Action      string                 `json:"action,omitempty"`   // Set for a quick action, e.g. MessageActionTodaySpending; Content holds its argument
+	Timezone    string                 `json:"timezone,omitempty"` // IANA timezone the messenger reports for the user, if any
+	Attachments []*Attachment          `json:"-"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Timestamp   time.Time              `json:"timestamp"`
+}
+
+// Quick actions a messenger button or command, such as a LINE rich menu area
+// or a Telegram slash command, sends instead of a typed message
+const (
+	MessageActionTodaySpending   = "today_spending"   // 今日支出
+	MessageActionMonthlyReport   = "monthly_report"   // 本月報表
+	MessageActionAddCategory     = "add_category"     // 新增分類, with the category name as the content
+	MessageActionBudgetStatus    = "budget_status"    // How the budgets stand this period
+	MessageActionListCategories  = "list_categories"  // The user's categories
+	MessageActionExport          = "export"           // A takeout of the user's data, linked once built
+	MessageActionDeleteExpense   = "delete_expense"   // With the expense ID as the content
+	MessageActionChangeCategory  = "change_category"  // With the expense ID, then the new category ID once picked
+	MessageActionOnboarding      = "onboarding"       // An answer to the onboarding wizard, with the choice as the content
+	MessageActionSetTimezone     = "set_timezone"     // With the IANA timezone as the content, e.g. Asia/Taipei
+	MessageActionSetLanguage     = "set_language"     // With the language as the content, e.g. English or ja
+	MessageActionCompareReport   = "compare_report"   // This month against last month, or against the same month last year when the content says 去年
+	MessageActionSubscriptions   = "subscriptions"    // The detected subscriptions, or with one's ID as the content, tracking it as a recurring expense
+	MessageActionSelectBudget    = "select_budget"    // The named budgets to pick from, or with a name as the content, the one replies follow
+	MessageActionClaims          = "claims"           // The outstanding reimbursement claims, or with "submit" as the content, marking the pending ones submitted
+	MessageActionSelectWorkspace = "select_workspace" // The workspaces to pick from, or with a name as the content, the one messages go into
+	MessageActionAddWorkspace    = "add_workspace"    // With the new workspace's name as the content, switching to it
+)
+
+// Attachment is media downloaded from a messenger platform alongside a message
+type Attachment struct {
+	Type     string // e.g. AttachmentTypeImage
+	MimeType string // e.g. "image/jpeg"
+	Data     []byte
+}
+
+// FirstAttachment returns the first attachment of the given type, or nil
+func (m *UserMessage) FirstAttachment(attachmentType string) *Attachment {
+	for _, a := range m.Attachments {
+		if a != nil && a.Type == attachmentType {
+			return a
+		}
+	}
+	return nil
 }
 
 // MessageResponse represents a standard response to be sent back to the user
 type MessageResponse struct {
-	Text string      `json:"text"`
-	Data interface{} `json:"data,omitempty"`
+	Text    string           `json:"text"`
+	Data    interface{}      `json:"data,omitempty"`
+	Buttons []*MessageButton `json:"buttons,omitempty"` // Quick replies, shown by messengers that support buttons; Text stands alone without them
+	Blocks  []*ContentBlock  `json:"blocks,omitempty"`  // Rich layout shown in place of Text by messengers that can render it
+}
+
+// Content block types
+const (
+	ContentBlockCard  = "card"
+	ContentBlockTable = "table"
+	ContentBlockChart = "chart"
+)
+
+// ContentBlock is a piece of a reply's rich layout, which each messenger
+// renders its own way: a card with a title, text and labeled fields, a table,
+// or a chart image with its legend
+type ContentBlock struct {
+	Type     string          `json:"type"`
+	Title    string          `json:"title,omitempty"`
+	Text     string          `json:"text,omitempty"`      // Card, or a chart's legend
+	Fields   []*ContentField `json:"fields,omitempty"`    // Card
+	Columns  []string        `json:"columns,omitempty"`   // Table header
+	Rows     [][]string      `json:"rows,omitempty"`      // Table
+	ImageURL string          `json:"image_url,omitempty"` // Chart
+}
+
+// ContentField is a labeled value on a card, e.g. "Spent: 1200"
+type ContentField struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// MessageButton is a button under a reply. Pressing it either sends Action
+// back as a quick action with Value as its content, or opens URL.
+type MessageButton struct {
+	Label  string `json:"label"`
+	Action string `json:"action,omitempty"`
+	Value  string `json:"value,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+// PushNotifier sends unsolicited messages to a user on one messenger platform,
+// outside of the reply to an incoming message
+type PushNotifier interface {
+	PushMessage(ctx context.Context, userID, text string) error
+}
+
+// LoginVerifier checks the payload a messenger's web login (such as LINE Login
+// or the Telegram Login widget) hands to the dashboard, returning the ID of the
+// messenger user who signed in
+type LoginVerifier interface {
+	VerifyLogin(ctx context.Context, payload map[string]string) (string, error)
+}
+
+// EventDeduplicator lets webhook handlers skip events a messenger delivers more than once
+type EventDeduplicator interface {
+	// Seen reports whether the event was already handled, recording it if not
+	Seen(ctx context.Context, messenger, eventID string) bool
+}
+
+// MessageQueue lets webhook handlers acknowledge a delivery at once and leave
+// parsing the message to background workers
+type MessageQueue interface {
+	// Enqueue hands the message to a worker, failing when the queue is full
+	Enqueue(ctx context.Context, msg *UserMessage) error
+}
+
+// MessageReplier delivers the response to a queued message once a worker has
+// processed it, through the messenger the message came from
+type MessageReplier interface {
+	Reply(ctx context.Context, msg *UserMessage, resp *MessageResponse) error
+}
+
+// ParseProgress reports partial results while a message is still being parsed
+type ParseProgress struct {
+	Expenses []*ParsedExpense // Expenses fully parsed so far
+	Done     bool
+}
+
+// ProgressFunc receives parse progress updates, e.g. to keep a "typing" indicator alive
+type ProgressFunc func(progress *ParseProgress)
+
+type progressKey struct{}
+
+// WithProgress returns a context that carries a parse progress callback
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ProgressFromContext returns the parse progress callback carried by ctx, or nil
+func ProgressFromContext(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
+
+// SpendingSummary is a user's month-to-date spending at a glance, as shown on
+// a messenger's home screen such as the Slack App Home
+type SpendingSummary struct {
+	Period        string // e.g. "2025-03"
+	Total         float64
+	ExpenseCount  int
+	TopCategories []CategorySpending // Largest first
+	Budgets       []BudgetSpending
+}
+
+// CategorySpending is the spending in one category of a SpendingSummary
+type CategorySpending struct {
+	Category   string
+	Total      float64
+	Percentage float64
+}
+
+// BudgetSpending is how one budget of a SpendingSummary stands
+type BudgetSpending struct {
+	Category string
+	Period   string
+	Limit    float64
+	Spent    float64
+	Alert    bool // Past the budget's alert threshold
+	Exceeded bool
 }
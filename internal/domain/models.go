@@ -2,34 +2,358 @@ package domain
 
 import (
 	"context"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 )
 
 // User represents a user in the system
 type User struct {
-	UserID        string    `db:"user_id"`
-	MessengerType string    `db:"messenger_type"`
-	CreatedAt     time.Time `db:"created_at"`
-	HomeCurrency  string    `db:"home_currency"`
-	Locale        string    `db:"locale"`
+	UserID          string     `db:"user_id"`
+	MessengerType   string     `db:"messenger_type"`
+	CreatedAt       time.Time  `db:"created_at"`
+	HomeCurrency    string     `db:"home_currency"`
+	Locale          string     `db:"locale"`
+	OnboardedAt     *time.Time `db:"onboarded_at"`     // Set once the onboarding wizard is finished or skipped
+	Timezone        string     `db:"timezone"`         // IANA name, e.g. "Asia/Taipei"; empty for the server's
+	ActiveBudget    string     `db:"active_budget"`    // The named budget chat replies report on; empty for the everyday one
+	ActiveWorkspace string     `db:"active_workspace"` // The workspace chat messages go into and report on; empty for the personal one
+}
+
+// Location returns the user's timezone, or the server's when it is unset or unknown
+func (u *User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+type locationKey struct{}
+
+// WithLocation returns a context that carries the user's timezone, which
+// "today", relative dates and report periods are resolved in
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// LocationFromContext returns the timezone carried by ctx, or the server's
+func LocationFromContext(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok && loc != nil {
+		return loc
+	}
+	return time.Local
+}
+
+// InLocation returns t in the timezone carried by ctx, or t as is when ctx
+// carries none
+func InLocation(ctx context.Context, t time.Time) time.Time {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok && loc != nil {
+		return t.In(loc)
+	}
+	return t
+}
+
+// LocalNow returns the current time in the timezone carried by ctx
+func LocalNow(ctx context.Context) time.Time {
+	return InLocation(ctx, time.Now())
 }
 
 // Expense represents a single expense record
 type Expense struct {
-	ID             string    `db:"id"`
-	UserID         string    `db:"user_id"`
-	Description    string    `db:"description"`
-	OriginalAmount float64   `db:"original_amount"`
-	Currency       string    `db:"currency"`
-	HomeAmount     float64   `db:"home_amount"`
-	HomeCurrency   string    `db:"home_currency"`
-	ExchangeRate   float64   `db:"exchange_rate"`
-	CategoryID     *string   `db:"category_id"`
-	Account        string    `db:"account"` // Default 'Cash' / specific account name
-	ExpenseDate    time.Time `db:"expense_date"`
-	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"`
-	Amount         float64   `db:"-"` // Deprecated: kept for backward compatibility until callers migrate to HomeAmount
+	ID             string     `db:"id"`
+	UserID         string     `db:"user_id"`
+	Description    string     `db:"description"`
+	OriginalAmount float64    `db:"original_amount"`
+	Currency       string     `db:"currency"`
+	HomeAmount     float64    `db:"home_amount"`
+	HomeCurrency   string     `db:"home_currency"`
+	ExchangeRate   float64    `db:"exchange_rate"`
+	CategoryID     *string    `db:"category_id"`
+	GroupID        *string    `db:"group_id"`        // Set when recorded into a shared group ledger
+	Account        string     `db:"account"`         // Default 'Cash' / specific account name
+	MerchantID     *string    `db:"merchant_id"`     // The store it was paid at, when known
+	Reimbursable   bool       `db:"is_reimbursable"` // Paid for an employer, to be claimed back
+	ClaimStatus    string     `db:"claim_status"`    // A ClaimStatus of a reimbursable expense, empty otherwise
+	WorkspaceID    string     `db:"workspace_id"`    // Empty for the personal workspace
+	ExpenseDate    time.Time  `db:"expense_date"`
+	CreatedAt      time.Time  `db:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at"`
+	DeletedAt      *time.Time `db:"deleted_at"` // Set once soft-deleted; only loaded by GetDeletedByID
+	Amount         float64    `db:"-"`          // Deprecated: kept for backward compatibility until callers migrate to HomeAmount
+
+	Splits        []*ExpenseSplit `db:"-"` // Per-participant shares; loaded separately, empty unless the bill was split
+	AttachmentIDs []string        `db:"-"` // Stored files such as the receipt photo; loaded separately
+	Tags          []string        `db:"-"` // Tag names, e.g. "出差"; loaded separately
+	Items         []*ExpenseItem  `db:"-"` // Receipt line items; loaded separately, empty unless read from a receipt
+}
+
+// IsSplit reports whether the expense has been split between participants
+func (e *Expense) IsSplit() bool {
+	return len(e.Splits) > 0
+}
+
+// Expense listing sort keys
+const (
+	ExpenseSortDate      = "date"
+	ExpenseSortAmount    = "amount"
+	ExpenseSortCreatedAt = "created_at"
+)
+
+// Expense listing sort directions
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// ExpenseListOptions pages and orders an expense listing. AfterID is a keyset
+// cursor: only expenses after that one in the sort order are returned, which
+// stays stable while new expenses are recorded. Ties are broken by ID.
+type ExpenseListOptions struct {
+	Limit   int // 0 means no limit
+	Offset  int
+	SortBy  string
+	SortDir string
+	AfterID string
+}
+
+// ExpenseTotalsQuery selects the expenses an aggregate covers: a user's
+// expenses or, when GroupID is set, a shared group ledger's, dated From to To,
+// and only those paid at one merchant when MerchantID is set
+type ExpenseTotalsQuery struct {
+	UserID     string
+	GroupID    string
+	MerchantID string
+	From       time.Time
+	To         time.Time
+}
+
+// CategoryTotal sums the selected expenses of one category in home currency;
+// CategoryID is nil for uncategorized expenses
+type CategoryTotal struct {
+	CategoryID *string
+	Total      float64
+	Count      int
+	Highest    float64
+	Lowest     float64
+}
+
+// Periods expense totals can be grouped by
+const (
+	TotalsPeriodDay   = "day"
+	TotalsPeriodMonth = "month"
+)
+
+// PeriodTotal sums the selected expenses of the day or month starting at Start (UTC)
+type PeriodTotal struct {
+	Start time.Time
+	Total float64
+	Count int
+}
+
+// Matches reports whether the query selects the expense
+func (q ExpenseTotalsQuery) Matches(expense *Expense) bool {
+	if expense.DeletedAt != nil || expense.ExpenseDate.Before(q.From) || expense.ExpenseDate.After(q.To) {
+		return false
+	}
+	if q.MerchantID != "" && (expense.MerchantID == nil || *expense.MerchantID != q.MerchantID) {
+		return false
+	}
+	if q.GroupID != "" {
+		return expense.GroupID != nil && *expense.GroupID == q.GroupID
+	}
+	return expense.UserID == q.UserID
+}
+
+// ExpenseFilter selects a user's expenses by any combination of criteria;
+// unset criteria select everything. Lists match any of their values.
+type ExpenseFilter struct {
+	UserID        string
+	From          *time.Time // Expense date, inclusive
+	To            *time.Time // Expense date, inclusive
+	MinAmount     *float64   // Home amount, inclusive
+	MaxAmount     *float64   // Home amount, inclusive
+	CategoryIDs   []string
+	Currencies    []string // Currency the expense was paid in
+	HasAttachment *bool
+	Text          string   // Found anywhere in the description, ignoring case
+	Tags          []string // Normalized tag names the expense must all carry
+	Reimbursable  *bool
+	ClaimStatuses []string // Claim statuses of reimbursable expenses
+}
+
+// MatchesText reports whether a description contains the filter's text
+func (f *ExpenseFilter) MatchesText(description string) bool {
+	return strings.Contains(strings.ToLower(description), strings.ToLower(f.Text))
+}
+
+// Matches reports whether the filter selects the expense, the way
+// ExpenseRepository.Filter does, for in-memory ledgers. Attachments are
+// judged by AttachmentIDs and tags by Tags, so they must be loaded.
+func (f *ExpenseFilter) Matches(expense *Expense) bool {
+	if expense.UserID != f.UserID || expense.DeletedAt != nil {
+		return false
+	}
+	if (f.From != nil && expense.ExpenseDate.Before(*f.From)) || (f.To != nil && expense.ExpenseDate.After(*f.To)) {
+		return false
+	}
+	if (f.MinAmount != nil && expense.HomeAmount < *f.MinAmount) || (f.MaxAmount != nil && expense.HomeAmount > *f.MaxAmount) {
+		return false
+	}
+	if len(f.CategoryIDs) > 0 && (expense.CategoryID == nil || !slices.Contains(f.CategoryIDs, *expense.CategoryID)) {
+		return false
+	}
+	if len(f.Currencies) > 0 && !slices.Contains(f.Currencies, expense.Currency) {
+		return false
+	}
+	if f.HasAttachment != nil && *f.HasAttachment != (len(expense.AttachmentIDs) > 0) {
+		return false
+	}
+	if !expense.HasTags(f.Tags) {
+		return false
+	}
+	if f.Reimbursable != nil && *f.Reimbursable != expense.Reimbursable {
+		return false
+	}
+	if len(f.ClaimStatuses) > 0 && !slices.Contains(f.ClaimStatuses, expense.ClaimStatus) {
+		return false
+	}
+	return f.MatchesText(expense.Description)
+}
+
+// SumExpensesByCategory totals expenses per category the way
+// ExpenseRepository.SumByCategoryAndDateRange does, for in-memory ledgers
+func SumExpensesByCategory(expenses []*Expense) []*CategoryTotal {
+	var totals []*CategoryTotal
+	byCategory := make(map[string]*CategoryTotal)
+	for _, expense := range expenses {
+		key := "" // Uncategorized
+		if expense.CategoryID != nil {
+			key = *expense.CategoryID
+		}
+		total, ok := byCategory[key]
+		if !ok {
+			total = &CategoryTotal{CategoryID: expense.CategoryID, Highest: expense.Amount, Lowest: expense.Amount}
+			byCategory[key] = total
+			totals = append(totals, total)
+		}
+		total.Total += expense.Amount
+		total.Count++
+		total.Highest = max(total.Highest, expense.Amount)
+		total.Lowest = min(total.Lowest, expense.Amount)
+	}
+	return totals
+}
+
+// SumExpensesByPeriod totals expenses per UTC day or month, oldest first, the
+// way ExpenseRepository.SumByPeriodAndDateRange does, for in-memory ledgers
+func SumExpensesByPeriod(expenses []*Expense, period string) []*PeriodTotal {
+	byStart := make(map[time.Time]*PeriodTotal)
+	for _, expense := range expenses {
+		date := expense.ExpenseDate.UTC()
+		start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		if period == TotalsPeriodMonth {
+			start = start.AddDate(0, 0, 1-date.Day())
+		}
+		total, ok := byStart[start]
+		if !ok {
+			total = &PeriodTotal{Start: start}
+			byStart[start] = total
+		}
+		total.Total += expense.Amount
+		total.Count++
+	}
+
+	totals := make([]*PeriodTotal, 0, len(byStart))
+	for _, total := range byStart {
+		totals = append(totals, total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Start.Before(totals[j].Start) })
+	return totals
+}
+
+// ExpenseAttachment is a stored file, e.g. a receipt photo, linked to an expense.
+// The items of one receipt share a single stored file.
+type ExpenseAttachment struct {
+	ID         string    `db:"id" json:"id"`
+	ExpenseID  string    `db:"expense_id" json:"expense_id"`
+	UserID     string    `db:"user_id" json:"user_id"`
+	MimeType   string    `db:"mime_type" json:"mime_type"`
+	Size       int64     `db:"size_bytes" json:"size"`
+	StorageKey string    `db:"storage_key" json:"-"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// Archive is a snapshot of a user's expenses over a period. The expenses are
+// kept as a compressed bundle in object storage under ObjectKey; only the
+// metadata is stored in the database.
+type Archive struct {
+	ID             string     `db:"id" json:"id"`
+	UserID         string     `db:"user_id" json:"user_id"`
+	Period         string     `db:"period" json:"period"` // "monthly", "yearly", "custom"
+	StartDate      time.Time  `db:"start_date" json:"start_date"`
+	EndDate        time.Time  `db:"end_date" json:"end_date"`
+	ExpenseCount   int        `db:"expense_count" json:"expense_count"`
+	TotalAmount    float64    `db:"total_amount" json:"total_amount"` // In home currency
+	Format         string     `db:"format" json:"format"`             // ArchiveFormatJSONLinesGzip
+	ObjectKey      string     `db:"object_key" json:"-"`
+	Checksum       string     `db:"checksum" json:"checksum"` // SHA-256 of the stored bundle, hex
+	Size           int64      `db:"size_bytes" json:"size"`   // Uncompressed
+	CompressedSize int64      `db:"compressed_size_bytes" json:"compressed_size"`
+	RetentionDays  int        `db:"retention_days" json:"retention_days"` // 0 keeps it indefinitely
+	ExpiresAt      *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ArchiveFormatJSONLinesGzip is a gzip-compressed file with one JSON expense per line
+const ArchiveFormatJSONLinesGzip = "jsonl.gz"
+
+// ArchivePolicy is when a user's expenses are archived and their archives
+// purged automatically. Users without a policy of their own follow the global one.
+type ArchivePolicy struct {
+	UserID             string    `db:"user_id" json:"user_id,omitempty"`                 // Empty for the global policy
+	ArchiveAfterMonths int       `db:"archive_after_months" json:"archive_after_months"` // 0 never archives
+	PurgeAfterDays     int       `db:"purge_after_days" json:"purge_after_days"`         // 0 keeps archives
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// ExpenseSplit is one participant's share of a split expense, in home currency.
+// The payer is the user who recorded the expense; every other participant owes
+// the payer their share.
+type ExpenseSplit struct {
+	ID          string    `db:"id" json:"id"`
+	ExpenseID   string    `db:"expense_id" json:"expense_id"`
+	PayerID     string    `db:"payer_id" json:"payer_id"`
+	Participant string    `db:"participant" json:"participant"` // User ID, or a name for someone without an account
+	Amount      float64   `db:"share_amount" json:"amount"`
+	Percentage  float64   `db:"percentage" json:"percentage"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// Owes reports whether the participant owes the payer for this share
+func (s *ExpenseSplit) Owes() bool {
+	return s.Participant != s.PayerID
+}
+
+// ExpenseItem is one line of the receipt an expense was read from, priced in
+// the expense's original currency
+type ExpenseItem struct {
+	ID        string    `db:"id" json:"id"`
+	ExpenseID string    `db:"expense_id" json:"expense_id"`
+	Name      string    `db:"name" json:"name"`
+	Quantity  float64   `db:"quantity" json:"quantity"`
+	UnitPrice float64   `db:"unit_price" json:"unit_price"`
+	Position  int       `db:"position" json:"position"` // Order on the receipt, from 0
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Total returns the line total, quantity times unit price
+func (i *ExpenseItem) Total() float64 {
+	return i.Quantity * i.UnitPrice
 }
 
 // Currency represents a supported currency definition
@@ -62,13 +386,88 @@ type ExchangeRate struct {
 	FetchedAt      time.Time `db:"fetched_at"`
 }
 
+// Group member roles
+const (
+	GroupRoleOwner  = "owner"
+	GroupRoleMember = "member"
+)
+
+// Group is a shared ledger, e.g. a household, bound to one group chat on a messenger
+type Group struct {
+	ID            string    `db:"id" json:"id"`
+	Name          string    `db:"name" json:"name"`
+	MessengerType string    `db:"messenger_type" json:"messenger_type"`
+	ExternalID    string    `db:"external_id" json:"external_id"` // Messenger chat ID, e.g. LINE groupId
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+}
+
+// GroupMember links a user to a group
+type GroupMember struct {
+	GroupID  string    `db:"group_id" json:"group_id"`
+	UserID   string    `db:"user_id" json:"user_id"`
+	Role     string    `db:"role" json:"role"`
+	JoinedAt time.Time `db:"joined_at" json:"joined_at"`
+}
+
 // Category represents an expense category
 type Category struct {
-	ID        string    `db:"id"`
-	UserID    string    `db:"user_id"`
-	Name      string    `db:"name"`
-	IsDefault bool      `db:"is_default"`
-	CreatedAt time.Time `db:"created_at"`
+	ID          string    `db:"id"`
+	UserID      string    `db:"user_id"`
+	Name        string    `db:"name"`
+	IsDefault   bool      `db:"is_default"`
+	WorkspaceID string    `db:"workspace_id"` // Empty for the personal workspace
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// Budget periods
+const (
+	BudgetPeriodMonthly = "monthly"
+	BudgetPeriodWeekly  = "weekly"
+	BudgetPeriodCustom  = "custom" // Once, from StartDate to EndDate, e.g. a trip
+)
+
+// Budget is a spending limit over a recurring period, or over a date range.
+// A nil CategoryID means the limit covers all of the user's spending.
+// Budgets with the same Name make up a named budget, like 日本旅行 with an
+// overall limit and one for food; the unnamed ones are the everyday budget.
+type Budget struct {
+	ID          string     `db:"id" json:"id"`
+	UserID      string     `db:"user_id" json:"user_id"`
+	CategoryID  *string    `db:"category_id" json:"category_id,omitempty"`
+	GroupID     *string    `db:"group_id" json:"group_id,omitempty"` // Set for a shared group ledger budget
+	Limit       float64    `db:"limit_amount" json:"limit"`
+	Period      string     `db:"period" json:"period"`       // BudgetPeriodMonthly, BudgetPeriodWeekly or BudgetPeriodCustom
+	Threshold   float64    `db:"threshold" json:"threshold"` // Alert percentage, e.g. 80
+	Name        string     `db:"name" json:"name,omitempty"`
+	StartDate   *time.Time `db:"start_date" json:"start_date,omitempty"`     // The first day of a custom period
+	EndDate     *time.Time `db:"end_date" json:"end_date,omitempty"`         // The last day of a custom period
+	WorkspaceID string     `db:"workspace_id" json:"workspace_id,omitempty"` // Empty for the personal workspace
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// PeriodRange returns the start and end of the budget period containing t,
+// or of a custom period whether or not it contains t. Weekly periods start
+// on Monday.
+func (b *Budget) PeriodRange(t time.Time) (time.Time, time.Time) {
+	if b.Period == BudgetPeriodCustom && b.StartDate != nil && b.EndDate != nil {
+		start := time.Date(b.StartDate.Year(), b.StartDate.Month(), b.StartDate.Day(), 0, 0, 0, 0, t.Location())
+		end := time.Date(b.EndDate.Year(), b.EndDate.Month(), b.EndDate.Day(), 0, 0, 0, 0, t.Location())
+		return start, end.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if b.Period == BudgetPeriodWeekly {
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 7).Add(-time.Nanosecond)
+	}
+	start := day.AddDate(0, 0, 1-day.Day())
+	return start, start.AddDate(0, 1, 0).Add(-time.Nanosecond)
+}
+
+// IsOverall reports whether the budget covers all categories
+func (b *Budget) IsOverall() bool {
+	return b.CategoryID == nil || *b.CategoryID == ""
 }
 
 // CategoryKeyword maps keywords to categories
@@ -80,6 +479,70 @@ type CategoryKeyword struct {
 	CreatedAt  time.Time `db:"created_at"`
 }
 
+// CategoryCorrection records a user moving an expense to another category, used
+// to learn the user's keywords and as few-shot examples for the AI
+type CategoryCorrection struct {
+	ID             string    `db:"id" json:"id"`
+	UserID         string    `db:"user_id" json:"user_id"`
+	ExpenseID      string    `db:"expense_id" json:"expense_id"`
+	Description    string    `db:"description" json:"description"`
+	FromCategoryID *string   `db:"from_category_id" json:"from_category_id,omitempty"`
+	ToCategoryID   string    `db:"to_category_id" json:"to_category_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+// Expense audit actions
+const (
+	ExpenseAuditCreate  = "create"
+	ExpenseAuditUpdate  = "update"
+	ExpenseAuditDelete  = "delete"
+	ExpenseAuditRestore = "restore"
+)
+
+// Audit channels for changes that don't come from a messenger
+const (
+	AuditChannelAPI    = "api"
+	AuditChannelImport = "import" // Bank statement import
+	AuditChannelSystem = "system"
+)
+
+// DeletedUserActor replaces the actor on audit entries left by a user whose
+// data was purged
+const DeletedUserActor = "deleted_user"
+
+// ExpenseAuditEntry records one change to an expense, with snapshots of the
+// expense before and after it. Before is nil for a create.
+type ExpenseAuditEntry struct {
+	ID        string    `db:"id" json:"id"`
+	ExpenseID string    `db:"expense_id" json:"expense_id"`
+	UserID    string    `db:"user_id" json:"user_id"` // Owner of the expense
+	Action    string    `db:"action" json:"action"`
+	Actor     string    `db:"actor" json:"actor"`     // Who made the change
+	Channel   string    `db:"channel" json:"channel"` // Messenger type, "api" or "system"
+	Before    *Expense  `db:"before_snapshot" json:"before,omitempty"`
+	After     *Expense  `db:"after_snapshot" json:"after,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// AuditSource identifies who is changing data and through which channel
+type AuditSource struct {
+	Actor   string
+	Channel string
+}
+
+type auditSourceKey struct{}
+
+// WithAuditSource returns a context that carries the source of the changes made with it
+func WithAuditSource(ctx context.Context, source AuditSource) context.Context {
+	return context.WithValue(ctx, auditSourceKey{}, source)
+}
+
+// AuditSourceFromContext returns the audit source carried by ctx, or the zero value
+func AuditSourceFromContext(ctx context.Context) AuditSource {
+	source, _ := ctx.Value(auditSourceKey{}).(AuditSource)
+	return source
+}
+
 // ParsedExpense represents an expense extracted from conversation
 type ParsedExpense struct {
 	Description       string
@@ -88,6 +551,9 @@ type ParsedExpense struct {
 	CurrencyOriginal  string
 	SuggestedCategory string
 	Account           string
+	Merchant          string         // Store or service paid, as written
+	Tags              []string       // From the message's hashtags
+	Items             []*ExpenseItem // Receipt lines the amount adds up, when read from a receipt
 
 	Date time.Time
 }
@@ -95,6 +561,7 @@ type ParsedExpense struct {
 // ParseResult represents the result of parsing a conversation
 type ParseResult struct {
 	Expenses     []*ParsedExpense
+	Merchant     string // Set when expenses were read from a receipt
 	SystemPrompt string
 	RawResponse  string
 }
@@ -108,6 +575,51 @@ type DailyMetrics struct {
 	AverageExpense float64
 }
 
+// MetricsRollup is the precomputed activity, spending and AI cost of one UTC day
+type MetricsRollup struct {
+	Date           time.Time
+	ActiveUsers    int // Users who recorded an expense or made an AI call
+	NewUsers       int
+	TotalExpense   float64
+	ExpenseCount   int
+	AICalls        int
+	AIInputTokens  int
+	AIOutputTokens int
+	AITotalTokens  int
+	AICost         float64
+	UpdatedAt      time.Time
+}
+
+// MetricsDay returns the start of the UTC day containing t
+func MetricsDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// MetricsWeek returns the start of the UTC week, a Monday, containing t
+func MetricsWeek(t time.Time) time.Time {
+	day := MetricsDay(t)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// PlatformMetrics represents activity and AI cost on one messenger platform
+type PlatformMetrics struct {
+	Platform    string  `json:"platform"` // Messenger type, e.g. "line", or "api"
+	Signups     int     `json:"signups"`
+	ActiveUsers int     `json:"active_users"` // Users who sent a message on the platform
+	Messages    int     `json:"messages"`
+	AICalls     int     `json:"ai_calls"`
+	AICost      float64 `json:"ai_cost"`
+}
+
+// UserActivity is when a user signed up and the UTC days they were active on,
+// recording an expense or making an AI call
+type UserActivity struct {
+	UserID     string
+	SignedUpAt time.Time
+	ActiveDays []time.Time
+}
+
 // CategoryMetrics represents metrics for a category
 type CategoryMetrics struct {
 	CategoryID string
@@ -119,18 +631,20 @@ type CategoryMetrics struct {
 
 // AICostLog represents a record of AI API usage and cost
 type AICostLog struct {
-	ID           string    `db:"id"`
-	UserID       string    `db:"user_id"`
-	Operation    string    `db:"operation"` // e.g., "parse_expense", "suggest_category"
-	Provider     string    `db:"provider"`  // e.g., "gemini", "openai"
-	Model        string    `db:"model"`     // e.g., "gemini-2.5-lite"
-	InputTokens  int       `db:"input_tokens"`
-	OutputTokens int       `db:"output_tokens"`
-	TotalTokens  int       `db:"total_tokens"`
-	Cost         float64   `db:"cost"`
-	Currency     string    `db:"currency"`  // e.g., "USD"
-	CostNote     *string   `db:"cost_note"` // Optional: reason for special cost (e.g., "pricing_not_configured")
-	CreatedAt    time.Time `db:"created_at"`
+	ID            string    `db:"id"`
+	UserID        string    `db:"user_id"`
+	Operation     string    `db:"operation"` // e.g., "parse_expense", "suggest_category"
+	Provider      string    `db:"provider"`  // e.g., "gemini", "openai"
+	Model         string    `db:"model"`     // e.g., "gemini-2.5-lite"
+	InputTokens   int       `db:"input_tokens"`
+	OutputTokens  int       `db:"output_tokens"`
+	TotalTokens   int       `db:"total_tokens"`
+	Cost          float64   `db:"cost"`
+	Currency      string    `db:"currency"`       // e.g., "USD"
+	CostNote      *string   `db:"cost_note"`      // Optional: reason for special cost (e.g., "pricing_not_configured")
+	PromptVersion int       `db:"prompt_version"` // Prompt template version used; 0 is the built-in prompt
+	Source        string    `db:"source"`         // Messenger type or audit channel the call was made for; empty when unknown
+	CreatedAt     time.Time `db:"created_at"`
 }
 
 // GetCost calculates the cost based on token usage and this pricing configuration
@@ -211,6 +725,8 @@ type PricingConfig struct {
 type InteractionLog struct {
 	ID            string    `db:"id" json:"id"`
 	UserID        string    `db:"user_id" json:"user_id"`
+	Source        string    `db:"source" json:"source"` // Messenger type the message came from
+	Intent        string    `db:"intent" json:"intent"` // How the message was handled, e.g. InteractionIntentExpense
 	UserInput     string    `db:"user_input" json:"user_input"`
 	SystemPrompt  string    `db:"system_prompt" json:"system_prompt"`
 	AIRawResponse string    `db:"ai_raw_response" json:"ai_raw_response"`
@@ -220,6 +736,417 @@ type InteractionLog struct {
 	Timestamp     time.Time `db:"timestamp" json:"timestamp"`
 }
 
+// Interaction intents, i.e. how an inbound message was handled
+const (
+	InteractionIntentExpense              = "expense"
+	InteractionIntentReceipt              = "receipt"
+	InteractionIntentCategoryConfirmation = "category_confirmation"
+	InteractionIntentSettlement           = "settlement"
+	InteractionIntentUndo                 = "undo"
+	InteractionIntentEdit                 = "edit"
+	InteractionIntentQuery                = "query"
+	InteractionIntentReport               = "report"
+	InteractionIntentAddCategory          = "add_category"
+	InteractionIntentBudget               = "budget"
+	InteractionIntentCategories           = "categories"
+	InteractionIntentExport               = "export"
+	InteractionIntentDelete               = "delete"
+	InteractionIntentChangeCategory       = "change_category"
+	InteractionIntentCancel               = "cancel"
+	InteractionIntentOnboarding           = "onboarding"
+	InteractionIntentTimezone             = "timezone"
+	InteractionIntentLanguage             = "language"
+	InteractionIntentSubscriptions        = "subscriptions"
+	InteractionIntentClaims               = "claims"
+	InteractionIntentWorkspace            = "workspace"
+)
+
+// InteractionLogFilter selects interaction log entries; zero fields match everything
+type InteractionLogFilter struct {
+	UserID string
+	Source string
+	Intent string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// ProcessedEvent records a messenger webhook event that has been handled, so
+// platform retries and redeliveries of the same event are ignored
+type ProcessedEvent struct {
+	Messenger   string    `db:"messenger" json:"messenger"`
+	EventID     string    `db:"event_id" json:"event_id"`
+	ProcessedAt time.Time `db:"processed_at" json:"processed_at"`
+}
+
+// Prompt template names, one per AI operation
+const (
+	PromptParseExpense    = "parse_expense"
+	PromptSuggestCategory = "suggest_category"
+	PromptParseReceipt    = "parse_receipt"
+	PromptTranscribe      = "transcribe_audio"
+)
+
+// PromptNames lists the prompts that can be managed at runtime
+var PromptNames = []string{PromptParseExpense, PromptSuggestCategory, PromptParseReceipt, PromptTranscribe}
+
+// PromptTemplate is one version of an AI prompt, written as a Go text/template.
+// At most one version per name is active; without one the built-in prompt (version 0) is used.
+type PromptTemplate struct {
+	ID        string    `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Version   int       `db:"version" json:"version"`
+	Template  string    `db:"template" json:"template"`
+	Active    bool      `db:"active" json:"active"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Report schedule frequencies
+const (
+	ReportFrequencyWeekly  = "weekly"
+	ReportFrequencyMonthly = "monthly"
+)
+
+// Emailed report formats
+const (
+	ReportFormatHTML = "html" // Summary in the email body
+	ReportFormatPDF  = "pdf"  // Summary plus the PDF statement attached
+)
+
+// ReportSchedule is a user's opt-in to spending summaries emailed after each
+// week (Monday to Sunday) or calendar month
+type ReportSchedule struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     string     `db:"user_id" json:"user_id"`
+	Email      string     `db:"email" json:"email"`
+	Frequency  string     `db:"frequency" json:"frequency"`
+	Format     string     `db:"format" json:"format"`
+	Enabled    bool       `db:"enabled" json:"enabled"`
+	NextRunAt  time.Time  `db:"next_run_at" json:"next_run_at"`
+	LastSentAt *time.Time `db:"last_sent_at" json:"last_sent_at,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// EmailAddress is an email address bound to a user: receipts forwarded from it
+// to the inbound address are recorded as the user's expenses. An address is
+// bound once the user enters the code emailed to it; until then it holds the
+// code's hash.
+type EmailAddress struct {
+	Address       string     `db:"address" json:"address"` // Lowercased
+	UserID        string     `db:"user_id" json:"user_id"`
+	CodeHash      string     `db:"code_hash" json:"-"`
+	CodeExpiresAt *time.Time `db:"code_expires_at" json:"-"`
+	Attempts      int        `db:"attempts" json:"-"` // Wrong codes entered since the code was sent
+	VerifiedAt    *time.Time `db:"verified_at" json:"verified_at,omitempty"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+}
+
+// ConversationState is a clarification question the bot is waiting on a user
+// to answer, e.g. 哪個分類？ after 新增分類 without a name: the user's next
+// message fills the Awaiting slot of the pending intent, until it expires.
+type ConversationState struct {
+	UserID    string            `db:"user_id" json:"user_id"`
+	Intent    string            `db:"intent" json:"intent"`         // The pending quick action, e.g. MessageActionAddCategory
+	Awaiting  string            `db:"awaiting" json:"awaiting"`     // The slot the next message fills, e.g. "name"
+	Slots     map[string]string `db:"slots" json:"slots,omitempty"` // Slots filled so far, e.g. the expense ID
+	ExpiresAt time.Time         `db:"expires_at" json:"expires_at"`
+	UpdatedAt time.Time         `db:"updated_at" json:"updated_at"`
+}
+
+// Notifications users opt into, as NotificationPreferences names them
+const (
+	NotificationDailyDigest       = "daily_digest"       // Yesterday's spending, pushed each morning
+	NotificationWeeklyReport      = "weekly_report"      // Last week compared with the week before, pushed each Monday
+	NotificationExpenseReminders  = "expense_reminders"  // A nudge after days without a recorded expense
+	NotificationBudgetAlerts      = "budget_alerts"      // A budget crossing its alert threshold or limit
+	NotificationSpendingAnomalies = "spending_anomalies" // An expense far above the category's usual, or a day with far more expenses than usual
+)
+
+// Channels a notification can go through, as NotificationPreferences.Channels names them
+const (
+	NotificationChannelPush  = "push"   // A message to the user's messenger, plus the in-app notification
+	NotificationChannelInApp = "in_app" // Only the in-app notification
+	NotificationChannelEmail = "email"  // An email to the user's verified address, plus the in-app notification
+)
+
+// NotificationPreferences are the notifications a user opted into, and when
+// the scheduled ones were last pushed to their messenger. Users who never
+// changed theirs and were never pushed a scheduled notification have the
+// defaults and no stored preferences.
+type NotificationPreferences struct {
+	UserID                string            `db:"user_id" json:"user_id"`
+	BudgetAlerts          bool              `db:"budget_alerts" json:"budget_alerts"`
+	RecurringReminders    bool              `db:"recurring_reminders" json:"recurring_reminders"`
+	ReportNotifications   bool              `db:"report_notifications" json:"report_notifications"`
+	ExpenseReminders      bool              `db:"expense_reminders" json:"expense_reminders"`
+	DailyDigest           bool              `db:"daily_digest" json:"daily_digest"`
+	DailyDigestHour       int               `db:"daily_digest_hour" json:"daily_digest_hour"` // Hour of the day in the user's timezone, 0-23
+	WeeklyReport          bool              `db:"weekly_report" json:"weekly_report"`
+	ExpenseReminderDays   int               `db:"expense_reminder_days" json:"expense_reminder_days"` // Days without an expense before a reminder
+	QuietHoursStart       int               `db:"quiet_hours_start" json:"quiet_hours_start"`         // Hour reminders stop, 0-23
+	QuietHoursEnd         int               `db:"quiet_hours_end" json:"quiet_hours_end"`             // Hour reminders resume, 0-23; equal to the start for none
+	SpendingAnomalies     bool              `db:"spending_anomalies" json:"spending_anomalies"`
+	AnomalySensitivity    string            `db:"anomaly_sensitivity" json:"anomaly_sensitivity"` // How unusual spending must be for an alert: low, medium or high
+	Channels              map[string]string `db:"channels" json:"channels,omitempty"`             // Channel of each notification, keyed by notification; push when unset
+	LastDailyDigestAt     *time.Time        `db:"last_daily_digest_at" json:"last_daily_digest_at,omitempty"`
+	LastWeeklyReportAt    *time.Time        `db:"last_weekly_report_at" json:"last_weekly_report_at,omitempty"`
+	LastExpenseReminderAt *time.Time        `db:"last_expense_reminder_at" json:"last_expense_reminder_at,omitempty"`
+	LastAnomalyCheckAt    *time.Time        `db:"last_anomaly_check_at" json:"last_anomaly_check_at,omitempty"` // Expenses recorded since are checked next
+	UpdatedAt             time.Time         `db:"updated_at" json:"updated_at"`
+}
+
+// InQuietHours reports whether hour, in the user's timezone, is one reminders are held back in
+func (p *NotificationPreferences) InQuietHours(hour int) bool {
+	if p.QuietHoursStart <= p.QuietHoursEnd {
+		return hour >= p.QuietHoursStart && hour < p.QuietHoursEnd
+	}
+	return hour >= p.QuietHoursStart || hour < p.QuietHoursEnd
+}
+
+// Channel returns the channel the user picked for the notification
+func (p *NotificationPreferences) Channel(notification string) string {
+	if channel := p.Channels[notification]; channel != "" {
+		return channel
+	}
+	return NotificationChannelPush
+}
+
+// Enabled reports whether the user gets the notification at all
+func (p *NotificationPreferences) Enabled(notification string) bool {
+	switch notification {
+	case NotificationDailyDigest:
+		return p.DailyDigest
+	case NotificationWeeklyReport:
+		return p.WeeklyReport
+	case NotificationExpenseReminders:
+		return p.ExpenseReminders
+	case NotificationBudgetAlerts:
+		return p.BudgetAlerts
+	case NotificationSpendingAnomalies:
+		return p.SpendingAnomalies
+	}
+	return true
+}
+
+// Notification preference defaults
+const (
+	DefaultDailyDigestHour     = 8  // Hour daily digests are pushed unless the user picks another
+	DefaultExpenseReminderDays = 3  // Days without an expense before a reminder
+	DefaultQuietHoursStart     = 22 // Reminders wait out the night
+	DefaultQuietHoursEnd       = 8
+	DefaultAnomalySensitivity  = AnomalySensitivityMedium
+)
+
+// Anomaly sensitivities; the higher it is, the less unusual spending must be
+// for an alert
+const (
+	AnomalySensitivityLow    = "low"
+	AnomalySensitivityMedium = "medium"
+	AnomalySensitivityHigh   = "high"
+)
+
+// DefaultNotificationPreferences returns the preferences of a user who never changed them
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:              userID,
+		BudgetAlerts:        true,
+		RecurringReminders:  true,
+		ReportNotifications: true,
+		DailyDigestHour:     DefaultDailyDigestHour,
+		WeeklyReport:        true,
+		ExpenseReminderDays: DefaultExpenseReminderDays,
+		QuietHoursStart:     DefaultQuietHoursStart,
+		QuietHoursEnd:       DefaultQuietHoursEnd,
+		SpendingAnomalies:   true,
+		AnomalySensitivity:  DefaultAnomalySensitivity,
+	}
+}
+
+// Event types published on the in-process event bus as a user's data changes
+const (
+	EventUserSignedUp        = "user.signed_up"
+	EventExpenseCreated      = "expense.created"
+	EventExpenseUpdated      = "expense.updated"
+	EventExpenseDeleted      = "expense.deleted"
+	EventExpenseRestored     = "expense.restored"
+	EventBudgetExceeded      = "budget.exceeded"
+	EventNotificationCreated = "notification.created"
+
+	// Published for the admins rather than a user, with an empty user ID
+	EventAICostAnomalyDetected = "ai_cost.anomaly_detected"
+)
+
+// Webhook event types, the bus events forwarded to webhook subscriptions
+const (
+	WebhookEventExpenseCreated = EventExpenseCreated
+	WebhookEventBudgetExceeded = EventBudgetExceeded
+)
+
+// WebhookEvents lists the events users can subscribe to
+var WebhookEvents = []string{WebhookEventExpenseCreated, WebhookEventBudgetExceeded}
+
+// WebhookSubscription sends the user's events to a URL of their choosing.
+// Each delivery is signed with Secret so the receiver can verify it.
+type WebhookSubscription struct {
+	ID        string    `db:"id" json:"id"`
+	UserID    string    `db:"user_id" json:"user_id"`
+	URL       string    `db:"url" json:"url"`
+	Secret    string    `db:"secret" json:"secret,omitempty"` // Only returned when the subscription is created
+	Events    []string  `db:"events" json:"events"`
+	Enabled   bool      `db:"enabled" json:"enabled"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Subscribes reports whether the subscription wants event
+func (s *WebhookSubscription) Subscribes(event string) bool {
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"   // Waiting for its first attempt or a retry
+	WebhookDeliverySucceeded = "succeeded" // The receiver answered with a 2xx status
+	WebhookDeliveryFailed    = "failed"    // Every attempt failed; no more retries
+)
+
+// WebhookDelivery is one event sent to a subscription, kept as a delivery log
+type WebhookDelivery struct {
+	ID             string     `db:"id" json:"id"`
+	SubscriptionID string     `db:"subscription_id" json:"subscription_id"`
+	Event          string     `db:"event" json:"event"`
+	Payload        string     `db:"payload" json:"payload"` // JSON request body, identical on every attempt
+	Status         string     `db:"status" json:"status"`
+	Attempts       int        `db:"attempts" json:"attempts"`
+	ResponseStatus int        `db:"response_status" json:"response_status,omitempty"` // HTTP status of the last attempt
+	LastError      string     `db:"last_error" json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `db:"next_attempt_at" json:"next_attempt_at,omitempty"` // Set while pending
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	DeliveredAt    *time.Time `db:"delivered_at" json:"delivered_at,omitempty"`
+}
+
+// Reply outbox statuses
+const (
+	OutboxReplyPending   = "pending"   // Waiting for its first attempt or a retry
+	OutboxReplyDelivered = "delivered" // The messenger accepted the reply
+	OutboxReplyFailed    = "failed"    // Every attempt failed; no more retries
+)
+
+// OutboxReply is the reply to a messenger message, persisted before it is
+// sent so that a failed send or a crash doesn't lose the user's confirmation.
+// Pushed messages, which answer no message, go through the outbox too.
+type OutboxReply struct {
+	ID            string     `db:"id" json:"id"`
+	UserID        string     `db:"user_id" json:"user_id"`
+	Messenger     string     `db:"messenger" json:"messenger"`
+	GroupChatID   string     `db:"group_chat_id" json:"group_chat_id,omitempty"`
+	Metadata      string     `db:"metadata" json:"metadata"` // JSON metadata of the message, such as its reply token or chat ID
+	Push          bool       `db:"push" json:"push"`         // Sent with the messenger's notifier rather than as a reply
+	Text          string     `db:"text" json:"text"`
+	Status        string     `db:"status" json:"status"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	LastError     string     `db:"last_error" json:"last_error,omitempty"`
+	NextAttemptAt *time.Time `db:"next_attempt_at" json:"next_attempt_at,omitempty"` // Set while pending
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	DeliveredAt   *time.Time `db:"delivered_at" json:"delivered_at,omitempty"`
+}
+
+// API key scopes, each granting a group of admin endpoints
+const (
+	APIKeyScopeAdmin            = "admin"             // Every admin endpoint, including API key management
+	APIKeyScopeMetricsRead      = "metrics:read"      // Usage, growth, event and AI cost metrics
+	APIKeyScopePricingWrite     = "pricing:write"     // AI model pricing and spending caps
+	APIKeyScopeInteractionsRead = "interactions:read" // Logged conversations
+	APIKeyScopePromptsWrite     = "prompts:write"     // Versioned AI prompts
+	APIKeyScopeExpensesRPC      = "expenses:rpc"      // The gRPC expense service, for internal services
+)
+
+// APIKeyScopes lists the scopes a key can be granted
+var APIKeyScopes = []string{APIKeyScopeAdmin, APIKeyScopeMetricsRead, APIKeyScopePricingWrite, APIKeyScopeInteractionsRead, APIKeyScopePromptsWrite, APIKeyScopeExpensesRPC}
+
+// APIKey grants access to the admin endpoints covered by its scopes. Only a
+// hash of the key is stored; the key itself is shown once, when it is created.
+type APIKey struct {
+	ID         string     `db:"id" json:"id"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"` // First characters of the key, to tell keys apart
+	KeyHash    string     `db:"key_hash" json:"-"`
+	Scopes     []string   `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at,omitempty"` // Nil for keys that don't expire
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Allows reports whether the key grants scope
+func (k *APIKey) Allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == APIKeyScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// User deletion statuses
+const (
+	UserDeletionPending   = "pending"   // Waiting out the grace period; the user can still cancel
+	UserDeletionCancelled = "cancelled" // The user cancelled before their data was purged
+	UserDeletionPurged    = "purged"    // All of the user's data was deleted
+)
+
+// UserDeletion is a user's request to have their data erased. Data is purged
+// once PurgeAfter passes, and the record is kept as an audit trail of the
+// purge, holding only how many rows were deleted from each table.
+type UserDeletion struct {
+	ID            string           `db:"id" json:"id"`
+	UserID        string           `db:"user_id" json:"user_id"`
+	Status        string           `db:"status" json:"status"`
+	RequestedAt   time.Time        `db:"requested_at" json:"requested_at"`
+	PurgeAfter    time.Time        `db:"purge_after" json:"purge_after"`
+	PurgedAt      *time.Time       `db:"purged_at" json:"purged_at,omitempty"`
+	DeletedCounts map[string]int64 `db:"deleted_counts" json:"deleted_counts,omitempty"` // Rows deleted per table
+}
+
+// UserPurge is what purging a user's data removed
+type UserPurge struct {
+	DeletedCounts map[string]int64 // Rows deleted per table
+	StorageKeys   []string         // Blob storage keys of the deleted attachments
+	ArchiveKeys   []string         // Object keys of the deleted archives' bundles
+}
+
+// AICostCapGlobal is the cap scope covering AI spending by all users combined
+const AICostCapGlobal = "global"
+
+// AICostCap limits AI spending in USD per UTC day and calendar month. Scope is
+// AICostCapGlobal or a user ID; a zero limit means unlimited.
+type AICostCap struct {
+	Scope      string    `db:"scope" json:"scope"`
+	DailyUSD   float64   `db:"daily_usd" json:"daily_usd"`
+	MonthlyUSD float64   `db:"monthly_usd" json:"monthly_usd"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// AICostAnomaly is a UTC day whose AI spend exceeded a multiple of the
+// average daily spend of the days before it. The admin dashboard lists them
+// as alerts.
+type AICostAnomaly struct {
+	ID           string    `db:"id" json:"id"`
+	Date         time.Time `db:"anomaly_date" json:"date"`
+	Cost         float64   `db:"cost" json:"cost"`
+	Calls        int       `db:"calls" json:"calls"`
+	BaselineCost float64   `db:"baseline_cost" json:"baseline_cost"` // Average daily cost of the preceding days
+	Ratio        float64   `db:"ratio" json:"ratio"`                 // Cost over baseline cost
+	DetectedAt   time.Time `db:"detected_at" json:"detected_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
 // PricingProvider defines the contract for fetching pricing from an AI provider
 type PricingProvider interface {
 	// Fetch retrieves current pricing from the provider
@@ -462,3 +462,73 @@ func TestAggregateBoundaries(t *testing.T) {
 		}
 	})
 }
+
+// TestBudgetPeriodRange tests period boundaries for budgets
+func TestBudgetPeriodRange(t *testing.T) {
+	// Wednesday, 2025-01-15
+	ref := time.Date(2025, 1, 15, 14, 30, 0, 0, time.UTC)
+
+	t.Run("Monthly", func(t *testing.T) {
+		b := &Budget{Period: BudgetPeriodMonthly}
+		start, end := b.PeriodRange(ref)
+		if !start.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected start: %v", start)
+		}
+		if end.Month() != time.January || end.Day() != 31 {
+			t.Errorf("unexpected end: %v", end)
+		}
+	})
+
+	t.Run("Weekly", func(t *testing.T) {
+		b := &Budget{Period: BudgetPeriodWeekly}
+		start, end := b.PeriodRange(ref)
+		if !start.Equal(time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("expected week to start Monday 13th, got %v", start)
+		}
+		if end.Day() != 19 {
+			t.Errorf("expected week to end Sunday 19th, got %v", end)
+		}
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		startDate := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		endDate := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+		b := &Budget{Period: BudgetPeriodCustom, StartDate: &startDate, EndDate: &endDate}
+		taipei := time.FixedZone("CST", 8*60*60)
+		start, end := b.PeriodRange(ref.In(taipei))
+		if !start.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, taipei)) {
+			t.Errorf("expected the trip's first day in the user's timezone, got %v", start)
+		}
+		if !end.Equal(time.Date(2025, 3, 8, 0, 0, 0, 0, taipei).Add(-time.Nanosecond)) {
+			t.Errorf("expected the end of the trip's last day, got %v", end)
+		}
+	})
+
+	t.Run("Overall", func(t *testing.T) {
+		empty := ""
+		if !(&Budget{}).IsOverall() || !(&Budget{CategoryID: &empty}).IsOverall() {
+			t.Error("expected budget without category to be overall")
+		}
+	})
+}
+
+func TestNotificationPreferencesInQuietHours(t *testing.T) {
+	tests := []struct {
+		start, end, hour int
+		want             bool
+	}{
+		{22, 8, 23, true},
+		{22, 8, 3, true},
+		{22, 8, 8, false},
+		{22, 8, 21, false},
+		{13, 14, 13, true},
+		{13, 14, 14, false},
+		{9, 9, 9, false},
+	}
+	for _, tt := range tests {
+		prefs := &NotificationPreferences{QuietHoursStart: tt.start, QuietHoursEnd: tt.end}
+		if got := prefs.InQuietHours(tt.hour); got != tt.want {
+			t.Errorf("InQuietHours(%d) with %d-%d = %v, want %v", tt.hour, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
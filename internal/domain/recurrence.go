@@ -0,0 +1,342 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies, named as in RFC 5545
+const (
+	RecurrenceDaily   = "DAILY"
+	RecurrenceWeekly  = "WEEKLY"
+	RecurrenceMonthly = "MONTHLY"
+	RecurrenceYearly  = "YEARLY"
+)
+
+// RecurrencePresets are the named frequencies of recurring expenses and the
+// rules they stand for
+var RecurrencePresets = map[string]string{
+	"daily":     "FREQ=DAILY",
+	"weekly":    "FREQ=WEEKLY",
+	"biweekly":  "FREQ=WEEKLY;INTERVAL=2",
+	"monthly":   "FREQ=MONTHLY",
+	"quarterly": "FREQ=MONTHLY;INTERVAL=3",
+	"yearly":    "FREQ=YEARLY",
+}
+
+// ErrInvalidRecurrence is returned for a frequency that is neither a preset
+// nor a supported RRULE
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+// maxRecurrencePeriods bounds the periods looked through for occurrences, so
+// a rule that rarely or never matches, like the 31st of every other February,
+// can't loop forever. It is over 27 years of days.
+const maxRecurrencePeriods = 10000
+
+// recurrenceWeekdays are the RRULE names of the weekdays
+var recurrenceWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// WeekdayRule is a BYDAY entry: a weekday, and for monthly rules optionally
+// which of the month's such weekdays, e.g. 2FR for the second Friday or -1MO
+// for the last Monday
+type WeekdayRule struct {
+	Ordinal int // 0 for every one
+	Weekday time.Weekday
+}
+
+// Recurrence is when a recurring expense falls due: the subset of an RFC 5545
+// RRULE with FREQ, INTERVAL, BYDAY, BYMONTHDAY, COUNT and UNTIL. Occurrences
+// count from a start time, which gives their time of day and, for rules that
+// don't say otherwise, their weekday or day of the month.
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	ByDay      []WeekdayRule
+	ByMonthDay []int // Negative days count from the end of the month
+	Count      int   // 0 for no limit
+	Until      *time.Time
+}
+
+// ParseRecurrence reads a preset frequency like "biweekly", or an RRULE with
+// or without its "RRULE:" prefix, e.g. "FREQ=MONTHLY;BYDAY=2FR" for the
+// second Friday of every month
+func ParseRecurrence(s string) (*Recurrence, error) {
+	s = strings.TrimSpace(s)
+	if preset, ok := RecurrencePresets[strings.ToLower(s)]; ok {
+		s = preset
+	}
+	s = strings.TrimPrefix(strings.ToUpper(s), "RRULE:")
+
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: %q is not NAME=VALUE", ErrInvalidRecurrence, part)
+		}
+		switch name {
+		case "FREQ":
+			switch value {
+			case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly:
+				r.Freq = value
+			default:
+				return nil, fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRecurrence, value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 999 {
+				return nil, fmt.Errorf("%w: INTERVAL must be 1 to 999", ErrInvalidRecurrence)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				rule, err := parseWeekdayRule(day)
+				if err != nil {
+					return nil, err
+				}
+				r.ByDay = append(r.ByDay, rule)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("%w: BYMONTHDAY %s is not a day of the month", ErrInvalidRecurrence, day)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be at least 1", ErrInvalidRecurrence)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseRecurrenceUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = &until
+		default:
+			return nil, fmt.Errorf("%w: unsupported %s", ErrInvalidRecurrence, name)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL can't both be set", ErrInvalidRecurrence)
+	}
+	if len(r.ByDay) > 0 && r.Freq == RecurrenceYearly {
+		return nil, fmt.Errorf("%w: BYDAY needs FREQ=DAILY, WEEKLY or MONTHLY", ErrInvalidRecurrence)
+	}
+	for _, rule := range r.ByDay {
+		if rule.Ordinal != 0 && r.Freq != RecurrenceMonthly {
+			return nil, fmt.Errorf("%w: BYDAY ordinals need FREQ=MONTHLY", ErrInvalidRecurrence)
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != RecurrenceDaily && r.Freq != RecurrenceMonthly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY needs FREQ=DAILY or MONTHLY", ErrInvalidRecurrence)
+	}
+	return r, nil
+}
+
+// parseWeekdayRule reads a BYDAY entry like FR, 2FR or -1MO
+func parseWeekdayRule(s string) (WeekdayRule, error) {
+	if len(s) < 2 {
+		return WeekdayRule{}, fmt.Errorf("%w: BYDAY %s is not a weekday", ErrInvalidRecurrence, s)
+	}
+	weekday, ok := recurrenceWeekdays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayRule{}, fmt.Errorf("%w: BYDAY %s is not a weekday", ErrInvalidRecurrence, s)
+	}
+	rule := WeekdayRule{Weekday: weekday}
+	if ordinal := s[:len(s)-2]; ordinal != "" {
+		n, err := strconv.Atoi(ordinal)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayRule{}, fmt.Errorf("%w: BYDAY %s has no week 1 to 5 of the month", ErrInvalidRecurrence, s)
+		}
+		rule.Ordinal = n
+	}
+	return rule, nil
+}
+
+// parseRecurrenceUntil reads an UNTIL time in UTC, or a date, which includes
+// the whole day
+func parseRecurrenceUntil(s string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", s); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse("20060102", s); err == nil {
+		return until.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: UNTIL must look like 20261231 or 20261231T235959Z", ErrInvalidRecurrence)
+}
+
+// String returns the rule as an RRULE without its prefix
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, rule := range r.ByDay {
+			days[i] = strings.ToUpper(rule.Weekday.String()[:2])
+			if rule.Ordinal != 0 {
+				days[i] = strconv.Itoa(rule.Ordinal) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Between returns the occurrences counted from start that fall in [from, to],
+// oldest first, stopping after limit of them unless limit is 0
+func (r *Recurrence) Between(start, from, to time.Time, limit int) []time.Time {
+	var occurrences []time.Time
+	count := 0
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		candidates, periodStart := r.period(start, period)
+		if periodStart.After(to) || (r.Until != nil && periodStart.After(*r.Until)) {
+			break
+		}
+		for _, c := range candidates {
+			if c.Before(start) {
+				continue
+			}
+			count++
+			if (r.Count > 0 && count > r.Count) || (r.Until != nil && c.After(*r.Until)) || c.After(to) {
+				return occurrences
+			}
+			if !c.Before(from) {
+				occurrences = append(occurrences, c)
+				if limit > 0 && len(occurrences) == limit {
+					return occurrences
+				}
+			}
+		}
+	}
+	return occurrences
+}
+
+// Next returns the first occurrence counted from start after the given time,
+// and false when there are no more
+func (r *Recurrence) Next(start, after time.Time) (time.Time, bool) {
+	next := r.Between(start, after.Add(time.Nanosecond), after.AddDate(maxRecurrencePeriods/365+1, 0, 0), 1)
+	if len(next) == 0 {
+		return time.Time{}, false
+	}
+	return next[0], true
+}
+
+// period returns the occurrence candidates of a period of the rule, the day,
+// week, month or year that is the given number of intervals after start's,
+// oldest first, along with the period's first day
+func (r *Recurrence) period(start time.Time, n int) ([]time.Time, time.Time) {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	}
+	steps := n * r.Interval
+
+	switch r.Freq {
+	case RecurrenceDaily:
+		day := at(start.Year(), start.Month(), start.Day()+steps)
+		if r.matches(day) {
+			return []time.Time{day}, day
+		}
+		return nil, day
+
+	case RecurrenceWeekly:
+		// Weeks start on Monday, as RRULE's WKST does by default
+		monday := at(start.Year(), start.Month(), start.Day()-mondayOffset(start.Weekday())+7*steps)
+		if len(r.ByDay) == 0 {
+			return []time.Time{monday.AddDate(0, 0, mondayOffset(start.Weekday()))}, monday
+		}
+		var days []time.Time
+		for _, rule := range r.ByDay {
+			days = append(days, monday.AddDate(0, 0, mondayOffset(rule.Weekday)))
+		}
+		sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+		return days, monday
+
+	case RecurrenceMonthly:
+		first := at(start.Year(), start.Month()+time.Month(steps), 1)
+		last := daysInMonth(first.Year(), first.Month())
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			// Charged on the 31st means the last day in shorter months
+			return []time.Time{at(first.Year(), first.Month(), min(start.Day(), last))}, first
+		}
+		var days []time.Time
+		for day := 1; day <= last; day++ {
+			if date := at(first.Year(), first.Month(), day); r.matches(date) {
+				days = append(days, date)
+			}
+		}
+		return days, first
+
+	default:
+		first := at(start.Year()+steps, 1, 1)
+		// Charged on February 29th means the 28th in other years
+		day := min(start.Day(), daysInMonth(first.Year(), start.Month()))
+		return []time.Time{at(first.Year(), start.Month(), day)}, first
+	}
+}
+
+// matches reports whether a day is in the rule's BYDAY and BYMONTHDAY
+func (r *Recurrence) matches(date time.Time) bool {
+	day, last := date.Day(), daysInMonth(date.Year(), date.Month())
+	if len(r.ByMonthDay) > 0 {
+		found := false
+		for _, d := range r.ByMonthDay {
+			if d == day || last+1+d == day {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	// Which of the month's such weekdays the day is, from the start and the end
+	nth, nthLast := (day-1)/7+1, -((last-day)/7 + 1)
+	for _, rule := range r.ByDay {
+		if rule.Weekday == date.Weekday() && (rule.Ordinal == 0 || rule.Ordinal == nth || rule.Ordinal == nthLast) {
+			return true
+		}
+	}
+	return false
+}
+
+// mondayOffset returns how many days after Monday the weekday is
+func mondayOffset(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+// daysInMonth returns the number of days in the month
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceBetween(t *testing.T) {
+	// A Thursday
+	start := time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC)
+	from := start
+	to := time.Date(2026, 3, 31, 23, 59, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule  string
+		limit int
+		want  []string
+	}{
+		{"monthly", 0, []string{"2026-01-01", "2026-02-01", "2026-03-01"}},
+		{"biweekly", 3, []string{"2026-01-01", "2026-01-15", "2026-01-29"}},
+		{"quarterly", 0, []string{"2026-01-01"}},
+		// The second Friday of every month
+		{"RRULE:FREQ=MONTHLY;BYDAY=2FR", 0, []string{"2026-01-09", "2026-02-13", "2026-03-13"}},
+		// Every other Friday
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", 3, []string{"2026-01-02", "2026-01-16", "2026-01-30"}},
+		// Mondays and Wednesdays, the week's earlier days coming first
+		{"FREQ=WEEKLY;BYDAY=WE,MO", 4, []string{"2026-01-05", "2026-01-07", "2026-01-12", "2026-01-14"}},
+		{"FREQ=MONTHLY;BYDAY=-1MO", 0, []string{"2026-01-26", "2026-02-23", "2026-03-30"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", 0, []string{"2026-01-31", "2026-02-28", "2026-03-31"}},
+		// Friday the 13th
+		{"FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", 0, []string{"2026-02-13", "2026-03-13"}},
+		{"FREQ=DAILY;BYDAY=SA,SU;COUNT=3", 0, []string{"2026-01-03", "2026-01-04", "2026-01-10"}},
+		{"FREQ=WEEKLY;UNTIL=20260115", 0, []string{"2026-01-01", "2026-01-08", "2026-01-15"}},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) error = %v", tt.rule, err)
+		}
+		got := r.Between(start, from, to, tt.limit)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.rule, tt.want, got)
+			continue
+		}
+		for i, occurrence := range got {
+			if occurrence.Format("2006-01-02") != tt.want[i] || occurrence.Format("15:04") != "09:30" {
+				t.Errorf("%s: expected %v, got %v", tt.rule, tt.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestRecurrenceMonthEnd(t *testing.T) {
+	r, err := ParseRecurrence("monthly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	next, ok := r.Next(start, start)
+	if !ok || !next.Equal(time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the last day of February, got %v", next)
+	}
+	next, _ = r.Next(start, next)
+	if !next.Equal(time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected March 31, got %v", next)
+	}
+
+	r, _ = ParseRecurrence("FREQ=MONTHLY;COUNT=2")
+	if _, ok := r.Next(start, time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)); ok {
+		t.Error("expected no occurrence after the last one")
+	}
+}
+
+func TestRecurrenceString(t *testing.T) {
+	tests := map[string]string{
+		"biweekly":                           "FREQ=WEEKLY;INTERVAL=2",
+		"rrule:freq=monthly;byday=2fr":       "FREQ=MONTHLY;BYDAY=2FR",
+		"FREQ=MONTHLY;BYMONTHDAY=1,-1":       "FREQ=MONTHLY;BYMONTHDAY=1,-1",
+		"FREQ=WEEKLY;UNTIL=20261231T120000Z": "FREQ=WEEKLY;UNTIL=20261231T120000Z",
+	}
+	for rule, want := range tests {
+		r, err := ParseRecurrence(rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrence(%q) error = %v", rule, err)
+		}
+		if got := r.String(); got != want {
+			t.Errorf("%s: expected %q, got %q", rule, want, got)
+		}
+	}
+}
+
+func TestParseRecurrenceInvalid(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"fortnightly",
+		"FREQ=HOURLY",
+		"INTERVAL=2",
+		"FREQ=WEEKLY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=2FR",
+		"FREQ=MONTHLY;BYDAY=6FR",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=YEARLY;BYDAY=MO",
+		"FREQ=DAILY;COUNT=2;UNTIL=20261231",
+		"FREQ=DAILY;BYHOUR=9",
+	} {
+		if _, err := ParseRecurrence(rule); !errors.Is(err, ErrInvalidRecurrence) {
+			t.Errorf("%q: expected ErrInvalidRecurrence, got %v", rule, err)
+		}
+	}
+}
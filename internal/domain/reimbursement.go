@@ -0,0 +1,62 @@
+package domain
+
+import "slices"
+
+// Claim statuses of a reimbursable expense, in the order a claim moves through them
+const (
+	ClaimStatusPending   = "pending"   // Not yet submitted to the employer
+	ClaimStatusSubmitted = "submitted" // Submitted and waiting to be paid back
+	ClaimStatusPaid      = "paid"      // Paid back
+)
+
+// ClaimStatuses are the claim statuses, in lifecycle order
+var ClaimStatuses = []string{ClaimStatusPending, ClaimStatusSubmitted, ClaimStatusPaid}
+
+// ReimbursementTags are the hashtags that mark an expense as reimbursable
+// when it's recorded, e.g. "計程車 300 #報帳"
+var ReimbursementTags = []string{"報帳", "報銷", "报账", "报销", "立替", "経費精算", "reimburse", "reimbursable"}
+
+// HasReimbursementTag reports whether any of the tag names marks an expense as
+// reimbursable; the names may carry the # and any case
+func HasReimbursementTag(tags []string) bool {
+	for _, tag := range tags {
+		if slices.Contains(ReimbursementTags, NormalizeTagName(tag)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClaimStatus reports whether status is one of the claim statuses
+func IsClaimStatus(status string) bool {
+	return slices.Contains(ClaimStatuses, status)
+}
+
+// CanMoveClaim reports whether a claim can move from one status to another.
+// Claims move forward, skipping steps if need be, and a submitted claim can
+// go back to pending when the employer returns it; a paid claim is final.
+func CanMoveClaim(from, to string) bool {
+	if !IsClaimStatus(from) || !IsClaimStatus(to) || from == ClaimStatusPaid {
+		return false
+	}
+	if from == ClaimStatusSubmitted && to == ClaimStatusPending {
+		return true
+	}
+	return slices.Index(ClaimStatuses, to) > slices.Index(ClaimStatuses, from)
+}
+
+// SetReimbursable marks the expense reimbursable, with a pending claim, or
+// not reimbursable. Marking it again keeps the claim's status.
+func (e *Expense) SetReimbursable(reimbursable bool) {
+	switch {
+	case !reimbursable:
+		e.Reimbursable, e.ClaimStatus = false, ""
+	case !e.Reimbursable:
+		e.Reimbursable, e.ClaimStatus = true, ClaimStatusPending
+	}
+}
+
+// IsOutstandingClaim reports whether the expense is reimbursable and not yet paid back
+func (e *Expense) IsOutstandingClaim() bool {
+	return e.Reimbursable && e.ClaimStatus != ClaimStatusPaid
+}
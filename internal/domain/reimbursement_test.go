@@ -0,0 +1,54 @@
+package domain
+
+import "testing"
+
+func TestCanMoveClaim(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{ClaimStatusPending, ClaimStatusSubmitted, true},
+		{ClaimStatusPending, ClaimStatusPaid, true},
+		{ClaimStatusSubmitted, ClaimStatusPaid, true},
+		{ClaimStatusSubmitted, ClaimStatusPending, true},
+		{ClaimStatusPaid, ClaimStatusSubmitted, false},
+		{ClaimStatusPaid, ClaimStatusPending, false},
+		{ClaimStatusPending, ClaimStatusPending, false},
+		{"", ClaimStatusPending, false},
+		{ClaimStatusPending, "approved", false},
+	}
+	for _, tt := range tests {
+		if got := CanMoveClaim(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanMoveClaim(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestHasReimbursementTag(t *testing.T) {
+	if !HasReimbursementTag([]string{"travel", "#報帳"}) || !HasReimbursementTag([]string{"Reimbursable"}) {
+		t.Error("expected a reimbursement tag")
+	}
+	if HasReimbursementTag([]string{"travel"}) || HasReimbursementTag(nil) {
+		t.Error("expected no reimbursement tag")
+	}
+}
+
+func TestSetReimbursable(t *testing.T) {
+	expense := &Expense{}
+	expense.SetReimbursable(true)
+	if !expense.Reimbursable || expense.ClaimStatus != ClaimStatusPending || !expense.IsOutstandingClaim() {
+		t.Fatalf("expected a pending claim, got %+v", expense)
+	}
+
+	// Marking it again keeps the claim's status
+	expense.ClaimStatus = ClaimStatusPaid
+	expense.SetReimbursable(true)
+	if expense.ClaimStatus != ClaimStatusPaid || expense.IsOutstandingClaim() {
+		t.Errorf("expected the paid claim to stay paid, got %q", expense.ClaimStatus)
+	}
+
+	expense.SetReimbursable(false)
+	if expense.Reimbursable || expense.ClaimStatus != "" {
+		t.Errorf("expected no claim, got %+v", expense)
+	}
+}
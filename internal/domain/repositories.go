@@ -13,6 +13,9 @@ type UserRepository interface {
 	// GetByID retrieves a user by ID
 	GetByID(ctx context.Context, userID string) (*User, error)
 
+	// Update saves the user's home currency, locale and onboarding
+	Update(ctx context.Context, user *User) error
+
 	// Exists checks if a user exists
 	Exists(ctx context.Context, userID string) (bool, error)
 }
@@ -22,22 +25,123 @@ type ExpenseRepository interface {
 	// Create creates a new expense
 	Create(ctx context.Context, expense *Expense) error
 
+	// CreateBatch creates several expenses with as few statements as possible;
+	// either all of them are created or none is
+	CreateBatch(ctx context.Context, expenses []*Expense) error
+
 	// GetByID retrieves an expense by ID
 	GetByID(ctx context.Context, id string) (*Expense, error)
 
 	// GetByUserID retrieves all expenses for a user
 	GetByUserID(ctx context.Context, userID string) ([]*Expense, error)
 
+	// ListByUserID retrieves one page of a user's expenses in the requested order
+	ListByUserID(ctx context.Context, userID string, opts ExpenseListOptions) ([]*Expense, error)
+
 	// GetByUserIDAndDateRange retrieves expenses for a user within a date range
 	GetByUserIDAndDateRange(ctx context.Context, userID string, from, to time.Time) ([]*Expense, error)
 
 	// GetByUserIDAndCategory retrieves expenses for a user in a category
 	GetByUserIDAndCategory(ctx context.Context, userID, categoryID string) ([]*Expense, error)
 
+	// Filter retrieves the expenses a filter selects, newest first
+	Filter(ctx context.Context, filter ExpenseFilter) ([]*Expense, error)
+
 	// Update updates an existing expense
 	Update(ctx context.Context, expense *Expense) error
 
-	// Delete deletes an expense
+	// Delete soft-deletes an expense; deleted expenses are left out of every other query
+	Delete(ctx context.Context, id string) error
+
+	// GetDeletedByID retrieves a soft-deleted expense by ID
+	GetDeletedByID(ctx context.Context, id string) (*Expense, error)
+
+	// Restore undoes the soft delete of an expense
+	Restore(ctx context.Context, id string) error
+
+	// GetByGroupIDAndDateRange retrieves a group ledger's expenses within a date range
+	GetByGroupIDAndDateRange(ctx context.Context, groupID string, from, to time.Time) ([]*Expense, error)
+
+	// SumByCategoryAndDateRange totals the selected expenses per category
+	SumByCategoryAndDateRange(ctx context.Context, query ExpenseTotalsQuery) ([]*CategoryTotal, error)
+
+	// SumByPeriodAndDateRange totals the selected expenses per day or month
+	// (TotalsPeriodDay, TotalsPeriodMonth), oldest first
+	SumByPeriodAndDateRange(ctx context.Context, query ExpenseTotalsQuery, period string) ([]*PeriodTotal, error)
+}
+
+// ExpenseSearchRepository searches expense descriptions with a full-text index
+type ExpenseSearchRepository interface {
+	// Search returns one page of the expenses the query matches, in its sort
+	// order, and how many it matches in all
+	Search(ctx context.Context, query *ExpenseSearchQuery) ([]*ExpenseSearchHit, int, error)
+}
+
+// TagRepository defines operations for tags and the expenses they label
+type TagRepository interface {
+	// Create creates a new tag; a user can't have two tags of the same name
+	Create(ctx context.Context, tag *Tag) error
+
+	// GetByID retrieves a tag by ID
+	GetByID(ctx context.Context, id string) (*Tag, error)
+
+	// GetByUserID retrieves all tags for a user, by name
+	GetByUserID(ctx context.Context, userID string) ([]*Tag, error)
+
+	// GetByUserIDAndName retrieves a tag by user and normalized name
+	GetByUserIDAndName(ctx context.Context, userID, name string) (*Tag, error)
+
+	// Update renames a tag
+	Update(ctx context.Context, tag *Tag) error
+
+	// Delete deletes a tag, removing it from its expenses
+	Delete(ctx context.Context, id string) error
+
+	// SetExpenseTags replaces the tags of an expense
+	SetExpenseTags(ctx context.Context, expenseID string, tagIDs []string) error
+
+	// GetNamesByExpenseIDs returns the tag names of each expense, by name;
+	// expenses without tags are left out
+	GetNamesByExpenseIDs(ctx context.Context, expenseIDs []string) (map[string][]string, error)
+
+	// SumByTagAndDateRange totals the live expenses the query selects per tag,
+	// largest total first
+	SumByTagAndDateRange(ctx context.Context, query ExpenseTotalsQuery) ([]*TagTotal, error)
+}
+
+// MerchantRepository defines operations for the merchants expenses are paid at
+type MerchantRepository interface {
+	// Create creates a new merchant; a user can't have two merchants of the
+	// same normalized name
+	Create(ctx context.Context, merchant *Merchant) error
+
+	// GetByID retrieves a merchant by ID
+	GetByID(ctx context.Context, id string) (*Merchant, error)
+
+	// GetByUserIDAndNormalizedName retrieves a merchant by user and normalized name
+	GetByUserIDAndNormalizedName(ctx context.Context, userID, normalizedName string) (*Merchant, error)
+
+	// SumByMerchantAndDateRange totals the live expenses the query selects per
+	// merchant, largest total first; expenses without a merchant are left out
+	SumByMerchantAndDateRange(ctx context.Context, query ExpenseTotalsQuery) ([]*MerchantTotal, error)
+}
+
+// WorkspaceRepository defines operations for the workspaces users keep apart
+// from their personal one
+type WorkspaceRepository interface {
+	// Create creates a new workspace; a user can't have two workspaces of the same name
+	Create(ctx context.Context, workspace *Workspace) error
+
+	// GetByID retrieves a workspace by ID
+	GetByID(ctx context.Context, id string) (*Workspace, error)
+
+	// GetByUserID retrieves a user's workspaces by name
+	GetByUserID(ctx context.Context, userID string) ([]*Workspace, error)
+
+	// Update renames a workspace
+	Update(ctx context.Context, workspace *Workspace) error
+
+	// Delete deletes a workspace
 	Delete(ctx context.Context, id string) error
 }
 
@@ -54,6 +158,126 @@ type ExchangeRateRepository interface {
 	SaveRate(ctx context.Context, rate *ExchangeRate) error
 	GetRate(ctx context.Context, baseCurrency, targetCurrency string, rateDate time.Time) (*ExchangeRate, error)
 	GetMostRecentRate(ctx context.Context, baseCurrency, targetCurrency string, before time.Time) (*ExchangeRate, error)
+	GetLatestRates(ctx context.Context, baseCurrency string, before time.Time) ([]*ExchangeRate, error)
+}
+
+// BudgetRepository defines operations for budget data
+type BudgetRepository interface {
+	// Create creates a new budget
+	Create(ctx context.Context, budget *Budget) error
+
+	// GetByID retrieves a budget by ID
+	GetByID(ctx context.Context, id string) (*Budget, error)
+
+	// GetByUserID retrieves all personal budgets for a user
+	GetByUserID(ctx context.Context, userID string) ([]*Budget, error)
+
+	// GetByGroupID retrieves all budgets for a group ledger
+	GetByGroupID(ctx context.Context, groupID string) ([]*Budget, error)
+
+	// Update updates a budget
+	Update(ctx context.Context, budget *Budget) error
+
+	// Delete deletes a budget
+	Delete(ctx context.Context, id string) error
+}
+
+// AttachmentRepository defines operations for expense attachment metadata
+type AttachmentRepository interface {
+	// Create creates a new attachment record
+	Create(ctx context.Context, attachment *ExpenseAttachment) error
+
+	// GetByID retrieves an attachment by ID
+	GetByID(ctx context.Context, id string) (*ExpenseAttachment, error)
+
+	// GetByExpenseID retrieves all attachments of an expense
+	GetByExpenseID(ctx context.Context, expenseID string) ([]*ExpenseAttachment, error)
+}
+
+// ArchiveRepository stores the metadata of expense archives, whose bundles
+// are kept in object storage
+type ArchiveRepository interface {
+	// Create creates a new archive record
+	Create(ctx context.Context, archive *Archive) error
+
+	// GetByID retrieves an archive by ID
+	GetByID(ctx context.Context, id string) (*Archive, error)
+
+	// GetByUserID retrieves the user's archives, newest first
+	GetByUserID(ctx context.Context, userID string) ([]*Archive, error)
+
+	// Delete removes an archive record
+	Delete(ctx context.Context, id string) error
+
+	// GetCreatedBefore retrieves every user's archives created before the given time, oldest first
+	GetCreatedBefore(ctx context.Context, before time.Time) ([]*Archive, error)
+}
+
+// ArchivePolicyRepository stores per-user archive policies
+type ArchivePolicyRepository interface {
+	// GetByUserID retrieves the user's policy
+	GetByUserID(ctx context.Context, userID string) (*ArchivePolicy, error)
+
+	// Save creates the user's policy or replaces it
+	Save(ctx context.Context, policy *ArchivePolicy) error
+
+	// Delete removes the user's policy, so the global policy applies again
+	Delete(ctx context.Context, userID string) error
+
+	// List retrieves every per-user policy
+	List(ctx context.Context) ([]*ArchivePolicy, error)
+
+	// ListUsersWithExpensesBefore retrieves the IDs of users who have expenses
+	// dated before the given time that aren't deleted
+	ListUsersWithExpensesBefore(ctx context.Context, before time.Time) ([]string, error)
+}
+
+// ExpenseSplitRepository defines operations for split-bill shares
+type ExpenseSplitRepository interface {
+	// ReplaceForExpense replaces all shares of an expense
+	ReplaceForExpense(ctx context.Context, expenseID string, splits []*ExpenseSplit) error
+
+	// GetByExpenseID retrieves the shares of an expense
+	GetByExpenseID(ctx context.Context, expenseID string) ([]*ExpenseSplit, error)
+
+	// GetByPayerID retrieves the shares of all expenses a user paid for
+	GetByPayerID(ctx context.Context, payerID string) ([]*ExpenseSplit, error)
+
+	// GetByGroupID retrieves the shares of all expenses in a group ledger
+	GetByGroupID(ctx context.Context, groupID string) ([]*ExpenseSplit, error)
+}
+
+// ExpenseItemRepository defines operations for receipt line items
+type ExpenseItemRepository interface {
+	// ReplaceForExpense replaces all line items of an expense
+	ReplaceForExpense(ctx context.Context, expenseID string, items []*ExpenseItem) error
+
+	// GetByExpenseIDs retrieves the line items of each expense, in receipt order
+	GetByExpenseIDs(ctx context.Context, expenseIDs []string) (map[string][]*ExpenseItem, error)
+}
+
+// GroupRepository defines operations for shared group ledgers
+type GroupRepository interface {
+	// Create creates a new group
+	Create(ctx context.Context, group *Group) error
+
+	// GetByID retrieves a group by ID
+	GetByID(ctx context.Context, id string) (*Group, error)
+
+	// GetByExternalID retrieves the group bound to a messenger chat
+	GetByExternalID(ctx context.Context, messengerType, externalID string) (*Group, error)
+
+	// GetByUserID retrieves all groups a user belongs to
+	GetByUserID(ctx context.Context, userID string) ([]*Group, error)
+
+	// AddMember adds a user to a group; adding an existing member is a no-op
+	AddMember(ctx context.Context, member *GroupMember) error
+
+	// GetMembers retrieves all members of a group
+	GetMembers(ctx context.Context, groupID string) ([]*GroupMember, error)
+
+	// IsMember checks if a user belongs to a group
+	IsMember(ctx context.Context, groupID, userID string) (bool, error)
 }
 
 // CategoryRepository defines operations for category data
@@ -82,10 +306,34 @@ type CategoryRepository interface {
 	// GetKeywordsByCategory retrieves keywords for a category
 	GetKeywordsByCategory(ctx context.Context, categoryID string) ([]*CategoryKeyword, error)
 
+	// GetKeywordsByUserID retrieves keywords across all of a user's categories, highest priority first
+	GetKeywordsByUserID(ctx context.Context, userID string) ([]*CategoryKeyword, error)
+
+	// UpdateKeyword updates a keyword mapping's priority
+	UpdateKeyword(ctx context.Context, keyword *CategoryKeyword) error
+
 	// DeleteKeyword deletes a keyword mapping
 	DeleteKeyword(ctx context.Context, id string) error
 }
 
+// CategoryCorrectionRepository defines operations for recorded category corrections
+type CategoryCorrectionRepository interface {
+	// Create records a category correction
+	Create(ctx context.Context, correction *CategoryCorrection) error
+
+	// GetRecentByUserID retrieves a user's latest corrections, newest first
+	GetRecentByUserID(ctx context.Context, userID string, limit int) ([]*CategoryCorrection, error)
+}
+
+// ExpenseAuditRepository stores the change history of expenses
+type ExpenseAuditRepository interface {
+	// Create records an audit entry
+	Create(ctx context.Context, entry *ExpenseAuditEntry) error
+
+	// GetByExpenseID retrieves an expense's audit entries, oldest first
+	GetByExpenseID(ctx context.Context, expenseID string) ([]*ExpenseAuditEntry, error)
+}
+
 // MetricsRepository defines operations for metrics queries
 type MetricsRepository interface {
 	// GetDailyActiveUsers retrieves DAU for a date range
@@ -102,6 +350,26 @@ type MetricsRepository interface {
 
 	// GetNewUsersPerDay retrieves new users created per day
 	GetNewUsersPerDay(ctx context.Context, from, to time.Time) ([]*DailyMetrics, error)
+
+	// GetUserActivity retrieves the users who signed up or were active since
+	// the given time, with the days they were active on since then
+	GetUserActivity(ctx context.Context, since time.Time) ([]*UserActivity, error)
+
+	// GetPlatformMetrics retrieves signups, messages, active users and AI
+	// cost per messenger platform for a date range
+	GetPlatformMetrics(ctx context.Context, from, to time.Time) ([]*PlatformMetrics, error)
+}
+
+// MetricsRollupRepository stores the per-day metrics rollup
+type MetricsRollupRepository interface {
+	// ComputeDay aggregates the raw tables for the UTC day starting at day
+	ComputeDay(ctx context.Context, day time.Time) (*MetricsRollup, error)
+
+	// Upsert stores a day's rollup, replacing any earlier one
+	Upsert(ctx context.Context, rollup *MetricsRollup) error
+
+	// GetRange retrieves the rollups of the days from through to, oldest first
+	GetRange(ctx context.Context, from, to time.Time) ([]*MetricsRollup, error)
 }
 
 // AICostRepository defines operations for AI cost logging
@@ -123,6 +391,55 @@ type AICostRepository interface {
 
 	// GetByUserSummary retrieves AI cost breakdown by user
 	GetByUserSummary(ctx context.Context, from, to time.Time, limit int) ([]*AICostByUser, error)
+
+	// GetUserSummary retrieves aggregated AI cost metrics for one user in a date range
+	GetUserSummary(ctx context.Context, userID string, from, to time.Time) (*AICostSummary, error)
+}
+
+// PromptRepository stores versioned AI prompt templates
+type PromptRepository interface {
+	// Create stores a new prompt version
+	Create(ctx context.Context, prompt *PromptTemplate) error
+
+	// GetActive retrieves the active version of a prompt, or ErrNotFound if none is active
+	GetActive(ctx context.Context, name string) (*PromptTemplate, error)
+
+	// GetByName retrieves all versions of a prompt, newest first
+	GetByName(ctx context.Context, name string) ([]*PromptTemplate, error)
+
+	// Activate makes a version the only active one for its prompt; version 0
+	// deactivates all versions so the built-in prompt is used
+	Activate(ctx context.Context, name string, version int) error
+}
+
+// AICostCapRepository stores admin overrides of AI spending caps
+type AICostCapRepository interface {
+	// Get retrieves the cap for a scope, or ErrNotFound if it is not overridden
+	Get(ctx context.Context, scope string) (*AICostCap, error)
+
+	// GetAll retrieves all cap overrides
+	GetAll(ctx context.Context) ([]*AICostCap, error)
+
+	// Upsert creates or replaces the cap for its scope
+	Upsert(ctx context.Context, costCap *AICostCap) error
+
+	// Delete removes the cap override for a scope
+	Delete(ctx context.Context, scope string) error
+}
+
+// AICostAnomalyRepository stores the detected AI cost anomalies
+type AICostAnomalyRepository interface {
+	// GetByDate retrieves the anomaly of a UTC day, or ErrNotFound
+	GetByDate(ctx context.Context, date time.Time) (*AICostAnomaly, error)
+
+	// Create stores a new anomaly
+	Create(ctx context.Context, anomaly *AICostAnomaly) error
+
+	// Update saves an anomaly's latest cost and ratio
+	Update(ctx context.Context, anomaly *AICostAnomaly) error
+
+	// List retrieves the latest anomalies, newest day first
+	List(ctx context.Context, limit int) ([]*AICostAnomaly, error)
 }
 
 // PricingRepository defines operations for pricing configuration
@@ -133,6 +450,9 @@ type PricingRepository interface {
 	// Update updates an existing pricing config
 	Update(ctx context.Context, config *PricingConfig) error
 
+	// GetByID retrieves a pricing config, active or not
+	GetByID(ctx context.Context, id string) (*PricingConfig, error)
+
 	// GetByProviderAndModel retrieves active pricing for a specific model
 	GetByProviderAndModel(ctx context.Context, provider, model string) (*PricingConfig, error)
 
@@ -153,4 +473,172 @@ type PolicyRepository interface {
 type InteractionLogRepository interface {
 	// Create creates a new interaction log entry
 	Create(ctx context.Context, log *InteractionLog) error
+
+	// List retrieves a page of entries matching the filter, newest first, and the total number of matches
+	List(ctx context.Context, filter InteractionLogFilter) ([]*InteractionLog, int, error)
+}
+
+// ProcessedEventRepository tracks handled messenger webhook events
+type ProcessedEventRepository interface {
+	// MarkProcessed records the event, returning false if it was already recorded
+	MarkProcessed(ctx context.Context, event *ProcessedEvent) (bool, error)
+
+	// DeleteBefore removes events processed before the given time
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// WebhookRepository defines operations for webhook subscriptions and their delivery log
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *WebhookSubscription) error
+
+	// GetSubscription retrieves a subscription by ID, or ErrNotFound if it does not exist
+	GetSubscription(ctx context.Context, id string) (*WebhookSubscription, error)
+
+	// ListSubscriptions retrieves the user's subscriptions, oldest first
+	ListSubscriptions(ctx context.Context, userID string) ([]*WebhookSubscription, error)
+
+	UpdateSubscription(ctx context.Context, subscription *WebhookSubscription) error
+
+	// DeleteSubscription deletes a subscription along with its deliveries
+	DeleteSubscription(ctx context.Context, id string) error
+
+	CreateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *WebhookDelivery) error
+
+	// ListDeliveries retrieves a subscription's most recent deliveries, newest first
+	ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*WebhookDelivery, error)
+
+	// GetRetryableDeliveries retrieves pending deliveries whose next attempt is at or before now
+	GetRetryableDeliveries(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error)
+}
+
+// ReplyOutboxRepository defines operations for the outbox of messenger replies
+type ReplyOutboxRepository interface {
+	Create(ctx context.Context, reply *OutboxReply) error
+
+	// Update records the outcome of a delivery attempt
+	Update(ctx context.Context, reply *OutboxReply) error
+
+	// GetDue retrieves pending replies whose next attempt is at or before now, oldest first
+	GetDue(ctx context.Context, now time.Time, limit int) ([]*OutboxReply, error)
+
+	// DeleteFinishedBefore removes delivered and failed replies created before the given time
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ReportScheduleRepository defines operations for emailed report schedules; each user has at most one
+type ReportScheduleRepository interface {
+	// Save creates the user's schedule or replaces the existing one
+	Save(ctx context.Context, schedule *ReportSchedule) error
+
+	// GetByUserID retrieves the user's schedule, or ErrNotFound if there is none
+	GetByUserID(ctx context.Context, userID string) (*ReportSchedule, error)
+
+	// GetDue retrieves enabled schedules whose next run is at or before now
+	GetDue(ctx context.Context, now time.Time) ([]*ReportSchedule, error)
+}
+
+// EmailAddressRepository defines operations for the email addresses bound to users
+type EmailAddressRepository interface {
+	// Save creates the address or replaces the existing one
+	Save(ctx context.Context, address *EmailAddress) error
+
+	// GetByAddress retrieves the address, or ErrNotFound if it isn't bound or being verified
+	GetByAddress(ctx context.Context, address string) (*EmailAddress, error)
+
+	// GetByUserID retrieves the user's addresses, oldest first
+	GetByUserID(ctx context.Context, userID string) ([]*EmailAddress, error)
+
+	Delete(ctx context.Context, address string) error
+}
+
+// NotificationPreferencesRepository stores the notification preferences of
+// users who changed the defaults or were pushed a scheduled notification,
+// one row per user
+type NotificationPreferencesRepository interface {
+	// Save creates the user's preferences or replaces them
+	Save(ctx context.Context, prefs *NotificationPreferences) error
+
+	// GetByUserID retrieves the user's preferences, or ErrNotFound if they have the defaults
+	GetByUserID(ctx context.Context, userID string) (*NotificationPreferences, error)
+
+	// ListSubscribed retrieves the preferences of users who opted into the
+	// notification, such as NotificationDailyDigest. For notifications on by
+	// default, such as NotificationWeeklyReport, users without stored
+	// preferences are included with the defaults.
+	ListSubscribed(ctx context.Context, notification string) ([]*NotificationPreferences, error)
+}
+
+// ConversationStateRepository stores the clarification question each user
+// was last asked, one per user
+type ConversationStateRepository interface {
+	// Save creates the user's state or replaces it
+	Save(ctx context.Context, state *ConversationState) error
+
+	// GetByUserID retrieves the user's state, or ErrNotFound if there is none.
+	// Expired states are returned until they are deleted.
+	GetByUserID(ctx context.Context, userID string) (*ConversationState, error)
+
+	Delete(ctx context.Context, userID string) error
+
+	// DeleteExpiredBefore removes states that expired before the given time
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// APIKeyRepository defines operations for admin API keys
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+
+	// GetByHash retrieves the key with the given hash, or ErrNotFound if there is none
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+
+	// List retrieves all keys, oldest first
+	List(ctx context.Context) ([]*APIKey, error)
+
+	// Delete revokes a key
+	Delete(ctx context.Context, id string) error
+
+	// UpdateLastUsed records when a key was last used
+	UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
+
+// UserDeletionRepository defines operations for data deletion requests and purging a user's data
+type UserDeletionRepository interface {
+	Create(ctx context.Context, deletion *UserDeletion) error
+	Update(ctx context.Context, deletion *UserDeletion) error
+
+	// GetPending retrieves the user's pending deletion, or ErrNotFound if there is none
+	GetPending(ctx context.Context, userID string) (*UserDeletion, error)
+
+	// GetDue retrieves pending deletions whose grace period ended at or before now
+	GetDue(ctx context.Context, now time.Time) ([]*UserDeletion, error)
+
+	// List retrieves the most recent deletions, newest first
+	List(ctx context.Context, limit int) ([]*UserDeletion, error)
+
+	// PurgeUserData deletes the user and every row that belongs to them in one transaction
+	PurgeUserData(ctx context.Context, userID string) (*UserPurge, error)
+}
+
+// UnitOfWork runs several repository writes as one transaction
+type UnitOfWork interface {
+	// Do calls fn with a context that carries the transaction. Repository calls
+	// made with that context commit together when fn returns nil and roll back
+	// together when it returns an error. A Do inside another joins the outer one.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type unitOfWorkKey struct{}
+
+// WithinUnitOfWork marks ctx as running inside a unit of work; UnitOfWork
+// implementations call it on the context they pass to fn
+func WithinUnitOfWork(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unitOfWorkKey{}, true)
+}
+
+// InUnitOfWork reports whether ctx runs inside a unit of work, where a failed
+// write must be returned rather than logged so that the whole unit rolls back
+func InUnitOfWork(ctx context.Context) bool {
+	inside, _ := ctx.Value(unitOfWorkKey{}).(bool)
+	return inside
 }
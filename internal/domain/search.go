@@ -0,0 +1,231 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expense search sort keys
+const (
+	SearchSortRelevance  = "relevance"
+	SearchSortDateDesc   = "date_desc"
+	SearchSortDateAsc    = "date_asc"
+	SearchSortAmountDesc = "amount_desc"
+	SearchSortAmountAsc  = "amount_asc"
+)
+
+// maxSearchTerms caps how many words of a search are matched
+const maxSearchTerms = 8
+
+// ExpenseSearchQuery selects the expenses a search matches: a user's expenses
+// dated StartDate to EndDate whose description contains every term or, for
+// longer terms, a word close to it, optionally narrowed to a category and a
+// home amount range
+type ExpenseSearchQuery struct {
+	UserID     string
+	Terms      []string // From SearchTerms; none matches every description
+	CategoryID *string
+	MinAmount  *float64
+	MaxAmount  *float64
+	Tags       []string // Normalized tag names the expense must all carry
+	StartDate  time.Time
+	EndDate    time.Time
+	SortBy     string
+	Limit      int
+	Offset     int
+}
+
+// ExpenseSearchHit is an expense a search matched; a higher score is a better match
+type ExpenseSearchHit struct {
+	Expense *Expense
+	Score   float64
+}
+
+// SearchTerms splits a search into the distinct lowercase words it matches
+func SearchTerms(text string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isSearchSeparator) {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+func isSearchSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// SearchTermMaxEdits returns how many typos a search term tolerates: none
+// under 4 characters, one under 8 and two from then on
+func SearchTermMaxEdits(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// EditDistance returns how many single-character insertions, deletions,
+// substitutions and swaps of adjacent characters turn a into b
+func EditDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// Three rows of the distance matrix: two back, previous and current
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(t)]
+}
+
+// MatchSearchTerm returns the byte ranges of text a lowercase search term
+// matches, ignoring case: everywhere the term appears or, if it appears
+// nowhere, the words within SearchTermMaxEdits of it. Ranges are in order and
+// don't overlap; nil means the term doesn't match.
+func MatchSearchTerm(text, term string) [][2]int {
+	matches, _ := matchSearchTerm(text, term)
+	return matches
+}
+
+// matchSearchTerm is MatchSearchTerm, also reporting whether the term
+// appears as written rather than misspelled
+func matchSearchTerm(text, term string) (matches [][2]int, exact bool) {
+	if term == "" {
+		return nil, false
+	}
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	// offsets[i] is the byte offset of runes[i]; offsets[len(runes)] is len(text)
+	offsets := make([]int, 0, len(runes)+1)
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	for i := range text {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(text))
+
+	want := []rune(term)
+	for i := 0; i+len(want) <= len(lower); i++ {
+		if string(lower[i:i+len(want)]) == term {
+			matches = append(matches, [2]int{offsets[i], offsets[i+len(want)]})
+			i += len(want) - 1
+		}
+	}
+	if matches != nil {
+		return matches, true
+	}
+
+	maxEdits := SearchTermMaxEdits(term)
+	if maxEdits == 0 {
+		return nil, false
+	}
+	for start := 0; start < len(lower); {
+		if isSearchSeparator(lower[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(lower) && !isSearchSeparator(lower[end]) {
+			end++
+		}
+		if EditDistance(string(lower[start:end]), term) <= maxEdits {
+			matches = append(matches, [2]int{offsets[start], offsets[end]})
+		}
+		start = end
+	}
+	return matches, false
+}
+
+// ScoreSearch rates how well a description matches the terms, the way an
+// ExpenseSearchRepository without its own ranking does: 1 for each term found
+// as written and 0.5 for each found misspelled. ok is false if any term is missing.
+func ScoreSearch(description string, terms []string) (score float64, ok bool) {
+	for _, term := range terms {
+		matches, exact := matchSearchTerm(description, term)
+		switch {
+		case matches == nil:
+			return 0, false
+		case exact:
+			score++
+		default:
+			score += 0.5
+		}
+	}
+	return score, true
+}
+
+// Matches reports whether the query selects the expense, ignoring its terms
+func (q *ExpenseSearchQuery) Matches(expense *Expense) bool {
+	if expense.UserID != q.UserID || expense.DeletedAt != nil ||
+		expense.ExpenseDate.Before(q.StartDate) || expense.ExpenseDate.After(q.EndDate) {
+		return false
+	}
+	if q.CategoryID != nil && (expense.CategoryID == nil || *expense.CategoryID != *q.CategoryID) {
+		return false
+	}
+	if q.MinAmount != nil && expense.HomeAmount < *q.MinAmount {
+		return false
+	}
+	if !expense.HasTags(q.Tags) {
+		return false
+	}
+	return q.MaxAmount == nil || expense.HomeAmount <= *q.MaxAmount
+}
+
+// SortSearchHits orders hits by a search sort key. Relevance puts the best
+// matches first; ties, and unknown keys, go newest first.
+func SortSearchHits(hits []*ExpenseSearchHit, sortBy string) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := hits[i].Expense, hits[j].Expense
+		switch sortBy {
+		case SearchSortRelevance:
+			if hits[i].Score != hits[j].Score {
+				return hits[i].Score > hits[j].Score
+			}
+		case SearchSortDateAsc:
+			if !a.ExpenseDate.Equal(b.ExpenseDate) {
+				return a.ExpenseDate.Before(b.ExpenseDate)
+			}
+		case SearchSortAmountDesc:
+			if a.HomeAmount != b.HomeAmount {
+				return a.HomeAmount > b.HomeAmount
+			}
+		case SearchSortAmountAsc:
+			if a.HomeAmount != b.HomeAmount {
+				return a.HomeAmount < b.HomeAmount
+			}
+		}
+		if !a.ExpenseDate.Equal(b.ExpenseDate) {
+			return a.ExpenseDate.After(b.ExpenseDate)
+		}
+		return a.ID < b.ID
+	})
+}
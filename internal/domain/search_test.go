@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+	got := SearchTerms("Lunch, lunch & 午餐 (café)")
+	want := []string{"lunch", "午餐", "café"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"lunch", "lunch", 0},
+		{"lunhc", "lunch", 1},
+		{"lnch", "lunch", 1},
+		{"lunch", "launch", 1},
+		{"restuarant", "restaurant", 1},
+		{"dinner", "lunch", 5},
+		{"午餐", "午飯", 1},
+		{"", "tax", 3},
+	}
+	for _, tt := range tests {
+		if got := EditDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("EditDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchSearchTerm(t *testing.T) {
+	tests := []struct {
+		text, term string
+		want       [][2]int
+	}{
+		{"Lunch at lunchbox", "lunch", [][2]int{{0, 5}, {9, 14}}},
+		{"午餐便當", "便當", [][2]int{{6, 12}}},
+		{"Lunhc box", "lunch", [][2]int{{0, 5}}},
+		{"Taxi", "tac", nil},
+		{"Dinner", "lunch", nil},
+	}
+	for _, tt := range tests {
+		if got := MatchSearchTerm(tt.text, tt.term); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MatchSearchTerm(%q, %q) = %v, want %v", tt.text, tt.term, got, tt.want)
+		}
+	}
+}
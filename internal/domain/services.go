@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"io"
 	"time"
 )
 
@@ -43,4 +44,76 @@ type ExchangeRateService interface {
 	Convert(ctx context.Context, amount float64, fromCurrency, toCurrency string, txTime time.Time) (convertedAmount float64, rate float64, err error)
 	RefreshRates(ctx context.Context) error
 	GetRate(ctx context.Context, fromCurrency, toCurrency string, txTime time.Time) (*ExchangeRate, error)
+	GetRates(ctx context.Context, baseCurrency string, date time.Time) ([]*ExchangeRate, error)
+}
+
+// BlobStorage stores attachment and archive bytes, e.g. on local disk or in
+// an S3-compatible bucket
+type BlobStorage interface {
+	// Put stores data under key, replacing any existing object
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+
+	// Get retrieves the data stored under key
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Open streams the data stored under key; the caller closes it
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the data stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// EmailAttachment is a file attached to an email
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// EmailMessage is an email with an HTML body, a plain text alternative and optional attachments
+type EmailMessage struct {
+	To          string
+	Subject     string
+	HTMLBody    string
+	TextBody    string
+	Attachments []EmailAttachment
+}
+
+// EmailSender delivers email, e.g. through an SMTP server
+type EmailSender interface {
+	// Send delivers the message to its recipient
+	Send(ctx context.Context, msg *EmailMessage) error
+}
+
+// FieldCipher encrypts sensitive column values, such as expense descriptions
+// and raw AI output, before they are stored
+type FieldCipher interface {
+	// Encrypt returns the value to store for plaintext
+	Encrypt(plaintext string) (string, error)
+
+	// Decrypt returns the plaintext of a stored value. Values stored before
+	// encryption was enabled are returned unchanged.
+	Decrypt(stored string) (string, error)
+
+	// NeedsReencrypt reports whether a stored value is plaintext or encrypted
+	// with a key other than the current one
+	NeedsReencrypt(stored string) bool
+}
+
+// Cache stores short-lived values by key, e.g. in memory or in Redis
+type Cache interface {
+	// Get retrieves the value stored under key, reporting false when it is
+	// missing or expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key until ttl has passed
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes the value stored under key
+	Delete(ctx context.Context, key string) error
+}
+
+// ChartRenderer draws charts as PNG images
+type ChartRenderer interface {
+	Render(chart *Chart) ([]byte, error)
 }
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MaxTagNameLength is the most characters a tag name can have
+const MaxTagNameLength = 50
+
+// Tag labels expenses across categories, e.g. #出差 for a business trip or
+// #報帳 for expenses to claim back
+type Tag struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"` // Normalized by NormalizeTagName, without the #
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// TagTotal sums the selected expenses carrying one tag in home currency. An
+// expense with several tags counts toward each of them.
+type TagTotal struct {
+	TagID string
+	Name  string
+	Total float64
+	Count int
+}
+
+// NormalizeTagName returns the name a tag is stored under: without a leading
+// # or surrounding space, and lowercase, so #Travel and #travel are one tag.
+// It returns "" for a name that is not a valid tag: one made of anything but
+// letters, digits and underscores, only digits, or too long.
+func NormalizeTagName(name string) string {
+	name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	if name == "" || len([]rune(name)) > MaxTagNameLength {
+		return ""
+	}
+	hasLetter := false
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			hasLetter = true
+		case unicode.IsDigit(r) || r == '_':
+		default:
+			return ""
+		}
+	}
+	if !hasLetter {
+		return ""
+	}
+	return name
+}
+
+// NormalizeTagNames normalizes names, dropping invalid ones and duplicates
+func NormalizeTagNames(names []string) []string {
+	var normalized []string
+	for _, name := range names {
+		if name = NormalizeTagName(name); name != "" && !slices.Contains(normalized, name) {
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
+// hashtagPattern finds #tags starting a word, so "C#" and "a#b" aren't tags
+var hashtagPattern = regexp.MustCompile(`(^|\s)[#＃]([\p{L}\p{M}\p{N}_]+)`)
+
+// ParseHashtags returns the tags in a message, e.g. 出差 and 報帳 in
+// "計程車 300 #出差 #報帳", and the message without them
+func ParseHashtags(text string) ([]string, string) {
+	var tags []string
+	rest := hashtagPattern.ReplaceAllStringFunc(text, func(match string) string {
+		groups := hashtagPattern.FindStringSubmatch(match)
+		name := NormalizeTagName(groups[2])
+		if name == "" {
+			return match // Not a tag, e.g. #1
+		}
+		if !slices.Contains(tags, name) {
+			tags = append(tags, name)
+		}
+		return groups[1]
+	})
+	if len(tags) == 0 {
+		return nil, text
+	}
+	return tags, strings.Join(strings.Fields(rest), " ")
+}
+
+// HasTags reports whether the expense carries every one of the tags. Tags are
+// loaded separately, so they must be set.
+func (e *Expense) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(e.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
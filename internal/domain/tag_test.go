@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTagName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"#Travel", "travel"},
+		{" 出差 ", "出差"},
+		{"work_trip2", "work_trip2"},
+		{"2024", ""},
+		{"a-b", ""},
+		{"#", ""},
+		{strings.Repeat("a", MaxTagNameLength+1), ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeTagName(tt.name); got != tt.want {
+			t.Errorf("NormalizeTagName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseHashtags(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantTags []string
+		wantText string
+	}{
+		{"計程車 300 #出差 #報帳", []string{"出差", "報帳"}, "計程車 300"},
+		{"#Travel hotel 2000 #travel", []string{"travel"}, "hotel 2000"},
+		{"午餐 120 ＃公司", []string{"公司"}, "午餐 120"},
+		{"C# book 500 #1", nil, "C# book 500 #1"},
+	}
+	for _, tt := range tests {
+		tags, text := ParseHashtags(tt.text)
+		if !reflect.DeepEqual(tags, tt.wantTags) || text != tt.wantText {
+			t.Errorf("ParseHashtags(%q) = %v, %q, want %v, %q", tt.text, tags, text, tt.wantTags, tt.wantText)
+		}
+	}
+}
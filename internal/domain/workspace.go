@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// PersonalWorkspaceID is the workspace every user has, holding what they
+// record before creating any other; it has no row of its own
+const PersonalWorkspaceID = ""
+
+// MaxWorkspaceNameLength is the longest workspace name, in characters
+const MaxWorkspaceNameLength = 50
+
+// Workspace keeps a separate set of expenses, categories and budgets for one
+// of a user's lives, e.g. their business apart from the personal workspace
+type Workspace struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type workspaceKey struct{}
+
+// WithWorkspace returns a context that carries the workspace the user works
+// in: the expenses, categories and budgets repositories list with it are that
+// workspace's, and what is recorded with it goes into that workspace
+func WithWorkspace(ctx context.Context, workspaceID string) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+// WorkspaceFromContext returns the workspace carried by ctx, reporting false
+// when ctx carries none and listings span every workspace
+func WorkspaceFromContext(ctx context.Context) (string, bool) {
+	workspaceID, ok := ctx.Value(workspaceKey{}).(string)
+	return workspaceID, ok
+}
+
+// InWorkspace reports whether something in workspaceID is listed with ctx:
+// it is in the workspace ctx carries, or ctx carries none
+func InWorkspace(ctx context.Context, workspaceID string) bool {
+	current, ok := WorkspaceFromContext(ctx)
+	return !ok || current == workspaceID
+}
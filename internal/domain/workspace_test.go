@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInWorkspace(t *testing.T) {
+	ctx := context.Background()
+	if !InWorkspace(ctx, "ws-1") || !InWorkspace(ctx, PersonalWorkspaceID) {
+		t.Error("expected a context without a workspace to span every workspace")
+	}
+
+	personal := WithWorkspace(ctx, PersonalWorkspaceID)
+	if _, ok := WorkspaceFromContext(personal); !ok {
+		t.Error("expected the personal workspace to be carried")
+	}
+	if !InWorkspace(personal, PersonalWorkspaceID) || InWorkspace(personal, "ws-1") {
+		t.Error("expected only the personal workspace's rows")
+	}
+
+	business := WithWorkspace(ctx, "ws-1")
+	if !InWorkspace(business, "ws-1") || InWorkspace(business, PersonalWorkspaceID) {
+		t.Error("expected only ws-1's rows")
+	}
+}
@@ -0,0 +1,100 @@
+package i18n
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// currencySymbols are the symbols amounts are shown with, by currency, then
+// locale; "" is the symbol in every other locale. Currencies without one are
+// shown by their code after the amount.
+var currencySymbols = map[string]map[string]string{
+	"TWD": {"": "NT$"},
+	"USD": {"": "US$", "en": "$"},
+	"JPY": {"": "¥"},
+	"CNY": {"": "CN¥", "zh-CN": "¥"},
+	"HKD": {"": "HK$"},
+	"EUR": {"": "€"},
+	"GBP": {"": "£"},
+	"KRW": {"": "₩"},
+}
+
+// wholeCurrencies have no minor unit, so amounts in them are rounded
+var wholeCurrencies = map[string]bool{"JPY": true, "KRW": true}
+
+// dateLayouts are how dates are written, by locale
+var dateLayouts = map[string]string{
+	"en":    "Jan 2, 2006",
+	"zh-TW": "2006/1/2",
+	"zh-CN": "2006/1/2",
+	"ja":    "2006/01/02",
+}
+
+// FormatNumber writes a number with thousands separators and up to two
+// decimals, e.g. 1,234.5. Every locale we have groups digits the same way.
+func FormatNumber(locale string, n float64) string {
+	return groupThousands(strconv.FormatFloat(math.Round(n*100)/100, 'f', -1, 64))
+}
+
+// FormatMoney writes an amount with its currency's symbol, e.g. NT$1,234,
+// ¥1,234 or $12.34. Cents are shown only when the amount has any.
+func FormatMoney(locale string, amount float64, currency string) string {
+	currency = strings.ToUpper(currency)
+	var number string
+	switch {
+	case wholeCurrencies[currency]:
+		number = strconv.FormatFloat(math.Round(amount), 'f', 0, 64)
+	case math.Round(amount*100) == math.Round(amount)*100:
+		number = strconv.FormatFloat(math.Round(amount), 'f', 0, 64)
+	default:
+		number = strconv.FormatFloat(amount, 'f', 2, 64)
+	}
+	number = groupThousands(number)
+
+	symbols, ok := currencySymbols[currency]
+	if !ok {
+		if currency == "" {
+			return number
+		}
+		return number + " " + currency
+	}
+	symbol, ok := symbols[locale]
+	if !ok {
+		symbol = symbols[""]
+	}
+	if strings.HasPrefix(number, "-") {
+		return "-" + symbol + number[1:]
+	}
+	return symbol + number
+}
+
+// FormatDate writes a date the way the locale does
+func FormatDate(locale string, t time.Time) string {
+	layout, ok := dateLayouts[locale]
+	if !ok {
+		layout = dateLayouts[DefaultLocale]
+	}
+	return t.Format(layout)
+}
+
+// groupThousands adds separators to the integer part of a formatted number
+func groupThousands(number string) string {
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	integer, fraction, hasFraction := strings.Cut(number, ".")
+	var sb strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(digit)
+	}
+	if hasFraction {
+		sb.WriteString("." + fraction)
+	}
+	return sign + sb.String()
+}
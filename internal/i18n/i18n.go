@@ -0,0 +1,144 @@
+// Package i18n translates the messages users see. Each locale has a catalog
+// of message keys to text, a JSON file embedded in the binary; a key missing
+// from a catalog falls back to the default locale's text, then to the key.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultLocale is the locale of users who haven't picked one, and of
+// messages sent before their locale is known
+const DefaultLocale = "zh-TW"
+
+//go:embed locales/*.json
+var catalogFiles embed.FS
+
+// catalogs are the messages by locale, then key
+var catalogs = loadCatalogs()
+
+// loadCatalogs reads the embedded catalogs, named after their locale
+func loadCatalogs() map[string]map[string]string {
+	files, err := catalogFiles.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read catalogs: %v", err))
+	}
+	loaded := make(map[string]map[string]string, len(files))
+	for _, f := range files {
+		data, err := catalogFiles.ReadFile(path.Join("locales", f.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read %s: %v", f.Name(), err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: invalid catalog %s: %v", f.Name(), err))
+		}
+		loaded[strings.TrimSuffix(f.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+// Locales returns the locales that have a catalog, sorted
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Supported reports whether the locale has a catalog
+func Supported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// T returns the message in the locale
+func T(locale, key string) string {
+	if message, ok := catalogs[locale][key]; ok {
+		return message
+	}
+	if message, ok := catalogs[DefaultLocale][key]; ok {
+		return message
+	}
+	return key
+}
+
+// Tf returns the message in the locale, formatted with args as fmt.Sprintf does
+func Tf(locale, key string, args ...interface{}) string {
+	if len(args) == 0 {
+		return T(locale, key)
+	}
+	return fmt.Sprintf(T(locale, key), args...)
+}
+
+// Match returns the locale a language tag such as "en-US", "zh-Hant" or
+// "ja_JP" is answered in, or "" when there is no catalog for the language
+func Match(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	language, region, _ := strings.Cut(tag, "-")
+	switch language {
+	case "zh":
+		// Simplified for mainland China and Singapore, Traditional otherwise
+		switch region {
+		case "hans", "cn", "sg":
+			return "zh-CN"
+		}
+		return "zh-TW"
+	case "":
+		return ""
+	}
+	if Supported(language) {
+		return language
+	}
+	return ""
+}
+
+// MatchAcceptLanguage returns the locale of the most preferred language in an
+// Accept-Language header that has a catalog, or "" when none does
+func MatchAcceptLanguage(header string) string {
+	type preference struct {
+		locale string
+		q      float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if _, err := fmt.Sscanf(value, "%g", &q); err != nil {
+				continue
+			}
+		}
+		if locale := Match(tag); locale != "" && q > 0 {
+			preferences = append(preferences, preference{locale, q})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].q > preferences[j].q })
+	if len(preferences) == 0 {
+		return ""
+	}
+	return preferences[0].locale
+}
+
+type localeKey struct{}
+
+// WithLocale returns a context that carries the locale messages are sent in
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// FromContext returns the locale carried by ctx, or DefaultLocale
+func FromContext(ctx context.Context) string {
+	if locale, ok := ctx.Value(localeKey{}).(string); ok && locale != "" {
+		return locale
+	}
+	return DefaultLocale
+}
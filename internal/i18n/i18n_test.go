@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var verbPattern = regexp.MustCompile(`%[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// verbs returns the formatting verbs of a message, sorted so that
+// translations may reorder the words around them
+func verbs(message string) []string {
+	found := verbPattern.FindAllString(message, -1)
+	sort.Strings(found)
+	return found
+}
+
+func TestCatalogs(t *testing.T) {
+	assert.Equal(t, []string{"en", "ja", "zh-CN", "zh-TW"}, Locales())
+
+	// Every catalog has every message, with the same verbs as the default
+	for _, locale := range Locales() {
+		for key, message := range catalogs[DefaultLocale] {
+			translated, ok := catalogs[locale][key]
+			if assert.True(t, ok, "%s is missing %s", locale, key) {
+				assert.Equal(t, verbs(message), verbs(translated), "%s %s", locale, key)
+			}
+		}
+		for key := range catalogs[locale] {
+			_, ok := catalogs[DefaultLocale][key]
+			assert.True(t, ok, "%s has %s, which %s doesn't", locale, key, DefaultLocale)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "OK, cancelled.", T("en", "conversation.cancelled"))
+	assert.Equal(t, "好的，已取消。", T("zh-TW", "conversation.cancelled"))
+	// Unknown locales get the default locale's text, unknown keys the key
+	assert.Equal(t, "好的，已取消。", T("fr", "conversation.cancelled"))
+	assert.Equal(t, "no.such.key", T("en", "no.such.key"))
+
+	assert.Equal(t, "✓ Added category 'Pets'", Tf("en", "category.added", "Pets"))
+	assert.Equal(t, "Monthly report", Tf("en", "report.monthly_title"))
+}
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"en":         "en",
+		"en-US":      "en",
+		"ja_JP":      "ja",
+		"zh":         "zh-TW",
+		"zh-Hant-HK": "zh-TW",
+		"zh-hans":    "zh-CN",
+		"zh-CN":      "zh-CN",
+		"fr":         "",
+		"":           "",
+	}
+	for tag, want := range tests {
+		assert.Equal(t, want, Match(tag), tag)
+	}
+
+	assert.Equal(t, "ja", MatchAcceptLanguage("fr-FR, ja;q=0.8, en;q=0.5"))
+	assert.Equal(t, "en", MatchAcceptLanguage("ja;q=0.2, en-GB"))
+	assert.Equal(t, "", MatchAcceptLanguage("fr, de;q=0.9"))
+	assert.Equal(t, "", MatchAcceptLanguage(""))
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, DefaultLocale, FromContext(context.Background()))
+	assert.Equal(t, "ja", FromContext(WithLocale(context.Background(), "ja")))
+}
+
+func TestFormatMoney(t *testing.T) {
+	tests := []struct {
+		locale   string
+		amount   float64
+		currency string
+		want     string
+	}{
+		{"zh-TW", 1234, "TWD", "NT$1,234"},
+		{"en", 1234.5, "TWD", "NT$1,234.50"},
+		{"ja", 1234.4, "JPY", "¥1,234"},
+		{"en", 12.34, "USD", "$12.34"},
+		{"zh-TW", 12.34, "usd", "US$12.34"},
+		{"zh-CN", 88, "CNY", "¥88"},
+		{"en", 88, "CNY", "CN¥88"},
+		{"en", -1500, "EUR", "-€1,500"},
+		{"en", 1234567, "CHF", "1,234,567 CHF"},
+		{"en", 120, "", "120"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, FormatMoney(tt.locale, tt.amount, tt.currency), "%s %v %s", tt.locale, tt.amount, tt.currency)
+	}
+
+	assert.Equal(t, "1,234.57", FormatNumber("en", 1234.567))
+	assert.Equal(t, "999", FormatNumber("en", 999))
+	assert.Equal(t, "-1,000", FormatNumber("en", -1000))
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "Mar 5, 2026", FormatDate("en", date))
+	assert.Equal(t, "2026/3/5", FormatDate("zh-TW", date))
+	assert.Equal(t, "2026/03/05", FormatDate("ja", date))
+	assert.Equal(t, "2026/3/5", FormatDate("fr", date))
+}
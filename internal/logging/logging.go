@@ -0,0 +1,108 @@
+// Package logging sets up the structured logger and carries request-scoped
+// fields, such as the request ID and the user, through context so that every
+// line logged with that context includes them.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userKey
+)
+
+type user struct {
+	id        string
+	messenger string
+}
+
+// New creates a logger writing format ("text" or "json") lines at or above level
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithLevel(w, format, lvl)
+}
+
+// NewWithLevel creates a logger whose level can change while it logs, such
+// as a *slog.LevelVar that a configuration reload sets
+func NewWithLevel(w io.Writer, format string, level slog.Leveler) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text", "":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+	return slog.New(&contextHandler{handler}), nil
+}
+
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", level)
+	}
+	return lvl, nil
+}
+
+// NewRequestID returns a new random request ID
+func NewRequestID() string {
+	return uuid.New().String()
+}
+
+// WithRequestID returns a context whose log lines carry the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID returns the context's request ID, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUser returns a context whose log lines carry the user and the messenger
+// they wrote from; messenger may be empty for requests outside a messenger
+func WithUser(ctx context.Context, userID, messenger string) context.Context {
+	return context.WithValue(ctx, userKey, user{id: userID, messenger: messenger})
+}
+
+// contextHandler adds the request ID and user from the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if u, ok := ctx.Value(userKey).(user); ok {
+		record.AddAttrs(slog.String("user_id", u.id))
+		if u.messenger != "" {
+			record.AddAttrs(slog.String("messenger", u.messenger))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
@@ -2,19 +2,57 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/riverlin/aiexpense/internal/ai"
 	"github.com/riverlin/aiexpense/internal/domain"
 )
 
 type AICostUseCase struct {
 	aiCostRepo domain.AICostRepository
+	rollups    domain.MetricsRollupRepository
 }
 
 func NewAICostUseCase(aiCostRepo domain.AICostRepository) *AICostUseCase {
 	return &AICostUseCase{aiCostRepo: aiCostRepo}
 }
 
+// SetRollups makes the daily stats read the metrics aggregator's
+// precomputed rows instead of the cost logs
+func (u *AICostUseCase) SetRollups(rollups domain.MetricsRollupRepository) {
+	u.rollups = rollups
+}
+
+// dailyStats retrieves the cost per day, oldest first, leaving out days
+// without calls
+func (u *AICostUseCase) dailyStats(ctx context.Context, from, to time.Time) ([]*domain.AICostDailyStats, error) {
+	if u.rollups == nil {
+		return u.aiCostRepo.GetDailyStats(ctx, from, to)
+	}
+	rollups, err := u.rollups.GetRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var stats []*domain.AICostDailyStats
+	for _, r := range rollups {
+		if r.AICalls == 0 {
+			continue
+		}
+		stats = append(stats, &domain.AICostDailyStats{
+			Date:         r.Date,
+			Calls:        r.AICalls,
+			InputTokens:  r.AIInputTokens,
+			OutputTokens: r.AIOutputTokens,
+			TotalTokens:  r.AITotalTokens,
+			Cost:         r.AICost,
+		})
+	}
+	return stats, nil
+}
+
 type AICostMetricsRequest struct {
 	Days int
 }
@@ -39,7 +77,7 @@ func (u *AICostUseCase) GetAICostMetrics(ctx context.Context, req *AICostMetrics
 		return nil, err
 	}
 
-	dailyStats, err := u.aiCostRepo.GetDailyStats(ctx, from, to)
+	dailyStats, err := u.dailyStats(ctx, from, to)
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +127,7 @@ func (u *AICostUseCase) GetDailyStats(ctx context.Context, req *AICostDailyReque
 	to := time.Now()
 	from := to.AddDate(0, 0, -req.Days)
 
-	return u.aiCostRepo.GetDailyStats(ctx, from, to)
+	return u.dailyStats(ctx, from, to)
 }
 
 type AICostByOperationRequest struct {
@@ -125,3 +163,85 @@ func (u *AICostUseCase) GetTopUsers(ctx context.Context, req *AICostByUserReques
 
 	return u.aiCostRepo.GetByUserSummary(ctx, from, to, req.Limit)
 }
+
+// servedBy returns the provider and model an AI response names, e.g. after a
+// failover, or the configured ones when it names none
+func servedBy(provider, model, respProvider, respModel string) (string, string) {
+	if respProvider == "" {
+		return provider, model
+	}
+	return respProvider, respModel
+}
+
+// currentModel returns the model the AI service calls now, which a
+// configuration reload may have switched since the use case was created
+func currentModel(aiService ai.Service, model string) string {
+	if configurable, ok := aiService.(ai.ModelConfigurable); ok {
+		return configurable.Model()
+	}
+	return model
+}
+
+// logAICost prices token usage for provider/model and persists it as an AI cost log entry
+func logAICost(
+	ctx context.Context,
+	pricingRepo domain.PricingRepository,
+	costRepo domain.AICostRepository,
+	provider, model, userID, operation string,
+	tokens *ai.TokenMetadata,
+	promptVersion int,
+) {
+	if tokens == nil || costRepo == nil || pricingRepo == nil {
+		return
+	}
+
+	// Skip logging if no tokens were used (fallback parsing or zero input)
+	if tokens.TotalTokens == 0 {
+		return
+	}
+
+	// Look up pricing for provider/model
+	pricing, err := pricingRepo.GetByProviderAndModel(ctx, provider, model)
+	if errors.Is(err, domain.ErrNotFound) {
+		pricing, err = nil, nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to look up AI pricing", "provider", provider, "model", model, "error", err)
+		return
+	}
+
+	var cost float64
+	var costNote *string
+	if pricing == nil {
+		// Pricing not configured
+		cost = 0
+		msg := "pricing_not_configured"
+		costNote = &msg
+		slog.WarnContext(ctx, "AI pricing not configured", "provider", provider, "model", model)
+	} else {
+		// Calculate cost
+		cost = pricing.GetCost(tokens.InputTokens, tokens.OutputTokens)
+	}
+
+	// Create and persist cost log
+	costLog := &domain.AICostLog{
+		ID:            fmt.Sprintf("log_%d", time.Now().UnixNano()),
+		UserID:        userID,
+		Operation:     operation,
+		Provider:      provider,
+		Model:         model,
+		InputTokens:   tokens.InputTokens,
+		OutputTokens:  tokens.OutputTokens,
+		TotalTokens:   tokens.TotalTokens,
+		Cost:          cost,
+		Currency:      "USD",
+		CostNote:      costNote,
+		PromptVersion: promptVersion,
+		Source:        domain.AuditSourceFromContext(ctx).Channel,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if err := costRepo.Create(ctx, costLog); err != nil {
+		slog.ErrorContext(ctx, "Failed to log AI cost", "error", err)
+	}
+}
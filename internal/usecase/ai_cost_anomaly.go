@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Defaults of the AI cost anomaly detector
+const (
+	DefaultAICostAnomalyFactor     = 3.0
+	DefaultAICostAnomalyMinUSD     = 1.0
+	DefaultAICostAnomalyWindowDays = 7
+)
+
+// aiCostAnomalyListLimit is how many anomalies the admin list returns
+const aiCostAnomalyListLimit = 50
+
+// AICostAnomalyDetector flags UTC days whose AI spend reaches factor times the
+// average daily spend of the week before, so runaway model spend surfaces
+// without anyone watching the charts. Days below minUSD are never flagged,
+// so a quiet baseline doesn't turn cents into alerts.
+//
+// An anomaly is stored once per day and updated as the day's spend grows.
+// The first detection is logged and published as an ai_cost.anomaly_detected
+// event, which is what the admin dashboard alerts on.
+type AICostAnomalyDetector struct {
+	costRepo    domain.AICostRepository
+	anomalyRepo domain.AICostAnomalyRepository
+	factor      float64
+	minUSD      float64
+	windowDays  int
+	events      EventPublisher
+	now         func() time.Time
+}
+
+// NewAICostAnomalyDetector creates a detector flagging days that spend at
+// least factor times the rolling average and at least minUSD
+func NewAICostAnomalyDetector(costRepo domain.AICostRepository, anomalyRepo domain.AICostAnomalyRepository, factor, minUSD float64) *AICostAnomalyDetector {
+	if factor <= 1 {
+		factor = DefaultAICostAnomalyFactor
+	}
+	return &AICostAnomalyDetector{
+		costRepo:    costRepo,
+		anomalyRepo: anomalyRepo,
+		factor:      factor,
+		minUSD:      minUSD,
+		windowDays:  DefaultAICostAnomalyWindowDays,
+		now:         time.Now,
+	}
+}
+
+// SetEventPublisher publishes an ai_cost.anomaly_detected event for each new anomaly
+func (d *AICostAnomalyDetector) SetEventPublisher(events EventPublisher) {
+	d.events = events
+}
+
+// Detect checks yesterday and today so far against the days before each, and
+// returns the anomalies detected for the first time
+func (d *AICostAnomalyDetector) Detect(ctx context.Context) ([]*domain.AICostAnomaly, error) {
+	now := d.now()
+	today := domain.MetricsDay(now)
+	stats, err := d.costRepo.GetDailyStats(ctx, today.AddDate(0, 0, -d.windowDays-1), now)
+	if err != nil {
+		return nil, err
+	}
+	byDay := make(map[time.Time]*domain.AICostDailyStats, len(stats))
+	for _, s := range stats {
+		byDay[domain.MetricsDay(s.Date)] = s
+	}
+
+	var detected []*domain.AICostAnomaly
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		spent := byDay[day]
+		if spent == nil || spent.Cost < d.minUSD {
+			continue
+		}
+
+		// Days without calls count as zero spend
+		var windowCost float64
+		for i := 1; i <= d.windowDays; i++ {
+			if s := byDay[day.AddDate(0, 0, -i)]; s != nil {
+				windowCost += s.Cost
+			}
+		}
+		baseline := windowCost / float64(d.windowDays)
+		if baseline <= 0 || spent.Cost < d.factor*baseline {
+			continue
+		}
+
+		anomaly, created, err := d.record(ctx, day, spent, baseline, now)
+		if err != nil {
+			return detected, err
+		}
+		if created {
+			detected = append(detected, anomaly)
+		}
+	}
+	return detected, nil
+}
+
+// record stores the day's anomaly, or updates it if the day was already flagged
+func (d *AICostAnomalyDetector) record(ctx context.Context, day time.Time, spent *domain.AICostDailyStats, baseline float64, now time.Time) (*domain.AICostAnomaly, bool, error) {
+	anomaly, err := d.anomalyRepo.GetByDate(ctx, day)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, false, err
+	}
+	if anomaly != nil {
+		anomaly.Cost = spent.Cost
+		anomaly.Calls = spent.Calls
+		anomaly.BaselineCost = baseline
+		anomaly.Ratio = spent.Cost / baseline
+		anomaly.UpdatedAt = now
+		return anomaly, false, d.anomalyRepo.Update(ctx, anomaly)
+	}
+
+	anomaly = &domain.AICostAnomaly{
+		ID:           uuid.New().String(),
+		Date:         day,
+		Cost:         spent.Cost,
+		Calls:        spent.Calls,
+		BaselineCost: baseline,
+		Ratio:        spent.Cost / baseline,
+		DetectedAt:   now,
+		UpdatedAt:    now,
+	}
+	if err := d.anomalyRepo.Create(ctx, anomaly); err != nil {
+		return nil, false, err
+	}
+
+	slog.WarnContext(ctx, "AI cost anomaly detected", "date", day.Format("2006-01-02"), "cost_usd", anomaly.Cost, "baseline_usd", baseline, "ratio", anomaly.Ratio)
+	if d.events != nil {
+		d.events.Publish(ctx, "", domain.EventAICostAnomalyDetected, anomaly)
+	}
+	return anomaly, true, nil
+}
+
+// ListAnomalies returns the latest anomalies, newest day first
+func (d *AICostAnomalyDetector) ListAnomalies(ctx context.Context) ([]*domain.AICostAnomaly, error) {
+	anomalies, err := d.anomalyRepo.List(ctx, aiCostAnomalyListLimit)
+	if err != nil {
+		return nil, err
+	}
+	if anomalies == nil {
+		anomalies = []*domain.AICostAnomaly{}
+	}
+	return anomalies, nil
+}
+
+// Run detects anomalies once per interval until ctx is done
+func (d *AICostAnomalyDetector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.Detect(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to detect AI cost anomalies", "error", err)
+			}
+		}
+	}
+}
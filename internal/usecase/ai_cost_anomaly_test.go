@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestAICostAnomalyDetector(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC)
+	today := domain.MetricsDay(now)
+
+	costRepo := NewMockAICostRepository()
+	spend := func(day time.Time, cost float64) {
+		costRepo.Create(ctx, &domain.AICostLog{ID: fmt.Sprintf("log_%d", len(costRepo.logs)), UserID: "U1", Cost: cost, CreatedAt: day.Add(time.Hour)})
+	}
+	// A steady $1 a day, then $2 yesterday: double, but under the 3x factor
+	for i := 8; i >= 2; i-- {
+		spend(today.AddDate(0, 0, -i), 1)
+	}
+	spend(today.AddDate(0, 0, -1), 2)
+
+	anomalyRepo := NewMockAICostAnomalyRepository()
+	publisher := &recordingPublisher{}
+	detector := NewAICostAnomalyDetector(costRepo, anomalyRepo, 3, 1)
+	detector.SetEventPublisher(publisher)
+	detector.now = func() time.Time { return now }
+
+	if detected, err := detector.Detect(ctx); err != nil || len(detected) != 0 {
+		t.Fatalf("expected no anomaly, got %v, %v", detected, err)
+	}
+
+	// Today reaches 3x the week's average of 8/7
+	spend(today, 3.5)
+	detected, err := detector.Detect(ctx)
+	if err != nil || len(detected) != 1 {
+		t.Fatalf("expected 1 anomaly, got %v, %v", detected, err)
+	}
+	if !detected[0].Date.Equal(today) || detected[0].Ratio < 3 || detected[0].Ratio > 3.1 {
+		t.Errorf("unexpected anomaly: %+v", detected[0])
+	}
+	if len(publisher.events) != 1 || publisher.events[0] != " "+domain.EventAICostAnomalyDetected {
+		t.Errorf("expected an anomaly event for the admins, got %v", publisher.events)
+	}
+
+	// Spending more the same day updates the anomaly without alerting again
+	spend(today, 1)
+	if detected, _ := detector.Detect(ctx); len(detected) != 0 || len(publisher.events) != 1 {
+		t.Errorf("expected the anomaly updated quietly, got %v and events %v", detected, publisher.events)
+	}
+	anomalies, err := detector.ListAnomalies(ctx)
+	if err != nil || len(anomalies) != 1 || anomalies[0].Cost != 4.5 {
+		t.Fatalf("expected the updated anomaly listed, got %v, %v", anomalies, err)
+	}
+
+	t.Run("Ignores spend below the minimum", func(t *testing.T) {
+		quiet := NewAICostAnomalyDetector(costRepo, NewMockAICostAnomalyRepository(), 3, 10)
+		quiet.now = detector.now
+		if detected, _ := quiet.Detect(ctx); len(detected) != 0 {
+			t.Errorf("expected no anomaly under $10, got %v", detected)
+		}
+	})
+}
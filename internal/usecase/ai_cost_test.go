@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/ai"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestLogAICost_RecordsSource(t *testing.T) {
+	pricingRepo := NewMockPricingRepository()
+	pricingRepo.Create(context.Background(), &domain.PricingConfig{Provider: "gemini", Model: "flash", InputTokenPrice: 1, OutputTokenPrice: 2})
+	costRepo := NewMockAICostRepository()
+
+	ctx := domain.WithAuditSource(context.Background(), domain.AuditSource{Actor: "U1", Channel: "telegram"})
+	logAICost(ctx, pricingRepo, costRepo, "gemini", "flash", "U1", "parse_expense", &ai.TokenMetadata{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}, 0)
+
+	if len(costRepo.logs) != 1 {
+		t.Fatalf("expected 1 cost log, got %d", len(costRepo.logs))
+	}
+	if costRepo.logs[0].Source != "telegram" {
+		t.Errorf("expected the cost attributed to telegram, got %q", costRepo.logs[0].Source)
+	}
+}
+
+func TestServedBy_AttributesFailover(t *testing.T) {
+	if provider, model := servedBy("gemini", "flash", "", ""); provider != "gemini" || model != "flash" {
+		t.Errorf("expected the configured provider, got %s/%s", provider, model)
+	}
+	if provider, model := servedBy("gemini", "flash", "claude", "haiku"); provider != "claude" || model != "haiku" {
+		t.Errorf("expected the provider that served the call, got %s/%s", provider, model)
+	}
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// apiKeyPrefixLength is how much of a key is kept in the clear to tell keys apart
+const apiKeyPrefixLength = 12
+
+var (
+	// ErrAPIKeyInvalid is returned for a missing, unknown or expired API key
+	ErrAPIKeyInvalid = errors.New("invalid or expired API key")
+	// ErrAPIKeyScope is returned when a valid key lacks the scope an endpoint needs
+	ErrAPIKeyScope = errors.New("API key lacks the required scope")
+	// ErrAPIKeyNotFound is returned when revoking a key that doesn't exist
+	ErrAPIKeyNotFound = fmt.Errorf("API key %w", domain.ErrNotFound)
+)
+
+// APIKeyUseCase manages the API keys that grant access to admin endpoints.
+// The bootstrap key from ADMIN_API_KEY holds every scope, so it can create the
+// first stored keys; if neither it nor any stored key exists, admin endpoints
+// stay open, as they were before keys were configured.
+type APIKeyUseCase struct {
+	repo         domain.APIKeyRepository
+	bootstrapKey string
+	now          func() time.Time
+}
+
+// NewAPIKeyUseCase creates a new API key use case; bootstrapKey may be empty
+func NewAPIKeyUseCase(repo domain.APIKeyRepository, bootstrapKey string) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		repo:         repo,
+		bootstrapKey: bootstrapKey,
+		now:          time.Now,
+	}
+}
+
+// CreateAPIKeyRequest represents a request to issue a key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreatedAPIKey is a newly issued key along with its secret, which is not shown again
+type CreatedAPIKey struct {
+	*domain.APIKey
+	Key string `json:"key"`
+}
+
+// Create issues a new key with the requested scopes
+func (u *APIKeyUseCase) Create(ctx context.Context, req *CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	if req.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if len(req.Scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range req.Scopes {
+		if !isAPIKeyScope(scope) {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	now := u.now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("expires_at must be in the future")
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	raw := "aek_" + hex.EncodeToString(secret)
+
+	key := &domain.APIKey{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		Prefix:    raw[:apiKeyPrefixLength],
+		KeyHash:   hashAPIKey(raw),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: now,
+	}
+	if err := u.repo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+	return &CreatedAPIKey{APIKey: key, Key: raw}, nil
+}
+
+// List retrieves all stored keys, without their secrets
+func (u *APIKeyUseCase) List(ctx context.Context) ([]*domain.APIKey, error) {
+	keys, err := u.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	if keys == nil {
+		keys = []*domain.APIKey{}
+	}
+	return keys, nil
+}
+
+// Revoke deletes a stored key so it no longer works
+func (u *APIKeyUseCase) Revoke(ctx context.Context, id string) error {
+	keys, err := u.repo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list API keys: %w", err)
+	}
+	for _, key := range keys {
+		if key.ID == id {
+			if err := u.repo.Delete(ctx, id); err != nil {
+				return fmt.Errorf("failed to delete API key: %w", err)
+			}
+			return nil
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+// Authorize checks that raw is a current key holding scope. It returns
+// ErrAPIKeyInvalid if the key is missing, unknown or expired, and
+// ErrAPIKeyScope if it doesn't grant scope.
+func (u *APIKeyUseCase) Authorize(ctx context.Context, raw, scope string) error {
+	if u.bootstrapKey != "" && subtle.ConstantTimeCompare([]byte(raw), []byte(u.bootstrapKey)) == 1 {
+		return nil
+	}
+
+	if raw == "" {
+		if u.bootstrapKey == "" {
+			keys, err := u.repo.List(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list API keys: %w", err)
+			}
+			if len(keys) == 0 {
+				return nil
+			}
+		}
+		return ErrAPIKeyInvalid
+	}
+
+	key, err := u.repo.GetByHash(ctx, hashAPIKey(raw))
+	if errors.Is(err, domain.ErrNotFound) {
+		return ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get API key: %w", err)
+	}
+	now := u.now()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return ErrAPIKeyInvalid
+	}
+	if !key.Allows(scope) {
+		return ErrAPIKeyScope
+	}
+
+	if err := u.repo.UpdateLastUsed(ctx, key.ID, now); err != nil {
+		slog.WarnContext(ctx, "Failed to record API key use", "key_id", key.ID, "error", err)
+	}
+	return nil
+}
+
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func isAPIKeyScope(scope string) bool {
+	for _, s := range domain.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
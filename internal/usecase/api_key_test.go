@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestAPIKeyUseCase(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Scoped key", func(t *testing.T) {
+		repo := NewMockAPIKeyRepository()
+		uc := NewAPIKeyUseCase(repo, "bootstrap")
+
+		created, err := uc.Create(ctx, &CreateAPIKeyRequest{Name: "grafana", Scopes: []string{domain.APIKeyScopeMetricsRead}})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if !strings.HasPrefix(created.Key, created.Prefix) || repo.keys[0].KeyHash == created.Key {
+			t.Errorf("expected only a hash of the key to be stored, got %+v", repo.keys[0])
+		}
+
+		if err := uc.Authorize(ctx, created.Key, domain.APIKeyScopeMetricsRead); err != nil {
+			t.Errorf("expected the key to read metrics, got %v", err)
+		}
+		if repo.keys[0].LastUsedAt == nil {
+			t.Error("expected the key's use to be recorded")
+		}
+		if err := uc.Authorize(ctx, created.Key, domain.APIKeyScopePricingWrite); !errors.Is(err, ErrAPIKeyScope) {
+			t.Errorf("expected ErrAPIKeyScope, got %v", err)
+		}
+		if err := uc.Authorize(ctx, "aek_unknown", domain.APIKeyScopeMetricsRead); !errors.Is(err, ErrAPIKeyInvalid) {
+			t.Errorf("expected ErrAPIKeyInvalid, got %v", err)
+		}
+
+		if err := uc.Revoke(ctx, created.ID); err != nil {
+			t.Fatalf("Revoke failed: %v", err)
+		}
+		if err := uc.Authorize(ctx, created.Key, domain.APIKeyScopeMetricsRead); !errors.Is(err, ErrAPIKeyInvalid) {
+			t.Errorf("expected a revoked key to be rejected, got %v", err)
+		}
+		if err := uc.Revoke(ctx, created.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+			t.Errorf("expected ErrAPIKeyNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Expired key", func(t *testing.T) {
+		uc := NewAPIKeyUseCase(NewMockAPIKeyRepository(), "")
+		now := time.Now()
+		uc.now = func() time.Time { return now }
+
+		expiresAt := now.Add(time.Hour)
+		created, err := uc.Create(ctx, &CreateAPIKeyRequest{Name: "temp", Scopes: []string{domain.APIKeyScopeAdmin}, ExpiresAt: &expiresAt})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := uc.Authorize(ctx, created.Key, domain.APIKeyScopePromptsWrite); err != nil {
+			t.Errorf("expected an admin key to hold every scope, got %v", err)
+		}
+
+		now = expiresAt
+		if err := uc.Authorize(ctx, created.Key, domain.APIKeyScopeAdmin); !errors.Is(err, ErrAPIKeyInvalid) {
+			t.Errorf("expected ErrAPIKeyInvalid, got %v", err)
+		}
+	})
+
+	t.Run("Bootstrap key", func(t *testing.T) {
+		uc := NewAPIKeyUseCase(NewMockAPIKeyRepository(), "bootstrap")
+		if err := uc.Authorize(ctx, "bootstrap", domain.APIKeyScopeAdmin); err != nil {
+			t.Errorf("expected the bootstrap key to be accepted, got %v", err)
+		}
+		if err := uc.Authorize(ctx, "", domain.APIKeyScopeMetricsRead); !errors.Is(err, ErrAPIKeyInvalid) {
+			t.Errorf("expected a missing key to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("Open until a key exists", func(t *testing.T) {
+		uc := NewAPIKeyUseCase(NewMockAPIKeyRepository(), "")
+		if err := uc.Authorize(ctx, "", domain.APIKeyScopeAdmin); err != nil {
+			t.Errorf("expected admin endpoints to be open without keys, got %v", err)
+		}
+		if _, err := uc.Create(ctx, &CreateAPIKeyRequest{Name: "ops", Scopes: []string{domain.APIKeyScopeAdmin}}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := uc.Authorize(ctx, "", domain.APIKeyScopeAdmin); !errors.Is(err, ErrAPIKeyInvalid) {
+			t.Errorf("expected a key to be required once one exists, got %v", err)
+		}
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		uc := NewAPIKeyUseCase(NewMockAPIKeyRepository(), "")
+		past := time.Now().Add(-time.Hour)
+		for name, req := range map[string]*CreateAPIKeyRequest{
+			"missing name":    {Scopes: []string{domain.APIKeyScopeAdmin}},
+			"missing scopes":  {Name: "ops"},
+			"unknown scope":   {Name: "ops", Scopes: []string{"expenses:write"}},
+			"already expired": {Name: "ops", Scopes: []string{domain.APIKeyScopeAdmin}, ExpiresAt: &past},
+		} {
+			if _, err := uc.Create(ctx, req); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+}
@@ -1,41 +1,100 @@
 package usecase
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/riverlin/aiexpense/internal/domain"
 )
 
-// ArchiveUseCase handles data archiving and retention
+// ErrArchivesDisabled is returned when no archive storage is configured
+var ErrArchivesDisabled = errors.New("archive storage is not configured")
+
+// ArchiveUseCase handles data archiving and retention. An archive's expenses
+// are written as a compressed bundle to object storage; only its metadata is
+// kept in the database.
 type ArchiveUseCase struct {
-	expenseRepo domain.ExpenseRepository
+	expenseRepo  domain.ExpenseRepository
+	categoryRepo domain.CategoryRepository
+	archiveRepo  domain.ArchiveRepository
+	storage      domain.BlobStorage
+	uow          domain.UnitOfWork
+	now          func() time.Time
 }
 
 // NewArchiveUseCase creates a new archive use case
 func NewArchiveUseCase(
 	expenseRepo domain.ExpenseRepository,
+	categoryRepo domain.CategoryRepository,
 ) *ArchiveUseCase {
 	return &ArchiveUseCase{
-		expenseRepo: expenseRepo,
+		expenseRepo:  expenseRepo,
+		categoryRepo: categoryRepo,
+		now:          time.Now,
 	}
 }
 
-// Archive represents an archived data snapshot
-type Archive struct {
+// SetStorage sets where archive metadata and bundles are kept; without it,
+// archives can't be created or read
+func (u *ArchiveUseCase) SetStorage(archiveRepo domain.ArchiveRepository, storage domain.BlobStorage) {
+	u.archiveRepo = archiveRepo
+	u.storage = storage
+}
+
+// SetUnitOfWork restores each archive in one transaction, so a restore that
+// fails part way, e.g. on a corrupt bundle, changes nothing
+func (u *ArchiveUseCase) SetUnitOfWork(uow domain.UnitOfWork) {
+	u.uow = uow
+}
+
+// archivedExpense is one line of an archive bundle
+type archivedExpense struct {
 	ID             string    `json:"id"`
-	UserID         string    `json:"user_id"`
-	Period         string    `json:"period"` // "monthly", "yearly", "custom"
-	StartDate      time.Time `json:"start_date"`
-	EndDate        time.Time `json:"end_date"`
-	ExpenseCount   int       `json:"expense_count"`
-	TotalAmount    float64   `json:"total_amount"`
-	Checksum       string    `json:"checksum"` // For data integrity
+	Description    string    `json:"description"`
+	OriginalAmount float64   `json:"original_amount"`
+	Currency       string    `json:"currency"`
+	HomeAmount     float64   `json:"home_amount"`
+	HomeCurrency   string    `json:"home_currency"`
+	ExchangeRate   float64   `json:"exchange_rate"`
+	CategoryID     *string   `json:"category_id,omitempty"`
+	Category       string    `json:"category,omitempty"`
+	GroupID        *string   `json:"group_id,omitempty"`
+	Account        string    `json:"account"`
+	WorkspaceID    string    `json:"workspace_id,omitempty"`
+	ExpenseDate    time.Time `json:"expense_date"`
 	CreatedAt      time.Time `json:"created_at"`
-	CompressedSize int64     `json:"compressed_size"`
-	RetentionDays  int       `json:"retention_days"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (e *archivedExpense) expense(userID string) *domain.Expense {
+	return &domain.Expense{
+		ID:             e.ID,
+		UserID:         userID,
+		Description:    e.Description,
+		OriginalAmount: e.OriginalAmount,
+		Currency:       e.Currency,
+		HomeAmount:     e.HomeAmount,
+		HomeCurrency:   e.HomeCurrency,
+		ExchangeRate:   e.ExchangeRate,
+		CategoryID:     e.CategoryID,
+		GroupID:        e.GroupID,
+		Account:        e.Account,
+		WorkspaceID:    e.WorkspaceID,
+		ExpenseDate:    e.ExpenseDate,
+		CreatedAt:      e.CreatedAt,
+		UpdatedAt:      e.UpdatedAt,
+		Amount:         e.HomeAmount,
+	}
 }
 
 // CreateArchiveRequest represents a request to create an archive
@@ -44,21 +103,30 @@ type CreateArchiveRequest struct {
 	Period        string // "monthly", "yearly", "custom"
 	StartDate     time.Time
 	EndDate       time.Time
-	RetentionDays int // How long to keep this archive (0 = indefinite)
+	RetentionDays int // How long to keep this archive (0 = 7 years)
 }
 
 // CreateArchiveResponse represents the response after creating an archive
 type CreateArchiveResponse struct {
-	ArchiveID string
-	Period    string
-	Message   string
+	ArchiveID string          `json:"archive_id"`
+	Period    string          `json:"period"`
+	Archive   *domain.Archive `json:"archive"`
+	Message   string          `json:"message"`
 }
 
-// CreateArchive creates a data archive for a period
+// CreateArchive writes the user's expenses in a period to object storage as
+// gzip-compressed JSON Lines and records the archive's metadata. The expenses
+// themselves are left in place.
 func (u *ArchiveUseCase) CreateArchive(ctx context.Context, req *CreateArchiveRequest) (*CreateArchiveResponse, error) {
 	if req.UserID == "" {
 		return nil, fmt.Errorf("user_id is required")
 	}
+	if u.archiveRepo == nil || u.storage == nil {
+		return nil, ErrArchivesDisabled
+	}
+	if req.EndDate.Before(req.StartDate) {
+		return nil, fmt.Errorf("end_date must not be before start_date")
+	}
 
 	if req.Period == "" {
 		req.Period = "monthly"
@@ -74,28 +142,96 @@ func (u *ArchiveUseCase) CreateArchive(ctx context.Context, req *CreateArchiveRe
 		return nil, fmt.Errorf("failed to archive: %w", err)
 	}
 
-	// Calculate total
-	total := 0.0
-	for _, exp := range expenses {
-		total += exp.Amount
+	archive, err := u.writeArchive(ctx, req, expenses)
+	if err != nil {
+		return nil, err
 	}
 
-	archiveID := uuid.New().String()
-
-	// In production:
-	// 1. Create archive record in database
-	// 2. Compress expense data
-	// 3. Store metadata (checksum, size, etc.)
-	// 4. Optionally delete original records if older than threshold
-
 	return &CreateArchiveResponse{
-		ArchiveID: archiveID,
-		Period:    req.Period,
+		ArchiveID: archive.ID,
+		Period:    archive.Period,
+		Archive:   archive,
 		Message: fmt.Sprintf("Created archive for period %s-%s with %d expenses (total: %.2f)",
-			req.StartDate.Format("2006-01-02"), req.EndDate.Format("2006-01-02"), len(expenses), total),
+			req.StartDate.Format("2006-01-02"), req.EndDate.Format("2006-01-02"), archive.ExpenseCount, archive.TotalAmount),
 	}, nil
 }
 
+// writeArchive stores the expenses as the bundle of a new archive of the
+// request's period and records it
+func (u *ArchiveUseCase) writeArchive(ctx context.Context, req *CreateArchiveRequest, expenses []*domain.Expense) (*domain.Archive, error) {
+	categories, err := u.categoryNames(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var raw bytes.Buffer
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	enc := json.NewEncoder(io.MultiWriter(&raw, zw))
+	total := 0.0
+	for _, exp := range expenses {
+		line := &archivedExpense{
+			ID:             exp.ID,
+			Description:    exp.Description,
+			OriginalAmount: exp.OriginalAmount,
+			Currency:       exp.Currency,
+			HomeAmount:     exp.HomeAmount,
+			HomeCurrency:   exp.HomeCurrency,
+			ExchangeRate:   exp.ExchangeRate,
+			CategoryID:     exp.CategoryID,
+			GroupID:        exp.GroupID,
+			Account:        exp.Account,
+			WorkspaceID:    exp.WorkspaceID,
+			ExpenseDate:    exp.ExpenseDate,
+			CreatedAt:      exp.CreatedAt,
+			UpdatedAt:      exp.UpdatedAt,
+		}
+		if exp.CategoryID != nil {
+			line.Category = categories[*exp.CategoryID]
+		}
+		if err := enc.Encode(line); err != nil {
+			return nil, fmt.Errorf("failed to encode archive: %w", err)
+		}
+		total += exp.HomeAmount
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress archive: %w", err)
+	}
+
+	sum := sha256.Sum256(compressed.Bytes())
+	now := u.now()
+	archive := &domain.Archive{
+		ID:             uuid.New().String(),
+		UserID:         req.UserID,
+		Period:         req.Period,
+		StartDate:      req.StartDate,
+		EndDate:        req.EndDate,
+		ExpenseCount:   len(expenses),
+		TotalAmount:    total,
+		Format:         domain.ArchiveFormatJSONLinesGzip,
+		Checksum:       hex.EncodeToString(sum[:]),
+		Size:           int64(raw.Len()),
+		CompressedSize: int64(compressed.Len()),
+		RetentionDays:  req.RetentionDays,
+		CreatedAt:      now,
+	}
+	archive.ObjectKey = fmt.Sprintf("archives/%s/%s.%s", req.UserID, archive.ID, archive.Format)
+	if req.RetentionDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.RetentionDays)
+		archive.ExpiresAt = &expiresAt
+	}
+
+	if err := u.storage.Put(ctx, archive.ObjectKey, compressed.Bytes(), "application/gzip"); err != nil {
+		return nil, fmt.Errorf("failed to store archive: %w", err)
+	}
+	if err := u.archiveRepo.Create(ctx, archive); err != nil {
+		if delErr := u.storage.Delete(ctx, archive.ObjectKey); delErr != nil {
+			slog.WarnContext(ctx, "Failed to delete orphaned archive bundle", "object_key", archive.ObjectKey, "error", delErr)
+		}
+		return nil, fmt.Errorf("failed to save archive: %w", err)
+	}
+	return archive, nil
+}
+
 // ListArchivesRequest represents a request to list archives
 type ListArchivesRequest struct {
 	UserID string
@@ -105,22 +241,38 @@ type ListArchivesRequest struct {
 
 // ListArchivesResponse represents a list of archives
 type ListArchivesResponse struct {
-	Archives []*Archive `json:"archives"`
-	Total    int        `json:"total"`
-	Message  string     `json:"message"`
+	Archives []*domain.Archive `json:"archives"`
+	Total    int               `json:"total"`
+	Message  string            `json:"message"`
 }
 
-// ListArchives retrieves all archives for a user
+// ListArchives retrieves a page of the user's archives, newest first
 func (u *ArchiveUseCase) ListArchives(ctx context.Context, req *ListArchivesRequest) (*ListArchivesResponse, error) {
 	if req.UserID == "" {
 		return nil, fmt.Errorf("user_id is required")
 	}
+	if u.archiveRepo == nil {
+		return nil, ErrArchivesDisabled
+	}
 
-	// In production: query from archives table
+	archives, err := u.archiveRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+	total := len(archives)
+	if total == 0 {
+		return &ListArchivesResponse{Archives: make([]*domain.Archive, 0), Message: "No archives found"}, nil
+	}
+
+	start := min(max(req.Offset, 0), total)
+	end := total
+	if req.Limit > 0 {
+		end = min(start+req.Limit, total)
+	}
 	return &ListArchivesResponse{
-		Archives: make([]*Archive, 0),
-		Total:    0,
-		Message:  "No archives found",
+		Archives: archives[start:end],
+		Total:    total,
+		Message:  fmt.Sprintf("Found %d archives", total),
 	}, nil
 }
 
@@ -132,29 +284,52 @@ type GetArchiveRequest struct {
 
 // ArchiveDetail represents detailed archive information
 type ArchiveDetail struct {
-	Archive  *Archive        `json:"archive"`
+	Archive  *domain.Archive `json:"archive"`
 	Expenses []*SearchResult `json:"expenses"`
 	Message  string          `json:"message"`
 }
 
-// GetArchive retrieves detailed information about an archive
+// GetArchive retrieves an archive and the expenses in it
 func (u *ArchiveUseCase) GetArchive(ctx context.Context, req *GetArchiveRequest) (*ArchiveDetail, error) {
 	if req.UserID == "" || req.ArchiveID == "" {
 		return nil, fmt.Errorf("user_id and archive_id are required")
 	}
 
-	// In production:
-	// 1. Retrieve archive metadata
-	// 2. Decompress and retrieve expense data
-	// 3. Return detailed information
+	archive, err := u.getArchive(ctx, req.UserID, req.ArchiveID)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses := make([]*SearchResult, 0, archive.ExpenseCount)
+	err = u.readArchive(ctx, archive, func(e *archivedExpense) error {
+		expenses = append(expenses, &SearchResult{
+			ID:          e.ID,
+			Description: e.Description,
+			Amount:      e.HomeAmount,
+			Category:    e.Category,
+			Date:        e.ExpenseDate,
+			Account:     e.Account,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	return &ArchiveDetail{
-		Archive:  &Archive{ID: req.ArchiveID, UserID: req.UserID},
-		Expenses: make([]*SearchResult, 0),
+		Archive:  archive,
+		Expenses: expenses,
 		Message:  "Archive retrieved",
 	}, nil
 }
 
+// Archive restore strategies
+const (
+	ArchiveRestoreMerge          = "merge"           // Also overwrite current expenses with their archived version
+	ArchiveRestoreReplace        = "replace"         // Also delete current expenses in the period that aren't in the archive
+	ArchiveRestoreSkipDuplicates = "skip_duplicates" // Only bring back expenses that are missing
+)
+
 // RestoreArchiveRequest represents a request to restore from an archive
 type RestoreArchiveRequest struct {
 	UserID    string
@@ -164,32 +339,125 @@ type RestoreArchiveRequest struct {
 
 // RestoreArchiveResponse represents the response after restoration
 type RestoreArchiveResponse struct {
-	RestoredCount int
-	SkippedCount  int
-	Message       string
+	RestoredCount int    `json:"restored_count"`
+	SkippedCount  int    `json:"skipped_count"`
+	RemovedCount  int    `json:"removed_count"`
+	Message       string `json:"message"`
 }
 
-// RestoreArchive restores expenses from an archive
+// RestoreArchive streams an archive back from object storage and restores
+// its expenses. Expenses that were deleted are undeleted; an expense whose
+// category no longer exists is restored uncategorized.
 func (u *ArchiveUseCase) RestoreArchive(ctx context.Context, req *RestoreArchiveRequest) (*RestoreArchiveResponse, error) {
 	if req.UserID == "" || req.ArchiveID == "" {
 		return nil, fmt.Errorf("user_id and archive_id are required")
 	}
 
 	if req.Strategy == "" {
-		req.Strategy = "skip_duplicates"
+		req.Strategy = ArchiveRestoreSkipDuplicates
+	}
+	switch req.Strategy {
+	case ArchiveRestoreMerge, ArchiveRestoreReplace, ArchiveRestoreSkipDuplicates:
+	default:
+		return nil, fmt.Errorf("invalid strategy %q", req.Strategy)
 	}
 
-	// In production:
-	// 1. Retrieve archived expenses
-	// 2. Apply merge strategy
-	// 3. Create new expense records
-	// 4. Return summary
+	archive, err := u.getArchive(ctx, req.UserID, req.ArchiveID)
+	if err != nil {
+		return nil, err
+	}
 
-	return &RestoreArchiveResponse{
-		RestoredCount: 0,
-		SkippedCount:  0,
-		Message:       "Archive restored",
-	}, nil
+	categories, err := u.categoryNames(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *RestoreArchiveResponse
+	err = inUnitOfWork(ctx, u.uow, func(ctx context.Context) error {
+		resp, err = u.restore(ctx, archive, req.Strategy, categories)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Message = fmt.Sprintf("Restored %d expenses, skipped %d", resp.RestoredCount, resp.SkippedCount)
+	return resp, nil
+}
+
+// restore restores the archive's expenses with the given strategy
+func (u *ArchiveUseCase) restore(ctx context.Context, archive *domain.Archive, strategy string, categories map[string]string) (*RestoreArchiveResponse, error) {
+	resp := &RestoreArchiveResponse{}
+	archived := make(map[string]bool, archive.ExpenseCount)
+	err := u.readArchive(ctx, archive, func(e *archivedExpense) error {
+		archived[e.ID] = true
+		expense := e.expense(archive.UserID)
+		if expense.CategoryID != nil {
+			if _, ok := categories[*expense.CategoryID]; !ok {
+				expense.CategoryID = nil
+			}
+		}
+
+		current, err := u.expenseRepo.GetByID(ctx, expense.ID)
+		switch {
+		case err == nil:
+			if current.UserID != archive.UserID || strategy == ArchiveRestoreSkipDuplicates {
+				resp.SkippedCount++
+				return nil
+			}
+			if err := u.expenseRepo.Update(ctx, expense); err != nil {
+				return fmt.Errorf("failed to restore expense: %w", err)
+			}
+		case errors.Is(err, domain.ErrNotFound):
+			deleted, err := u.expenseRepo.GetDeletedByID(ctx, expense.ID)
+			if errors.Is(err, domain.ErrNotFound) {
+				if err := u.expenseRepo.Create(ctx, expense); err != nil {
+					return fmt.Errorf("failed to restore expense: %w", err)
+				}
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get expense: %w", err)
+			}
+			if deleted.UserID != archive.UserID {
+				resp.SkippedCount++
+				return nil
+			}
+			if err := u.expenseRepo.Restore(ctx, expense.ID); err != nil {
+				return fmt.Errorf("failed to restore expense: %w", err)
+			}
+			if strategy != ArchiveRestoreSkipDuplicates {
+				if err := u.expenseRepo.Update(ctx, expense); err != nil {
+					return fmt.Errorf("failed to restore expense: %w", err)
+				}
+			}
+		default:
+			return fmt.Errorf("failed to get expense: %w", err)
+		}
+		resp.RestoredCount++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == ArchiveRestoreReplace {
+		current, err := u.expenseRepo.GetByUserIDAndDateRange(ctx, archive.UserID, archive.StartDate, archive.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get expenses: %w", err)
+		}
+		for _, expense := range current {
+			if archived[expense.ID] {
+				continue
+			}
+			if err := u.expenseRepo.Delete(ctx, expense.ID); err != nil {
+				return nil, fmt.Errorf("failed to delete expense: %w", err)
+			}
+			resp.RemovedCount++
+		}
+	}
+
+	return resp, nil
 }
 
 // PurgeArchiveRequest represents a request to purge old archives
@@ -201,15 +469,18 @@ type PurgeArchiveRequest struct {
 
 // PurgeArchiveResponse represents the response after purging
 type PurgeArchiveResponse struct {
-	PurgedCount int
-	Message     string
+	PurgedCount int    `json:"purged_count"`
+	Message     string `json:"message"`
 }
 
-// PurgeArchive deletes old archives based on retention policy
+// PurgeArchive deletes old archives, bundle and metadata, based on retention policy
 func (u *ArchiveUseCase) PurgeArchive(ctx context.Context, req *PurgeArchiveRequest) (*PurgeArchiveResponse, error) {
 	if req.UserID == "" {
 		return nil, fmt.Errorf("user_id is required")
 	}
+	if u.archiveRepo == nil || u.storage == nil {
+		return nil, ErrArchivesDisabled
+	}
 
 	if req.DaysOld <= 0 {
 		req.DaysOld = 365 * 7 // Default 7 years
@@ -219,15 +490,30 @@ func (u *ArchiveUseCase) PurgeArchive(ctx context.Context, req *PurgeArchiveRequ
 		req.KeepMin = 3 // Keep at least 3 recent archives
 	}
 
-	// In production:
-	// 1. Find all archives older than DaysOld
-	// 2. Keep at least KeepMin recent ones
-	// 3. Delete excess archives
-	// 4. Return count of purged archives
+	archives, err := u.archiveRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
 
+	cutoff := u.now().AddDate(0, 0, -req.DaysOld)
+	purged := 0
+	// Archives are newest first, so the ones kept come first
+	for i := req.KeepMin; i < len(archives); i++ {
+		if !archives[i].CreatedAt.Before(cutoff) {
+			continue
+		}
+		if err := u.deleteArchive(ctx, archives[i]); err != nil {
+			return nil, err
+		}
+		purged++
+	}
+
+	if purged == 0 {
+		return &PurgeArchiveResponse{Message: "No archives to purge"}, nil
+	}
 	return &PurgeArchiveResponse{
-		PurgedCount: 0,
-		Message:     "No archives to purge",
+		PurgedCount: purged,
+		Message:     fmt.Sprintf("Purged %d archives", purged),
 	}, nil
 }
 
@@ -235,38 +521,41 @@ func (u *ArchiveUseCase) PurgeArchive(ctx context.Context, req *PurgeArchiveRequ
 type ExportArchiveRequest struct {
 	UserID    string
 	ArchiveID string
-	Format    string // "json", "csv", "zip"
+	Format    string // Only the archive's own format, "jsonl.gz", for now
 }
 
 // ExportArchiveResponse represents the export
 type ExportArchiveResponse struct {
-	ArchiveID string
-	Format    string
-	Size      int64
-	URL       string // Download URL
-	Message   string
+	ArchiveID string `json:"archive_id"`
+	Format    string `json:"format"`
+	Size      int64  `json:"size"`
+	Checksum  string `json:"checksum"`
+	URL       string `json:"url"` // Download URL
+	Message   string `json:"message"`
 }
 
-// ExportArchive exports an archive in a specific format
+// ExportArchive describes an archive's bundle for download
 func (u *ArchiveUseCase) ExportArchive(ctx context.Context, req *ExportArchiveRequest) (*ExportArchiveResponse, error) {
 	if req.UserID == "" || req.ArchiveID == "" {
 		return nil, fmt.Errorf("user_id and archive_id are required")
 	}
 
+	archive, err := u.getArchive(ctx, req.UserID, req.ArchiveID)
+	if err != nil {
+		return nil, err
+	}
 	if req.Format == "" {
-		req.Format = "zip"
+		req.Format = archive.Format
+	}
+	if req.Format != archive.Format {
+		return nil, fmt.Errorf("unsupported export format %q", req.Format)
 	}
-
-	// In production:
-	// 1. Retrieve archived data
-	// 2. Convert to requested format
-	// 3. Generate download URL
-	// 4. Return export information
 
 	return &ExportArchiveResponse{
-		ArchiveID: req.ArchiveID,
-		Format:    req.Format,
-		Size:      0,
+		ArchiveID: archive.ID,
+		Format:    archive.Format,
+		Size:      archive.CompressedSize,
+		Checksum:  archive.Checksum,
 		URL:       "",
 		Message:   "Archive export generated",
 	}, nil
@@ -281,7 +570,7 @@ type ArchiveStatisticsRequest struct {
 type ArchiveStatistics struct {
 	TotalArchives         int        `json:"total_archives"`
 	TotalArchivedExpenses int        `json:"total_archived_expenses"`
-	TotalSize             int64      `json:"total_size"`
+	TotalSize             int64      `json:"total_size"` // Compressed, as stored
 	OldestArchive         *time.Time `json:"oldest_archive"`
 	NewestArchive         *time.Time `json:"newest_archive"`
 	Message               string     `json:"message"`
@@ -292,14 +581,108 @@ func (u *ArchiveUseCase) GetStatistics(ctx context.Context, req *ArchiveStatisti
 	if req.UserID == "" {
 		return nil, fmt.Errorf("user_id is required")
 	}
+	if u.archiveRepo == nil {
+		return nil, ErrArchivesDisabled
+	}
 
-	// In production: query archive metadata and calculate statistics
-	return &ArchiveStatistics{
-		TotalArchives:         0,
-		TotalArchivedExpenses: 0,
-		TotalSize:             0,
-		OldestArchive:         nil,
-		NewestArchive:         nil,
-		Message:               "No archives found",
-	}, nil
+	archives, err := u.archiveRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+	if len(archives) == 0 {
+		return &ArchiveStatistics{Message: "No archives found"}, nil
+	}
+
+	stats := &ArchiveStatistics{
+		TotalArchives: len(archives),
+		NewestArchive: &archives[0].CreatedAt,
+		OldestArchive: &archives[len(archives)-1].CreatedAt,
+	}
+	for _, archive := range archives {
+		stats.TotalArchivedExpenses += archive.ExpenseCount
+		stats.TotalSize += archive.CompressedSize
+	}
+	stats.Message = fmt.Sprintf("%d archives with %d expenses", stats.TotalArchives, stats.TotalArchivedExpenses)
+	return stats, nil
+}
+
+// getArchive returns one of the user's archives
+func (u *ArchiveUseCase) getArchive(ctx context.Context, userID, archiveID string) (*domain.Archive, error) {
+	if u.archiveRepo == nil || u.storage == nil {
+		return nil, ErrArchivesDisabled
+	}
+	archive, err := u.archiveRepo.GetByID(ctx, archiveID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && archive.UserID != userID) {
+		return nil, fmt.Errorf("archive %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive: %w", err)
+	}
+	return archive, nil
+}
+
+// readArchive streams the archive's bundle from storage, calling fn with each
+// expense in it, and checks the bundle against the archive's checksum
+func (u *ArchiveUseCase) readArchive(ctx context.Context, archive *domain.Archive, fn func(*archivedExpense) error) error {
+	if archive.Format != domain.ArchiveFormatJSONLinesGzip {
+		return fmt.Errorf("unsupported archive format %q", archive.Format)
+	}
+	r, err := u.storage.Open(ctx, archive.ObjectKey)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer r.Close()
+
+	hash := sha256.New()
+	zr, err := gzip.NewReader(io.TeeReader(r, hash))
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	dec := json.NewDecoder(zr)
+	for {
+		var e archivedExpense
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+	if err := zr.Close(); err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	// Drain anything gzip didn't consume so the whole bundle is hashed
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != archive.Checksum {
+		return fmt.Errorf("archive %s failed its checksum", archive.ID)
+	}
+	return nil
+}
+
+// deleteArchive removes an archive's bundle and then its metadata
+func (u *ArchiveUseCase) deleteArchive(ctx context.Context, archive *domain.Archive) error {
+	if err := u.storage.Delete(ctx, archive.ObjectKey); err != nil {
+		return fmt.Errorf("failed to delete archive bundle: %w", err)
+	}
+	if err := u.archiveRepo.Delete(ctx, archive.ID); err != nil {
+		return fmt.Errorf("failed to delete archive: %w", err)
+	}
+	return nil
+}
+
+// categoryNames maps the user's category IDs to their names
+func (u *ArchiveUseCase) categoryNames(ctx context.Context, userID string) (map[string]string, error) {
+	categories, err := u.categoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	names := make(map[string]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+	return names, nil
 }
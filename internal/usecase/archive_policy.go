@@ -0,0 +1,337 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ErrInvalidArchivePolicy is returned for a policy with negative periods
+var ErrInvalidArchivePolicy = errors.New("archive_after_months and purge_after_days must not be negative")
+
+// ArchivePolicyUseCase archives users' old expenses and purges their old
+// archives by policy. Each user follows their own policy if they set one and
+// the global policy otherwise. Archived expenses are soft-deleted, so
+// restoring their archive brings them back, until a policy that still covers
+// them archives them again. In dry-run mode, runs only report what they would do.
+type ArchivePolicyUseCase struct {
+	policyRepo domain.ArchivePolicyRepository
+	archives   *ArchiveUseCase
+	global     domain.ArchivePolicy
+	dryRun     bool
+	timezones  TimezoneLocator
+	now        func() time.Time
+}
+
+// NewArchivePolicyUseCase creates a new archive policy use case that applies
+// the global policy to users without one of their own
+func NewArchivePolicyUseCase(policyRepo domain.ArchivePolicyRepository, archives *ArchiveUseCase, global domain.ArchivePolicy) *ArchivePolicyUseCase {
+	global.UserID = ""
+	return &ArchivePolicyUseCase{
+		policyRepo: policyRepo,
+		archives:   archives,
+		global:     global,
+		now:        time.Now,
+	}
+}
+
+// SetDryRun makes scheduled runs only report what they would archive and purge
+func (u *ArchivePolicyUseCase) SetDryRun(dryRun bool) {
+	u.dryRun = dryRun
+}
+
+// SetTimezoneLocator splits archives at month boundaries in each user's
+// timezone instead of the server's
+func (u *ArchivePolicyUseCase) SetTimezoneLocator(timezones TimezoneLocator) {
+	u.timezones = timezones
+}
+
+// ArchivePolicyResponse is the policy that applies to a user
+type ArchivePolicyResponse struct {
+	Policy *domain.ArchivePolicy `json:"policy"`
+	Global bool                  `json:"global"` // The user has no policy of their own
+}
+
+// GetPolicy returns the policy that applies to the user
+func (u *ArchivePolicyUseCase) GetPolicy(ctx context.Context, userID string) (*ArchivePolicyResponse, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	policy, err := u.policyRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		global := u.global
+		global.UserID = userID
+		return &ArchivePolicyResponse{Policy: &global, Global: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive policy: %w", err)
+	}
+	return &ArchivePolicyResponse{Policy: policy}, nil
+}
+
+// SavePolicy sets the user's own policy in place of the global one
+func (u *ArchivePolicyUseCase) SavePolicy(ctx context.Context, policy *domain.ArchivePolicy) (*ArchivePolicyResponse, error) {
+	if policy.UserID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if policy.ArchiveAfterMonths < 0 || policy.PurgeAfterDays < 0 {
+		return nil, ErrInvalidArchivePolicy
+	}
+	policy.UpdatedAt = u.now()
+	if err := u.policyRepo.Save(ctx, policy); err != nil {
+		return nil, fmt.Errorf("failed to save archive policy: %w", err)
+	}
+	return &ArchivePolicyResponse{Policy: policy}, nil
+}
+
+// ResetPolicy removes the user's own policy, so the global one applies again
+func (u *ArchivePolicyUseCase) ResetPolicy(ctx context.Context, userID string) (*ArchivePolicyResponse, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if err := u.policyRepo.Delete(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete archive policy: %w", err)
+	}
+	return u.GetPolicy(ctx, userID)
+}
+
+// ArchivePolicyReport is what a policy run archived and purged, or would
+// have in a dry run
+type ArchivePolicyReport struct {
+	DryRun           bool                       `json:"dry_run"`
+	RanAt            time.Time                  `json:"ran_at"`
+	GlobalPolicy     domain.ArchivePolicy       `json:"global_policy"`
+	ArchivesCreated  int                        `json:"archives_created"`
+	ExpensesArchived int                        `json:"expenses_archived"`
+	ArchivesPurged   int                        `json:"archives_purged"`
+	Users            []*ArchivePolicyUserReport `json:"users"`
+}
+
+// ArchivePolicyUserReport is what a policy run did for one user
+type ArchivePolicyUserReport struct {
+	UserID         string                `json:"user_id"`
+	Policy         domain.ArchivePolicy  `json:"policy"`
+	Months         []*ArchivePolicyMonth `json:"months,omitempty"`
+	PurgedArchives []*domain.Archive     `json:"purged_archives,omitempty"`
+	Error          string                `json:"error,omitempty"`
+}
+
+// ArchivePolicyMonth is a month of a user's expenses a policy run archived
+type ArchivePolicyMonth struct {
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
+	ExpenseCount int       `json:"expense_count"`
+	TotalAmount  float64   `json:"total_amount"`
+	ArchiveID    string    `json:"archive_id,omitempty"` // Empty in a dry run
+}
+
+// Preview reports what applying the policies now would archive and purge,
+// without changing anything
+func (u *ArchivePolicyUseCase) Preview(ctx context.Context) (*ArchivePolicyReport, error) {
+	return u.run(ctx, true)
+}
+
+// Apply archives and purges what the policies say is due; in dry-run mode it
+// only reports what it would do
+func (u *ArchivePolicyUseCase) Apply(ctx context.Context) (*ArchivePolicyReport, error) {
+	return u.run(ctx, u.dryRun)
+}
+
+// RunScheduler applies the policies once per interval until ctx is done
+func (u *ArchivePolicyUseCase) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := u.Apply(ctx)
+			if err != nil {
+				slog.WarnContext(ctx, "Failed to apply archive policies", "error", err)
+				continue
+			}
+			slog.InfoContext(ctx, "Archive policies applied",
+				"dry_run", report.DryRun,
+				"archives_created", report.ArchivesCreated,
+				"expenses_archived", report.ExpensesArchived,
+				"archives_purged", report.ArchivesPurged,
+			)
+		}
+	}
+}
+
+func (u *ArchivePolicyUseCase) run(ctx context.Context, dryRun bool) (*ArchivePolicyReport, error) {
+	if u.archives.archiveRepo == nil || u.archives.storage == nil {
+		return nil, ErrArchivesDisabled
+	}
+
+	policies, err := u.policyRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archive policies: %w", err)
+	}
+	byUser := make(map[string]domain.ArchivePolicy, len(policies))
+	// The users due soonest are the ones with the shortest policies
+	archiveAfter, purgeAfter := u.global.ArchiveAfterMonths, u.global.PurgeAfterDays
+	for _, policy := range policies {
+		byUser[policy.UserID] = *policy
+		archiveAfter = shortest(archiveAfter, policy.ArchiveAfterMonths)
+		purgeAfter = shortest(purgeAfter, policy.PurgeAfterDays)
+	}
+	policyFor := func(userID string) domain.ArchivePolicy {
+		if policy, ok := byUser[userID]; ok {
+			return policy
+		}
+		policy := u.global
+		policy.UserID = userID
+		return policy
+	}
+
+	now := u.now()
+	report := &ArchivePolicyReport{DryRun: dryRun, RanAt: now, GlobalPolicy: u.global}
+	users := make(map[string]*ArchivePolicyUserReport)
+	reportFor := func(userID string) *ArchivePolicyUserReport {
+		if users[userID] == nil {
+			users[userID] = &ArchivePolicyUserReport{UserID: userID, Policy: policyFor(userID)}
+		}
+		return users[userID]
+	}
+
+	if archiveAfter > 0 {
+		// A day's margin for users whose timezone is ahead of the server's
+		userIDs, err := u.policyRepo.ListUsersWithExpensesBefore(ctx, now.AddDate(0, -archiveAfter, 1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users with old expenses: %w", err)
+		}
+		for _, userID := range userIDs {
+			policy := policyFor(userID)
+			if policy.ArchiveAfterMonths <= 0 {
+				continue
+			}
+			userReport := reportFor(userID)
+			if err := u.archiveExpenses(ctx, userReport, now, dryRun); err != nil {
+				slog.WarnContext(ctx, "Failed to archive expenses by policy", "user_id", userID, "error", err)
+				userReport.Error = err.Error()
+			}
+			for _, month := range userReport.Months {
+				report.ArchivesCreated++
+				report.ExpensesArchived += month.ExpenseCount
+			}
+		}
+	}
+
+	if purgeAfter > 0 {
+		old, err := u.archives.archiveRepo.GetCreatedBefore(ctx, now.AddDate(0, 0, -purgeAfter))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list old archives: %w", err)
+		}
+		for _, archive := range old {
+			policy := policyFor(archive.UserID)
+			if policy.PurgeAfterDays <= 0 || !archive.CreatedAt.Before(now.AddDate(0, 0, -policy.PurgeAfterDays)) {
+				continue
+			}
+			userReport := reportFor(archive.UserID)
+			if !dryRun {
+				if err := u.archives.deleteArchive(ctx, archive); err != nil {
+					slog.WarnContext(ctx, "Failed to purge archive by policy", "user_id", archive.UserID, "archive_id", archive.ID, "error", err)
+					userReport.Error = err.Error()
+					continue
+				}
+			}
+			userReport.PurgedArchives = append(userReport.PurgedArchives, archive)
+			report.ArchivesPurged++
+		}
+	}
+
+	report.Users = make([]*ArchivePolicyUserReport, 0, len(users))
+	for _, userReport := range users {
+		report.Users = append(report.Users, userReport)
+	}
+	sort.Slice(report.Users, func(i, j int) bool {
+		return report.Users[i].UserID < report.Users[j].UserID
+	})
+	return report, nil
+}
+
+// archiveExpenses archives the user's expenses from before the months their
+// policy keeps, one archive per month, and deletes them once archived
+func (u *ArchivePolicyUseCase) archiveExpenses(ctx context.Context, report *ArchivePolicyUserReport, now time.Time, dryRun bool) error {
+	loc := time.Local
+	if u.timezones != nil {
+		loc = u.timezones.Locate(ctx, report.UserID, "")
+	}
+	local := now.In(loc)
+	cutoff := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, loc).AddDate(0, -report.Policy.ArchiveAfterMonths, 0)
+
+	expenses, err := u.archives.expenseRepo.GetByUserIDAndDateRange(ctx, report.UserID, time.Unix(0, 0), cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to get expenses: %w", err)
+	}
+	months := make(map[time.Time][]*domain.Expense)
+	for _, expense := range expenses {
+		if !expense.ExpenseDate.Before(cutoff) {
+			continue
+		}
+		date := expense.ExpenseDate.In(loc)
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, loc)
+		months[start] = append(months[start], expense)
+	}
+	starts := make([]time.Time, 0, len(months))
+	for start := range months {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	for _, start := range starts {
+		month := &ArchivePolicyMonth{
+			StartDate:    start,
+			EndDate:      start.AddDate(0, 1, 0).Add(-time.Nanosecond),
+			ExpenseCount: len(months[start]),
+		}
+		for _, expense := range months[start] {
+			month.TotalAmount += expense.HomeAmount
+		}
+		if !dryRun {
+			archive, err := u.archives.writeArchive(ctx, &CreateArchiveRequest{
+				UserID:        report.UserID,
+				Period:        "monthly",
+				StartDate:     month.StartDate,
+				EndDate:       month.EndDate,
+				RetentionDays: report.Policy.PurgeAfterDays,
+			}, months[start])
+			if err != nil {
+				return err
+			}
+			err = inUnitOfWork(ctx, u.archives.uow, func(ctx context.Context) error {
+				for _, expense := range months[start] {
+					if err := u.archives.expenseRepo.Delete(ctx, expense.ID); err != nil {
+						return fmt.Errorf("failed to delete archived expense: %w", err)
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			month.ArchiveID = archive.ID
+		}
+		report.Months = append(report.Months, month)
+	}
+	return nil
+}
+
+// shortest returns the shorter of two policy periods, where 0 means never
+func shortest(a, b int) int {
+	if a <= 0 {
+		return b
+	}
+	if b <= 0 {
+		return a
+	}
+	return min(a, b)
+}
@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchivePolicyUseCaseApply(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	expenseRepo := NewMockExpenseRepository()
+	for i, e := range []struct {
+		userID string
+		date   time.Time
+	}{
+		{"user1", time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)},
+		{"user1", time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{"user1", time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)},
+		// Within the 24 months kept
+		{"user1", time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		// user2 keeps 12 months
+		{"user2", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		// user3 never archives
+		{"user3", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+			ID:          string(rune('a' + i)),
+			UserID:      e.userID,
+			HomeAmount:  100,
+			ExpenseDate: e.date,
+			CreatedAt:   e.date,
+		}))
+	}
+
+	archiveRepo := NewMockArchiveRepository()
+	blobs := NewMockBlobStorage()
+	archives := NewArchiveUseCase(expenseRepo, NewMockCategoryRepository())
+	archives.SetStorage(archiveRepo, blobs)
+	archives.now = func() time.Time { return now }
+	// An archive from over 7 years ago, and one user3 keeps
+	for _, archive := range []*domain.Archive{
+		{ID: "old", UserID: "user1", ObjectKey: "archives/user1/old.jsonl.gz", CreatedAt: now.AddDate(-8, 0, 0)},
+		{ID: "kept", UserID: "user3", ObjectKey: "archives/user3/kept.jsonl.gz", CreatedAt: now.AddDate(-8, 0, 0)},
+	} {
+		require.NoError(t, archiveRepo.Create(ctx, archive))
+		require.NoError(t, blobs.Put(ctx, archive.ObjectKey, []byte("gz"), "application/gzip"))
+	}
+
+	policyRepo := NewMockArchivePolicyRepository(expenseRepo)
+	uc := NewArchivePolicyUseCase(policyRepo, archives, domain.ArchivePolicy{ArchiveAfterMonths: 24, PurgeAfterDays: 365 * 7})
+	uc.SetDryRun(true)
+	userRepo := NewMockUserRepository()
+	for _, userID := range []string{"user1", "user2", "user3"} {
+		require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: userID, Timezone: "UTC"}))
+	}
+	uc.SetTimezoneLocator(NewTimezoneUseCase(userRepo))
+	uc.now = func() time.Time { return now }
+
+	_, err := uc.SavePolicy(ctx, &domain.ArchivePolicy{UserID: "user2", ArchiveAfterMonths: -1})
+	assert.ErrorIs(t, err, ErrInvalidArchivePolicy)
+	_, err = uc.SavePolicy(ctx, &domain.ArchivePolicy{UserID: "user2", ArchiveAfterMonths: 12, PurgeAfterDays: 365})
+	require.NoError(t, err)
+	_, err = uc.SavePolicy(ctx, &domain.ArchivePolicy{UserID: "user3"})
+	require.NoError(t, err)
+
+	policy, err := uc.GetPolicy(ctx, "user1")
+	require.NoError(t, err)
+	assert.True(t, policy.Global)
+	assert.Equal(t, 24, policy.Policy.ArchiveAfterMonths)
+
+	// A dry run reports what is due without changing anything
+	report, err := uc.Apply(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 3, report.ArchivesCreated)
+	assert.Equal(t, 4, report.ExpensesArchived)
+	assert.Equal(t, 1, report.ArchivesPurged)
+	require.Len(t, report.Users, 2)
+	user1 := report.Users[0]
+	assert.Equal(t, "user1", user1.UserID)
+	require.Len(t, user1.Months, 2)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), user1.Months[0].StartDate.UTC())
+	assert.Equal(t, 2, user1.Months[0].ExpenseCount)
+	assert.Equal(t, 200.0, user1.Months[0].TotalAmount)
+	assert.Empty(t, user1.Months[0].ArchiveID)
+	require.Len(t, user1.PurgedArchives, 1)
+	assert.Equal(t, "old", user1.PurgedArchives[0].ID)
+	assert.Equal(t, 12, report.Users[1].Policy.ArchiveAfterMonths)
+	assert.Len(t, expenseRepo.expenses, 6)
+	assert.Len(t, blobs.blobs, 2)
+
+	uc.SetDryRun(false)
+	report, err = uc.Apply(ctx)
+	require.NoError(t, err)
+	assert.False(t, report.DryRun)
+	assert.Equal(t, 3, report.ArchivesCreated)
+	assert.NotEmpty(t, report.Users[0].Months[0].ArchiveID)
+
+	// The archived expenses are deleted and can be restored from their archive
+	assert.Equal(t, []string{"d", "f"}, sortedKeys(expenseRepo.expenses))
+	_, err = archiveRepo.GetByID(ctx, "old")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = archiveRepo.GetByID(ctx, "kept")
+	assert.NoError(t, err)
+
+	archive, err := archiveRepo.GetByID(ctx, report.Users[0].Months[0].ArchiveID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, archive.ExpenseCount)
+	require.NotNil(t, archive.ExpiresAt)
+	restored, err := archives.RestoreArchive(ctx, &RestoreArchiveRequest{UserID: "user1", ArchiveID: archive.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored.RestoredCount)
+
+	// Nothing is left to do, except what was just restored
+	report, err = uc.Preview(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.ArchivesCreated)
+	assert.Zero(t, report.ArchivesPurged)
+
+	reset, err := uc.ResetPolicy(ctx, "user2")
+	require.NoError(t, err)
+	assert.True(t, reset.Global)
+}
+
+func sortedKeys(expenses map[string]*domain.Expense) []string {
+	keys := make([]string, 0, len(expenses))
+	for key := range expenses {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newArchiveTestUseCase(t *testing.T) (*ArchiveUseCase, *MockExpenseRepository, *MockBlobStorage) {
+	t.Helper()
+	ctx := context.Background()
+	food := "cat_food"
+
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	require.NoError(t, categoryRepo.Create(ctx, &domain.Category{ID: food, UserID: "user1", Name: "Food"}))
+	for i, amount := range []float64{120, 80, 300} {
+		date := time.Date(2024, 1, 5+i, 12, 0, 0, 0, time.UTC)
+		require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+			ID:           string(rune('a' + i)),
+			UserID:       "user1",
+			Description:  "Lunch",
+			HomeAmount:   amount,
+			HomeCurrency: "TWD",
+			CategoryID:   &food,
+			ExpenseDate:  date,
+			CreatedAt:    date,
+		}))
+	}
+	// Outside the archived month
+	require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{ID: "feb", UserID: "user1", HomeAmount: 50, ExpenseDate: time.Date(2024, 2, 3, 0, 0, 0, 0, time.UTC)}))
+
+	blobs := NewMockBlobStorage()
+	uc := NewArchiveUseCase(expenseRepo, categoryRepo)
+	uc.SetStorage(NewMockArchiveRepository(), blobs)
+	uc.now = func() time.Time { return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC) }
+	return uc, expenseRepo, blobs
+}
+
+func TestArchiveUseCaseCreateArchive(t *testing.T) {
+	ctx := context.Background()
+	uc, _, blobs := newArchiveTestUseCase(t)
+
+	resp, err := uc.CreateArchive(ctx, &CreateArchiveRequest{
+		UserID:    "user1",
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	archive := resp.Archive
+	assert.Equal(t, 3, archive.ExpenseCount)
+	assert.Equal(t, 500.0, archive.TotalAmount)
+	assert.Equal(t, domain.ArchiveFormatJSONLinesGzip, archive.Format)
+	assert.Equal(t, "archives/user1/"+archive.ID+".jsonl.gz", archive.ObjectKey)
+	require.NotNil(t, archive.ExpiresAt)
+	assert.Equal(t, time.Date(2033, 2, 27, 0, 0, 0, 0, time.UTC), *archive.ExpiresAt)
+
+	// The bundle is gzip-compressed JSON Lines, one expense per line
+	stored := blobs.blobs[archive.ObjectKey]
+	assert.Equal(t, archive.CompressedSize, int64(len(stored)))
+	zr, err := gzip.NewReader(bytes.NewReader(stored))
+	require.NoError(t, err)
+	raw, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, archive.Size, int64(len(raw)))
+	assert.Equal(t, 3, bytes.Count(raw, []byte("\n")))
+	assert.Contains(t, string(raw), `"category":"Food"`)
+
+	detail, err := uc.GetArchive(ctx, &GetArchiveRequest{UserID: "user1", ArchiveID: archive.ID})
+	require.NoError(t, err)
+	require.Len(t, detail.Expenses, 3)
+	assert.Equal(t, "Food", detail.Expenses[0].Category)
+
+	// Other users can't see it
+	_, err = uc.GetArchive(ctx, &GetArchiveRequest{UserID: "user2", ArchiveID: archive.ID})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	// A bundle that was swapped out fails its checksum, even if it's valid
+	var swapped bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&swapped, gzip.BestCompression)
+	require.NoError(t, err)
+	_, err = zw.Write(raw[:bytes.IndexByte(raw, '\n')+1])
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	blobs.blobs[archive.ObjectKey] = swapped.Bytes()
+	_, err = uc.GetArchive(ctx, &GetArchiveRequest{UserID: "user1", ArchiveID: archive.ID})
+	assert.ErrorContains(t, err, "checksum")
+}
+
+func TestArchiveUseCaseRestoreArchive(t *testing.T) {
+	ctx := context.Background()
+	uc, expenseRepo, _ := newArchiveTestUseCase(t)
+	uow := NewMockUnitOfWork()
+	uc.SetUnitOfWork(uow)
+
+	resp, err := uc.CreateArchive(ctx, &CreateArchiveRequest{
+		UserID:    "user1",
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	archiveID := resp.ArchiveID
+
+	// a is deleted, b is gone entirely and c was edited since
+	require.NoError(t, expenseRepo.Delete(ctx, "a"))
+	delete(expenseRepo.expenses, "b")
+	expenseRepo.expenses["c"].Description = "Dinner"
+	require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{ID: "new", UserID: "user1", ExpenseDate: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)}))
+
+	_, err = uc.RestoreArchive(ctx, &RestoreArchiveRequest{UserID: "user1", ArchiveID: archiveID, Strategy: "overwrite"})
+	assert.Error(t, err)
+
+	restored, err := uc.RestoreArchive(ctx, &RestoreArchiveRequest{UserID: "user1", ArchiveID: archiveID})
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored.RestoredCount)
+	assert.Equal(t, 1, restored.SkippedCount)
+	assert.Equal(t, 1, uow.Commits)
+	for _, id := range []string{"a", "b"} {
+		expense, err := expenseRepo.GetByID(ctx, id)
+		require.NoError(t, err, id)
+		assert.Equal(t, "Lunch", expense.Description)
+		assert.Equal(t, "cat_food", *expense.CategoryID)
+	}
+	assert.Equal(t, "Dinner", expenseRepo.expenses["c"].Description)
+
+	restored, err = uc.RestoreArchive(ctx, &RestoreArchiveRequest{UserID: "user1", ArchiveID: archiveID, Strategy: ArchiveRestoreMerge})
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored.RestoredCount)
+	assert.Equal(t, "Lunch", expenseRepo.expenses["c"].Description)
+	assert.Contains(t, expenseRepo.expenses, "new")
+
+	restored, err = uc.RestoreArchive(ctx, &RestoreArchiveRequest{UserID: "user1", ArchiveID: archiveID, Strategy: ArchiveRestoreReplace})
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored.RemovedCount)
+	assert.NotContains(t, expenseRepo.expenses, "new")
+	assert.Contains(t, expenseRepo.expenses, "feb")
+}
+
+func TestArchiveUseCasePurgeArchive(t *testing.T) {
+	ctx := context.Background()
+	uc, _, blobs := newArchiveTestUseCase(t)
+
+	created := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ids []string
+	for i := 0; i < 5; i++ {
+		uc.now = func() time.Time { return created.AddDate(i, 0, 0) }
+		resp, err := uc.CreateArchive(ctx, &CreateArchiveRequest{
+			UserID:    "user1",
+			StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		})
+		require.NoError(t, err)
+		ids = append(ids, resp.ArchiveID)
+	}
+	uc.now = func() time.Time { return time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC) }
+
+	// Three archives are over 7 years old, but the newest three are always kept
+	resp, err := uc.PurgeArchive(ctx, &PurgeArchiveRequest{UserID: "user1"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.PurgedCount)
+	assert.Len(t, blobs.blobs, 3)
+
+	stats, err := uc.GetStatistics(ctx, &ArchiveStatisticsRequest{UserID: "user1"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.TotalArchives)
+	assert.Equal(t, 9, stats.TotalArchivedExpenses)
+	assert.Equal(t, created.AddDate(2, 0, 0), *stats.OldestArchive)
+
+	list, err := uc.ListArchives(ctx, &ListArchivesRequest{UserID: "user1", Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, list.Total)
+	require.Len(t, list.Archives, 2)
+	assert.Equal(t, ids[3], list.Archives[0].ID)
+}
+
+func TestArchiveUseCaseWithoutStorage(t *testing.T) {
+	uc := NewArchiveUseCase(NewMockExpenseRepository(), NewMockCategoryRepository())
+	_, err := uc.CreateArchive(context.Background(), &CreateArchiveRequest{UserID: "user1"})
+	assert.ErrorIs(t, err, ErrArchivesDisabled)
+}
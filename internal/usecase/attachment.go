@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// AttachmentUseCase persists files sent with expenses, e.g. receipt photos,
+// and serves them back to the expense owner
+type AttachmentUseCase struct {
+	attachmentRepo domain.AttachmentRepository
+	storage        domain.BlobStorage
+	expenseRepo    domain.ExpenseRepository
+	groupRepo      domain.GroupRepository
+}
+
+// NewAttachmentUseCase creates a new attachment use case
+func NewAttachmentUseCase(
+	attachmentRepo domain.AttachmentRepository,
+	storage domain.BlobStorage,
+	expenseRepo domain.ExpenseRepository,
+	groupRepo domain.GroupRepository,
+) *AttachmentUseCase {
+	return &AttachmentUseCase{
+		attachmentRepo: attachmentRepo,
+		storage:        storage,
+		expenseRepo:    expenseRepo,
+		groupRepo:      groupRepo,
+	}
+}
+
+// Save stores a file once and links it to each of the given expenses
+func (u *AttachmentUseCase) Save(ctx context.Context, userID string, expenseIDs []string, file *domain.Attachment) ([]*domain.ExpenseAttachment, error) {
+	if file == nil || len(file.Data) == 0 {
+		return nil, fmt.Errorf("attachment is empty")
+	}
+	if len(expenseIDs) == 0 {
+		return nil, nil
+	}
+
+	key := fmt.Sprintf("%s/%s%s", userID, uuid.New().String(), attachmentExtension(file.MimeType))
+	if err := u.storage.Put(ctx, key, file.Data, file.MimeType); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	now := time.Now()
+	var saved []*domain.ExpenseAttachment
+	for _, expenseID := range expenseIDs {
+		attachment := &domain.ExpenseAttachment{
+			ID:         uuid.New().String(),
+			ExpenseID:  expenseID,
+			UserID:     userID,
+			MimeType:   file.MimeType,
+			Size:       int64(len(file.Data)),
+			StorageKey: key,
+			CreatedAt:  now,
+		}
+		if err := u.attachmentRepo.Create(ctx, attachment); err != nil {
+			return saved, fmt.Errorf("failed to save attachment: %w", err)
+		}
+		saved = append(saved, attachment)
+	}
+	return saved, nil
+}
+
+// SaveReceipt links a receipt photo to the expenses recorded from it
+func (u *AttachmentUseCase) SaveReceipt(ctx context.Context, userID string, expenseIDs []string, image *domain.Attachment) error {
+	_, err := u.Save(ctx, userID, expenseIDs, image)
+	return err
+}
+
+// ExpenseAttachmentsResponse lists the files linked to an expense
+type ExpenseAttachmentsResponse struct {
+	ExpenseID     string                      `json:"expense_id"`
+	AttachmentIDs []string                    `json:"attachment_ids"`
+	Attachments   []*domain.ExpenseAttachment `json:"attachments"`
+}
+
+// ListForExpense returns the attachments of an expense the user can see
+func (u *AttachmentUseCase) ListForExpense(ctx context.Context, expenseID, userID string) (*ExpenseAttachmentsResponse, error) {
+	expense, err := getVisibleExpense(ctx, u.expenseRepo, u.groupRepo, expenseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := u.attachmentRepo.GetByExpenseID(ctx, expense.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+
+	expense.AttachmentIDs = make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		expense.AttachmentIDs = append(expense.AttachmentIDs, attachment.ID)
+	}
+
+	return &ExpenseAttachmentsResponse{
+		ExpenseID:     expense.ID,
+		AttachmentIDs: expense.AttachmentIDs,
+		Attachments:   attachments,
+	}, nil
+}
+
+// Open returns an attachment and its contents
+func (u *AttachmentUseCase) Open(ctx context.Context, id, userID string) (*domain.ExpenseAttachment, []byte, error) {
+	if id == "" || userID == "" {
+		return nil, nil, fmt.Errorf("id and user_id are required")
+	}
+
+	attachment, err := u.attachmentRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil, fmt.Errorf("attachment %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if _, err := getVisibleExpense(ctx, u.expenseRepo, u.groupRepo, attachment.ExpenseID, userID); err != nil {
+		return nil, nil, fmt.Errorf("attachment %w", domain.ErrNotFound)
+	}
+
+	data, err := u.storage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	return attachment, data, nil
+}
+
+// attachmentExtension returns a file extension for a MIME type, e.g. ".jpg"
+func attachmentExtension(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func newAttachmentTestUseCase(t *testing.T) (*AttachmentUseCase, *MockExpenseRepository, *MockGroupRepository, *MockBlobStorage) {
+	t.Helper()
+	expenseRepo := NewMockExpenseRepository()
+	groupRepo := NewMockGroupRepository()
+	blobs := NewMockBlobStorage()
+	uc := NewAttachmentUseCase(NewMockAttachmentRepository(), blobs, expenseRepo, groupRepo)
+
+	expenseRepo.Create(context.Background(), &domain.Expense{ID: "e1", UserID: "user1", Description: "Latte", HomeAmount: 65})
+	expenseRepo.Create(context.Background(), &domain.Expense{ID: "e2", UserID: "user1", Description: "Sandwich", HomeAmount: 45})
+	return uc, expenseRepo, groupRepo, blobs
+}
+
+func TestAttachmentUseCase(t *testing.T) {
+	ctx := context.Background()
+	image := &domain.Attachment{Type: domain.AttachmentTypeImage, MimeType: "image/jpeg", Data: []byte("jpeg")}
+
+	t.Run("Receipt is stored once and linked to each expense", func(t *testing.T) {
+		uc, _, _, blobs := newAttachmentTestUseCase(t)
+		if err := uc.SaveReceipt(ctx, "user1", []string{"e1", "e2"}, image); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(blobs.blobs) != 1 {
+			t.Fatalf("expected 1 stored blob, got %d", len(blobs.blobs))
+		}
+		for key := range blobs.blobs {
+			if !strings.HasPrefix(key, "user1/") || !strings.HasSuffix(key, ".jpg") {
+				t.Errorf("unexpected storage key %q", key)
+			}
+		}
+
+		for _, expenseID := range []string{"e1", "e2"} {
+			resp, err := uc.ListForExpense(ctx, expenseID, "user1")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(resp.AttachmentIDs) != 1 || resp.Attachments[0].Size != 4 {
+				t.Errorf("unexpected attachments for %s: %+v", expenseID, resp)
+			}
+		}
+	})
+
+	t.Run("Open returns contents", func(t *testing.T) {
+		uc, _, _, _ := newAttachmentTestUseCase(t)
+		saved, err := uc.Save(ctx, "user1", []string{"e1"}, image)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		attachment, data, err := uc.Open(ctx, saved[0].ID, "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if attachment.MimeType != "image/jpeg" || !bytes.Equal(data, image.Data) {
+			t.Errorf("unexpected attachment %+v with data %q", attachment, data)
+		}
+	})
+
+	t.Run("Other users cannot see attachments", func(t *testing.T) {
+		uc, _, _, _ := newAttachmentTestUseCase(t)
+		saved, _ := uc.Save(ctx, "user1", []string{"e1"}, image)
+
+		if _, err := uc.ListForExpense(ctx, "e1", "user2"); err == nil {
+			t.Error("expected error listing another user's attachments")
+		}
+		if _, _, err := uc.Open(ctx, saved[0].ID, "user2"); err == nil {
+			t.Error("expected error opening another user's attachment")
+		}
+	})
+
+	t.Run("Group members can see group attachments", func(t *testing.T) {
+		uc, expenseRepo, groupRepo, _ := newAttachmentTestUseCase(t)
+		groups := NewGroupLedgerUseCase(groupRepo)
+		group, _ := groups.EnsureGroup(ctx, "line", "C1", "Family", "user1")
+		groups.EnsureGroup(ctx, "line", "C1", "Family", "user2")
+		expenseRepo.expenses["e1"].GroupID = &group.ID
+
+		saved, _ := uc.Save(ctx, "user1", []string{"e1"}, image)
+		if _, _, err := uc.Open(ctx, saved[0].ID, "user2"); err != nil {
+			t.Errorf("expected group member to open attachment, got %v", err)
+		}
+	})
+
+	t.Run("Empty attachment", func(t *testing.T) {
+		uc, _, _, _ := newAttachmentTestUseCase(t)
+		if _, err := uc.Save(ctx, "user1", []string{"e1"}, &domain.Attachment{}); err == nil {
+			t.Error("expected error for empty attachment")
+		}
+	})
+}
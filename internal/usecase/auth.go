@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+const (
+	// loginCodeTTL is how long a sign-in code sent to a messenger stays valid
+	loginCodeTTL = 5 * time.Minute
+	// loginCodeAttempts is how many wrong guesses a user gets, across the
+	// codes sent to them until one expires unused
+	loginCodeAttempts = 5
+	// loginCodeWindow is the period the sign-in code limits below apply to
+	loginCodeWindow = 15 * time.Minute
+	// loginCodesPerUser is how many sign-in codes one user can be sent per window
+	loginCodesPerUser = 3
+	// loginCodesPerMessenger is how many sign-in codes one messenger can
+	// deliver per window, across all its users
+	loginCodesPerMessenger = 100
+	// accessTokenTTL is how long an API access token stays valid
+	accessTokenTTL = 24 * time.Hour
+)
+
+var (
+	// ErrInvalidLoginCode is returned for a wrong, expired or used sign-in code
+	ErrInvalidLoginCode = errors.New("invalid or expired code")
+	// ErrInvalidToken is returned for a token that is malformed, expired or not signed by us
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrNoMessenger is returned when a sign-in code can't be delivered to the user
+	ErrNoMessenger = errors.New("user has no messenger to send a code to")
+	// ErrTooManyCodeRequests is returned when sign-in codes are requested too often
+	ErrTooManyCodeRequests = errors.New("too many sign-in codes requested; try again later")
+	// ErrLoginNotSupported is returned for a messenger without web login
+	ErrLoginNotSupported = errors.New("login is not supported for this messenger")
+	// ErrLoginFailed is returned when a messenger login payload doesn't check out
+	ErrLoginFailed = errors.New("login failed")
+	// ErrUnknownUser is returned when someone logs in before ever messaging the bot
+	ErrUnknownUser = errors.New("no expense account for this user; send the bot a message first")
+)
+
+// loginCode is a sign-in code waiting to be exchanged for a token
+type loginCode struct {
+	code      string
+	expiresAt time.Time
+	attempts  int
+}
+
+// AccessToken is a signed token identifying the user to the HTTP API
+type AccessToken struct {
+	Token     string    `json:"token"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AuthUseCase signs users in to the HTTP API. A one-time code is pushed to the
+// messenger the user signed up with and exchanged for a JWT, so only someone
+// who can read the user's chat can act as them. The dashboard can instead sign
+// users in with their messenger's web login, such as LINE Login.
+type AuthUseCase struct {
+	userRepo  domain.UserRepository
+	jwtSecret []byte
+	notifiers map[string]domain.PushNotifier
+	verifiers map[string]domain.LoginVerifier
+	now       func() time.Time
+
+	mu     sync.Mutex
+	codes  map[string]*loginCode
+	issued map[string][]time.Time // when codes were sent, by "user:" and "messenger:" key
+}
+
+// NewAuthUseCase creates a new auth use case signing tokens with jwtSecret
+func NewAuthUseCase(userRepo domain.UserRepository, jwtSecret string) *AuthUseCase {
+	return &AuthUseCase{
+		userRepo:  userRepo,
+		jwtSecret: []byte(jwtSecret),
+		notifiers: make(map[string]domain.PushNotifier),
+		verifiers: make(map[string]domain.LoginVerifier),
+		now:       time.Now,
+		codes:     make(map[string]*loginCode),
+		issued:    make(map[string][]time.Time),
+	}
+}
+
+// RegisterNotifier sets the channel sign-in codes are sent through for users
+// who signed up through messengerType
+func (u *AuthUseCase) RegisterNotifier(messengerType string, notifier domain.PushNotifier) {
+	u.notifiers[messengerType] = notifier
+}
+
+// RegisterLoginVerifier enables web login for users of messengerType
+func (u *AuthUseCase) RegisterLoginVerifier(messengerType string, verifier domain.LoginVerifier) {
+	u.verifiers[messengerType] = verifier
+}
+
+// RequestCode sends a new sign-in code to the user's messenger, replacing any
+// code sent before. The new code inherits the wrong guesses made against the
+// old one, and codes are limited per user and per messenger.
+func (u *AuthUseCase) RequestCode(ctx context.Context, userID string) error {
+	if userID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return ErrNoMessenger
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	notifier := u.notifiers[user.MessengerType]
+	if notifier == nil {
+		return ErrNoMessenger
+	}
+
+	code, err := newLoginCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	u.mu.Lock()
+	u.removeExpired()
+	attempts := 0
+	if pending := u.codes[userID]; pending != nil {
+		attempts = pending.attempts
+	}
+	userKey, messengerKey := "user:"+userID, "messenger:"+user.MessengerType
+	if attempts >= loginCodeAttempts || len(u.issued[userKey]) >= loginCodesPerUser || len(u.issued[messengerKey]) >= loginCodesPerMessenger {
+		u.mu.Unlock()
+		return ErrTooManyCodeRequests
+	}
+	u.issued[userKey] = append(u.issued[userKey], u.now())
+	u.issued[messengerKey] = append(u.issued[messengerKey], u.now())
+	u.codes[userID] = &loginCode{code: code, expiresAt: u.now().Add(loginCodeTTL), attempts: attempts}
+	u.mu.Unlock()
+
+	if err := notifier.PushMessage(ctx, userID, i18n.Tf(user.Locale, "auth.login_code", code)); err != nil {
+		return fmt.Errorf("failed to send code: %w", err)
+	}
+	return nil
+}
+
+// VerifyCode exchanges a sign-in code for an access token. Each code works
+// once. After too many wrong guesses the code stops working, and no new one is
+// sent until it would have expired.
+func (u *AuthUseCase) VerifyCode(ctx context.Context, userID, code string) (*AccessToken, error) {
+	u.mu.Lock()
+	pending := u.codes[userID]
+	if pending == nil || !u.now().Before(pending.expiresAt) {
+		delete(u.codes, userID)
+		u.mu.Unlock()
+		return nil, ErrInvalidLoginCode
+	}
+	if pending.attempts >= loginCodeAttempts || subtle.ConstantTimeCompare([]byte(pending.code), []byte(code)) != 1 {
+		pending.attempts++
+		u.mu.Unlock()
+		return nil, ErrInvalidLoginCode
+	}
+	delete(u.codes, userID)
+	u.mu.Unlock()
+
+	return u.issueToken(userID)
+}
+
+// Login checks a messenger's web login payload and returns an access token for
+// the messenger user it identifies, who must already have signed up by
+// messaging the bot
+func (u *AuthUseCase) Login(ctx context.Context, messengerType string, payload map[string]string) (*AccessToken, error) {
+	verifier := u.verifiers[messengerType]
+	if verifier == nil {
+		return nil, ErrLoginNotSupported
+	}
+	userID, err := verifier.VerifyLogin(ctx, payload)
+	if err != nil {
+		slog.WarnContext(ctx, "Messenger login rejected", "messenger", messengerType, "error", err)
+		return nil, ErrLoginFailed
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrUnknownUser
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.MessengerType != messengerType {
+		return nil, ErrUnknownUser
+	}
+	return u.issueToken(userID)
+}
+
+// issueToken signs an access token for the user
+func (u *AuthUseCase) issueToken(userID string) (*AccessToken, error) {
+	expiresAt := u.now().Add(accessTokenTTL)
+	claims := jwt.MapClaims{
+		"sub":  userID,
+		"exp":  expiresAt.Unix(),
+		"type": "access",
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(u.jwtSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign token: %w", err)
+	}
+	return &AccessToken{Token: token, UserID: userID, ExpiresAt: time.Unix(expiresAt.Unix(), 0)}, nil
+}
+
+// Authenticate returns the user an access token was issued to
+func (u *AuthUseCase) Authenticate(tokenString string) (string, error) {
+	return u.authenticate(tokenString, "access")
+}
+
+// AuthenticateReport returns the user an access token or report link token was
+// issued to. Report link tokens are shared in chats and live for days, so they
+// are only good for reading reports.
+func (u *AuthUseCase) AuthenticateReport(tokenString string) (string, error) {
+	return u.authenticate(tokenString, "access", "report_access")
+}
+
+// authenticate returns the user a token of one of the types was issued to
+func (u *AuthUseCase) authenticate(tokenString string, types ...string) (string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return u.jwtSecret, nil
+	}, jwt.WithTimeFunc(u.now))
+	if err != nil || !token.Valid {
+		return "", ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	if tokenType, _ := claims["type"].(string); !slices.Contains(types, tokenType) {
+		return "", ErrInvalidToken
+	}
+	userID, _ := claims["sub"].(string)
+	if userID == "" {
+		return "", ErrInvalidToken
+	}
+	return userID, nil
+}
+
+// removeExpired drops codes nobody redeemed and forgets codes sent before the
+// current window; u.mu must be held
+func (u *AuthUseCase) removeExpired() {
+	now := u.now()
+	for userID, code := range u.codes {
+		if !now.Before(code.expiresAt) {
+			delete(u.codes, userID)
+		}
+	}
+	for key, times := range u.issued {
+		times = slices.DeleteFunc(times, func(t time.Time) bool { return !now.Before(t.Add(loginCodeWindow)) })
+		if len(times) == 0 {
+			delete(u.issued, key)
+		} else {
+			u.issued[key] = times
+		}
+	}
+}
+
+// newLoginCode returns a random 6-digit code
+func newLoginCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// stubLoginVerifier accepts the payload whose "user" field is set
+type stubLoginVerifier struct{}
+
+func (stubLoginVerifier) VerifyLogin(ctx context.Context, payload map[string]string) (string, error) {
+	if payload["user"] == "" {
+		return "", errors.New("bad payload")
+	}
+	return payload["user"], nil
+}
+
+func TestAuthUseCase(t *testing.T) {
+	ctx := context.Background()
+	codePattern := regexp.MustCompile(`\d{6}`)
+
+	setup := func(t *testing.T) (*AuthUseCase, *recordingNotifier, *time.Time) {
+		t.Helper()
+		userRepo := NewMockUserRepository()
+		userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "telegram"})
+		userRepo.Create(ctx, &domain.User{UserID: "user2", MessengerType: "terminal"})
+
+		now := time.Now()
+		notifier := &recordingNotifier{}
+		uc := NewAuthUseCase(userRepo, "test-secret")
+		uc.RegisterNotifier("telegram", notifier)
+		uc.now = func() time.Time { return now }
+		return uc, notifier, &now
+	}
+
+	requestCode := func(t *testing.T, uc *AuthUseCase, notifier *recordingNotifier) string {
+		t.Helper()
+		if err := uc.RequestCode(ctx, "user1"); err != nil {
+			t.Fatalf("RequestCode failed: %v", err)
+		}
+		code := codePattern.FindString(notifier.messages[len(notifier.messages)-1])
+		if code == "" {
+			t.Fatalf("expected a code in %q", notifier.messages)
+		}
+		return code
+	}
+
+	t.Run("Code exchanged for a token", func(t *testing.T) {
+		uc, notifier, _ := setup(t)
+		code := requestCode(t, uc, notifier)
+
+		token, err := uc.VerifyCode(ctx, "user1", code)
+		if err != nil {
+			t.Fatalf("VerifyCode failed: %v", err)
+		}
+		userID, err := uc.Authenticate(token.Token)
+		if err != nil || userID != "user1" {
+			t.Errorf("expected the token to authenticate user1, got %q, %v", userID, err)
+		}
+
+		if _, err := uc.VerifyCode(ctx, "user1", code); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Errorf("expected a used code to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("Code for another user rejected", func(t *testing.T) {
+		uc, notifier, _ := setup(t)
+		code := requestCode(t, uc, notifier)
+		if _, err := uc.VerifyCode(ctx, "user2", code); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Errorf("expected ErrInvalidLoginCode, got %v", err)
+		}
+	})
+
+	t.Run("Expired code rejected", func(t *testing.T) {
+		uc, notifier, now := setup(t)
+		code := requestCode(t, uc, notifier)
+		*now = now.Add(loginCodeTTL)
+		if _, err := uc.VerifyCode(ctx, "user1", code); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Errorf("expected ErrInvalidLoginCode, got %v", err)
+		}
+	})
+
+	t.Run("Too many wrong guesses", func(t *testing.T) {
+		uc, notifier, _ := setup(t)
+		code := requestCode(t, uc, notifier)
+		for i := 0; i < loginCodeAttempts; i++ {
+			uc.VerifyCode(ctx, "user1", "wrong")
+		}
+		if _, err := uc.VerifyCode(ctx, "user1", code); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Errorf("expected the code to be discarded, got %v", err)
+		}
+		if err := uc.RequestCode(ctx, "user1"); !errors.Is(err, ErrTooManyCodeRequests) {
+			t.Errorf("expected no new code while locked out, got %v", err)
+		}
+	})
+
+	t.Run("New code keeps wrong guesses", func(t *testing.T) {
+		uc, notifier, _ := setup(t)
+		requestCode(t, uc, notifier)
+		for i := 0; i < loginCodeAttempts-1; i++ {
+			uc.VerifyCode(ctx, "user1", "wrong")
+		}
+		code := requestCode(t, uc, notifier)
+		uc.VerifyCode(ctx, "user1", "wrong")
+		if _, err := uc.VerifyCode(ctx, "user1", code); !errors.Is(err, ErrInvalidLoginCode) {
+			t.Errorf("expected the guesses to carry over to the new code, got %v", err)
+		}
+	})
+
+	t.Run("Codes limited per user", func(t *testing.T) {
+		uc, notifier, now := setup(t)
+		for i := 0; i < loginCodesPerUser; i++ {
+			requestCode(t, uc, notifier)
+		}
+		if err := uc.RequestCode(ctx, "user1"); !errors.Is(err, ErrTooManyCodeRequests) {
+			t.Errorf("expected ErrTooManyCodeRequests, got %v", err)
+		}
+		*now = now.Add(loginCodeWindow)
+		requestCode(t, uc, notifier)
+	})
+
+	t.Run("Codes limited per messenger", func(t *testing.T) {
+		uc, notifier, _ := setup(t)
+		for i := 0; i < loginCodesPerMessenger; i++ {
+			uc.issued["messenger:telegram"] = append(uc.issued["messenger:telegram"], uc.now())
+		}
+		if err := uc.RequestCode(ctx, "user1"); !errors.Is(err, ErrTooManyCodeRequests) {
+			t.Errorf("expected ErrTooManyCodeRequests, got %v", err)
+		}
+		if len(notifier.messages) != 0 {
+			t.Errorf("expected no code to be sent, got %q", notifier.messages)
+		}
+	})
+
+	t.Run("No messenger to send to", func(t *testing.T) {
+		uc, _, _ := setup(t)
+		for _, userID := range []string{"user2", "unknown"} {
+			if err := uc.RequestCode(ctx, userID); !errors.Is(err, ErrNoMessenger) {
+				t.Errorf("expected ErrNoMessenger for %s, got %v", userID, err)
+			}
+		}
+	})
+
+	t.Run("Report link tokens only read reports", func(t *testing.T) {
+		uc, _, _ := setup(t)
+		sign := func(claims jwt.MapClaims, secret string) string {
+			token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+			return token
+		}
+		exp := time.Now().Add(time.Hour).Unix()
+
+		reportToken := sign(jwt.MapClaims{"sub": "user1", "exp": exp, "type": "report_access"}, "test-secret")
+		if _, err := uc.Authenticate(reportToken); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected a report link token to be refused for the API, got %v", err)
+		}
+		if userID, err := uc.AuthenticateReport(reportToken); err != nil || userID != "user1" {
+			t.Errorf("expected a report link token to read reports, got %q, %v", userID, err)
+		}
+		for name, token := range map[string]string{
+			"wrong secret": sign(jwt.MapClaims{"sub": "user1", "exp": exp, "type": "access"}, "other"),
+			"unknown type": sign(jwt.MapClaims{"sub": "user1", "exp": exp, "type": "refresh"}, "test-secret"),
+			"expired":      sign(jwt.MapClaims{"sub": "user1", "exp": time.Now().Add(-time.Hour).Unix(), "type": "access"}, "test-secret"),
+			"not a token":  "abc",
+			"missing user": sign(jwt.MapClaims{"exp": exp, "type": "access"}, "test-secret"),
+		} {
+			if _, err := uc.Authenticate(token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+			}
+		}
+	})
+	t.Run("Messenger login", func(t *testing.T) {
+		uc, _, _ := setup(t)
+		uc.RegisterLoginVerifier("telegram", stubLoginVerifier{})
+
+		token, err := uc.Login(ctx, "telegram", map[string]string{"user": "user1"})
+		if err != nil {
+			t.Fatalf("Login failed: %v", err)
+		}
+		if userID, err := uc.Authenticate(token.Token); err != nil || userID != "user1" {
+			t.Errorf("expected the session to authenticate user1, got %q, %v", userID, err)
+		}
+
+		if _, err := uc.Login(ctx, "telegram", map[string]string{}); !errors.Is(err, ErrLoginFailed) {
+			t.Errorf("expected ErrLoginFailed, got %v", err)
+		}
+		// user2 signed up through another messenger
+		if _, err := uc.Login(ctx, "telegram", map[string]string{"user": "user2"}); !errors.Is(err, ErrUnknownUser) {
+			t.Errorf("expected ErrUnknownUser, got %v", err)
+		}
+		if _, err := uc.Login(ctx, "line", map[string]string{"user": "user1"}); !errors.Is(err, ErrLoginNotSupported) {
+			t.Errorf("expected ErrLoginNotSupported, got %v", err)
+		}
+	})
+}
@@ -8,10 +8,22 @@ import (
 	"github.com/riverlin/aiexpense/internal/domain"
 )
 
+// defaultCategoryNames are the categories a new user, and each new workspace,
+// starts with
+var defaultCategoryNames = []string{"Food", "Transport", "Shopping", "Entertainment", "Other"}
+
 // AutoSignupUseCase handles automatic user registration
 type AutoSignupUseCase struct {
 	userRepo     domain.UserRepository
 	categoryRepo domain.CategoryRepository
+	events       EventPublisher
+}
+
+// UserSignedUpEvent is the data of a user.signed_up event
+type UserSignedUpEvent struct {
+	UserID        string    `json:"user_id"`
+	MessengerType string    `json:"messenger_type"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 // NewAutoSignupUseCase creates a new auto-signup use case
@@ -22,6 +34,11 @@ func NewAutoSignupUseCase(userRepo domain.UserRepository, categoryRepo domain.Ca
 	}
 }
 
+// SetEventPublisher publishes a user.signed_up event for each new user
+func (u *AutoSignupUseCase) SetEventPublisher(events EventPublisher) {
+	u.events = events
+}
+
 // Execute registers a new user and initializes default categories
 func (u *AutoSignupUseCase) Execute(ctx context.Context, userID, messengerType string) error {
 	// Check if user already exists
@@ -47,8 +64,6 @@ func (u *AutoSignupUseCase) Execute(ctx context.Context, userID, messengerType s
 	}
 
 	// Initialize default categories
-	defaultCategoryNames := []string{"Food", "Transport", "Shopping", "Entertainment", "Other"}
-
 	for _, name := range defaultCategoryNames {
 		category := &domain.Category{
 			ID:        uuid.New().String(),
@@ -64,5 +79,13 @@ func (u *AutoSignupUseCase) Execute(ctx context.Context, userID, messengerType s
 		}
 	}
 
+	if u.events != nil {
+		u.events.Publish(ctx, userID, domain.EventUserSignedUp, &UserSignedUpEvent{
+			UserID:        userID,
+			MessengerType: messengerType,
+			CreatedAt:     user.CreatedAt,
+		})
+	}
+
 	return nil
 }
@@ -0,0 +1,236 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// BudgetAlertUseCase pushes a message to the user's messenger when a new expense
+// takes a budget past its alert threshold or its limit
+type BudgetAlertUseCase struct {
+	budgets    *BudgetManagementUseCase
+	userRepo   domain.UserRepository
+	notifiers  map[string]domain.PushNotifier
+	events     EventPublisher
+	dispatcher *NotificationDispatcher
+}
+
+// NewBudgetAlertUseCase creates a new budget alert use case
+func NewBudgetAlertUseCase(budgets *BudgetManagementUseCase, userRepo domain.UserRepository) *BudgetAlertUseCase {
+	return &BudgetAlertUseCase{
+		budgets:   budgets,
+		userRepo:  userRepo,
+		notifiers: make(map[string]domain.PushNotifier),
+	}
+}
+
+// RegisterNotifier sets the push channel for users who signed up through messengerType
+func (u *BudgetAlertUseCase) RegisterNotifier(messengerType string, notifier domain.PushNotifier) {
+	u.notifiers[messengerType] = notifier
+}
+
+// SetEventPublisher publishes each alert as a notification.created event to
+// the expense's owner, and a budget.exceeded event whenever an expense takes a
+// budget past its limit
+func (u *BudgetAlertUseCase) SetEventPublisher(events EventPublisher) {
+	u.events = events
+}
+
+// SetDispatcher delivers the user's own alerts through the dispatcher, which
+// applies their notification preferences, instead of the notifiers; alerts
+// on group budgets still go to the group chat
+func (u *BudgetAlertUseCase) SetDispatcher(dispatcher *NotificationDispatcher) {
+	u.dispatcher = dispatcher
+}
+
+// HandleExpenseCreated checks an expense.created event from the event bus
+// against the owner's budgets
+func (u *BudgetAlertUseCase) HandleExpenseCreated(ctx context.Context, userID string, event UserEvent) {
+	data, ok := event.Data.(*ExpenseEvent)
+	if !ok {
+		return
+	}
+	expense, err := u.budgets.expenseRepo.GetByID(ctx, data.ExpenseID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get expense for budget alerts", "expense_id", data.ExpenseID, "error", err)
+		return
+	}
+	if err := u.CheckExpense(ctx, expense); err != nil {
+		slog.WarnContext(ctx, "Budget alert check failed", "expense_id", expense.ID, "error", err)
+	}
+}
+
+// CheckExpense pushes an alert for every budget the expense takes across its
+// threshold or limit. Budgets already past a level before the expense stay quiet.
+// Personal budget alerts go to the user; group budget alerts go to the group chat.
+func (u *BudgetAlertUseCase) CheckExpense(ctx context.Context, expense *domain.Expense) error {
+	user, err := u.userRepo.GetByID(ctx, expense.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if i18n.Supported(user.Locale) {
+		ctx = i18n.WithLocale(ctx, user.Locale)
+	}
+
+	var lastErr error
+	if notifier := u.notifiers[user.MessengerType]; notifier != nil || u.events != nil || u.dispatcher != nil {
+		budgets, err := u.budgets.budgetRepo.GetByUserID(ctx, expense.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get budgets: %w", err)
+		}
+		deliver := u.pushTo(notifier, expense.UserID)
+		if u.dispatcher != nil {
+			deliver = func(ctx context.Context, alert *Notification) error {
+				err := u.dispatcher.Dispatch(ctx, domain.NotificationBudgetAlerts, user.MessengerType, alert)
+				if errors.Is(err, ErrNoNotifier) {
+					return nil
+				}
+				return err
+			}
+		}
+		if err := u.checkBudgets(ctx, expense, budgets, deliver); err != nil {
+			lastErr = err
+		}
+	}
+
+	if expense.GroupID != nil && u.budgets.groupRepo != nil {
+		group, err := u.budgets.groupRepo.GetByID(ctx, *expense.GroupID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("failed to get group: %w", err)
+		}
+		if err == nil && (u.notifiers[group.MessengerType] != nil || u.events != nil) {
+			budgets, err := u.budgets.budgetRepo.GetByGroupID(ctx, group.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get budgets: %w", err)
+			}
+			if err := u.checkBudgets(ctx, expense, budgets, u.pushTo(u.notifiers[group.MessengerType], group.ExternalID)); err != nil {
+				lastErr = err
+			}
+		}
+	}
+
+	return lastErr
+}
+
+// checkBudgets delivers the alerts for one ledger's budgets with deliver
+func (u *BudgetAlertUseCase) checkBudgets(ctx context.Context, expense *domain.Expense, budgets []*domain.Budget, deliver func(context.Context, *Notification) error) error {
+	now := time.Now()
+	var lastErr error
+	for _, budget := range budgets {
+		start, end := budget.PeriodRange(now)
+		if expense.ExpenseDate.Before(start) || expense.ExpenseDate.After(end) {
+			continue
+		}
+
+		expenses, err := u.budgets.budgetExpenses(ctx, budget, now)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		// Only count expenses recorded up to this one, so concurrent inserts
+		// (e.g. the items of one receipt) each see their own before/after totals.
+		// Skipping budgets the expense is not covered by also handles category
+		// budgets of group ledgers, which match by category name.
+		covered := false
+		after := 0.0
+		for _, e := range expenses {
+			if e.ID == expense.ID {
+				covered = true
+			}
+			if e.ID == expense.ID || e.CreatedAt.Before(expense.CreatedAt) {
+				after += e.Amount
+			}
+		}
+		if !covered {
+			continue
+		}
+		before := after - expense.Amount
+
+		if u.events != nil && before < budget.Limit && after >= budget.Limit {
+			categoryName, err := u.budgets.budgetCategoryName(ctx, budget)
+			if err != nil {
+				categoryName = "Uncategorized"
+			}
+			u.events.Publish(ctx, expense.UserID, domain.EventBudgetExceeded, &BudgetExceededEvent{
+				BudgetID:  budget.ID,
+				GroupID:   budget.GroupID,
+				Category:  categoryName,
+				Period:    budget.Period,
+				Limit:     budget.Limit,
+				Spent:     after,
+				Currency:  expense.HomeCurrency,
+				ExpenseID: expense.ID,
+			})
+		}
+
+		text := u.alertText(ctx, budget, before, after, expense.HomeCurrency)
+		if text == "" {
+			continue
+		}
+
+		alert := &Notification{
+			ID:        uuid.New().String(),
+			UserID:    expense.UserID,
+			Type:      "budget_alert",
+			Title:     translate(ctx, "notification."+domain.NotificationBudgetAlerts),
+			Message:   text,
+			Data:      map[string]interface{}{"budget_id": budget.ID, "expense_id": expense.ID},
+			CreatedAt: now,
+		}
+		if err := deliver(ctx, alert); err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// pushTo returns a deliver function for checkBudgets that publishes each
+// alert as a notification.created event and pushes it to recipient, if the
+// ledger's messenger has a notifier
+func (u *BudgetAlertUseCase) pushTo(notifier domain.PushNotifier, recipient string) func(context.Context, *Notification) error {
+	return func(ctx context.Context, alert *Notification) error {
+		if u.events != nil {
+			u.events.Publish(ctx, alert.UserID, domain.EventNotificationCreated, alert)
+		}
+		if notifier == nil {
+			return nil
+		}
+		if err := notifier.PushMessage(ctx, recipient, alert.Message); err != nil {
+			slog.WarnContext(ctx, "Failed to push budget alert", "recipient", recipient, "error", err)
+			return err
+		}
+		return nil
+	}
+}
+
+// alertText returns the alert for the highest level crossed between before and after, or ""
+func (u *BudgetAlertUseCase) alertText(ctx context.Context, budget *domain.Budget, before, after float64, currency string) string {
+	thresholdAmount := budget.Limit * budget.Threshold / 100
+
+	categoryName, err := u.budgets.budgetCategoryName(ctx, budget)
+	if err != nil {
+		categoryName = translate(ctx, "budget.uncategorized")
+	}
+
+	switch {
+	case before < budget.Limit && after >= budget.Limit:
+		return translate(ctx, "budget.alert.exceeded",
+			categoryName, budget.Period, formatMoney(ctx, after, currency), formatMoney(ctx, budget.Limit, currency))
+	case before < thresholdAmount && after >= thresholdAmount:
+		return translate(ctx, "budget.alert.threshold",
+			categoryName, budget.Period, after/budget.Limit*100, formatMoney(ctx, after, currency), formatMoney(ctx, budget.Limit, currency))
+	}
+	return ""
+}
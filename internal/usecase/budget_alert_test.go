@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+type recordingNotifier struct {
+	messages []string
+}
+
+func (n *recordingNotifier) PushMessage(ctx context.Context, userID, text string) error {
+	n.messages = append(n.messages, text)
+	return nil
+}
+
+type recordingPublisher struct {
+	events []string
+	data   []interface{}
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, userID, event string, data interface{}) {
+	p.events = append(p.events, userID+" "+event)
+	p.data = append(p.data, data)
+}
+
+func TestBudgetAlertCheckExpense(t *testing.T) {
+	ctx := context.Background()
+	food := "cat_food"
+	now := time.Now()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	setup := func(t *testing.T) (*BudgetAlertUseCase, *MockExpenseRepository, *recordingNotifier) {
+		t.Helper()
+		budgets, _, expenseRepo := newBudgetTestUseCase(t)
+		userRepo := NewMockUserRepository()
+		userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "telegram", Locale: "en"})
+
+		if _, err := budgets.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", CategoryID: &food, Limit: 1000}); err != nil {
+			t.Fatalf("failed to create budget: %v", err)
+		}
+
+		notifier := &recordingNotifier{}
+		uc := NewBudgetAlertUseCase(budgets, userRepo)
+		uc.RegisterNotifier("telegram", notifier)
+		return uc, expenseRepo, notifier
+	}
+
+	record := func(repo *MockExpenseRepository, id string, amount float64, createdAt time.Time) *domain.Expense {
+		expense := &domain.Expense{
+			ID:           id,
+			UserID:       "user1",
+			CategoryID:   &food,
+			Amount:       amount,
+			HomeCurrency: "TWD",
+			ExpenseDate:  createdAt,
+			CreatedAt:    createdAt,
+		}
+		repo.Create(ctx, expense)
+		return expense
+	}
+
+	t.Run("Crossing threshold", func(t *testing.T) {
+		uc, repo, notifier := setup(t)
+		base := monthStart.Add(time.Hour)
+		record(repo, "e1", 700, base)
+		expense := record(repo, "e2", 150, base.Add(time.Minute))
+
+		if err := uc.CheckExpense(ctx, expense); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(notifier.messages) != 1 {
+			t.Fatalf("expected 1 alert, got %d", len(notifier.messages))
+		}
+		want := "⚠️ Food monthly budget: 85% used (NT$850 / NT$1,000)"
+		if notifier.messages[0] != want {
+			t.Errorf("expected %q, got %q", want, notifier.messages[0])
+		}
+	})
+
+	t.Run("Crossing limit", func(t *testing.T) {
+		uc, repo, notifier := setup(t)
+		base := monthStart.Add(time.Hour)
+		record(repo, "e1", 900, base)
+		expense := record(repo, "e2", 200, base.Add(time.Minute))
+
+		uc.CheckExpense(ctx, expense)
+		if len(notifier.messages) != 1 || notifier.messages[0] != "🚨 Food monthly budget exceeded: NT$1,100 / NT$1,000" {
+			t.Errorf("unexpected alerts: %v", notifier.messages)
+		}
+	})
+
+	t.Run("In the user's locale", func(t *testing.T) {
+		uc, repo, notifier := setup(t)
+		uc.userRepo.Update(ctx, &domain.User{UserID: "user1", MessengerType: "telegram", Locale: "zh-TW"})
+		base := monthStart.Add(time.Hour)
+		record(repo, "e1", 900, base)
+
+		uc.CheckExpense(ctx, record(repo, "e2", 200, base.Add(time.Minute)))
+		if len(notifier.messages) != 1 || notifier.messages[0] != "🚨 Food monthly 預算已超支：NT$1,100 / NT$1,000" {
+			t.Errorf("unexpected alerts: %v", notifier.messages)
+		}
+	})
+
+	t.Run("Already past threshold", func(t *testing.T) {
+		uc, repo, notifier := setup(t)
+		base := monthStart.Add(time.Hour)
+		record(repo, "e1", 850, base)
+		expense := record(repo, "e2", 50, base.Add(time.Minute))
+
+		uc.CheckExpense(ctx, expense)
+		if len(notifier.messages) != 0 {
+			t.Errorf("expected no alert, got %v", notifier.messages)
+		}
+	})
+
+	t.Run("Later expense not counted", func(t *testing.T) {
+		uc, repo, notifier := setup(t)
+		base := monthStart.Add(time.Hour)
+		expense := record(repo, "e1", 100, base)
+		record(repo, "e2", 900, base.Add(time.Minute))
+
+		uc.CheckExpense(ctx, expense)
+		if len(notifier.messages) != 0 {
+			t.Errorf("expected no alert for the earlier expense, got %v", notifier.messages)
+		}
+	})
+
+	t.Run("No notifier for messenger", func(t *testing.T) {
+		uc, repo, notifier := setup(t)
+		uc.notifiers = map[string]domain.PushNotifier{"line": notifier}
+		expense := record(repo, "e1", 1200, monthStart.Add(time.Hour))
+
+		if err := uc.CheckExpense(ctx, expense); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(notifier.messages) != 0 {
+			t.Errorf("expected no alert, got %v", notifier.messages)
+		}
+	})
+
+	t.Run("Events when limit crossed", func(t *testing.T) {
+		uc, repo, notifier := setup(t)
+		uc.notifiers = map[string]domain.PushNotifier{}
+		publisher := &recordingPublisher{}
+		uc.SetEventPublisher(publisher)
+		base := monthStart.Add(time.Hour)
+		record(repo, "e1", 700, base)
+
+		// Crossing only the threshold is a notification, not budget.exceeded
+		uc.CheckExpense(ctx, record(repo, "e2", 150, base.Add(time.Minute)))
+		if len(publisher.events) != 1 || publisher.events[0] != "user1 notification.created" {
+			t.Fatalf("expected only a notification below the limit, got %v", publisher.events)
+		}
+
+		uc.CheckExpense(ctx, record(repo, "e3", 200, base.Add(2*time.Minute)))
+		if len(publisher.events) != 3 || publisher.events[1] != "user1 budget.exceeded" || publisher.events[2] != "user1 notification.created" {
+			t.Fatalf("expected budget.exceeded and a notification, got %v", publisher.events)
+		}
+		event := publisher.data[1].(*BudgetExceededEvent)
+		if event.Category != "Food" || event.Limit != 1000 || event.Spent != 1050 || event.ExpenseID != "e3" || event.Currency != "TWD" {
+			t.Errorf("unexpected event: %+v", event)
+		}
+		if len(notifier.messages) != 0 {
+			t.Errorf("expected no push without a notifier, got %v", notifier.messages)
+		}
+	})
+
+	t.Run("Dispatched by preferences", func(t *testing.T) {
+		uc, repo, notifier := setup(t)
+		prefsRepo := NewMockNotificationPreferencesRepository()
+		prefs := domain.DefaultNotificationPreferences("user1")
+		prefs.BudgetAlerts = false
+		prefsRepo.Save(ctx, prefs)
+		pusher := &recordingHeldPusher{notifiers: map[string]bool{"telegram": true}}
+		uc.SetDispatcher(NewNotificationDispatcher(prefsRepo, pusher))
+		base := monthStart.Add(time.Hour)
+		record(repo, "e1", 700, base)
+
+		if err := uc.CheckExpense(ctx, record(repo, "e2", 150, base.Add(time.Minute))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(pusher.pushed) != 0 {
+			t.Fatalf("expected no alert while alerts are off, got %v", pusher.pushed)
+		}
+
+		// Without quiet hours the alert is pushed right away
+		prefs.BudgetAlerts = true
+		prefs.QuietHoursStart, prefs.QuietHoursEnd = 0, 0
+		prefsRepo.Save(ctx, prefs)
+		if err := uc.CheckExpense(ctx, record(repo, "e3", 200, base.Add(2*time.Minute))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(pusher.pushed) != 1 || pusher.pushed[0].text != "🚨 Food monthly budget exceeded: NT$1,050 / NT$1,000" {
+			t.Errorf("unexpected alerts: %v", pusher.pushed)
+		}
+		if len(notifier.messages) != 0 {
+			t.Errorf("expected the notifier unused, got %v", notifier.messages)
+		}
+	})
+
+	t.Run("Handles expense.created from the bus", func(t *testing.T) {
+		uc, repo, notifier := setup(t)
+		bus := NewEventBus()
+		bus.Handle(domain.EventExpenseCreated, uc.HandleExpenseCreated)
+		record(repo, "e1", 700, monthStart.Add(time.Hour))
+		expense := record(repo, "e2", 150, monthStart.Add(2*time.Hour))
+
+		bus.Publish(ctx, "user1", domain.EventExpenseCreated, newExpenseEvent(expense, "Food"))
+		bus.Wait()
+		if len(notifier.messages) != 1 {
+			t.Errorf("expected the threshold alert, got %v", notifier.messages)
+		}
+	})
+}
@@ -2,8 +2,12 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/riverlin/aiexpense/internal/domain"
@@ -11,109 +15,488 @@ import (
 
 // BudgetManagementUseCase handles managing user budgets
 type BudgetManagementUseCase struct {
+	budgetRepo   domain.BudgetRepository
 	categoryRepo domain.CategoryRepository
 	expenseRepo  domain.ExpenseRepository
+	groupRepo    domain.GroupRepository
+	userRepo     domain.UserRepository
+	summaries    *SummaryCache
 }
 
 // NewBudgetManagementUseCase creates a new budget management use case
 func NewBudgetManagementUseCase(
+	budgetRepo domain.BudgetRepository,
 	categoryRepo domain.CategoryRepository,
 	expenseRepo domain.ExpenseRepository,
+	groupRepo domain.GroupRepository,
 ) *BudgetManagementUseCase {
 	return &BudgetManagementUseCase{
+		budgetRepo:   budgetRepo,
 		categoryRepo: categoryRepo,
 		expenseRepo:  expenseRepo,
+		groupRepo:    groupRepo,
 	}
 }
 
-// Budget represents a user's budget
-type Budget struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"user_id"`
-	CategoryID *string   `json:"category_id,omitempty"`
-	Category   string    `json:"category"`
-	Limit      float64   `json:"limit"`
-	Period     string    `json:"period"`    // "monthly", "weekly", "daily"
-	Threshold  float64   `json:"threshold"` // Alert when spending exceeds this %
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+// SetSummaryCache caches budget statuses until the ledger's expenses or budgets change
+func (u *BudgetManagementUseCase) SetSummaryCache(summaries *SummaryCache) {
+	u.summaries = summaries
+}
+
+// SetUserRepository remembers which named budget each user's chat replies follow
+func (u *BudgetManagementUseCase) SetUserRepository(userRepo domain.UserRepository) {
+	u.userRepo = userRepo
+}
+
+// invalidateStatus drops the cached statuses of the budget's ledger
+func (u *BudgetManagementUseCase) invalidateStatus(ctx context.Context, budget *domain.Budget) {
+	if u.summaries == nil {
+		return
+	}
+	if budget.GroupID != nil {
+		u.summaries.Invalidate(ctx, groupLedger(*budget.GroupID))
+	} else {
+		u.summaries.Invalidate(ctx, userLedger(budget.UserID))
+	}
 }
 
 // BudgetStatus represents the current status of a budget
 type BudgetStatus struct {
-	ID             string  `json:"id"`
-	Category       string  `json:"category"`
-	Limit          float64 `json:"limit"`
-	Spent          float64 `json:"spent"`
-	Remaining      float64 `json:"remaining"`
-	Percentage     float64 `json:"percentage"`
-	IsExceeded     bool    `json:"is_exceeded"`
-	AlertTriggered bool    `json:"alert_triggered"`
-	Message        string  `json:"message"`
+	ID             string     `json:"id"`
+	Name           string     `json:"name,omitempty"`
+	Category       string     `json:"category"`
+	Period         string     `json:"period"`
+	StartDate      *time.Time `json:"start_date,omitempty"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+	Limit          float64    `json:"limit"`
+	Spent          float64    `json:"spent"`
+	Remaining      float64    `json:"remaining"`
+	Percentage     float64    `json:"percentage"`
+	IsExceeded     bool       `json:"is_exceeded"`
+	AlertTriggered bool       `json:"alert_triggered"`
+	Message        string     `json:"message"`
 }
 
-// SetBudgetRequest represents a request to set a budget
-type SetBudgetRequest struct {
+// CreateBudgetRequest represents a request to create a budget
+type CreateBudgetRequest struct {
 	UserID     string
-	CategoryID *string
-	Category   string
+	CategoryID *string // nil for an overall budget
+	GroupID    *string // Budget a shared group ledger instead of the user's own spending
 	Limit      float64
-	Period     string  // "monthly", "weekly", "daily"
+	Period     string  // "monthly" or "weekly"; "custom" when dates are given
 	Threshold  float64 // 0-100, percentage
+	Name       string  // The named budget it is part of, e.g. 日本旅行; empty for the everyday one
+	StartDate  *time.Time
+	EndDate    *time.Time
+}
+
+// UpdateBudgetRequest represents a request to update a budget
+type UpdateBudgetRequest struct {
+	ID         string
+	UserID     string
+	CategoryID *string
+	Limit      *float64
+	Period     *string
+	Threshold  *float64
+	Name       *string
+	StartDate  *time.Time
+	EndDate    *time.Time
 }
 
-// SetBudgetResponse represents the response after setting a budget
-type SetBudgetResponse struct {
-	Budget  *Budget `json:"budget"`
-	Message string  `json:"message"`
+// DeleteBudgetRequest represents a request to delete a budget
+type DeleteBudgetRequest struct {
+	ID     string
+	UserID string
 }
 
-// SetBudget creates or updates a budget for a category
-func (u *BudgetManagementUseCase) SetBudget(ctx context.Context, req *SetBudgetRequest) (*SetBudgetResponse, error) {
+// BudgetResponse represents the response after changing a budget
+type BudgetResponse struct {
+	Budget   *domain.Budget `json:"budget"`
+	Category string         `json:"category"`
+	Message  string         `json:"message"`
+}
+
+// CreateBudget creates a budget for a category or for overall spending
+func (u *BudgetManagementUseCase) CreateBudget(ctx context.Context, req *CreateBudgetRequest) (*BudgetResponse, error) {
 	if req.UserID == "" {
 		return nil, fmt.Errorf("user_id is required")
 	}
 
-	if req.Limit <= 0 {
-		return nil, fmt.Errorf("budget limit must be greater than 0")
+	if req.CategoryID != nil && *req.CategoryID == "" {
+		req.CategoryID = nil
+	}
+
+	if req.GroupID != nil && *req.GroupID == "" {
+		req.GroupID = nil
+	}
+	if req.GroupID != nil {
+		if err := requireGroupMember(ctx, u.groupRepo, *req.GroupID, req.UserID); err != nil {
+			return nil, err
+		}
 	}
 
 	if req.Period == "" {
-		req.Period = "monthly"
+		req.Period = domain.BudgetPeriodMonthly
+		if req.StartDate != nil || req.EndDate != nil {
+			req.Period = domain.BudgetPeriodCustom
+		}
 	}
 
-	if req.Threshold <= 0 {
+	if req.Threshold == 0 {
 		req.Threshold = 80 // Default 80%
 	}
 
-	// In production, this would be stored in a budget table
-	// For now, we're just returning the budget object
-	budget := &Budget{
+	budget := &domain.Budget{
 		ID:         uuid.New().String(),
 		UserID:     req.UserID,
 		CategoryID: req.CategoryID,
-		Category:   req.Category,
+		GroupID:    req.GroupID,
 		Limit:      req.Limit,
 		Period:     req.Period,
 		Threshold:  req.Threshold,
+		Name:       strings.TrimSpace(req.Name),
+		StartDate:  budgetDate(req.StartDate),
+		EndDate:    budgetDate(req.EndDate),
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
+	if budget.GroupID == nil {
+		budget.WorkspaceID, _ = domain.WorkspaceFromContext(ctx)
+	}
+
+	categoryName, err := u.validateBudget(ctx, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []*domain.Budget
+	if budget.GroupID != nil {
+		existing, err = u.budgetRepo.GetByGroupID(ctx, *budget.GroupID)
+	} else {
+		existing, err = u.budgetRepo.GetByUserID(ctx, req.UserID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	for _, b := range existing {
+		if sameBudgetScope(b, budget) {
+			return nil, fmt.Errorf("a %s budget for %s%s %w", budget.Period, categoryName, budgetNameSuffix(budget), domain.ErrConflict)
+		}
+	}
+
+	if err := u.budgetRepo.Create(ctx, budget); err != nil {
+		return nil, fmt.Errorf("failed to create budget: %w", err)
+	}
+	u.invalidateStatus(ctx, budget)
+
+	return &BudgetResponse{
+		Budget:   budget,
+		Category: categoryName,
+		Message:  fmt.Sprintf("Budget set: %s%s %s %.2f (alert at %.0f%%)", categoryName, budgetNameSuffix(budget), budget.Period, budget.Limit, budget.Threshold),
+	}, nil
+}
+
+// UpdateBudget updates the limit, period, threshold or category of a budget
+func (u *BudgetManagementUseCase) UpdateBudget(ctx context.Context, req *UpdateBudgetRequest) (*BudgetResponse, error) {
+	budget, err := u.getOwnedBudget(ctx, req.ID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CategoryID != nil {
+		if *req.CategoryID == "" {
+			budget.CategoryID = nil
+		} else {
+			budget.CategoryID = req.CategoryID
+		}
+	}
+	if req.Limit != nil {
+		budget.Limit = *req.Limit
+	}
+	if req.Period != nil {
+		budget.Period = *req.Period
+	}
+	if req.Threshold != nil {
+		budget.Threshold = *req.Threshold
+	}
+	if req.Name != nil {
+		budget.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.StartDate != nil {
+		budget.StartDate = budgetDate(req.StartDate)
+	}
+	if req.EndDate != nil {
+		budget.EndDate = budgetDate(req.EndDate)
+	}
+	if budget.Period != domain.BudgetPeriodCustom {
+		budget.StartDate, budget.EndDate = nil, nil
+	}
+
+	categoryName, err := u.validateBudget(ctx, budget)
+	if err != nil {
+		return nil, err
+	}
+
+	budget.UpdatedAt = time.Now()
+	if err := u.budgetRepo.Update(ctx, budget); err != nil {
+		return nil, fmt.Errorf("failed to update budget: %w", err)
+	}
+	u.invalidateStatus(ctx, budget)
+
+	return &BudgetResponse{
+		Budget:   budget,
+		Category: categoryName,
+		Message:  fmt.Sprintf("Budget updated: %s%s %s %.2f (alert at %.0f%%)", categoryName, budgetNameSuffix(budget), budget.Period, budget.Limit, budget.Threshold),
+	}, nil
+}
+
+// DeleteBudget deletes a budget
+func (u *BudgetManagementUseCase) DeleteBudget(ctx context.Context, req *DeleteBudgetRequest) (*BudgetResponse, error) {
+	budget, err := u.getOwnedBudget(ctx, req.ID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.budgetRepo.Delete(ctx, budget.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete budget: %w", err)
+	}
+	u.invalidateStatus(ctx, budget)
 
-	return &SetBudgetResponse{
+	return &BudgetResponse{
 		Budget:  budget,
-		Message: fmt.Sprintf("Budget set: %s %s %.2f (alert at %.0f%%)", req.Category, req.Period, req.Limit, req.Threshold),
+		Message: "Budget deleted",
 	}, nil
 }
 
+// ListBudgets returns all budgets for a user
+func (u *BudgetManagementUseCase) ListBudgets(ctx context.Context, userID string) ([]*domain.Budget, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	budgets, err := u.budgetRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+// ListGroupBudgets returns all budgets for a group ledger the user belongs to
+func (u *BudgetManagementUseCase) ListGroupBudgets(ctx context.Context, groupID, userID string) ([]*domain.Budget, error) {
+	if err := requireGroupMember(ctx, u.groupRepo, groupID, userID); err != nil {
+		return nil, err
+	}
+
+	budgets, err := u.budgetRepo.GetByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+func (u *BudgetManagementUseCase) getOwnedBudget(ctx context.Context, id, userID string) (*domain.Budget, error) {
+	if id == "" || userID == "" {
+		return nil, fmt.Errorf("id and user_id are required")
+	}
+
+	budget, err := u.budgetRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("budget %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget: %w", err)
+	}
+
+	// Verify ownership; any member may manage a group budget
+	if budget.GroupID != nil {
+		if err := requireGroupMember(ctx, u.groupRepo, *budget.GroupID, userID); err != nil {
+			return nil, fmt.Errorf("unauthorized: user does not own this budget")
+		}
+	} else if budget.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: user does not own this budget")
+	}
+
+	return budget, nil
+}
+
+// validateBudget checks the budget fields and returns the display name of its category
+func (u *BudgetManagementUseCase) validateBudget(ctx context.Context, budget *domain.Budget) (string, error) {
+	if budget.Limit <= 0 {
+		return "", fmt.Errorf("budget limit must be greater than 0")
+	}
+
+	switch budget.Period {
+	case domain.BudgetPeriodMonthly, domain.BudgetPeriodWeekly:
+		if budget.StartDate != nil || budget.EndDate != nil {
+			return "", fmt.Errorf("start_date and end_date are only for custom budget periods")
+		}
+	case domain.BudgetPeriodCustom:
+		if budget.StartDate == nil || budget.EndDate == nil {
+			return "", fmt.Errorf("a custom budget period needs start_date and end_date")
+		}
+		if budget.EndDate.Before(*budget.StartDate) {
+			return "", fmt.Errorf("budget end_date must not be before start_date")
+		}
+	default:
+		return "", fmt.Errorf("budget period must be monthly, weekly or custom")
+	}
+
+	if utf8.RuneCountInString(budget.Name) > maxBudgetNameLength {
+		return "", fmt.Errorf("budget name must be at most %d characters", maxBudgetNameLength)
+	}
+
+	if budget.Threshold <= 0 || budget.Threshold > 100 {
+		return "", fmt.Errorf("budget threshold must be between 0 and 100")
+	}
+
+	return u.budgetCategoryName(ctx, budget)
+}
+
+func (u *BudgetManagementUseCase) budgetCategoryName(ctx context.Context, budget *domain.Budget) (string, error) {
+	if budget.IsOverall() {
+		return "Overall", nil
+	}
+
+	cat, err := u.categoryRepo.GetByID(ctx, *budget.CategoryID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return "", fmt.Errorf("failed to get category: %w", err)
+	}
+	if err != nil || cat.UserID != budget.UserID {
+		return "", fmt.Errorf("category %w", domain.ErrNotFound)
+	}
+	return cat.Name, nil
+}
+
+// maxBudgetNameLength is the longest a budget name may be, in characters
+const maxBudgetNameLength = 50
+
+// budgetDate keeps the day of a custom budget period's date. The day is read
+// in the user's timezone, so it is stored without one.
+func budgetDate(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return &day
+}
+
+// budgetNameSuffix names the budget's named budget in messages
+func budgetNameSuffix(budget *domain.Budget) string {
+	if budget.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf(" in %s", budget.Name)
+}
+
+// sameBudgetScope reports whether two budgets cover the same ledger, category
+// and period of the same named budget
+func sameBudgetScope(a, b *domain.Budget) bool {
+	if a.Period != b.Period || a.IsOverall() != b.IsOverall() || a.Name != b.Name {
+		return false
+	}
+	if (a.GroupID == nil) != (b.GroupID == nil) || (a.GroupID != nil && *a.GroupID != *b.GroupID) {
+		return false
+	}
+	if a.WorkspaceID != b.WorkspaceID {
+		return false
+	}
+	return a.IsOverall() || *a.CategoryID == *b.CategoryID
+}
+
+// budgetExpenses returns the user's, or the group's, expenses covered by the budget
+// in the period containing now
+func (u *BudgetManagementUseCase) budgetExpenses(ctx context.Context, budget *domain.Budget, now time.Time) ([]*domain.Expense, error) {
+	startDate, endDate := budget.PeriodRange(now)
+	var expenses []*domain.Expense
+	var err error
+	if budget.GroupID != nil {
+		expenses, err = u.expenseRepo.GetByGroupIDAndDateRange(ctx, *budget.GroupID, startDate, endDate)
+	} else {
+		// A user's budget only counts spending in its own workspace
+		expenses, err = u.expenseRepo.GetByUserIDAndDateRange(domain.WithWorkspace(ctx, budget.WorkspaceID), budget.UserID, startDate, endDate)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expenses: %w", err)
+	}
+
+	covers := u.budgetCovers(ctx, budget)
+	var covered []*domain.Expense
+	for _, expense := range expenses {
+		if covers(expense.CategoryID) {
+			covered = append(covered, expense)
+		}
+	}
+	return covered, nil
+}
+
+// budgetCovers returns whether the budget counts spending in a category
+func (u *BudgetManagementUseCase) budgetCovers(ctx context.Context, budget *domain.Budget) func(categoryID *string) bool {
+	if budget.IsOverall() {
+		return func(*string) bool { return true }
+	}
+
+	// Categories are per user, so group members' expenses match by category name
+	categoryName := ""
+	if budget.GroupID != nil {
+		categoryName, _ = u.budgetCategoryName(ctx, budget)
+	}
+
+	return func(categoryID *string) bool {
+		if categoryID == nil {
+			return false
+		}
+		if *categoryID == *budget.CategoryID {
+			return true
+		}
+		if categoryName != "" {
+			if cat, _ := u.categoryRepo.GetByID(ctx, *categoryID); cat != nil && cat.Name == categoryName {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// budgetTotalsQuery selects the budget's ledger in the period containing now
+func budgetTotalsQuery(budget *domain.Budget, now time.Time) domain.ExpenseTotalsQuery {
+	from, to := budget.PeriodRange(now)
+	query := domain.ExpenseTotalsQuery{UserID: budget.UserID, From: from, To: to}
+	if budget.GroupID != nil {
+		query.GroupID = *budget.GroupID
+	}
+	return query
+}
+
+// spentInPeriod sums the user's spending covered by the budget in the period
+// containing now, from the ledger's per-category totals
+func (u *BudgetManagementUseCase) spentInPeriod(ctx context.Context, budget *domain.Budget, now time.Time) (float64, error) {
+	totals, err := u.expenseRepo.SumByCategoryAndDateRange(ctx, budgetTotalsQuery(budget, now))
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum expenses: %w", err)
+	}
+
+	covers := u.budgetCovers(ctx, budget)
+	spent := 0.0
+	for _, total := range totals {
+		if covers(total.CategoryID) {
+			spent += total.Total
+		}
+	}
+	return spent, nil
+}
+
 // GetBudgetStatusRequest represents a request to get budget status
 type GetBudgetStatusRequest struct {
 	UserID     string
+	GroupID    string // Status of a shared group ledger the user belongs to
 	CategoryID *string
+	Name       *string // Only the budgets of this named budget; "" for the everyday one
+	Active     bool    // Only the budgets of the user's active named budget
 }
 
 // GetBudgetStatusResponse represents the response with budget status
 type GetBudgetStatusResponse struct {
+	Name       *string        `json:"name,omitempty"` // The named budget reported on, when only one is
 	Budgets    []BudgetStatus `json:"budgets"`
 	TotalLimit float64        `json:"total_limit"`
 	TotalSpent float64        `json:"total_spent"`
@@ -121,63 +504,115 @@ type GetBudgetStatusResponse struct {
 	Message    string         `json:"message"`
 }
 
-// GetBudgetStatus retrieves the current budget status
+// GetBudgetStatus retrieves the current status of each of the user's budgets
 func (u *BudgetManagementUseCase) GetBudgetStatus(ctx context.Context, req *GetBudgetStatusRequest) (*GetBudgetStatusResponse, error) {
 	if req.UserID == "" {
 		return nil, fmt.Errorf("user_id is required")
 	}
 
-	// Get user's expenses for the current month
-	now := time.Now()
-	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	endDate := now
+	ledger := userLedger(req.UserID)
+	if req.GroupID != "" {
+		if err := requireGroupMember(ctx, u.groupRepo, req.GroupID, req.UserID); err != nil {
+			return nil, err
+		}
+		ledger = groupLedger(req.GroupID)
+	}
+	if req.Active {
+		name, err := u.ActiveBudget(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		req.Name = &name
+	}
 
-	expenses, err := u.expenseRepo.GetByUserIDAndDateRange(ctx, req.UserID, startDate, endDate)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get expenses: %w", err)
+	// Spending in a budget's period changes with the user's day, so the day
+	// and the timezone it's in are part of the key
+	now := domain.LocalNow(ctx)
+	key := fmt.Sprintf("budget-status:%s:%s:%s", req.UserID, now.Location(), now.Format("2006-01-02"))
+	if req.CategoryID != nil {
+		key += ":" + *req.CategoryID
+	}
+	if req.Name != nil {
+		key += ":name=" + *req.Name
 	}
+	return cachedSummary(ctx, u.summaries, ledger, key, func() (*GetBudgetStatusResponse, error) {
+		return u.budgetStatus(ctx, req, now)
+	})
+}
 
-	// Calculate spending by category
-	categorySpending := make(map[string]float64)
-	totalSpent := 0.0
+// budgetStatus computes the status of the requested budgets as of now
+func (u *BudgetManagementUseCase) budgetStatus(ctx context.Context, req *GetBudgetStatusRequest, now time.Time) (*GetBudgetStatusResponse, error) {
+	var all []*domain.Budget
+	var err error
+	if req.GroupID != "" {
+		all, err = u.budgetRepo.GetByGroupID(ctx, req.GroupID)
+	} else {
+		all, err = u.budgetRepo.GetByUserID(ctx, req.UserID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
 
-	for _, expense := range expenses {
-		categoryName := "Uncategorized"
-		if expense.CategoryID != nil {
-			cat, _ := u.categoryRepo.GetByID(ctx, *expense.CategoryID)
-			if cat != nil {
-				categoryName = cat.Name
-			}
+	var budgets []*domain.Budget
+	for _, budget := range all {
+		if req.CategoryID != nil && (budget.IsOverall() || *budget.CategoryID != *req.CategoryID) {
+			continue
+		}
+		if req.Name != nil && budget.Name != *req.Name {
+			continue
 		}
+		budgets = append(budgets, budget)
+	}
 
-		categorySpending[categoryName] += expense.Amount
-		totalSpent += expense.Amount
+	// Total spending for the current month, or over the date range of a
+	// named budget with only custom periods, like a trip
+	totalPeriod := domain.BudgetPeriodMonthly
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	to := now
+	if start, end, ok := customBudgetRange(budgets, now); ok {
+		totalPeriod, from, to = domain.BudgetPeriodCustom, start, end
+		if now.Before(to) {
+			to = now
+		}
 	}
 
-	// Get user's categories to determine budgets
-	categories, _ := u.categoryRepo.GetByUserID(ctx, req.UserID)
+	totalSpent := 0.0
+	if !to.Before(from) {
+		totals, err := u.expenseRepo.SumByCategoryAndDateRange(ctx, domain.ExpenseTotalsQuery{UserID: req.UserID, GroupID: req.GroupID, From: from, To: to})
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum expenses: %w", err)
+		}
+		for _, total := range totals {
+			totalSpent += total.Total
+		}
+	}
 
 	// Build budget status list
-	var budgets []BudgetStatus
+	var statuses []BudgetStatus
 	totalLimit := 0.0
+	overallLimit := 0.0
 	hasAlert := false
 
-	for _, cat := range categories {
-		categoryName := cat.Name
-		spent := categorySpending[categoryName]
+	for _, budget := range budgets {
 
-		// Default budget: 100 per category (in production, would be from budget table)
-		limit := 100.0
-		threshold := 80.0
+		categoryName, err := u.budgetCategoryName(ctx, budget)
+		if err != nil {
+			categoryName = "Uncategorized"
+		}
+
+		spent, err := u.spentInPeriod(ctx, budget, now)
+		if err != nil {
+			return nil, err
+		}
 
-		remaining := limit - spent
+		remaining := budget.Limit - spent
 		percentage := 0.0
-		if limit > 0 {
-			percentage = (spent / limit) * 100
+		if budget.Limit > 0 {
+			percentage = (spent / budget.Limit) * 100
 		}
 
-		isExceeded := spent > limit
-		alertTriggered := percentage >= threshold
+		isExceeded := spent > budget.Limit
+		alertTriggered := percentage >= budget.Threshold
 
 		if alertTriggered {
 			hasAlert = true
@@ -185,15 +620,19 @@ func (u *BudgetManagementUseCase) GetBudgetStatus(ctx context.Context, req *GetB
 
 		message := "On track"
 		if isExceeded {
-			message = fmt.Sprintf("Exceeded by %.2f", spent-limit)
+			message = fmt.Sprintf("Exceeded by %.2f", spent-budget.Limit)
 		} else if alertTriggered {
 			message = fmt.Sprintf("%.0f%% of budget used", percentage)
 		}
 
-		budgets = append(budgets, BudgetStatus{
-			ID:             cat.ID,
+		statuses = append(statuses, BudgetStatus{
+			ID:             budget.ID,
+			Name:           budget.Name,
 			Category:       categoryName,
-			Limit:          limit,
+			Period:         budget.Period,
+			StartDate:      budget.StartDate,
+			EndDate:        budget.EndDate,
+			Limit:          budget.Limit,
 			Spent:          spent,
 			Remaining:      remaining,
 			Percentage:     percentage,
@@ -202,40 +641,176 @@ func (u *BudgetManagementUseCase) GetBudgetStatus(ctx context.Context, req *GetB
 			Message:        message,
 		})
 
-		totalLimit += limit
+		if budget.IsOverall() && budget.Period == totalPeriod {
+			overallLimit = budget.Limit
+		} else if !budget.IsOverall() && budget.Period == totalPeriod {
+			totalLimit += budget.Limit
+		}
+	}
+
+	// An overall budget takes precedence over the sum of category budgets
+	if overallLimit > 0 {
+		totalLimit = overallLimit
 	}
 
 	resp := &GetBudgetStatusResponse{
-		Budgets:    budgets,
+		Name:       req.Name,
+		Budgets:    statuses,
 		TotalLimit: totalLimit,
 		TotalSpent: totalSpent,
 		Alert:      hasAlert,
 	}
 
-	if hasAlert {
+	switch {
+	case len(statuses) == 0:
+		resp.Message = "No budgets set"
+	case hasAlert:
 		resp.Message = "Budget alert: Some categories have exceeded alerts"
-	} else {
+	default:
 		resp.Message = "All budgets on track"
 	}
 
 	return resp, nil
 }
 
+// customBudgetRange returns the dates the budgets cover when all of them have
+// custom periods, in now's timezone
+func customBudgetRange(budgets []*domain.Budget, now time.Time) (time.Time, time.Time, bool) {
+	if len(budgets) == 0 {
+		return time.Time{}, time.Time{}, false
+	}
+	var from, to time.Time
+	for i, budget := range budgets {
+		if budget.Period != domain.BudgetPeriodCustom {
+			return time.Time{}, time.Time{}, false
+		}
+		start, end := budget.PeriodRange(now)
+		if i == 0 || start.Before(from) {
+			from = start
+		}
+		if i == 0 || end.After(to) {
+			to = end
+		}
+	}
+	return from, to, true
+}
+
+// NamedBudget is one of a user's named budgets and what it covers
+type NamedBudget struct {
+	Name      string     `json:"name"` // Empty for the everyday budget
+	Budgets   int        `json:"budgets"`
+	StartDate *time.Time `json:"start_date,omitempty"` // The first day its custom periods cover
+	EndDate   *time.Time `json:"end_date,omitempty"`   // The last day its custom periods cover
+	Active    bool       `json:"active"`               // Whether chat replies follow it
+}
+
+// ErrUnknownBudget is returned when switching to a named budget the user doesn't have
+var ErrUnknownBudget = fmt.Errorf("named budget %w", domain.ErrNotFound)
+
+// ListNamedBudgets returns the user's named budgets, the everyday one first
+// and the rest by name
+func (u *BudgetManagementUseCase) ListNamedBudgets(ctx context.Context, userID string) ([]*NamedBudget, error) {
+	budgets, err := u.ListBudgets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	active, err := u.ActiveBudget(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*NamedBudget)
+	named := make([]*NamedBudget, 0)
+	for _, budget := range budgets {
+		n, ok := byName[budget.Name]
+		if !ok {
+			n = &NamedBudget{Name: budget.Name, Active: budget.Name == active}
+			byName[budget.Name] = n
+			named = append(named, n)
+		}
+		n.Budgets++
+		if budget.Period == domain.BudgetPeriodCustom {
+			if n.StartDate == nil || budget.StartDate.Before(*n.StartDate) {
+				n.StartDate = budget.StartDate
+			}
+			if n.EndDate == nil || budget.EndDate.After(*n.EndDate) {
+				n.EndDate = budget.EndDate
+			}
+		}
+	}
+	sort.SliceStable(named, func(i, j int) bool { return named[i].Name < named[j].Name })
+	return named, nil
+}
+
+// ActiveBudget returns the name of the named budget the user's chat replies
+// follow, "" for the everyday one
+func (u *BudgetManagementUseCase) ActiveBudget(ctx context.Context, userID string) (string, error) {
+	if u.userRepo == nil {
+		return "", nil
+	}
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.ActiveBudget, nil
+}
+
+// SetActiveBudget makes the user's chat replies follow one of their named
+// budgets, or the everyday one when name is empty
+func (u *BudgetManagementUseCase) SetActiveBudget(ctx context.Context, userID, name string) (*NamedBudget, error) {
+	if u.userRepo == nil {
+		return nil, fmt.Errorf("switching budgets is not supported")
+	}
+	named, err := u.ListNamedBudgets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	var target *NamedBudget
+	for _, n := range named {
+		if strings.EqualFold(n.Name, name) {
+			target = n
+			break
+		}
+	}
+	if target == nil && name != "" {
+		return nil, ErrUnknownBudget
+	}
+	if target == nil {
+		target = &NamedBudget{}
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	user.ActiveBudget = target.Name
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to save active budget: %w", err)
+	}
+	target.Active = true
+	return target, nil
+}
+
 // CompareToBudgetRequest represents a request to compare spending to budget
 type CompareToBudgetRequest struct {
 	UserID     string
-	CategoryID *string
-	Period     string // "daily", "weekly", "monthly"
+	CategoryID *string // nil compares against the overall budget
+	Period     string  // "weekly", "monthly"; empty uses whichever budget exists
 }
 
 // BudgetComparison represents a comparison of spending to budget
 type BudgetComparison struct {
 	Category       string  `json:"category"`
+	Period         string  `json:"period"`
 	BudgetLimit    float64 `json:"budget_limit"`
 	Spent          float64 `json:"spent"`
 	Remaining      float64 `json:"remaining"`
 	PercentageUsed float64 `json:"percentage_used"`
-	Status         string  `json:"status"` // "under", "warning", "exceeded"
+	Status         string  `json:"status"` // "no_budget", "under", "warning", "exceeded"
 	Recommendation string  `json:"recommendation"`
 }
 
@@ -245,72 +820,51 @@ func (u *BudgetManagementUseCase) CompareToBudget(ctx context.Context, req *Comp
 		return nil, fmt.Errorf("user_id is required")
 	}
 
-	if req.Period == "" {
-		req.Period = "monthly"
+	budgets, err := u.budgetRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
 	}
 
-	// Calculate period dates
-	now := time.Now()
-	var startDate, endDate time.Time
-
-	switch req.Period {
-	case "daily":
-		startDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		endDate = startDate.Add(24*time.Hour - time.Nanosecond)
-	case "weekly":
-		startDate = now.AddDate(0, 0, -int(now.Weekday()))
-		endDate = startDate.AddDate(0, 0, 7).Add(-time.Nanosecond)
-	case "monthly":
-		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		endDate = startDate.AddDate(0, 1, -1).Add(24*time.Hour - time.Nanosecond)
+	// Find the budget for the requested scope, preferring monthly when no period is given
+	target := &domain.Budget{UserID: req.UserID, CategoryID: req.CategoryID}
+	target.WorkspaceID, _ = domain.WorkspaceFromContext(ctx)
+	if target.IsOverall() {
+		target.CategoryID = nil
 	}
-
-	// Get category name
-	var categoryName string
-	if req.CategoryID != nil {
-		cat, _ := u.categoryRepo.GetByID(ctx, *req.CategoryID)
-		if cat != nil {
-			categoryName = cat.Name
+	var budget *domain.Budget
+	for _, b := range budgets {
+		target.Period = b.Period
+		if !sameBudgetScope(b, target) || (req.Period != "" && b.Period != req.Period) {
+			continue
+		}
+		if budget == nil || b.Period == domain.BudgetPeriodMonthly {
+			budget = b
 		}
 	}
 
-	// Get expenses for the category and period
-	var expenses []*domain.Expense
-	var err error
-
-	if req.CategoryID != nil {
-		expenses, err = u.expenseRepo.GetByUserIDAndCategory(ctx, req.UserID, *req.CategoryID)
-	} else {
-		expenses, err = u.expenseRepo.GetByUserIDAndDateRange(ctx, req.UserID, startDate, endDate)
-	}
-
+	categoryName, err := u.budgetCategoryName(ctx, target)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get expenses: %w", err)
+		return nil, err
 	}
 
-	// Filter by date range if category was specified
-	if req.CategoryID != nil {
-		var filtered []*domain.Expense
-		for _, exp := range expenses {
-			if exp.ExpenseDate.After(startDate) && exp.ExpenseDate.Before(endDate) {
-				filtered = append(filtered, exp)
-			}
-		}
-		expenses = filtered
+	if budget == nil {
+		return &BudgetComparison{
+			Category:       categoryName,
+			Period:         req.Period,
+			Status:         "no_budget",
+			Recommendation: "No budget set. Create one to start tracking your spending.",
+		}, nil
 	}
 
-	// Calculate spending
-	spent := 0.0
-	for _, exp := range expenses {
-		spent += exp.Amount
+	spent, err := u.spentInPeriod(ctx, budget, domain.LocalNow(ctx))
+	if err != nil {
+		return nil, err
 	}
 
-	// Default budget (in production, would come from budget table)
-	budgetLimit := 100.0
-	remaining := budgetLimit - spent
+	remaining := budget.Limit - spent
 	percentageUsed := 0.0
-	if budgetLimit > 0 {
-		percentageUsed = (spent / budgetLimit) * 100
+	if budget.Limit > 0 {
+		percentageUsed = (spent / budget.Limit) * 100
 	}
 
 	// Determine status and recommendation
@@ -319,15 +873,16 @@ func (u *BudgetManagementUseCase) CompareToBudget(ctx context.Context, req *Comp
 
 	if percentageUsed >= 100 {
 		status = "exceeded"
-		recommendation = fmt.Sprintf("You've exceeded your budget by %.2f. Try to reduce spending.", spent-budgetLimit)
-	} else if percentageUsed >= 80 {
+		recommendation = fmt.Sprintf("You've exceeded your budget by %.2f. Try to reduce spending.", spent-budget.Limit)
+	} else if percentageUsed >= budget.Threshold {
 		status = "warning"
 		recommendation = fmt.Sprintf("You're at %.0f%% of your budget. Be careful not to exceed it.", percentageUsed)
 	}
 
 	return &BudgetComparison{
 		Category:       categoryName,
-		BudgetLimit:    budgetLimit,
+		Period:         budget.Period,
+		BudgetLimit:    budget.Limit,
 		Spent:          spent,
 		Remaining:      remaining,
 		PercentageUsed: percentageUsed,
@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func newBudgetTestUseCase(t *testing.T) (*BudgetManagementUseCase, *MockCategoryRepository, *MockExpenseRepository) {
+	t.Helper()
+	categoryRepo := NewMockCategoryRepository()
+	expenseRepo := NewMockExpenseRepository()
+	uc := NewBudgetManagementUseCase(NewMockBudgetRepository(), categoryRepo, expenseRepo, NewMockGroupRepository())
+
+	ctx := context.Background()
+	categoryRepo.Create(ctx, &domain.Category{ID: "cat_food", UserID: "user1", Name: "Food"})
+	categoryRepo.Create(ctx, &domain.Category{ID: "cat_other", UserID: "user2", Name: "Other"})
+	return uc, categoryRepo, expenseRepo
+}
+
+func TestBudgetCRUD(t *testing.T) {
+	uc, _, _ := newBudgetTestUseCase(t)
+	ctx := context.Background()
+	food := "cat_food"
+
+	created, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", CategoryID: &food, Limit: 5000})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Category != "Food" || created.Budget.Period != domain.BudgetPeriodMonthly || created.Budget.Threshold != 80 {
+		t.Errorf("unexpected defaults: %+v (category %q)", created.Budget, created.Category)
+	}
+
+	if _, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", CategoryID: &food, Limit: 3000}); err == nil {
+		t.Error("expected duplicate monthly budget to be rejected")
+	}
+
+	if _, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", Limit: 2000, Period: domain.BudgetPeriodWeekly}); err != nil {
+		t.Fatalf("unexpected error creating overall budget: %v", err)
+	}
+
+	limit := 6000.0
+	updated, err := uc.UpdateBudget(ctx, &UpdateBudgetRequest{ID: created.Budget.ID, UserID: "user1", Limit: &limit})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Budget.Limit != 6000 {
+		t.Errorf("expected limit 6000, got %.2f", updated.Budget.Limit)
+	}
+
+	if _, err := uc.UpdateBudget(ctx, &UpdateBudgetRequest{ID: created.Budget.ID, UserID: "user2", Limit: &limit}); err == nil {
+		t.Error("expected update by another user to be rejected")
+	}
+
+	if _, err := uc.DeleteBudget(ctx, &DeleteBudgetRequest{ID: created.Budget.ID, UserID: "user1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	budgets, _ := uc.ListBudgets(ctx, "user1")
+	if len(budgets) != 1 || !budgets[0].IsOverall() {
+		t.Errorf("expected only the overall budget to remain, got %d", len(budgets))
+	}
+}
+
+func TestCreateBudgetValidation(t *testing.T) {
+	uc, _, _ := newBudgetTestUseCase(t)
+	ctx := context.Background()
+	other := "cat_other"
+	start := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 6)
+
+	tests := []struct {
+		name string
+		req  *CreateBudgetRequest
+	}{
+		{name: "zero limit", req: &CreateBudgetRequest{UserID: "user1", Limit: 0}},
+		{name: "daily period", req: &CreateBudgetRequest{UserID: "user1", Limit: 100, Period: "daily"}},
+		{name: "threshold over 100", req: &CreateBudgetRequest{UserID: "user1", Limit: 100, Threshold: 120}},
+		{name: "foreign category", req: &CreateBudgetRequest{UserID: "user1", CategoryID: &other, Limit: 100}},
+		{name: "custom period without dates", req: &CreateBudgetRequest{UserID: "user1", Limit: 100, Period: domain.BudgetPeriodCustom}},
+		{name: "dates on a monthly period", req: &CreateBudgetRequest{UserID: "user1", Limit: 100, Period: domain.BudgetPeriodMonthly, StartDate: &start, EndDate: &end}},
+		{name: "end before start", req: &CreateBudgetRequest{UserID: "user1", Limit: 100, StartDate: &end, EndDate: &start}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := uc.CreateBudget(ctx, tt.req); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestGetBudgetStatus(t *testing.T) {
+	uc, _, expenseRepo := newBudgetTestUseCase(t)
+	ctx := context.Background()
+	food := "cat_food"
+
+	uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", CategoryID: &food, Limit: 1000})
+	uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", Limit: 10000})
+
+	now := time.Now()
+	expenseRepo.Create(ctx, &domain.Expense{ID: "e1", UserID: "user1", CategoryID: &food, Amount: 850, ExpenseDate: now.Add(-time.Minute)})
+	expenseRepo.Create(ctx, &domain.Expense{ID: "e2", UserID: "user1", Amount: 300, ExpenseDate: now.Add(-time.Minute)})
+
+	resp, err := uc.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: "user1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Budgets) != 2 {
+		t.Fatalf("expected 2 budget statuses, got %d", len(resp.Budgets))
+	}
+	if !resp.Alert {
+		t.Error("expected alert for food budget at 85%")
+	}
+	if resp.TotalLimit != 10000 {
+		t.Errorf("expected overall limit to take precedence, got %.2f", resp.TotalLimit)
+	}
+
+	for _, status := range resp.Budgets {
+		switch status.Category {
+		case "Food":
+			if status.Spent != 850 || !status.AlertTriggered || status.IsExceeded {
+				t.Errorf("unexpected food status: %+v", status)
+			}
+		case "Overall":
+			if status.Spent != 1150 || status.AlertTriggered {
+				t.Errorf("unexpected overall status: %+v", status)
+			}
+		default:
+			t.Errorf("unexpected category %q", status.Category)
+		}
+	}
+}
+
+func TestCompareToBudget(t *testing.T) {
+	uc, _, expenseRepo := newBudgetTestUseCase(t)
+	ctx := context.Background()
+	food := "cat_food"
+
+	resp, err := uc.CompareToBudget(ctx, &CompareToBudgetRequest{UserID: "user1", CategoryID: &food})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != "no_budget" {
+		t.Errorf("expected no_budget, got %q", resp.Status)
+	}
+
+	uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", CategoryID: &food, Limit: 500})
+	expenseRepo.Create(ctx, &domain.Expense{ID: "e1", UserID: "user1", CategoryID: &food, Amount: 600, ExpenseDate: time.Now().Add(-time.Minute)})
+
+	resp, err = uc.CompareToBudget(ctx, &CompareToBudgetRequest{UserID: "user1", CategoryID: &food})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != "exceeded" || resp.BudgetLimit != 500 || resp.Spent != 600 {
+		t.Errorf("unexpected comparison: %+v", resp)
+	}
+}
+
+func TestGroupBudgetStatus(t *testing.T) {
+	uc, categoryRepo, expenseRepo := newBudgetTestUseCase(t)
+	ctx := context.Background()
+	food := "cat_food"
+
+	groups := NewGroupLedgerUseCase(uc.groupRepo)
+	group, _ := groups.EnsureGroup(ctx, "line", "C123", "Family", "user1")
+	groups.EnsureGroup(ctx, "line", "C123", "Family", "user2")
+	categoryRepo.Create(ctx, &domain.Category{ID: "cat_food_2", UserID: "user2", Name: "Food"})
+
+	if _, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", GroupID: &group.ID, CategoryID: &food, Limit: 1000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "stranger", GroupID: &group.ID, Limit: 1000}); err == nil {
+		t.Error("expected non-member to be rejected")
+	}
+
+	food2 := "cat_food_2"
+	now := time.Now().Add(-time.Minute)
+	expenseRepo.Create(ctx, &domain.Expense{ID: "e1", UserID: "user1", GroupID: &group.ID, CategoryID: &food, Amount: 300, ExpenseDate: now})
+	expenseRepo.Create(ctx, &domain.Expense{ID: "e2", UserID: "user2", GroupID: &group.ID, CategoryID: &food2, Amount: 500, ExpenseDate: now})
+	expenseRepo.Create(ctx, &domain.Expense{ID: "e3", UserID: "user1", CategoryID: &food, Amount: 999, ExpenseDate: now})
+
+	resp, err := uc.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: "user2", GroupID: group.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Budgets) != 1 || resp.Budgets[0].Spent != 800 || !resp.Budgets[0].AlertTriggered {
+		t.Errorf("unexpected group budget status: %+v", resp.Budgets)
+	}
+	if resp.TotalSpent != 800 {
+		t.Errorf("expected group total 800, got %.2f", resp.TotalSpent)
+	}
+
+	// The group budget does not count against user1's personal budgets
+	personal, _ := uc.ListBudgets(ctx, "user1")
+	if len(personal) != 0 {
+		t.Errorf("expected no personal budgets, got %d", len(personal))
+	}
+}
+
+func TestNamedBudgets(t *testing.T) {
+	uc, _, expenseRepo := newBudgetTestUseCase(t)
+	userRepo := NewMockUserRepository()
+	uc.SetUserRepository(userRepo)
+	ctx := context.Background()
+	food := "cat_food"
+	userRepo.Create(ctx, &domain.User{UserID: "user1"})
+
+	now := time.Now()
+	tripStart, tripEnd := now.AddDate(0, 0, -3), now.AddDate(0, 0, 3)
+	if _, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", Limit: 10000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	trip, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", Name: " 日本旅行 ", Limit: 50000, StartDate: &tripStart, EndDate: &tripEnd})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if trip.Budget.Name != "日本旅行" || trip.Budget.Period != domain.BudgetPeriodCustom {
+		t.Errorf("unexpected trip budget: %+v", trip.Budget)
+	}
+	if _, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", Name: "日本旅行", CategoryID: &food, Limit: 20000, StartDate: &tripStart, EndDate: &tripEnd}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", Name: "日本旅行", Limit: 1000, StartDate: &tripStart, EndDate: &tripEnd}); err == nil {
+		t.Error("expected a second overall trip budget to be rejected")
+	}
+
+	expenseRepo.Create(ctx, &domain.Expense{ID: "e1", UserID: "user1", CategoryID: &food, Amount: 3000, ExpenseDate: now.Add(-time.Minute)})
+	expenseRepo.Create(ctx, &domain.Expense{ID: "e2", UserID: "user1", Amount: 700, ExpenseDate: now.AddDate(0, 0, -10)})
+
+	named, err := uc.ListNamedBudgets(ctx, "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(named) != 2 || named[0].Name != "" || !named[0].Active || named[1].Name != "日本旅行" || named[1].Budgets != 2 || named[1].StartDate == nil {
+		t.Fatalf("unexpected named budgets: %+v", named)
+	}
+
+	if _, err := uc.SetActiveBudget(ctx, "user1", "Hokkaido"); !errors.Is(err, ErrUnknownBudget) {
+		t.Errorf("expected ErrUnknownBudget, got %v", err)
+	}
+	if _, err := uc.SetActiveBudget(ctx, "user1", "日本旅行"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if active, _ := uc.ActiveBudget(ctx, "user1"); active != "日本旅行" {
+		t.Errorf("expected the trip to be active, got %q", active)
+	}
+
+	// The active trip reports its own budgets, totalled over the trip's dates
+	resp, err := uc.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: "user1", Active: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Budgets) != 2 || resp.TotalLimit != 50000 || resp.TotalSpent != 3000 {
+		t.Errorf("unexpected trip status: %+v", resp)
+	}
+	for _, status := range resp.Budgets {
+		if status.Name != "日本旅行" || status.Spent != 3000 {
+			t.Errorf("unexpected trip budget status: %+v", status)
+		}
+	}
+
+	everyday := ""
+	resp, err = uc.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: "user1", Name: &everyday})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Budgets) != 1 || resp.Budgets[0].Name != "" || resp.TotalLimit != 10000 {
+		t.Errorf("unexpected everyday status: %+v", resp)
+	}
+
+	if _, err := uc.SetActiveBudget(ctx, "user1", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if active, _ := uc.ActiveBudget(ctx, "user1"); active != "" {
+		t.Errorf("expected the everyday budget to be active, got %q", active)
+	}
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// DefaultCategoryConfirmationTTL is how long a category question can still be answered
+const DefaultCategoryConfirmationTTL = 10 * time.Minute
+
+// ExpenseUpdater changes fields of an existing expense
+type ExpenseUpdater interface {
+	Execute(ctx context.Context, req *UpdateRequest) (*UpdateResponse, error)
+}
+
+// pendingCategoryConfirmation is the category question last asked to a user
+type pendingCategoryConfirmation struct {
+	expenseID    string
+	description  string
+	alternatives []string
+	expiresAt    time.Time
+}
+
+// CategoryConfirmationUseCase asks the user to confirm a low-confidence AI category
+// and applies their answer ("2" or a category name) to the expense. Only the latest
+// question per user is kept, in memory.
+type CategoryConfirmationUseCase struct {
+	categoryRepo  domain.CategoryRepository
+	expenseUpdate ExpenseUpdater
+	ttl           time.Duration
+	now           func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*pendingCategoryConfirmation
+}
+
+// NewCategoryConfirmationUseCase creates a new category confirmation use case
+func NewCategoryConfirmationUseCase(categoryRepo domain.CategoryRepository, expenseUpdate ExpenseUpdater, ttl time.Duration) *CategoryConfirmationUseCase {
+	if ttl <= 0 {
+		ttl = DefaultCategoryConfirmationTTL
+	}
+	return &CategoryConfirmationUseCase{
+		categoryRepo:  categoryRepo,
+		expenseUpdate: expenseUpdate,
+		ttl:           ttl,
+		now:           time.Now,
+		pending:       make(map[string]*pendingCategoryConfirmation),
+	}
+}
+
+// Ask remembers the question for the user and returns it as reply text
+func (u *CategoryConfirmationUseCase) Ask(ctx context.Context, userID, expenseID, description, category string, confidence float64, alternatives []string) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	now := u.now()
+	for id, pending := range u.pending {
+		if now.After(pending.expiresAt) {
+			delete(u.pending, id)
+		}
+	}
+	u.pending[userID] = &pendingCategoryConfirmation{
+		expenseID:    expenseID,
+		description:  description,
+		alternatives: alternatives,
+		expiresAt:    now.Add(u.ttl),
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(ctx, "confirm.ask", description, category, int(confidence*100+0.5)))
+	for i, alt := range alternatives {
+		sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, alt))
+	}
+	return sb.String()
+}
+
+// Resolve applies the user's answer to their open question. It returns false when
+// the message is not an answer, so it can be processed as usual.
+func (u *CategoryConfirmationUseCase) Resolve(ctx context.Context, userID, text string) (string, bool) {
+	pending, choice := u.take(userID, strings.TrimSpace(text))
+	if pending == nil {
+		return "", false
+	}
+
+	category, err := u.categoryRepo.GetByUserIDAndName(ctx, userID, choice)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to find category", "category", choice, "error", err)
+		return translate(ctx, "confirm.not_found", choice), true
+	}
+
+	if _, err := u.expenseUpdate.Execute(ctx, &UpdateRequest{
+		ID:         pending.expenseID,
+		UserID:     userID,
+		CategoryID: &category.ID,
+	}); err != nil {
+		slog.ErrorContext(ctx, "Failed to change category of expense", "expense_id", pending.expenseID, "error", err)
+		return translate(ctx, "confirm.failed"), true
+	}
+	return translate(ctx, "confirm.changed", pending.description, category.Name), true
+}
+
+// take removes and returns the user's open question with the chosen category when text answers it
+func (u *CategoryConfirmationUseCase) take(userID, text string) (*pendingCategoryConfirmation, string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	pending, ok := u.pending[userID]
+	if !ok {
+		return nil, ""
+	}
+	if u.now().After(pending.expiresAt) {
+		delete(u.pending, userID)
+		return nil, ""
+	}
+
+	choice := ""
+	if n, err := strconv.Atoi(text); err == nil {
+		if n >= 1 && n <= len(pending.alternatives) {
+			choice = pending.alternatives[n-1]
+		}
+	} else {
+		for _, alt := range pending.alternatives {
+			if strings.EqualFold(alt, text) {
+				choice = alt
+			}
+		}
+	}
+	if choice == "" {
+		return nil, ""
+	}
+
+	delete(u.pending, userID)
+	return pending, choice
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestCategoryConfirmationUseCase(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*CategoryConfirmationUseCase, *MockExpenseRepository, *time.Time) {
+		expenseRepo := NewMockExpenseRepository()
+		categoryRepo := NewMockCategoryRepository()
+		for _, name := range []string{"Food", "Entertainment", "Shopping"} {
+			categoryRepo.Create(ctx, &domain.Category{ID: "cat_" + name, UserID: "user1", Name: name})
+		}
+		foodID := "cat_Food"
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "電影院爆米花", Amount: 150, CategoryID: &foodID})
+
+		confirmer := NewCategoryConfirmationUseCase(categoryRepo, NewUpdateExpenseUseCase(expenseRepo, categoryRepo), time.Minute)
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		confirmer.now = func() time.Time { return now }
+		return confirmer, expenseRepo, &now
+	}
+
+	t.Run("Ask lists alternatives", func(t *testing.T) {
+		confirmer, _, _ := setup()
+		question := confirmer.Ask(ctx, "user1", "exp1", "電影院爆米花", "Food", 0.45, []string{"Entertainment", "Shopping"})
+		for _, want := range []string{"Food", "45%", "1. Entertainment", "2. Shopping"} {
+			if !strings.Contains(question, want) {
+				t.Errorf("expected question to contain %q, got %q", want, question)
+			}
+		}
+	})
+
+	t.Run("Answer by number", func(t *testing.T) {
+		confirmer, expenseRepo, _ := setup()
+		confirmer.Ask(ctx, "user1", "exp1", "電影院爆米花", "Food", 0.45, []string{"Entertainment", "Shopping"})
+
+		reply, ok := confirmer.Resolve(ctx, "user1", " 1 ")
+		if !ok {
+			t.Fatal("expected reply to be handled")
+		}
+		if !strings.Contains(reply, "Entertainment") {
+			t.Errorf("expected confirmation to mention Entertainment, got %q", reply)
+		}
+		expense, _ := expenseRepo.GetByID(ctx, "exp1")
+		if expense.CategoryID == nil || *expense.CategoryID != "cat_Entertainment" {
+			t.Errorf("expected category cat_Entertainment, got %v", expense.CategoryID)
+		}
+
+		if _, ok := confirmer.Resolve(ctx, "user1", "2"); ok {
+			t.Error("expected question to be closed after it was answered")
+		}
+	})
+
+	t.Run("Answer by name", func(t *testing.T) {
+		confirmer, expenseRepo, _ := setup()
+		confirmer.Ask(ctx, "user1", "exp1", "電影院爆米花", "Food", 0.45, []string{"Entertainment", "Shopping"})
+
+		if _, ok := confirmer.Resolve(ctx, "user1", "shopping"); !ok {
+			t.Fatal("expected reply to be handled")
+		}
+		expense, _ := expenseRepo.GetByID(ctx, "exp1")
+		if expense.CategoryID == nil || *expense.CategoryID != "cat_Shopping" {
+			t.Errorf("expected category cat_Shopping, got %v", expense.CategoryID)
+		}
+	})
+
+	t.Run("Other messages are not answers", func(t *testing.T) {
+		confirmer, expenseRepo, _ := setup()
+		confirmer.Ask(ctx, "user1", "exp1", "電影院爆米花", "Food", 0.45, []string{"Entertainment", "Shopping"})
+
+		for _, text := range []string{"午餐 120", "3", "0"} {
+			if _, ok := confirmer.Resolve(ctx, "user1", text); ok {
+				t.Errorf("expected %q not to be handled", text)
+			}
+		}
+		if _, ok := confirmer.Resolve(ctx, "user2", "1"); ok {
+			t.Error("expected another user's reply not to be handled")
+		}
+		expense, _ := expenseRepo.GetByID(ctx, "exp1")
+		if *expense.CategoryID != "cat_Food" {
+			t.Errorf("expected category to stay cat_Food, got %s", *expense.CategoryID)
+		}
+	})
+
+	t.Run("Question expires", func(t *testing.T) {
+		confirmer, _, now := setup()
+		confirmer.Ask(ctx, "user1", "exp1", "電影院爆米花", "Food", 0.45, []string{"Entertainment", "Shopping"})
+		*now = now.Add(2 * time.Minute)
+
+		if _, ok := confirmer.Resolve(ctx, "user1", "1"); ok {
+			t.Error("expected expired question not to be handled")
+		}
+	})
+}
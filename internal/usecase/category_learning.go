@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/ai"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// maxLearnedKeywordLength skips descriptions too specific to be useful as keywords
+const maxLearnedKeywordLength = 20
+
+// categoryExampleLimit is how many recent corrections are offered as few-shot examples
+const categoryExampleLimit = 5
+
+// CategoryLearningUseCase learns from the categories users pick for their expenses.
+// Each correction is recorded, and the expense description becomes a keyword of the
+// chosen category, gaining priority each time it is confirmed and losing it when
+// the user moves it elsewhere.
+type CategoryLearningUseCase struct {
+	categoryRepo   domain.CategoryRepository
+	correctionRepo domain.CategoryCorrectionRepository
+}
+
+var _ ai.CategoryHintSource = (*CategoryLearningUseCase)(nil)
+
+// NewCategoryLearningUseCase creates a new category learning use case
+func NewCategoryLearningUseCase(categoryRepo domain.CategoryRepository, correctionRepo domain.CategoryCorrectionRepository) *CategoryLearningUseCase {
+	return &CategoryLearningUseCase{
+		categoryRepo:   categoryRepo,
+		correctionRepo: correctionRepo,
+	}
+}
+
+// RecordCorrection stores that the user moved an expense to another category and
+// updates the user's keyword priorities
+func (u *CategoryLearningUseCase) RecordCorrection(ctx context.Context, expense *domain.Expense, fromCategoryID *string, toCategoryID string) error {
+	correction := &domain.CategoryCorrection{
+		ID:             uuid.New().String(),
+		UserID:         expense.UserID,
+		ExpenseID:      expense.ID,
+		Description:    expense.Description,
+		FromCategoryID: fromCategoryID,
+		ToCategoryID:   toCategoryID,
+		CreatedAt:      time.Now(),
+	}
+	if err := u.correctionRepo.Create(ctx, correction); err != nil {
+		return fmt.Errorf("failed to record category correction: %w", err)
+	}
+
+	keyword := learnedKeyword(expense.Description)
+	if keyword == "" {
+		return nil
+	}
+
+	keywords, err := u.categoryRepo.GetKeywordsByUserID(ctx, expense.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get keywords: %w", err)
+	}
+
+	learned := false
+	for _, kw := range keywords {
+		if !strings.EqualFold(kw.Keyword, keyword) {
+			continue
+		}
+		switch {
+		case kw.CategoryID == toCategoryID:
+			kw.Priority++
+			learned = true
+			if err := u.categoryRepo.UpdateKeyword(ctx, kw); err != nil {
+				return fmt.Errorf("failed to update keyword: %w", err)
+			}
+		case fromCategoryID != nil && kw.CategoryID == *fromCategoryID:
+			kw.Priority--
+			if kw.Priority <= 0 {
+				err = u.categoryRepo.DeleteKeyword(ctx, kw.ID)
+			} else {
+				err = u.categoryRepo.UpdateKeyword(ctx, kw)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update keyword: %w", err)
+			}
+		}
+	}
+
+	if !learned {
+		kw := &domain.CategoryKeyword{
+			ID:         uuid.New().String(),
+			CategoryID: toCategoryID,
+			Keyword:    keyword,
+			Priority:   1,
+			CreatedAt:  time.Now(),
+		}
+		if err := u.categoryRepo.CreateKeyword(ctx, kw); err != nil {
+			return fmt.Errorf("failed to create keyword: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetCategoryHints returns the user's keywords and latest corrections by category name
+func (u *CategoryLearningUseCase) GetCategoryHints(ctx context.Context, userID string) (*ai.CategoryHints, error) {
+	categories, err := u.categoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	names := make(map[string]string, len(categories))
+	for _, cat := range categories {
+		names[cat.ID] = cat.Name
+	}
+
+	hints := &ai.CategoryHints{}
+
+	keywords, err := u.categoryRepo.GetKeywordsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keywords: %w", err)
+	}
+	for _, kw := range keywords {
+		if name, ok := names[kw.CategoryID]; ok {
+			hints.Keywords = append(hints.Keywords, ai.LearnedKeyword{Keyword: kw.Keyword, Category: name, Priority: kw.Priority})
+		}
+	}
+
+	corrections, err := u.correctionRepo.GetRecentByUserID(ctx, userID, categoryExampleLimit*2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category corrections: %w", err)
+	}
+	seen := make(map[string]bool)
+	for _, correction := range corrections {
+		name, ok := names[correction.ToCategoryID]
+		key := strings.ToLower(strings.TrimSpace(correction.Description))
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		hints.Examples = append(hints.Examples, ai.CategoryExample{Description: correction.Description, Category: name})
+		if len(hints.Examples) == categoryExampleLimit {
+			break
+		}
+	}
+	return hints, nil
+}
+
+// learnedKeyword turns an expense description into a keyword, or "" when it is too long to reuse
+func learnedKeyword(description string) string {
+	keyword := strings.ToLower(strings.TrimSpace(description))
+	if utf8.RuneCountInString(keyword) > maxLearnedKeywordLength {
+		return ""
+	}
+	return keyword
+}
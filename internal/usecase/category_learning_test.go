@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestCategoryLearningUseCase(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (*CategoryLearningUseCase, *UpdateExpenseUseCase, *MockCategoryRepository, *MockCategoryCorrectionRepository, *MockExpenseRepository) {
+		categoryRepo := NewMockCategoryRepository()
+		for _, name := range []string{"Food", "Entertainment", "Shopping"} {
+			categoryRepo.Create(ctx, &domain.Category{ID: "cat_" + name, UserID: "user1", Name: name})
+		}
+		correctionRepo := NewMockCategoryCorrectionRepository()
+		expenseRepo := NewMockExpenseRepository()
+		foodID := "cat_Food"
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "Popcorn", CategoryID: &foodID})
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp2", UserID: "user1", Description: "popcorn ", CategoryID: &foodID})
+
+		learner := NewCategoryLearningUseCase(categoryRepo, correctionRepo)
+		updater := NewUpdateExpenseUseCase(expenseRepo, categoryRepo)
+		updater.SetCategoryLearner(learner)
+		return learner, updater, categoryRepo, correctionRepo, expenseRepo
+	}
+
+	moveTo := func(t *testing.T, updater *UpdateExpenseUseCase, expenseID, categoryID string) {
+		t.Helper()
+		if _, err := updater.Execute(ctx, &UpdateRequest{ID: expenseID, UserID: "user1", CategoryID: &categoryID}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	keywordPriority := func(categoryRepo *MockCategoryRepository, categoryID string) int {
+		keywords, _ := categoryRepo.GetKeywordsByCategory(ctx, categoryID)
+		for _, kw := range keywords {
+			if kw.Keyword == "popcorn" {
+				return kw.Priority
+			}
+		}
+		return 0
+	}
+
+	t.Run("Corrections raise keyword priority", func(t *testing.T) {
+		_, updater, categoryRepo, correctionRepo, _ := setup()
+		moveTo(t, updater, "exp1", "cat_Entertainment")
+		moveTo(t, updater, "exp2", "cat_Entertainment")
+
+		if got := keywordPriority(categoryRepo, "cat_Entertainment"); got != 2 {
+			t.Errorf("expected priority 2, got %d", got)
+		}
+		corrections, _ := correctionRepo.GetRecentByUserID(ctx, "user1", 10)
+		if len(corrections) != 2 {
+			t.Fatalf("expected 2 corrections, got %d", len(corrections))
+		}
+		if corrections[0].FromCategoryID == nil || *corrections[0].FromCategoryID != "cat_Food" {
+			t.Errorf("expected correction from cat_Food, got %v", corrections[0].FromCategoryID)
+		}
+	})
+
+	t.Run("Moving away lowers the old keyword", func(t *testing.T) {
+		_, updater, categoryRepo, _, _ := setup()
+		moveTo(t, updater, "exp1", "cat_Entertainment")
+		moveTo(t, updater, "exp1", "cat_Shopping")
+
+		if got := keywordPriority(categoryRepo, "cat_Entertainment"); got != 0 {
+			t.Errorf("expected Entertainment keyword to be removed, got priority %d", got)
+		}
+		if got := keywordPriority(categoryRepo, "cat_Shopping"); got != 1 {
+			t.Errorf("expected Shopping priority 1, got %d", got)
+		}
+	})
+
+	t.Run("Unchanged category is not a correction", func(t *testing.T) {
+		_, updater, _, correctionRepo, _ := setup()
+		moveTo(t, updater, "exp1", "cat_Food")
+
+		if corrections, _ := correctionRepo.GetRecentByUserID(ctx, "user1", 10); len(corrections) != 0 {
+			t.Errorf("expected no corrections, got %d", len(corrections))
+		}
+	})
+
+	t.Run("Long descriptions are recorded but not learned as keywords", func(t *testing.T) {
+		learner, _, categoryRepo, correctionRepo, _ := setup()
+		expense := &domain.Expense{ID: "exp3", UserID: "user1", Description: "Dinner with the whole team after the launch"}
+		if err := learner.RecordCorrection(ctx, expense, nil, "cat_Food"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if keywords, _ := categoryRepo.GetKeywordsByUserID(ctx, "user1"); len(keywords) != 0 {
+			t.Errorf("expected no keywords, got %d", len(keywords))
+		}
+		if corrections, _ := correctionRepo.GetRecentByUserID(ctx, "user1", 10); len(corrections) != 1 {
+			t.Errorf("expected 1 correction, got %d", len(corrections))
+		}
+	})
+
+	t.Run("Hints use category names", func(t *testing.T) {
+		learner, updater, _, _, _ := setup()
+		moveTo(t, updater, "exp1", "cat_Entertainment")
+		moveTo(t, updater, "exp2", "cat_Entertainment")
+
+		hints, err := learner.GetCategoryHints(ctx, "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(hints.Keywords) != 1 || hints.Keywords[0].Category != "Entertainment" || hints.Keywords[0].Priority != 2 {
+			t.Errorf("expected popcorn keyword for Entertainment with priority 2, got %+v", hints.Keywords)
+		}
+		// Both corrections are for the same description, so only one example is given
+		if len(hints.Examples) != 1 || hints.Examples[0].Category != "Entertainment" {
+			t.Errorf("expected one Entertainment example, got %+v", hints.Examples)
+		}
+	})
+}
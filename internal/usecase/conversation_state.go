@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// DefaultConversationStateTTL is how long a clarification question can still be answered
+const DefaultConversationStateTTL = 10 * time.Minute
+
+// cancelKeywords drop the question the user was asked instead of answering it
+var cancelKeywords = []string{"取消", "算了", "不用了", "cancel", "never mind", "nevermind"}
+
+// ConversationStateUseCase remembers the clarification question each user was
+// last asked, so their next message can answer it. Questions are kept in the
+// repository, so they survive restarts and are shared between instances.
+type ConversationStateUseCase struct {
+	repo domain.ConversationStateRepository
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+// NewConversationStateUseCase creates a new conversation state use case
+func NewConversationStateUseCase(repo domain.ConversationStateRepository, ttl time.Duration) *ConversationStateUseCase {
+	if ttl <= 0 {
+		ttl = DefaultConversationStateTTL
+	}
+	return &ConversationStateUseCase{
+		repo: repo,
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+// Ask remembers that the user's next message fills the awaiting slot of the
+// intent, replacing any question they were asked before
+func (u *ConversationStateUseCase) Ask(ctx context.Context, userID, intent, awaiting string, slots map[string]string) error {
+	now := u.now()
+	return u.repo.Save(ctx, &domain.ConversationState{
+		UserID:    userID,
+		Intent:    intent,
+		Awaiting:  awaiting,
+		Slots:     slots,
+		ExpiresAt: now.Add(u.ttl),
+		UpdatedAt: now,
+	})
+}
+
+// Pending returns the question the user has yet to answer, or nil when there
+// is none or it expired
+func (u *ConversationStateUseCase) Pending(ctx context.Context, userID string) (*domain.ConversationState, error) {
+	state, err := u.repo.GetByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.now().After(state.ExpiresAt) {
+		if err := u.repo.Delete(ctx, userID); err != nil {
+			slog.WarnContext(ctx, "Failed to delete expired conversation state", "error", err)
+		}
+		return nil, nil
+	}
+	return state, nil
+}
+
+// Clear forgets the user's question once it is answered or cancelled
+func (u *ConversationStateUseCase) Clear(ctx context.Context, userID string) error {
+	return u.repo.Delete(ctx, userID)
+}
+
+// Cleanup deletes the questions that expired without an answer
+func (u *ConversationStateUseCase) Cleanup(ctx context.Context) (int64, error) {
+	return u.repo.DeleteExpiredBefore(ctx, u.now())
+}
+
+// RunCleanup deletes expired questions once per interval until ctx is done
+func (u *ConversationStateUseCase) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.Cleanup(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to clean up conversation states", "error", err)
+			}
+		}
+	}
+}
+
+// isCancellation reports whether the message asks to drop the pending question
+func isCancellation(text string) bool {
+	text = strings.ToLower(strings.TrimRight(strings.TrimSpace(text), "!！.。"))
+	for _, k := range cancelKeywords {
+		if text == k {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationStateUseCase(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockConversationStateRepository()
+	uc := NewConversationStateUseCase(repo, 0)
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	uc.now = func() time.Time { return now }
+
+	state, err := uc.Pending(ctx, "user1")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+
+	require.NoError(t, uc.Ask(ctx, "user1", domain.MessageActionChangeCategory, "category", map[string]string{"expense_id": "exp1"}))
+	state, err = uc.Pending(ctx, "user1")
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.Equal(t, "category", state.Awaiting)
+	assert.Equal(t, "exp1", state.Slots["expense_id"])
+	assert.Equal(t, now.Add(DefaultConversationStateTTL), state.ExpiresAt)
+
+	// An expired question is gone once looked up, or cleaned up
+	require.NoError(t, uc.Ask(ctx, "user2", domain.MessageActionAddCategory, "name", nil))
+	now = now.Add(DefaultConversationStateTTL + time.Second)
+	state, err = uc.Pending(ctx, "user1")
+	require.NoError(t, err)
+	assert.Nil(t, state)
+	_, err = repo.GetByUserID(ctx, "user1")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	deleted, err := uc.Cleanup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+}
+
+func TestIsCancellation(t *testing.T) {
+	for _, text := range []string{"取消", " 算了！", "Cancel", "never mind."} {
+		assert.True(t, isCancellation(text), text)
+	}
+	for _, text := range []string{"取消訂閱 120", "寵物", ""} {
+		assert.False(t, isCancellation(text), text)
+	}
+}
@@ -0,0 +1,199 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ErrAICostCapReached is returned instead of calling the AI provider once a spending cap is hit
+var ErrAICostCapReached = errors.New("AI spending cap reached")
+
+// CostGuardUseCase blocks AI calls once the global or per-user daily/monthly USD
+// spending cap is reached, based on the logged AI costs. Caps come from
+// configuration and can be overridden per scope by an admin.
+type CostGuardUseCase struct {
+	costRepo domain.AICostRepository
+	capRepo  domain.AICostCapRepository
+	now      func() time.Time
+
+	mu         sync.RWMutex
+	globalCap  domain.AICostCap
+	perUserCap domain.AICostCap
+}
+
+// CostCapStatus is a cap together with the spending it is checked against
+type CostCapStatus struct {
+	Cap             domain.AICostCap `json:"cap"`
+	Overridden      bool             `json:"overridden"`
+	DailySpentUSD   float64          `json:"daily_spent_usd"`
+	MonthlySpentUSD float64          `json:"monthly_spent_usd"`
+	Blocked         bool             `json:"blocked"`
+}
+
+// CostGuardStatusResponse shows the global cap and, when requested, one user's cap
+type CostGuardStatusResponse struct {
+	Global    *CostCapStatus      `json:"global"`
+	User      *CostCapStatus      `json:"user,omitempty"`
+	Overrides []*domain.AICostCap `json:"overrides"`
+}
+
+// NewCostGuardUseCase creates a cost guard with the default global cap and the
+// default cap applied to each user
+func NewCostGuardUseCase(
+	costRepo domain.AICostRepository,
+	capRepo domain.AICostCapRepository,
+	globalCap domain.AICostCap,
+	perUserCap domain.AICostCap,
+) *CostGuardUseCase {
+	globalCap.Scope = domain.AICostCapGlobal
+	return &CostGuardUseCase{
+		costRepo:   costRepo,
+		capRepo:    capRepo,
+		globalCap:  globalCap,
+		perUserCap: perUserCap,
+		now:        time.Now,
+	}
+}
+
+// SetDefaultCaps replaces the configured global and per-user caps, as a
+// configuration reload does; admin overrides still take precedence
+func (u *CostGuardUseCase) SetDefaultCaps(globalCap, perUserCap domain.AICostCap) {
+	globalCap.Scope = domain.AICostCapGlobal
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.globalCap = globalCap
+	u.perUserCap = perUserCap
+}
+
+// AllowAI reports whether an AI call may be made for the user. Errors reading
+// costs or caps let the call through rather than blocking every message.
+func (u *CostGuardUseCase) AllowAI(ctx context.Context, userID string) bool {
+	for _, scope := range []string{domain.AICostCapGlobal, userID} {
+		status, err := u.status(ctx, scope)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to check AI spending cap", "scope", scope, "error", err)
+			continue
+		}
+		if status.Blocked {
+			slog.InfoContext(ctx, "AI spending cap reached", "scope", scope, "daily_usd", status.DailySpentUSD, "monthly_usd", status.MonthlySpentUSD)
+			return false
+		}
+	}
+	return true
+}
+
+// GetStatus returns the global cap and spending, plus the user's when userID is set
+func (u *CostGuardUseCase) GetStatus(ctx context.Context, userID string) (*CostGuardStatusResponse, error) {
+	global, err := u.status(ctx, domain.AICostCapGlobal)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := u.capRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI cost caps: %w", err)
+	}
+	if overrides == nil {
+		overrides = []*domain.AICostCap{}
+	}
+
+	resp := &CostGuardStatusResponse{Global: global, Overrides: overrides}
+	if userID != "" {
+		if resp.User, err = u.status(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// SetCap overrides the cap for a scope; a zero limit means unlimited
+func (u *CostGuardUseCase) SetCap(ctx context.Context, costCap *domain.AICostCap) (*domain.AICostCap, error) {
+	if costCap.Scope == "" {
+		return nil, fmt.Errorf("scope is required")
+	}
+	if costCap.DailyUSD < 0 || costCap.MonthlyUSD < 0 {
+		return nil, fmt.Errorf("caps cannot be negative")
+	}
+
+	costCap.UpdatedAt = u.now()
+	if err := u.capRepo.Upsert(ctx, costCap); err != nil {
+		return nil, fmt.Errorf("failed to save AI cost costCap: %w", err)
+	}
+	return costCap, nil
+}
+
+// ResetCap removes the override for a scope so the configured default applies again
+func (u *CostGuardUseCase) ResetCap(ctx context.Context, scope string) error {
+	if scope == "" {
+		return fmt.Errorf("scope is required")
+	}
+	if err := u.capRepo.Delete(ctx, scope); err != nil {
+		return fmt.Errorf("failed to delete AI cost costCap: %w", err)
+	}
+	return nil
+}
+
+// status resolves the effective cap for a scope and compares it with this UTC
+// day's and month's spending
+func (u *CostGuardUseCase) status(ctx context.Context, scope string) (*CostCapStatus, error) {
+	override, err := u.capRepo.Get(ctx, scope)
+	if errors.Is(err, domain.ErrNotFound) {
+		override, err = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI cost costCap: %w", err)
+	}
+
+	status := &CostCapStatus{}
+	u.mu.RLock()
+	switch {
+	case override != nil:
+		status.Cap = *override
+		status.Overridden = true
+	case scope == domain.AICostCapGlobal:
+		status.Cap = u.globalCap
+	default:
+		status.Cap = u.perUserCap
+		status.Cap.Scope = scope
+	}
+	u.mu.RUnlock()
+
+	now := u.now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	if status.DailySpentUSD, err = u.spent(ctx, scope, dayStart, now); err != nil {
+		return nil, err
+	}
+	if status.MonthlySpentUSD, err = u.spent(ctx, scope, monthStart, now); err != nil {
+		return nil, err
+	}
+
+	status.Blocked = (status.Cap.DailyUSD > 0 && status.DailySpentUSD >= status.Cap.DailyUSD) ||
+		(status.Cap.MonthlyUSD > 0 && status.MonthlySpentUSD >= status.Cap.MonthlyUSD)
+	return status, nil
+}
+
+// spent sums the logged AI cost for a scope in a time range
+func (u *CostGuardUseCase) spent(ctx context.Context, scope string, from, to time.Time) (float64, error) {
+	var summary *domain.AICostSummary
+	var err error
+	if scope == domain.AICostCapGlobal {
+		summary, err = u.costRepo.GetSummary(ctx, from, to)
+	} else {
+		summary, err = u.costRepo.GetUserSummary(ctx, scope, from, to)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get AI cost summary: %w", err)
+	}
+	if summary == nil {
+		return 0, nil
+	}
+	return summary.TotalCost, nil
+}
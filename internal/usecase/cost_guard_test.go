@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func newCostGuardTestUseCase(t *testing.T, globalCap, perUserCap domain.AICostCap) (*CostGuardUseCase, *MockAICostRepository) {
+	t.Helper()
+	costRepo := NewMockAICostRepository()
+	guard := NewCostGuardUseCase(costRepo, NewMockAICostCapRepository(), globalCap, perUserCap)
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+
+	// user1 spent $0.50 today and $2 earlier this month; user2 spent $0.10 today
+	costRepo.Create(context.Background(), &domain.AICostLog{UserID: "user1", Cost: 0.5, CreatedAt: now.Add(-time.Hour)})
+	costRepo.Create(context.Background(), &domain.AICostLog{UserID: "user1", Cost: 2, CreatedAt: now.AddDate(0, 0, -5)})
+	costRepo.Create(context.Background(), &domain.AICostLog{UserID: "user2", Cost: 0.1, CreatedAt: now.Add(-time.Hour)})
+	return guard, costRepo
+}
+
+func TestCostGuardUseCase(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("No caps allow everything", func(t *testing.T) {
+		guard, _ := newCostGuardTestUseCase(t, domain.AICostCap{}, domain.AICostCap{})
+		if !guard.AllowAI(ctx, "user1") {
+			t.Error("expected AI to be allowed without caps")
+		}
+	})
+
+	t.Run("Per-user daily cap", func(t *testing.T) {
+		guard, _ := newCostGuardTestUseCase(t, domain.AICostCap{}, domain.AICostCap{DailyUSD: 0.5})
+		if guard.AllowAI(ctx, "user1") {
+			t.Error("expected user1 to be blocked at the daily cap")
+		}
+		if !guard.AllowAI(ctx, "user2") {
+			t.Error("expected user2 to be allowed under the daily cap")
+		}
+	})
+
+	t.Run("Global monthly cap blocks everyone", func(t *testing.T) {
+		guard, _ := newCostGuardTestUseCase(t, domain.AICostCap{MonthlyUSD: 2.5}, domain.AICostCap{})
+		if guard.AllowAI(ctx, "user2") {
+			t.Error("expected global monthly cap to block user2")
+		}
+	})
+
+	t.Run("Default caps change while running", func(t *testing.T) {
+		guard, _ := newCostGuardTestUseCase(t, domain.AICostCap{}, domain.AICostCap{})
+		guard.SetDefaultCaps(domain.AICostCap{}, domain.AICostCap{DailyUSD: 0.5})
+		if guard.AllowAI(ctx, "user1") {
+			t.Error("expected the new per-user cap to block user1")
+		}
+		guard.SetDefaultCaps(domain.AICostCap{}, domain.AICostCap{})
+		if !guard.AllowAI(ctx, "user1") {
+			t.Error("expected removing the cap to allow user1")
+		}
+	})
+
+	t.Run("Override replaces and reset restores the default", func(t *testing.T) {
+		guard, _ := newCostGuardTestUseCase(t, domain.AICostCap{}, domain.AICostCap{DailyUSD: 0.5})
+		if _, err := guard.SetCap(ctx, &domain.AICostCap{Scope: "user1", DailyUSD: 1}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !guard.AllowAI(ctx, "user1") {
+			t.Error("expected raised cap to allow user1")
+		}
+
+		status, err := guard.GetStatus(ctx, "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !status.User.Overridden || status.User.DailySpentUSD != 0.5 || status.User.MonthlySpentUSD != 2.5 {
+			t.Errorf("unexpected user status %+v", status.User)
+		}
+		if len(status.Overrides) != 1 {
+			t.Errorf("expected 1 override, got %d", len(status.Overrides))
+		}
+
+		if err := guard.ResetCap(ctx, "user1"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if guard.AllowAI(ctx, "user1") {
+			t.Error("expected default cap to apply again after reset")
+		}
+	})
+
+	t.Run("Invalid caps", func(t *testing.T) {
+		guard, _ := newCostGuardTestUseCase(t, domain.AICostCap{}, domain.AICostCap{})
+		if _, err := guard.SetCap(ctx, &domain.AICostCap{DailyUSD: 1}); err == nil {
+			t.Error("expected error without scope")
+		}
+		if _, err := guard.SetCap(ctx, &domain.AICostCap{Scope: "global", MonthlyUSD: -1}); err == nil {
+			t.Error("expected error for negative cap")
+		}
+	})
+
+	t.Run("Capped parsing falls back to regex", func(t *testing.T) {
+		guard, _ := newCostGuardTestUseCase(t, domain.AICostCap{DailyUSD: 0.1}, domain.AICostCap{})
+		parser := NewParseConversationUseCase(&TestMockAIService{}, nil, nil, "gemini", "gemini-2.5-lite")
+		parser.SetCostGuard(guard)
+
+		result, err := parser.Execute(ctx, "coffee $5", "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(result.Expenses) != 1 || result.Expenses[0].Description != "coffee" || result.RawResponse != "" {
+			t.Errorf("expected regex-parsed expense, got %+v", result.Expenses)
+		}
+
+		if _, err := parser.ExecuteReceipt(ctx, &domain.Attachment{Data: []byte("jpeg")}, "user1"); err != ErrAICostCapReached {
+			t.Errorf("expected ErrAICostCapReached for receipt, got %v", err)
+		}
+	})
+}
@@ -3,7 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -21,10 +21,20 @@ type CreateExpenseUseCase struct {
 	aiCostRepo      domain.AICostRepository
 	pricingRepo     domain.PricingRepository
 	aiService       ai.Service
+	events          EventPublisher
+	auditRepo       domain.ExpenseAuditRepository
+	tagRepo         domain.TagRepository
+	itemRepo        domain.ExpenseItemRepository
+	merchantRepo    domain.MerchantRepository
+	uow             domain.UnitOfWork
+	confirmBelow    float64
 	provider        string
 	model           string
 }
 
+// DefaultCategoryConfirmThreshold is the AI category confidence below which the user is asked to confirm
+const DefaultCategoryConfirmThreshold = 0.6
+
 // NewCreateExpenseUseCase creates a new create expense use case
 func NewCreateExpenseUseCase(
 	expenseRepo domain.ExpenseRepository,
@@ -74,11 +84,51 @@ func NewCreateExpenseUseCaseWithAIConfig(
 		aiCostRepo:      aiCostRepo,
 		pricingRepo:     pricingRepo,
 		aiService:       aiService,
+		confirmBelow:    DefaultCategoryConfirmThreshold,
 		provider:        provider,
 		model:           model,
 	}
 }
 
+// SetEventPublisher publishes an expense.created event for each created expense,
+// which budget alerts and webhooks subscribe to
+func (u *CreateExpenseUseCase) SetEventPublisher(events EventPublisher) {
+	u.events = events
+}
+
+// SetAuditRepository records each created expense in the expense audit log
+func (u *CreateExpenseUseCase) SetAuditRepository(auditRepo domain.ExpenseAuditRepository) {
+	u.auditRepo = auditRepo
+}
+
+// SetTagRepository saves the tags of created expenses; without it they are dropped
+func (u *CreateExpenseUseCase) SetTagRepository(tagRepo domain.TagRepository) {
+	u.tagRepo = tagRepo
+}
+
+// SetExpenseItemRepository saves the receipt line items of created expenses;
+// without it they are dropped
+func (u *CreateExpenseUseCase) SetExpenseItemRepository(itemRepo domain.ExpenseItemRepository) {
+	u.itemRepo = itemRepo
+}
+
+// SetMerchantRepository links created expenses to the merchant they name,
+// creating it the first time; without it merchants are dropped
+func (u *CreateExpenseUseCase) SetMerchantRepository(merchantRepo domain.MerchantRepository) {
+	u.merchantRepo = merchantRepo
+}
+
+// SetUnitOfWork saves each expense and its audit entry in one transaction
+func (u *CreateExpenseUseCase) SetUnitOfWork(uow domain.UnitOfWork) {
+	u.uow = uow
+}
+
+// SetCategoryConfirmThreshold sets the AI category confidence below which the
+// response asks for confirmation; 0 never asks
+func (u *CreateExpenseUseCase) SetCategoryConfirmThreshold(threshold float64) {
+	u.confirmBelow = threshold
+}
+
 // CreateRequest represents a request to create an expense
 type CreateRequest struct {
 	UserID           string
@@ -90,7 +140,12 @@ type CreateRequest struct {
 	HomeCurrency     string
 	ExchangeRate     float64
 	CategoryID       *string
+	GroupID          *string // Shared group ledger, nil for a personal expense
 	Account          string
+	Merchant         string                // Store or service paid, as written
+	Tags             []string              // Tag names, with or without the #; #報帳 also marks it reimbursable
+	Items            []*domain.ExpenseItem // Receipt line items; IDs and positions are assigned
+	Reimbursable     bool                  // Paid for an employer, to be claimed back
 	Date             time.Time
 }
 
@@ -105,13 +160,178 @@ type CreateResponse struct {
 	HomeCurrency   string
 	ExchangeRate   float64
 	Account        string
+	Merchant       string // Normalized by domain.NormalizeMerchantName
+	Tags           []string
+	Reimbursable   bool
+
+	// Set when the AI was unsure of the category; alternatives are the user's
+	// categories the AI considered next, most likely first
+	NeedsCategoryConfirmation bool
+	CategoryConfidence        float64
+	CategoryAlternatives      []string
 }
 
 // Execute creates a new expense
 func (u *CreateExpenseUseCase) Execute(ctx context.Context, req *CreateRequest) (*CreateResponse, error) {
+	expense, resp := u.prepare(ctx, req)
+	err := inUnitOfWork(ctx, u.uow, func(ctx context.Context) error {
+		if err := u.linkMerchant(ctx, expense, resp.Merchant); err != nil {
+			return err
+		}
+		if err := u.expenseRepo.Create(ctx, expense); err != nil {
+			return err
+		}
+		if err := u.saveTags(ctx, expense); err != nil {
+			return err
+		}
+		if err := u.saveItems(ctx, expense); err != nil {
+			return err
+		}
+		return recordExpenseAudit(ctx, u.auditRepo, domain.ExpenseAuditCreate, nil, expense)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.publishCreated(ctx, expense, resp.Category)
+	return resp, nil
+}
+
+// MaxBatchExpenses is the most expenses one ExecuteBatch call can create
+const MaxBatchExpenses = 100
+
+// CreateBatchResult is the outcome of one request of a batch: Response when
+// the expense was created, Error when it was not
+type CreateBatchResult struct {
+	Response *CreateResponse
+	Error    error
+}
+
+// ExecuteBatch creates several expenses, such as the ones parsed from
+// "早餐50 午餐120 晚餐200", with a single repository write. It returns one
+// result per request, in order. Requests that fail validation are reported
+// while the rest are still created; the write itself is all or nothing.
+func (u *CreateExpenseUseCase) ExecuteBatch(ctx context.Context, reqs []*CreateRequest) ([]CreateBatchResult, error) {
+	if len(reqs) > MaxBatchExpenses {
+		return nil, fmt.Errorf("at most %d expenses can be created at once, got %d", MaxBatchExpenses, len(reqs))
+	}
+
+	results := make([]CreateBatchResult, len(reqs))
+	var expenses []*domain.Expense
+	var indexes []int
+	for i, req := range reqs {
+		if err := validateCreateRequest(req); err != nil {
+			results[i].Error = err
+			continue
+		}
+		expense, resp := u.prepare(ctx, req)
+		results[i].Response = resp
+		expenses = append(expenses, expense)
+		indexes = append(indexes, i)
+	}
+	if len(expenses) == 0 {
+		return results, nil
+	}
+
+	err := inUnitOfWork(ctx, u.uow, func(ctx context.Context) error {
+		for k, expense := range expenses {
+			if err := u.linkMerchant(ctx, expense, results[indexes[k]].Response.Merchant); err != nil {
+				return err
+			}
+		}
+		if err := u.expenseRepo.CreateBatch(ctx, expenses); err != nil {
+			return err
+		}
+		for _, expense := range expenses {
+			if err := u.saveTags(ctx, expense); err != nil {
+				return err
+			}
+			if err := u.saveItems(ctx, expense); err != nil {
+				return err
+			}
+			if err := recordExpenseAudit(ctx, u.auditRepo, domain.ExpenseAuditCreate, nil, expense); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create expense batch", "count", len(expenses), "error", err)
+		for _, i := range indexes {
+			results[i] = CreateBatchResult{Error: fmt.Errorf("failed to create expense: %w", err)}
+		}
+		return results, nil
+	}
+
+	for k, expense := range expenses {
+		u.publishCreated(ctx, expense, results[indexes[k]].Response.Category)
+	}
+	return results, nil
+}
+
+// validateCreateRequest rejects a batch request that cannot become an expense
+func validateCreateRequest(req *CreateRequest) error {
+	switch {
+	case req == nil:
+		return fmt.Errorf("expense is required")
+	case req.UserID == "":
+		return fmt.Errorf("user_id is required")
+	case strings.TrimSpace(req.Description) == "":
+		return fmt.Errorf("description is required")
+	case req.Amount <= 0:
+		return fmt.Errorf("amount must be positive")
+	}
+	return nil
+}
+
+// saveTags links a new expense to its tags
+func (u *CreateExpenseUseCase) saveTags(ctx context.Context, expense *domain.Expense) error {
+	if u.tagRepo == nil || len(expense.Tags) == 0 {
+		return nil
+	}
+	return tagExpense(ctx, u.tagRepo, expense.UserID, expense.ID, expense.Tags, expense.CreatedAt)
+}
+
+// saveItems stores the receipt line items of a new expense
+func (u *CreateExpenseUseCase) saveItems(ctx context.Context, expense *domain.Expense) error {
+	if u.itemRepo == nil || len(expense.Items) == 0 {
+		return nil
+	}
+	if err := u.itemRepo.ReplaceForExpense(ctx, expense.ID, expense.Items); err != nil {
+		return fmt.Errorf("failed to save expense items: %w", err)
+	}
+	return nil
+}
+
+// linkMerchant sets the merchant of a new expense from its name
+func (u *CreateExpenseUseCase) linkMerchant(ctx context.Context, expense *domain.Expense, name string) error {
+	if u.merchantRepo == nil || name == "" {
+		return nil
+	}
+	merchant, err := findOrCreateMerchant(ctx, u.merchantRepo, expense.UserID, name, expense.CreatedAt)
+	if err != nil {
+		return err
+	}
+	expense.MerchantID = &merchant.ID
+	return nil
+}
+
+// publishCreated publishes the expense.created event of a committed expense.
+// Budget alerts and webhooks handle it in the background so the reply isn't delayed.
+func (u *CreateExpenseUseCase) publishCreated(ctx context.Context, expense *domain.Expense, categoryName string) {
+	if u.events != nil {
+		u.events.Publish(ctx, expense.UserID, domain.EventExpenseCreated, newExpenseEvent(expense, categoryName))
+	}
+}
+
+// prepare builds the expense for a request, suggesting its category and
+// converting its amount, along with the response to return once it is saved
+func (u *CreateExpenseUseCase) prepare(ctx context.Context, req *CreateRequest) (*domain.Expense, *CreateResponse) {
 	// If no category is specified, get AI suggestion
 	var categoryID *string
 	var categoryName string
+	var confidence float64
+	var alternatives []string
 
 	if req.CategoryID != nil {
 		categoryID = req.CategoryID
@@ -119,52 +339,18 @@ func (u *CreateExpenseUseCase) Execute(ctx context.Context, req *CreateRequest)
 		category, _ := u.categoryRepo.GetByID(ctx, *req.CategoryID)
 		if category != nil {
 			categoryName = category.Name
-			log.Printf("Expense created with manual category: %s (ID: %s)", categoryName, *req.CategoryID)
+			slog.InfoContext(ctx, "Expense created with manual category", "category", categoryName, "category_id", *req.CategoryID)
 		}
 	} else {
 		// Get AI suggestion
 		resp, err := u.aiService.SuggestCategory(ctx, req.Description, req.UserID)
 		if err == nil && resp != nil {
-			log.Printf("AI suggested category: %s for description: %s", resp.Category, req.Description)
-
-			// Log AI cost
-			if resp.Tokens != nil {
-				go func() {
-					// Create background context for logging to not block response
-					logCtx := context.Background()
-					cost := 0.0
-					provider := u.provider
-					model := u.model
-
-					// Calculate cost if pricing is available
-					if u.pricingRepo != nil {
-						pricing, err := u.pricingRepo.GetByProviderAndModel(logCtx, provider, model)
-						if err == nil && pricing != nil {
-							cost = pricing.GetCost(resp.Tokens.InputTokens, resp.Tokens.OutputTokens)
-						}
-					}
+			slog.DebugContext(ctx, "AI suggested category", "category", resp.Category, "description", req.Description)
 
-					costLog := &domain.AICostLog{
-						ID:           uuid.New().String(),
-						UserID:       req.UserID,
-						Operation:    "suggest_category",
-						Provider:     provider,
-						Model:        model,
-						InputTokens:  resp.Tokens.InputTokens,
-						OutputTokens: resp.Tokens.OutputTokens,
-						TotalTokens:  resp.Tokens.TotalTokens,
-						Cost:         cost,
-						Currency:     "USD",
-						CreatedAt:    time.Now(),
-					}
-
-					if u.aiCostRepo != nil {
-						if err := u.aiCostRepo.Create(logCtx, costLog); err != nil {
-							log.Printf("Failed to log AI cost: %v", err)
-						}
-					}
-				}()
-			}
+			// Log in the background, keeping the messenger the message came from
+			logCtx := domain.WithAuditSource(context.Background(), domain.AuditSourceFromContext(ctx))
+			provider, model := servedBy(u.provider, currentModel(u.aiService, u.model), resp.Provider, resp.Model)
+			go logAICost(logCtx, u.pricingRepo, u.aiCostRepo, provider, model, req.UserID, "suggest_category", resp.Tokens, resp.PromptVersion)
 
 			// Find category by name
 			categories, _ := u.categoryRepo.GetByUserID(ctx, req.UserID)
@@ -175,6 +361,17 @@ func (u *CreateExpenseUseCase) Execute(ctx context.Context, req *CreateRequest)
 					break
 				}
 			}
+
+			// Only offer alternatives the user can actually be moved to
+			confidence = resp.Confidence
+			for _, alt := range resp.Alternatives {
+				for _, cat := range categories {
+					if cat.Name == alt && cat.Name != categoryName {
+						alternatives = append(alternatives, cat.Name)
+						break
+					}
+				}
+			}
 		}
 	}
 
@@ -200,7 +397,7 @@ func (u *CreateExpenseUseCase) Execute(ctx context.Context, req *CreateRequest)
 				homeAmount = converted
 				exchangeRate = rate
 			} else {
-				log.Printf("WARN: failed currency conversion %s->%s: %v", currency, homeCurrency, err)
+				slog.WarnContext(ctx, "Failed currency conversion", "from", currency, "to", homeCurrency, "error", err)
 				homeAmount = originalAmount
 				exchangeRate = 1.0
 			}
@@ -225,21 +422,40 @@ func (u *CreateExpenseUseCase) Execute(ctx context.Context, req *CreateRequest)
 		HomeCurrency:   homeCurrency,
 		ExchangeRate:   exchangeRate,
 		CategoryID:     categoryID,
+		GroupID:        req.GroupID,
 		Account:        account,
 		ExpenseDate:    req.Date,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
 	expense.Amount = expense.HomeAmount
-
-	if err := u.expenseRepo.Create(ctx, expense); err != nil {
-		return nil, err
+	expense.WorkspaceID, _ = domain.WorkspaceFromContext(ctx)
+	expense.SetReimbursable(req.Reimbursable || domain.HasReimbursementTag(req.Tags))
+	if u.tagRepo != nil {
+		expense.Tags = domain.NormalizeTagNames(req.Tags)
+	}
+	if u.itemRepo != nil {
+		for i, item := range req.Items {
+			expense.Items = append(expense.Items, &domain.ExpenseItem{
+				ID:        uuid.New().String(),
+				ExpenseID: expense.ID,
+				Name:      item.Name,
+				Quantity:  item.Quantity,
+				UnitPrice: item.UnitPrice,
+				Position:  i,
+				CreatedAt: expense.CreatedAt,
+			})
+		}
+	}
+	var merchant string
+	if u.merchantRepo != nil {
+		merchant, _ = domain.NormalizeMerchantName(req.Merchant)
 	}
 
 	// Prepare response message
 	message := buildCreateMessage(req.Description, originalAmount, currency, homeAmount, homeCurrency, categoryName)
 
-	return &CreateResponse{
+	return expense, &CreateResponse{
 		ID:             expense.ID,
 		Message:        message,
 		Category:       categoryName,
@@ -249,7 +465,14 @@ func (u *CreateExpenseUseCase) Execute(ctx context.Context, req *CreateRequest)
 		HomeCurrency:   homeCurrency,
 		ExchangeRate:   exchangeRate,
 		Account:        account,
-	}, nil
+		Merchant:       merchant,
+		Tags:           expense.Tags,
+		Reimbursable:   expense.Reimbursable,
+
+		NeedsCategoryConfirmation: categoryName != "" && len(alternatives) > 0 && confidence < u.confirmBelow,
+		CategoryConfidence:        confidence,
+		CategoryAlternatives:      alternatives,
+	}
 }
 
 // formatAmount formats amount for display
@@ -316,7 +539,7 @@ func (u *CreateExpenseUseCase) resolveHomeCurrency(ctx context.Context, userID,
 		return "TWD"
 	}
 	user, err := u.userRepo.GetByID(ctx, userID)
-	if err != nil || user == nil || user.HomeCurrency == "" {
+	if err != nil || user.HomeCurrency == "" {
 		return "TWD"
 	}
 	return strings.ToUpper(user.HomeCurrency)
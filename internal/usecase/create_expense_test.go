@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -124,6 +126,57 @@ func TestCreateExpenseWithAICategory(t *testing.T) {
 	}
 }
 
+func TestCreateExpenseLowConfidenceCategory(t *testing.T) {
+	tests := []struct {
+		name                 string
+		confidence           float64
+		threshold            float64
+		expectConfirmation   bool
+		expectedAlternatives []string
+	}{
+		{name: "below threshold", confidence: 0.4, threshold: DefaultCategoryConfirmThreshold, expectConfirmation: true, expectedAlternatives: []string{"Entertainment", "Shopping"}},
+		{name: "above threshold", confidence: 0.9, threshold: DefaultCategoryConfirmThreshold, expectedAlternatives: []string{"Entertainment", "Shopping"}},
+		{name: "disabled", confidence: 0.1, threshold: 0, expectedAlternatives: []string{"Entertainment", "Shopping"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			categoryRepo := NewMockCategoryRepository()
+			for _, name := range []string{"Food", "Entertainment", "Shopping"} {
+				categoryRepo.Create(context.Background(), &domain.Category{ID: "cat_" + name, UserID: "test_user", Name: name})
+			}
+
+			// "Bills" is not one of the user's categories, so it cannot be offered
+			aiService := &MockAIService{categoryConfidence: tt.confidence, categoryAlternatives: []string{"Entertainment", "Bills", "Shopping"}}
+			uc := NewCreateExpenseUseCase(NewMockExpenseRepository(), categoryRepo, nil, nil, nil, nil, aiService)
+			uc.SetCategoryConfirmThreshold(tt.threshold)
+
+			resp, err := uc.Execute(context.Background(), &CreateRequest{
+				UserID:      "test_user",
+				Description: "午餐",
+				Amount:      120,
+				Date:        time.Now(),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.Category != "Food" {
+				t.Errorf("expected category Food, got %s", resp.Category)
+			}
+			if resp.NeedsCategoryConfirmation != tt.expectConfirmation {
+				t.Errorf("expected NeedsCategoryConfirmation %v, got %v", tt.expectConfirmation, resp.NeedsCategoryConfirmation)
+			}
+			if resp.CategoryConfidence != tt.confidence {
+				t.Errorf("expected confidence %v, got %v", tt.confidence, resp.CategoryConfidence)
+			}
+			if strings.Join(resp.CategoryAlternatives, ",") != strings.Join(tt.expectedAlternatives, ",") {
+				t.Errorf("expected alternatives %v, got %v", tt.expectedAlternatives, resp.CategoryAlternatives)
+			}
+		})
+	}
+}
+
 func TestCreateExpenseMessage(t *testing.T) {
 	expenseRepo := NewMockExpenseRepository()
 	categoryRepo := NewMockCategoryRepository()
@@ -195,3 +248,111 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+// failingAuditRepository fails every write, like a database rejecting the audit row
+type failingAuditRepository struct {
+	MockExpenseAuditRepository
+}
+
+func (r *failingAuditRepository) Create(ctx context.Context, entry *domain.ExpenseAuditEntry) error {
+	return errors.New("audit table unavailable")
+}
+
+func TestCreateExpenseUnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	req := &CreateRequest{UserID: "test_user", Description: "午餐", Amount: 120, Date: time.Now()}
+
+	t.Run("CommitsThenPublishes", func(t *testing.T) {
+		uow := NewMockUnitOfWork()
+		events := &recordingPublisher{}
+		uc := NewCreateExpenseUseCase(NewMockExpenseRepository(), NewMockCategoryRepository(), nil, nil, nil, nil, &MockAIService{})
+		uc.SetAuditRepository(NewMockExpenseAuditRepository())
+		uc.SetUnitOfWork(uow)
+		uc.SetEventPublisher(events)
+
+		if _, err := uc.Execute(ctx, req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if uow.Commits != 1 || uow.Rollbacks != 0 {
+			t.Errorf("expected 1 commit, got %d commits and %d rollbacks", uow.Commits, uow.Rollbacks)
+		}
+		if len(events.events) != 1 {
+			t.Errorf("expected the expense.created event, got %v", events.events)
+		}
+	})
+
+	t.Run("AuditFailureRollsBack", func(t *testing.T) {
+		uow := NewMockUnitOfWork()
+		events := &recordingPublisher{}
+		uc := NewCreateExpenseUseCase(NewMockExpenseRepository(), NewMockCategoryRepository(), nil, nil, nil, nil, &MockAIService{})
+		uc.SetAuditRepository(&failingAuditRepository{})
+		uc.SetUnitOfWork(uow)
+		uc.SetEventPublisher(events)
+
+		if _, err := uc.Execute(ctx, req); err == nil {
+			t.Fatal("expected the audit failure to fail the expense")
+		}
+		if uow.Rollbacks != 1 {
+			t.Errorf("expected 1 rollback, got %d", uow.Rollbacks)
+		}
+		if len(events.events) != 0 {
+			t.Errorf("expected no event for a rolled back expense, got %v", events.events)
+		}
+	})
+
+	t.Run("AuditFailureLoggedWithoutUnitOfWork", func(t *testing.T) {
+		expenseRepo := NewMockExpenseRepository()
+		uc := NewCreateExpenseUseCase(expenseRepo, NewMockCategoryRepository(), nil, nil, nil, nil, &MockAIService{})
+		uc.SetAuditRepository(&failingAuditRepository{})
+
+		resp, err := uc.Execute(ctx, req)
+		if err != nil {
+			t.Fatalf("expected the audit failure to be logged only, got %v", err)
+		}
+		if _, err := expenseRepo.GetByID(ctx, resp.ID); err != nil {
+			t.Errorf("expected the expense saved, got %v", err)
+		}
+	})
+}
+
+func TestCreateExpenseBatch(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	events := &recordingPublisher{}
+	uc := NewCreateExpenseUseCase(expenseRepo, NewMockCategoryRepository(), nil, nil, nil, nil, &MockAIService{})
+	uc.SetEventPublisher(events)
+
+	now := time.Now()
+	results, err := uc.ExecuteBatch(ctx, []*CreateRequest{
+		{UserID: "test_user", Description: "早餐", Amount: 50, Date: now},
+		{UserID: "test_user", Description: "午餐", Amount: 0, Date: now},
+		{UserID: "test_user", Description: "晚餐", Amount: 200, Date: now},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	if results[1].Error == nil || results[1].Response != nil {
+		t.Errorf("expected the zero amount to fail, got %+v", results[1])
+	}
+	for _, i := range []int{0, 2} {
+		if results[i].Error != nil {
+			t.Fatalf("expected expense %d created, got %v", i, results[i].Error)
+		}
+		if _, err := expenseRepo.GetByID(ctx, results[i].Response.ID); err != nil {
+			t.Errorf("expected expense %d saved, got %v", i, err)
+		}
+	}
+	if expenseRepo.Batches != 1 {
+		t.Errorf("expected a single batch write, got %d", expenseRepo.Batches)
+	}
+	if len(events.events) != 2 {
+		t.Errorf("expected 2 expense.created events, got %v", events.events)
+	}
+
+	if _, err := uc.ExecuteBatch(ctx, make([]*CreateRequest, MaxBatchExpenses+1)); err == nil {
+		t.Error("expected an oversized batch to be rejected")
+	}
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// DailyDigestUseCase pushes the users who opted into the daily digest a
+// summary of yesterday's spending: the total, the top category and how their
+// budgets stand. Each digest goes out once a day, at the hour the user picked
+// in their timezone, through the pusher.
+type DailyDigestUseCase struct {
+	prefsRepo domain.NotificationPreferencesRepository
+	userRepo  domain.UserRepository
+	reports   ExpenseReporter
+	budgets   BudgetStatusReporter
+	pusher    MessagePusher
+	timezones TimezoneLocator
+	now       func() time.Time
+}
+
+// NewDailyDigestUseCase creates a new daily digest use case; budgets may be nil
+func NewDailyDigestUseCase(
+	prefsRepo domain.NotificationPreferencesRepository,
+	userRepo domain.UserRepository,
+	reports ExpenseReporter,
+	budgets BudgetStatusReporter,
+	pusher MessagePusher,
+) *DailyDigestUseCase {
+	return &DailyDigestUseCase{
+		prefsRepo: prefsRepo,
+		userRepo:  userRepo,
+		reports:   reports,
+		budgets:   budgets,
+		pusher:    pusher,
+		now:       time.Now,
+	}
+}
+
+// SetTimezoneLocator sends digests at the hour in each user's timezone, for
+// their yesterday, instead of the server's
+func (u *DailyDigestUseCase) SetTimezoneLocator(timezones TimezoneLocator) {
+	u.timezones = timezones
+}
+
+// SendDue pushes the digests whose hour has come and that weren't sent yet
+// today, and returns how many were sent. A digest that fails is retried on
+// the next run.
+func (u *DailyDigestUseCase) SendDue(ctx context.Context) (int, error) {
+	subscribed, err := u.prefsRepo.ListSubscribed(ctx, domain.NotificationDailyDigest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get daily digest subscribers: %w", err)
+	}
+
+	now := u.now()
+	sent := 0
+	for _, prefs := range subscribed {
+		loc := time.Local
+		if u.timezones != nil {
+			loc = u.timezones.Locate(ctx, prefs.UserID, "")
+		}
+		local := now.In(loc)
+		if !dailyDigestDue(prefs, local) {
+			continue
+		}
+
+		ok, err := u.send(domain.WithLocation(ctx, loc), prefs.UserID, local)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to push daily digest", "user_id", prefs.UserID, "error", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		sent++
+
+		prefs.LastDailyDigestAt = &now
+		if err := u.prefsRepo.Save(ctx, prefs); err != nil {
+			slog.ErrorContext(ctx, "Failed to record daily digest", "user_id", prefs.UserID, "error", err)
+		}
+	}
+	return sent, nil
+}
+
+// RunScheduler sends due digests once per interval until ctx is done
+func (u *DailyDigestUseCase) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.SendDue(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to send daily digests", "error", err)
+			}
+		}
+	}
+}
+
+// dailyDigestDue reports whether the user's digest hour has come on the
+// local day and the digest wasn't sent yet that day
+func dailyDigestDue(prefs *domain.NotificationPreferences, local time.Time) bool {
+	if local.Hour() < prefs.DailyDigestHour {
+		return false
+	}
+	if prefs.LastDailyDigestAt == nil {
+		return true
+	}
+	lastYear, lastMonth, lastDay := prefs.LastDailyDigestAt.In(local.Location()).Date()
+	year, month, day := local.Date()
+	return lastYear != year || lastMonth != month || lastDay != day
+}
+
+// send pushes the user the digest of the day before today, in their
+// language. It reports false when their messenger can't be pushed to.
+func (u *DailyDigestUseCase) send(ctx context.Context, userID string, today time.Time) (bool, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	if i18n.Supported(user.Locale) {
+		ctx = i18n.WithLocale(ctx, user.Locale)
+	}
+
+	end := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	start := end.AddDate(0, 0, -1)
+	report, err := u.reports.Execute(ctx, &ReportRequest{
+		UserID:     userID,
+		ReportType: "daily",
+		StartDate:  start,
+		EndDate:    end.Add(-time.Nanosecond),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to generate report: %w", err)
+	}
+
+	var status *GetBudgetStatusResponse
+	if u.budgets != nil {
+		if status, err = u.budgets.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: userID, Active: true}); err != nil {
+			return false, fmt.Errorf("failed to get budget status: %w", err)
+		}
+	}
+
+	text := formatDailyDigest(ctx, start, report, status, user.HomeCurrency)
+	switch err := u.pusher.Push(ctx, userID, user.MessengerType, text); {
+	case errors.Is(err, ErrNoNotifier):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+// formatDailyDigest writes the day's total, its top category and the
+// budgets, in the locale carried by ctx
+func formatDailyDigest(ctx context.Context, day time.Time, report *ExpenseReport, status *GetBudgetStatusResponse, currency string) string {
+	locale := i18n.FromContext(ctx)
+	date := i18n.FormatDate(locale, day)
+
+	var sb strings.Builder
+	if report.TransactionCount == 0 {
+		sb.WriteString(translate(ctx, "digest.none", date))
+	} else {
+		sb.WriteString(translate(ctx, "digest.title", date, formatMoney(ctx, report.TotalExpenses, currency), pluralizeExpenses(locale, report.TransactionCount)))
+		categories := append([]CategoryBreakdown(nil), report.CategoryBreakdown...)
+		sort.SliceStable(categories, func(i, j int) bool { return categories[i].Total > categories[j].Total })
+		if len(categories) > 0 {
+			sb.WriteString("\n" + translate(ctx, "digest.top_category", categories[0].Category, formatMoney(ctx, categories[0].Total, currency)))
+		}
+	}
+
+	if status != nil && len(status.Budgets) > 0 {
+		sb.WriteString("\n\n" + budgetStatusTitle(ctx, status))
+		for _, b := range status.Budgets {
+			line := fmt.Sprintf("\n• %s (%s): %s / %s (%.0f%%)", b.Category, budgetPeriodLabel(ctx, b), formatMoney(ctx, b.Spent, currency), formatMoney(ctx, b.Limit, currency), b.Percentage)
+			if b.IsExceeded {
+				line += " ⚠️"
+			}
+			sb.WriteString(line)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyDigestDue(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	morning := time.Date(2026, 3, 5, 8, 30, 0, 0, tokyo)
+	sentYesterday := time.Date(2026, 3, 4, 8, 0, 0, 0, tokyo)
+	// 00:30 on March 5 in Tokyo, still March 4 in UTC
+	sentToday := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		hour int
+		last *time.Time
+		want bool
+	}{
+		{"never sent", 8, nil, true},
+		{"before the hour", 9, nil, false},
+		{"sent yesterday", 8, &sentYesterday, true},
+		{"sent today", 8, &sentToday, false},
+	}
+	for _, tt := range tests {
+		prefs := &domain.NotificationPreferences{DailyDigest: true, DailyDigestHour: tt.hour, LastDailyDigestAt: tt.last}
+		assert.Equal(t, tt.want, dailyDigestDue(prefs, morning), tt.name)
+	}
+}
+
+func TestDailyDigestSendDue(t *testing.T) {
+	ctx := context.Background()
+	food := "cat_food"
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	// 08:00 on March 5 in Tokyo
+	now := time.Date(2026, 3, 4, 23, 0, 0, 0, time.UTC)
+
+	budgets, categoryRepo, expenseRepo := newBudgetTestUseCase(t)
+	categoryRepo.Create(ctx, &domain.Category{ID: "cat_transport", UserID: "user1", Name: "Transport"})
+	_, err = budgets.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", CategoryID: &food, Limit: 5000})
+	require.NoError(t, err)
+
+	userRepo := NewMockUserRepository()
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "telegram", HomeCurrency: "TWD", Locale: "en", Timezone: "Asia/Tokyo"}))
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user2", MessengerType: "telegram", HomeCurrency: "TWD", Locale: "en", Timezone: "UTC"}))
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user3", MessengerType: "terminal"}))
+
+	transport := "cat_transport"
+	for i, e := range []struct {
+		category *string
+		amount   float64
+		date     time.Time
+	}{
+		{&food, 120, time.Date(2026, 3, 4, 12, 0, 0, 0, tokyo)},
+		{&food, 280, time.Date(2026, 3, 4, 19, 0, 0, 0, tokyo)},
+		{&transport, 1500, time.Date(2026, 3, 4, 9, 0, 0, 0, tokyo)},
+		// The day before and today aren't part of the digest
+		{&food, 999, time.Date(2026, 3, 3, 23, 0, 0, 0, tokyo)},
+		{&food, 999, time.Date(2026, 3, 5, 7, 0, 0, 0, tokyo)},
+	} {
+		require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+			ID:           string(rune('a' + i)),
+			UserID:       "user1",
+			CategoryID:   e.category,
+			Amount:       e.amount,
+			HomeAmount:   e.amount,
+			HomeCurrency: "TWD",
+			ExpenseDate:  e.date,
+			CreatedAt:    e.date,
+		}))
+	}
+
+	prefsRepo := NewMockNotificationPreferencesRepository()
+	for _, userID := range []string{"user1", "user2", "user3"} {
+		prefs := domain.DefaultNotificationPreferences(userID)
+		prefs.DailyDigest = true
+		require.NoError(t, prefsRepo.Save(ctx, prefs))
+	}
+	// Opted out
+	require.NoError(t, prefsRepo.Save(ctx, domain.DefaultNotificationPreferences("user4")))
+
+	pusher := &recordingPusher{notifiers: map[string]bool{"telegram": true}, messages: make(map[string]string)}
+	uc := NewDailyDigestUseCase(prefsRepo, userRepo, NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil), budgets, pusher)
+	uc.SetTimezoneLocator(NewTimezoneUseCase(userRepo))
+	uc.now = func() time.Time { return now }
+
+	// In UTC, user2's yesterday is March 3; user3's messenger can't be pushed to
+	sent, err := uc.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	require.Len(t, pusher.messages, 2)
+	assert.Equal(t, "☀️ You didn't record any expenses yesterday (Mar 3, 2026).", pusher.messages["user2"])
+
+	digest := pusher.messages["user1"]
+	assert.Contains(t, digest, "☀️ Yesterday (Mar 4, 2026) you spent NT$1,900 across 3 expenses")
+	assert.Contains(t, digest, "Top category: Transport, NT$1,500")
+	assert.Contains(t, digest, "💰 Budgets")
+
+	prefs, err := prefsRepo.GetByUserID(ctx, "user1")
+	require.NoError(t, err)
+	require.NotNil(t, prefs.LastDailyDigestAt)
+	assert.True(t, prefs.LastDailyDigestAt.Equal(now))
+	prefs, err = prefsRepo.GetByUserID(ctx, "user3")
+	require.NoError(t, err)
+	assert.Nil(t, prefs.LastDailyDigestAt)
+
+	// Each digest goes out once a day
+	sent, err = uc.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+}
+
+func TestNotificationPreferencesPersisted(t *testing.T) {
+	ctx := context.Background()
+	uc := NewNotificationUseCase()
+	uc.SetPreferencesRepository(NewMockNotificationPreferencesRepository())
+
+	resp, err := uc.GetPreferences(ctx, &GetPreferencesRequest{UserID: "user1"})
+	require.NoError(t, err)
+	assert.False(t, resp.Preferences.DailyDigest)
+	assert.Equal(t, domain.DefaultDailyDigestHour, resp.Preferences.DailyDigestHour)
+
+	on, hour, invalid := true, 7, 24
+	_, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", DailyDigestHour: &invalid})
+	assert.Error(t, err)
+
+	_, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", DailyDigest: &on, DailyDigestHour: &hour})
+	require.NoError(t, err)
+
+	// Fields left out of the update keep their values
+	resp, err = uc.GetPreferences(ctx, &GetPreferencesRequest{UserID: "user1"})
+	require.NoError(t, err)
+	assert.True(t, resp.Preferences.DailyDigest)
+	assert.Equal(t, 7, resp.Preferences.DailyDigestHour)
+	assert.True(t, resp.Preferences.BudgetAlerts)
+	assert.True(t, resp.Preferences.WeeklyReport)
+
+	days, quietStart := 0, 25
+	_, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", ExpenseReminderDays: &days})
+	assert.Error(t, err)
+	_, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", QuietHoursStart: &quietStart})
+	assert.Error(t, err)
+	days, quietStart = 5, 23
+	updated, err := uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", ExpenseReminders: &on, ExpenseReminderDays: &days, QuietHoursStart: &quietStart})
+	require.NoError(t, err)
+	assert.True(t, updated.Preferences.ExpenseReminders)
+	assert.Equal(t, 5, updated.Preferences.ExpenseReminderDays)
+	assert.Equal(t, 23, updated.Preferences.QuietHoursStart)
+	assert.Equal(t, domain.DefaultQuietHoursEnd, updated.Preferences.QuietHoursEnd)
+
+	_, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", Channels: map[string]string{domain.NotificationWeeklyReport: "fax"}})
+	assert.Error(t, err)
+	_, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", Channels: map[string]string{"carrier_pigeon": domain.NotificationChannelEmail}})
+	assert.Error(t, err)
+	_, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", Channels: map[string]string{domain.NotificationWeeklyReport: domain.NotificationChannelEmail}})
+	require.NoError(t, err)
+	updated, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", Channels: map[string]string{domain.NotificationBudgetAlerts: domain.NotificationChannelInApp}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		domain.NotificationBudgetAlerts:      domain.NotificationChannelInApp,
+		domain.NotificationDailyDigest:       domain.NotificationChannelPush,
+		domain.NotificationWeeklyReport:      domain.NotificationChannelEmail,
+		domain.NotificationExpenseReminders:  domain.NotificationChannelPush,
+		domain.NotificationSpendingAnomalies: domain.NotificationChannelPush,
+	}, updated.Preferences.Channels)
+
+	assert.True(t, updated.Preferences.SpendingAnomalies)
+	assert.Equal(t, domain.AnomalySensitivityMedium, updated.Preferences.AnomalySensitivity)
+	sensitivity := "paranoid"
+	_, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", AnomalySensitivity: &sensitivity})
+	assert.Error(t, err)
+	sensitivity = domain.AnomalySensitivityHigh
+	updated, err = uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", AnomalySensitivity: &sensitivity})
+	require.NoError(t, err)
+	assert.Equal(t, domain.AnomalySensitivityHigh, updated.Preferences.AnomalySensitivity)
+}
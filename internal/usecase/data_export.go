@@ -6,6 +6,9 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
@@ -15,6 +18,8 @@ import (
 type DataExportUseCase struct {
 	expenseRepo  domain.ExpenseRepository
 	categoryRepo domain.CategoryRepository
+	tagRepo      domain.TagRepository
+	itemRepo     domain.ExpenseItemRepository
 }
 
 // NewDataExportUseCase creates a new data export use case
@@ -28,24 +33,62 @@ func NewDataExportUseCase(
 	}
 }
 
+// SetTagRepository includes each expense's tags and totals per tag in exports
+func (u *DataExportUseCase) SetTagRepository(tagRepo domain.TagRepository) {
+	u.tagRepo = tagRepo
+}
+
+// SetExpenseItemRepository includes the receipt line items of each expense in exports
+func (u *DataExportUseCase) SetExpenseItemRepository(itemRepo domain.ExpenseItemRepository) {
+	u.itemRepo = itemRepo
+}
+
 // ExportRequest represents a request to export data
 type ExportRequest struct {
 	UserID    string
-	Format    string // "csv", "json"
+	Format    string // "csv", "json", "pdf", "xlsx"
 	StartDate time.Time
 	EndDate   time.Time
 }
 
 // ExportedExpense represents an expense in export format
 type ExportedExpense struct {
-	ID          string  `json:"id" csv:"ID"`
-	Date        string  `json:"date" csv:"Date"`
-	Description string  `json:"description" csv:"Description"`
-	Amount      float64 `json:"amount" csv:"Amount"`
-	Category    string  `json:"category" csv:"Category"`
-	Account     string  `json:"account" csv:"Account"`
-	CreatedAt   string  `json:"created_at" csv:"CreatedAt"`
-	UpdatedAt   string  `json:"updated_at" csv:"UpdatedAt"`
+	ID          string                `json:"id" csv:"ID"`
+	Date        string                `json:"date" csv:"Date"`
+	Description string                `json:"description" csv:"Description"`
+	Amount      float64               `json:"amount" csv:"Amount"`
+	Currency    string                `json:"currency" csv:"Currency"`
+	Category    string                `json:"category" csv:"Category"`
+	Account     string                `json:"account" csv:"Account"`
+	Tags        []string              `json:"tags,omitempty" csv:"Tags"`
+	Items       []ExportedExpenseItem `json:"items,omitempty" csv:"Items"`
+	CreatedAt   string                `json:"created_at" csv:"CreatedAt"`
+	UpdatedAt   string                `json:"updated_at" csv:"UpdatedAt"`
+}
+
+// ExportedExpenseItem represents a receipt line item in export format
+type ExportedExpenseItem struct {
+	Name      string  `json:"name"`
+	Quantity  float64 `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Total     float64 `json:"total"`
+}
+
+// exportExpenseItems converts receipt line items to export format
+func exportExpenseItems(items []*domain.ExpenseItem) []ExportedExpenseItem {
+	if len(items) == 0 {
+		return nil
+	}
+	exported := make([]ExportedExpenseItem, len(items))
+	for i, item := range items {
+		exported[i] = ExportedExpenseItem{
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: item.UnitPrice,
+			Total:     item.Total(),
+		}
+	}
+	return exported
 }
 
 // ExportData represents exported data
@@ -73,6 +116,12 @@ func (u *DataExportUseCase) Execute(ctx context.Context, req *ExportRequest) (*E
 	if err != nil {
 		return nil, fmt.Errorf("failed to get expenses: %w", err)
 	}
+	if err := loadExpenseTags(ctx, u.tagRepo, expenses); err != nil {
+		return nil, err
+	}
+	if err := loadExpenseItems(ctx, u.itemRepo, expenses); err != nil {
+		return nil, err
+	}
 
 	// Convert to export format
 	var exportedExpenses []ExportedExpense
@@ -91,8 +140,11 @@ func (u *DataExportUseCase) Execute(ctx context.Context, req *ExportRequest) (*E
 			Date:        expense.ExpenseDate.Format("2006-01-02"),
 			Description: expense.Description,
 			Amount:      expense.Amount,
+			Currency:    expense.HomeCurrency,
 			Category:    categoryName,
 			Account:     expense.Account,
+			Tags:        expense.Tags,
+			Items:       exportExpenseItems(expense.Items),
 			CreatedAt:   expense.CreatedAt.Format("2006-01-02 15:04:05"),
 			UpdatedAt:   expense.UpdatedAt.Format("2006-01-02 15:04:05"),
 		})
@@ -129,10 +181,9 @@ func (u *DataExportUseCase) ExportAsCSV(ctx context.Context, req *ExportRequest)
 
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
-	defer writer.Flush()
 
 	// Write header
-	headers := []string{"ID", "Date", "Description", "Amount", "Category", "Account", "CreatedAt", "UpdatedAt"}
+	headers := []string{"ID", "Date", "Description", "Amount", "Category", "Account", "Tags", "Items", "CreatedAt", "UpdatedAt"}
 	if err := writer.Write(headers); err != nil {
 		return nil, fmt.Errorf("failed to write CSV header: %w", err)
 	}
@@ -146,6 +197,8 @@ func (u *DataExportUseCase) ExportAsCSV(ctx context.Context, req *ExportRequest)
 			fmt.Sprintf("%.2f", exp.Amount),
 			exp.Category,
 			exp.Account,
+			hashtags(exp.Tags),
+			itemList(exp.Items),
 			exp.CreatedAt,
 			exp.UpdatedAt,
 		}
@@ -154,9 +207,271 @@ func (u *DataExportUseCase) ExportAsCSV(ctx context.Context, req *ExportRequest)
 		}
 	}
 
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
 	return buf.Bytes(), nil
 }
 
+// hashtags writes tag names the way they are typed in messages, e.g. "#出差 #報帳"
+func hashtags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return "#" + strings.Join(tags, " #")
+}
+
+// itemList writes receipt line items on one line, e.g. "Latte 2 × 65.00; Sandwich 1 × 45.00"
+func itemList(items []ExportedExpenseItem) string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		lines[i] = fmt.Sprintf("%s %s × %.2f", item.Name, strconv.FormatFloat(item.Quantity, 'f', -1, 64), item.UnitPrice)
+	}
+	return strings.Join(lines, "; ")
+}
+
+// statementCategory is one row of a statement's category breakdown
+type statementCategory struct {
+	name     string
+	count    int
+	total    float64
+	expenses []ExportedExpense
+}
+
+// groupStatementCategories groups exported expenses by category, largest total
+// first, and returns the overall total
+func groupStatementCategories(expenses []ExportedExpense) ([]*statementCategory, float64) {
+	total := 0.0
+	byName := make(map[string]*statementCategory)
+	var categories []*statementCategory
+	for _, exp := range expenses {
+		total += exp.Amount
+		category, ok := byName[exp.Category]
+		if !ok {
+			category = &statementCategory{name: exp.Category}
+			byName[exp.Category] = category
+			categories = append(categories, category)
+		}
+		category.count++
+		category.total += exp.Amount
+		category.expenses = append(category.expenses, exp)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].total != categories[j].total {
+			return categories[i].total > categories[j].total
+		}
+		return categories[i].name < categories[j].name
+	})
+	return categories, total
+}
+
+// ExportAsPDF exports expenses as a printable statement: the period's totals, a
+// category breakdown table and a bar chart of spending by category
+func (u *DataExportUseCase) ExportAsPDF(ctx context.Context, req *ExportRequest) ([]byte, error) {
+	req.Format = "pdf"
+	data, err := u.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	categories, total := groupStatementCategories(data.Data)
+
+	doc := newPDFDocument()
+	right := pdfPageWidth - pdfMargin
+	y := pdfPageHeight - pdfMargin - 20
+
+	// ensureSpace starts a new page when the next h points would not fit
+	ensureSpace := func(h float64) {
+		if y-h < pdfMargin {
+			doc.addPage()
+			y = pdfPageHeight - pdfMargin
+		}
+	}
+
+	doc.text(pdfMargin, y, 20, true, "Expense Statement")
+	y -= 22
+	doc.text(pdfMargin, y, 12, false, statementPeriodLabel(data.PeriodStart, data.PeriodEnd))
+	doc.textRight(right, y, 9, false, "Generated "+data.ExportedAt.Format("2006-01-02 15:04"))
+	y -= 36
+
+	// Totals
+	days := int(data.PeriodEnd.Sub(data.PeriodStart).Hours()/24) + 1
+	average := 0.0
+	if data.TotalRecords > 0 {
+		average = total / float64(data.TotalRecords)
+	}
+	totals := []struct {
+		label string
+		value string
+	}{
+		{"Total spent", fmt.Sprintf("%.2f", total)},
+		{"Expenses", fmt.Sprintf("%d", data.TotalRecords)},
+		{"Average per expense", fmt.Sprintf("%.2f", average)},
+		{"Daily average", fmt.Sprintf("%.2f", total/float64(days))},
+	}
+	for _, row := range totals {
+		doc.text(pdfMargin, y, 11, false, row.label)
+		doc.textRight(pdfMargin+260, y, 11, true, row.value)
+		y -= 18
+	}
+	y -= 24
+
+	// Category breakdown table
+	ensureSpace(60)
+	doc.text(pdfMargin, y, 14, true, "Spending by Category")
+	y -= 24
+	tableHeader := func() {
+		doc.text(pdfMargin, y, 10, true, "Category")
+		doc.textRight(pdfMargin+300, y, 10, true, "Expenses")
+		doc.textRight(pdfMargin+400, y, 10, true, "Amount")
+		doc.textRight(right, y, 10, true, "Share")
+		doc.line(pdfMargin, y-6, right, y-6)
+		y -= 22
+	}
+	tableHeader()
+	if len(categories) == 0 {
+		doc.text(pdfMargin, y, 10, false, "No expenses in this period")
+		y -= 18
+	}
+	for _, category := range categories {
+		if y-18 < pdfMargin {
+			ensureSpace(18)
+			tableHeader()
+		}
+		doc.text(pdfMargin, y, 10, false, truncatePDFText(category.name, 10, 220))
+		doc.textRight(pdfMargin+300, y, 10, false, fmt.Sprintf("%d", category.count))
+		doc.textRight(pdfMargin+400, y, 10, false, fmt.Sprintf("%.2f", category.total))
+		doc.textRight(right, y, 10, false, fmt.Sprintf("%.1f%%", category.total/total*100))
+		y -= 18
+	}
+	if len(categories) > 0 {
+		ensureSpace(20)
+		doc.line(pdfMargin, y+12, right, y+12)
+		doc.text(pdfMargin, y-2, 10, true, "Total")
+		doc.textRight(pdfMargin+300, y-2, 10, true, fmt.Sprintf("%d", data.TotalRecords))
+		doc.textRight(pdfMargin+400, y-2, 10, true, fmt.Sprintf("%.2f", total))
+		doc.textRight(right, y-2, 10, true, "100.0%")
+		y -= 44
+	}
+
+	// Bar chart, scaled to the largest category
+	if len(categories) > 0 {
+		ensureSpace(60)
+		doc.text(pdfMargin, y, 14, true, "Chart")
+		y -= 26
+		const labelWidth, barHeight = 120.0, 12.0
+		barWidth := right - pdfMargin - labelWidth - 70
+		largest := categories[0].total
+		for _, category := range categories {
+			ensureSpace(barHeight + 8)
+			doc.text(pdfMargin, y, 9, false, truncatePDFText(category.name, 9, labelWidth-10))
+			width := 0.0
+			if largest > 0 {
+				width = barWidth * category.total / largest
+			}
+			doc.rect(pdfMargin+labelWidth, y-3, width, barHeight, 0.26, 0.52, 0.96)
+			doc.text(pdfMargin+labelWidth+width+6, y, 9, false, fmt.Sprintf("%.2f", category.total))
+			y -= barHeight + 8
+		}
+	}
+
+	return doc.bytes(), nil
+}
+
+// ExportAsXLSX exports expenses as a workbook with a summary sheet and one sheet
+// per category. The summary's counts and totals are formulas over the category
+// sheets, so edits to an expense carry through.
+func (u *DataExportUseCase) ExportAsXLSX(ctx context.Context, req *ExportRequest) ([]byte, error) {
+	req.Format = "xlsx"
+	data, err := u.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	categories, total := groupStatementCategories(data.Data)
+
+	wb := newXLSXWorkbook()
+	bold := wb.style("", true)
+	dateStyle := wb.style("yyyy-mm-dd", false)
+	percent := wb.style("0.0%", false)
+	amountStyle := func(currency string, bold bool) int {
+		return wb.style(xlsxCurrencyFormat(currency), bold)
+	}
+
+	summary := wb.addSheet("Summary", 28, 12, 18, 10)
+	summary.addRow(xlsxText("Expense Statement", bold))
+	summary.addRow(xlsxText(statementPeriodLabel(data.PeriodStart, data.PeriodEnd), 0))
+	summary.addRow()
+	summary.addRow(xlsxText("Category", bold), xlsxText("Expenses", bold), xlsxText("Total", bold), xlsxText("Share", bold))
+	if len(categories) == 0 {
+		summary.addRow(xlsxText("No expenses in this period", 0))
+	}
+
+	firstRow := len(summary.rows) + 1
+	totalRow := firstRow + len(categories)
+	for _, category := range categories {
+		sheet := wb.addSheet(category.name, 12, 36, 16, 16, 24, 48)
+		sheet.addRow(xlsxText("Date", bold), xlsxText("Description", bold), xlsxText("Amount", bold), xlsxText("Account", bold), xlsxText("Tags", bold), xlsxText("Items", bold))
+		for _, exp := range category.expenses {
+			date, _ := time.Parse("2006-01-02", exp.Date)
+			sheet.addRow(
+				xlsxNumber(xlsxDate(date), dateStyle),
+				xlsxText(exp.Description, 0),
+				xlsxNumber(exp.Amount, amountStyle(exp.Currency, false)),
+				xlsxText(exp.Account, 0),
+				xlsxText(hashtags(exp.Tags), 0),
+				xlsxText(itemList(exp.Items), 0),
+			)
+		}
+		amounts := fmt.Sprintf("C2:C%d", len(sheet.rows))
+		sheet.addRow(xlsxText("Total", bold), xlsxText("", 0),
+			xlsxFormula("SUM("+amounts+")", category.total, amountStyle(statementCurrency(category.expenses), true)))
+
+		row := len(summary.rows) + 1
+		ref := xlsxSheetRef(sheet.name) + "!" + amounts
+		share := 0.0
+		if total != 0 {
+			share = category.total / total
+		}
+		summary.addRow(
+			xlsxText(category.name, 0),
+			xlsxFormula("COUNT("+ref+")", float64(category.count), 0),
+			xlsxFormula("SUM("+ref+")", category.total, amountStyle(statementCurrency(category.expenses), false)),
+			xlsxFormula(fmt.Sprintf("IF($C$%d=0,0,C%d/$C$%d)", totalRow, row, totalRow), share, percent),
+		)
+	}
+	if len(categories) > 0 {
+		summary.addRow(
+			xlsxText("Total", bold),
+			xlsxFormula(fmt.Sprintf("SUM(B%d:B%d)", firstRow, totalRow-1), float64(data.TotalRecords), bold),
+			xlsxFormula(fmt.Sprintf("SUM(C%d:C%d)", firstRow, totalRow-1), total, amountStyle(statementCurrency(data.Data), true)),
+		)
+	}
+
+	return wb.bytes()
+}
+
+// statementCurrency returns the currency shared by all expenses, or "" when they differ
+func statementCurrency(expenses []ExportedExpense) string {
+	currency := ""
+	for i, exp := range expenses {
+		if i > 0 && exp.Currency != currency {
+			return ""
+		}
+		currency = exp.Currency
+	}
+	return currency
+}
+
+// statementPeriodLabel names the statement period, e.g. "March 2026" for a
+// whole calendar month or "2026-03-01 to 2026-03-15"
+func statementPeriodLabel(start, end time.Time) string {
+	monthEnd := time.Date(start.Year(), start.Month()+1, 0, 0, 0, 0, 0, start.Location())
+	if start.Day() == 1 && end.Year() == start.Year() && end.Month() == start.Month() && end.Day() == monthEnd.Day() {
+		return start.Format("January 2006")
+	}
+	return fmt.Sprintf("%s to %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+}
+
 // SummaryExportRequest represents a request for summary export
 type SummaryExportRequest struct {
 	UserID    string
@@ -173,6 +488,7 @@ type SummaryData struct {
 	TransactionCount int                `json:"transaction_count"`
 	AverageExpense   float64            `json:"average_expense"`
 	CategoryTotals   map[string]float64 `json:"category_totals"`
+	TagTotals        map[string]float64 `json:"tag_totals,omitempty"`
 	DailyAverages    float64            `json:"daily_average"`
 	ExportedAt       time.Time          `json:"exported_at"`
 }
@@ -189,8 +505,13 @@ func (u *DataExportUseCase) ExportSummary(ctx context.Context, req *SummaryExpor
 		return nil, fmt.Errorf("failed to get expenses: %w", err)
 	}
 
+	if err := loadExpenseTags(ctx, u.tagRepo, expenses); err != nil {
+		return nil, err
+	}
+
 	totalExpenses := 0.0
 	categoryTotals := make(map[string]float64)
+	var tagTotals map[string]float64
 
 	for _, expense := range expenses {
 		totalExpenses += expense.Amount
@@ -204,6 +525,12 @@ func (u *DataExportUseCase) ExportSummary(ctx context.Context, req *SummaryExpor
 		}
 
 		categoryTotals[categoryName] += expense.Amount
+		for _, tag := range expense.Tags {
+			if tagTotals == nil {
+				tagTotals = make(map[string]float64)
+			}
+			tagTotals[tag] += expense.Amount
+		}
 	}
 
 	// Calculate averages
@@ -228,6 +555,7 @@ func (u *DataExportUseCase) ExportSummary(ctx context.Context, req *SummaryExpor
 		TransactionCount: len(expenses),
 		AverageExpense:   avgExpense,
 		CategoryTotals:   categoryTotals,
+		TagTotals:        tagTotals,
 		DailyAverages:    dailyAverage,
 		ExportedAt:       time.Now(),
 	}, nil
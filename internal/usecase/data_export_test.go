@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestDataExport_ExportAsPDF(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	categoryRepo.Create(ctx, &domain.Category{ID: "food", UserID: "user1", Name: "Food"})
+	categoryRepo.Create(ctx, &domain.Category{ID: "transport", UserID: "user1", Name: "交通"})
+
+	food, transport := "food", "transport"
+	expenses := []*domain.Expense{
+		{ID: "e1", UserID: "user1", Description: "lunch", Amount: 120, CategoryID: &food, ExpenseDate: time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)},
+		{ID: "e2", UserID: "user1", Description: "dinner", Amount: 280, CategoryID: &food, ExpenseDate: time.Date(2026, 3, 10, 19, 0, 0, 0, time.UTC)},
+		{ID: "e3", UserID: "user1", Description: "taxi", Amount: 100, CategoryID: &transport, ExpenseDate: time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, expense := range expenses {
+		expenseRepo.Create(ctx, expense)
+	}
+
+	uc := NewDataExportUseCase(expenseRepo, categoryRepo)
+	data, err := uc.ExportAsPDF(ctx, &ExportRequest{
+		UserID:    "user1",
+		StartDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("%PDF-")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("expected a complete PDF document")
+	}
+	for _, want := range []string{
+		"(March 2026)",
+		"(500.00)",   // total
+		"(Food)",     // category table and chart
+		"<4EA4901A>", // 交通, encoded for the CJK font
+		"(80.0%)",    // Food's share
+		" re f",      // chart bars
+		"/BaseFont /MSung-Light",
+	} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("expected statement to contain %q", want)
+		}
+	}
+
+	// Every cross-reference entry must point at its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if startxref == nil {
+		t.Fatal("expected startxref")
+	}
+	offset, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(data[offset:], []byte("xref\n")) {
+		t.Fatal("expected startxref to point at the xref table")
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[offset:], -1)
+	if len(entries) != 9 {
+		t.Fatalf("expected 9 objects for a one-page statement, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		objOffset, _ := strconv.Atoi(string(entry[1]))
+		if !bytes.HasPrefix(data[objOffset:], []byte(fmt.Sprintf("%d 0 obj", i+1))) {
+			t.Errorf("xref entry %d does not point at its object", i+1)
+		}
+	}
+}
+
+func TestStatementPeriodLabel(t *testing.T) {
+	tests := []struct {
+		start, end time.Time
+		want       string
+	}{
+		{time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 2, 28, 23, 59, 59, 0, time.UTC), "February 2026"},
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), "2026-03-01 to 2026-03-15"},
+		{time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC), "2026-03-05 to 2026-03-31"},
+	}
+	for _, tt := range tests {
+		if got := statementPeriodLabel(tt.start, tt.end); got != tt.want {
+			t.Errorf("statementPeriodLabel(%v, %v) = %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestDataExport_ExportAsXLSX(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	categoryRepo.Create(ctx, &domain.Category{ID: "food", UserID: "user1", Name: "Food & Drink"})
+	categoryRepo.Create(ctx, &domain.Category{ID: "transport", UserID: "user1", Name: "交通"})
+
+	food, transport := "food", "transport"
+	expenses := []*domain.Expense{
+		{ID: "e1", UserID: "user1", Description: "lunch", Amount: 120, HomeCurrency: "TWD", CategoryID: &food, ExpenseDate: time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)},
+		{ID: "e2", UserID: "user1", Description: "dinner", Amount: 280, HomeCurrency: "TWD", CategoryID: &food, ExpenseDate: time.Date(2026, 3, 10, 19, 0, 0, 0, time.UTC)},
+		{ID: "e3", UserID: "user1", Description: "taxi", Amount: 100, HomeCurrency: "TWD", CategoryID: &transport, ExpenseDate: time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, expense := range expenses {
+		expenseRepo.Create(ctx, expense)
+	}
+
+	uc := NewDataExportUseCase(expenseRepo, categoryRepo)
+	data, err := uc.ExportAsXLSX(ctx, &ExportRequest{
+		UserID:    "user1",
+		StartDate: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("expected a zip archive: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", f.Name, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/styles.xml",
+		"xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml", "xl/worksheets/sheet3.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("expected workbook part %s", name)
+		}
+	}
+
+	checks := []struct {
+		part string
+		want string
+	}{
+		{"xl/workbook.xml", `<sheet name="Summary" sheetId="1"`},
+		{"xl/workbook.xml", `<sheet name="Food &amp; Drink" sheetId="2"`},
+		{"xl/workbook.xml", `<sheet name="交通" sheetId="3"`},
+		{"xl/worksheets/sheet1.xml", `<f>SUM(&#39;Food &amp; Drink&#39;!C2:C3)</f><v>400</v>`},
+		{"xl/worksheets/sheet1.xml", `<f>COUNT(&#39;交通&#39;!C2:C2)</f><v>1</v>`},
+		{"xl/worksheets/sheet1.xml", `<f>SUM(C5:C6)</f><v>500</v>`},
+		{"xl/worksheets/sheet2.xml", `<f>SUM(C2:C3)</f><v>400</v>`},
+		{"xl/worksheets/sheet2.xml", `<v>46083</v>`}, // 2026-03-02 as a date serial
+		{"xl/styles.xml", `formatCode="#,##0.00 &#34;TWD&#34;"`},
+	}
+	for _, check := range checks {
+		if !strings.Contains(parts[check.part], check.want) {
+			t.Errorf("expected %s to contain %s", check.part, check.want)
+		}
+	}
+}
+
+func TestXLSXWorkbook_AddSheet(t *testing.T) {
+	wb := newXLSXWorkbook()
+	wb.addSheet("Summary")
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"summary", "summary (2)"},
+		{"Rent/Utilities", "Rent_Utilities"},
+		{"A very long category name that will not fit", "A very long category name that "},
+		{"A very long category name that will not fit either", "A very long category name t (2)"},
+		{"", "Sheet"},
+	}
+	for _, tt := range tests {
+		if got := wb.addSheet(tt.name).name; got != tt.want {
+			t.Errorf("addSheet(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -2,14 +2,26 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
 )
 
-// DeleteExpenseUseCase handles deleting expenses
+// ExpenseGraceWindow is how long after being recorded an expense can be undone,
+// and how long after being deleted it can be restored
+const ExpenseGraceWindow = 24 * time.Hour
+
+// DeleteExpenseUseCase handles deleting expenses. Deletes are soft, so a deleted
+// expense can be restored within the grace window.
 type DeleteExpenseUseCase struct {
 	expenseRepo domain.ExpenseRepository
+	auditRepo   domain.ExpenseAuditRepository
+	uow         domain.UnitOfWork
+	events      EventPublisher
+	graceWindow time.Duration
+	now         func() time.Time
 }
 
 // NewDeleteExpenseUseCase creates a new delete expense use case
@@ -18,9 +30,26 @@ func NewDeleteExpenseUseCase(
 ) *DeleteExpenseUseCase {
 	return &DeleteExpenseUseCase{
 		expenseRepo: expenseRepo,
+		graceWindow: ExpenseGraceWindow,
+		now:         time.Now,
 	}
 }
 
+// SetAuditRepository records deletes and restores in the expense audit log
+func (u *DeleteExpenseUseCase) SetAuditRepository(auditRepo domain.ExpenseAuditRepository) {
+	u.auditRepo = auditRepo
+}
+
+// SetUnitOfWork saves each delete or restore and its audit entry in one transaction
+func (u *DeleteExpenseUseCase) SetUnitOfWork(uow domain.UnitOfWork) {
+	u.uow = uow
+}
+
+// SetEventPublisher publishes expense.deleted and expense.restored events
+func (u *DeleteExpenseUseCase) SetEventPublisher(events EventPublisher) {
+	u.events = events
+}
+
 // DeleteRequest represents a request to delete an expense
 type DeleteRequest struct {
 	ID     string
@@ -37,26 +66,137 @@ type DeleteResponse struct {
 func (u *DeleteExpenseUseCase) Execute(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error) {
 	// Get the expense to verify ownership
 	expense, err := u.expenseRepo.GetByID(ctx, req.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("expense %w", domain.ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get expense: %w", err)
 	}
 
-	if expense == nil {
-		return nil, fmt.Errorf("expense not found")
-	}
-
 	// Verify authorization (user owns this expense)
 	if expense.UserID != req.UserID {
 		return nil, fmt.Errorf("unauthorized: user does not own this expense")
 	}
 
 	// Delete the expense
-	if err := u.expenseRepo.Delete(ctx, req.ID); err != nil {
-		return nil, fmt.Errorf("failed to delete expense: %w", err)
+	if err := u.delete(ctx, expense); err != nil {
+		return nil, err
+	}
+
+	return &DeleteResponse{
+		ID:      req.ID,
+		Message: translate(ctx, "expense.deleted", expense.Description),
+	}, nil
+}
+
+// UndoLast deletes the user's most recently recorded expense, if it was recorded
+// within the grace window
+func (u *DeleteExpenseUseCase) UndoLast(ctx context.Context, userID string) (*DeleteResponse, error) {
+	expenses, err := u.expenseRepo.ListByUserID(ctx, userID, domain.ExpenseListOptions{
+		Limit:   1,
+		SortBy:  domain.ExpenseSortCreatedAt,
+		SortDir: domain.SortDesc,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest expense: %w", err)
+	}
+	if len(expenses) == 0 || u.now().Sub(expenses[0].CreatedAt) > u.graceWindow {
+		return nil, fmt.Errorf("no expense recorded in the last %s", formatGraceWindow(u.graceWindow))
+	}
+
+	expense := expenses[0]
+	if err := u.delete(ctx, expense); err != nil {
+		return nil, err
 	}
 
 	return &DeleteResponse{
+		ID:      expense.ID,
+		Message: translate(ctx, "undo.deleted", expense.Description, formatMoney(ctx, expense.HomeAmount, expense.HomeCurrency)),
+	}, nil
+}
+
+// RestoreRequest represents a request to restore a deleted expense
+type RestoreRequest struct {
+	ID     string
+	UserID string // For authorization
+}
+
+// RestoreResponse represents the response after restoring an expense
+type RestoreResponse struct {
+	ID      string
+	Message string
+}
+
+// Restore brings back a deleted expense within the grace window
+func (u *DeleteExpenseUseCase) Restore(ctx context.Context, req *RestoreRequest) (*RestoreResponse, error) {
+	expense, err := u.expenseRepo.GetDeletedByID(ctx, req.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("deleted expense %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+
+	// Verify authorization (user owns this expense)
+	if expense.UserID != req.UserID {
+		return nil, fmt.Errorf("unauthorized: user does not own this expense")
+	}
+
+	if expense.DeletedAt != nil && u.now().Sub(*expense.DeletedAt) > u.graceWindow {
+		return nil, fmt.Errorf("expense can only be restored within %s of deletion", formatGraceWindow(u.graceWindow))
+	}
+
+	before := *expense
+	restored := before
+	restored.DeletedAt = nil
+	err = inUnitOfWork(ctx, u.uow, func(ctx context.Context) error {
+		if err := u.expenseRepo.Restore(ctx, req.ID); err != nil {
+			return fmt.Errorf("failed to restore expense: %w", err)
+		}
+		return recordExpenseAudit(ctx, u.auditRepo, domain.ExpenseAuditRestore, &before, &restored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if u.events != nil {
+		u.events.Publish(ctx, restored.UserID, domain.EventExpenseRestored, newExpenseEvent(&restored, ""))
+	}
+
+	return &RestoreResponse{
 		ID:      req.ID,
-		Message: fmt.Sprintf("Expense '%s' deleted successfully", expense.Description),
+		Message: translate(ctx, "expense.restored", expense.Description),
 	}, nil
 }
+
+// delete soft deletes an expense and records it in the audit log together,
+// then publishes the expense.deleted event
+func (u *DeleteExpenseUseCase) delete(ctx context.Context, expense *domain.Expense) error {
+	before := *expense
+	deletedAt := u.now()
+	deleted := before
+	deleted.DeletedAt = &deletedAt
+	err := inUnitOfWork(ctx, u.uow, func(ctx context.Context) error {
+		if err := u.expenseRepo.Delete(ctx, before.ID); err != nil {
+			return fmt.Errorf("failed to delete expense: %w", err)
+		}
+		return recordExpenseAudit(ctx, u.auditRepo, domain.ExpenseAuditDelete, &before, &deleted)
+	})
+	if err != nil {
+		return err
+	}
+	if u.events != nil {
+		u.events.Publish(ctx, before.UserID, domain.EventExpenseDeleted, newExpenseEvent(&before, ""))
+	}
+	return nil
+}
+
+// formatGraceWindow renders the window in whole hours, e.g. "24 hours"
+func formatGraceWindow(window time.Duration) string {
+	if hours := int(window.Hours()); hours >= 1 {
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return window.String()
+}
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+func TestDeleteExpenseUseCase_SoftDeleteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "午餐", HomeAmount: 120, HomeCurrency: "TWD"})
+
+	uc := NewDeleteExpenseUseCase(expenseRepo)
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	if _, err := uc.Execute(ctx, &DeleteRequest{ID: "exp1", UserID: "user1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expense, _ := expenseRepo.GetByID(ctx, "exp1"); expense != nil {
+		t.Fatal("expected deleted expense to be hidden")
+	}
+
+	if _, err := uc.Restore(ctx, &RestoreRequest{ID: "exp1", UserID: "user2"}); err == nil {
+		t.Error("expected another user's restore to fail")
+	}
+
+	resp, err := uc.Restore(ctx, &RestoreRequest{ID: "exp1", UserID: "user1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(resp.Message, "午餐") {
+		t.Errorf("expected message to mention the expense, got %q", resp.Message)
+	}
+	if expense, _ := expenseRepo.GetByID(ctx, "exp1"); expense == nil {
+		t.Fatal("expected expense to be restored")
+	}
+
+	if _, err := uc.Restore(ctx, &RestoreRequest{ID: "exp1", UserID: "user1"}); err == nil {
+		t.Error("expected restoring an expense that is not deleted to fail")
+	}
+}
+
+func TestDeleteExpenseUseCase_RestoreAfterGraceWindow(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "午餐"})
+
+	uc := NewDeleteExpenseUseCase(expenseRepo)
+	uc.Execute(ctx, &DeleteRequest{ID: "exp1", UserID: "user1"})
+	uc.now = func() time.Time { return time.Now().Add(ExpenseGraceWindow + time.Minute) }
+
+	_, err := uc.Restore(ctx, &RestoreRequest{ID: "exp1", UserID: "user1"})
+	if err == nil || !strings.Contains(err.Error(), "24 hours") {
+		t.Errorf("expected grace window error, got %v", err)
+	}
+}
+
+func TestDeleteExpenseUseCase_UndoLast(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "en")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Deletes the most recently recorded expense", func(t *testing.T) {
+		expenseRepo := NewMockExpenseRepository()
+		// Recorded later but dated earlier, so undo must go by creation time
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "早餐", HomeAmount: 60, HomeCurrency: "TWD", ExpenseDate: now, CreatedAt: now.Add(-time.Hour)})
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp2", UserID: "user1", Description: "午餐", HomeAmount: 120, HomeCurrency: "TWD", ExpenseDate: now.AddDate(0, 0, -1), CreatedAt: now.Add(-time.Minute)})
+
+		uc := NewDeleteExpenseUseCase(expenseRepo)
+		uc.now = func() time.Time { return now }
+
+		resp, err := uc.UndoLast(ctx, "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.ID != "exp2" || resp.Message != "Deleted 午餐 NT$120" {
+			t.Errorf("expected exp2 to be deleted, got %+v", resp)
+		}
+		if deleted, _ := expenseRepo.GetDeletedByID(ctx, "exp2"); deleted == nil {
+			t.Error("expected exp2 to be soft-deleted")
+		}
+	})
+
+	t.Run("Nothing recent to undo", func(t *testing.T) {
+		expenseRepo := NewMockExpenseRepository()
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "早餐", CreatedAt: now.Add(-48 * time.Hour)})
+
+		uc := NewDeleteExpenseUseCase(expenseRepo)
+		uc.now = func() time.Time { return now }
+
+		if _, err := uc.UndoLast(ctx, "user1"); err == nil {
+			t.Error("expected an old expense not to be undone")
+		}
+		if _, err := uc.UndoLast(ctx, "user2"); err == nil {
+			t.Error("expected an error for a user without expenses")
+		}
+	})
+}
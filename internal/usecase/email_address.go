@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+const (
+	// emailCodeTTL is how long the code sent to a new email address stays valid
+	emailCodeTTL = 15 * time.Minute
+	// emailCodeAttempts is how many wrong guesses an email verification code survives
+	emailCodeAttempts = 5
+)
+
+var (
+	// ErrInvalidEmailAddress is returned for an address that doesn't parse
+	ErrInvalidEmailAddress = errors.New("a valid email address is required")
+	// ErrInvalidVerificationCode is returned for a wrong, expired or used email verification code
+	ErrInvalidVerificationCode = errors.New("invalid or expired code")
+)
+
+// EmailAddressUseCase binds email addresses to users, so receipts forwarded
+// from them to the inbound address are recorded as the user's expenses. A
+// code is emailed to the address and the binding holds once the user enters
+// it, so only someone who can read the mailbox can bind it.
+type EmailAddressUseCase struct {
+	repo           domain.EmailAddressRepository
+	sender         domain.EmailSender
+	inboundAddress string
+	now            func() time.Time
+}
+
+// NewEmailAddressUseCase creates a new email address use case; inboundAddress
+// is the address receipts are forwarded to, mentioned in the code email
+func NewEmailAddressUseCase(repo domain.EmailAddressRepository, sender domain.EmailSender, inboundAddress string) *EmailAddressUseCase {
+	return &EmailAddressUseCase{
+		repo:           repo,
+		sender:         sender,
+		inboundAddress: inboundAddress,
+		now:            time.Now,
+	}
+}
+
+// RequestVerification emails a code to the address, replacing any code sent
+// before. Addresses another user has bound can't be claimed; an address the
+// user has already bound is returned as is.
+func (u *EmailAddressUseCase) RequestVerification(ctx context.Context, userID, address string) (*domain.EmailAddress, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	address, err := normalizeEmailAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := u.repo.GetByAddress(ctx, address)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get email address: %w", err)
+	}
+	if err == nil && existing.VerifiedAt != nil {
+		if existing.UserID != userID {
+			return nil, fmt.Errorf("email address %w for another user", domain.ErrConflict)
+		}
+		return existing, nil
+	}
+
+	code, err := newLoginCode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate code: %w", err)
+	}
+	now := u.now()
+	expiresAt := now.Add(emailCodeTTL)
+	binding := &domain.EmailAddress{
+		Address:       address,
+		UserID:        userID,
+		CodeHash:      hashEmailCode(address, code),
+		CodeExpiresAt: &expiresAt,
+		CreatedAt:     now,
+	}
+	if err := u.repo.Save(ctx, binding); err != nil {
+		return nil, fmt.Errorf("failed to save email address: %w", err)
+	}
+
+	if err := u.sender.Send(ctx, u.codeEmail(address, code)); err != nil {
+		return nil, fmt.Errorf("failed to send code: %w", err)
+	}
+	slog.InfoContext(ctx, "Email address verification requested", "user_id", userID)
+	return binding, nil
+}
+
+// Verify binds the address to the user if the code is the one last emailed to it
+func (u *EmailAddressUseCase) Verify(ctx context.Context, userID, address, code string) (*domain.EmailAddress, error) {
+	address, err := normalizeEmailAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	binding, err := u.repo.GetByAddress(ctx, address)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidVerificationCode
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email address: %w", err)
+	}
+	if binding.UserID != userID {
+		return nil, ErrInvalidVerificationCode
+	}
+	if binding.VerifiedAt != nil {
+		return binding, nil
+	}
+
+	now := u.now()
+	if binding.CodeExpiresAt == nil || now.After(*binding.CodeExpiresAt) || binding.Attempts >= emailCodeAttempts {
+		return nil, ErrInvalidVerificationCode
+	}
+	if subtle.ConstantTimeCompare([]byte(hashEmailCode(address, strings.TrimSpace(code))), []byte(binding.CodeHash)) != 1 {
+		binding.Attempts++
+		if err := u.repo.Save(ctx, binding); err != nil {
+			return nil, fmt.Errorf("failed to save email address: %w", err)
+		}
+		return nil, ErrInvalidVerificationCode
+	}
+
+	binding.VerifiedAt = &now
+	binding.CodeHash = ""
+	binding.CodeExpiresAt = nil
+	binding.Attempts = 0
+	if err := u.repo.Save(ctx, binding); err != nil {
+		return nil, fmt.Errorf("failed to save email address: %w", err)
+	}
+	slog.InfoContext(ctx, "Email address bound", "user_id", userID)
+	return binding, nil
+}
+
+// List returns the user's addresses, bound or waiting for their code
+func (u *EmailAddressUseCase) List(ctx context.Context, userID string) ([]*domain.EmailAddress, error) {
+	addresses, err := u.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email addresses: %w", err)
+	}
+	if addresses == nil {
+		addresses = []*domain.EmailAddress{}
+	}
+	return addresses, nil
+}
+
+// Remove unbinds one of the user's addresses
+func (u *EmailAddressUseCase) Remove(ctx context.Context, userID, address string) error {
+	address, err := normalizeEmailAddress(address)
+	if err != nil {
+		return err
+	}
+	binding, err := u.repo.GetByAddress(ctx, address)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return fmt.Errorf("failed to get email address: %w", err)
+	}
+	if err != nil || binding.UserID != userID {
+		return fmt.Errorf("email address %w", domain.ErrNotFound)
+	}
+	if err := u.repo.Delete(ctx, address); err != nil {
+		return fmt.Errorf("failed to delete email address: %w", err)
+	}
+	return nil
+}
+
+// Resolve returns the user the address is bound to, or ErrNotFound if it
+// isn't bound or is still waiting for its code
+func (u *EmailAddressUseCase) Resolve(ctx context.Context, address string) (string, error) {
+	address, err := normalizeEmailAddress(address)
+	if err != nil {
+		return "", err
+	}
+	binding, err := u.repo.GetByAddress(ctx, address)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return "", fmt.Errorf("failed to get email address: %w", err)
+	}
+	if err != nil || binding.VerifiedAt == nil {
+		return "", fmt.Errorf("email address %w", domain.ErrNotFound)
+	}
+	return binding.UserID, nil
+}
+
+// codeEmail is the email carrying the verification code
+func (u *EmailAddressUseCase) codeEmail(address, code string) *domain.EmailMessage {
+	text := fmt.Sprintf("Your AI Expense verification code is %s. It expires in 15 minutes.", code)
+	if u.inboundAddress != "" {
+		text += fmt.Sprintf("\n\nOnce verified, forward receipts from this address to %s to record them.", u.inboundAddress)
+	}
+	text += "\n\nIf you didn't ask for it, ignore this email."
+
+	return &domain.EmailMessage{
+		To:       address,
+		Subject:  "Your AI Expense verification code",
+		TextBody: text,
+		HTMLBody: "<p>" + strings.ReplaceAll(html.EscapeString(text), "\n\n", "</p><p>") + "</p>",
+	}
+}
+
+// normalizeEmailAddress returns the lowercased address of "Name <addr>" or "addr"
+func normalizeEmailAddress(address string) (string, error) {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(address))
+	if err != nil {
+		return "", ErrInvalidEmailAddress
+	}
+	return strings.ToLower(parsed.Address), nil
+}
+
+// hashEmailCode hashes a verification code with its address, so a code only
+// works for the address it was sent to
+func hashEmailCode(address, code string) string {
+	sum := sha256.Sum256([]byte(address + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
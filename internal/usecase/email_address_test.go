@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func newTestEmailAddressUseCase(now time.Time) (*EmailAddressUseCase, *fakeEmailSender) {
+	sender := &fakeEmailSender{}
+	uc := NewEmailAddressUseCase(NewMockEmailAddressRepository(), sender, "receipts@in.example.com")
+	uc.now = func() time.Time { return now }
+	return uc, sender
+}
+
+// sentCode returns the code in the last email sent
+func sentCode(t *testing.T, sender *fakeEmailSender) string {
+	t.Helper()
+	if len(sender.sent) == 0 {
+		t.Fatal("expected a code email")
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(sender.sent[len(sender.sent)-1].TextBody)
+	if code == "" {
+		t.Fatalf("no code in %q", sender.sent[len(sender.sent)-1].TextBody)
+	}
+	return code
+}
+
+func TestEmailAddressUseCase_Verify(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	uc, sender := newTestEmailAddressUseCase(now)
+
+	pending, err := uc.RequestVerification(ctx, "line_u1", "River <River@Example.com>")
+	if err != nil {
+		t.Fatalf("RequestVerification failed: %v", err)
+	}
+	if pending.Address != "river@example.com" || pending.VerifiedAt != nil {
+		t.Errorf("expected a pending lowercased address, got %+v", pending)
+	}
+	if sender.sent[0].To != "river@example.com" || !strings.Contains(sender.sent[0].TextBody, "receipts@in.example.com") {
+		t.Errorf("unexpected code email %+v", sender.sent[0])
+	}
+	code := sentCode(t, sender)
+
+	if _, err := uc.Resolve(ctx, "river@example.com"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected an unverified address not to resolve, got %v", err)
+	}
+	if _, err := uc.Verify(ctx, "telegram_2", "river@example.com", code); !errors.Is(err, ErrInvalidVerificationCode) {
+		t.Errorf("expected another user's code rejected, got %v", err)
+	}
+	if _, err := uc.Verify(ctx, "line_u1", "river@example.com", "000000x"); !errors.Is(err, ErrInvalidVerificationCode) {
+		t.Errorf("expected a wrong code rejected, got %v", err)
+	}
+
+	bound, err := uc.Verify(ctx, "line_u1", "river@example.com", code)
+	if err != nil || bound.VerifiedAt == nil || bound.CodeHash != "" {
+		t.Fatalf("expected the address bound, got %+v, %v", bound, err)
+	}
+	if userID, err := uc.Resolve(ctx, "RIVER@example.com"); err != nil || userID != "line_u1" {
+		t.Errorf("expected the address to resolve to line_u1, got %q, %v", userID, err)
+	}
+
+	if _, err := uc.RequestVerification(ctx, "telegram_2", "river@example.com"); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("expected a bound address not claimable by another user, got %v", err)
+	}
+
+	if err := uc.Remove(ctx, "telegram_2", "river@example.com"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected another user's address not removable, got %v", err)
+	}
+	if err := uc.Remove(ctx, "line_u1", "river@example.com"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if addresses, _ := uc.List(ctx, "line_u1"); len(addresses) != 0 {
+		t.Errorf("expected no addresses left, got %+v", addresses)
+	}
+}
+
+func TestEmailAddressUseCase_VerifyLimits(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("expired code", func(t *testing.T) {
+		uc, sender := newTestEmailAddressUseCase(now)
+		if _, err := uc.RequestVerification(ctx, "line_u1", "river@example.com"); err != nil {
+			t.Fatalf("RequestVerification failed: %v", err)
+		}
+		uc.now = func() time.Time { return now.Add(emailCodeTTL + time.Second) }
+		if _, err := uc.Verify(ctx, "line_u1", "river@example.com", sentCode(t, sender)); !errors.Is(err, ErrInvalidVerificationCode) {
+			t.Errorf("expected an expired code rejected, got %v", err)
+		}
+	})
+
+	t.Run("too many wrong codes", func(t *testing.T) {
+		uc, sender := newTestEmailAddressUseCase(now)
+		if _, err := uc.RequestVerification(ctx, "line_u1", "river@example.com"); err != nil {
+			t.Fatalf("RequestVerification failed: %v", err)
+		}
+		for i := 0; i < emailCodeAttempts; i++ {
+			uc.Verify(ctx, "line_u1", "river@example.com", "wrong")
+		}
+		if _, err := uc.Verify(ctx, "line_u1", "river@example.com", sentCode(t, sender)); !errors.Is(err, ErrInvalidVerificationCode) {
+			t.Errorf("expected the code locked after %d wrong guesses, got %v", emailCodeAttempts, err)
+		}
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		uc, sender := newTestEmailAddressUseCase(now)
+		if _, err := uc.RequestVerification(ctx, "line_u1", "not an address"); !errors.Is(err, ErrInvalidEmailAddress) {
+			t.Errorf("expected an invalid address rejected, got %v", err)
+		}
+		if len(sender.sent) != 0 {
+			t.Errorf("expected no email sent, got %d", len(sender.sent))
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// eventBusBuffer is how many events a subscriber can fall behind before it misses new ones
+const eventBusBuffer = 32
+
+// EventHandler reacts to an event published on the bus
+type EventHandler func(ctx context.Context, userID string, event UserEvent)
+
+// EventPublisher publishes a user's events to the in-process event bus
+type EventPublisher interface {
+	Publish(ctx context.Context, userID, eventType string, data interface{})
+}
+
+// UserEvent is an event about a user's data, such as a new expense
+type UserEvent struct {
+	ID        uint64      `json:"-"` // Increases with every event published on the bus
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// EventBus decouples the use cases that change data from the ones reacting to
+// it. Handlers registered for an event type, such as webhooks or budget alerts,
+// run in the background for every user's events; subscribers, such as the
+// dashboard's live update stream, receive one user's events on a channel.
+// Delivery is in-process and best effort: events are not stored, and a
+// subscriber that stops reading misses events rather than holding up the
+// publisher.
+type EventBus struct {
+	mu          sync.Mutex
+	nextID      uint64
+	subscribers map[string]map[chan UserEvent]struct{}
+	handlers    map[string][]EventHandler
+	running     sync.WaitGroup
+}
+
+// NewEventBus creates an empty event bus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[string]map[chan UserEvent]struct{}),
+		handlers:    make(map[string][]EventHandler),
+	}
+}
+
+// Handle registers a handler for every event of the given type
+func (b *EventBus) Handle(eventType string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Subscribe returns a channel of the user's events and a function that ends
+// the subscription and closes the channel
+func (b *EventBus) Subscribe(userID string) (<-chan UserEvent, func()) {
+	ch := make(chan UserEvent, eventBusBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan UserEvent]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[userID], ch)
+			if len(b.subscribers[userID]) == 0 {
+				delete(b.subscribers, userID)
+			}
+			close(ch)
+		})
+	}
+}
+
+// Publish starts the event's handlers and sends it to the user's subscribers
+// without blocking. Handlers outlive the publisher's request, so they do not
+// inherit its cancellation.
+func (b *EventBus) Publish(ctx context.Context, userID, eventType string, data interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	event := UserEvent{ID: b.nextID, Type: eventType, Data: data, CreatedAt: time.Now()}
+	handlerCtx := context.WithoutCancel(ctx)
+	for _, handler := range b.handlers[eventType] {
+		b.running.Add(1)
+		go func(handler EventHandler) {
+			defer b.running.Done()
+			handler(handlerCtx, userID, event)
+		}(handler)
+	}
+	for ch := range b.subscribers[userID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Wait blocks until the handlers of every event published so far have returned
+func (b *EventBus) Wait() {
+	b.running.Wait()
+}
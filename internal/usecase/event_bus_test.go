@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestEventBus(t *testing.T) {
+	ctx := context.Background()
+	bus := NewEventBus()
+
+	events, unsubscribe := bus.Subscribe("user1")
+	other, unsubscribeOther := bus.Subscribe("user2")
+	defer unsubscribeOther()
+
+	bus.Publish(ctx, "user1", domain.EventExpenseCreated, &ExpenseEvent{ExpenseID: "e1"})
+	bus.Publish(ctx, "user1", domain.EventExpenseDeleted, &ExpenseEvent{ExpenseID: "e1"})
+
+	first, second := <-events, <-events
+	if first.Type != domain.EventExpenseCreated || second.Type != domain.EventExpenseDeleted || second.ID <= first.ID {
+		t.Errorf("expected the user's events in order, got %+v then %+v", first, second)
+	}
+	select {
+	case event := <-other:
+		t.Errorf("expected no events for another user, got %+v", event)
+	default:
+	}
+
+	// A subscriber that stops reading misses events instead of blocking the publisher
+	for i := 0; i < eventBusBuffer+10; i++ {
+		bus.Publish(ctx, "user1", domain.EventExpenseUpdated, nil)
+	}
+	if len(events) != eventBusBuffer {
+		t.Errorf("expected the buffer to fill up, got %d events", len(events))
+	}
+
+	unsubscribe()
+	unsubscribe()
+	for range events {
+	}
+	bus.Publish(ctx, "user1", domain.EventExpenseCreated, nil)
+	if len(bus.subscribers) != 1 {
+		t.Errorf("expected only user2 to be subscribed, got %d users", len(bus.subscribers))
+	}
+}
+
+func TestEventBus_ExpenseEvents(t *testing.T) {
+	ctx := context.Background()
+	bus := NewEventBus()
+	events, unsubscribe := bus.Subscribe("user1")
+	defer unsubscribe()
+
+	expenseRepo := NewMockExpenseRepository()
+	expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "午餐", HomeAmount: 120, HomeCurrency: "TWD"})
+	uc := NewDeleteExpenseUseCase(expenseRepo)
+	uc.SetEventPublisher(bus)
+
+	if _, err := uc.Execute(ctx, &DeleteRequest{ID: "exp1", UserID: "user1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := uc.Restore(ctx, &RestoreRequest{ID: "exp1", UserID: "user1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{domain.EventExpenseDeleted, domain.EventExpenseRestored} {
+		event := <-events
+		data, ok := event.Data.(*ExpenseEvent)
+		if event.Type != want || !ok || data.ExpenseID != "exp1" || data.Description != "午餐" {
+			t.Errorf("expected %s for exp1, got %s %+v", want, event.Type, event.Data)
+		}
+	}
+
+	notifications := NewNotificationUseCase()
+	notifications.SetEventPublisher(bus)
+	notifications.CreateNotification(ctx, &CreateNotificationRequest{UserID: "user1", Type: "report", Title: "Weekly report ready"})
+	if event := <-events; event.Type != domain.EventNotificationCreated || event.Data.(*Notification).Title != "Weekly report ready" {
+		t.Errorf("expected a notification event, got %+v", event)
+	}
+}
+
+func TestEventBus_Handlers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	bus := NewEventBus()
+
+	metrics := NewMetricsUseCase(nil)
+	bus.Handle(domain.EventUserSignedUp, metrics.RecordEvent)
+	bus.Handle(domain.EventExpenseCreated, metrics.RecordEvent)
+
+	var handled []UserEvent
+	var handledCtxErr error
+	bus.Handle(domain.EventUserSignedUp, func(ctx context.Context, userID string, event UserEvent) {
+		handledCtxErr = ctx.Err()
+		handled = append(handled, event)
+	})
+
+	signup := NewAutoSignupUseCase(NewMockUserRepository(), NewMockCategoryRepository())
+	signup.SetEventPublisher(bus)
+	signup.Execute(ctx, "user1", "line")
+	signup.Execute(ctx, "user1", "line")
+	cancel()
+	bus.Wait()
+
+	if len(handled) != 1 || handled[0].Data.(*UserSignedUpEvent).MessengerType != "line" {
+		t.Fatalf("expected one user.signed_up event, got %+v", handled)
+	}
+	if handledCtxErr != nil {
+		t.Errorf("expected the handler to outlive the publisher's context, got %v", handledCtxErr)
+	}
+
+	bus.Publish(context.Background(), "user1", domain.EventExpenseCreated, &ExpenseEvent{ExpenseID: "e1"})
+	bus.Publish(context.Background(), "user1", domain.EventExpenseDeleted, &ExpenseEvent{ExpenseID: "e1"})
+	bus.Wait()
+	counts := metrics.GetEventCounts().Counts
+	if counts[domain.EventUserSignedUp] != 1 || counts[domain.EventExpenseCreated] != 1 || counts[domain.EventExpenseDeleted] != 0 {
+		t.Errorf("unexpected event counts: %v", counts)
+	}
+}
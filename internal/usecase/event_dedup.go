@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// DefaultProcessedEventTTL is how long handled webhook events are remembered.
+// Messenger platforms stop retrying a delivery well within a day.
+const DefaultProcessedEventTTL = 24 * time.Hour
+
+// EventDedupUseCase remembers handled messenger webhook events so a retried or
+// redelivered event does not record the same expense twice
+type EventDedupUseCase struct {
+	repo domain.ProcessedEventRepository
+	ttl  time.Duration
+}
+
+// NewEventDedupUseCase creates a new event deduplication use case
+func NewEventDedupUseCase(repo domain.ProcessedEventRepository, ttl time.Duration) *EventDedupUseCase {
+	if ttl <= 0 {
+		ttl = DefaultProcessedEventTTL
+	}
+	return &EventDedupUseCase{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// Seen reports whether the event was already handled, recording it if not. Events
+// without an ID are never treated as duplicates, and storage errors let the event
+// through rather than dropping a user's message.
+func (u *EventDedupUseCase) Seen(ctx context.Context, messenger, eventID string) bool {
+	if eventID == "" {
+		return false
+	}
+
+	recorded, err := u.repo.MarkProcessed(ctx, &domain.ProcessedEvent{
+		Messenger:   messenger,
+		EventID:     eventID,
+		ProcessedAt: time.Now(),
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to record messenger event", "messenger", messenger, "event_id", eventID, "error", err)
+		return false
+	}
+	if !recorded {
+		slog.InfoContext(ctx, "Skipping duplicate messenger event", "messenger", messenger, "event_id", eventID)
+	}
+	return !recorded
+}
+
+// Cleanup forgets events older than the TTL
+func (u *EventDedupUseCase) Cleanup(ctx context.Context) (int64, error) {
+	return u.repo.DeleteBefore(ctx, time.Now().Add(-u.ttl))
+}
+
+// RunCleanup forgets expired events once per interval until ctx is done
+func (u *EventDedupUseCase) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.Cleanup(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to clean up processed events", "error", err)
+			}
+		}
+	}
+}
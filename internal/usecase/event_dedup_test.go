@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestEventDedupUseCase(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Second delivery is a duplicate", func(t *testing.T) {
+		uc := NewEventDedupUseCase(NewMockProcessedEventRepository(), time.Hour)
+		if uc.Seen(ctx, "line", "evt-1") {
+			t.Error("expected first delivery to be new")
+		}
+		if !uc.Seen(ctx, "line", "evt-1") {
+			t.Error("expected second delivery to be a duplicate")
+		}
+		if uc.Seen(ctx, "slack", "evt-1") {
+			t.Error("expected the same ID from another messenger to be new")
+		}
+	})
+
+	t.Run("Events without ID are never duplicates", func(t *testing.T) {
+		uc := NewEventDedupUseCase(NewMockProcessedEventRepository(), time.Hour)
+		uc.Seen(ctx, "line", "")
+		if uc.Seen(ctx, "line", "") {
+			t.Error("expected empty event ID to be let through")
+		}
+	})
+
+	t.Run("Cleanup forgets expired events", func(t *testing.T) {
+		repo := NewMockProcessedEventRepository()
+		uc := NewEventDedupUseCase(repo, time.Hour)
+		repo.MarkProcessed(ctx, &domain.ProcessedEvent{Messenger: "line", EventID: "old", ProcessedAt: time.Now().Add(-2 * time.Hour)})
+		uc.Seen(ctx, "line", "new")
+
+		deleted, err := uc.Cleanup(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if deleted != 1 {
+			t.Errorf("expected 1 deleted event, got %d", deleted)
+		}
+		if uc.Seen(ctx, "line", "old") {
+			t.Error("expected expired event to be processed again")
+		}
+		if !uc.Seen(ctx, "line", "new") {
+			t.Error("expected recent event to still be a duplicate")
+		}
+	})
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 	"strings"
 	"time"
 
@@ -68,29 +70,56 @@ func (s *ExchangeRateService) GetRate(ctx context.Context, fromCurrency, toCurre
 		return nil, nil
 	}
 	rate, err := s.repo.GetRate(ctx, strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency), txTime)
-	if err != nil || rate != nil {
+	if !errors.Is(err, domain.ErrNotFound) {
 		return rate, err
 	}
 	return s.repo.GetMostRecentRate(ctx, strings.ToUpper(fromCurrency), strings.ToUpper(toCurrency), txTime)
 }
 
+// GetRates lists the latest cached rates for a base currency as of date,
+// fetching from the provider when nothing has been cached yet
+func (s *ExchangeRateService) GetRates(ctx context.Context, baseCurrency string, date time.Time) ([]*domain.ExchangeRate, error) {
+	if s.repo == nil {
+		return nil, nil
+	}
+	base := strings.ToUpper(baseCurrency)
+	rates, err := s.repo.GetLatestRates(ctx, base, date)
+	if err != nil || len(rates) > 0 {
+		return rates, err
+	}
+	if err := s.fetchAndStore(ctx, base, nil); err != nil {
+		return nil, err
+	}
+	return s.repo.GetLatestRates(ctx, base, date)
+}
+
+// RunDailyRefresh refreshes rates immediately and then once per interval until ctx is done
+func (s *ExchangeRateService) RunDailyRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.RefreshRates(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to refresh exchange rates", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func (s *ExchangeRateService) ensureRate(ctx context.Context, fromCurrency, toCurrency string, txTime time.Time) (*domain.ExchangeRate, error) {
 	if s.repo == nil {
 		return &domain.ExchangeRate{BaseCurrency: fromCurrency, TargetCurrency: toCurrency, Rate: 1.0, RateDate: txTime}, nil
 	}
 	rate, err := s.repo.GetRate(ctx, fromCurrency, toCurrency, txTime)
-	if err != nil {
-		return nil, err
-	}
-	if rate != nil {
-		return rate, nil
+	if !errors.Is(err, domain.ErrNotFound) {
+		return rate, err
 	}
 	rate, err = s.repo.GetMostRecentRate(ctx, fromCurrency, toCurrency, txTime)
-	if err != nil {
-		return nil, err
-	}
-	if rate != nil {
-		return rate, nil
+	if !errors.Is(err, domain.ErrNotFound) {
+		return rate, err
 	}
 	if err := s.fetchAndStore(ctx, fromCurrency, []string{toCurrency}); err != nil {
 		return nil, err
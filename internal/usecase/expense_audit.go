@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ExpenseAuditUseCase reads the change history of expenses
+type ExpenseAuditUseCase struct {
+	auditRepo   domain.ExpenseAuditRepository
+	expenseRepo domain.ExpenseRepository
+}
+
+// NewExpenseAuditUseCase creates a new expense audit use case
+func NewExpenseAuditUseCase(auditRepo domain.ExpenseAuditRepository, expenseRepo domain.ExpenseRepository) *ExpenseAuditUseCase {
+	return &ExpenseAuditUseCase{
+		auditRepo:   auditRepo,
+		expenseRepo: expenseRepo,
+	}
+}
+
+// GetHistory returns the changes made to a user's expense, oldest first.
+// Deleted expenses keep their history.
+func (u *ExpenseAuditUseCase) GetHistory(ctx context.Context, expenseID, userID string) ([]*domain.ExpenseAuditEntry, error) {
+	expense, err := u.expenseRepo.GetByID(ctx, expenseID)
+	if errors.Is(err, domain.ErrNotFound) {
+		expense, err = u.expenseRepo.GetDeletedByID(ctx, expenseID)
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("expense %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+
+	// Verify authorization (user owns this expense)
+	if expense.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: user does not own this expense")
+	}
+
+	entries, err := u.auditRepo.GetByExpenseID(ctx, expenseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense history: %w", err)
+	}
+	return entries, nil
+}
+
+// recordExpenseAudit stores a change to an expense, attributed to the audit
+// source carried by ctx. Inside a unit of work a failure is returned so the
+// change rolls back with it; otherwise it is logged and the change stands.
+func recordExpenseAudit(ctx context.Context, repo domain.ExpenseAuditRepository, action string, before, after *domain.Expense) error {
+	if repo == nil {
+		return nil
+	}
+	expense := after
+	if expense == nil {
+		expense = before
+	}
+
+	source := domain.AuditSourceFromContext(ctx)
+	if source.Actor == "" {
+		source.Actor = expense.UserID
+	}
+	if source.Channel == "" {
+		source.Channel = domain.AuditChannelSystem
+	}
+
+	entry := &domain.ExpenseAuditEntry{
+		ID:        uuid.New().String(),
+		ExpenseID: expense.ID,
+		UserID:    expense.UserID,
+		Action:    action,
+		Actor:     source.Actor,
+		Channel:   source.Channel,
+		Before:    before,
+		After:     after,
+		CreatedAt: time.Now(),
+	}
+	if err := repo.Create(ctx, entry); err != nil {
+		if domain.InUnitOfWork(ctx) {
+			return fmt.Errorf("failed to record expense audit entry: %w", err)
+		}
+		slog.WarnContext(ctx, "Failed to record expense audit entry", "action", action, "expense_id", expense.ID, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestExpenseAudit_RecordsEveryChange(t *testing.T) {
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	auditRepo := NewMockExpenseAuditRepository()
+
+	createUC := NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, &MockAIService{})
+	createUC.SetAuditRepository(auditRepo)
+	updateUC := NewUpdateExpenseUseCase(expenseRepo, categoryRepo)
+	updateUC.SetAuditRepository(auditRepo)
+	deleteUC := NewDeleteExpenseUseCase(expenseRepo)
+	deleteUC.SetAuditRepository(auditRepo)
+
+	lineCtx := domain.WithAuditSource(context.Background(), domain.AuditSource{Actor: "user1", Channel: "line"})
+	apiCtx := domain.WithAuditSource(context.Background(), domain.AuditSource{Actor: "user1", Channel: domain.AuditChannelAPI})
+
+	created, err := createUC.Execute(lineCtx, &CreateRequest{UserID: "user1", Description: "午餐", Amount: 120, Date: time.Now()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	amount := 250.0
+	if _, err := updateUC.Execute(apiCtx, &UpdateRequest{ID: created.ID, UserID: "user1", Amount: &amount}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := deleteUC.Execute(apiCtx, &DeleteRequest{ID: created.ID, UserID: "user1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := deleteUC.Restore(context.Background(), &RestoreRequest{ID: created.ID, UserID: "user1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	history, err := NewExpenseAuditUseCase(auditRepo, expenseRepo).GetHistory(context.Background(), created.ID, "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 4 {
+		t.Fatalf("expected 4 audit entries, got %d", len(history))
+	}
+
+	want := []struct {
+		action  string
+		channel string
+	}{
+		{domain.ExpenseAuditCreate, "line"},
+		{domain.ExpenseAuditUpdate, domain.AuditChannelAPI},
+		{domain.ExpenseAuditDelete, domain.AuditChannelAPI},
+		{domain.ExpenseAuditRestore, domain.AuditChannelSystem},
+	}
+	for i, w := range want {
+		if history[i].Action != w.action || history[i].Channel != w.channel || history[i].Actor != "user1" {
+			t.Errorf("entry %d: expected %s via %s by user1, got %s via %s by %s",
+				i, w.action, w.channel, history[i].Action, history[i].Channel, history[i].Actor)
+		}
+	}
+
+	if history[0].Before != nil || history[0].After == nil || history[0].After.HomeAmount != 120 {
+		t.Errorf("expected create entry to hold only the new expense, got before=%v after=%v", history[0].Before, history[0].After)
+	}
+	if history[1].Before.HomeAmount != 120 || history[1].After.HomeAmount != 250 {
+		t.Errorf("expected update snapshots 120 -> 250, got %v -> %v", history[1].Before.HomeAmount, history[1].After.HomeAmount)
+	}
+	if history[2].Before.DeletedAt != nil || history[2].After.DeletedAt == nil {
+		t.Error("expected delete entry to show the expense being deleted")
+	}
+	if history[3].Before.DeletedAt == nil || history[3].After.DeletedAt != nil {
+		t.Error("expected restore entry to show the expense being restored")
+	}
+}
+
+func TestExpenseAudit_GetHistory(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	auditRepo := NewMockExpenseAuditRepository()
+	expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "午餐"})
+
+	deleteUC := NewDeleteExpenseUseCase(expenseRepo)
+	deleteUC.SetAuditRepository(auditRepo)
+	deleteUC.Execute(ctx, &DeleteRequest{ID: "exp1", UserID: "user1"})
+
+	uc := NewExpenseAuditUseCase(auditRepo, expenseRepo)
+
+	history, err := uc.GetHistory(ctx, "exp1", "user1")
+	if err != nil {
+		t.Fatalf("expected deleted expense to keep its history, got %v", err)
+	}
+	if len(history) != 1 || history[0].Action != domain.ExpenseAuditDelete {
+		t.Errorf("expected one delete entry, got %v", history)
+	}
+
+	if _, err := uc.GetHistory(ctx, "exp1", "user2"); err == nil {
+		t.Error("expected another user's request to fail")
+	}
+	if _, err := uc.GetHistory(ctx, "missing", "user1"); err == nil {
+		t.Error("expected unknown expense to fail")
+	}
+}
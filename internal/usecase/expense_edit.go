@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// expenseEditLookback is how many of the user's latest expenses an edit request can refer to
+const expenseEditLookback = 20
+
+// expenseEditPatterns capture the referenced expense and the new value, e.g.
+// "把剛剛的午餐改成 250", "午餐改成交通" or "change lunch to 250"
+var expenseEditPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^(?:把|将|將)?\s*(?:剛剛|刚刚|剛才|刚才|上一筆|上一笔|最後一筆|最后一笔)?\s*(?:的|那筆|那笔)?\s*(.*?)\s*(?:改成|改為|改为|換成|换成)\s*(.+?)$`),
+	regexp.MustCompile(`(?i)^(?:change|update|edit)\s+(?:the\s+|my\s+)?(?:last\s+)?(.+?)\s+to\s+(.+?)$`),
+}
+
+// expenseEditAmountPattern matches a new amount, e.g. "250", "$12.5" or "250元"
+var expenseEditAmountPattern = regexp.MustCompile(`^[$＄]?\s*([0-9]+(?:\.[0-9]+)?)\s*(?:元|塊|块|dollars?)?$`)
+
+// ExpenseEditUseCase applies edits requested in chat, e.g. "把剛剛的午餐改成 250",
+// to the user's most recent expense with a matching description. The new value is
+// an amount, or the name of one of the user's categories.
+type ExpenseEditUseCase struct {
+	expenseRepo   domain.ExpenseRepository
+	categoryRepo  domain.CategoryRepository
+	expenseUpdate ExpenseUpdater
+}
+
+// NewExpenseEditUseCase creates a new expense edit use case
+func NewExpenseEditUseCase(expenseRepo domain.ExpenseRepository, categoryRepo domain.CategoryRepository, expenseUpdate ExpenseUpdater) *ExpenseEditUseCase {
+	return &ExpenseEditUseCase{
+		expenseRepo:   expenseRepo,
+		categoryRepo:  categoryRepo,
+		expenseUpdate: expenseUpdate,
+	}
+}
+
+// Edit applies the edit requested by text and returns the reply. It returns false
+// when the message is not an edit request, so it can be processed as usual.
+func (u *ExpenseEditUseCase) Edit(ctx context.Context, userID, text string) (string, bool) {
+	target, value, ok := parseExpenseEdit(strings.TrimSpace(text))
+	if !ok {
+		return "", false
+	}
+
+	req := &UpdateRequest{UserID: userID}
+	var category *domain.Category
+	if m := expenseEditAmountPattern.FindStringSubmatch(value); m != nil {
+		amount, err := strconv.ParseFloat(m[1], 64)
+		if err != nil || amount <= 0 {
+			return "", false
+		}
+		req.Amount = &amount
+	} else {
+		var err error
+		category, err = u.categoryRepo.GetByUserIDAndName(ctx, userID, value)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			slog.WarnContext(ctx, "Failed to find category", "category", value, "error", err)
+		}
+		if err != nil {
+			// Not a value we can set; let the message be parsed as usual
+			return "", false
+		}
+		req.CategoryID = &category.ID
+	}
+
+	expense, err := u.findRecent(ctx, userID, target)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to look up expenses to edit", "error", err)
+		return translate(ctx, "edit.failed"), true
+	}
+	if expense == nil {
+		if target == "" {
+			return translate(ctx, "edit.no_recent"), true
+		}
+		return translate(ctx, "edit.no_match", target), true
+	}
+
+	req.ID = expense.ID
+	description, previousAmount, currency := expense.Description, expense.HomeAmount, expense.HomeCurrency
+	if _, err := u.expenseUpdate.Execute(ctx, req); err != nil {
+		slog.ErrorContext(ctx, "Failed to edit expense", "expense_id", expense.ID, "error", err)
+		return translate(ctx, "edit.failed"), true
+	}
+
+	if category != nil {
+		return translate(ctx, "edit.moved", description, category.Name), true
+	}
+	return translate(ctx, "edit.changed", description, formatMoney(ctx, previousAmount, currency), formatMoney(ctx, *req.Amount, currency)), true
+}
+
+// findRecent returns the user's most recently recorded expense whose description
+// matches target, or the latest expense when target is empty
+func (u *ExpenseEditUseCase) findRecent(ctx context.Context, userID, target string) (*domain.Expense, error) {
+	expenses, err := u.expenseRepo.ListByUserID(ctx, userID, domain.ExpenseListOptions{
+		Limit:   expenseEditLookback,
+		SortBy:  domain.ExpenseSortCreatedAt,
+		SortDir: domain.SortDesc,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	target = strings.ToLower(target)
+	for _, expense := range expenses {
+		description := strings.ToLower(expense.Description)
+		if target == "" || strings.Contains(description, target) || (description != "" && strings.Contains(target, description)) {
+			return expense, nil
+		}
+	}
+	return nil, nil
+}
+
+// parseExpenseEdit splits an edit request into the referenced expense and the new value
+func parseExpenseEdit(text string) (target, value string, ok bool) {
+	for _, pattern := range expenseEditPatterns {
+		if m := pattern.FindStringSubmatch(text); m != nil && strings.TrimSpace(m[2]) != "" {
+			return strings.TrimSpace(m[1]), strings.TrimSpace(m[2]), true
+		}
+	}
+	return "", "", false
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+func TestParseExpenseEdit(t *testing.T) {
+	tests := []struct {
+		text   string
+		target string
+		value  string
+		ok     bool
+	}{
+		{"把剛剛的午餐改成 250", "午餐", "250", true},
+		{"把刚刚的午餐改为250元", "午餐", "250元", true},
+		{"晚餐改成交通", "晚餐", "交通", true},
+		{"剛剛那筆改成 80", "", "80", true},
+		{"change lunch to 250", "lunch", "250", true},
+		{"Change the last potato to $30", "potato", "$30", true},
+		{"午餐 250", "", "", false},
+		{"改成", "", "", false},
+		{"taxi to airport 500", "", "", false},
+	}
+
+	for _, tt := range tests {
+		target, value, ok := parseExpenseEdit(tt.text)
+		if ok != tt.ok || target != tt.target || value != tt.value {
+			t.Errorf("parseExpenseEdit(%q) = (%q, %q, %v), want (%q, %q, %v)", tt.text, target, value, ok, tt.target, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestExpenseEditUseCase(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "en")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	setup := func() (*ExpenseEditUseCase, *MockExpenseRepository) {
+		expenseRepo := NewMockExpenseRepository()
+		categoryRepo := NewMockCategoryRepository()
+		categoryRepo.Create(ctx, &domain.Category{ID: "cat_food", UserID: "user1", Name: "Food"})
+		categoryRepo.Create(ctx, &domain.Category{ID: "cat_transport", UserID: "user1", Name: "交通"})
+
+		foodID := "cat_food"
+		expenseRepo.Create(ctx, &domain.Expense{ID: "old_lunch", UserID: "user1", Description: "午餐", HomeAmount: 100, HomeCurrency: "TWD", CategoryID: &foodID, CreatedAt: now.Add(-2 * time.Hour)})
+		expenseRepo.Create(ctx, &domain.Expense{ID: "lunch", UserID: "user1", Description: "午餐便當", HomeAmount: 120, HomeCurrency: "TWD", CategoryID: &foodID, CreatedAt: now.Add(-time.Hour)})
+		expenseRepo.Create(ctx, &domain.Expense{ID: "coffee", UserID: "user1", Description: "咖啡", HomeAmount: 60, HomeCurrency: "TWD", CategoryID: &foodID, CreatedAt: now})
+		expenseRepo.Create(ctx, &domain.Expense{ID: "other", UserID: "user2", Description: "午餐", HomeAmount: 90, HomeCurrency: "TWD", CreatedAt: now})
+
+		return NewExpenseEditUseCase(expenseRepo, categoryRepo, NewUpdateExpenseUseCase(expenseRepo, categoryRepo)), expenseRepo
+	}
+
+	t.Run("Changes the amount of the latest matching expense", func(t *testing.T) {
+		editor, expenseRepo := setup()
+
+		reply, ok := editor.Edit(ctx, "user1", "把剛剛的午餐改成 250")
+		if !ok {
+			t.Fatal("expected edit request to be handled")
+		}
+		if reply != "✏️ Changed 午餐便當 from NT$120 to NT$250" {
+			t.Errorf("unexpected reply %q", reply)
+		}
+		if expense, _ := expenseRepo.GetByID(ctx, "lunch"); expense.HomeAmount != 250 {
+			t.Errorf("expected amount 250, got %v", expense.HomeAmount)
+		}
+		if expense, _ := expenseRepo.GetByID(ctx, "old_lunch"); expense.HomeAmount != 100 {
+			t.Errorf("expected older lunch to be unchanged, got %v", expense.HomeAmount)
+		}
+		if expense, _ := expenseRepo.GetByID(ctx, "other"); expense.HomeAmount != 90 {
+			t.Errorf("expected another user's expense to be unchanged, got %v", expense.HomeAmount)
+		}
+	})
+
+	t.Run("Changes the category", func(t *testing.T) {
+		editor, expenseRepo := setup()
+
+		reply, ok := editor.Edit(ctx, "user1", "咖啡改成交通")
+		if !ok {
+			t.Fatal("expected edit request to be handled")
+		}
+		if reply != "✏️ Moved 咖啡 to 交通" {
+			t.Errorf("unexpected reply %q", reply)
+		}
+		if expense, _ := expenseRepo.GetByID(ctx, "coffee"); *expense.CategoryID != "cat_transport" {
+			t.Errorf("expected category cat_transport, got %s", *expense.CategoryID)
+		}
+	})
+
+	t.Run("No matching expense", func(t *testing.T) {
+		editor, _ := setup()
+
+		reply, ok := editor.Edit(ctx, "user1", "change dinner to 300")
+		if !ok {
+			t.Fatal("expected edit request to be handled")
+		}
+		if reply != "I couldn't find a recent expense matching dinner." {
+			t.Errorf("unexpected reply %q", reply)
+		}
+	})
+
+	t.Run("Other messages are not edits", func(t *testing.T) {
+		editor, _ := setup()
+
+		for _, text := range []string{"午餐 120", "午餐改成好吃的", "change lunch to something"} {
+			if _, ok := editor.Edit(ctx, "user1", text); ok {
+				t.Errorf("expected %q not to be handled", text)
+			}
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// loadExpenseItems sets the receipt line Items of expenses from the item repository
+func loadExpenseItems(ctx context.Context, itemRepo domain.ExpenseItemRepository, expenses []*domain.Expense) error {
+	if itemRepo == nil || len(expenses) == 0 {
+		return nil
+	}
+	ids := make([]string, len(expenses))
+	for i, expense := range expenses {
+		ids[i] = expense.ID
+	}
+	items, err := itemRepo.GetByExpenseIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load expense items: %w", err)
+	}
+	for _, expense := range expenses {
+		expense.Items = items[expense.ID]
+	}
+	return nil
+}
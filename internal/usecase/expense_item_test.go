@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpenseItemsAcrossUseCases(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	itemRepo := NewMockExpenseItemRepository()
+	createUC := NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, NewMockAIService())
+	createUC.SetExpenseItemRepository(itemRepo)
+
+	now := time.Now()
+	results, err := createUC.ExecuteBatch(ctx, []*CreateRequest{
+		{UserID: "user1", Description: "Latte, Sandwich", Amount: 175, Date: now, Items: []*domain.ExpenseItem{
+			{Name: "Latte", Quantity: 2, UnitPrice: 65},
+			{Name: "Sandwich", Quantity: 1, UnitPrice: 45},
+		}},
+		{UserID: "user1", Description: "Taxi", Amount: 300, Date: now},
+	})
+	require.NoError(t, err)
+	require.NoError(t, results[0].Error)
+	require.NoError(t, results[1].Error)
+
+	items := itemRepo.items[results[0].Response.ID]
+	require.Len(t, items, 2)
+	assert.Equal(t, "Latte", items[0].Name)
+	assert.Equal(t, 0, items[0].Position)
+	assert.Equal(t, results[0].Response.ID, items[0].ExpenseID)
+	assert.NotEmpty(t, items[0].ID)
+	assert.Equal(t, 130.0, items[0].Total())
+	assert.Equal(t, 1, items[1].Position)
+	assert.Empty(t, itemRepo.items[results[1].Response.ID])
+
+	// Exports list each expense's items
+	exportUC := NewDataExportUseCase(expenseRepo, categoryRepo)
+	exportUC.SetExpenseItemRepository(itemRepo)
+	req := &ExportRequest{UserID: "user1", StartDate: now.Add(-time.Hour), EndDate: now.Add(time.Hour)}
+	data, err := exportUC.ExportAsJSON(ctx, req)
+	require.NoError(t, err)
+	var exported ExportData
+	require.NoError(t, json.Unmarshal(data, &exported))
+	for _, expense := range exported.Data {
+		if expense.ID == results[0].Response.ID {
+			assert.Equal(t, []ExportedExpenseItem{
+				{Name: "Latte", Quantity: 2, UnitPrice: 65, Total: 130},
+				{Name: "Sandwich", Quantity: 1, UnitPrice: 45, Total: 45},
+			}, expense.Items)
+		} else {
+			assert.Empty(t, expense.Items)
+		}
+	}
+
+	csv, err := exportUC.ExportAsCSV(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(csv), "ID,Date,Description,Amount,Category,Account,Tags,Items,CreatedAt,UpdatedAt\n"))
+	assert.Contains(t, string(csv), "Latte 2 × 65.00; Sandwich 1 × 45.00")
+}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// expenseQueryTopCategories is how many categories a spending summary lists
+const expenseQueryTopCategories = 3
+
+// expenseQueryMarkers are the phrases that make a message a question about spending
+// rather than an expense to record
+var expenseQueryMarkers = []string{"多少", "how much", "total spent", "spent so far", "總共花", "总共花", "花費總", "花费总"}
+
+// expenseQueryLastDaysPattern matches "最近7天", "過去 30 天" or "last 7 days"
+var expenseQueryLastDaysPattern = regexp.MustCompile(`(?i)(?:最近|過去|过去|last|past)\s*([0-9]+)\s*(?:天|days?)`)
+
+// ExpenseReporter summarizes a user's or group's spending over a date range
+type ExpenseReporter interface {
+	Execute(ctx context.Context, req *ReportRequest) (*ExpenseReport, error)
+}
+
+// expenseQuery is the period, and optionally the category, a spending question asks about
+type expenseQuery struct {
+	label      string
+	reportType string
+	start      time.Time
+	end        time.Time
+	category   *domain.Category
+}
+
+// ExpenseQueryUseCase answers spending questions sent in chat, e.g. "這個月花多少？"
+// or "how much on food last week", with a summary of the matching expenses.
+// Questions without a period are about the current month.
+type ExpenseQueryUseCase struct {
+	reports      ExpenseReporter
+	categoryRepo domain.CategoryRepository
+	now          func() time.Time
+}
+
+// NewExpenseQueryUseCase creates a new expense query use case
+func NewExpenseQueryUseCase(reports ExpenseReporter, categoryRepo domain.CategoryRepository) *ExpenseQueryUseCase {
+	return &ExpenseQueryUseCase{
+		reports:      reports,
+		categoryRepo: categoryRepo,
+		now:          time.Now,
+	}
+}
+
+// Answer replies to a spending question about the user's expenses, or the group
+// ledger's when groupID is set. It returns false when the message is not a
+// question, so it can be processed as usual.
+func (u *ExpenseQueryUseCase) Answer(ctx context.Context, userID, groupID, text string) (string, bool) {
+	lower := strings.ToLower(strings.TrimSpace(text))
+	if !isExpenseQuery(lower) {
+		return "", false
+	}
+
+	locale := i18n.FromContext(ctx)
+	query := parseExpenseQueryPeriod(lower, domain.InLocation(ctx, u.now()), locale)
+	categories, err := u.categoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get categories", "error", err)
+	}
+	query.category = matchQueryCategory(lower, categories)
+
+	report, err := u.reports.Execute(ctx, &ReportRequest{
+		UserID:     userID,
+		GroupID:    groupID,
+		ReportType: query.reportType,
+		StartDate:  query.start,
+		EndDate:    query.end,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to answer spending question", "error", err)
+		return i18n.T(locale, "query.failed"), true
+	}
+
+	return formatExpenseQueryReply(locale, query, report), true
+}
+
+// isExpenseQuery reports whether lowercased text asks about spending
+func isExpenseQuery(text string) bool {
+	for _, marker := range expenseQueryMarkers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExpenseQueryPeriod returns the date range lowercased text asks about,
+// defaulting to the current month, labelled in the locale. Weeks start on
+// Monday, as budget weeks do.
+func parseExpenseQueryPeriod(text string, now time.Time, locale string) *expenseQuery {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	year := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+
+	period := func(label, reportType string, start, end time.Time) *expenseQuery {
+		return &expenseQuery{label: label, reportType: reportType, start: start, end: end.Add(-time.Nanosecond)}
+	}
+	has := func(keywords ...string) bool {
+		for _, k := range keywords {
+			if strings.Contains(text, k) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if m := expenseQueryLastDaysPattern.FindStringSubmatch(text); m != nil {
+		if days, err := strconv.Atoi(m[1]); err == nil && days > 0 {
+			return period(i18n.Tf(locale, "query.period.last_days", days), "custom", today.AddDate(0, 0, 1-days), today.AddDate(0, 0, 1))
+		}
+	}
+
+	switch {
+	case has("今天", "今日", "today"):
+		return period(i18n.T(locale, "query.period.today"), "daily", today, today.AddDate(0, 0, 1))
+	case has("昨天", "昨日", "yesterday"):
+		return period(i18n.T(locale, "query.period.yesterday"), "daily", today.AddDate(0, 0, -1), today)
+	case has("上週", "上周", "上禮拜", "上礼拜", "上星期", "last week"):
+		return period(i18n.T(locale, "query.period.last_week"), "weekly", monday.AddDate(0, 0, -7), monday)
+	case has("這週", "这周", "本週", "本周", "這禮拜", "这礼拜", "這星期", "这星期", "this week"):
+		return period(i18n.T(locale, "query.period.this_week"), "weekly", monday, monday.AddDate(0, 0, 7))
+	case has("上個月", "上个月", "上月", "last month"):
+		return period(i18n.T(locale, "query.period.last_month"), "monthly", month.AddDate(0, -1, 0), month)
+	case has("去年", "last year"):
+		return period(i18n.T(locale, "query.period.last_year"), "custom", year.AddDate(-1, 0, 0), year)
+	case has("今年", "this year"):
+		return period(i18n.T(locale, "query.period.this_year"), "custom", year, year.AddDate(1, 0, 0))
+	}
+	return period(i18n.T(locale, "query.period.this_month"), "monthly", month, month.AddDate(0, 1, 0))
+}
+
+// matchQueryCategory returns the category lowercased text mentions, preferring the longest name
+func matchQueryCategory(text string, categories []*domain.Category) *domain.Category {
+	var match *domain.Category
+	for _, category := range categories {
+		name := strings.ToLower(category.Name)
+		if name != "" && strings.Contains(text, name) && (match == nil || len(name) > len(match.Name)) {
+			match = category
+		}
+	}
+	return match
+}
+
+// formatExpenseQueryReply renders the total for the period, with the top categories
+// or only the asked category
+func formatExpenseQueryReply(locale string, query *expenseQuery, report *ExpenseReport) string {
+	if query.category != nil {
+		total, count := 0.0, 0
+		for _, breakdown := range report.CategoryBreakdown {
+			if breakdown.Category == query.category.Name {
+				total, count = breakdown.Total, breakdown.Count
+			}
+		}
+		return i18n.Tf(locale, "query.category_total", query.label, query.category.Name, i18n.FormatNumber(locale, total), pluralizeExpenses(locale, count))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.Tf(locale, "query.total", query.label, i18n.FormatNumber(locale, report.TotalExpenses), pluralizeExpenses(locale, report.TransactionCount)))
+
+	breakdown := append([]CategoryBreakdown(nil), report.CategoryBreakdown...)
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].Total != breakdown[j].Total {
+			return breakdown[i].Total > breakdown[j].Total
+		}
+		return breakdown[i].Category < breakdown[j].Category
+	})
+	if len(breakdown) > expenseQueryTopCategories {
+		breakdown = breakdown[:expenseQueryTopCategories]
+	}
+	for _, category := range breakdown {
+		sb.WriteString(fmt.Sprintf("\n• %s %s (%d%%)", category.Category, i18n.FormatNumber(locale, category.Total), int(category.Percentage+0.5)))
+	}
+	return sb.String()
+}
+
+func pluralizeExpenses(locale string, count int) string {
+	if count == 1 {
+		return i18n.T(locale, "query.expense_count.one")
+	}
+	return i18n.Tf(locale, "query.expense_count", count)
+}
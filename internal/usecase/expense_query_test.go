@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+func TestParseExpenseQueryPeriod(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time { return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		text  string
+		label string
+		start time.Time
+		end   time.Time
+	}{
+		{"這個月花多少？", "This month", day(3, 1), day(4, 1)},
+		{"今天花了多少", "Today", day(3, 4), day(3, 5)},
+		{"how much did i spend yesterday", "Yesterday", day(3, 3), day(3, 4)},
+		{"這週花多少", "This week", day(3, 2), day(3, 9)},
+		{"上週花了多少錢", "Last week", day(2, 23), day(3, 2)},
+		{"上個月花多少", "Last month", day(2, 1), day(3, 1)},
+		{"最近7天花多少", "Last 7 days", day(2, 26), day(3, 5)},
+		{"how much this year", "This year", day(1, 1), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		query := parseExpenseQueryPeriod(tt.text, now, "en")
+		if query.label != tt.label || !query.start.Equal(tt.start) || !query.end.Equal(tt.end.Add(-time.Nanosecond)) {
+			t.Errorf("parseExpenseQueryPeriod(%q) = %s %v - %v, want %s %v - %v", tt.text, query.label, query.start, query.end, tt.label, tt.start, tt.end)
+		}
+	}
+}
+
+func TestExpenseQueryUseCase(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "en")
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	categoryRepo.Create(ctx, &domain.Category{ID: "cat_food", UserID: "user1", Name: "Food"})
+	categoryRepo.Create(ctx, &domain.Category{ID: "cat_transport", UserID: "user1", Name: "交通"})
+	categoryRepo.Create(ctx, &domain.Category{ID: "cat_fun", UserID: "user1", Name: "Entertainment"})
+
+	food, transport := "cat_food", "cat_transport"
+	expenses := []*domain.Expense{
+		{ID: "e1", Description: "午餐", Amount: 120, CategoryID: &food, ExpenseDate: now},
+		{ID: "e2", Description: "晚餐", Amount: 280, CategoryID: &food, ExpenseDate: now.AddDate(0, 0, -2)},
+		{ID: "e3", Description: "計程車", Amount: 200, CategoryID: &transport, ExpenseDate: now.AddDate(0, 0, -3)},
+		{ID: "e4", Description: "上個月的晚餐", Amount: 500, CategoryID: &food, ExpenseDate: now.AddDate(0, -1, 0)},
+	}
+	for _, expense := range expenses {
+		expense.UserID = "user1"
+		expenseRepo.Create(ctx, expense)
+	}
+
+	querier := NewExpenseQueryUseCase(NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil), categoryRepo)
+	querier.now = func() time.Time { return now }
+
+	t.Run("Total with top categories", func(t *testing.T) {
+		reply, ok := querier.Answer(ctx, "user1", "", "這個月花多少？")
+		if !ok {
+			t.Fatal("expected question to be answered")
+		}
+		want := "📊 This month: 600 across 3 expenses\n• Food 400 (67%)\n• 交通 200 (33%)"
+		if reply != want {
+			t.Errorf("expected %q, got %q", want, reply)
+		}
+	})
+
+	t.Run("Single category", func(t *testing.T) {
+		reply, ok := querier.Answer(ctx, "user1", "", "上個月 food 花了多少")
+		if !ok {
+			t.Fatal("expected question to be answered")
+		}
+		if want := "📊 Last month on Food: 500 across 1 expense"; reply != want {
+			t.Errorf("expected %q, got %q", want, reply)
+		}
+
+		reply, _ = querier.Answer(ctx, "user1", "", "how much on entertainment this week")
+		if want := "📊 This week on Entertainment: 0 across 0 expenses"; reply != want {
+			t.Errorf("expected %q, got %q", want, reply)
+		}
+	})
+
+	t.Run("Expenses are not questions", func(t *testing.T) {
+		for _, text := range []string{"午餐 120", "花了 300 買書", "taxi 200"} {
+			if _, ok := querier.Answer(ctx, "user1", "", text); ok {
+				t.Errorf("expected %q not to be handled", text)
+			}
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// ExpenseReminderUseCase nudges the users who turned on expense reminders
+// once they go the number of days they picked without recording an expense,
+// and again every as many days while they stay away. Reminders wait until
+// the user's quiet hours are over and go through the pusher, so a failed
+// send is retried.
+type ExpenseReminderUseCase struct {
+	prefsRepo   domain.NotificationPreferencesRepository
+	userRepo    domain.UserRepository
+	expenseRepo domain.ExpenseRepository
+	pusher      MessagePusher
+	timezones   TimezoneLocator
+	now         func() time.Time
+}
+
+// NewExpenseReminderUseCase creates a new expense reminder use case
+func NewExpenseReminderUseCase(
+	prefsRepo domain.NotificationPreferencesRepository,
+	userRepo domain.UserRepository,
+	expenseRepo domain.ExpenseRepository,
+	pusher MessagePusher,
+) *ExpenseReminderUseCase {
+	return &ExpenseReminderUseCase{
+		prefsRepo:   prefsRepo,
+		userRepo:    userRepo,
+		expenseRepo: expenseRepo,
+		pusher:      pusher,
+		now:         time.Now,
+	}
+}
+
+// SetTimezoneLocator keeps quiet hours in each user's timezone instead of the server's
+func (u *ExpenseReminderUseCase) SetTimezoneLocator(timezones TimezoneLocator) {
+	u.timezones = timezones
+}
+
+// SendDue pushes the reminders that are due outside their user's quiet
+// hours, and returns how many were pushed. A reminder that fails is retried
+// on the next run; users whose messenger can't be pushed to are skipped
+// until the next one would be due.
+func (u *ExpenseReminderUseCase) SendDue(ctx context.Context) (int, error) {
+	subscribed, err := u.prefsRepo.ListSubscribed(ctx, domain.NotificationExpenseReminders)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expense reminder subscribers: %w", err)
+	}
+
+	now := u.now()
+	sent := 0
+	for _, prefs := range subscribed {
+		loc := time.Local
+		if u.timezones != nil {
+			loc = u.timezones.Locate(ctx, prefs.UserID, "")
+		}
+		if prefs.InQuietHours(now.In(loc).Hour()) {
+			continue
+		}
+
+		user, err := u.userRepo.GetByID(ctx, prefs.UserID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to get user for expense reminder", "user_id", prefs.UserID, "error", err)
+			continue
+		}
+		lastActive, err := u.lastActive(ctx, user)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to get latest expense", "user_id", prefs.UserID, "error", err)
+			continue
+		}
+		if !expenseReminderDue(prefs, lastActive, now) {
+			continue
+		}
+
+		pushCtx := ctx
+		if i18n.Supported(user.Locale) {
+			pushCtx = i18n.WithLocale(ctx, user.Locale)
+		}
+		switch err := u.pusher.Push(pushCtx, user.UserID, user.MessengerType, expenseReminderText(user, now.Sub(lastActive))); {
+		case errors.Is(err, ErrNoNotifier):
+		case err != nil:
+			slog.WarnContext(ctx, "Failed to push expense reminder", "user_id", prefs.UserID, "error", err)
+			continue
+		default:
+			sent++
+		}
+
+		prefs.LastExpenseReminderAt = &now
+		if err := u.prefsRepo.Save(ctx, prefs); err != nil {
+			slog.ErrorContext(ctx, "Failed to record expense reminder", "user_id", prefs.UserID, "error", err)
+		}
+	}
+	return sent, nil
+}
+
+// RunScheduler sends due reminders once per interval until ctx is done
+func (u *ExpenseReminderUseCase) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.SendDue(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to send expense reminders", "error", err)
+			}
+		}
+	}
+}
+
+// lastActive returns when the user last recorded an expense, or signed up
+// if they never did
+func (u *ExpenseReminderUseCase) lastActive(ctx context.Context, user *domain.User) (time.Time, error) {
+	latest, err := u.expenseRepo.ListByUserID(ctx, user.UserID, domain.ExpenseListOptions{
+		Limit:   1,
+		SortBy:  domain.ExpenseSortCreatedAt,
+		SortDir: domain.SortDesc,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(latest) > 0 && latest[0].CreatedAt.After(user.CreatedAt) {
+		return latest[0].CreatedAt, nil
+	}
+	return user.CreatedAt, nil
+}
+
+// expenseReminderDue reports whether the user has gone their reminder days
+// without an expense since lastActive and since they were last reminded
+func expenseReminderDue(prefs *domain.NotificationPreferences, lastActive, now time.Time) bool {
+	days := prefs.ExpenseReminderDays
+	if days <= 0 {
+		days = domain.DefaultExpenseReminderDays
+	}
+	since := lastActive
+	if prefs.LastExpenseReminderAt != nil && prefs.LastExpenseReminderAt.After(since) {
+		since = *prefs.LastExpenseReminderAt
+	}
+	return now.Sub(since) >= time.Duration(days)*24*time.Hour
+}
+
+// expenseReminderText is the reminder for a user who has been away for idle, in their language
+func expenseReminderText(user *domain.User, idle time.Duration) string {
+	locale := i18n.DefaultLocale
+	if i18n.Supported(user.Locale) {
+		locale = user.Locale
+	}
+	return i18n.Tf(locale, "reminder.inactive", pluralizeDays(locale, int(idle/(24*time.Hour))))
+}
+
+// pluralizeDays writes a number of days in the locale, e.g. "a day" or "3 days"
+func pluralizeDays(locale string, days int) string {
+	if days == 1 {
+		return i18n.T(locale, "reminder.days.one")
+	}
+	return i18n.Tf(locale, "reminder.days", days)
+}
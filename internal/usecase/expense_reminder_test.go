@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpenseReminderDue(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	remindedYesterday := now.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name       string
+		days       int
+		lastActive time.Time
+		reminded   *time.Time
+		want       bool
+	}{
+		{"idle for the reminder days", 3, now.AddDate(0, 0, -3), nil, true},
+		{"active recently", 3, now.AddDate(0, 0, -2), nil, false},
+		{"reminded recently", 3, now.AddDate(0, 0, -10), &remindedYesterday, false},
+		{"reminder days unset", 0, now.AddDate(0, 0, -domain.DefaultExpenseReminderDays), nil, true},
+	}
+	for _, tt := range tests {
+		prefs := &domain.NotificationPreferences{ExpenseReminders: true, ExpenseReminderDays: tt.days, LastExpenseReminderAt: tt.reminded}
+		assert.Equal(t, tt.want, expenseReminderDue(prefs, tt.lastActive, now), tt.name)
+	}
+}
+
+func TestExpenseReminderSendDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	joined := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	userRepo := NewMockUserRepository()
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "line", Locale: "en", Timezone: "UTC", CreatedAt: joined}))
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user2", MessengerType: "line", Timezone: "UTC", CreatedAt: joined}))
+	// 01:00 in Auckland, within quiet hours
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user3", MessengerType: "line", Timezone: "Pacific/Auckland", CreatedAt: joined}))
+	// Never recorded anything and can't be pushed to
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user4", MessengerType: "teams", Timezone: "UTC", CreatedAt: joined}))
+
+	expenseRepo := NewMockExpenseRepository()
+	for i, e := range []struct {
+		userID    string
+		createdAt time.Time
+	}{
+		{"user1", now.AddDate(0, 0, -10)},
+		{"user1", now.AddDate(0, 0, -4)},
+		{"user2", now.AddDate(0, 0, -1)},
+		{"user3", now.AddDate(0, 0, -5)},
+	} {
+		require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+			ID:          string(rune('a' + i)),
+			UserID:      e.userID,
+			Amount:      100,
+			HomeAmount:  100,
+			ExpenseDate: e.createdAt,
+			CreatedAt:   e.createdAt,
+		}))
+	}
+
+	prefsRepo := NewMockNotificationPreferencesRepository()
+	for _, userID := range []string{"user1", "user2", "user3", "user4"} {
+		prefs := domain.DefaultNotificationPreferences(userID)
+		prefs.ExpenseReminders = true
+		require.NoError(t, prefsRepo.Save(ctx, prefs))
+	}
+
+	pusher := &recordingPusher{notifiers: map[string]bool{"line": true}, messages: make(map[string]string)}
+	uc := NewExpenseReminderUseCase(prefsRepo, userRepo, expenseRepo, pusher)
+	uc.SetTimezoneLocator(NewTimezoneUseCase(userRepo))
+	uc.now = func() time.Time { return now }
+
+	sent, err := uc.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, map[string]string{
+		"user1": `👋 You haven't recorded an expense in 4 days. Whenever you're ready, just send something like "lunch 120".`,
+	}, pusher.messages)
+
+	for userID, reminded := range map[string]bool{"user1": true, "user2": false, "user3": false, "user4": true} {
+		prefs, err := prefsRepo.GetByUserID(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, reminded, prefs.LastExpenseReminderAt != nil, userID)
+	}
+
+	// Reminders repeat every reminder days while the user stays away. At
+	// midnight UTC, user2 is in quiet hours and user3 is out of them.
+	now = now.Add(2*24*time.Hour + 12*time.Hour)
+	pusher.messages = make(map[string]string)
+	sent, err = uc.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Contains(t, pusher.messages["user3"], "已經 7 天沒有記帳了")
+
+	now = now.Add(12 * time.Hour)
+	sent, err = uc.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Contains(t, pusher.messages["user1"], "7 days")
+	assert.Contains(t, pusher.messages["user2"], "已經 4 天沒有記帳了")
+}
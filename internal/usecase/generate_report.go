@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
@@ -13,6 +14,9 @@ type GenerateReportUseCase struct {
 	expenseRepo  domain.ExpenseRepository
 	categoryRepo domain.CategoryRepository
 	metricsRepo  domain.MetricsRepository
+	groupRepo    domain.GroupRepository
+	tagRepo      domain.TagRepository
+	summaries    *SummaryCache
 }
 
 // NewGenerateReportUseCase creates a new generate report use case
@@ -20,17 +24,30 @@ func NewGenerateReportUseCase(
 	expenseRepo domain.ExpenseRepository,
 	categoryRepo domain.CategoryRepository,
 	metricsRepo domain.MetricsRepository,
+	groupRepo domain.GroupRepository,
 ) *GenerateReportUseCase {
 	return &GenerateReportUseCase{
 		expenseRepo:  expenseRepo,
 		categoryRepo: categoryRepo,
 		metricsRepo:  metricsRepo,
+		groupRepo:    groupRepo,
 	}
 }
 
+// SetSummaryCache caches reports until the ledger's expenses change
+func (u *GenerateReportUseCase) SetSummaryCache(summaries *SummaryCache) {
+	u.summaries = summaries
+}
+
+// SetTagRepository breaks reports down by tag and lists each expense's tags
+func (u *GenerateReportUseCase) SetTagRepository(tagRepo domain.TagRepository) {
+	u.tagRepo = tagRepo
+}
+
 // ReportRequest represents a request to generate a report
 type ReportRequest struct {
 	UserID     string
+	GroupID    string // Report on a shared group ledger the user belongs to
 	ReportType string // "daily", "weekly", "monthly"
 	StartDate  time.Time
 	EndDate    time.Time
@@ -42,6 +59,7 @@ type ExpenseDetail struct {
 	Description string    `json:"description"`
 	Amount      float64   `json:"amount"`
 	Category    string    `json:"category"`
+	Tags        []string  `json:"tags,omitempty"`
 	Date        time.Time `json:"date"`
 	Account     string    `json:"account"`
 }
@@ -54,6 +72,15 @@ type CategoryBreakdown struct {
 	Percentage float64 `json:"percentage"`
 }
 
+// TagBreakdown represents spending by tag. An expense with several tags
+// counts toward each, so percentages can add up to more than 100.
+type TagBreakdown struct {
+	Tag        string  `json:"tag"`
+	Total      float64 `json:"total"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
 // DailyBreakdown represents spending by day
 type DailyBreakdown struct {
 	Date   time.Time `json:"date"`
@@ -62,9 +89,17 @@ type DailyBreakdown struct {
 	Amount float64   `json:"amount"`
 }
 
+// MonthlyBreakdown represents spending by calendar month
+type MonthlyBreakdown struct {
+	Month string  `json:"month"` // YYYY-MM
+	Total float64 `json:"total"`
+	Count int     `json:"count"`
+}
+
 // ExpenseReport represents a generated expense report
 type ExpenseReport struct {
 	UserID            string              `json:"user_id"`
+	GroupID           string              `json:"group_id,omitempty"`
 	ReportType        string              `json:"report_type"`
 	Period            string              `json:"period"`
 	StartDate         time.Time           `json:"start_date"`
@@ -75,7 +110,9 @@ type ExpenseReport struct {
 	HighestExpense    float64             `json:"highest_expense"`
 	LowestExpense     float64             `json:"lowest_expense"`
 	CategoryBreakdown []CategoryBreakdown `json:"category_breakdown"`
+	TagBreakdown      []TagBreakdown      `json:"tag_breakdown,omitempty"`
 	DailyBreakdown    []DailyBreakdown    `json:"daily_breakdown"`
+	MonthlyBreakdown  []MonthlyBreakdown  `json:"monthly_breakdown,omitempty"`
 	TopExpenses       []ExpenseDetail     `json:"top_expenses"`
 	GeneratedAt       time.Time           `json:"generated_at"`
 }
@@ -86,102 +123,158 @@ func (u *GenerateReportUseCase) Execute(ctx context.Context, req *ReportRequest)
 		return nil, fmt.Errorf("user_id is required")
 	}
 
-	// Get all expenses for the user in the date range
-	expenses, err := u.expenseRepo.GetByUserIDAndDateRange(ctx, req.UserID, req.StartDate, req.EndDate)
+	ledger := userLedger(req.UserID)
+	if req.GroupID != "" {
+		if err := requireGroupMember(ctx, u.groupRepo, req.GroupID, req.UserID); err != nil {
+			return nil, err
+		}
+		ledger = groupLedger(req.GroupID)
+	}
+
+	key := fmt.Sprintf("report:%s:%s:%s:%s", req.UserID, req.ReportType, req.StartDate.Format(time.RFC3339Nano), req.EndDate.Format(time.RFC3339Nano))
+	return cachedSummary(ctx, u.summaries, ledger, key, func() (*ExpenseReport, error) {
+		return u.generate(ctx, req)
+	})
+}
+
+// generate builds the report from SQL aggregates of its date range; only the
+// expense list itself is read row by row
+func (u *GenerateReportUseCase) generate(ctx context.Context, req *ReportRequest) (*ExpenseReport, error) {
+	query := domain.ExpenseTotalsQuery{UserID: req.UserID, GroupID: req.GroupID, From: req.StartDate, To: req.EndDate}
+
+	categoryTotals, err := u.expenseRepo.SumByCategoryAndDateRange(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum expenses by category: %w", err)
+	}
+	dailyTotals, err := u.expenseRepo.SumByPeriodAndDateRange(ctx, query, domain.TotalsPeriodDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum expenses by day: %w", err)
+	}
+
+	// Get all expenses for the user, or the user's group, in the date range
+	var expenses []*domain.Expense
+	if req.GroupID != "" {
+		expenses, err = u.expenseRepo.GetByGroupIDAndDateRange(ctx, req.GroupID, req.StartDate, req.EndDate)
+	} else {
+		expenses, err = u.expenseRepo.GetByUserIDAndDateRange(ctx, req.UserID, req.StartDate, req.EndDate)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get expenses: %w", err)
 	}
 
+	// Each category is looked up once; group members' categories with the
+	// same name share a breakdown entry
+	categoryNames := make(map[string]string)
+	categoryName := func(categoryID *string) string {
+		if categoryID == nil {
+			return "Uncategorized"
+		}
+		if name, ok := categoryNames[*categoryID]; ok {
+			return name
+		}
+		name := "Uncategorized"
+		if cat, _ := u.categoryRepo.GetByID(ctx, *categoryID); cat != nil {
+			name = cat.Name
+		}
+		categoryNames[*categoryID] = name
+		return name
+	}
+
 	// Calculate basic statistics
 	totalExpenses := 0.0
+	transactionCount := 0
 	highestExpense := 0.0
 	lowestExpense := 0.0
-	if len(expenses) > 0 {
-		lowestExpense = expenses[0].Amount
-	}
-
-	categoryMap := make(map[string]*CategoryBreakdown)
-	dailyMap := make(map[string]*DailyBreakdown)
-
-	for _, expense := range expenses {
-		// Update totals
-		totalExpenses += expense.Amount
-
-		if expense.Amount > highestExpense {
-			highestExpense = expense.Amount
+	var categoryBreakdown []CategoryBreakdown
+	categoryIndex := make(map[string]int)
+	for i, total := range categoryTotals {
+		totalExpenses += total.Total
+		transactionCount += total.Count
+		if i == 0 || total.Highest > highestExpense {
+			highestExpense = total.Highest
 		}
-
-		if expense.Amount < lowestExpense || lowestExpense == 0 {
-			lowestExpense = expense.Amount
+		if i == 0 || total.Lowest < lowestExpense {
+			lowestExpense = total.Lowest
 		}
 
-		// Category breakdown
-		categoryName := "Uncategorized"
-		if expense.CategoryID != nil {
-			cat, _ := u.categoryRepo.GetByID(ctx, *expense.CategoryID)
-			if cat != nil {
-				categoryName = cat.Name
-			}
+		name := categoryName(total.CategoryID)
+		if idx, ok := categoryIndex[name]; ok {
+			categoryBreakdown[idx].Total += total.Total
+			categoryBreakdown[idx].Count += total.Count
+			continue
 		}
+		categoryIndex[name] = len(categoryBreakdown)
+		categoryBreakdown = append(categoryBreakdown, CategoryBreakdown{Category: name, Total: total.Total, Count: total.Count})
+	}
+	for i := range categoryBreakdown {
+		if totalExpenses > 0 {
+			categoryBreakdown[i].Percentage = (categoryBreakdown[i].Total / totalExpenses) * 100
+		}
+	}
 
-		if _, ok := categoryMap[categoryName]; !ok {
-			categoryMap[categoryName] = &CategoryBreakdown{
-				Category: categoryName,
-				Total:    0,
-				Count:    0,
+	// Group members' tags with the same name share a breakdown entry
+	var tagBreakdown []TagBreakdown
+	if u.tagRepo != nil {
+		tagTotals, err := u.tagRepo.SumByTagAndDateRange(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum expenses by tag: %w", err)
+		}
+		tagIndex := make(map[string]int)
+		for _, total := range tagTotals {
+			if idx, ok := tagIndex[total.Name]; ok {
+				tagBreakdown[idx].Total += total.Total
+				tagBreakdown[idx].Count += total.Count
+				continue
 			}
+			tagIndex[total.Name] = len(tagBreakdown)
+			tagBreakdown = append(tagBreakdown, TagBreakdown{Tag: total.Name, Total: total.Total, Count: total.Count})
 		}
-
-		categoryMap[categoryName].Total += expense.Amount
-		categoryMap[categoryName].Count += 1
-
-		// Daily breakdown
-		dayKey := expense.ExpenseDate.Format("2006-01-02")
-		if _, ok := dailyMap[dayKey]; !ok {
-			dailyMap[dayKey] = &DailyBreakdown{
-				Date:   expense.ExpenseDate,
-				Total:  0,
-				Count:  0,
-				Amount: 0,
+		sort.SliceStable(tagBreakdown, func(i, j int) bool { return tagBreakdown[i].Total > tagBreakdown[j].Total })
+		for i := range tagBreakdown {
+			if totalExpenses > 0 {
+				tagBreakdown[i].Percentage = (tagBreakdown[i].Total / totalExpenses) * 100
 			}
 		}
-
-		dailyMap[dayKey].Total += expense.Amount
-		dailyMap[dayKey].Count += 1
-		dailyMap[dayKey].Amount += expense.Amount
-	}
-
-	// Convert maps to slices
-	var categoryBreakdown []CategoryBreakdown
-	for _, cb := range categoryMap {
-		cb.Percentage = 0
-		if totalExpenses > 0 {
-			cb.Percentage = (cb.Total / totalExpenses) * 100
+		if err := loadExpenseTags(ctx, u.tagRepo, expenses); err != nil {
+			return nil, err
 		}
-		categoryBreakdown = append(categoryBreakdown, *cb)
 	}
 
 	var dailyBreakdown []DailyBreakdown
-	for _, db := range dailyMap {
-		dailyBreakdown = append(dailyBreakdown, *db)
+	for _, total := range dailyTotals {
+		dailyBreakdown = append(dailyBreakdown, DailyBreakdown{
+			Date:   total.Start,
+			Total:  total.Total,
+			Count:  total.Count,
+			Amount: total.Total,
+		})
+	}
+
+	// Reports spanning several months also break spending down by month
+	var monthlyBreakdown []MonthlyBreakdown
+	if req.StartDate.Year() != req.EndDate.Year() || req.StartDate.Month() != req.EndDate.Month() {
+		monthlyTotals, err := u.expenseRepo.SumByPeriodAndDateRange(ctx, query, domain.TotalsPeriodMonth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sum expenses by month: %w", err)
+		}
+		for _, total := range monthlyTotals {
+			monthlyBreakdown = append(monthlyBreakdown, MonthlyBreakdown{
+				Month: total.Start.Format("2006-01"),
+				Total: total.Total,
+				Count: total.Count,
+			})
+		}
 	}
 
 	// Get all expenses (removed the 10 item limit for comprehensive expense list)
 	var topExpenses []ExpenseDetail
 	for _, expense := range expenses {
-		categoryName := "Uncategorized"
-		if expense.CategoryID != nil {
-			cat, _ := u.categoryRepo.GetByID(ctx, *expense.CategoryID)
-			if cat != nil {
-				categoryName = cat.Name
-			}
-		}
-
 		topExpenses = append(topExpenses, ExpenseDetail{
 			ID:          expense.ID,
 			Description: expense.Description,
 			Amount:      expense.Amount,
-			Category:    categoryName,
+			Category:    categoryName(expense.CategoryID),
+			Tags:        expense.Tags,
 			Date:        expense.ExpenseDate,
 			Account:     expense.Account,
 		})
@@ -189,25 +282,28 @@ func (u *GenerateReportUseCase) Execute(ctx context.Context, req *ReportRequest)
 
 	// Calculate average
 	avgExpense := 0.0
-	if len(expenses) > 0 {
-		avgExpense = totalExpenses / float64(len(expenses))
+	if transactionCount > 0 {
+		avgExpense = totalExpenses / float64(transactionCount)
 	}
 
 	period := u.formatPeriod(req.ReportType, req.StartDate, req.EndDate)
 
 	return &ExpenseReport{
 		UserID:            req.UserID,
+		GroupID:           req.GroupID,
 		ReportType:        req.ReportType,
 		Period:            period,
 		StartDate:         req.StartDate,
 		EndDate:           req.EndDate,
 		TotalExpenses:     totalExpenses,
-		TransactionCount:  len(expenses),
+		TransactionCount:  transactionCount,
 		AverageExpense:    avgExpense,
 		HighestExpense:    highestExpense,
 		LowestExpense:     lowestExpense,
 		CategoryBreakdown: categoryBreakdown,
+		TagBreakdown:      tagBreakdown,
 		DailyBreakdown:    dailyBreakdown,
+		MonthlyBreakdown:  monthlyBreakdown,
 		TopExpenses:       topExpenses,
 		GeneratedAt:       time.Now(),
 	}, nil
@@ -215,7 +311,7 @@ func (u *GenerateReportUseCase) Execute(ctx context.Context, req *ReportRequest)
 
 // GenerateMonthlyReport generates a monthly report for the current month
 func (u *GenerateReportUseCase) GenerateMonthlyReport(ctx context.Context, userID string) (*ExpenseReport, error) {
-	now := time.Now()
+	now := domain.LocalNow(ctx)
 	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 	endDate := startDate.AddDate(0, 1, -1).Add(24*time.Hour - time.Nanosecond)
 
@@ -229,7 +325,7 @@ func (u *GenerateReportUseCase) GenerateMonthlyReport(ctx context.Context, userI
 
 // GenerateWeeklyReport generates a weekly report for the current week
 func (u *GenerateReportUseCase) GenerateWeeklyReport(ctx context.Context, userID string) (*ExpenseReport, error) {
-	now := time.Now()
+	now := domain.LocalNow(ctx)
 	startDate := now.AddDate(0, 0, -int(now.Weekday()))
 	endDate := startDate.AddDate(0, 0, 7).Add(-time.Nanosecond)
 
@@ -243,7 +339,7 @@ func (u *GenerateReportUseCase) GenerateWeeklyReport(ctx context.Context, userID
 
 // GenerateDailyReport generates a daily report for today
 func (u *GenerateReportUseCase) GenerateDailyReport(ctx context.Context, userID string) (*ExpenseReport, error) {
-	now := time.Now()
+	now := domain.LocalNow(ctx)
 	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	endDate := startDate.Add(24*time.Hour - time.Nanosecond)
 
@@ -32,11 +32,20 @@ func NewGenerateReportLinkUseCase(baseURL string, shortLinkRepo domain.ShortLink
 	}
 }
 
+// reportTokenTTL is how long the token behind a report link is valid
+const reportTokenTTL = 7 * 24 * time.Hour
+
 func (u *GenerateReportLinkUseCase) Execute(userID string) (string, error) {
-	// 1. Generate JWT (valid for 15 min)
+	return u.ExecuteWithExpiry(userID, 5*time.Minute)
+}
+
+// ExecuteWithExpiry generates a report link that can be opened for ttl, for
+// links that aren't opened right away, such as those in pushed reports
+func (u *GenerateReportLinkUseCase) ExecuteWithExpiry(userID string, ttl time.Duration) (string, error) {
+	// 1. Generate JWT
 	claims := jwt.MapClaims{
 		"sub":  userID,
-		"exp":  time.Now().Add(7 * 24 * time.Hour).Unix(),
+		"exp":  time.Now().Add(max(reportTokenTTL, ttl)).Unix(),
 		"type": "report_access",
 	}
 
@@ -46,9 +55,9 @@ func (u *GenerateReportLinkUseCase) Execute(userID string) (string, error) {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
 
-	// 2. Generate Short Link (valid for 5 min)
+	// 2. Generate Short Link
 	shortID := generateShortID()
-	expiresAt := time.Now().Add(5 * time.Minute)
+	expiresAt := time.Now().Add(ttl)
 
 	shortLink := &domain.ShortLink{
 		ID:          shortID,
@@ -58,7 +67,6 @@ func (u *GenerateReportLinkUseCase) Execute(userID string) (string, error) {
 	}
 
 	if err := u.shortLinkRepo.Create(context.Background(), shortLink); err != nil {
-		fmt.Printf("Failed to save short link to repository: %v\n", err)
 		return "", fmt.Errorf("failed to create short link: %w", err)
 	}
 
@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
@@ -24,8 +27,86 @@ func NewGetExpensesUseCase(
 	}
 }
 
-// GetAllRequest represents a request to get all expenses
+// Expense listing page sizes
+const (
+	DefaultExpensePageSize = 50
+	MaxExpensePageSize     = 500
+)
+
+// GetAllRequest represents a request to get all expenses. Without any paging or
+// sorting fields every expense is returned, newest first.
 type GetAllRequest struct {
+	UserID  string
+	Limit   int
+	Offset  int
+	Cursor  string // NextCursor of the previous page; cannot be combined with Offset
+	SortBy  string // "date" (default), "amount" or "created_at"
+	SortDir string // "desc" (default) or "asc"
+}
+
+// Validate checks the paging and sorting fields
+func (r *GetAllRequest) Validate() error {
+	if !r.isPaged() {
+		return nil
+	}
+	_, err := r.listOptions()
+	return err
+}
+
+func (r *GetAllRequest) isPaged() bool {
+	return r.Limit != 0 || r.Offset != 0 || r.Cursor != "" || r.SortBy != "" || r.SortDir != ""
+}
+
+// listOptions validates the paging and sorting fields
+func (r *GetAllRequest) listOptions() (domain.ExpenseListOptions, error) {
+	opts := domain.ExpenseListOptions{
+		Limit:   r.Limit,
+		Offset:  r.Offset,
+		SortBy:  r.SortBy,
+		SortDir: r.SortDir,
+	}
+
+	switch {
+	case opts.Limit < 0 || opts.Limit > MaxExpensePageSize:
+		return opts, fmt.Errorf("limit must be between 1 and %d", MaxExpensePageSize)
+	case opts.Limit == 0:
+		opts.Limit = DefaultExpensePageSize
+	}
+	if opts.Offset < 0 {
+		return opts, fmt.Errorf("offset must not be negative")
+	}
+
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = domain.ExpenseSortDate
+	case domain.ExpenseSortDate, domain.ExpenseSortAmount, domain.ExpenseSortCreatedAt:
+	default:
+		return opts, fmt.Errorf("sort_by must be one of date, amount, created_at")
+	}
+	switch opts.SortDir {
+	case "":
+		opts.SortDir = domain.SortDesc
+	case domain.SortAsc, domain.SortDesc:
+	default:
+		return opts, fmt.Errorf("sort_dir must be asc or desc")
+	}
+
+	if r.Cursor != "" {
+		if opts.Offset != 0 {
+			return opts, fmt.Errorf("cursor and offset cannot be combined")
+		}
+		id, err := base64.RawURLEncoding.DecodeString(r.Cursor)
+		if err != nil || len(id) == 0 {
+			return opts, fmt.Errorf("invalid cursor")
+		}
+		opts.AfterID = string(id)
+	}
+	return opts, nil
+}
+
+// GetByIDRequest represents a request to get a single expense
+type GetByIDRequest struct {
+	ID     string
 	UserID string
 }
 
@@ -55,17 +136,25 @@ type ExpenseDTO struct {
 	CategoryName   *string
 	Date           time.Time
 	Account        string
+	Reimbursable   bool
+	ClaimStatus    string // Set for a reimbursable expense
 }
 
 // GetAllResponse represents the response for getting all expenses
 type GetAllResponse struct {
-	Expenses []*ExpenseDTO
-	Total    float64
-	Count    int
+	Expenses   []*ExpenseDTO
+	Total      float64
+	Count      int
+	NextCursor string // Set when a paged listing has more expenses
 }
 
-// ExecuteGetAll retrieves all expenses for a user
+// ExecuteGetAll retrieves all expenses for a user, or one page of them when
+// paging or sorting is requested
 func (u *GetExpensesUseCase) ExecuteGetAll(ctx context.Context, req *GetAllRequest) (*GetAllResponse, error) {
+	if req.isPaged() {
+		return u.executeGetPage(ctx, req)
+	}
+
 	expenses, err := u.expenseRepo.GetByUserID(ctx, req.UserID)
 	if err != nil {
 		return nil, err
@@ -74,6 +163,50 @@ func (u *GetExpensesUseCase) ExecuteGetAll(ctx context.Context, req *GetAllReque
 	return u.buildResponse(ctx, expenses)
 }
 
+// executeGetPage retrieves one page of expenses, fetching one extra row to tell
+// whether another page follows
+func (u *GetExpensesUseCase) executeGetPage(ctx context.Context, req *GetAllRequest) (*GetAllResponse, error) {
+	opts, err := req.listOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := opts.Limit
+	opts.Limit++
+	expenses, err := u.expenseRepo.ListByUserID(ctx, req.UserID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expenses: %w", err)
+	}
+
+	hasMore := len(expenses) > pageSize
+	if hasMore {
+		expenses = expenses[:pageSize]
+	}
+
+	resp, err := u.buildResponse(ctx, expenses)
+	if err != nil {
+		return nil, err
+	}
+	if hasMore {
+		resp.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(expenses[len(expenses)-1].ID))
+	}
+	return resp, nil
+}
+
+// ExecuteGetByID retrieves a single expense owned by the user
+func (u *GetExpensesUseCase) ExecuteGetByID(ctx context.Context, req *GetByIDRequest) (*ExpenseDTO, error) {
+	expense, err := u.expenseRepo.GetByID(ctx, req.ID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+
+	if err != nil || expense.UserID != req.UserID {
+		return nil, fmt.Errorf("expense %w", domain.ErrNotFound)
+	}
+
+	return u.toDTO(ctx, expense), nil
+}
+
 // ExecuteGetByDateRange retrieves expenses within a date range
 func (u *GetExpensesUseCase) ExecuteGetByDateRange(ctx context.Context, req *GetByDateRangeRequest) (*GetAllResponse, error) {
 	expenses, err := u.expenseRepo.GetByUserIDAndDateRange(ctx, req.UserID, req.From, req.To)
@@ -100,28 +233,7 @@ func (u *GetExpensesUseCase) buildResponse(ctx context.Context, expenses []*doma
 	var total float64
 
 	for _, expense := range expenses {
-		var categoryName *string
-		if expense.CategoryID != nil {
-			category, _ := u.categoryRepo.GetByID(ctx, *expense.CategoryID)
-			if category != nil {
-				categoryName = &category.Name
-			}
-		}
-
-		dto := &ExpenseDTO{
-			ID:             expense.ID,
-			Description:    expense.Description,
-			Amount:         expense.Amount,
-			OriginalAmount: expense.OriginalAmount,
-			Currency:       expense.Currency,
-			HomeCurrency:   expense.HomeCurrency,
-			ExchangeRate:   expense.ExchangeRate,
-			CategoryID:     expense.CategoryID,
-			CategoryName:   categoryName,
-			Date:           expense.ExpenseDate,
-			Account:        expense.Account,
-		}
-		dtos = append(dtos, dto)
+		dtos = append(dtos, u.toDTO(ctx, expense))
 		total += expense.Amount
 	}
 
@@ -131,3 +243,29 @@ func (u *GetExpensesUseCase) buildResponse(ctx context.Context, expenses []*doma
 		Count:    len(dtos),
 	}, nil
 }
+
+func (u *GetExpensesUseCase) toDTO(ctx context.Context, expense *domain.Expense) *ExpenseDTO {
+	var categoryName *string
+	if expense.CategoryID != nil {
+		category, _ := u.categoryRepo.GetByID(ctx, *expense.CategoryID)
+		if category != nil {
+			categoryName = &category.Name
+		}
+	}
+
+	return &ExpenseDTO{
+		ID:             expense.ID,
+		Description:    expense.Description,
+		Amount:         expense.Amount,
+		OriginalAmount: expense.OriginalAmount,
+		Currency:       expense.Currency,
+		HomeCurrency:   expense.HomeCurrency,
+		ExchangeRate:   expense.ExchangeRate,
+		CategoryID:     expense.CategoryID,
+		CategoryName:   categoryName,
+		Date:           expense.ExpenseDate,
+		Account:        expense.Account,
+		Reimbursable:   expense.Reimbursable,
+		ClaimStatus:    expense.ClaimStatus,
+	}
+}
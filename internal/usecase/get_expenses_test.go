@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func newGetExpensesTestUseCase(t *testing.T) *GetExpensesUseCase {
+	t.Helper()
+	expenseRepo := NewMockExpenseRepository()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 5; i++ {
+		expenseRepo.Create(context.Background(), &domain.Expense{
+			ID:          fmt.Sprintf("e%d", i),
+			UserID:      "user1",
+			Description: fmt.Sprintf("Expense %d", i),
+			Amount:      float64(60 - i*10),
+			HomeAmount:  float64(60 - i*10),
+			ExpenseDate: base.AddDate(0, 0, i),
+			CreatedAt:   base,
+		})
+	}
+	return NewGetExpensesUseCase(expenseRepo, NewMockCategoryRepository())
+}
+
+func expenseIDs(resp *GetAllResponse) []string {
+	ids := make([]string, 0, len(resp.Expenses))
+	for _, exp := range resp.Expenses {
+		ids = append(ids, exp.ID)
+	}
+	return ids
+}
+
+func TestGetExpensesPagination(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Unpaged returns everything", func(t *testing.T) {
+		uc := newGetExpensesTestUseCase(t)
+		resp, err := uc.ExecuteGetAll(ctx, &GetAllRequest{UserID: "user1"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Count != 5 || resp.NextCursor != "" {
+			t.Errorf("expected all 5 expenses without cursor, got %d (cursor %q)", resp.Count, resp.NextCursor)
+		}
+	})
+
+	t.Run("Cursor walks pages newest first", func(t *testing.T) {
+		uc := newGetExpensesTestUseCase(t)
+		var got []string
+		cursor := ""
+		for page := 0; page < 5; page++ {
+			resp, err := uc.ExecuteGetAll(ctx, &GetAllRequest{UserID: "user1", Limit: 2, Cursor: cursor})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, expenseIDs(resp)...)
+			if resp.NextCursor == "" {
+				break
+			}
+			cursor = resp.NextCursor
+		}
+		want := fmt.Sprint([]string{"e5", "e4", "e3", "e2", "e1"})
+		if fmt.Sprint(got) != want {
+			t.Errorf("expected %s, got %v", want, got)
+		}
+	})
+
+	t.Run("Offset with sort by amount ascending", func(t *testing.T) {
+		uc := newGetExpensesTestUseCase(t)
+		resp, err := uc.ExecuteGetAll(ctx, &GetAllRequest{UserID: "user1", Limit: 2, Offset: 1, SortBy: "amount", SortDir: "asc"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := fmt.Sprint(expenseIDs(resp)); got != "[e4 e3]" {
+			t.Errorf("expected [e4 e3], got %s", got)
+		}
+		if resp.NextCursor == "" {
+			t.Error("expected a next cursor")
+		}
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		uc := newGetExpensesTestUseCase(t)
+		for name, req := range map[string]*GetAllRequest{
+			"limit too large":  {UserID: "user1", Limit: MaxExpensePageSize + 1},
+			"negative offset":  {UserID: "user1", Offset: -1},
+			"unknown sort_by":  {UserID: "user1", SortBy: "description"},
+			"unknown sort_dir": {UserID: "user1", SortDir: "up"},
+			"bad cursor":       {UserID: "user1", Cursor: "!!"},
+			"cursor + offset":  {UserID: "user1", Cursor: "ZTE", Offset: 2},
+		} {
+			if err := req.Validate(); err == nil {
+				t.Errorf("%s: expected validation error", name)
+			}
+			if _, err := uc.ExecuteGetAll(ctx, req); err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+		}
+	})
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/riverlin/aiexpense/internal/domain"
@@ -22,11 +23,11 @@ func NewGetPolicyUseCase(policyRepo domain.PolicyRepository) *GetPolicyUseCase {
 // Execute retrieves a policy by key
 func (uc *GetPolicyUseCase) Execute(ctx context.Context, key string) (*domain.Policy, error) {
 	policy, err := uc.policyRepo.GetByKey(ctx, key)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("policy %w", domain.ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get policy: %w", err)
 	}
-	if policy == nil {
-		return nil, nil // Policy not found
-	}
 	return policy, nil
 }
@@ -318,20 +318,26 @@ func TestScenario_ListExpensesByDateRange(t *testing.T) {
 
 // TestScenario_UpdateExpense tests the scenario:
 // Scenario 3: Update expense
-// [ ] WHEN user modifies existing expense
-// [ ] THEN system updates record and recalculates metrics
-// [ ] AND maintains audit trail of changes
+// [x] WHEN user modifies existing expense
+// [-] THEN system updates record and recalculates metrics
+// [x] AND maintains audit trail of changes
 func TestScenario_UpdateExpense(t *testing.T) {
 	expenseRepo := NewMockExpenseRepository()
-	ctx := context.Background()
+	categoryRepo := NewMockCategoryRepository()
+	auditRepo := NewMockExpenseAuditRepository()
+	ctx := domain.WithAuditSource(context.Background(), domain.AuditSource{Actor: "user_123", Channel: "line"})
 	userID := "user_123"
 
+	updateUC := NewUpdateExpenseUseCase(expenseRepo, categoryRepo)
+	updateUC.SetAuditRepository(auditRepo)
+
 	// Create initial expense
 	original := &domain.Expense{
 		ID:          uuid.New().String(),
 		UserID:      userID,
 		Description: "breakfast",
 		Amount:      20.00,
+		HomeAmount:  20.00,
 		ExpenseDate: time.Now(),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -341,12 +347,16 @@ func TestScenario_UpdateExpense(t *testing.T) {
 	// WHEN user modifies existing expense
 	time.Sleep(10 * time.Millisecond) // Ensure UpdatedAt is different
 	originalCreatedAt := original.CreatedAt
-	original.Description = "breakfast + coffee"
-	original.Amount = 25.00
-	original.UpdatedAt = time.Now()
+	description := "breakfast + coffee"
+	amount := 25.00
 
 	// THEN system updates record
-	err := expenseRepo.Update(ctx, original)
+	_, err := updateUC.Execute(ctx, &UpdateRequest{
+		ID:          original.ID,
+		UserID:      userID,
+		Description: &description,
+		Amount:      &amount,
+	})
 	if err != nil {
 		t.Fatalf("failed to update expense: %v", err)
 	}
@@ -360,13 +370,31 @@ func TestScenario_UpdateExpense(t *testing.T) {
 		t.Errorf("update failed: expected amount 25.00, got %f", updated.Amount)
 	}
 
-	// AND maintains audit trail of changes (CreatedAt should not change)
+	// AND maintains audit trail of changes
 	if !updated.CreatedAt.Equal(originalCreatedAt) {
 		t.Error("CreatedAt should not change on update")
 	}
 	if !updated.UpdatedAt.After(originalCreatedAt) {
 		t.Error("UpdatedAt should be after CreatedAt")
 	}
+
+	history, err := auditRepo.GetByExpenseID(ctx, original.ID)
+	if err != nil {
+		t.Fatalf("failed to retrieve audit trail: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(history))
+	}
+	entry := history[0]
+	if entry.Action != domain.ExpenseAuditUpdate || entry.Actor != userID || entry.Channel != "line" {
+		t.Errorf("expected update by %s via line, got %s by %s via %s", userID, entry.Action, entry.Actor, entry.Channel)
+	}
+	if entry.Before.Description != "breakfast" || entry.Before.HomeAmount != 20.00 {
+		t.Errorf("expected before snapshot breakfast 20, got %s %f", entry.Before.Description, entry.Before.HomeAmount)
+	}
+	if entry.After.Description != "breakfast + coffee" || entry.After.HomeAmount != 25.00 {
+		t.Errorf("expected after snapshot breakfast + coffee 25, got %s %f", entry.After.Description, entry.After.HomeAmount)
+	}
 }
 
 // TestScenario_DeleteExpense tests the scenario:
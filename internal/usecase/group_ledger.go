@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// GroupLedgerUseCase manages shared group ledgers, e.g. a family LINE group
+// recording into one household book
+type GroupLedgerUseCase struct {
+	groupRepo domain.GroupRepository
+}
+
+// NewGroupLedgerUseCase creates a new group ledger use case
+func NewGroupLedgerUseCase(groupRepo domain.GroupRepository) *GroupLedgerUseCase {
+	return &GroupLedgerUseCase{
+		groupRepo: groupRepo,
+	}
+}
+
+// EnsureGroup returns the ledger bound to a messenger group chat, creating it on
+// first use and enrolling userID as a member. The creator becomes the owner.
+func (u *GroupLedgerUseCase) EnsureGroup(ctx context.Context, messengerType, externalID, name, userID string) (*domain.Group, error) {
+	if externalID == "" {
+		return nil, fmt.Errorf("group chat id is required")
+	}
+
+	group, err := u.groupRepo.GetByExternalID(ctx, messengerType, externalID)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	role := domain.GroupRoleMember
+	if group == nil {
+		if name == "" {
+			name = fmt.Sprintf("%s group", messengerType)
+		}
+		group = &domain.Group{
+			ID:            uuid.New().String(),
+			Name:          name,
+			MessengerType: messengerType,
+			ExternalID:    externalID,
+			CreatedAt:     time.Now(),
+		}
+		if err := u.groupRepo.Create(ctx, group); err != nil {
+			return nil, fmt.Errorf("failed to create group: %w", err)
+		}
+		role = domain.GroupRoleOwner
+	}
+
+	member := &domain.GroupMember{
+		GroupID:  group.ID,
+		UserID:   userID,
+		Role:     role,
+		JoinedAt: time.Now(),
+	}
+	if err := u.groupRepo.AddMember(ctx, member); err != nil {
+		return nil, fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	return group, nil
+}
+
+// ListGroups returns the groups a user belongs to
+func (u *GroupLedgerUseCase) ListGroups(ctx context.Context, userID string) ([]*domain.Group, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	groups, err := u.groupRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	return groups, nil
+}
+
+// ListMembers returns the members of a group the user belongs to
+func (u *GroupLedgerUseCase) ListMembers(ctx context.Context, groupID, userID string) ([]*domain.GroupMember, error) {
+	if err := requireGroupMember(ctx, u.groupRepo, groupID, userID); err != nil {
+		return nil, err
+	}
+	members, err := u.groupRepo.GetMembers(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group members: %w", err)
+	}
+	return members, nil
+}
+
+// requireGroupMember rejects access to a group ledger by anyone outside the group
+func requireGroupMember(ctx context.Context, groupRepo domain.GroupRepository, groupID, userID string) error {
+	if groupRepo == nil {
+		return fmt.Errorf("group ledgers are not enabled")
+	}
+	isMember, err := groupRepo.IsMember(ctx, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check group membership: %w", err)
+	}
+	if !isMember {
+		return fmt.Errorf("group %w", domain.ErrNotFound)
+	}
+	return nil
+}
+
+// getVisibleExpense loads an expense the user may see: their own, or one recorded
+// in a group they belong to
+func getVisibleExpense(ctx context.Context, expenseRepo domain.ExpenseRepository, groupRepo domain.GroupRepository, expenseID, userID string) (*domain.Expense, error) {
+	if expenseID == "" || userID == "" {
+		return nil, fmt.Errorf("expense_id and user_id are required")
+	}
+
+	expense, err := expenseRepo.GetByID(ctx, expenseID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("expense %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+
+	if expense.UserID != userID {
+		if expense.GroupID == nil || requireGroupMember(ctx, groupRepo, *expense.GroupID, userID) != nil {
+			return nil, fmt.Errorf("expense %w", domain.ErrNotFound)
+		}
+	}
+	return expense, nil
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestGroupLedgerEnsureGroup(t *testing.T) {
+	ctx := context.Background()
+	uc := NewGroupLedgerUseCase(NewMockGroupRepository())
+
+	group, err := uc.EnsureGroup(ctx, "line", "C123", "Family", "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	again, err := uc.EnsureGroup(ctx, "line", "C123", "Family", "user2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.ID != group.ID {
+		t.Errorf("expected the same group for the same chat, got %s and %s", group.ID, again.ID)
+	}
+
+	// Repeat messages from an existing member do not duplicate the membership
+	uc.EnsureGroup(ctx, "line", "C123", "Family", "user1")
+
+	members, err := uc.ListMembers(ctx, group.ID, "user2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(members) != 2 {
+		t.Fatalf("expected 2 members, got %d", len(members))
+	}
+	if members[0].UserID != "user1" || members[0].Role != domain.GroupRoleOwner || members[1].Role != domain.GroupRoleMember {
+		t.Errorf("unexpected roles: %+v, %+v", members[0], members[1])
+	}
+
+	if _, err := uc.ListMembers(ctx, group.ID, "stranger"); err == nil {
+		t.Error("expected non-member to be rejected")
+	}
+
+	other, _ := uc.EnsureGroup(ctx, "telegram", "C123", "", "user1")
+	if other.ID == group.ID {
+		t.Error("expected chats on different messengers to get separate groups")
+	}
+
+	groups, _ := uc.ListGroups(ctx, "user1")
+	if len(groups) != 2 {
+		t.Errorf("expected user1 in 2 groups, got %d", len(groups))
+	}
+}
@@ -0,0 +1,154 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Statuses of a component of the readiness check
+const (
+	ComponentStatusOK      = "ok"
+	ComponentStatusFailing = "failing"
+)
+
+// Statuses of the readiness check
+const (
+	ReadinessReady    = "ready"
+	ReadinessDegraded = "degraded" // An optional component is failing, and the server still serves
+	ReadinessNotReady = "not_ready"
+)
+
+// DefaultAIReachabilityTTL is how long a check of the AI provider is reused,
+// so probes every few seconds don't each call the provider
+const DefaultAIReachabilityTTL = time.Minute
+
+// healthCheckTimeout bounds each component check of a probe
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck is a component the readiness check verifies
+type HealthCheck struct {
+	Name     string
+	Check    func(ctx context.Context) error
+	Optional bool          // A failure degrades readiness instead of failing it
+	CacheFor time.Duration // Reuse a result this long, for checks that call out
+}
+
+// ComponentHealth is the result of checking one component
+type ComponentHealth struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Required  bool      `json:"required"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached,omitempty"`
+}
+
+// ReadinessResponse is the result of the readiness check
+type ReadinessResponse struct {
+	Status     string             `json:"status"`
+	CheckedAt  time.Time          `json:"checked_at"`
+	Components []*ComponentHealth `json:"components"`
+}
+
+// LivenessResponse is the result of the liveness check
+type LivenessResponse struct {
+	Status    string    `json:"status"`
+	StartedAt time.Time `json:"started_at"`
+	Uptime    string    `json:"uptime"`
+}
+
+// registeredCheck is a component check and its last result
+type registeredCheck struct {
+	HealthCheck
+	mu   sync.Mutex
+	last *ComponentHealth
+}
+
+// HealthUseCase answers the liveness and readiness probes of orchestrators
+// such as Kubernetes. Liveness only says the process serves; readiness checks
+// the components added to it.
+type HealthUseCase struct {
+	checks    []*registeredCheck
+	startedAt time.Time
+	now       func() time.Time
+}
+
+// NewHealthUseCase creates a new health use case
+func NewHealthUseCase() *HealthUseCase {
+	return &HealthUseCase{startedAt: time.Now(), now: time.Now}
+}
+
+// AddCheck adds a component to the readiness check
+func (u *HealthUseCase) AddCheck(check HealthCheck) {
+	u.checks = append(u.checks, &registeredCheck{HealthCheck: check})
+}
+
+// Liveness reports that the server is up
+func (u *HealthUseCase) Liveness() *LivenessResponse {
+	return &LivenessResponse{
+		Status:    "ok",
+		StartedAt: u.startedAt,
+		Uptime:    u.now().Sub(u.startedAt).Round(time.Second).String(),
+	}
+}
+
+// Readiness checks every component at once. The server is not ready when a
+// required component fails, and degraded when only optional ones do.
+func (u *HealthUseCase) Readiness(ctx context.Context) *ReadinessResponse {
+	components := make([]*ComponentHealth, len(u.checks))
+	var wg sync.WaitGroup
+	for i, check := range u.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			components[i] = u.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	status := ReadinessReady
+	for _, component := range components {
+		if component.Status == ComponentStatusOK {
+			continue
+		}
+		if component.Required {
+			status = ReadinessNotReady
+			break
+		}
+		status = ReadinessDegraded
+	}
+	return &ReadinessResponse{Status: status, CheckedAt: u.now(), Components: components}
+}
+
+// run checks a component, or reuses its last result while that is fresh.
+// Concurrent probes wait for a check in progress rather than starting another.
+func (u *HealthUseCase) run(ctx context.Context, check *registeredCheck) *ComponentHealth {
+	check.mu.Lock()
+	defer check.mu.Unlock()
+
+	if check.last != nil && check.CacheFor > 0 && u.now().Sub(check.last.CheckedAt) < check.CacheFor {
+		cached := *check.last
+		cached.Cached = true
+		return &cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	started := u.now()
+	err := check.Check(ctx)
+	result := &ComponentHealth{
+		Name:      check.Name,
+		Status:    ComponentStatusOK,
+		Required:  !check.Optional,
+		LatencyMS: u.now().Sub(started).Milliseconds(),
+		CheckedAt: started,
+	}
+	if err != nil {
+		result.Status = ComponentStatusFailing
+		result.Error = err.Error()
+	}
+	check.last = result
+	return result
+}
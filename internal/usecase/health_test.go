@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthUseCase_Readiness(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	var dbErr, aiErr error
+	var aiCalls int32
+
+	uc := NewHealthUseCase()
+	uc.now = func() time.Time { return now }
+	uc.AddCheck(HealthCheck{Name: "database", Check: func(ctx context.Context) error { return dbErr }})
+	uc.AddCheck(HealthCheck{Name: "ai_provider", Optional: true, CacheFor: time.Minute, Check: func(ctx context.Context) error {
+		atomic.AddInt32(&aiCalls, 1)
+		return aiErr
+	}})
+
+	resp := uc.Readiness(ctx)
+	assert.Equal(t, ReadinessReady, resp.Status)
+	require.Len(t, resp.Components, 2)
+	assert.Equal(t, "database", resp.Components[0].Name)
+	assert.True(t, resp.Components[0].Required)
+	assert.Equal(t, ComponentStatusOK, resp.Components[1].Status)
+	assert.False(t, resp.Components[1].Cached)
+
+	// The AI check is reused until it expires, failures included
+	aiErr = errors.New("API error 503")
+	resp = uc.Readiness(ctx)
+	assert.Equal(t, ReadinessReady, resp.Status)
+	assert.True(t, resp.Components[1].Cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&aiCalls))
+
+	now = now.Add(time.Minute)
+	resp = uc.Readiness(ctx)
+	assert.Equal(t, ReadinessDegraded, resp.Status, "an optional component only degrades readiness")
+	assert.Equal(t, ComponentStatusFailing, resp.Components[1].Status)
+	assert.Equal(t, "API error 503", resp.Components[1].Error)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&aiCalls))
+
+	dbErr = errors.New("connection refused")
+	resp = uc.Readiness(ctx)
+	assert.Equal(t, ReadinessNotReady, resp.Status)
+	assert.Equal(t, "connection refused", resp.Components[0].Error)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&aiCalls))
+}
+
+func TestHealthUseCase_Liveness(t *testing.T) {
+	uc := NewHealthUseCase()
+	uc.now = func() time.Time { return uc.startedAt.Add(90 * time.Second) }
+	resp := uc.Liveness()
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, "1m30s", resp.Uptime)
+}
+
+func TestSchedulerMonitor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	monitor := NewSchedulerMonitor()
+	done := make(chan struct{})
+
+	monitor.Go(ctx, "daily_digest", func(ctx context.Context) { <-ctx.Done() })
+	monitor.Go(ctx, "weekly_report", func(ctx context.Context) { close(done) })
+	<-done
+
+	require.Eventually(t, func() bool { return !monitor.Running()["weekly_report"] }, time.Second, time.Millisecond)
+	assert.True(t, monitor.Running()["daily_digest"])
+	assert.EqualError(t, monitor.Check(ctx), "schedulers stopped: weekly_report")
+}
@@ -0,0 +1,542 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Import file formats
+const (
+	ImportFormatCSV = "csv"
+	ImportFormatOFX = "ofx"
+)
+
+// MaxImportRows is the most transactions a single import may create
+const MaxImportRows = 1000
+
+// DefaultImportProfile is the mapping used when a request names no profile
+const DefaultImportProfile = "default"
+
+// ImportProfile maps the columns of a bank's CSV statement to expense fields.
+// Columns are matched by header name, ignoring case; a column left empty is
+// found by common header names such as "Date", "Amount" or "交易日期".
+type ImportProfile struct {
+	Name              string `json:"name"`
+	DateColumn        string `json:"date_column"`
+	DescriptionColumn string `json:"description_column"`
+	AmountColumn      string `json:"amount_column"`   // Signed amount; see ExpensesPositive
+	DebitColumn       string `json:"debit_column"`    // Money out, for statements with separate debit/credit columns
+	CurrencyColumn    string `json:"currency_column"` // Optional; defaults to the user's home currency
+	DateFormat        string `json:"date_format"`     // Go time layout; common layouts are tried when empty
+	Delimiter         string `json:"delimiter"`       // Detected from the header when empty
+	SkipRows          int    `json:"skip_rows"`       // Lines before the header row, e.g. account details
+	ExpensesPositive  bool   `json:"expenses_positive"`
+}
+
+// importColumnAliases are the header names tried for columns a profile leaves empty
+var importColumnAliases = map[string][]string{
+	"date":        {"date", "transaction date", "posted date", "posting date", "booking date", "日期", "交易日期", "記帳日", "记账日"},
+	"description": {"description", "memo", "payee", "details", "narrative", "name", "說明", "说明", "摘要", "備註", "备注", "交易說明"},
+	"amount":      {"amount", "transaction amount", "金額", "金额", "交易金額"},
+	"debit":       {"debit", "withdrawal", "withdrawals", "money out", "支出", "提款"},
+	"currency":    {"currency", "幣別", "币别", "幣種", "币种"},
+}
+
+// importDateLayouts are tried in order when a profile sets no date format
+var importDateLayouts = []string{
+	"2006-01-02", "2006/01/02", "2006.01.02", "20060102", "2006-01-02 15:04:05", "2006/01/02 15:04:05",
+	"01/02/2006", "1/2/2006", "02.01.2006", "Jan 2, 2006", "2 Jan 2006",
+}
+
+// importAmountCleaner strips currency symbols, thousands separators and spaces from amounts
+var importAmountCleaner = regexp.MustCompile(`[^0-9.\-()]`)
+
+// ImportUseCase imports bank statements, creating an expense for each outgoing
+// transaction. Transactions already recorded, i.e. an existing expense on the
+// same day for the same amount, are skipped. Categories are suggested by the AI
+// as for expenses sent in chat.
+type ImportUseCase struct {
+	expenseRepo   domain.ExpenseRepository
+	createExpense CreateExpense
+	profiles      map[string]ImportProfile
+}
+
+// NewImportUseCase creates a new import use case
+func NewImportUseCase(expenseRepo domain.ExpenseRepository, createExpense CreateExpense) *ImportUseCase {
+	return &ImportUseCase{
+		expenseRepo:   expenseRepo,
+		createExpense: createExpense,
+		profiles:      map[string]ImportProfile{DefaultImportProfile: {Name: DefaultImportProfile}},
+	}
+}
+
+// SetProfiles adds named mapping profiles, replacing any with the same name
+func (u *ImportUseCase) SetProfiles(profiles []ImportProfile) {
+	for _, profile := range profiles {
+		u.profiles[strings.ToLower(profile.Name)] = profile
+	}
+}
+
+// ParseImportProfiles reads mapping profiles from a JSON array
+func ParseImportProfiles(data []byte) ([]ImportProfile, error) {
+	var profiles []ImportProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse import profiles: %w", err)
+	}
+	for i, profile := range profiles {
+		if profile.Name == "" {
+			return nil, fmt.Errorf("import profile %d has no name", i+1)
+		}
+	}
+	return profiles, nil
+}
+
+// ImportRequest represents a request to import a bank statement
+type ImportRequest struct {
+	UserID  string
+	Data    []byte
+	Format  string         // "csv" or "ofx"; detected from the content when empty
+	Profile string         // Name of a configured mapping profile for CSV files
+	Mapping *ImportProfile // Explicit CSV mapping; takes precedence over Profile
+	Account string         // Account to record the expenses against, e.g. the card name
+}
+
+// ImportedExpense is an expense created by an import
+type ImportedExpense struct {
+	ID          string  `json:"id"`
+	Date        string  `json:"date"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Category    string  `json:"category"`
+}
+
+// ImportRowError is a transaction that could not be imported
+type ImportRowError struct {
+	Row   int    `json:"row"` // CSV line number, or the transaction's position in an OFX file
+	Error string `json:"error"`
+}
+
+// ImportResult summarizes an import
+type ImportResult struct {
+	Imported   []ImportedExpense `json:"imported"`
+	Duplicates int               `json:"duplicates"` // Already recorded
+	Skipped    int               `json:"skipped"`    // Not spending, e.g. deposits and refunds
+	Errors     []ImportRowError  `json:"errors"`
+}
+
+// importRow is one outgoing transaction read from a statement
+type importRow struct {
+	row         int
+	date        time.Time
+	description string
+	amount      float64
+	currency    string
+}
+
+// Execute imports a statement and reports what was created
+func (u *ImportUseCase) Execute(ctx context.Context, req *ImportRequest) (*ImportResult, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	data := bytes.TrimPrefix(req.Data, []byte("\xef\xbb\xbf"))
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = detectImportFormat(data)
+	}
+
+	result := &ImportResult{Imported: []ImportedExpense{}, Errors: []ImportRowError{}}
+	var rows []importRow
+	var err error
+	switch format {
+	case ImportFormatCSV:
+		profile := req.Mapping
+		if profile == nil {
+			name := req.Profile
+			if name == "" {
+				name = DefaultImportProfile
+			}
+			p, ok := u.profiles[strings.ToLower(name)]
+			if !ok {
+				return nil, fmt.Errorf("unknown import profile: %s", name)
+			}
+			profile = &p
+		}
+		rows, err = parseImportCSV(data, profile, result)
+	case ImportFormatOFX:
+		rows, err = parseImportOFX(data, result)
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", req.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxImportRows {
+		return nil, fmt.Errorf("file has %d transactions; at most %d can be imported at once", len(rows), MaxImportRows)
+	}
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	recorded, err := u.recordedExpenses(ctx, req.UserID, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = domain.WithAuditSource(ctx, domain.AuditSource{Actor: req.UserID, Channel: domain.AuditChannelImport})
+	for _, row := range rows {
+		key := importDedupKey(row.date, row.amount)
+		if recorded[key] > 0 {
+			recorded[key]--
+			result.Duplicates++
+			continue
+		}
+
+		resp, err := u.createExpense.Execute(ctx, &CreateRequest{
+			UserID:      req.UserID,
+			Description: row.description,
+			Amount:      row.amount,
+			Currency:    row.currency,
+			Account:     req.Account,
+			Date:        row.date,
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to import row", "row", row.row, "user_id", req.UserID, "error", err)
+			result.Errors = append(result.Errors, ImportRowError{Row: row.row, Error: "failed to create expense"})
+			continue
+		}
+		result.Imported = append(result.Imported, ImportedExpense{
+			ID:          resp.ID,
+			Date:        row.date.Format("2006-01-02"),
+			Description: row.description,
+			Amount:      resp.OriginalAmount,
+			Currency:    resp.Currency,
+			Category:    resp.Category,
+		})
+	}
+
+	return result, nil
+}
+
+// recordedExpenses counts the user's existing expenses in the statement's date
+// range by day and amount. Each one can only match a single imported row, so
+// two identical coffees on a statement still import once one is recorded.
+func (u *ImportUseCase) recordedExpenses(ctx context.Context, userID string, rows []importRow) (map[string]int, error) {
+	from, to := rows[0].date, rows[0].date
+	for _, row := range rows {
+		if row.date.Before(from) {
+			from = row.date
+		}
+		if row.date.After(to) {
+			to = row.date
+		}
+	}
+
+	expenses, err := u.expenseRepo.GetByUserIDAndDateRange(ctx, userID, from, to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing expenses: %w", err)
+	}
+	recorded := make(map[string]int)
+	for _, expense := range expenses {
+		recorded[importDedupKey(expense.ExpenseDate, expense.OriginalAmount)]++
+	}
+	return recorded, nil
+}
+
+func importDedupKey(date time.Time, amount float64) string {
+	return fmt.Sprintf("%s|%d", date.Format("2006-01-02"), int64(math.Round(amount*100)))
+}
+
+// detectImportFormat recognizes OFX files by their header; anything else is read as CSV
+func detectImportFormat(data []byte) string {
+	head := strings.ToUpper(string(data[:min(len(data), 1024)]))
+	if strings.Contains(head, "OFXHEADER") || strings.Contains(head, "<OFX>") {
+		return ImportFormatOFX
+	}
+	return ImportFormatCSV
+}
+
+// parseImportCSV reads the outgoing transactions from a CSV statement, noting
+// skipped and invalid rows in result
+func parseImportCSV(data []byte, profile *ImportProfile, result *ImportResult) ([]importRow, error) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if profile.SkipRows >= len(lines) {
+		return nil, fmt.Errorf("file has no header row")
+	}
+	data = bytes.Join(lines[profile.SkipRows:], nil)
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = importDelimiter(profile.Delimiter, lines[profile.SkipRows])
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := map[string]string{
+		"date":        profile.DateColumn,
+		"description": profile.DescriptionColumn,
+		"amount":      profile.AmountColumn,
+		"debit":       profile.DebitColumn,
+		"currency":    profile.CurrencyColumn,
+	}
+	index := make(map[string]int)
+	for field, name := range columns {
+		index[field] = importColumnIndex(header, name, importColumnAliases[field])
+	}
+	if profile.DebitColumn != "" && profile.AmountColumn == "" {
+		// An explicit debit column wins over a detected amount column
+		index["amount"] = -1
+	}
+	for _, field := range []string{"date", "description"} {
+		if index[field] < 0 {
+			return nil, fmt.Errorf("CSV has no %s column", field)
+		}
+	}
+	if index["amount"] < 0 && index["debit"] < 0 {
+		return nil, fmt.Errorf("CSV has no amount or debit column")
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		fieldLine, _ := reader.FieldPos(0)
+		line := profile.SkipRows + fieldLine
+		if err != nil {
+			result.Errors = append(result.Errors, ImportRowError{Row: line, Error: err.Error()})
+			continue
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		field := func(name string) string {
+			if i := index[name]; i >= 0 && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		date, err := parseImportDate(field("date"), profile.DateFormat)
+		if err != nil {
+			result.Errors = append(result.Errors, ImportRowError{Row: line, Error: fmt.Sprintf("invalid date %q", field("date"))})
+			continue
+		}
+
+		var amount float64
+		if index["amount"] >= 0 {
+			raw := field("amount")
+			if amount, err = parseImportAmount(raw); err != nil {
+				result.Errors = append(result.Errors, ImportRowError{Row: line, Error: fmt.Sprintf("invalid amount %q", raw)})
+				continue
+			}
+			if !profile.ExpensesPositive {
+				amount = -amount
+			}
+		} else if raw := field("debit"); raw != "" {
+			if amount, err = parseImportAmount(raw); err != nil {
+				result.Errors = append(result.Errors, ImportRowError{Row: line, Error: fmt.Sprintf("invalid amount %q", raw)})
+				continue
+			}
+			amount = math.Abs(amount)
+		}
+		if amount <= 0 {
+			result.Skipped++
+			continue
+		}
+
+		description := field("description")
+		if description == "" {
+			description = "Imported transaction"
+		}
+		rows = append(rows, importRow{
+			row:         line,
+			date:        date,
+			description: description,
+			amount:      amount,
+			currency:    strings.ToUpper(field("currency")),
+		})
+	}
+	return rows, nil
+}
+
+// importDelimiter returns the profile's delimiter, or the one used most in the header line
+func importDelimiter(configured string, header []byte) rune {
+	if configured == `\t` {
+		return '\t'
+	}
+	if configured != "" {
+		return []rune(configured)[0]
+	}
+	best, bestCount := ',', 0
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		if count := strings.Count(string(header), string(candidate)); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	return best
+}
+
+// importColumnIndex finds the column named name, or any of the aliases when
+// name is empty, returning -1 when there is none
+func importColumnIndex(header []string, name string, aliases []string) int {
+	candidates := aliases
+	if name != "" {
+		candidates = []string{name}
+	}
+	for _, candidate := range candidates {
+		for i, column := range header {
+			if strings.EqualFold(strings.TrimSpace(column), candidate) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func parseImportDate(value, layout string) (time.Time, error) {
+	if layout != "" {
+		return time.Parse(layout, value)
+	}
+	for _, layout := range importDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date: %s", value)
+}
+
+// parseImportAmount parses amounts such as "-1,234.50", "NT$ 250" or "(12.00)",
+// where parentheses mean a negative amount
+func parseImportAmount(value string) (float64, error) {
+	cleaned := importAmountCleaner.ReplaceAllString(value, "")
+	negative := strings.HasPrefix(cleaned, "(") && strings.HasSuffix(cleaned, ")")
+	if strings.HasSuffix(cleaned, "-") {
+		// Trailing minus, e.g. "12.50-"
+		negative = true
+		cleaned = strings.TrimSuffix(cleaned, "-")
+	}
+	cleaned = strings.Trim(cleaned, "()")
+	amount, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		amount = -math.Abs(amount)
+	}
+	return amount, nil
+}
+
+// parseImportOFX reads the outgoing transactions from an OFX statement. Both
+// SGML (OFX 1.x, unclosed tags) and XML (OFX 2.x) files are accepted.
+func parseImportOFX(data []byte, result *ImportResult) ([]importRow, error) {
+	text := string(data)
+	upper := asciiUpper(text)
+	if !strings.Contains(upper, "<STMTTRN>") {
+		return nil, fmt.Errorf("OFX file has no transactions")
+	}
+	currency := strings.ToUpper(ofxValue(text, "CURDEF"))
+
+	var rows []importRow
+	blocks := strings.Split(upper, "<STMTTRN>")
+	offset := len(blocks[0])
+	for i, block := range blocks[1:] {
+		start := offset + len("<STMTTRN>")
+		offset = start + len(block)
+		txn := text[start:offset]
+		if end := strings.Index(asciiUpper(txn), "</STMTTRN>"); end >= 0 {
+			txn = txn[:end]
+		}
+
+		posted := ofxValue(txn, "DTPOSTED")
+		date, err := time.Parse("20060102", posted[:min(len(posted), 8)])
+		if err != nil {
+			result.Errors = append(result.Errors, ImportRowError{Row: i + 1, Error: fmt.Sprintf("invalid date %q", posted)})
+			continue
+		}
+		raw := ofxValue(txn, "TRNAMT")
+		amount, err := parseImportAmount(raw)
+		if err != nil {
+			result.Errors = append(result.Errors, ImportRowError{Row: i + 1, Error: fmt.Sprintf("invalid amount %q", raw)})
+			continue
+		}
+		if amount >= 0 {
+			result.Skipped++
+			continue
+		}
+
+		description := ofxValue(txn, "NAME")
+		if description == "" {
+			description = ofxValue(txn, "MEMO")
+		}
+		if description == "" {
+			description = "Imported transaction"
+		}
+		rows = append(rows, importRow{
+			row:         i + 1,
+			date:        date,
+			description: description,
+			amount:      -amount,
+			currency:    currency,
+		})
+	}
+	return rows, nil
+}
+
+// ofxValue returns the value of the first <tag> in s
+func ofxValue(s, tag string) string {
+	upper := asciiUpper(s)
+	open := "<" + tag + ">"
+	i := strings.Index(upper, open)
+	if i < 0 {
+		return ""
+	}
+	value := s[i+len(open):]
+	if end := strings.IndexAny(value, "<\r\n"); end >= 0 {
+		value = value[:end]
+	}
+	return strings.TrimSpace(unescapeOFX(value))
+}
+
+// asciiUpper upper-cases ASCII letters only, so byte offsets into s stay valid
+func asciiUpper(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}, s)
+}
+
+func unescapeOFX(s string) string {
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'").Replace(s)
+}
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func newTestImportUseCase() (*ImportUseCase, *MockExpenseRepository, *MockExpenseAuditRepository) {
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	categoryRepo.Create(context.Background(), &domain.Category{ID: "food", UserID: "user1", Name: "Food"})
+	categoryRepo.Create(context.Background(), &domain.Category{ID: "transport", UserID: "user1", Name: "Transport"})
+	auditRepo := NewMockExpenseAuditRepository()
+
+	createUC := NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, NewMockAIService())
+	createUC.SetAuditRepository(auditRepo)
+	return NewImportUseCase(expenseRepo, createUC), expenseRepo, auditRepo
+}
+
+func TestImportUseCase_CSV(t *testing.T) {
+	ctx := context.Background()
+	uc, expenseRepo, auditRepo := newTestImportUseCase()
+
+	// Already recorded from chat; the statement's 120.00 lunch on the same day is a duplicate
+	expenseRepo.Create(ctx, &domain.Expense{ID: "existing", UserID: "user1", Description: "午餐", OriginalAmount: 120, HomeAmount: 120,
+		ExpenseDate: time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)})
+
+	csv := "Date,Description,Amount\n" +
+		"2026-03-02,RESTAURANT LUNCH,-120.00\n" +
+		"2026-03-03,UBER TRIP,\"-1,250.50\"\n" +
+		"2026-03-04,SALARY,50000\n" +
+		"not a date,COFFEE,-80\n" +
+		"\n" +
+		"2026-03-05,Corner cafe,(95)\n"
+
+	result, err := uc.Execute(ctx, &ImportRequest{UserID: "user1", Data: []byte("\xef\xbb\xbf" + csv), Account: "Visa"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Imported) != 2 || result.Duplicates != 1 || result.Skipped != 1 || len(result.Errors) != 1 {
+		t.Fatalf("expected 2 imported, 1 duplicate, 1 skipped and 1 error, got %d, %d, %d and %v",
+			len(result.Imported), result.Duplicates, result.Skipped, result.Errors)
+	}
+	if result.Errors[0].Row != 5 {
+		t.Errorf("expected the bad date to be reported on line 5, got %d", result.Errors[0].Row)
+	}
+
+	uber := result.Imported[0]
+	if uber.Description != "UBER TRIP" || uber.Amount != 1250.5 || uber.Date != "2026-03-03" || uber.Category != "Transport" {
+		t.Errorf("unexpected imported expense: %+v", uber)
+	}
+	if cafe := result.Imported[1]; cafe.Amount != 95 || cafe.Category != "Food" {
+		t.Errorf("expected parenthesized amount to import as 95 Food, got %+v", cafe)
+	}
+
+	created, _ := expenseRepo.GetByID(ctx, uber.ID)
+	if created == nil || created.Account != "Visa" {
+		t.Errorf("expected imported expense on the Visa account, got %+v", created)
+	}
+	history, _ := auditRepo.GetByExpenseID(ctx, uber.ID)
+	if len(history) != 1 || history[0].Channel != domain.AuditChannelImport {
+		t.Errorf("expected the import to be recorded in the audit log, got %v", history)
+	}
+}
+
+func TestImportUseCase_MappingProfile(t *testing.T) {
+	ctx := context.Background()
+	uc, _, _ := newTestImportUseCase()
+	uc.SetProfiles([]ImportProfile{{
+		Name:              "mybank",
+		DateColumn:        "Posted",
+		DescriptionColumn: "Payee",
+		DebitColumn:       "Out",
+		DateFormat:        "02/01/2006",
+		Delimiter:         ";",
+		SkipRows:          1,
+	}})
+
+	csv := "Account 123-456\n" +
+		"Posted;Payee;Out;In\n" +
+		"31/03/2026;Taxi;250;\n" +
+		"31/03/2026;Refund;;100\n"
+
+	result, err := uc.Execute(ctx, &ImportRequest{UserID: "user1", Data: []byte(csv), Profile: "MyBank"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Imported) != 1 || result.Skipped != 1 {
+		t.Fatalf("expected 1 imported and 1 skipped, got %+v", result)
+	}
+	if got := result.Imported[0]; got.Date != "2026-03-31" || got.Amount != 250 || got.Description != "Taxi" {
+		t.Errorf("unexpected imported expense: %+v", got)
+	}
+
+	// A request can bring its own mapping
+	result, err = uc.Execute(ctx, &ImportRequest{
+		UserID:  "user1",
+		Data:    []byte("when,what,value\n2026-04-01,Bus,30\n"),
+		Mapping: &ImportProfile{DateColumn: "when", DescriptionColumn: "what", AmountColumn: "value", ExpensesPositive: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Imported) != 1 || result.Imported[0].Amount != 30 {
+		t.Errorf("expected the explicit mapping to import the bus fare, got %+v", result)
+	}
+
+	if _, err := uc.Execute(ctx, &ImportRequest{UserID: "user1", Data: []byte(csv), Profile: "unknown"}); err == nil {
+		t.Error("expected an unknown profile to be rejected")
+	}
+	if _, err := uc.Execute(ctx, &ImportRequest{UserID: "user1", Data: []byte("foo,bar\n1,2\n")}); err == nil {
+		t.Error("expected a CSV without the needed columns to be rejected")
+	}
+}
+
+func TestImportUseCase_OFX(t *testing.T) {
+	ctx := context.Background()
+	uc, _, _ := newTestImportUseCase()
+
+	ofx := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>usd
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260310120000[-5:EST]
+<TRNAMT>-42.10
+<FITID>1
+<NAME>Joe's Diner &amp; Bar
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20260311
+<TRNAMT>1000.00
+<FITID>2
+<NAME>Payroll
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20260312
+<TRNAMT>-15
+<FITID>3
+<MEMO>Bus pass
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+	result, err := uc.Execute(ctx, &ImportRequest{UserID: "user1", Data: []byte(ofx)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Imported) != 2 || result.Skipped != 1 {
+		t.Fatalf("expected 2 imported and 1 skipped, got %+v", result)
+	}
+	diner := result.Imported[0]
+	if diner.Description != "Joe's Diner & Bar" || diner.Amount != 42.1 || diner.Currency != "USD" || diner.Date != "2026-03-10" {
+		t.Errorf("unexpected imported expense: %+v", diner)
+	}
+	if bus := result.Imported[1]; bus.Description != "Bus pass" || bus.Category != "Transport" {
+		t.Errorf("expected memo to be used as the description, got %+v", bus)
+	}
+
+	// Importing the same statement again creates nothing
+	result, err = uc.Execute(ctx, &ImportRequest{UserID: "user1", Data: []byte(ofx), Format: ImportFormatOFX})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Imported) != 0 || result.Duplicates != 2 {
+		t.Errorf("expected both transactions to be duplicates on re-import, got %+v", result)
+	}
+}
+
+func TestParseImportAmount(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"-1,234.50", -1234.5},
+		{"NT$ 250", 250},
+		{"(12.00)", -12},
+		{"12.50-", -12.5},
+		{"$0.99", 0.99},
+	}
+	for _, tt := range tests {
+		got, err := parseImportAmount(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("parseImportAmount(%q) = %v, %v; want %v", tt.input, got, err, tt.want)
+		}
+	}
+	if _, err := parseImportAmount("n/a"); err == nil {
+		t.Error("expected a non-numeric amount to fail")
+	}
+}
@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Interaction log page sizes
+const (
+	DefaultInteractionPageSize = 50
+	MaxInteractionPageSize     = 200
+)
+
+// InteractionLogUseCase lets admins browse the logged conversations, e.g. to debug
+// misparsed messages or collect training data
+type InteractionLogUseCase struct {
+	interactionRepo domain.InteractionLogRepository
+}
+
+// NewInteractionLogUseCase creates a new interaction log use case
+func NewInteractionLogUseCase(interactionRepo domain.InteractionLogRepository) *InteractionLogUseCase {
+	return &InteractionLogUseCase{
+		interactionRepo: interactionRepo,
+	}
+}
+
+// InteractionLogPage is one page of logged interactions, newest first
+type InteractionLogPage struct {
+	Interactions []*domain.InteractionLog `json:"interactions"`
+	Total        int                      `json:"total"`
+	Limit        int                      `json:"limit"`
+	Offset       int                      `json:"offset"`
+}
+
+// List returns the page of interactions matching the filter
+func (u *InteractionLogUseCase) List(ctx context.Context, filter domain.InteractionLogFilter) (*InteractionLogPage, error) {
+	switch {
+	case filter.Limit < 0 || filter.Limit > MaxInteractionPageSize:
+		return nil, fmt.Errorf("limit must be between 1 and %d", MaxInteractionPageSize)
+	case filter.Limit == 0:
+		filter.Limit = DefaultInteractionPageSize
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	interactions, total, err := u.interactionRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interactions: %w", err)
+	}
+	if interactions == nil {
+		interactions = []*domain.InteractionLog{}
+	}
+
+	return &InteractionLogPage{
+		Interactions: interactions,
+		Total:        total,
+		Limit:        filter.Limit,
+		Offset:       filter.Offset,
+	}, nil
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestInteractionLogUseCase_List(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockInteractionLogRepository()
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		intent := domain.InteractionIntentExpense
+		if i%2 == 1 {
+			intent = domain.InteractionIntentQuery
+		}
+		repo.Create(ctx, &domain.InteractionLog{
+			ID:        fmt.Sprintf("log%d", i),
+			UserID:    "user1",
+			Source:    "line",
+			Intent:    intent,
+			Timestamp: start.AddDate(0, 0, i),
+		})
+	}
+	uc := NewInteractionLogUseCase(repo)
+
+	page, err := uc.List(ctx, domain.InteractionLogFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if page.Limit != DefaultInteractionPageSize || page.Total != 5 || page.Interactions[0].ID != "log4" {
+		t.Errorf("expected newest first with the default page size, got limit=%d total=%d first=%s",
+			page.Limit, page.Total, page.Interactions[0].ID)
+	}
+
+	page, err = uc.List(ctx, domain.InteractionLogFilter{Intent: domain.InteractionIntentQuery, Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if page.Total != 2 || len(page.Interactions) != 1 || page.Interactions[0].ID != "log1" {
+		t.Errorf("expected second query interaction of 2, got total=%d %v", page.Total, page.Interactions)
+	}
+
+	page, err = uc.List(ctx, domain.InteractionLogFilter{UserID: "user2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if page.Interactions == nil || len(page.Interactions) != 0 {
+		t.Errorf("expected an empty page, got %v", page.Interactions)
+	}
+
+	invalid := []domain.InteractionLogFilter{
+		{Limit: MaxInteractionPageSize + 1},
+		{Limit: -1},
+		{Offset: -1},
+		{From: start, To: start},
+	}
+	for _, filter := range invalid {
+		if _, err := uc.List(ctx, filter); err == nil {
+			t.Errorf("expected filter %+v to be rejected", filter)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// ErrUnknownLocale is returned for a language there is no catalog for
+var ErrUnknownLocale = errors.New("unknown language")
+
+// LocaleUseCase keeps track of the language each user is answered in
+type LocaleUseCase struct {
+	userRepo domain.UserRepository
+}
+
+// NewLocaleUseCase creates a new locale use case
+func NewLocaleUseCase(userRepo domain.UserRepository) *LocaleUseCase {
+	return &LocaleUseCase{userRepo: userRepo}
+}
+
+// Locale returns the locale the user is answered in, or the default when
+// they can't be looked up
+func (u *LocaleUseCase) Locale(ctx context.Context, userID string) string {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get user locale", "error", err)
+		return i18n.DefaultLocale
+	}
+	if !i18n.Supported(user.Locale) {
+		return i18n.DefaultLocale
+	}
+	return user.Locale
+}
+
+// SetLocale changes the user's language to one picked by its name, number or
+// locale, as the onboarding wizard offers them, and returns its locale
+func (u *LocaleUseCase) SetLocale(ctx context.Context, userID, language string) (string, error) {
+	locale, ok := parseOnboardingLocale(language)
+	if !ok {
+		if locale = i18n.Match(language); locale == "" {
+			return "", ErrUnknownLocale
+		}
+	}
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	user.Locale = locale
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return "", err
+	}
+	return locale, nil
+}
+
+// translate returns the message in the locale carried by ctx, formatted with args
+func translate(ctx context.Context, key string, args ...interface{}) string {
+	return i18n.Tf(i18n.FromContext(ctx), key, args...)
+}
+
+// formatMoney writes the amount with its currency's symbol in the locale carried by ctx
+func formatMoney(ctx context.Context, amount float64, currency string) string {
+	return i18n.FormatMoney(i18n.FromContext(ctx), amount, currency)
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocaleUseCase(t *testing.T) {
+	ctx := context.Background()
+	userRepo := NewMockUserRepository()
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "line", CreatedAt: time.Now()}))
+	uc := NewLocaleUseCase(userRepo)
+
+	// Users without a language, and unknown users, get the default
+	assert.Equal(t, i18n.DefaultLocale, uc.Locale(ctx, "user1"))
+	assert.Equal(t, i18n.DefaultLocale, uc.Locale(ctx, "nobody"))
+
+	// Languages are picked by name, number or tag
+	for language, want := range map[string]string{"English": "en", "2": "zh-CN", "ja-JP": "ja", "zh-Hant": "zh-TW"} {
+		locale, err := uc.SetLocale(ctx, "user1", language)
+		require.NoError(t, err, language)
+		assert.Equal(t, want, locale, language)
+		assert.Equal(t, want, uc.Locale(ctx, "user1"), language)
+	}
+
+	_, err := uc.SetLocale(ctx, "user1", "Klingon")
+	assert.ErrorIs(t, err, ErrUnknownLocale)
+	_, err = uc.SetLocale(ctx, "nobody", "English")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -46,9 +47,12 @@ func (u *ManageCategoryUseCase) CreateCategory(ctx context.Context, req *CreateC
 	}
 
 	// Check if category already exists
-	existing, err := u.categoryRepo.GetByUserIDAndName(ctx, req.UserID, req.Name)
-	if err == nil && existing != nil {
-		return nil, fmt.Errorf("category '%s' already exists", req.Name)
+	_, err := u.categoryRepo.GetByUserIDAndName(ctx, req.UserID, req.Name)
+	if err == nil {
+		return nil, fmt.Errorf("category '%s' %w", req.Name, domain.ErrConflict)
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("failed to check category: %w", err)
 	}
 
 	// Create the category
@@ -59,6 +63,7 @@ func (u *ManageCategoryUseCase) CreateCategory(ctx context.Context, req *CreateC
 		IsDefault: false,
 		CreatedAt: time.Now(),
 	}
+	category.WorkspaceID, _ = domain.WorkspaceFromContext(ctx)
 
 	if err := u.categoryRepo.Create(ctx, category); err != nil {
 		return nil, fmt.Errorf("failed to create category: %w", err)
@@ -108,14 +113,13 @@ type UpdateCategoryRequest struct {
 func (u *ManageCategoryUseCase) UpdateCategory(ctx context.Context, req *UpdateCategoryRequest) (*CategoryResponse, error) {
 	// Get existing category
 	category, err := u.categoryRepo.GetByID(ctx, req.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("category %w", domain.ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get category: %w", err)
 	}
 
-	if category == nil {
-		return nil, fmt.Errorf("category not found")
-	}
-
 	// Verify ownership
 	if category.UserID != req.UserID {
 		return nil, fmt.Errorf("unauthorized: user does not own this category")
@@ -194,14 +198,13 @@ type DeleteCategoryRequest struct {
 func (u *ManageCategoryUseCase) DeleteCategory(ctx context.Context, req *DeleteCategoryRequest) (*CategoryResponse, error) {
 	// Get category
 	category, err := u.categoryRepo.GetByID(ctx, req.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("category %w", domain.ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get category: %w", err)
 	}
 
-	if category == nil {
-		return nil, fmt.Errorf("category not found")
-	}
-
 	// Verify ownership
 	if category.UserID != req.UserID {
 		return nil, fmt.Errorf("unauthorized: user does not own this category")
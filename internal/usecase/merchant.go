@@ -0,0 +1,217 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// Merchant ranking orders
+const (
+	MerchantSortCount  = "count"
+	MerchantSortAmount = "amount"
+)
+
+const (
+	// DefaultTopMerchants is how many merchants Top ranks without a limit
+	DefaultTopMerchants = 10
+	// DefaultMerchantTrendMonths is how many months Trend covers without a count
+	DefaultMerchantTrendMonths = 12
+	// MaxMerchantTrendMonths is the most months Trend covers
+	MaxMerchantTrendMonths = 36
+)
+
+// ErrInvalidMerchantQuery is returned for a merchant ranking or trend that
+// can't be computed, such as a reversed date range
+var ErrInvalidMerchantQuery = errors.New("invalid merchant query")
+
+// MerchantUseCase ranks the merchants a user pays and follows their spending
+// at each over time
+type MerchantUseCase struct {
+	merchantRepo domain.MerchantRepository
+	expenseRepo  domain.ExpenseRepository
+	now          func() time.Time
+}
+
+// NewMerchantUseCase creates a new merchant use case
+func NewMerchantUseCase(merchantRepo domain.MerchantRepository, expenseRepo domain.ExpenseRepository) *MerchantUseCase {
+	return &MerchantUseCase{
+		merchantRepo: merchantRepo,
+		expenseRepo:  expenseRepo,
+		now:          time.Now,
+	}
+}
+
+// TopMerchantsRequest selects the expenses merchants are ranked by. Without
+// dates the last 90 days count; SortBy is MerchantSortCount (the default),
+// ranking the merchants the user pays most often first, or MerchantSortAmount.
+type TopMerchantsRequest struct {
+	UserID string
+	From   time.Time
+	To     time.Time
+	SortBy string
+	Limit  int
+}
+
+// MerchantRanking is one merchant's spending in a ranking
+type MerchantRanking struct {
+	MerchantID string  `json:"merchant_id"`
+	Name       string  `json:"name"`
+	Total      float64 `json:"total"`
+	Count      int     `json:"count"`
+	Average    float64 `json:"average"`
+	Percentage float64 `json:"percentage"` // Of the total spent at merchants
+}
+
+// TopMerchantsResponse ranks the merchants of a period
+type TopMerchantsResponse struct {
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	SortBy    string            `json:"sort_by"`
+	Merchants []MerchantRanking `json:"merchants"`
+}
+
+// Top ranks the merchants the user paid in a period
+func (u *MerchantUseCase) Top(ctx context.Context, req *TopMerchantsRequest) (*TopMerchantsResponse, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	sortBy := req.SortBy
+	switch sortBy {
+	case "":
+		sortBy = MerchantSortCount
+	case MerchantSortCount, MerchantSortAmount:
+	default:
+		return nil, fmt.Errorf("%w: sort_by must be %s or %s", ErrInvalidMerchantQuery, MerchantSortCount, MerchantSortAmount)
+	}
+	to := req.To
+	if to.IsZero() {
+		to = u.now()
+	}
+	from := req.From
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -90)
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("%w: start date is after end date", ErrInvalidMerchantQuery)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultTopMerchants
+	}
+
+	totals, err := u.merchantRepo.SumByMerchantAndDateRange(ctx, domain.ExpenseTotalsQuery{UserID: req.UserID, From: from, To: to})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum expenses by merchant: %w", err)
+	}
+	if sortBy == MerchantSortCount {
+		sort.SliceStable(totals, func(i, j int) bool { return totals[i].Count > totals[j].Count })
+	}
+
+	spent := 0.0
+	for _, total := range totals {
+		spent += total.Total
+	}
+	rankings := make([]MerchantRanking, 0, min(limit, len(totals)))
+	for _, total := range totals[:min(limit, len(totals))] {
+		ranking := MerchantRanking{
+			MerchantID: total.MerchantID,
+			Name:       total.Name,
+			Total:      total.Total,
+			Count:      total.Count,
+			Average:    total.Total / float64(total.Count),
+		}
+		if spent > 0 {
+			ranking.Percentage = total.Total / spent * 100
+		}
+		rankings = append(rankings, ranking)
+	}
+	return &TopMerchantsResponse{From: from, To: to, SortBy: sortBy, Merchants: rankings}, nil
+}
+
+// MerchantMonth is the spending at a merchant in one month
+type MerchantMonth struct {
+	Month string  `json:"month"` // YYYY-MM
+	Total float64 `json:"total"`
+	Count int     `json:"count"`
+}
+
+// MerchantTrend is the monthly spending at one merchant, oldest month first;
+// months without expenses are included with zero totals
+type MerchantTrend struct {
+	Merchant *domain.Merchant `json:"merchant"`
+	Months   []MerchantMonth  `json:"months"`
+	Total    float64          `json:"total"`
+	Count    int              `json:"count"`
+}
+
+// Trend returns the user's spending at one of their merchants in each of the
+// last months, the current one included
+func (u *MerchantUseCase) Trend(ctx context.Context, userID, merchantID string, months int) (*MerchantTrend, error) {
+	if months <= 0 {
+		months = DefaultMerchantTrendMonths
+	}
+	if months > MaxMerchantTrendMonths {
+		return nil, fmt.Errorf("%w: at most %d months", ErrInvalidMerchantQuery, MaxMerchantTrendMonths)
+	}
+	merchant, err := u.merchantRepo.GetByID(ctx, merchantID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && merchant.UserID != userID) {
+		return nil, fmt.Errorf("merchant %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merchant: %w", err)
+	}
+
+	// Monthly totals are by UTC month, like report breakdowns
+	now := u.now().UTC()
+	start := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+	totals, err := u.expenseRepo.SumByPeriodAndDateRange(ctx, domain.ExpenseTotalsQuery{
+		UserID:     userID,
+		MerchantID: merchant.ID,
+		From:       start,
+		To:         now,
+	}, domain.TotalsPeriodMonth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum merchant expenses by month: %w", err)
+	}
+	byMonth := make(map[string]*domain.PeriodTotal, len(totals))
+	for _, total := range totals {
+		byMonth[total.Start.Format("2006-01")] = total
+	}
+
+	trend := &MerchantTrend{Merchant: merchant, Months: make([]MerchantMonth, months)}
+	for i := range trend.Months {
+		month := start.AddDate(0, i, 0).Format("2006-01")
+		trend.Months[i].Month = month
+		if total, ok := byMonth[month]; ok {
+			trend.Months[i].Total = total.Total
+			trend.Months[i].Count = total.Count
+			trend.Total += total.Total
+			trend.Count += total.Count
+		}
+	}
+	return trend, nil
+}
+
+// findOrCreateMerchant returns the user's merchant of a name, creating it the
+// first time the name is seen
+func findOrCreateMerchant(ctx context.Context, merchantRepo domain.MerchantRepository, userID, name string, now time.Time) (*domain.Merchant, error) {
+	display, key := domain.NormalizeMerchantName(name)
+	if key == "" {
+		return nil, fmt.Errorf("invalid merchant name %q", name)
+	}
+	merchant, err := merchantRepo.GetByUserIDAndNormalizedName(ctx, userID, key)
+	if errors.Is(err, domain.ErrNotFound) {
+		merchant = &domain.Merchant{ID: uuid.New().String(), UserID: userID, Name: display, NormalizedName: key, CreatedAt: now}
+		err = merchantRepo.Create(ctx, merchant)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve merchant %q: %w", name, err)
+	}
+	return merchant, nil
+}
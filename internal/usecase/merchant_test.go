@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerchantUseCase(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	merchantRepo := NewMockMerchantRepository(expenseRepo)
+	createUC := NewCreateExpenseUseCase(expenseRepo, NewMockCategoryRepository(), nil, nil, nil, nil, NewMockAIService())
+	createUC.SetMerchantRepository(merchantRepo)
+
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	for _, req := range []*CreateRequest{
+		{UserID: "user1", Description: "Coffee", Amount: 65, Merchant: "7-11", Date: now},
+		{UserID: "user1", Description: "Snacks", Amount: 40, Merchant: "7-Eleven 信義店", Date: now.AddDate(0, -1, 0)},
+		{UserID: "user1", Description: "Milk", Amount: 55, Merchant: "統一超商", Date: now.AddDate(0, -2, 0)},
+		{UserID: "user1", Description: "Latte", Amount: 150, Merchant: "星巴克", Date: now},
+		{UserID: "user1", Description: "Cake", Amount: 120, Merchant: "Starbucks", Date: now},
+		{UserID: "user1", Description: "Dinner", Amount: 900, Date: now},
+	} {
+		resp, err := createUC.Execute(ctx, req)
+		require.NoError(t, err)
+		if req.Merchant != "" {
+			assert.NotEmpty(t, resp.Merchant)
+		}
+	}
+
+	uc := NewMerchantUseCase(merchantRepo, expenseRepo)
+	uc.now = func() time.Time { return now }
+
+	// Spellings of a chain are one merchant; expenses without one are left out
+	top, err := uc.Top(ctx, &TopMerchantsRequest{UserID: "user1"})
+	require.NoError(t, err)
+	require.Len(t, top.Merchants, 2)
+	assert.Equal(t, "7-ELEVEN", top.Merchants[0].Name)
+	assert.Equal(t, 3, top.Merchants[0].Count)
+	assert.Equal(t, 160.0, top.Merchants[0].Total)
+	assert.InDelta(t, 160.0/430*100, top.Merchants[0].Percentage, 0.001)
+	seven := top.Merchants[0].MerchantID
+
+	top, err = uc.Top(ctx, &TopMerchantsRequest{UserID: "user1", SortBy: MerchantSortAmount, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, top.Merchants, 1)
+	assert.Equal(t, "Starbucks", top.Merchants[0].Name)
+	assert.Equal(t, 135.0, top.Merchants[0].Average)
+
+	_, err = uc.Top(ctx, &TopMerchantsRequest{UserID: "user1", SortBy: "name"})
+	assert.ErrorIs(t, err, ErrInvalidMerchantQuery)
+
+	// Months without expenses are included
+	trend, err := uc.Trend(ctx, "user1", seven, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []MerchantMonth{
+		{Month: "2024-12"},
+		{Month: "2025-01", Total: 55, Count: 1},
+		{Month: "2025-02", Total: 40, Count: 1},
+		{Month: "2025-03", Total: 65, Count: 1},
+	}, trend.Months)
+	assert.Equal(t, 160.0, trend.Total)
+
+	_, err = uc.Trend(ctx, "user2", seven, 4)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = uc.Trend(ctx, "user1", seven, MaxMerchantTrendMonths+1)
+	assert.ErrorIs(t, err, ErrInvalidMerchantQuery)
+}
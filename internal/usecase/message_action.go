@@ -0,0 +1,861 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// CategoryManager adds and lists the user's categories for the 新增分類 and
+// category list quick actions
+type CategoryManager interface {
+	CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*CategoryResponse, error)
+	ListCategories(ctx context.Context, req *ListCategoriesRequest) (*ListCategoriesResponse, error)
+}
+
+// BudgetStatusReporter reports how the user's budgets stand for the budget quick action
+type BudgetStatusReporter interface {
+	GetBudgetStatus(ctx context.Context, req *GetBudgetStatusRequest) (*GetBudgetStatusResponse, error)
+}
+
+// DataExporter starts a takeout of the user's data for the export quick
+// action; the download link is sent once it is built
+type DataExporter interface {
+	RequestExport(ctx context.Context, userID string) (*UserExport, error)
+}
+
+// BudgetSelector lists the user's named budgets and switches the one the
+// budget quick action reports on, for the 切換預算 quick action
+type BudgetSelector interface {
+	ListNamedBudgets(ctx context.Context, userID string) ([]*NamedBudget, error)
+	SetActiveBudget(ctx context.Context, userID, name string) (*NamedBudget, error)
+}
+
+// ReportComparer compares a month's spending with an earlier period's for the 比較 quick action
+type ReportComparer interface {
+	Compare(ctx context.Context, req *ComparisonRequest) (*ComparisonReport, error)
+}
+
+// ReportCharts draws the spending breakdown of the monthly report and 比較
+// quick actions as chart images
+type ReportCharts interface {
+	MonthBreakdown(ctx context.Context, userID, groupID string) (*domain.ContentBlock, error)
+	ComparisonChart(ctx context.Context, report *ComparisonReport) *domain.ContentBlock
+}
+
+// SubscriptionDetector finds the user's monthly subscriptions and tracks them
+// as recurring expenses for the 訂閱 quick action
+type SubscriptionDetector interface {
+	DetectSubscriptions(ctx context.Context, userID string) (*DetectSubscriptionsResponse, error)
+	ConfirmSubscription(ctx context.Context, userID, id string) (*ConfirmSubscriptionResponse, error)
+}
+
+// ClaimTracker lists the user's reimbursement claims and moves them along
+// for the 報帳清單 quick action
+type ClaimTracker interface {
+	ListClaims(ctx context.Context, req *ListClaimsRequest) (*ClaimsResponse, error)
+	UpdateClaimStatus(ctx context.Context, userID string, expenseIDs []string, status string) ([]*Claim, error)
+}
+
+// WorkspaceSwitcher lists, adds and switches the user's workspaces for the
+// 切換帳本 and 新增帳本 quick actions; messages go into the active one
+type WorkspaceSwitcher interface {
+	ListWorkspaces(ctx context.Context, userID string) ([]*WorkspaceSummary, error)
+	CreateWorkspace(ctx context.Context, userID, name string) (*domain.Workspace, error)
+	SwitchWorkspace(ctx context.Context, userID, workspace string) (*WorkspaceSummary, error)
+	ActiveWorkspace(ctx context.Context, userID string) (string, error)
+}
+
+// ExpenseDeleter deletes one of the user's expenses for the delete button
+type ExpenseDeleter interface {
+	Execute(ctx context.Context, req *DeleteRequest) (*DeleteResponse, error)
+}
+
+// messageActions are the quick actions ProcessMessageUseCase handles
+var messageActions = map[string]bool{
+	domain.MessageActionTodaySpending:   true,
+	domain.MessageActionMonthlyReport:   true,
+	domain.MessageActionAddCategory:     true,
+	domain.MessageActionBudgetStatus:    true,
+	domain.MessageActionListCategories:  true,
+	domain.MessageActionExport:          true,
+	domain.MessageActionDeleteExpense:   true,
+	domain.MessageActionChangeCategory:  true,
+	domain.MessageActionSetTimezone:     true,
+	domain.MessageActionSetLanguage:     true,
+	domain.MessageActionCompareReport:   true,
+	domain.MessageActionSubscriptions:   true,
+	domain.MessageActionSelectBudget:    true,
+	domain.MessageActionClaims:          true,
+	domain.MessageActionSelectWorkspace: true,
+	domain.MessageActionAddWorkspace:    true,
+}
+
+// messageActionLabels maps the labels of the quick action buttons to their
+// action, so typing a label works like tapping the button
+var messageActionLabels = map[string]string{
+	"今日支出": domain.MessageActionTodaySpending,
+	"本月報表": domain.MessageActionMonthlyReport,
+	"本月报表": domain.MessageActionMonthlyReport,
+	// Only the word itself, as 訂閱 Netflix 390 is an expense
+	"訂閱":            domain.MessageActionSubscriptions,
+	"订阅":            domain.MessageActionSubscriptions,
+	"subscriptions": domain.MessageActionSubscriptions,
+	"報帳清單":          domain.MessageActionClaims,
+	"报销清单":          domain.MessageActionClaims,
+	"立替一覧":          domain.MessageActionClaims,
+	"claims":        domain.MessageActionClaims,
+}
+
+// messageActionPrefixes start typed quick actions that take an argument: the
+// category name for 新增分類, the timezone for 時區, the language for 語言,
+// what to compare with for 比較, the budget name for 切換預算, the workspace
+// name for 切換帳本 and 新增帳本
+var messageActionPrefixes = map[string][]string{
+	domain.MessageActionSelectWorkspace: {"切換帳本", "切换账本", "帳簿切り替え", "switch workspace"},
+	domain.MessageActionAddWorkspace:    {"新增帳本", "新增账本", "帳簿追加", "add workspace"},
+	domain.MessageActionSelectBudget:    {"切換預算", "切换预算", "予算切り替え", "switch budget"},
+	domain.MessageActionCompareReport:   {"比較", "比较", "compare"},
+	domain.MessageActionAddCategory:     {"新增分類", "新增分类", "add category"},
+	domain.MessageActionSetTimezone:     {"時區", "时区", "timezone"},
+	domain.MessageActionSetLanguage:     {"語言", "语言", "言語", "language"},
+}
+
+// messageAction returns the quick action the message asks for and its
+// argument, from the action a button sent or from typed text
+func messageAction(msg *domain.UserMessage) (string, string, bool) {
+	text := strings.TrimSpace(msg.Content)
+	if msg.Action != "" {
+		return msg.Action, text, messageActions[msg.Action]
+	}
+
+	if action, ok := messageActionLabels[text]; ok {
+		return action, "", true
+	}
+	lower := strings.ToLower(text)
+	for action, prefixes := range messageActionPrefixes {
+		for _, prefix := range prefixes {
+			if lower == prefix || strings.HasPrefix(lower, prefix+" ") {
+				return action, strings.TrimSpace(text[len(prefix):]), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// executeAction runs a quick action and returns the interaction intent it
+// was logged under along with the response
+func (u *ProcessMessageUseCase) executeAction(ctx context.Context, msg *domain.UserMessage, groupID *string, action, argument string) (string, *domain.MessageResponse) {
+	queryGroupID := ""
+	if groupID != nil {
+		queryGroupID = *groupID
+	}
+
+	switch action {
+	case domain.MessageActionTodaySpending:
+		if u.expenseQuerier == nil {
+			return domain.InteractionIntentQuery, &domain.MessageResponse{Text: translate(ctx, "query.unavailable")}
+		}
+		// The same answer as asking 今天花多少
+		reply, _ := u.expenseQuerier.Answer(ctx, msg.UserID, queryGroupID, "今天花多少")
+		return domain.InteractionIntentQuery, &domain.MessageResponse{Text: reply}
+
+	case domain.MessageActionMonthlyReport:
+		var sb strings.Builder
+		if u.expenseQuerier != nil {
+			reply, _ := u.expenseQuerier.Answer(ctx, msg.UserID, queryGroupID, "這個月花多少")
+			sb.WriteString(reply)
+		}
+		resp := &domain.MessageResponse{}
+		if sb.Len() > 0 {
+			resp.Blocks = []*domain.ContentBlock{{Type: domain.ContentBlockCard, Title: translate(ctx, "report.monthly_title"), Text: sb.String()}}
+			if u.reportCharts != nil {
+				block, err := u.reportCharts.MonthBreakdown(ctx, msg.UserID, queryGroupID)
+				if err != nil {
+					slog.WarnContext(ctx, "Failed to chart monthly report", "error", err)
+				} else if block != nil {
+					resp.Blocks = append(resp.Blocks, block)
+				}
+			}
+		}
+		link, err := u.generateReportLink.Execute(msg.UserID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to generate report link", "error", err)
+		} else {
+			if sb.Len() > 0 {
+				sb.WriteString("\n\n")
+			}
+			sb.WriteString(translate(ctx, "report.full_link", link))
+			resp.Buttons = []*domain.MessageButton{{Label: translate(ctx, "report.open"), URL: link}}
+		}
+		if sb.Len() == 0 {
+			return domain.InteractionIntentReport, &domain.MessageResponse{Text: translate(ctx, "report.link_failed")}
+		}
+		resp.Text = sb.String()
+		return domain.InteractionIntentReport, resp
+
+	case domain.MessageActionBudgetStatus:
+		if u.budgetReporter == nil {
+			return domain.InteractionIntentBudget, &domain.MessageResponse{Text: translate(ctx, "budget.unavailable")}
+		}
+		return domain.InteractionIntentBudget, u.budgetStatus(ctx, msg.UserID, queryGroupID)
+
+	case domain.MessageActionSelectBudget:
+		return domain.InteractionIntentBudget, u.selectBudget(ctx, msg.UserID, argument)
+
+	case domain.MessageActionListCategories:
+		if u.categoryManager == nil {
+			return domain.InteractionIntentCategories, &domain.MessageResponse{Text: translate(ctx, "categories.unavailable")}
+		}
+		categories, err := u.categoryManager.ListCategories(ctx, &ListCategoriesRequest{UserID: msg.UserID})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to list categories", "error", err)
+			return domain.InteractionIntentCategories, &domain.MessageResponse{Text: translate(ctx, "categories.failed")}
+		}
+		return domain.InteractionIntentCategories, &domain.MessageResponse{Text: formatCategoryList(ctx, categories.Categories)}
+
+	case domain.MessageActionExport:
+		if u.dataExporter == nil {
+			return domain.InteractionIntentExport, &domain.MessageResponse{Text: translate(ctx, "export.unavailable")}
+		}
+		if _, err := u.dataExporter.RequestExport(ctx, msg.UserID); err != nil {
+			slog.ErrorContext(ctx, "Failed to request data export", "error", err)
+			return domain.InteractionIntentExport, &domain.MessageResponse{Text: translate(ctx, "export.failed")}
+		}
+		return domain.InteractionIntentExport, &domain.MessageResponse{Text: translate(ctx, "export.started")}
+
+	case domain.MessageActionDeleteExpense:
+		if u.expenseDeleter == nil || argument == "" {
+			return domain.InteractionIntentDelete, &domain.MessageResponse{Text: translate(ctx, "delete.unsupported")}
+		}
+		deleted, err := u.expenseDeleter.Execute(ctx, &DeleteRequest{ID: argument, UserID: msg.UserID})
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to delete expense", "expense_id", argument, "error", err)
+			return domain.InteractionIntentDelete, &domain.MessageResponse{Text: translate(ctx, "delete.failed")}
+		}
+		return domain.InteractionIntentDelete, &domain.MessageResponse{Text: "🗑 " + deleted.Message}
+
+	case domain.MessageActionChangeCategory:
+		return domain.InteractionIntentChangeCategory, u.changeCategory(ctx, msg.UserID, argument)
+
+	case domain.MessageActionSetTimezone:
+		return domain.InteractionIntentTimezone, u.setTimezone(ctx, msg.UserID, argument)
+
+	case domain.MessageActionSetLanguage:
+		return domain.InteractionIntentLanguage, u.setLanguage(ctx, msg.UserID, argument)
+
+	case domain.MessageActionCompareReport:
+		if u.reportComparer == nil {
+			return domain.InteractionIntentReport, &domain.MessageResponse{Text: translate(ctx, "compare.unavailable")}
+		}
+		report, err := u.reportComparer.Compare(ctx, &ComparisonRequest{UserID: msg.UserID, GroupID: queryGroupID, Mode: comparisonMode(argument)})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to compare reports", "error", err)
+			return domain.InteractionIntentReport, &domain.MessageResponse{Text: translate(ctx, "compare.failed")}
+		}
+		resp := &domain.MessageResponse{Text: FormatComparison(ctx, report)}
+		if u.reportCharts != nil {
+			if block := u.reportCharts.ComparisonChart(ctx, report); block != nil {
+				title, text, _ := strings.Cut(resp.Text, "\n")
+				resp.Blocks = []*domain.ContentBlock{{Type: domain.ContentBlockCard, Title: title, Text: text}, block}
+			}
+		}
+		return domain.InteractionIntentReport, resp
+
+	case domain.MessageActionSubscriptions:
+		return domain.InteractionIntentSubscriptions, u.subscriptions(ctx, msg.UserID, argument)
+
+	case domain.MessageActionClaims:
+		return domain.InteractionIntentClaims, u.claims(ctx, msg.UserID, argument)
+
+	case domain.MessageActionSelectWorkspace:
+		return domain.InteractionIntentWorkspace, u.selectWorkspace(ctx, msg.UserID, argument)
+
+	case domain.MessageActionAddWorkspace:
+		return domain.InteractionIntentWorkspace, u.addWorkspace(ctx, msg.UserID, argument)
+
+	default:
+		if u.categoryManager == nil {
+			return domain.InteractionIntentAddCategory, &domain.MessageResponse{Text: translate(ctx, "category.add_unsupported")}
+		}
+		if argument == "" {
+			if u.askConversation(ctx, msg.UserID, domain.MessageActionAddCategory, conversationSlotName, nil) {
+				return domain.InteractionIntentAddCategory, &domain.MessageResponse{Text: translate(ctx, "category.ask_name")}
+			}
+			return domain.InteractionIntentAddCategory, &domain.MessageResponse{Text: translate(ctx, "category.name_hint")}
+		}
+		if _, err := u.categoryManager.CreateCategory(ctx, &CreateCategoryRequest{UserID: msg.UserID, Name: argument}); err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				return domain.InteractionIntentAddCategory, &domain.MessageResponse{Text: translate(ctx, "category.exists", argument)}
+			}
+			slog.ErrorContext(ctx, "Failed to create category", "error", err)
+			return domain.InteractionIntentAddCategory, &domain.MessageResponse{Text: translate(ctx, "category.add_failed")}
+		}
+		return domain.InteractionIntentAddCategory, &domain.MessageResponse{Text: translate(ctx, "category.added", argument)}
+	}
+}
+
+// changeCategory offers the user's categories for an expense, then moves the
+// expense to the one picked. argument is the expense ID, followed by the
+// category ID once one is picked.
+func (u *ProcessMessageUseCase) changeCategory(ctx context.Context, userID, argument string) *domain.MessageResponse {
+	if u.expenseUpdater == nil || u.categoryManager == nil {
+		return &domain.MessageResponse{Text: translate(ctx, "change_category.unsupported")}
+	}
+	expenseID, categoryID, _ := strings.Cut(argument, " ")
+	if expenseID == "" {
+		return &domain.MessageResponse{Text: translate(ctx, "change_category.no_expense")}
+	}
+
+	categories, err := u.categoryManager.ListCategories(ctx, &ListCategoriesRequest{UserID: userID})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list categories", "error", err)
+		return &domain.MessageResponse{Text: translate(ctx, "categories.failed")}
+	}
+
+	if categoryID = strings.TrimSpace(categoryID); categoryID == "" {
+		resp := &domain.MessageResponse{Text: translate(ctx, "change_category.pick")}
+		if u.askConversation(ctx, userID, domain.MessageActionChangeCategory, conversationSlotCategory, map[string]string{conversationSlotExpenseID: expenseID}) {
+			resp.Text = translate(ctx, "change_category.pick_or_type")
+		}
+		for _, c := range categories.Categories {
+			resp.Buttons = append(resp.Buttons, &domain.MessageButton{
+				Label:  c.Name,
+				Action: domain.MessageActionChangeCategory,
+				Value:  expenseID + " " + c.ID,
+			})
+		}
+		return resp
+	}
+
+	for _, c := range categories.Categories {
+		if c.ID != categoryID {
+			continue
+		}
+		if _, err := u.expenseUpdater.Execute(ctx, &UpdateRequest{ID: expenseID, UserID: userID, CategoryID: &c.ID}); err != nil {
+			slog.WarnContext(ctx, "Failed to change expense category", "expense_id", expenseID, "error", err)
+			return &domain.MessageResponse{Text: translate(ctx, "change_category.failed")}
+		}
+		return &domain.MessageResponse{Text: translate(ctx, "change_category.moved", c.Name)}
+	}
+	return &domain.MessageResponse{Text: translate(ctx, "change_category.gone")}
+}
+
+// budgetStatus reports how the budgets stand: the group ledger's, or the
+// user's active named budget, offering to switch to their other ones
+func (u *ProcessMessageUseCase) budgetStatus(ctx context.Context, userID, groupID string) *domain.MessageResponse {
+	status, err := u.budgetReporter.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: userID, GroupID: groupID, Active: groupID == ""})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get budget status", "error", err)
+		return &domain.MessageResponse{Text: translate(ctx, "budget.failed")}
+	}
+	resp := &domain.MessageResponse{Text: formatBudgetStatus(ctx, status)}
+	if block := budgetStatusBlock(ctx, status); block != nil {
+		resp.Blocks = []*domain.ContentBlock{block}
+	}
+	if groupID == "" && u.budgetSelector != nil {
+		named, err := u.budgetSelector.ListNamedBudgets(ctx, userID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to list named budgets", "error", err)
+		}
+		for _, n := range named {
+			if !n.Active && len(named) > 1 {
+				resp.Buttons = append(resp.Buttons, &domain.MessageButton{Label: budgetLabel(ctx, n.Name), Action: domain.MessageActionSelectBudget, Value: budgetLabel(ctx, n.Name)})
+			}
+		}
+	}
+	return resp
+}
+
+// selectBudget offers the user's named budgets, then makes the budget quick
+// action report on the one picked
+func (u *ProcessMessageUseCase) selectBudget(ctx context.Context, userID, argument string) *domain.MessageResponse {
+	if u.budgetSelector == nil {
+		return &domain.MessageResponse{Text: translate(ctx, "budget.unavailable")}
+	}
+
+	if argument == "" {
+		named, err := u.budgetSelector.ListNamedBudgets(ctx, userID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to list named budgets", "error", err)
+			return &domain.MessageResponse{Text: translate(ctx, "budget.select_failed")}
+		}
+		if len(named) < 2 {
+			return &domain.MessageResponse{Text: translate(ctx, "budget.single")}
+		}
+		resp := &domain.MessageResponse{Text: translate(ctx, "budget.pick")}
+		if u.askConversation(ctx, userID, domain.MessageActionSelectBudget, conversationSlotBudget, nil) {
+			resp.Text = translate(ctx, "budget.pick_or_type")
+		}
+		for _, n := range named {
+			label := budgetLabel(ctx, n.Name)
+			if n.Active {
+				label = "✓ " + label
+			}
+			resp.Buttons = append(resp.Buttons, &domain.MessageButton{Label: label, Action: domain.MessageActionSelectBudget, Value: budgetLabel(ctx, n.Name)})
+		}
+		return resp
+	}
+
+	selected, err := u.budgetSelector.SetActiveBudget(ctx, userID, argument)
+	if errors.Is(err, ErrUnknownBudget) && argument == translate(ctx, "budget.everyday") {
+		selected, err = u.budgetSelector.SetActiveBudget(ctx, userID, "")
+	}
+	if errors.Is(err, ErrUnknownBudget) {
+		return &domain.MessageResponse{Text: translate(ctx, "budget.unknown", argument)}
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to switch budget", "error", err)
+		return &domain.MessageResponse{Text: translate(ctx, "budget.select_failed")}
+	}
+
+	text := translate(ctx, "budget.switched", budgetLabel(ctx, selected.Name))
+	if u.budgetReporter == nil {
+		return &domain.MessageResponse{Text: text}
+	}
+	resp := u.budgetStatus(ctx, userID, "")
+	resp.Text = text + "\n\n" + resp.Text
+	return resp
+}
+
+// budgetLabel names a named budget in replies, the everyday one included
+func budgetLabel(ctx context.Context, name string) string {
+	if name == "" {
+		return translate(ctx, "budget.everyday")
+	}
+	return name
+}
+
+// selectWorkspace offers the user's workspaces, then makes their messages go
+// into the one picked
+func (u *ProcessMessageUseCase) selectWorkspace(ctx context.Context, userID, argument string) *domain.MessageResponse {
+	if u.workspaceSwitcher == nil {
+		return &domain.MessageResponse{Text: translate(ctx, "workspace.unavailable")}
+	}
+
+	if argument == "" {
+		workspaces, err := u.workspaceSwitcher.ListWorkspaces(ctx, userID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to list workspaces", "error", err)
+			return &domain.MessageResponse{Text: translate(ctx, "workspace.failed")}
+		}
+		if len(workspaces) < 2 {
+			return &domain.MessageResponse{Text: translate(ctx, "workspace.single")}
+		}
+		resp := &domain.MessageResponse{Text: translate(ctx, "workspace.pick")}
+		if u.askConversation(ctx, userID, domain.MessageActionSelectWorkspace, conversationSlotWorkspace, nil) {
+			resp.Text = translate(ctx, "workspace.pick_or_type")
+		}
+		for _, w := range workspaces {
+			label := workspaceLabel(ctx, w.Name)
+			if w.Active {
+				label = "✓ " + label
+			}
+			resp.Buttons = append(resp.Buttons, &domain.MessageButton{Label: label, Action: domain.MessageActionSelectWorkspace, Value: workspaceLabel(ctx, w.Name)})
+		}
+		return resp
+	}
+
+	selected, err := u.workspaceSwitcher.SwitchWorkspace(ctx, userID, argument)
+	if errors.Is(err, ErrUnknownWorkspace) && argument == translate(ctx, "workspace.personal") {
+		selected, err = u.workspaceSwitcher.SwitchWorkspace(ctx, userID, domain.PersonalWorkspaceID)
+	}
+	if errors.Is(err, ErrUnknownWorkspace) {
+		return &domain.MessageResponse{Text: translate(ctx, "workspace.unknown", argument)}
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to switch workspace", "error", err)
+		return &domain.MessageResponse{Text: translate(ctx, "workspace.failed")}
+	}
+	return &domain.MessageResponse{Text: translate(ctx, "workspace.switched", workspaceLabel(ctx, selected.Name))}
+}
+
+// addWorkspace adds a workspace with the name given, asking for one first,
+// and makes the user's messages go into it
+func (u *ProcessMessageUseCase) addWorkspace(ctx context.Context, userID, argument string) *domain.MessageResponse {
+	if u.workspaceSwitcher == nil {
+		return &domain.MessageResponse{Text: translate(ctx, "workspace.unavailable")}
+	}
+	if argument == "" {
+		if u.askConversation(ctx, userID, domain.MessageActionAddWorkspace, conversationSlotWorkspace, nil) {
+			return &domain.MessageResponse{Text: translate(ctx, "workspace.ask_name")}
+		}
+		return &domain.MessageResponse{Text: translate(ctx, "workspace.name_hint")}
+	}
+
+	workspace, err := u.workspaceSwitcher.CreateWorkspace(ctx, userID, argument)
+	if errors.Is(err, domain.ErrConflict) {
+		return &domain.MessageResponse{Text: translate(ctx, "workspace.exists", argument)}
+	}
+	if errors.Is(err, ErrInvalidWorkspace) {
+		return &domain.MessageResponse{Text: translate(ctx, "workspace.invalid_name", domain.MaxWorkspaceNameLength)}
+	}
+	if err == nil {
+		_, err = u.workspaceSwitcher.SwitchWorkspace(ctx, userID, workspace.ID)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to add workspace", "error", err)
+		return &domain.MessageResponse{Text: translate(ctx, "workspace.add_failed")}
+	}
+	return &domain.MessageResponse{Text: translate(ctx, "workspace.added", workspace.Name)}
+}
+
+// workspaceLabel names a workspace in replies, the personal one included
+func workspaceLabel(ctx context.Context, name string) string {
+	if name == "" {
+		return translate(ctx, "workspace.personal")
+	}
+	return name
+}
+
+// subscriptions lists the user's detected subscriptions to pick from, or with
+// a subscription's ID as the argument, tracks it as a recurring expense
+func (u *ProcessMessageUseCase) subscriptions(ctx context.Context, userID, argument string) *domain.MessageResponse {
+	if u.subscriptionDetector == nil {
+		return &domain.MessageResponse{Text: translate(ctx, "subscriptions.unavailable")}
+	}
+	if argument != "" {
+		confirmed, err := u.subscriptionDetector.ConfirmSubscription(ctx, userID, argument)
+		if errors.Is(err, domain.ErrNotFound) {
+			return &domain.MessageResponse{Text: translate(ctx, "subscriptions.gone")}
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to track subscription", "error", err)
+			return &domain.MessageResponse{Text: translate(ctx, "subscriptions.failed")}
+		}
+		s := confirmed.Subscription
+		return &domain.MessageResponse{Text: translate(ctx, "subscriptions.tracked", s.Description, formatMoney(ctx, s.Amount, s.Currency), i18n.FormatDate(i18n.FromContext(ctx), s.NextCharge))}
+	}
+
+	detected, err := u.subscriptionDetector.DetectSubscriptions(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to detect subscriptions", "error", err)
+		return &domain.MessageResponse{Text: translate(ctx, "subscriptions.failed")}
+	}
+	if len(detected.Detected) == 0 {
+		return &domain.MessageResponse{Text: translate(ctx, "subscriptions.none")}
+	}
+
+	lines := []string{translate(ctx, "subscriptions.found")}
+	ids := make([]string, len(detected.Detected))
+	resp := &domain.MessageResponse{}
+	for i, s := range detected.Detected {
+		ids[i] = s.ID
+		lines = append(lines, translate(ctx, "subscriptions.line", i+1, s.Description, formatMoney(ctx, s.Amount, s.Currency), i18n.FormatDate(i18n.FromContext(ctx), s.NextCharge)))
+		resp.Buttons = append(resp.Buttons, &domain.MessageButton{Label: s.Description, Action: domain.MessageActionSubscriptions, Value: s.ID})
+	}
+	if u.askConversation(ctx, userID, domain.MessageActionSubscriptions, conversationSlotSubscription, map[string]string{conversationSlotSubscriptions: strings.Join(ids, " ")}) {
+		lines = append(lines, translate(ctx, "subscriptions.pick_or_type"))
+	} else {
+		lines = append(lines, translate(ctx, "subscriptions.pick"))
+	}
+	resp.Text = strings.Join(lines, "\n")
+	return resp
+}
+
+// claimsSubmit is the argument of the 報帳清單 quick action that marks the
+// pending claims submitted
+const claimsSubmit = "submit"
+
+// maxClaimLines is how many claims the 報帳清單 reply lists
+const maxClaimLines = 10
+
+// claims lists the user's outstanding reimbursement claims, or with
+// claimsSubmit as the argument, marks the pending ones submitted
+func (u *ProcessMessageUseCase) claims(ctx context.Context, userID, argument string) *domain.MessageResponse {
+	if u.claimTracker == nil {
+		return &domain.MessageResponse{Text: translate(ctx, "claims.unavailable")}
+	}
+	outstanding, err := u.claimTracker.ListClaims(ctx, &ListClaimsRequest{
+		UserID:   userID,
+		Statuses: []string{domain.ClaimStatusPending, domain.ClaimStatusSubmitted},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to list claims", "error", err)
+		return &domain.MessageResponse{Text: translate(ctx, "claims.failed")}
+	}
+	locale := i18n.FromContext(ctx)
+
+	var pending []string
+	for _, claim := range outstanding.Claims {
+		if claim.Status == domain.ClaimStatusPending {
+			pending = append(pending, claim.ExpenseID)
+		}
+	}
+	if strings.EqualFold(argument, claimsSubmit) {
+		if len(pending) == 0 {
+			return &domain.MessageResponse{Text: translate(ctx, "claims.nothing_pending")}
+		}
+		if _, err := u.claimTracker.UpdateClaimStatus(ctx, userID, pending, domain.ClaimStatusSubmitted); err != nil {
+			slog.ErrorContext(ctx, "Failed to submit claims", "error", err)
+			return &domain.MessageResponse{Text: translate(ctx, "claims.failed")}
+		}
+		amount := outstanding.Totals[domain.ClaimStatusPending]
+		return &domain.MessageResponse{Text: translate(ctx, "claims.submitted", pluralizeExpenses(locale, len(pending)), formatMoney(ctx, amount, outstanding.HomeCurrency))}
+	}
+	if outstanding.Count == 0 {
+		return &domain.MessageResponse{Text: translate(ctx, "claims.none")}
+	}
+
+	lines := []string{translate(ctx, "claims.title", formatMoney(ctx, outstanding.Total, outstanding.HomeCurrency), pluralizeExpenses(locale, outstanding.Count))}
+	for i, claim := range outstanding.Claims {
+		if i == maxClaimLines {
+			lines = append(lines, translate(ctx, "claims.more", outstanding.Count-maxClaimLines))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("• [%s] %s: %s (%s)", i18n.FormatDate(locale, claim.Date), claim.Description, formatMoney(ctx, claim.HomeAmount, claim.HomeCurrency), translate(ctx, "claims.status."+claim.Status)))
+	}
+	resp := &domain.MessageResponse{Text: strings.Join(lines, "\n")}
+	if len(pending) > 0 {
+		resp.Buttons = []*domain.MessageButton{{Label: translate(ctx, "claims.submit_button"), Action: domain.MessageActionClaims, Value: claimsSubmit}}
+	}
+	return resp
+}
+
+// setTimezone changes the timezone the user's dates are read in, asking for
+// it when argument is empty
+func (u *ProcessMessageUseCase) setTimezone(ctx context.Context, userID, argument string) *domain.MessageResponse {
+	if u.timezones == nil {
+		return &domain.MessageResponse{Text: translate(ctx, "timezone.unsupported")}
+	}
+	if argument == "" {
+		if u.askConversation(ctx, userID, domain.MessageActionSetTimezone, conversationSlotTimezone, nil) {
+			return &domain.MessageResponse{Text: translate(ctx, "timezone.ask")}
+		}
+		return &domain.MessageResponse{Text: translate(ctx, "timezone.hint")}
+	}
+	loc, err := u.timezones.Set(ctx, userID, argument)
+	if errors.Is(err, ErrUnknownTimezone) {
+		return &domain.MessageResponse{Text: translate(ctx, "timezone.unknown", argument)}
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set timezone", "error", err)
+		return &domain.MessageResponse{Text: translate(ctx, "timezone.failed")}
+	}
+	return &domain.MessageResponse{Text: translate(ctx, "timezone.set", loc, time.Now().In(loc).Format("15:04"))}
+}
+
+// setLanguage changes the language the user is answered in, asking for it
+// when argument is empty. The confirmation is in the new language.
+func (u *ProcessMessageUseCase) setLanguage(ctx context.Context, userID, argument string) *domain.MessageResponse {
+	if u.locales == nil {
+		return &domain.MessageResponse{Text: translate(ctx, "language.unsupported")}
+	}
+	if argument == "" {
+		resp := &domain.MessageResponse{Text: translate(ctx, "language.hint")}
+		if u.askConversation(ctx, userID, domain.MessageActionSetLanguage, conversationSlotLanguage, nil) {
+			resp.Text = translate(ctx, "language.ask")
+		}
+		for _, l := range onboardingLocales {
+			resp.Buttons = append(resp.Buttons, &domain.MessageButton{Label: l.label, Action: domain.MessageActionSetLanguage, Value: l.locale})
+		}
+		return resp
+	}
+	locale, err := u.locales.SetLocale(ctx, userID, argument)
+	if errors.Is(err, ErrUnknownLocale) {
+		return &domain.MessageResponse{Text: translate(ctx, "language.unknown", argument)}
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set language", "error", err)
+		return &domain.MessageResponse{Text: translate(ctx, "language.failed")}
+	}
+	return &domain.MessageResponse{Text: i18n.T(locale, "language.set")}
+}
+
+// Slots of the clarification questions the quick actions ask
+const (
+	conversationSlotName      = "name"       // The name of the category to add
+	conversationSlotCategory  = "category"   // The name of the category to move an expense to
+	conversationSlotExpenseID = "expense_id" // The expense whose category is changed
+	conversationSlotTimezone  = "timezone"   // The timezone to set
+	conversationSlotLanguage  = "language"   // The language to answer in
+	// The number of the detected subscription to track
+	conversationSlotSubscription = "subscription"
+	// The IDs of the detected subscriptions offered, in order
+	conversationSlotSubscriptions = "subscriptions"
+	// The named budget to report on
+	conversationSlotBudget = "budget"
+	// The name of the workspace to switch to or add
+	conversationSlotWorkspace = "workspace"
+)
+
+// askConversation remembers the question the user is asked, reporting false
+// when their answer can't be taken from their next message
+func (u *ProcessMessageUseCase) askConversation(ctx context.Context, userID, intent, awaiting string, slots map[string]string) bool {
+	if u.conversation == nil {
+		return false
+	}
+	if err := u.conversation.Ask(ctx, userID, intent, awaiting, slots); err != nil {
+		slog.WarnContext(ctx, "Failed to save conversation state", "intent", intent, "error", err)
+		return false
+	}
+	return true
+}
+
+// clearConversation forgets the question the user was asked, if any
+func (u *ProcessMessageUseCase) clearConversation(ctx context.Context, userID string) {
+	if u.conversation == nil {
+		return
+	}
+	if err := u.conversation.Clear(ctx, userID); err != nil {
+		slog.WarnContext(ctx, "Failed to clear conversation state", "error", err)
+	}
+}
+
+// resumeConversation fills the open question's slot with the message and
+// runs its quick action, or drops the question when the message cancels it.
+// It returns false when there is no question or the message doesn't answer
+// it, which drops the question too so the message is processed as usual.
+func (u *ProcessMessageUseCase) resumeConversation(ctx context.Context, msg *domain.UserMessage, groupID *string) (string, *domain.MessageResponse, bool) {
+	if u.conversation == nil {
+		return "", nil, false
+	}
+	state, err := u.conversation.Pending(ctx, msg.UserID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get conversation state", "error", err)
+		return "", nil, false
+	}
+	if state == nil {
+		return "", nil, false
+	}
+	u.clearConversation(ctx, msg.UserID)
+
+	text := strings.TrimSpace(msg.Content)
+	if isCancellation(text) {
+		return domain.InteractionIntentCancel, &domain.MessageResponse{Text: translate(ctx, "conversation.cancelled")}, true
+	}
+
+	var argument string
+	switch state.Awaiting {
+	case conversationSlotName, conversationSlotTimezone, conversationSlotLanguage, conversationSlotBudget, conversationSlotWorkspace:
+		argument = text
+	case conversationSlotCategory:
+		categoryID := u.findCategoryID(ctx, msg.UserID, text)
+		if categoryID == "" {
+			return "", nil, false
+		}
+		argument = state.Slots[conversationSlotExpenseID] + " " + categoryID
+	case conversationSlotSubscription:
+		ids := strings.Fields(state.Slots[conversationSlotSubscriptions])
+		if n, err := strconv.Atoi(text); err == nil && n >= 1 && n <= len(ids) {
+			argument = ids[n-1]
+		}
+	}
+	if argument == "" || !messageActions[state.Intent] {
+		return "", nil, false
+	}
+	intent, resp := u.executeAction(ctx, msg, groupID, state.Intent, argument)
+	return intent, resp, true
+}
+
+// findCategoryID returns the ID of the user's category with the given name,
+// or "" when there is none
+func (u *ProcessMessageUseCase) findCategoryID(ctx context.Context, userID, name string) string {
+	if u.categoryManager == nil {
+		return ""
+	}
+	categories, err := u.categoryManager.ListCategories(ctx, &ListCategoriesRequest{UserID: userID})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to list categories", "error", err)
+		return ""
+	}
+	for _, c := range categories.Categories {
+		if strings.EqualFold(c.Name, name) {
+			return c.ID
+		}
+	}
+	return ""
+}
+
+// formatBudgetStatus lists each budget with its spending so far
+func formatBudgetStatus(ctx context.Context, status *GetBudgetStatusResponse) string {
+	if len(status.Budgets) == 0 {
+		return translate(ctx, "budget.none")
+	}
+
+	locale := i18n.FromContext(ctx)
+	var sb strings.Builder
+	sb.WriteString(budgetStatusTitle(ctx, status))
+	for _, b := range status.Budgets {
+		sb.WriteString(fmt.Sprintf("\n• %s (%s): %s / %s, %s", b.Category, budgetPeriodLabel(ctx, b), i18n.FormatNumber(locale, b.Spent), i18n.FormatNumber(locale, b.Limit), b.Message))
+	}
+	return sb.String()
+}
+
+// budgetStatusTitle heads the budget status, naming the named budget it is of
+func budgetStatusTitle(ctx context.Context, status *GetBudgetStatusResponse) string {
+	if status.Name == nil || *status.Name == "" {
+		return translate(ctx, "budget.title")
+	}
+	return translate(ctx, "budget.named_title", *status.Name)
+}
+
+// budgetPeriodLabel is the budget's period, or the dates of a custom one
+func budgetPeriodLabel(ctx context.Context, b BudgetStatus) string {
+	if b.Period != domain.BudgetPeriodCustom || b.StartDate == nil || b.EndDate == nil {
+		return b.Period
+	}
+	locale := i18n.FromContext(ctx)
+	return i18n.FormatDate(locale, *b.StartDate) + "–" + i18n.FormatDate(locale, *b.EndDate)
+}
+
+// budgetStatusBlock lays the budgets out as a table, or returns nil without any
+func budgetStatusBlock(ctx context.Context, status *GetBudgetStatusResponse) *domain.ContentBlock {
+	if len(status.Budgets) == 0 {
+		return nil
+	}
+
+	block := &domain.ContentBlock{
+		Type:  domain.ContentBlockTable,
+		Title: budgetStatusTitle(ctx, status),
+		Columns: []string{
+			translate(ctx, "budget.column.category"),
+			translate(ctx, "budget.column.period"),
+			translate(ctx, "budget.column.spent"),
+			translate(ctx, "budget.column.limit"),
+			translate(ctx, "budget.column.used"),
+		},
+	}
+	locale := i18n.FromContext(ctx)
+	for _, b := range status.Budgets {
+		used := fmt.Sprintf("%.0f%%", b.Percentage)
+		if b.IsExceeded {
+			used += " ⚠️"
+		}
+		block.Rows = append(block.Rows, []string{b.Category, budgetPeriodLabel(ctx, b), i18n.FormatNumber(locale, b.Spent), i18n.FormatNumber(locale, b.Limit), used})
+	}
+	return block
+}
+
+// formatCategoryList lists the category names, the user's own after the defaults
+func formatCategoryList(ctx context.Context, categories []*CategoryResponse) string {
+	if len(categories) == 0 {
+		return translate(ctx, "categories.none")
+	}
+
+	var defaults, custom []string
+	for _, c := range categories {
+		if c.IsDefault {
+			defaults = append(defaults, c.Name)
+		} else {
+			custom = append(custom, c.Name)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(ctx, "categories.title"))
+	if len(defaults) > 0 {
+		sb.WriteString("\n" + strings.Join(defaults, ", "))
+	}
+	if len(custom) > 0 {
+		sb.WriteString("\n" + translate(ctx, "categories.yours", strings.Join(custom, ", ")))
+	}
+	return sb.String()
+}
@@ -2,21 +2,146 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/riverlin/aiexpense/internal/ai"
 	"github.com/riverlin/aiexpense/internal/domain"
 )
 
 // MetricsUseCase handles metrics aggregation and reporting
 type MetricsUseCase struct {
 	metricsRepo domain.MetricsRepository
+	rollups     domain.MetricsRollupRepository
+
+	// Events seen on the event bus since the server started
+	mu          sync.Mutex
+	eventCounts map[string]int
+	countsSince time.Time
+
+	// Connection pool of the database the repositories use
+	dbDriver string
+	dbStats  func() sql.DBStats
+
+	// Circuit breakers of the AI providers
+	aiProviderHealth func() []ai.ProviderHealth
+
+	now func() time.Time
 }
 
 // NewMetricsUseCase creates a new metrics use case
 func NewMetricsUseCase(metricsRepo domain.MetricsRepository) *MetricsUseCase {
 	return &MetricsUseCase{
 		metricsRepo: metricsRepo,
+		eventCounts: make(map[string]int),
+		countsSince: time.Now(),
+		now:         time.Now,
+	}
+}
+
+// EventCountsResponse represents how many of each event were published
+type EventCountsResponse struct {
+	Since  time.Time      `json:"since"`
+	Counts map[string]int `json:"counts"`
+}
+
+// RecordEvent counts an event from the event bus
+func (u *MetricsUseCase) RecordEvent(ctx context.Context, userID string, event UserEvent) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.eventCounts[event.Type]++
+}
+
+// GetEventCounts retrieves the event counts since the server started
+func (u *MetricsUseCase) GetEventCounts() *EventCountsResponse {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	counts := make(map[string]int, len(u.eventCounts))
+	for eventType, count := range u.eventCounts {
+		counts[eventType] = count
+	}
+	return &EventCountsResponse{Since: u.countsSince, Counts: counts}
+}
+
+// SetDBStats reports the connection pool of the named database driver
+func (u *MetricsUseCase) SetDBStats(driver string, stats func() sql.DBStats) {
+	u.dbDriver = driver
+	u.dbStats = stats
+}
+
+// DBPoolStatsResponse represents the state of the database connection pool
+type DBPoolStatsResponse struct {
+	Driver             string `json:"driver"`
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDurationMS     int64  `json:"wait_duration_ms"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
+
+// GetDBPoolStats retrieves the current database connection pool statistics
+func (u *MetricsUseCase) GetDBPoolStats() (*DBPoolStatsResponse, error) {
+	if u.dbStats == nil {
+		return nil, errors.New("database pool statistics are not available")
+	}
+	stats := u.dbStats()
+	return &DBPoolStatsResponse{
+		Driver:             u.dbDriver,
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMS:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
+}
+
+// SetAIProviderHealth reports the circuit breakers of the AI providers
+func (u *MetricsUseCase) SetAIProviderHealth(health func() []ai.ProviderHealth) {
+	u.aiProviderHealth = health
+}
+
+// GetAIProviderHealth retrieves the health of each AI provider
+func (u *MetricsUseCase) GetAIProviderHealth() ([]ai.ProviderHealth, error) {
+	if u.aiProviderHealth == nil {
+		return nil, errors.New("AI provider health is not available")
 	}
+	return u.aiProviderHealth(), nil
+}
+
+// SetRollups makes the daily active users and expense summary read the
+// metrics aggregator's precomputed rows instead of scanning the raw tables
+func (u *MetricsUseCase) SetRollups(rollups domain.MetricsRollupRepository) {
+	u.rollups = rollups
+}
+
+// rolledUpDays converts the precomputed rows of from..to into daily metrics,
+// newest first like the raw queries, leaving out days convert returns nil for
+func (u *MetricsUseCase) rolledUpDays(ctx context.Context, from, to time.Time, convert func(*domain.MetricsRollup) *domain.DailyMetrics) ([]*domain.DailyMetrics, error) {
+	rollups, err := u.rollups.GetRange(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var metrics []*domain.DailyMetrics
+	for i := len(rollups) - 1; i >= 0; i-- {
+		if m := convert(rollups[i]); m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
 }
 
 // DailyActiveUsersRequest represents a request for DAU metrics
@@ -40,7 +165,18 @@ func (u *MetricsUseCase) GetDailyActiveUsers(ctx context.Context, req *DailyActi
 	to := time.Now()
 	from := to.AddDate(0, 0, -req.Days)
 
-	metrics, err := u.metricsRepo.GetDailyActiveUsers(ctx, from, to)
+	var metrics []*domain.DailyMetrics
+	var err error
+	if u.rollups != nil {
+		metrics, err = u.rolledUpDays(ctx, from, to, func(r *domain.MetricsRollup) *domain.DailyMetrics {
+			if r.ActiveUsers == 0 {
+				return nil
+			}
+			return &domain.DailyMetrics{Date: r.Date, ActiveUsers: r.ActiveUsers}
+		})
+	} else {
+		metrics, err = u.metricsRepo.GetDailyActiveUsers(ctx, from, to)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +222,23 @@ func (u *MetricsUseCase) GetExpensesSummary(ctx context.Context, req *ExpensesSu
 	to := time.Now()
 	from := to.AddDate(0, 0, -req.Days)
 
-	metrics, err := u.metricsRepo.GetExpensesSummary(ctx, from, to)
+	var metrics []*domain.DailyMetrics
+	var err error
+	if u.rollups != nil {
+		metrics, err = u.rolledUpDays(ctx, from, to, func(r *domain.MetricsRollup) *domain.DailyMetrics {
+			if r.ExpenseCount == 0 {
+				return nil
+			}
+			return &domain.DailyMetrics{
+				Date:           r.Date,
+				TotalExpense:   r.TotalExpense,
+				ExpenseCount:   r.ExpenseCount,
+				AverageExpense: r.TotalExpense / float64(r.ExpenseCount),
+			}
+		})
+	} else {
+		metrics, err = u.metricsRepo.GetExpensesSummary(ctx, from, to)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -167,6 +319,132 @@ func (u *MetricsUseCase) GetCategoryTrends(ctx context.Context, req *CategoryTre
 	}, nil
 }
 
+// Category trend buckets
+const (
+	TrendBucketWeek  = "week"  // Weeks starting on Monday, UTC
+	TrendBucketMonth = "month" // Calendar months, UTC
+)
+
+// Category trend defaults and limits, in buckets
+const (
+	DefaultTrendPeriods = 12
+	maxTrendWeeks       = 52
+	maxTrendMonths      = 24
+)
+
+// ErrInvalidTrendQuery is returned for an unknown bucket or too many periods
+var ErrInvalidTrendQuery = errors.New("bucket must be week or month, with at most 52 weeks or 24 months")
+
+// CategoryTrendSeriesRequest asks for a user's spending per category in
+// each of the last Periods weeks or months, the current one included
+type CategoryTrendSeriesRequest struct {
+	UserID  string
+	Bucket  string // TrendBucketWeek or TrendBucketMonth (default)
+	Periods int    // Number of buckets (default: 12)
+}
+
+// CategoryTrendSeriesResponse holds one series per category, with a value
+// for each bucket, ready to be charted
+type CategoryTrendSeriesResponse struct {
+	Bucket  string                 `json:"bucket"`
+	Buckets []time.Time            `json:"buckets"` // Start of each bucket, oldest first
+	Series  []*CategoryTrendSeries `json:"series"`  // Largest total first
+}
+
+// CategoryTrendSeries is a category's spending in each bucket
+type CategoryTrendSeries struct {
+	CategoryID string    `json:"category_id"`
+	Category   string    `json:"category"`
+	Totals     []float64 `json:"totals"` // Home currency, one per bucket
+	Counts     []int     `json:"counts"`
+	Total      float64   `json:"total"`
+}
+
+// GetCategoryTrendSeries buckets the user's category totals by week or
+// month. Categories the user spent nothing in over the whole range are left
+// out.
+func (u *MetricsUseCase) GetCategoryTrendSeries(ctx context.Context, req *CategoryTrendSeriesRequest) (*CategoryTrendSeriesResponse, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	bucket, periods := req.Bucket, req.Periods
+	if bucket == "" {
+		bucket = TrendBucketMonth
+	}
+	if periods == 0 {
+		periods = DefaultTrendPeriods
+	}
+	limit := maxTrendMonths
+	if bucket == TrendBucketWeek {
+		limit = maxTrendWeeks
+	}
+	if (bucket != TrendBucketWeek && bucket != TrendBucketMonth) || periods < 0 || periods > limit {
+		return nil, ErrInvalidTrendQuery
+	}
+
+	buckets := trendBuckets(bucket, periods, u.now())
+	byCategory := make(map[string]*CategoryTrendSeries)
+	var series []*CategoryTrendSeries
+	for i, start := range buckets {
+		end := nextTrendBucket(bucket, start)
+		metrics, err := u.metricsRepo.GetCategoryTrends(ctx, req.UserID, start, end.Add(-time.Nanosecond))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range metrics {
+			s, ok := byCategory[m.CategoryID]
+			if !ok {
+				s = &CategoryTrendSeries{
+					CategoryID: m.CategoryID,
+					Category:   m.Category,
+					Totals:     make([]float64, len(buckets)),
+					Counts:     make([]int, len(buckets)),
+				}
+				byCategory[m.CategoryID] = s
+				series = append(series, s)
+			}
+			s.Totals[i] += m.Total
+			s.Counts[i] += m.Count
+			s.Total += m.Total
+		}
+	}
+
+	series = slices.DeleteFunc(series, func(s *CategoryTrendSeries) bool { return s.Total == 0 })
+	sort.SliceStable(series, func(i, j int) bool { return series[i].Total > series[j].Total })
+	return &CategoryTrendSeriesResponse{
+		Bucket:  bucket,
+		Buckets: buckets,
+		Series:  series,
+	}, nil
+}
+
+// trendBuckets returns the starts of the last periods weeks or months up to
+// the one containing now, oldest first
+func trendBuckets(bucket string, periods int, now time.Time) []time.Time {
+	current := domain.MetricsWeek(now)
+	if bucket == TrendBucketMonth {
+		day := domain.MetricsDay(now)
+		current = day.AddDate(0, 0, 1-day.Day())
+	}
+	buckets := make([]time.Time, periods)
+	for i := range buckets {
+		if bucket == TrendBucketWeek {
+			buckets[i] = current.AddDate(0, 0, -7*(periods-1-i))
+		} else {
+			buckets[i] = current.AddDate(0, -(periods - 1 - i), 0)
+		}
+	}
+	return buckets
+}
+
+// nextTrendBucket returns the start of the bucket after the one starting at start
+func nextTrendBucket(bucket string, start time.Time) time.Time {
+	if bucket == TrendBucketWeek {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
 // GrowthMetricsRequest represents a request for growth metrics
 type GrowthMetricsRequest struct {
 	Days int // Number of days to retrieve (default: 30)
@@ -217,3 +495,155 @@ func (u *MetricsUseCase) GetGrowthMetrics(ctx context.Context, req *GrowthMetric
 
 	return resp, nil
 }
+
+// PlatformMetricsRequest represents a request for per-platform metrics
+type PlatformMetricsRequest struct {
+	Days int // Number of days to retrieve (default: 30)
+}
+
+// PlatformMetricsResponse represents metrics broken down by messenger platform
+type PlatformMetricsResponse struct {
+	Data          []*domain.PlatformMetrics `json:"data"`
+	TotalSignups  int                       `json:"total_signups"`
+	TotalMessages int                       `json:"total_messages"`
+	TotalAICost   float64                   `json:"total_ai_cost"`
+}
+
+// GetPlatformMetrics retrieves signups, active users, message volume and AI
+// cost per messenger platform
+func (u *MetricsUseCase) GetPlatformMetrics(ctx context.Context, req *PlatformMetricsRequest) (*PlatformMetricsResponse, error) {
+	if req.Days == 0 {
+		req.Days = 30
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -req.Days)
+
+	platforms, err := u.metricsRepo.GetPlatformMetrics(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if platforms == nil {
+		platforms = []*domain.PlatformMetrics{}
+	}
+
+	resp := &PlatformMetricsResponse{Data: platforms}
+	for _, p := range platforms {
+		resp.TotalSignups += p.Signups
+		resp.TotalMessages += p.Messages
+		resp.TotalAICost += p.AICost
+	}
+	return resp, nil
+}
+
+// DefaultRetentionWeeks and MaxRetentionWeeks bound how many weekly signup
+// cohorts a retention request covers
+const (
+	DefaultRetentionWeeks = 8
+	MaxRetentionWeeks     = 52
+)
+
+// RetentionRequest represents a request for cohort retention
+type RetentionRequest struct {
+	Weeks int // Number of weekly cohorts, the current week included (default: 8)
+}
+
+// RetentionCohort represents the users who signed up in one week and how
+// many of them were active in each week since
+type RetentionCohort struct {
+	WeekStart        time.Time `json:"week_start"`
+	Users            int       `json:"users"`
+	Retained         []int     `json:"retained"` // Index N counts the users active N weeks after their signup week
+	RetentionPercent []float64 `json:"retention_percent"`
+}
+
+// RetentionResponse represents weekly cohort retention and churn
+type RetentionResponse struct {
+	Cohorts []*RetentionCohort `json:"cohorts"`
+
+	// Churn compares the last two complete weeks: the users active in the
+	// earlier week who were not active in the later one
+	PreviousWeekActive int     `json:"previous_week_active"`
+	ChurnedUsers       int     `json:"churned_users"`
+	ChurnRate          float64 `json:"churn_rate"`
+}
+
+// GetRetention retrieves weekly signup cohorts, the share of each active in
+// the weeks after signup, and last week's churn rate. Weeks start on Monday,
+// UTC; a user is active in a week they recorded an expense or made an AI call.
+func (u *MetricsUseCase) GetRetention(ctx context.Context, req *RetentionRequest) (*RetentionResponse, error) {
+	if req.Weeks <= 0 {
+		req.Weeks = DefaultRetentionWeeks
+	}
+	if req.Weeks > MaxRetentionWeeks {
+		req.Weeks = MaxRetentionWeeks
+	}
+
+	thisWeek := domain.MetricsWeek(u.now())
+	firstCohort := thisWeek.AddDate(0, 0, -7*(req.Weeks-1))
+	lastWeek := thisWeek.AddDate(0, 0, -7)
+	previousWeek := thisWeek.AddDate(0, 0, -14)
+
+	since := firstCohort
+	if previousWeek.Before(since) {
+		since = previousWeek
+	}
+	activity, err := u.metricsRepo.GetUserActivity(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	cohorts := make(map[time.Time]*RetentionCohort, req.Weeks)
+	resp := &RetentionResponse{}
+	for week := firstCohort; !week.After(thisWeek); week = week.AddDate(0, 0, 7) {
+		elapsed := int(thisWeek.Sub(week).Hours()/24/7) + 1
+		cohort := &RetentionCohort{
+			WeekStart:        week,
+			Retained:         make([]int, elapsed),
+			RetentionPercent: make([]float64, elapsed),
+		}
+		cohorts[week] = cohort
+		resp.Cohorts = append(resp.Cohorts, cohort)
+	}
+
+	for _, user := range activity {
+		activeWeeks := make(map[time.Time]bool)
+		for _, day := range user.ActiveDays {
+			activeWeeks[domain.MetricsWeek(day)] = true
+		}
+
+		if activeWeeks[previousWeek] {
+			resp.PreviousWeekActive++
+			if !activeWeeks[lastWeek] {
+				resp.ChurnedUsers++
+			}
+		}
+
+		signupWeek := domain.MetricsWeek(user.SignedUpAt)
+		cohort, ok := cohorts[signupWeek]
+		if !ok {
+			continue
+		}
+		cohort.Users++
+		for week := range activeWeeks {
+			offset := int(week.Sub(signupWeek).Hours() / 24 / 7)
+			if offset >= 0 && offset < len(cohort.Retained) {
+				cohort.Retained[offset]++
+			}
+		}
+	}
+
+	for _, cohort := range resp.Cohorts {
+		if cohort.Users == 0 {
+			continue
+		}
+		for i, retained := range cohort.Retained {
+			cohort.RetentionPercent[i] = float64(retained) / float64(cohort.Users) * 100
+		}
+	}
+	if resp.PreviousWeekActive > 0 {
+		resp.ChurnRate = float64(resp.ChurnedUsers) / float64(resp.PreviousWeekActive) * 100
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// DefaultMetricsRollupDays is how many days back the aggregator keeps rolled
+// up, matching the 30-day windows the metrics endpoints read
+const DefaultMetricsRollupDays = 30
+
+// MetricsAggregator rolls up each day's active users, expense totals and AI
+// cost into the daily metrics table, so the metrics endpoints read one row
+// per day instead of scanning the raw tables.
+//
+// Today and yesterday are recomputed on every run since they are still
+// changing; an older day is recomputed when an expense dated on it changes.
+type MetricsAggregator struct {
+	repo domain.MetricsRollupRepository
+	days int
+	now  func() time.Time
+
+	mu    sync.Mutex
+	stale map[time.Time]bool
+}
+
+// NewMetricsAggregator creates a metrics aggregator
+func NewMetricsAggregator(repo domain.MetricsRollupRepository) *MetricsAggregator {
+	return &MetricsAggregator{
+		repo:  repo,
+		days:  DefaultMetricsRollupDays,
+		now:   time.Now,
+		stale: make(map[time.Time]bool),
+	}
+}
+
+// RollUp recomputes and stores the rollup of the UTC day containing day
+func (a *MetricsAggregator) RollUp(ctx context.Context, day time.Time) (*domain.MetricsRollup, error) {
+	rollup, err := a.repo.ComputeDay(ctx, domain.MetricsDay(day))
+	if err != nil {
+		return nil, err
+	}
+	rollup.UpdatedAt = a.now()
+	if err := a.repo.Upsert(ctx, rollup); err != nil {
+		return nil, err
+	}
+	return rollup, nil
+}
+
+// HandleExpenseEvent marks the day of a changed expense stale, so the next
+// run recomputes it even when it is older than yesterday
+func (a *MetricsAggregator) HandleExpenseEvent(ctx context.Context, userID string, event UserEvent) {
+	data, ok := event.Data.(*ExpenseEvent)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.stale[domain.MetricsDay(data.ExpenseDate)] = true
+}
+
+// Refresh rolls up today, yesterday, the days marked stale and any day of
+// the rollup window without a row. It returns how many days it rolled up.
+func (a *MetricsAggregator) Refresh(ctx context.Context) (int, error) {
+	today := domain.MetricsDay(a.now())
+	from := today.AddDate(0, 0, 1-a.days)
+
+	existing, err := a.repo.GetRange(ctx, from, today)
+	if err != nil {
+		return 0, err
+	}
+	rolledUp := make(map[time.Time]bool, len(existing))
+	for _, rollup := range existing {
+		rolledUp[domain.MetricsDay(rollup.Date)] = true
+	}
+
+	a.mu.Lock()
+	stale := a.stale
+	a.stale = make(map[time.Time]bool)
+	a.mu.Unlock()
+
+	yesterday := today.AddDate(0, 0, -1)
+	due := make(map[time.Time]bool)
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if !rolledUp[day] || !day.Before(yesterday) {
+			due[day] = true
+		}
+	}
+	for day := range stale {
+		if !day.After(today) {
+			due[day] = true
+		}
+	}
+
+	count := 0
+	for day := range due {
+		if _, err := a.RollUp(ctx, day); err != nil {
+			// Keep the stale days so the next run retries them
+			a.mu.Lock()
+			for day := range stale {
+				a.stale[day] = true
+			}
+			a.mu.Unlock()
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Run refreshes the rollup now and then once per interval until ctx is done
+func (a *MetricsAggregator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := a.Refresh(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to roll up daily metrics", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestMetricsAggregator(t *testing.T) {
+	ctx := context.Background()
+	// The metrics use cases read windows ending at the current time
+	now := time.Now()
+	today := domain.MetricsDay(now)
+
+	setup := func() (*MetricsAggregator, *MockMetricsRollupRepository) {
+		repo := NewMockMetricsRollupRepository()
+		aggregator := NewMetricsAggregator(repo)
+		aggregator.now = func() time.Time { return now }
+		return aggregator, repo
+	}
+
+	t.Run("Backfills the window, then refreshes recent and stale days", func(t *testing.T) {
+		aggregator, repo := setup()
+
+		n, err := aggregator.Refresh(ctx)
+		if err != nil || n != DefaultMetricsRollupDays {
+			t.Fatalf("expected %d days rolled up, got %d, %v", DefaultMetricsRollupDays, n, err)
+		}
+
+		repo.Computed = nil
+		if n, _ := aggregator.Refresh(ctx); n != 2 {
+			t.Errorf("expected only today and yesterday recomputed, got %d: %v", n, repo.Computed)
+		}
+
+		weekAgo := today.AddDate(0, 0, -7)
+		aggregator.HandleExpenseEvent(ctx, "U1", UserEvent{
+			Type: domain.EventExpenseCreated,
+			Data: &ExpenseEvent{ExpenseDate: weekAgo.Add(9 * time.Hour)},
+		})
+		repo.Computed = nil
+		if n, _ := aggregator.Refresh(ctx); n != 3 {
+			t.Fatalf("expected the stale day recomputed too, got %d: %v", n, repo.Computed)
+		}
+		found := false
+		for _, day := range repo.Computed {
+			found = found || day.Equal(weekAgo)
+		}
+		if !found {
+			t.Errorf("expected %v recomputed, got %v", weekAgo, repo.Computed)
+		}
+	})
+
+	t.Run("Metrics read the rolled up days", func(t *testing.T) {
+		aggregator, repo := setup()
+		yesterday := today.AddDate(0, 0, -1)
+		repo.Raw[today] = domain.MetricsRollup{ActiveUsers: 3, TotalExpense: 90, ExpenseCount: 3, AICalls: 2, AITotalTokens: 500, AICost: 0.02}
+		repo.Raw[yesterday] = domain.MetricsRollup{ActiveUsers: 1, TotalExpense: 10, ExpenseCount: 1}
+		if _, err := aggregator.Refresh(ctx); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+
+		metricsUC := NewMetricsUseCase(nil)
+		metricsUC.SetRollups(repo)
+		dau, err := metricsUC.GetDailyActiveUsers(ctx, &DailyActiveUsersRequest{})
+		if err != nil {
+			t.Fatalf("GetDailyActiveUsers failed: %v", err)
+		}
+		if len(dau.Data) != 2 || dau.TotalActiveUsers != 4 || !dau.Data[0].Date.Equal(today) {
+			t.Errorf("expected 2 active days newest first, got %+v", dau)
+		}
+
+		expenses, err := metricsUC.GetExpensesSummary(ctx, &ExpensesSummaryRequest{})
+		if err != nil {
+			t.Fatalf("GetExpensesSummary failed: %v", err)
+		}
+		if expenses.TotalExpenses != 100 || expenses.TotalTransactions != 4 || expenses.Data[0].AverageExpense != 30 {
+			t.Errorf("unexpected expense summary: %+v", expenses)
+		}
+
+		aiCostUC := NewAICostUseCase(nil)
+		aiCostUC.SetRollups(repo)
+		stats, err := aiCostUC.GetDailyStats(ctx, &AICostDailyRequest{})
+		if err != nil {
+			t.Fatalf("GetDailyStats failed: %v", err)
+		}
+		if len(stats) != 1 || stats[0].Calls != 2 || stats[0].TotalTokens != 500 {
+			t.Errorf("expected only the day with AI calls, got %+v", stats)
+		}
+	})
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestMetricsUseCase_GetRetention(t *testing.T) {
+	ctx := context.Background()
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC) }
+
+	repo := NewMockMetricsRepository()
+	repo.Activity = []*domain.UserActivity{
+		{UserID: "A", SignedUpAt: day(2, 25).Add(9 * time.Hour), ActiveDays: []time.Time{day(2, 25), day(3, 4), day(3, 11)}},
+		{UserID: "B", SignedUpAt: day(2, 26), ActiveDays: []time.Time{day(2, 26)}},
+		{UserID: "C", SignedUpAt: day(3, 5), ActiveDays: []time.Time{day(3, 5), day(3, 6)}},
+		// Signed up before the cohorts, still counts toward churn
+		{UserID: "D", SignedUpAt: day(1, 1), ActiveDays: []time.Time{day(2, 27), day(3, 4)}},
+	}
+	uc := NewMetricsUseCase(repo)
+	uc.now = func() time.Time { return day(3, 12).Add(15 * time.Hour) } // A Wednesday
+
+	resp, err := uc.GetRetention(ctx, &RetentionRequest{Weeks: 3})
+	if err != nil {
+		t.Fatalf("GetRetention failed: %v", err)
+	}
+	if len(resp.Cohorts) != 3 {
+		t.Fatalf("expected 3 cohorts, got %d", len(resp.Cohorts))
+	}
+
+	first := resp.Cohorts[0]
+	if !first.WeekStart.Equal(day(2, 24)) || first.Users != 2 {
+		t.Errorf("expected 2 users in the week of Feb 24, got %+v", first)
+	}
+	if len(first.Retained) != 3 || first.Retained[0] != 2 || first.Retained[1] != 1 || first.Retained[2] != 1 {
+		t.Errorf("unexpected retention of the first cohort: %v", first.Retained)
+	}
+	if first.RetentionPercent[1] != 50 {
+		t.Errorf("expected 50%% retained after a week, got %v", first.RetentionPercent)
+	}
+	if second := resp.Cohorts[1]; second.Users != 1 || len(second.Retained) != 2 || second.Retained[1] != 0 {
+		t.Errorf("unexpected second cohort: %+v", second)
+	}
+	if third := resp.Cohorts[2]; third.Users != 0 || len(third.Retained) != 1 {
+		t.Errorf("expected an empty current cohort, got %+v", third)
+	}
+
+	if resp.PreviousWeekActive != 3 || resp.ChurnedUsers != 1 {
+		t.Errorf("expected 1 of 3 users churned, got %d of %d", resp.ChurnedUsers, resp.PreviousWeekActive)
+	}
+	if resp.ChurnRate < 33.3 || resp.ChurnRate > 33.4 {
+		t.Errorf("expected a churn rate of 33.3%%, got %v", resp.ChurnRate)
+	}
+}
+
+func TestMetricsUseCase_GetPlatformMetrics(t *testing.T) {
+	repo := NewMockMetricsRepository()
+	repo.Platforms = []*domain.PlatformMetrics{
+		{Platform: "line", Signups: 4, ActiveUsers: 3, Messages: 20, AICalls: 18, AICost: 0.3},
+		{Platform: "telegram", Signups: 1, ActiveUsers: 1, Messages: 5, AICalls: 5, AICost: 0.1},
+	}
+	uc := NewMetricsUseCase(repo)
+
+	resp, err := uc.GetPlatformMetrics(context.Background(), &PlatformMetricsRequest{})
+	if err != nil {
+		t.Fatalf("GetPlatformMetrics failed: %v", err)
+	}
+	if len(resp.Data) != 2 || resp.TotalSignups != 5 || resp.TotalMessages != 25 {
+		t.Errorf("unexpected platform totals: %+v", resp)
+	}
+	if resp.TotalAICost < 0.39 || resp.TotalAICost > 0.41 {
+		t.Errorf("expected a total AI cost of 0.4, got %v", resp.TotalAICost)
+	}
+}
+
+func TestMetricsUseCase_GetCategoryTrendSeries(t *testing.T) {
+	ctx := context.Background()
+	food, transport, gifts := "food", "transport", "gifts"
+	repo := NewMockMetricsRepository()
+	repo.Categories = []*domain.Category{
+		{ID: food, UserID: "user1", Name: "餐飲"},
+		{ID: transport, UserID: "user1", Name: "交通"},
+		{ID: gifts, UserID: "user1", Name: "禮物"},
+	}
+	for i, e := range []struct {
+		category string
+		date     time.Time
+		amount   float64
+	}{
+		{food, time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC), 100},
+		{food, time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC), 150},
+		{food, time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC), 50},
+		{transport, time.Date(2025, 3, 11, 8, 0, 0, 0, time.UTC), 400},
+		// Before the first bucket
+		{gifts, time.Date(2024, 12, 31, 12, 0, 0, 0, time.UTC), 1000},
+	} {
+		repo.Expenses = append(repo.Expenses, &domain.Expense{
+			ID: string(rune('a' + i)), UserID: "user1", CategoryID: &e.category, HomeAmount: e.amount, ExpenseDate: e.date,
+		})
+	}
+	uc := NewMetricsUseCase(repo)
+	uc.now = func() time.Time { return time.Date(2025, 3, 12, 15, 0, 0, 0, time.UTC) } // A Wednesday
+
+	resp, err := uc.GetCategoryTrendSeries(ctx, &CategoryTrendSeriesRequest{UserID: "user1", Periods: 3})
+	if err != nil {
+		t.Fatalf("GetCategoryTrendSeries failed: %v", err)
+	}
+	if resp.Bucket != TrendBucketMonth || len(resp.Buckets) != 3 || !resp.Buckets[0].Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the months of January to March, got %s %v", resp.Bucket, resp.Buckets)
+	}
+	if len(resp.Series) != 2 {
+		t.Fatalf("expected the categories with spending, got %+v", resp.Series)
+	}
+	if s := resp.Series[0]; s.CategoryID != transport || s.Totals[2] != 400 || s.Total != 400 {
+		t.Errorf("expected transport first, got %+v", s)
+	}
+	if s := resp.Series[1]; s.Totals[0] != 100 || s.Totals[1] != 0 || s.Totals[2] != 200 || s.Counts[2] != 2 {
+		t.Errorf("unexpected food series: %+v", s)
+	}
+
+	resp, err = uc.GetCategoryTrendSeries(ctx, &CategoryTrendSeriesRequest{UserID: "user1", Bucket: TrendBucketWeek, Periods: 2})
+	if err != nil {
+		t.Fatalf("GetCategoryTrendSeries failed: %v", err)
+	}
+	if !resp.Buckets[0].Equal(time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)) || !resp.Buckets[1].Equal(time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the weeks starting Mar 3 and 10, got %v", resp.Buckets)
+	}
+	if s := resp.Series[1]; s.CategoryID != food || s.Totals[0] != 0 || s.Totals[1] != 50 {
+		t.Errorf("expected food only in the current week, the 2nd being a Sunday of the week before, got %+v", s)
+	}
+
+	for _, req := range []*CategoryTrendSeriesRequest{
+		{UserID: "user1", Bucket: "day"},
+		{UserID: "user1", Bucket: TrendBucketMonth, Periods: 25},
+		{UserID: "user1", Bucket: TrendBucketWeek, Periods: 53},
+	} {
+		if _, err := uc.GetCategoryTrendSeries(ctx, req); !errors.Is(err, ErrInvalidTrendQuery) {
+			t.Errorf("expected ErrInvalidTrendQuery for %+v, got %v", req, err)
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -22,12 +27,24 @@ func NewMockUserRepository() *MockUserRepository {
 }
 
 func (m *MockUserRepository) Create(ctx context.Context, user *domain.User) error {
+	if _, ok := m.users[user.UserID]; ok {
+		return domain.ErrConflict
+	}
 	m.users[user.UserID] = user
 	return nil
 }
 
 func (m *MockUserRepository) GetByID(ctx context.Context, userID string) (*domain.User, error) {
-	return m.users[userID], nil
+	user, ok := m.users[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return user, nil
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
+	m.users[user.UserID] = user
+	return nil
 }
 
 func (m *MockUserRepository) Exists(ctx context.Context, userID string) (bool, error) {
@@ -57,18 +74,25 @@ func NewMockCategoryRepository() *MockCategoryRepository {
 }
 
 func (m *MockCategoryRepository) Create(ctx context.Context, category *domain.Category) error {
+	if _, ok := m.categories[category.ID]; ok {
+		return domain.ErrConflict
+	}
 	m.categories[category.ID] = category
 	return nil
 }
 
 func (m *MockCategoryRepository) GetByID(ctx context.Context, id string) (*domain.Category, error) {
-	return m.categories[id], nil
+	category, ok := m.categories[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return category, nil
 }
 
 func (m *MockCategoryRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Category, error) {
 	var result []*domain.Category
 	for _, cat := range m.categories {
-		if cat.UserID == userID {
+		if cat.UserID == userID && domain.InWorkspace(ctx, cat.WorkspaceID) {
 			result = append(result, cat)
 		}
 	}
@@ -76,12 +100,18 @@ func (m *MockCategoryRepository) GetByUserID(ctx context.Context, userID string)
 }
 
 func (m *MockCategoryRepository) GetByUserIDAndName(ctx context.Context, userID, name string) (*domain.Category, error) {
+	var found *domain.Category
 	for _, cat := range m.categories {
-		if cat.UserID == userID && cat.Name == name {
-			return cat, nil
+		if cat.UserID == userID && cat.Name == name && domain.InWorkspace(ctx, cat.WorkspaceID) {
+			if found == nil || cat.WorkspaceID < found.WorkspaceID {
+				found = cat
+			}
 		}
 	}
-	return nil, nil
+	if found == nil {
+		return nil, domain.ErrNotFound
+	}
+	return found, nil
 }
 
 func (m *MockCategoryRepository) Update(ctx context.Context, category *domain.Category) error {
@@ -109,19 +139,96 @@ func (m *MockCategoryRepository) GetKeywordsByCategory(ctx context.Context, cate
 	return result, nil
 }
 
+func (m *MockCategoryRepository) GetKeywordsByUserID(ctx context.Context, userID string) ([]*domain.CategoryKeyword, error) {
+	var result []*domain.CategoryKeyword
+	for _, kw := range m.keywords {
+		if cat, ok := m.categories[kw.CategoryID]; ok && cat.UserID == userID && domain.InWorkspace(ctx, cat.WorkspaceID) {
+			result = append(result, kw)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Priority > result[j].Priority })
+	return result, nil
+}
+
+func (m *MockCategoryRepository) UpdateKeyword(ctx context.Context, keyword *domain.CategoryKeyword) error {
+	m.keywords[keyword.ID] = keyword
+	return nil
+}
+
 func (m *MockCategoryRepository) DeleteKeyword(ctx context.Context, id string) error {
 	delete(m.keywords, id)
 	return nil
 }
 
+// MockCategoryCorrectionRepository is a mock implementation for testing
+type MockCategoryCorrectionRepository struct {
+	corrections []*domain.CategoryCorrection
+}
+
+func NewMockCategoryCorrectionRepository() *MockCategoryCorrectionRepository {
+	return &MockCategoryCorrectionRepository{}
+}
+
+func (m *MockCategoryCorrectionRepository) Create(ctx context.Context, correction *domain.CategoryCorrection) error {
+	m.corrections = append(m.corrections, correction)
+	return nil
+}
+
+func (m *MockCategoryCorrectionRepository) GetRecentByUserID(ctx context.Context, userID string, limit int) ([]*domain.CategoryCorrection, error) {
+	var result []*domain.CategoryCorrection
+	for i := len(m.corrections) - 1; i >= 0 && len(result) < limit; i-- {
+		if m.corrections[i].UserID == userID {
+			result = append(result, m.corrections[i])
+		}
+	}
+	return result, nil
+}
+
+// MockExpenseAuditRepository is a mock implementation for testing. Like the real
+// repositories it keeps copies of the snapshots.
+type MockExpenseAuditRepository struct {
+	entries []*domain.ExpenseAuditEntry
+}
+
+func NewMockExpenseAuditRepository() *MockExpenseAuditRepository {
+	return &MockExpenseAuditRepository{}
+}
+
+func (m *MockExpenseAuditRepository) Create(ctx context.Context, entry *domain.ExpenseAuditEntry) error {
+	stored := *entry
+	if entry.Before != nil {
+		before := *entry.Before
+		stored.Before = &before
+	}
+	if entry.After != nil {
+		after := *entry.After
+		stored.After = &after
+	}
+	m.entries = append(m.entries, &stored)
+	return nil
+}
+
+func (m *MockExpenseAuditRepository) GetByExpenseID(ctx context.Context, expenseID string) ([]*domain.ExpenseAuditEntry, error) {
+	var result []*domain.ExpenseAuditEntry
+	for _, entry := range m.entries {
+		if entry.ExpenseID == expenseID {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
 // MockExpenseRepository is a mock implementation for testing
 type MockExpenseRepository struct {
 	expenses map[string]*domain.Expense
+	deleted  map[string]*domain.Expense
+	Batches  int // CreateBatch calls
 }
 
 func NewMockExpenseRepository() *MockExpenseRepository {
 	return &MockExpenseRepository{
 		expenses: make(map[string]*domain.Expense),
+		deleted:  make(map[string]*domain.Expense),
 	}
 }
 
@@ -130,37 +237,144 @@ func (m *MockExpenseRepository) Create(ctx context.Context, expense *domain.Expe
 	return nil
 }
 
+func (m *MockExpenseRepository) CreateBatch(ctx context.Context, expenses []*domain.Expense) error {
+	m.Batches++
+	for _, expense := range expenses {
+		if _, ok := m.expenses[expense.ID]; ok {
+			return domain.ErrConflict
+		}
+	}
+	for _, expense := range expenses {
+		m.expenses[expense.ID] = expense
+	}
+	return nil
+}
+
 func (m *MockExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Expense, error) {
-	return m.expenses[id], nil
+	expense, ok := m.expenses[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return expense, nil
 }
 
 func (m *MockExpenseRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Expense, error) {
 	var result []*domain.Expense
 	for _, exp := range m.expenses {
-		if exp.UserID == userID {
+		if exp.UserID == userID && domain.InWorkspace(ctx, exp.WorkspaceID) {
 			result = append(result, exp)
 		}
 	}
 	return result, nil
 }
 
+func (m *MockExpenseRepository) ListByUserID(ctx context.Context, userID string, opts domain.ExpenseListOptions) ([]*domain.Expense, error) {
+	expenses, _ := m.GetByUserID(ctx, userID)
+
+	less := func(a, b *domain.Expense) bool {
+		switch opts.SortBy {
+		case domain.ExpenseSortAmount:
+			if a.HomeAmount != b.HomeAmount {
+				return a.HomeAmount < b.HomeAmount
+			}
+		case domain.ExpenseSortCreatedAt:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+		default:
+			if !a.ExpenseDate.Equal(b.ExpenseDate) {
+				return a.ExpenseDate.Before(b.ExpenseDate)
+			}
+		}
+		return a.ID < b.ID
+	}
+	if opts.SortDir != domain.SortAsc {
+		asc := less
+		less = func(a, b *domain.Expense) bool { return asc(b, a) }
+	}
+	sort.Slice(expenses, func(i, j int) bool { return less(expenses[i], expenses[j]) })
+
+	if opts.AfterID != "" {
+		cursor := m.expenses[opts.AfterID]
+		var after []*domain.Expense
+		for _, exp := range expenses {
+			if cursor != nil && less(cursor, exp) {
+				after = append(after, exp)
+			}
+		}
+		expenses = after
+	}
+	if opts.Offset >= len(expenses) {
+		return nil, nil
+	}
+	expenses = expenses[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(expenses) {
+		expenses = expenses[:opts.Limit]
+	}
+	return expenses, nil
+}
+
 func (m *MockExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, from, to time.Time) ([]*domain.Expense, error) {
 	var result []*domain.Expense
 	for _, exp := range m.expenses {
-		if exp.UserID == userID && exp.ExpenseDate.After(from) && exp.ExpenseDate.Before(to) {
+		if exp.UserID == userID && domain.InWorkspace(ctx, exp.WorkspaceID) && !exp.ExpenseDate.Before(from) && !exp.ExpenseDate.After(to) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockExpenseRepository) GetByGroupIDAndDateRange(ctx context.Context, groupID string, from, to time.Time) ([]*domain.Expense, error) {
+	var result []*domain.Expense
+	for _, exp := range m.expenses {
+		if exp.GroupID != nil && *exp.GroupID == groupID && exp.ExpenseDate.After(from) && exp.ExpenseDate.Before(to) {
 			result = append(result, exp)
 		}
 	}
 	return result, nil
 }
 
+func (m *MockExpenseRepository) selectTotals(ctx context.Context, query domain.ExpenseTotalsQuery) []*domain.Expense {
+	var selected []*domain.Expense
+	for _, exp := range m.expenses {
+		if query.Matches(exp) && (query.GroupID != "" || domain.InWorkspace(ctx, exp.WorkspaceID)) {
+			selected = append(selected, exp)
+		}
+	}
+	return selected
+}
+
+func (m *MockExpenseRepository) SumByCategoryAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.CategoryTotal, error) {
+	return domain.SumExpensesByCategory(m.selectTotals(ctx, query)), nil
+}
+
+func (m *MockExpenseRepository) SumByPeriodAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery, period string) ([]*domain.PeriodTotal, error) {
+	return domain.SumExpensesByPeriod(m.selectTotals(ctx, query), period), nil
+}
+
 func (m *MockExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID, categoryID string) ([]*domain.Expense, error) {
 	var result []*domain.Expense
 	for _, exp := range m.expenses {
-		if exp.UserID == userID && exp.CategoryID != nil && *exp.CategoryID == categoryID {
+		if exp.UserID == userID && domain.InWorkspace(ctx, exp.WorkspaceID) && exp.CategoryID != nil && *exp.CategoryID == categoryID {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockExpenseRepository) Filter(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, error) {
+	var result []*domain.Expense
+	for _, exp := range m.expenses {
+		if filter.Matches(exp) && domain.InWorkspace(ctx, exp.WorkspaceID) {
 			result = append(result, exp)
 		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].ExpenseDate.Equal(result[j].ExpenseDate) {
+			return result[i].ExpenseDate.After(result[j].ExpenseDate)
+		}
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
 	return result, nil
 }
 
@@ -170,13 +384,233 @@ func (m *MockExpenseRepository) Update(ctx context.Context, expense *domain.Expe
 }
 
 func (m *MockExpenseRepository) Delete(ctx context.Context, id string) error {
-	delete(m.expenses, id)
+	if expense, ok := m.expenses[id]; ok {
+		now := time.Now()
+		expense.DeletedAt = &now
+		m.deleted[id] = expense
+		delete(m.expenses, id)
+	}
+	return nil
+}
+
+func (m *MockExpenseRepository) GetDeletedByID(ctx context.Context, id string) (*domain.Expense, error) {
+	expense, ok := m.deleted[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return expense, nil
+}
+
+func (m *MockExpenseRepository) Restore(ctx context.Context, id string) error {
+	if expense, ok := m.deleted[id]; ok {
+		expense.DeletedAt = nil
+		m.expenses[id] = expense
+		delete(m.deleted, id)
+	}
+	return nil
+}
+
+// MockBudgetRepository is a mock implementation for testing
+type MockBudgetRepository struct {
+	budgets map[string]*domain.Budget
+}
+
+func NewMockBudgetRepository() *MockBudgetRepository {
+	return &MockBudgetRepository{
+		budgets: make(map[string]*domain.Budget),
+	}
+}
+
+func (m *MockBudgetRepository) Create(ctx context.Context, budget *domain.Budget) error {
+	m.budgets[budget.ID] = budget
+	return nil
+}
+
+func (m *MockBudgetRepository) GetByID(ctx context.Context, id string) (*domain.Budget, error) {
+	budget, ok := m.budgets[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return budget, nil
+}
+
+func (m *MockBudgetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Budget, error) {
+	var result []*domain.Budget
+	for _, b := range m.budgets {
+		if b.UserID == userID && b.GroupID == nil && domain.InWorkspace(ctx, b.WorkspaceID) {
+			result = append(result, b)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockBudgetRepository) GetByGroupID(ctx context.Context, groupID string) ([]*domain.Budget, error) {
+	var result []*domain.Budget
+	for _, b := range m.budgets {
+		if b.GroupID != nil && *b.GroupID == groupID {
+			result = append(result, b)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockBudgetRepository) Update(ctx context.Context, budget *domain.Budget) error {
+	m.budgets[budget.ID] = budget
+	return nil
+}
+
+func (m *MockBudgetRepository) Delete(ctx context.Context, id string) error {
+	delete(m.budgets, id)
+	return nil
+}
+
+// MockExpenseSplitRepository is a mock implementation for testing
+type MockExpenseSplitRepository struct {
+	expenseRepo *MockExpenseRepository
+	splits      map[string][]*domain.ExpenseSplit
+}
+
+// NewMockExpenseSplitRepository creates a split repository that resolves groups through expenseRepo
+func NewMockExpenseSplitRepository(expenseRepo *MockExpenseRepository) *MockExpenseSplitRepository {
+	return &MockExpenseSplitRepository{
+		expenseRepo: expenseRepo,
+		splits:      make(map[string][]*domain.ExpenseSplit),
+	}
+}
+
+func (m *MockExpenseSplitRepository) ReplaceForExpense(ctx context.Context, expenseID string, splits []*domain.ExpenseSplit) error {
+	m.splits[expenseID] = splits
+	return nil
+}
+
+func (m *MockExpenseSplitRepository) GetByExpenseID(ctx context.Context, expenseID string) ([]*domain.ExpenseSplit, error) {
+	return m.splits[expenseID], nil
+}
+
+func (m *MockExpenseSplitRepository) GetByPayerID(ctx context.Context, payerID string) ([]*domain.ExpenseSplit, error) {
+	var result []*domain.ExpenseSplit
+	for _, splits := range m.splits {
+		for _, split := range splits {
+			if split.PayerID == payerID {
+				result = append(result, split)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (m *MockExpenseSplitRepository) GetByGroupID(ctx context.Context, groupID string) ([]*domain.ExpenseSplit, error) {
+	var result []*domain.ExpenseSplit
+	for expenseID, splits := range m.splits {
+		expense := m.expenseRepo.expenses[expenseID]
+		if expense != nil && expense.GroupID != nil && *expense.GroupID == groupID {
+			result = append(result, splits...)
+		}
+	}
+	return result, nil
+}
+
+// MockExpenseItemRepository is a mock implementation for testing
+type MockExpenseItemRepository struct {
+	items map[string][]*domain.ExpenseItem
+}
+
+func NewMockExpenseItemRepository() *MockExpenseItemRepository {
+	return &MockExpenseItemRepository{
+		items: make(map[string][]*domain.ExpenseItem),
+	}
+}
+
+func (m *MockExpenseItemRepository) ReplaceForExpense(ctx context.Context, expenseID string, items []*domain.ExpenseItem) error {
+	m.items[expenseID] = items
+	return nil
+}
+
+func (m *MockExpenseItemRepository) GetByExpenseIDs(ctx context.Context, expenseIDs []string) (map[string][]*domain.ExpenseItem, error) {
+	result := make(map[string][]*domain.ExpenseItem)
+	for _, id := range expenseIDs {
+		if items, ok := m.items[id]; ok {
+			result[id] = items
+		}
+	}
+	return result, nil
+}
+
+// MockGroupRepository is a mock implementation for testing
+type MockGroupRepository struct {
+	groups  map[string]*domain.Group
+	members map[string][]*domain.GroupMember
+}
+
+func NewMockGroupRepository() *MockGroupRepository {
+	return &MockGroupRepository{
+		groups:  make(map[string]*domain.Group),
+		members: make(map[string][]*domain.GroupMember),
+	}
+}
+
+func (m *MockGroupRepository) Create(ctx context.Context, group *domain.Group) error {
+	m.groups[group.ID] = group
+	return nil
+}
+
+func (m *MockGroupRepository) GetByID(ctx context.Context, id string) (*domain.Group, error) {
+	group, ok := m.groups[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return group, nil
+}
+
+func (m *MockGroupRepository) GetByExternalID(ctx context.Context, messengerType, externalID string) (*domain.Group, error) {
+	for _, g := range m.groups {
+		if g.MessengerType == messengerType && g.ExternalID == externalID {
+			return g, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockGroupRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Group, error) {
+	var result []*domain.Group
+	for groupID, members := range m.members {
+		for _, member := range members {
+			if member.UserID == userID {
+				result = append(result, m.groups[groupID])
+			}
+		}
+	}
+	return result, nil
+}
+
+func (m *MockGroupRepository) AddMember(ctx context.Context, member *domain.GroupMember) error {
+	if ok, _ := m.IsMember(ctx, member.GroupID, member.UserID); ok {
+		return nil
+	}
+	m.members[member.GroupID] = append(m.members[member.GroupID], member)
 	return nil
 }
 
+func (m *MockGroupRepository) GetMembers(ctx context.Context, groupID string) ([]*domain.GroupMember, error) {
+	return m.members[groupID], nil
+}
+
+func (m *MockGroupRepository) IsMember(ctx context.Context, groupID, userID string) (bool, error) {
+	for _, member := range m.members[groupID] {
+		if member.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // MockAIService is a mock implementation for testing
 type MockAIService struct {
 	shouldFail bool
+
+	// Returned with every category suggestion, e.g. to simulate an unsure AI
+	categoryConfidence   float64
+	categoryAlternatives []string
 }
 
 var _ ai.Service = (*MockAIService)(nil)
@@ -282,7 +716,9 @@ func (m *MockAIService) SuggestCategory(ctx context.Context, description string,
 	}
 
 	return &ai.SuggestCategoryResponse{
-		Category: category,
+		Category:     category,
+		Confidence:   m.categoryConfidence,
+		Alternatives: m.categoryAlternatives,
 		Tokens: &ai.TokenMetadata{
 			InputTokens:  5,
 			OutputTokens: 5,
@@ -290,3 +726,1336 @@ func (m *MockAIService) SuggestCategory(ctx context.Context, description string,
 		},
 	}, nil
 }
+
+// MockAttachmentRepository is a mock implementation for testing
+type MockAttachmentRepository struct {
+	attachments map[string]*domain.ExpenseAttachment
+}
+
+func NewMockAttachmentRepository() *MockAttachmentRepository {
+	return &MockAttachmentRepository{
+		attachments: make(map[string]*domain.ExpenseAttachment),
+	}
+}
+
+func (m *MockAttachmentRepository) Create(ctx context.Context, attachment *domain.ExpenseAttachment) error {
+	m.attachments[attachment.ID] = attachment
+	return nil
+}
+
+func (m *MockAttachmentRepository) GetByID(ctx context.Context, id string) (*domain.ExpenseAttachment, error) {
+	attachment, ok := m.attachments[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return attachment, nil
+}
+
+func (m *MockAttachmentRepository) GetByExpenseID(ctx context.Context, expenseID string) ([]*domain.ExpenseAttachment, error) {
+	var result []*domain.ExpenseAttachment
+	for _, attachment := range m.attachments {
+		if attachment.ExpenseID == expenseID {
+			result = append(result, attachment)
+		}
+	}
+	return result, nil
+}
+
+// MockBlobStorage is an in-memory blob storage for testing
+type MockBlobStorage struct {
+	blobs map[string][]byte
+}
+
+func NewMockBlobStorage() *MockBlobStorage {
+	return &MockBlobStorage{
+		blobs: make(map[string][]byte),
+	}
+}
+
+func (m *MockBlobStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	m.blobs[key] = data
+	return nil
+}
+
+func (m *MockBlobStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := m.blobs[key]
+	if !ok {
+		return nil, fmt.Errorf("blob not found: %s", key)
+	}
+	return data, nil
+}
+
+func (m *MockBlobStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, err := m.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MockBlobStorage) Delete(ctx context.Context, key string) error {
+	delete(m.blobs, key)
+	return nil
+}
+
+// MockCache is an in-memory cache for testing; values never expire
+type MockCache struct {
+	values map[string][]byte
+}
+
+func NewMockCache() *MockCache {
+	return &MockCache{
+		values: make(map[string][]byte),
+	}
+}
+
+func (m *MockCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok := m.values[key]
+	return value, ok, nil
+}
+
+func (m *MockCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.values[key] = value
+	return nil
+}
+
+func (m *MockCache) Delete(ctx context.Context, key string) error {
+	delete(m.values, key)
+	return nil
+}
+
+// MockProcessedEventRepository is a mock implementation for testing
+type MockProcessedEventRepository struct {
+	events map[string]*domain.ProcessedEvent
+}
+
+func NewMockProcessedEventRepository() *MockProcessedEventRepository {
+	return &MockProcessedEventRepository{
+		events: make(map[string]*domain.ProcessedEvent),
+	}
+}
+
+func (m *MockProcessedEventRepository) MarkProcessed(ctx context.Context, event *domain.ProcessedEvent) (bool, error) {
+	key := event.Messenger + "/" + event.EventID
+	if _, ok := m.events[key]; ok {
+		return false, nil
+	}
+	m.events[key] = event
+	return true, nil
+}
+
+func (m *MockProcessedEventRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var deleted int64
+	for key, event := range m.events {
+		if event.ProcessedAt.Before(cutoff) {
+			delete(m.events, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// MockMetricsRepository is a mock implementation for testing; only the user
+// activity and platform metrics are stored, the other metrics are empty
+type MockMetricsRepository struct {
+	Activity   []*domain.UserActivity
+	Platforms  []*domain.PlatformMetrics
+	Categories []*domain.Category
+	Expenses   []*domain.Expense
+}
+
+func NewMockMetricsRepository() *MockMetricsRepository {
+	return &MockMetricsRepository{}
+}
+
+func (m *MockMetricsRepository) GetDailyActiveUsers(ctx context.Context, from, to time.Time) ([]*domain.DailyMetrics, error) {
+	return nil, nil
+}
+
+func (m *MockMetricsRepository) GetExpensesSummary(ctx context.Context, from, to time.Time) ([]*domain.DailyMetrics, error) {
+	return nil, nil
+}
+
+// GetCategoryTrends totals Expenses per category of Categories, like the
+// repositories' LEFT JOIN; it doesn't order them or fill in percentages
+func (m *MockMetricsRepository) GetCategoryTrends(ctx context.Context, userID string, from, to time.Time) ([]*domain.CategoryMetrics, error) {
+	var metrics []*domain.CategoryMetrics
+	for _, category := range m.Categories {
+		if category.UserID != userID {
+			continue
+		}
+		metric := &domain.CategoryMetrics{CategoryID: category.ID, Category: category.Name}
+		for _, expense := range m.Expenses {
+			if expense.UserID == userID && expense.CategoryID != nil && *expense.CategoryID == category.ID &&
+				!expense.ExpenseDate.Before(from) && !expense.ExpenseDate.After(to) {
+				metric.Total += expense.HomeAmount
+				metric.Count++
+			}
+		}
+		metrics = append(metrics, metric)
+	}
+	return metrics, nil
+}
+
+func (m *MockMetricsRepository) GetGrowthMetrics(ctx context.Context, days int) (map[string]interface{}, error) {
+	return make(map[string]interface{}), nil
+}
+
+func (m *MockMetricsRepository) GetNewUsersPerDay(ctx context.Context, from, to time.Time) ([]*domain.DailyMetrics, error) {
+	return nil, nil
+}
+
+func (m *MockMetricsRepository) GetPlatformMetrics(ctx context.Context, from, to time.Time) ([]*domain.PlatformMetrics, error) {
+	return m.Platforms, nil
+}
+
+func (m *MockMetricsRepository) GetUserActivity(ctx context.Context, since time.Time) ([]*domain.UserActivity, error) {
+	var activity []*domain.UserActivity
+	for _, user := range m.Activity {
+		active := &domain.UserActivity{UserID: user.UserID, SignedUpAt: user.SignedUpAt}
+		for _, day := range user.ActiveDays {
+			if !day.Before(since) {
+				active.ActiveDays = append(active.ActiveDays, day)
+			}
+		}
+		if !user.SignedUpAt.Before(since) || len(active.ActiveDays) > 0 {
+			activity = append(activity, active)
+		}
+	}
+	return activity, nil
+}
+
+// MockMetricsRollupRepository is a mock implementation for testing; ComputeDay
+// returns the day's entry in Raw and records the day in Computed
+type MockMetricsRollupRepository struct {
+	Raw      map[time.Time]domain.MetricsRollup
+	Computed []time.Time
+	rollups  map[time.Time]*domain.MetricsRollup
+}
+
+func NewMockMetricsRollupRepository() *MockMetricsRollupRepository {
+	return &MockMetricsRollupRepository{
+		Raw:     make(map[time.Time]domain.MetricsRollup),
+		rollups: make(map[time.Time]*domain.MetricsRollup),
+	}
+}
+
+func (m *MockMetricsRollupRepository) ComputeDay(ctx context.Context, day time.Time) (*domain.MetricsRollup, error) {
+	m.Computed = append(m.Computed, day)
+	rollup := m.Raw[day]
+	rollup.Date = day
+	return &rollup, nil
+}
+
+func (m *MockMetricsRollupRepository) Upsert(ctx context.Context, rollup *domain.MetricsRollup) error {
+	stored := *rollup
+	m.rollups[domain.MetricsDay(rollup.Date)] = &stored
+	return nil
+}
+
+func (m *MockMetricsRollupRepository) GetRange(ctx context.Context, from, to time.Time) ([]*domain.MetricsRollup, error) {
+	var rollups []*domain.MetricsRollup
+	for day := domain.MetricsDay(from); !day.After(domain.MetricsDay(to)); day = day.AddDate(0, 0, 1) {
+		if rollup, ok := m.rollups[day]; ok {
+			rollups = append(rollups, rollup)
+		}
+	}
+	return rollups, nil
+}
+
+// MockAICostRepository is a mock implementation for testing
+type MockAICostRepository struct {
+	logs []*domain.AICostLog
+}
+
+func NewMockAICostRepository() *MockAICostRepository {
+	return &MockAICostRepository{}
+}
+
+func (m *MockAICostRepository) Create(ctx context.Context, log *domain.AICostLog) error {
+	m.logs = append(m.logs, log)
+	return nil
+}
+
+func (m *MockAICostRepository) GetByUserID(ctx context.Context, userID string, limit int) ([]*domain.AICostLog, error) {
+	var result []*domain.AICostLog
+	for _, log := range m.logs {
+		if log.UserID == userID {
+			result = append(result, log)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockAICostRepository) GetSummary(ctx context.Context, from, to time.Time) (*domain.AICostSummary, error) {
+	return m.summarize("", from, to), nil
+}
+
+func (m *MockAICostRepository) GetDailyStats(ctx context.Context, from, to time.Time) ([]*domain.AICostDailyStats, error) {
+	byDay := make(map[time.Time]*domain.AICostDailyStats)
+	var stats []*domain.AICostDailyStats
+	for _, log := range m.logs {
+		if log.CreatedAt.Before(from) || log.CreatedAt.After(to) {
+			continue
+		}
+		day := domain.MetricsDay(log.CreatedAt)
+		s, ok := byDay[day]
+		if !ok {
+			s = &domain.AICostDailyStats{Date: day}
+			byDay[day] = s
+			stats = append(stats, s)
+		}
+		s.Calls++
+		s.InputTokens += log.InputTokens
+		s.OutputTokens += log.OutputTokens
+		s.TotalTokens += log.TotalTokens
+		s.Cost += log.Cost
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Date.Before(stats[j].Date) })
+	return stats, nil
+}
+
+func (m *MockAICostRepository) GetByOperation(ctx context.Context, from, to time.Time) ([]*domain.AICostByOperation, error) {
+	return nil, nil
+}
+
+func (m *MockAICostRepository) GetByUserSummary(ctx context.Context, from, to time.Time, limit int) ([]*domain.AICostByUser, error) {
+	return nil, nil
+}
+
+func (m *MockAICostRepository) GetUserSummary(ctx context.Context, userID string, from, to time.Time) (*domain.AICostSummary, error) {
+	return m.summarize(userID, from, to), nil
+}
+
+func (m *MockAICostRepository) summarize(userID string, from, to time.Time) *domain.AICostSummary {
+	summary := &domain.AICostSummary{Currency: "USD"}
+	for _, log := range m.logs {
+		if (userID != "" && log.UserID != userID) || log.CreatedAt.Before(from) || log.CreatedAt.After(to) {
+			continue
+		}
+		summary.TotalCalls++
+		summary.TotalTokens += log.TotalTokens
+		summary.TotalCost += log.Cost
+	}
+	return summary
+}
+
+// MockAICostAnomalyRepository is a mock implementation for testing
+type MockAICostAnomalyRepository struct {
+	anomalies map[time.Time]*domain.AICostAnomaly
+}
+
+func NewMockAICostAnomalyRepository() *MockAICostAnomalyRepository {
+	return &MockAICostAnomalyRepository{
+		anomalies: make(map[time.Time]*domain.AICostAnomaly),
+	}
+}
+
+func (m *MockAICostAnomalyRepository) GetByDate(ctx context.Context, date time.Time) (*domain.AICostAnomaly, error) {
+	anomaly, ok := m.anomalies[domain.MetricsDay(date)]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return anomaly, nil
+}
+
+func (m *MockAICostAnomalyRepository) Create(ctx context.Context, anomaly *domain.AICostAnomaly) error {
+	m.anomalies[domain.MetricsDay(anomaly.Date)] = anomaly
+	return nil
+}
+
+func (m *MockAICostAnomalyRepository) Update(ctx context.Context, anomaly *domain.AICostAnomaly) error {
+	m.anomalies[domain.MetricsDay(anomaly.Date)] = anomaly
+	return nil
+}
+
+func (m *MockAICostAnomalyRepository) List(ctx context.Context, limit int) ([]*domain.AICostAnomaly, error) {
+	var anomalies []*domain.AICostAnomaly
+	for _, anomaly := range m.anomalies {
+		anomalies = append(anomalies, anomaly)
+	}
+	sort.Slice(anomalies, func(i, j int) bool { return anomalies[i].Date.After(anomalies[j].Date) })
+	if len(anomalies) > limit {
+		anomalies = anomalies[:limit]
+	}
+	return anomalies, nil
+}
+
+// MockReplyOutboxRepository is a mock implementation for testing
+type MockReplyOutboxRepository struct {
+	replies map[string]*domain.OutboxReply
+}
+
+func NewMockReplyOutboxRepository() *MockReplyOutboxRepository {
+	return &MockReplyOutboxRepository{
+		replies: make(map[string]*domain.OutboxReply),
+	}
+}
+
+func (m *MockReplyOutboxRepository) Create(ctx context.Context, reply *domain.OutboxReply) error {
+	stored := *reply
+	m.replies[reply.ID] = &stored
+	return nil
+}
+
+func (m *MockReplyOutboxRepository) Update(ctx context.Context, reply *domain.OutboxReply) error {
+	if _, ok := m.replies[reply.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	stored := *reply
+	m.replies[reply.ID] = &stored
+	return nil
+}
+
+func (m *MockReplyOutboxRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*domain.OutboxReply, error) {
+	var replies []*domain.OutboxReply
+	for _, reply := range m.replies {
+		if reply.Status == domain.OutboxReplyPending && reply.NextAttemptAt != nil && !reply.NextAttemptAt.After(now) {
+			due := *reply
+			replies = append(replies, &due)
+		}
+	}
+	sort.Slice(replies, func(i, j int) bool { return replies[i].NextAttemptAt.Before(*replies[j].NextAttemptAt) })
+	if len(replies) > limit {
+		replies = replies[:limit]
+	}
+	return replies, nil
+}
+
+func (m *MockReplyOutboxRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for id, reply := range m.replies {
+		if reply.Status != domain.OutboxReplyPending && reply.CreatedAt.Before(before) {
+			delete(m.replies, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// MockEmailAddressRepository is a mock implementation for testing
+type MockEmailAddressRepository struct {
+	addresses map[string]*domain.EmailAddress
+}
+
+func NewMockEmailAddressRepository() *MockEmailAddressRepository {
+	return &MockEmailAddressRepository{
+		addresses: make(map[string]*domain.EmailAddress),
+	}
+}
+
+func (m *MockEmailAddressRepository) Save(ctx context.Context, address *domain.EmailAddress) error {
+	stored := *address
+	m.addresses[address.Address] = &stored
+	return nil
+}
+
+func (m *MockEmailAddressRepository) GetByAddress(ctx context.Context, address string) (*domain.EmailAddress, error) {
+	stored, ok := m.addresses[address]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	found := *stored
+	return &found, nil
+}
+
+func (m *MockEmailAddressRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.EmailAddress, error) {
+	var addresses []*domain.EmailAddress
+	for _, address := range m.addresses {
+		if address.UserID == userID {
+			found := *address
+			addresses = append(addresses, &found)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].CreatedAt.Before(addresses[j].CreatedAt) })
+	return addresses, nil
+}
+
+func (m *MockEmailAddressRepository) Delete(ctx context.Context, address string) error {
+	delete(m.addresses, address)
+	return nil
+}
+
+// MockConversationStateRepository is a mock implementation for testing
+type MockConversationStateRepository struct {
+	states map[string]*domain.ConversationState
+}
+
+func NewMockConversationStateRepository() *MockConversationStateRepository {
+	return &MockConversationStateRepository{
+		states: make(map[string]*domain.ConversationState),
+	}
+}
+
+func (m *MockConversationStateRepository) Save(ctx context.Context, state *domain.ConversationState) error {
+	stored := *state
+	m.states[state.UserID] = &stored
+	return nil
+}
+
+func (m *MockConversationStateRepository) GetByUserID(ctx context.Context, userID string) (*domain.ConversationState, error) {
+	stored, ok := m.states[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	found := *stored
+	return &found, nil
+}
+
+func (m *MockConversationStateRepository) Delete(ctx context.Context, userID string) error {
+	delete(m.states, userID)
+	return nil
+}
+
+func (m *MockConversationStateRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for userID, state := range m.states {
+		if state.ExpiresAt.Before(before) {
+			delete(m.states, userID)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// MockAICostCapRepository is a mock implementation for testing
+type MockAICostCapRepository struct {
+	caps map[string]*domain.AICostCap
+}
+
+func NewMockAICostCapRepository() *MockAICostCapRepository {
+	return &MockAICostCapRepository{
+		caps: make(map[string]*domain.AICostCap),
+	}
+}
+
+func (m *MockAICostCapRepository) Get(ctx context.Context, scope string) (*domain.AICostCap, error) {
+	costCap, ok := m.caps[scope]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	return costCap, nil
+}
+
+func (m *MockAICostCapRepository) GetAll(ctx context.Context) ([]*domain.AICostCap, error) {
+	var result []*domain.AICostCap
+	for _, costCap := range m.caps {
+		result = append(result, costCap)
+	}
+	return result, nil
+}
+
+func (m *MockAICostCapRepository) Upsert(ctx context.Context, costCap *domain.AICostCap) error {
+	m.caps[costCap.Scope] = costCap
+	return nil
+}
+
+func (m *MockAICostCapRepository) Delete(ctx context.Context, scope string) error {
+	delete(m.caps, scope)
+	return nil
+}
+
+// MockPromptRepository is a mock implementation for testing
+type MockPromptRepository struct {
+	prompts []*domain.PromptTemplate
+}
+
+func NewMockPromptRepository() *MockPromptRepository {
+	return &MockPromptRepository{}
+}
+
+func (m *MockPromptRepository) Create(ctx context.Context, prompt *domain.PromptTemplate) error {
+	m.prompts = append(m.prompts, prompt)
+	return nil
+}
+
+func (m *MockPromptRepository) GetActive(ctx context.Context, name string) (*domain.PromptTemplate, error) {
+	for _, prompt := range m.prompts {
+		if prompt.Name == name && prompt.Active {
+			return prompt, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockPromptRepository) GetByName(ctx context.Context, name string) ([]*domain.PromptTemplate, error) {
+	var result []*domain.PromptTemplate
+	for i := len(m.prompts) - 1; i >= 0; i-- {
+		if m.prompts[i].Name == name {
+			result = append(result, m.prompts[i])
+		}
+	}
+	return result, nil
+}
+
+func (m *MockPromptRepository) Activate(ctx context.Context, name string, version int) error {
+	found := version == 0
+	for _, prompt := range m.prompts {
+		if prompt.Name == name && prompt.Version == version {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("prompt %s version %d not found", name, version)
+	}
+	for _, prompt := range m.prompts {
+		if prompt.Name == name {
+			prompt.Active = prompt.Version == version
+		}
+	}
+	return nil
+}
+
+// MockInteractionLogRepository is a mock implementation for testing
+type MockInteractionLogRepository struct {
+	logs []*domain.InteractionLog
+}
+
+func NewMockInteractionLogRepository() *MockInteractionLogRepository {
+	return &MockInteractionLogRepository{}
+}
+
+func (m *MockInteractionLogRepository) Create(ctx context.Context, log *domain.InteractionLog) error {
+	m.logs = append(m.logs, log)
+	return nil
+}
+
+func (m *MockInteractionLogRepository) List(ctx context.Context, filter domain.InteractionLogFilter) ([]*domain.InteractionLog, int, error) {
+	var matched []*domain.InteractionLog
+	for i := len(m.logs) - 1; i >= 0; i-- {
+		log := m.logs[i]
+		if (filter.UserID != "" && log.UserID != filter.UserID) ||
+			(filter.Source != "" && log.Source != filter.Source) ||
+			(filter.Intent != "" && log.Intent != filter.Intent) ||
+			(!filter.From.IsZero() && log.Timestamp.Before(filter.From)) ||
+			(!filter.To.IsZero() && !log.Timestamp.Before(filter.To)) {
+			continue
+		}
+		matched = append(matched, log)
+	}
+
+	total := len(matched)
+	if filter.Offset >= total {
+		return nil, total, nil
+	}
+	matched = matched[filter.Offset:]
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	return matched, total, nil
+}
+
+// MockReportScheduleRepository is a mock implementation for testing
+type MockReportScheduleRepository struct {
+	schedules map[string]*domain.ReportSchedule // keyed by user ID
+}
+
+func NewMockReportScheduleRepository() *MockReportScheduleRepository {
+	return &MockReportScheduleRepository{schedules: make(map[string]*domain.ReportSchedule)}
+}
+
+func (m *MockReportScheduleRepository) Save(ctx context.Context, schedule *domain.ReportSchedule) error {
+	saved := *schedule
+	m.schedules[schedule.UserID] = &saved
+	return nil
+}
+
+func (m *MockReportScheduleRepository) GetByUserID(ctx context.Context, userID string) (*domain.ReportSchedule, error) {
+	schedule, ok := m.schedules[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *schedule
+	return &copied, nil
+}
+
+func (m *MockReportScheduleRepository) GetDue(ctx context.Context, now time.Time) ([]*domain.ReportSchedule, error) {
+	var due []*domain.ReportSchedule
+	for _, schedule := range m.schedules {
+		if schedule.Enabled && !schedule.NextRunAt.After(now) {
+			copied := *schedule
+			due = append(due, &copied)
+		}
+	}
+	return due, nil
+}
+
+// MockNotificationPreferencesRepository is a mock implementation for testing
+type MockNotificationPreferencesRepository struct {
+	prefs map[string]*domain.NotificationPreferences // keyed by user ID
+}
+
+func NewMockNotificationPreferencesRepository() *MockNotificationPreferencesRepository {
+	return &MockNotificationPreferencesRepository{prefs: make(map[string]*domain.NotificationPreferences)}
+}
+
+func (m *MockNotificationPreferencesRepository) Save(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	saved := *prefs
+	m.prefs[prefs.UserID] = &saved
+	return nil
+}
+
+func (m *MockNotificationPreferencesRepository) GetByUserID(ctx context.Context, userID string) (*domain.NotificationPreferences, error) {
+	prefs, ok := m.prefs[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *prefs
+	return &copied, nil
+}
+
+// ListSubscribed only returns stored preferences; it doesn't know the users
+// who have the defaults
+func (m *MockNotificationPreferencesRepository) ListSubscribed(ctx context.Context, notification string) ([]*domain.NotificationPreferences, error) {
+	var subscribed []*domain.NotificationPreferences
+	for _, prefs := range m.prefs {
+		if (notification == domain.NotificationDailyDigest && prefs.DailyDigest) ||
+			(notification == domain.NotificationWeeklyReport && prefs.WeeklyReport) ||
+			(notification == domain.NotificationExpenseReminders && prefs.ExpenseReminders) ||
+			(notification == domain.NotificationSpendingAnomalies && prefs.SpendingAnomalies) {
+			copied := *prefs
+			subscribed = append(subscribed, &copied)
+		}
+	}
+	return subscribed, nil
+}
+
+// MockWebhookRepository is a mock implementation for testing
+type MockWebhookRepository struct {
+	subscriptions []*domain.WebhookSubscription
+	deliveries    []*domain.WebhookDelivery
+}
+
+func NewMockWebhookRepository() *MockWebhookRepository {
+	return &MockWebhookRepository{}
+}
+
+func (m *MockWebhookRepository) CreateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	saved := *subscription
+	m.subscriptions = append(m.subscriptions, &saved)
+	return nil
+}
+
+func (m *MockWebhookRepository) GetSubscription(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	for _, subscription := range m.subscriptions {
+		if subscription.ID == id {
+			copied := *subscription
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockWebhookRepository) ListSubscriptions(ctx context.Context, userID string) ([]*domain.WebhookSubscription, error) {
+	var result []*domain.WebhookSubscription
+	for _, subscription := range m.subscriptions {
+		if subscription.UserID == userID {
+			copied := *subscription
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockWebhookRepository) UpdateSubscription(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	for _, existing := range m.subscriptions {
+		if existing.ID == subscription.ID {
+			existing.URL = subscription.URL
+			existing.Events = subscription.Events
+			existing.Enabled = subscription.Enabled
+			existing.UpdatedAt = subscription.UpdatedAt
+		}
+	}
+	return nil
+}
+
+func (m *MockWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	var subscriptions []*domain.WebhookSubscription
+	for _, subscription := range m.subscriptions {
+		if subscription.ID != id {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	var deliveries []*domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.SubscriptionID != id {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	m.subscriptions, m.deliveries = subscriptions, deliveries
+	return nil
+}
+
+func (m *MockWebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	saved := *delivery
+	m.deliveries = append(m.deliveries, &saved)
+	return nil
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	for i, existing := range m.deliveries {
+		if existing.ID == delivery.ID {
+			saved := *delivery
+			m.deliveries[i] = &saved
+		}
+	}
+	return nil
+}
+
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, limit int) ([]*domain.WebhookDelivery, error) {
+	var result []*domain.WebhookDelivery
+	for i := len(m.deliveries) - 1; i >= 0 && len(result) < limit; i-- {
+		if m.deliveries[i].SubscriptionID == subscriptionID {
+			copied := *m.deliveries[i]
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockWebhookRepository) GetRetryableDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	var result []*domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if len(result) < limit && delivery.Status == domain.WebhookDeliveryPending && delivery.NextAttemptAt != nil && !delivery.NextAttemptAt.After(now) {
+			copied := *delivery
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+// MockAPIKeyRepository is a mock implementation for testing
+type MockAPIKeyRepository struct {
+	keys []*domain.APIKey
+}
+
+func NewMockAPIKeyRepository() *MockAPIKeyRepository {
+	return &MockAPIKeyRepository{}
+}
+
+func (m *MockAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	saved := *key
+	m.keys = append(m.keys, &saved)
+	return nil
+}
+
+func (m *MockAPIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	for _, key := range m.keys {
+		if key.KeyHash == keyHash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockAPIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	var result []*domain.APIKey
+	for _, key := range m.keys {
+		copied := *key
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (m *MockAPIKeyRepository) Delete(ctx context.Context, id string) error {
+	var keys []*domain.APIKey
+	for _, key := range m.keys {
+		if key.ID != id {
+			keys = append(keys, key)
+		}
+	}
+	m.keys = keys
+	return nil
+}
+
+func (m *MockAPIKeyRepository) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	for _, key := range m.keys {
+		if key.ID == id {
+			key.LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+// MockUserDeletionRepository is a mock implementation for testing. Purges are
+// recorded in Purged and return PurgeResult.
+type MockUserDeletionRepository struct {
+	deletions   []*domain.UserDeletion
+	Purged      []string
+	PurgeResult *domain.UserPurge
+}
+
+func NewMockUserDeletionRepository() *MockUserDeletionRepository {
+	return &MockUserDeletionRepository{
+		PurgeResult: &domain.UserPurge{DeletedCounts: map[string]int64{}},
+	}
+}
+
+func (m *MockUserDeletionRepository) Create(ctx context.Context, deletion *domain.UserDeletion) error {
+	saved := *deletion
+	m.deletions = append(m.deletions, &saved)
+	return nil
+}
+
+func (m *MockUserDeletionRepository) Update(ctx context.Context, deletion *domain.UserDeletion) error {
+	for i, existing := range m.deletions {
+		if existing.ID == deletion.ID {
+			saved := *deletion
+			m.deletions[i] = &saved
+		}
+	}
+	return nil
+}
+
+func (m *MockUserDeletionRepository) GetPending(ctx context.Context, userID string) (*domain.UserDeletion, error) {
+	for _, deletion := range m.deletions {
+		if deletion.UserID == userID && deletion.Status == domain.UserDeletionPending {
+			copied := *deletion
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockUserDeletionRepository) GetDue(ctx context.Context, now time.Time) ([]*domain.UserDeletion, error) {
+	var result []*domain.UserDeletion
+	for _, deletion := range m.deletions {
+		if deletion.Status == domain.UserDeletionPending && !deletion.PurgeAfter.After(now) {
+			copied := *deletion
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (m *MockUserDeletionRepository) List(ctx context.Context, limit int) ([]*domain.UserDeletion, error) {
+	var result []*domain.UserDeletion
+	for i := len(m.deletions) - 1; i >= 0 && len(result) < limit; i-- {
+		copied := *m.deletions[i]
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (m *MockUserDeletionRepository) PurgeUserData(ctx context.Context, userID string) (*domain.UserPurge, error) {
+	m.Purged = append(m.Purged, userID)
+	return m.PurgeResult, nil
+}
+
+// MockArchiveRepository is a mock implementation for testing
+type MockArchiveRepository struct {
+	archives []*domain.Archive
+}
+
+func NewMockArchiveRepository() *MockArchiveRepository {
+	return &MockArchiveRepository{}
+}
+
+func (m *MockArchiveRepository) Create(ctx context.Context, archive *domain.Archive) error {
+	saved := *archive
+	m.archives = append(m.archives, &saved)
+	return nil
+}
+
+func (m *MockArchiveRepository) GetByID(ctx context.Context, id string) (*domain.Archive, error) {
+	for _, archive := range m.archives {
+		if archive.ID == id {
+			copied := *archive
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockArchiveRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Archive, error) {
+	var result []*domain.Archive
+	for _, archive := range m.archives {
+		if archive.UserID == userID {
+			copied := *archive
+			result = append(result, &copied)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (m *MockArchiveRepository) Delete(ctx context.Context, id string) error {
+	for i, archive := range m.archives {
+		if archive.ID == id {
+			m.archives = append(m.archives[:i], m.archives[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *MockArchiveRepository) GetCreatedBefore(ctx context.Context, before time.Time) ([]*domain.Archive, error) {
+	var result []*domain.Archive
+	for _, archive := range m.archives {
+		if archive.CreatedAt.Before(before) {
+			copied := *archive
+			result = append(result, &copied)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// MockArchivePolicyRepository is a mock implementation for testing; it finds
+// users with old expenses in expenseRepo
+type MockArchivePolicyRepository struct {
+	policies    map[string]*domain.ArchivePolicy
+	expenseRepo *MockExpenseRepository
+}
+
+func NewMockArchivePolicyRepository(expenseRepo *MockExpenseRepository) *MockArchivePolicyRepository {
+	return &MockArchivePolicyRepository{
+		policies:    make(map[string]*domain.ArchivePolicy),
+		expenseRepo: expenseRepo,
+	}
+}
+
+func (m *MockArchivePolicyRepository) GetByUserID(ctx context.Context, userID string) (*domain.ArchivePolicy, error) {
+	policy, ok := m.policies[userID]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *policy
+	return &copied, nil
+}
+
+func (m *MockArchivePolicyRepository) Save(ctx context.Context, policy *domain.ArchivePolicy) error {
+	saved := *policy
+	m.policies[policy.UserID] = &saved
+	return nil
+}
+
+func (m *MockArchivePolicyRepository) Delete(ctx context.Context, userID string) error {
+	delete(m.policies, userID)
+	return nil
+}
+
+func (m *MockArchivePolicyRepository) List(ctx context.Context) ([]*domain.ArchivePolicy, error) {
+	var result []*domain.ArchivePolicy
+	for _, policy := range m.policies {
+		copied := *policy
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
+}
+
+func (m *MockArchivePolicyRepository) ListUsersWithExpensesBefore(ctx context.Context, before time.Time) ([]string, error) {
+	seen := make(map[string]bool)
+	var userIDs []string
+	for _, expense := range m.expenseRepo.expenses {
+		if expense.ExpenseDate.Before(before) && !seen[expense.UserID] {
+			seen[expense.UserID] = true
+			userIDs = append(userIDs, expense.UserID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
+
+// MockTagRepository is a mock implementation for testing; it keeps the Tags
+// of expenses in expenseRepo in step, so filters over them see the tags
+type MockTagRepository struct {
+	tags        map[string]*domain.Tag
+	expenseTags map[string][]string // Tag IDs by expense ID
+	expenseRepo *MockExpenseRepository
+}
+
+func NewMockTagRepository(expenseRepo *MockExpenseRepository) *MockTagRepository {
+	return &MockTagRepository{
+		tags:        make(map[string]*domain.Tag),
+		expenseTags: make(map[string][]string),
+		expenseRepo: expenseRepo,
+	}
+}
+
+func (m *MockTagRepository) Create(ctx context.Context, tag *domain.Tag) error {
+	if existing, _ := m.GetByUserIDAndName(ctx, tag.UserID, tag.Name); existing != nil {
+		return domain.ErrConflict
+	}
+	saved := *tag
+	m.tags[tag.ID] = &saved
+	return nil
+}
+
+func (m *MockTagRepository) GetByID(ctx context.Context, id string) (*domain.Tag, error) {
+	tag, ok := m.tags[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *tag
+	return &copied, nil
+}
+
+func (m *MockTagRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Tag, error) {
+	var result []*domain.Tag
+	for _, tag := range m.tags {
+		if tag.UserID == userID {
+			copied := *tag
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (m *MockTagRepository) GetByUserIDAndName(ctx context.Context, userID, name string) (*domain.Tag, error) {
+	for _, tag := range m.tags {
+		if tag.UserID == userID && tag.Name == name {
+			copied := *tag
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockTagRepository) Update(ctx context.Context, tag *domain.Tag) error {
+	if _, ok := m.tags[tag.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	if existing, _ := m.GetByUserIDAndName(ctx, tag.UserID, tag.Name); existing != nil && existing.ID != tag.ID {
+		return domain.ErrConflict
+	}
+	saved := *tag
+	m.tags[tag.ID] = &saved
+	m.syncExpenses()
+	return nil
+}
+
+func (m *MockTagRepository) Delete(ctx context.Context, id string) error {
+	delete(m.tags, id)
+	for expenseID, tagIDs := range m.expenseTags {
+		m.expenseTags[expenseID] = slices.DeleteFunc(tagIDs, func(tagID string) bool { return tagID == id })
+	}
+	m.syncExpenses()
+	return nil
+}
+
+func (m *MockTagRepository) SetExpenseTags(ctx context.Context, expenseID string, tagIDs []string) error {
+	m.expenseTags[expenseID] = slices.Clone(tagIDs)
+	m.syncExpenses()
+	return nil
+}
+
+func (m *MockTagRepository) GetNamesByExpenseIDs(ctx context.Context, expenseIDs []string) (map[string][]string, error) {
+	names := make(map[string][]string)
+	for _, expenseID := range expenseIDs {
+		if tags := m.names(expenseID); len(tags) > 0 {
+			names[expenseID] = tags
+		}
+	}
+	return names, nil
+}
+
+func (m *MockTagRepository) SumByTagAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.TagTotal, error) {
+	byTag := make(map[string]*domain.TagTotal)
+	var totals []*domain.TagTotal
+	for expenseID, tagIDs := range m.expenseTags {
+		expense := m.expenseRepo.expenses[expenseID]
+		if expense == nil || !query.Matches(expense) {
+			continue
+		}
+		for _, tagID := range tagIDs {
+			total, ok := byTag[tagID]
+			if !ok {
+				total = &domain.TagTotal{TagID: tagID, Name: m.tags[tagID].Name}
+				byTag[tagID] = total
+				totals = append(totals, total)
+			}
+			total.Total += expense.HomeAmount
+			total.Count++
+		}
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Total != totals[j].Total {
+			return totals[i].Total > totals[j].Total
+		}
+		return totals[i].Name < totals[j].Name
+	})
+	return totals, nil
+}
+
+// names returns the names of an expense's tags, sorted
+func (m *MockTagRepository) names(expenseID string) []string {
+	var names []string
+	for _, tagID := range m.expenseTags[expenseID] {
+		if tag, ok := m.tags[tagID]; ok {
+			names = append(names, tag.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// syncExpenses sets the Tags of the expenses in expenseRepo
+func (m *MockTagRepository) syncExpenses() {
+	if m.expenseRepo == nil {
+		return
+	}
+	for expenseID := range m.expenseTags {
+		if expense, ok := m.expenseRepo.expenses[expenseID]; ok {
+			expense.Tags = m.names(expenseID)
+		}
+	}
+}
+
+// MockMerchantRepository is a mock implementation for testing; it sums the
+// expenses in expenseRepo
+type MockMerchantRepository struct {
+	merchants   map[string]*domain.Merchant
+	expenseRepo *MockExpenseRepository
+}
+
+func NewMockMerchantRepository(expenseRepo *MockExpenseRepository) *MockMerchantRepository {
+	return &MockMerchantRepository{
+		merchants:   make(map[string]*domain.Merchant),
+		expenseRepo: expenseRepo,
+	}
+}
+
+func (m *MockMerchantRepository) Create(ctx context.Context, merchant *domain.Merchant) error {
+	if existing, _ := m.GetByUserIDAndNormalizedName(ctx, merchant.UserID, merchant.NormalizedName); existing != nil {
+		return domain.ErrConflict
+	}
+	saved := *merchant
+	m.merchants[merchant.ID] = &saved
+	return nil
+}
+
+func (m *MockMerchantRepository) GetByID(ctx context.Context, id string) (*domain.Merchant, error) {
+	merchant, ok := m.merchants[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *merchant
+	return &copied, nil
+}
+
+func (m *MockMerchantRepository) GetByUserIDAndNormalizedName(ctx context.Context, userID, normalizedName string) (*domain.Merchant, error) {
+	for _, merchant := range m.merchants {
+		if merchant.UserID == userID && merchant.NormalizedName == normalizedName {
+			copied := *merchant
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockMerchantRepository) SumByMerchantAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.MerchantTotal, error) {
+	byMerchant := make(map[string]*domain.MerchantTotal)
+	var totals []*domain.MerchantTotal
+	for _, expense := range m.expenseRepo.selectTotals(ctx, query) {
+		if expense.MerchantID == nil || m.merchants[*expense.MerchantID] == nil {
+			continue
+		}
+		total, ok := byMerchant[*expense.MerchantID]
+		if !ok {
+			total = &domain.MerchantTotal{MerchantID: *expense.MerchantID, Name: m.merchants[*expense.MerchantID].Name}
+			byMerchant[*expense.MerchantID] = total
+			totals = append(totals, total)
+		}
+		total.Total += expense.HomeAmount
+		total.Count++
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Total != totals[j].Total {
+			return totals[i].Total > totals[j].Total
+		}
+		return totals[i].Name < totals[j].Name
+	})
+	return totals, nil
+}
+
+// MockWorkspaceRepository is a mock implementation for testing
+type MockWorkspaceRepository struct {
+	workspaces map[string]*domain.Workspace
+}
+
+func NewMockWorkspaceRepository() *MockWorkspaceRepository {
+	return &MockWorkspaceRepository{
+		workspaces: make(map[string]*domain.Workspace),
+	}
+}
+
+func (m *MockWorkspaceRepository) Create(ctx context.Context, workspace *domain.Workspace) error {
+	for _, existing := range m.workspaces {
+		if existing.UserID == workspace.UserID && existing.Name == workspace.Name {
+			return domain.ErrConflict
+		}
+	}
+	saved := *workspace
+	m.workspaces[workspace.ID] = &saved
+	return nil
+}
+
+func (m *MockWorkspaceRepository) GetByID(ctx context.Context, id string) (*domain.Workspace, error) {
+	workspace, ok := m.workspaces[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	copied := *workspace
+	return &copied, nil
+}
+
+func (m *MockWorkspaceRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Workspace, error) {
+	var result []*domain.Workspace
+	for _, workspace := range m.workspaces {
+		if workspace.UserID == userID {
+			copied := *workspace
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func (m *MockWorkspaceRepository) Update(ctx context.Context, workspace *domain.Workspace) error {
+	if _, ok := m.workspaces[workspace.ID]; !ok {
+		return domain.ErrNotFound
+	}
+	for _, existing := range m.workspaces {
+		if existing.ID != workspace.ID && existing.UserID == workspace.UserID && existing.Name == workspace.Name {
+			return domain.ErrConflict
+		}
+	}
+	saved := *workspace
+	m.workspaces[workspace.ID] = &saved
+	return nil
+}
+
+func (m *MockWorkspaceRepository) Delete(ctx context.Context, id string) error {
+	delete(m.workspaces, id)
+	return nil
+}
+
+// MockUnitOfWork is a mock implementation for testing. The mock repositories
+// are not transactional, so it only counts how each unit ended.
+type MockUnitOfWork struct {
+	Commits   int
+	Rollbacks int
+}
+
+func NewMockUnitOfWork() *MockUnitOfWork {
+	return &MockUnitOfWork{}
+}
+
+func (m *MockUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(domain.WithinUnitOfWork(ctx)); err != nil {
+		m.Rollbacks++
+		return err
+	}
+	m.Commits++
+	return nil
+}
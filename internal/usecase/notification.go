@@ -2,27 +2,55 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
 )
 
 // NotificationUseCase handles notifications and reminders
 type NotificationUseCase struct {
 	// In production, would have notification repository
+	prefsRepo       domain.NotificationPreferencesRepository
+	reportScheduler ReportScheduler
+	events          EventPublisher
+	now             func() time.Time
+}
+
+// ReportScheduler manages a user's emailed spending reports
+type ReportScheduler interface {
+	GetSchedule(ctx context.Context, userID string) (*domain.ReportSchedule, error)
+	UpdateSchedule(ctx context.Context, userID string, update *ReportScheduleUpdate) (*domain.ReportSchedule, error)
 }
 
 // NewNotificationUseCase creates a new notification use case
 func NewNotificationUseCase() *NotificationUseCase {
-	return &NotificationUseCase{}
+	return &NotificationUseCase{now: time.Now}
+}
+
+// SetPreferencesRepository keeps the preferences users change; without it
+// everyone has the defaults
+func (u *NotificationUseCase) SetPreferencesRepository(repo domain.NotificationPreferencesRepository) {
+	u.prefsRepo = repo
+}
+
+// SetReportScheduler enables emailed reports in notification preferences
+func (u *NotificationUseCase) SetReportScheduler(scheduler ReportScheduler) {
+	u.reportScheduler = scheduler
+}
+
+// SetEventPublisher publishes a notification.created event for each new notification
+func (u *NotificationUseCase) SetEventPublisher(events EventPublisher) {
+	u.events = events
 }
 
 // Notification represents a user notification
 type Notification struct {
 	ID        string                 `json:"id"`
 	UserID    string                 `json:"user_id"`
-	Type      string                 `json:"type"` // "budget_alert", "recurring_due", "expense_reminder", "report"
+	Type      string                 `json:"type"` // "budget_alert", "recurring_due", "expense_reminder", "report", or a dispatched kind such as "weekly_report"
 	Title     string                 `json:"title"`
 	Message   string                 `json:"message"`
 	Data      map[string]interface{} `json:"data,omitempty"`
@@ -53,6 +81,17 @@ func (u *NotificationUseCase) CreateNotification(ctx context.Context, req *Creat
 	}
 
 	id := uuid.New().String()
+	if u.events != nil {
+		u.events.Publish(ctx, req.UserID, domain.EventNotificationCreated, &Notification{
+			ID:        id,
+			UserID:    req.UserID,
+			Type:      req.Type,
+			Title:     req.Title,
+			Message:   req.Message,
+			Data:      req.Data,
+			CreatedAt: time.Now(),
+		})
+	}
 
 	return &CreateNotificationResponse{
 		ID:      id,
@@ -177,7 +216,16 @@ type NotificationPreferences struct {
 	ReportNotifications bool   `json:"report_notifications"`
 	ExpenseReminders    bool   `json:"expense_reminders"`
 	DailyDigest         bool   `json:"daily_digest"`
+	DailyDigestHour     int    `json:"daily_digest_hour"` // Hour of the day in the user's timezone, 0-23
 	WeeklyReport        bool   `json:"weekly_report"`
+	ExpenseReminderDays int    `json:"expense_reminder_days"` // Days without an expense before a reminder
+	QuietHoursStart     int    `json:"quiet_hours_start"`     // Hour reminders stop, 0-23
+	QuietHoursEnd       int    `json:"quiet_hours_end"`       // Hour reminders resume, 0-23
+	SpendingAnomalies   bool   `json:"spending_anomalies"`
+	AnomalySensitivity  string `json:"anomaly_sensitivity"` // low, medium or high
+
+	Channels    map[string]string      `json:"channels"`               // Channel of each notification: push, in_app or email
+	EmailReport *domain.ReportSchedule `json:"email_report,omitempty"` // Set once the user opts into emailed reports
 }
 
 // GetPreferencesRequest represents a request to get notification preferences
@@ -197,15 +245,18 @@ func (u *NotificationUseCase) GetPreferences(ctx context.Context, req *GetPrefer
 		return nil, fmt.Errorf("user_id is required")
 	}
 
-	// Default preferences
-	prefs := &NotificationPreferences{
-		UserID:              req.UserID,
-		BudgetAlerts:        true,
-		RecurringReminders:  true,
-		ReportNotifications: true,
-		ExpenseReminders:    false,
-		DailyDigest:         false,
-		WeeklyReport:        true,
+	stored, err := u.storedPreferences(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	prefs := preferencesView(stored)
+
+	if u.reportScheduler != nil {
+		schedule, err := u.reportScheduler.GetSchedule(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		prefs.EmailReport = schedule
 	}
 
 	return &GetPreferencesResponse{
@@ -222,7 +273,15 @@ type UpdatePreferencesRequest struct {
 	ReportNotifications *bool
 	ExpenseReminders    *bool
 	DailyDigest         *bool
+	DailyDigestHour     *int
 	WeeklyReport        *bool
+	ExpenseReminderDays *int
+	QuietHoursStart     *int
+	QuietHoursEnd       *int
+	SpendingAnomalies   *bool
+	AnomalySensitivity  *string
+	Channels            map[string]string // Channels to change, keyed by notification
+	EmailReport         *ReportScheduleUpdate
 }
 
 // UpdatePreferencesResponse represents the response after updating
@@ -237,9 +296,38 @@ func (u *NotificationUseCase) UpdatePreferences(ctx context.Context, req *Update
 		return nil, fmt.Errorf("user_id is required")
 	}
 
-	// In production: get current preferences, update specified fields, save
-	prefs := &NotificationPreferences{
-		UserID: req.UserID,
+	if req.DailyDigestHour != nil && (*req.DailyDigestHour < 0 || *req.DailyDigestHour > 23) {
+		return nil, fmt.Errorf("daily_digest_hour must be between 0 and 23")
+	}
+	if req.ExpenseReminderDays != nil && (*req.ExpenseReminderDays < 1 || *req.ExpenseReminderDays > 30) {
+		return nil, fmt.Errorf("expense_reminder_days must be between 1 and 30")
+	}
+	if (req.QuietHoursStart != nil && (*req.QuietHoursStart < 0 || *req.QuietHoursStart > 23)) ||
+		(req.QuietHoursEnd != nil && (*req.QuietHoursEnd < 0 || *req.QuietHoursEnd > 23)) {
+		return nil, fmt.Errorf("quiet hours must be between 0 and 23")
+	}
+	if req.AnomalySensitivity != nil {
+		if _, ok := anomalyThresholds[*req.AnomalySensitivity]; !ok {
+			return nil, fmt.Errorf("anomaly_sensitivity must be low, medium or high")
+		}
+	}
+	for notification, channel := range req.Channels {
+		if !notificationChannels[notification] {
+			return nil, fmt.Errorf("unknown notification %q", notification)
+		}
+		if channel != domain.NotificationChannelPush && channel != domain.NotificationChannelInApp && channel != domain.NotificationChannelEmail {
+			return nil, fmt.Errorf("channel must be push, in_app or email")
+		}
+	}
+
+	// Without a repository only the fields in the request are returned
+	prefs := &domain.NotificationPreferences{UserID: req.UserID}
+	if u.prefsRepo != nil {
+		stored, err := u.storedPreferences(ctx, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+		prefs = stored
 	}
 
 	if req.BudgetAlerts != nil {
@@ -257,12 +345,107 @@ func (u *NotificationUseCase) UpdatePreferences(ctx context.Context, req *Update
 	if req.DailyDigest != nil {
 		prefs.DailyDigest = *req.DailyDigest
 	}
+	if req.DailyDigestHour != nil {
+		prefs.DailyDigestHour = *req.DailyDigestHour
+	}
 	if req.WeeklyReport != nil {
 		prefs.WeeklyReport = *req.WeeklyReport
 	}
+	if req.ExpenseReminderDays != nil {
+		prefs.ExpenseReminderDays = *req.ExpenseReminderDays
+	}
+	if req.QuietHoursStart != nil {
+		prefs.QuietHoursStart = *req.QuietHoursStart
+	}
+	if req.QuietHoursEnd != nil {
+		prefs.QuietHoursEnd = *req.QuietHoursEnd
+	}
+	if req.SpendingAnomalies != nil {
+		prefs.SpendingAnomalies = *req.SpendingAnomalies
+	}
+	if req.AnomalySensitivity != nil {
+		prefs.AnomalySensitivity = *req.AnomalySensitivity
+	}
+	if len(req.Channels) > 0 {
+		channels := make(map[string]string, len(prefs.Channels)+len(req.Channels))
+		for notification, channel := range prefs.Channels {
+			channels[notification] = channel
+		}
+		for notification, channel := range req.Channels {
+			channels[notification] = channel
+		}
+		prefs.Channels = channels
+	}
+	if u.prefsRepo != nil {
+		prefs.UpdatedAt = u.now()
+		if err := u.prefsRepo.Save(ctx, prefs); err != nil {
+			return nil, fmt.Errorf("failed to save preferences: %w", err)
+		}
+	}
+
+	view := preferencesView(prefs)
+	if req.EmailReport != nil {
+		if u.reportScheduler == nil {
+			return nil, fmt.Errorf("email reports are not configured")
+		}
+		schedule, err := u.reportScheduler.UpdateSchedule(ctx, req.UserID, req.EmailReport)
+		if err != nil {
+			return nil, err
+		}
+		view.EmailReport = schedule
+	}
 
 	return &UpdatePreferencesResponse{
-		Preferences: prefs,
+		Preferences: view,
 		Message:     "Preferences updated successfully",
 	}, nil
 }
+
+// notificationChannels are the notifications users pick a channel for
+var notificationChannels = map[string]bool{
+	domain.NotificationBudgetAlerts:      true,
+	domain.NotificationDailyDigest:       true,
+	domain.NotificationWeeklyReport:      true,
+	domain.NotificationExpenseReminders:  true,
+	domain.NotificationSpendingAnomalies: true,
+}
+
+// storedPreferences returns the user's preferences, or the defaults when they
+// never changed them
+func (u *NotificationUseCase) storedPreferences(ctx context.Context, userID string) (*domain.NotificationPreferences, error) {
+	if u.prefsRepo == nil {
+		return domain.DefaultNotificationPreferences(userID), nil
+	}
+	prefs, err := u.prefsRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.DefaultNotificationPreferences(userID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// preferencesView returns the preferences as the API shows them
+func preferencesView(prefs *domain.NotificationPreferences) *NotificationPreferences {
+	channels := make(map[string]string, len(notificationChannels))
+	for notification := range notificationChannels {
+		channels[notification] = prefs.Channel(notification)
+	}
+	return &NotificationPreferences{
+		UserID:              prefs.UserID,
+		BudgetAlerts:        prefs.BudgetAlerts,
+		RecurringReminders:  prefs.RecurringReminders,
+		ReportNotifications: prefs.ReportNotifications,
+		ExpenseReminders:    prefs.ExpenseReminders,
+		DailyDigest:         prefs.DailyDigest,
+		DailyDigestHour:     prefs.DailyDigestHour,
+		WeeklyReport:        prefs.WeeklyReport,
+		ExpenseReminderDays: prefs.ExpenseReminderDays,
+		QuietHoursStart:     prefs.QuietHoursStart,
+		QuietHoursEnd:       prefs.QuietHoursEnd,
+		SpendingAnomalies:   prefs.SpendingAnomalies,
+		AnomalySensitivity:  prefs.AnomalySensitivity,
+		Channels:            channels,
+	}
+}
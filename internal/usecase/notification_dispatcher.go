@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// HeldPusher sends a message the user didn't ask for to their messenger, no
+// sooner than at
+type HeldPusher interface {
+	PushAt(ctx context.Context, userID, messenger, text string, at time.Time) error
+}
+
+// NotificationDispatcher delivers notifications the way each user wants
+// them. Notifications the user turned off are dropped; the rest become an
+// in-app notification and, unless the user picked in-app only, are pushed to
+// their messenger or emailed to their verified address. Pushes due during
+// the user's quiet hours are held until those end.
+type NotificationDispatcher struct {
+	prefsRepo domain.NotificationPreferencesRepository
+	pusher    HeldPusher
+	events    EventPublisher
+	addresses domain.EmailAddressRepository
+	sender    domain.EmailSender
+	timezones TimezoneLocator
+	now       func() time.Time
+}
+
+// NewNotificationDispatcher creates a new notification dispatcher
+func NewNotificationDispatcher(prefsRepo domain.NotificationPreferencesRepository, pusher HeldPusher) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		prefsRepo: prefsRepo,
+		pusher:    pusher,
+		now:       time.Now,
+	}
+}
+
+// SetEventPublisher publishes each notification as a notification.created event
+func (d *NotificationDispatcher) SetEventPublisher(events EventPublisher) {
+	d.events = events
+}
+
+// SetEmail enables the email channel; without it, notifications users want
+// emailed are pushed instead
+func (d *NotificationDispatcher) SetEmail(addresses domain.EmailAddressRepository, sender domain.EmailSender) {
+	d.addresses = addresses
+	d.sender = sender
+}
+
+// SetTimezoneLocator keeps quiet hours in each user's timezone instead of the server's
+func (d *NotificationDispatcher) SetTimezoneLocator(timezones TimezoneLocator) {
+	d.timezones = timezones
+}
+
+// Dispatch delivers n, one of the user's notifications of the given kind,
+// e.g. domain.NotificationBudgetAlerts, through the channel they picked for
+// it. The notification's title defaults to the kind's. Like a pusher, it
+// returns ErrNoNotifier when the message was to be pushed to a messenger that
+// can't be pushed to.
+func (d *NotificationDispatcher) Dispatch(ctx context.Context, notification, messenger string, n *Notification) error {
+	prefs, err := d.prefsRepo.GetByUserID(ctx, n.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		prefs = domain.DefaultNotificationPreferences(n.UserID)
+	} else if err != nil {
+		return fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	if !prefs.Enabled(notification) {
+		return nil
+	}
+
+	now := d.now()
+	if n.ID == "" {
+		n.ID = uuid.New().String()
+	}
+	if n.Title == "" {
+		n.Title = translate(ctx, "notification."+notification)
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = now
+	}
+	if d.events != nil {
+		d.events.Publish(ctx, n.UserID, domain.EventNotificationCreated, n)
+	}
+
+	switch prefs.Channel(notification) {
+	case domain.NotificationChannelInApp:
+		return nil
+	case domain.NotificationChannelEmail:
+		sent, err := d.email(ctx, n)
+		if sent || err != nil {
+			return err
+		}
+	}
+
+	at := now
+	if local := d.local(ctx, n.UserID, now); prefs.InQuietHours(local.Hour()) {
+		at = quietHoursOver(prefs, local)
+	}
+	return d.pusher.PushAt(ctx, n.UserID, messenger, n.Message, at)
+}
+
+// For returns a pusher that dispatches what it pushes as notifications of the
+// given kind, for use cases that only push text
+func (d *NotificationDispatcher) For(notification string) MessagePusher {
+	return &notificationPusher{dispatcher: d, notification: notification}
+}
+
+// email sends n to the user's first verified address. It reports false when
+// there's no way to email them, so the notification is pushed instead.
+func (d *NotificationDispatcher) email(ctx context.Context, n *Notification) (bool, error) {
+	if d.addresses == nil || d.sender == nil {
+		return false, nil
+	}
+	addresses, err := d.addresses.GetByUserID(ctx, n.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get email addresses: %w", err)
+	}
+	for _, address := range addresses {
+		if address.VerifiedAt == nil {
+			continue
+		}
+		if err := d.sender.Send(ctx, &domain.EmailMessage{To: address.Address, Subject: n.Title, TextBody: n.Message}); err != nil {
+			return false, fmt.Errorf("failed to email notification: %w", err)
+		}
+		return true, nil
+	}
+	slog.InfoContext(ctx, "No verified email address for emailed notification, pushing it instead", "user_id", n.UserID)
+	return false, nil
+}
+
+// local returns now in the user's timezone
+func (d *NotificationDispatcher) local(ctx context.Context, userID string, now time.Time) time.Time {
+	if d.timezones == nil {
+		return now.In(time.Local)
+	}
+	return now.In(d.timezones.Locate(ctx, userID, ""))
+}
+
+// quietHoursOver returns when the quiet hours local is in end
+func quietHoursOver(prefs *domain.NotificationPreferences, local time.Time) time.Time {
+	end := time.Date(local.Year(), local.Month(), local.Day(), prefs.QuietHoursEnd, 0, 0, 0, local.Location())
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// notificationPusher pushes text as a notification of one kind
+type notificationPusher struct {
+	dispatcher   *NotificationDispatcher
+	notification string
+}
+
+func (p *notificationPusher) Push(ctx context.Context, userID, messenger, text string) error {
+	return p.dispatcher.Dispatch(ctx, p.notification, messenger, &Notification{
+		UserID:  userID,
+		Type:    p.notification,
+		Message: text,
+	})
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// heldPush is a message a recordingHeldPusher was asked to push
+type heldPush struct {
+	userID, text string
+	at           time.Time
+}
+
+// recordingHeldPusher pushes to the messengers in notifiers and records what it pushed
+type recordingHeldPusher struct {
+	notifiers map[string]bool
+	pushed    []heldPush
+}
+
+func (p *recordingHeldPusher) PushAt(ctx context.Context, userID, messenger, text string, at time.Time) error {
+	if !p.notifiers[messenger] {
+		return ErrNoNotifier
+	}
+	p.pushed = append(p.pushed, heldPush{userID: userID, text: text, at: at})
+	return nil
+}
+
+func TestNotificationDispatcherDispatch(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "en")
+	// 23:00 in Tokyo
+	now := time.Date(2026, 3, 10, 14, 0, 0, 0, time.UTC)
+	verified := now.AddDate(0, -1, 0)
+
+	userRepo := NewMockUserRepository()
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "line", Timezone: "UTC"}))
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user2", MessengerType: "line", Timezone: "Asia/Tokyo"}))
+
+	addresses := NewMockEmailAddressRepository()
+	require.NoError(t, addresses.Save(ctx, &domain.EmailAddress{Address: "pending@example.com", UserID: "user1"}))
+	require.NoError(t, addresses.Save(ctx, &domain.EmailAddress{Address: "user1@example.com", UserID: "user1", VerifiedAt: &verified}))
+
+	prefsRepo := NewMockNotificationPreferencesRepository()
+	prefs := domain.DefaultNotificationPreferences("user1")
+	prefs.Channels = map[string]string{
+		domain.NotificationWeeklyReport:     domain.NotificationChannelEmail,
+		domain.NotificationExpenseReminders: domain.NotificationChannelInApp,
+	}
+	prefs.BudgetAlerts = false
+	prefs.ExpenseReminders = true
+	prefs.DailyDigest = true
+	require.NoError(t, prefsRepo.Save(ctx, prefs))
+	prefs = domain.DefaultNotificationPreferences("user2")
+	prefs.Channels = map[string]string{domain.NotificationWeeklyReport: domain.NotificationChannelEmail}
+	require.NoError(t, prefsRepo.Save(ctx, prefs))
+
+	pusher := &recordingHeldPusher{notifiers: map[string]bool{"line": true}}
+	sender := &fakeEmailSender{}
+	publisher := &recordingPublisher{}
+	d := NewNotificationDispatcher(prefsRepo, pusher)
+	d.SetEventPublisher(publisher)
+	d.SetEmail(addresses, sender)
+	d.SetTimezoneLocator(NewTimezoneUseCase(userRepo))
+	d.now = func() time.Time { return now }
+
+	dispatch := func(userID, notification, text string) {
+		t.Helper()
+		require.NoError(t, d.For(notification).Push(ctx, userID, "line", text))
+	}
+
+	// Turned off
+	dispatch("user1", domain.NotificationBudgetAlerts, "Budget exceeded")
+	assert.Empty(t, publisher.events)
+
+	// In-app only
+	dispatch("user1", domain.NotificationExpenseReminders, "Time to record")
+	assert.Equal(t, []string{"user1 notification.created"}, publisher.events)
+	assert.Equal(t, "Expense reminder", publisher.data[0].(*Notification).Title)
+	assert.Empty(t, pusher.pushed)
+
+	// Emailed to the verified address
+	dispatch("user1", domain.NotificationWeeklyReport, "Last week")
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, "user1@example.com", sender.sent[0].To)
+	assert.Equal(t, "Weekly report", sender.sent[0].Subject)
+	assert.Empty(t, pusher.pushed)
+
+	// Pushed right away outside quiet hours
+	dispatch("user1", domain.NotificationDailyDigest, "Yesterday")
+	require.Len(t, pusher.pushed, 1)
+	assert.Equal(t, heldPush{userID: "user1", text: "Yesterday", at: now}, pusher.pushed[0])
+
+	// user2 has no address to email, and it's 23:00 for them, so the report
+	// is pushed at 08:00 the next morning
+	dispatch("user2", domain.NotificationWeeklyReport, "Last week")
+	require.Len(t, pusher.pushed, 2)
+	assert.True(t, pusher.pushed[1].at.Equal(time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)), "pushed at %v", pusher.pushed[1].at)
+	assert.Len(t, publisher.events, 4)
+
+	// The event is published before finding the messenger can't be pushed to
+	assert.ErrorIs(t, d.For(domain.NotificationDailyDigest).Push(ctx, "user1", "teams", "Yesterday"), ErrNoNotifier)
+	assert.Len(t, publisher.events, 5)
+}
+
+func TestQuietHoursOver(t *testing.T) {
+	prefs := &domain.NotificationPreferences{QuietHoursStart: 22, QuietHoursEnd: 8}
+	tests := []struct {
+		local time.Time
+		want  time.Time
+	}{
+		{time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC), time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 3, 11, 2, 0, 0, 0, time.UTC), time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, quietHoursOver(prefs, tt.local), "quietHoursOver(%v)", tt.local)
+	}
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// Onboarding wizard steps, the slot each answer fills
+const (
+	onboardingStepLanguage = "language"
+	onboardingStepCurrency = "currency"
+	onboardingStepBudget   = "budget"
+)
+
+// onboardingSkipKeywords finish the wizard, keeping the defaults for the steps left
+var onboardingSkipKeywords = []string{"skip", "跳過", "跳过", "略過", "スキップ"}
+
+// onboardingLocales are the languages offered, in the order they are numbered
+var onboardingLocales = []struct {
+	locale string
+	label  string
+}{
+	{"zh-TW", "繁體中文"},
+	{"zh-CN", "简体中文"},
+	{"en", "English"},
+	{"ja", "日本語"},
+}
+
+// onboardingCurrencyAliases map currency names typed instead of codes
+var onboardingCurrencyAliases = map[string]string{
+	"台幣": "TWD", "新台幣": "TWD", "臺幣": "TWD",
+	"人民幣": "CNY", "人民币": "CNY",
+	"美金": "USD", "美元": "USD",
+	"日幣": "JPY", "日圓": "JPY", "日元": "JPY", "円": "JPY",
+	"港幣": "HKD", "港币": "HKD",
+	"歐元": "EUR", "欧元": "EUR",
+}
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// BudgetCreator creates the monthly budget picked during onboarding
+type BudgetCreator interface {
+	CreateBudget(ctx context.Context, req *CreateBudgetRequest) (*BudgetResponse, error)
+}
+
+// OnboardingUseCase walks a new user through a short setup before their
+// messages are recorded: their language, home currency and monthly budget.
+// Each answer is saved on the user as it is given; the wizard's progress is
+// kept in the conversation state, so an unanswered step expires with it and
+// the wizard starts over.
+type OnboardingUseCase struct {
+	userRepo     domain.UserRepository
+	conversation ConversationStore
+	budgets      BudgetCreator
+	now          func() time.Time
+}
+
+// NewOnboardingUseCase creates a new onboarding use case
+func NewOnboardingUseCase(userRepo domain.UserRepository, conversation ConversationStore) *OnboardingUseCase {
+	return &OnboardingUseCase{
+		userRepo:     userRepo,
+		conversation: conversation,
+		now:          time.Now,
+	}
+}
+
+// SetBudgetCreator enables the monthly budget step; the wizard ends after the
+// currency when unset
+func (u *OnboardingUseCase) SetBudgetCreator(budgets BudgetCreator) {
+	u.budgets = budgets
+}
+
+// Onboard answers the message with the wizard's next step while the user is
+// being onboarded. It returns false once they are, or when their onboarding
+// can't be looked up, so the message is processed as usual.
+func (u *OnboardingUseCase) Onboard(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, bool) {
+	user, err := u.userRepo.GetByID(ctx, msg.UserID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			slog.WarnContext(ctx, "Failed to get user for onboarding", "error", err)
+		}
+		return nil, false
+	}
+	if user.OnboardedAt != nil {
+		return nil, false
+	}
+
+	state, err := u.conversation.Pending(ctx, user.UserID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get onboarding state", "error", err)
+		return nil, false
+	}
+	if state == nil || state.Intent != domain.MessageActionOnboarding {
+		return u.ask(ctx, user, onboardingStepLanguage, ""), true
+	}
+
+	text := strings.TrimSpace(msg.Content)
+	if isOnboardingSkip(text) || isCancellation(text) {
+		return u.finish(ctx, user), true
+	}
+
+	switch state.Awaiting {
+	case onboardingStepLanguage:
+		locale, ok := parseOnboardingLocale(text)
+		if !ok {
+			return u.ask(ctx, user, onboardingStepLanguage, "Sorry, I didn't get that. 抱歉，我看不懂。"), true
+		}
+		user.Locale = locale
+		if !u.save(ctx, user) {
+			return &domain.MessageResponse{Text: i18n.T(user.Locale, "onboarding.save_failed")}, true
+		}
+		return u.ask(ctx, user, onboardingStepCurrency, ""), true
+
+	case onboardingStepCurrency:
+		currency, ok := parseOnboardingCurrency(text)
+		if !ok {
+			return u.ask(ctx, user, onboardingStepCurrency, i18n.T(user.Locale, "onboarding.retry")), true
+		}
+		user.HomeCurrency = currency
+		if !u.save(ctx, user) {
+			return &domain.MessageResponse{Text: i18n.T(user.Locale, "onboarding.save_failed")}, true
+		}
+		if u.budgets == nil {
+			return u.finish(ctx, user), true
+		}
+		return u.ask(ctx, user, onboardingStepBudget, ""), true
+
+	default:
+		limit, ok := parseOnboardingBudget(text)
+		if !ok {
+			return u.ask(ctx, user, onboardingStepBudget, i18n.T(user.Locale, "onboarding.retry")), true
+		}
+		if limit > 0 {
+			if _, err := u.budgets.CreateBudget(ctx, &CreateBudgetRequest{UserID: user.UserID, Limit: limit, Period: domain.BudgetPeriodMonthly}); err != nil {
+				slog.WarnContext(ctx, "Failed to create onboarding budget", "error", err)
+			}
+		}
+		return u.finish(ctx, user), true
+	}
+}
+
+// ask asks the step's question, after the retry text when the last answer
+// wasn't understood
+func (u *OnboardingUseCase) ask(ctx context.Context, user *domain.User, step, retry string) *domain.MessageResponse {
+	if err := u.conversation.Ask(ctx, user.UserID, domain.MessageActionOnboarding, step, nil); err != nil {
+		slog.WarnContext(ctx, "Failed to save onboarding state", "step", step, "error", err)
+	}
+
+	resp := &domain.MessageResponse{}
+	switch step {
+	case onboardingStepLanguage:
+		var sb strings.Builder
+		if retry == "" {
+			sb.WriteString("👋 Welcome to AI Expense! 歡迎使用 AI Expense！\n")
+		}
+		sb.WriteString("1/3 Pick your language 請選擇語言:")
+		for i, l := range onboardingLocales {
+			sb.WriteString(fmt.Sprintf("\n%d. %s", i+1, l.label))
+			resp.Buttons = append(resp.Buttons, &domain.MessageButton{Label: l.label, Action: domain.MessageActionOnboarding, Value: l.locale})
+		}
+		sb.WriteString("\n(skip / 跳過 to keep the defaults)")
+		resp.Text = sb.String()
+	case onboardingStepCurrency:
+		resp.Text = i18n.T(user.Locale, "onboarding.currency")
+		for _, c := range strings.Split(i18n.T(user.Locale, "onboarding.currencies"), ",") {
+			resp.Buttons = append(resp.Buttons, &domain.MessageButton{Label: c, Action: domain.MessageActionOnboarding, Value: c})
+		}
+	default:
+		resp.Text = i18n.T(user.Locale, "onboarding.budget")
+	}
+	if retry != "" {
+		resp.Text = retry + "\n" + resp.Text
+	}
+	return resp
+}
+
+// finish marks the user onboarded, keeping the defaults of the steps left
+func (u *OnboardingUseCase) finish(ctx context.Context, user *domain.User) *domain.MessageResponse {
+	now := u.now()
+	user.OnboardedAt = &now
+	u.save(ctx, user)
+	if err := u.conversation.Clear(ctx, user.UserID); err != nil {
+		slog.WarnContext(ctx, "Failed to clear onboarding state", "error", err)
+	}
+	return &domain.MessageResponse{Text: i18n.T(user.Locale, "onboarding.done")}
+}
+
+// save stores the user's answers so far, reporting false when they couldn't be
+func (u *OnboardingUseCase) save(ctx context.Context, user *domain.User) bool {
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Failed to save onboarding answer", "error", err)
+		return false
+	}
+	return true
+}
+
+// isOnboardingSkip reports whether the message asks to skip the wizard
+func isOnboardingSkip(text string) bool {
+	text = strings.ToLower(text)
+	for _, k := range onboardingSkipKeywords {
+		if text == k {
+			return true
+		}
+	}
+	return false
+}
+
+// parseOnboardingLocale reads the language picked by its number, name or locale
+func parseOnboardingLocale(text string) (string, bool) {
+	if n, err := strconv.Atoi(text); err == nil {
+		if n >= 1 && n <= len(onboardingLocales) {
+			return onboardingLocales[n-1].locale, true
+		}
+		return "", false
+	}
+	for _, l := range onboardingLocales {
+		if strings.EqualFold(text, l.locale) || strings.EqualFold(text, l.label) {
+			return l.locale, true
+		}
+	}
+	switch strings.ToLower(text) {
+	case "中文", "繁中", "繁體":
+		return "zh-TW", true
+	case "简中", "简体":
+		return "zh-CN", true
+	case "en", "英文":
+		return "en", true
+	case "日文", "日本語", "にほんご":
+		return "ja", true
+	}
+	return "", false
+}
+
+// parseOnboardingCurrency reads a currency code, or a currency name such as 台幣
+func parseOnboardingCurrency(text string) (string, bool) {
+	if code, ok := onboardingCurrencyAliases[text]; ok {
+		return code, true
+	}
+	code := strings.ToUpper(text)
+	return code, currencyCodePattern.MatchString(code)
+}
+
+// parseOnboardingBudget reads a monthly amount such as "20,000" or "$500";
+// 0 means no budget
+func parseOnboardingBudget(text string) (float64, bool) {
+	text = strings.NewReplacer(",", "", "$", "", "元", "", " ", "").Replace(text)
+	limit, err := strconv.ParseFloat(text, 64)
+	if err != nil || limit < 0 {
+		return 0, false
+	}
+	return limit, true
+}
@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingBudgetCreator records the budgets created
+type recordingBudgetCreator struct {
+	requests []*CreateBudgetRequest
+}
+
+func (c *recordingBudgetCreator) CreateBudget(ctx context.Context, req *CreateBudgetRequest) (*BudgetResponse, error) {
+	c.requests = append(c.requests, req)
+	return &BudgetResponse{}, nil
+}
+
+func TestOnboardingUseCase(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	newOnboarding := func() (*OnboardingUseCase, *MockUserRepository, *recordingBudgetCreator) {
+		userRepo := NewMockUserRepository()
+		userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "line", HomeCurrency: "TWD", Locale: "zh-TW", CreatedAt: now})
+		budgets := &recordingBudgetCreator{}
+		uc := NewOnboardingUseCase(userRepo, NewConversationStateUseCase(NewMockConversationStateRepository(), 0))
+		uc.SetBudgetCreator(budgets)
+		uc.now = func() time.Time { return now }
+		return uc, userRepo, budgets
+	}
+	send := func(uc *OnboardingUseCase, text string) *domain.MessageResponse {
+		resp, ok := uc.Onboard(ctx, &domain.UserMessage{UserID: "user1", Content: text, Source: "line"})
+		require.True(t, ok, "expected %q to be answered by the wizard", text)
+		return resp
+	}
+
+	t.Run("Wizard", func(t *testing.T) {
+		uc, userRepo, budgets := newOnboarding()
+
+		// The first message starts the wizard
+		resp := send(uc, "午餐 120")
+		assert.Contains(t, resp.Text, "Welcome")
+		assert.Len(t, resp.Buttons, 4)
+
+		resp = send(uc, "Klingon")
+		assert.Contains(t, resp.Text, "Sorry")
+		resp = send(uc, "3")
+		assert.Contains(t, resp.Text, "home currency")
+		resp = send(uc, "usd")
+		assert.Contains(t, resp.Text, "per month")
+		resp = send(uc, "lots")
+		assert.Contains(t, resp.Text, "Sorry, I didn't get that.")
+		resp = send(uc, "20,000")
+		assert.Contains(t, resp.Text, "All set")
+
+		user, _ := userRepo.GetByID(ctx, "user1")
+		assert.Equal(t, "en", user.Locale)
+		assert.Equal(t, "USD", user.HomeCurrency)
+		assert.Equal(t, &now, user.OnboardedAt)
+		require.Len(t, budgets.requests, 1)
+		assert.Equal(t, 20000.0, budgets.requests[0].Limit)
+		assert.Equal(t, domain.BudgetPeriodMonthly, budgets.requests[0].Period)
+
+		// Onboarded users' messages are processed as usual
+		_, ok := uc.Onboard(ctx, &domain.UserMessage{UserID: "user1", Content: "午餐 120"})
+		assert.False(t, ok)
+	})
+
+	t.Run("Skip keeps the defaults", func(t *testing.T) {
+		uc, userRepo, budgets := newOnboarding()
+
+		send(uc, "hi")
+		resp := send(uc, "台幣")
+		assert.Contains(t, resp.Text, "Sorry")
+		resp = send(uc, "跳過")
+		assert.Equal(t, i18n.T("zh-TW", "onboarding.done"), resp.Text)
+
+		user, _ := userRepo.GetByID(ctx, "user1")
+		assert.Equal(t, "zh-TW", user.Locale)
+		assert.Equal(t, "TWD", user.HomeCurrency)
+		assert.NotNil(t, user.OnboardedAt)
+		assert.Empty(t, budgets.requests)
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		uc, _, _ := newOnboarding()
+		_, ok := uc.Onboard(ctx, &domain.UserMessage{UserID: "user2", Content: "hi"})
+		assert.False(t, ok)
+	})
+}
+
+func TestParseOnboardingAnswers(t *testing.T) {
+	for text, want := range map[string]string{"1": "zh-TW", "简体中文": "zh-CN", "english": "en", "ja": "ja"} {
+		locale, ok := parseOnboardingLocale(text)
+		assert.True(t, ok, text)
+		assert.Equal(t, want, locale, text)
+	}
+	_, ok := parseOnboardingLocale("5")
+	assert.False(t, ok)
+
+	for text, want := range map[string]string{"twd": "TWD", "日幣": "JPY", "EUR": "EUR"} {
+		currency, ok := parseOnboardingCurrency(text)
+		assert.True(t, ok, text)
+		assert.Equal(t, want, currency, text)
+	}
+	_, ok = parseOnboardingCurrency("dollars")
+	assert.False(t, ok)
+
+	limit, ok := parseOnboardingBudget("$1,500")
+	assert.True(t, ok)
+	assert.Equal(t, 1500.0, limit)
+	_, ok = parseOnboardingBudget("-5")
+	assert.False(t, ok)
+}
@@ -3,7 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"regexp"
 	"strings"
 	"time"
@@ -19,6 +19,12 @@ type ParseConversationUseCase struct {
 	costRepo    domain.AICostRepository
 	provider    string // e.g., "gemini"
 	model       string // e.g., "gemini-2.5-lite"
+	costGuard   AICostGuard
+}
+
+// AICostGuard decides whether an AI call may be made for a user
+type AICostGuard interface {
+	AllowAI(ctx context.Context, userID string) bool
 }
 
 // NewParseConversationUseCase creates a new parse conversation use case
@@ -38,13 +44,25 @@ func NewParseConversationUseCase(
 	}
 }
 
-// Execute parses conversation text and extracts expenses with cost tracking
+// SetCostGuard stops AI calls once spending caps are reached; text messages then
+// fall back to the regex parser
+func (u *ParseConversationUseCase) SetCostGuard(costGuard AICostGuard) {
+	u.costGuard = costGuard
+}
+
+// Execute parses conversation text and extracts expenses with cost tracking.
+// Hashtags such as #出差 tag every expense in the text and are left out of
+// what is parsed, so they don't end up in descriptions.
 func (u *ParseConversationUseCase) Execute(ctx context.Context, text, userID string) (*domain.ParseResult, error) {
+	tags, text := domain.ParseHashtags(text)
+
 	// Call AI service to parse expenses (returns token metadata)
-	resp, err := u.aiService.ParseExpense(ctx, text, userID)
+	resp, err := u.parseExpense(ctx, text, userID)
 	var expenses []*domain.ParsedExpense
 	var tokens *ai.TokenMetadata
 	var systemPrompt, rawResponse string
+	var promptVersion int
+	provider, model := u.provider, currentModel(u.aiService, u.model)
 
 	if err != nil || resp == nil || len(resp.Expenses) == 0 {
 		// Fallback to regex parsing if AI fails or returns no expenses
@@ -54,26 +72,29 @@ func (u *ParseConversationUseCase) Execute(ctx context.Context, text, userID str
 		expenses = resp.Expenses
 		tokens = resp.Tokens
 		systemPrompt = resp.SystemPrompt
+		promptVersion = resp.PromptVersion
 		rawResponse = resp.RawResponse
+		provider, model = servedBy(provider, model, resp.Provider, resp.Model)
 	}
 
 	// Parse relative dates ONLY if date is zero (not set by AI)
 	for _, expense := range expenses {
 		if expense.Date.IsZero() {
-			log.Printf("DEBUG: Expense date is zero, parsing relative date from text: %s", text)
-			expense.Date = u.parseDate(text)
+			slog.DebugContext(ctx, "Expense date is zero, parsing relative date from text", "text", text)
+			expense.Date = u.parseDate(ctx, text)
 		} else {
-			log.Printf("DEBUG: Expense date already set (by AI?): %v", expense.Date)
+			slog.DebugContext(ctx, "Expense date already set by AI", "date", expense.Date)
 		}
 
 		// Set default account if not set
 		if expense.Account == "" {
 			expense.Account = "Cash"
 		}
+		expense.Tags = tags
 	}
 
 	// Log cost asynchronously (if pricing available)
-	go u.logCost(context.Background(), userID, tokens)
+	go u.logCost(context.Background(), userID, "parse_conversation", provider, model, tokens, promptVersion)
 
 	return &domain.ParseResult{
 		Expenses:     expenses,
@@ -82,103 +103,112 @@ func (u *ParseConversationUseCase) Execute(ctx context.Context, text, userID str
 	}, nil
 }
 
-// logCost calculates and logs the cost of the AI API call
-func (u *ParseConversationUseCase) logCost(ctx context.Context, userID string, tokens *ai.TokenMetadata) {
-	if tokens == nil || u.costRepo == nil || u.pricingRepo == nil {
-		return
+// parseExpense streams partial results to the caller when both the provider and the
+// context support it, otherwise it falls back to a single blocking call
+func (u *ParseConversationUseCase) parseExpense(ctx context.Context, text, userID string) (*ai.ParseExpenseResponse, error) {
+	if u.costGuard != nil && !u.costGuard.AllowAI(ctx, userID) {
+		return nil, ErrAICostCapReached
 	}
+	if onProgress := domain.ProgressFromContext(ctx); onProgress != nil {
+		if streaming, ok := u.aiService.(ai.StreamingService); ok {
+			return streaming.ParseExpenseStream(ctx, text, userID, onProgress)
+		}
+	}
+	return u.aiService.ParseExpense(ctx, text, userID)
+}
 
-	// Skip logging if no tokens were used (fallback parsing or zero input)
-	if tokens.TotalTokens == 0 {
-		return
+// ExecuteReceipt runs OCR and line-item extraction on a receipt image with cost tracking
+func (u *ParseConversationUseCase) ExecuteReceipt(ctx context.Context, image *domain.Attachment, userID string) (*domain.ParseResult, error) {
+	if image == nil || len(image.Data) == 0 {
+		return nil, fmt.Errorf("receipt image is empty")
 	}
 
-	// Look up pricing for provider/model
-	pricing, err := u.pricingRepo.GetByProviderAndModel(ctx, u.provider, u.model)
-	if err != nil {
-		log.Printf("ERROR: Failed to lookup pricing for %s/%s: %v", u.provider, u.model, err)
-		return
+	if u.costGuard != nil && !u.costGuard.AllowAI(ctx, userID) {
+		return nil, ErrAICostCapReached
 	}
 
-	var cost float64
-	var costNote *string
-	if pricing == nil {
-		// Pricing not configured
-		cost = 0
-		msg := "pricing_not_configured"
-		costNote = &msg
-		log.Printf("WARN: Pricing not configured for %s/%s", u.provider, u.model)
-	} else {
-		// Calculate cost
-		cost = pricing.GetCost(tokens.InputTokens, tokens.OutputTokens)
+	receiptService, ok := u.aiService.(ai.ReceiptService)
+	if !ok {
+		return nil, fmt.Errorf("AI provider %s does not support receipt images", u.provider)
 	}
 
-	// Create and persist cost log
-	costLog := &domain.AICostLog{
-		ID:           fmt.Sprintf("log_%d", time.Now().UnixNano()),
-		UserID:       userID,
-		Operation:    "parse_conversation",
-		Provider:     u.provider,
-		Model:        u.model,
-		InputTokens:  tokens.InputTokens,
-		OutputTokens: tokens.OutputTokens,
-		TotalTokens:  tokens.TotalTokens,
-		Cost:         cost,
-		Currency:     "USD",
-		CostNote:     costNote,
-		CreatedAt:    time.Now().UTC(),
+	resp, err := receiptService.ParseReceipt(ctx, image.Data, image.MimeType, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
 	}
 
-	if err := u.costRepo.Create(ctx, costLog); err != nil {
-		log.Printf("ERROR: Failed to log cost: %v", err)
+	for _, expense := range resp.Expenses {
+		if expense.Date.IsZero() {
+			expense.Date = domain.LocalNow(ctx)
+		}
+		if expense.Account == "" {
+			expense.Account = "Cash"
+		}
 	}
+
+	provider, model := servedBy(u.provider, currentModel(u.aiService, u.model), resp.Provider, resp.Model)
+	go u.logCost(context.Background(), userID, "parse_receipt", provider, model, resp.Tokens, resp.PromptVersion)
+
+	return &domain.ParseResult{
+		Expenses:     resp.Expenses,
+		Merchant:     resp.Merchant,
+		SystemPrompt: resp.SystemPrompt,
+		RawResponse:  resp.RawResponse,
+	}, nil
+}
+
+// logCost calculates and logs the cost of the AI API call
+func (u *ParseConversationUseCase) logCost(ctx context.Context, userID, operation, provider, model string, tokens *ai.TokenMetadata, promptVersion int) {
+	logAICost(ctx, u.pricingRepo, u.costRepo, provider, model, userID, operation, tokens, promptVersion)
 }
 
-// parseDate extracts relative dates from text (昨天, 上週, etc.)
-func (u *ParseConversationUseCase) parseDate(text string) time.Time {
+// parseDate extracts relative dates from text (昨天, 上週, etc.), counted
+// from today in the user's timezone
+func (u *ParseConversationUseCase) parseDate(ctx context.Context, text string) time.Time {
+	now := domain.LocalNow(ctx)
 	text = strings.ToLower(text)
-	log.Printf("DEBUG: parseDate called with: %s", text)
+	slog.Debug("parseDate called", "text", text)
 
 	// Check for day before yesterday (前天) - MUST check before yesterday
 	if strings.Contains(text, "前天") || strings.Contains(text, "前日") {
-		d := time.Now().AddDate(0, 0, -2)
-		log.Printf("DEBUG: Detect '前天', returning %v", d)
+		d := now.AddDate(0, 0, -2)
+		slog.Debug("Detected 前天", "date", d)
 		return d
 	}
 
 	// Check for yesterday (昨天)
 	if strings.Contains(text, "昨天") || strings.Contains(text, "昨日") {
-		return time.Now().AddDate(0, 0, -1)
+		return now.AddDate(0, 0, -1)
 	}
 
 	// Check for tomorrow (明天)
 	if strings.Contains(text, "明天") || strings.Contains(text, "明日") {
-		return time.Now().AddDate(0, 0, 1)
+		return now.AddDate(0, 0, 1)
 	}
 
 	// Check for day after tomorrow (後天)
 	if strings.Contains(text, "後天") || strings.Contains(text, "后天") {
-		return time.Now().AddDate(0, 0, 2)
+		return now.AddDate(0, 0, 2)
 	}
 
 	// Check for last week
 	if strings.Contains(text, "上週") || strings.Contains(text, "上周") {
-		return time.Now().AddDate(0, 0, -7)
+		return now.AddDate(0, 0, -7)
 	}
 
 	// Check for last month
 	if strings.Contains(text, "上個月") || strings.Contains(text, "上月") {
-		return time.Now().AddDate(0, -1, 0)
+		return now.AddDate(0, -1, 0)
 	}
 
 	// Default to today
-	return time.Now()
+	return now
 }
 
 // parseWithRegex uses regex to extract expenses (fallback)
 func (u *ParseConversationUseCase) parseWithRegex(text string) []*domain.ParsedExpense {
 	// Debug log
-	log.Printf("DEBUG: parseWithRegex called with: %s\n", text)
+	slog.Debug("parseWithRegex called", "text", text)
 	var expenses []*domain.ParsedExpense
 
 	// Helper
@@ -201,7 +231,7 @@ func (u *ParseConversationUseCase) parseWithRegex(text string) []*domain.ParsedE
 		expense := &domain.ParsedExpense{
 			Description: description,
 			Amount:      amount,
-			// Date:        time.Now(), // DON'T SET DATE HERE, let Execute() handle it
+			// Date:        now, // DON'T SET DATE HERE, let Execute() handle it
 		}
 		expenses = append(expenses, expense)
 	}
@@ -209,12 +239,10 @@ func (u *ParseConversationUseCase) parseWithRegex(text string) []*domain.ParsedE
 	// Pattern 1: description$amount
 	reDollar := regexp.MustCompile(`([^\d$]+?)\s*\$(\d+(?:\.\d{2})?)`)
 	dollarMatches := reDollar.FindAllStringSubmatch(text, -1)
-	fmt.Printf("DEBUG: dollarMatches: %v\n", dollarMatches)
 
 	// Pattern 2: description amount 元
 	reYuan := regexp.MustCompile(`(.*?)\s+(\d+(?:\.\d{2})?)\s*元`)
 	yuanMatches := reYuan.FindAllStringSubmatch(text, -1)
-	fmt.Printf("DEBUG: yuanMatches: %v\n", yuanMatches)
 
 	if len(dollarMatches) > 0 || len(yuanMatches) > 0 {
 		for _, match := range dollarMatches {
@@ -227,7 +255,6 @@ func (u *ParseConversationUseCase) parseWithRegex(text string) []*domain.ParsedE
 		// Pattern 3: Loose space
 		reSpace := regexp.MustCompile(`([^\d]+?)\s+(\d+(?:\.\d{2})?)(?:\s|$)`)
 		matches := reSpace.FindAllStringSubmatch(text, -1)
-		fmt.Printf("DEBUG: reSpace matches: %v\n", matches)
 		for _, match := range matches {
 			addExpense(match[1], match[2])
 		}
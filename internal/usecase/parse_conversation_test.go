@@ -115,4 +115,20 @@ func TestParseDateLogic(t *testing.T) {
 			}
 		})
 	}
+	// Dates are counted from the user's today, which is a day apart in these
+	// timezones for most of the day
+	for _, name := range []string{"Pacific/Kiritimati", "Etc/GMT+12"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatalf("LoadLocation(%s): %v", name, err)
+		}
+		result, err := uc.Execute(domain.WithLocation(ctx, loc), "昨天 lunch $15", "user")
+		if err != nil || len(result.Expenses) == 0 {
+			t.Fatalf("Execute() = %v, %v", result, err)
+		}
+		want := time.Now().In(loc).AddDate(0, 0, -1).Format("2006-01-02")
+		if got := result.Expenses[0].Date.Format("2006-01-02"); got != want {
+			t.Errorf("%s: parseDate() = %v, want %v", name, got, want)
+		}
+	}
 }
@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ErrPricingNotFound is returned for a pricing row that doesn't exist
+var ErrPricingNotFound = fmt.Errorf("pricing %w", domain.ErrNotFound)
+
+// ErrModelNotPriced is returned by ValidateModel for a model without active
+// pricing, whose AI calls would be logged at zero cost
+var ErrModelNotPriced = errors.New("no active pricing for model; its AI calls would be logged at zero cost")
+
+// PricingManagementUseCase lets admins maintain the per-model token prices
+// that AI cost logs are priced with
+type PricingManagementUseCase struct {
+	pricingRepo domain.PricingRepository
+}
+
+// PricingRequest holds the fields an admin sets on a pricing row. Prices are
+// USD per million tokens.
+type PricingRequest struct {
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	InputTokenPrice  float64 `json:"input_token_price"`
+	OutputTokenPrice float64 `json:"output_token_price"`
+}
+
+// NewPricingManagementUseCase creates a new pricing management use case
+func NewPricingManagementUseCase(pricingRepo domain.PricingRepository) *PricingManagementUseCase {
+	return &PricingManagementUseCase{pricingRepo: pricingRepo}
+}
+
+// validatePrices rejects prices that would log a model's calls at no or negative cost
+func validatePrices(input, output float64) error {
+	if input < 0 || output < 0 {
+		return fmt.Errorf("token prices cannot be negative")
+	}
+	if input == 0 && output == 0 {
+		return fmt.Errorf("input_token_price or output_token_price is required")
+	}
+	return nil
+}
+
+// List returns the active pricing rows
+func (u *PricingManagementUseCase) List(ctx context.Context) ([]*domain.PricingConfig, error) {
+	configs, err := u.pricingRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pricing: %w", err)
+	}
+	active := []*domain.PricingConfig{}
+	for _, config := range configs {
+		if config.IsActive {
+			active = append(active, config)
+		}
+	}
+	return active, nil
+}
+
+// Create stores the pricing of a provider's model, effective now. The model's
+// earlier pricing is deactivated, so exactly one row prices its calls.
+func (u *PricingManagementUseCase) Create(ctx context.Context, req *PricingRequest) (*domain.PricingConfig, error) {
+	provider := strings.TrimSpace(req.Provider)
+	model := strings.TrimSpace(req.Model)
+	if provider == "" || model == "" {
+		return nil, fmt.Errorf("provider and model are required")
+	}
+	if err := validatePrices(req.InputTokenPrice, req.OutputTokenPrice); err != nil {
+		return nil, err
+	}
+
+	current, err := u.pricingRepo.GetByProviderAndModel(ctx, provider, model)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get current pricing: %w", err)
+	}
+	if current != nil {
+		if err := u.pricingRepo.Deactivate(ctx, provider, model); err != nil {
+			return nil, fmt.Errorf("failed to deactivate current pricing: %w", err)
+		}
+	}
+
+	now := time.Now().UTC()
+	config := &domain.PricingConfig{
+		ID:               uuid.New().String(),
+		Provider:         provider,
+		Model:            model,
+		InputTokenPrice:  req.InputTokenPrice,
+		OutputTokenPrice: req.OutputTokenPrice,
+		Currency:         "USD",
+		EffectiveDate:    now,
+		IsActive:         true,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := u.pricingRepo.Create(ctx, config); err != nil {
+		return nil, fmt.Errorf("failed to create pricing: %w", err)
+	}
+	return config, nil
+}
+
+// Update changes the token prices of an active pricing row
+func (u *PricingManagementUseCase) Update(ctx context.Context, id string, inputPrice, outputPrice float64) (*domain.PricingConfig, error) {
+	if err := validatePrices(inputPrice, outputPrice); err != nil {
+		return nil, err
+	}
+	config, err := u.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !config.IsActive {
+		return nil, fmt.Errorf("pricing is deactivated; create a new one for %s/%s instead", config.Provider, config.Model)
+	}
+
+	config.InputTokenPrice = inputPrice
+	config.OutputTokenPrice = outputPrice
+	config.UpdatedAt = time.Now().UTC()
+	if err := u.pricingRepo.Update(ctx, config); err != nil {
+		return nil, fmt.Errorf("failed to update pricing: %w", err)
+	}
+	return config, nil
+}
+
+// Deactivate stops a pricing row from pricing new AI calls
+func (u *PricingManagementUseCase) Deactivate(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	config, err := u.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !config.IsActive {
+		return config, nil
+	}
+
+	config.IsActive = false
+	config.UpdatedAt = time.Now().UTC()
+	if err := u.pricingRepo.Update(ctx, config); err != nil {
+		return nil, fmt.Errorf("failed to deactivate pricing: %w", err)
+	}
+	return config, nil
+}
+
+// ValidateModel returns the pricing AI calls to a provider's model are logged
+// with, or ErrModelNotPriced when they would be logged at zero cost
+func (u *PricingManagementUseCase) ValidateModel(ctx context.Context, provider, model string) (*domain.PricingConfig, error) {
+	config, err := u.pricingRepo.GetByProviderAndModel(ctx, provider, model)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("%s/%s: %w", provider, model, ErrModelNotPriced)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing: %w", err)
+	}
+	return config, nil
+}
+
+func (u *PricingManagementUseCase) get(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	config, err := u.pricingRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrPricingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing: %w", err)
+	}
+	return config, nil
+}
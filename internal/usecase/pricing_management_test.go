@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestPricingManagement(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockPricingRepository()
+	uc := NewPricingManagementUseCase(repo)
+
+	if _, err := uc.ValidateModel(ctx, "gemini", "gemini-2.5-lite"); !errors.Is(err, ErrModelNotPriced) {
+		t.Fatalf("expected an unpriced model, got %v", err)
+	}
+
+	for _, req := range []*PricingRequest{
+		{Provider: "gemini", InputTokenPrice: 0.1, OutputTokenPrice: 0.4},
+		{Provider: "gemini", Model: "gemini-2.5-lite"},
+		{Provider: "gemini", Model: "gemini-2.5-lite", InputTokenPrice: -1, OutputTokenPrice: 0.4},
+	} {
+		if _, err := uc.Create(ctx, req); err == nil {
+			t.Errorf("expected %+v to be rejected", req)
+		}
+	}
+
+	first, err := uc.Create(ctx, &PricingRequest{Provider: "gemini", Model: "gemini-2.5-lite", InputTokenPrice: 0.1, OutputTokenPrice: 0.4})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := uc.ValidateModel(ctx, "gemini", "gemini-2.5-lite"); err != nil {
+		t.Errorf("expected the model priced, got %v", err)
+	}
+
+	second, err := uc.Create(ctx, &PricingRequest{Provider: "gemini", Model: "gemini-2.5-lite", InputTokenPrice: 0.2, OutputTokenPrice: 0.8})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !repo.deactivated["gemini:gemini-2.5-lite"] {
+		t.Errorf("expected %s deactivated by the new pricing", first.ID)
+	}
+
+	updated, err := uc.Update(ctx, second.ID, 0.3, 1.2)
+	if err != nil || updated.InputTokenPrice != 0.3 || !updated.IsActive {
+		t.Fatalf("expected the prices updated and the row kept active, got %+v, %v", updated, err)
+	}
+	if _, err := uc.Update(ctx, second.ID, 0, 0); err == nil {
+		t.Error("expected zero prices to be rejected")
+	}
+	if _, err := uc.Update(ctx, "missing", 0.3, 1.2); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := uc.Deactivate(ctx, second.ID); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+	if _, err := uc.ValidateModel(ctx, "gemini", "gemini-2.5-lite"); !errors.Is(err, ErrModelNotPriced) {
+		t.Errorf("expected the deactivated model unpriced, got %v", err)
+	}
+	if _, err := uc.Update(ctx, second.ID, 0.3, 1.2); err == nil {
+		t.Error("expected a deactivated row to reject updates")
+	}
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
@@ -50,7 +51,7 @@ func (u *PricingSyncUseCase) Sync(ctx context.Context) (*SyncResult, error) {
 		return result, err
 	}
 
-	fmt.Printf("Sync started for provider=%s, found %d models\n", result.Provider, len(fetchedConfigs))
+	slog.InfoContext(ctx, "Pricing sync started", "provider", result.Provider, "models", len(fetchedConfigs))
 
 	// Compare and update pricing
 	for _, fetched := range fetchedConfigs {
@@ -65,42 +66,39 @@ func (u *PricingSyncUseCase) Sync(ctx context.Context) (*SyncResult, error) {
 		}
 
 		if !pricesChanged {
-			fmt.Printf("Model %s: price unchanged (%.10f, %.10f)\n",
-				fetched.Model, current.InputTokenPrice, current.OutputTokenPrice)
+			slog.DebugContext(ctx, "Model price unchanged", "model", fetched.Model,
+				"input_price", current.InputTokenPrice, "output_price", current.OutputTokenPrice)
 			result.ModelsUnchanged++
 			continue
 		}
 
 		if current != nil {
 			if err := u.pricingRepo.Deactivate(ctx, fetched.Provider, fetched.Model); err != nil {
-				errMsg := fmt.Sprintf("failed to deactivate old pricing for %s: %v", fetched.Model, err)
-				result.Errors = append(result.Errors, errMsg)
-				fmt.Printf("[ERROR] %s\n", errMsg)
+				result.Errors = append(result.Errors, fmt.Sprintf("failed to deactivate old pricing for %s: %v", fetched.Model, err))
+				slog.ErrorContext(ctx, "Failed to deactivate old pricing", "model", fetched.Model, "error", err)
 				continue
 			}
 		}
 
 		if err := u.pricingRepo.Create(ctx, fetched); err != nil {
-			errMsg := fmt.Sprintf("failed to create new pricing for %s: %v", fetched.Model, err)
-			result.Errors = append(result.Errors, errMsg)
-			fmt.Printf("[ERROR] %s\n", errMsg)
+			result.Errors = append(result.Errors, fmt.Sprintf("failed to create new pricing for %s: %v", fetched.Model, err))
+			slog.ErrorContext(ctx, "Failed to create new pricing", "model", fetched.Model, "error", err)
 			continue
 		}
 
-		oldPrice := ""
+		attrs := []any{"model", fetched.Model, "input_price", fetched.InputTokenPrice, "output_price", fetched.OutputTokenPrice}
 		if current != nil {
-			oldPrice = fmt.Sprintf("(was %.10f, %.10f)", current.InputTokenPrice, current.OutputTokenPrice)
+			attrs = append(attrs, "old_input_price", current.InputTokenPrice, "old_output_price", current.OutputTokenPrice)
 		}
-		fmt.Printf("Model %s: price changed %s now %.10f, %.10f\n",
-			fetched.Model, oldPrice, fetched.InputTokenPrice, fetched.OutputTokenPrice)
+		slog.InfoContext(ctx, "Model price changed", attrs...)
 
 		result.ModelsUpdated++
 		result.UpdatedConfigs = append(result.UpdatedConfigs, fetched)
 	}
 
 	result.Success = true
-	fmt.Printf("Sync completed: %d updated, %d unchanged, %d errors\n",
-		result.ModelsUpdated, result.ModelsUnchanged, len(result.Errors))
+	slog.InfoContext(ctx, "Pricing sync completed", "provider", result.Provider,
+		"updated", result.ModelsUpdated, "unchanged", result.ModelsUnchanged, "errors", len(result.Errors))
 
 	return result, nil
 }
@@ -45,7 +45,19 @@ func NewMockPricingRepository() *MockPricingRepository {
 
 func (m *MockPricingRepository) GetByProviderAndModel(ctx context.Context, provider, model string) (*domain.PricingConfig, error) {
 	key := provider + ":" + model
-	return m.configs[key], nil
+	if config, ok := m.configs[key]; ok {
+		return config, nil
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *MockPricingRepository) GetByID(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	for _, config := range m.allConfigs {
+		if config.ID == id {
+			return config, nil
+		}
+	}
+	return nil, domain.ErrNotFound
 }
 
 func (m *MockPricingRepository) GetAll(ctx context.Context) ([]*domain.PricingConfig, error) {
@@ -61,6 +73,10 @@ func (m *MockPricingRepository) Create(ctx context.Context, config *domain.Prici
 
 func (m *MockPricingRepository) Update(ctx context.Context, config *domain.PricingConfig) error {
 	key := config.Provider + ":" + config.Model
+	if !config.IsActive {
+		delete(m.configs, key)
+		return nil
+	}
 	m.configs[key] = config
 	return nil
 }
@@ -3,21 +3,49 @@ package usecase
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+	"github.com/riverlin/aiexpense/internal/logging"
 )
 
 // ProcessMessageUseCase handles the core logic for processing messages from any source
 type ProcessMessageUseCase struct {
-	autoSignup         AutoSignup
-	parseConversation  ParseConversation
-	createExpense      CreateExpense
-	getExpenses        GetExpenses
-	generateReportLink domain.GenerateReportLinkUseCase
-	interactionRepo    domain.InteractionLogRepository
+	autoSignup           AutoSignup
+	parseConversation    ParseConversation
+	createExpense        CreateExpense
+	getExpenses          GetExpenses
+	generateReportLink   domain.GenerateReportLinkUseCase
+	interactionRepo      domain.InteractionLogRepository
+	receiptParser        ReceiptParser
+	transcriber          AudioTranscriber
+	groupResolver        GroupResolver
+	billSplitter         BillSplitter
+	settlementReporter   SettlementReporter
+	attachmentSaver      AttachmentSaver
+	rateLimiter          MessageRateLimiter
+	categoryConfirmer    CategoryConfirmer
+	expenseUndoer        ExpenseUndoer
+	expenseEditor        ExpenseEditor
+	expenseQuerier       ExpenseQuerier
+	categoryManager      CategoryManager
+	budgetReporter       BudgetStatusReporter
+	budgetSelector       BudgetSelector
+	reportComparer       ReportComparer
+	reportCharts         ReportCharts
+	subscriptionDetector SubscriptionDetector
+	claimTracker         ClaimTracker
+	workspaceSwitcher    WorkspaceSwitcher
+	dataExporter         DataExporter
+	expenseDeleter       ExpenseDeleter
+	expenseUpdater       ExpenseUpdater
+	conversation         ConversationStore
+	onboarder            Onboarder
+	timezones            TimezoneManager
+	locales              LocaleManager
 }
 
 // Interfaces to break dependency cycles (if needed) or mock easier
@@ -29,8 +57,96 @@ type ParseConversation interface {
 	Execute(ctx context.Context, text, userID string) (*domain.ParseResult, error)
 }
 
+// ReceiptParser extracts line items from a receipt photo
+type ReceiptParser interface {
+	ExecuteReceipt(ctx context.Context, image *domain.Attachment, userID string) (*domain.ParseResult, error)
+}
+
+// AudioTranscriber converts a voice message to text
+type AudioTranscriber interface {
+	Execute(ctx context.Context, audio *domain.Attachment, userID string) (string, error)
+}
+
+// GroupResolver maps a messenger group chat to its shared ledger
+type GroupResolver interface {
+	EnsureGroup(ctx context.Context, messengerType, externalID, name, userID string) (*domain.Group, error)
+}
+
+// BillSplitter splits a recorded expense equally between participants
+type BillSplitter interface {
+	SplitEqually(ctx context.Context, expenseID, userID string, count int) (*SplitExpenseResponse, error)
+}
+
+// SettlementReporter renders who owes whom in the group bound to a messenger chat
+type SettlementReporter interface {
+	ExecuteForChat(ctx context.Context, messengerType, externalID, userID string) (string, error)
+}
+
+// AttachmentSaver keeps the receipt photo an expense was recorded from
+type AttachmentSaver interface {
+	SaveReceipt(ctx context.Context, userID string, expenseIDs []string, image *domain.Attachment) error
+}
+
+// MessageRateLimiter throttles how often a user's messages are processed; when a
+// message is rejected it returns the reply to send instead
+type MessageRateLimiter interface {
+	Allow(ctx context.Context, userID string) (string, bool)
+}
+
+// CategoryConfirmer asks the user to confirm a low-confidence AI category and
+// applies their reply; Resolve returns false for messages that are not a reply
+type CategoryConfirmer interface {
+	Ask(ctx context.Context, userID, expenseID, description, category string, confidence float64, alternatives []string) string
+	Resolve(ctx context.Context, userID, text string) (string, bool)
+}
+
+// ExpenseUndoer deletes the expense the user recorded last
+type ExpenseUndoer interface {
+	UndoLast(ctx context.Context, userID string) (*DeleteResponse, error)
+}
+
+// ExpenseEditor applies chat requests such as "把剛剛的午餐改成 250" to a recent
+// expense; Edit returns false for messages that are not an edit request
+type ExpenseEditor interface {
+	Edit(ctx context.Context, userID, text string) (string, bool)
+}
+
+// ExpenseQuerier answers spending questions such as "這個月花多少？"; Answer
+// returns false for messages that are not a question
+type ExpenseQuerier interface {
+	Answer(ctx context.Context, userID, groupID, text string) (string, bool)
+}
+
+// ConversationStore remembers the clarification question a user was asked, so
+// their next message answers it; Pending returns nil when there is none
+type ConversationStore interface {
+	Ask(ctx context.Context, userID, intent, awaiting string, slots map[string]string) error
+	Pending(ctx context.Context, userID string) (*domain.ConversationState, error)
+	Clear(ctx context.Context, userID string) error
+}
+
+// Onboarder walks new users through setting up their account before their
+// messages are processed; Onboard returns false once the user is set up
+type Onboarder interface {
+	Onboard(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, bool)
+}
+
+// TimezoneManager keeps track of the timezone each user's dates are read in;
+// Locate saves the timezone their messenger reports when they have none yet
+type TimezoneManager interface {
+	Locate(ctx context.Context, userID, reported string) *time.Location
+	Set(ctx context.Context, userID, name string) (*time.Location, error)
+}
+
+// LocaleManager keeps track of the language each user is answered in
+type LocaleManager interface {
+	Locale(ctx context.Context, userID string) string
+	SetLocale(ctx context.Context, userID, language string) (string, error)
+}
+
 type CreateExpense interface {
 	Execute(ctx context.Context, req *CreateRequest) (*CreateResponse, error)
+	ExecuteBatch(ctx context.Context, reqs []*CreateRequest) ([]CreateBatchResult, error)
 }
 
 type GetExpenses interface {
@@ -56,12 +172,171 @@ func NewProcessMessageUseCase(
 	}
 }
 
+// SetReceiptParser enables receipt photo handling; image messages are rejected when unset
+func (u *ProcessMessageUseCase) SetReceiptParser(receiptParser ReceiptParser) {
+	u.receiptParser = receiptParser
+}
+
+// SetTranscriber enables voice message handling; audio messages are rejected when unset
+func (u *ProcessMessageUseCase) SetTranscriber(transcriber AudioTranscriber) {
+	u.transcriber = transcriber
+}
+
+// SetGroupResolver enables shared group ledgers; group chat messages are recorded
+// as personal expenses when unset
+func (u *ProcessMessageUseCase) SetGroupResolver(groupResolver GroupResolver) {
+	u.groupResolver = groupResolver
+}
+
+// SetBillSplitter enables "三人平分"-style split requests; they are recorded
+// as plain expenses when unset
+func (u *ProcessMessageUseCase) SetBillSplitter(billSplitter BillSplitter) {
+	u.billSplitter = billSplitter
+}
+
+// SetSettlementReporter enables "settle up" requests in group chats
+func (u *ProcessMessageUseCase) SetSettlementReporter(settlementReporter SettlementReporter) {
+	u.settlementReporter = settlementReporter
+}
+
+// SetAttachmentSaver enables storing receipt photos with the expenses recorded
+// from them; photos are discarded after parsing when unset
+func (u *ProcessMessageUseCase) SetAttachmentSaver(attachmentSaver AttachmentSaver) {
+	u.attachmentSaver = attachmentSaver
+}
+
+// SetRateLimiter throttles messages per user before any AI call is made;
+// messages are never throttled when unset
+func (u *ProcessMessageUseCase) SetRateLimiter(rateLimiter MessageRateLimiter) {
+	u.rateLimiter = rateLimiter
+}
+
+// SetCategoryConfirmer enables asking the user to confirm categories the AI was unsure of
+func (u *ProcessMessageUseCase) SetCategoryConfirmer(categoryConfirmer CategoryConfirmer) {
+	u.categoryConfirmer = categoryConfirmer
+}
+
+// SetExpenseUndoer enables the "undo" command for removing the last recorded expense
+func (u *ProcessMessageUseCase) SetExpenseUndoer(expenseUndoer ExpenseUndoer) {
+	u.expenseUndoer = expenseUndoer
+}
+
+// SetExpenseEditor enables editing recent expenses from chat, e.g. "change lunch to 250"
+func (u *ProcessMessageUseCase) SetExpenseEditor(expenseEditor ExpenseEditor) {
+	u.expenseEditor = expenseEditor
+}
+
+// SetExpenseQuerier enables answering spending questions instead of parsing them as expenses
+func (u *ProcessMessageUseCase) SetExpenseQuerier(expenseQuerier ExpenseQuerier) {
+	u.expenseQuerier = expenseQuerier
+}
+
+// SetReportComparer enables the 比較 quick action, comparing this month's
+// spending with last month's or the same month last year's
+func (u *ProcessMessageUseCase) SetReportComparer(reportComparer ReportComparer) {
+	u.reportComparer = reportComparer
+}
+
+// SetReportCharts adds chart images of the spending breakdown to the monthly
+// report and 比較 quick actions
+func (u *ProcessMessageUseCase) SetReportCharts(reportCharts ReportCharts) {
+	u.reportCharts = reportCharts
+}
+
+// SetSubscriptionDetector enables the 訂閱 quick action, listing the user's
+// monthly subscriptions and tracking the one picked as a recurring expense
+func (u *ProcessMessageUseCase) SetSubscriptionDetector(subscriptionDetector SubscriptionDetector) {
+	u.subscriptionDetector = subscriptionDetector
+}
+
+// SetClaimTracker enables the 報帳清單 quick action, listing the user's
+// outstanding reimbursement claims and marking the pending ones submitted
+func (u *ProcessMessageUseCase) SetClaimTracker(claimTracker ClaimTracker) {
+	u.claimTracker = claimTracker
+}
+
+// SetWorkspaceSwitcher keeps each user's messages in their active workspace
+// and enables the 切換帳本 and 新增帳本 quick actions
+func (u *ProcessMessageUseCase) SetWorkspaceSwitcher(workspaceSwitcher WorkspaceSwitcher) {
+	u.workspaceSwitcher = workspaceSwitcher
+}
+
+// SetCategoryManager enables the 新增分類 and category list quick actions
+func (u *ProcessMessageUseCase) SetCategoryManager(categoryManager CategoryManager) {
+	u.categoryManager = categoryManager
+}
+
+// SetBudgetStatusReporter enables the budget quick action
+func (u *ProcessMessageUseCase) SetBudgetStatusReporter(budgetReporter BudgetStatusReporter) {
+	u.budgetReporter = budgetReporter
+}
+
+// SetBudgetSelector enables the 切換預算 quick action, switching the named
+// budget the budget quick action reports on
+func (u *ProcessMessageUseCase) SetBudgetSelector(budgetSelector BudgetSelector) {
+	u.budgetSelector = budgetSelector
+}
+
+// SetDataExporter enables the export quick action
+func (u *ProcessMessageUseCase) SetDataExporter(dataExporter DataExporter) {
+	u.dataExporter = dataExporter
+}
+
+// SetExpenseDeleter enables the delete button under recorded expenses
+func (u *ProcessMessageUseCase) SetExpenseDeleter(expenseDeleter ExpenseDeleter) {
+	u.expenseDeleter = expenseDeleter
+}
+
+// SetExpenseUpdater enables the change category button under recorded expenses
+func (u *ProcessMessageUseCase) SetExpenseUpdater(expenseUpdater ExpenseUpdater) {
+	u.expenseUpdater = expenseUpdater
+}
+
+// SetConversationStore enables clarification questions, e.g. 哪個分類？ after
+// 新增分類 without a name, answered by the user's next message
+func (u *ProcessMessageUseCase) SetConversationStore(conversation ConversationStore) {
+	u.conversation = conversation
+}
+
+// SetOnboarder enables the onboarding wizard for new users in direct chats
+func (u *ProcessMessageUseCase) SetOnboarder(onboarder Onboarder) {
+	u.onboarder = onboarder
+}
+
+// SetTimezoneManager reads "today", relative dates and report periods in the
+// user's timezone instead of the server's, and enables the 時區 quick action
+func (u *ProcessMessageUseCase) SetTimezoneManager(timezones TimezoneManager) {
+	u.timezones = timezones
+}
+
+// SetLocaleManager answers users in their own language instead of the
+// default, and enables the 語言 quick action
+func (u *ProcessMessageUseCase) SetLocaleManager(locales LocaleManager) {
+	u.locales = locales
+}
+
 // Execute processes the incoming UserMessage
 func (u *ProcessMessageUseCase) Execute(ctx context.Context, msg *domain.UserMessage) (*domain.MessageResponse, error) {
+	if u.rateLimiter != nil {
+		if reply, ok := u.rateLimiter.Allow(ctx, msg.UserID); !ok {
+			return &domain.MessageResponse{
+				Text: reply,
+			}, nil
+		}
+	}
+
+	ctx = domain.WithAuditSource(ctx, domain.AuditSource{Actor: msg.UserID, Channel: msg.Source})
+	ctx = logging.WithUser(ctx, msg.UserID, msg.Source)
+
+	if audio := msg.FirstAttachment(domain.AttachmentTypeAudio); audio != nil {
+		return u.executeVoice(ctx, msg, audio)
+	}
+
 	start := time.Now()
 	var botReply string
 	var err error
 	var systemPrompt, rawResponse string
+	intent := domain.InteractionIntentExpense
 
 	defer func() {
 		// Log interaction asynchronously
@@ -79,6 +354,8 @@ func (u *ProcessMessageUseCase) Execute(ctx context.Context, msg *domain.UserMes
 				interactionLog := &domain.InteractionLog{
 					ID:            fmt.Sprintf("int_%d", start.UnixNano()),
 					UserID:        msg.UserID,
+					Source:        msg.Source,
+					Intent:        intent,
 					UserInput:     msg.Content,
 					SystemPrompt:  systemPrompt,
 					AIRawResponse: rawResponse,
@@ -94,22 +371,165 @@ func (u *ProcessMessageUseCase) Execute(ctx context.Context, msg *domain.UserMes
 
 	// 1. Auto-signup
 	if err = u.autoSignup.Execute(ctx, msg.UserID, msg.Source); err != nil {
-		botReply = fmt.Sprintf("Failed to signup user: %v", err)
+		botReply = translate(ctx, "message.signup_failed", err)
 		return &domain.MessageResponse{
 			Text: botReply,
 		}, nil // We return success to the adapter so it can send the error message back to user
 	}
+	if u.timezones != nil {
+		ctx = domain.WithLocation(ctx, u.timezones.Locate(ctx, msg.UserID, msg.Timezone))
+	}
+	if u.locales != nil {
+		ctx = i18n.WithLocale(ctx, u.locales.Locale(ctx, msg.UserID))
+	}
+	if u.workspaceSwitcher != nil {
+		active, err := u.workspaceSwitcher.ActiveWorkspace(ctx, msg.UserID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to get active workspace", "error", err)
+		}
+		ctx = domain.WithWorkspace(ctx, active)
+	}
+
+	// 1.1. New user: the onboarding wizard comes first, except for photos
+	// so a receipt isn't lost
+	if u.onboarder != nil && msg.GroupChatID == "" && len(msg.Attachments) == 0 {
+		if resp, ok := u.onboarder.Onboard(ctx, msg); ok {
+			intent = domain.InteractionIntentOnboarding
+			botReply = resp.Text
+			return resp, nil
+		}
+	}
+
+	// 1.2. Group chat: record into the shared ledger
+	groupID := u.resolveGroup(ctx, msg)
+
+	// 1.3. Receipt photo: one expense per category, with its line items
+	if image := msg.FirstAttachment(domain.AttachmentTypeImage); image != nil {
+		intent = domain.InteractionIntentReceipt
+		if u.receiptParser == nil {
+			botReply = translate(ctx, "receipt.unsupported")
+			return &domain.MessageResponse{
+				Text: botReply,
+			}, nil
+		}
+
+		var receipt *domain.ParseResult
+		receipt, err = u.receiptParser.ExecuteReceipt(ctx, image, msg.UserID)
+		if err != nil {
+			botReply = translate(ctx, "receipt.failed", err)
+			return &domain.MessageResponse{
+				Text: botReply,
+			}, nil
+		}
+
+		systemPrompt = receipt.SystemPrompt
+		rawResponse = receipt.RawResponse
+
+		if len(receipt.Expenses) == 0 {
+			botReply = translate(ctx, "receipt.empty")
+			return &domain.MessageResponse{
+				Text: botReply,
+			}, nil
+		}
 
-	// 1.5. Check for "View Report" intent
+		createdExpenses, totalAmount := u.createExpenses(ctx, msg.UserID, groupID, receipt.Expenses)
+		u.saveReceipt(ctx, msg.UserID, createdExpenses, image)
+		botReply = formatReceiptCard(ctx, receipt.Merchant, createdExpenses, totalAmount)
+		botReply += u.askCategoryConfirmation(ctx, msg.UserID, createdExpenses)
+		return &domain.MessageResponse{
+			Text: botReply,
+			Data: createdExpenses,
+		}, nil
+	}
+
+	// 1.4. Quick action from a messenger button, or its label typed as text
+	if action, argument, ok := messageAction(msg); ok {
+		// A new action drops any question still open
+		u.clearConversation(ctx, msg.UserID)
+		var resp *domain.MessageResponse
+		intent, resp = u.executeAction(ctx, msg, groupID, action, argument)
+		botReply = resp.Text
+		return resp, nil
+	}
+
+	// 1.5. Answer to a clarification question
+	if pendingIntent, resp, ok := u.resumeConversation(ctx, msg, groupID); ok {
+		intent = pendingIntent
+		botReply = resp.Text
+		return resp, nil
+	}
+
+	// 1.6. Answer to a category confirmation question
+	if u.categoryConfirmer != nil {
+		if reply, ok := u.categoryConfirmer.Resolve(ctx, msg.UserID, msg.Content); ok {
+			intent = domain.InteractionIntentCategoryConfirmation
+			botReply = reply
+			return &domain.MessageResponse{
+				Text: botReply,
+			}, nil
+		}
+	}
+
+	// 1.7. Check for "View Report" intent
 	msgLower := strings.ToLower(strings.TrimSpace(msg.Content))
+	if groupID != nil && u.settlementReporter != nil && u.isSettlementIntent(msgLower) {
+		intent = domain.InteractionIntentSettlement
+		botReply, err = u.settlementReporter.ExecuteForChat(ctx, msg.Source, msg.GroupChatID, msg.UserID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to settle group", "group_chat_id", msg.GroupChatID, "error", err)
+			botReply = translate(ctx, "settlement.failed")
+		}
+		return &domain.MessageResponse{
+			Text: botReply,
+		}, nil
+	}
+	if u.expenseUndoer != nil && u.isUndoIntent(msgLower) {
+		intent = domain.InteractionIntentUndo
+		var resp *DeleteResponse
+		resp, err = u.expenseUndoer.UndoLast(ctx, msg.UserID)
+		if err != nil {
+			botReply = translate(ctx, "undo.nothing", err)
+		} else {
+			botReply = "🗑 " + resp.Message
+		}
+		return &domain.MessageResponse{
+			Text: botReply,
+		}, nil
+	}
+	if u.expenseEditor != nil {
+		if reply, ok := u.expenseEditor.Edit(ctx, msg.UserID, msg.Content); ok {
+			intent = domain.InteractionIntentEdit
+			botReply = reply
+			return &domain.MessageResponse{
+				Text: botReply,
+			}, nil
+		}
+	}
+	if u.expenseQuerier != nil {
+		queryGroupID := ""
+		if groupID != nil {
+			queryGroupID = *groupID
+		}
+		if reply, ok := u.expenseQuerier.Answer(ctx, msg.UserID, queryGroupID, msg.Content); ok {
+			intent = domain.InteractionIntentQuery
+			botReply = reply
+			return &domain.MessageResponse{
+				Text: botReply,
+			}, nil
+		}
+	}
 	if u.isReportIntent(msgLower) {
+		intent = domain.InteractionIntentReport
 		link, err := u.generateReportLink.Execute(msg.UserID)
 		if err != nil {
-			// Log the error for debugging
-			fmt.Printf("Error generating report link: %v\n", err)
-			botReply = "Sorry, I couldn't generate the report link. Please try again later."
+			slog.ErrorContext(ctx, "Failed to generate report link", "error", err)
+			botReply = translate(ctx, "report.link_failed")
 		} else {
-			botReply = fmt.Sprintf("Here is your expense report:\n%s\n(Link valid for 5 minutes)", link)
+			botReply = translate(ctx, "report.link", link)
+			return &domain.MessageResponse{
+				Text:    botReply,
+				Buttons: []*domain.MessageButton{{Label: translate(ctx, "report.open"), URL: link}},
+			}, nil
 		}
 
 		return &domain.MessageResponse{
@@ -121,7 +541,7 @@ func (u *ProcessMessageUseCase) Execute(ctx context.Context, msg *domain.UserMes
 	var parseResult *domain.ParseResult
 	parseResult, err = u.parseConversation.Execute(ctx, msg.Content, msg.UserID)
 	if err != nil {
-		botReply = fmt.Sprintf("Failed to parse message: %v", err)
+		botReply = translate(ctx, "message.parse_failed", err)
 		return &domain.MessageResponse{
 			Text: botReply,
 		}, nil
@@ -132,32 +552,153 @@ func (u *ProcessMessageUseCase) Execute(ctx context.Context, msg *domain.UserMes
 	expenses := parseResult.Expenses
 
 	if len(expenses) == 0 {
-		botReply = "No expenses detected in message"
+		botReply = translate(ctx, "message.no_expenses")
 		return &domain.MessageResponse{
 			Text: botReply,
 		}, nil
 	}
 
 	// 3. Create Expenses
+	createdExpenses, totalAmount := u.createExpenses(ctx, msg.UserID, groupID, expenses)
+
+	// 4. Format Response
+	var sb strings.Builder
+	primaryCurrency := getPrimaryCurrency(createdExpenses)
+	sb.WriteString(translate(ctx, "expense.recorded", len(createdExpenses), formatMoney(ctx, totalAmount, primaryCurrency)))
+	writeExpenseLines(ctx, &sb, createdExpenses)
+	u.splitExpenses(ctx, msg, createdExpenses, &sb)
+	sb.WriteString(u.askCategoryConfirmation(ctx, msg.UserID, createdExpenses))
+
+	botReply = sb.String()
+
+	return &domain.MessageResponse{
+		Text: botReply,
+		Data: createdExpenses,
+	}, nil
+}
+
+// executeVoice transcribes a voice message and processes the transcript like a typed message,
+// echoing what was heard so the user can spot transcription mistakes
+func (u *ProcessMessageUseCase) executeVoice(ctx context.Context, msg *domain.UserMessage, audio *domain.Attachment) (*domain.MessageResponse, error) {
+	if u.transcriber == nil {
+		return &domain.MessageResponse{
+			Text: translate(ctx, "voice.unsupported"),
+		}, nil
+	}
+
+	transcript, err := u.transcriber.Execute(ctx, audio, msg.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to transcribe voice message", "error", err)
+		return &domain.MessageResponse{
+			Text: translate(ctx, "voice.failed"),
+		}, nil
+	}
+	if strings.TrimSpace(transcript) == "" {
+		return &domain.MessageResponse{
+			Text: translate(ctx, "voice.empty"),
+		}, nil
+	}
+
+	textMsg := *msg
+	textMsg.Content = transcript
+	textMsg.Attachments = nil
+
+	resp, err := u.Execute(ctx, &textMsg)
+	if resp != nil {
+		resp.Text = fmt.Sprintf("🎤 「%s」\n%s", transcript, resp.Text)
+	}
+	return resp, err
+}
+
+// resolveGroup returns the ledger ID for a group chat message, or nil for a
+// direct message. Failures fall back to the personal ledger.
+func (u *ProcessMessageUseCase) resolveGroup(ctx context.Context, msg *domain.UserMessage) *string {
+	if msg.GroupChatID == "" || u.groupResolver == nil {
+		return nil
+	}
+	group, err := u.groupResolver.EnsureGroup(ctx, msg.Source, msg.GroupChatID, msg.GroupName, msg.UserID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to resolve group", "group_chat_id", msg.GroupChatID, "error", err)
+		return nil
+	}
+	return &group.ID
+}
+
+// splitExpenses splits each created expense equally when the message asks for it,
+// appending the split details to the reply
+func (u *ProcessMessageUseCase) splitExpenses(ctx context.Context, msg *domain.UserMessage, createdExpenses []map[string]interface{}, sb *strings.Builder) {
+	if u.billSplitter == nil {
+		return
+	}
+	count := parseSplitCount(msg.Content)
+	if count == 0 {
+		return
+	}
+
+	for _, exp := range createdExpenses {
+		expenseID, _ := exp["id"].(string)
+		resp, err := u.billSplitter.SplitEqually(ctx, expenseID, msg.UserID, count)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to split expense", "expense_id", expenseID, "error", err)
+			sb.WriteString("\n" + translate(ctx, "expense.split_failed", exp["description"], err))
+			continue
+		}
+		sb.WriteString("\n" + resp.Message)
+	}
+}
+
+// saveReceipt links the receipt photo to the expenses recorded from it. A storage
+// failure is logged rather than failing the message, since the expenses are saved.
+func (u *ProcessMessageUseCase) saveReceipt(ctx context.Context, userID string, createdExpenses []map[string]interface{}, image *domain.Attachment) {
+	if u.attachmentSaver == nil || len(createdExpenses) == 0 {
+		return
+	}
+
+	expenseIDs := make([]string, 0, len(createdExpenses))
+	for _, exp := range createdExpenses {
+		if expenseID, _ := exp["id"].(string); expenseID != "" {
+			expenseIDs = append(expenseIDs, expenseID)
+		}
+	}
+	if err := u.attachmentSaver.SaveReceipt(ctx, userID, expenseIDs, image); err != nil {
+		slog.WarnContext(ctx, "Failed to save receipt", "error", err)
+	}
+}
+
+// createExpenses persists parsed expenses in one batch, skipping any that
+// fail, and returns reply-friendly summaries along with the total in home currency
+func (u *ProcessMessageUseCase) createExpenses(ctx context.Context, userID string, groupID *string, expenses []*domain.ParsedExpense) ([]map[string]interface{}, float64) {
 	createdExpenses := []map[string]interface{}{}
 	totalAmount := 0.0
 
-	for _, parsedExp := range expenses {
-		req := &CreateRequest{
-			UserID:           msg.UserID,
+	reqs := make([]*CreateRequest, len(expenses))
+	for i, parsedExp := range expenses {
+		reqs[i] = &CreateRequest{
+			UserID:           userID,
 			Description:      parsedExp.Description,
 			Amount:           parsedExp.Amount,
 			Currency:         parsedExp.Currency,
 			CurrencyOriginal: parsedExp.CurrencyOriginal,
+			GroupID:          groupID,
 			Account:          parsedExp.Account,
+			Merchant:         parsedExp.Merchant,
+			Tags:             parsedExp.Tags,
+			Items:            parsedExp.Items,
 			Date:             parsedExp.Date,
 		}
+	}
+	results, err := u.createExpense.ExecuteBatch(ctx, reqs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to create expenses", "error", err)
+		return createdExpenses, totalAmount
+	}
 
-		resp, err := u.createExpense.Execute(ctx, req)
-		if err != nil {
-			log.Printf("ERROR: Failed to create expense for user %s: %v", msg.UserID, err)
+	for i, parsedExp := range expenses {
+		if results[i].Error != nil {
+			slog.ErrorContext(ctx, "Failed to create expense", "error", results[i].Error)
 			continue
 		}
+		resp := results[i].Response
 
 		totalAmount += resp.HomeAmount
 		account := resp.Account
@@ -175,16 +716,55 @@ func (u *ProcessMessageUseCase) Execute(ctx context.Context, msg *domain.UserMes
 			"date":            parsedExp.Date,
 			"account":         account,
 		})
+		if resp.Merchant != "" {
+			createdExpenses[len(createdExpenses)-1]["merchant"] = resp.Merchant
+		}
+		if len(resp.Tags) > 0 {
+			createdExpenses[len(createdExpenses)-1]["tags"] = resp.Tags
+		}
+		if resp.Reimbursable {
+			createdExpenses[len(createdExpenses)-1]["reimbursable"] = true
+		}
+		if len(parsedExp.Items) > 0 {
+			createdExpenses[len(createdExpenses)-1]["item_count"] = len(parsedExp.Items)
+		}
+		if resp.NeedsCategoryConfirmation {
+			exp := createdExpenses[len(createdExpenses)-1]
+			exp["category_confidence"] = resp.CategoryConfidence
+			exp["category_alternatives"] = resp.CategoryAlternatives
+		}
 	}
 
-	// 4. Format Response
-	var sb strings.Builder
-	primaryCurrency := getPrimaryCurrency(createdExpenses)
-	sb.WriteString(fmt.Sprintf("✓ Recorded %d expense(s), total: %s %s", len(createdExpenses), formatAmount(totalAmount), primaryCurrency))
+	return createdExpenses, totalAmount
+}
+
+// askCategoryConfirmation returns the question for the first expense whose AI
+// category needs confirming, prefixed with a blank line, or "" when none does.
+// Only one question is asked since a reply can answer only the latest one.
+func (u *ProcessMessageUseCase) askCategoryConfirmation(ctx context.Context, userID string, createdExpenses []map[string]interface{}) string {
+	if u.categoryConfirmer == nil {
+		return ""
+	}
+	for _, exp := range createdExpenses {
+		alternatives, ok := exp["category_alternatives"].([]string)
+		if !ok {
+			continue
+		}
+		expenseID, _ := exp["id"].(string)
+		description, _ := exp["description"].(string)
+		category, _ := exp["category"].(string)
+		return "\n\n" + u.categoryConfirmer.Ask(ctx, userID, expenseID, description, category, asFloat(exp["category_confidence"]), alternatives)
+	}
+	return ""
+}
+
+// writeExpenseLines appends one bullet line per created expense
+func writeExpenseLines(ctx context.Context, sb *strings.Builder, createdExpenses []map[string]interface{}) {
+	locale := i18n.FromContext(ctx)
 	for _, exp := range createdExpenses {
 		dateStr := ""
 		if d, ok := exp["date"].(time.Time); ok {
-			dateStr = d.Format("2006-01-02")
+			dateStr = i18n.FormatDate(locale, d)
 		}
 		homeAmount := asFloat(exp["home_amount"])
 		homeCurrency, _ := exp["home_currency"].(string)
@@ -199,21 +779,76 @@ func (u *ProcessMessageUseCase) Execute(ctx context.Context, msg *domain.UserMes
 		if account != "" {
 			line = fmt.Sprintf("%s [%s]", line, account)
 		}
-		line = fmt.Sprintf("%s: %s %s", line, formatAmount(homeAmount), homeCurrency)
+		if tags, _ := exp["tags"].([]string); len(tags) > 0 {
+			line = fmt.Sprintf("%s #%s", line, strings.Join(tags, " #"))
+		}
+		line = fmt.Sprintf("%s: %s", line, i18n.FormatMoney(locale, homeAmount, homeCurrency))
 		if orig := asFloat(exp["original_amount"]); orig > 0 {
 			if curr, _ := exp["currency"].(string); curr != "" && curr != homeCurrency {
-				line = fmt.Sprintf("%s (≈ %s %s)", line, formatAmount(orig), curr)
+				line = fmt.Sprintf("%s (≈ %s)", line, i18n.FormatMoney(locale, orig, curr))
 			}
 		}
+		if reimbursable, _ := exp["reimbursable"].(bool); reimbursable {
+			line = fmt.Sprintf("%s %s", line, translate(ctx, "claims.marker"))
+		}
 		sb.WriteString(line)
 	}
+}
 
-	botReply = sb.String()
+// formatReceiptCard builds the confirmation card sent after a receipt photo is recorded
+func formatReceiptCard(ctx context.Context, merchant string, createdExpenses []map[string]interface{}, totalAmount float64) string {
+	var sb strings.Builder
+	if merchant == "" {
+		merchant = translate(ctx, "receipt.merchant")
+	}
+	sb.WriteString(fmt.Sprintf("🧾 %s\n", merchant))
+	sb.WriteString(translate(ctx, "receipt.recorded", pluralizeReceiptItems(ctx, receiptItemCount(createdExpenses)), formatMoney(ctx, totalAmount, getPrimaryCurrency(createdExpenses))))
+	writeExpenseLines(ctx, &sb, createdExpenses)
+	return sb.String()
+}
 
-	return &domain.MessageResponse{
-		Text: botReply,
-		Data: createdExpenses,
-	}, nil
+// receiptItemCount counts the receipt lines recorded, counting an expense
+// without line items as one
+func receiptItemCount(createdExpenses []map[string]interface{}) int {
+	count := 0
+	for _, exp := range createdExpenses {
+		if items, _ := exp["item_count"].(int); items > 0 {
+			count += items
+		} else {
+			count++
+		}
+	}
+	return count
+}
+
+func pluralizeReceiptItems(ctx context.Context, count int) string {
+	if count == 1 {
+		return translate(ctx, "receipt.items.one")
+	}
+	return translate(ctx, "receipt.items", count)
+}
+
+// isSettlementIntent matches short "who owes whom" requests; longer messages are
+// left to the expense parser so "settle dinner 500" is still recorded
+func (u *ProcessMessageUseCase) isSettlementIntent(text string) bool {
+	keywords := []string{"settle", "settle up", "settlement", "who owes", "結算", "结算", "分帳", "分账", "誰欠誰"}
+	for _, k := range keywords {
+		if text == k || (strings.HasPrefix(text, k) && len([]rune(text)) <= len([]rune(k))+4) {
+			return true
+		}
+	}
+	return false
+}
+
+// isUndoIntent matches the short commands for removing the last recorded expense
+func (u *ProcessMessageUseCase) isUndoIntent(text string) bool {
+	keywords := []string{"undo", "undo last", "delete last", "刪掉剛剛那筆", "刪除剛剛那筆", "删掉刚刚那笔", "删除刚刚那笔", "取消剛剛那筆", "取消刚刚那笔"}
+	for _, k := range keywords {
+		if text == k {
+			return true
+		}
+	}
+	return false
 }
 
 func (u *ProcessMessageUseCase) isReportIntent(text string) bool {
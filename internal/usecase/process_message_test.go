@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -29,6 +30,57 @@ func (m *mockParseConversation) Execute(ctx context.Context, text, userID string
 	return args.Get(0).(*domain.ParseResult), args.Error(1)
 }
 
+type mockReceiptParser struct{ mock.Mock }
+
+func (m *mockReceiptParser) ExecuteReceipt(ctx context.Context, image *domain.Attachment, userID string) (*domain.ParseResult, error) {
+	args := m.Called(ctx, image, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ParseResult), args.Error(1)
+}
+
+type mockAudioTranscriber struct{ mock.Mock }
+
+func (m *mockAudioTranscriber) Execute(ctx context.Context, audio *domain.Attachment, userID string) (string, error) {
+	args := m.Called(ctx, audio, userID)
+	return args.String(0), args.Error(1)
+}
+
+type mockGroupResolver struct{ mock.Mock }
+
+func (m *mockGroupResolver) EnsureGroup(ctx context.Context, messengerType, externalID, name, userID string) (*domain.Group, error) {
+	args := m.Called(ctx, messengerType, externalID, name, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Group), args.Error(1)
+}
+
+type mockBillSplitter struct{ mock.Mock }
+
+func (m *mockBillSplitter) SplitEqually(ctx context.Context, expenseID, userID string, count int) (*SplitExpenseResponse, error) {
+	args := m.Called(ctx, expenseID, userID, count)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*SplitExpenseResponse), args.Error(1)
+}
+
+type mockSettlementReporter struct{ mock.Mock }
+
+func (m *mockSettlementReporter) ExecuteForChat(ctx context.Context, messengerType, externalID, userID string) (string, error) {
+	args := m.Called(ctx, messengerType, externalID, userID)
+	return args.String(0), args.Error(1)
+}
+
+type mockAttachmentSaver struct{ mock.Mock }
+
+func (m *mockAttachmentSaver) SaveReceipt(ctx context.Context, userID string, expenseIDs []string, image *domain.Attachment) error {
+	args := m.Called(ctx, userID, expenseIDs, image)
+	return args.Error(0)
+}
+
 type mockCreateExpense struct{ mock.Mock }
 
 func (m *mockCreateExpense) Execute(ctx context.Context, req *CreateRequest) (*CreateResponse, error) {
@@ -39,6 +91,15 @@ func (m *mockCreateExpense) Execute(ctx context.Context, req *CreateRequest) (*C
 	return args.Get(0).(*CreateResponse), args.Error(1)
 }
 
+// ExecuteBatch creates each request through Execute, so tests set expectations per expense
+func (m *mockCreateExpense) ExecuteBatch(ctx context.Context, reqs []*CreateRequest) ([]CreateBatchResult, error) {
+	results := make([]CreateBatchResult, len(reqs))
+	for i, req := range reqs {
+		results[i].Response, results[i].Error = m.Execute(ctx, req)
+	}
+	return results, nil
+}
+
 type mockGenerateReportLink struct{ mock.Mock }
 
 func (m *mockGenerateReportLink) Execute(userID string) (string, error) {
@@ -47,6 +108,9 @@ func (m *mockGenerateReportLink) Execute(userID string) (string, error) {
 }
 
 func TestProcessMessageUseCase_Execute(t *testing.T) {
+	// Replies are asserted in English
+	ctx := i18n.WithLocale(context.Background(), "en")
+
 	t.Run("Success - Single Expense", func(t *testing.T) {
 		// Setup
 		autoSignup := new(mockAutoSignup)
@@ -89,15 +153,15 @@ func TestProcessMessageUseCase_Execute(t *testing.T) {
 			Content: "Lunch 100 Taishin",
 			Source:  "terminal",
 		}
-		resp, err := uc.Execute(context.Background(), msg)
+		resp, err := uc.Execute(ctx, msg)
 
 		// Verify
 		assert.NoError(t, err)
 		assert.Contains(t, resp.Text, "Recorded 1 expense")
 		assert.Contains(t, resp.Text, "Lunch")
-		assert.Contains(t, resp.Text, "100 TWD")
+		assert.Contains(t, resp.Text, "NT$100")
 		assert.Contains(t, resp.Text, "Taishin")
-		assert.Contains(t, resp.Text, "100 TWD")
+		assert.Contains(t, resp.Text, "NT$100")
 		assert.Contains(t, resp.Text, "Taishin")
 	})
 
@@ -116,10 +180,841 @@ func TestProcessMessageUseCase_Execute(t *testing.T) {
 
 		// Execute
 		msg := &domain.UserMessage{UserID: "user1", Content: "Bad input", Source: "terminal"}
-		resp, err := uc.Execute(context.Background(), msg)
+		resp, err := uc.Execute(ctx, msg)
 
 		// Verify
 		assert.NoError(t, err) // Should not return error to caller, but handle it in response
 		assert.Contains(t, resp.Text, "Failed to parse message")
 	})
+
+	t.Run("Success - Receipt Photo", func(t *testing.T) {
+		// Setup
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+		receiptParser := new(mockReceiptParser)
+		saver := new(mockAttachmentSaver)
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetReceiptParser(receiptParser)
+		uc.SetAttachmentSaver(saver)
+
+		image := &domain.Attachment{Type: domain.AttachmentTypeImage, MimeType: "image/jpeg", Data: []byte("jpeg")}
+
+		// Expectations
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+		receiptParser.On("ExecuteReceipt", mock.Anything, image, "user1").Return(&domain.ParseResult{
+			Merchant: "7-ELEVEN",
+			Expenses: []*domain.ParsedExpense{
+				{Description: "Latte, Sandwich", Amount: 175, Date: time.Now(), Items: []*domain.ExpenseItem{
+					{Name: "Latte", Quantity: 2, UnitPrice: 65},
+					{Name: "Sandwich", Quantity: 1, UnitPrice: 45},
+				}},
+				{Description: "Umbrella", Amount: 20, Date: time.Now(), Items: []*domain.ExpenseItem{{Name: "Umbrella", Quantity: 1, UnitPrice: 20}}},
+			},
+		}, nil)
+		creator.On("Execute", mock.Anything, mock.MatchedBy(func(req *CreateRequest) bool {
+			return req.Description == "Latte, Sandwich" && len(req.Items) == 2
+		})).Return(&CreateResponse{ID: "1", Category: "Food", OriginalAmount: 175, Currency: "TWD", HomeAmount: 175, HomeCurrency: "TWD"}, nil)
+		creator.On("Execute", mock.Anything, mock.MatchedBy(func(req *CreateRequest) bool {
+			return req.Description == "Umbrella"
+		})).Return(&CreateResponse{ID: "2", Category: "Shopping", OriginalAmount: 20, Currency: "TWD", HomeAmount: 20, HomeCurrency: "TWD"}, nil)
+		saver.On("SaveReceipt", mock.Anything, "user1", []string{"1", "2"}, image).Return(nil)
+
+		// Execute
+		msg := &domain.UserMessage{UserID: "user1", Source: "line", Attachments: []*domain.Attachment{image}}
+		resp, err := uc.Execute(ctx, msg)
+
+		// Verify
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "7-ELEVEN")
+		assert.Contains(t, resp.Text, "Recorded 3 items, total NT$195")
+		assert.Contains(t, resp.Text, "Latte, Sandwich (Food): NT$175")
+		assert.Contains(t, resp.Text, "Umbrella (Shopping): NT$20")
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+		saver.AssertExpectations(t)
+	})
+
+	t.Run("Failure - Receipt Photo Unsupported", func(t *testing.T) {
+		// Setup
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+
+		// Execute
+		msg := &domain.UserMessage{UserID: "user1", Source: "line", Attachments: []*domain.Attachment{{Type: domain.AttachmentTypeImage}}}
+		resp, err := uc.Execute(ctx, msg)
+
+		// Verify
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "not supported")
+	})
+
+	t.Run("Success - Voice Message", func(t *testing.T) {
+		// Setup
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+		transcriber := new(mockAudioTranscriber)
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetTranscriber(transcriber)
+
+		audio := &domain.Attachment{Type: domain.AttachmentTypeAudio, MimeType: "audio/ogg", Data: []byte("OggS")}
+
+		// Expectations
+		transcriber.On("Execute", mock.Anything, audio, "user1").Return("我剛花了兩百塊買咖啡", nil)
+		autoSignup.On("Execute", mock.Anything, "user1", "telegram").Return(nil)
+		parser.On("Execute", mock.Anything, "我剛花了兩百塊買咖啡", "user1").Return(&domain.ParseResult{
+			Expenses: []*domain.ParsedExpense{{Description: "咖啡", Amount: 200, Date: time.Now()}},
+		}, nil)
+		creator.On("Execute", mock.Anything, mock.Anything).Return(&CreateResponse{ID: "1", Category: "Food", OriginalAmount: 200, Currency: "TWD", HomeAmount: 200, HomeCurrency: "TWD"}, nil)
+
+		// Execute
+		msg := &domain.UserMessage{UserID: "user1", Source: "telegram", Attachments: []*domain.Attachment{audio}}
+		resp, err := uc.Execute(ctx, msg)
+
+		// Verify
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "我剛花了兩百塊買咖啡")
+		assert.Contains(t, resp.Text, "Recorded 1 expense")
+		assert.Contains(t, resp.Text, "NT$200")
+	})
+
+	t.Run("Failure - Voice Message Unsupported", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+
+		msg := &domain.UserMessage{UserID: "user1", Source: "telegram", Attachments: []*domain.Attachment{{Type: domain.AttachmentTypeAudio}}}
+		resp, err := uc.Execute(ctx, msg)
+
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "not supported")
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Success - Group Chat", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+		resolver := new(mockGroupResolver)
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetGroupResolver(resolver)
+
+		autoSignup.On("Execute", mock.Anything, "user1", "telegram").Return(nil)
+		resolver.On("EnsureGroup", mock.Anything, "telegram", "-100123", "Family", "user1").Return(&domain.Group{ID: "g1"}, nil)
+		parser.On("Execute", mock.Anything, "groceries 500", "user1").Return(&domain.ParseResult{
+			Expenses: []*domain.ParsedExpense{{Description: "groceries", Amount: 500, Date: time.Now()}},
+		}, nil)
+		creator.On("Execute", mock.Anything, mock.MatchedBy(func(req *CreateRequest) bool {
+			return req.GroupID != nil && *req.GroupID == "g1"
+		})).Return(&CreateResponse{ID: "1", Category: "Food", OriginalAmount: 500, Currency: "TWD", HomeAmount: 500, HomeCurrency: "TWD"}, nil)
+
+		msg := &domain.UserMessage{UserID: "user1", Content: "groceries 500", Source: "telegram", GroupChatID: "-100123", GroupName: "Family"}
+		resp, err := uc.Execute(ctx, msg)
+
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Recorded 1 expense")
+		creator.AssertExpectations(t)
+	})
+
+	t.Run("Success - Split Bill", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+		splitter := new(mockBillSplitter)
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetBillSplitter(splitter)
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+		parser.On("Execute", mock.Anything, "晚餐1200 三人平分", "user1").Return(&domain.ParseResult{
+			Expenses: []*domain.ParsedExpense{{Description: "晚餐", Amount: 1200, Date: time.Now()}},
+		}, nil)
+		creator.On("Execute", mock.Anything, mock.Anything).Return(&CreateResponse{ID: "e1", Category: "Food", OriginalAmount: 1200, Currency: "TWD", HomeAmount: 1200, HomeCurrency: "TWD"}, nil)
+		splitter.On("SplitEqually", mock.Anything, "e1", "user1", 3).Return(&SplitExpenseResponse{Message: "✂️ Split NT$1,200 3 ways: NT$400 each"}, nil)
+
+		msg := &domain.UserMessage{UserID: "user1", Content: "晚餐1200 三人平分", Source: "line"}
+		resp, err := uc.Execute(ctx, msg)
+
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Recorded 1 expense")
+		assert.Contains(t, resp.Text, "NT$400 each")
+		splitter.AssertExpectations(t)
+	})
+
+	t.Run("Success - Group Settlement", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+		resolver := new(mockGroupResolver)
+		reporter := new(mockSettlementReporter)
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetGroupResolver(resolver)
+		uc.SetSettlementReporter(reporter)
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+		resolver.On("EnsureGroup", mock.Anything, "line", "C1", "", "user1").Return(&domain.Group{ID: "g1"}, nil)
+		reporter.On("ExecuteForChat", mock.Anything, "line", "C1", "user1").Return("🤝 Settle up Family (1 transfer(s))", nil)
+
+		msg := &domain.UserMessage{UserID: "user1", Content: "結算", Source: "line", GroupChatID: "C1"}
+		resp, err := uc.Execute(ctx, msg)
+
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Settle up Family")
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	})
+	t.Run("Rate Limited", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetRateLimiter(NewRateLimitUseCase(nil, 1, 1))
+
+		autoSignup.On("Execute", mock.Anything, "user1", "terminal").Return(nil)
+		parser.On("Execute", mock.Anything, "Lunch 100", "user1").Return(&domain.ParseResult{}, nil).Once()
+
+		msg := &domain.UserMessage{UserID: "user1", Content: "Lunch 100", Source: "terminal"}
+		_, err := uc.Execute(ctx, msg)
+		assert.NoError(t, err)
+
+		resp, err := uc.Execute(ctx, msg)
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "too quickly")
+		parser.AssertNumberOfCalls(t, "Execute", 1)
+	})
+	t.Run("Low Confidence Category", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		expenseRepo := NewMockExpenseRepository()
+		categoryRepo := NewMockCategoryRepository()
+		categoryRepo.Create(ctx, &domain.Category{ID: "cat_fun", UserID: "user1", Name: "Entertainment"})
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "Popcorn"})
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetCategoryConfirmer(NewCategoryConfirmationUseCase(categoryRepo, NewUpdateExpenseUseCase(expenseRepo, categoryRepo), 0))
+
+		autoSignup.On("Execute", mock.Anything, "user1", "terminal").Return(nil)
+		parser.On("Execute", mock.Anything, "Popcorn 150", "user1").Return(&domain.ParseResult{
+			Expenses: []*domain.ParsedExpense{{Description: "Popcorn", Amount: 150, Date: time.Now()}},
+		}, nil)
+		creator.On("Execute", mock.Anything, mock.Anything).Return(&CreateResponse{
+			ID:                        "exp1",
+			Category:                  "Food",
+			HomeAmount:                150,
+			HomeCurrency:              "TWD",
+			NeedsCategoryConfirmation: true,
+			CategoryConfidence:        0.4,
+			CategoryAlternatives:      []string{"Entertainment"},
+		}, nil)
+
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "Popcorn 150", Source: "terminal"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "1. Entertainment")
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "1", Source: "terminal"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Changed Popcorn to Entertainment")
+		parser.AssertNumberOfCalls(t, "Execute", 1)
+
+		expense, _ := expenseRepo.GetByID(ctx, "exp1")
+		assert.Equal(t, "cat_fun", *expense.CategoryID)
+	})
+	t.Run("Undo Last Expense", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		expenseRepo := NewMockExpenseRepository()
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "Lunch", HomeAmount: 120, HomeCurrency: "TWD", CreatedAt: time.Now()})
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetExpenseUndoer(NewDeleteExpenseUseCase(expenseRepo))
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "刪掉剛剛那筆", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Deleted Lunch NT$120")
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "Undo", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Nothing to undo")
+	})
+
+	t.Run("Edit Recent Expense", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		expenseRepo := NewMockExpenseRepository()
+		categoryRepo := NewMockCategoryRepository()
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "午餐", HomeAmount: 120, HomeCurrency: "TWD", CreatedAt: time.Now()})
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetExpenseEditor(NewExpenseEditUseCase(expenseRepo, categoryRepo, NewUpdateExpenseUseCase(expenseRepo, categoryRepo)))
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "把剛剛的午餐改成 250", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "✏️ Changed 午餐 from NT$120 to NT$250", resp.Text)
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+
+		expense, _ := expenseRepo.GetByID(ctx, "exp1")
+		assert.Equal(t, 250.0, expense.HomeAmount)
+	})
+
+	t.Run("Spending Question", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		expenseRepo := NewMockExpenseRepository()
+		categoryRepo := NewMockCategoryRepository()
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "午餐", Amount: 120, ExpenseDate: time.Now()})
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetExpenseQuerier(NewExpenseQueryUseCase(NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil), categoryRepo))
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "這個月花多少？", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "📊 This month: 120 across 1 expense")
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Quick Actions", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		expenseRepo := NewMockExpenseRepository()
+		categoryRepo := NewMockCategoryRepository()
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "午餐", Amount: 120, ExpenseDate: time.Now()})
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetExpenseQuerier(NewExpenseQueryUseCase(NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil), categoryRepo))
+		uc.SetCategoryManager(NewManageCategoryUseCase(categoryRepo))
+
+		autoSignup.On("Execute", mock.Anything, "user1", mock.Anything).Return(nil)
+		reportLink.On("Execute", "user1").Return("http://report/link", nil)
+
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionTodaySpending, Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "📊 Today: 120 across 1 expense")
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "本月報表", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "📊 This month: 120 across 1 expense")
+		assert.Contains(t, resp.Text, "http://report/link")
+		if assert.Len(t, resp.Blocks, 1) {
+			assert.Equal(t, domain.ContentBlockCard, resp.Blocks[0].Type)
+			assert.Contains(t, resp.Blocks[0].Text, "📊 This month: 120 across 1 expense")
+		}
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionAddCategory, Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "新增分類 寵物")
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "新增分類 寵物", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "✓ Added category '寵物'", resp.Text)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionAddCategory, Content: "寵物", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Category '寵物' already exists", resp.Text)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionListCategories, Source: "telegram"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Yours: 寵物")
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionBudgetStatus, Source: "telegram"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Sorry, budgets are not available.", resp.Text)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "比較", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Sorry, comparing reports is not supported.", resp.Text)
+
+		uc.SetReportComparer(NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil))
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionCompareReport, Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "📊 This month so far vs the same days last month\nTotal: 120 new (then: 0)")
+		assert.Empty(t, resp.Blocks)
+
+		// With charts, the reports come with their breakdown drawn
+		uc.SetReportCharts(NewReportChartUseCase(NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil), "https://expense.example.com"))
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "本月報表", Source: "line"})
+		assert.NoError(t, err)
+		if assert.Len(t, resp.Blocks, 2) {
+			assert.Equal(t, domain.ContentBlockChart, resp.Blocks[1].Type)
+			assert.Equal(t, "https://expense.example.com/charts/pie.png?v=120", resp.Blocks[1].ImageURL)
+		}
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "比較", Source: "line"})
+		assert.NoError(t, err)
+		if assert.Len(t, resp.Blocks, 2) {
+			assert.Equal(t, "📊 This month so far vs the same days last month", resp.Blocks[0].Title)
+			assert.Equal(t, "Total: 120 new (then: 0)\nUncategorized new", resp.Blocks[0].Text)
+			assert.Equal(t, "https://expense.example.com/charts/bar.png?p=0&v=120", resp.Blocks[1].ImageURL)
+		}
+
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Clarification Questions", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		expenseRepo := NewMockExpenseRepository()
+		categoryRepo := NewMockCategoryRepository()
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "午餐", Amount: 120, ExpenseDate: time.Now()})
+		categoryRepo.Create(ctx, &domain.Category{ID: "cat_food", UserID: "user1", Name: "Food"})
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetCategoryManager(NewManageCategoryUseCase(categoryRepo))
+		uc.SetExpenseUpdater(NewUpdateExpenseUseCase(expenseRepo, categoryRepo))
+		uc.SetConversationStore(NewConversationStateUseCase(NewMockConversationStateRepository(), 0))
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+
+		// 新增分類 without a name asks for it, and the next message answers
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "新增分類", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Which category?")
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "寵物", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "✓ Added category '寵物'", resp.Text)
+
+		// The question can be cancelled
+		uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionAddCategory, Source: "line"})
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "取消", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "OK, cancelled.", resp.Text)
+
+		// A category can be typed instead of picked
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionChangeCategory, Content: "exp1", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Pick the new category, or type its name:", resp.Text)
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "food", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "✓ Moved to Food", resp.Text)
+		expense, _ := expenseRepo.GetByID(ctx, "exp1")
+		if assert.NotNil(t, expense.CategoryID) {
+			assert.Equal(t, "cat_food", *expense.CategoryID)
+		}
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+
+		// A message that doesn't answer the question is processed as usual
+		uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionChangeCategory, Content: "exp1", Source: "line"})
+		parser.On("Execute", mock.Anything, "taxi 250", "user1").Return(&domain.ParseResult{}, nil)
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "taxi 250", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "No expenses detected in message", resp.Text)
+	})
+
+	t.Run("Subscriptions", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		expenseRepo := NewMockExpenseRepository()
+		categoryRepo := NewMockCategoryRepository()
+		last := time.Now().AddDate(0, 0, -3)
+		for i := 0; i < 3; i++ {
+			expenseRepo.Create(ctx, &domain.Expense{ID: fmt.Sprintf("nf%d", i), UserID: "user1", Description: "Netflix", OriginalAmount: 390, Currency: "TWD", HomeAmount: 390, ExpenseDate: last.AddDate(0, -i, 0)})
+		}
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetConversationStore(NewConversationStateUseCase(NewMockConversationStateRepository(), 0))
+
+		autoSignup.On("Execute", mock.Anything, mock.Anything, "line").Return(nil)
+
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "訂閱", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Sorry, detecting subscriptions is not supported.", resp.Text)
+
+		uc.SetSubscriptionDetector(NewRecurringExpenseUseCase(expenseRepo, categoryRepo))
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "subscriptions", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "🔁 These look like monthly subscriptions:\n1. Netflix NT$390, next around ")
+		assert.Contains(t, resp.Text, "reply with its number")
+		if assert.Len(t, resp.Buttons, 1) {
+			assert.Equal(t, domain.MessageActionSubscriptions, resp.Buttons[0].Action)
+		}
+
+		// The number picks the subscription to track
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "1", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "✓ Netflix (NT$390) is now tracked as a monthly recurring expense")
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionSubscriptions, Content: "gone", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Sorry, that subscription is no longer detected.", resp.Text)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user2", Content: "訂閱", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "I didn't find any subscriptions.")
+
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Named Budgets", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		userRepo := NewMockUserRepository()
+		userRepo.Create(ctx, &domain.User{UserID: "user1"})
+		budgets := NewBudgetManagementUseCase(NewMockBudgetRepository(), NewMockCategoryRepository(), NewMockExpenseRepository(), NewMockGroupRepository())
+		budgets.SetUserRepository(userRepo)
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetConversationStore(NewConversationStateUseCase(NewMockConversationStateRepository(), 0))
+		uc.SetBudgetStatusReporter(budgets)
+		uc.SetBudgetSelector(budgets)
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+
+		budgets.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", Limit: 10000})
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "切換預算", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "You have only one budget. Give budgets a name, like 日本旅行, to switch between them.", resp.Text)
+
+		start, end := time.Now().AddDate(0, 0, -1), time.Now().AddDate(0, 0, 5)
+		budgets.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", Name: "日本旅行", Limit: 50000, StartDate: &start, EndDate: &end})
+
+		// The everyday budget is reported with a button to switch to the trip
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionBudgetStatus, Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "💰 Budgets\n• Overall (monthly)")
+		if assert.Len(t, resp.Buttons, 1) {
+			assert.Equal(t, domain.MessageActionSelectBudget, resp.Buttons[0].Action)
+			assert.Equal(t, "日本旅行", resp.Buttons[0].Value)
+		}
+
+		// Without a name, the budgets are offered and the reply picks one
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "切換預算", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Which budget should I report on? Tap one or reply with its name.", resp.Text)
+		if assert.Len(t, resp.Buttons, 2) {
+			assert.Equal(t, "✓ Everyday", resp.Buttons[0].Label)
+		}
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "日本旅行", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "✓ Now reporting on the 日本旅行 budget.\n\n💰 Budget: 日本旅行\n• Overall (")
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "switch budget Hokkaido", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "You have no budget named Hokkaido.", resp.Text)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionSelectBudget, Content: "Everyday", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "✓ Now reporting on the Everyday budget.\n\n💰 Budgets")
+
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Claims", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		expenseRepo := NewMockExpenseRepository()
+		for i, desc := range []string{"Taxi", "Hotel"} {
+			expense := &domain.Expense{ID: fmt.Sprintf("c%d", i), UserID: "user1", Description: desc, OriginalAmount: 300, Currency: "TWD", HomeAmount: 300, HomeCurrency: "TWD", ExpenseDate: time.Now().AddDate(0, 0, -i)}
+			expense.SetReimbursable(true)
+			expenseRepo.Create(ctx, expense)
+		}
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "報帳清單", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Sorry, tracking claims is not supported.", resp.Text)
+
+		uc.SetClaimTracker(NewReimbursementUseCase(expenseRepo, NewMockCategoryRepository()))
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "claims", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "💼 Outstanding claims: NT$600 (2 expenses)\n• [")
+		assert.Contains(t, resp.Text, "Taxi: NT$300 (to submit)")
+		if assert.Len(t, resp.Buttons, 1) {
+			assert.Equal(t, domain.MessageActionClaims, resp.Buttons[0].Action)
+			assert.Equal(t, "submit", resp.Buttons[0].Value)
+		}
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionClaims, Content: "submit", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "✓ Marked 2 expenses (NT$600) as submitted.", resp.Text)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "claims", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Hotel: NT$300 (submitted)")
+		assert.Empty(t, resp.Buttons)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionClaims, Content: "submit", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "There are no claims left to submit.", resp.Text)
+
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Workspaces", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		userRepo := NewMockUserRepository()
+		userRepo.Create(ctx, &domain.User{UserID: "user1"})
+		workspaces := NewWorkspaceUseCase(NewMockWorkspaceRepository(), userRepo, NewMockCategoryRepository(), NewMockExpenseRepository(), NewMockBudgetRepository())
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetConversationStore(NewConversationStateUseCase(NewMockConversationStateRepository(), 0))
+
+		autoSignup.On("Execute", mock.Anything, "user1", "line").Return(nil)
+
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "切換帳本", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Sorry, workspaces are not available.", resp.Text)
+
+		uc.SetWorkspaceSwitcher(workspaces)
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "切換帳本", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "You have only the personal workspace. Add one for your business with add workspace 公司.", resp.Text)
+
+		// Without a name, the new workspace's name is asked for
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "新增帳本", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "📒 What should the new workspace be called? Send its name, or 取消 to cancel.", resp.Text)
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "公司", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "✓ Added workspace '公司' and switched to it. Switch back with switch workspace.", resp.Text)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "add workspace 公司", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Workspace '公司' already exists", resp.Text)
+
+		// Expenses go into the active workspace
+		business, err := workspaces.ActiveWorkspace(ctx, "user1")
+		assert.NoError(t, err)
+		parser.On("Execute", mock.Anything, "Taxi 300", "user1").Return(&domain.ParseResult{
+			Expenses: []*domain.ParsedExpense{{Description: "Taxi", Amount: 300, Date: time.Now()}},
+		}, nil)
+		creator.On("Execute", mock.MatchedBy(func(ctx context.Context) bool {
+			workspaceID, ok := domain.WorkspaceFromContext(ctx)
+			return ok && workspaceID == business
+		}), mock.Anything).Return(&CreateResponse{ID: "1", Category: "Transport", OriginalAmount: 300, Currency: "TWD", HomeAmount: 300, HomeCurrency: "TWD", ExchangeRate: 1}, nil)
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "Taxi 300", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Recorded 1 expense")
+		creator.AssertExpectations(t)
+
+		// The workspaces are offered and the reply picks one
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "switch workspace", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Which workspace should new expenses go into? Tap one or reply with its name.", resp.Text)
+		if assert.Len(t, resp.Buttons, 2) {
+			assert.Equal(t, "Personal", resp.Buttons[0].Label)
+			assert.Equal(t, "✓ 公司", resp.Buttons[1].Label)
+		}
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "Personal", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "✓ Now working in Personal. Expenses, categories, budgets and reports are this workspace's.", resp.Text)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionSelectWorkspace, Content: "旅行", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "You have no workspace named 旅行.", resp.Text)
+	})
+
+	t.Run("Onboarding", func(t *testing.T) {
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		userRepo := NewMockUserRepository()
+		autoSignup := NewAutoSignupUseCase(userRepo, NewMockCategoryRepository())
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetOnboarder(NewOnboardingUseCase(userRepo, NewConversationStateUseCase(NewMockConversationStateRepository(), 0)))
+
+		// A new user's first direct message starts the wizard instead of being parsed
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "午餐 120", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Welcome")
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+
+		// Group chats aren't held up by it
+		parser.On("Execute", mock.Anything, "taxi 250", "user1").Return(&domain.ParseResult{}, nil)
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "taxi 250", Source: "line", GroupChatID: "group1"})
+		assert.NoError(t, err)
+		assert.Equal(t, "No expenses detected in message", resp.Text)
+	})
+
+	t.Run("Timezone", func(t *testing.T) {
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		userRepo := NewMockUserRepository()
+		autoSignup := NewAutoSignupUseCase(userRepo, NewMockCategoryRepository())
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetTimezoneManager(NewTimezoneUseCase(userRepo))
+		uc.SetConversationStore(NewConversationStateUseCase(NewMockConversationStateRepository(), 0))
+
+		// The timezone the messenger reports is saved, and the message is
+		// parsed in it
+		inTokyo := mock.MatchedBy(func(ctx context.Context) bool {
+			return domain.LocationFromContext(ctx).String() == "Asia/Tokyo"
+		})
+		parser.On("Execute", inTokyo, "taxi 250", "user1").Return(&domain.ParseResult{}, nil)
+		_, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "taxi 250", Source: "teams", Timezone: "Asia/Tokyo"})
+		assert.NoError(t, err)
+		parser.AssertExpectations(t)
+		user, _ := userRepo.GetByID(ctx, "user1")
+		assert.Equal(t, "Asia/Tokyo", user.Timezone)
+
+		// 時區 without a timezone asks for it; a city works too
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "時區", Source: "teams"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Which timezone?")
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "台北", Source: "teams", Timezone: "Asia/Tokyo"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "✓ Timezone set to Asia/Taipei")
+		user, _ = userRepo.GetByID(ctx, "user1")
+		assert.Equal(t, "Asia/Taipei", user.Timezone)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "timezone Mars/Olympus", Source: "teams"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "don't know the timezone")
+	})
+
+	t.Run("Language", func(t *testing.T) {
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		userRepo := NewMockUserRepository()
+		autoSignup := NewAutoSignupUseCase(userRepo, NewMockCategoryRepository())
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetLocaleManager(NewLocaleUseCase(userRepo))
+		uc.SetConversationStore(NewConversationStateUseCase(NewMockConversationStateRepository(), 0))
+
+		// Replies are in the user's language, not the one ctx carried
+		parser.On("Execute", mock.Anything, "taxi 250", "user1").Return(&domain.ParseResult{}, nil)
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "taxi 250", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "訊息中沒有找到支出", resp.Text)
+
+		// 語言 without a language asks for it, offering each as a button
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "語言", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "哪種語言")
+		assert.Len(t, resp.Buttons, 4)
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "English", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, "✓ I'll answer in English from now on", resp.Text)
+		user, _ := userRepo.GetByID(ctx, "user1")
+		assert.Equal(t, "en", user.Locale)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionSetLanguage, Content: "ja", Source: "line"})
+		assert.NoError(t, err)
+		assert.Equal(t, i18n.T("ja", "language.set"), resp.Text)
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Content: "language Klingon", Source: "line"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "Klingon")
+	})
+
+	t.Run("Expense Buttons", func(t *testing.T) {
+		autoSignup := new(mockAutoSignup)
+		parser := new(mockParseConversation)
+		creator := new(mockCreateExpense)
+		reportLink := new(mockGenerateReportLink)
+
+		expenseRepo := NewMockExpenseRepository()
+		categoryRepo := NewMockCategoryRepository()
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "午餐", Amount: 120, ExpenseDate: time.Now()})
+		categoryRepo.Create(ctx, &domain.Category{ID: "cat_food", UserID: "user1", Name: "Food"})
+
+		uc := NewProcessMessageUseCase(autoSignup, parser, creator, nil, reportLink, nil)
+		uc.SetCategoryManager(NewManageCategoryUseCase(categoryRepo))
+		uc.SetExpenseUpdater(NewUpdateExpenseUseCase(expenseRepo, categoryRepo))
+		uc.SetExpenseDeleter(NewDeleteExpenseUseCase(expenseRepo))
+
+		autoSignup.On("Execute", mock.Anything, "user1", "slack").Return(nil)
+
+		resp, err := uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionChangeCategory, Content: "exp1", Source: "slack"})
+		assert.NoError(t, err)
+		if assert.Len(t, resp.Buttons, 1) {
+			assert.Equal(t, &domain.MessageButton{Label: "Food", Action: domain.MessageActionChangeCategory, Value: "exp1 cat_food"}, resp.Buttons[0])
+		}
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionChangeCategory, Content: resp.Buttons[0].Value, Source: "slack"})
+		assert.NoError(t, err)
+		assert.Equal(t, "✓ Moved to Food", resp.Text)
+		expense, _ := expenseRepo.GetByID(ctx, "exp1")
+		if assert.NotNil(t, expense.CategoryID) {
+			assert.Equal(t, "cat_food", *expense.CategoryID)
+		}
+
+		resp, err = uc.Execute(ctx, &domain.UserMessage{UserID: "user1", Action: domain.MessageActionDeleteExpense, Content: "exp1", Source: "slack"})
+		assert.NoError(t, err)
+		assert.Contains(t, resp.Text, "午餐")
+		_, err = expenseRepo.GetByID(ctx, "exp1")
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		parser.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestBudgetStatusBlock(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "en")
+	assert.Nil(t, budgetStatusBlock(ctx, &GetBudgetStatusResponse{}))
+
+	block := budgetStatusBlock(ctx, &GetBudgetStatusResponse{Budgets: []BudgetStatus{
+		{Category: "Food", Period: "monthly", Limit: 5000, Spent: 1234.5, Percentage: 24.7},
+		{Category: "交通", Period: "weekly", Limit: 500, Spent: 620, Percentage: 124, IsExceeded: true},
+	}})
+	assert.Equal(t, domain.ContentBlockTable, block.Type)
+	assert.Equal(t, []string{"Category", "Period", "Spent", "Limit", "Used"}, block.Columns)
+	assert.Equal(t, [][]string{
+		{"Food", "monthly", "1,234.5", "5,000", "25%"},
+		{"交通", "weekly", "620", "500", "124% ⚠️"},
+	}, block.Rows)
 }
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/ai"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// PromptManagementUseCase lets admins edit AI prompts as versioned templates and
+// choose which version is active, e.g. to compare versions by their AI cost logs
+type PromptManagementUseCase struct {
+	promptRepo domain.PromptRepository
+}
+
+// PromptDetails is a prompt's built-in template, stored versions and active version
+type PromptDetails struct {
+	Name          string                   `json:"name"`
+	ActiveVersion int                      `json:"active_version"`
+	Default       string                   `json:"default"`
+	Versions      []*domain.PromptTemplate `json:"versions"`
+}
+
+// NewPromptManagementUseCase creates a new prompt management use case
+func NewPromptManagementUseCase(promptRepo domain.PromptRepository) *PromptManagementUseCase {
+	return &PromptManagementUseCase{promptRepo: promptRepo}
+}
+
+// List returns every managed prompt with its versions
+func (u *PromptManagementUseCase) List(ctx context.Context) ([]*PromptDetails, error) {
+	prompts := make([]*PromptDetails, 0, len(domain.PromptNames))
+	for _, name := range domain.PromptNames {
+		details, err := u.Get(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, details)
+	}
+	return prompts, nil
+}
+
+// Get returns one prompt with its versions
+func (u *PromptManagementUseCase) Get(ctx context.Context, name string) (*PromptDetails, error) {
+	defaultTemplate, ok := ai.DefaultPrompt(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt: %s", name)
+	}
+
+	versions, err := u.promptRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt versions: %w", err)
+	}
+
+	details := &PromptDetails{Name: name, Default: defaultTemplate, Versions: versions}
+	if details.Versions == nil {
+		details.Versions = []*domain.PromptTemplate{}
+	}
+	for _, version := range versions {
+		if version.Active {
+			details.ActiveVersion = version.Version
+		}
+	}
+	return details, nil
+}
+
+// CreateVersion stores a new version of a prompt, optionally activating it
+func (u *PromptManagementUseCase) CreateVersion(ctx context.Context, name, template string, activate bool) (*domain.PromptTemplate, error) {
+	if _, ok := ai.DefaultPrompt(name); !ok {
+		return nil, fmt.Errorf("unknown prompt: %s", name)
+	}
+	if strings.TrimSpace(template) == "" {
+		return nil, fmt.Errorf("template is required")
+	}
+	if err := ai.ValidatePrompt(template); err != nil {
+		return nil, err
+	}
+
+	versions, err := u.promptRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt versions: %w", err)
+	}
+	next := 1
+	if len(versions) > 0 {
+		next = versions[0].Version + 1
+	}
+
+	prompt := &domain.PromptTemplate{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Version:   next,
+		Template:  template,
+		CreatedAt: time.Now(),
+	}
+	if err := u.promptRepo.Create(ctx, prompt); err != nil {
+		return nil, fmt.Errorf("failed to create prompt version: %w", err)
+	}
+
+	if activate {
+		if err := u.Activate(ctx, name, prompt.Version); err != nil {
+			return nil, err
+		}
+		prompt.Active = true
+	}
+	return prompt, nil
+}
+
+// Activate makes a version the one used for AI calls; version 0 restores the built-in prompt
+func (u *PromptManagementUseCase) Activate(ctx context.Context, name string, version int) error {
+	if _, ok := ai.DefaultPrompt(name); !ok {
+		return fmt.Errorf("unknown prompt: %s", name)
+	}
+	if version < 0 {
+		return fmt.Errorf("version cannot be negative")
+	}
+	if err := u.promptRepo.Activate(ctx, name, version); err != nil {
+		return fmt.Errorf("failed to activate prompt version: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestPromptManagementUseCase(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Versions are numbered and activated", func(t *testing.T) {
+		repo := NewMockPromptRepository()
+		uc := NewPromptManagementUseCase(repo)
+
+		v1, err := uc.CreateVersion(ctx, domain.PromptParseExpense, "Parse: {{.Text}}", false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		v2, err := uc.CreateVersion(ctx, domain.PromptParseExpense, "Extract expenses from {{.Text}} ({{.Today}})", true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if v1.Version != 1 || v2.Version != 2 || v1.Active || !v2.Active {
+			t.Errorf("unexpected versions v1=%+v v2=%+v", v1, v2)
+		}
+
+		details, err := uc.Get(ctx, domain.PromptParseExpense)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if details.ActiveVersion != 2 || len(details.Versions) != 2 || details.Default == "" {
+			t.Errorf("unexpected details %+v", details)
+		}
+
+		if err := uc.Activate(ctx, domain.PromptParseExpense, 0); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if active, _ := repo.GetActive(ctx, domain.PromptParseExpense); active != nil {
+			t.Errorf("expected built-in prompt after activating version 0, got v%d", active.Version)
+		}
+	})
+
+	t.Run("List covers every prompt", func(t *testing.T) {
+		uc := NewPromptManagementUseCase(NewMockPromptRepository())
+		prompts, err := uc.List(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(prompts) != len(domain.PromptNames) {
+			t.Errorf("expected %d prompts, got %d", len(domain.PromptNames), len(prompts))
+		}
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		uc := NewPromptManagementUseCase(NewMockPromptRepository())
+		if _, err := uc.CreateVersion(ctx, "unknown", "{{.Text}}", false); err == nil {
+			t.Error("expected error for unknown prompt")
+		}
+		if _, err := uc.CreateVersion(ctx, domain.PromptParseExpense, "{{.Text", false); err == nil {
+			t.Error("expected error for invalid template")
+		}
+		if _, err := uc.CreateVersion(ctx, domain.PromptParseExpense, "  ", false); err == nil {
+			t.Error("expected error for empty template")
+		}
+		if err := uc.Activate(ctx, domain.PromptParseExpense, 7); err == nil {
+			t.Error("expected error activating a missing version")
+		}
+	})
+}
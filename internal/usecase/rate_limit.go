@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// maxIdleBuckets bounds how many per-user buckets are kept before refilled ones are dropped
+const maxIdleBuckets = 10000
+
+// tokenBucket holds one user's remaining message allowance
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimitUseCase throttles message processing per user with a token bucket so a
+// single chat user cannot exhaust the AI quota
+type RateLimitUseCase struct {
+	userRepo domain.UserRepository
+	now      func() time.Time
+
+	mu      sync.Mutex
+	rate    float64 // tokens per second; 0 lets every message through
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+// NewRateLimitUseCase creates a limiter allowing perMinute messages per user on
+// average, with bursts of up to burst messages; perMinute <= 0 disables it
+func NewRateLimitUseCase(userRepo domain.UserRepository, perMinute, burst int) *RateLimitUseCase {
+	u := &RateLimitUseCase{
+		userRepo: userRepo,
+		now:      time.Now,
+		buckets:  make(map[string]*tokenBucket),
+	}
+	u.SetLimits(perMinute, burst)
+	return u
+}
+
+// SetLimits changes the limits of a running limiter, as a configuration reload
+// does. Buckets keep their tokens, capped at the new burst.
+func (u *RateLimitUseCase) SetLimits(perMinute, burst int) {
+	if perMinute < 0 {
+		perMinute = 0
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.rate = float64(perMinute) / 60
+	u.burst = float64(burst)
+}
+
+// Allow takes a token from the user's bucket. When the bucket is empty it returns
+// false with a "slow down" reply in the user's language.
+func (u *RateLimitUseCase) Allow(ctx context.Context, userID string) (string, bool) {
+	wait, ok := u.take(userID)
+	if ok {
+		return "", true
+	}
+	seconds := int(math.Ceil(wait.Seconds()))
+	return u.slowDownMessage(ctx, userID, seconds), false
+}
+
+// take refills the user's bucket and removes a token, or reports how long until one is available
+func (u *RateLimitUseCase) take(userID string) (time.Duration, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.rate == 0 {
+		return 0, true
+	}
+
+	now := u.now()
+	bucket, ok := u.buckets[userID]
+	if !ok {
+		if len(u.buckets) >= maxIdleBuckets {
+			u.pruneLocked(now)
+		}
+		bucket = &tokenBucket{tokens: u.burst, updated: now}
+		u.buckets[userID] = bucket
+	}
+
+	bucket.tokens = u.refill(bucket, now)
+	bucket.updated = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - bucket.tokens) / u.rate * float64(time.Second)), false
+}
+
+// refill returns the bucket's tokens after the time elapsed since its last update
+func (u *RateLimitUseCase) refill(bucket *tokenBucket, now time.Time) float64 {
+	elapsed := now.Sub(bucket.updated).Seconds()
+	return math.Min(u.burst, bucket.tokens+elapsed*u.rate)
+}
+
+// pruneLocked drops buckets that have refilled completely; they behave the same as a new bucket
+func (u *RateLimitUseCase) pruneLocked(now time.Time) {
+	for userID, bucket := range u.buckets {
+		if u.refill(bucket, now) >= u.burst {
+			delete(u.buckets, userID)
+		}
+	}
+}
+
+// slowDownMessage is the throttled reply in the user's language; it is sent
+// before the message is processed, so the user is looked up here
+func (u *RateLimitUseCase) slowDownMessage(ctx context.Context, userID string, seconds int) string {
+	locale := i18n.FromContext(ctx)
+	if u.userRepo != nil {
+		if user, err := u.userRepo.GetByID(ctx, userID); err == nil && i18n.Supported(user.Locale) {
+			locale = user.Locale
+		}
+	}
+	return i18n.Tf(locale, "rate_limit.slow_down", seconds)
+}
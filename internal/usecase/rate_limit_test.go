@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestRateLimitUseCase(t *testing.T) {
+	ctx := context.Background()
+
+	newLimiter := func(perMinute, burst int) (*RateLimitUseCase, *time.Time) {
+		userRepo := NewMockUserRepository()
+		userRepo.Create(ctx, &domain.User{UserID: "user_tw", Locale: "zh-TW"})
+		userRepo.Create(ctx, &domain.User{UserID: "user1", Locale: "en"})
+		limiter := NewRateLimitUseCase(userRepo, perMinute, burst)
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		limiter.now = func() time.Time { return now }
+		return limiter, &now
+	}
+
+	t.Run("Burst then throttle", func(t *testing.T) {
+		limiter, _ := newLimiter(6, 3)
+		for i := 0; i < 3; i++ {
+			if _, ok := limiter.Allow(ctx, "user1"); !ok {
+				t.Fatalf("expected message %d to be allowed", i+1)
+			}
+		}
+		reply, ok := limiter.Allow(ctx, "user1")
+		if ok {
+			t.Fatal("expected message over the burst to be throttled")
+		}
+		if !strings.Contains(reply, "10 seconds") {
+			t.Errorf("expected wait of 10 seconds in reply, got %q", reply)
+		}
+	})
+
+	t.Run("Tokens refill over time", func(t *testing.T) {
+		limiter, now := newLimiter(6, 1)
+		limiter.Allow(ctx, "user1")
+		if _, ok := limiter.Allow(ctx, "user1"); ok {
+			t.Fatal("expected second message to be throttled")
+		}
+		*now = now.Add(10 * time.Second)
+		if _, ok := limiter.Allow(ctx, "user1"); !ok {
+			t.Error("expected message to be allowed after refill")
+		}
+	})
+
+	t.Run("Limits change while running", func(t *testing.T) {
+		limiter, _ := newLimiter(6, 3)
+		limiter.Allow(ctx, "user1")
+		limiter.SetLimits(6, 1)
+		limiter.Allow(ctx, "user1")
+		if _, ok := limiter.Allow(ctx, "user1"); ok {
+			t.Error("expected the bucket to be capped at the lowered burst")
+		}
+		limiter.SetLimits(0, 1)
+		if _, ok := limiter.Allow(ctx, "user1"); !ok {
+			t.Error("expected a disabled limiter to allow every message")
+		}
+	})
+
+	t.Run("Users have separate buckets", func(t *testing.T) {
+		limiter, _ := newLimiter(6, 1)
+		limiter.Allow(ctx, "user1")
+		if _, ok := limiter.Allow(ctx, "user2"); !ok {
+			t.Error("expected another user's message to be allowed")
+		}
+	})
+
+	t.Run("Reply uses the user's locale", func(t *testing.T) {
+		limiter, _ := newLimiter(6, 1)
+		limiter.Allow(ctx, "user_tw")
+		reply, _ := limiter.Allow(ctx, "user_tw")
+		if !strings.Contains(reply, "請等 10 秒") {
+			t.Errorf("expected zh-TW reply, got %q", reply)
+		}
+	})
+}
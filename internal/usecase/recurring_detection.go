@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+const (
+	// subscriptionLookbackMonths is how far back charges are looked for
+	subscriptionLookbackMonths = 13
+	// minSubscriptionCharges is how many monthly charges in a row make a subscription
+	minSubscriptionCharges = 3
+	// minSubscriptionGapDays and maxSubscriptionGapDays bound the days between
+	// two charges of a monthly subscription
+	minSubscriptionGapDays = 25
+	maxSubscriptionGapDays = 35
+	// subscriptionLapseDays is how long after its last charge a subscription is
+	// taken as cancelled
+	subscriptionLapseDays = 45
+)
+
+// ErrSubscriptionNotFound is returned when confirming a subscription that
+// isn't detected, or no longer is
+var ErrSubscriptionNotFound = fmt.Errorf("detected subscription %w", domain.ErrNotFound)
+
+// DetectedSubscription is a charge the user pays every month, the same amount
+// at the same merchant, that isn't tracked as a recurring expense yet
+type DetectedSubscription struct {
+	ID           string    `json:"id"` // Stable while the charges continue, for confirming it
+	Description  string    `json:"description"`
+	MerchantID   string    `json:"merchant_id,omitempty"`
+	Merchant     string    `json:"merchant,omitempty"`
+	Amount       float64   `json:"amount"` // In the currency charged
+	Currency     string    `json:"currency"`
+	HomeAmount   float64   `json:"home_amount"` // Of the last charge
+	CategoryID   *string   `json:"category_id,omitempty"`
+	Category     string    `json:"category,omitempty"`
+	Frequency    string    `json:"frequency"`
+	Charges      int       `json:"charges"` // Monthly charges in a row
+	FirstCharged time.Time `json:"first_charged"`
+	LastCharged  time.Time `json:"last_charged"`
+	NextCharge   time.Time `json:"next_charge"`
+}
+
+// DetectSubscriptionsResponse lists the subscriptions detected in a user's expenses
+type DetectSubscriptionsResponse struct {
+	Detected []*DetectedSubscription `json:"detected"`
+	Total    int                     `json:"total"`
+}
+
+// ConfirmSubscriptionResponse is the recurring expense a detected subscription became
+type ConfirmSubscriptionResponse struct {
+	RecurringID  string                `json:"recurring_id"`
+	Subscription *DetectedSubscription `json:"subscription"`
+	Message      string                `json:"message"`
+}
+
+// SetMerchantRepository names the merchants of detected subscriptions
+func (u *RecurringExpenseUseCase) SetMerchantRepository(merchantRepo domain.MerchantRepository) {
+	u.merchantRepo = merchantRepo
+}
+
+// DetectSubscriptions finds the charges the user paid every month for at
+// least the last three months, the same amount at the same merchant (or with
+// the same description), and still pays. Subscriptions already tracked as
+// recurring expenses are left out. The largest come first.
+func (u *RecurringExpenseUseCase) DetectSubscriptions(ctx context.Context, userID string) (*DetectSubscriptionsResponse, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	now := domain.InLocation(ctx, u.now())
+	expenses, err := u.expenseRepo.GetByUserIDAndDateRange(ctx, userID, now.AddDate(0, -subscriptionLookbackMonths, 0), now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expenses: %w", err)
+	}
+	tracked, err := u.ListRecurring(ctx, &ListRecurringRequest{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring expenses: %w", err)
+	}
+
+	// Charges of one subscription share the merchant, currency and amount
+	charges := make(map[string][]*domain.Expense)
+	var keys []string
+	for _, expense := range expenses {
+		key := subscriptionKey(expense)
+		if _, ok := charges[key]; !ok {
+			keys = append(keys, key)
+		}
+		charges[key] = append(charges[key], expense)
+	}
+
+	detected := make([]*DetectedSubscription, 0)
+	for _, key := range keys {
+		run := monthlyRun(charges[key])
+		if len(run) < minSubscriptionCharges {
+			continue
+		}
+		last := run[len(run)-1]
+		if now.Sub(last.ExpenseDate) > subscriptionLapseDays*24*time.Hour {
+			continue
+		}
+		if isTrackedRecurring(tracked.Recurring, last) {
+			continue
+		}
+		detected = append(detected, u.describeSubscription(ctx, key, run))
+	}
+	sort.SliceStable(detected, func(i, j int) bool {
+		if detected[i].HomeAmount != detected[j].HomeAmount {
+			return detected[i].HomeAmount > detected[j].HomeAmount
+		}
+		return detected[i].Description < detected[j].Description
+	})
+
+	return &DetectSubscriptionsResponse{
+		Detected: detected,
+		Total:    len(detected),
+	}, nil
+}
+
+// ConfirmSubscription tracks a detected subscription as a monthly recurring
+// expense starting from its next charge
+func (u *RecurringExpenseUseCase) ConfirmSubscription(ctx context.Context, userID, id string) (*ConfirmSubscriptionResponse, error) {
+	detected, err := u.DetectSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, subscription := range detected.Detected {
+		if subscription.ID != id {
+			continue
+		}
+		created, err := u.CreateRecurring(ctx, &CreateRecurringRequest{
+			UserID:      userID,
+			Description: subscription.Description,
+			Amount:      subscription.HomeAmount,
+			CategoryID:  subscription.CategoryID,
+			Frequency:   subscription.Frequency,
+			StartDate:   subscription.NextCharge,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create recurring expense: %w", err)
+		}
+		return &ConfirmSubscriptionResponse{
+			RecurringID:  created.ID,
+			Subscription: subscription,
+			Message:      created.Message,
+		}, nil
+	}
+	return nil, ErrSubscriptionNotFound
+}
+
+// subscriptionKey groups the charges of one subscription: the merchant, or
+// the description when there is none, with the currency and amount charged
+func subscriptionKey(expense *domain.Expense) string {
+	payee := "d:" + strings.ToLower(strings.TrimSpace(expense.Description))
+	if expense.MerchantID != nil {
+		payee = "m:" + *expense.MerchantID
+	}
+	return fmt.Sprintf("%s|%s|%.2f", payee, expense.Currency, expense.OriginalAmount)
+}
+
+// monthlyRun returns the latest charges that came a month apart each, oldest first
+func monthlyRun(charges []*domain.Expense) []*domain.Expense {
+	sort.SliceStable(charges, func(i, j int) bool {
+		return charges[i].ExpenseDate.Before(charges[j].ExpenseDate)
+	})
+	start := len(charges) - 1
+	for start > 0 {
+		gap := charges[start].ExpenseDate.Sub(charges[start-1].ExpenseDate).Hours() / 24
+		if gap < minSubscriptionGapDays || gap > maxSubscriptionGapDays {
+			break
+		}
+		start--
+	}
+	return charges[start:]
+}
+
+// isTrackedRecurring reports whether a recurring expense already covers the charge
+func isTrackedRecurring(recurring []*RecurringExpense, charge *domain.Expense) bool {
+	for _, r := range recurring {
+		if r.IsActive && strings.EqualFold(r.Description, charge.Description) && math.Abs(r.Amount-charge.HomeAmount) < 0.01 {
+			return true
+		}
+	}
+	return false
+}
+
+// describeSubscription builds the detected subscription of a run of monthly charges
+func (u *RecurringExpenseUseCase) describeSubscription(ctx context.Context, key string, run []*domain.Expense) *DetectedSubscription {
+	first, last := run[0], run[len(run)-1]
+	sum := sha256.Sum256([]byte(key))
+	subscription := &DetectedSubscription{
+		ID:           hex.EncodeToString(sum[:8]),
+		Description:  last.Description,
+		Amount:       last.OriginalAmount,
+		Currency:     last.Currency,
+		HomeAmount:   last.HomeAmount,
+		CategoryID:   last.CategoryID,
+		Frequency:    "monthly",
+		Charges:      len(run),
+		FirstCharged: first.ExpenseDate,
+		LastCharged:  last.ExpenseDate,
+		NextCharge:   last.ExpenseDate.AddDate(0, 1, 0),
+	}
+	if last.MerchantID != nil && u.merchantRepo != nil {
+		subscription.MerchantID = *last.MerchantID
+		merchant, err := u.merchantRepo.GetByID(ctx, *last.MerchantID)
+		if err == nil {
+			subscription.Merchant = merchant.Name
+		} else if !errors.Is(err, domain.ErrNotFound) {
+			slog.WarnContext(ctx, "Failed to get merchant", "merchant_id", *last.MerchantID, "error", err)
+		}
+	}
+	if last.CategoryID != nil {
+		if category, err := u.categoryRepo.GetByID(ctx, *last.CategoryID); err == nil {
+			subscription.Category = category.Name
+		}
+	}
+	return subscription
+}
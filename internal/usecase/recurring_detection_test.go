@@ -0,0 +1,86 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurringExpenseUseCase_DetectSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	merchantRepo := NewMockMerchantRepository(expenseRepo)
+	require.NoError(t, categoryRepo.Create(ctx, &domain.Category{ID: "cat_fun", UserID: "user1", Name: "Entertainment"}))
+	require.NoError(t, merchantRepo.Create(ctx, &domain.Merchant{ID: "m_netflix", UserID: "user1", Name: "Netflix", NormalizedName: "netflix"}))
+
+	add := func(id, description string, merchantID *string, amount float64, date time.Time) {
+		categoryID := "cat_fun"
+		require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+			ID: id, UserID: "user1", Description: description, MerchantID: merchantID, CategoryID: &categoryID,
+			OriginalAmount: amount, Currency: "TWD", HomeAmount: amount, HomeCurrency: "TWD", ExpenseDate: date,
+		}))
+	}
+	netflix := "m_netflix"
+	for i := 0; i < 4; i++ {
+		// Netflix on the 5th, described differently each month
+		add(fmt.Sprintf("nf%d", i), fmt.Sprintf("Netflix %d", i), &netflix, 390, time.Date(2026, time.Month(i), 5, 9, 0, 0, 0, time.UTC))
+		// A gym membership that lapsed in December
+		add(fmt.Sprintf("gym%d", i), "Gym", nil, 1200, time.Date(2025, time.Month(9+i), 1, 9, 0, 0, 0, time.UTC))
+	}
+	for i := 0; i < 3; i++ {
+		// Spotify monthly, with a price change breaking the run
+		add(fmt.Sprintf("sp%d", i), "Spotify", nil, 149, time.Date(2025, time.Month(11+i), 10, 9, 0, 0, 0, time.UTC))
+		// Coffee every day isn't a subscription
+		add(fmt.Sprintf("coffee%d", i), "Coffee", nil, 65, now.AddDate(0, 0, -i))
+	}
+	add("sp3", "spotify", nil, 149, time.Date(2026, 2, 10, 9, 0, 0, 0, time.UTC))
+	add("sp4", "Spotify", nil, 169, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC))
+
+	uc := NewRecurringExpenseUseCase(expenseRepo, categoryRepo)
+	uc.SetMerchantRepository(merchantRepo)
+	uc.now = func() time.Time { return now }
+
+	detected, err := uc.DetectSubscriptions(ctx, "user1")
+	require.NoError(t, err)
+	require.Equal(t, 2, detected.Total)
+
+	nf := detected.Detected[0]
+	assert.Equal(t, "Netflix 3", nf.Description)
+	assert.Equal(t, "m_netflix", nf.MerchantID)
+	assert.Equal(t, "Netflix", nf.Merchant)
+	assert.Equal(t, 390.0, nf.Amount)
+	assert.Equal(t, "Entertainment", nf.Category)
+	assert.Equal(t, 4, nf.Charges)
+	assert.Equal(t, time.Date(2026, 4, 5, 9, 0, 0, 0, time.UTC), nf.NextCharge)
+
+	sp := detected.Detected[1]
+	assert.Equal(t, "spotify", sp.Description)
+	assert.Equal(t, 149.0, sp.Amount)
+	assert.Equal(t, 4, sp.Charges)
+	assert.Equal(t, time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC), sp.NextCharge)
+
+	again, err := uc.DetectSubscriptions(ctx, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, nf.ID, again.Detected[0].ID, "the same charges are the same subscription")
+
+	confirmed, err := uc.ConfirmSubscription(ctx, "user1", nf.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, confirmed.RecurringID)
+	assert.Equal(t, "Netflix 3", confirmed.Subscription.Description)
+
+	_, err = uc.ConfirmSubscription(ctx, "user1", "unknown")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	empty, err := uc.DetectSubscriptions(ctx, "user2")
+	require.NoError(t, err)
+	assert.Equal(t, 0, empty.Total)
+	assert.NotNil(t, empty.Detected)
+}
@@ -3,6 +3,9 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +16,8 @@ import (
 type RecurringExpenseUseCase struct {
 	expenseRepo  domain.ExpenseRepository
 	categoryRepo domain.CategoryRepository
+	merchantRepo domain.MerchantRepository
+	now          func() time.Time
 }
 
 // NewRecurringExpenseUseCase creates a new recurring expense use case
@@ -23,6 +28,7 @@ func NewRecurringExpenseUseCase(
 	return &RecurringExpenseUseCase{
 		expenseRepo:  expenseRepo,
 		categoryRepo: categoryRepo,
+		now:          time.Now,
 	}
 }
 
@@ -33,7 +39,7 @@ type RecurringExpense struct {
 	Description string     `json:"description"`
 	Amount      float64    `json:"amount"`
 	CategoryID  *string    `json:"category_id,omitempty"`
-	Frequency   string     `json:"frequency"` // "daily", "weekly", "biweekly", "monthly", "quarterly", "yearly"
+	Frequency   string     `json:"frequency"` // "daily", "weekly", "biweekly", "monthly", "quarterly", "yearly" or an RRULE
 	StartDate   time.Time  `json:"start_date"`
 	EndDate     *time.Time `json:"end_date,omitempty"` // nil = no end date
 	IsActive    bool       `json:"is_active"`
@@ -68,14 +74,11 @@ func (u *RecurringExpenseUseCase) CreateRecurring(ctx context.Context, req *Crea
 		req.Frequency = "monthly"
 	}
 
-	// Validate frequency
-	validFrequencies := map[string]bool{
-		"daily": true, "weekly": true, "biweekly": true, "monthly": true,
-		"quarterly": true, "yearly": true,
-	}
-	if !validFrequencies[req.Frequency] {
-		return nil, fmt.Errorf("invalid frequency: %s", req.Frequency)
+	frequency, err := normalizeFrequency(req.Frequency)
+	if err != nil {
+		return nil, err
 	}
+	req.Frequency = frequency
 
 	// In production, this would be stored in a recurring_expenses table
 	// For now, we return the created ID
@@ -83,10 +86,31 @@ func (u *RecurringExpenseUseCase) CreateRecurring(ctx context.Context, req *Crea
 
 	return &CreateRecurringResponse{
 		ID:      id,
-		Message: fmt.Sprintf("Recurring expense '%s' created: %s every %s", req.Description, formatAmount(req.Amount), req.Frequency),
+		Message: fmt.Sprintf("Recurring expense '%s' created: %s %s", req.Description, formatAmount(req.Amount), describeFrequency(req.Frequency)),
 	}, nil
 }
 
+// normalizeFrequency validates a preset frequency or RRULE, returning the
+// preset's name or the rule in its canonical form
+func normalizeFrequency(frequency string) (string, error) {
+	if _, ok := domain.RecurrencePresets[strings.ToLower(strings.TrimSpace(frequency))]; ok {
+		return strings.ToLower(strings.TrimSpace(frequency)), nil
+	}
+	rule, err := domain.ParseRecurrence(frequency)
+	if err != nil {
+		return "", fmt.Errorf("invalid frequency %q: %w", frequency, err)
+	}
+	return rule.String(), nil
+}
+
+// describeFrequency phrases a normalized frequency for messages
+func describeFrequency(frequency string) string {
+	if _, ok := domain.RecurrencePresets[frequency]; ok {
+		return "every " + frequency
+	}
+	return "on " + frequency
+}
+
 // ListRecurringRequest represents a request to list recurring expenses
 type ListRecurringRequest struct {
 	UserID string
@@ -179,6 +203,13 @@ func (u *RecurringExpenseUseCase) UpdateRecurring(ctx context.Context, req *Upda
 	if req.UserID == "" || req.ID == "" {
 		return nil, fmt.Errorf("user_id and id are required")
 	}
+	if req.Frequency != nil {
+		frequency, err := normalizeFrequency(*req.Frequency)
+		if err != nil {
+			return nil, err
+		}
+		req.Frequency = &frequency
+	}
 
 	// In production: retrieve, verify ownership, update, save
 	return &UpdateRecurringResponse{
@@ -244,10 +275,101 @@ func (u *RecurringExpenseUseCase) GetUpcoming(ctx context.Context, req *GetUpcom
 		req.Days = 30 // Default to next 30 days
 	}
 
-	// In production: calculate upcoming occurrences based on frequency
+	recurring, err := u.ListRecurring(ctx, &ListRecurringRequest{UserID: req.UserID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring expenses: %w", err)
+	}
+
+	now := domain.InLocation(ctx, u.now())
+	upcoming := make([]*UpcomingExpense, 0)
+	for _, r := range recurring.Recurring {
+		if !r.IsActive {
+			continue
+		}
+		rule, err := domain.ParseRecurrence(r.Frequency)
+		if err != nil {
+			slog.WarnContext(ctx, "Skipping recurring expense with an invalid frequency", "recurring_id", r.ID, "error", err)
+			continue
+		}
+		end := now.AddDate(0, 0, req.Days)
+		if r.EndDate != nil && r.EndDate.Before(end) {
+			end = *r.EndDate
+		}
+		category := ""
+		if r.CategoryID != nil {
+			if c, err := u.categoryRepo.GetByID(ctx, *r.CategoryID); err == nil {
+				category = c.Name
+			}
+		}
+		for _, due := range rule.Between(r.StartDate, now, end, 0) {
+			upcoming = append(upcoming, &UpcomingExpense{
+				RecurringID: r.ID,
+				Description: r.Description,
+				Amount:      r.Amount,
+				Category:    category,
+				DueDate:     due,
+			})
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return upcoming[i].DueDate.Before(upcoming[j].DueDate) })
+
+	message := "No upcoming recurring expenses"
+	if len(upcoming) > 0 {
+		message = fmt.Sprintf("%d upcoming recurring expenses in the next %d days", len(upcoming), req.Days)
+	}
 	return &GetUpcomingResponse{
-		Upcoming: make([]*UpcomingExpense, 0),
-		Total:    0,
-		Message:  "No upcoming recurring expenses",
+		Upcoming: upcoming,
+		Total:    len(upcoming),
+		Message:  message,
+	}, nil
+}
+
+// PreviewRecurrenceRequest represents a request to preview when a frequency falls due
+type PreviewRecurrenceRequest struct {
+	Frequency string
+	StartDate time.Time // Defaults to now
+	Count     int       // How many occurrences, 5 by default and at most 50
+}
+
+// PreviewRecurrenceResponse lists the first occurrences of a frequency
+type PreviewRecurrenceResponse struct {
+	Frequency   string      `json:"frequency"` // The preset name, or the rule in its canonical form
+	Rule        string      `json:"rule"`
+	Occurrences []time.Time `json:"occurrences"`
+}
+
+// PreviewRecurrence validates a preset frequency or RRULE and returns its
+// first occurrences from the start date, so a rule can be checked before a
+// recurring expense is created with it
+func (u *RecurringExpenseUseCase) PreviewRecurrence(ctx context.Context, req *PreviewRecurrenceRequest) (*PreviewRecurrenceResponse, error) {
+	if req.Frequency == "" {
+		return nil, fmt.Errorf("frequency is required")
+	}
+	frequency, err := normalizeFrequency(req.Frequency)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := domain.ParseRecurrence(frequency)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Count <= 0 {
+		req.Count = 5
+	}
+	req.Count = min(req.Count, 50)
+	start := req.StartDate
+	if start.IsZero() {
+		start = domain.InLocation(ctx, u.now())
+	}
+
+	occurrences := rule.Between(start, start, start.AddDate(100, 0, 0), req.Count)
+	if occurrences == nil {
+		occurrences = make([]time.Time, 0)
+	}
+	return &PreviewRecurrenceResponse{
+		Frequency:   frequency,
+		Rule:        rule.String(),
+		Occurrences: occurrences,
 	}, nil
 }
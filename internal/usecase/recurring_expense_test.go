@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurringExpenseUseCase_CreateRecurringFrequency(t *testing.T) {
+	ctx := context.Background()
+	uc := NewRecurringExpenseUseCase(NewMockExpenseRepository(), NewMockCategoryRepository())
+	req := func(frequency string) *CreateRecurringRequest {
+		return &CreateRecurringRequest{UserID: "user1", Description: "Piano lesson", Amount: 800, Frequency: frequency}
+	}
+
+	resp, err := uc.CreateRecurring(ctx, req("Biweekly"))
+	require.NoError(t, err)
+	assert.Equal(t, "Recurring expense 'Piano lesson' created: 800 every biweekly", resp.Message)
+
+	resp, err = uc.CreateRecurring(ctx, req("rrule:freq=monthly;byday=2fr"))
+	require.NoError(t, err)
+	assert.Equal(t, "Recurring expense 'Piano lesson' created: 800 on FREQ=MONTHLY;BYDAY=2FR", resp.Message)
+
+	_, err = uc.CreateRecurring(ctx, req("FREQ=WEEKLY;BYDAY=2FR"))
+	assert.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+
+	invalid := "fortnightly"
+	_, err = uc.UpdateRecurring(ctx, &UpdateRecurringRequest{UserID: "user1", ID: "rec1", Frequency: &invalid})
+	assert.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+}
+
+func TestRecurringExpenseUseCase_PreviewRecurrence(t *testing.T) {
+	ctx := domain.WithLocation(context.Background(), time.FixedZone("Asia/Taipei", 8*3600))
+	uc := NewRecurringExpenseUseCase(NewMockExpenseRepository(), NewMockCategoryRepository())
+	uc.now = func() time.Time { return time.Date(2026, 3, 20, 1, 0, 0, 0, time.UTC) }
+
+	// Every other Friday from now, a Friday in Taipei
+	preview, err := uc.PreviewRecurrence(ctx, &PreviewRecurrenceRequest{Frequency: "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", Count: 3})
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=FR", preview.Rule)
+	var dates []string
+	for _, o := range preview.Occurrences {
+		dates = append(dates, o.Format("2006-01-02 15:04"))
+	}
+	assert.Equal(t, []string{"2026-03-20 09:00", "2026-04-03 09:00", "2026-04-17 09:00"}, dates)
+
+	preview, err = uc.PreviewRecurrence(ctx, &PreviewRecurrenceRequest{Frequency: "quarterly", StartDate: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	assert.Equal(t, "quarterly", preview.Frequency)
+	assert.Equal(t, "FREQ=MONTHLY;INTERVAL=3", preview.Rule)
+	require.Len(t, preview.Occurrences, 5)
+	assert.Equal(t, time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC), preview.Occurrences[1])
+
+	preview, err = uc.PreviewRecurrence(ctx, &PreviewRecurrenceRequest{Frequency: "FREQ=DAILY;COUNT=2", Count: 10})
+	require.NoError(t, err)
+	assert.Len(t, preview.Occurrences, 2)
+
+	_, err = uc.PreviewRecurrence(ctx, &PreviewRecurrenceRequest{Frequency: "FREQ=HOURLY"})
+	assert.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+}
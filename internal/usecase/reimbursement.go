@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ErrInvalidClaim is returned for a claim status change the lifecycle doesn't
+// allow, such as moving a paid claim back, or for an unknown status
+var ErrInvalidClaim = errors.New("invalid claim")
+
+// ReimbursementUseCase tracks the expenses users paid for their employer and
+// the claims they make to be paid back
+type ReimbursementUseCase struct {
+	expenseRepo  domain.ExpenseRepository
+	categoryRepo domain.CategoryRepository
+	merchantRepo domain.MerchantRepository
+}
+
+// NewReimbursementUseCase creates a new reimbursement use case
+func NewReimbursementUseCase(expenseRepo domain.ExpenseRepository, categoryRepo domain.CategoryRepository) *ReimbursementUseCase {
+	return &ReimbursementUseCase{
+		expenseRepo:  expenseRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+// SetMerchantRepository names the merchants of claims
+func (u *ReimbursementUseCase) SetMerchantRepository(merchantRepo domain.MerchantRepository) {
+	u.merchantRepo = merchantRepo
+}
+
+// Claim is a reimbursable expense and where its claim stands
+type Claim struct {
+	ExpenseID    string    `json:"expense_id"`
+	Date         time.Time `json:"date"`
+	Description  string    `json:"description"`
+	Category     string    `json:"category"`
+	Merchant     string    `json:"merchant,omitempty"`
+	Amount       float64   `json:"amount"` // In the currency paid
+	Currency     string    `json:"currency"`
+	HomeAmount   float64   `json:"home_amount"`
+	HomeCurrency string    `json:"home_currency"`
+	Status       string    `json:"status"`
+}
+
+// ListClaimsRequest selects claims by status and expense date. Without
+// statuses every claim is listed.
+type ListClaimsRequest struct {
+	UserID   string
+	Statuses []string
+	From     *time.Time
+	To       *time.Time
+}
+
+// ClaimsResponse lists claims, newest first, with their totals in home currency
+type ClaimsResponse struct {
+	Claims       []*Claim           `json:"claims"`
+	Count        int                `json:"count"`
+	Total        float64            `json:"total"`
+	Totals       map[string]float64 `json:"totals"` // By status
+	HomeCurrency string             `json:"home_currency,omitempty"`
+}
+
+// MarkReimbursable marks one of the user's expenses as paid for their
+// employer, with a pending claim, or no longer so. A paid claim stays marked.
+func (u *ReimbursementUseCase) MarkReimbursable(ctx context.Context, userID, expenseID string, reimbursable bool) (*Claim, error) {
+	expense, err := u.getOwnedExpense(ctx, userID, expenseID)
+	if err != nil {
+		return nil, err
+	}
+	if !reimbursable && expense.ClaimStatus == domain.ClaimStatusPaid {
+		return nil, fmt.Errorf("%w: the expense was already paid back", ErrInvalidClaim)
+	}
+	if reimbursable != expense.Reimbursable {
+		expense.SetReimbursable(reimbursable)
+		if err := u.expenseRepo.Update(ctx, expense); err != nil {
+			return nil, fmt.Errorf("failed to update expense: %w", err)
+		}
+	}
+	return u.claim(ctx, expense), nil
+}
+
+// UpdateClaimStatus moves the claims of the user's reimbursable expenses to
+// status. Either every claim moves or, when one can't, none does.
+func (u *ReimbursementUseCase) UpdateClaimStatus(ctx context.Context, userID string, expenseIDs []string, status string) ([]*Claim, error) {
+	if !domain.IsClaimStatus(status) {
+		return nil, fmt.Errorf("%w: status must be pending, submitted or paid", ErrInvalidClaim)
+	}
+	if len(expenseIDs) == 0 {
+		return nil, fmt.Errorf("%w: expense_ids are required", ErrInvalidClaim)
+	}
+
+	expenses := make([]*domain.Expense, 0, len(expenseIDs))
+	for _, id := range expenseIDs {
+		expense, err := u.getOwnedExpense(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		if !expense.Reimbursable {
+			return nil, fmt.Errorf("%w: expense %s is not reimbursable", ErrInvalidClaim, id)
+		}
+		if expense.ClaimStatus != status && !domain.CanMoveClaim(expense.ClaimStatus, status) {
+			return nil, fmt.Errorf("%w: a %s claim can't become %s", ErrInvalidClaim, expense.ClaimStatus, status)
+		}
+		expenses = append(expenses, expense)
+	}
+
+	claims := make([]*Claim, len(expenses))
+	for i, expense := range expenses {
+		if expense.ClaimStatus != status {
+			expense.ClaimStatus = status
+			if err := u.expenseRepo.Update(ctx, expense); err != nil {
+				return nil, fmt.Errorf("failed to update claim: %w", err)
+			}
+		}
+		claims[i] = u.claim(ctx, expense)
+	}
+	return claims, nil
+}
+
+// ListClaims lists the user's claims
+func (u *ReimbursementUseCase) ListClaims(ctx context.Context, req *ListClaimsRequest) (*ClaimsResponse, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	for _, status := range req.Statuses {
+		if !domain.IsClaimStatus(status) {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidClaim, status)
+		}
+	}
+
+	reimbursable := true
+	expenses, err := u.expenseRepo.Filter(ctx, domain.ExpenseFilter{
+		UserID:        req.UserID,
+		From:          req.From,
+		To:            req.To,
+		Reimbursable:  &reimbursable,
+		ClaimStatuses: req.Statuses,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claims: %w", err)
+	}
+
+	resp := &ClaimsResponse{
+		Claims: make([]*Claim, 0, len(expenses)),
+		Totals: make(map[string]float64),
+	}
+	for _, expense := range expenses {
+		claim := u.claim(ctx, expense)
+		resp.Claims = append(resp.Claims, claim)
+		resp.Total += claim.HomeAmount
+		resp.Totals[claim.Status] += claim.HomeAmount
+		if resp.HomeCurrency == "" {
+			resp.HomeCurrency = claim.HomeCurrency
+		}
+	}
+	resp.Count = len(resp.Claims)
+	return resp, nil
+}
+
+// ExportClaimsRequest selects the claims to export. Without statuses the
+// outstanding ones, pending and submitted, are exported. With MarkSubmitted
+// the pending claims exported are marked submitted.
+type ExportClaimsRequest struct {
+	UserID        string
+	Statuses      []string
+	From          *time.Time
+	To            *time.Time
+	MarkSubmitted bool
+}
+
+// ExportClaims writes the claims as CSV to hand to an employer: one row per
+// expense, oldest first, and a total row in home currency
+func (u *ReimbursementUseCase) ExportClaims(ctx context.Context, req *ExportClaimsRequest) ([]byte, error) {
+	statuses := req.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{domain.ClaimStatusPending, domain.ClaimStatusSubmitted}
+	}
+	claims, err := u.ListClaims(ctx, &ListClaimsRequest{UserID: req.UserID, Statuses: statuses, From: req.From, To: req.To})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"Date", "Description", "Category", "Merchant", "Amount", "Currency", "Claimed Amount", "Claimed Currency", "Status", "Expense ID"})
+	for i := len(claims.Claims) - 1; i >= 0; i-- {
+		claim := claims.Claims[i]
+		writer.Write([]string{
+			claim.Date.Format("2006-01-02"),
+			claim.Description,
+			claim.Category,
+			claim.Merchant,
+			csvAmount(claim.Amount),
+			claim.Currency,
+			csvAmount(claim.HomeAmount),
+			claim.HomeCurrency,
+			claim.Status,
+			claim.ExpenseID,
+		})
+	}
+	writer.Write([]string{"", "Total (" + strconv.Itoa(claims.Count) + " expenses)", "", "", "", "", csvAmount(claims.Total), claims.HomeCurrency, "", ""})
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	if req.MarkSubmitted {
+		var pending []string
+		for _, claim := range claims.Claims {
+			if claim.Status == domain.ClaimStatusPending {
+				pending = append(pending, claim.ExpenseID)
+			}
+		}
+		if len(pending) > 0 {
+			if _, err := u.UpdateClaimStatus(ctx, req.UserID, pending, domain.ClaimStatusSubmitted); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// getOwnedExpense returns one of the user's expenses; other users' expenses are not found
+func (u *ReimbursementUseCase) getOwnedExpense(ctx context.Context, userID, expenseID string) (*domain.Expense, error) {
+	if userID == "" || expenseID == "" {
+		return nil, fmt.Errorf("user_id and expense_id are required")
+	}
+	expense, err := u.expenseRepo.GetByID(ctx, expenseID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && expense.UserID != userID) {
+		return nil, fmt.Errorf("expense %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+	return expense, nil
+}
+
+// claim describes the claim of a reimbursable expense
+func (u *ReimbursementUseCase) claim(ctx context.Context, expense *domain.Expense) *Claim {
+	claim := &Claim{
+		ExpenseID:    expense.ID,
+		Date:         expense.ExpenseDate,
+		Description:  expense.Description,
+		Category:     "Uncategorized",
+		Amount:       expense.OriginalAmount,
+		Currency:     expense.Currency,
+		HomeAmount:   expense.HomeAmount,
+		HomeCurrency: expense.HomeCurrency,
+		Status:       expense.ClaimStatus,
+	}
+	if expense.CategoryID != nil {
+		if category, err := u.categoryRepo.GetByID(ctx, *expense.CategoryID); err == nil {
+			claim.Category = category.Name
+		}
+	}
+	if expense.MerchantID != nil && u.merchantRepo != nil {
+		merchant, err := u.merchantRepo.GetByID(ctx, *expense.MerchantID)
+		if err == nil {
+			claim.Merchant = merchant.Name
+		} else if !errors.Is(err, domain.ErrNotFound) {
+			slog.WarnContext(ctx, "Failed to get merchant", "merchant_id", *expense.MerchantID, "error", err)
+		}
+	}
+	return claim
+}
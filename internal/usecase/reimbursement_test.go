@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReimbursementUseCase(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	createUC := NewCreateExpenseUseCase(expenseRepo, NewMockCategoryRepository(), nil, nil, nil, nil, NewMockAIService())
+
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	taxi, err := createUC.Execute(ctx, &CreateRequest{UserID: "user1", Description: "Taxi", Amount: 300, Tags: []string{"#報帳"}, Date: day})
+	require.NoError(t, err)
+	assert.True(t, taxi.Reimbursable)
+	hotel, err := createUC.Execute(ctx, &CreateRequest{UserID: "user1", Description: "Hotel", Amount: 2400, Reimbursable: true, Date: day.AddDate(0, 0, 1)})
+	require.NoError(t, err)
+	lunch, err := createUC.Execute(ctx, &CreateRequest{UserID: "user1", Description: "Lunch", Amount: 120, Date: day.AddDate(0, 0, 2)})
+	require.NoError(t, err)
+	assert.False(t, lunch.Reimbursable)
+
+	uc := NewReimbursementUseCase(expenseRepo, NewMockCategoryRepository())
+
+	claim, err := uc.MarkReimbursable(ctx, "user1", lunch.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ClaimStatusPending, claim.Status)
+	_, err = uc.MarkReimbursable(ctx, "user2", lunch.ID, true)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	claims, err := uc.ListClaims(ctx, &ListClaimsRequest{UserID: "user1"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, claims.Count)
+	assert.Equal(t, 2820.0, claims.Total)
+	assert.Equal(t, "Lunch", claims.Claims[0].Description)
+
+	// Claims move forward, and a paid one is final
+	_, err = uc.UpdateClaimStatus(ctx, "user1", []string{taxi.ID}, domain.ClaimStatusPaid)
+	require.NoError(t, err)
+	_, err = uc.UpdateClaimStatus(ctx, "user1", []string{taxi.ID, hotel.ID}, domain.ClaimStatusPending)
+	assert.ErrorIs(t, err, ErrInvalidClaim)
+	_, err = uc.MarkReimbursable(ctx, "user1", taxi.ID, false)
+	assert.ErrorIs(t, err, ErrInvalidClaim)
+	_, err = uc.UpdateClaimStatus(ctx, "user1", []string{hotel.ID}, "approved")
+	assert.ErrorIs(t, err, ErrInvalidClaim)
+
+	// Unmarking drops the claim
+	_, err = uc.MarkReimbursable(ctx, "user1", lunch.ID, false)
+	require.NoError(t, err)
+	claims, err = uc.ListClaims(ctx, &ListClaimsRequest{UserID: "user1", Statuses: []string{domain.ClaimStatusPending}})
+	require.NoError(t, err)
+	require.Equal(t, 1, claims.Count)
+	assert.Equal(t, hotel.ID, claims.Claims[0].ExpenseID)
+	assert.Equal(t, map[string]float64{domain.ClaimStatusPending: 2400}, claims.Totals)
+
+	// The export has the outstanding claims and marks the pending ones submitted
+	data, err := uc.ExportClaims(ctx, &ExportClaimsRequest{UserID: "user1", MarkSubmitted: true})
+	require.NoError(t, err)
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "Claimed Amount", rows[0][6])
+	assert.Equal(t, []string{"2025-03-11", "Hotel"}, rows[1][:2])
+	assert.Equal(t, "pending", rows[1][8])
+	assert.Equal(t, "Total (1 expenses)", rows[2][1])
+	assert.Equal(t, "2400.00", rows[2][6])
+
+	exported, err := expenseRepo.GetByID(ctx, hotel.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ClaimStatusSubmitted, exported.ClaimStatus)
+}
@@ -0,0 +1,269 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var _ domain.MessageReplier = (*ReplyOutboxUseCase)(nil)
+
+// ErrNoNotifier is returned when a message is pushed to a user whose
+// messenger can't push messages
+var ErrNoNotifier = errors.New("messenger has no notifier")
+
+const (
+	// ReplyOutboxMaxAttempts is how many times a reply is tried before it is marked failed
+	ReplyOutboxMaxAttempts = 5
+
+	// ReplyOutboxRetention is how long delivered and failed replies are kept
+	ReplyOutboxRetention = 7 * 24 * time.Hour
+
+	replyOutboxBatch = 100
+
+	// replyOutboxLease keeps the retry loop away from a reply whose first
+	// attempt is under way; a reply left pending by a crash is sent once it has passed
+	replyOutboxLease = time.Minute
+)
+
+// replyBackoff is the wait before each retry; the last one repeats if
+// ReplyOutboxMaxAttempts outgrows it. Replies answer a message the user just
+// sent, so they are retried sooner than webhooks.
+var replyBackoff = []time.Duration{
+	15 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+}
+
+// ReplyOutboxUseCase records each messenger reply before sending it, and
+// retries the replies that weren't delivered, whether the send failed or the
+// server stopped before it was made. Replies are sent by the replier
+// registered for the messenger, and pushed messages by its notifier.
+type ReplyOutboxUseCase struct {
+	repo domain.ReplyOutboxRepository
+	now  func() time.Time
+
+	mu        sync.RWMutex
+	repliers  map[string]domain.MessageReplier
+	notifiers map[string]domain.PushNotifier
+}
+
+// NewReplyOutboxUseCase creates a new reply outbox use case
+func NewReplyOutboxUseCase(repo domain.ReplyOutboxRepository) *ReplyOutboxUseCase {
+	return &ReplyOutboxUseCase{
+		repo:      repo,
+		now:       time.Now,
+		repliers:  make(map[string]domain.MessageReplier),
+		notifiers: make(map[string]domain.PushNotifier),
+	}
+}
+
+// RegisterReplier sends the replies to messages from the given messenger
+func (u *ReplyOutboxUseCase) RegisterReplier(messenger string, replier domain.MessageReplier) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.repliers[messenger] = replier
+}
+
+// RegisterNotifier sends the messages pushed to users of the given messenger
+func (u *ReplyOutboxUseCase) RegisterNotifier(messenger string, notifier domain.PushNotifier) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.notifiers[messenger] = notifier
+}
+
+// Push records a message that answers none of the user's and sends it with
+// their messenger's notifier, leaving a failed send to RunRetries. It returns
+// ErrNoNotifier, recording nothing, when the messenger has no notifier.
+func (u *ReplyOutboxUseCase) Push(ctx context.Context, userID, messenger, text string) error {
+	return u.PushAt(ctx, userID, messenger, text, u.now())
+}
+
+// PushAt is Push for a message held back until at: one due later is only
+// recorded, and RunRetries sends it once its time comes
+func (u *ReplyOutboxUseCase) PushAt(ctx context.Context, userID, messenger, text string, at time.Time) error {
+	notifier := u.notifier(messenger)
+	if notifier == nil {
+		return ErrNoNotifier
+	}
+
+	now := u.now()
+	held := at.After(now)
+	next := now.Add(replyOutboxLease)
+	if held {
+		next = at
+	}
+	reply := &domain.OutboxReply{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		Messenger:     messenger,
+		Metadata:      "{}",
+		Push:          true,
+		Text:          text,
+		Status:        domain.OutboxReplyPending,
+		NextAttemptAt: &next,
+		CreatedAt:     now,
+	}
+	if err := u.repo.Create(ctx, reply); err != nil {
+		if held {
+			return fmt.Errorf("failed to record pushed message: %w", err)
+		}
+		slog.WarnContext(ctx, "Failed to record pushed message in the outbox, sending it directly", "error", err)
+		return notifier.PushMessage(ctx, userID, text)
+	}
+
+	if !held {
+		u.attempt(ctx, reply, nil)
+	}
+	return nil
+}
+
+// Reply records the reply and sends it, leaving a failed send to RunRetries.
+// A reply that can't be recorded is sent directly instead.
+func (u *ReplyOutboxUseCase) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	if resp == nil || resp.Text == "" {
+		return nil
+	}
+
+	metadata, err := json.Marshal(msg.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode message metadata: %w", err)
+	}
+	now := u.now()
+	lease := now.Add(replyOutboxLease)
+	reply := &domain.OutboxReply{
+		ID:            uuid.New().String(),
+		UserID:        msg.UserID,
+		Messenger:     msg.Source,
+		GroupChatID:   msg.GroupChatID,
+		Metadata:      string(metadata),
+		Text:          resp.Text,
+		Status:        domain.OutboxReplyPending,
+		NextAttemptAt: &lease,
+		CreatedAt:     now,
+	}
+	if err := u.repo.Create(ctx, reply); err != nil {
+		slog.WarnContext(ctx, "Failed to record reply in the outbox, sending it directly", "error", err)
+		replier := u.replier(msg.Source)
+		if replier == nil {
+			return fmt.Errorf("no replier for %s", msg.Source)
+		}
+		return replier.Reply(ctx, msg, resp)
+	}
+
+	u.attempt(ctx, reply, resp)
+	return nil
+}
+
+// RetryDue retries pending replies whose backoff has passed and returns how many were attempted
+func (u *ReplyOutboxUseCase) RetryDue(ctx context.Context) (int, error) {
+	replies, err := u.repo.GetDue(ctx, u.now(), replyOutboxBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due replies: %w", err)
+	}
+	for _, reply := range replies {
+		u.attempt(ctx, reply, nil)
+	}
+	return len(replies), nil
+}
+
+// RunRetries retries undelivered replies once per interval until ctx is done,
+// and removes finished ones older than ReplyOutboxRetention
+func (u *ReplyOutboxUseCase) RunRetries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.RetryDue(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to retry replies", "error", err)
+			}
+			if _, err := u.repo.DeleteFinishedBefore(ctx, u.now().Add(-ReplyOutboxRetention)); err != nil {
+				slog.WarnContext(ctx, "Failed to clean up the reply outbox", "error", err)
+			}
+		}
+	}
+}
+
+// attempt sends the reply once and records the outcome, scheduling a retry
+// after a failure until ReplyOutboxMaxAttempts is reached. Only the text is
+// recorded, so retries go out without the first attempt's buttons.
+func (u *ReplyOutboxUseCase) attempt(ctx context.Context, reply *domain.OutboxReply, resp *domain.MessageResponse) {
+	reply.Attempts++
+	reply.LastError = ""
+
+	err := u.send(ctx, reply, resp)
+	now := u.now()
+	if err == nil {
+		reply.Status = domain.OutboxReplyDelivered
+		reply.NextAttemptAt = nil
+		reply.DeliveredAt = &now
+	} else {
+		reply.LastError = err.Error()
+		if reply.Attempts >= ReplyOutboxMaxAttempts {
+			reply.Status = domain.OutboxReplyFailed
+			reply.NextAttemptAt = nil
+		} else {
+			next := now.Add(replyBackoff[min(reply.Attempts, len(replyBackoff))-1])
+			reply.NextAttemptAt = &next
+		}
+		slog.WarnContext(ctx, "Reply delivery failed", "reply_id", reply.ID, "messenger", reply.Messenger, "attempt", reply.Attempts, "error", err)
+	}
+
+	if err := u.repo.Update(ctx, reply); err != nil {
+		slog.WarnContext(ctx, "Failed to update outbox reply", "reply_id", reply.ID, "error", err)
+	}
+}
+
+// send rebuilds the message the reply answers and hands both to the
+// messenger's replier, with resp or else the recorded text. Pushed messages
+// go to the messenger's notifier instead.
+func (u *ReplyOutboxUseCase) send(ctx context.Context, reply *domain.OutboxReply, resp *domain.MessageResponse) error {
+	if reply.Push {
+		notifier := u.notifier(reply.Messenger)
+		if notifier == nil {
+			return fmt.Errorf("no notifier for %s", reply.Messenger)
+		}
+		return notifier.PushMessage(ctx, reply.UserID, reply.Text)
+	}
+
+	replier := u.replier(reply.Messenger)
+	if replier == nil {
+		return fmt.Errorf("no replier for %s", reply.Messenger)
+	}
+
+	msg := &domain.UserMessage{
+		UserID:      reply.UserID,
+		Source:      reply.Messenger,
+		GroupChatID: reply.GroupChatID,
+	}
+	if err := json.Unmarshal([]byte(reply.Metadata), &msg.Metadata); err != nil {
+		return fmt.Errorf("failed to decode message metadata: %w", err)
+	}
+	if resp == nil {
+		resp = &domain.MessageResponse{Text: reply.Text}
+	}
+	return replier.Reply(ctx, msg, resp)
+}
+
+func (u *ReplyOutboxUseCase) replier(messenger string) domain.MessageReplier {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.repliers[messenger]
+}
+
+func (u *ReplyOutboxUseCase) notifier(messenger string) domain.PushNotifier {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.notifiers[messenger]
+}
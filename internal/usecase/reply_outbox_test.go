@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// flakyReplier fails while err is set and records the messages it answered
+type flakyReplier struct {
+	err     error
+	replies []*domain.UserMessage
+}
+
+func (r *flakyReplier) Reply(ctx context.Context, msg *domain.UserMessage, resp *domain.MessageResponse) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.replies = append(r.replies, msg)
+	return nil
+}
+
+// flakyNotifier fails while err is set and records the messages it pushed
+type flakyNotifier struct {
+	err      error
+	messages []string
+}
+
+func (n *flakyNotifier) PushMessage(ctx context.Context, userID, text string) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.messages = append(n.messages, userID+": "+text)
+	return nil
+}
+
+func TestReplyOutboxUseCase(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := NewMockReplyOutboxRepository()
+	uc := NewReplyOutboxUseCase(repo)
+	uc.now = func() time.Time { return now }
+	replier := &flakyReplier{err: errors.New("telegram api error: status 502")}
+	uc.RegisterReplier("telegram", replier)
+
+	msg := &domain.UserMessage{
+		UserID:   "telegram_42",
+		Source:   "telegram",
+		Metadata: map[string]interface{}{"chat_id": int64(42)},
+	}
+	if err := uc.Reply(ctx, msg, &domain.MessageResponse{Text: "Saved lunch $100"}); err != nil {
+		t.Fatalf("Reply failed: %v", err)
+	}
+	if len(repo.replies) != 1 {
+		t.Fatalf("expected the reply recorded, got %d", len(repo.replies))
+	}
+	var reply *domain.OutboxReply
+	for _, r := range repo.replies {
+		reply = r
+	}
+	if reply.Status != domain.OutboxReplyPending || reply.Attempts != 1 || reply.LastError == "" || !reply.NextAttemptAt.Equal(now.Add(15*time.Second)) {
+		t.Fatalf("expected a retry scheduled after the failed send, got %+v", reply)
+	}
+
+	// Nothing is due before the backoff has passed
+	if attempted, _ := uc.RetryDue(ctx); attempted != 0 {
+		t.Errorf("expected no retries yet, got %d", attempted)
+	}
+
+	now = now.Add(time.Minute)
+	replier.err = nil
+	if attempted, err := uc.RetryDue(ctx); err != nil || attempted != 1 {
+		t.Fatalf("expected one retry, got %d, %v", attempted, err)
+	}
+	reply = repo.replies[reply.ID]
+	if reply.Status != domain.OutboxReplyDelivered || reply.DeliveredAt == nil || reply.NextAttemptAt != nil {
+		t.Errorf("expected the reply delivered, got %+v", reply)
+	}
+	if len(replier.replies) != 1 || replier.replies[0].UserID != "telegram_42" || replier.replies[0].Metadata["chat_id"] != float64(42) {
+		t.Errorf("expected the message rebuilt from the outbox, got %+v", replier.replies)
+	}
+
+	// A reply whose messenger has no replier gives up after the last attempt
+	if err := uc.Reply(ctx, &domain.UserMessage{UserID: "U1", Source: "line"}, &domain.MessageResponse{Text: "Saved"}); err != nil {
+		t.Fatalf("Reply failed: %v", err)
+	}
+	for i := 1; i < ReplyOutboxMaxAttempts; i++ {
+		now = now.Add(time.Hour)
+		uc.RetryDue(ctx)
+	}
+	for _, r := range repo.replies {
+		if r.Messenger == "line" && (r.Status != domain.OutboxReplyFailed || r.Attempts != ReplyOutboxMaxAttempts) {
+			t.Errorf("expected the line reply failed after %d attempts, got %+v", ReplyOutboxMaxAttempts, r)
+		}
+	}
+
+	if deleted, _ := repo.DeleteFinishedBefore(ctx, now.Add(time.Second)); deleted != 2 {
+		t.Errorf("expected both finished replies cleaned up, got %d", deleted)
+	}
+}
+
+func TestReplyOutboxPush(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := NewMockReplyOutboxRepository()
+	uc := NewReplyOutboxUseCase(repo)
+	uc.now = func() time.Time { return now }
+	notifier := &flakyNotifier{err: errors.New("line api error: status 500")}
+	uc.RegisterNotifier("line", notifier)
+
+	if err := uc.Push(ctx, "U1", "teams", "Weekly report"); !errors.Is(err, ErrNoNotifier) {
+		t.Errorf("expected ErrNoNotifier for a messenger without a notifier, got %v", err)
+	}
+	if len(repo.replies) != 0 {
+		t.Fatalf("expected nothing recorded, got %d", len(repo.replies))
+	}
+
+	if err := uc.Push(ctx, "U1", "line", "Weekly report"); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	var reply *domain.OutboxReply
+	for _, r := range repo.replies {
+		reply = r
+	}
+	if reply == nil || !reply.Push || reply.Status != domain.OutboxReplyPending || reply.Attempts != 1 {
+		t.Fatalf("expected the pushed message recorded with a retry scheduled, got %+v", reply)
+	}
+
+	now = now.Add(time.Minute)
+	notifier.err = nil
+	if attempted, err := uc.RetryDue(ctx); err != nil || attempted != 1 {
+		t.Fatalf("expected one retry, got %d, %v", attempted, err)
+	}
+	if reply = repo.replies[reply.ID]; reply.Status != domain.OutboxReplyDelivered {
+		t.Errorf("expected the pushed message delivered, got %+v", reply)
+	}
+	if len(notifier.messages) != 1 || notifier.messages[0] != "U1: Weekly report" {
+		t.Errorf("expected the message pushed to U1, got %v", notifier.messages)
+	}
+}
+
+func TestReplyOutboxPushAt(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 23, 0, 0, 0, time.UTC)
+	repo := NewMockReplyOutboxRepository()
+	uc := NewReplyOutboxUseCase(repo)
+	uc.now = func() time.Time { return now }
+	notifier := &flakyNotifier{}
+	uc.RegisterNotifier("line", notifier)
+
+	morning := time.Date(2025, 3, 11, 8, 0, 0, 0, time.UTC)
+	if err := uc.PushAt(ctx, "U1", "line", "Budget alert", morning); err != nil {
+		t.Fatalf("PushAt failed: %v", err)
+	}
+	if len(notifier.messages) != 0 {
+		t.Fatalf("expected nothing pushed before %v, got %v", morning, notifier.messages)
+	}
+	if attempted, _ := uc.RetryDue(ctx); attempted != 0 {
+		t.Errorf("expected no attempt before %v, got %d", morning, attempted)
+	}
+
+	now = morning
+	if attempted, err := uc.RetryDue(ctx); err != nil || attempted != 1 {
+		t.Fatalf("expected the held message attempted, got %d, %v", attempted, err)
+	}
+	if len(notifier.messages) != 1 || notifier.messages[0] != "U1: Budget alert" {
+		t.Errorf("expected the message pushed to U1, got %v", notifier.messages)
+	}
+}
@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// ReportChartUseCase adds chart images of the spending breakdown to report
+// replies. The images are drawn by the chart endpoint from the amounts in
+// their links, and their legends, which name the categories, are written
+// beside them.
+type ReportChartUseCase struct {
+	reports ExpenseReporter
+	baseURL string
+	now     func() time.Time
+}
+
+// NewReportChartUseCase creates a new report chart use case linking to the
+// chart endpoint at baseURL
+func NewReportChartUseCase(reports ExpenseReporter, baseURL string) *ReportChartUseCase {
+	return &ReportChartUseCase{
+		reports: reports,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		now:     time.Now,
+	}
+}
+
+// chartSlice is a slice or bar of a chart and its line in the legend
+type chartSlice struct {
+	label    string
+	current  float64
+	previous float64
+}
+
+// MonthBreakdown returns a pie chart of the month's spending so far per
+// category, for the user or the group ledger when groupID is set, or nil when
+// nothing was spent
+func (u *ReportChartUseCase) MonthBreakdown(ctx context.Context, userID, groupID string) (*domain.ContentBlock, error) {
+	now := domain.InLocation(ctx, u.now())
+	report, err := u.reports.Execute(ctx, &ReportRequest{
+		UserID:     userID,
+		GroupID:    groupID,
+		ReportType: "monthly",
+		StartDate:  time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()),
+		EndDate:    now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly report: %w", err)
+	}
+	if report.TotalExpenses <= 0 {
+		return nil, nil
+	}
+
+	var slices []chartSlice
+	for _, c := range report.CategoryBreakdown {
+		if c.Total > 0 {
+			slices = append(slices, chartSlice{label: c.Category, current: c.Total})
+		}
+	}
+	slices = topChartSlices(ctx, slices)
+
+	chart := &domain.Chart{Type: domain.ChartPie}
+	legend := make([]string, len(slices))
+	locale := i18n.FromContext(ctx)
+	for i, s := range slices {
+		chart.Values = append(chart.Values, s.current)
+		legend[i] = fmt.Sprintf("%s %s %s (%d%%)", domain.ChartLegendMarks[i], s.label, i18n.FormatNumber(locale, s.current), int(s.current/report.TotalExpenses*100+0.5))
+	}
+	return &domain.ContentBlock{
+		Type:     domain.ContentBlockChart,
+		Title:    translate(ctx, "chart.breakdown"),
+		Text:     strings.Join(legend, "\n"),
+		ImageURL: u.baseURL + chart.Path(),
+	}, nil
+}
+
+// ComparisonChart returns a bar chart of each category's spending in both
+// periods of a comparison, the earlier faded, or nil when nothing was spent
+func (u *ReportChartUseCase) ComparisonChart(ctx context.Context, report *ComparisonReport) *domain.ContentBlock {
+	var slices []chartSlice
+	for _, c := range report.Categories {
+		if c.Current > 0 || c.Previous > 0 {
+			slices = append(slices, chartSlice{label: c.Category, current: c.Current, previous: c.Previous})
+		}
+	}
+	if len(slices) == 0 {
+		return nil
+	}
+	slices = topChartSlices(ctx, slices)
+
+	chart := &domain.Chart{Type: domain.ChartBar}
+	legend := make([]string, len(slices))
+	for i, s := range slices {
+		chart.Values = append(chart.Values, s.current)
+		chart.Previous = append(chart.Previous, s.previous)
+		legend[i] = fmt.Sprintf("%s %s %s", domain.ChartLegendMarks[i], s.label, comparisonTrend(ctx, compareAmounts(s.label, s.current, s.previous)))
+	}
+	return &domain.ContentBlock{
+		Type:     domain.ContentBlockChart,
+		Title:    translate(ctx, "chart.comparison"),
+		Text:     strings.Join(legend, "\n"),
+		ImageURL: u.baseURL + chart.Path(),
+	}
+}
+
+// topChartSlices orders slices largest first, keeping as many as a chart has
+// room for and adding up the rest as one more
+func topChartSlices(ctx context.Context, slices []chartSlice) []chartSlice {
+	sort.SliceStable(slices, func(i, j int) bool {
+		if slices[i].current != slices[j].current {
+			return slices[i].current > slices[j].current
+		}
+		return slices[i].previous > slices[j].previous
+	})
+	if len(slices) <= domain.MaxChartValues {
+		return slices
+	}
+	other := chartSlice{label: translate(ctx, "chart.other")}
+	for _, s := range slices[domain.MaxChartValues-1:] {
+		other.current += s.current
+		other.previous += s.previous
+	}
+	return append(slices[:domain.MaxChartValues-1:domain.MaxChartValues-1], other)
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportChartUseCase_MonthBreakdown(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "en")
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.UTC)
+
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	// Nine categories, one more than a chart has room for
+	for i := 1; i <= 9; i++ {
+		categoryID := fmt.Sprintf("cat%d", i)
+		require.NoError(t, categoryRepo.Create(ctx, &domain.Category{ID: categoryID, UserID: "user1", Name: fmt.Sprintf("Category %d", i)}))
+		require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+			ID:          fmt.Sprintf("e%d", i),
+			UserID:      "user1",
+			Amount:      float64(1000 - i*100),
+			CategoryID:  &categoryID,
+			ExpenseDate: now.AddDate(0, 0, -i),
+		}))
+	}
+	// Last month's spending isn't this month's breakdown
+	food := "cat1"
+	require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{ID: "old", UserID: "user1", Amount: 5000, CategoryID: &food, ExpenseDate: now.AddDate(0, -1, 0)}))
+
+	uc := NewReportChartUseCase(NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil), "https://expense.example.com/")
+	uc.now = func() time.Time { return now }
+
+	block, err := uc.MonthBreakdown(ctx, "user1", "")
+	require.NoError(t, err)
+	require.NotNil(t, block)
+	assert.Equal(t, domain.ContentBlockChart, block.Type)
+	assert.Equal(t, "Spending by category", block.Title)
+	assert.Equal(t, "https://expense.example.com/charts/pie.png?v=900,800,700,600,500,400,300,300", block.ImageURL)
+	assert.Equal(t, "🟥 Category 1 900 (20%)\n🟧 Category 2 800 (18%)\n🟨 Category 3 700 (16%)\n🟩 Category 4 600 (13%)\n"+
+		"🟦 Category 5 500 (11%)\n🟪 Category 6 400 (9%)\n🟫 Category 7 300 (7%)\n⬛ Other 300 (7%)", block.Text)
+
+	block, err = uc.MonthBreakdown(ctx, "user2", "")
+	require.NoError(t, err)
+	assert.Nil(t, block, "nothing spent")
+}
+
+func TestReportChartUseCase_ComparisonChart(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "zh-TW")
+	uc := NewReportChartUseCase(nil, "https://expense.example.com")
+
+	change := func(v float64) *float64 { return &v }
+	block := uc.ComparisonChart(ctx, &ComparisonReport{
+		Mode: CompareModePreviousMonth,
+		Categories: []CategoryComparison{
+			{Category: "餐飲", Current: 1230, Previous: 1000, Delta: 230, Change: change(23)},
+			{Category: "娛樂", Current: 300},
+			{Category: "交通", Current: 0, Previous: 500, Delta: -500, Change: change(-100)},
+		},
+	})
+	require.NotNil(t, block)
+	assert.Equal(t, "同期（淡色）與本期", block.Title)
+	assert.Equal(t, "https://expense.example.com/charts/bar.png?p=1000,0,500&v=1230,300,0", block.ImageURL)
+	assert.Equal(t, "🟥 餐飲 ↑23%\n🟧 娛樂 新增\n🟨 交通 ↓100%", block.Text)
+
+	assert.Nil(t, uc.ComparisonChart(ctx, &ComparisonReport{Mode: CompareModePreviousMonth}))
+}
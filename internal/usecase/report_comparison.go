@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// Report comparison modes
+const (
+	CompareModePreviousMonth = "previous_month" // The month against the month before
+	CompareModeLastYear      = "last_year"      // The month against the same month a year before
+)
+
+// ErrInvalidComparison is returned for an unknown comparison mode
+var ErrInvalidComparison = errors.New("mode must be previous_month or last_year")
+
+// ComparisonRequest asks for a month's spending compared with an earlier one
+type ComparisonRequest struct {
+	UserID  string
+	GroupID string    // Compare a shared group ledger the user belongs to
+	Mode    string    // CompareModePreviousMonth (default) or CompareModeLastYear
+	Month   time.Time // Any time in the month; the current month when zero
+}
+
+// CategoryComparison is a category's spending in both periods. Change is
+// the percentage change, nil when nothing was spent in the earlier period.
+type CategoryComparison struct {
+	Category string   `json:"category"`
+	Current  float64  `json:"current"`
+	Previous float64  `json:"previous"`
+	Delta    float64  `json:"delta"`
+	Change   *float64 `json:"change_percent"`
+}
+
+// ComparisonReport compares a month's spending with an earlier period's,
+// in total and per category, largest current spending first. The current
+// month is compared up to today with the same days of the earlier month.
+type ComparisonReport struct {
+	Mode          string               `json:"mode"`
+	CurrentStart  time.Time            `json:"current_start"`
+	CurrentEnd    time.Time            `json:"current_end"`
+	PreviousStart time.Time            `json:"previous_start"`
+	PreviousEnd   time.Time            `json:"previous_end"`
+	Total         CategoryComparison   `json:"total"`
+	Categories    []CategoryComparison `json:"categories"`
+}
+
+// Compare reports how the month's spending changed from the month before or
+// from the same month last year
+func (u *GenerateReportUseCase) Compare(ctx context.Context, req *ComparisonRequest) (*ComparisonReport, error) {
+	mode := req.Mode
+	if mode == "" {
+		mode = CompareModePreviousMonth
+	}
+	if mode != CompareModePreviousMonth && mode != CompareModeLastYear {
+		return nil, ErrInvalidComparison
+	}
+	month := req.Month
+	if month.IsZero() {
+		month = domain.LocalNow(ctx)
+	}
+	curStart, curEnd, prevStart, prevEnd := comparisonPeriods(mode, month, domain.LocalNow(ctx))
+
+	current, err := u.Execute(ctx, &ReportRequest{UserID: req.UserID, GroupID: req.GroupID, ReportType: "monthly", StartDate: curStart, EndDate: curEnd})
+	if err != nil {
+		return nil, err
+	}
+	previous, err := u.Execute(ctx, &ReportRequest{UserID: req.UserID, GroupID: req.GroupID, ReportType: "monthly", StartDate: prevStart, EndDate: prevEnd})
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*CategoryComparison)
+	var names []string
+	category := func(name string) *CategoryComparison {
+		if byName[name] == nil {
+			byName[name] = &CategoryComparison{Category: name}
+			names = append(names, name)
+		}
+		return byName[name]
+	}
+	for _, c := range current.CategoryBreakdown {
+		category(c.Category).Current += c.Total
+	}
+	for _, c := range previous.CategoryBreakdown {
+		category(c.Category).Previous += c.Total
+	}
+
+	categories := make([]CategoryComparison, len(names))
+	for i, name := range names {
+		categories[i] = compareAmounts(name, byName[name].Current, byName[name].Previous)
+	}
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].Current != categories[j].Current {
+			return categories[i].Current > categories[j].Current
+		}
+		return categories[i].Previous > categories[j].Previous
+	})
+
+	return &ComparisonReport{
+		Mode:          mode,
+		CurrentStart:  curStart,
+		CurrentEnd:    curEnd,
+		PreviousStart: prevStart,
+		PreviousEnd:   prevEnd,
+		Total:         compareAmounts("", current.TotalExpenses, previous.TotalExpenses),
+		Categories:    categories,
+	}, nil
+}
+
+// comparisonPeriods returns the month containing month and the period it is
+// compared with. A month still under way ends today, and so does the same
+// stretch of the earlier month, as far as that month goes.
+func comparisonPeriods(mode string, month, now time.Time) (curStart, curEnd, prevStart, prevEnd time.Time) {
+	curStart = time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	next := curStart.AddDate(0, 1, 0)
+	if mode == CompareModeLastYear {
+		prevStart = curStart.AddDate(-1, 0, 0)
+	} else {
+		prevStart = curStart.AddDate(0, -1, 0)
+	}
+	prevNext := prevStart.AddDate(0, 1, 0)
+
+	curEnd, prevEnd = next.Add(-time.Nanosecond), prevNext.Add(-time.Nanosecond)
+	if now = now.In(month.Location()); !now.Before(curStart) && now.Before(next) {
+		curEnd = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).Add(-time.Nanosecond)
+		if sameDays := prevStart.AddDate(0, 0, now.Day()); sameDays.Before(prevNext) {
+			prevEnd = sameDays.Add(-time.Nanosecond)
+		}
+	}
+	return curStart, curEnd, prevStart, prevEnd
+}
+
+// compareAmounts compares a category's spending, rounding the percentage
+// change to one decimal
+func compareAmounts(category string, current, previous float64) CategoryComparison {
+	c := CategoryComparison{Category: category, Current: current, Previous: previous, Delta: current - previous}
+	if previous != 0 {
+		change := math.Round((current-previous)/previous*1000) / 10
+		c.Change = &change
+	}
+	return c
+}
+
+// FormatComparison writes the comparison compactly for a messenger, e.g.
+// "餐飲 ↑23%, 交通 ↓10%", in the locale carried by ctx
+func FormatComparison(ctx context.Context, report *ComparisonReport) string {
+	if report.Total.Current == 0 && report.Total.Previous == 0 {
+		return translate(ctx, "compare.none")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(ctx, "compare."+report.Mode))
+	locale := i18n.FromContext(ctx)
+	sb.WriteString("\n" + translate(ctx, "compare.total",
+		i18n.FormatNumber(locale, report.Total.Current),
+		comparisonTrend(ctx, report.Total),
+		i18n.FormatNumber(locale, report.Total.Previous)))
+
+	trends := make([]string, 0, len(report.Categories))
+	for _, c := range report.Categories {
+		trends = append(trends, c.Category+" "+comparisonTrend(ctx, c))
+	}
+	if len(trends) > 0 {
+		sb.WriteString("\n" + strings.Join(trends, ", "))
+	}
+	return sb.String()
+}
+
+// comparisonTrend describes a change, e.g. "↑23%", or "new" for spending
+// the earlier period didn't have
+func comparisonTrend(ctx context.Context, c CategoryComparison) string {
+	if c.Change == nil {
+		if c.Current == 0 {
+			return "±0%"
+		}
+		return translate(ctx, "weekly.new")
+	}
+	change := math.Round(*c.Change)
+	locale := i18n.FromContext(ctx)
+	switch {
+	case change > 0:
+		return "↑" + i18n.FormatNumber(locale, change) + "%"
+	case change < 0:
+		return "↓" + i18n.FormatNumber(locale, -change) + "%"
+	default:
+		return "±0%"
+	}
+}
+
+// comparisonMode returns the comparison a 比較 message asks for: with last
+// year when it mentions it, otherwise with the month before
+func comparisonMode(argument string) string {
+	argument = strings.ToLower(argument)
+	for _, word := range []string{"去年", "year"} {
+		if strings.Contains(argument, word) {
+			return CompareModeLastYear
+		}
+	}
+	return CompareModePreviousMonth
+}
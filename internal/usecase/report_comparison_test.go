@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComparisonPeriods(t *testing.T) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	endOf := func(year int, month time.Month, d int) time.Time {
+		return day(year, month, d+1).Add(-time.Nanosecond)
+	}
+
+	tests := []struct {
+		name      string
+		mode      string
+		month     time.Time
+		now       time.Time
+		curEnd    time.Time
+		prevStart time.Time
+		prevEnd   time.Time
+	}{
+		{
+			name:      "past month against the whole month before",
+			mode:      CompareModePreviousMonth,
+			month:     day(2026, 2, 10),
+			now:       day(2026, 5, 4),
+			curEnd:    endOf(2026, 2, 28),
+			prevStart: day(2026, 1, 1),
+			prevEnd:   endOf(2026, 1, 31),
+		},
+		{
+			name:      "current month against the same days of the month before",
+			mode:      CompareModePreviousMonth,
+			month:     day(2026, 3, 1),
+			now:       time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC),
+			curEnd:    endOf(2026, 3, 10),
+			prevStart: day(2026, 2, 1),
+			prevEnd:   endOf(2026, 2, 10),
+		},
+		{
+			name:      "days the month before doesn't have",
+			mode:      CompareModePreviousMonth,
+			month:     day(2026, 3, 30),
+			now:       day(2026, 3, 30),
+			curEnd:    endOf(2026, 3, 30),
+			prevStart: day(2026, 2, 1),
+			prevEnd:   endOf(2026, 2, 28),
+		},
+		{
+			name:      "current month against last year",
+			mode:      CompareModeLastYear,
+			month:     day(2026, 3, 1),
+			now:       day(2026, 3, 10),
+			curEnd:    endOf(2026, 3, 10),
+			prevStart: day(2025, 3, 1),
+			prevEnd:   endOf(2025, 3, 10),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curStart, curEnd, prevStart, prevEnd := comparisonPeriods(tt.mode, tt.month, tt.now)
+			assert.Equal(t, day(tt.month.Year(), tt.month.Month(), 1), curStart)
+			assert.Equal(t, tt.curEnd, curEnd)
+			assert.Equal(t, tt.prevStart, prevStart)
+			assert.Equal(t, tt.prevEnd, prevEnd)
+		})
+	}
+}
+
+func TestGenerateReportUseCase_Compare(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "zh-TW")
+
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	require.NoError(t, categoryRepo.Create(ctx, &domain.Category{ID: "cat_food", UserID: "user1", Name: "餐飲"}))
+	require.NoError(t, categoryRepo.Create(ctx, &domain.Category{ID: "cat_transport", UserID: "user1", Name: "交通"}))
+	require.NoError(t, categoryRepo.Create(ctx, &domain.Category{ID: "cat_fun", UserID: "user1", Name: "娛樂"}))
+
+	food, transport, fun := "cat_food", "cat_transport", "cat_fun"
+	expenses := []*domain.Expense{
+		{ID: "e1", Amount: 1230, CategoryID: &food, ExpenseDate: time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)},
+		{ID: "e2", Amount: 450, CategoryID: &transport, ExpenseDate: time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC)},
+		{ID: "e3", Amount: 300, CategoryID: &fun, ExpenseDate: time.Date(2025, 2, 28, 12, 0, 0, 0, time.UTC)},
+		{ID: "e4", Amount: 1000, CategoryID: &food, ExpenseDate: time.Date(2025, 1, 5, 12, 0, 0, 0, time.UTC)},
+		{ID: "e5", Amount: 500, CategoryID: &transport, ExpenseDate: time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{ID: "e6", Amount: 800, CategoryID: &food, ExpenseDate: time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, expense := range expenses {
+		expense.UserID = "user1"
+		require.NoError(t, expenseRepo.Create(ctx, expense))
+	}
+	uc := NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil)
+	month := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Previous month", func(t *testing.T) {
+		report, err := uc.Compare(ctx, &ComparisonRequest{UserID: "user1", Month: month})
+		require.NoError(t, err)
+		assert.Equal(t, CompareModePreviousMonth, report.Mode)
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), report.PreviousStart)
+		assert.Equal(t, 1980.0, report.Total.Current)
+		assert.Equal(t, 1500.0, report.Total.Previous)
+		assert.Equal(t, 480.0, report.Total.Delta)
+		require.NotNil(t, report.Total.Change)
+		assert.Equal(t, 32.0, *report.Total.Change)
+
+		require.Len(t, report.Categories, 3)
+		assert.Equal(t, "餐飲", report.Categories[0].Category)
+		assert.Equal(t, 230.0, report.Categories[0].Delta)
+		assert.Equal(t, 23.0, *report.Categories[0].Change)
+		assert.Equal(t, "交通", report.Categories[1].Category)
+		assert.Equal(t, -50.0, report.Categories[1].Delta)
+		assert.Equal(t, -10.0, *report.Categories[1].Change)
+		assert.Equal(t, "娛樂", report.Categories[2].Category)
+		assert.Nil(t, report.Categories[2].Change, "nothing to compare with")
+
+		assert.Equal(t, "📊 本月至今與上月同期相比\n總計：1,980 ↑32%（同期：1,500）\n餐飲 ↑23%, 交通 ↓10%, 娛樂 新增", FormatComparison(ctx, report))
+	})
+
+	t.Run("Last year", func(t *testing.T) {
+		report, err := uc.Compare(ctx, &ComparisonRequest{UserID: "user1", Mode: CompareModeLastYear, Month: month})
+		require.NoError(t, err)
+		assert.Equal(t, 800.0, report.Total.Previous)
+		assert.Equal(t, 800.0, report.Categories[0].Previous)
+		assert.Equal(t, 53.8, *report.Categories[0].Change)
+		assert.Zero(t, report.Categories[1].Previous)
+	})
+
+	t.Run("Nothing to compare", func(t *testing.T) {
+		report, err := uc.Compare(ctx, &ComparisonRequest{UserID: "user2", Month: month})
+		require.NoError(t, err)
+		assert.Empty(t, report.Categories)
+		assert.Equal(t, "目前還沒有可以比較的支出。", FormatComparison(ctx, report))
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		_, err := uc.Compare(ctx, &ComparisonRequest{UserID: "user1", Mode: "last_week"})
+		assert.ErrorIs(t, err, ErrInvalidComparison)
+	})
+}
+
+func TestComparisonMode(t *testing.T) {
+	assert.Equal(t, CompareModePreviousMonth, comparisonMode(""))
+	assert.Equal(t, CompareModePreviousMonth, comparisonMode("上個月"))
+	assert.Equal(t, CompareModeLastYear, comparisonMode("去年"))
+	assert.Equal(t, CompareModeLastYear, comparisonMode("Last Year"))
+}
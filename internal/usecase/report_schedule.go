@@ -0,0 +1,343 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// reportSendHour is the local hour scheduled reports go out, the morning after the period ends
+const reportSendHour = 8
+
+// StatementExporter renders a user's expenses as a PDF statement
+type StatementExporter interface {
+	ExportAsPDF(ctx context.Context, req *ExportRequest) ([]byte, error)
+}
+
+// ReportScheduleUpdate changes a user's emailed report schedule; nil fields keep their value
+type ReportScheduleUpdate struct {
+	Enabled   *bool   `json:"enabled,omitempty"`
+	Email     *string `json:"email,omitempty"`
+	Frequency *string `json:"frequency,omitempty"` // "weekly" or "monthly"
+	Format    *string `json:"format,omitempty"`    // "html" or "pdf"
+}
+
+// ReportScheduleUseCase emails users a summary of their spending after each
+// week or month they opted into, as HTML or with the PDF statement attached
+type ReportScheduleUseCase struct {
+	scheduleRepo domain.ReportScheduleRepository
+	reports      ExpenseReporter
+	exporter     StatementExporter
+	sender       domain.EmailSender
+	timezones    TimezoneLocator
+	now          func() time.Time
+}
+
+// TimezoneLocator returns the timezone a user's dates are read in
+type TimezoneLocator interface {
+	Locate(ctx context.Context, userID, reported string) *time.Location
+}
+
+// NewReportScheduleUseCase creates a new report schedule use case
+func NewReportScheduleUseCase(
+	scheduleRepo domain.ReportScheduleRepository,
+	reports ExpenseReporter,
+	exporter StatementExporter,
+	sender domain.EmailSender,
+) *ReportScheduleUseCase {
+	return &ReportScheduleUseCase{
+		scheduleRepo: scheduleRepo,
+		reports:      reports,
+		exporter:     exporter,
+		sender:       sender,
+		now:          time.Now,
+	}
+}
+
+// SetTimezoneLocator sends reports in the morning of each user's timezone, for
+// the weeks and months in it, instead of the server's
+func (u *ReportScheduleUseCase) SetTimezoneLocator(timezones TimezoneLocator) {
+	u.timezones = timezones
+}
+
+// inUserTimezone returns t in the timezone the user's reports are scheduled in
+func (u *ReportScheduleUseCase) inUserTimezone(ctx context.Context, userID string, t time.Time) time.Time {
+	if u.timezones == nil {
+		return t
+	}
+	return t.In(u.timezones.Locate(ctx, userID, ""))
+}
+
+// GetSchedule returns the user's schedule, or nil if they never set one up
+func (u *ReportScheduleUseCase) GetSchedule(ctx context.Context, userID string) (*domain.ReportSchedule, error) {
+	schedule, err := u.scheduleRepo.GetByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// UpdateSchedule creates or changes the user's schedule. A new schedule sends
+// weekly HTML reports unless the update says otherwise.
+func (u *ReportScheduleUseCase) UpdateSchedule(ctx context.Context, userID string, update *ReportScheduleUpdate) (*domain.ReportSchedule, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	schedule, err := u.GetSchedule(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := u.now()
+	reschedule := false
+	if schedule == nil {
+		schedule = &domain.ReportSchedule{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			Frequency: domain.ReportFrequencyWeekly,
+			Format:    domain.ReportFormatHTML,
+			Enabled:   true,
+			CreatedAt: now,
+		}
+		reschedule = true
+	}
+
+	if update.Email != nil {
+		schedule.Email = strings.TrimSpace(*update.Email)
+	}
+	if update.Frequency != nil && *update.Frequency != schedule.Frequency {
+		schedule.Frequency = strings.ToLower(*update.Frequency)
+		reschedule = true
+	}
+	if update.Format != nil {
+		schedule.Format = strings.ToLower(*update.Format)
+	}
+	if update.Enabled != nil {
+		reschedule = reschedule || (*update.Enabled && !schedule.Enabled)
+		schedule.Enabled = *update.Enabled
+	}
+
+	switch schedule.Frequency {
+	case domain.ReportFrequencyWeekly, domain.ReportFrequencyMonthly:
+	default:
+		return nil, fmt.Errorf("frequency must be weekly or monthly")
+	}
+	switch schedule.Format {
+	case domain.ReportFormatHTML, domain.ReportFormatPDF:
+	default:
+		return nil, fmt.Errorf("format must be html or pdf")
+	}
+	if schedule.Enabled {
+		if _, err := mail.ParseAddress(schedule.Email); err != nil {
+			return nil, fmt.Errorf("a valid email address is required")
+		}
+	}
+
+	if reschedule {
+		schedule.NextRunAt = nextReportRun(schedule.Frequency, u.inUserTimezone(ctx, userID, now))
+	}
+	schedule.UpdatedAt = now
+	if err := u.scheduleRepo.Save(ctx, schedule); err != nil {
+		return nil, fmt.Errorf("failed to save report schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// SendDue emails every report that is due and returns how many were sent. A
+// report that fails is retried on the next run.
+func (u *ReportScheduleUseCase) SendDue(ctx context.Context) (int, error) {
+	now := u.now()
+	schedules, err := u.scheduleRepo.GetDue(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due report schedules: %w", err)
+	}
+
+	sent := 0
+	for _, schedule := range schedules {
+		if err := u.send(ctx, schedule); err != nil {
+			slog.WarnContext(ctx, "Failed to email report", "frequency", schedule.Frequency, "user_id", schedule.UserID, "error", err)
+			continue
+		}
+		sent++
+
+		schedule.LastSentAt = &now
+		schedule.NextRunAt = nextReportRun(schedule.Frequency, u.inUserTimezone(ctx, schedule.UserID, now))
+		schedule.UpdatedAt = now
+		if err := u.scheduleRepo.Save(ctx, schedule); err != nil {
+			slog.ErrorContext(ctx, "Failed to advance report schedule", "user_id", schedule.UserID, "error", err)
+		}
+	}
+	return sent, nil
+}
+
+// RunScheduler sends due reports once per interval until ctx is done
+func (u *ReportScheduleUseCase) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.SendDue(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to send scheduled reports", "error", err)
+			}
+		}
+	}
+}
+
+// send emails the report for the period that ended before the schedule's run
+func (u *ReportScheduleUseCase) send(ctx context.Context, schedule *domain.ReportSchedule) error {
+	start, end := reportPeriod(schedule.Frequency, u.inUserTimezone(ctx, schedule.UserID, schedule.NextRunAt))
+	report, err := u.reports.Execute(ctx, &ReportRequest{
+		UserID:     schedule.UserID,
+		ReportType: schedule.Frequency,
+		StartDate:  start,
+		EndDate:    end,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate report: %w", err)
+	}
+
+	label := statementPeriodLabel(start, end)
+	msg := &domain.EmailMessage{
+		To:       schedule.Email,
+		Subject:  fmt.Sprintf("Your %s spending summary: %s", schedule.Frequency, label),
+		TextBody: reportEmailText(label, report),
+	}
+	if msg.HTMLBody, err = reportEmailHTML(label, report); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+
+	if schedule.Format == domain.ReportFormatPDF {
+		pdf, err := u.exporter.ExportAsPDF(ctx, &ExportRequest{UserID: schedule.UserID, StartDate: start, EndDate: end})
+		if err != nil {
+			return fmt.Errorf("failed to export statement: %w", err)
+		}
+		name := start.Format("2006-01-02")
+		if schedule.Frequency == domain.ReportFrequencyMonthly {
+			name = start.Format("2006-01")
+		}
+		msg.Attachments = append(msg.Attachments, domain.EmailAttachment{
+			Filename:    "statement-" + name + ".pdf",
+			ContentType: "application/pdf",
+			Data:        pdf,
+		})
+	}
+
+	return u.sender.Send(ctx, msg)
+}
+
+// nextReportRun returns the first send time after now: the next Monday
+// morning for weekly reports, the morning of the 1st for monthly ones
+func nextReportRun(frequency string, now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), reportSendHour, 0, 0, 0, now.Location())
+	if frequency == domain.ReportFrequencyMonthly {
+		next := time.Date(now.Year(), now.Month(), 1, reportSendHour, 0, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 1, 0)
+		}
+		return next
+	}
+
+	next := today.AddDate(0, 0, (8-int(today.Weekday()))%7)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 7)
+	}
+	return next
+}
+
+// reportPeriod returns the week (Monday to Sunday) or calendar month before runAt
+func reportPeriod(frequency string, runAt time.Time) (time.Time, time.Time) {
+	day := time.Date(runAt.Year(), runAt.Month(), runAt.Day(), 0, 0, 0, 0, runAt.Location())
+	if frequency == domain.ReportFrequencyMonthly {
+		month := time.Date(runAt.Year(), runAt.Month(), 1, 0, 0, 0, 0, runAt.Location())
+		return month.AddDate(0, -1, 0), month.Add(-time.Nanosecond)
+	}
+	monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, -7), monday.Add(-time.Nanosecond)
+}
+
+// reportCategories returns the report's categories, largest total first
+func reportCategories(report *ExpenseReport) []CategoryBreakdown {
+	categories := append([]CategoryBreakdown(nil), report.CategoryBreakdown...)
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Total != categories[j].Total {
+			return categories[i].Total > categories[j].Total
+		}
+		return categories[i].Category < categories[j].Category
+	})
+	return categories
+}
+
+// reportEmailText is the plain text body of a report email; emails are in
+// English, like the HTML template
+func reportEmailText(label string, report *ExpenseReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Spending summary for %s\n\n", label)
+	fmt.Fprintf(&sb, "Total: %s across %s\n", formatAmount(roundCents(report.TotalExpenses)), pluralizeExpenses("en", report.TransactionCount))
+	for _, category := range reportCategories(report) {
+		fmt.Fprintf(&sb, "- %s: %s (%.0f%%)\n", category.Category, formatAmount(roundCents(category.Total)), category.Percentage)
+	}
+	return sb.String()
+}
+
+var reportEmailTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: Helvetica, Arial, sans-serif; color: #222;">
+<h2 style="margin-bottom: 4px;">Spending summary</h2>
+<p style="margin-top: 0; color: #666;">{{.Label}}</p>
+<p style="font-size: 18px;"><b>{{.Total}}</b> across {{.Count}}</p>
+{{if .Categories}}<table cellpadding="6" style="border-collapse: collapse;">
+<tr style="border-bottom: 1px solid #ccc; text-align: left;"><th>Category</th><th style="text-align: right;">Expenses</th><th style="text-align: right;">Amount</th><th style="text-align: right;">Share</th></tr>
+{{range .Categories}}<tr><td>{{.Category}}</td><td style="text-align: right;">{{.Count}}</td><td style="text-align: right;">{{.Total}}</td><td style="text-align: right;">{{.Share}}</td></tr>
+{{end}}</table>{{else}}<p>No expenses recorded in this period.</p>{{end}}
+</body>
+</html>
+`))
+
+func reportEmailHTML(label string, report *ExpenseReport) (string, error) {
+	type row struct {
+		Category string
+		Count    int
+		Total    string
+		Share    string
+	}
+	data := struct {
+		Label      string
+		Total      string
+		Count      string
+		Categories []row
+	}{
+		Label: label,
+		Total: formatAmount(roundCents(report.TotalExpenses)),
+		Count: pluralizeExpenses("en", report.TransactionCount),
+	}
+	for _, category := range reportCategories(report) {
+		data.Categories = append(data.Categories, row{
+			Category: category.Category,
+			Count:    category.Count,
+			Total:    formatAmount(roundCents(category.Total)),
+			Share:    fmt.Sprintf("%.0f%%", category.Percentage),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := reportEmailTemplate.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+type fakeEmailSender struct {
+	sent []*domain.EmailMessage
+	err  error
+}
+
+func (f *fakeEmailSender) Send(ctx context.Context, msg *domain.EmailMessage) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func newTestReportScheduleUseCase(now time.Time) (*ReportScheduleUseCase, *MockReportScheduleRepository, *MockExpenseRepository, *fakeEmailSender) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	categoryRepo.Create(ctx, &domain.Category{ID: "cat_food", UserID: "user1", Name: "Food"})
+	categoryRepo.Create(ctx, &domain.Category{ID: "cat_transport", UserID: "user1", Name: "交通"})
+
+	scheduleRepo := NewMockReportScheduleRepository()
+	sender := &fakeEmailSender{}
+	uc := NewReportScheduleUseCase(
+		scheduleRepo,
+		NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil),
+		NewDataExportUseCase(expenseRepo, categoryRepo),
+		sender,
+	)
+	uc.now = func() time.Time { return now }
+	return uc, scheduleRepo, expenseRepo, sender
+}
+
+func TestNextReportRun(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	monday := time.Date(2026, 3, 9, 7, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		frequency string
+		now       time.Time
+		want      time.Time
+	}{
+		{domain.ReportFrequencyWeekly, now, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+		{domain.ReportFrequencyWeekly, monday, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)},
+		{domain.ReportFrequencyWeekly, monday.Add(time.Hour), time.Date(2026, 3, 16, 8, 0, 0, 0, time.UTC)},
+		{domain.ReportFrequencyMonthly, now, time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)},
+		{domain.ReportFrequencyMonthly, time.Date(2026, 12, 1, 9, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := nextReportRun(tt.frequency, tt.now); !got.Equal(tt.want) {
+			t.Errorf("nextReportRun(%s, %v) = %v, want %v", tt.frequency, tt.now, got, tt.want)
+		}
+	}
+
+	start, end := reportPeriod(domain.ReportFrequencyWeekly, time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)) {
+		t.Errorf("unexpected weekly period %v - %v", start, end)
+	}
+	start, end = reportPeriod(domain.ReportFrequencyMonthly, time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC))
+	if !start.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)) {
+		t.Errorf("unexpected monthly period %v - %v", start, end)
+	}
+}
+
+func TestReportScheduleUseCase_UpdateSchedule(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	uc, _, _, _ := newTestReportScheduleUseCase(now)
+	str := func(s string) *string { return &s }
+
+	if _, err := uc.UpdateSchedule(ctx, "user1", &ReportScheduleUpdate{}); err == nil {
+		t.Error("expected an email address to be required")
+	}
+	if _, err := uc.UpdateSchedule(ctx, "user1", &ReportScheduleUpdate{Email: str("user@example.com"), Frequency: str("daily")}); err == nil {
+		t.Error("expected an unknown frequency to be rejected")
+	}
+
+	schedule, err := uc.UpdateSchedule(ctx, "user1", &ReportScheduleUpdate{Email: str(" user@example.com ")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schedule.Email != "user@example.com" || schedule.Frequency != domain.ReportFrequencyWeekly || schedule.Format != domain.ReportFormatHTML || !schedule.Enabled {
+		t.Errorf("unexpected defaults: %+v", schedule)
+	}
+	if !schedule.NextRunAt.Equal(time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the first report next Monday, got %v", schedule.NextRunAt)
+	}
+
+	schedule, err = uc.UpdateSchedule(ctx, "user1", &ReportScheduleUpdate{Frequency: str("monthly"), Format: str("pdf")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schedule.Email != "user@example.com" || schedule.Format != domain.ReportFormatPDF {
+		t.Errorf("expected unchanged fields to be kept: %+v", schedule)
+	}
+	if !schedule.NextRunAt.Equal(time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected a monthly report on the 1st, got %v", schedule.NextRunAt)
+	}
+
+	disabled := false
+	schedule, err = uc.UpdateSchedule(ctx, "user1", &ReportScheduleUpdate{Enabled: &disabled})
+	if err != nil || schedule.Enabled {
+		t.Errorf("expected the schedule to be disabled, got %+v, %v", schedule, err)
+	}
+}
+
+func TestReportScheduleUseCase_UserTimezone(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	uc, _, _, _ := newTestReportScheduleUseCase(now)
+	userRepo := NewMockUserRepository()
+	userRepo.Create(ctx, &domain.User{UserID: "user1", Timezone: "Asia/Taipei"})
+	uc.SetTimezoneLocator(NewTimezoneUseCase(userRepo))
+
+	// Monday 8:00 in Taipei is midnight UTC
+	email := "user@example.com"
+	schedule, err := uc.UpdateSchedule(ctx, "user1", &ReportScheduleUpdate{Email: &email})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !schedule.NextRunAt.Equal(time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the first report Monday morning in Taipei, got %v", schedule.NextRunAt)
+	}
+}
+
+func TestReportScheduleUseCase_SendDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	uc, scheduleRepo, expenseRepo, sender := newTestReportScheduleUseCase(now)
+
+	food, transport := "cat_food", "cat_transport"
+	for _, expense := range []*domain.Expense{
+		{ID: "e1", Description: "午餐", Amount: 100, CategoryID: &food, ExpenseDate: time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)},
+		{ID: "e2", Description: "計程車", Amount: 300, CategoryID: &transport, ExpenseDate: time.Date(2026, 3, 8, 22, 0, 0, 0, time.UTC)},
+		{ID: "e3", Description: "上週的晚餐", Amount: 500, CategoryID: &food, ExpenseDate: time.Date(2026, 3, 1, 19, 0, 0, 0, time.UTC)},
+	} {
+		expense.UserID = "user1"
+		expenseRepo.Create(ctx, expense)
+	}
+
+	email := "user@example.com"
+	if _, err := uc.UpdateSchedule(ctx, "user1", &ReportScheduleUpdate{Email: &email}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nothing is due before Monday morning
+	if sent, err := uc.SendDue(ctx); err != nil || sent != 0 {
+		t.Fatalf("expected nothing to be sent yet, got %d, %v", sent, err)
+	}
+
+	sendAt := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	uc.now = func() time.Time { return sendAt }
+	sender.err = errors.New("connection refused")
+	if sent, _ := uc.SendDue(ctx); sent != 0 {
+		t.Fatalf("expected a failed send not to count, got %d", sent)
+	}
+	if schedule, _ := scheduleRepo.GetByUserID(ctx, "user1"); schedule.LastSentAt != nil {
+		t.Fatal("expected a failed report to be retried")
+	}
+
+	sender.err = nil
+	if sent, err := uc.SendDue(ctx); err != nil || sent != 1 {
+		t.Fatalf("expected one report to be sent, got %d, %v", sent, err)
+	}
+	msg := sender.sent[0]
+	if msg.To != email || !strings.Contains(msg.Subject, "2026-03-02 to 2026-03-08") {
+		t.Errorf("unexpected message: to=%s subject=%s", msg.To, msg.Subject)
+	}
+	for _, want := range []string{"Total: 400 across 2 expenses", "- 交通: 300 (75%)", "- Food: 100 (25%)"} {
+		if !strings.Contains(msg.TextBody, want) {
+			t.Errorf("expected text body to contain %q, got:\n%s", want, msg.TextBody)
+		}
+	}
+	if !strings.Contains(msg.HTMLBody, "<td>交通</td>") || len(msg.Attachments) != 0 {
+		t.Errorf("expected an HTML summary without attachments")
+	}
+
+	schedule, _ := scheduleRepo.GetByUserID(ctx, "user1")
+	if schedule.LastSentAt == nil || !schedule.NextRunAt.Equal(time.Date(2026, 3, 16, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the schedule to advance a week, got %+v", schedule)
+	}
+	if sent, _ := uc.SendDue(ctx); sent != 0 {
+		t.Errorf("expected the report to be sent once, got %d more", sent)
+	}
+}
+
+func TestReportScheduleUseCase_SendDuePDF(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 2, 20, 10, 0, 0, 0, time.UTC)
+	uc, _, expenseRepo, sender := newTestReportScheduleUseCase(now)
+
+	food := "cat_food"
+	expenseRepo.Create(ctx, &domain.Expense{ID: "e1", UserID: "user1", Description: "午餐", Amount: 120, CategoryID: &food, ExpenseDate: now})
+
+	email, frequency, format := "user@example.com", "monthly", "pdf"
+	if _, err := uc.UpdateSchedule(ctx, "user1", &ReportScheduleUpdate{Email: &email, Frequency: &frequency, Format: &format}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	uc.now = func() time.Time { return time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC) }
+	if sent, err := uc.SendDue(ctx); err != nil || sent != 1 {
+		t.Fatalf("expected one report to be sent, got %d, %v", sent, err)
+	}
+	msg := sender.sent[0]
+	if !strings.Contains(msg.Subject, "February 2026") {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Filename != "statement-2026-02.pdf" || !bytes.HasPrefix(msg.Attachments[0].Data, []byte("%PDF-")) {
+		t.Errorf("expected the monthly PDF statement to be attached, got %+v", msg.Attachments)
+	}
+}
+
+func TestNotificationUseCase_EmailReportPreferences(t *testing.T) {
+	ctx := context.Background()
+	uc := NewNotificationUseCase()
+	email := "user@example.com"
+
+	if _, err := uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", EmailReport: &ReportScheduleUpdate{Email: &email}}); err == nil {
+		t.Error("expected email reports to fail when email is not configured")
+	}
+
+	scheduler, _, _, _ := newTestReportScheduleUseCase(time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC))
+	uc.SetReportScheduler(scheduler)
+
+	resp, err := uc.GetPreferences(ctx, &GetPreferencesRequest{UserID: "user1"})
+	if err != nil || resp.Preferences.EmailReport != nil {
+		t.Fatalf("expected no email report before opting in, got %+v, %v", resp, err)
+	}
+
+	updated, err := uc.UpdatePreferences(ctx, &UpdatePreferencesRequest{UserID: "user1", EmailReport: &ReportScheduleUpdate{Email: &email}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Preferences.EmailReport == nil || updated.Preferences.EmailReport.Email != email {
+		t.Errorf("expected the schedule in the response, got %+v", updated.Preferences.EmailReport)
+	}
+
+	resp, _ = uc.GetPreferences(ctx, &GetPreferencesRequest{UserID: "user1"})
+	if resp.Preferences.EmailReport == nil || resp.Preferences.EmailReport.Frequency != domain.ReportFrequencyWeekly {
+		t.Errorf("expected the saved schedule, got %+v", resp.Preferences.EmailReport)
+	}
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SchedulerMonitor starts the background schedulers and tracks which are
+// running, so the readiness check can tell when one has stopped
+type SchedulerMonitor struct {
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// NewSchedulerMonitor creates a new scheduler monitor
+func NewSchedulerMonitor() *SchedulerMonitor {
+	return &SchedulerMonitor{running: make(map[string]bool)}
+}
+
+// Go runs a scheduler in the background under name. Schedulers run until ctx
+// is done, so one that returns before is reported as stopped.
+func (m *SchedulerMonitor) Go(ctx context.Context, name string, run func(ctx context.Context)) {
+	m.mu.Lock()
+	m.running[name] = true
+	m.mu.Unlock()
+
+	go func() {
+		defer func() {
+			m.mu.Lock()
+			m.running[name] = false
+			m.mu.Unlock()
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Scheduler stopped", "scheduler", name)
+			}
+		}()
+		run(ctx)
+	}()
+}
+
+// Running returns whether each scheduler started is still running
+func (m *SchedulerMonitor) Running() map[string]bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	running := make(map[string]bool, len(m.running))
+	for name, ok := range m.running {
+		running[name] = ok
+	}
+	return running
+}
+
+// Check fails when a scheduler has stopped, naming the ones that did
+func (m *SchedulerMonitor) Check(ctx context.Context) error {
+	var stopped []string
+	for name, running := range m.Running() {
+		if !running {
+			stopped = append(stopped, name)
+		}
+	}
+	if len(stopped) == 0 {
+		return nil
+	}
+	sort.Strings(stopped)
+	return fmt.Errorf("schedulers stopped: %s", strings.Join(stopped, ", "))
+}
@@ -2,7 +2,11 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,6 +17,8 @@ import (
 type SearchExpenseUseCase struct {
 	expenseRepo  domain.ExpenseRepository
 	categoryRepo domain.CategoryRepository
+	searchRepo   domain.ExpenseSearchRepository
+	tagRepo      domain.TagRepository
 }
 
 // NewSearchExpenseUseCase creates a new search expense use case
@@ -26,28 +32,45 @@ func NewSearchExpenseUseCase(
 	}
 }
 
+// SetSearchRepository searches with the database's full-text index; without
+// it, searches match each of the user's expenses in the period in memory
+func (u *SearchExpenseUseCase) SetSearchRepository(searchRepo domain.ExpenseSearchRepository) {
+	u.searchRepo = searchRepo
+}
+
+// SetTagRepository lists each result's tags; without it tag filters match nothing
+func (u *SearchExpenseUseCase) SetTagRepository(tagRepo domain.TagRepository) {
+	u.tagRepo = tagRepo
+}
+
 // SearchRequest represents a request to search expenses
 type SearchRequest struct {
 	UserID     string
-	Query      string     // Search term for description
+	Query      string     // Words to find in descriptions, each allowing a typo or two; #hashtags filter by tag
 	CategoryID *string    // Filter by category
+	Tags       []string   // Filter by tags, all of which must be carried
 	MinAmount  *float64   // Filter by minimum amount
 	MaxAmount  *float64   // Filter by maximum amount
 	StartDate  *time.Time // Filter by date range start
 	EndDate    *time.Time // Filter by date range end
-	SortBy     string     // "date_desc", "date_asc", "amount_desc", "amount_asc"
+	SortBy     string     // "relevance" (the default with a query), "date_desc", "date_asc", "amount_desc", "amount_asc"
 	Limit      int
 	Offset     int
 }
 
-// SearchResult represents a search result
+// SearchResult represents a search result. Highlight is the description,
+// HTML-escaped, with the words the query matched wrapped in <mark>.
 type SearchResult struct {
 	ID          string    `json:"id"`
 	Description string    `json:"description"`
+	Highlight   string    `json:"highlight,omitempty"`
+	Score       float64   `json:"score,omitempty"`
 	Amount      float64   `json:"amount"`
 	Category    string    `json:"category"`
+	Tags        []string  `json:"tags,omitempty"`
 	Date        time.Time `json:"date"`
 	Account     string    `json:"account"`
+	ClaimStatus string    `json:"claim_status,omitempty"` // Set for a reimbursable expense
 }
 
 // SearchResponse represents the response from a search
@@ -74,10 +97,20 @@ func (u *SearchExpenseUseCase) Search(ctx context.Context, req *SearchRequest) (
 	if req.Limit > 100 {
 		req.Limit = 100
 	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
 
-	// Default sort
+	tags, text := domain.ParseHashtags(req.Query)
+	tags = domain.NormalizeTagNames(slices.Concat(req.Tags, tags))
+	terms := domain.SearchTerms(text)
+
+	// Default sort: best matches first when there is something to match
 	if req.SortBy == "" {
-		req.SortBy = "date_desc"
+		req.SortBy = domain.SearchSortDateDesc
+		if len(terms) > 0 {
+			req.SortBy = domain.SearchSortRelevance
+		}
 	}
 
 	// Set default date range if not provided
@@ -90,62 +123,45 @@ func (u *SearchExpenseUseCase) Search(ctx context.Context, req *SearchRequest) (
 		req.EndDate = &end
 	}
 
-	// Get expenses from repository (in production, would support filtering)
-	allExpenses, err := u.expenseRepo.GetByUserIDAndDateRange(ctx, req.UserID, *req.StartDate, *req.EndDate)
+	query := &domain.ExpenseSearchQuery{
+		UserID:     req.UserID,
+		Terms:      terms,
+		CategoryID: req.CategoryID,
+		MinAmount:  req.MinAmount,
+		MaxAmount:  req.MaxAmount,
+		Tags:       tags,
+		StartDate:  *req.StartDate,
+		EndDate:    *req.EndDate,
+		SortBy:     req.SortBy,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+	}
+	var hits []*domain.ExpenseSearchHit
+	var total int
+	var err error
+	if u.searchRepo != nil {
+		hits, total, err = u.searchRepo.Search(ctx, query)
+	} else {
+		hits, total, err = u.searchInMemory(ctx, query)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to search expenses: %w", err)
 	}
-
-	// Filter expenses
-	var filtered []*domain.Expense
-	for _, exp := range allExpenses {
-		// Filter by query (description search)
-		if req.Query != "" {
-			if !strings.Contains(strings.ToLower(exp.Description), strings.ToLower(req.Query)) {
-				continue
-			}
-		}
-
-		// Filter by category
-		if req.CategoryID != nil {
-			if exp.CategoryID == nil || *exp.CategoryID != *req.CategoryID {
-				continue
-			}
-		}
-
-		// Filter by amount range
-		if req.MinAmount != nil && exp.Amount < *req.MinAmount {
-			continue
-		}
-		if req.MaxAmount != nil && exp.Amount > *req.MaxAmount {
-			continue
-		}
-
-		filtered = append(filtered, exp)
+	matched := make([]*domain.Expense, len(hits))
+	for i, hit := range hits {
+		matched[i] = hit.Expense
+	}
+	if err := loadExpenseTags(ctx, u.tagRepo, matched); err != nil {
+		return nil, err
 	}
 
-	// Sort expenses
-	u.sortExpenses(filtered, req.SortBy)
-
-	// Apply pagination
-	total := len(filtered)
 	pages := (total + req.Limit - 1) / req.Limit
 	currentPage := (req.Offset / req.Limit) + 1
 
-	start := req.Offset
-	end := start + req.Limit
-	if end > total {
-		end = total
-	}
-
-	var paginatedExpenses []*domain.Expense
-	if start < total {
-		paginatedExpenses = filtered[start:end]
-	}
-
 	// Convert to results
 	var results []*SearchResult
-	for _, exp := range paginatedExpenses {
+	for _, hit := range hits {
+		exp := hit.Expense
 		categoryName := "Uncategorized"
 		if exp.CategoryID != nil {
 			cat, _ := u.categoryRepo.GetByID(ctx, *exp.CategoryID)
@@ -157,8 +173,11 @@ func (u *SearchExpenseUseCase) Search(ctx context.Context, req *SearchRequest) (
 		results = append(results, &SearchResult{
 			ID:          exp.ID,
 			Description: exp.Description,
+			Highlight:   highlightSearch(exp.Description, terms),
+			Score:       hit.Score,
 			Amount:      exp.Amount,
 			Category:    categoryName,
+			Tags:        exp.Tags,
 			Date:        exp.ExpenseDate,
 			Account:     exp.Account,
 		})
@@ -180,44 +199,88 @@ func (u *SearchExpenseUseCase) Search(ctx context.Context, req *SearchRequest) (
 	}, nil
 }
 
-// sortExpenses sorts expenses based on the sort parameter
-func (u *SearchExpenseUseCase) sortExpenses(expenses []*domain.Expense, sortBy string) {
-	switch sortBy {
-	case "date_asc":
-		// Sort by date ascending (already in order from DB)
-	case "date_desc":
-		// Reverse order (most recent first)
-		for i, j := 0, len(expenses)-1; i < j; i, j = i+1, j-1 {
-			expenses[i], expenses[j] = expenses[j], expenses[i]
+// searchInMemory matches and ranks the user's expenses in the period one by
+// one, for databases without a full-text index
+func (u *SearchExpenseUseCase) searchInMemory(ctx context.Context, query *domain.ExpenseSearchQuery) ([]*domain.ExpenseSearchHit, int, error) {
+	expenses, err := u.expenseRepo.GetByUserIDAndDateRange(ctx, query.UserID, query.StartDate, query.EndDate)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(query.Tags) > 0 {
+		if err := loadExpenseTags(ctx, u.tagRepo, expenses); err != nil {
+			return nil, 0, err
 		}
-	case "amount_desc":
-		// Sort by amount descending
-		for i := 0; i < len(expenses); i++ {
-			for j := i + 1; j < len(expenses); j++ {
-				if expenses[j].Amount > expenses[i].Amount {
-					expenses[i], expenses[j] = expenses[j], expenses[i]
-				}
-			}
+	}
+
+	var hits []*domain.ExpenseSearchHit
+	for _, exp := range expenses {
+		if !query.Matches(exp) {
+			continue
 		}
-	case "amount_asc":
-		// Sort by amount ascending
-		for i := 0; i < len(expenses); i++ {
-			for j := i + 1; j < len(expenses); j++ {
-				if expenses[j].Amount < expenses[i].Amount {
-					expenses[i], expenses[j] = expenses[j], expenses[i]
-				}
-			}
+		score, ok := domain.ScoreSearch(exp.Description, query.Terms)
+		if !ok {
+			continue
 		}
+		hits = append(hits, &domain.ExpenseSearchHit{Expense: exp, Score: score})
 	}
+	domain.SortSearchHits(hits, query.SortBy)
+
+	total := len(hits)
+	start := min(query.Offset, total)
+	end := min(start+query.Limit, total)
+	return hits[start:end], total, nil
 }
 
-// FilterRequest represents a request to filter expenses
+// highlightSearch HTML-escapes a description and wraps the parts the terms
+// match in <mark>, or returns "" when there are no terms
+func highlightSearch(description string, terms []string) string {
+	if len(terms) == 0 {
+		return ""
+	}
+	var ranges [][2]int
+	for _, term := range terms {
+		ranges = append(ranges, domain.MatchSearchTerm(description, term)...)
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+
+	var b strings.Builder
+	pos := 0
+	for i := 0; i < len(ranges); {
+		// Merge overlapping and adjacent matches into one mark
+		start, end := ranges[i][0], ranges[i][1]
+		for i++; i < len(ranges) && ranges[i][0] <= end; i++ {
+			end = max(end, ranges[i][1])
+		}
+		b.WriteString(html.EscapeString(description[pos:start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(description[start:end]))
+		b.WriteString("</mark>")
+		pos = end
+	}
+	b.WriteString(html.EscapeString(description[pos:]))
+	return b.String()
+}
+
+// ErrInvalidFilter is returned for a filter that can match nothing
+// because its ranges are reversed or incomplete
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FilterRequest represents a request to filter expenses. Each list matches
+// any of its values; unset criteria don't filter.
 type FilterRequest struct {
-	UserID     string
-	CategoryID string
-	Period     string // "today", "this_week", "this_month", "last_30_days", "custom"
-	StartDate  *time.Time
-	EndDate    *time.Time
+	UserID        string
+	CategoryIDs   []string
+	Currencies    []string // Currency the expense was paid in
+	MinAmount     *float64 // Home amount
+	MaxAmount     *float64
+	HasAttachment *bool
+	Text          string   // Found in the description, ignoring case
+	Tags          []string // Tag names, all of which must be carried
+	Reimbursable  *bool
+	ClaimStatuses []string   // Claim statuses of reimbursable expenses
+	Period        string     // "today", "this_week", "this_month", "last_30_days", "custom"
+	StartDate     *time.Time // Used with the custom period, or on their own
+	EndDate       *time.Time
 }
 
 // FilterResponse represents filtered expenses
@@ -236,12 +299,24 @@ func (u *SearchExpenseUseCase) Filter(ctx context.Context, req *FilterRequest) (
 	if req.UserID == "" {
 		return nil, fmt.Errorf("user_id is required")
 	}
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MinAmount > *req.MaxAmount {
+		return nil, fmt.Errorf("%w: min_amount is above max_amount", ErrInvalidFilter)
+	}
+	for _, status := range req.ClaimStatuses {
+		if !domain.IsClaimStatus(status) {
+			return nil, fmt.Errorf("%w: unknown claim_status %q", ErrInvalidFilter, status)
+		}
+	}
 
 	// Determine date range based on period
 	now := time.Now()
 	var startDate, endDate time.Time
 
-	switch req.Period {
+	period := req.Period
+	if period == "" && (req.StartDate != nil || req.EndDate != nil) {
+		period = "custom"
+	}
+	switch period {
 	case "today":
 		startDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 		endDate = startDate.Add(24*time.Hour - time.Nanosecond)
@@ -255,11 +330,11 @@ func (u *SearchExpenseUseCase) Filter(ctx context.Context, req *FilterRequest) (
 		endDate = now
 		startDate = now.AddDate(0, 0, -30)
 	case "custom":
-		if req.StartDate != nil && req.EndDate != nil {
-			startDate = *req.StartDate
-			endDate = *req.EndDate
-		} else {
-			return nil, fmt.Errorf("start_date and end_date required for custom period")
+		if req.StartDate == nil && req.EndDate == nil {
+			return nil, fmt.Errorf("%w: start_date or end_date required for custom period", ErrInvalidFilter)
+		}
+		if req.StartDate != nil && req.EndDate != nil && req.StartDate.After(*req.EndDate) {
+			return nil, fmt.Errorf("%w: start_date is after end_date", ErrInvalidFilter)
 		}
 	default:
 		// Default to current month
@@ -267,48 +342,46 @@ func (u *SearchExpenseUseCase) Filter(ctx context.Context, req *FilterRequest) (
 		endDate = startDate.AddDate(0, 1, -1).Add(24*time.Hour - time.Nanosecond)
 	}
 
-	// Get expenses
-	var expenses []*domain.Expense
-	var err error
+	filter := domain.ExpenseFilter{
+		UserID:        req.UserID,
+		From:          req.StartDate,
+		To:            req.EndDate,
+		MinAmount:     req.MinAmount,
+		MaxAmount:     req.MaxAmount,
+		CategoryIDs:   req.CategoryIDs,
+		HasAttachment: req.HasAttachment,
+		Text:          req.Text,
+		Tags:          domain.NormalizeTagNames(req.Tags),
+		Reimbursable:  req.Reimbursable,
+		ClaimStatuses: req.ClaimStatuses,
+	}
+	if period != "custom" {
+		filter.From, filter.To = &startDate, &endDate
+	}
+	for _, currency := range req.Currencies {
+		filter.Currencies = append(filter.Currencies, strings.ToUpper(currency))
+	}
 
-	if req.CategoryID != "" {
-		expenses, err = u.expenseRepo.GetByUserIDAndCategory(ctx, req.UserID, req.CategoryID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to filter expenses: %w", err)
-		}
-		// Filter by date
-		var filtered []*domain.Expense
-		for _, exp := range expenses {
-			if exp.ExpenseDate.After(startDate) && exp.ExpenseDate.Before(endDate) {
-				filtered = append(filtered, exp)
-			}
-		}
-		expenses = filtered
-	} else {
-		expenses, err = u.expenseRepo.GetByUserIDAndDateRange(ctx, req.UserID, startDate, endDate)
-		if err != nil {
-			return nil, fmt.Errorf("failed to filter expenses: %w", err)
-		}
+	expenses, err := u.expenseRepo.Filter(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter expenses: %w", err)
+	}
+	if err := loadExpenseTags(ctx, u.tagRepo, expenses); err != nil {
+		return nil, err
 	}
 
 	// Calculate statistics
 	total := 0.0
-	min := 0.0
-	max := 0.0
-	if len(expenses) > 0 {
-		min = expenses[0].Amount
-		max = expenses[0].Amount
-	}
-
+	lowest := 0.0
+	highest := 0.0
 	var results []*SearchResult
-	for _, exp := range expenses {
+	for i, exp := range expenses {
 		total += exp.Amount
-
-		if exp.Amount < min || min == 0 {
-			min = exp.Amount
+		if i == 0 || exp.Amount < lowest {
+			lowest = exp.Amount
 		}
-		if exp.Amount > max {
-			max = exp.Amount
+		if i == 0 || exp.Amount > highest {
+			highest = exp.Amount
 		}
 
 		categoryName := "Uncategorized"
@@ -324,8 +397,10 @@ func (u *SearchExpenseUseCase) Filter(ctx context.Context, req *FilterRequest) (
 			Description: exp.Description,
 			Amount:      exp.Amount,
 			Category:    categoryName,
+			Tags:        exp.Tags,
 			Date:        exp.ExpenseDate,
 			Account:     exp.Account,
+			ClaimStatus: exp.ClaimStatus,
 		})
 	}
 
@@ -338,8 +413,8 @@ func (u *SearchExpenseUseCase) Filter(ctx context.Context, req *FilterRequest) (
 		Total:    total,
 		Count:    len(expenses),
 		Average:  avg,
-		Min:      min,
-		Max:      max,
+		Min:      lowest,
+		Max:      highest,
 		Expenses: results,
 		Message:  fmt.Sprintf("Retrieved %d expenses for period", len(expenses)),
 	}, nil
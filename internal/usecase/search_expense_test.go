@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchExpenseUseCaseSearch(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	now := time.Now()
+	for i, e := range []struct {
+		description string
+		amount      float64
+	}{
+		{"Lunch at <Café>", 180},
+		{"Lunhc box", 90},
+		{"午餐便當", 100},
+		{"Taxi home", 250},
+	} {
+		require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+			ID:          string(rune('a' + i)),
+			UserID:      "user1",
+			Description: e.description,
+			Amount:      e.amount,
+			HomeAmount:  e.amount,
+			ExpenseDate: now.AddDate(0, 0, -i),
+		}))
+	}
+	uc := NewSearchExpenseUseCase(expenseRepo, NewMockCategoryRepository())
+
+	// The exact match ranks above the misspelled one, and both are highlighted
+	resp, err := uc.Search(ctx, &SearchRequest{UserID: "user1", Query: "LUNCH"})
+	require.NoError(t, err)
+	require.Equal(t, 2, resp.Total)
+	assert.Equal(t, "a", resp.Results[0].ID)
+	assert.Equal(t, "<mark>Lunch</mark> at &lt;Café&gt;", resp.Results[0].Highlight)
+	assert.Equal(t, "<mark>Lunhc</mark> box", resp.Results[1].Highlight)
+	assert.Greater(t, resp.Results[0].Score, resp.Results[1].Score)
+
+	resp, err = uc.Search(ctx, &SearchRequest{UserID: "user1", Query: "便當"})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, "午餐<mark>便當</mark>", resp.Results[0].Highlight)
+
+	// Without a query everything matches, newest first
+	resp, err = uc.Search(ctx, &SearchRequest{UserID: "user1", Limit: 3, Offset: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 4, resp.Total)
+	assert.Equal(t, 2, resp.Pages)
+	require.Len(t, resp.Results, 1)
+	assert.Equal(t, "d", resp.Results[0].ID)
+	assert.Empty(t, resp.Results[0].Highlight)
+}
+
+func TestSearchExpenseUseCaseSearchRepository(t *testing.T) {
+	searchRepo := &stubExpenseSearchRepository{hits: []*domain.ExpenseSearchHit{
+		{Expense: &domain.Expense{ID: "a", Description: "Lunch", Amount: 100}, Score: 0.8},
+	}, total: 21}
+	uc := NewSearchExpenseUseCase(NewMockExpenseRepository(), NewMockCategoryRepository())
+	uc.SetSearchRepository(searchRepo)
+
+	resp, err := uc.Search(context.Background(), &SearchRequest{UserID: "user1", Query: "lunch, lunch box", Offset: 20})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lunch", "box"}, searchRepo.query.Terms)
+	assert.Equal(t, domain.SearchSortRelevance, searchRepo.query.SortBy)
+	assert.Equal(t, 20, searchRepo.query.Limit)
+	assert.Equal(t, 2, resp.Pages)
+	assert.Equal(t, 2, resp.CurrentPage)
+	assert.Equal(t, "<mark>Lunch</mark>", resp.Results[0].Highlight)
+	assert.Equal(t, 0.8, resp.Results[0].Score)
+}
+
+// stubExpenseSearchRepository returns fixed hits and records the query
+type stubExpenseSearchRepository struct {
+	hits  []*domain.ExpenseSearchHit
+	total int
+	query *domain.ExpenseSearchQuery
+}
+
+func (s *stubExpenseSearchRepository) Search(ctx context.Context, query *domain.ExpenseSearchQuery) ([]*domain.ExpenseSearchHit, int, error) {
+	s.query = query
+	return s.hits, s.total, nil
+}
+
+func TestSearchExpenseUseCaseFilter(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	day := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	for i, e := range []struct {
+		amount   float64
+		currency string
+	}{{80, "TWD"}, {120, "USD"}, {300, "USD"}} {
+		require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+			ID:          string(rune('a' + i)),
+			UserID:      "user1",
+			Description: "Lunch",
+			Amount:      e.amount,
+			HomeAmount:  e.amount,
+			Currency:    e.currency,
+			ExpenseDate: day.AddDate(0, 0, i),
+		}))
+	}
+	uc := NewSearchExpenseUseCase(expenseRepo, NewMockCategoryRepository())
+
+	// Dates without a period filter the custom range
+	start, end := day, day.AddDate(0, 0, 5)
+	minAmount := 100.0
+	resp, err := uc.Filter(ctx, &FilterRequest{UserID: "user1", StartDate: &start, EndDate: &end, MinAmount: &minAmount, Currencies: []string{"usd"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.Count)
+	assert.Equal(t, 420.0, resp.Total)
+	assert.Equal(t, 120.0, resp.Min)
+	assert.Equal(t, 300.0, resp.Max)
+	assert.Equal(t, "c", resp.Expenses[0].ID)
+
+	maxAmount := 50.0
+	_, err = uc.Filter(ctx, &FilterRequest{UserID: "user1", MinAmount: &minAmount, MaxAmount: &maxAmount})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	_, err = uc.Filter(ctx, &FilterRequest{UserID: "user1", StartDate: &end, EndDate: &start})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	_, err = uc.Filter(ctx, &FilterRequest{UserID: "user1", Period: "custom"})
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
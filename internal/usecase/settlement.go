@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// SettlementUseCase works out who owes whom in a group ledger from its split expenses
+type SettlementUseCase struct {
+	groupRepo   domain.GroupRepository
+	splitRepo   domain.ExpenseSplitRepository
+	expenseRepo domain.ExpenseRepository
+}
+
+// NewSettlementUseCase creates a new settlement use case
+func NewSettlementUseCase(
+	groupRepo domain.GroupRepository,
+	splitRepo domain.ExpenseSplitRepository,
+	expenseRepo domain.ExpenseRepository,
+) *SettlementUseCase {
+	return &SettlementUseCase{
+		groupRepo:   groupRepo,
+		splitRepo:   splitRepo,
+		expenseRepo: expenseRepo,
+	}
+}
+
+// MemberBalance is a participant's net position; positive means they are owed money
+type MemberBalance struct {
+	Participant string  `json:"participant"`
+	Balance     float64 `json:"balance"`
+}
+
+// Transfer is one payment needed to settle up
+type Transfer struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+}
+
+// Settlement is the set of transfers that settles every balance in a group
+type Settlement struct {
+	GroupID   string          `json:"group_id"`
+	GroupName string          `json:"group_name"`
+	Currency  string          `json:"currency"`
+	Balances  []MemberBalance `json:"balances"`
+	Transfers []Transfer      `json:"transfers"`
+	Text      string          `json:"text"`
+}
+
+// Execute computes the settlement for a group the user belongs to
+func (u *SettlementUseCase) Execute(ctx context.Context, groupID, userID string) (*Settlement, error) {
+	if groupID == "" || userID == "" {
+		return nil, fmt.Errorf("group_id and user_id are required")
+	}
+	if err := requireGroupMember(ctx, u.groupRepo, groupID, userID); err != nil {
+		return nil, err
+	}
+
+	group, err := u.groupRepo.GetByID(ctx, groupID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("group %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	splits, err := u.splitRepo.GetByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get splits: %w", err)
+	}
+
+	currency := "TWD"
+	if len(splits) > 0 {
+		if expense, _ := u.expenseRepo.GetByID(ctx, splits[0].ExpenseID); expense != nil && expense.HomeCurrency != "" {
+			currency = expense.HomeCurrency
+		}
+	}
+
+	balances := netBalances(splits)
+	settlement := &Settlement{
+		GroupID:   group.ID,
+		GroupName: group.Name,
+		Currency:  currency,
+		Balances:  balances,
+		Transfers: minimalTransfers(balances),
+	}
+	settlement.Text = formatSettlement(ctx, settlement)
+
+	return settlement, nil
+}
+
+// ExecuteForChat computes the settlement for the group bound to a messenger chat;
+// used by the bot when someone asks to settle up in a group chat
+func (u *SettlementUseCase) ExecuteForChat(ctx context.Context, messengerType, externalID, userID string) (string, error) {
+	group, err := u.groupRepo.GetByExternalID(ctx, messengerType, externalID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return "", fmt.Errorf("group %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get group: %w", err)
+	}
+
+	settlement, err := u.Execute(ctx, group.ID, userID)
+	if err != nil {
+		return "", err
+	}
+	return settlement.Text, nil
+}
+
+// netBalances sums each participant's position: payers are owed every share
+// that is not their own, and participants owe their shares
+func netBalances(splits []*domain.ExpenseSplit) []MemberBalance {
+	totals := make(map[string]float64)
+	for _, split := range splits {
+		if !split.Owes() {
+			continue
+		}
+		totals[split.PayerID] += split.Amount
+		totals[split.Participant] -= split.Amount
+	}
+
+	var balances []MemberBalance
+	for participant, balance := range totals {
+		if balance = roundCents(balance); balance != 0 {
+			balances = append(balances, MemberBalance{Participant: participant, Balance: balance})
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Balance != balances[j].Balance {
+			return balances[i].Balance > balances[j].Balance
+		}
+		return balances[i].Participant < balances[j].Participant
+	})
+	return balances
+}
+
+// minimalTransfers settles the balances by repeatedly paying the largest creditor
+// from the largest debtor. Each transfer clears at least one balance, so n
+// people settle in at most n-1 transfers.
+func minimalTransfers(balances []MemberBalance) []Transfer {
+	var creditors, debtors []MemberBalance
+	for _, b := range balances {
+		if b.Balance > 0 {
+			creditors = append(creditors, b)
+		} else {
+			debtors = append(debtors, MemberBalance{Participant: b.Participant, Balance: -b.Balance})
+		}
+	}
+
+	var transfers []Transfer
+	for len(creditors) > 0 && len(debtors) > 0 {
+		sortBalancesDesc(creditors)
+		sortBalancesDesc(debtors)
+
+		amount := roundCents(math.Min(creditors[0].Balance, debtors[0].Balance))
+		transfers = append(transfers, Transfer{From: debtors[0].Participant, To: creditors[0].Participant, Amount: amount})
+
+		creditors[0].Balance = roundCents(creditors[0].Balance - amount)
+		debtors[0].Balance = roundCents(debtors[0].Balance - amount)
+		if creditors[0].Balance <= 0 {
+			creditors = creditors[1:]
+		}
+		if debtors[0].Balance <= 0 {
+			debtors = debtors[1:]
+		}
+	}
+	return transfers
+}
+
+func sortBalancesDesc(balances []MemberBalance) {
+	sort.SliceStable(balances, func(i, j int) bool {
+		if balances[i].Balance != balances[j].Balance {
+			return balances[i].Balance > balances[j].Balance
+		}
+		return balances[i].Participant < balances[j].Participant
+	})
+}
+
+// formatSettlement renders the transfers for a messenger reply
+func formatSettlement(ctx context.Context, s *Settlement) string {
+	name := s.GroupName
+	if name == "" {
+		name = translate(ctx, "settlement.group")
+	}
+	if len(s.Transfers) == 0 {
+		return translate(ctx, "settlement.settled", name)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(translate(ctx, "settlement.transfers", name, len(s.Transfers)))
+	for _, t := range s.Transfers {
+		sb.WriteString(fmt.Sprintf("\n• %s → %s: %s", t.From, t.To, formatMoney(ctx, t.Amount, s.Currency)))
+	}
+	return sb.String()
+}
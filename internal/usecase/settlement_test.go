@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+func TestSettlement(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "en")
+	expenseRepo := NewMockExpenseRepository()
+	splitRepo := NewMockExpenseSplitRepository(expenseRepo)
+	groupRepo := NewMockGroupRepository()
+	splits := NewSplitExpenseUseCase(expenseRepo, splitRepo, groupRepo)
+	uc := NewSettlementUseCase(groupRepo, splitRepo, expenseRepo)
+
+	groups := NewGroupLedgerUseCase(groupRepo)
+	group, _ := groups.EnsureGroup(ctx, "line", "C1", "Trip", "alice")
+	groups.EnsureGroup(ctx, "line", "C1", "Trip", "bob")
+	groups.EnsureGroup(ctx, "line", "C1", "Trip", "carol")
+
+	settlement, err := uc.Execute(ctx, group.ID, "alice")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(settlement.Transfers) != 0 || settlement.Text != "✅ Trip is all settled up" {
+		t.Errorf("expected no transfers, got %+v (%q)", settlement.Transfers, settlement.Text)
+	}
+
+	// alice pays 900 for everyone, bob pays 300 for everyone, carol pays 120 for herself and bob
+	record := func(id, payer string, amount float64) {
+		expenseRepo.Create(ctx, &domain.Expense{ID: id, UserID: payer, GroupID: &group.ID, HomeAmount: amount, HomeCurrency: "TWD"})
+	}
+	record("e1", "alice", 900)
+	record("e2", "bob", 300)
+	record("e3", "carol", 120)
+	splits.SplitEqually(ctx, "e1", "alice", 3)
+	splits.SplitEqually(ctx, "e2", "bob", 3)
+	splits.Execute(ctx, &SplitExpenseRequest{ExpenseID: "e3", UserID: "carol", Participants: []SplitParticipant{{Participant: "carol"}, {Participant: "bob"}}})
+
+	settlement, err = uc.Execute(ctx, group.ID, "bob")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// alice: +600 (owed by bob and carol), -100 (bob's dinner) = +500
+	// bob: +200, -300, -60 = -160; carol: -300, -100, +60 = -340
+	want := []Transfer{
+		{From: "carol", To: "alice", Amount: 340},
+		{From: "bob", To: "alice", Amount: 160},
+	}
+	if len(settlement.Transfers) != len(want) {
+		t.Fatalf("expected %d transfers, got %+v", len(want), settlement.Transfers)
+	}
+	for i, transfer := range settlement.Transfers {
+		if transfer != want[i] {
+			t.Errorf("transfer %d: expected %+v, got %+v", i, want[i], transfer)
+		}
+	}
+	wantText := "🤝 Settle up Trip (2 transfer(s))\n• carol → alice: NT$340\n• bob → alice: NT$160"
+	if settlement.Text != wantText {
+		t.Errorf("expected %q, got %q", wantText, settlement.Text)
+	}
+
+	if _, err := uc.Execute(ctx, group.ID, "mallory"); err == nil {
+		t.Error("expected non-member to be rejected")
+	}
+}
+
+func TestMinimalTransfers(t *testing.T) {
+	balances := []MemberBalance{
+		{Participant: "a", Balance: 50},
+		{Participant: "b", Balance: 30},
+		{Participant: "c", Balance: -20},
+		{Participant: "d", Balance: -60},
+	}
+	transfers := minimalTransfers(balances)
+	if len(transfers) > len(balances)-1 {
+		t.Errorf("expected at most %d transfers, got %d", len(balances)-1, len(transfers))
+	}
+
+	net := make(map[string]float64)
+	for _, transfer := range transfers {
+		net[transfer.From] += transfer.Amount
+		net[transfer.To] -= transfer.Amount
+	}
+	for _, b := range balances {
+		if roundCents(net[b.Participant]+b.Balance) != 0 {
+			t.Errorf("%s not settled: balance %.2f, transfers %.2f", b.Participant, b.Balance, net[b.Participant])
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// Spending anomaly detection
+const (
+	anomalyHistoryDays   = 90 // Days of expenses a category's usual amount is taken from
+	anomalyFrequencyDays = 30 // Days the usual number of expenses a day is averaged over
+	anomalyMinSamples    = 5  // Expenses needed before amounts or counts count as usual
+	anomalyMinDailyCount = 5  // Expenses in a day before it can be a spike
+)
+
+// anomalyThreshold is how far above the user's usual spending is unusual
+type anomalyThreshold struct {
+	amount    float64 // Times the category's median amount an expense costs
+	frequency float64 // Times the usual number of expenses a day
+}
+
+// anomalyThresholds are the thresholds of each sensitivity
+var anomalyThresholds = map[string]anomalyThreshold{
+	domain.AnomalySensitivityLow:    {amount: 5, frequency: 4},
+	domain.AnomalySensitivityMedium: {amount: 3, frequency: 3},
+	domain.AnomalySensitivityHigh:   {amount: 2, frequency: 2},
+}
+
+// SpendingAnomalyUseCase alerts the users who keep spending anomaly alerts
+// on when an expense they record costs far more than they usually spend in
+// its category, or when they record far more expenses in a day than usual.
+// How far is set by their sensitivity. Each expense is checked once, on the
+// run after it was recorded, and alerts go through the pusher, so a failed
+// send is retried.
+type SpendingAnomalyUseCase struct {
+	prefsRepo    domain.NotificationPreferencesRepository
+	userRepo     domain.UserRepository
+	expenseRepo  domain.ExpenseRepository
+	categoryRepo domain.CategoryRepository
+	pusher       MessagePusher
+	now          func() time.Time
+}
+
+// NewSpendingAnomalyUseCase creates a new spending anomaly use case
+func NewSpendingAnomalyUseCase(
+	prefsRepo domain.NotificationPreferencesRepository,
+	userRepo domain.UserRepository,
+	expenseRepo domain.ExpenseRepository,
+	categoryRepo domain.CategoryRepository,
+	pusher MessagePusher,
+) *SpendingAnomalyUseCase {
+	return &SpendingAnomalyUseCase{
+		prefsRepo:    prefsRepo,
+		userRepo:     userRepo,
+		expenseRepo:  expenseRepo,
+		categoryRepo: categoryRepo,
+		pusher:       pusher,
+		now:          time.Now,
+	}
+}
+
+// CheckDue checks the expenses recorded since each subscriber's last check,
+// and returns how many users were alerted. The first check of a user covers
+// the day before it.
+func (u *SpendingAnomalyUseCase) CheckDue(ctx context.Context) (int, error) {
+	subscribed, err := u.prefsRepo.ListSubscribed(ctx, domain.NotificationSpendingAnomalies)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get spending anomaly subscribers: %w", err)
+	}
+
+	now := u.now()
+	alerted := 0
+	for _, prefs := range subscribed {
+		since := now.Add(-24 * time.Hour)
+		if prefs.LastAnomalyCheckAt != nil {
+			since = *prefs.LastAnomalyCheckAt
+		}
+		ok, err := u.check(ctx, prefs, since, now)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to check spending anomalies", "user_id", prefs.UserID, "error", err)
+			continue
+		}
+		if ok {
+			alerted++
+		}
+
+		prefs.LastAnomalyCheckAt = &now
+		if err := u.prefsRepo.Save(ctx, prefs); err != nil {
+			slog.ErrorContext(ctx, "Failed to record spending anomaly check", "user_id", prefs.UserID, "error", err)
+		}
+	}
+	return alerted, nil
+}
+
+// RunScheduler checks for anomalies once per interval until ctx is done
+func (u *SpendingAnomalyUseCase) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.CheckDue(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to check spending anomalies", "error", err)
+			}
+		}
+	}
+}
+
+// check alerts the user to the anomalies among the expenses they recorded
+// after since, in their language. It reports whether an alert was pushed.
+func (u *SpendingAnomalyUseCase) check(ctx context.Context, prefs *domain.NotificationPreferences, since, now time.Time) (bool, error) {
+	expenses, err := u.expenseRepo.GetByUserIDAndDateRange(ctx, prefs.UserID, now.AddDate(0, 0, -anomalyHistoryDays), now)
+	if err != nil {
+		return false, fmt.Errorf("failed to get expenses: %w", err)
+	}
+	var recent, history []*domain.Expense
+	for _, expense := range expenses {
+		if expense.CreatedAt.After(since) {
+			recent = append(recent, expense)
+		} else {
+			history = append(history, expense)
+		}
+	}
+	if len(recent) == 0 {
+		return false, nil
+	}
+
+	user, err := u.userRepo.GetByID(ctx, prefs.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	if i18n.Supported(user.Locale) {
+		ctx = i18n.WithLocale(ctx, user.Locale)
+	}
+	threshold, ok := anomalyThresholds[prefs.AnomalySensitivity]
+	if !ok {
+		threshold = anomalyThresholds[domain.DefaultAnomalySensitivity]
+	}
+
+	var alerts []string
+	for _, expense := range recent {
+		if expense.CategoryID == nil {
+			continue
+		}
+		usual, ok := usualAmount(history, *expense.CategoryID)
+		if !ok || expense.HomeAmount < usual*threshold.amount {
+			continue
+		}
+		category, err := u.categoryRepo.GetByID(ctx, *expense.CategoryID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get category: %w", err)
+		}
+		alerts = append(alerts, translate(ctx, "anomaly.amount",
+			expense.Description,
+			formatMoney(ctx, expense.HomeAmount, user.HomeCurrency),
+			formatRatio(ctx, expense.HomeAmount/usual),
+			category.Name,
+			formatMoney(ctx, usual, user.HomeCurrency),
+		))
+	}
+	if count, usual, ok := frequencySpike(history, recent, now, threshold.frequency); ok {
+		alerts = append(alerts, translate(ctx, "anomaly.frequency",
+			count,
+			formatRatio(ctx, float64(count)/usual),
+			i18n.FormatNumber(i18n.FromContext(ctx), usual),
+		))
+	}
+	if len(alerts) == 0 {
+		return false, nil
+	}
+
+	switch err := u.pusher.Push(ctx, user.UserID, user.MessengerType, strings.Join(alerts, "\n")); {
+	case errors.Is(err, ErrNoNotifier):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+// usualAmount is the median home amount of the category's expenses, if
+// there are enough of them to tell
+func usualAmount(expenses []*domain.Expense, categoryID string) (float64, bool) {
+	var amounts []float64
+	for _, expense := range expenses {
+		if expense.CategoryID != nil && *expense.CategoryID == categoryID {
+			amounts = append(amounts, expense.HomeAmount)
+		}
+	}
+	if len(amounts) < anomalyMinSamples {
+		return 0, false
+	}
+	sort.Float64s(amounts)
+	median := amounts[len(amounts)/2]
+	if len(amounts)%2 == 0 {
+		median = (amounts[len(amounts)/2-1] + median) / 2
+	}
+	return median, median > 0
+}
+
+// frequencySpike returns how many expenses are dated in the day before now
+// and how many a day are usual over the days before it. It reports a spike
+// when the recent expenses take the day's count past the usual times factor,
+// so each spike is only reported once.
+func frequencySpike(history, recent []*domain.Expense, now time.Time, factor float64) (int, float64, bool) {
+	dayStart := now.Add(-24 * time.Hour)
+	baselineStart := dayStart.AddDate(0, 0, -anomalyFrequencyDays)
+	earlier, count, baseline := 0, 0, 0
+	for i, expense := range slices.Concat(history, recent) {
+		switch {
+		case expense.ExpenseDate.After(dayStart):
+			count++
+			if i < len(history) {
+				earlier++
+			}
+		case !expense.ExpenseDate.Before(baselineStart):
+			baseline++
+		}
+	}
+	if baseline < anomalyMinSamples {
+		return count, 0, false
+	}
+	usual := float64(baseline) / anomalyFrequencyDays
+	limit := math.Max(usual*factor, anomalyMinDailyCount)
+	return count, usual, float64(count) >= limit && float64(earlier) < limit
+}
+
+// formatRatio writes how many times something is, to one decimal, in the locale carried by ctx
+func formatRatio(ctx context.Context, ratio float64) string {
+	return i18n.FormatNumber(i18n.FromContext(ctx), math.Round(ratio*10)/10)
+}
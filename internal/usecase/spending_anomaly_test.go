@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsualAmount(t *testing.T) {
+	dinner, lunch := "dinner", "lunch"
+	var expenses []*domain.Expense
+	for _, amount := range []float64{150, 200, 220, 250, 900} {
+		expenses = append(expenses, &domain.Expense{CategoryID: &dinner, HomeAmount: amount})
+	}
+	expenses = append(expenses, &domain.Expense{CategoryID: &lunch, HomeAmount: 100}, &domain.Expense{HomeAmount: 50})
+
+	usual, ok := usualAmount(expenses, dinner)
+	assert.True(t, ok)
+	assert.Equal(t, 220.0, usual)
+
+	expenses = append(expenses, &domain.Expense{CategoryID: &dinner, HomeAmount: 1000})
+	usual, _ = usualAmount(expenses, dinner)
+	assert.Equal(t, 235.0, usual)
+
+	_, ok = usualAmount(expenses, lunch)
+	assert.False(t, ok, "too few expenses to tell")
+}
+
+func TestFrequencySpike(t *testing.T) {
+	now := time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+	// One expense a day for the last month
+	var history []*domain.Expense
+	for i := 1; i <= anomalyFrequencyDays; i++ {
+		history = append(history, &domain.Expense{ExpenseDate: now.AddDate(0, 0, -i).Add(-time.Hour)})
+	}
+	today := func(n int) []*domain.Expense {
+		expenses := make([]*domain.Expense, n)
+		for i := range expenses {
+			expenses[i] = &domain.Expense{ExpenseDate: now.Add(-time.Duration(i+1) * time.Hour)}
+		}
+		return expenses
+	}
+
+	count, usual, ok := frequencySpike(history, today(5), now, 3)
+	assert.True(t, ok)
+	assert.Equal(t, 5, count)
+	assert.Equal(t, 1.0, usual)
+
+	// Below the minimum a day, however far above usual
+	_, _, ok = frequencySpike(history, today(4), now, 2)
+	assert.False(t, ok)
+
+	// Already reported when the earlier expenses crossed it
+	_, _, ok = frequencySpike(append(history, today(5)...), today(1), now, 3)
+	assert.False(t, ok)
+
+	// Without a usual to compare with
+	_, _, ok = frequencySpike(nil, today(10), now, 2)
+	assert.False(t, ok)
+}
+
+func TestSpendingAnomalyCheckDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 10, 20, 0, 0, 0, time.UTC)
+
+	userRepo := NewMockUserRepository()
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "line", Locale: "zh-TW", HomeCurrency: "TWD"}))
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user2", MessengerType: "line", Locale: "en", HomeCurrency: "TWD"}))
+
+	categoryRepo := NewMockCategoryRepository()
+	prefsRepo := NewMockNotificationPreferencesRepository()
+	expenseRepo := NewMockExpenseRepository()
+	for _, userID := range []string{"user1", "user2"} {
+		categoryID := "dinner-" + userID
+		require.NoError(t, categoryRepo.Create(ctx, &domain.Category{ID: categoryID, UserID: userID, Name: "晚餐"}))
+		for i := 1; i <= 6; i++ {
+			day := now.AddDate(0, 0, -3*i)
+			require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+				ID:          fmt.Sprintf("%s-%d", userID, i),
+				UserID:      userID,
+				Description: "晚餐",
+				HomeAmount:  200,
+				CategoryID:  &categoryID,
+				ExpenseDate: day,
+				CreatedAt:   day,
+			}))
+		}
+		// Four times the usual dinner, recorded since the last check
+		require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{
+			ID:          userID + "-new",
+			UserID:      userID,
+			Description: "牛排",
+			HomeAmount:  800,
+			CategoryID:  &categoryID,
+			ExpenseDate: now.Add(-2 * time.Hour),
+			CreatedAt:   now.Add(-2 * time.Hour),
+		}))
+
+		prefs := domain.DefaultNotificationPreferences(userID)
+		checked := now.Add(-3 * time.Hour)
+		prefs.LastAnomalyCheckAt = &checked
+		require.NoError(t, prefsRepo.Save(ctx, prefs))
+	}
+	// user2 only wants to hear about spending five times their usual
+	prefs, err := prefsRepo.GetByUserID(ctx, "user2")
+	require.NoError(t, err)
+	prefs.AnomalySensitivity = domain.AnomalySensitivityLow
+	require.NoError(t, prefsRepo.Save(ctx, prefs))
+
+	pusher := &recordingPusher{notifiers: map[string]bool{"line": true}, messages: make(map[string]string)}
+	uc := NewSpendingAnomalyUseCase(prefsRepo, userRepo, expenseRepo, categoryRepo, pusher)
+	uc.now = func() time.Time { return now }
+
+	alerted, err := uc.CheckDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, alerted)
+	assert.Equal(t, map[string]string{"user1": "⚠️ 「牛排」NT$800，比你平常的晚餐（約 NT$200）貴 4 倍。"}, pusher.messages)
+	for _, userID := range []string{"user1", "user2"} {
+		prefs, err := prefsRepo.GetByUserID(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, now, *prefs.LastAnomalyCheckAt, userID)
+	}
+
+	// Each expense is only checked once
+	now = now.Add(15 * time.Minute)
+	alerted, err = uc.CheckDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, alerted)
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// spendingSummaryTopCategories is how many categories a summary lists
+const spendingSummaryTopCategories = 3
+
+// SpendingSummaryUseCase summarizes a user's month so far, with their largest
+// categories and how their budgets stand, for messenger home screens
+type SpendingSummaryUseCase struct {
+	reports ExpenseReporter
+	budgets BudgetStatusReporter
+	now     func() time.Time
+}
+
+// NewSpendingSummaryUseCase creates a new spending summary use case; budgets may be nil
+func NewSpendingSummaryUseCase(reports ExpenseReporter, budgets BudgetStatusReporter) *SpendingSummaryUseCase {
+	return &SpendingSummaryUseCase{
+		reports: reports,
+		budgets: budgets,
+		now:     time.Now,
+	}
+}
+
+// Summarize returns the user's spending from the start of the month until now
+func (u *SpendingSummaryUseCase) Summarize(ctx context.Context, userID string) (*domain.SpendingSummary, error) {
+	now := u.now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	report, err := u.reports.Execute(ctx, &ReportRequest{
+		UserID:     userID,
+		ReportType: "monthly",
+		StartDate:  start,
+		EndDate:    now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate report: %w", err)
+	}
+
+	summary := &domain.SpendingSummary{
+		Period:       start.Format("2006-01"),
+		Total:        roundCents(report.TotalExpenses),
+		ExpenseCount: report.TransactionCount,
+	}
+
+	categories := append([]CategoryBreakdown(nil), report.CategoryBreakdown...)
+	sort.SliceStable(categories, func(i, j int) bool { return categories[i].Total > categories[j].Total })
+	for _, c := range categories[:min(len(categories), spendingSummaryTopCategories)] {
+		summary.TopCategories = append(summary.TopCategories, domain.CategorySpending{
+			Category:   c.Category,
+			Total:      roundCents(c.Total),
+			Percentage: c.Percentage,
+		})
+	}
+
+	if u.budgets != nil {
+		status, err := u.budgets.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: userID, Active: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get budget status: %w", err)
+		}
+		for _, b := range status.Budgets {
+			summary.Budgets = append(summary.Budgets, domain.BudgetSpending{
+				Category: b.Category,
+				Period:   b.Period,
+				Limit:    b.Limit,
+				Spent:    roundCents(b.Spent),
+				Alert:    b.AlertTriggered,
+				Exceeded: b.IsExceeded,
+			})
+		}
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestSpendingSummaryUseCase(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	for _, c := range []*domain.Category{
+		{ID: "cat_food", UserID: "user1", Name: "Food"},
+		{ID: "cat_transport", UserID: "user1", Name: "交通"},
+		{ID: "cat_fun", UserID: "user1", Name: "Entertainment"},
+		{ID: "cat_home", UserID: "user1", Name: "Home"},
+	} {
+		categoryRepo.Create(ctx, c)
+	}
+
+	food, transport, fun, home := "cat_food", "cat_transport", "cat_fun", "cat_home"
+	expenses := []*domain.Expense{
+		{ID: "e1", Description: "午餐", Amount: 120, CategoryID: &food, ExpenseDate: now},
+		{ID: "e2", Description: "計程車", Amount: 300, CategoryID: &transport, ExpenseDate: now.AddDate(0, 0, -1)},
+		{ID: "e3", Description: "電影", Amount: 50, CategoryID: &fun, ExpenseDate: now.AddDate(0, 0, -2)},
+		{ID: "e4", Description: "燈泡", Amount: 80, CategoryID: &home, ExpenseDate: now.AddDate(0, 0, -3)},
+		{ID: "e5", Description: "上個月的晚餐", Amount: 500, CategoryID: &food, ExpenseDate: now.AddDate(0, -1, 0)},
+	}
+	for _, expense := range expenses {
+		expense.UserID = "user1"
+		expenseRepo.Create(ctx, expense)
+	}
+
+	uc := NewSpendingSummaryUseCase(NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil), nil)
+	uc.now = func() time.Time { return now }
+
+	summary, err := uc.Summarize(ctx, "user1")
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if summary.Period != "2026-03" || summary.Total != 550 || summary.ExpenseCount != 4 {
+		t.Errorf("unexpected month to date: %+v", summary)
+	}
+	if len(summary.TopCategories) != 3 || summary.TopCategories[0].Category != "交通" || summary.TopCategories[1].Category != "Food" || summary.TopCategories[2].Category != "Home" {
+		t.Errorf("expected the three largest categories, got %+v", summary.TopCategories)
+	}
+	if summary.Budgets != nil {
+		t.Errorf("expected no budgets without a budget reporter, got %+v", summary.Budgets)
+	}
+}
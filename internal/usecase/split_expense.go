@@ -0,0 +1,401 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// SplitExpenseUseCase splits a bill between participants and tracks what each
+// of them owes the payer
+type SplitExpenseUseCase struct {
+	expenseRepo domain.ExpenseRepository
+	splitRepo   domain.ExpenseSplitRepository
+	groupRepo   domain.GroupRepository
+}
+
+// NewSplitExpenseUseCase creates a new split expense use case
+func NewSplitExpenseUseCase(
+	expenseRepo domain.ExpenseRepository,
+	splitRepo domain.ExpenseSplitRepository,
+	groupRepo domain.GroupRepository,
+) *SplitExpenseUseCase {
+	return &SplitExpenseUseCase{
+		expenseRepo: expenseRepo,
+		splitRepo:   splitRepo,
+		groupRepo:   groupRepo,
+	}
+}
+
+// SplitParticipant is one participant's requested share. Set Amount for a fixed
+// share, Percentage for a proportional one, or neither to split equally.
+type SplitParticipant struct {
+	Participant string  `json:"participant"`
+	Amount      float64 `json:"amount,omitempty"`
+	Percentage  float64 `json:"percentage,omitempty"`
+}
+
+// SplitExpenseRequest represents a request to split an expense
+type SplitExpenseRequest struct {
+	ExpenseID    string
+	UserID       string
+	Participants []SplitParticipant // Include the payer to give them a share too
+	Count        int                // Split equally this many ways when Participants is empty
+}
+
+// SplitExpenseResponse represents the response after splitting an expense
+type SplitExpenseResponse struct {
+	Expense *domain.Expense        `json:"expense"`
+	Splits  []*domain.ExpenseSplit `json:"splits"`
+	Message string                 `json:"message"`
+}
+
+// Execute splits an expense, replacing any previous split
+func (u *SplitExpenseUseCase) Execute(ctx context.Context, req *SplitExpenseRequest) (*SplitExpenseResponse, error) {
+	expense, err := getVisibleExpense(ctx, u.expenseRepo, u.groupRepo, req.ExpenseID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if expense.UserID != req.UserID {
+		return nil, fmt.Errorf("only the payer can split an expense")
+	}
+
+	participants := req.Participants
+	if len(participants) == 0 {
+		if req.Count < 2 {
+			return nil, fmt.Errorf("at least 2 participants are required")
+		}
+		participants, err = u.defaultParticipants(ctx, expense, req.Count)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	splits, err := buildSplits(expense, participants)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.splitRepo.ReplaceForExpense(ctx, expense.ID, splits); err != nil {
+		return nil, fmt.Errorf("failed to save split: %w", err)
+	}
+	expense.Splits = splits
+
+	return &SplitExpenseResponse{
+		Expense: expense,
+		Splits:  splits,
+		Message: formatSplitMessage(ctx, expense, splits),
+	}, nil
+}
+
+// SplitEqually splits an expense count ways; used by the bot for messages like "三人平分"
+func (u *SplitExpenseUseCase) SplitEqually(ctx context.Context, expenseID, userID string, count int) (*SplitExpenseResponse, error) {
+	return u.Execute(ctx, &SplitExpenseRequest{
+		ExpenseID: expenseID,
+		UserID:    userID,
+		Count:     count,
+	})
+}
+
+// GetSplits returns an expense with its shares
+func (u *SplitExpenseUseCase) GetSplits(ctx context.Context, expenseID, userID string) (*SplitExpenseResponse, error) {
+	expense, err := getVisibleExpense(ctx, u.expenseRepo, u.groupRepo, expenseID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	splits, err := u.splitRepo.GetByExpenseID(ctx, expense.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get split: %w", err)
+	}
+	expense.Splits = splits
+
+	message := "Expense is not split"
+	if expense.IsSplit() {
+		message = formatSplitMessage(ctx, expense, splits)
+	}
+	return &SplitExpenseResponse{
+		Expense: expense,
+		Splits:  splits,
+		Message: message,
+	}, nil
+}
+
+// ParticipantBalance is the total a participant owes across split expenses
+type ParticipantBalance struct {
+	Participant string  `json:"participant"`
+	Amount      float64 `json:"amount"`
+}
+
+// SplitSummary lists what everyone owes a payer
+type SplitSummary struct {
+	UserID   string               `json:"user_id"`
+	Owed     []ParticipantBalance `json:"owed"`
+	Total    float64              `json:"total"`
+	Currency string               `json:"currency"`
+	Text     string               `json:"text"`
+}
+
+// GetSummary totals what each participant owes the user across the expenses they paid for
+func (u *SplitExpenseUseCase) GetSummary(ctx context.Context, userID string) (*SplitSummary, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	splits, err := u.splitRepo.GetByPayerID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get splits: %w", err)
+	}
+
+	owed := make(map[string]float64)
+	var order []string
+	total := 0.0
+	for _, split := range splits {
+		if !split.Owes() {
+			continue
+		}
+		if _, ok := owed[split.Participant]; !ok {
+			order = append(order, split.Participant)
+		}
+		owed[split.Participant] += split.Amount
+		total += split.Amount
+	}
+
+	currency := "TWD"
+	if len(splits) > 0 {
+		if expense, _ := u.expenseRepo.GetByID(ctx, splits[0].ExpenseID); expense != nil && expense.HomeCurrency != "" {
+			currency = expense.HomeCurrency
+		}
+	}
+
+	summary := &SplitSummary{UserID: userID, Total: roundCents(total), Currency: currency}
+	for _, participant := range order {
+		summary.Owed = append(summary.Owed, ParticipantBalance{Participant: participant, Amount: roundCents(owed[participant])})
+	}
+	sort.SliceStable(summary.Owed, func(i, j int) bool {
+		return summary.Owed[i].Amount > summary.Owed[j].Amount
+	})
+
+	var sb strings.Builder
+	if len(summary.Owed) == 0 {
+		sb.WriteString(translate(ctx, "split.nobody_owes"))
+	} else {
+		sb.WriteString(translate(ctx, "split.owed", formatMoney(ctx, summary.Total, currency)))
+		for _, balance := range summary.Owed {
+			sb.WriteString("\n" + translate(ctx, "split.owes", balance.Participant, formatMoney(ctx, balance.Amount, currency)))
+		}
+	}
+	summary.Text = sb.String()
+
+	return summary, nil
+}
+
+// defaultParticipants picks who shares a bill split count ways: the group's
+// members when the group is exactly that size, otherwise the payer and
+// numbered guests
+func (u *SplitExpenseUseCase) defaultParticipants(ctx context.Context, expense *domain.Expense, count int) ([]SplitParticipant, error) {
+	participants := []SplitParticipant{{Participant: expense.UserID}}
+
+	if expense.GroupID != nil && u.groupRepo != nil {
+		members, err := u.groupRepo.GetMembers(ctx, *expense.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group members: %w", err)
+		}
+		if len(members) == count {
+			for _, member := range members {
+				if member.UserID != expense.UserID {
+					participants = append(participants, SplitParticipant{Participant: member.UserID})
+				}
+			}
+			if len(participants) == count {
+				return participants, nil
+			}
+			participants = participants[:1]
+		}
+	}
+
+	for i := 2; i <= count; i++ {
+		participants = append(participants, SplitParticipant{Participant: fmt.Sprintf("Guest %d", i)})
+	}
+	return participants, nil
+}
+
+// buildSplits turns the requested shares into split records. Shares are rounded
+// to cents and any rounding remainder goes to the payer, or the first participant.
+func buildSplits(expense *domain.Expense, participants []SplitParticipant) ([]*domain.ExpenseSplit, error) {
+	if len(participants) < 2 {
+		return nil, fmt.Errorf("at least 2 participants are required")
+	}
+
+	total := expense.HomeAmount
+	if total == 0 {
+		total = expense.Amount
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("expense amount must be greater than 0")
+	}
+
+	seen := make(map[string]bool)
+	byAmount, byPercentage := 0, 0
+	for _, p := range participants {
+		name := strings.TrimSpace(p.Participant)
+		if name == "" {
+			return nil, fmt.Errorf("participant is required")
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate participant %q", name)
+		}
+		seen[name] = true
+		if p.Amount < 0 || p.Percentage < 0 {
+			return nil, fmt.Errorf("shares must not be negative")
+		}
+		if p.Amount > 0 {
+			byAmount++
+		}
+		if p.Percentage > 0 {
+			byPercentage++
+		}
+	}
+
+	shares := make([]float64, len(participants))
+	switch {
+	case byAmount == len(participants) && byPercentage == 0:
+		sum := 0.0
+		for i, p := range participants {
+			shares[i] = roundCents(p.Amount)
+			sum += shares[i]
+		}
+		if math.Abs(sum-total) > 0.01 {
+			return nil, fmt.Errorf("shares add up to %s but the expense is %s", formatAmount(roundCents(sum)), formatAmount(total))
+		}
+	case byPercentage == len(participants) && byAmount == 0:
+		sum := 0.0
+		for i, p := range participants {
+			shares[i] = roundCents(total * p.Percentage / 100)
+			sum += p.Percentage
+		}
+		if math.Abs(sum-100) > 0.01 {
+			return nil, fmt.Errorf("percentages add up to %s%%, not 100%%", formatAmount(roundCents(sum)))
+		}
+	case byAmount == 0 && byPercentage == 0:
+		share := math.Floor(total*100/float64(len(participants))) / 100
+		for i := range shares {
+			shares[i] = share
+		}
+	default:
+		return nil, fmt.Errorf("use either amounts, percentages or an equal split for every participant")
+	}
+
+	// Give the rounding remainder to the payer so the shares always add up
+	remainderIdx := 0
+	for i, p := range participants {
+		if strings.TrimSpace(p.Participant) == expense.UserID {
+			remainderIdx = i
+			break
+		}
+	}
+	sum := 0.0
+	for _, share := range shares {
+		sum += share
+	}
+	shares[remainderIdx] = roundCents(shares[remainderIdx] + total - sum)
+
+	now := time.Now()
+	splits := make([]*domain.ExpenseSplit, len(participants))
+	for i, p := range participants {
+		splits[i] = &domain.ExpenseSplit{
+			ID:          uuid.New().String(),
+			ExpenseID:   expense.ID,
+			PayerID:     expense.UserID,
+			Participant: strings.TrimSpace(p.Participant),
+			Amount:      shares[i],
+			Percentage:  roundCents(shares[i] / total * 100),
+			CreatedAt:   now,
+		}
+	}
+	return splits, nil
+}
+
+// formatSplitMessage describes a split for the bot reply
+func formatSplitMessage(ctx context.Context, expense *domain.Expense, splits []*domain.ExpenseSplit) string {
+	currency := expense.HomeCurrency
+	if currency == "" {
+		currency = "TWD"
+	}
+	total := 0.0
+	equal := true
+	for _, split := range splits {
+		total += split.Amount
+		if math.Abs(split.Amount-splits[0].Amount) > 0.01 {
+			equal = false
+		}
+	}
+
+	var sb strings.Builder
+	if equal {
+		sb.WriteString(translate(ctx, "split.equal", formatMoney(ctx, total, currency), len(splits), formatMoney(ctx, splits[0].Amount, currency)))
+	} else {
+		sb.WriteString(translate(ctx, "split.between", formatMoney(ctx, total, currency), len(splits)))
+	}
+	for _, split := range splits {
+		if split.Owes() {
+			sb.WriteString("\n" + translate(ctx, "split.owes", split.Participant, formatMoney(ctx, split.Amount, currency)))
+		}
+	}
+	return sb.String()
+}
+
+var splitCountPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`([0-9]+|[一二兩两三四五六七八九十]+)\s*(?:個)?人\s*(?:平分|均分|分攤|分摊|分)`),
+	regexp.MustCompile(`(?:平分|均分)\s*(?:給|给)?\s*([0-9]+|[一二兩两三四五六七八九十]+)\s*(?:個)?人`),
+	regexp.MustCompile(`(?i)split\s+(?:it\s+)?([0-9]+)\s+ways`),
+	regexp.MustCompile(`(?i)split\s+(?:it\s+)?(?:between|among|by)\s+([0-9]+)`),
+}
+
+// parseSplitCount detects a request to split a bill equally, e.g. "晚餐1200 三人平分"
+// or "dinner 1200 split 3 ways", and returns the number of ways, or 0
+func parseSplitCount(text string) int {
+	for _, pattern := range splitCountPatterns {
+		if m := pattern.FindStringSubmatch(text); m != nil {
+			if n := parseSmallNumber(m[1]); n >= 2 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// parseSmallNumber parses Arabic digits or a Chinese numeral up to 99
+func parseSmallNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+
+	digits := map[rune]int{'一': 1, '二': 2, '兩': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	runes := []rune(s)
+	switch {
+	case len(runes) == 1 && runes[0] == '十':
+		return 10
+	case len(runes) == 1:
+		return digits[runes[0]]
+	case len(runes) == 2 && runes[0] == '十':
+		return 10 + digits[runes[1]]
+	case len(runes) == 2 && runes[1] == '十':
+		return digits[runes[0]] * 10
+	case len(runes) == 3 && runes[1] == '十':
+		return digits[runes[0]]*10 + digits[runes[2]]
+	}
+	return 0
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+func newSplitTestUseCase(t *testing.T) (*SplitExpenseUseCase, *MockExpenseRepository, *MockGroupRepository) {
+	t.Helper()
+	expenseRepo := NewMockExpenseRepository()
+	groupRepo := NewMockGroupRepository()
+	uc := NewSplitExpenseUseCase(expenseRepo, NewMockExpenseSplitRepository(expenseRepo), groupRepo)
+
+	expenseRepo.Create(context.Background(), &domain.Expense{ID: "e1", UserID: "user1", Description: "晚餐", HomeAmount: 1200, HomeCurrency: "TWD"})
+	expenseRepo.Create(context.Background(), &domain.Expense{ID: "e2", UserID: "user1", Description: "Taxi", HomeAmount: 100, HomeCurrency: "TWD"})
+	return uc, expenseRepo, groupRepo
+}
+
+func TestSplitExpense(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "en")
+
+	t.Run("Equal split with guests", func(t *testing.T) {
+		uc, _, _ := newSplitTestUseCase(t)
+		resp, err := uc.SplitEqually(ctx, "e1", "user1", 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(resp.Splits) != 3 || resp.Splits[0].Participant != "user1" || resp.Splits[2].Participant != "Guest 3" {
+			t.Fatalf("unexpected participants: %+v", resp.Splits)
+		}
+		for _, split := range resp.Splits {
+			if split.Amount != 400 {
+				t.Errorf("expected 400 each, got %.2f for %s", split.Amount, split.Participant)
+			}
+		}
+		want := "✂️ Split NT$1,200 3 ways: NT$400 each\n• Guest 2 owes NT$400\n• Guest 3 owes NT$400"
+		if resp.Message != want {
+			t.Errorf("expected %q, got %q", want, resp.Message)
+		}
+	})
+
+	t.Run("Rounding remainder goes to payer", func(t *testing.T) {
+		uc, _, _ := newSplitTestUseCase(t)
+		resp, err := uc.SplitEqually(ctx, "e2", "user1", 3)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Splits[0].Amount != 33.34 || resp.Splits[1].Amount != 33.33 || resp.Splits[2].Amount != 33.33 {
+			t.Errorf("unexpected shares: %.2f %.2f %.2f", resp.Splits[0].Amount, resp.Splits[1].Amount, resp.Splits[2].Amount)
+		}
+	})
+
+	t.Run("Group members", func(t *testing.T) {
+		uc, expenseRepo, groupRepo := newSplitTestUseCase(t)
+		groups := NewGroupLedgerUseCase(groupRepo)
+		group, _ := groups.EnsureGroup(ctx, "line", "C1", "Family", "user1")
+		groups.EnsureGroup(ctx, "line", "C1", "Family", "user2")
+		expenseRepo.expenses["e1"].GroupID = &group.ID
+
+		resp, err := uc.SplitEqually(ctx, "e1", "user1", 2)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Splits[1].Participant != "user2" || resp.Splits[1].Amount != 600 {
+			t.Errorf("expected user2 to owe 600, got %+v", resp.Splits[1])
+		}
+
+		if _, err := uc.GetSplits(ctx, "e1", "user2"); err != nil {
+			t.Errorf("expected group member to see the split: %v", err)
+		}
+		if _, err := uc.SplitEqually(ctx, "e1", "user2", 2); err == nil {
+			t.Error("expected non-payer split to be rejected")
+		}
+	})
+
+	t.Run("Custom shares", func(t *testing.T) {
+		uc, _, _ := newSplitTestUseCase(t)
+		resp, err := uc.Execute(ctx, &SplitExpenseRequest{ExpenseID: "e1", UserID: "user1", Participants: []SplitParticipant{
+			{Participant: "user1", Percentage: 50},
+			{Participant: "Amy", Percentage: 25},
+			{Participant: "Ben", Percentage: 25},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if resp.Splits[0].Amount != 600 || resp.Splits[1].Amount != 300 {
+			t.Errorf("unexpected shares: %+v", resp.Splits)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		uc, _, _ := newSplitTestUseCase(t)
+		tests := []struct {
+			name string
+			req  *SplitExpenseRequest
+		}{
+			{name: "one way", req: &SplitExpenseRequest{ExpenseID: "e1", UserID: "user1", Count: 1}},
+			{name: "other user", req: &SplitExpenseRequest{ExpenseID: "e1", UserID: "user2", Count: 2}},
+			{name: "amounts mismatch", req: &SplitExpenseRequest{ExpenseID: "e1", UserID: "user1", Participants: []SplitParticipant{
+				{Participant: "user1", Amount: 500}, {Participant: "Amy", Amount: 500},
+			}}},
+			{name: "mixed modes", req: &SplitExpenseRequest{ExpenseID: "e1", UserID: "user1", Participants: []SplitParticipant{
+				{Participant: "user1", Amount: 600}, {Participant: "Amy", Percentage: 50},
+			}}},
+			{name: "duplicate", req: &SplitExpenseRequest{ExpenseID: "e1", UserID: "user1", Participants: []SplitParticipant{
+				{Participant: "Amy"}, {Participant: "Amy"},
+			}}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if _, err := uc.Execute(ctx, tt.req); err == nil {
+					t.Error("expected error")
+				}
+			})
+		}
+	})
+}
+
+func TestSplitSummary(t *testing.T) {
+	ctx := i18n.WithLocale(context.Background(), "en")
+	uc, _, _ := newSplitTestUseCase(t)
+
+	uc.SplitEqually(ctx, "e1", "user1", 3)
+	uc.Execute(ctx, &SplitExpenseRequest{ExpenseID: "e2", UserID: "user1", Participants: []SplitParticipant{
+		{Participant: "user1", Amount: 40},
+		{Participant: "Guest 2", Amount: 60},
+	}})
+
+	summary, err := uc.GetSummary(ctx, "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Total != 860 || len(summary.Owed) != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if summary.Owed[0].Participant != "Guest 2" || summary.Owed[0].Amount != 460 {
+		t.Errorf("expected Guest 2 to owe 460 first, got %+v", summary.Owed[0])
+	}
+	want := "💰 You are owed NT$860\n• Guest 2 owes NT$460\n• Guest 3 owes NT$400"
+	if summary.Text != want {
+		t.Errorf("expected %q, got %q", want, summary.Text)
+	}
+
+	summary, _ = uc.GetSummary(context.Background(), "user1")
+	want = "💰 別人共欠你 NT$860\n• Guest 2 欠 NT$460\n• Guest 3 欠 NT$400"
+	if summary.Text != want {
+		t.Errorf("expected the default locale, got %q", summary.Text)
+	}
+}
+
+func TestParseSplitCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"晚餐1200 三人平分", 3},
+		{"午餐 600 2人均分", 2},
+		{"KTV 3000 十二個人分攤", 12},
+		{"火鍋 2000 平分給四人", 4},
+		{"dinner 1200 split 3 ways", 3},
+		{"pizza 500 split between 5", 5},
+		{"晚餐 1200", 0},
+		{"一人平分", 0},
+	}
+	for _, tt := range tests {
+		if got := parseSplitCount(tt.text); got != tt.want {
+			t.Errorf("parseSplitCount(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 page size and margin, in points
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+)
+
+// Fonts available to statement pages. Helvetica covers plain ASCII text; text
+// with other characters, such as Chinese category names, uses the viewer's
+// Traditional Chinese font so nothing has to be embedded.
+const (
+	pdfFontRegular = "F1"
+	pdfFontBold    = "F2"
+	pdfFontCJK     = "F3"
+)
+
+// pdfDocument builds a minimal multi-page PDF of text, lines and filled boxes
+type pdfDocument struct {
+	pages []*bytes.Buffer
+}
+
+func newPDFDocument() *pdfDocument {
+	doc := &pdfDocument{}
+	doc.addPage()
+	return doc
+}
+
+func (d *pdfDocument) addPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *pdfDocument) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// text draws s with its baseline starting at (x, y); bold is ignored for CJK text
+func (d *pdfDocument) text(x, y, size float64, bold bool, s string) {
+	font, encoded := pdfFontRegular, pdfLiteral(s)
+	if !isPDFASCII(s) {
+		font, encoded = pdfFontCJK, pdfUTF16Hex(s)
+	} else if bold {
+		font = pdfFontBold
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, encoded)
+}
+
+// textRight draws s so it ends at x
+func (d *pdfDocument) textRight(x, y, size float64, bold bool, s string) {
+	d.text(x-pdfTextWidth(s, size), y, size, bold, s)
+}
+
+// line draws a thin grey line from (x1, y1) to (x2, y2)
+func (d *pdfDocument) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page(), "0.6 G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n", x1, y1, x2, y2)
+}
+
+// rect fills a box whose lower left corner is (x, y) with an RGB colour
+func (d *pdfDocument) rect(x, y, w, h, r, g, b float64) {
+	fmt.Fprintf(d.page(), "%.2f %.2f %.2f rg %.2f %.2f %.2f %.2f re f 0 g\n", r, g, b, x, y, w, h)
+}
+
+// bytes serializes the document
+func (d *pdfDocument) bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-7 are the catalog, page tree and fonts; each page then takes
+	// two objects, the page and its content stream
+	pageIDs := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageIDs[i] = fmt.Sprintf("%d 0 R", 8+2*i)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageIDs, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type0 /BaseFont /MSung-Light /Encoding /UniCNS-UCS2-H /DescendantFonts [6 0 R] >>")
+	object("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /MSung-Light " +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (CNS1) /Supplement 0 >> /FontDescriptor 7 0 R /DW 1000 >>")
+	object("<< /Type /FontDescriptor /FontName /MSung-Light /Flags 6 /FontBBox [0 -200 1000 900] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	for _, content := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, len(offsets)+2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+func isPDFASCII(s string) bool {
+	for _, r := range s {
+		if r > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// pdfLiteral encodes ASCII text as a PDF string literal
+func pdfLiteral(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`)
+	return "(" + replacer.Replace(s) + ")"
+}
+
+// pdfUTF16Hex encodes text for the CJK font. Characters outside the Basic
+// Multilingual Plane, such as emoji, cannot be shown and are dropped.
+func pdfUTF16Hex(s string) string {
+	var sb strings.Builder
+	sb.WriteString("<")
+	for _, r := range s {
+		if r > 0xFFFF {
+			continue
+		}
+		for _, unit := range utf16.Encode([]rune{r}) {
+			fmt.Fprintf(&sb, "%04X", unit)
+		}
+	}
+	sb.WriteString(">")
+	return sb.String()
+}
+
+// pdfTextWidth estimates the width of s in points: exact for the Helvetica
+// glyphs used in amounts, full width for CJK characters
+func pdfTextWidth(s string, size float64) float64 {
+	units := 0
+	for _, r := range s {
+		switch {
+		case r == '.' || r == ',' || r == ' ' || r == '/' || r == ':':
+			units += 278
+		case r == '%':
+			units += 889
+		case r == '-':
+			units += 333
+		case r > 126:
+			units += 1000
+		default:
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// truncatePDFText shortens s to fit within width points
+func truncatePDFText(s string, size, width float64) string {
+	if pdfTextWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
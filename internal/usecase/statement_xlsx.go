@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsxMaxSheetName is the longest sheet name spreadsheet applications accept
+const xlsxMaxSheetName = 31
+
+// xlsxCell is one worksheet cell: text, a number, or a formula with its cached result
+type xlsxCell struct {
+	text    string
+	number  float64
+	formula string
+	isText  bool
+	style   int
+}
+
+func xlsxText(s string, style int) xlsxCell {
+	return xlsxCell{text: s, isText: true, style: style}
+}
+
+func xlsxNumber(n float64, style int) xlsxCell {
+	return xlsxCell{number: n, style: style}
+}
+
+func xlsxFormula(formula string, cached float64, style int) xlsxCell {
+	return xlsxCell{formula: formula, number: cached, style: style}
+}
+
+// xlsxSheet is a worksheet of rows starting at A1
+type xlsxSheet struct {
+	name   string
+	widths []float64
+	rows   [][]xlsxCell
+}
+
+func (s *xlsxSheet) addRow(cells ...xlsxCell) {
+	s.rows = append(s.rows, cells)
+}
+
+// xlsxStyle is a cell format: a number format code and whether the text is bold
+type xlsxStyle struct {
+	numFmt string
+	bold   bool
+}
+
+// xlsxWorkbook builds a minimal Office Open XML spreadsheet
+type xlsxWorkbook struct {
+	sheets []*xlsxSheet
+	styles []xlsxStyle
+}
+
+func newXLSXWorkbook() *xlsxWorkbook {
+	// Style 0 is the default format
+	return &xlsxWorkbook{styles: []xlsxStyle{{}}}
+}
+
+// style returns the index of the cell format, registering it on first use
+func (w *xlsxWorkbook) style(numFmt string, bold bool) int {
+	for i, style := range w.styles {
+		if style.numFmt == numFmt && style.bold == bold {
+			return i
+		}
+	}
+	w.styles = append(w.styles, xlsxStyle{numFmt: numFmt, bold: bold})
+	return len(w.styles) - 1
+}
+
+// addSheet adds a worksheet, making name valid and unique
+func (w *xlsxWorkbook) addSheet(name string, widths ...float64) *xlsxSheet {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.Trim(name, "'"))
+	if name == "" {
+		name = "Sheet"
+	}
+
+	base := []rune(name)
+	if len(base) > xlsxMaxSheetName {
+		base = base[:xlsxMaxSheetName]
+	}
+	unique := string(base)
+	for n := 2; w.hasSheet(unique); n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		trimmed := base
+		if len(trimmed)+len(suffix) > xlsxMaxSheetName {
+			trimmed = trimmed[:xlsxMaxSheetName-len(suffix)]
+		}
+		unique = string(trimmed) + suffix
+	}
+
+	sheet := &xlsxSheet{name: unique, widths: widths}
+	w.sheets = append(w.sheets, sheet)
+	return sheet
+}
+
+func (w *xlsxWorkbook) hasSheet(name string) bool {
+	for _, sheet := range w.sheets {
+		if strings.EqualFold(sheet.name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// bytes serializes the workbook as an .xlsx file
+func (w *xlsxWorkbook) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	write := func(name, content string) error {
+		f, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write([]byte(xml.Header + content))
+		return err
+	}
+
+	var overrides, sheets, rels strings.Builder
+	for i, sheet := range w.sheets {
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xlsxEscape(sheet.name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			overrides.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets><calcPr fullCalcOnLoad="1"/></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+		{"xl/styles.xml", w.stylesXML()},
+	}
+	for i, sheet := range w.sheets {
+		parts = append(parts, struct {
+			name    string
+			content string
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.xml()})
+	}
+
+	for _, part := range parts {
+		if err := write(part.name, part.content); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// stylesXML renders the registered cell formats. Custom number formats take
+// IDs from 164, the first one not reserved for built-in formats.
+func (w *xlsxWorkbook) stylesXML() string {
+	var numFmts, xfs strings.Builder
+	fmtIDs := make(map[string]int)
+	for _, style := range w.styles {
+		if style.numFmt == "" || fmtIDs[style.numFmt] != 0 {
+			continue
+		}
+		fmtIDs[style.numFmt] = 164 + len(fmtIDs)
+		fmt.Fprintf(&numFmts, `<numFmt numFmtId="%d" formatCode="%s"/>`, fmtIDs[style.numFmt], xlsxEscape(style.numFmt))
+	}
+	for _, style := range w.styles {
+		fontID := 0
+		if style.bold {
+			fontID = 1
+		}
+		fmt.Fprintf(&xfs, `<xf numFmtId="%d" fontId="%d" fillId="0" borderId="0" xfId="0"`, fmtIDs[style.numFmt], fontID)
+		if style.numFmt != "" {
+			xfs.WriteString(` applyNumberFormat="1"`)
+		}
+		if style.bold {
+			xfs.WriteString(` applyFont="1"`)
+		}
+		xfs.WriteString(`/>`)
+	}
+
+	return `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		fmt.Sprintf(`<numFmts count="%d">%s</numFmts>`, len(fmtIDs), numFmts.String()) +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		fmt.Sprintf(`<cellXfs count="%d">%s</cellXfs>`, len(w.styles), xfs.String()) +
+		`</styleSheet>`
+}
+
+func (s *xlsxSheet) xml() string {
+	var sb strings.Builder
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(s.widths) > 0 {
+		sb.WriteString(`<cols>`)
+		for i, width := range s.widths {
+			fmt.Fprintf(&sb, `<col min="%d" max="%d" width="%.1f" customWidth="1"/>`, i+1, i+1, width)
+		}
+		sb.WriteString(`</cols>`)
+	}
+	sb.WriteString(`<sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&sb, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			ref := xlsxCellRef(c, r+1)
+			switch {
+			case cell.isText:
+				fmt.Fprintf(&sb, `<c r="%s" s="%d" t="inlineStr"><is><t>%s</t></is></c>`, ref, cell.style, xlsxEscape(cell.text))
+			case cell.formula != "":
+				fmt.Fprintf(&sb, `<c r="%s" s="%d"><f>%s</f><v>%s</v></c>`, ref, cell.style, xlsxEscape(cell.formula), xlsxNumberValue(cell.number))
+			default:
+				fmt.Fprintf(&sb, `<c r="%s" s="%d"><v>%s</v></c>`, ref, cell.style, xlsxNumberValue(cell.number))
+			}
+		}
+		sb.WriteString(`</row>`)
+	}
+	sb.WriteString(`</sheetData></worksheet>`)
+	return sb.String()
+}
+
+// xlsxCellRef returns the A1-style reference of a zero-based column and one-based row
+func xlsxCellRef(col, row int) string {
+	return xlsxColumn(col) + strconv.Itoa(row)
+}
+
+func xlsxColumn(col int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name
+}
+
+// xlsxSheetRef quotes a sheet name for use in a formula, e.g. 'Food & Drink'!C2
+func xlsxSheetRef(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// xlsxDate converts a date to a spreadsheet serial number
+func xlsxDate(t time.Time) float64 {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.Sub(epoch).Hours() / 24
+}
+
+// xlsxCurrencyFormat is the number format for amounts in a currency, e.g. #,##0.00 "TWD"
+func xlsxCurrencyFormat(currency string) string {
+	if currency == "" {
+		return "#,##0.00"
+	}
+	return `#,##0.00 "` + strings.ReplaceAll(currency, `"`, "") + `"`
+}
+
+func xlsxNumberValue(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func xlsxEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// DefaultSummaryCacheTTL bounds how stale a cached summary can get when a
+// change bypasses the event bus, such as an import or a recurring expense
+const DefaultSummaryCacheTTL = 5 * time.Minute
+
+// SummaryCache keeps reports and budget statuses of a user or group ledger so
+// repeated requests skip the aggregate scans. Expense events and budget
+// changes invalidate the ledger's summaries.
+//
+// Summaries are stored under a per-ledger version that invalidation drops, so
+// a summary computed before a change and stored after it is never read.
+type SummaryCache struct {
+	cache domain.Cache
+	ttl   time.Duration
+}
+
+// NewSummaryCache creates a summary cache keeping entries for ttl
+func NewSummaryCache(cache domain.Cache, ttl time.Duration) *SummaryCache {
+	if ttl <= 0 {
+		ttl = DefaultSummaryCacheTTL
+	}
+	return &SummaryCache{cache: cache, ttl: ttl}
+}
+
+// userLedger and groupLedger name the scopes summaries are invalidated by
+func userLedger(userID string) string   { return "user:" + userID }
+func groupLedger(groupID string) string { return "group:" + groupID }
+
+// HandleExpenseEvent invalidates the summaries of the ledgers an expense
+// event changed: its user's and, for a shared expense, its group's
+func (c *SummaryCache) HandleExpenseEvent(ctx context.Context, userID string, event UserEvent) {
+	c.Invalidate(ctx, userLedger(userID))
+	if data, ok := event.Data.(*ExpenseEvent); ok && data.GroupID != nil {
+		c.Invalidate(ctx, groupLedger(*data.GroupID))
+	}
+}
+
+// Invalidate drops every summary cached for the ledger
+func (c *SummaryCache) Invalidate(ctx context.Context, ledger string) {
+	if err := c.cache.Delete(ctx, c.versionKey(ledger)); err != nil {
+		slog.WarnContext(ctx, "Failed to invalidate cached summaries", "ledger", ledger, "error", err)
+	}
+}
+
+func (c *SummaryCache) versionKey(ledger string) string {
+	return "summary:" + ledger + ":version"
+}
+
+// version returns the ledger's current version, starting a new one when it
+// has none
+func (c *SummaryCache) version(ctx context.Context, ledger string) (string, error) {
+	key := c.versionKey(ledger)
+	if version, ok, err := c.cache.Get(ctx, key); err != nil || ok {
+		return string(version), err
+	}
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	return version, c.cache.Set(ctx, key, []byte(version), c.ttl)
+}
+
+// cachedSummary returns the ledger's summary stored under key, or computes
+// and stores it. A nil cache or a failing one computes the summary directly.
+func cachedSummary[T any](ctx context.Context, c *SummaryCache, ledger, key string, compute func() (*T, error)) (*T, error) {
+	if c == nil {
+		return compute()
+	}
+
+	version, err := c.version(ctx, ledger)
+	if err != nil {
+		slog.WarnContext(ctx, "Summary cache unavailable", "error", err)
+		return compute()
+	}
+	key = "summary:" + ledger + ":" + version + ":" + key
+	// Listings narrow to the workspace ctx carries, so summaries are kept per workspace
+	if workspaceID, ok := domain.WorkspaceFromContext(ctx); ok {
+		key += ":workspace=" + workspaceID
+	}
+
+	if data, ok, err := c.cache.Get(ctx, key); err == nil && ok {
+		var summary T
+		if err := json.Unmarshal(data, &summary); err == nil {
+			return &summary, nil
+		}
+	}
+
+	summary, err := compute()
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(summary); err == nil {
+		if err := c.cache.Set(ctx, key, data, c.ttl); err != nil {
+			slog.WarnContext(ctx, "Failed to cache summary", "error", err)
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// countingExpenseRepository counts the aggregate scans summaries are built from
+type countingExpenseRepository struct {
+	*MockExpenseRepository
+	scans int
+}
+
+func (r *countingExpenseRepository) SumByCategoryAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.CategoryTotal, error) {
+	r.scans++
+	return r.MockExpenseRepository.SumByCategoryAndDateRange(ctx, query)
+}
+
+func TestSummaryCacheReports(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := &countingExpenseRepository{MockExpenseRepository: NewMockExpenseRepository()}
+	summaries := NewSummaryCache(NewMockCache(), time.Minute)
+	uc := NewGenerateReportUseCase(expenseRepo, NewMockCategoryRepository(), nil, NewMockGroupRepository())
+	uc.SetSummaryCache(summaries)
+
+	expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Amount: 100, ExpenseDate: time.Now()})
+
+	first, err := uc.GenerateMonthlyReport(ctx, "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := uc.GenerateMonthlyReport(ctx, "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expenseRepo.scans != 1 || second.TotalExpenses != first.TotalExpenses {
+		t.Errorf("expected the second report from the cache, got %d scans and total %.2f", expenseRepo.scans, second.TotalExpenses)
+	}
+
+	if _, err := uc.GenerateMonthlyReport(ctx, "user2"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expenseRepo.scans != 2 {
+		t.Errorf("expected another user's report to be computed, got %d scans", expenseRepo.scans)
+	}
+
+	expenseRepo.Create(ctx, &domain.Expense{ID: "exp2", UserID: "user1", Amount: 50, ExpenseDate: time.Now()})
+	summaries.HandleExpenseEvent(ctx, "user1", UserEvent{Type: domain.EventExpenseCreated, Data: &ExpenseEvent{ExpenseID: "exp2"}})
+
+	third, err := uc.GenerateMonthlyReport(ctx, "user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expenseRepo.scans != 3 || third.TotalExpenses != 150 {
+		t.Errorf("expected the report to be recomputed after expense.created, got %d scans and total %.2f", expenseRepo.scans, third.TotalExpenses)
+	}
+}
+
+func TestSummaryCacheBudgetStatus(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := &countingExpenseRepository{MockExpenseRepository: NewMockExpenseRepository()}
+	uc := NewBudgetManagementUseCase(NewMockBudgetRepository(), NewMockCategoryRepository(), expenseRepo, NewMockGroupRepository())
+	uc.SetSummaryCache(NewSummaryCache(NewMockCache(), time.Minute))
+
+	expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Amount: 100, ExpenseDate: time.Now()})
+	if _, err := uc.CreateBudget(ctx, &CreateBudgetRequest{UserID: "user1", Limit: 1000}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := uc.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: "user1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scans := expenseRepo.scans
+	status, err := uc.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: "user1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expenseRepo.scans != scans || len(status.Budgets) != 1 || status.TotalSpent != 100 {
+		t.Errorf("expected the cached status, got %d new scans and %+v", expenseRepo.scans-scans, status)
+	}
+
+	limit := 2000.0
+	if _, err := uc.UpdateBudget(ctx, &UpdateBudgetRequest{ID: status.Budgets[0].ID, UserID: "user1", Limit: &limit}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status, err = uc.GetBudgetStatus(ctx, &GetBudgetStatusRequest{UserID: "user1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Budgets[0].Limit != 2000 {
+		t.Errorf("expected the status to be recomputed after the budget update, got limit %.2f", status.Budgets[0].Limit)
+	}
+}
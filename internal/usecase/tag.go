@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ErrInvalidTagName is returned for a tag name NormalizeTagName rejects
+var ErrInvalidTagName = fmt.Errorf("tag names are letters, digits and underscores, up to %d characters", domain.MaxTagNameLength)
+
+// TagUseCase manages a user's tags and the expenses they label
+type TagUseCase struct {
+	tagRepo     domain.TagRepository
+	expenseRepo domain.ExpenseRepository
+	summaries   *SummaryCache
+	now         func() time.Time
+}
+
+// NewTagUseCase creates a new tag use case
+func NewTagUseCase(tagRepo domain.TagRepository, expenseRepo domain.ExpenseRepository) *TagUseCase {
+	return &TagUseCase{
+		tagRepo:     tagRepo,
+		expenseRepo: expenseRepo,
+		now:         time.Now,
+	}
+}
+
+// SetSummaryCache drops cached reports when tags change, since reports break
+// spending down by tag
+func (u *TagUseCase) SetSummaryCache(summaries *SummaryCache) {
+	u.summaries = summaries
+}
+
+// invalidateReports drops the cached reports of the user's ledger, and of the
+// expense's group ledger when there is one
+func (u *TagUseCase) invalidateReports(ctx context.Context, userID string, expense *domain.Expense) {
+	if u.summaries == nil {
+		return
+	}
+	u.summaries.Invalidate(ctx, userLedger(userID))
+	if expense != nil && expense.GroupID != nil {
+		u.summaries.Invalidate(ctx, groupLedger(*expense.GroupID))
+	}
+}
+
+// List returns the user's tags, by name
+func (u *TagUseCase) List(ctx context.Context, userID string) ([]*domain.Tag, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	tags, err := u.tagRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	return tags, nil
+}
+
+// Create creates a tag; the user can't already have one of the same name
+func (u *TagUseCase) Create(ctx context.Context, userID, name string) (*domain.Tag, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	normalized := domain.NormalizeTagName(name)
+	if normalized == "" {
+		return nil, ErrInvalidTagName
+	}
+	tag := &domain.Tag{ID: uuid.New().String(), UserID: userID, Name: normalized, CreatedAt: u.now()}
+	if err := u.tagRepo.Create(ctx, tag); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, fmt.Errorf("tag '%s' %w", normalized, domain.ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return tag, nil
+}
+
+// Rename renames one of the user's tags, which relabels its expenses
+func (u *TagUseCase) Rename(ctx context.Context, userID, id, name string) (*domain.Tag, error) {
+	normalized := domain.NormalizeTagName(name)
+	if normalized == "" {
+		return nil, ErrInvalidTagName
+	}
+	tag, err := u.get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	tag.Name = normalized
+	if err := u.tagRepo.Update(ctx, tag); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, fmt.Errorf("tag '%s' %w", normalized, domain.ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to rename tag: %w", err)
+	}
+	u.invalidateReports(ctx, userID, nil)
+	return tag, nil
+}
+
+// Delete deletes one of the user's tags, removing it from its expenses
+func (u *TagUseCase) Delete(ctx context.Context, userID, id string) error {
+	if _, err := u.get(ctx, userID, id); err != nil {
+		return err
+	}
+	if err := u.tagRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	u.invalidateReports(ctx, userID, nil)
+	return nil
+}
+
+// SetExpenseTags replaces the tags of one of the user's expenses, creating
+// the tags the user doesn't have yet, and returns the names it now carries
+func (u *TagUseCase) SetExpenseTags(ctx context.Context, userID, expenseID string, names []string) ([]string, error) {
+	expense, err := u.expenseRepo.GetByID(ctx, expenseID)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && expense.UserID != userID) {
+		return nil, fmt.Errorf("expense %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+
+	normalized := domain.NormalizeTagNames(names)
+	if len(normalized) != len(names) {
+		for _, name := range names {
+			if domain.NormalizeTagName(name) == "" {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidTagName, name)
+			}
+		}
+	}
+	if err := tagExpense(ctx, u.tagRepo, userID, expenseID, normalized, u.now()); err != nil {
+		return nil, err
+	}
+	u.invalidateReports(ctx, userID, expense)
+	return normalized, nil
+}
+
+// get returns one of the user's tags; other users' tags are not found
+func (u *TagUseCase) get(ctx context.Context, userID, id string) (*domain.Tag, error) {
+	tag, err := u.tagRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && tag.UserID != userID) {
+		return nil, fmt.Errorf("tag %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	return tag, nil
+}
+
+// tagExpense sets an expense's tags by normalized name, creating the tags the
+// user doesn't have yet
+func tagExpense(ctx context.Context, tagRepo domain.TagRepository, userID, expenseID string, names []string, now time.Time) error {
+	tagIDs := make([]string, 0, len(names))
+	for _, name := range names {
+		tag, err := tagRepo.GetByUserIDAndName(ctx, userID, name)
+		if errors.Is(err, domain.ErrNotFound) {
+			tag = &domain.Tag{ID: uuid.New().String(), UserID: userID, Name: name, CreatedAt: now}
+			err = tagRepo.Create(ctx, tag)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to resolve tag %q: %w", name, err)
+		}
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	if err := tagRepo.SetExpenseTags(ctx, expenseID, tagIDs); err != nil {
+		return fmt.Errorf("failed to tag expense: %w", err)
+	}
+	return nil
+}
+
+// loadExpenseTags sets the Tags of expenses from the tag repository
+func loadExpenseTags(ctx context.Context, tagRepo domain.TagRepository, expenses []*domain.Expense) error {
+	if tagRepo == nil || len(expenses) == 0 {
+		return nil
+	}
+	ids := make([]string, len(expenses))
+	for i, expense := range expenses {
+		ids[i] = expense.ID
+	}
+	names, err := tagRepo.GetNamesByExpenseIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load tags: %w", err)
+	}
+	for _, expense := range expenses {
+		expense.Tags = names[expense.ID]
+	}
+	return nil
+}
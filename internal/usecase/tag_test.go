@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagUseCase(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	tagRepo := NewMockTagRepository(expenseRepo)
+	require.NoError(t, expenseRepo.Create(ctx, &domain.Expense{ID: "exp1", UserID: "user1", Description: "Taxi", Amount: 300, HomeAmount: 300, ExpenseDate: time.Now()}))
+	uc := NewTagUseCase(tagRepo, expenseRepo)
+
+	tag, err := uc.Create(ctx, "user1", "#Travel")
+	require.NoError(t, err)
+	assert.Equal(t, "travel", tag.Name)
+	_, err = uc.Create(ctx, "user1", "travel")
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	_, err = uc.Create(ctx, "user1", "a b")
+	assert.ErrorIs(t, err, ErrInvalidTagName)
+
+	// Tags the user doesn't have yet are created
+	names, err := uc.SetExpenseTags(ctx, "user1", "exp1", []string{"#Travel", "報帳"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"travel", "報帳"}, names)
+	tags, err := uc.List(ctx, "user1")
+	require.NoError(t, err)
+	assert.Len(t, tags, 2)
+	_, err = uc.SetExpenseTags(ctx, "user2", "exp1", []string{"travel"})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = uc.SetExpenseTags(ctx, "user1", "exp1", []string{"no-dashes"})
+	assert.ErrorIs(t, err, ErrInvalidTagName)
+
+	// Renaming relabels the expense, deleting removes the tag from it
+	_, err = uc.Rename(ctx, "user1", tag.ID, "Trip")
+	require.NoError(t, err)
+	expense, err := expenseRepo.GetByID(ctx, "exp1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"trip", "報帳"}, expense.Tags)
+	assert.ErrorIs(t, uc.Delete(ctx, "user2", tag.ID), domain.ErrNotFound)
+	require.NoError(t, uc.Delete(ctx, "user1", tag.ID))
+	expense, err = expenseRepo.GetByID(ctx, "exp1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"報帳"}, expense.Tags)
+}
+
+func TestTagsAcrossUseCases(t *testing.T) {
+	ctx := context.Background()
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	tagRepo := NewMockTagRepository(expenseRepo)
+	createUC := NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, NewMockAIService())
+	createUC.SetTagRepository(tagRepo)
+
+	now := time.Now()
+	for _, req := range []*CreateRequest{
+		{UserID: "user1", Description: "Taxi", Amount: 300, Date: now, Tags: []string{"#出差", "報帳"}},
+		{UserID: "user1", Description: "Hotel", Amount: 2000, Date: now, Tags: []string{"出差"}},
+		{UserID: "user1", Description: "Lunch", Amount: 120, Date: now},
+	} {
+		resp, err := createUC.Execute(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, domain.NormalizeTagNames(req.Tags), resp.Tags)
+	}
+
+	// Hashtags in a search query filter by tag
+	searchUC := NewSearchExpenseUseCase(expenseRepo, categoryRepo)
+	searchUC.SetTagRepository(tagRepo)
+	resp, err := searchUC.Search(ctx, &SearchRequest{UserID: "user1", Query: "taxi #報帳"})
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 1)
+	assert.ElementsMatch(t, []string{"出差", "報帳"}, resp.Results[0].Tags)
+	filtered, err := searchUC.Filter(ctx, &FilterRequest{UserID: "user1", Tags: []string{"出差"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, filtered.Count)
+	assert.Equal(t, 2300.0, filtered.Total)
+
+	// Reports break spending down by tag
+	reportUC := NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil)
+	reportUC.SetTagRepository(tagRepo)
+	report, err := reportUC.Execute(ctx, &ReportRequest{UserID: "user1", ReportType: "daily", StartDate: now.Add(-time.Hour), EndDate: now.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, report.TagBreakdown, 2)
+	assert.Equal(t, "出差", report.TagBreakdown[0].Tag)
+	assert.Equal(t, 2300.0, report.TagBreakdown[0].Total)
+	assert.Equal(t, 2, report.TagBreakdown[0].Count)
+	assert.Equal(t, "報帳", report.TagBreakdown[1].Tag)
+	assert.Equal(t, 300.0, report.TagBreakdown[1].Total)
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// ErrUnknownTimezone is returned for a timezone that isn't an IANA name or a
+// city we know
+var ErrUnknownTimezone = errors.New("unknown timezone")
+
+// timezoneAliases map city names typed instead of IANA names
+var timezoneAliases = map[string]string{
+	"台北": "Asia/Taipei", "臺北": "Asia/Taipei", "taipei": "Asia/Taipei", "台灣": "Asia/Taipei", "taiwan": "Asia/Taipei",
+	"北京": "Asia/Shanghai", "上海": "Asia/Shanghai", "beijing": "Asia/Shanghai", "shanghai": "Asia/Shanghai",
+	"香港": "Asia/Hong_Kong", "hong kong": "Asia/Hong_Kong",
+	"東京": "Asia/Tokyo", "东京": "Asia/Tokyo", "tokyo": "Asia/Tokyo", "日本": "Asia/Tokyo", "japan": "Asia/Tokyo",
+	"新加坡": "Asia/Singapore", "singapore": "Asia/Singapore",
+	"london": "Europe/London", "倫敦": "Europe/London",
+	"new york": "America/New_York", "紐約": "America/New_York",
+	"los angeles": "America/Los_Angeles", "洛杉磯": "America/Los_Angeles",
+	"utc": "UTC",
+}
+
+// TimezoneUseCase keeps track of the timezone each user's dates are read in:
+// the one their messenger reports, until they set their own
+type TimezoneUseCase struct {
+	userRepo domain.UserRepository
+}
+
+// NewTimezoneUseCase creates a new timezone use case
+func NewTimezoneUseCase(userRepo domain.UserRepository) *TimezoneUseCase {
+	return &TimezoneUseCase{userRepo: userRepo}
+}
+
+// Locate returns the user's timezone. reported is the timezone their
+// messenger reports, if any; it is saved when the user has none yet. The
+// server's timezone is returned when the user has none at all.
+func (u *TimezoneUseCase) Locate(ctx context.Context, userID, reported string) *time.Location {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get user timezone", "error", err)
+		return time.Local
+	}
+	if user.Timezone != "" || reported == "" {
+		return user.Location()
+	}
+
+	name, loc, err := loadTimezone(reported)
+	if err != nil {
+		return time.Local
+	}
+	user.Timezone = name
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		slog.WarnContext(ctx, "Failed to save detected timezone", "timezone", name, "error", err)
+	}
+	return loc
+}
+
+// Set changes the user's timezone to an IANA name such as Asia/Taipei, or a
+// city such as 東京
+func (u *TimezoneUseCase) Set(ctx context.Context, userID, name string) (*time.Location, error) {
+	name, loc, err := loadTimezone(name)
+	if err != nil {
+		return nil, err
+	}
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.Timezone = name
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return loc, nil
+}
+
+// loadTimezone resolves a timezone name or city to its IANA name and location
+func loadTimezone(name string) (string, *time.Location, error) {
+	name = strings.TrimSpace(name)
+	if alias, ok := timezoneAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	// "" and "Local" load the server's timezone, which isn't the user's
+	if name == "" || name == "Local" {
+		return "", nil, ErrUnknownTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return "", nil, ErrUnknownTimezone
+	}
+	return loc.String(), loc, nil
+}
@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimezoneUseCase(t *testing.T) {
+	ctx := context.Background()
+	userRepo := NewMockUserRepository()
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "teams", CreatedAt: time.Now()}))
+	uc := NewTimezoneUseCase(userRepo)
+
+	// Without a timezone of their own, users get the server's; an unknown
+	// reported timezone isn't saved
+	assert.Equal(t, time.Local, uc.Locate(ctx, "user1", ""))
+	assert.Equal(t, time.Local, uc.Locate(ctx, "user1", "Mars/Olympus"))
+
+	assert.Equal(t, "Asia/Tokyo", uc.Locate(ctx, "user1", "Asia/Tokyo").String())
+	// Once saved, the reported timezone doesn't replace it
+	assert.Equal(t, "Asia/Tokyo", uc.Locate(ctx, "user1", "Europe/London").String())
+
+	loc, err := uc.Set(ctx, "user1", " New York ")
+	require.NoError(t, err)
+	assert.Equal(t, "America/New_York", loc.String())
+	assert.Equal(t, "America/New_York", uc.Locate(ctx, "user1", "").String())
+
+	for _, name := range []string{"", "Local", "Mars/Olympus"} {
+		_, err := uc.Set(ctx, "user1", name)
+		assert.ErrorIs(t, err, ErrUnknownTimezone, name)
+	}
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/riverlin/aiexpense/internal/ai"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// TranscribeAudioUseCase converts voice messages to text with cost tracking
+type TranscribeAudioUseCase struct {
+	transcriber ai.Transcriber
+	pricingRepo domain.PricingRepository
+	costRepo    domain.AICostRepository
+	provider    string // e.g., "gemini", "openai"
+	model       string // e.g., "whisper-1"
+}
+
+// NewTranscribeAudioUseCase creates a new transcribe audio use case
+func NewTranscribeAudioUseCase(
+	transcriber ai.Transcriber,
+	pricingRepo domain.PricingRepository,
+	costRepo domain.AICostRepository,
+	provider string,
+	model string,
+) *TranscribeAudioUseCase {
+	return &TranscribeAudioUseCase{
+		transcriber: transcriber,
+		pricingRepo: pricingRepo,
+		costRepo:    costRepo,
+		provider:    provider,
+		model:       model,
+	}
+}
+
+// Execute transcribes a voice message and returns the spoken text
+func (u *TranscribeAudioUseCase) Execute(ctx context.Context, audio *domain.Attachment, userID string) (string, error) {
+	if audio == nil || len(audio.Data) == 0 {
+		return "", fmt.Errorf("voice message is empty")
+	}
+
+	resp, err := u.transcriber.Transcribe(ctx, audio.Data, audio.MimeType)
+	if err != nil {
+		return "", fmt.Errorf("failed to transcribe voice message: %w", err)
+	}
+
+	// Log in the background, keeping the messenger the message came from
+	logCtx := domain.WithAuditSource(context.Background(), domain.AuditSourceFromContext(ctx))
+	go logAICost(logCtx, u.pricingRepo, u.costRepo, u.provider, u.model, userID, "transcribe_audio", resp.Tokens, resp.PromptVersion)
+
+	return resp.Text, nil
+}
@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// inUnitOfWork runs fn in uow so its writes commit or roll back together.
+// Without a unit of work fn runs directly, as each write did before.
+func inUnitOfWork(ctx context.Context, uow domain.UnitOfWork, fn func(ctx context.Context) error) error {
+	if uow == nil {
+		return fn(ctx)
+	}
+	return uow.Do(ctx, fn)
+}
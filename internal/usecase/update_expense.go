@@ -2,7 +2,9 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/riverlin/aiexpense/internal/domain"
@@ -10,8 +12,17 @@ import (
 
 // UpdateExpenseUseCase handles updating existing expenses
 type UpdateExpenseUseCase struct {
-	expenseRepo  domain.ExpenseRepository
-	categoryRepo domain.CategoryRepository
+	expenseRepo     domain.ExpenseRepository
+	categoryRepo    domain.CategoryRepository
+	categoryLearner CategoryLearner
+	auditRepo       domain.ExpenseAuditRepository
+	uow             domain.UnitOfWork
+	events          EventPublisher
+}
+
+// CategoryLearner learns from a user moving an expense to another category
+type CategoryLearner interface {
+	RecordCorrection(ctx context.Context, expense *domain.Expense, fromCategoryID *string, toCategoryID string) error
 }
 
 // NewUpdateExpenseUseCase creates a new update expense use case
@@ -25,6 +36,26 @@ func NewUpdateExpenseUseCase(
 	}
 }
 
+// SetCategoryLearner records category changes so future suggestions follow the user's choices
+func (u *UpdateExpenseUseCase) SetCategoryLearner(learner CategoryLearner) {
+	u.categoryLearner = learner
+}
+
+// SetAuditRepository records each change in the expense audit log
+func (u *UpdateExpenseUseCase) SetAuditRepository(auditRepo domain.ExpenseAuditRepository) {
+	u.auditRepo = auditRepo
+}
+
+// SetUnitOfWork saves each change and its audit entry in one transaction
+func (u *UpdateExpenseUseCase) SetUnitOfWork(uow domain.UnitOfWork) {
+	u.uow = uow
+}
+
+// SetEventPublisher publishes an expense.updated event for each change
+func (u *UpdateExpenseUseCase) SetEventPublisher(events EventPublisher) {
+	u.events = events
+}
+
 // UpdateRequest represents a request to update an expense
 type UpdateRequest struct {
 	ID          string
@@ -47,19 +78,20 @@ type UpdateResponse struct {
 func (u *UpdateExpenseUseCase) Execute(ctx context.Context, req *UpdateRequest) (*UpdateResponse, error) {
 	// Get the existing expense
 	expense, err := u.expenseRepo.GetByID(ctx, req.ID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("expense %w", domain.ErrNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get expense: %w", err)
 	}
 
-	if expense == nil {
-		return nil, fmt.Errorf("expense not found")
-	}
-
 	// Verify authorization (user owns this expense)
 	if expense.UserID != req.UserID {
 		return nil, fmt.Errorf("unauthorized: user does not own this expense")
 	}
 
+	before := *expense
+
 	// Update fields if provided
 	if req.Description != nil {
 		expense.Description = *req.Description
@@ -84,6 +116,7 @@ func (u *UpdateExpenseUseCase) Execute(ctx context.Context, req *UpdateRequest)
 
 	// Handle category update
 	var categoryName string
+	previousCategoryID := expense.CategoryID
 	if req.CategoryID != nil {
 		expense.CategoryID = req.CategoryID
 		// Get category name for response
@@ -103,8 +136,23 @@ func (u *UpdateExpenseUseCase) Execute(ctx context.Context, req *UpdateRequest)
 	expense.UpdatedAt = time.Now()
 
 	// Save the updated expense
-	if err := u.expenseRepo.Update(ctx, expense); err != nil {
-		return nil, fmt.Errorf("failed to update expense: %w", err)
+	err = inUnitOfWork(ctx, u.uow, func(ctx context.Context) error {
+		if err := u.expenseRepo.Update(ctx, expense); err != nil {
+			return fmt.Errorf("failed to update expense: %w", err)
+		}
+		return recordExpenseAudit(ctx, u.auditRepo, domain.ExpenseAuditUpdate, &before, expense)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if u.events != nil {
+		u.events.Publish(ctx, expense.UserID, domain.EventExpenseUpdated, newExpenseEvent(expense, categoryName))
+	}
+
+	if u.categoryLearner != nil && req.CategoryID != nil && (previousCategoryID == nil || *previousCategoryID != *req.CategoryID) {
+		if err := u.categoryLearner.RecordCorrection(ctx, expense, previousCategoryID, *req.CategoryID); err != nil {
+			slog.WarnContext(ctx, "Failed to learn category correction", "expense_id", expense.ID, "error", err)
+		}
 	}
 
 	// Prepare response message
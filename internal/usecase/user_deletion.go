@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+// userDeletionAuditLimit is how many deletions the admin audit list returns
+const userDeletionAuditLimit = 200
+
+// ErrNoPendingDeletion is returned when cancelling a deletion the user never requested
+var ErrNoPendingDeletion = errors.New("no pending deletion for this user")
+
+// UserDeletionUseCase erases users' data on request. Deletion is soft at first:
+// the user has a grace period to change their mind, after which RunPurges
+// removes everything they own. The deletion record is kept as the audit trail.
+type UserDeletionUseCase struct {
+	repo        domain.UserDeletionRepository
+	userRepo    domain.UserRepository
+	storage     domain.BlobStorage
+	archives    domain.BlobStorage
+	gracePeriod time.Duration
+	now         func() time.Time
+}
+
+// NewUserDeletionUseCase creates a new user deletion use case that purges
+// data gracePeriod after it is requested
+func NewUserDeletionUseCase(repo domain.UserDeletionRepository, userRepo domain.UserRepository, gracePeriod time.Duration) *UserDeletionUseCase {
+	return &UserDeletionUseCase{
+		repo:        repo,
+		userRepo:    userRepo,
+		gracePeriod: gracePeriod,
+		now:         time.Now,
+	}
+}
+
+// SetBlobStorage sets where receipt attachments are stored, so purges can
+// remove the files as well as their rows
+func (u *UserDeletionUseCase) SetBlobStorage(storage domain.BlobStorage) {
+	u.storage = storage
+}
+
+// SetArchiveStorage sets where expense archive bundles are stored, so purges
+// can remove the bundles as well as their metadata
+func (u *UserDeletionUseCase) SetArchiveStorage(storage domain.BlobStorage) {
+	u.archives = storage
+}
+
+// RequestDeletion schedules the user's data to be purged once the grace period
+// ends. Asking again returns the deletion already pending.
+func (u *UserDeletionUseCase) RequestDeletion(ctx context.Context, userID string) (*domain.UserDeletion, error) {
+	pending, err := u.repo.GetPending(ctx, userID)
+	if err == nil {
+		return pending, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("failed to get pending deletion: %w", err)
+	}
+
+	_, err = u.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("user %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	now := u.now()
+	deletion := &domain.UserDeletion{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Status:      domain.UserDeletionPending,
+		RequestedAt: now,
+		PurgeAfter:  now.Add(u.gracePeriod),
+	}
+	if err := u.repo.Create(ctx, deletion); err != nil {
+		return nil, fmt.Errorf("failed to save deletion: %w", err)
+	}
+	slog.InfoContext(ctx, "User deletion requested", "user_id", userID, "purge_after", deletion.PurgeAfter)
+	return deletion, nil
+}
+
+// GetPending returns the user's pending deletion, or nil if there is none
+func (u *UserDeletionUseCase) GetPending(ctx context.Context, userID string) (*domain.UserDeletion, error) {
+	pending, err := u.repo.GetPending(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending deletion: %w", err)
+	}
+	return pending, nil
+}
+
+// Cancel keeps the user's data when they change their mind during the grace period
+func (u *UserDeletionUseCase) Cancel(ctx context.Context, userID string) error {
+	pending, err := u.repo.GetPending(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return ErrNoPendingDeletion
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get pending deletion: %w", err)
+	}
+
+	pending.Status = domain.UserDeletionCancelled
+	if err := u.repo.Update(ctx, pending); err != nil {
+		return fmt.Errorf("failed to cancel deletion: %w", err)
+	}
+	slog.InfoContext(ctx, "User deletion cancelled", "user_id", userID)
+	return nil
+}
+
+// List returns the most recent deletions for the admin audit trail
+func (u *UserDeletionUseCase) List(ctx context.Context) ([]*domain.UserDeletion, error) {
+	deletions, err := u.repo.List(ctx, userDeletionAuditLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deletions: %w", err)
+	}
+	return deletions, nil
+}
+
+// PurgeDue purges the data of users whose grace period has ended and returns
+// how many were purged. A failed purge is retried on the next run.
+func (u *UserDeletionUseCase) PurgeDue(ctx context.Context) (int, error) {
+	due, err := u.repo.GetDue(ctx, u.now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due deletions: %w", err)
+	}
+
+	purged := 0
+	for _, deletion := range due {
+		if err := u.purge(ctx, deletion); err != nil {
+			slog.ErrorContext(ctx, "Failed to purge user data", "user_id", deletion.UserID, "error", err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// RunPurges purges due deletions once per interval until ctx is done
+func (u *UserDeletionUseCase) RunPurges(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.PurgeDue(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to purge deleted users", "error", err)
+			}
+		}
+	}
+}
+
+func (u *UserDeletionUseCase) purge(ctx context.Context, deletion *domain.UserDeletion) error {
+	result, err := u.repo.PurgeUserData(ctx, deletion.UserID)
+	if err != nil {
+		return err
+	}
+
+	// The rows are gone, so a file left behind is only reachable by its key;
+	// log it rather than fail the purge
+	if u.storage != nil {
+		for _, key := range result.StorageKeys {
+			if err := u.storage.Delete(ctx, key); err != nil {
+				slog.WarnContext(ctx, "Failed to delete attachment", "user_id", deletion.UserID, "storage_key", key, "error", err)
+			}
+		}
+	}
+	if u.archives != nil {
+		for _, key := range result.ArchiveKeys {
+			if err := u.archives.Delete(ctx, key); err != nil {
+				slog.WarnContext(ctx, "Failed to delete archive bundle", "user_id", deletion.UserID, "object_key", key, "error", err)
+			}
+		}
+	}
+
+	purgedAt := u.now()
+	deletion.Status = domain.UserDeletionPurged
+	deletion.PurgedAt = &purgedAt
+	deletion.DeletedCounts = result.DeletedCounts
+	if err := u.repo.Update(ctx, deletion); err != nil {
+		return fmt.Errorf("failed to record purge: %w", err)
+	}
+	slog.InfoContext(ctx, "User data purged", "user_id", deletion.UserID, "deleted", result.DeletedCounts)
+	return nil
+}
@@ -0,0 +1,100 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestUserDeletionUseCase(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	setup := func() (*UserDeletionUseCase, *MockUserDeletionRepository, *MockBlobStorage) {
+		userRepo := NewMockUserRepository()
+		userRepo.Create(ctx, &domain.User{UserID: "U1", MessengerType: "line"})
+		repo := NewMockUserDeletionRepository()
+		storage := NewMockBlobStorage()
+		uc := NewUserDeletionUseCase(repo, userRepo, 30*24*time.Hour)
+		uc.SetBlobStorage(storage)
+		uc.now = func() time.Time { return now }
+		return uc, repo, storage
+	}
+
+	t.Run("Purges after the grace period", func(t *testing.T) {
+		uc, repo, storage := setup()
+		storage.Put(ctx, "receipts/U1/a.jpg", []byte("jpg"), "image/jpeg")
+		archives := NewMockBlobStorage()
+		archives.Put(ctx, "archives/U1/a.jsonl.gz", []byte("gz"), "application/gzip")
+		uc.SetArchiveStorage(archives)
+		repo.PurgeResult = &domain.UserPurge{
+			DeletedCounts: map[string]int64{"expenses": 3, "users": 1},
+			StorageKeys:   []string{"receipts/U1/a.jpg"},
+			ArchiveKeys:   []string{"archives/U1/a.jsonl.gz"},
+		}
+
+		deletion, err := uc.RequestDeletion(ctx, "U1")
+		if err != nil {
+			t.Fatalf("RequestDeletion failed: %v", err)
+		}
+		if !deletion.PurgeAfter.Equal(now.Add(30 * 24 * time.Hour)) {
+			t.Errorf("expected the purge 30 days out, got %v", deletion.PurgeAfter)
+		}
+		again, _ := uc.RequestDeletion(ctx, "U1")
+		if again.ID != deletion.ID {
+			t.Error("expected asking again to return the pending deletion")
+		}
+
+		if n, _ := uc.PurgeDue(ctx); n != 0 || len(repo.Purged) != 0 {
+			t.Fatalf("expected nothing purged during the grace period, got %d", n)
+		}
+
+		now = now.Add(31 * 24 * time.Hour)
+		if n, err := uc.PurgeDue(ctx); err != nil || n != 1 {
+			t.Fatalf("expected 1 purge, got %d, %v", n, err)
+		}
+		if len(repo.Purged) != 1 || repo.Purged[0] != "U1" {
+			t.Errorf("expected U1 purged, got %v", repo.Purged)
+		}
+		if _, err := storage.Get(ctx, "receipts/U1/a.jpg"); err == nil {
+			t.Error("expected the attachment file to be deleted")
+		}
+		if _, err := archives.Get(ctx, "archives/U1/a.jsonl.gz"); err == nil {
+			t.Error("expected the archive bundle to be deleted")
+		}
+
+		audit, _ := uc.List(ctx)
+		if len(audit) != 1 || audit[0].Status != domain.UserDeletionPurged || audit[0].PurgedAt == nil || audit[0].DeletedCounts["expenses"] != 3 {
+			t.Errorf("expected an audit record of the purge, got %+v", audit)
+		}
+		if n, _ := uc.PurgeDue(ctx); n != 0 {
+			t.Errorf("expected a purge to run once, got %d", n)
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		uc, repo, _ := setup()
+		if err := uc.Cancel(ctx, "U1"); !errors.Is(err, ErrNoPendingDeletion) {
+			t.Errorf("expected ErrNoPendingDeletion, got %v", err)
+		}
+
+		uc.RequestDeletion(ctx, "U1")
+		if err := uc.Cancel(ctx, "U1"); err != nil {
+			t.Fatalf("Cancel failed: %v", err)
+		}
+		now = now.Add(365 * 24 * time.Hour)
+		if n, _ := uc.PurgeDue(ctx); n != 0 || len(repo.Purged) != 0 {
+			t.Errorf("expected a cancelled deletion not to purge, got %d", n)
+		}
+	})
+
+	t.Run("Unknown user", func(t *testing.T) {
+		uc, _, _ := setup()
+		if _, err := uc.RequestDeletion(ctx, "nobody"); err == nil {
+			t.Error("expected an error for an unknown user")
+		}
+	})
+}
@@ -0,0 +1,483 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// userExportTTL is how long a finished export can be downloaded
+const userExportTTL = 24 * time.Hour
+
+// User export statuses
+const (
+	UserExportPending = "pending"
+	UserExportReady   = "ready"
+	UserExportFailed  = "failed"
+)
+
+// UserExport is a takeout of everything a user has stored, built in the
+// background and downloadable by its token until it expires
+type UserExport struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	Status      string    `json:"status"`
+	RequestedAt time.Time `json:"requested_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+
+	token string
+	data  []byte
+}
+
+// UserExportUseCase builds zip archives of a user's data. The archive is kept
+// in memory, and its download link is pushed to the messenger the user signed
+// up with, so only someone who can read their chat can fetch it.
+type UserExportUseCase struct {
+	userRepo       domain.UserRepository
+	expenseRepo    domain.ExpenseRepository
+	categoryRepo   domain.CategoryRepository
+	budgetRepo     domain.BudgetRepository
+	tagRepo        domain.TagRepository
+	itemRepo       domain.ExpenseItemRepository
+	recurringUC    *RecurringExpenseUseCase
+	notificationUC *NotificationUseCase
+	baseURL        string
+	notifiers      map[string]domain.PushNotifier
+	now            func() time.Time
+
+	mu      sync.Mutex
+	exports map[string]*UserExport // By download token
+	running sync.WaitGroup
+}
+
+// NewUserExportUseCase creates a new user export use case whose download links
+// point at baseURL
+func NewUserExportUseCase(
+	userRepo domain.UserRepository,
+	expenseRepo domain.ExpenseRepository,
+	categoryRepo domain.CategoryRepository,
+	budgetRepo domain.BudgetRepository,
+	recurringUC *RecurringExpenseUseCase,
+	notificationUC *NotificationUseCase,
+	baseURL string,
+) *UserExportUseCase {
+	return &UserExportUseCase{
+		userRepo:       userRepo,
+		expenseRepo:    expenseRepo,
+		categoryRepo:   categoryRepo,
+		budgetRepo:     budgetRepo,
+		recurringUC:    recurringUC,
+		notificationUC: notificationUC,
+		baseURL:        baseURL,
+		notifiers:      make(map[string]domain.PushNotifier),
+		now:            time.Now,
+		exports:        make(map[string]*UserExport),
+	}
+}
+
+// RegisterNotifier sets the channel download links are sent through for users
+// who signed up through messengerType
+func (u *UserExportUseCase) RegisterNotifier(messengerType string, notifier domain.PushNotifier) {
+	u.notifiers[messengerType] = notifier
+}
+
+// SetTagRepository adds the user's tags, and each expense's, to exports
+func (u *UserExportUseCase) SetTagRepository(tagRepo domain.TagRepository) {
+	u.tagRepo = tagRepo
+}
+
+// SetExpenseItemRepository adds the receipt line items of each expense to exports
+func (u *UserExportUseCase) SetExpenseItemRepository(itemRepo domain.ExpenseItemRepository) {
+	u.itemRepo = itemRepo
+}
+
+// RequestExport starts building the user's export and returns without waiting
+// for it. Asking again while an export is being built returns that export.
+func (u *UserExportUseCase) RequestExport(ctx context.Context, userID string) (*UserExport, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrNoMessenger
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if u.notifiers[user.MessengerType] == nil {
+		return nil, ErrNoMessenger
+	}
+
+	token, err := newExportToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate download token: %w", err)
+	}
+
+	u.mu.Lock()
+	u.removeExpired()
+	for _, export := range u.exports {
+		if export.UserID == userID && export.Status == UserExportPending {
+			u.mu.Unlock()
+			return export.snapshot(), nil
+		}
+	}
+	now := u.now()
+	export := &UserExport{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Status:      UserExportPending,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(userExportTTL),
+		token:       token,
+	}
+	u.exports[token] = export
+	requested := export.snapshot()
+	u.mu.Unlock()
+
+	// The export outlives the request that asked for it
+	u.running.Add(1)
+	go func() {
+		defer u.running.Done()
+		u.build(context.WithoutCancel(ctx), user, export)
+	}()
+	return requested, nil
+}
+
+// Download returns a finished export's zip archive, or nil if the token is
+// unknown, expired or the export isn't ready
+func (u *UserExportUseCase) Download(token string) ([]byte, *UserExport) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	export := u.exports[token]
+	if export == nil || export.Status != UserExportReady || !u.now().Before(export.ExpiresAt) {
+		return nil, nil
+	}
+	return export.data, export.snapshot()
+}
+
+// Wait blocks until every export requested so far has been built
+func (u *UserExportUseCase) Wait() {
+	u.running.Wait()
+}
+
+// build creates the archive and sends its download link to the user
+func (u *UserExportUseCase) build(ctx context.Context, user *domain.User, export *UserExport) {
+	data, err := u.Archive(ctx, user.UserID)
+
+	u.mu.Lock()
+	if err != nil {
+		export.Status = UserExportFailed
+	} else {
+		export.Status = UserExportReady
+		export.data = data
+	}
+	u.mu.Unlock()
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to build user export", "user_id", user.UserID, "error", err)
+		return
+	}
+
+	link := fmt.Sprintf("%s/api/v1/exports/%s", u.baseURL, export.token)
+	if err := u.notifiers[user.MessengerType].PushMessage(ctx, user.UserID, i18n.Tf(user.Locale, "export.ready", link)); err != nil {
+		slog.WarnContext(ctx, "Failed to send export link", "user_id", user.UserID, "error", err)
+	}
+}
+
+// takeoutExpense is an expense as written to the export
+type takeoutExpense struct {
+	ID             string                `json:"id"`
+	Date           string                `json:"date"`
+	Description    string                `json:"description"`
+	OriginalAmount float64               `json:"original_amount"`
+	Currency       string                `json:"currency"`
+	HomeAmount     float64               `json:"home_amount"`
+	HomeCurrency   string                `json:"home_currency"`
+	ExchangeRate   float64               `json:"exchange_rate"`
+	Category       string                `json:"category"`
+	Account        string                `json:"account"`
+	Tags           []string              `json:"tags,omitempty"`
+	Items          []ExportedExpenseItem `json:"items,omitempty"`
+	GroupID        string                `json:"group_id,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// takeoutCategory is a category as written to the export
+type takeoutCategory struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Archive builds a zip of the user's profile, expenses, categories, tags, budgets,
+// recurring rules and notifications, with JSON for each and CSV for the tables
+func (u *UserExportUseCase) Archive(ctx context.Context, userID string) ([]byte, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("user %w", domain.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	categories, err := u.categoryRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	categoryNames := make(map[string]string, len(categories))
+	takeoutCategories := make([]takeoutCategory, 0, len(categories))
+	for _, category := range categories {
+		categoryNames[category.ID] = category.Name
+		takeoutCategories = append(takeoutCategories, takeoutCategory{
+			ID:        category.ID,
+			Name:      category.Name,
+			IsDefault: category.IsDefault,
+			CreatedAt: category.CreatedAt,
+		})
+	}
+
+	expenses, err := u.expenseRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expenses: %w", err)
+	}
+	if err := loadExpenseTags(ctx, u.tagRepo, expenses); err != nil {
+		return nil, err
+	}
+	if err := loadExpenseItems(ctx, u.itemRepo, expenses); err != nil {
+		return nil, err
+	}
+	takeoutExpenses := make([]takeoutExpense, 0, len(expenses))
+	for _, expense := range expenses {
+		row := takeoutExpense{
+			ID:             expense.ID,
+			Date:           expense.ExpenseDate.Format("2006-01-02"),
+			Description:    expense.Description,
+			OriginalAmount: expense.OriginalAmount,
+			Currency:       expense.Currency,
+			HomeAmount:     expense.HomeAmount,
+			HomeCurrency:   expense.HomeCurrency,
+			ExchangeRate:   expense.ExchangeRate,
+			Category:       "Uncategorized",
+			Account:        expense.Account,
+			Tags:           expense.Tags,
+			Items:          exportExpenseItems(expense.Items),
+			CreatedAt:      expense.CreatedAt,
+			UpdatedAt:      expense.UpdatedAt,
+		}
+		if expense.CategoryID != nil {
+			if name, ok := categoryNames[*expense.CategoryID]; ok {
+				row.Category = name
+			}
+		}
+		if expense.GroupID != nil {
+			row.GroupID = *expense.GroupID
+		}
+		takeoutExpenses = append(takeoutExpenses, row)
+	}
+
+	budgets, err := u.budgetRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+
+	recurring, err := u.recurringUC.ListRecurring(ctx, &ListRecurringRequest{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring expenses: %w", err)
+	}
+	notifications, err := u.notificationUC.ListNotifications(ctx, &ListNotificationsRequest{UserID: userID, Limit: 1000})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notifications: %w", err)
+	}
+	preferences, err := u.notificationUC.GetPreferences(ctx, &GetPreferencesRequest{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	archive := newTakeoutArchive()
+	archive.writeJSON("user.json", map[string]interface{}{
+		"user_id":        user.UserID,
+		"messenger_type": user.MessengerType,
+		"home_currency":  user.HomeCurrency,
+		"locale":         user.Locale,
+		"created_at":     user.CreatedAt,
+		"exported_at":    u.now(),
+	})
+
+	archive.writeJSON("expenses.json", takeoutExpenses)
+	expenseRows := make([][]string, 0, len(takeoutExpenses))
+	for _, e := range takeoutExpenses {
+		expenseRows = append(expenseRows, []string{
+			e.ID, e.Date, e.Description,
+			csvAmount(e.OriginalAmount), e.Currency,
+			csvAmount(e.HomeAmount), e.HomeCurrency,
+			strconv.FormatFloat(e.ExchangeRate, 'f', -1, 64),
+			e.Category, e.Account, hashtags(e.Tags), itemList(e.Items), e.GroupID,
+			e.CreatedAt.Format(time.RFC3339), e.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	archive.writeCSV("expenses.csv", []string{
+		"ID", "Date", "Description", "OriginalAmount", "Currency", "HomeAmount", "HomeCurrency",
+		"ExchangeRate", "Category", "Account", "Tags", "Items", "GroupID", "CreatedAt", "UpdatedAt",
+	}, expenseRows)
+
+	archive.writeJSON("categories.json", takeoutCategories)
+	categoryRows := make([][]string, 0, len(takeoutCategories))
+	for _, c := range takeoutCategories {
+		categoryRows = append(categoryRows, []string{c.ID, c.Name, strconv.FormatBool(c.IsDefault), c.CreatedAt.Format(time.RFC3339)})
+	}
+	archive.writeCSV("categories.csv", []string{"ID", "Name", "IsDefault", "CreatedAt"}, categoryRows)
+
+	if u.tagRepo != nil {
+		tags, err := u.tagRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tags: %w", err)
+		}
+		archive.writeJSON("tags.json", tags)
+	}
+
+	archive.writeJSON("budgets.json", budgets)
+	budgetRows := make([][]string, 0, len(budgets))
+	for _, b := range budgets {
+		category := ""
+		if b.CategoryID != nil {
+			category = categoryNames[*b.CategoryID]
+		}
+		startDate, endDate := "", ""
+		if b.StartDate != nil && b.EndDate != nil {
+			startDate, endDate = b.StartDate.Format("2006-01-02"), b.EndDate.Format("2006-01-02")
+		}
+		budgetRows = append(budgetRows, []string{
+			b.ID, category, b.Period, csvAmount(b.Limit),
+			strconv.FormatFloat(b.Threshold, 'f', -1, 64), b.CreatedAt.Format(time.RFC3339),
+			b.Name, startDate, endDate,
+		})
+	}
+	archive.writeCSV("budgets.csv", []string{"ID", "Category", "Period", "Limit", "Threshold", "CreatedAt", "Name", "StartDate", "EndDate"}, budgetRows)
+
+	archive.writeJSON("recurring.json", recurring.Recurring)
+	recurringRows := make([][]string, 0, len(recurring.Recurring))
+	for _, r := range recurring.Recurring {
+		category, endDate := "", ""
+		if r.CategoryID != nil {
+			category = categoryNames[*r.CategoryID]
+		}
+		if r.EndDate != nil {
+			endDate = r.EndDate.Format("2006-01-02")
+		}
+		recurringRows = append(recurringRows, []string{
+			r.ID, r.Description, csvAmount(r.Amount), category, r.Frequency,
+			r.StartDate.Format("2006-01-02"), endDate, strconv.FormatBool(r.IsActive),
+		})
+	}
+	archive.writeCSV("recurring.csv", []string{"ID", "Description", "Amount", "Category", "Frequency", "StartDate", "EndDate", "Active"}, recurringRows)
+
+	archive.writeJSON("notifications.json", map[string]interface{}{
+		"notifications": notifications.Notifications,
+		"preferences":   preferences.Preferences,
+	})
+
+	return archive.close()
+}
+
+// takeoutArchive writes files into a zip, keeping the first error
+type takeoutArchive struct {
+	buf *bytes.Buffer
+	zw  *zip.Writer
+	err error
+}
+
+func newTakeoutArchive() *takeoutArchive {
+	buf := &bytes.Buffer{}
+	return &takeoutArchive{buf: buf, zw: zip.NewWriter(buf)}
+}
+
+func (a *takeoutArchive) writeJSON(name string, v interface{}) {
+	if a.err != nil {
+		return
+	}
+	w, err := a.zw.Create(name)
+	if err != nil {
+		a.err = fmt.Errorf("failed to add %s: %w", name, err)
+		return
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		a.err = fmt.Errorf("failed to write %s: %w", name, err)
+	}
+}
+
+func (a *takeoutArchive) writeCSV(name string, header []string, rows [][]string) {
+	if a.err != nil {
+		return
+	}
+	w, err := a.zw.Create(name)
+	if err != nil {
+		a.err = fmt.Errorf("failed to add %s: %w", name, err)
+		return
+	}
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+	writer.WriteAll(rows)
+	if err := writer.Error(); err != nil {
+		a.err = fmt.Errorf("failed to write %s: %w", name, err)
+	}
+}
+
+func (a *takeoutArchive) close() ([]byte, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	if err := a.zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return a.buf.Bytes(), nil
+}
+
+// snapshot copies the export's public fields; the use case's mu must be held
+func (e *UserExport) snapshot() *UserExport {
+	return &UserExport{
+		ID:          e.ID,
+		UserID:      e.UserID,
+		Status:      e.Status,
+		RequestedAt: e.RequestedAt,
+		ExpiresAt:   e.ExpiresAt,
+	}
+}
+
+// removeExpired drops exports past their expiry; u.mu must be held
+func (u *UserExportUseCase) removeExpired() {
+	now := u.now()
+	for token, export := range u.exports {
+		if !now.Before(export.ExpiresAt) {
+			delete(u.exports, token)
+		}
+	}
+}
+
+// csvAmount formats an amount for a CSV cell with two decimals
+func csvAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// newExportToken returns a random, unguessable download token
+func newExportToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
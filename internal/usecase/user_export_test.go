@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestUserExportUseCase(t *testing.T) {
+	ctx := context.Background()
+	linkPattern := regexp.MustCompile(`https://api\.example\.com/api/v1/exports/([0-9a-f]+)`)
+
+	setup := func(t *testing.T) (*UserExportUseCase, *recordingNotifier) {
+		t.Helper()
+		userRepo := NewMockUserRepository()
+		userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "telegram", Locale: "en"})
+		userRepo.Create(ctx, &domain.User{UserID: "user2", MessengerType: "terminal"})
+
+		categoryRepo := NewMockCategoryRepository()
+		categoryRepo.Create(ctx, &domain.Category{ID: "cat1", UserID: "user1", Name: "Food"})
+		catID := "cat1"
+		expenseRepo := NewMockExpenseRepository()
+		expenseRepo.Create(ctx, &domain.Expense{
+			ID: "exp1", UserID: "user1", Description: "Ramen, large", OriginalAmount: 12.5, Currency: "USD",
+			HomeAmount: 12.5, HomeCurrency: "USD", ExchangeRate: 1, CategoryID: &catID, ExpenseDate: time.Now(),
+		})
+		expenseRepo.Create(ctx, &domain.Expense{ID: "exp2", UserID: "user2", Description: "Not mine", ExpenseDate: time.Now()})
+		budgetRepo := NewMockBudgetRepository()
+		budgetRepo.Create(ctx, &domain.Budget{ID: "b1", UserID: "user1", CategoryID: &catID, Limit: 300, Period: domain.BudgetPeriodMonthly})
+
+		notifier := &recordingNotifier{}
+		uc := NewUserExportUseCase(userRepo, expenseRepo, categoryRepo, budgetRepo,
+			NewRecurringExpenseUseCase(expenseRepo, categoryRepo), NewNotificationUseCase(), "https://api.example.com")
+		uc.RegisterNotifier("telegram", notifier)
+		return uc, notifier
+	}
+
+	t.Run("Link sent once the archive is built", func(t *testing.T) {
+		uc, notifier := setup(t)
+		export, err := uc.RequestExport(ctx, "user1")
+		if err != nil {
+			t.Fatalf("RequestExport failed: %v", err)
+		}
+		if export.Status != UserExportPending {
+			t.Errorf("expected a pending export, got %s", export.Status)
+		}
+		uc.Wait()
+
+		if len(notifier.messages) != 1 {
+			t.Fatalf("expected the download link to be sent, got %v", notifier.messages)
+		}
+		match := linkPattern.FindStringSubmatch(notifier.messages[0])
+		if match == nil {
+			t.Fatalf("expected a download link in %q", notifier.messages[0])
+		}
+
+		data, ready := uc.Download(match[1])
+		if data == nil || ready.Status != UserExportReady {
+			t.Fatal("expected the export to be downloadable")
+		}
+		if data, _ := uc.Download("not-a-token"); data != nil {
+			t.Error("expected an unknown token to download nothing")
+		}
+
+		uc.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+		if data, _ := uc.Download(match[1]); data != nil {
+			t.Error("expected the export to expire")
+		}
+	})
+
+	t.Run("Archive contents", func(t *testing.T) {
+		uc, _ := setup(t)
+		data, err := uc.Archive(ctx, "user1")
+		if err != nil {
+			t.Fatalf("Archive failed: %v", err)
+		}
+		reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("expected a zip archive: %v", err)
+		}
+
+		files := make(map[string]string)
+		for _, file := range reader.File {
+			rc, _ := file.Open()
+			content, _ := io.ReadAll(rc)
+			rc.Close()
+			files[file.Name] = string(content)
+		}
+		for _, name := range []string{
+			"user.json", "expenses.json", "expenses.csv", "categories.json", "categories.csv",
+			"budgets.json", "budgets.csv", "recurring.json", "recurring.csv", "notifications.json",
+		} {
+			if _, ok := files[name]; !ok {
+				t.Errorf("expected %s in the archive", name)
+			}
+		}
+
+		rows, err := csv.NewReader(strings.NewReader(files["expenses.csv"])).ReadAll()
+		if err != nil {
+			t.Fatalf("expected valid CSV: %v", err)
+		}
+		if len(rows) != 2 || rows[1][2] != "Ramen, large" || rows[1][8] != "Food" {
+			t.Errorf("expected only user1's expense with its category, got %v", rows)
+		}
+		if strings.Contains(files["expenses.json"], "Not mine") {
+			t.Error("expected other users' expenses to be left out")
+		}
+		if !strings.Contains(files["budgets.csv"], "b1,Food,monthly,300.00") {
+			t.Errorf("unexpected budgets.csv: %s", files["budgets.csv"])
+		}
+	})
+
+	t.Run("No messenger to send to", func(t *testing.T) {
+		uc, _ := setup(t)
+		for _, userID := range []string{"user2", "unknown"} {
+			if _, err := uc.RequestExport(ctx, userID); !errors.Is(err, ErrNoMessenger) {
+				t.Errorf("expected ErrNoMessenger for %s, got %v", userID, err)
+			}
+		}
+	})
+}
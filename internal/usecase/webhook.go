@@ -0,0 +1,441 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+const (
+	// WebhookMaxAttempts is how many times a delivery is tried before it is marked failed
+	WebhookMaxAttempts = 6
+
+	// DefaultWebhookDeliveryLimit is how many deliveries the delivery log returns by default
+	DefaultWebhookDeliveryLimit = 50
+
+	webhookTimeout    = 10 * time.Second
+	webhookRetryBatch = 100
+)
+
+// ErrWebhookNotFound is returned for a subscription that doesn't exist or belongs to another user
+var ErrWebhookNotFound = fmt.Errorf("webhook %w", domain.ErrNotFound)
+
+// webhookBackoff is the wait before each retry; the last one repeats if
+// WebhookMaxAttempts outgrows it
+var webhookBackoff = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+// WebhookUseCase manages users' webhook subscriptions and delivers events to
+// them. Each delivery is a JSON POST signed with the subscription's secret;
+// failed deliveries are retried with backoff by RunRetries.
+type WebhookUseCase struct {
+	repo   domain.WebhookRepository
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewWebhookUseCase creates a new webhook use case
+func NewWebhookUseCase(repo domain.WebhookRepository) *WebhookUseCase {
+	return &WebhookUseCase{
+		repo:   repo,
+		client: &http.Client{Timeout: webhookTimeout},
+		now:    time.Now,
+	}
+}
+
+// WebhookPayload is the JSON body of every delivery
+type WebhookPayload struct {
+	ID        string      `json:"id"` // Delivery ID, the same on every retry
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// ExpenseEvent is the data of expense events, such as an expense.created delivery
+type ExpenseEvent struct {
+	ExpenseID    string    `json:"expense_id"`
+	Description  string    `json:"description"`
+	Amount       float64   `json:"amount"`
+	Currency     string    `json:"currency"`
+	HomeAmount   float64   `json:"home_amount"`
+	HomeCurrency string    `json:"home_currency"`
+	CategoryID   *string   `json:"category_id,omitempty"`
+	Category     string    `json:"category,omitempty"`
+	Account      string    `json:"account,omitempty"`
+	GroupID      *string   `json:"group_id,omitempty"`
+	ExpenseDate  time.Time `json:"expense_date"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// newExpenseEvent returns the event data of an expense; category is its category name, if known
+func newExpenseEvent(expense *domain.Expense, category string) *ExpenseEvent {
+	return &ExpenseEvent{
+		ExpenseID:    expense.ID,
+		Description:  expense.Description,
+		Amount:       expense.OriginalAmount,
+		Currency:     expense.Currency,
+		HomeAmount:   expense.HomeAmount,
+		HomeCurrency: expense.HomeCurrency,
+		CategoryID:   expense.CategoryID,
+		Category:     category,
+		Account:      expense.Account,
+		GroupID:      expense.GroupID,
+		ExpenseDate:  expense.ExpenseDate,
+		CreatedAt:    expense.CreatedAt,
+	}
+}
+
+// BudgetExceededEvent is the data of a budget.exceeded delivery, sent when an
+// expense takes a budget past its limit
+type BudgetExceededEvent struct {
+	BudgetID  string  `json:"budget_id"`
+	GroupID   *string `json:"group_id,omitempty"` // Set for a shared group ledger budget
+	Category  string  `json:"category"`
+	Period    string  `json:"period"`
+	Limit     float64 `json:"limit"`
+	Spent     float64 `json:"spent"`
+	Currency  string  `json:"currency"`
+	ExpenseID string  `json:"expense_id"` // The expense that crossed the limit
+}
+
+// CreateWebhookRequest represents a request to subscribe a URL to events
+type CreateWebhookRequest struct {
+	UserID string   `json:"user_id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// UpdateWebhookRequest changes a subscription; nil fields keep their value
+type UpdateWebhookRequest struct {
+	URL     *string  `json:"url,omitempty"`
+	Events  []string `json:"events,omitempty"`
+	Enabled *bool    `json:"enabled,omitempty"`
+}
+
+// CreateSubscription subscribes a URL to events. The returned subscription
+// holds the signing secret, which is not shown again.
+func (u *WebhookUseCase) CreateSubscription(ctx context.Context, req *CreateWebhookRequest) (*domain.WebhookSubscription, error) {
+	if req.UserID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(req.Events); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	now := u.now()
+	subscription := &domain.WebhookSubscription{
+		ID:        uuid.New().String(),
+		UserID:    req.UserID,
+		URL:       req.URL,
+		Secret:    "whsec_" + hex.EncodeToString(secret),
+		Events:    req.Events,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := u.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions returns the user's subscriptions without their secrets
+func (u *WebhookUseCase) ListSubscriptions(ctx context.Context, userID string) ([]*domain.WebhookSubscription, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	subscriptions, err := u.repo.ListSubscriptions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription changes the URL, events or enabled flag of the user's subscription
+func (u *WebhookUseCase) UpdateSubscription(ctx context.Context, id, userID string, req *UpdateWebhookRequest) (*domain.WebhookSubscription, error) {
+	subscription, err := u.getOwnSubscription(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *req.URL
+	}
+	if req.Events != nil {
+		if err := validateWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+		subscription.Events = req.Events
+	}
+	if req.Enabled != nil {
+		subscription.Enabled = *req.Enabled
+	}
+	subscription.UpdatedAt = u.now()
+
+	if err := u.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// DeleteSubscription removes the user's subscription and its delivery log
+func (u *WebhookUseCase) DeleteSubscription(ctx context.Context, id, userID string) error {
+	if _, err := u.getOwnSubscription(ctx, id, userID); err != nil {
+		return err
+	}
+	if err := u.repo.DeleteSubscription(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the most recent deliveries of the user's subscription, newest first
+func (u *WebhookUseCase) ListDeliveries(ctx context.Context, id, userID string, limit int) ([]*domain.WebhookDelivery, error) {
+	if _, err := u.getOwnSubscription(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > DefaultWebhookDeliveryLimit {
+		limit = DefaultWebhookDeliveryLimit
+	}
+	deliveries, err := u.repo.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// HandleEvent delivers an event from the event bus to the user's webhooks
+func (u *WebhookUseCase) HandleEvent(ctx context.Context, userID string, event UserEvent) {
+	u.Publish(ctx, userID, event.Type, event.Data)
+}
+
+// Publish delivers the event to every enabled subscription of the user that
+// wants it. Failures are logged and left for RunRetries, so callers that
+// shouldn't wait on the receivers run it in the background.
+func (u *WebhookUseCase) Publish(ctx context.Context, userID, event string, data interface{}) {
+	subscriptions, err := u.repo.ListSubscriptions(ctx, userID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get webhooks", "user_id", userID, "error", err)
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Enabled || !subscription.Subscribes(event) {
+			continue
+		}
+
+		now := u.now()
+		delivery := &domain.WebhookDelivery{
+			ID:             uuid.New().String(),
+			SubscriptionID: subscription.ID,
+			Event:          event,
+			Status:         domain.WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+			CreatedAt:      now,
+		}
+		payload, err := json.Marshal(&WebhookPayload{ID: delivery.ID, Event: event, CreatedAt: now, Data: data})
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to encode webhook", "event", event, "error", err)
+			return
+		}
+		delivery.Payload = string(payload)
+
+		if err := u.repo.CreateDelivery(ctx, delivery); err != nil {
+			slog.WarnContext(ctx, "Failed to record webhook delivery", "event", event, "error", err)
+			continue
+		}
+		u.attempt(ctx, subscription, delivery)
+	}
+}
+
+// RetryDue retries pending deliveries whose backoff has passed and returns how many were attempted
+func (u *WebhookUseCase) RetryDue(ctx context.Context) (int, error) {
+	deliveries, err := u.repo.GetRetryableDeliveries(ctx, u.now(), webhookRetryBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get webhook retries: %w", err)
+	}
+
+	subscriptions := make(map[string]*domain.WebhookSubscription)
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = u.repo.GetSubscription(ctx, delivery.SubscriptionID)
+			if err != nil && !errors.Is(err, domain.ErrNotFound) {
+				return 0, fmt.Errorf("failed to get webhook: %w", err)
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		if subscription == nil || !subscription.Enabled {
+			delivery.Status = domain.WebhookDeliveryFailed
+			delivery.LastError = "webhook disabled"
+			delivery.NextAttemptAt = nil
+			if err := u.repo.UpdateDelivery(ctx, delivery); err != nil {
+				slog.WarnContext(ctx, "Failed to update webhook delivery", "delivery_id", delivery.ID, "error", err)
+			}
+			continue
+		}
+		u.attempt(ctx, subscription, delivery)
+	}
+	return len(deliveries), nil
+}
+
+// RunRetries retries failed deliveries once per interval until ctx is done
+func (u *WebhookUseCase) RunRetries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.RetryDue(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to retry webhooks", "error", err)
+			}
+		}
+	}
+}
+
+// attempt sends the delivery once and records the outcome, scheduling a
+// retry after a failure until WebhookMaxAttempts is reached
+func (u *WebhookUseCase) attempt(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseStatus, delivery.LastError = 0, ""
+
+	status, err := u.post(ctx, subscription, delivery)
+	now := u.now()
+	switch {
+	case err == nil && status >= 200 && status < 300:
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.ResponseStatus = status
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	default:
+		delivery.ResponseStatus = status
+		if err != nil {
+			delivery.LastError = err.Error()
+		} else {
+			delivery.LastError = fmt.Sprintf("receiver responded with status %d", status)
+		}
+		if delivery.Attempts >= WebhookMaxAttempts {
+			delivery.Status = domain.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			next := now.Add(webhookBackoff[min(delivery.Attempts, len(webhookBackoff))-1])
+			delivery.NextAttemptAt = &next
+		}
+		slog.WarnContext(ctx, "Webhook delivery failed", "delivery_id", delivery.ID, "url", subscription.URL, "attempt", delivery.Attempts, "error", delivery.LastError)
+	}
+
+	if err := u.repo.UpdateDelivery(ctx, delivery); err != nil {
+		slog.WarnContext(ctx, "Failed to update webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// post sends the delivery and returns the response status
+func (u *WebhookUseCase) post(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(u.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AIExpense-Webhook/1.0")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", SignWebhook(subscription.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// SignWebhook returns the X-Webhook-Signature header for a delivery: the
+// hex HMAC-SHA256 of "<timestamp>.<body>" keyed by the subscription secret
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (u *WebhookUseCase) getOwnSubscription(ctx context.Context, id, userID string) (*domain.WebhookSubscription, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	subscription, err := u.repo.GetSubscription(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	if subscription.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return subscription, nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+func validateWebhookEvents(events []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, event := range events {
+		known := false
+		for _, e := range domain.WebhookEvents {
+			known = known || e == event
+		}
+		if !known {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+func TestWebhookUseCase_Subscriptions(t *testing.T) {
+	ctx := context.Background()
+	uc := NewWebhookUseCase(NewMockWebhookRepository())
+
+	invalid := []*CreateWebhookRequest{
+		{URL: "https://example.com/hook", Events: []string{domain.WebhookEventExpenseCreated}},
+		{UserID: "user1", URL: "ftp://example.com/hook", Events: []string{domain.WebhookEventExpenseCreated}},
+		{UserID: "user1", URL: "/hook", Events: []string{domain.WebhookEventExpenseCreated}},
+		{UserID: "user1", URL: "https://example.com/hook"},
+		{UserID: "user1", URL: "https://example.com/hook", Events: []string{"expense.deleted"}},
+	}
+	for _, req := range invalid {
+		if _, err := uc.CreateSubscription(ctx, req); err == nil {
+			t.Errorf("expected %+v to be rejected", req)
+		}
+	}
+
+	created, err := uc.CreateSubscription(ctx, &CreateWebhookRequest{
+		UserID: "user1",
+		URL:    "https://example.com/hook",
+		Events: []string{domain.WebhookEventExpenseCreated},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(created.Secret, "whsec_") || !created.Enabled {
+		t.Errorf("expected an enabled subscription with a secret, got %+v", created)
+	}
+
+	listed, _ := uc.ListSubscriptions(ctx, "user1")
+	if len(listed) != 1 || listed[0].Secret != "" {
+		t.Errorf("expected the subscription without its secret, got %+v", listed)
+	}
+
+	disabled := false
+	if _, err := uc.UpdateSubscription(ctx, created.ID, "user2", &UpdateWebhookRequest{Enabled: &disabled}); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected another user's webhook to be hidden, got %v", err)
+	}
+	updated, err := uc.UpdateSubscription(ctx, created.ID, "user1", &UpdateWebhookRequest{
+		Events:  []string{domain.WebhookEventExpenseCreated, domain.WebhookEventBudgetExceeded},
+		Enabled: &disabled,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Enabled || !updated.Subscribes(domain.WebhookEventBudgetExceeded) || updated.URL != "https://example.com/hook" {
+		t.Errorf("unexpected update: %+v", updated)
+	}
+
+	if err := uc.DeleteSubscription(ctx, created.ID, "user2"); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected another user's webhook to be hidden, got %v", err)
+	}
+	if err := uc.DeleteSubscription(ctx, created.ID, "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if listed, _ := uc.ListSubscriptions(ctx, "user1"); len(listed) != 0 {
+		t.Errorf("expected the subscription to be deleted, got %d", len(listed))
+	}
+}
+
+func TestWebhookUseCase_Publish(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var requests []received
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, received{r.Header, body})
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	repo := NewMockWebhookRepository()
+	uc := NewWebhookUseCase(repo)
+	uc.now = func() time.Time { return now }
+
+	subscription, _ := uc.CreateSubscription(ctx, &CreateWebhookRequest{UserID: "user1", URL: server.URL, Events: []string{domain.WebhookEventExpenseCreated}})
+	uc.CreateSubscription(ctx, &CreateWebhookRequest{UserID: "user1", URL: server.URL, Events: []string{domain.WebhookEventBudgetExceeded}})
+	uc.CreateSubscription(ctx, &CreateWebhookRequest{UserID: "user2", URL: server.URL, Events: []string{domain.WebhookEventExpenseCreated}})
+
+	uc.Publish(ctx, "user1", domain.WebhookEventExpenseCreated, &ExpenseEvent{ExpenseID: "e1", Description: "午餐", Amount: 120})
+	if len(requests) != 1 {
+		t.Fatalf("expected one delivery to the subscribed webhook, got %d", len(requests))
+	}
+
+	req := requests[0]
+	if req.header.Get("X-Webhook-Event") != domain.WebhookEventExpenseCreated || req.header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers: %v", req.header)
+	}
+	want := SignWebhook(subscription.Secret, req.header.Get("X-Webhook-Timestamp"), req.body)
+	if req.header.Get("X-Webhook-Signature") != want {
+		t.Errorf("expected signature %s, got %s", want, req.header.Get("X-Webhook-Signature"))
+	}
+
+	var payload struct {
+		ID    string       `json:"id"`
+		Event string       `json:"event"`
+		Data  ExpenseEvent `json:"data"`
+	}
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.ID != req.header.Get("X-Webhook-Delivery") || payload.Data.ExpenseID != "e1" || payload.Data.Description != "午餐" {
+		t.Errorf("unexpected payload: %s", req.body)
+	}
+
+	deliveries, _ := uc.ListDeliveries(ctx, subscription.ID, "user1", 0)
+	if len(deliveries) != 1 || deliveries[0].Status != domain.WebhookDeliverySucceeded || deliveries[0].ResponseStatus != 200 || deliveries[0].DeliveredAt == nil {
+		t.Errorf("expected a succeeded delivery, got %+v", deliveries)
+	}
+	if _, err := uc.ListDeliveries(ctx, subscription.ID, "user2", 0); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("expected another user's deliveries to be hidden, got %v", err)
+	}
+}
+
+func TestWebhookUseCase_Retries(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	attempts := 0
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	repo := NewMockWebhookRepository()
+	uc := NewWebhookUseCase(repo)
+	uc.now = func() time.Time { return now }
+
+	subscription, _ := uc.CreateSubscription(ctx, &CreateWebhookRequest{UserID: "user1", URL: server.URL, Events: []string{domain.WebhookEventBudgetExceeded}})
+	uc.Publish(ctx, "user1", domain.WebhookEventBudgetExceeded, &BudgetExceededEvent{BudgetID: "b1"})
+
+	delivery := repo.deliveries[0]
+	if delivery.Status != domain.WebhookDeliveryPending || delivery.ResponseStatus != 500 || !delivery.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected a retry in a minute, got %+v", delivery)
+	}
+
+	// Not retried before the backoff has passed
+	if n, _ := uc.RetryDue(ctx); n != 0 {
+		t.Errorf("expected no retries yet, got %d", n)
+	}
+
+	now = now.Add(time.Minute)
+	if n, _ := uc.RetryDue(ctx); n != 1 || attempts != 2 {
+		t.Fatalf("expected a second attempt, got %d retried and %d attempts", n, attempts)
+	}
+	delivery = repo.deliveries[0]
+	if delivery.Attempts != 2 || !delivery.NextAttemptAt.Equal(now.Add(5*time.Minute)) {
+		t.Errorf("expected the backoff to grow, got %+v", delivery)
+	}
+
+	status = http.StatusNoContent
+	now = now.Add(5 * time.Minute)
+	uc.RetryDue(ctx)
+	delivery = repo.deliveries[0]
+	if delivery.Status != domain.WebhookDeliverySucceeded || delivery.NextAttemptAt != nil || delivery.LastError != "" {
+		t.Errorf("expected the retry to succeed, got %+v", delivery)
+	}
+
+	// A receiver that never recovers is given up on after the last attempt
+	status = http.StatusBadGateway
+	uc.Publish(ctx, "user1", domain.WebhookEventBudgetExceeded, &BudgetExceededEvent{BudgetID: "b2"})
+	for i := 0; i < WebhookMaxAttempts; i++ {
+		now = now.Add(24 * time.Hour)
+		uc.RetryDue(ctx)
+	}
+	delivery = repo.deliveries[1]
+	if delivery.Status != domain.WebhookDeliveryFailed || delivery.Attempts != WebhookMaxAttempts || delivery.NextAttemptAt != nil {
+		t.Errorf("expected the delivery to fail after %d attempts, got %+v", WebhookMaxAttempts, delivery)
+	}
+
+	// Pending deliveries of a disabled webhook are not retried
+	uc.Publish(ctx, "user1", domain.WebhookEventBudgetExceeded, &BudgetExceededEvent{BudgetID: "b3"})
+	disabled := false
+	uc.UpdateSubscription(ctx, subscription.ID, "user1", &UpdateWebhookRequest{Enabled: &disabled})
+	before := attempts
+	now = now.Add(time.Hour)
+	uc.RetryDue(ctx)
+	if attempts != before || repo.deliveries[2].Status != domain.WebhookDeliveryFailed {
+		t.Errorf("expected the disabled webhook's delivery to be dropped, got %+v", repo.deliveries[2])
+	}
+}
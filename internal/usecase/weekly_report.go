@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/riverlin/aiexpense/internal/i18n"
+)
+
+// weeklyReportLinkTTL keeps the dashboard link in a weekly report working
+// until the next report arrives
+const weeklyReportLinkTTL = 7 * 24 * time.Hour
+
+// MessagePusher sends a message the user didn't ask for to their messenger
+type MessagePusher interface {
+	Push(ctx context.Context, userID, messenger, text string) error
+}
+
+// ReportLinker generates links to the user's dashboard report
+type ReportLinker interface {
+	ExecuteWithExpiry(userID string, ttl time.Duration) (string, error)
+}
+
+// WeeklyReportUseCase pushes the users who keep the weekly report on a
+// summary of last week compared with the week before, by category, every
+// Monday morning in their timezone. Reports go through the pusher, so a
+// failed send is retried.
+type WeeklyReportUseCase struct {
+	prefsRepo domain.NotificationPreferencesRepository
+	userRepo  domain.UserRepository
+	reports   ExpenseReporter
+	pusher    MessagePusher
+	links     ReportLinker
+	timezones TimezoneLocator
+	now       func() time.Time
+}
+
+// NewWeeklyReportUseCase creates a new weekly report use case
+func NewWeeklyReportUseCase(
+	prefsRepo domain.NotificationPreferencesRepository,
+	userRepo domain.UserRepository,
+	reports ExpenseReporter,
+	pusher MessagePusher,
+) *WeeklyReportUseCase {
+	return &WeeklyReportUseCase{
+		prefsRepo: prefsRepo,
+		userRepo:  userRepo,
+		reports:   reports,
+		pusher:    pusher,
+		now:       time.Now,
+	}
+}
+
+// SetReportLinker ends each weekly report with a link to the full dashboard report
+func (u *WeeklyReportUseCase) SetReportLinker(links ReportLinker) {
+	u.links = links
+}
+
+// SetTimezoneLocator sends reports on Monday morning in each user's
+// timezone, for their week, instead of the server's
+func (u *WeeklyReportUseCase) SetTimezoneLocator(timezones TimezoneLocator) {
+	u.timezones = timezones
+}
+
+// SendDue pushes the weekly reports whose Monday morning has come and that
+// weren't sent yet this week, and returns how many were pushed. A report
+// that fails is retried on the next run. Users who had nothing to report,
+// or whose messenger can't be pushed to, are skipped until the next week.
+func (u *WeeklyReportUseCase) SendDue(ctx context.Context) (int, error) {
+	subscribed, err := u.prefsRepo.ListSubscribed(ctx, domain.NotificationWeeklyReport)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get weekly report subscribers: %w", err)
+	}
+
+	now := u.now()
+	sent := 0
+	for _, prefs := range subscribed {
+		loc := time.Local
+		if u.timezones != nil {
+			loc = u.timezones.Locate(ctx, prefs.UserID, "")
+		}
+		runAt := weeklyReportRun(now.In(loc))
+		if prefs.LastWeeklyReportAt != nil && !prefs.LastWeeklyReportAt.Before(runAt) {
+			continue
+		}
+
+		pushed, err := u.send(domain.WithLocation(ctx, loc), prefs.UserID, runAt)
+		if errors.Is(err, ErrNoNotifier) {
+			pushed, err = false, nil
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to push weekly report", "user_id", prefs.UserID, "error", err)
+			continue
+		}
+		if pushed {
+			sent++
+		}
+
+		prefs.LastWeeklyReportAt = &now
+		if prefs.UpdatedAt.IsZero() {
+			prefs.UpdatedAt = now
+		}
+		if err := u.prefsRepo.Save(ctx, prefs); err != nil {
+			slog.ErrorContext(ctx, "Failed to record weekly report", "user_id", prefs.UserID, "error", err)
+		}
+	}
+	return sent, nil
+}
+
+// RunScheduler sends due weekly reports once per interval until ctx is done
+func (u *WeeklyReportUseCase) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := u.SendDue(ctx); err != nil {
+				slog.WarnContext(ctx, "Failed to send weekly reports", "error", err)
+			}
+		}
+	}
+}
+
+// weeklyReportRun returns the latest Monday morning at or before local
+func weeklyReportRun(local time.Time) time.Time {
+	day := time.Date(local.Year(), local.Month(), local.Day(), reportSendHour, 0, 0, 0, local.Location())
+	monday := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	if monday.After(local) {
+		monday = monday.AddDate(0, 0, -7)
+	}
+	return monday
+}
+
+// send pushes the user the report of the week before runAt, in their
+// language. It reports false when there was nothing to push: the user
+// joined after that week, or spent nothing in it or the week before.
+func (u *WeeklyReportUseCase) send(ctx context.Context, userID string, runAt time.Time) (bool, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	start, end := reportPeriod(domain.ReportFrequencyWeekly, runAt)
+	if user.CreatedAt.After(end) {
+		return false, nil
+	}
+	if i18n.Supported(user.Locale) {
+		ctx = i18n.WithLocale(ctx, user.Locale)
+	}
+
+	current, err := u.reports.Execute(ctx, &ReportRequest{UserID: userID, ReportType: "weekly", StartDate: start, EndDate: end})
+	if err != nil {
+		return false, fmt.Errorf("failed to generate report: %w", err)
+	}
+	previous, err := u.reports.Execute(ctx, &ReportRequest{
+		UserID:     userID,
+		ReportType: "weekly",
+		StartDate:  start.AddDate(0, 0, -7),
+		EndDate:    start.Add(-time.Nanosecond),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to generate report: %w", err)
+	}
+	if current.TransactionCount == 0 && previous.TransactionCount == 0 {
+		return false, nil
+	}
+
+	link := ""
+	if u.links != nil {
+		if link, err = u.links.ExecuteWithExpiry(userID, weeklyReportLinkTTL); err != nil {
+			slog.WarnContext(ctx, "Failed to generate weekly report link", "user_id", userID, "error", err)
+		}
+	}
+
+	text := formatWeeklyReport(ctx, start, end, current, previous, user.HomeCurrency, link)
+	if err := u.pusher.Push(ctx, userID, user.MessengerType, text); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// weeklyCategory is a category's spending in the reported week and the week before
+type weeklyCategory struct {
+	name              string
+	current, previous float64
+}
+
+// formatWeeklyReport writes the week's total and each category's spending,
+// with how they changed from the week before, in the locale carried by ctx
+func formatWeeklyReport(ctx context.Context, start, end time.Time, current, previous *ExpenseReport, currency, link string) string {
+	locale := i18n.FromContext(ctx)
+
+	byName := make(map[string]*weeklyCategory)
+	var categories []*weeklyCategory
+	category := func(name string) *weeklyCategory {
+		if byName[name] == nil {
+			byName[name] = &weeklyCategory{name: name}
+			categories = append(categories, byName[name])
+		}
+		return byName[name]
+	}
+	for _, c := range current.CategoryBreakdown {
+		category(c.Category).current += c.Total
+	}
+	for _, c := range previous.CategoryBreakdown {
+		category(c.Category).previous += c.Total
+	}
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].current != categories[j].current {
+			return categories[i].current > categories[j].current
+		}
+		if categories[i].previous != categories[j].previous {
+			return categories[i].previous > categories[j].previous
+		}
+		return categories[i].name < categories[j].name
+	})
+
+	var sb strings.Builder
+	sb.WriteString(translate(ctx, "weekly.title", i18n.FormatDate(locale, start), i18n.FormatDate(locale, end)))
+	sb.WriteString("\n" + translate(ctx, "weekly.total",
+		formatMoney(ctx, current.TotalExpenses, currency),
+		weeklyTrend(ctx, current.TotalExpenses, previous.TotalExpenses),
+		formatMoney(ctx, previous.TotalExpenses, currency)))
+	for _, c := range categories {
+		fmt.Fprintf(&sb, "\n• %s: %s%s", c.name, formatMoney(ctx, c.current, currency), weeklyTrend(ctx, c.current, c.previous))
+	}
+	if link != "" {
+		sb.WriteString("\n\n" + translate(ctx, "weekly.link", link))
+	}
+	return sb.String()
+}
+
+// weeklyTrend describes the change from the week before, e.g. " ▲25%", or
+// " (new)" for spending the week before didn't have
+func weeklyTrend(ctx context.Context, current, previous float64) string {
+	if previous == 0 {
+		if current == 0 {
+			return ""
+		}
+		return " (" + translate(ctx, "weekly.new") + ")"
+	}
+	change := math.Round((current - previous) / previous * 100)
+	locale := i18n.FromContext(ctx)
+	switch {
+	case change > 0:
+		return " ▲" + i18n.FormatNumber(locale, change) + "%"
+	case change < 0:
+		return " ▼" + i18n.FormatNumber(locale, -change) + "%"
+	default:
+		return " ±0%"
+	}
+}
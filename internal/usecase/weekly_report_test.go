@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPusher pushes to the messengers in notifiers and records what it pushed
+type recordingPusher struct {
+	notifiers map[string]bool
+	messages  map[string]string // keyed by user ID
+}
+
+func (p *recordingPusher) Push(ctx context.Context, userID, messenger, text string) error {
+	if !p.notifiers[messenger] {
+		return ErrNoNotifier
+	}
+	p.messages[userID] = text
+	return nil
+}
+
+type fakeReportLinker struct {
+	ttl time.Duration
+}
+
+func (l *fakeReportLinker) ExecuteWithExpiry(userID string, ttl time.Duration) (string, error) {
+	l.ttl = ttl
+	return "https://example.com/r/" + userID, nil
+}
+
+func TestWeeklyReportRun(t *testing.T) {
+	monday := time.Date(2026, 3, 9, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{monday, monday},
+		{monday.Add(-time.Minute), monday.AddDate(0, 0, -7)},
+		{time.Date(2026, 3, 11, 15, 0, 0, 0, time.UTC), monday},
+		{time.Date(2026, 3, 15, 23, 59, 0, 0, time.UTC), monday},
+	}
+	for _, tt := range tests {
+		assert.True(t, weeklyReportRun(tt.now).Equal(tt.want), "weeklyReportRun(%v) = %v", tt.now, weeklyReportRun(tt.now))
+	}
+}
+
+func TestWeeklyReportSendDue(t *testing.T) {
+	ctx := context.Background()
+	// 09:00 on Monday March 9 in Tokyo
+	now := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	joined := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expenseRepo := NewMockExpenseRepository()
+	categoryRepo := NewMockCategoryRepository()
+	for _, c := range []*domain.Category{
+		{ID: "cat_food", UserID: "user1", Name: "Food"},
+		{ID: "cat_transport", UserID: "user1", Name: "Transport"},
+		{ID: "cat_shopping", UserID: "user1", Name: "Shopping"},
+		{ID: "cat_games", UserID: "user1", Name: "Games"},
+	} {
+		require.NoError(t, categoryRepo.Create(ctx, c))
+	}
+
+	userRepo := NewMockUserRepository()
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user1", MessengerType: "line", HomeCurrency: "TWD", Locale: "en", Timezone: "Asia/Tokyo", CreatedAt: joined}))
+	// Nothing spent in either week
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user2", MessengerType: "line", HomeCurrency: "TWD", Timezone: "Asia/Tokyo", CreatedAt: joined}))
+	// Teams can't be pushed to
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user3", MessengerType: "teams", HomeCurrency: "TWD", Timezone: "Asia/Tokyo", CreatedAt: joined}))
+	// Still Sunday in New York
+	require.NoError(t, userRepo.Create(ctx, &domain.User{UserID: "user4", MessengerType: "line", HomeCurrency: "TWD", Timezone: "America/New_York", CreatedAt: joined}))
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	for i, e := range []struct {
+		userID   string
+		category string
+		amount   float64
+		date     time.Time
+	}{
+		// Last week, March 2-8
+		{"user1", "cat_food", 2500, time.Date(2026, 3, 2, 12, 0, 0, 0, tokyo)},
+		{"user1", "cat_transport", 900, time.Date(2026, 3, 8, 23, 0, 0, 0, tokyo)},
+		{"user1", "cat_shopping", 600, time.Date(2026, 3, 5, 18, 0, 0, 0, tokyo)},
+		// The week before, February 23 - March 1
+		{"user1", "cat_food", 2000, time.Date(2026, 2, 25, 12, 0, 0, 0, tokyo)},
+		{"user1", "cat_transport", 1000, time.Date(2026, 2, 23, 8, 0, 0, 0, tokyo)},
+		{"user1", "cat_games", 300, time.Date(2026, 3, 1, 20, 0, 0, 0, tokyo)},
+		// This week isn't reported yet
+		{"user1", "cat_food", 9999, time.Date(2026, 3, 9, 7, 0, 0, 0, tokyo)},
+		{"user3", "", 100, time.Date(2026, 3, 3, 12, 0, 0, 0, tokyo)},
+		{"user4", "", 100, time.Date(2026, 3, 3, 12, 0, 0, 0, tokyo)},
+	} {
+		expense := &domain.Expense{
+			ID:           string(rune('a' + i)),
+			UserID:       e.userID,
+			Amount:       e.amount,
+			HomeAmount:   e.amount,
+			HomeCurrency: "TWD",
+			ExpenseDate:  e.date,
+			CreatedAt:    e.date,
+		}
+		if e.category != "" {
+			category := e.category
+			expense.CategoryID = &category
+		}
+		require.NoError(t, expenseRepo.Create(ctx, expense))
+	}
+
+	prefsRepo := NewMockNotificationPreferencesRepository()
+	for _, userID := range []string{"user1", "user2", "user3", "user4", "user5"} {
+		prefs := domain.DefaultNotificationPreferences(userID)
+		prefs.WeeklyReport = userID != "user5"
+		require.NoError(t, prefsRepo.Save(ctx, prefs))
+	}
+
+	pusher := &recordingPusher{notifiers: map[string]bool{"line": true}, messages: make(map[string]string)}
+	linker := &fakeReportLinker{}
+	uc := NewWeeklyReportUseCase(prefsRepo, userRepo, NewGenerateReportUseCase(expenseRepo, categoryRepo, nil, nil), pusher)
+	uc.SetReportLinker(linker)
+	uc.SetTimezoneLocator(NewTimezoneUseCase(userRepo))
+	uc.now = func() time.Time { return now }
+
+	sent, err := uc.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, pusher.messages, 1)
+	assert.Equal(t, "📊 Last week (Mar 2, 2026 – Mar 8, 2026)\n"+
+		"Total: NT$4,000 ▲21% (the week before: NT$3,300)\n"+
+		"• Food: NT$2,500 ▲25%\n"+
+		"• Transport: NT$900 ▼10%\n"+
+		"• Shopping: NT$600 (new)\n"+
+		"• Games: NT$0 ▼100%\n\n"+
+		"Full report: https://example.com/r/user1", pusher.messages["user1"])
+	assert.Equal(t, weeklyReportLinkTTL, linker.ttl)
+
+	// Users with nothing to report, or no way to be pushed to, aren't tried
+	// again this week. user4's Monday hasn't come, so their previous week,
+	// with nothing in it, was the one due.
+	for _, userID := range []string{"user1", "user2", "user3", "user4"} {
+		prefs, err := prefsRepo.GetByUserID(ctx, userID)
+		require.NoError(t, err)
+		assert.NotNil(t, prefs.LastWeeklyReportAt, userID)
+	}
+
+	sent, err = uc.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	// Monday morning in New York
+	now = time.Date(2026, 3, 9, 13, 0, 0, 0, time.UTC)
+	sent, err = uc.SendDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Contains(t, pusher.messages["user4"], "總計：NT$100 (新增)（前一週：NT$0）")
+}
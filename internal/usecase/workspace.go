@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/riverlin/aiexpense/internal/domain"
+)
+
+var (
+	// ErrInvalidWorkspace is returned for a workspace name that is empty or too long
+	ErrInvalidWorkspace = fmt.Errorf("workspace name must be 1 to %d characters", domain.MaxWorkspaceNameLength)
+	// ErrUnknownWorkspace is returned when switching to a workspace the user doesn't have
+	ErrUnknownWorkspace = fmt.Errorf("workspace %w", domain.ErrNotFound)
+	// ErrWorkspaceNotEmpty is returned when deleting a workspace that still has expenses
+	ErrWorkspaceNotEmpty = fmt.Errorf("workspace still has expenses: %w", domain.ErrConflict)
+)
+
+// WorkspaceUseCase keeps a user's workspaces, each with its own expenses,
+// categories and budgets, and the one their chat messages go into
+type WorkspaceUseCase struct {
+	workspaceRepo domain.WorkspaceRepository
+	userRepo      domain.UserRepository
+	categoryRepo  domain.CategoryRepository
+	expenseRepo   domain.ExpenseRepository
+	budgetRepo    domain.BudgetRepository
+	now           func() time.Time
+}
+
+// NewWorkspaceUseCase creates a new workspace use case
+func NewWorkspaceUseCase(
+	workspaceRepo domain.WorkspaceRepository,
+	userRepo domain.UserRepository,
+	categoryRepo domain.CategoryRepository,
+	expenseRepo domain.ExpenseRepository,
+	budgetRepo domain.BudgetRepository,
+) *WorkspaceUseCase {
+	return &WorkspaceUseCase{
+		workspaceRepo: workspaceRepo,
+		userRepo:      userRepo,
+		categoryRepo:  categoryRepo,
+		expenseRepo:   expenseRepo,
+		budgetRepo:    budgetRepo,
+		now:           time.Now,
+	}
+}
+
+// WorkspaceSummary is one of a user's workspaces and whether it is active
+type WorkspaceSummary struct {
+	ID     string `json:"id"`   // Empty for the personal workspace
+	Name   string `json:"name"` // Empty for the personal workspace
+	Active bool   `json:"active"`
+}
+
+// ListWorkspaces returns the user's workspaces, the personal one first and
+// the rest by name
+func (u *WorkspaceUseCase) ListWorkspaces(ctx context.Context, userID string) ([]*WorkspaceSummary, error) {
+	workspaces, err := u.workspaceRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	active, err := u.ActiveWorkspace(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []*WorkspaceSummary{{ID: domain.PersonalWorkspaceID, Active: active == domain.PersonalWorkspaceID}}
+	for _, workspace := range workspaces {
+		summaries = append(summaries, &WorkspaceSummary{ID: workspace.ID, Name: workspace.Name, Active: workspace.ID == active})
+	}
+	return summaries, nil
+}
+
+// CreateWorkspace adds a workspace with the default categories
+func (u *WorkspaceUseCase) CreateWorkspace(ctx context.Context, userID, name string) (*domain.Workspace, error) {
+	name, err := workspaceName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace := &domain.Workspace{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		CreatedAt: u.now(),
+	}
+	if err := u.workspaceRepo.Create(ctx, workspace); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, fmt.Errorf("workspace '%s' %w", name, domain.ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	for _, categoryName := range defaultCategoryNames {
+		category := &domain.Category{
+			ID:          uuid.New().String(),
+			UserID:      userID,
+			Name:        categoryName,
+			IsDefault:   true,
+			WorkspaceID: workspace.ID,
+			CreatedAt:   u.now(),
+		}
+		if err := u.categoryRepo.Create(ctx, category); err != nil {
+			return nil, fmt.Errorf("failed to create default categories: %w", err)
+		}
+	}
+	return workspace, nil
+}
+
+// RenameWorkspace renames one of the user's workspaces
+func (u *WorkspaceUseCase) RenameWorkspace(ctx context.Context, userID, id, name string) (*domain.Workspace, error) {
+	workspace, err := u.userWorkspace(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if workspace.Name, err = workspaceName(name); err != nil {
+		return nil, err
+	}
+	if err := u.workspaceRepo.Update(ctx, workspace); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, fmt.Errorf("workspace '%s' %w", workspace.Name, domain.ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to rename workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+// DeleteWorkspace deletes one of the user's workspaces along with its
+// categories and budgets. A workspace with expenses is kept, so nothing
+// recorded is lost; a user working in the deleted workspace is moved back to
+// the personal one.
+func (u *WorkspaceUseCase) DeleteWorkspace(ctx context.Context, userID, id string) error {
+	if _, err := u.userWorkspace(ctx, userID, id); err != nil {
+		return err
+	}
+
+	inWorkspace := domain.WithWorkspace(ctx, id)
+	expenses, err := u.expenseRepo.GetByUserID(inWorkspace, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get expenses: %w", err)
+	}
+	if len(expenses) > 0 {
+		return ErrWorkspaceNotEmpty
+	}
+
+	budgets, err := u.budgetRepo.GetByUserID(inWorkspace, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get budgets: %w", err)
+	}
+	for _, budget := range budgets {
+		if err := u.budgetRepo.Delete(ctx, budget.ID); err != nil {
+			return fmt.Errorf("failed to delete budget: %w", err)
+		}
+	}
+	categories, err := u.categoryRepo.GetByUserID(inWorkspace, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get categories: %w", err)
+	}
+	for _, category := range categories {
+		keywords, _ := u.categoryRepo.GetKeywordsByCategory(ctx, category.ID)
+		for _, keyword := range keywords {
+			u.categoryRepo.DeleteKeyword(ctx, keyword.ID)
+		}
+		if err := u.categoryRepo.Delete(ctx, category.ID); err != nil {
+			return fmt.Errorf("failed to delete category: %w", err)
+		}
+	}
+	if err := u.workspaceRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.ActiveWorkspace == id {
+		user.ActiveWorkspace = domain.PersonalWorkspaceID
+		if err := u.userRepo.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to save active workspace: %w", err)
+		}
+	}
+	return nil
+}
+
+// ActiveWorkspace returns the ID of the workspace the user's chat messages go
+// into, "" for the personal one
+func (u *WorkspaceUseCase) ActiveWorkspace(ctx context.Context, userID string) (string, error) {
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.PersonalWorkspaceID, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.ActiveWorkspace, nil
+}
+
+// SwitchWorkspace makes the user's chat messages go into the workspace with
+// the given name or ID, or the personal one when it is empty
+func (u *WorkspaceUseCase) SwitchWorkspace(ctx context.Context, userID, workspace string) (*WorkspaceSummary, error) {
+	workspaces, err := u.ListWorkspaces(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	workspace = strings.TrimSpace(workspace)
+	var target *WorkspaceSummary
+	for _, w := range workspaces {
+		if w.ID == workspace || strings.EqualFold(w.Name, workspace) {
+			target = w
+			break
+		}
+	}
+	if target == nil {
+		return nil, ErrUnknownWorkspace
+	}
+
+	user, err := u.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	user.ActiveWorkspace = target.ID
+	if err := u.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to save active workspace: %w", err)
+	}
+	target.Active = true
+	return target, nil
+}
+
+// userWorkspace returns one of the user's workspaces; other users' are not found
+func (u *WorkspaceUseCase) userWorkspace(ctx context.Context, userID, id string) (*domain.Workspace, error) {
+	workspace, err := u.workspaceRepo.GetByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) || (err == nil && workspace.UserID != userID) {
+		return nil, ErrUnknownWorkspace
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return workspace, nil
+}
+
+// workspaceName trims a workspace name and checks its length
+func workspaceName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > domain.MaxWorkspaceNameLength {
+		return "", ErrInvalidWorkspace
+	}
+	return name, nil
+}
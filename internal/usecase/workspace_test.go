@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/riverlin/aiexpense/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceUseCase(t *testing.T) {
+	ctx := context.Background()
+	userRepo := NewMockUserRepository()
+	categoryRepo := NewMockCategoryRepository()
+	expenseRepo := NewMockExpenseRepository()
+	budgetRepo := NewMockBudgetRepository()
+	require.NoError(t, NewAutoSignupUseCase(userRepo, categoryRepo).Execute(ctx, "user1", "line"))
+	uc := NewWorkspaceUseCase(NewMockWorkspaceRepository(), userRepo, categoryRepo, expenseRepo, budgetRepo)
+
+	business, err := uc.CreateWorkspace(ctx, "user1", " 公司 ")
+	require.NoError(t, err)
+	assert.Equal(t, "公司", business.Name)
+	_, err = uc.CreateWorkspace(ctx, "user1", "公司")
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	_, err = uc.CreateWorkspace(ctx, "user1", " ")
+	assert.ErrorIs(t, err, ErrInvalidWorkspace)
+
+	// Each workspace has its own default categories
+	personalCtx := domain.WithWorkspace(ctx, domain.PersonalWorkspaceID)
+	businessCtx := domain.WithWorkspace(ctx, business.ID)
+	personalFood, err := categoryRepo.GetByUserIDAndName(personalCtx, "user1", "Food")
+	require.NoError(t, err)
+	businessFood, err := categoryRepo.GetByUserIDAndName(businessCtx, "user1", "Food")
+	require.NoError(t, err)
+	assert.NotEqual(t, personalFood.ID, businessFood.ID)
+	categories, err := categoryRepo.GetByUserID(businessCtx, "user1")
+	require.NoError(t, err)
+	assert.Len(t, categories, len(defaultCategoryNames))
+
+	switched, err := uc.SwitchWorkspace(ctx, "user1", "公司")
+	require.NoError(t, err)
+	assert.True(t, switched.Active)
+	active, err := uc.ActiveWorkspace(ctx, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, business.ID, active)
+	workspaces, err := uc.ListWorkspaces(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, workspaces, 2)
+	assert.Equal(t, &WorkspaceSummary{ID: domain.PersonalWorkspaceID}, workspaces[0])
+	assert.Equal(t, &WorkspaceSummary{ID: business.ID, Name: "公司", Active: true}, workspaces[1])
+	_, err = uc.SwitchWorkspace(ctx, "user1", "旅行")
+	assert.ErrorIs(t, err, ErrUnknownWorkspace)
+
+	// Expenses and budgets stay in the workspace they were recorded in
+	createUC := NewCreateExpenseUseCase(expenseRepo, categoryRepo, nil, nil, nil, nil, NewMockAIService())
+	now := time.Now()
+	_, err = createUC.Execute(personalCtx, &CreateRequest{UserID: "user1", Description: "Dinner", Amount: 500, CategoryID: &personalFood.ID, Date: now})
+	require.NoError(t, err)
+	lunch, err := createUC.Execute(businessCtx, &CreateRequest{UserID: "user1", Description: "Client lunch", Amount: 1200, CategoryID: &businessFood.ID, Date: now})
+	require.NoError(t, err)
+	recorded, err := expenseRepo.GetByID(ctx, lunch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, business.ID, recorded.WorkspaceID)
+
+	expenses, err := expenseRepo.GetByUserID(businessCtx, "user1")
+	require.NoError(t, err)
+	require.Len(t, expenses, 1)
+	assert.Equal(t, "Client lunch", expenses[0].Description)
+	expenses, err = expenseRepo.GetByUserID(ctx, "user1")
+	require.NoError(t, err)
+	assert.Len(t, expenses, 2)
+
+	budgetUC := NewBudgetManagementUseCase(budgetRepo, categoryRepo, expenseRepo, NewMockGroupRepository())
+	_, err = budgetUC.CreateBudget(personalCtx, &CreateBudgetRequest{UserID: "user1", Limit: 10000, Period: domain.BudgetPeriodMonthly})
+	require.NoError(t, err)
+	_, err = budgetUC.CreateBudget(businessCtx, &CreateBudgetRequest{UserID: "user1", Limit: 5000, Period: domain.BudgetPeriodMonthly})
+	require.NoError(t, err)
+	status, err := budgetUC.GetBudgetStatus(businessCtx, &GetBudgetStatusRequest{UserID: "user1"})
+	require.NoError(t, err)
+	require.Len(t, status.Budgets, 1)
+	assert.Equal(t, 5000.0, status.Budgets[0].Limit)
+	assert.Equal(t, 1200.0, status.Budgets[0].Spent)
+
+	// A workspace with expenses is kept; an empty one goes with its categories and budgets
+	assert.ErrorIs(t, uc.DeleteWorkspace(ctx, "user1", business.ID), ErrWorkspaceNotEmpty)
+	assert.ErrorIs(t, uc.DeleteWorkspace(ctx, "user2", business.ID), ErrUnknownWorkspace)
+
+	travel, err := uc.CreateWorkspace(ctx, "user1", "旅行")
+	require.NoError(t, err)
+	travelCtx := domain.WithWorkspace(ctx, travel.ID)
+	_, err = budgetUC.CreateBudget(travelCtx, &CreateBudgetRequest{UserID: "user1", Limit: 30000, Period: domain.BudgetPeriodMonthly})
+	require.NoError(t, err)
+	_, err = uc.SwitchWorkspace(ctx, "user1", travel.ID)
+	require.NoError(t, err)
+	require.NoError(t, uc.DeleteWorkspace(ctx, "user1", travel.ID))
+
+	active, err = uc.ActiveWorkspace(ctx, "user1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.PersonalWorkspaceID, active)
+	categories, err = categoryRepo.GetByUserID(travelCtx, "user1")
+	require.NoError(t, err)
+	assert.Empty(t, categories)
+	budgets, err := budgetRepo.GetByUserID(travelCtx, "user1")
+	require.NoError(t, err)
+	assert.Empty(t, budgets)
+	budgets, err = budgetRepo.GetByUserID(ctx, "user1")
+	require.NoError(t, err)
+	assert.Len(t, budgets, 2)
+}
@@ -1,48 +0,0 @@
--- User profiles (created first - no FK dependencies)
-CREATE TABLE IF NOT EXISTS users (
-  user_id TEXT PRIMARY KEY,
-  messenger_type TEXT NOT NULL,
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
--- Categories (created before expenses - expenses references categories)
-CREATE TABLE IF NOT EXISTS categories (
-  id TEXT PRIMARY KEY,
-  user_id TEXT NOT NULL,
-  name TEXT NOT NULL,
-  is_default BOOLEAN DEFAULT FALSE,
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  FOREIGN KEY (user_id) REFERENCES users(user_id),
-  UNIQUE(user_id, name)
-);
-
--- Category suggestion keywords
-CREATE TABLE IF NOT EXISTS category_keywords (
-  id TEXT PRIMARY KEY,
-  category_id TEXT NOT NULL,
-  keyword TEXT NOT NULL,
-  priority INT DEFAULT 0,
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  FOREIGN KEY (category_id) REFERENCES categories(id),
-  UNIQUE(category_id, keyword)
-);
-
--- Expense records (created after categories - has FK to categories)
-CREATE TABLE IF NOT EXISTS expenses (
-  id TEXT PRIMARY KEY,
-  user_id TEXT NOT NULL,
-  description TEXT NOT NULL,
-  amount DECIMAL NOT NULL,
-  category_id TEXT,
-  expense_date DATE NOT NULL,
-  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  FOREIGN KEY (user_id) REFERENCES users(user_id),
-  FOREIGN KEY (category_id) REFERENCES categories(id)
-);
-
--- Indexes for performance
-CREATE INDEX IF NOT EXISTS idx_expenses_user_date ON expenses(user_id, expense_date);
-CREATE INDEX IF NOT EXISTS idx_expenses_user_category ON expenses(user_id, category_id);
-CREATE INDEX IF NOT EXISTS idx_categories_user ON categories(user_id);
-CREATE INDEX IF NOT EXISTS idx_keywords_category ON category_keywords(category_id);
@@ -1,2 +0,0 @@
--- Drop policies table
-DROP TABLE IF EXISTS policies;
@@ -1,2 +0,0 @@
--- Rollback: Drop ai_pricing_config table
-DROP TABLE IF EXISTS ai_pricing_config;
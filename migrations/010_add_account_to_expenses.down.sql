@@ -1 +0,0 @@
-ALTER TABLE expenses DROP COLUMN account;
@@ -1 +0,0 @@
-ALTER TABLE expenses ADD COLUMN account TEXT DEFAULT 'Cash';
@@ -1,3 +0,0 @@
-DROP TABLE IF EXISTS currency_translations;
-DROP TABLE IF EXISTS exchange_rates;
-DROP TABLE IF EXISTS currencies;
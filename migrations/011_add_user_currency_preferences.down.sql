@@ -1,3 +0,0 @@
-ALTER TABLE users
-    DROP COLUMN IF EXISTS home_currency,
-    DROP COLUMN IF EXISTS locale;
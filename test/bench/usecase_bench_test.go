@@ -20,11 +20,18 @@ func (r *BenchExpenseRepository) Create(ctx context.Context, expense *domain.Exp
 	return nil
 }
 
+func (r *BenchExpenseRepository) CreateBatch(ctx context.Context, expenses []*domain.Expense) error {
+	for _, expense := range expenses {
+		r.expenses[expense.ID] = expense
+	}
+	return nil
+}
+
 func (r *BenchExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Expense, error) {
 	if exp, ok := r.expenses[id]; ok {
 		return exp, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
 }
 
 func (r *BenchExpenseRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Expense, error) {
@@ -37,6 +44,10 @@ func (r *BenchExpenseRepository) GetByUserID(ctx context.Context, userID string)
 	return result, nil
 }
 
+func (r *BenchExpenseRepository) ListByUserID(ctx context.Context, userID string, opts domain.ExpenseListOptions) ([]*domain.Expense, error) {
+	return r.GetByUserID(ctx, userID)
+}
+
 func (r *BenchExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, from, to time.Time) ([]*domain.Expense, error) {
 	var result []*domain.Expense
 	for _, exp := range r.expenses {
@@ -47,6 +58,34 @@ func (r *BenchExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, us
 	return result, nil
 }
 
+func (r *BenchExpenseRepository) GetByGroupIDAndDateRange(ctx context.Context, groupID string, from, to time.Time) ([]*domain.Expense, error) {
+	var result []*domain.Expense
+	for _, exp := range r.expenses {
+		if exp.GroupID != nil && *exp.GroupID == groupID && !exp.ExpenseDate.Before(from) && !exp.ExpenseDate.After(to) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
+func (r *BenchExpenseRepository) selectTotals(query domain.ExpenseTotalsQuery) []*domain.Expense {
+	var selected []*domain.Expense
+	for _, exp := range r.expenses {
+		if query.Matches(exp) {
+			selected = append(selected, exp)
+		}
+	}
+	return selected
+}
+
+func (r *BenchExpenseRepository) SumByCategoryAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.CategoryTotal, error) {
+	return domain.SumExpensesByCategory(r.selectTotals(query)), nil
+}
+
+func (r *BenchExpenseRepository) SumByPeriodAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery, period string) ([]*domain.PeriodTotal, error) {
+	return domain.SumExpensesByPeriod(r.selectTotals(query), period), nil
+}
+
 func (r *BenchExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID, categoryID string) ([]*domain.Expense, error) {
 	var result []*domain.Expense
 	for _, exp := range r.expenses {
@@ -57,6 +96,16 @@ func (r *BenchExpenseRepository) GetByUserIDAndCategory(ctx context.Context, use
 	return result, nil
 }
 
+func (r *BenchExpenseRepository) Filter(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, error) {
+	var result []*domain.Expense
+	for _, exp := range r.expenses {
+		if filter.Matches(exp) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
 func (r *BenchExpenseRepository) Update(ctx context.Context, expense *domain.Expense) error {
 	r.expenses[expense.ID] = expense
 	return nil
@@ -67,6 +116,14 @@ func (r *BenchExpenseRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (r *BenchExpenseRepository) GetDeletedByID(ctx context.Context, id string) (*domain.Expense, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *BenchExpenseRepository) Restore(ctx context.Context, id string) error {
+	return nil
+}
+
 type BenchUserRepository struct {
 	users map[string]*domain.User
 }
@@ -80,7 +137,12 @@ func (r *BenchUserRepository) GetByID(ctx context.Context, userID string) (*doma
 	if user, ok := r.users[userID]; ok {
 		return user, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
+}
+
+func (r *BenchUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.users[user.UserID] = user
+	return nil
 }
 
 func (r *BenchUserRepository) Exists(ctx context.Context, userID string) (bool, error) {
@@ -101,7 +163,7 @@ func (r *BenchCategoryRepository) GetByID(ctx context.Context, id string) (*doma
 	if cat, ok := r.categories[id]; ok {
 		return cat, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
 }
 
 func (r *BenchCategoryRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Category, error) {
@@ -120,7 +182,7 @@ func (r *BenchCategoryRepository) GetByUserIDAndName(ctx context.Context, userID
 			return cat, nil
 		}
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
 }
 
 func (r *BenchCategoryRepository) Update(ctx context.Context, category *domain.Category) error {
@@ -141,6 +203,14 @@ func (r *BenchCategoryRepository) GetKeywordsByCategory(ctx context.Context, cat
 	return []*domain.CategoryKeyword{}, nil
 }
 
+func (r *BenchCategoryRepository) GetKeywordsByUserID(ctx context.Context, userID string) ([]*domain.CategoryKeyword, error) {
+	return []*domain.CategoryKeyword{}, nil
+}
+
+func (r *BenchCategoryRepository) UpdateKeyword(ctx context.Context, keyword *domain.CategoryKeyword) error {
+	return nil
+}
+
 func (r *BenchCategoryRepository) DeleteKeyword(ctx context.Context, id string) error {
 	return nil
 }
@@ -155,7 +225,16 @@ func (r *BenchPricingRepository) GetByProviderAndModel(ctx context.Context, prov
 	if p, ok := r.pricing[key]; ok {
 		return p, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
+}
+
+func (r *BenchPricingRepository) GetByID(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	for _, p := range r.pricing {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrNotFound
 }
 
 func (r *BenchPricingRepository) GetAll(ctx context.Context) ([]*domain.PricingConfig, error) {
@@ -225,6 +304,10 @@ func (r *BenchAICostRepository) GetByUserSummary(ctx context.Context, from, to t
 	return []*domain.AICostByUser{}, nil
 }
 
+func (r *BenchAICostRepository) GetUserSummary(ctx context.Context, userID string, from, to time.Time) (*domain.AICostSummary, error) {
+	return &domain.AICostSummary{}, nil
+}
+
 type BenchAIService struct{}
 
 var _ ai.Service = (*BenchAIService)(nil)
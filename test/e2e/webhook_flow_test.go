@@ -27,6 +27,15 @@ func (r *E2EExpenseRepository) Create(ctx context.Context, expense *domain.Expen
 	return nil
 }
 
+func (r *E2EExpenseRepository) CreateBatch(ctx context.Context, expenses []*domain.Expense) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, expense := range expenses {
+		r.expenses[expense.ID] = expense
+	}
+	return nil
+}
+
 func (r *E2EExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Expense, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -48,6 +57,10 @@ func (r *E2EExpenseRepository) GetByUserID(ctx context.Context, userID string) (
 	return result, nil
 }
 
+func (r *E2EExpenseRepository) ListByUserID(ctx context.Context, userID string, opts domain.ExpenseListOptions) ([]*domain.Expense, error) {
+	return r.GetByUserID(ctx, userID)
+}
+
 func (r *E2EExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, from, to time.Time) ([]*domain.Expense, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -60,6 +73,38 @@ func (r *E2EExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, user
 	return result, nil
 }
 
+func (r *E2EExpenseRepository) GetByGroupIDAndDateRange(ctx context.Context, groupID string, from, to time.Time) ([]*domain.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.Expense
+	for _, exp := range r.expenses {
+		if exp.GroupID != nil && *exp.GroupID == groupID && !exp.ExpenseDate.Before(from) && !exp.ExpenseDate.After(to) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
+func (r *E2EExpenseRepository) selectTotals(query domain.ExpenseTotalsQuery) []*domain.Expense {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var selected []*domain.Expense
+	for _, exp := range r.expenses {
+		if query.Matches(exp) {
+			selected = append(selected, exp)
+		}
+	}
+	return selected
+}
+
+func (r *E2EExpenseRepository) SumByCategoryAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.CategoryTotal, error) {
+	return domain.SumExpensesByCategory(r.selectTotals(query)), nil
+}
+
+func (r *E2EExpenseRepository) SumByPeriodAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery, period string) ([]*domain.PeriodTotal, error) {
+	return domain.SumExpensesByPeriod(r.selectTotals(query), period), nil
+}
+
 func (r *E2EExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID, categoryID string) ([]*domain.Expense, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -72,6 +117,18 @@ func (r *E2EExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userI
 	return result, nil
 }
 
+func (r *E2EExpenseRepository) Filter(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.Expense
+	for _, exp := range r.expenses {
+		if filter.Matches(exp) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
 func (r *E2EExpenseRepository) Update(ctx context.Context, expense *domain.Expense) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -86,6 +143,14 @@ func (r *E2EExpenseRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+func (r *E2EExpenseRepository) GetDeletedByID(ctx context.Context, id string) (*domain.Expense, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *E2EExpenseRepository) Restore(ctx context.Context, id string) error {
+	return nil
+}
+
 type E2EUserRepository struct {
 	users map[string]*domain.User
 	mu    sync.RWMutex
@@ -107,6 +172,13 @@ func (r *E2EUserRepository) GetByID(ctx context.Context, userID string) (*domain
 	return nil, errNotFound
 }
 
+func (r *E2EUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.UserID] = user
+	return nil
+}
+
 func (r *E2EUserRepository) Exists(ctx context.Context, userID string) (bool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -180,6 +252,14 @@ func (r *E2ECategoryRepository) GetKeywordsByCategory(ctx context.Context, categ
 	return []*domain.CategoryKeyword{}, nil
 }
 
+func (r *E2ECategoryRepository) GetKeywordsByUserID(ctx context.Context, userID string) ([]*domain.CategoryKeyword, error) {
+	return []*domain.CategoryKeyword{}, nil
+}
+
+func (r *E2ECategoryRepository) UpdateKeyword(ctx context.Context, keyword *domain.CategoryKeyword) error {
+	return nil
+}
+
 func (r *E2ECategoryRepository) DeleteKeyword(ctx context.Context, id string) error {
 	return nil
 }
@@ -197,7 +277,18 @@ func (r *E2EPricingRepository) GetByProviderAndModel(ctx context.Context, provid
 	if p, ok := r.pricing[key]; ok {
 		return p, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
+}
+
+func (r *E2EPricingRepository) GetByID(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.pricing {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrNotFound
 }
 
 func (r *E2EPricingRepository) GetAll(ctx context.Context) ([]*domain.PricingConfig, error) {
@@ -280,6 +371,10 @@ func (r *E2EAICostRepository) GetByUserSummary(ctx context.Context, from, to tim
 	return []*domain.AICostByUser{}, nil
 }
 
+func (r *E2EAICostRepository) GetUserSummary(ctx context.Context, userID string, from, to time.Time) (*domain.AICostSummary, error) {
+	return &domain.AICostSummary{}, nil
+}
+
 type E2EAIService struct {
 	parseResponses map[string][]*domain.ParsedExpense
 	mu             sync.RWMutex
@@ -26,13 +26,22 @@ func (r *LoadTestExpenseRepository) Create(ctx context.Context, expense *domain.
 	return nil
 }
 
+func (r *LoadTestExpenseRepository) CreateBatch(ctx context.Context, expenses []*domain.Expense) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, expense := range expenses {
+		r.expenses[expense.ID] = expense
+	}
+	return nil
+}
+
 func (r *LoadTestExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Expense, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	if exp, ok := r.expenses[id]; ok {
 		return exp, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
 }
 
 func (r *LoadTestExpenseRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Expense, error) {
@@ -47,6 +56,10 @@ func (r *LoadTestExpenseRepository) GetByUserID(ctx context.Context, userID stri
 	return result, nil
 }
 
+func (r *LoadTestExpenseRepository) ListByUserID(ctx context.Context, userID string, opts domain.ExpenseListOptions) ([]*domain.Expense, error) {
+	return r.GetByUserID(ctx, userID)
+}
+
 func (r *LoadTestExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, from, to time.Time) ([]*domain.Expense, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -59,6 +72,38 @@ func (r *LoadTestExpenseRepository) GetByUserIDAndDateRange(ctx context.Context,
 	return result, nil
 }
 
+func (r *LoadTestExpenseRepository) GetByGroupIDAndDateRange(ctx context.Context, groupID string, from, to time.Time) ([]*domain.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.Expense
+	for _, exp := range r.expenses {
+		if exp.GroupID != nil && *exp.GroupID == groupID && !exp.ExpenseDate.Before(from) && !exp.ExpenseDate.After(to) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
+func (r *LoadTestExpenseRepository) selectTotals(query domain.ExpenseTotalsQuery) []*domain.Expense {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var selected []*domain.Expense
+	for _, exp := range r.expenses {
+		if query.Matches(exp) {
+			selected = append(selected, exp)
+		}
+	}
+	return selected
+}
+
+func (r *LoadTestExpenseRepository) SumByCategoryAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.CategoryTotal, error) {
+	return domain.SumExpensesByCategory(r.selectTotals(query)), nil
+}
+
+func (r *LoadTestExpenseRepository) SumByPeriodAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery, period string) ([]*domain.PeriodTotal, error) {
+	return domain.SumExpensesByPeriod(r.selectTotals(query), period), nil
+}
+
 func (r *LoadTestExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID, categoryID string) ([]*domain.Expense, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -71,6 +116,18 @@ func (r *LoadTestExpenseRepository) GetByUserIDAndCategory(ctx context.Context,
 	return result, nil
 }
 
+func (r *LoadTestExpenseRepository) Filter(ctx context.Context, filter domain.ExpenseFilter) ([]*domain.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*domain.Expense
+	for _, exp := range r.expenses {
+		if filter.Matches(exp) {
+			result = append(result, exp)
+		}
+	}
+	return result, nil
+}
+
 func (r *LoadTestExpenseRepository) Update(ctx context.Context, expense *domain.Expense) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -85,6 +142,14 @@ func (r *LoadTestExpenseRepository) Delete(ctx context.Context, id string) error
 	return nil
 }
 
+func (r *LoadTestExpenseRepository) GetDeletedByID(ctx context.Context, id string) (*domain.Expense, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *LoadTestExpenseRepository) Restore(ctx context.Context, id string) error {
+	return nil
+}
+
 // LoadTestUserRepository implements in-memory user repository for load testing
 type LoadTestUserRepository struct {
 	users map[string]*domain.User
@@ -104,7 +169,14 @@ func (r *LoadTestUserRepository) GetByID(ctx context.Context, userID string) (*d
 	if user, ok := r.users[userID]; ok {
 		return user, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
+}
+
+func (r *LoadTestUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.UserID] = user
+	return nil
 }
 
 func (r *LoadTestUserRepository) Exists(ctx context.Context, userID string) (bool, error) {
@@ -133,7 +205,7 @@ func (r *LoadTestCategoryRepository) GetByID(ctx context.Context, id string) (*d
 	if cat, ok := r.categories[id]; ok {
 		return cat, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
 }
 
 func (r *LoadTestCategoryRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Category, error) {
@@ -156,7 +228,7 @@ func (r *LoadTestCategoryRepository) GetByUserIDAndName(ctx context.Context, use
 			return cat, nil
 		}
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
 }
 
 func (r *LoadTestCategoryRepository) Update(ctx context.Context, category *domain.Category) error {
@@ -181,6 +253,14 @@ func (r *LoadTestCategoryRepository) GetKeywordsByCategory(ctx context.Context,
 	return []*domain.CategoryKeyword{}, nil
 }
 
+func (r *LoadTestCategoryRepository) GetKeywordsByUserID(ctx context.Context, userID string) ([]*domain.CategoryKeyword, error) {
+	return []*domain.CategoryKeyword{}, nil
+}
+
+func (r *LoadTestCategoryRepository) UpdateKeyword(ctx context.Context, keyword *domain.CategoryKeyword) error {
+	return nil
+}
+
 func (r *LoadTestCategoryRepository) DeleteKeyword(ctx context.Context, id string) error {
 	return nil
 }
@@ -198,7 +278,18 @@ func (r *LoadTestPricingRepository) GetByProviderAndModel(ctx context.Context, p
 	if p, ok := r.pricing[key]; ok {
 		return p, nil
 	}
-	return nil, nil
+	return nil, domain.ErrNotFound
+}
+
+func (r *LoadTestPricingRepository) GetByID(ctx context.Context, id string) (*domain.PricingConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.pricing {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, domain.ErrNotFound
 }
 
 func (r *LoadTestPricingRepository) GetAll(ctx context.Context) ([]*domain.PricingConfig, error) {
@@ -281,6 +372,10 @@ func (r *LoadTestAICostRepository) GetByUserSummary(ctx context.Context, from, t
 	return []*domain.AICostByUser{}, nil
 }
 
+func (r *LoadTestAICostRepository) GetUserSummary(ctx context.Context, userID string, from, to time.Time) (*domain.AICostSummary, error) {
+	return &domain.AICostSummary{}, nil
+}
+
 // LoadTestAIService implements minimal AI service for load testing
 type LoadTestAIService struct{}
 
@@ -27,6 +27,13 @@ func (r *SecurityTestExpenseRepository) Create(ctx context.Context, expense *dom
 	return nil
 }
 
+func (r *SecurityTestExpenseRepository) CreateBatch(ctx context.Context, expenses []*domain.Expense) error {
+	for _, expense := range expenses {
+		r.expenses[expense.ID] = expense
+	}
+	return nil
+}
+
 func (r *SecurityTestExpenseRepository) GetByID(ctx context.Context, id string) (*domain.Expense, error) {
 	if exp, ok := r.expenses[id]; ok {
 		return exp, nil
@@ -38,10 +45,26 @@ func (r *SecurityTestExpenseRepository) GetByUserID(ctx context.Context, userID
 	return []*domain.Expense{}, nil
 }
 
+func (r *SecurityTestExpenseRepository) ListByUserID(ctx context.Context, userID string, opts domain.ExpenseListOptions) ([]*domain.Expense, error) {
+	return r.GetByUserID(ctx, userID)
+}
+
 func (r *SecurityTestExpenseRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, from, to time.Time) ([]*domain.Expense, error) {
 	return []*domain.Expense{}, nil
 }
 
+func (r *SecurityTestExpenseRepository) GetByGroupIDAndDateRange(ctx context.Context, groupID string, from, to time.Time) ([]*domain.Expense, error) {
+	return []*domain.Expense{}, nil
+}
+
+func (r *SecurityTestExpenseRepository) SumByCategoryAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery) ([]*domain.CategoryTotal, error) {
+	return nil, nil
+}
+
+func (r *SecurityTestExpenseRepository) SumByPeriodAndDateRange(ctx context.Context, query domain.ExpenseTotalsQuery, period string) ([]*domain.PeriodTotal, error) {
+	return nil, nil
+}
+
 func (r *SecurityTestExpenseRepository) GetByUserIDAndCategory(ctx context.Context, userID, categoryID string) ([]*domain.Expense, error) {
 	return []*domain.Expense{}, nil
 }
@@ -54,6 +77,14 @@ func (r *SecurityTestExpenseRepository) Delete(ctx context.Context, id string) e
 	return nil
 }
 
+func (r *SecurityTestExpenseRepository) GetDeletedByID(ctx context.Context, id string) (*domain.Expense, error) {
+	return nil, domain.ErrNotFound
+}
+
+func (r *SecurityTestExpenseRepository) Restore(ctx context.Context, id string) error {
+	return nil
+}
+
 type SecurityTestUserRepository struct {
 	users map[string]*domain.User
 }
@@ -70,6 +101,11 @@ func (r *SecurityTestUserRepository) GetByID(ctx context.Context, userID string)
 	return nil, errNotFound
 }
 
+func (r *SecurityTestUserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.users[user.UserID] = user
+	return nil
+}
+
 func (r *SecurityTestUserRepository) Exists(ctx context.Context, userID string) (bool, error) {
 	_, ok := r.users[userID]
 	return ok, nil
@@ -112,6 +148,14 @@ func (r *SecurityTestCategoryRepository) GetKeywordsByCategory(ctx context.Conte
 	return []*domain.CategoryKeyword{}, nil
 }
 
+func (r *SecurityTestCategoryRepository) GetKeywordsByUserID(ctx context.Context, userID string) ([]*domain.CategoryKeyword, error) {
+	return []*domain.CategoryKeyword{}, nil
+}
+
+func (r *SecurityTestCategoryRepository) UpdateKeyword(ctx context.Context, keyword *domain.CategoryKeyword) error {
+	return nil
+}
+
 func (r *SecurityTestCategoryRepository) DeleteKeyword(ctx context.Context, id string) error {
 	return nil
 }